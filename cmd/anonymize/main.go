@@ -0,0 +1,147 @@
+// Command anonymize copies production data into a staging environment with
+// personal data replaced by deterministic fakes, following the rules in
+// rules.go. Target tables must exist, as created by the services on
+// startup; their rows, and the documents of target collections, are
+// replaced.
+//
+//	ANONYMIZE_KEY=... PII_MASTER_KEYS=<staging keys> anonymize \
+//	  -postgres-from "$PROD_USERS_DSN" -postgres-to "$STAGING_USERS_DSN" \
+//	  -mongo-from "$PROD_MONGODB_URI" -mongo-to "$STAGING_MONGODB_URI"
+//
+// ANONYMIZE_KEY keys the fakes: the same key gives the same fakes on every
+// run, and without it they cannot be traced back. Keep it out of staging.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/anonymize"
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+	"github.com/bekbull/online-shop/pkg/logging"
+	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	postgresFrom := flag.String("postgres-from", "", "DSN of the production user database")
+	postgresTo := flag.String("postgres-to", "", "DSN of the staging user database")
+	mongoFrom := flag.String("mongo-from", "", "URI of the production MongoDB")
+	mongoTo := flag.String("mongo-to", "", "URI of the staging MongoDB")
+	password := flag.String("staging-password", "staging-password", "password every staging user gets")
+	batchSize := flag.Int("batch-size", 1000, "documents inserted at once")
+	flag.Parse()
+
+	logger := logging.New(os.Stderr, logging.Options{Service: "anonymize", Level: "info", Text: true})
+	if err := run(logger, *postgresFrom, *postgresTo, *mongoFrom, *mongoTo, *password, *batchSize); err != nil {
+		logger.Error("Anonymization failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger, postgresFrom, postgresTo, mongoFrom, mongoTo, password string, batchSize int) error {
+	key := os.Getenv("ANONYMIZE_KEY")
+	if len(key) < 16 {
+		return fmt.Errorf("ANONYMIZE_KEY must be set to a secret of at least 16 characters")
+	}
+	if (postgresFrom == "") != (postgresTo == "") || (mongoFrom == "") != (mongoTo == "") {
+		return fmt.Errorf("-postgres-from and -postgres-to, and -mongo-from and -mongo-to, go together")
+	}
+	if postgresFrom != "" && postgresFrom == postgresTo || mongoFrom != "" && mongoFrom == mongoTo {
+		return fmt.Errorf("the source and target must differ")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	var crypt *fieldcrypt.Encryptor
+	if keys := os.Getenv("PII_MASTER_KEYS"); keys != "" {
+		keyring, err := fieldcrypt.ParseKeyring(keys)
+		if err != nil {
+			return fmt.Errorf("invalid PII_MASTER_KEYS: %w", err)
+		}
+		crypt = fieldcrypt.New(keyring)
+	} else {
+		logger.Warn("PII_MASTER_KEYS not set, phone numbers are cleared")
+	}
+	rules := newRules(ctx, string(hash), crypt)
+	scrambler := anonymize.NewScrambler([]byte(key))
+
+	if postgresFrom != "" {
+		if err := copyPostgres(ctx, postgresFrom, postgresTo, rules, scrambler, logger); err != nil {
+			return err
+		}
+	}
+	if mongoFrom != "" {
+		if err := copyMongo(ctx, mongoFrom, mongoTo, rules, scrambler, batchSize, logger); err != nil {
+			return err
+		}
+	}
+	logger.Info("Anonymization complete")
+	return nil
+}
+
+func copyPostgres(ctx context.Context, from, to string, rules Rules, s *anonymize.Scrambler, logger *slog.Logger) error {
+	src, err := sql.Open("postgres", from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := sql.Open("postgres", to)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = anonymize.CopyPostgres(ctx, src, dst, rules.Postgres, s, logger)
+	return err
+}
+
+func copyMongo(ctx context.Context, from, to string, rules Rules, s *anonymize.Scrambler, batchSize int, logger *slog.Logger) error {
+	connect := func(uri string) (*mongo.Client, error) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			return nil, err
+		}
+		return client, client.Ping(ctx, nil)
+	}
+	src, err := connect(from)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the source MongoDB: %w", err)
+	}
+	defer src.Disconnect(context.Background())
+	dst, err := connect(to)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the target MongoDB: %w", err)
+	}
+	defer dst.Disconnect(context.Background())
+
+	databases := make([]string, 0, len(rules.Mongo))
+	for database := range rules.Mongo {
+		databases = append(databases, database)
+	}
+	sort.Strings(databases)
+	for _, database := range databases {
+		_, err := anonymize.CopyMongo(ctx, src.Database(database), dst.Database(database), rules.Mongo[database], s, batchSize, logger)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
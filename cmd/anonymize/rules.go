@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/anonymize"
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+)
+
+// Rules say how every table and collection is anonymized. The copy stops
+// at tables and collections without one, so add a rule with every new
+// table or collection, and a case to rules_test.go for every new field
+// holding personal data.
+type Rules struct {
+	// Postgres covers the user service's database
+	Postgres []anonymize.Rule
+	// Mongo covers the other services' databases, by database name
+	Mongo map[string][]anonymize.Rule
+}
+
+// newRules returns the rules. Passwords are all reset to passwordHash.
+// Phone numbers are encrypted with the staging keys of crypt, or cleared
+// if it is nil.
+func newRules(ctx context.Context, passwordHash string, crypt *fieldcrypt.Encryptor) Rules {
+	return Rules{
+		Postgres: []anonymize.Rule{
+			{Name: "users", Fields: map[string]anonymize.Replacer{
+				"email":           anonymize.Email,
+				"first_name":      anonymize.FirstName,
+				"last_name":       anonymize.LastName,
+				"password_hash":   anonymize.Set(passwordHash),
+				"phone_encrypted": encryptedPhone(ctx, crypt),
+				"handle":          handle,
+			}},
+			{Name: "notification_preferences"},
+			// Changed by holds service and admin token subjects
+			{Name: "consent_changes"},
+			// User agents and coarse locations are kept to test detection
+			{Name: "logins", Fields: map[string]anonymize.Replacer{
+				"ip": anonymize.Clear,
+			}},
+			// Filters may hold part of an email address
+			{Name: "bulk_jobs", Fields: map[string]anonymize.Replacer{
+				"filter": anonymize.Clear,
+			}},
+			// Actors are service and admin token subjects
+			{Name: "user_audit_log"},
+			// Cached responses hold personal data, and expire within a day
+			{Name: "idempotency_keys", Skip: true},
+		},
+		Mongo: map[string][]anonymize.Rule{
+			"product_db": {
+				{Name: "products"},
+				{Name: "suppliers", Fields: map[string]anonymize.Replacer{
+					"contact_name": anonymize.FullName,
+					"email":        anonymize.Email,
+					"phone":        anonymize.Phone,
+				}},
+				{Name: "purchase_orders"},
+				{Name: "badges"},
+				{Name: "inventory_operations"},
+				{Name: "schema_migrations"},
+				{Name: "product_popularity"},
+				{Name: "inventory_snapshots"},
+				// Order and user IDs are kept; license keys are secrets
+				{Name: "product_downloads", Fields: map[string]anonymize.Replacer{
+					"license_key": anonymize.Token,
+				}},
+			},
+			"inventory_db": {
+				{Name: "stock"},
+				{Name: "reservations"},
+				{Name: "ledger"},
+				// Scheduled jobs would run again in staging
+				{Name: "jobs", Skip: true},
+			},
+			"admin_db": {
+				// Actor IDs are user IDs, which are kept; params can hold
+				// anything an admin typed
+				{Name: "audit_log", Fields: map[string]anonymize.Replacer{
+					"actor_email": anonymize.Email,
+					"params":      anonymize.Clear,
+				}},
+			},
+			"webhooks_db": {
+				{Name: "subscriptions", Fields: map[string]anonymize.Replacer{
+					"owner":  anonymize.Email,
+					"url":    anonymize.URL,
+					"secret": anonymize.Token,
+				}},
+				// Event payloads hold customers' data, and would be
+				// delivered again from staging
+				{Name: "deliveries", Skip: true},
+			},
+			"notifications_db": {
+				{Name: "templates"},
+				{Name: "template_versions", Fields: map[string]anonymize.Replacer{
+					"author": anonymize.Email,
+				}},
+			},
+			"erp_sync_db": {
+				{Name: "sku_mappings"},
+				{Name: "sync_runs"},
+			},
+		},
+	}
+}
+
+// encryptedPhone replaces the encrypted phone number of a user with a fake
+// one derived from their ID, encrypted as the user service does
+func encryptedPhone(ctx context.Context, crypt *fieldcrypt.Encryptor) anonymize.Replacer {
+	if crypt == nil {
+		return anonymize.Clear
+	}
+	return func(s *anonymize.Scrambler, record map[string]interface{}, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return value, nil
+		}
+		id, ok := record["id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("user without an ID")
+		}
+		return crypt.Encrypt(ctx, s.Phone(id), "users.phone:"+id)
+	}
+}
+
+// handle replaces the public handle of a user with one derived from their
+// ID: u and the first 29 hex digits of the UUID, as long as handles may be.
+// Handles are unique, and so are the 116 bits of the ID they keep.
+func handle(_ *anonymize.Scrambler, record map[string]interface{}, value interface{}) (interface{}, error) {
+	if value == nil || value == "" {
+		return value, nil
+	}
+	id, ok := record["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("user without an ID")
+	}
+	digits := strings.ReplaceAll(id, "-", "")
+	if len(digits) < 29 {
+		return nil, fmt.Errorf("user ID %q is not a UUID", id)
+	}
+	return "u" + digits[:29], nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/anonymize"
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// personal is the personal data in the samples below, none of which may
+// survive anonymization
+var personal = []string{
+	"ann.lee@shop.example.org", "Ann", "Lee", "$2a$10$production-hash",
+	"Carol Diaz", "carol@acme.example.net", "+1 415 555 0134",
+	"https://partner.example.net/hooks", "whsec_live_secret", "partner-ops@example.net",
+	"refund for ann.lee@shop.example.org", "K7PQX-3MZ9A-WD4RT-H2NVC-8YJEB",
+	"198.51.100.23", `{"email":"ann.lee@"}`, "ann_lee",
+}
+
+// samples are production-like records of every table and collection with
+// personal data
+var samples = map[string]map[string]interface{}{
+	"users": {
+		"id": "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1", "email": "ann.lee@shop.example.org",
+		"first_name": "Ann", "last_name": "Lee", "password_hash": "$2a$10$production-hash",
+		"roles": "{customer}", "phone_encrypted": "enc:v1:prod:...", "handle": "ann_lee",
+	},
+	"logins": {
+		"user_id": "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1", "device_id": "57c9bb3491061e7f",
+		"ip": "198.51.100.23", "location": "DE/BE", "reasons": "{new_device}",
+	},
+	"bulk_jobs": {
+		"id": "9d3e2b1c-5f4a-4e8b-a6c7-0d1e2f3a4b5c", "action": "suspend",
+		"filter": `{"email":"ann.lee@"}`, "user_ids": "{4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1}",
+	},
+	"product_db.suppliers": {
+		"code": "ACME", "name": "Acme Corp", "contact_name": "Carol Diaz",
+		"email": "carol@acme.example.net", "phone": "+1 415 555 0134",
+	},
+	"product_db.product_downloads": {
+		"order_id": "order-1", "product_id": "65f1c0d2e4b0a1b2c3d4e5f1",
+		"user_id": "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1", "downloads": 2,
+		"license_key": "K7PQX-3MZ9A-WD4RT-H2NVC-8YJEB",
+	},
+	"admin_db.audit_log": {
+		"actor_id": "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1", "actor_email": "Ann.Lee@shop.example.org",
+		"action": "refund", "params": bson.M{"note": "refund for ann.lee@shop.example.org"},
+	},
+	"webhooks_db.subscriptions": {
+		"owner": "partner-ops@example.net", "url": "https://partner.example.net/hooks",
+		"secret": "whsec_live_secret", "event_types": bson.A{"order.*"},
+	},
+	"notifications_db.template_versions": {
+		"key": "welcome", "author": "ann.lee@shop.example.org", "subject": "Welcome {{.FirstName}}",
+	},
+}
+
+func testRules(t *testing.T) (Rules, *fieldcrypt.Encryptor) {
+	keyring, err := fieldcrypt.ParseKeyring("staging:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	crypt := fieldcrypt.New(keyring)
+	return newRules(context.Background(), "$2a$10$staging-hash", crypt), crypt
+}
+
+func lookup(rules Rules, name string) (anonymize.Rule, bool) {
+	list := rules.Postgres
+	if database, collection, ok := strings.Cut(name, "."); ok {
+		list, name = rules.Mongo[database], collection
+	}
+	for _, rule := range list {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return anonymize.Rule{}, false
+}
+
+func TestRulesRemovePersonalData(t *testing.T) {
+	rules, _ := testRules(t)
+	s := anonymize.NewScrambler([]byte("test-key-0123456789"))
+	for name, sample := range samples {
+		t.Run(name, func(t *testing.T) {
+			rule, ok := lookup(rules, name)
+			require.True(t, ok, "no rule for %s", name)
+			record := copyRecord(sample)
+			require.NoError(t, rule.Apply(s, record))
+
+			dump := fmt.Sprint(record)
+			for _, value := range personal {
+				assert.NotContains(t, dump, value)
+			}
+		})
+	}
+}
+
+func TestRulesKeepRelationships(t *testing.T) {
+	rules, crypt := testRules(t)
+	s := anonymize.NewScrambler([]byte("test-key-0123456789"))
+	anonymized := map[string]map[string]interface{}{}
+	for name, sample := range samples {
+		rule, _ := lookup(rules, name)
+		anonymized[name] = copyRecord(sample)
+		require.NoError(t, rule.Apply(s, anonymized[name]))
+	}
+
+	user := anonymized["users"]
+	assert.Equal(t, user["email"], anonymized["admin_db.audit_log"]["actor_email"], "audit entries still name the user")
+	assert.Equal(t, user["email"], anonymized["notifications_db.template_versions"]["author"])
+	assert.Equal(t, samples["users"]["id"], user["id"], "IDs are kept")
+	assert.Equal(t, "$2a$10$staging-hash", user["password_hash"])
+
+	phone, err := crypt.Decrypt(context.Background(), user["phone_encrypted"].(string), "users.phone:"+user["id"].(string))
+	require.NoError(t, err, "phones decrypt with the staging keys")
+	assert.Equal(t, s.Phone(user["id"].(string)), phone)
+
+	// Handles stay unique, as idx_users_handle requires, and valid
+	assert.Equal(t, "u4b0a6c389a554c849d4b5a3f3bb8f", user["handle"])
+	other := copyRecord(samples["users"])
+	other["id"] = "0f6e2d1c-3b4a-4c5d-8e7f-a1b2c3d4e5f6"
+	users, _ := lookup(rules, "users")
+	require.NoError(t, users.Apply(s, other))
+	assert.NotEqual(t, user["handle"], other["handle"])
+	assert.Len(t, user["handle"], 30)
+}
+
+func TestRulesAreWellFormed(t *testing.T) {
+	rules, _ := testRules(t)
+	check := func(list []anonymize.Rule) {
+		seen := map[string]bool{}
+		for _, rule := range list {
+			assert.False(t, seen[rule.Name], "two rules for %s", rule.Name)
+			seen[rule.Name] = true
+			if rule.Skip {
+				assert.Empty(t, rule.Fields, "%s is skipped, so its fields are not copied", rule.Name)
+			}
+		}
+	}
+	check(rules.Postgres)
+	for _, list := range rules.Mongo {
+		check(list)
+	}
+
+	// Without staging keys, phones are cleared rather than copied
+	users, _ := lookup(newRules(context.Background(), "hash", nil), "users")
+	record := copyRecord(samples["users"])
+	require.NoError(t, users.Apply(anonymize.NewScrambler([]byte("k")), record))
+	assert.Nil(t, record["phone_encrypted"])
+}
+
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		if doc, ok := value.(bson.M); ok {
+			value = copyRecord(doc)
+		}
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,227 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/clients"
+	productclient "github.com/bekbull/online-shop/pkg/clients/product"
+	userclient "github.com/bekbull/online-shop/pkg/clients/user"
+	"github.com/bekbull/online-shop/pkg/logging"
+	productv1 "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TestPurchaseJourney follows a customer from sign-up to a purchase: the
+// user is created over HTTP and read back over gRPC, the product is
+// created over gRPC and read back over HTTP, and stock is checked and
+// bought on both transports, which must agree with each other.
+func TestPurchaseJourney(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	run := time.Now().UnixNano()
+	products := productclient.New(dial(t, stack.ProductGRPC), clients.DefaultOptions())
+	users := userclient.New(dial(t, stack.UserGRPC), clients.DefaultOptions())
+
+	// Sign up over HTTP; the user is visible over gRPC
+	var user struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	email := fmt.Sprintf("e2e-%d@example.com", run)
+	status := doJSON(t, http.MethodPost, stack.UserHTTP+"/v1/users", map[string]interface{}{
+		"email":      email,
+		"first_name": "End",
+		"last_name":  "ToEnd",
+		"password":   "correct-horse-battery-staple",
+		"roles":      []string{"customer"},
+	}, &user)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotEmpty(t, user.ID)
+
+	fetched, err := users.GetUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, email, fetched.Email)
+	byEmail, err := users.GetUserByEmail(ctx, email)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, byEmail.Id)
+
+	// Create a product over gRPC; it is visible over HTTP
+	created, err := products.CreateProduct(ctx, &productv1.CreateProductRequest{
+		Name:     "End-to-end mug",
+		Price:    12.5,
+		Category: "kitchen",
+		Inventory: &productv1.InventoryInfo{
+			Quantity: 5,
+			Sku:      fmt.Sprintf("E2E-%d", run),
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Id)
+
+	var product struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Inventory struct {
+			Quantity int  `json:"quantity"`
+			InStock  bool `json:"in_stock"`
+		} `json:"inventory"`
+	}
+	status = doJSON(t, http.MethodGet, stack.ProductHTTP+"/v1/products/"+created.Id, nil, &product)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "End-to-end mug", product.Name)
+	assert.Equal(t, 5, product.Inventory.Quantity)
+	assert.True(t, product.Inventory.InStock)
+
+	// Both transports agree on the stock
+	stock, err := products.CheckStock(ctx, created.Id, 3)
+	require.NoError(t, err)
+	assert.True(t, stock.Available)
+	assert.EqualValues(t, 5, stock.CurrentStock)
+	assertHTTPStock(t, created.Id, 3, true, 5)
+
+	// The user buys three over gRPC. The purchase is keyed by the user, so
+	// repeating it, as a client would after a lost response, is a no-op.
+	purchase := &productv1.UpdateInventoryRequest{
+		ProductId:      created.Id,
+		QuantityChange: -3,
+		OperationId:    fmt.Sprintf("purchase-%s-%d", user.ID, run),
+		OperationType:  "purchase",
+	}
+	bought, err := products.UpdateInventory(ctx, purchase)
+	require.NoError(t, err)
+	assert.True(t, bought.Success)
+	assert.EqualValues(t, 2, bought.UpdatedInventory.Quantity)
+
+	_, err = products.UpdateInventory(ctx, purchase)
+	require.NoError(t, err)
+	assertHTTPStock(t, created.Id, 2, true, 2)
+	assertHTTPStock(t, created.Id, 3, false, 2)
+
+	// Buying more than is left fails on both transports and changes nothing
+	_, err = products.UpdateInventory(ctx, &productv1.UpdateInventoryRequest{
+		ProductId:      created.Id,
+		QuantityChange: -3,
+		OperationId:    fmt.Sprintf("purchase-%s-%d-again", user.ID, run),
+		OperationType:  "purchase",
+	})
+	require.Error(t, err)
+	assert.Equal(t, apperrors.Conflict, apperrors.KindOf(err))
+	assert.Equal(t, "INSUFFICIENT_STOCK", apperrors.ReasonOf(err))
+
+	var problem apperrors.Problem
+	status = doJSON(t, http.MethodPost, stack.ProductHTTP+"/v1/products/"+created.Id+"/inventory", map[string]interface{}{
+		"quantity_change": -3,
+		"operation_id":    fmt.Sprintf("purchase-%s-%d-http", user.ID, run),
+		"operation_type":  "purchase",
+	}, &problem)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "INSUFFICIENT_STOCK", problem.Reason)
+	assert.NotEmpty(t, problem.RequestID)
+
+	stock, err = products.CheckStock(ctx, created.Id, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stock.CurrentStock)
+
+	// Restocking over HTTP is seen over gRPC
+	var restocked struct {
+		Success   bool `json:"success"`
+		Inventory struct {
+			Quantity int `json:"quantity"`
+		} `json:"inventory"`
+	}
+	status = doJSON(t, http.MethodPost, stack.ProductHTTP+"/v1/products/"+created.Id+"/inventory", map[string]interface{}{
+		"quantity_change": 10,
+		"operation_id":    fmt.Sprintf("restock-%d", run),
+		"operation_type":  "restock",
+	}, &restocked)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, restocked.Success)
+	assert.Equal(t, 12, restocked.Inventory.Quantity)
+
+	got, err := products.GetProduct(ctx, created.Id)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, got.Inventory.Quantity)
+}
+
+// TestRequestIDsCrossTransports checks that both services echo the caller's
+// request ID and report unknown resources the same way on each transport
+func TestRequestIDsCrossTransports(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, url := range []string{
+		stack.ProductHTTP + "/v1/products/000000000000000000000000",
+		stack.UserHTTP + "/v1/users/00000000-0000-0000-0000-000000000000",
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Set(logging.RequestIDHeader, "e2e-request-id")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, url)
+		assert.Equal(t, "e2e-request-id", resp.Header.Get(logging.RequestIDHeader), url)
+	}
+
+	products := productclient.New(dial(t, stack.ProductGRPC), clients.DefaultOptions())
+	_, err := products.GetProduct(ctx, "000000000000000000000000")
+	assert.Equal(t, apperrors.NotFound, apperrors.KindOf(err))
+
+	users := userclient.New(dial(t, stack.UserGRPC), clients.DefaultOptions())
+	_, err = users.GetUser(ctx, "00000000-0000-0000-0000-000000000000")
+	assert.Equal(t, apperrors.NotFound, apperrors.KindOf(err))
+}
+
+// dial connects to a gRPC service of the stack, closing the connection
+// when the test ends
+func dial(t *testing.T, addr string) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// doJSON sends body as JSON, decodes the response into out unless it is
+// nil, and returns the status code
+func doJSON(t *testing.T, method, url string, body, out interface{}) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+	req, err := http.NewRequest(method, url, &payload)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out), "%s %s", method, url)
+	}
+	return resp.StatusCode
+}
+
+func assertHTTPStock(t *testing.T, productID string, quantity int, available bool, current int) {
+	t.Helper()
+	var stock struct {
+		Available    bool `json:"available"`
+		CurrentStock int  `json:"current_stock"`
+	}
+	status := doJSON(t, http.MethodGet, fmt.Sprintf("%s/v1/products/%s/stock?quantity=%d", stack.ProductHTTP, productID, quantity), nil, &stock)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, available, stock.Available, "available for %d", quantity)
+	assert.Equal(t, current, stock.CurrentStock)
+}
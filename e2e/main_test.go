@@ -0,0 +1,110 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// endpoints are the addresses of the services under test
+type endpoints struct {
+	ProductHTTP string
+	ProductGRPC string
+	UserHTTP    string
+	UserGRPC    string
+}
+
+var stack endpoints
+
+// TestMain starts the stack in docker-compose.yml, runs the tests and
+// removes it again. With E2E_EXTERNAL=1 the tests run against services
+// that are already up, at the E2E_*_ADDR addresses; with E2E_KEEP=1 the
+// stack is left running for debugging.
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	stack = endpoints{
+		ProductHTTP: getEnv("E2E_PRODUCT_HTTP_ADDR", "http://localhost:"+getEnv("E2E_PRODUCT_HTTP_PORT", "18080")),
+		ProductGRPC: getEnv("E2E_PRODUCT_GRPC_ADDR", "localhost:"+getEnv("E2E_PRODUCT_GRPC_PORT", "15051")),
+		UserHTTP:    getEnv("E2E_USER_HTTP_ADDR", "http://localhost:"+getEnv("E2E_USER_HTTP_PORT", "18081")),
+		UserGRPC:    getEnv("E2E_USER_GRPC_ADDR", "localhost:"+getEnv("E2E_USER_GRPC_PORT", "19091")),
+	}
+
+	managed := os.Getenv("E2E_EXTERNAL") == ""
+	if managed {
+		if _, err := exec.LookPath("docker"); err != nil {
+			fmt.Fprintln(os.Stderr, "e2e: docker is not installed, skipping the end-to-end tests")
+			return 0
+		}
+		if err := compose("up", "--detach", "--build", "--wait"); err != nil {
+			fmt.Fprintln(os.Stderr, "e2e: failed to start the stack:", err)
+			compose("logs", "--no-color")
+			compose("down", "--volumes", "--remove-orphans")
+			return 1
+		}
+		if os.Getenv("E2E_KEEP") == "" {
+			defer compose("down", "--volumes", "--remove-orphans")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	for _, url := range []string{stack.ProductHTTP + "/health", stack.UserHTTP + "/health"} {
+		if err := waitHealthy(ctx, url); err != nil {
+			fmt.Fprintln(os.Stderr, "e2e:", err)
+			return 1
+		}
+	}
+
+	code := m.Run()
+	if code != 0 && managed {
+		compose("logs", "--no-color", "product-service", "user-service")
+	}
+	return code
+}
+
+// compose runs docker compose on this directory's stack, as project
+// E2E_PROJECT so that parallel runs on one host do not collide
+func compose(args ...string) error {
+	args = append([]string{"compose", "--file", "docker-compose.yml", "--project-name", getEnv("E2E_PROJECT", "shop-e2e")}, args...)
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// waitHealthy polls url until it answers 200 OK or ctx is done
+func waitHealthy(ctx context.Context, url string) error {
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s is not healthy: %w", url, ctx.Err())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
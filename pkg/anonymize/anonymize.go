@@ -0,0 +1,247 @@
+// Package anonymize copies production data into staging with personal data
+// replaced. Replacements are deterministic: the same input always gets the
+// same fake value under the same key, so values that link records, such as
+// the email of a user and the actor_email of their audit entries, still
+// match after anonymization. Without the key, the fakes cannot be mapped
+// back to the originals.
+//
+// What is replaced is declared per table or collection with Rules. Copying
+// fails if the source has a table or collection without a rule, so that
+// new data is not copied before someone decides how to anonymize it.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Scrambler derives fake values from real ones with a secret key
+type Scrambler struct {
+	key []byte
+}
+
+// NewScrambler returns a Scrambler keyed with key, which should be random
+// and kept as secret as the production data
+func NewScrambler(key []byte) *Scrambler {
+	return &Scrambler{key: key}
+}
+
+// sum returns the keyed hash of value, separately for every kind of value
+func (s *Scrambler) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func (s *Scrambler) pick(kind, value string, options []string) string {
+	return options[binary.BigEndian.Uint64(s.sum(kind, value))%uint64(len(options))]
+}
+
+// FirstName returns a fake given name for name
+func (s *Scrambler) FirstName(name string) string {
+	return s.pick("first_name", normalize(name), firstNames)
+}
+
+// LastName returns a fake family name for name
+func (s *Scrambler) LastName(name string) string {
+	return s.pick("last_name", normalize(name), lastNames)
+}
+
+// FullName returns a fake "first last" name for name
+func (s *Scrambler) FullName(name string) string {
+	return s.FirstName(name) + " " + s.LastName(name)
+}
+
+// Email returns a fake address on a reserved example domain. Addresses that
+// differ only in case or surrounding space get the same fake, and different
+// addresses get different ones, so unique indexes still hold.
+func (s *Scrambler) Email(email string) string {
+	email = normalize(email)
+	return fmt.Sprintf("%s.%s.%s@example.com",
+		strings.ToLower(s.FirstName(email)), strings.ToLower(s.LastName(email)),
+		hex.EncodeToString(s.sum("email", email)[:6]))
+}
+
+// Phone returns a fake number in the 555-0100 to 555-0199 range reserved
+// for fiction. Formatting is ignored, so "+1 (202) 555-0143" and
+// "12025550143" get the same fake.
+func (s *Scrambler) Phone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	return fmt.Sprintf("+1202555%04d", 100+binary.BigEndian.Uint64(s.sum("phone", digits))%100)
+}
+
+// Address returns a fake street address for address
+func (s *Scrambler) Address(address string) string {
+	address = normalize(address)
+	number := 1 + binary.BigEndian.Uint64(s.sum("address_number", address))%9999
+	return fmt.Sprintf("%d %s %s", number, s.pick("street", address, streets), s.pick("street_suffix", address, streetSuffixes))
+}
+
+// Token returns a random-looking hex string for secrets such as API keys
+func (s *Scrambler) Token(token string) string {
+	return hex.EncodeToString(s.sum("token", token)[:16])
+}
+
+// URL returns a fake URL on a reserved example domain
+func (s *Scrambler) URL(url string) string {
+	return "https://hooks.example.com/" + hex.EncodeToString(s.sum("url", url)[:8])
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// Replacer returns the value to store instead of value, which is nil for
+// NULL or a missing field. record is the whole row or document, as read.
+type Replacer func(s *Scrambler, record map[string]interface{}, value interface{}) (interface{}, error)
+
+// Scramble replaces strings with fake(value), and keeps nil
+func Scramble(fake func(*Scrambler, string) string) Replacer {
+	return func(s *Scrambler, _ map[string]interface{}, value interface{}) (interface{}, error) {
+		switch value := value.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			if value == "" {
+				return value, nil
+			}
+			return fake(s, value), nil
+		}
+		return nil, fmt.Errorf("cannot scramble a %T", value)
+	}
+}
+
+// Replacers for the common kinds of personal data
+var (
+	Email     = Scramble((*Scrambler).Email)
+	FirstName = Scramble((*Scrambler).FirstName)
+	LastName  = Scramble((*Scrambler).LastName)
+	FullName  = Scramble((*Scrambler).FullName)
+	Phone     = Scramble((*Scrambler).Phone)
+	Address   = Scramble((*Scrambler).Address)
+	Token     = Scramble((*Scrambler).Token)
+	URL       = Scramble((*Scrambler).URL)
+)
+
+// Clear replaces values with nil, which is NULL in Postgres
+func Clear(*Scrambler, map[string]interface{}, interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+// Set replaces values with a constant
+func Set(constant interface{}) Replacer {
+	return func(*Scrambler, map[string]interface{}, interface{}) (interface{}, error) {
+		return constant, nil
+	}
+}
+
+// Rule says how to copy one table or collection
+type Rule struct {
+	// Name is the table or collection
+	Name string
+	// Skip leaves the table or collection empty in the target
+	Skip bool
+	// Fields replaces the values of columns, or of fields of documents by
+	// dotted path. Paths through arrays apply to every element. Other
+	// columns and fields are copied as they are.
+	Fields map[string]Replacer
+}
+
+// Apply replaces the fields of one row or document in place
+func (r Rule) Apply(s *Scrambler, record map[string]interface{}) error {
+	for path, replace := range r.Fields {
+		err := replacePath(record, strings.Split(path, "."), func(value interface{}) (interface{}, error) {
+			return replace(s, record, value)
+		})
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", r.Name, path, err)
+		}
+	}
+	return nil
+}
+
+// replacePath replaces the value at path below v; missing fields are left
+// missing
+func replacePath(v interface{}, path []string, replace func(interface{}) (interface{}, error)) error {
+	switch v := v.(type) {
+	case bson.M:
+		return replacePath(map[string]interface{}(v), path, replace)
+	case map[string]interface{}:
+		value, ok := v[path[0]]
+		if !ok {
+			return nil
+		}
+		if len(path) > 1 {
+			return replacePath(value, path[1:], replace)
+		}
+		replaced, err := replace(value)
+		if err != nil {
+			return err
+		}
+		v[path[0]] = replaced
+	case bson.A:
+		return replacePath([]interface{}(v), path, replace)
+	case []interface{}:
+		for _, element := range v {
+			if err := replacePath(element, path, replace); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ruleFor returns the rule for the table or collection name
+func ruleFor(rules []Rule, name string) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// checkCoverage returns an error naming the tables or collections without
+// a rule
+func checkCoverage(rules []Rule, names []string) error {
+	var missing []string
+	for _, name := range names {
+		if _, ok := ruleFor(rules, name); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no anonymization rule for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+var (
+	firstNames = []string{
+		"Alex", "Avery", "Blake", "Cameron", "Casey", "Dakota", "Drew", "Eden",
+		"Emerson", "Finley", "Harper", "Hayden", "Jamie", "Jordan", "Kai",
+		"Logan", "Morgan", "Parker", "Quinn", "Reese", "Riley", "Rowan", "Sage",
+		"Skyler", "Taylor",
+	}
+	lastNames = []string{
+		"Archer", "Bennett", "Carter", "Dalton", "Ellis", "Fletcher", "Grant",
+		"Hayes", "Irving", "Jennings", "Keller", "Lambert", "Mercer", "Nolan",
+		"Osborne", "Porter", "Quincy", "Reyes", "Sutton", "Turner", "Vaughn",
+		"Walsh", "Young",
+	}
+	streets        = []string{"Maple", "Oak", "Cedar", "Pine", "Elm", "Birch", "Willow", "Lake", "Hill", "Park"}
+	streetSuffixes = []string{"Street", "Avenue", "Road", "Lane", "Drive", "Court"}
+)
@@ -0,0 +1,86 @@
+package anonymize
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestScramblerIsDeterministic(t *testing.T) {
+	s := NewScrambler([]byte("test-key-0123456789"))
+	other := NewScrambler([]byte("other-key-0123456789"))
+
+	assert.Equal(t, s.Email("ann@example.org"), s.Email("ann@example.org"))
+	assert.Equal(t, s.Email("ann@example.org"), s.Email(" Ann@Example.org "), "case and surrounding space are ignored")
+	assert.NotEqual(t, s.Email("ann@example.org"), s.Email("bo@example.org"))
+	assert.NotEqual(t, s.Email("ann@example.org"), other.Email("ann@example.org"), "fakes depend on the key")
+
+	assert.Equal(t, s.Phone("+1 (202) 555-0143"), s.Phone("12025550143"), "formatting is ignored")
+	assert.Equal(t, s.FullName("Ann Lee"), s.FullName("ann lee"))
+	assert.Equal(t, s.Token("secret"), s.Token("secret"))
+}
+
+func TestScramblerFormats(t *testing.T) {
+	s := NewScrambler([]byte("test-key-0123456789"))
+	emails := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		email := s.Email(fmt.Sprintf("user%d@example.org", i))
+		assert.Regexp(t, `^[a-z]+\.[a-z]+\.[0-9a-f]{12}@example\.com$`, email)
+		emails[email] = true
+	}
+	assert.Len(t, emails, 1000, "different addresses get different fakes")
+
+	assert.Regexp(t, `^\+12025550[01]\d\d$`, s.Phone("+44 20 7946 0958"))
+	assert.Regexp(t, `^\d+ [A-Z][a-z]+ [A-Z][a-z]+$`, s.Address("221B Baker Street"))
+	assert.Regexp(t, `^[0-9a-f]{32}$`, s.Token("whsec_live"))
+	assert.Regexp(t, `^https://hooks\.example\.com/[0-9a-f]{16}$`, s.URL("https://partner.example.net/hook"))
+}
+
+func TestRuleApply(t *testing.T) {
+	s := NewScrambler([]byte("test-key-0123456789"))
+	rule := Rule{Name: "orders", Fields: map[string]Replacer{
+		"email":                 Email,
+		"shipping.phone":        Phone,
+		"contacts.name":         FullName,
+		"missing.field":         Email,
+		"notes":                 Clear,
+		"status":                Set("redacted"),
+		"shipping.address_line": Address,
+	}}
+	doc := bson.M{
+		"email":    "ann@example.org",
+		"shipping": bson.M{"phone": "+1 555 0100", "city": "Springfield"},
+		"contacts": bson.A{bson.M{"name": "Ann Lee"}, bson.M{"name": "Bo Chan"}},
+		"notes":    "call Ann after 5pm",
+		"status":   "open",
+		"total":    int32(42),
+	}
+
+	require.NoError(t, rule.Apply(s, doc))
+	assert.Equal(t, s.Email("ann@example.org"), doc["email"])
+	assert.Equal(t, s.Phone("+1 555 0100"), doc["shipping"].(bson.M)["phone"])
+	assert.Equal(t, "Springfield", doc["shipping"].(bson.M)["city"], "other fields are kept")
+	assert.NotContains(t, doc["shipping"], "address_line", "missing fields stay missing")
+	assert.Equal(t, s.FullName("Ann Lee"), doc["contacts"].(bson.A)[0].(bson.M)["name"])
+	assert.Equal(t, s.FullName("Bo Chan"), doc["contacts"].(bson.A)[1].(bson.M)["name"])
+	assert.NotContains(t, doc, "missing")
+	assert.Nil(t, doc["notes"])
+	assert.Equal(t, "redacted", doc["status"])
+	assert.Equal(t, int32(42), doc["total"])
+
+	row := map[string]interface{}{"email": nil}
+	require.NoError(t, Rule{Name: "users", Fields: map[string]Replacer{"email": Email}}.Apply(s, row))
+	assert.Nil(t, row["email"], "NULL stays NULL")
+
+	err := Rule{Name: "users", Fields: map[string]Replacer{"email": Email}}.Apply(s, map[string]interface{}{"email": 42})
+	assert.ErrorContains(t, err, "users.email: cannot scramble a int")
+}
+
+func TestCheckCoverage(t *testing.T) {
+	rules := []Rule{{Name: "users"}, {Name: "sessions", Skip: true}}
+	assert.NoError(t, checkCoverage(rules, []string{"users", "sessions"}))
+	assert.EqualError(t, checkCoverage(rules, []string{"users", "addresses", "payments"}), "no anonymization rule for addresses, payments")
+}
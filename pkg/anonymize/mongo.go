@@ -0,0 +1,89 @@
+package anonymize
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CopyMongo copies every collection of src into the collection of the same
+// name in dst, replacing its documents and keeping its indexes. Documents
+// are inserted batchSize at a time.
+func CopyMongo(ctx context.Context, src, dst *mongo.Database, rules []Rule, s *Scrambler, batchSize int, logger *slog.Logger) ([]Copied, error) {
+	collections, err := src.ListCollectionNames(ctx, bson.M{"type": "collection", "name": bson.M{"$not": bson.M{"$regex": "^system\\."}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	sort.Strings(collections)
+	if err := checkCoverage(rules, collections); err != nil {
+		return nil, fmt.Errorf("%s: %w", src.Name(), err)
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var copied []Copied
+	for _, name := range collections {
+		rule, _ := ruleFor(rules, name)
+		if _, err := dst.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+			return copied, fmt.Errorf("failed to empty %s.%s: %w", dst.Name(), name, err)
+		}
+		if rule.Skip {
+			logger.Info("Skipped collection", "database", src.Name(), "collection", name)
+			copied = append(copied, Copied{Name: name, Skipped: true})
+			continue
+		}
+		n, err := copyCollection(ctx, src.Collection(name), dst.Collection(name), rule, s, batchSize)
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy %s.%s: %w", src.Name(), name, err)
+		}
+		logger.Info("Copied collection", "database", src.Name(), "collection", name, "documents", n)
+		copied = append(copied, Copied{Name: name, Records: n})
+	}
+	return copied, nil
+}
+
+func copyCollection(ctx context.Context, src, dst *mongo.Collection, rule Rule, s *Scrambler, batchSize int) (int, error) {
+	cursor, err := src.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	n := 0
+	batch := make([]interface{}, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := dst.InsertMany(ctx, batch); err != nil {
+			return err
+		}
+		n += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return n, err
+		}
+		if err := rule.Apply(s, doc); err != nil {
+			return n, err
+		}
+		batch = append(batch, doc)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
@@ -0,0 +1,173 @@
+package anonymize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Copied is how many rows or documents were copied from one table or
+// collection
+type Copied struct {
+	Name    string
+	Records int
+	Skipped bool
+}
+
+// CopyPostgres copies every table of the public schema of src into the
+// table of the same name in dst, which must exist, replacing its rows. Each
+// table is copied in one transaction. Values reach replacers as strings, in
+// Postgres text format, or nil for NULL, and are cast back to the column's
+// type.
+func CopyPostgres(ctx context.Context, src, dst *sql.DB, rules []Rule, s *Scrambler, logger *slog.Logger) ([]Copied, error) {
+	tables, err := queryStrings(ctx, src, `SELECT tablename FROM pg_tables WHERE schemaname = 'public' ORDER BY tablename`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	if err := checkCoverage(rules, tables); err != nil {
+		return nil, err
+	}
+
+	var copied []Copied
+	for _, table := range tables {
+		rule, _ := ruleFor(rules, table)
+		if rule.Skip {
+			if _, err := dst.ExecContext(ctx, "TRUNCATE "+quoteIdent(table)); err != nil {
+				return copied, fmt.Errorf("failed to empty %s: %w", table, err)
+			}
+			logger.Info("Skipped table", "table", table)
+			copied = append(copied, Copied{Name: table, Skipped: true})
+			continue
+		}
+		n, err := copyTable(ctx, src, dst, rule, s)
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy %s: %w", table, err)
+		}
+		logger.Info("Copied table", "table", table, "rows", n)
+		copied = append(copied, Copied{Name: table, Records: n})
+	}
+	return copied, nil
+}
+
+func copyTable(ctx context.Context, src, dst *sql.DB, rule Rule, s *Scrambler) (int, error) {
+	rows, err := src.QueryContext(ctx, `
+		SELECT attname, format_type(atttypid, atttypmod) FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped
+		ORDER BY attnum`, quoteIdent(rule.Name))
+	if err != nil {
+		return 0, err
+	}
+	var columns, types []string
+	for rows.Next() {
+		var column, typ string
+		if err := rows.Scan(&column, &typ); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		columns, types = append(columns, column), append(types, typ)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for field := range rule.Fields {
+		if !contains(columns, field) {
+			return 0, fmt.Errorf("rule names column %s, which does not exist", field)
+		}
+	}
+
+	selects, quoted, params := make([]string, len(columns)), make([]string, len(columns)), make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdent(column)
+		selects[i] = quoted[i] + "::text"
+		params[i] = fmt.Sprintf("$%d::%s", i+1, types[i])
+	}
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "TRUNCATE "+quoteIdent(rule.Name)); err != nil {
+		return 0, err
+	}
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(rule.Name), strings.Join(quoted, ", "), strings.Join(params, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	rows, err = src.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), quoteIdent(rule.Name)))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	values := make([]sql.NullString, len(columns))
+	scan := make([]interface{}, len(columns))
+	for i := range values {
+		scan[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scan...); err != nil {
+			return n, err
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if values[i].Valid {
+				record[column] = values[i].String
+			} else {
+				record[column] = nil
+			}
+		}
+		if err := rule.Apply(s, record); err != nil {
+			return n, err
+		}
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			args[i] = record[column]
+		}
+		if _, err := insert.ExecContext(ctx, args...); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, tx.Commit()
+}
+
+func queryStrings(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Package apperrors defines the error kinds shared by all services and
+// translates them to HTTP and gRPC responses in one place.
+//
+// Domain packages declare their errors with a kind:
+//
+//	var ErrSupplierNotFound = apperrors.New(apperrors.NotFound, "supplier not found")
+//
+// and services wrap them as usual with fmt.Errorf("...: %w", err) or Wrap.
+// Handlers then call WriteHTTP or ToGRPC instead of matching on error text.
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error by how a caller should react to it
+type Kind int
+
+// Error kinds
+const (
+	Internal        Kind = iota // Unexpected failure; details are not shown to callers
+	NotFound                    // The addressed resource does not exist
+	Conflict                    // The request conflicts with the current state
+	Invalid                     // The request is malformed or fails validation
+	Unauthenticated             // The caller is not authenticated
+	Forbidden                   // The caller may not perform the operation
+	Unavailable                 // A dependency is temporarily unavailable; retry later
+	RateLimited                 // The caller exceeded its request quota; retry later
+	AlreadyExists               // A resource with the same unique key already exists
+)
+
+var kindNames = map[Kind]string{
+	Internal:        "internal",
+	NotFound:        "not_found",
+	Conflict:        "conflict",
+	Invalid:         "invalid",
+	Unauthenticated: "unauthenticated",
+	Forbidden:       "forbidden",
+	Unavailable:     "unavailable",
+	RateLimited:     "rate_limited",
+	AlreadyExists:   "already_exists",
+}
+
+// String returns the snake_case name of the kind
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return kindNames[Internal]
+}
+
+// Error is an error with a kind. Reason is an optional machine-readable
+// code, such as "SUPPLIER_IN_USE", passed on to clients.
+type Error struct {
+	Kind    Kind
+	Reason  string
+	Message string
+	Err     error
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithReason returns a copy of the error with the given reason code
+func (e *Error) WithReason(reason string) *Error {
+	copied := *e
+	copied.Reason = reason
+	return &copied
+}
+
+// New creates an error of the given kind
+func New(kind Kind, msg string) *Error {
+	return &Error{Kind: kind, Message: msg}
+}
+
+// Newf creates an error of the given kind with a formatted message
+func Newf(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap gives err a kind, prefixing its message with msg. It returns nil if
+// err is nil.
+func Wrap(err error, kind Kind, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Message: msg, Err: err}
+}
+
+// Wrapf is Wrap with a formatted message
+func Wrapf(err error, kind Kind, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// KindOf returns the kind of the outermost *Error in err's chain. Expired
+// or canceled contexts count as Unavailable; anything else without a kind
+// is Internal.
+func KindOf(err error) Kind {
+	var appErr *Error
+	switch {
+	case err == nil:
+		return Internal
+	case errors.As(err, &appErr):
+		return appErr.Kind
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return Unavailable
+	default:
+		return Internal
+	}
+}
+
+// ReasonOf returns the first reason code found in err's chain
+func ReasonOf(err error) string {
+	for err != nil {
+		var appErr *Error
+		if !errors.As(err, &appErr) {
+			return ""
+		}
+		if appErr.Reason != "" {
+			return appErr.Reason
+		}
+		err = appErr.Err
+	}
+	return ""
+}
+
+// Is reports whether err has the given kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
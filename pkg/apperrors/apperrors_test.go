@@ -0,0 +1,136 @@
+package apperrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errWidgetNotFound = New(NotFound, "widget not found")
+
+func TestKindOfWrappedErrors(t *testing.T) {
+	wrapped := fmt.Errorf("repository error: %w", errWidgetNotFound)
+
+	assert.Equal(t, NotFound, KindOf(wrapped))
+	assert.True(t, errors.Is(wrapped, errWidgetNotFound))
+	assert.True(t, Is(wrapped, NotFound))
+	assert.Equal(t, Internal, KindOf(errors.New("boom")))
+	assert.Equal(t, Unavailable, KindOf(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.Equal(t, Unavailable, KindOf(fmt.Errorf("query: %w", context.Canceled)))
+	assert.False(t, Is(nil, Internal))
+}
+
+func TestWrapUsesOutermostKind(t *testing.T) {
+	err := Wrap(errWidgetNotFound, Invalid, "validation error")
+
+	assert.Equal(t, Invalid, KindOf(err))
+	assert.Equal(t, "validation error: widget not found", err.Error())
+	assert.True(t, errors.Is(err, errWidgetNotFound))
+	assert.Nil(t, Wrap(nil, Invalid, "ignored"))
+}
+
+func TestReasonOf(t *testing.T) {
+	inUse := New(Conflict, "supplier is in use").WithReason("SUPPLIER_IN_USE")
+	err := fmt.Errorf("%w: linked to 3 products", inUse)
+
+	assert.Equal(t, "SUPPLIER_IN_USE", ReasonOf(err))
+	assert.Equal(t, "", ReasonOf(errWidgetNotFound))
+	assert.Equal(t, "", inUse.WithReason("").Reason)
+}
+
+func TestWriteHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+	}{
+		{"not found", fmt.Errorf("repository error: %w", errWidgetNotFound), http.StatusNotFound, "repository error: widget not found"},
+		{"invalid", New(Invalid, "name is required"), http.StatusBadRequest, "name is required"},
+		{"conflict", New(Conflict, "already exists"), http.StatusConflict, "already exists"},
+		{"already exists", New(AlreadyExists, "SKU already exists"), http.StatusConflict, "SKU already exists"},
+		{"unavailable", New(Unavailable, "fx down"), http.StatusServiceUnavailable, "fx down"},
+		{"rate limited", New(RateLimited, "slow down"), http.StatusTooManyRequests, "slow down"},
+		{"internal hides detail", errors.New("connection reset by peer"), http.StatusInternalServerError, "An internal error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/v1/widgets/1", nil)
+
+			WriteHTTP(rec, req, tt.err)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.detail, problem.Detail)
+			assert.Equal(t, "/v1/widgets/1", problem.Instance)
+		})
+	}
+}
+
+func TestWriteHTTPIncludesCorrelationIDs(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/widgets/1", nil)
+	ctx := logging.WithTrace(logging.WithRequestID(req.Context(), "req-1"), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+
+	WriteHTTP(rec, req.WithContext(ctx), errWidgetNotFound)
+
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "req-1", problem.RequestID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", problem.TraceID)
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	err := ToGRPC(fmt.Errorf("lookup: %w", New(Conflict, "sku taken").WithReason("SKU_EXISTS")))
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "lookup: sku taken", st.Message())
+
+	back := FromGRPC(err)
+	assert.Equal(t, Conflict, KindOf(back))
+	assert.Equal(t, "SKU_EXISTS", ReasonOf(back))
+}
+
+func TestGRPCAlreadyExists(t *testing.T) {
+	err := ToGRPC(New(AlreadyExists, "SKU already exists").WithReason("SKU_EXISTS"))
+
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	assert.Equal(t, AlreadyExists, KindOf(FromGRPC(err)))
+}
+
+func TestToGRPCPassesThroughStatusAndHidesInternal(t *testing.T) {
+	existing := status.Error(codes.ResourceExhausted, "slow down")
+	assert.Equal(t, existing, ToGRPC(existing))
+	assert.Nil(t, ToGRPC(nil))
+
+	st, _ := status.FromError(ToGRPC(errors.New("mongo: socket closed")))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal error", st.Message())
+}
+
+func TestFromGRPCMapsPlainStatusCodes(t *testing.T) {
+	assert.Equal(t, NotFound, KindOf(FromGRPC(status.Error(codes.NotFound, "gone"))))
+	assert.Equal(t, Unavailable, KindOf(FromGRPC(status.Error(codes.DeadlineExceeded, "slow"))))
+	assert.Equal(t, Conflict, KindOf(FromGRPC(status.Error(codes.AlreadyExists, "dup"))))
+	assert.Equal(t, RateLimited, KindOf(FromGRPC(status.Error(codes.ResourceExhausted, "quota"))))
+
+	plain := errors.New("not a status")
+	assert.Equal(t, plain, FromGRPC(plain))
+}
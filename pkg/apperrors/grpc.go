@@ -0,0 +1,111 @@
+package apperrors
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain reported in gRPC ErrorInfo details
+const ErrorDomain = "online-shop"
+
+var grpcCodes = map[Kind]codes.Code{
+	Internal:        codes.Internal,
+	NotFound:        codes.NotFound,
+	Conflict:        codes.FailedPrecondition,
+	Invalid:         codes.InvalidArgument,
+	Unauthenticated: codes.Unauthenticated,
+	Forbidden:       codes.PermissionDenied,
+	Unavailable:     codes.Unavailable,
+	RateLimited:     codes.ResourceExhausted,
+	AlreadyExists:   codes.AlreadyExists,
+}
+
+// GRPCCode returns the gRPC status code for err
+func GRPCCode(err error) codes.Code {
+	return grpcCodes[KindOf(err)]
+}
+
+// ToGRPC converts err to a gRPC status error carrying an ErrorInfo detail
+// with the kind and reason. Errors that already are gRPC statuses are
+// returned unchanged, and nil stays nil.
+func ToGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok && !hasKind(err) {
+		return err
+	}
+
+	kind := KindOf(err)
+	msg := err.Error()
+	if kind == Internal {
+		msg = "internal error"
+	}
+	st := status.New(grpcCodes[kind], msg)
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   ReasonOf(err),
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"kind": kind.String()},
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// FromGRPC converts an error returned by a gRPC client into an *Error so
+// that callers can branch on the kind of a remote failure. Kinds sent by
+// ToGRPC are restored exactly; other statuses are mapped from their code.
+func FromGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	appErr := &Error{Kind: kindFromCode(st.Code()), Message: st.Message(), Err: err}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorDomain {
+			continue
+		}
+		appErr.Reason = info.Reason
+		for kind, name := range kindNames {
+			if name == info.Metadata["kind"] {
+				appErr.Kind = kind
+			}
+		}
+	}
+	return appErr
+}
+
+func kindFromCode(code codes.Code) Kind {
+	switch code {
+	case codes.NotFound:
+		return NotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return Conflict
+	case codes.InvalidArgument, codes.OutOfRange:
+		return Invalid
+	case codes.Unauthenticated:
+		return Unauthenticated
+	case codes.PermissionDenied:
+		return Forbidden
+	case codes.ResourceExhausted:
+		return RateLimited
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return Unavailable
+	default:
+		return Internal
+	}
+}
+
+func hasKind(err error) bool {
+	var appErr *Error
+	return errors.As(err, &appErr)
+}
@@ -0,0 +1,77 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+)
+
+// ProblemContentType is the media type of problem responses (RFC 9457)
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of an error response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Kind     string `json:"kind"`
+	Reason   string `json:"reason,omitempty"`
+
+	// RequestID and TraceID identify the failed request in the logs
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+}
+
+var httpStatuses = map[Kind]int{
+	Internal:        http.StatusInternalServerError,
+	NotFound:        http.StatusNotFound,
+	Conflict:        http.StatusConflict,
+	Invalid:         http.StatusBadRequest,
+	Unauthenticated: http.StatusUnauthorized,
+	Forbidden:       http.StatusForbidden,
+	Unavailable:     http.StatusServiceUnavailable,
+	RateLimited:     http.StatusTooManyRequests,
+	AlreadyExists:   http.StatusConflict,
+}
+
+// HTTPStatus returns the HTTP status code for err
+func HTTPStatus(err error) int {
+	return httpStatuses[KindOf(err)]
+}
+
+// ToProblem builds the problem body for err, including the request's
+// correlation IDs. Internal errors get a generic detail so that driver and
+// stack messages are not leaked to clients.
+func ToProblem(r *http.Request, err error) Problem {
+	kind := KindOf(err)
+	status := httpStatuses[kind]
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+		Kind:   kind.String(),
+		Reason: ReasonOf(err),
+	}
+	if kind == Internal {
+		problem.Detail = "An internal error occurred"
+	}
+	if r != nil {
+		problem.Instance = r.URL.Path
+		problem.RequestID = logging.RequestID(r.Context())
+		problem.TraceID, _ = logging.Trace(r.Context())
+	}
+	return problem
+}
+
+// WriteHTTP writes err as a problem JSON response
+func WriteHTTP(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ToProblem(r, err)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,250 @@
+// Package audit makes audit trails tamper-evident and exportable. Each
+// entry carries the hash of the entry before it and its own hash, computed
+// over its JSON encoding, so a changed, removed or reordered entry breaks
+// the chain. Trails are exported, and archived, as NDJSON: one JSON entry
+// per line.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/objectstore"
+)
+
+// Fields of an entry's JSON encoding that hold the chain
+const (
+	PrevHashField = "prev_hash"
+	HashField     = "hash"
+)
+
+// ContentType is the media type of exported trails
+const ContentType = "application/x-ndjson"
+
+// Hash returns the hash of an entry: the SHA-256 of its JSON encoding,
+// with object keys sorted and without the hash field. The entry's
+// encoding must include the prev_hash field, so that the hash covers it,
+// and its values must read back from storage unchanged: times truncated
+// to the store's precision and in UTC.
+func Hash(entry interface{}) (string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	return hashJSON(data)
+}
+
+// hashJSON hashes the canonical form of an encoded entry
+func hashJSON(data []byte) (string, error) {
+	fields, err := decode(data)
+	if err != nil {
+		return "", err
+	}
+	delete(fields, HashField)
+	canonical, err := json.Marshal(fields) // sorts the keys
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// decode decodes an encoded entry, keeping numbers as written
+func decode(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+	}
+	return fields, nil
+}
+
+// VerifyResult is the outcome of verifying an exported trail
+type VerifyResult struct {
+	// Entries is how many chained entries were checked
+	Entries int `json:"entries"`
+	// Unchained is how many entries had no hash, such as actions still in
+	// progress
+	Unchained int `json:"unchained"`
+	// Anchor is the prev_hash of the first entry, which the trail is
+	// verified from. It is empty when the trail starts the chain.
+	Anchor string `json:"anchor"`
+	// Head is the hash of the last entry
+	Head string `json:"head"`
+}
+
+// Verify checks an NDJSON trail, as exported: each entry's hash, and that
+// it chains to the entry before it. The first entry is trusted as the
+// anchor, so a trail exported from a time range verifies on its own; to
+// check that nothing was removed before it, compare the anchor with the
+// head of the previous export or archive. Entries without a hash are
+// skipped.
+func Verify(r io.Reader) (VerifyResult, error) {
+	var result VerifyResult
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		fields, err := decode(data)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		hash, _ := fields[HashField].(string)
+		if hash == "" {
+			result.Unchained++
+			continue
+		}
+		prev, _ := fields[PrevHashField].(string)
+		if result.Entries == 0 {
+			result.Anchor = prev
+		} else if prev != result.Head {
+			return result, fmt.Errorf("line %d: entry does not follow the one before it", line)
+		}
+		computed, err := hashJSON(data)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if computed != hash {
+			return result, fmt.Errorf("line %d: entry was modified", line)
+		}
+		result.Entries++
+		result.Head = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read trail: %w", err)
+	}
+	return result, nil
+}
+
+// ParseRange parses the from and to query parameters of an export as RFC
+// 3339 timestamps. from is required; to defaults to now.
+func ParseRange(query url.Values) (from, to time.Time, err error) {
+	if query.Get("from") == "" {
+		return from, to, errors.New("from is required")
+	}
+	if from, err = time.Parse(time.RFC3339, query.Get("from")); err != nil {
+		return from, to, errors.New("from must be an RFC 3339 timestamp")
+	}
+	to = time.Now()
+	if query.Get("to") != "" {
+		if to, err = time.Parse(time.RFC3339, query.Get("to")); err != nil {
+			return from, to, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+// StreamWriter writes entries to an HTTP response as NDJSON, flushing each
+// so that large exports stream
+type StreamWriter struct {
+	w        http.ResponseWriter
+	filename string
+	encoder  *json.Encoder
+	flusher  http.Flusher
+	started  bool
+}
+
+// NewStreamWriter creates a writer for an export response, downloaded as
+// filename. The status and headers are sent with the first entry, or by
+// Finish, so errors before then can still be reported.
+func NewStreamWriter(w http.ResponseWriter, filename string) *StreamWriter {
+	flusher, _ := w.(http.Flusher)
+	return &StreamWriter{w: w, filename: filename, encoder: json.NewEncoder(w), flusher: flusher}
+}
+
+// Started reports whether the response has been sent, after which errors
+// can no longer change its status
+func (s *StreamWriter) Started() bool {
+	return s.started
+}
+
+// start sends the status and headers
+func (s *StreamWriter) start() {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", ContentType)
+		s.w.Header().Set("Content-Disposition", `attachment; filename="`+s.filename+`"`)
+		s.w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Write writes an entry
+func (s *StreamWriter) Write(entry interface{}) error {
+	s.start()
+	if err := s.encoder.Encode(entry); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// Finish sends the response of an empty export
+func (s *StreamWriter) Finish() {
+	s.start()
+}
+
+// Archive is a gzipped NDJSON trail written to object storage
+type Archive struct {
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+// NewArchive creates an empty archive
+func NewArchive() *Archive {
+	a := &Archive{}
+	a.gz = gzip.NewWriter(&a.buf)
+	a.encoder = json.NewEncoder(a.gz)
+	return a
+}
+
+// Write adds an entry to the archive
+func (a *Archive) Write(entry interface{}) error {
+	a.count++
+	return a.encoder.Encode(entry)
+}
+
+// Len returns how many entries were written
+func (a *Archive) Len() int {
+	return a.count
+}
+
+// Upload writes the archive to the store. The key is
+// <prefix>/<service>/<first>_<last>.ndjson.gz, from the first and last
+// sequence numbers it holds.
+func (a *Archive) Upload(ctx context.Context, store objectstore.Store, prefix, service string, first, last int64) (string, error) {
+	if err := a.gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress audit archive: %w", err)
+	}
+	key := fmt.Sprintf("%s/%s/%020d_%020d.ndjson.gz", prefix, service, first, last)
+	if prefix == "" {
+		key = key[1:]
+	}
+	if err := store.Put(ctx, key, "application/gzip", a.buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to upload audit archive: %w", err)
+	}
+	return key, nil
+}
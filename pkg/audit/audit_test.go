@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	Seq      int64             `json:"seq"`
+	Action   string            `json:"action"`
+	Params   map[string]string `json:"params,omitempty"`
+	At       time.Time         `json:"at"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash,omitempty"`
+}
+
+// chain seals the entries in order and returns them as NDJSON lines
+func chain(t *testing.T, anchor string, entries ...entry) []string {
+	t.Helper()
+	prev := anchor
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		e.PrevHash = prev
+		hash, err := Hash(e)
+		require.NoError(t, err)
+		e.Hash, prev = hash, hash
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		lines[i] = string(data)
+	}
+	return lines
+}
+
+func TestVerify(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lines := chain(t, "",
+		entry{Seq: 1, Action: "user.roles", Params: map[string]string{"roles": "admin", "b": "<x>"}, At: at},
+		entry{Seq: 2, Action: "user.delete", At: at.Add(time.Minute)},
+		entry{Seq: 3, Action: "product.activate", At: at.Add(2 * time.Minute)},
+	)
+
+	result, err := Verify(strings.NewReader(strings.Join(lines, "\n") + "\n" + `{"seq":0,"action":"pending"}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Entries)
+	assert.Equal(t, 1, result.Unchained)
+	assert.Empty(t, result.Anchor)
+
+	t.Run("a range verifies from its anchor", func(t *testing.T) {
+		result, err := Verify(strings.NewReader(strings.Join(lines[1:], "\n")))
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Entries)
+		assert.NotEmpty(t, result.Anchor)
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		tampered := append([]string(nil), lines...)
+		tampered[1] = strings.Replace(tampered[1], "user.delete", "user.view", 1)
+		_, err := Verify(strings.NewReader(strings.Join(tampered, "\n")))
+		assert.ErrorContains(t, err, "line 2: entry was modified")
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		_, err := Verify(strings.NewReader(lines[0] + "\n" + lines[2]))
+		assert.ErrorContains(t, err, "line 2: entry does not follow")
+	})
+}
+
+func TestArchive(t *testing.T) {
+	archive := NewArchive()
+	require.NoError(t, archive.Write(entry{Seq: 1, Action: "a"}))
+	assert.Equal(t, 1, archive.Len())
+
+	store := &memoryStore{}
+	key, err := archive.Upload(t.Context(), store, "audit", "admin", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "audit/admin/00000000000000000001_00000000000000000001.ndjson.gz", key)
+	assert.Equal(t, key, store.key)
+	assert.True(t, bytes.HasPrefix(store.body, []byte{0x1f, 0x8b}), "gzipped")
+}
+
+func TestParseRange(t *testing.T) {
+	from, to, err := ParseRange(url.Values{"from": {"2024-03-01T00:00:00Z"}, "to": {"2024-04-01T00:00:00Z"}})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), to)
+
+	for _, query := range []url.Values{
+		{},
+		{"from": {"yesterday"}},
+		{"from": {"2024-04-01T00:00:00Z"}, "to": {"2024-03-01T00:00:00Z"}},
+	} {
+		_, _, err := ParseRange(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
+
+type memoryStore struct {
+	key  string
+	body []byte
+}
+
+func (m *memoryStore) Put(_ context.Context, key, _ string, body []byte) error {
+	m.key, m.body = key, body
+	return nil
+}
+
+func TestConfigRequiresArchiveForRetention(t *testing.T) {
+	t.Setenv("AUDIT_RETENTION", "2160h")
+	cfg := FromEnv()
+	assert.Equal(t, 90*24*time.Hour, cfg.Retention)
+	assert.ErrorContains(t, cfg.Validate(), "AUDIT_ARCHIVE_BUCKET_URL")
+
+	t.Setenv("AUDIT_ARCHIVE_BUCKET_URL", "http://minio:9000/audit")
+	t.Setenv("AUDIT_ARCHIVE_REGION", "us-east-1")
+	t.Setenv("AUDIT_ARCHIVE_ACCESS_KEY_ID", "key")
+	t.Setenv("AUDIT_ARCHIVE_SECRET_ACCESS_KEY", "secret")
+	cfg = FromEnv()
+	require.NoError(t, cfg.Validate())
+	store, err := cfg.Store()
+	require.NoError(t, err)
+	assert.NotNil(t, store)
+
+	store, err = Config{}.Store()
+	require.NoError(t, err)
+	assert.Nil(t, store, "no retention, no archive")
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/objectstore"
+)
+
+// DefaultRetentionInterval is how often retention runs by default
+const DefaultRetentionInterval = 24 * time.Hour
+
+// Config configures the retention of an audit trail. Entries older than
+// Retention are archived to the bucket, then deleted; with no Retention
+// they are kept forever.
+type Config struct {
+	Retention time.Duration
+	// Interval is how often retention runs
+	Interval time.Duration
+
+	BucketURL       string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Prefix is prepended to the archive keys
+	Prefix string
+}
+
+// FromEnv reads the AUDIT_* environment variables
+func FromEnv() Config {
+	cfg := Config{
+		Interval:        DefaultRetentionInterval,
+		BucketURL:       os.Getenv("AUDIT_ARCHIVE_BUCKET_URL"),
+		Region:          os.Getenv("AUDIT_ARCHIVE_REGION"),
+		AccessKeyID:     os.Getenv("AUDIT_ARCHIVE_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AUDIT_ARCHIVE_SECRET_ACCESS_KEY"),
+		Prefix:          "audit",
+	}
+	if d, err := time.ParseDuration(os.Getenv("AUDIT_RETENTION")); err == nil {
+		cfg.Retention = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("AUDIT_RETENTION_INTERVAL")); err == nil && d > 0 {
+		cfg.Interval = d
+	}
+	if prefix, ok := os.LookupEnv("AUDIT_ARCHIVE_PREFIX"); ok {
+		cfg.Prefix = prefix
+	}
+	return cfg
+}
+
+// Validate checks that entries are archived before they are deleted
+func (c Config) Validate() error {
+	if c.Retention < 0 {
+		return errors.New("AUDIT_RETENTION must not be negative")
+	}
+	if c.Retention > 0 && c.BucketURL == "" {
+		return errors.New("AUDIT_RETENTION requires AUDIT_ARCHIVE_BUCKET_URL: entries are archived before they are deleted")
+	}
+	if c.Retention > 0 && c.Retention < 24*time.Hour {
+		return errors.New("AUDIT_RETENTION must be at least 24h")
+	}
+	return nil
+}
+
+// Store returns the archive bucket, or nil when retention is off
+func (c Config) Store() (objectstore.Store, error) {
+	if c.Retention == 0 {
+		return nil, nil
+	}
+	store, err := objectstore.NewS3(c.BucketURL, c.Region, c.AccessKeyID, c.SecretAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit archive: %w", err)
+	}
+	return store, nil
+}
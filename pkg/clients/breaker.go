@@ -0,0 +1,147 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned without calling the service while a method's
+// circuit breaker is open
+var ErrCircuitOpen = apperrors.New(apperrors.Unavailable, "circuit breaker is open").WithReason("CIRCUIT_OPEN")
+
+// BreakerPolicy controls the per-method circuit breakers
+type BreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker; a negative value disables breaking
+	FailureThreshold int
+	// OpenTimeout is how long an open breaker fails fast before letting a
+	// single probe call through
+	OpenTimeout time.Duration
+}
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // calls go through
+	BreakerHalfOpen                     // one probe call decides whether to close
+	BreakerOpen                         // calls fail fast
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "open"
+	}
+}
+
+// breaker is a consecutive-failure circuit breaker
+type breaker struct {
+	policy   BreakerPolicy
+	onChange func(BreakerState)
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(policy BreakerPolicy, onChange func(BreakerState)) *breaker {
+	return &breaker{policy: policy, onChange: onChange, now: time.Now}
+}
+
+// allow reports whether a call may go ahead
+func (b *breaker) allow() error {
+	if b.policy.FailureThreshold < 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.policy.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record feeds the outcome of an allowed call back into the breaker. Calls
+// the caller abandoned say nothing about the service and are ignored.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b.policy.FailureThreshold < 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return
+	case isFailure(err):
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.policy.FailureThreshold {
+			b.openedAt = b.now()
+			b.setState(BreakerOpen)
+		}
+	default:
+		b.failures = 0
+		b.setState(BreakerClosed)
+	}
+}
+
+func (b *breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
+
+func (b *breaker) currentState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// isFailure reports whether err means the service is unhealthy, as opposed
+// to a normal answer such as NotFound or InvalidArgument
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if Retryable(err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
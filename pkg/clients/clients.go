@@ -0,0 +1,254 @@
+// Package clients holds what the service SDKs under pkg/clients share: the
+// call options and the invoker that applies per-call timeouts, retries
+// idempotent calls on transient failures, hedges latency-sensitive reads,
+// fails fast through per-method circuit breakers and translates gRPC
+// statuses to pkg/apperrors errors.
+//
+// Consumers use the typed SDKs rather than this package directly:
+//
+//	conn, err := clientFactory.Dial("product", cfg.ProductServiceAddr)
+//	opts := clients.DefaultOptions()
+//	opts.Metrics = clients.NewMetrics(registry, "admin")
+//	products := product.New(conn, opts)
+//	p, err := products.GetProduct(ctx, id)
+//	if apperrors.Is(err, apperrors.NotFound) { ... }
+package clients
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Defaults used by DefaultOptions and for zero fields
+const (
+	DefaultTimeout          = 5 * time.Second
+	DefaultMaxAttempts      = 3
+	DefaultInitialBackoff   = 50 * time.Millisecond
+	DefaultMaxBackoff       = time.Second
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 10 * time.Second
+)
+
+// Options configures how an SDK calls its service
+type Options struct {
+	// Timeout bounds each attempt; a shorter deadline on the caller's
+	// context still wins
+	Timeout time.Duration
+	Retry   RetryPolicy
+	Breaker BreakerPolicy
+	Hedge   HedgePolicy
+	// MethodRetries overrides how many times each idempotent method is
+	// retried, keyed by method name ("CheckStock"); 0 disables retries.
+	// Services publish it in their endpoint policies, see
+	// middleware.EndpointPolicies.ClientRetries.
+	MethodRetries map[string]int
+
+	// Metrics records breaker states and hedged requests; optional
+	Metrics *Metrics
+}
+
+// RetryPolicy controls retries of idempotent calls
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first;
+	// 1 disables retries
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultOptions returns the options SDKs use unless told otherwise.
+// Hedging is off by default.
+func DefaultOptions() Options {
+	return Options{
+		Timeout: DefaultTimeout,
+		Retry: RetryPolicy{
+			MaxAttempts:    DefaultMaxAttempts,
+			InitialBackoff: DefaultInitialBackoff,
+			MaxBackoff:     DefaultMaxBackoff,
+		},
+		Breaker: BreakerPolicy{
+			FailureThreshold: DefaultFailureThreshold,
+			OpenTimeout:      DefaultOpenTimeout,
+		},
+	}
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.Retry.MaxAttempts <= 0 {
+		o.Retry.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.Retry.InitialBackoff <= 0 {
+		o.Retry.InitialBackoff = DefaultInitialBackoff
+	}
+	if o.Retry.MaxBackoff <= 0 {
+		o.Retry.MaxBackoff = DefaultMaxBackoff
+	}
+	if o.Breaker.FailureThreshold == 0 {
+		o.Breaker.FailureThreshold = DefaultFailureThreshold
+	}
+	if o.Breaker.OpenTimeout <= 0 {
+		o.Breaker.OpenTimeout = DefaultOpenTimeout
+	}
+	if o.Hedge.MaxAttempts <= 0 {
+		o.Hedge.MaxAttempts = 2
+	}
+	return o
+}
+
+// Method describes how one RPC may be called
+type Method struct {
+	Name string
+	// Idempotent methods are retried on transient failures
+	Idempotent bool
+	// Hedged methods send a second request when the first is slow, if the
+	// options enable hedging. Only idempotent reads should be hedged.
+	Hedged bool
+}
+
+// Invoker runs the calls of one SDK
+type Invoker struct {
+	service string
+	opts    Options
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewInvoker creates an invoker for the named service; zero options fall
+// back to the defaults
+func NewInvoker(service string, opts Options) *Invoker {
+	return &Invoker{
+		service:  service,
+		opts:     opts.withDefaults(),
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// breaker returns the circuit breaker of a method, creating it on first use
+func (in *Invoker) breaker(method string) *breaker {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	b, ok := in.breakers[method]
+	if !ok {
+		b = newBreaker(in.opts.Breaker, func(state BreakerState) {
+			in.opts.Metrics.breakerChanged(in.service, method, state)
+		})
+		in.breakers[method] = b
+		in.opts.Metrics.breakerCreated(in.service, method)
+	}
+	return b
+}
+
+// BreakerState returns the current state of a method's circuit breaker
+func (in *Invoker) BreakerState(method string) BreakerState {
+	return in.breaker(method).currentState()
+}
+
+// Invoke calls the method with a per-attempt timeout. Idempotent methods are
+// retried with jittered exponential backoff while the error is transient
+// and ctx allows, and hedged methods may race a second request against a
+// slow first one. While the method's breaker is open, calls fail with
+// ErrCircuitOpen without reaching the service. The returned error is
+// translated with apperrors.FromGRPC.
+func Invoke[T any](ctx context.Context, in *Invoker, m Method, call func(ctx context.Context) (T, error)) (T, error) {
+	attempts := 1
+	if m.Idempotent {
+		attempts = in.opts.Retry.MaxAttempts
+		if retries, ok := in.opts.MethodRetries[m.Name]; ok {
+			attempts = retries + 1
+		}
+	}
+	b := in.breaker(m.Name)
+
+	var resp T
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if waitErr := sleep(ctx, in.backoff(i)); waitErr != nil {
+				break
+			}
+		}
+		if err = b.allow(); err != nil {
+			break
+		}
+
+		if m.Hedged && in.opts.Hedge.Delay > 0 {
+			resp, err = hedge(ctx, in, m.Name, call)
+		} else {
+			resp, err = attempt(ctx, in.opts.Timeout, call)
+		}
+		b.record(ctx, err)
+		if err == nil || ctx.Err() != nil || !Retryable(err) {
+			break
+		}
+	}
+	if err != nil {
+		var zero T
+		return zero, apperrors.FromGRPC(err)
+	}
+	return resp, nil
+}
+
+// attempt makes one call bounded by timeout
+func attempt[T any](ctx context.Context, timeout time.Duration, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return call(ctx)
+}
+
+// Stream opens a streaming call. Streams are long-lived, so neither the
+// timeout nor retries apply; the breaker and error translation do.
+func (in *Invoker) Stream(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	b := in.breaker(method)
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := call(ctx)
+	b.record(ctx, err)
+	return apperrors.FromGRPC(err)
+}
+
+// backoff returns the wait before the given retry, with full jitter
+func (in *Invoker) backoff(retry int) time.Duration {
+	d := in.opts.Retry.InitialBackoff << (retry - 1)
+	if d <= 0 || d > in.opts.Retry.MaxBackoff {
+		d = in.opts.Retry.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retryable reports whether err is a transient failure worth retrying:
+// the service was unavailable, overloaded, aborted the call or did not
+// answer within the attempt's timeout
+func Retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
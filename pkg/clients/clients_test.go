@@ -0,0 +1,251 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fastOptions() Options {
+	return Options{
+		Timeout: 50 * time.Millisecond,
+		Retry:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond},
+	}
+}
+
+// call invokes fn as the method "Get" and discards the response
+func call(ctx context.Context, in *Invoker, idempotent bool, fn func(context.Context) error) error {
+	_, err := Invoke(ctx, in, Method{Name: "Get", Idempotent: idempotent}, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+func TestInvokeRetriesIdempotentCalls(t *testing.T) {
+	invoker := NewInvoker("test", fastOptions())
+	calls := 0
+	err := call(context.Background(), invoker, true, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestInvokeGivesUpAfterMaxAttempts(t *testing.T) {
+	invoker := NewInvoker("test", fastOptions())
+	calls := 0
+	err := call(context.Background(), invoker, true, func(context.Context) error {
+		calls++
+		return status.Error(codes.Unavailable, "connection refused")
+	})
+	assert.Equal(t, 3, calls)
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
+
+func TestInvokeAppliesMethodRetries(t *testing.T) {
+	for _, retries := range []int{0, 4} {
+		opts := fastOptions()
+		opts.MethodRetries = map[string]int{"Get": retries}
+		calls := 0
+		call(context.Background(), NewInvoker("test", opts), true, func(context.Context) error {
+			calls++
+			return status.Error(codes.Unavailable, "connection refused")
+		})
+		assert.Equal(t, retries+1, calls)
+	}
+}
+
+func TestInvokeDoesNotRetryNonIdempotentCalls(t *testing.T) {
+	invoker := NewInvoker("test", fastOptions())
+	calls := 0
+	err := call(context.Background(), invoker, false, func(context.Context) error {
+		calls++
+		return status.Error(codes.Unavailable, "connection refused")
+	})
+	assert.Equal(t, 1, calls)
+	assert.Error(t, err)
+}
+
+func TestInvokeDoesNotRetryPermanentErrors(t *testing.T) {
+	invoker := NewInvoker("test", fastOptions())
+	calls := 0
+	err := call(context.Background(), invoker, true, func(context.Context) error {
+		calls++
+		return apperrors.ToGRPC(apperrors.New(apperrors.NotFound, "product 42 not found").WithReason("PRODUCT_NOT_FOUND"))
+	})
+	assert.Equal(t, 1, calls)
+	assert.True(t, apperrors.Is(err, apperrors.NotFound))
+	assert.Equal(t, "PRODUCT_NOT_FOUND", apperrors.ReasonOf(err))
+}
+
+func TestInvokeAppliesTimeoutPerAttempt(t *testing.T) {
+	invoker := NewInvoker("test", fastOptions())
+	calls := 0
+	start := time.Now()
+	err := call(context.Background(), invoker, true, func(ctx context.Context) error {
+		calls++
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 20*time.Millisecond)
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	})
+	assert.Equal(t, 3, calls)
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestInvokeStopsWhenCallerGivesUp(t *testing.T) {
+	invoker := NewInvoker("test", Options{Retry: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second}})
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := call(ctx, invoker, true, func(context.Context) error {
+		calls++
+		cancel()
+		return status.Error(codes.Unavailable, "connection refused")
+	})
+	assert.Equal(t, 1, calls)
+	assert.Error(t, err)
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, Retryable(status.Error(codes.Unavailable, "")))
+	assert.True(t, Retryable(status.Error(codes.ResourceExhausted, "")))
+	assert.True(t, Retryable(context.DeadlineExceeded))
+	assert.False(t, Retryable(status.Error(codes.InvalidArgument, "")))
+	assert.False(t, Retryable(status.Error(codes.Internal, "")))
+}
+
+func breakerOptions() Options {
+	return Options{
+		Timeout: 50 * time.Millisecond,
+		Retry:   RetryPolicy{MaxAttempts: 1},
+		Breaker: BreakerPolicy{FailureThreshold: 2, OpenTimeout: time.Minute},
+	}
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := breakerOptions()
+	opts.Metrics = NewMetrics(reg, "test")
+	invoker := NewInvoker("product", opts)
+	now := time.Now()
+	invoker.breaker("Get").now = func() time.Time { return now }
+
+	calls := 0
+	failing := func(context.Context) error {
+		calls++
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	for range 2 {
+		assert.Error(t, call(context.Background(), invoker, false, failing))
+	}
+	assert.Equal(t, BreakerOpen, invoker.BreakerState("Get"))
+	assert.Equal(t, float64(BreakerOpen), testutil.ToFloat64(opts.Metrics.breakerState.WithLabelValues("product", "Get")))
+
+	err := call(context.Background(), invoker, false, failing)
+	assert.Equal(t, 2, calls, "open breaker must not reach the service")
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	assert.Equal(t, "CIRCUIT_OPEN", apperrors.ReasonOf(err))
+	assert.Equal(t, BreakerClosed, invoker.BreakerState("Other"), "breakers are per method")
+}
+
+func TestBreakerProbeClosesOrReopens(t *testing.T) {
+	invoker := NewInvoker("product", breakerOptions())
+	now := time.Now()
+	invoker.breaker("Get").now = func() time.Time { return now }
+	failing := func(context.Context) error { return status.Error(codes.Unavailable, "connection refused") }
+	for range 2 {
+		_ = call(context.Background(), invoker, false, failing)
+	}
+	require.Equal(t, BreakerOpen, invoker.BreakerState("Get"))
+
+	now = now.Add(time.Minute)
+	assert.Error(t, call(context.Background(), invoker, false, failing))
+	assert.Equal(t, BreakerOpen, invoker.BreakerState("Get"), "a failed probe reopens")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, call(context.Background(), invoker, false, func(context.Context) error { return nil }))
+	assert.Equal(t, BreakerClosed, invoker.BreakerState("Get"))
+}
+
+func TestBreakerIgnoresBusinessErrors(t *testing.T) {
+	invoker := NewInvoker("product", breakerOptions())
+	for range 5 {
+		err := call(context.Background(), invoker, false, func(context.Context) error {
+			return status.Error(codes.NotFound, "product 42 not found")
+		})
+		assert.True(t, apperrors.Is(err, apperrors.NotFound))
+	}
+	assert.Equal(t, BreakerClosed, invoker.BreakerState("Get"))
+}
+
+func TestBreakerCanBeDisabled(t *testing.T) {
+	opts := breakerOptions()
+	opts.Breaker.FailureThreshold = -1
+	invoker := NewInvoker("product", opts)
+	calls := 0
+	for range 5 {
+		_ = call(context.Background(), invoker, false, func(context.Context) error {
+			calls++
+			return status.Error(codes.Unavailable, "connection refused")
+		})
+	}
+	assert.Equal(t, 5, calls)
+}
+
+func TestInvokeHedgesSlowCalls(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := Options{
+		Timeout: time.Second,
+		Hedge:   HedgePolicy{Delay: 10 * time.Millisecond},
+		Metrics: NewMetrics(reg, "test"),
+	}
+	invoker := NewInvoker("product", opts)
+
+	var mu sync.Mutex
+	calls := 0
+	start := time.Now()
+	resp, err := Invoke(context.Background(), invoker, Method{Name: "GetProduct", Idempotent: true, Hedged: true},
+		func(ctx context.Context) (int, error) {
+			mu.Lock()
+			calls++
+			n := calls
+			mu.Unlock()
+			if n == 1 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return n, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp, "the hedged copy answers first")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(opts.Metrics.hedgedRequests.WithLabelValues("product", "GetProduct")))
+}
+
+func TestInvokeDoesNotHedgeUnlessEnabled(t *testing.T) {
+	invoker := NewInvoker("product", Options{Timeout: 20 * time.Millisecond, Retry: RetryPolicy{MaxAttempts: 1}})
+	calls := 0
+	_, err := Invoke(context.Background(), invoker, Method{Name: "GetProduct", Idempotent: true, Hedged: true},
+		func(ctx context.Context) (int, error) {
+			calls++
+			<-ctx.Done()
+			return 0, status.FromContextError(ctx.Err()).Err()
+		})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"context"
+	"time"
+)
+
+// HedgePolicy controls hedged requests for methods marked Hedged
+type HedgePolicy struct {
+	// Delay is how long to wait for an answer before sending another copy
+	// of the request; zero disables hedging. Set it near the method's p95
+	// latency so only the slow tail is duplicated.
+	Delay time.Duration
+	// MaxAttempts caps the concurrent copies of a request (default 2)
+	MaxAttempts int
+}
+
+type hedgeResult[T any] struct {
+	resp T
+	err  error
+}
+
+// hedge sends the call and, each time Delay passes without an answer or a
+// copy fails transiently, another copy up to MaxAttempts. The first
+// success or permanent error wins and the other copies are cancelled.
+func hedge[T any](ctx context.Context, in *Invoker, method string, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult[T], in.opts.Hedge.MaxAttempts)
+	sent := 0
+	send := func() {
+		sent++
+		go func() {
+			resp, err := attempt(ctx, in.opts.Timeout, call)
+			results <- hedgeResult[T]{resp: resp, err: err}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(in.opts.Hedge.Delay)
+	defer timer.Stop()
+
+	var last hedgeResult[T]
+	for received := 0; received < sent; {
+		select {
+		case r := <-results:
+			received++
+			if r.err == nil || !Retryable(r.err) {
+				return r.resp, r.err
+			}
+			last = r
+			if sent < in.opts.Hedge.MaxAttempts {
+				in.opts.Metrics.hedged(in.service, method)
+				send()
+			}
+		case <-timer.C:
+			if sent < in.opts.Hedge.MaxAttempts {
+				in.opts.Metrics.hedged(in.service, method)
+				send()
+				timer.Reset(in.opts.Hedge.Delay)
+			}
+		}
+	}
+	return last.resp, last.err
+}
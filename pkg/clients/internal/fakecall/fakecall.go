@@ -0,0 +1,102 @@
+// Package fakecall records the calls made on the SDK fakes and injects the
+// errors tests ask for, so that both fakes behave the same way
+package fakecall
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Call is a call made on a fake: the method name, as in the service's
+// proto, and the request message the SDK would have sent
+type Call struct {
+	Method  string
+	Request proto.Message
+	// Err is the error the call returned, injected or not
+	Err error
+}
+
+// Recorder records calls and holds the errors injected per method
+type Recorder struct {
+	mu     sync.Mutex
+	calls  []Call
+	next   map[string][]error
+	always map[string]error
+}
+
+// FailNext makes the next calls of method return errs, one call per error,
+// before any state is touched. Queued errors add up across calls; a nil
+// error lets its call through.
+func (r *Recorder) FailNext(method string, errs ...error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next == nil {
+		r.next = make(map[string][]error)
+	}
+	r.next[method] = append(r.next[method], errs...)
+}
+
+// Fail makes every call of method return err once FailNext's queue is
+// drained, until Fail is called again with a nil error
+func (r *Recorder) Fail(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.always, method)
+		return
+	}
+	if r.always == nil {
+		r.always = make(map[string]error)
+	}
+	r.always[method] = err
+}
+
+// Calls returns the calls made so far, oldest first, of the given methods
+// or of all methods when none are given
+func (r *Recorder) Calls(methods ...string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, call := range r.calls {
+		if len(methods) == 0 || contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// ResetCalls forgets the recorded calls and injected errors
+func (r *Recorder) ResetCalls() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls, r.next, r.always = nil, nil, nil
+}
+
+// Injected returns the error injected for the next call of method, if any
+func (r *Recorder) Injected(method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if queued := r.next[method]; len(queued) > 0 {
+		r.next[method] = queued[1:]
+		return queued[0]
+	}
+	return r.always[method]
+}
+
+// Record records a call of method with req and the error it returned. The
+// fakes defer it with a pointer to their named error result.
+func (r *Recorder) Record(method string, req proto.Message, err *error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Request: proto.Clone(req), Err: *err})
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
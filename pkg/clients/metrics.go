@@ -0,0 +1,56 @@
+package clients
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the Prometheus collectors shared by the SDKs of one process
+type Metrics struct {
+	breakerState       *prometheus.GaugeVec
+	breakerTransitions *prometheus.CounterVec
+	hedgedRequests     *prometheus.CounterVec
+}
+
+// NewMetrics creates the client metrics under namespace and registers them
+// with reg
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "client_circuit_breaker_state",
+			Help:      "Circuit breaker state by service and method: 0 closed, 1 half-open, 2 open.",
+		}, []string{"service", "method"}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "client_circuit_breaker_transitions_total",
+			Help:      "Circuit breaker state changes by service, method and new state.",
+		}, []string{"service", "method", "state"}),
+		hedgedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "client_hedged_requests_total",
+			Help:      "Extra copies of requests sent because the first was slow or failed.",
+		}, []string{"service", "method"}),
+	}
+	reg.MustRegister(m.breakerState, m.breakerTransitions, m.hedgedRequests)
+	return m
+}
+
+func (m *Metrics) breakerCreated(service, method string) {
+	if m == nil {
+		return
+	}
+	m.breakerState.WithLabelValues(service, method).Set(float64(BreakerClosed))
+}
+
+func (m *Metrics) breakerChanged(service, method string, state BreakerState) {
+	if m == nil {
+		return
+	}
+	m.breakerState.WithLabelValues(service, method).Set(float64(state))
+	m.breakerTransitions.WithLabelValues(service, method, state.String()).Inc()
+}
+
+func (m *Metrics) hedged(service, method string) {
+	if m == nil {
+		return
+	}
+	m.hedgedRequests.WithLabelValues(service, method).Inc()
+}
@@ -0,0 +1,642 @@
+// Package fake provides an in-memory product.Client for tests of services
+// that call product-service
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/clients/internal/fakecall"
+	"github.com/bekbull/online-shop/pkg/clients/product"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Client keeps products in memory and behaves like product-service for the
+// cases services depend on: lookups, filtering, paging and stock changes.
+// It records every call, and FailNext and Fail make methods return errors
+// such as apperrors.New(apperrors.Unavailable, ...) before touching state.
+// Methods are named as in the service's proto, e.g. "CheckStock".
+type Client struct {
+	calls fakecall.Recorder
+
+	mu         sync.Mutex
+	products   map[string]*pb.Product
+	order      []string
+	nextID     int
+	operations map[string]*pb.UpdateInventoryResponse
+	watchers   []chan *pb.InventoryUpdate
+}
+
+var _ product.Client = (*Client)(nil)
+
+// Call is a recorded call: the method name, the request message the SDK
+// would have sent and the error returned
+type Call = fakecall.Call
+
+// New creates a fake holding copies of products. Products without an ID
+// get one.
+func New(products ...*pb.Product) *Client {
+	c := &Client{
+		products:   make(map[string]*pb.Product),
+		operations: make(map[string]*pb.UpdateInventoryResponse),
+	}
+	for _, p := range products {
+		c.Put(p)
+	}
+	return c
+}
+
+// Put stores a copy of p, replacing any product with the same ID
+func (c *Client) Put(p *pb.Product) *pb.Product {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.put(proto.Clone(p).(*pb.Product))
+}
+
+// FailNext makes the next calls of method return errs, one call per error
+func (c *Client) FailNext(method string, errs ...error) {
+	c.calls.FailNext(method, errs...)
+}
+
+// Fail makes every call of method return err, until called with nil
+func (c *Client) Fail(method string, err error) {
+	c.calls.Fail(method, err)
+}
+
+// Calls returns the calls of the given methods, or of all methods, oldest
+// first
+func (c *Client) Calls(methods ...string) []Call {
+	return c.calls.Calls(methods...)
+}
+
+// ResetCalls forgets the recorded calls and injected errors, keeping the
+// products
+func (c *Client) ResetCalls() {
+	c.calls.ResetCalls()
+}
+
+func (c *Client) put(p *pb.Product) *pb.Product {
+	if p.Id == "" {
+		c.nextID++
+		p.Id = fmt.Sprintf("product-%d", c.nextID)
+	}
+	if _, ok := c.products[p.Id]; !ok {
+		c.order = append(c.order, p.Id)
+	}
+	c.products[p.Id] = p
+	return proto.Clone(p).(*pb.Product)
+}
+
+func (c *Client) get(id string) (*pb.Product, error) {
+	p, ok := c.products[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.NotFound, "product %s not found", id).WithReason("PRODUCT_NOT_FOUND")
+	}
+	return p, nil
+}
+
+func (c *Client) CreateProduct(_ context.Context, req *pb.CreateProductRequest) (_ *pb.Product, err error) {
+	defer c.calls.Record("CreateProduct", req, &err)
+	if err := c.calls.Injected("CreateProduct"); err != nil {
+		return nil, err
+	}
+	if req.GetName() == "" {
+		return nil, apperrors.New(apperrors.Invalid, "product name is required")
+	}
+	now := time.Now().Unix()
+	productType := req.GetType()
+	if productType == "" {
+		productType = "physical"
+	}
+
+	var preorder *pb.PreorderInfo
+	if req.GetPreorderAllocation() != 0 {
+		preorder = &pb.PreorderInfo{Allocation: req.PreorderAllocation}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.put(&pb.Product{
+		Name:              req.Name,
+		Description:       req.Description,
+		Price:             req.Price,
+		ImageUrls:         req.ImageUrls,
+		Category:          req.Category,
+		Inventory:         req.Inventory,
+		Tags:              req.Tags,
+		Attributes:        req.Attributes,
+		Suppliers:         req.Suppliers,
+		Type:              productType,
+		Digital:           req.Digital,
+		Weight:            req.Weight,
+		Dimensions:        req.Dimensions,
+		ShippingClass:     req.ShippingClass,
+		Barcode:           req.Barcode,
+		MinOrderQuantity:  req.MinOrderQuantity,
+		MaxPerCustomer:    req.MaxPerCustomer,
+		ReleaseDate:       req.ReleaseDate,
+		Preorder:          preorder,
+		MinAge:            req.MinAge,
+		RestrictedRegions: req.RestrictedRegions,
+		CompareAtPrice:    req.CompareAtPrice,
+		GroupPrices:       req.GroupPrices,
+		Badges:            req.Badges,
+		Slug:              req.Slug,
+		MetaTitle:         req.MetaTitle,
+		MetaDescription:   req.MetaDescription,
+		Active:            true,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}), nil
+}
+
+func (c *Client) GetProduct(ctx context.Context, id string) (_ *pb.Product, err error) {
+	defer c.calls.Record("GetProduct", &pb.GetProductRequest{Id: id}, &err)
+	if err := c.calls.Injected("GetProduct"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return priced(ctx, p), nil
+}
+
+func (c *Client) GetProductsByIDs(ctx context.Context, ids []string) (_ *pb.GetProductsByIDsResponse, err error) {
+	req := &pb.GetProductsByIDsRequest{Ids: ids}
+	defer c.calls.Record("GetProductsByIDs", req, &err)
+	if err := c.calls.Injected("GetProductsByIDs"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := &pb.GetProductsByIDsResponse{}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if p, ok := c.products[id]; ok {
+			resp.Products = append(resp.Products, priced(ctx, p))
+		} else {
+			resp.NotFoundIds = append(resp.NotFoundIds, id)
+		}
+	}
+	if len(seen) > 100 {
+		return nil, apperrors.New(apperrors.Invalid, "at most 100 product IDs can be retrieved at once")
+	}
+	return resp, nil
+}
+
+// priced returns a copy of p priced for the customer group of ctx, if any
+func priced(ctx context.Context, p *pb.Product) *pb.Product {
+	p = proto.Clone(p).(*pb.Product)
+	group := strings.ToLower(product.CustomerGroupFrom(ctx))
+	if group == "" {
+		return p
+	}
+	p.CustomerGroup, p.EffectivePrice = group, p.Price
+	for _, price := range p.GroupPrices {
+		if price.Group == group {
+			p.EffectivePrice = price.Price
+		}
+	}
+	return p
+}
+
+func (c *Client) UpdateProduct(_ context.Context, req *pb.UpdateProductRequest) (_ *pb.Product, err error) {
+	defer c.calls.Record("UpdateProduct", req, &err)
+	if err := c.calls.Injected("UpdateProduct"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.get(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		p.Name = *req.Name
+	}
+	if req.Description != nil {
+		p.Description = *req.Description
+	}
+	if req.Slug != nil && *req.Slug != p.Slug {
+		if p.Slug != "" {
+			p.PreviousSlugs = append(p.PreviousSlugs, p.Slug)
+		}
+		p.Slug = *req.Slug
+	}
+	if req.MetaTitle != nil {
+		p.MetaTitle = *req.MetaTitle
+	}
+	if req.MetaDescription != nil {
+		p.MetaDescription = *req.MetaDescription
+	}
+	if req.Price != nil {
+		p.Price = *req.Price
+	}
+	if req.CompareAtPrice != nil {
+		p.CompareAtPrice = req.CompareAtPrice
+		if *req.CompareAtPrice == 0 {
+			p.CompareAtPrice = nil
+		}
+	}
+	if len(req.GroupPrices) > 0 {
+		p.GroupPrices = req.GroupPrices
+	}
+	if req.Category != nil {
+		p.Category = *req.Category
+	}
+	if req.Inventory != nil {
+		p.Inventory = req.Inventory
+	}
+	if req.Active != nil {
+		p.Active = *req.Active
+	}
+	if len(req.ImageUrls) > 0 {
+		p.ImageUrls = req.ImageUrls
+	}
+	if len(req.Tags) > 0 {
+		p.Tags = req.Tags
+	}
+	if len(req.Attributes) > 0 {
+		p.Attributes = req.Attributes
+	}
+	if req.Digital != nil {
+		p.Digital = req.Digital
+	}
+	if req.Weight != nil {
+		p.Weight = req.Weight
+	}
+	if req.Dimensions != nil {
+		p.Dimensions = req.Dimensions
+	}
+	if req.ShippingClass != nil {
+		p.ShippingClass = *req.ShippingClass
+	}
+	if req.Barcode != nil {
+		p.Barcode = *req.Barcode
+	}
+	if req.ReleaseDate != nil {
+		p.ReleaseDate = *req.ReleaseDate
+	}
+	if req.PreorderAllocation != nil {
+		p.Preorder = &pb.PreorderInfo{Allocation: *req.PreorderAllocation, Ordered: p.GetPreorder().GetOrdered()}
+	}
+	p.UpdatedAt = time.Now().Unix()
+	return proto.Clone(p).(*pb.Product), nil
+}
+
+func (c *Client) DeleteProduct(_ context.Context, id string) (err error) {
+	defer c.calls.Record("DeleteProduct", &pb.DeleteProductRequest{Id: id}, &err)
+	if err := c.calls.Injected("DeleteProduct"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.get(id); err != nil {
+		return err
+	}
+	delete(c.products, id)
+	for i, existing := range c.order {
+		if existing == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// SetFeatured features or unfeatures a product; expired features are
+// reported as not featured by ListProducts with FeaturedOnly
+func (c *Client) SetFeatured(_ context.Context, req *pb.SetFeaturedRequest) (_ *pb.Product, err error) {
+	defer c.calls.Record("SetFeatured", req, &err)
+	if err := c.calls.Injected("SetFeatured"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.get(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	p.Featured, p.FeaturedUntil = req.GetFeatured(), 0
+	if req.GetFeatured() {
+		p.FeaturedUntil = req.GetFeaturedUntil()
+	}
+	p.UpdatedAt = time.Now().Unix()
+	return proto.Clone(p).(*pb.Product), nil
+}
+
+// ValidatePurchaseEligibility checks minimum ages and restricted regions
+// like product-service. Regions are compared as given, without
+// normalizing them.
+func (c *Client) ValidatePurchaseEligibility(_ context.Context, req *pb.ValidatePurchaseEligibilityRequest) (_ *pb.ValidatePurchaseEligibilityResponse, err error) {
+	defer c.calls.Record("ValidatePurchaseEligibility", req, &err)
+	if err := c.calls.Injected("ValidatePurchaseEligibility"); err != nil {
+		return nil, err
+	}
+	customer := req.GetCustomerProfile()
+	var birthDate time.Time
+	if customer.GetBirthDate() != "" {
+		if birthDate, err = time.Parse(time.DateOnly, customer.BirthDate); err != nil {
+			return nil, apperrors.New(apperrors.Invalid, "birth_date must be a date such as 2006-01-02")
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp := &pb.ValidatePurchaseEligibilityResponse{}
+	for _, id := range req.GetProductIds() {
+		p, ok := c.products[id]
+		if reason, message := ineligibility(p, ok, birthDate, customer.GetRegion()); reason != "" {
+			resp.Ineligible = append(resp.Ineligible, &pb.IneligibleProduct{ProductId: id, Reason: reason, Message: message})
+		}
+	}
+	resp.Eligible = len(resp.Ineligible) == 0
+	return resp, nil
+}
+
+func ineligibility(p *pb.Product, found bool, birthDate time.Time, region string) (reason, message string) {
+	if !found || !p.Active {
+		return "PRODUCT_UNAVAILABLE", "the product is not available"
+	}
+	if p.MinAge > 0 {
+		if birthDate.IsZero() {
+			return "BIRTH_DATE_REQUIRED", "a birth date is required"
+		}
+		if birthDate.AddDate(int(p.MinAge), 0, 0).After(time.Now()) {
+			return "UNDERAGE", fmt.Sprintf("buyers must be at least %d", p.MinAge)
+		}
+	}
+	if len(p.RestrictedRegions) > 0 && region == "" {
+		return "REGION_REQUIRED", "a region is required"
+	}
+	country, _, hasSubdivision := strings.Cut(region, "-")
+	for _, restricted := range p.RestrictedRegions {
+		switch {
+		case restricted == region || restricted == country:
+			return "REGION_RESTRICTED", "the product cannot be sold in " + restricted
+		case !hasSubdivision && strings.HasPrefix(restricted, country+"-"):
+			return "REGION_REQUIRED", "the subdivision is required"
+		}
+	}
+	return "", ""
+}
+
+// ListProducts filters by category, tags, price, stock, feature and search
+// term (a case-insensitive substring of the name) and pages like
+// product-service, with zero-based pages in insertion order
+func (c *Client) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (_ *pb.ListProductsResponse, err error) {
+	defer c.calls.Record("ListProducts", req, &err)
+	if err := c.calls.Injected("ListProducts"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matched []*pb.Product
+	for _, id := range c.order {
+		if p := c.products[id]; matches(p, req) {
+			matched = append(matched, p)
+		}
+	}
+
+	page := pagination.New(int(req.GetPage()), int(req.GetPageSize()), pagination.Options{ZeroBasedPages: true})
+	start := min(page.Offset(), len(matched))
+	end := min(start+page.PageSize, len(matched))
+	resp := &pb.ListProductsResponse{
+		Total:      int32(len(matched)),
+		Page:       int32(page.Page),
+		PageSize:   int32(page.PageSize),
+		TotalPages: int32(pagination.TotalPages(len(matched), page.PageSize)),
+	}
+	for _, p := range matched[start:end] {
+		resp.Products = append(resp.Products, priced(ctx, p))
+	}
+	return resp, nil
+}
+
+func matches(p *pb.Product, req *pb.ListProductsRequest) bool {
+	if req.GetCategory() != "" && p.Category != req.Category {
+		return false
+	}
+	for _, tag := range req.GetTags() {
+		if !contains(p.Tags, tag) {
+			return false
+		}
+	}
+	if req.GetMinPrice() > 0 && p.Price < req.MinPrice {
+		return false
+	}
+	if req.GetMaxPrice() > 0 && p.Price > req.MaxPrice {
+		return false
+	}
+	if req.GetInStockOnly() && p.Type != "digital" && p.GetInventory().GetQuantity() <= 0 {
+		return false
+	}
+	if req.GetFeaturedOnly() && (!p.Featured || (p.FeaturedUntil != 0 && p.FeaturedUntil <= time.Now().Unix())) {
+		return false
+	}
+	if req.GetShippingClass() != "" && p.ShippingClass != req.ShippingClass {
+		return false
+	}
+	if req.GetBadge() != "" && !contains(p.Badges, req.Badge) {
+		return false
+	}
+	if term := strings.ToLower(req.GetSearchTerm()); term != "" && !strings.Contains(strings.ToLower(p.Name), term) {
+		return false
+	}
+	if req.GetSupplierId() != "" {
+		linked := false
+		for _, s := range p.Suppliers {
+			linked = linked || s.SupplierId == req.SupplierId
+		}
+		if !linked {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateInventory applies the quantity change, refusing to go below zero.
+// Purchases and reservations of products on preorder count against the
+// preorder allocation instead, and releases cancel preorders. A repeated
+// operation ID returns the first result without applying the change again.
+func (c *Client) UpdateInventory(_ context.Context, req *pb.UpdateInventoryRequest) (_ *pb.UpdateInventoryResponse, err error) {
+	defer c.calls.Record("UpdateInventory", req, &err)
+	if err := c.calls.Injected("UpdateInventory"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if resp, ok := c.operations[req.GetOperationId()]; ok && req.GetOperationId() != "" {
+		return proto.Clone(resp).(*pb.UpdateInventoryResponse), nil
+	}
+	p, err := c.get(req.GetProductId())
+	if err != nil {
+		return nil, err
+	}
+	if p.Inventory == nil {
+		p.Inventory = &pb.InventoryInfo{}
+	}
+	if preorder := p.Preorder; preorder != nil && preorderOperation(req) {
+		ordered := preorder.Ordered - req.GetQuantityChange()
+		if ordered > preorder.Allocation {
+			return nil, apperrors.New(apperrors.Conflict, "preorder allocation exhausted").WithReason("PREORDER_SOLD_OUT")
+		}
+		if ordered < 0 {
+			return nil, apperrors.New(apperrors.Invalid, "cannot cancel more preorders than were taken")
+		}
+		preorder.Ordered = ordered
+	} else {
+		quantity := p.Inventory.Quantity + req.GetQuantityChange()
+		if quantity < 0 {
+			return nil, apperrors.Newf(apperrors.Conflict, "insufficient stock for product %s", p.Id).WithReason("INSUFFICIENT_STOCK")
+		}
+		p.Inventory.Quantity = quantity
+		p.Inventory.InStock = quantity > 0
+	}
+
+	resp := &pb.UpdateInventoryResponse{Success: true, UpdatedInventory: proto.Clone(p.Inventory).(*pb.InventoryInfo)}
+	if req.GetOperationId() != "" {
+		c.operations[req.OperationId] = resp
+	}
+	c.notify(p)
+	return proto.Clone(resp).(*pb.UpdateInventoryResponse), nil
+}
+
+// preorderOperation reports whether an inventory change to a product on
+// preorder goes to its preorders, as in product-service
+func preorderOperation(req *pb.UpdateInventoryRequest) bool {
+	switch req.GetOperationType() {
+	case "purchase", "reservation":
+		return req.GetQuantityChange() < 0
+	case "release":
+		return req.GetQuantityChange() > 0
+	}
+	return false
+}
+
+func (c *Client) CheckStock(_ context.Context, productID string, quantity int32) (_ *pb.CheckStockResponse, err error) {
+	defer c.calls.Record("CheckStock", &pb.CheckStockRequest{ProductId: productID, Quantity: quantity}, &err)
+	if err := c.calls.Injected("CheckStock"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.get(productID)
+	if err != nil {
+		return nil, err
+	}
+	if quantity < p.MinOrderQuantity {
+		return nil, apperrors.Newf(apperrors.Invalid, "quantity must be at least %d", p.MinOrderQuantity).
+			WithReason("BELOW_MIN_ORDER_QUANTITY")
+	}
+	if preorder := p.Preorder; preorder != nil {
+		left := preorder.Allocation - preorder.Ordered
+		return &pb.CheckStockResponse{Available: left >= quantity, CurrentStock: left}, nil
+	}
+	// Digital products are always available, as in product-service
+	current := p.GetInventory().GetQuantity()
+	return &pb.CheckStockResponse{Available: p.Type == "digital" || current >= quantity, CurrentStock: current}, nil
+}
+
+func (c *Client) StreamProducts(_ context.Context, includeInactive bool, fn func(*pb.Product) error) (err error) {
+	defer c.calls.Record("StreamProducts", &pb.StreamProductsRequest{IncludeInactive: includeInactive}, &err)
+	if err := c.calls.Injected("StreamProducts"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	var products []*pb.Product
+	for _, id := range c.order {
+		if p := c.products[id]; p.Active || includeInactive {
+			products = append(products, proto.Clone(p).(*pb.Product))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, p := range products {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchInventory delivers the updates made through UpdateInventory while
+// it runs, and returns when ctx is done or fn fails
+func (c *Client) WatchInventory(ctx context.Context, req *pb.WatchInventoryRequest, fn func(*pb.InventoryUpdate) error) (err error) {
+	defer c.calls.Record("WatchInventory", req, &err)
+	if err := c.calls.Injected("WatchInventory"); err != nil {
+		return err
+	}
+	updates := make(chan *pb.InventoryUpdate, 64)
+	c.mu.Lock()
+	c.watchers = append(c.watchers, updates)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, w := range c.watchers {
+			if w == updates {
+				c.watchers = append(c.watchers[:i], c.watchers[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case u := <-updates:
+			if len(req.GetProductIds()) > 0 && !contains(req.ProductIds, u.ProductId) {
+				continue
+			}
+			if req.GetThreshold() > 0 && u.Inventory.Quantity >= req.Threshold {
+				continue
+			}
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// notify fans an inventory change out to the watchers; c.mu must be held
+func (c *Client) notify(p *pb.Product) {
+	for _, w := range c.watchers {
+		select {
+		case w <- &pb.InventoryUpdate{
+			ProductId:   p.Id,
+			ProductName: p.Name,
+			Inventory:   proto.Clone(p.Inventory).(*pb.InventoryInfo),
+			Timestamp:   time.Now().Unix(),
+		}:
+		default:
+		}
+	}
+}
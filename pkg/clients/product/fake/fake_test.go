@@ -0,0 +1,273 @@
+package fake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/clients/product"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListProductsFiltersAndPages(t *testing.T) {
+	ctx := context.Background()
+	client := New(
+		&pb.Product{Name: "Red Shirt", Category: "apparel", Price: 20, Active: true},
+		&pb.Product{Name: "Blue Shirt", Category: "apparel", Price: 25, Active: true},
+		&pb.Product{Name: "Mug", Category: "kitchen", Price: 8, Active: true},
+	)
+
+	resp, err := client.ListProducts(ctx, &pb.ListProductsRequest{Category: "apparel", PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Total)
+	assert.Equal(t, int32(2), resp.TotalPages)
+	require.Len(t, resp.Products, 1)
+	assert.Equal(t, "Red Shirt", resp.Products[0].Name)
+
+	resp, err = client.ListProducts(ctx, &pb.ListProductsRequest{SearchTerm: "shirt", Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Blue Shirt", resp.Products[0].Name)
+}
+
+func TestSetFeatured(t *testing.T) {
+	ctx := context.Background()
+	client := New(
+		&pb.Product{Id: "p1", Name: "Mug"},
+		&pb.Product{Id: "p2", Name: "Cup"},
+		&pb.Product{Id: "p3", Name: "Bowl"},
+	)
+
+	_, err := client.SetFeatured(ctx, &pb.SetFeaturedRequest{Id: "p1", Featured: true})
+	require.NoError(t, err)
+	_, err = client.SetFeatured(ctx, &pb.SetFeaturedRequest{Id: "p2", Featured: true, FeaturedUntil: time.Now().Add(-time.Hour).Unix()})
+	require.NoError(t, err)
+
+	resp, err := client.ListProducts(ctx, &pb.ListProductsRequest{FeaturedOnly: true})
+	require.NoError(t, err)
+	require.Len(t, resp.Products, 1)
+	assert.Equal(t, "p1", resp.Products[0].Id)
+}
+
+func TestCustomerGroupPrices(t *testing.T) {
+	client := New(
+		&pb.Product{Id: "p1", Name: "Mug", Price: 8, GroupPrices: []*pb.GroupPrice{{Group: "wholesale", Price: 6.8}}},
+		&pb.Product{Id: "p2", Name: "Cup", Price: 5},
+	)
+
+	p, err := client.GetProduct(context.Background(), "p1")
+	require.NoError(t, err)
+	assert.Empty(t, p.CustomerGroup)
+	assert.Zero(t, p.EffectivePrice)
+
+	ctx := product.WithCustomerGroup(context.Background(), "wholesale")
+	resp, err := client.ListProducts(ctx, &pb.ListProductsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Products, 2)
+	assert.Equal(t, "wholesale", resp.Products[0].CustomerGroup)
+	assert.Equal(t, 6.8, resp.Products[0].EffectivePrice)
+	assert.Equal(t, 5.0, resp.Products[1].EffectivePrice)
+}
+
+func TestDigitalProductsAreAlwaysInStock(t *testing.T) {
+	ctx := context.Background()
+	client := New()
+	p, err := client.CreateProduct(ctx, &pb.CreateProductRequest{
+		Name:    "Go Book",
+		Type:    "digital",
+		Digital: &pb.DigitalInfo{Assets: []*pb.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}}},
+	})
+	require.NoError(t, err)
+
+	stock, err := client.CheckStock(ctx, p.Id, 100)
+	require.NoError(t, err)
+	assert.True(t, stock.Available)
+}
+
+func TestGetProductsByIDs(t *testing.T) {
+	client := New(
+		&pb.Product{Id: "p1", Weight: &pb.Weight{Value: 0.35, Unit: "kg"}},
+		&pb.Product{Id: "p2", Dimensions: &pb.Dimensions{Length: 30, Width: 20, Height: 10, Unit: "cm"}},
+	)
+
+	resp, err := client.GetProductsByIDs(context.Background(), []string{"p2", "missing", "p1", "p2"})
+	require.NoError(t, err)
+	require.Len(t, resp.Products, 2)
+	assert.Equal(t, "p2", resp.Products[0].Id)
+	assert.Equal(t, 30.0, resp.Products[0].Dimensions.Length)
+	assert.Equal(t, 0.35, resp.Products[1].Weight.Value)
+	assert.Equal(t, []string{"missing"}, resp.NotFoundIds)
+}
+
+func TestListProductsByShippingClass(t *testing.T) {
+	ctx := context.Background()
+	client := New(
+		&pb.Product{Id: "p1", ShippingClass: "bulky"},
+		&pb.Product{Id: "p2", ShippingClass: "standard"},
+		&pb.Product{Id: "p3"},
+	)
+
+	resp, err := client.ListProducts(ctx, &pb.ListProductsRequest{ShippingClass: "bulky"})
+	require.NoError(t, err)
+	require.Len(t, resp.Products, 1)
+	assert.Equal(t, "p1", resp.Products[0].Id)
+}
+
+func TestListProductsByBadge(t *testing.T) {
+	ctx := context.Background()
+	client := New(
+		&pb.Product{Id: "p1", Badges: []string{"eco", "bestseller"}},
+		&pb.Product{Id: "p2", Badges: []string{"bestseller"}},
+		&pb.Product{Id: "p3"},
+	)
+
+	resp, err := client.ListProducts(ctx, &pb.ListProductsRequest{Badge: "eco"})
+	require.NoError(t, err)
+	require.Len(t, resp.Products, 1)
+	assert.Equal(t, "p1", resp.Products[0].Id)
+}
+
+func TestCheckStockEnforcesMinimumOrderQuantity(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.Product{Id: "p1", Inventory: &pb.InventoryInfo{Quantity: 50}, MinOrderQuantity: 6})
+
+	_, err := client.CheckStock(ctx, "p1", 5)
+	assert.Equal(t, "BELOW_MIN_ORDER_QUANTITY", apperrors.ReasonOf(err))
+
+	stock, err := client.CheckStock(ctx, "p1", 6)
+	require.NoError(t, err)
+	assert.True(t, stock.Available)
+}
+
+func TestUpdateInventory(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.Product{Id: "p1", Inventory: &pb.InventoryInfo{Quantity: 5}})
+
+	req := &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -2, OperationId: "order-1"}
+	_, err := client.UpdateInventory(ctx, req)
+	require.NoError(t, err)
+	_, err = client.UpdateInventory(ctx, req)
+	require.NoError(t, err)
+
+	stock, err := client.CheckStock(ctx, "p1", 3)
+	require.NoError(t, err)
+	assert.True(t, stock.Available)
+	assert.Equal(t, int32(3), stock.CurrentStock, "a repeated operation is applied once")
+
+	_, err = client.UpdateInventory(ctx, &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -10})
+	assert.True(t, apperrors.Is(err, apperrors.Conflict))
+
+	_, err = client.CheckStock(ctx, "missing", 1)
+	assert.True(t, apperrors.Is(err, apperrors.NotFound))
+}
+
+func TestPreorderPurchasesLeaveStockAlone(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.Product{Id: "p1", Inventory: &pb.InventoryInfo{}, Preorder: &pb.PreorderInfo{Allocation: 3}})
+
+	purchase := &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -2, OperationType: "purchase"}
+	resp, err := client.UpdateInventory(ctx, purchase)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), resp.UpdatedInventory.Quantity)
+
+	stock, err := client.CheckStock(ctx, "p1", 2)
+	require.NoError(t, err)
+	assert.False(t, stock.Available)
+	assert.Equal(t, int32(1), stock.CurrentStock)
+
+	_, err = client.UpdateInventory(ctx, purchase)
+	assert.Equal(t, "PREORDER_SOLD_OUT", apperrors.ReasonOf(err))
+}
+
+func TestValidatePurchaseEligibility(t *testing.T) {
+	ctx := context.Background()
+	client := New(
+		&pb.Product{Id: "wine", Active: true, MinAge: 18, RestrictedRegions: []string{"US-UT"}},
+		&pb.Product{Id: "mug", Active: true},
+	)
+
+	resp, err := client.ValidatePurchaseEligibility(ctx, &pb.ValidatePurchaseEligibilityRequest{
+		ProductIds:      []string{"wine", "mug"},
+		CustomerProfile: &pb.CustomerProfile{BirthDate: "1990-01-01", Region: "US-CA"},
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Eligible)
+
+	resp, err = client.ValidatePurchaseEligibility(ctx, &pb.ValidatePurchaseEligibilityRequest{
+		ProductIds:      []string{"wine", "missing"},
+		CustomerProfile: &pb.CustomerProfile{Region: "US-UT"},
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Eligible)
+	if assert.Len(t, resp.Ineligible, 2) {
+		assert.Equal(t, "BIRTH_DATE_REQUIRED", resp.Ineligible[0].Reason)
+		assert.Equal(t, "PRODUCT_UNAVAILABLE", resp.Ineligible[1].Reason)
+	}
+}
+
+func TestWatchInventory(t *testing.T) {
+	client := New(&pb.Product{Id: "p1", Inventory: &pb.InventoryInfo{Quantity: 5}})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	got := make(chan *pb.InventoryUpdate, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WatchInventory(ctx, &pb.WatchInventoryRequest{ProductIds: []string{"p1"}}, func(u *pb.InventoryUpdate) error {
+			got <- u
+			cancel()
+			return nil
+		})
+	}()
+
+	assert.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return len(client.watchers) == 1
+	}, time.Second, 5*time.Millisecond)
+	_, err := client.UpdateInventory(context.Background(), &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(6), (<-got).Inventory.Quantity)
+	assert.NoError(t, <-done)
+}
+
+func TestInjectedErrorsAndRecordedCalls(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.Product{Id: "p1", Inventory: &pb.InventoryInfo{Quantity: 5}})
+	unavailable := apperrors.New(apperrors.Unavailable, "product-service is down")
+
+	client.FailNext("UpdateInventory", unavailable)
+	req := &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -2, OperationId: "order-1"}
+	_, err := client.UpdateInventory(ctx, req)
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	_, err = client.UpdateInventory(ctx, req)
+	require.NoError(t, err, "only the next call fails")
+
+	stock, err := client.CheckStock(ctx, "p1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), stock.CurrentStock, "a failed call does not change stock")
+
+	client.Fail("GetProduct", unavailable)
+	for range 2 {
+		_, err = client.GetProduct(ctx, "p1")
+		assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	}
+	client.Fail("GetProduct", nil)
+	_, err = client.GetProduct(ctx, "p1")
+	require.NoError(t, err)
+
+	calls := client.Calls("UpdateInventory")
+	require.Len(t, calls, 2)
+	assert.Equal(t, unavailable, calls[0].Err)
+	assert.NoError(t, calls[1].Err)
+	assert.Equal(t, "order-1", calls[1].Request.(*pb.UpdateInventoryRequest).OperationId)
+	assert.Len(t, client.Calls("GetProduct"), 3)
+	assert.Equal(t, int32(1), client.Calls("CheckStock")[0].Request.(*pb.CheckStockRequest).Quantity)
+	assert.Len(t, client.Calls(), 6)
+
+	client.ResetCalls()
+	assert.Empty(t, client.Calls())
+}
@@ -0,0 +1,204 @@
+// Package product is the Go SDK for product-service. It wraps the generated
+// gRPC stub with per-call timeouts, retries of idempotent methods and
+// errors from pkg/apperrors. Consumers depend on the Client interface and
+// use package fake in tests.
+package product
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/bekbull/online-shop/pkg/clients"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CustomerGroupMetadata is the metadata key GetProduct and ListProducts
+// read the customer group products are priced for from
+const CustomerGroupMetadata = "x-customer-group"
+
+// WithCustomerGroup returns a context whose calls have products priced for
+// the customer group, such as wholesale: they carry its effective_price
+func WithCustomerGroup(ctx context.Context, group string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, CustomerGroupMetadata, group)
+}
+
+// CustomerGroupFrom returns the customer group set on ctx by
+// WithCustomerGroup, or ""
+func CustomerGroupFrom(ctx context.Context) string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if values := md.Get(CustomerGroupMetadata); len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+// Client is the product-service API
+type Client interface {
+	CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error)
+	GetProduct(ctx context.Context, id string) (*pb.Product, error)
+	// GetProductsByIDs returns at most 100 products in one call, such as
+	// those of a cart, in the order asked for, and the IDs not found
+	GetProductsByIDs(ctx context.Context, ids []string) (*pb.GetProductsByIDsResponse, error)
+	UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error)
+	SetFeatured(ctx context.Context, req *pb.SetFeaturedRequest) (*pb.Product, error)
+	// ValidatePurchaseEligibility reports the products of an order the
+	// customer may not buy because of their age or region
+	ValidatePurchaseEligibility(ctx context.Context, req *pb.ValidatePurchaseEligibilityRequest) (*pb.ValidatePurchaseEligibilityResponse, error)
+
+	// UpdateInventory is retried only when the request carries an
+	// operation ID, which makes repeating it safe
+	UpdateInventory(ctx context.Context, req *pb.UpdateInventoryRequest) (*pb.UpdateInventoryResponse, error)
+	CheckStock(ctx context.Context, productID string, quantity int32) (*pb.CheckStockResponse, error)
+
+	// StreamProducts calls fn for every product in the catalog until the
+	// stream ends or fn returns an error
+	StreamProducts(ctx context.Context, includeInactive bool, fn func(*pb.Product) error) error
+	// WatchInventory calls fn for every inventory update until ctx is done
+	// or fn returns an error
+	WatchInventory(ctx context.Context, req *pb.WatchInventoryRequest, fn func(*pb.InventoryUpdate) error) error
+}
+
+// Methods of product-service and how they may be called. Only GetProduct
+// is hedged: it sits on the latency-critical path of cart and checkout.
+var (
+	createProduct = clients.Method{Name: "CreateProduct"}
+	getProduct    = clients.Method{Name: "GetProduct", Idempotent: true, Hedged: true}
+	getProducts   = clients.Method{Name: "GetProductsByIDs", Idempotent: true}
+	updateProduct = clients.Method{Name: "UpdateProduct"}
+	// Not retried: a repeat after a lost response would report NotFound
+	deleteProduct  = clients.Method{Name: "DeleteProduct"}
+	listProducts   = clients.Method{Name: "ListProducts", Idempotent: true}
+	setFeatured    = clients.Method{Name: "SetFeatured", Idempotent: true}
+	checkStock     = clients.Method{Name: "CheckStock", Idempotent: true}
+	eligibility    = clients.Method{Name: "ValidatePurchaseEligibility", Idempotent: true}
+	streamProducts = "StreamProducts"
+	watchInventory = "WatchInventory"
+)
+
+// GRPCClient implements Client over a gRPC connection
+type GRPCClient struct {
+	client  pb.ProductServiceClient
+	invoker *clients.Invoker
+}
+
+var _ Client = (*GRPCClient)(nil)
+
+// New creates a client using conn, which the caller keeps ownership of
+func New(conn grpc.ClientConnInterface, opts clients.Options) *GRPCClient {
+	return &GRPCClient{
+		client:  pb.NewProductServiceClient(conn),
+		invoker: clients.NewInvoker("product", opts),
+	}
+}
+
+// Invoker exposes the client's invoker, e.g. to inspect breaker states
+func (c *GRPCClient) Invoker() *clients.Invoker {
+	return c.invoker
+}
+
+func (c *GRPCClient) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	resp, err := clients.Invoke(ctx, c.invoker, createProduct, func(ctx context.Context) (*pb.ProductResponse, error) {
+		return c.client.CreateProduct(ctx, req)
+	})
+	return resp.GetProduct(), err
+}
+
+func (c *GRPCClient) GetProduct(ctx context.Context, id string) (*pb.Product, error) {
+	resp, err := clients.Invoke(ctx, c.invoker, getProduct, func(ctx context.Context) (*pb.ProductResponse, error) {
+		return c.client.GetProduct(ctx, &pb.GetProductRequest{Id: id})
+	})
+	return resp.GetProduct(), err
+}
+
+func (c *GRPCClient) GetProductsByIDs(ctx context.Context, ids []string) (*pb.GetProductsByIDsResponse, error) {
+	return clients.Invoke(ctx, c.invoker, getProducts, func(ctx context.Context) (*pb.GetProductsByIDsResponse, error) {
+		return c.client.GetProductsByIDs(ctx, &pb.GetProductsByIDsRequest{Ids: ids})
+	})
+}
+
+func (c *GRPCClient) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	resp, err := clients.Invoke(ctx, c.invoker, updateProduct, func(ctx context.Context) (*pb.ProductResponse, error) {
+		return c.client.UpdateProduct(ctx, req)
+	})
+	return resp.GetProduct(), err
+}
+
+func (c *GRPCClient) DeleteProduct(ctx context.Context, id string) error {
+	_, err := clients.Invoke(ctx, c.invoker, deleteProduct, func(ctx context.Context) (*pb.DeleteProductResponse, error) {
+		return c.client.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: id})
+	})
+	return err
+}
+
+func (c *GRPCClient) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	return clients.Invoke(ctx, c.invoker, listProducts, func(ctx context.Context) (*pb.ListProductsResponse, error) {
+		return c.client.ListProducts(ctx, req)
+	})
+}
+
+func (c *GRPCClient) SetFeatured(ctx context.Context, req *pb.SetFeaturedRequest) (*pb.Product, error) {
+	resp, err := clients.Invoke(ctx, c.invoker, setFeatured, func(ctx context.Context) (*pb.ProductResponse, error) {
+		return c.client.SetFeatured(ctx, req)
+	})
+	return resp.GetProduct(), err
+}
+
+func (c *GRPCClient) ValidatePurchaseEligibility(ctx context.Context, req *pb.ValidatePurchaseEligibilityRequest) (*pb.ValidatePurchaseEligibilityResponse, error) {
+	return clients.Invoke(ctx, c.invoker, eligibility, func(ctx context.Context) (*pb.ValidatePurchaseEligibilityResponse, error) {
+		return c.client.ValidatePurchaseEligibility(ctx, req)
+	})
+}
+
+func (c *GRPCClient) UpdateInventory(ctx context.Context, req *pb.UpdateInventoryRequest) (*pb.UpdateInventoryResponse, error) {
+	method := clients.Method{Name: "UpdateInventory", Idempotent: req.GetOperationId() != ""}
+	return clients.Invoke(ctx, c.invoker, method, func(ctx context.Context) (*pb.UpdateInventoryResponse, error) {
+		return c.client.UpdateInventory(ctx, req)
+	})
+}
+
+func (c *GRPCClient) CheckStock(ctx context.Context, productID string, quantity int32) (*pb.CheckStockResponse, error) {
+	return clients.Invoke(ctx, c.invoker, checkStock, func(ctx context.Context) (*pb.CheckStockResponse, error) {
+		return c.client.CheckStock(ctx, &pb.CheckStockRequest{ProductId: productID, Quantity: quantity})
+	})
+}
+
+func (c *GRPCClient) StreamProducts(ctx context.Context, includeInactive bool, fn func(*pb.Product) error) error {
+	return c.invoker.Stream(ctx, streamProducts, func(ctx context.Context) error {
+		stream, err := c.client.StreamProducts(ctx, &pb.StreamProductsRequest{IncludeInactive: includeInactive})
+		if err != nil {
+			return err
+		}
+		return receive(stream.Recv, fn)
+	})
+}
+
+func (c *GRPCClient) WatchInventory(ctx context.Context, req *pb.WatchInventoryRequest, fn func(*pb.InventoryUpdate) error) error {
+	return c.invoker.Stream(ctx, watchInventory, func(ctx context.Context) error {
+		stream, err := c.client.WatchInventory(ctx, req)
+		if err != nil {
+			return err
+		}
+		return receive(stream.Recv, fn)
+	})
+}
+
+// receive feeds every message of a server stream to fn
+func receive[T any](recv func() (*T, error), fn func(*T) error) error {
+	for {
+		msg, err := recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+}
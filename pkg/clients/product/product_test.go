@@ -0,0 +1,174 @@
+package product
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/clients"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// flakyServer fails the first failures calls of each method with
+// Unavailable and counts every call
+type flakyServer struct {
+	pb.UnimplementedProductServiceServer
+	failures int
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (s *flakyServer) attempt(method string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+	if s.calls[method] <= s.failures {
+		return status.Error(codes.Unavailable, "try again")
+	}
+	return nil
+}
+
+func (s *flakyServer) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+func (s *flakyServer) GetProduct(_ context.Context, req *pb.GetProductRequest) (*pb.ProductResponse, error) {
+	if err := s.attempt("GetProduct"); err != nil {
+		return nil, err
+	}
+	if req.Id != "p1" {
+		return nil, apperrors.ToGRPC(apperrors.Newf(apperrors.NotFound, "product %s not found", req.Id))
+	}
+	return &pb.ProductResponse{Product: &pb.Product{Id: "p1", Name: "Widget"}}, nil
+}
+
+func (s *flakyServer) GetProductsByIDs(_ context.Context, req *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error) {
+	if err := s.attempt("GetProductsByIDs"); err != nil {
+		return nil, err
+	}
+	resp := &pb.GetProductsByIDsResponse{}
+	for _, id := range req.Ids {
+		resp.Products = append(resp.Products, &pb.Product{Id: id})
+	}
+	return resp, nil
+}
+
+func (s *flakyServer) CreateProduct(context.Context, *pb.CreateProductRequest) (*pb.ProductResponse, error) {
+	if err := s.attempt("CreateProduct"); err != nil {
+		return nil, err
+	}
+	return &pb.ProductResponse{Product: &pb.Product{Id: "p2"}}, nil
+}
+
+func (s *flakyServer) UpdateInventory(context.Context, *pb.UpdateInventoryRequest) (*pb.UpdateInventoryResponse, error) {
+	if err := s.attempt("UpdateInventory"); err != nil {
+		return nil, err
+	}
+	return &pb.UpdateInventoryResponse{Success: true}, nil
+}
+
+func (s *flakyServer) StreamProducts(_ *pb.StreamProductsRequest, stream pb.ProductService_StreamProductsServer) error {
+	for _, id := range []string{"p1", "p2", "p3"} {
+		if err := stream.Send(&pb.Product{Id: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestClient(t *testing.T, failures int) (*GRPCClient, *flakyServer) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	impl := &flakyServer{failures: failures, calls: map[string]int{}}
+	pb.RegisterProductServiceServer(srv, impl)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return New(conn, clients.Options{
+		Timeout: time.Second,
+		Retry:   clients.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}), impl
+}
+
+func TestGetProductRetriesTransientErrors(t *testing.T) {
+	client, server := newTestClient(t, 2)
+
+	p, err := client.GetProduct(context.Background(), "p1")
+	require.NoError(t, err)
+	assert.Equal(t, "Widget", p.Name)
+	assert.Equal(t, 3, server.count("GetProduct"))
+}
+
+func TestGetProductTranslatesErrors(t *testing.T) {
+	client, _ := newTestClient(t, 0)
+
+	_, err := client.GetProduct(context.Background(), "missing")
+	assert.True(t, apperrors.Is(err, apperrors.NotFound))
+}
+
+func TestGetProductsByIDsRetriesTransientErrors(t *testing.T) {
+	client, server := newTestClient(t, 1)
+
+	resp, err := client.GetProductsByIDs(context.Background(), []string{"p1", "p2"})
+	require.NoError(t, err)
+	assert.Len(t, resp.Products, 2)
+	assert.Equal(t, 2, server.count("GetProductsByIDs"))
+}
+
+func TestCreateProductIsNotRetried(t *testing.T) {
+	client, server := newTestClient(t, 1)
+
+	_, err := client.CreateProduct(context.Background(), &pb.CreateProductRequest{Name: "Widget"})
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	assert.Equal(t, 1, server.count("CreateProduct"))
+}
+
+func TestUpdateInventoryRetriedOnlyWithOperationID(t *testing.T) {
+	client, server := newTestClient(t, 1)
+
+	_, err := client.UpdateInventory(context.Background(), &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -1})
+	assert.Error(t, err)
+	assert.Equal(t, 1, server.count("UpdateInventory"))
+
+	client, server = newTestClient(t, 1)
+	resp, err := client.UpdateInventory(context.Background(), &pb.UpdateInventoryRequest{ProductId: "p1", QuantityChange: -1, OperationId: "order-7"})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, 2, server.count("UpdateInventory"))
+}
+
+func TestStreamProducts(t *testing.T) {
+	client, _ := newTestClient(t, 0)
+
+	var ids []string
+	err := client.StreamProducts(context.Background(), false, func(p *pb.Product) error {
+		ids = append(ids, p.Id)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1", "p2", "p3"}, ids)
+
+	stop := apperrors.New(apperrors.Conflict, "stop")
+	err = client.StreamProducts(context.Background(), false, func(*pb.Product) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
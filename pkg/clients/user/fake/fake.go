@@ -0,0 +1,369 @@
+// Package fake provides an in-memory user.Client for tests of services
+// that call the user service
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/clients/internal/fakecall"
+	"github.com/bekbull/online-shop/pkg/clients/user"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Client keeps users in memory and behaves like the user service for the
+// cases services depend on: lookups by ID and email, unique emails,
+// filtering, paging and notification consent with the service's defaults. It records every call, and FailNext and Fail make
+// methods return errors before touching state. Methods are named as in the
+// service's proto, e.g. "GetUser".
+type Client struct {
+	calls fakecall.Recorder
+
+	mu     sync.Mutex
+	users  map[string]*pb.UserResponse
+	order  []string
+	nextID int
+	// consent holds the preferences set, by user ID and channel/category
+	consent map[string]map[string]bool
+}
+
+var _ user.Client = (*Client)(nil)
+
+// Call is a recorded call: the method name, the request message the SDK
+// would have sent and the error returned
+type Call = fakecall.Call
+
+// New creates a fake holding copies of users. Users without an ID get one.
+func New(users ...*pb.UserResponse) *Client {
+	c := &Client{users: make(map[string]*pb.UserResponse), consent: make(map[string]map[string]bool)}
+	for _, u := range users {
+		c.Put(u)
+	}
+	return c
+}
+
+// Put stores a copy of u, replacing any user with the same ID
+func (c *Client) Put(u *pb.UserResponse) *pb.UserResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.put(proto.Clone(u).(*pb.UserResponse))
+}
+
+// FailNext makes the next calls of method return errs, one call per error
+func (c *Client) FailNext(method string, errs ...error) {
+	c.calls.FailNext(method, errs...)
+}
+
+// Fail makes every call of method return err, until called with nil
+func (c *Client) Fail(method string, err error) {
+	c.calls.Fail(method, err)
+}
+
+// Calls returns the calls of the given methods, or of all methods, oldest
+// first
+func (c *Client) Calls(methods ...string) []Call {
+	return c.calls.Calls(methods...)
+}
+
+// ResetCalls forgets the recorded calls and injected errors, keeping the
+// users
+func (c *Client) ResetCalls() {
+	c.calls.ResetCalls()
+}
+
+func (c *Client) put(u *pb.UserResponse) *pb.UserResponse {
+	if u.Id == "" {
+		c.nextID++
+		u.Id = fmt.Sprintf("user-%d", c.nextID)
+	}
+	if _, ok := c.users[u.Id]; !ok {
+		c.order = append(c.order, u.Id)
+	}
+	c.users[u.Id] = u
+	return proto.Clone(u).(*pb.UserResponse)
+}
+
+func (c *Client) get(id string) (*pb.UserResponse, error) {
+	u, ok := c.users[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.NotFound, "user %s not found", id).WithReason("USER_NOT_FOUND")
+	}
+	return u, nil
+}
+
+func (c *Client) emailTaken(email, exceptID string) bool {
+	for id, u := range c.users {
+		if id != exceptID && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) CreateUser(_ context.Context, req *pb.CreateUserRequest) (_ *pb.UserResponse, err error) {
+	defer c.calls.Record("CreateUser", req, &err)
+	if err := c.calls.Injected("CreateUser"); err != nil {
+		return nil, err
+	}
+	if req.GetEmail() == "" {
+		return nil, apperrors.New(apperrors.Invalid, "email is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.emailTaken(req.Email, "") {
+		return nil, apperrors.Newf(apperrors.Conflict, "user with email %s already exists", req.Email).WithReason("EMAIL_TAKEN")
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	return c.put(&pb.UserResponse{
+		Email:         req.Email,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Roles:         req.Roles,
+		CustomerGroup: "retail",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}), nil
+}
+
+func (c *Client) GetUser(_ context.Context, id string) (_ *pb.UserResponse, err error) {
+	defer c.calls.Record("GetUser", &pb.GetUserRequest{Id: id}, &err)
+	if err := c.calls.Injected("GetUser"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(u).(*pb.UserResponse), nil
+}
+
+func (c *Client) GetUserByEmail(_ context.Context, email string) (_ *pb.UserResponse, err error) {
+	defer c.calls.Record("GetUserByEmail", &pb.GetUserByEmailRequest{Email: email}, &err)
+	if err := c.calls.Injected("GetUserByEmail"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.order {
+		if u := c.users[id]; strings.EqualFold(u.Email, email) {
+			return proto.Clone(u).(*pb.UserResponse), nil
+		}
+	}
+	return nil, apperrors.Newf(apperrors.NotFound, "user with email %s not found", email).WithReason("USER_NOT_FOUND")
+}
+
+func (c *Client) GetUserByHandle(_ context.Context, handle string) (_ *pb.UserResponse, err error) {
+	defer c.calls.Record("GetUserByHandle", &pb.GetUserByHandleRequest{Handle: handle}, &err)
+	if err := c.calls.Injected("GetUserByHandle"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.order {
+		if u := c.users[id]; u.Handle != "" && strings.EqualFold(u.Handle, handle) {
+			return proto.Clone(u).(*pb.UserResponse), nil
+		}
+	}
+	return nil, apperrors.Newf(apperrors.NotFound, "user with handle %s not found", handle).WithReason("USER_NOT_FOUND")
+}
+
+func (c *Client) UpdateUser(_ context.Context, req *pb.UpdateUserRequest) (_ *pb.UserResponse, err error) {
+	defer c.calls.Record("UpdateUser", req, &err)
+	if err := c.calls.Injected("UpdateUser"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.get(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		if c.emailTaken(*req.Email, u.Id) {
+			return nil, apperrors.Newf(apperrors.Conflict, "user with email %s already exists", *req.Email).WithReason("EMAIL_TAKEN")
+		}
+		u.Email = *req.Email
+	}
+	if req.Handle != nil {
+		handle := strings.ToLower(*req.Handle)
+		for id, other := range c.users {
+			if id != u.Id && handle != "" && other.Handle == handle {
+				return nil, apperrors.Newf(apperrors.Conflict, "handle %s is taken", handle).WithReason("HANDLE_TAKEN")
+			}
+		}
+		u.Handle = handle
+	}
+	if req.FirstName != nil {
+		u.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		u.LastName = *req.LastName
+	}
+	if len(req.Roles) > 0 {
+		u.Roles = req.Roles
+	}
+	if req.CustomerGroup != nil {
+		u.CustomerGroup = *req.CustomerGroup
+	}
+	u.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return proto.Clone(u).(*pb.UserResponse), nil
+}
+
+func (c *Client) DeleteUser(_ context.Context, id string) (err error) {
+	defer c.calls.Record("DeleteUser", &pb.DeleteUserRequest{Id: id}, &err)
+	if err := c.calls.Injected("DeleteUser"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.get(id); err != nil {
+		return err
+	}
+	delete(c.users, id)
+	delete(c.consent, id)
+	for i, existing := range c.order {
+		if existing == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ListUsers filters by a case-insensitive email substring and pages like
+// the user service, with pages starting at 1 in insertion order
+func (c *Client) ListUsers(_ context.Context, req *pb.ListUsersRequest) (_ *pb.ListUsersResponse, err error) {
+	defer c.calls.Record("ListUsers", req, &err)
+	if err := c.calls.Injected("ListUsers"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	filter := strings.ToLower(req.GetEmailFilter())
+	var matched []*pb.UserResponse
+	for _, id := range c.order {
+		if u := c.users[id]; strings.Contains(strings.ToLower(u.Email), filter) {
+			matched = append(matched, u)
+		}
+	}
+
+	page := pagination.New(int(req.GetPage()), int(req.GetPageSize()), pagination.Options{DefaultPageSize: 10})
+	start := min(page.Offset(), len(matched))
+	end := min(start+page.PageSize, len(matched))
+	resp := &pb.ListUsersResponse{
+		TotalCount: int32(len(matched)),
+		Page:       int32(page.Page),
+		PageSize:   int32(page.PageSize),
+	}
+	for _, u := range matched[start:end] {
+		resp.Users = append(resp.Users, proto.Clone(u).(*pb.UserResponse))
+	}
+	return resp, nil
+}
+
+// Notification channels and categories, as in the user service
+var (
+	channels   = []string{"email", "sms", "push"}
+	categories = []string{"order_updates", "marketing", "price_alerts"}
+)
+
+// preferences returns every preference of a user, with the user service's
+// defaults: order updates by email and push only
+func (c *Client) preferences(userID string) *pb.NotificationPreferencesResponse {
+	resp := &pb.NotificationPreferencesResponse{}
+	for _, channel := range channels {
+		for _, category := range categories {
+			enabled, set := c.consent[userID][channel+"/"+category]
+			if !set {
+				enabled = category == "order_updates" && channel != "sms"
+			}
+			resp.Preferences = append(resp.Preferences, &pb.NotificationPreference{
+				Channel: channel, Category: category, Enabled: enabled, Default: !set,
+			})
+		}
+	}
+	return resp
+}
+
+func validConsentKey(channel, category string) error {
+	for _, known := range channels {
+		if channel == known {
+			for _, known := range categories {
+				if category == known {
+					return nil
+				}
+			}
+			return apperrors.Newf(apperrors.Invalid, "unknown notification category %q", category)
+		}
+	}
+	return apperrors.Newf(apperrors.Invalid, "unknown notification channel %q", channel)
+}
+
+func (c *Client) GetNotificationPreferences(_ context.Context, userID string) (_ *pb.NotificationPreferencesResponse, err error) {
+	defer c.calls.Record("GetNotificationPreferences", &pb.GetNotificationPreferencesRequest{UserId: userID}, &err)
+	if err := c.calls.Injected("GetNotificationPreferences"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.get(userID); err != nil {
+		return nil, err
+	}
+	return c.preferences(userID), nil
+}
+
+func (c *Client) UpdateNotificationPreferences(_ context.Context, req *pb.UpdateNotificationPreferencesRequest) (_ *pb.NotificationPreferencesResponse, err error) {
+	defer c.calls.Record("UpdateNotificationPreferences", req, &err)
+	if err := c.calls.Injected("UpdateNotificationPreferences"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.get(req.GetUserId()); err != nil {
+		return nil, err
+	}
+	for _, pref := range req.GetPreferences() {
+		if err := validConsentKey(pref.Channel, pref.Category); err != nil {
+			return nil, err
+		}
+	}
+	if c.consent[req.UserId] == nil {
+		c.consent[req.UserId] = make(map[string]bool)
+	}
+	for _, pref := range req.Preferences {
+		c.consent[req.UserId][pref.Channel+"/"+pref.Category] = pref.Enabled
+	}
+	return c.preferences(req.UserId), nil
+}
+
+func (c *Client) CheckConsent(_ context.Context, userID, channel, category string) (_ bool, err error) {
+	defer c.calls.Record("CheckConsent", &pb.CheckConsentRequest{UserId: userID, Channel: channel, Category: category}, &err)
+	if err := c.calls.Injected("CheckConsent"); err != nil {
+		return false, err
+	}
+	if err := validConsentKey(channel, category); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.get(userID); err != nil {
+		return false, err
+	}
+	for _, pref := range c.preferences(userID).Preferences {
+		if pref.Channel == channel && pref.Category == category {
+			return pref.Enabled, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,91 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCreateAndLookUpUsers(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.UserResponse{Email: "ann@example.com", Roles: []string{"admin"}})
+
+	created, err := client.CreateUser(ctx, &pb.CreateUserRequest{Email: "bob@example.com", FirstName: "Bob"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Id)
+
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{Email: "ANN@example.com"})
+	assert.True(t, apperrors.Is(err, apperrors.Conflict))
+
+	found, err := client.GetUserByEmail(ctx, "bob@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, found.Id)
+
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{Id: created.Id, Handle: proto.String("Bob")})
+	require.NoError(t, err)
+	found, err = client.GetUserByHandle(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, found.Id)
+
+	require.NoError(t, client.DeleteUser(ctx, created.Id))
+	_, err = client.GetUser(ctx, created.Id)
+	assert.True(t, apperrors.Is(err, apperrors.NotFound))
+}
+
+func TestListUsers(t *testing.T) {
+	client := New(
+		&pb.UserResponse{Email: "ann@shop.test"},
+		&pb.UserResponse{Email: "bob@shop.test"},
+		&pb.UserResponse{Email: "eve@other.test"},
+	)
+
+	resp, err := client.ListUsers(context.Background(), &pb.ListUsersRequest{EmailFilter: "shop", Page: 2, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.TotalCount)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, "bob@shop.test", resp.Users[0].Email)
+}
+
+func TestCheckConsent(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.UserResponse{Id: "u1", Email: "ann@shop.test"})
+
+	allowed, err := client.CheckConsent(ctx, "u1", "email", "order_updates")
+	require.NoError(t, err)
+	assert.True(t, allowed, "order updates are emailed by default")
+
+	_, err = client.UpdateNotificationPreferences(ctx, &pb.UpdateNotificationPreferencesRequest{
+		UserId:      "u1",
+		Preferences: []*pb.NotificationPreference{{Channel: "email", Category: "order_updates", Enabled: false}},
+	})
+	require.NoError(t, err)
+	allowed, err = client.CheckConsent(ctx, "u1", "email", "order_updates")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = client.CheckConsent(ctx, "u1", "fax", "marketing")
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestInjectedErrorsAndRecordedCalls(t *testing.T) {
+	ctx := context.Background()
+	client := New(&pb.UserResponse{Id: "u1", Email: "ann@example.com"})
+
+	client.FailNext("GetUser", apperrors.New(apperrors.Unavailable, "user service is down"), nil)
+	_, err := client.GetUser(ctx, "u1")
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+	_, err = client.GetUser(ctx, "u1")
+	require.NoError(t, err)
+
+	calls := client.Calls("GetUser")
+	require.Len(t, calls, 2)
+	assert.Equal(t, "u1", calls[0].Request.(*pb.GetUserRequest).Id)
+	assert.Error(t, calls[0].Err)
+	assert.NoError(t, calls[1].Err)
+	assert.Empty(t, client.Calls("CreateUser"))
+}
@@ -0,0 +1,134 @@
+// Package user is the Go SDK for the user service. It wraps the generated
+// gRPC stub with per-call timeouts, retries of idempotent methods and
+// errors from pkg/apperrors. Consumers depend on the Client interface and
+// use package fake in tests.
+package user
+
+import (
+	"context"
+
+	"github.com/bekbull/online-shop/pkg/clients"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
+	"google.golang.org/grpc"
+)
+
+// Client is the user service API
+type Client interface {
+	CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error)
+	GetUser(ctx context.Context, id string) (*pb.UserResponse, error)
+	GetUserByEmail(ctx context.Context, email string) (*pb.UserResponse, error)
+	// GetUserByHandle finds a user by the public handle shown instead of
+	// their email, e.g. on reviews
+	GetUserByHandle(ctx context.Context, handle string) (*pb.UserResponse, error)
+	UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*pb.NotificationPreferencesResponse, error)
+	UpdateNotificationPreferences(ctx context.Context, req *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferencesResponse, error)
+	// CheckConsent reports whether the user gets notifications of a
+	// category on a channel; call it before sending each notification
+	CheckConsent(ctx context.Context, userID, channel, category string) (bool, error)
+}
+
+// Methods of the user service and how they may be called
+var (
+	createUser      = clients.Method{Name: "CreateUser"}
+	getUser         = clients.Method{Name: "GetUser", Idempotent: true}
+	getUserByEmail  = clients.Method{Name: "GetUserByEmail", Idempotent: true}
+	getUserByHandle = clients.Method{Name: "GetUserByHandle", Idempotent: true}
+	updateUser      = clients.Method{Name: "UpdateUser"}
+	// Not retried: a repeat after a lost response would report NotFound
+	deleteUser = clients.Method{Name: "DeleteUser"}
+	listUsers  = clients.Method{Name: "ListUsers", Idempotent: true}
+
+	getNotificationPreferences = clients.Method{Name: "GetNotificationPreferences", Idempotent: true}
+	// Setting preferences to given values is safe to repeat
+	updateNotificationPreferences = clients.Method{Name: "UpdateNotificationPreferences", Idempotent: true}
+	checkConsent                  = clients.Method{Name: "CheckConsent", Idempotent: true}
+)
+
+// GRPCClient implements Client over a gRPC connection
+type GRPCClient struct {
+	client  pb.UserServiceClient
+	invoker *clients.Invoker
+}
+
+var _ Client = (*GRPCClient)(nil)
+
+// New creates a client using conn, which the caller keeps ownership of
+func New(conn grpc.ClientConnInterface, opts clients.Options) *GRPCClient {
+	return &GRPCClient{
+		client:  pb.NewUserServiceClient(conn),
+		invoker: clients.NewInvoker("user", opts),
+	}
+}
+
+// Invoker exposes the client's invoker, e.g. to inspect breaker states
+func (c *GRPCClient) Invoker() *clients.Invoker {
+	return c.invoker
+}
+
+func (c *GRPCClient) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
+	return clients.Invoke(ctx, c.invoker, createUser, func(ctx context.Context) (*pb.UserResponse, error) {
+		return c.client.CreateUser(ctx, req)
+	})
+}
+
+func (c *GRPCClient) GetUser(ctx context.Context, id string) (*pb.UserResponse, error) {
+	return clients.Invoke(ctx, c.invoker, getUser, func(ctx context.Context) (*pb.UserResponse, error) {
+		return c.client.GetUser(ctx, &pb.GetUserRequest{Id: id})
+	})
+}
+
+func (c *GRPCClient) GetUserByEmail(ctx context.Context, email string) (*pb.UserResponse, error) {
+	return clients.Invoke(ctx, c.invoker, getUserByEmail, func(ctx context.Context) (*pb.UserResponse, error) {
+		return c.client.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: email})
+	})
+}
+
+func (c *GRPCClient) GetUserByHandle(ctx context.Context, handle string) (*pb.UserResponse, error) {
+	return clients.Invoke(ctx, c.invoker, getUserByHandle, func(ctx context.Context) (*pb.UserResponse, error) {
+		return c.client.GetUserByHandle(ctx, &pb.GetUserByHandleRequest{Handle: handle})
+	})
+}
+
+func (c *GRPCClient) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
+	return clients.Invoke(ctx, c.invoker, updateUser, func(ctx context.Context) (*pb.UserResponse, error) {
+		return c.client.UpdateUser(ctx, req)
+	})
+}
+
+func (c *GRPCClient) DeleteUser(ctx context.Context, id string) error {
+	_, err := clients.Invoke(ctx, c.invoker, deleteUser, func(ctx context.Context) (*pb.DeleteUserResponse, error) {
+		return c.client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id})
+	})
+	return err
+}
+
+func (c *GRPCClient) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	return clients.Invoke(ctx, c.invoker, listUsers, func(ctx context.Context) (*pb.ListUsersResponse, error) {
+		return c.client.ListUsers(ctx, req)
+	})
+}
+
+func (c *GRPCClient) GetNotificationPreferences(ctx context.Context, userID string) (*pb.NotificationPreferencesResponse, error) {
+	return clients.Invoke(ctx, c.invoker, getNotificationPreferences, func(ctx context.Context) (*pb.NotificationPreferencesResponse, error) {
+		return c.client.GetNotificationPreferences(ctx, &pb.GetNotificationPreferencesRequest{UserId: userID})
+	})
+}
+
+func (c *GRPCClient) UpdateNotificationPreferences(ctx context.Context, req *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferencesResponse, error) {
+	return clients.Invoke(ctx, c.invoker, updateNotificationPreferences, func(ctx context.Context) (*pb.NotificationPreferencesResponse, error) {
+		return c.client.UpdateNotificationPreferences(ctx, req)
+	})
+}
+
+func (c *GRPCClient) CheckConsent(ctx context.Context, userID, channel, category string) (bool, error) {
+	resp, err := clients.Invoke(ctx, c.invoker, checkConsent, func(ctx context.Context) (*pb.CheckConsentResponse, error) {
+		return c.client.CheckConsent(ctx, &pb.CheckConsentRequest{UserId: userID, Channel: channel, Category: category})
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Allowed, nil
+}
@@ -0,0 +1,66 @@
+package dualwrite
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the migration flags of a service
+type Config struct {
+	// Modes is the mode of each migration by name; migrations without one
+	// are in their default mode
+	Modes map[string]Mode
+	// ShadowTimeout bounds the reads made to compare the stores
+	ShadowTimeout time.Duration
+
+	// problems lists the malformed environment values seen by FromEnv
+	problems []string
+}
+
+// FromEnv reads MIGRATION_MODES, a comma-separated list of migration=mode
+// pairs such as "warehouse-inventory=shadow", and MIGRATION_SHADOW_TIMEOUT.
+// Malformed values are reported by Validate.
+func FromEnv() Config {
+	cfg := Config{Modes: make(map[string]Mode)}
+	for _, pair := range strings.Split(os.Getenv("MIGRATION_MODES"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("MIGRATION_MODES entry %q must be migration=mode", pair))
+			continue
+		}
+		mode, err := ParseMode(strings.TrimSpace(value))
+		if err != nil {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("MIGRATION_MODES entry %q: %v", pair, err))
+			continue
+		}
+		cfg.Modes[name] = mode
+	}
+	if value := os.Getenv("MIGRATION_SHADOW_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("MIGRATION_SHADOW_TIMEOUT=%q must be a positive duration, e.g. 2s", value))
+		}
+		cfg.ShadowTimeout = d
+	}
+	return cfg
+}
+
+// Validate reports malformed settings
+func (c Config) Validate() error {
+	if len(c.problems) > 0 {
+		return errors.New(strings.Join(c.problems, "; "))
+	}
+	return nil
+}
+
+// Flags returns flags holding the configured modes
+func (c Config) Flags() *Flags {
+	return NewFlags(c.Modes)
+}
@@ -0,0 +1,350 @@
+// Package dualwrite moves data from one store to another without downtime,
+// such as search from MongoDB to Elasticsearch or stock from a product's
+// own inventory to the inventory service's warehouses.
+//
+// A Migration runs writes and reads against the old store, the new store
+// or both, depending on its mode. The mode comes from feature flags, so a
+// migration advances one step at a time and steps back the same way:
+//
+//	old         writes and reads use the old store
+//	dual-write  writes go to both stores; reads use the old one
+//	shadow      as dual-write, and reads are compared with the new store
+//	cutover     writes go to both stores; reads use the new one, falling
+//	            back to the old one when it fails
+//	new         writes and reads use the new store
+//
+// Until the mode is new, the old store is kept up to date, so rolling back
+// is a flag change. Data written before dual writes began has to be
+// backfilled separately; shadow reads show when the stores agree:
+//
+//	migration := dualwrite.New("warehouse-inventory", flags, dualwrite.Options{Metrics: metrics}, logger)
+//	...
+//	err := migration.Write(ctx, writeOld, writeNew)
+//	availability, err := dualwrite.Read(ctx, migration, productID, readOld, readNew, sameAvailability)
+package dualwrite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Mode is the step a migration is at
+type Mode string
+
+// Migration modes, in the order a migration advances through them
+const (
+	ModeOld       Mode = "old"
+	ModeDualWrite Mode = "dual-write"
+	ModeShadow    Mode = "shadow"
+	ModeCutover   Mode = "cutover"
+	ModeNew       Mode = "new"
+)
+
+// Modes lists the migration modes in order
+var Modes = []Mode{ModeOld, ModeDualWrite, ModeShadow, ModeCutover, ModeNew}
+
+// ParseMode parses a mode name
+func ParseMode(s string) (Mode, error) {
+	for _, mode := range Modes {
+		if string(mode) == s {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown migration mode %q: must be one of old, dual-write, shadow, cutover or new", s)
+}
+
+// Stores, as in the metrics
+const (
+	StoreOld = "old"
+	StoreNew = "new"
+)
+
+// Flags holds the mode of each migration by name. Modes can be changed
+// while the service runs, and migrations follow on their next call.
+type Flags struct {
+	mu    sync.RWMutex
+	modes map[string]Mode
+}
+
+// NewFlags creates flags with the given modes
+func NewFlags(modes map[string]Mode) *Flags {
+	f := &Flags{modes: make(map[string]Mode, len(modes))}
+	for name, mode := range modes {
+		f.modes[name] = mode
+	}
+	return f
+}
+
+// Mode returns the mode of a migration, if it has one
+func (f *Flags) Mode(migration string) (Mode, bool) {
+	if f == nil {
+		return "", false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	mode, ok := f.modes[migration]
+	return mode, ok
+}
+
+// Set changes the mode of a migration
+func (f *Flags) Set(migration string, mode Mode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modes[migration] = mode
+}
+
+// Options configures a Migration
+type Options struct {
+	// Default is the mode while the flags have none for the migration
+	// (default old)
+	Default Mode
+	// ShadowTimeout bounds the reads made to compare the stores (default
+	// 2s)
+	ShadowTimeout time.Duration
+	// MaxShadowReads bounds the comparisons in flight; reads beyond it are
+	// not compared (default 64)
+	MaxShadowReads int
+	// Metrics records writes, comparisons and fallbacks; nil records
+	// nothing
+	Metrics *Metrics
+}
+
+// Migration moves one kind of data from an old store to a new one
+type Migration struct {
+	name   string
+	flags  *Flags
+	opts   Options
+	logger *slog.Logger
+	shadow chan struct{} // one slot per comparison in flight
+
+	mu   sync.Mutex
+	last Mode
+}
+
+// New creates the migration name, whose mode is read from flags
+func New(name string, flags *Flags, opts Options, logger *slog.Logger) *Migration {
+	if opts.Default == "" {
+		opts.Default = ModeOld
+	}
+	if opts.ShadowTimeout <= 0 {
+		opts.ShadowTimeout = 2 * time.Second
+	}
+	if opts.MaxShadowReads <= 0 {
+		opts.MaxShadowReads = 64
+	}
+	return &Migration{
+		name:   name,
+		flags:  flags,
+		opts:   opts,
+		logger: logger,
+		shadow: make(chan struct{}, opts.MaxShadowReads),
+	}
+}
+
+// Name returns the name of the migration
+func (m *Migration) Name() string {
+	return m.name
+}
+
+// Mode returns the current mode of the migration
+func (m *Migration) Mode() Mode {
+	mode, ok := m.flags.Mode(m.name)
+	if !ok {
+		mode = m.opts.Default
+	}
+
+	m.mu.Lock()
+	changed := mode != m.last
+	m.last = mode
+	m.mu.Unlock()
+	if changed {
+		m.logger.Info("Migration mode set", "migration", m.name, "mode", mode)
+		m.opts.Metrics.setMode(m.name, mode)
+	}
+	return mode
+}
+
+// Write runs a write against the stores the mode writes to. The store
+// reads use is written first, and its error is returned. A failed write to
+// the other store is logged and counted but does not fail the write, since
+// shadow reads and the backfill catch the stores up.
+func (m *Migration) Write(ctx context.Context, writeOld, writeNew func(ctx context.Context) error) error {
+	writes := map[string]func(ctx context.Context) error{StoreOld: writeOld, StoreNew: writeNew}
+	var stores []string
+	switch mode := m.Mode(); mode {
+	case ModeOld:
+		stores = []string{StoreOld}
+	case ModeNew:
+		stores = []string{StoreNew}
+	case ModeCutover:
+		stores = []string{StoreNew, StoreOld}
+	default:
+		stores = []string{StoreOld, StoreNew}
+	}
+
+	if err := m.write(ctx, stores[0], writes[stores[0]]); err != nil {
+		return err
+	}
+	for _, store := range stores[1:] {
+		if err := m.write(ctx, store, writes[store]); err != nil {
+			m.logger.Warn("Failed to write to the secondary store", "migration", m.name, "store", store, "error", err)
+		}
+	}
+	return nil
+}
+
+func (m *Migration) write(ctx context.Context, store string, write func(ctx context.Context) error) error {
+	err := write(ctx)
+	m.opts.Metrics.wrote(m.name, store, err)
+	return err
+}
+
+// Read runs a read against the store the migration's mode reads from. In
+// shadow mode the new store is read too, in the background, and the
+// results compared with equal; mismatches are logged with key and counted.
+// In cutover mode a failed read of the new store falls back to the old one.
+func Read[T any](ctx context.Context, m *Migration, key string, readOld, readNew func(ctx context.Context) (T, error), equal func(a, b T) bool) (T, error) {
+	switch mode := m.Mode(); mode {
+	case ModeShadow:
+		value, err := readOld(ctx)
+		if err == nil {
+			m.compare(ctx, key, func(ctx context.Context) (bool, error) {
+				shadow, err := readNew(ctx)
+				if err != nil {
+					return false, err
+				}
+				return equal(value, shadow), nil
+			})
+		}
+		return value, err
+	case ModeCutover:
+		value, err := readNew(ctx)
+		if err != nil && ctx.Err() == nil {
+			m.logger.Warn("Failed to read from the new store; reading the old one", "migration", m.name, "key", key, "error", err)
+			m.opts.Metrics.fellBack(m.name)
+			return readOld(ctx)
+		}
+		return value, err
+	case ModeNew:
+		return readNew(ctx)
+	default:
+		return readOld(ctx)
+	}
+}
+
+// compare runs a comparison in the background, detached from the caller's
+// cancellation, unless too many are in flight already
+func (m *Migration) compare(ctx context.Context, key string, compare func(ctx context.Context) (bool, error)) {
+	select {
+	case m.shadow <- struct{}{}:
+	default:
+		m.opts.Metrics.compared(m.name, ResultSkipped)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.opts.ShadowTimeout)
+	go func() {
+		defer func() { <-m.shadow }()
+		defer cancel()
+
+		match, err := compare(ctx)
+		switch {
+		case err != nil:
+			m.logger.Warn("Failed to read from the new store for comparison", "migration", m.name, "key", key, "error", err)
+			m.opts.Metrics.compared(m.name, ResultError)
+		case !match:
+			m.logger.Warn("Stores disagree", "migration", m.name, "key", key)
+			m.opts.Metrics.compared(m.name, ResultMismatch)
+		default:
+			m.opts.Metrics.compared(m.name, ResultMatch)
+		}
+	}()
+}
+
+// Results of comparisons, as in the metrics
+const (
+	ResultMatch    = "match"
+	ResultMismatch = "mismatch"
+	ResultError    = "error"
+	ResultSkipped  = "skipped"
+)
+
+// Metrics holds the Prometheus collectors for the migrations of one process
+type Metrics struct {
+	mode        *prometheus.GaugeVec
+	writes      *prometheus.CounterVec
+	comparisons *prometheus.CounterVec
+	fallbacks   *prometheus.CounterVec
+}
+
+// NewMetrics creates the migration metrics under namespace and registers
+// them with reg
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		mode: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "dualwrite_mode",
+			Help:      "The mode each migration is in: 1 for its current mode, 0 for the others.",
+		}, []string{"migration", "mode"}),
+		writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dualwrite_writes_total",
+			Help:      "Writes made by migrations by store (old or new) and result (success or error).",
+		}, []string{"migration", "store", "result"}),
+		comparisons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dualwrite_comparisons_total",
+			Help:      "Shadow reads compared by migrations by result: match, mismatch, error or skipped.",
+		}, []string{"migration", "result"}),
+		fallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dualwrite_read_fallbacks_total",
+			Help:      "Reads that fell back to the old store after the new one failed, by migration.",
+		}, []string{"migration"}),
+	}
+	reg.MustRegister(m.mode, m.writes, m.comparisons, m.fallbacks)
+	return m
+}
+
+func (m *Metrics) setMode(migration string, current Mode) {
+	if m == nil {
+		return
+	}
+	for _, mode := range Modes {
+		value := 0.0
+		if mode == current {
+			value = 1
+		}
+		m.mode.WithLabelValues(migration, string(mode)).Set(value)
+	}
+}
+
+func (m *Metrics) wrote(migration, store string, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.writes.WithLabelValues(migration, store, result).Inc()
+}
+
+func (m *Metrics) compared(migration, result string) {
+	if m == nil {
+		return
+	}
+	m.comparisons.WithLabelValues(migration, result).Inc()
+}
+
+func (m *Metrics) fellBack(migration string) {
+	if m == nil {
+		return
+	}
+	m.fallbacks.WithLabelValues(migration).Inc()
+}
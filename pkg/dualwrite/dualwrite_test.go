@@ -0,0 +1,138 @@
+package dualwrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestWrite(t *testing.T) {
+	flags := NewFlags(nil)
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	migration := New("search", flags, Options{Metrics: metrics}, discard)
+
+	var writes []string
+	writeTo := func(store string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			writes = append(writes, store)
+			return err
+		}
+	}
+
+	for mode, want := range map[Mode][]string{
+		ModeOld:       {StoreOld},
+		ModeDualWrite: {StoreOld, StoreNew},
+		ModeShadow:    {StoreOld, StoreNew},
+		ModeCutover:   {StoreNew, StoreOld},
+		ModeNew:       {StoreNew},
+	} {
+		flags.Set("search", mode)
+		writes = nil
+		require.NoError(t, migration.Write(context.Background(), writeTo(StoreOld, nil), writeTo(StoreNew, nil)))
+		assert.Equal(t, want, writes, mode)
+	}
+
+	// The secondary store failing does not fail the write; the primary does
+	flags.Set("search", ModeDualWrite)
+	writes = nil
+	assert.NoError(t, migration.Write(context.Background(), writeTo(StoreOld, nil), writeTo(StoreNew, errors.New("timeout"))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.writes.WithLabelValues("search", StoreNew, "error")))
+
+	writes = nil
+	assert.Error(t, migration.Write(context.Background(), writeTo(StoreOld, errors.New("timeout")), writeTo(StoreNew, nil)))
+	assert.Equal(t, []string{StoreOld}, writes)
+}
+
+func TestRead(t *testing.T) {
+	flags := NewFlags(map[string]Mode{"search": ModeShadow})
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	migration := New("search", flags, Options{Metrics: metrics}, discard)
+
+	newValue, newErr := 2, error(nil)
+	readOld := func(context.Context) (int, error) { return 1, nil }
+	readNew := func(context.Context) (int, error) { return newValue, newErr }
+	equal := func(a, b int) bool { return a == b }
+	comparisons := func(result string) float64 {
+		return testutil.ToFloat64(metrics.comparisons.WithLabelValues("search", result))
+	}
+
+	// Shadow reads answer from the old store and compare the new one
+	value, err := Read(context.Background(), migration, "p1", readOld, readNew, equal)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	require.Eventually(t, func() bool { return comparisons(ResultMismatch) == 1 }, time.Second, time.Millisecond)
+
+	newValue = 1
+	_, err = Read(context.Background(), migration, "p1", readOld, readNew, equal)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return comparisons(ResultMatch) == 1 }, time.Second, time.Millisecond)
+
+	// Cutover answers from the new store, falling back to the old one
+	flags.Set("search", ModeCutover)
+	newValue = 2
+	value, err = Read(context.Background(), migration, "p1", readOld, readNew, equal)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	newErr = errors.New("connection refused")
+	value, err = Read(context.Background(), migration, "p1", readOld, readNew, equal)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.fallbacks.WithLabelValues("search")))
+
+	flags.Set("search", ModeNew)
+	_, err = Read(context.Background(), migration, "p1", readOld, readNew, equal)
+	assert.ErrorIs(t, err, newErr, "nothing to fall back to once the old store is retired")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.mode.WithLabelValues("search", string(ModeNew))))
+	assert.Zero(t, testutil.ToFloat64(metrics.mode.WithLabelValues("search", string(ModeCutover))))
+}
+
+func TestShadowReadsAreBounded(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	migration := New("search", nil, Options{Default: ModeShadow, MaxShadowReads: 1, Metrics: metrics}, discard)
+
+	release := make(chan struct{})
+	readOld := func(context.Context) (int, error) { return 1, nil }
+	readNew := func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}
+	equal := func(a, b int) bool { return a == b }
+
+	for i := 0; i < 3; i++ {
+		_, err := Read(context.Background(), migration, "p1", readOld, readNew, equal)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.comparisons.WithLabelValues("search", ResultSkipped)))
+	close(release)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.comparisons.WithLabelValues("search", ResultMatch)) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("MIGRATION_MODES", "search=shadow, warehouse-inventory = cutover")
+	t.Setenv("MIGRATION_SHADOW_TIMEOUT", "500ms")
+	cfg := FromEnv()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, map[string]Mode{"search": ModeShadow, "warehouse-inventory": ModeCutover}, cfg.Modes)
+	assert.Equal(t, 500*time.Millisecond, cfg.ShadowTimeout)
+
+	t.Setenv("MIGRATION_MODES", "search=halfway,orders")
+	t.Setenv("MIGRATION_SHADOW_TIMEOUT", "soon")
+	err := FromEnv().Validate()
+	assert.ErrorContains(t, err, `unknown migration mode "halfway"`)
+	assert.ErrorContains(t, err, `"orders" must be migration=mode`)
+	assert.ErrorContains(t, err, "MIGRATION_SHADOW_TIMEOUT")
+}
@@ -0,0 +1,74 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event is the envelope carried on the bus for every domain event. Event
+// types and their payloads are defined in pkg/events.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Source     string    `json:"source"`
+	Key        string    `json:"key"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Version is the version of the payload's schema. Events published
+	// before schemas were versioned have none, which means version 1.
+	Version int `json:"version,omitempty"`
+	// Traceparent is the W3C trace context of the operation that published
+	// the event, if it was traced
+	Traceparent string          `json:"traceparent,omitempty"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// NewEvent creates an event of the given type with its payload encoded as
+// JSON. Services publish through events.New, which also validates the
+// payload and stamps its schema version and trace context.
+func NewEvent(eventType, source, key string, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return &Event{
+		ID:         primitive.NewObjectID().Hex(),
+		Type:       eventType,
+		Source:     source,
+		Key:        key,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}, nil
+}
+
+// Decode unmarshals the event payload into v
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Handler processes a single event. Returning an error leaves the event
+// unacknowledged so it is redelivered.
+type Handler func(ctx context.Context, event *Event) error
+
+// Publisher publishes events to the bus
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Subscriber delivers events of the given types to a handler until ctx is done.
+// Subscribers sharing the same group split the stream between them.
+type Subscriber interface {
+	Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error
+}
+
+// NoopPublisher discards all events. It is used when eventing is disabled.
+type NoopPublisher struct{}
+
+// Publish implements Publisher
+func (NoopPublisher) Publish(ctx context.Context, event *Event) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBus is an in-process bus used for local development and tests.
+// Handlers run synchronously on the publishing goroutine.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[string]map[string]Handler // event type -> group -> handler
+}
+
+// NewMemoryBus creates an empty in-memory bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers: make(map[string]map[string]Handler),
+	}
+}
+
+// Publish delivers the event to one handler per subscribed group
+func (b *MemoryBus) Publish(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	groups := b.handlers[event.Type]
+	handlers := make([]Handler, 0, len(groups))
+	for _, h := range groups {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe registers the handler and blocks until ctx is cancelled
+func (b *MemoryBus) Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error {
+	b.mu.Lock()
+	for _, t := range eventTypes {
+		if b.handlers[t] == nil {
+			b.handlers[t] = make(map[string]Handler)
+		}
+		b.handlers[t][group] = handler
+	}
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	for _, t := range eventTypes {
+		delete(b.handlers[t], group)
+	}
+	b.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,184 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig holds the settings for the Redis Streams transport
+type RedisConfig struct {
+	StreamPrefix string        // Streams are named <prefix>:<event type>
+	MaxLen       int64         // Approximate cap on stream length, 0 for unbounded
+	BlockTimeout time.Duration // How long XREADGROUP blocks waiting for events
+	ClaimIdle    time.Duration // Pending events idle longer than this are redelivered
+	BatchSize    int64
+}
+
+// RedisBus publishes and consumes events through Redis Streams consumer groups
+type RedisBus struct {
+	client   *redis.Client
+	config   RedisConfig
+	consumer string
+	logger   *slog.Logger
+}
+
+// NewRedisBus creates a Redis Streams backed bus
+func NewRedisBus(client *redis.Client, cfg RedisConfig, logger *slog.Logger) *RedisBus {
+	if cfg.StreamPrefix == "" {
+		cfg.StreamPrefix = "events"
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 5 * time.Second
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = time.Minute
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	hostname, _ := os.Hostname()
+	return &RedisBus{
+		client:   client,
+		config:   cfg,
+		consumer: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		logger:   logger,
+	}
+}
+
+// Publish appends the event to the stream for its type
+func (b *RedisBus) Publish(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: b.streamName(event.Type),
+		Values: map[string]interface{}{"event": payload},
+	}
+	if b.config.MaxLen > 0 {
+		args.MaxLen = b.config.MaxLen
+		args.Approx = true
+	}
+
+	if err := b.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Subscribe consumes the streams for the given event types as part of the
+// consumer group until ctx is cancelled. Events are acknowledged only after
+// the handler succeeds; failed events are reclaimed after ClaimIdle.
+func (b *RedisBus) Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error {
+	streams := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		stream := b.streamName(t)
+		err := b.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("failed to create consumer group for %s: %w", stream, err)
+		}
+		streams = append(streams, stream)
+	}
+
+	lastClaim := time.Time{}
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Periodically pick up events that a crashed or failing consumer left pending
+		if time.Since(lastClaim) >= b.config.ClaimIdle {
+			for _, stream := range streams {
+				b.reclaim(ctx, stream, group, handler)
+			}
+			lastClaim = time.Now()
+		}
+
+		args := &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: b.consumer,
+			Streams:  append(append([]string{}, streams...), repeat(">", len(streams))...),
+			Count:    b.config.BatchSize,
+			Block:    b.config.BlockTimeout,
+		}
+		result, err := b.client.XReadGroup(ctx, args).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			b.logger.Error("Failed to read from event streams", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, s := range result {
+			for _, msg := range s.Messages {
+				b.process(ctx, s.Stream, group, msg, handler)
+			}
+		}
+	}
+}
+
+func (b *RedisBus) reclaim(ctx context.Context, stream, group string, handler Handler) {
+	messages, _, err := b.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: b.consumer,
+		MinIdle:  b.config.ClaimIdle,
+		Start:    "0",
+		Count:    b.config.BatchSize,
+	}).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+			b.logger.Error("Failed to reclaim pending events", "stream", stream, "error", err)
+		}
+		return
+	}
+
+	for _, msg := range messages {
+		b.process(ctx, stream, group, msg, handler)
+	}
+}
+
+func (b *RedisBus) process(ctx context.Context, stream, group string, msg redis.XMessage, handler Handler) {
+	raw, _ := msg.Values["event"].(string)
+
+	var event Event
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		// A malformed message will never succeed, so drop it rather than redeliver forever
+		b.logger.Error("Dropping malformed event", "stream", stream, "id", msg.ID, "error", err)
+		b.client.XAck(ctx, stream, group, msg.ID)
+		return
+	}
+
+	if err := handler(ctx, &event); err != nil {
+		b.logger.Error("Event handler failed", "type", event.Type, "id", event.ID, "error", err)
+		return
+	}
+
+	if err := b.client.XAck(ctx, stream, group, msg.ID).Err(); err != nil {
+		b.logger.Error("Failed to acknowledge event", "type", event.Type, "id", event.ID, "error", err)
+	}
+}
+
+func (b *RedisBus) streamName(eventType string) string {
+	return b.config.StreamPrefix + ":" + eventType
+}
+
+func repeat(s string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = s
+	}
+	return out
+}
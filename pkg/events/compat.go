@@ -0,0 +1,151 @@
+package events
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Compatible reports whether the payload type next can replace previous in
+// a schema's next version. Consumers on either version must decode events
+// of the other, so next keeps every JSON field of previous with the same
+// JSON type, and any field it adds is optional (omitempty).
+func Compatible(previous, next reflect.Type) error {
+	return compatible("", previous, next)
+}
+
+func compatible(path string, previous, next reflect.Type) error {
+	previous, next = indirect(previous), indirect(next)
+
+	// Types with their own encoding are opaque: only the same type will do
+	if marshals(previous) || marshals(next) {
+		if previous != next {
+			return fmt.Errorf("%s changes type from %s to %s", fieldPath(path), previous, next)
+		}
+		return nil
+	}
+
+	if jsonKind(previous) != jsonKind(next) {
+		return fmt.Errorf("%s changes type from %s to %s", fieldPath(path), jsonKind(previous), jsonKind(next))
+	}
+	switch previous.Kind() {
+	case reflect.Slice, reflect.Array:
+		return compatible(path+"[]", previous.Elem(), next.Elem())
+	case reflect.Map:
+		if err := compatible(path+"{key}", previous.Key(), next.Key()); err != nil {
+			return err
+		}
+		return compatible(path+"{}", previous.Elem(), next.Elem())
+	case reflect.Struct:
+		return compatibleFields(path, previous, next)
+	}
+	return nil
+}
+
+func compatibleFields(path string, previous, next reflect.Type) error {
+	previousFields, nextFields := jsonFields(previous), jsonFields(next)
+	for name, field := range previousFields {
+		nextField, ok := nextFields[name]
+		if !ok {
+			return fmt.Errorf("%s is removed", fieldPath(join(path, name)))
+		}
+		if err := compatible(join(path, name), field.typ, nextField.typ); err != nil {
+			return err
+		}
+	}
+	for name, field := range nextFields {
+		if _, ok := previousFields[name]; !ok && !field.optional {
+			return fmt.Errorf("%s is added without omitempty", fieldPath(join(path, name)))
+		}
+	}
+	return nil
+}
+
+type jsonField struct {
+	typ      reflect.Type
+	optional bool
+}
+
+// jsonFields returns the fields of a struct as encoding/json sees them,
+// by name, with those of embedded structs promoted
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := make(map[string]jsonField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			for embedded, field := range jsonFields(indirect(f.Type)) {
+				if _, ok := fields[embedded]; !ok {
+					fields[embedded] = field
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = jsonField{typ: f.Type, optional: strings.Contains(","+options+",", ",omitempty,")}
+	}
+	return fields
+}
+
+// jsonKind is the JSON type a Go type encodes as
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return t.String()
+}
+
+func marshals(t reflect.Type) bool {
+	for _, i := range []reflect.Type{jsonMarshaler, textMarshaler} {
+		if t.Implements(i) || reflect.PointerTo(t).Implements(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func fieldPath(path string) string {
+	if path == "" {
+		return "the payload"
+	}
+	return "field " + path
+}
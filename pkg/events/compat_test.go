@@ -0,0 +1,71 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompatible(t *testing.T) {
+	type item struct {
+		SKU string `json:"sku"`
+	}
+	type base struct {
+		ID       string            `json:"id"`
+		Quantity int               `json:"quantity"`
+		Items    []item            `json:"items"`
+		Labels   map[string]string `json:"labels"`
+		At       time.Time         `json:"at"`
+		Internal string            `json:"-"`
+	}
+
+	tests := []struct {
+		name string
+		next interface{}
+		want string
+	}{
+		{"unchanged", base{}, ""},
+		{"optional field added", struct {
+			base
+			Note string `json:"note,omitempty"`
+		}{}, ""},
+		{"ignored field dropped, same JSON types", struct {
+			ID       string            `json:"id"`
+			Quantity int64             `json:"quantity"`
+			Items    []*item           `json:"items"`
+			Labels   map[string]string `json:"labels"`
+			At       time.Time         `json:"at"`
+		}{}, ""},
+		{"required field added", struct {
+			base
+			Note string `json:"note"`
+		}{}, "field note is added without omitempty"},
+		{"field removed", struct {
+			ID string `json:"id"`
+		}{}, "is removed"},
+		{"field retyped", struct {
+			base
+			Quantity float64 `json:"quantity"`
+		}{}, "field quantity changes type from integer to number"},
+		{"nested field retyped", struct {
+			base
+			Items []struct {
+				SKU int `json:"sku"`
+			} `json:"items"`
+		}{}, "field items[].sku changes type from string to integer"},
+		{"encoded type changed", struct {
+			base
+			At string `json:"at"`
+		}{}, "field at changes type from time.Time to string"},
+	}
+	for _, tt := range tests {
+		err := Compatible(reflect.TypeOf(base{}), reflect.TypeOf(tt.next))
+		if tt.want == "" {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.ErrorContains(t, err, tt.want, tt.name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package events defines the domain events the services publish: their
+// types, their payloads and the versioned schemas that tie them together.
+//
+// Every event type has one schema per payload version, kept in a Registry.
+// Publishers build events with New, which checks the payload against the
+// latest schema and stamps its version and the caller's trace context on
+// the envelope. Consumers decode with Decode, which validates what they
+// received:
+//
+//	event, err := events.New(ctx, events.OrderPaid, "order-service", orderID, payload)
+//	...
+//	var order events.OrderPayload
+//	if err := events.Decode(event, &order); errors.Is(err, events.ErrInvalidPayload) {
+//		// drop it: redelivery will not fix it
+//	}
+//
+// Schemas only evolve compatibly (see Compatible): a new version may add
+// optional fields but not remove, rename or retype existing ones, so
+// consumers decode events of older and newer versions alike. A change that
+// cannot be made compatibly needs a new event type.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/logging"
+)
+
+// Event types published by the services
+const (
+	ProductCreated   = "product.created"
+	ProductUpdated   = "product.updated"
+	ProductDeleted   = "product.deleted"
+	ProductReleased  = "product.released"
+	InventoryChanged = "inventory.changed"
+
+	InventoryReserved  = "inventory.reserved"
+	InventoryReleased  = "inventory.released"
+	InventoryCommitted = "inventory.committed"
+	InventoryAdjusted  = "inventory.adjusted"
+	InventoryLowStock  = "inventory.low_stock"
+
+	UserSuspiciousLogin = "user.suspicious_login"
+
+	OrderPaid      = "order.paid"
+	OrderCancelled = "order.cancelled"
+)
+
+var (
+	// ErrUnknownType is returned for events of a type without a schema
+	ErrUnknownType = errors.New("unknown event type")
+	// ErrInvalidPayload is returned for payloads that do not decode or do
+	// not pass validation
+	ErrInvalidPayload = errors.New("invalid event payload")
+)
+
+// Payload is an event body that checks its own fields
+type Payload interface {
+	Validate() error
+}
+
+// Schema is one version of the payload of an event type
+type Schema struct {
+	Type    string
+	Version int
+	// Payload returns an empty payload of this version to decode into
+	Payload func() Payload
+}
+
+// Registry holds the schemas of the known event types
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string][]Schema // by type, version 1 first
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string][]Schema)}
+}
+
+// Register adds the next version of an event type's schema. Versions are
+// numbered from 1 without gaps, and each must be compatible with the one
+// before it.
+func (r *Registry) Register(schema Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.schemas[schema.Type]
+	if want := len(versions) + 1; schema.Version != want {
+		return fmt.Errorf("%s: registering version %d, expected version %d", schema.Type, schema.Version, want)
+	}
+	if len(versions) > 0 {
+		previous := versions[len(versions)-1]
+		if err := Compatible(reflect.TypeOf(previous.Payload()), reflect.TypeOf(schema.Payload())); err != nil {
+			return fmt.Errorf("%s version %d is not compatible with version %d: %w", schema.Type, schema.Version, previous.Version, err)
+		}
+	}
+	r.schemas[schema.Type] = append(versions, schema)
+	return nil
+}
+
+// MustRegister registers schemas and panics if one is rejected
+func (r *Registry) MustRegister(schemas ...Schema) {
+	for _, schema := range schemas {
+		if err := r.Register(schema); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Latest returns the latest schema of an event type
+func (r *Registry) Latest(eventType string) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.schemas[eventType]
+	if len(versions) == 0 {
+		return Schema{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Types returns the registered event types, sorted
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.schemas))
+	for eventType := range r.schemas {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New creates an event of a registered type. The payload, which may be any
+// value with the schema's JSON shape, is checked against the latest schema,
+// and the event carries that schema's version and the trace context of ctx.
+func (r *Registry) New(ctx context.Context, eventType, source, key string, payload interface{}) (*eventbus.Event, error) {
+	schema, ok := r.Latest(eventType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+
+	event, err := eventbus.NewEvent(eventType, source, key, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := decode(event, schema.Payload()); err != nil {
+		return nil, err
+	}
+	event.Version = schema.Version
+	event.Traceparent = logging.Traceparent(ctx)
+	return event, nil
+}
+
+// Decode decodes the payload of an event of a registered type into v and
+// validates it. Events of any version decode into the latest payload,
+// since versions are compatible.
+func (r *Registry) Decode(event *eventbus.Event, v Payload) error {
+	if _, ok := r.Latest(event.Type); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, event.Type)
+	}
+	return decode(event, v)
+}
+
+func decode(event *eventbus.Event, v Payload) error {
+	if err := json.Unmarshal(event.Data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.Type, err)
+	}
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.Type, err)
+	}
+	return nil
+}
+
+// New creates an event with the schemas of the services' events; see
+// Registry.New
+func New(ctx context.Context, eventType, source, key string, payload interface{}) (*eventbus.Event, error) {
+	return Schemas.New(ctx, eventType, source, key, payload)
+}
+
+// Decode decodes an event with the schemas of the services' events; see
+// Registry.Decode
+func Decode(event *eventbus.Event, v Payload) error {
+	return Schemas.Decode(event, v)
+}
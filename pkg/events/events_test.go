@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := logging.WithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	event, err := New(ctx, OrderPaid, "order-service", "order-1", OrderPayload{
+		OrderID: "order-1",
+		Items:   []OrderItemPayload{{ProductID: "p1", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, event.Version)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", event.Traceparent)
+
+	var order OrderPayload
+	require.NoError(t, Decode(event, &order))
+	assert.Equal(t, "p1", order.Items[0].ProductID)
+
+	_, err = New(ctx, OrderPaid, "order-service", "order-1", OrderPayload{OrderID: "order-1", Items: []OrderItemPayload{{ProductID: "p1"}}})
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+	assert.ErrorContains(t, err, "items[0]: quantity must be positive")
+
+	_, err = New(ctx, "order.shipped", "order-service", "order-1", OrderPayload{OrderID: "order-1"})
+	assert.ErrorIs(t, err, ErrUnknownType)
+}
+
+func TestDecode(t *testing.T) {
+	// Events published before schemas were versioned decode as version 1
+	event, err := eventbus.NewEvent(ProductDeleted, "product-service", "p1", ProductDeletedPayload{ID: "p1"})
+	require.NoError(t, err)
+	var deleted ProductDeletedPayload
+	require.NoError(t, Decode(event, &deleted))
+	assert.Equal(t, "p1", deleted.ID)
+
+	event, err = eventbus.NewEvent(ProductDeleted, "product-service", "p1", map[string]int{"id": 1})
+	require.NoError(t, err)
+	assert.ErrorIs(t, Decode(event, &deleted), ErrInvalidPayload)
+
+	event, err = eventbus.NewEvent(ProductDeleted, "product-service", "p1", ProductDeletedPayload{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, Decode(event, &deleted), ErrInvalidPayload)
+
+	event.Type = "product.archived"
+	assert.ErrorIs(t, Decode(event, &deleted), ErrUnknownType)
+}
+
+// orderV2 adds an optional field to OrderPayload
+type orderV2 struct {
+	OrderPayload
+	Currency string `json:"currency,omitempty"`
+}
+
+// orderWithoutKey drops a field of OrderPayload
+type orderWithoutKey struct {
+	OrderID string             `json:"order_id"`
+	Items   []OrderItemPayload `json:"items"`
+}
+
+func (o *orderWithoutKey) Validate() error { return nil }
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry()
+	v1 := Schema{Type: OrderPaid, Version: 1, Payload: func() Payload { return &OrderPayload{} }}
+
+	assert.ErrorContains(t, registry.Register(Schema{Type: OrderPaid, Version: 2, Payload: v1.Payload}), "expected version 1")
+	require.NoError(t, registry.Register(v1))
+	assert.ErrorContains(t, registry.Register(v1), "expected version 2")
+
+	err := registry.Register(Schema{Type: OrderPaid, Version: 2, Payload: func() Payload { return &orderWithoutKey{} }})
+	assert.ErrorContains(t, err, "field idempotency_key is removed")
+
+	require.NoError(t, registry.Register(Schema{Type: OrderPaid, Version: 2, Payload: func() Payload { return &orderV2{} }}))
+	latest, ok := registry.Latest(OrderPaid)
+	require.True(t, ok)
+	assert.Equal(t, 2, latest.Version)
+
+	// Events of either version decode into the other
+	event, err := registry.New(context.Background(), OrderPaid, "order-service", "order-1", orderV2{OrderPayload: OrderPayload{OrderID: "order-1"}, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, event.Version)
+	var order OrderPayload
+	require.NoError(t, registry.Decode(event, &order))
+	assert.Equal(t, "order-1", order.OrderID)
+}
+
+func TestSchemas(t *testing.T) {
+	assert.Len(t, Schemas.Types(), 13)
+	for _, eventType := range Schemas.Types() {
+		schema, _ := Schemas.Latest(eventType)
+		assert.Equal(t, 1, schema.Version, eventType)
+	}
+}
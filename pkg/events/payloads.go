@@ -0,0 +1,210 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProductPayload is the body of product.created and product.updated events
+type ProductPayload struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       float64           `json:"price"`
+	ImageURLs   []string          `json:"image_urls"`
+	Category    string            `json:"category"`
+	Inventory   InventoryPayload  `json:"inventory"`
+	Barcode     string            `json:"barcode,omitempty"`
+	Tags        []string          `json:"tags"`
+	Attributes  map[string]string `json:"attributes"`
+	Active      bool              `json:"active"`
+	// Status is "draft", "published" or "retired". Products written
+	// before statuses existed have none and are published.
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Listed reports whether shoppers see the product: whether it is active
+// and published
+func (p *ProductPayload) Listed() bool {
+	return p.Active && (p.Status == "" || p.Status == "published")
+}
+
+// Validate checks the product has an ID, a name and a price
+func (p *ProductPayload) Validate() error {
+	switch {
+	case p.ID == "":
+		return errors.New("id is required")
+	case p.Name == "":
+		return errors.New("name is required")
+	case p.Price < 0:
+		return errors.New("price must not be negative")
+	}
+	return nil
+}
+
+// InventoryPayload mirrors the inventory block of a product
+type InventoryPayload struct {
+	Quantity int    `json:"quantity"`
+	SKU      string `json:"sku"`
+	InStock  bool   `json:"in_stock"`
+	Reserved int    `json:"reserved"`
+}
+
+// ProductReleasedPayload is the body of product.released events. Preorders
+// is the number of units preordered, which are now reserved.
+type ProductReleasedPayload struct {
+	ProductID   string    `json:"product_id"`
+	Name        string    `json:"name"`
+	ReleaseDate time.Time `json:"release_date"`
+	Preorders   int       `json:"preorders"`
+}
+
+// Validate checks the event names a product
+func (p *ProductReleasedPayload) Validate() error {
+	if p.ProductID == "" {
+		return errors.New("product_id is required")
+	}
+	return nil
+}
+
+// ProductDeletedPayload is the body of product.deleted events
+type ProductDeletedPayload struct {
+	ID string `json:"id"`
+}
+
+// Validate checks the event names a product
+func (p *ProductDeletedPayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+// InventoryChangedPayload is the body of inventory.changed events
+type InventoryChangedPayload struct {
+	ProductID      string           `json:"product_id"`
+	QuantityChange int              `json:"quantity_change"`
+	OperationID    string           `json:"operation_id"`
+	OperationType  string           `json:"operation_type"`
+	Inventory      InventoryPayload `json:"inventory"`
+}
+
+// Validate checks the event names a product
+func (p *InventoryChangedPayload) Validate() error {
+	if p.ProductID == "" {
+		return errors.New("product_id is required")
+	}
+	return nil
+}
+
+// StockMovementPayload is the body of inventory.reserved, inventory.released,
+// inventory.committed and inventory.adjusted events. Stock levels are the
+// warehouse totals after the movement was applied.
+type StockMovementPayload struct {
+	OperationID    string `json:"operation_id"`
+	ProductID      string `json:"product_id"`
+	WarehouseID    string `json:"warehouse_id"`
+	ReservationID  string `json:"reservation_id,omitempty"`
+	QuantityChange int    `json:"quantity_change"`
+	Reason         string `json:"reason,omitempty"`
+	OnHand         int    `json:"on_hand"`
+	Reserved       int    `json:"reserved"`
+	Available      int    `json:"available"`
+}
+
+// Validate checks the movement names its operation, product and warehouse
+func (p *StockMovementPayload) Validate() error {
+	switch {
+	case p.OperationID == "":
+		return errors.New("operation_id is required")
+	case p.ProductID == "":
+		return errors.New("product_id is required")
+	case p.WarehouseID == "":
+		return errors.New("warehouse_id is required")
+	}
+	return nil
+}
+
+// LowStockPayload is the body of inventory.low_stock events, published by
+// the product service when a product's stock falls below the threshold of
+// a stock alert. It is published once per alert and product until the
+// stock is back at the threshold.
+type LowStockPayload struct {
+	AlertID   string `json:"alert_id"`
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	SKU       string `json:"sku"`
+	Category  string `json:"category"`
+	Quantity  int    `json:"quantity"`
+	Threshold int    `json:"threshold"`
+}
+
+// Validate checks the event names its alert and product
+func (p *LowStockPayload) Validate() error {
+	switch {
+	case p.AlertID == "":
+		return errors.New("alert_id is required")
+	case p.ProductID == "":
+		return errors.New("product_id is required")
+	}
+	return nil
+}
+
+// SuspiciousLoginPayload is the body of user.suspicious_login events,
+// published when a user signs in from a new device or location. Reasons
+// lists which, as new_device and new_location.
+type SuspiciousLoginPayload struct {
+	UserID         string    `json:"user_id"`
+	Email          string    `json:"email"`
+	LoginID        int64     `json:"login_id"`
+	DeviceID       string    `json:"device_id"`
+	UserAgent      string    `json:"user_agent"`
+	Location       string    `json:"location"`
+	Reasons        []string  `json:"reasons"`
+	StepUpRequired bool      `json:"step_up_required"`
+	LoggedInAt     time.Time `json:"logged_in_at"`
+}
+
+// Validate checks the event names a user
+func (p *SuspiciousLoginPayload) Validate() error {
+	if p.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	return nil
+}
+
+// OrderPayload is the body of order.paid and order.cancelled events,
+// published by the order service. IdempotencyKey is the key of the checkout
+// that placed the order; consumers derive the IDs of the operations they
+// apply from it, so that redelivered events are applied once.
+type OrderPayload struct {
+	OrderID        string             `json:"order_id"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	Items          []OrderItemPayload `json:"items"`
+}
+
+// Validate checks the order has an ID and that each item names a product
+// and a positive quantity
+func (p *OrderPayload) Validate() error {
+	if p.OrderID == "" {
+		return errors.New("order_id is required")
+	}
+	for i, item := range p.Items {
+		if item.ProductID == "" {
+			return fmt.Errorf("items[%d]: product_id is required", i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("items[%d]: quantity must be positive", i)
+		}
+	}
+	return nil
+}
+
+// OrderItemPayload is one line of an order
+type OrderItemPayload struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
@@ -0,0 +1,26 @@
+package events
+
+// Schemas is the registry of the services' event schemas. A new version of
+// an event's payload is registered here after the versions before it.
+var Schemas = NewRegistry()
+
+func init() {
+	Schemas.MustRegister(
+		Schema{Type: ProductCreated, Version: 1, Payload: func() Payload { return &ProductPayload{} }},
+		Schema{Type: ProductUpdated, Version: 1, Payload: func() Payload { return &ProductPayload{} }},
+		Schema{Type: ProductDeleted, Version: 1, Payload: func() Payload { return &ProductDeletedPayload{} }},
+		Schema{Type: ProductReleased, Version: 1, Payload: func() Payload { return &ProductReleasedPayload{} }},
+		Schema{Type: InventoryChanged, Version: 1, Payload: func() Payload { return &InventoryChangedPayload{} }},
+
+		Schema{Type: InventoryReserved, Version: 1, Payload: func() Payload { return &StockMovementPayload{} }},
+		Schema{Type: InventoryReleased, Version: 1, Payload: func() Payload { return &StockMovementPayload{} }},
+		Schema{Type: InventoryCommitted, Version: 1, Payload: func() Payload { return &StockMovementPayload{} }},
+		Schema{Type: InventoryAdjusted, Version: 1, Payload: func() Payload { return &StockMovementPayload{} }},
+		Schema{Type: InventoryLowStock, Version: 1, Payload: func() Payload { return &LowStockPayload{} }},
+
+		Schema{Type: UserSuspiciousLogin, Version: 1, Payload: func() Payload { return &SuspiciousLoginPayload{} }},
+
+		Schema{Type: OrderPaid, Version: 1, Payload: func() Payload { return &OrderPayload{} }},
+		Schema{Type: OrderCancelled, Version: 1, Payload: func() Payload { return &OrderPayload{} }},
+	)
+}
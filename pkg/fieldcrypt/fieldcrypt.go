@@ -0,0 +1,205 @@
+// Package fieldcrypt encrypts individual database fields holding personal
+// data with envelope encryption: every value is sealed with its own random
+// data key (AES-256-GCM), and the data key is stored next to it wrapped by
+// a master key held in a KMS or, with LocalKeyring, in the service's
+// configuration.
+//
+// Ciphertexts are tagged with the ID of the master key that wrapped their
+// data key, so master keys can be rotated: add the new key as the current
+// one, keep the old ones for decryption and re-wrap stored values with
+// Rewrap, which leaves the data itself untouched.
+//
+//	keyring, err := fieldcrypt.ParseKeyring(os.Getenv("PII_MASTER_KEYS"))
+//	crypt := fieldcrypt.New(keyring)
+//	sealed, err := crypt.Encrypt(ctx, user.Phone, "users.phone:"+user.ID)
+//	phone, err := crypt.Decrypt(ctx, sealed, "users.phone:"+user.ID)
+//
+// The associated data binds a ciphertext to its row and column, so a value
+// copied into another row fails to decrypt.
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks values written by this package and their format version
+const prefix = "enc:v1:"
+
+// dataKeySize is the size of the per-value AES-256 data keys
+const dataKeySize = 32
+
+// ErrMalformed is returned for values that are not ciphertexts of this
+// package
+var ErrMalformed = errors.New("fieldcrypt: malformed ciphertext")
+
+// KeyWrapper wraps and unwraps data keys with master keys, typically by
+// calling a KMS
+type KeyWrapper interface {
+	// Wrap encrypts dataKey with the current master key and returns that
+	// key's ID with the wrapped data key
+	Wrap(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap decrypts a data key wrapped by the master key keyID
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+	// CurrentKeyID returns the ID of the master key Wrap uses
+	CurrentKeyID() string
+}
+
+// Encryptor seals and opens field values
+type Encryptor struct {
+	keys KeyWrapper
+}
+
+// New creates an encryptor wrapping data keys with keys
+func New(keys KeyWrapper) *Encryptor {
+	return &Encryptor{keys: keys}
+}
+
+// Encrypt seals plaintext bound to associatedData, usually the table,
+// column and row ID of the value. Empty values stay empty so optional
+// fields need no special casing.
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext, associatedData string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(plaintext), []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+	keyID, wrapped, err := e.keys.Wrap(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return format(keyID, wrapped, sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with the same associated data
+func (e *Encryptor) Decrypt(ctx context.Context, ciphertext, associatedData string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	keyID, wrapped, sealed, err := parse(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := e.keys.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key with master key %q: %w", keyID, err)
+	}
+	plaintext, err := open(dataKey, sealed, []byte(associatedData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether ciphertext's data key is wrapped by a master
+// key other than the current one
+func (e *Encryptor) NeedsRewrap(ciphertext string) bool {
+	keyID, err := KeyID(ciphertext)
+	return err == nil && keyID != e.keys.CurrentKeyID()
+}
+
+// Rewrap re-wraps the data key of ciphertext with the current master key.
+// The sealed value itself is unchanged, so no plaintext is handled.
+func (e *Encryptor) Rewrap(ctx context.Context, ciphertext string) (string, error) {
+	if !e.NeedsRewrap(ciphertext) {
+		return ciphertext, nil
+	}
+	keyID, wrapped, sealed, err := parse(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := e.keys.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key with master key %q: %w", keyID, err)
+	}
+	newKeyID, rewrapped, err := e.keys.Wrap(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return format(newKeyID, rewrapped, sealed), nil
+}
+
+// KeyID returns the ID of the master key that wrapped ciphertext's data key
+func KeyID(ciphertext string) (string, error) {
+	keyID, _, _, err := parse(ciphertext)
+	return keyID, err
+}
+
+// IsEncrypted reports whether value looks like a ciphertext of this
+// package, e.g. to migrate plaintext columns
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// format encodes a ciphertext as enc:v1:<key ID>:<wrapped data key>:<sealed value>
+func format(keyID string, wrapped, sealed []byte) string {
+	return prefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+func parse(ciphertext string) (keyID string, wrapped, sealed []byte, err error) {
+	if !IsEncrypted(ciphertext) {
+		return "", nil, nil, ErrMalformed
+	}
+	parts := strings.Split(strings.TrimPrefix(ciphertext, prefix), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", nil, nil, ErrMalformed
+	}
+	if wrapped, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	if sealed, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	return parts[0], wrapped, sealed, nil
+}
+
+// seal encrypts plaintext with AES-GCM under key, prefixing the nonce
+func seal(key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// open reverses seal
+func open(key, sealed, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
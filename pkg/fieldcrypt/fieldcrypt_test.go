@@ -0,0 +1,106 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func newEncryptor(t *testing.T, spec string) *Encryptor {
+	t.Helper()
+	keyring, err := ParseKeyring(spec)
+	require.NoError(t, err)
+	return New(keyring)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	crypt := newEncryptor(t, "k1:"+testKey(1))
+
+	sealed, err := crypt.Encrypt(ctx, "+44 20 7946 0000", "users.phone:u1")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.NotContains(t, sealed, "7946")
+	keyID, err := KeyID(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+
+	again, err := crypt.Encrypt(ctx, "+44 20 7946 0000", "users.phone:u1")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every value gets its own data key and nonce")
+
+	phone, err := crypt.Decrypt(ctx, sealed, "users.phone:u1")
+	require.NoError(t, err)
+	assert.Equal(t, "+44 20 7946 0000", phone)
+
+	_, err = crypt.Decrypt(ctx, sealed, "users.phone:u2")
+	assert.Error(t, err, "a value moved to another row must not decrypt")
+}
+
+func TestEmptyValues(t *testing.T) {
+	ctx := context.Background()
+	crypt := newEncryptor(t, "k1:"+testKey(1))
+
+	sealed, err := crypt.Encrypt(ctx, "", "users.phone:u1")
+	require.NoError(t, err)
+	assert.Empty(t, sealed)
+
+	plain, err := crypt.Decrypt(ctx, "", "users.phone:u1")
+	require.NoError(t, err)
+	assert.Empty(t, plain)
+}
+
+func TestDecryptMalformed(t *testing.T) {
+	crypt := newEncryptor(t, "k1:"+testKey(1))
+
+	for _, value := range []string{"plaintext", "enc:v1:k1:zz", "enc:v1::AAAA:AAAA", "enc:v1:k1:!!:AAAA"} {
+		_, err := crypt.Decrypt(context.Background(), value, "aad")
+		assert.ErrorIs(t, err, ErrMalformed, value)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	ctx := context.Background()
+	old := newEncryptor(t, "k1:"+testKey(1))
+	sealed, err := old.Encrypt(ctx, "secret", "aad")
+	require.NoError(t, err)
+
+	rotated := newEncryptor(t, "k2:"+testKey(2)+", k1:"+testKey(1))
+	plain, err := rotated.Decrypt(ctx, sealed, "aad")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plain, "old keys still decrypt")
+
+	require.True(t, rotated.NeedsRewrap(sealed))
+	rewrapped, err := rotated.Rewrap(ctx, sealed)
+	require.NoError(t, err)
+	assert.False(t, rotated.NeedsRewrap(rewrapped))
+	assert.Equal(t, sealed[strings.LastIndex(sealed, ":"):], rewrapped[strings.LastIndex(rewrapped, ":"):], "the sealed value is unchanged")
+
+	retired := newEncryptor(t, "k2:"+testKey(2))
+	plain, err = retired.Decrypt(ctx, rewrapped, "aad")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plain)
+
+	_, err = retired.Decrypt(ctx, sealed, "aad")
+	assert.ErrorContains(t, err, `unknown master key "k1"`)
+}
+
+func TestParseKeyring(t *testing.T) {
+	keyring, err := ParseKeyring("a:" + testKey(1) + ",b:" + testKey(2))
+	require.NoError(t, err)
+	assert.Equal(t, "a", keyring.CurrentKeyID())
+
+	for _, spec := range []string{"", " , ", "a", "a:not-base64!", "a:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		_, err := ParseKeyring(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,85 @@
+package fieldcrypt
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// LocalKeyring wraps data keys with AES-256 master keys held in memory. It
+// stands in for a KMS where none is available; keep the keys in a secret
+// store, never in the repository.
+type LocalKeyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyWrapper = (*LocalKeyring)(nil)
+
+// NewLocalKeyring creates a keyring wrapping with the key current, which
+// must be in keys. The other keys are kept to unwrap data keys wrapped
+// before a rotation.
+func NewLocalKeyring(current string, keys map[string][]byte) (*LocalKeyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("fieldcrypt: current master key %q is not in the keyring", current)
+	}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("fieldcrypt: master key %q must be 32 bytes, got %d", id, len(key))
+		}
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("fieldcrypt: invalid master key ID %q", id)
+		}
+	}
+	return &LocalKeyring{current: current, keys: keys}, nil
+}
+
+// ParseKeyring parses a comma-separated list of <id>:<base64 key> master
+// keys, e.g. "2025-06:q3J...,2024-11:Zx9...". The first key is current.
+func ParseKeyring(spec string) (*LocalKeyring, error) {
+	keys := make(map[string][]byte)
+	current := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("fieldcrypt: master key entry %q is not <id>:<base64 key>", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: master key %q is not valid base64: %w", id, err)
+		}
+		if current == "" {
+			current = id
+		}
+		keys[id] = key
+	}
+	if current == "" {
+		return nil, fmt.Errorf("fieldcrypt: no master keys configured")
+	}
+	return NewLocalKeyring(current, keys)
+}
+
+// CurrentKeyID implements KeyWrapper
+func (k *LocalKeyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Wrap implements KeyWrapper
+func (k *LocalKeyring) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(k.keys[k.current], dataKey, []byte(k.current))
+	return k.current, wrapped, err
+}
+
+// Unwrap implements KeyWrapper
+func (k *LocalKeyring) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("fieldcrypt: unknown master key %q", keyID)
+	}
+	return open(key, wrapped, []byte(keyID))
+}
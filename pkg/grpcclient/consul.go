@@ -0,0 +1,206 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	consulWait       = 5 * time.Minute
+	consulMinBackoff = time.Second
+	consulMaxBackoff = 30 * time.Second
+)
+
+// consulBuilder resolves consul://[agent]/service[?tag=...&dc=...] targets to
+// the instances passing their Consul health checks
+type consulBuilder struct {
+	addr   string
+	token  string
+	client *http.Client
+	logger *slog.Logger
+}
+
+func newConsulBuilder(addr, token string, logger *slog.Logger) *consulBuilder {
+	return &consulBuilder{
+		addr:  addr,
+		token: token,
+		// Blocking queries hold the request for up to consulWait
+		client: &http.Client{Timeout: consulWait + 30*time.Second},
+		logger: logger,
+	}
+}
+
+func (b *consulBuilder) Scheme() string { return "consul" }
+
+func (b *consulBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := strings.Trim(target.URL.Path, "/")
+	if service == "" {
+		return nil, fmt.Errorf("consul target %q has no service name", target.URL.String())
+	}
+	agent := b.addr
+	if target.URL.Host != "" {
+		agent = target.URL.Host
+	}
+	if !strings.Contains(agent, "://") {
+		agent = "http://" + agent
+	}
+
+	query := url.Values{"passing": {"1"}}
+	for _, key := range []string{"tag", "dc"} {
+		if v := target.URL.Query().Get(key); v != "" {
+			query.Set(key, v)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &consulResolver{
+		builder: b,
+		url:     strings.TrimRight(agent, "/") + "/v1/health/service/" + url.PathEscape(service),
+		query:   query,
+		service: service,
+		cc:      cc,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	r.wg.Add(1)
+	go r.watch()
+	return r, nil
+}
+
+type consulResolver struct {
+	builder *consulBuilder
+	url     string
+	query   url.Values
+	service string
+	cc      resolver.ClientConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// watch follows the service's health with Consul blocking queries and
+// pushes every change to the connection
+func (r *consulResolver) watch() {
+	defer r.wg.Done()
+
+	var index uint64
+	backoff := consulMinBackoff
+	for {
+		addrs, next, err := r.fetch(index)
+		if r.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.builder.logger.Warn("Consul lookup failed", "service", r.service, "error", err)
+			r.cc.ReportError(err)
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, consulMaxBackoff)
+			continue
+		}
+		backoff = consulMinBackoff
+
+		// The wait expired without changes
+		if index != 0 && next == index {
+			continue
+		}
+		// Consul may reset its index; start over rather than block forever
+		if next < index {
+			next = 0
+		}
+		index = next
+
+		if len(addrs) == 0 {
+			r.cc.ReportError(fmt.Errorf("no healthy instances of %s in consul", r.service))
+		} else {
+			r.cc.UpdateState(resolver.State{Addresses: addrs})
+		}
+
+		// Without an index there is nothing to block on; poll instead
+		if index == 0 {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(consulMinBackoff):
+			}
+		}
+	}
+}
+
+type consulEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+func (r *consulResolver) fetch(index uint64) ([]resolver.Address, uint64, error) {
+	query := url.Values{}
+	for k, v := range r.query {
+		query[k] = v
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", consulWait.String())
+	}
+
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.builder.token != "" {
+		req.Header.Set("X-Consul-Token", r.builder.token)
+	}
+
+	resp, err := r.builder.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consul returned %s", resp.Status)
+	}
+
+	var entries []consulEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	addrs := make([]resolver.Address, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		addrs = append(addrs, resolver.Address{Addr: net.JoinHostPort(host, strconv.Itoa(e.Service.Port))})
+	}
+	return addrs, next, nil
+}
+
+// ResolveNow is a no-op: the blocking query already reports changes as
+// soon as Consul sees them
+func (r *consulResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *consulResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,156 @@
+// Package grpcclient builds gRPC client connections the same way for every
+// service: service discovery, round-robin load balancing across healthy
+// backends, transport credentials, propagation of request and trace IDs
+// and per-target dial options.
+//
+// Targets are ordinary gRPC target strings, so upstream addresses keep
+// coming from the existing *_ADDR variables:
+//
+//	inventory:50052                   DNS, re-resolved as instances come and go
+//	dns:///inventory.shop.svc:50052   the same, explicitly
+//	consul:///inventory               healthy instances registered in Consul
+//	consul://agent:8500/inventory?tag=primary&dc=eu1
+//
+// Connections balance round-robin over every resolved address and, unless
+// disabled, use the standard gRPC health checking protocol so a backend
+// reporting NOT_SERVING stops receiving requests. With a subset size set,
+// each client connects to a stable subset of the backends instead of all of
+// them, which bounds connection counts in large deployments.
+//
+//	clients := grpcclient.New(cfg.Discovery, creds, logger)
+//	clients.WithTargetOptions("inventory", grpc.WithUserAgent("product-service"))
+//	conn, err := clients.Dial("inventory", cfg.Inventory.Addr)
+package grpcclient
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // registers client-side health checking
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultConsulAddr is the local Consul agent used when CONSUL_HTTP_ADDR is unset
+const DefaultConsulAddr = "127.0.0.1:8500"
+
+// Options configures discovery and load balancing for all targets
+type Options struct {
+	ConsulAddr  string // Consul agent address, host:port or URL
+	ConsulToken string // ACL token sent to Consul
+
+	// SubsetSize limits how many backends each client connects to; 0
+	// connects to all of them
+	SubsetSize int
+	// SubsetKey picks this client's subset; clients with different keys
+	// spread over different backends. Defaults to the hostname.
+	SubsetKey string
+
+	// HealthCheck enables gRPC health checking of every backend
+	HealthCheck bool
+}
+
+// FromEnv reads the options from CONSUL_HTTP_ADDR, CONSUL_HTTP_TOKEN,
+// GRPC_SUBSET_SIZE and GRPC_HEALTH_CHECK (default true)
+func FromEnv() Options {
+	opts := Options{
+		ConsulAddr:  os.Getenv("CONSUL_HTTP_ADDR"),
+		ConsulToken: os.Getenv("CONSUL_HTTP_TOKEN"),
+		HealthCheck: true,
+	}
+	if opts.ConsulAddr == "" {
+		opts.ConsulAddr = DefaultConsulAddr
+	}
+	if n, err := strconv.Atoi(os.Getenv("GRPC_SUBSET_SIZE")); err == nil && n > 0 {
+		opts.SubsetSize = n
+	}
+	if b, err := strconv.ParseBool(os.Getenv("GRPC_HEALTH_CHECK")); err == nil {
+		opts.HealthCheck = b
+	}
+	return opts
+}
+
+// Factory creates client connections with the shared options
+type Factory struct {
+	opts      Options
+	creds     *mtls.Credentials
+	resolvers []resolver.Builder
+	logger    *slog.Logger
+
+	mu      sync.RWMutex
+	targets map[string][]grpc.DialOption
+}
+
+// New creates a factory. creds may be nil for plaintext connections.
+func New(opts Options, creds *mtls.Credentials, logger *slog.Logger) *Factory {
+	if opts.ConsulAddr == "" {
+		opts.ConsulAddr = DefaultConsulAddr
+	}
+	if opts.SubsetKey == "" {
+		opts.SubsetKey, _ = os.Hostname()
+	}
+
+	var consul resolver.Builder = newConsulBuilder(opts.ConsulAddr, opts.ConsulToken, logger)
+	dns := resolver.Get("dns")
+	if opts.SubsetSize > 0 {
+		consul = subsetBuilder{Builder: consul, size: opts.SubsetSize, key: opts.SubsetKey}
+		dns = subsetBuilder{Builder: dns, size: opts.SubsetSize, key: opts.SubsetKey}
+	}
+
+	return &Factory{
+		opts:      opts,
+		creds:     creds,
+		resolvers: []resolver.Builder{consul, dns},
+		logger:    logger,
+		targets:   make(map[string][]grpc.DialOption),
+	}
+}
+
+// WithTargetOptions adds dial options used only for the named target, after
+// the shared ones
+func (f *Factory) WithTargetOptions(name string, opts ...grpc.DialOption) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets[name] = append(f.targets[name], opts...)
+	return f
+}
+
+// DialOptions returns the dial options for the named target: transport
+// credentials, resolvers, load balancing, health checking and request-ID
+// and trace propagation, followed by the target's own options
+func (f *Factory) DialOptions(name string) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		f.creds.DialOption(),
+		grpc.WithResolvers(f.resolvers...),
+		grpc.WithDefaultServiceConfig(f.serviceConfig()),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor),
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append(opts, f.targets[name]...)
+}
+
+// Dial creates a connection to target for the named upstream. Like
+// grpc.NewClient it does not connect until the first RPC.
+func (f *Factory) Dial(name, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(target, append(f.DialOptions(name), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client for %s: %w", name, target, err)
+	}
+	f.logger.Info("Created gRPC client", "target", name, "addr", target, "subset", f.opts.SubsetSize, "healthCheck", f.opts.HealthCheck)
+	return conn, nil
+}
+
+func (f *Factory) serviceConfig() string {
+	if f.opts.HealthCheck {
+		return `{"loadBalancingConfig": [{"round_robin": {}}], "healthCheckConfig": {"serviceName": ""}}`
+	}
+	return `{"loadBalancingConfig": [{"round_robin": {}}]}`
+}
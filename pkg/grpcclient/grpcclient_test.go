@@ -0,0 +1,256 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/resolver"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func addrs(n int) []resolver.Address {
+	out := make([]resolver.Address, n)
+	for i := range out {
+		out[i] = resolver.Address{Addr: fmt.Sprintf("10.0.0.%d:50051", i+1)}
+	}
+	return out
+}
+
+func addrOf(a resolver.Address) string { return a.Addr }
+
+func TestSubsetIsStable(t *testing.T) {
+	all := addrs(10)
+	picked := subset(all, 3, "client-a", addrOf)
+	assert.Len(t, picked, 3)
+	assert.Equal(t, picked, subset(all, 3, "client-a", addrOf), "same key, same subset")
+
+	// Removing a backend outside the subset leaves it untouched
+	var rest []resolver.Address
+	removed := false
+	for _, a := range all {
+		if !removed && !contains(picked, a) {
+			removed = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	assert.Equal(t, picked, subset(rest, 3, "client-a", addrOf))
+
+	// Removing a backend inside it replaces only that one
+	var without []resolver.Address
+	for _, a := range all {
+		if a != picked[0] {
+			without = append(without, a)
+		}
+	}
+	assert.Subset(t, subset(without, 3, "client-a", addrOf), picked[1:])
+
+	assert.Equal(t, all[:2], subset(all[:2], 3, "client-a", addrOf), "fewer backends than the subset size")
+	assert.Equal(t, all, subset(all, 0, "client-a", addrOf), "subsetting disabled")
+}
+
+func contains(list []resolver.Address, a resolver.Address) bool {
+	for _, b := range list {
+		if b.Addr == a.Addr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSubsetSpreadsClients(t *testing.T) {
+	all := addrs(10)
+	used := map[string]int{}
+	for i := 0; i < 100; i++ {
+		for _, a := range subset(all, 2, fmt.Sprintf("pod-%d", i), addrOf) {
+			used[a.Addr]++
+		}
+	}
+	assert.Len(t, used, 10, "every backend gets clients")
+}
+
+// fakeConsul serves /v1/health/service/<name> from a mutable instance list
+type fakeConsul struct {
+	mu        sync.Mutex
+	instances map[string][]string // service -> host:port
+	index     uint64
+	changed   chan struct{}
+	queries   []url.Values
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, string) {
+	f := &fakeConsul{instances: map[string][]string{}, index: 1, changed: make(chan struct{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (f *fakeConsul) set(service string, instances ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.instances[service] = instances
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service := strings.TrimPrefix(r.URL.Path, "/v1/health/service/")
+	f.mu.Lock()
+	f.queries = append(f.queries, r.URL.Query())
+	index, changed := f.index, f.changed
+	f.mu.Unlock()
+
+	// Emulate a blocking query
+	if r.URL.Query().Get("index") == fmt.Sprint(index) {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var entries []map[string]any
+	for _, inst := range f.instances[service] {
+		host, port, _ := net.SplitHostPort(inst)
+		var p int
+		fmt.Sscan(port, &p)
+		entries = append(entries, map[string]any{
+			"Node":    map[string]any{"Address": host},
+			"Service": map[string]any{"Address": "", "Port": p},
+		})
+	}
+	w.Header().Set("X-Consul-Index", fmt.Sprint(f.index))
+	json.NewEncoder(w).Encode(entries)
+}
+
+// recordingConn captures the states a resolver pushes
+type recordingConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+}
+
+func (c *recordingConn) UpdateState(s resolver.State) error {
+	c.states <- s
+	return nil
+}
+
+func (c *recordingConn) ReportError(error) {}
+
+func TestConsulResolverFollowsChanges(t *testing.T) {
+	consul, agent := newFakeConsul(t)
+	consul.set("inventory", "10.0.0.1:50052", "10.0.0.2:50052")
+
+	cc := &recordingConn{states: make(chan resolver.State, 10)}
+	target := resolver.Target{URL: url.URL{Scheme: "consul", Host: agent, Path: "/inventory", RawQuery: "tag=primary"}}
+	r, err := newConsulBuilder("unused:8500", "", discardLogger()).Build(target, cc, resolver.BuildOptions{})
+	require.NoError(t, err)
+	defer r.Close()
+
+	state := <-cc.states
+	assert.Equal(t, []resolver.Address{{Addr: "10.0.0.1:50052"}, {Addr: "10.0.0.2:50052"}}, state.Addresses)
+
+	consul.set("inventory", "10.0.0.2:50052")
+	state = <-cc.states
+	assert.Equal(t, []resolver.Address{{Addr: "10.0.0.2:50052"}}, state.Addresses)
+
+	consul.mu.Lock()
+	first := consul.queries[0]
+	consul.mu.Unlock()
+	assert.Equal(t, "1", first.Get("passing"))
+	assert.Equal(t, "primary", first.Get("tag"))
+}
+
+func TestConsulTargetWithoutService(t *testing.T) {
+	_, err := newConsulBuilder(DefaultConsulAddr, "", discardLogger()).Build(
+		resolver.Target{URL: url.URL{Scheme: "consul", Path: "/"}}, &recordingConn{}, resolver.BuildOptions{})
+	assert.Error(t, err)
+}
+
+// startBackend runs a gRPC server exposing only the health service
+func startBackend(t *testing.T) (string, *health.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), hs
+}
+
+func TestDialBalancesOverHealthyBackends(t *testing.T) {
+	a, healthA := startBackend(t)
+	b, _ := startBackend(t)
+	consul, agent := newFakeConsul(t)
+	consul.set("product", a, b)
+
+	factory := New(Options{ConsulAddr: agent, HealthCheck: true}, nil, discardLogger())
+	conn, err := factory.Dial("product", "consul:///product")
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	served := func(n int) map[string]int {
+		seen := map[string]int{}
+		for i := 0; i < n; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			var p peer.Peer
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true), grpc.Peer(&p))
+			cancel()
+			require.NoError(t, err)
+			seen[p.Addr.String()]++
+		}
+		return seen
+	}
+
+	assert.Eventually(t, func() bool { return len(served(10)) == 2 }, 5*time.Second, 50*time.Millisecond,
+		"requests are spread over both backends")
+
+	healthA.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	assert.Eventually(t, func() bool {
+		seen := served(10)
+		return len(seen) == 1 && seen[b] == 10
+	}, 5*time.Second, 50*time.Millisecond, "the unhealthy backend is skipped")
+}
+
+func TestTargetOptionsApplyOnlyToTheirTarget(t *testing.T) {
+	factory := New(Options{}, nil, discardLogger())
+	factory.WithTargetOptions("inventory", grpc.WithUserAgent("test"))
+
+	assert.Len(t, factory.DialOptions("inventory"), len(factory.DialOptions("fx"))+1)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_ADDR", "consul:8500")
+	t.Setenv("GRPC_SUBSET_SIZE", "3")
+	t.Setenv("GRPC_HEALTH_CHECK", "false")
+
+	opts := FromEnv()
+	assert.Equal(t, "consul:8500", opts.ConsulAddr)
+	assert.Equal(t, 3, opts.SubsetSize)
+	assert.False(t, opts.HealthCheck)
+}
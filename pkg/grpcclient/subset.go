@@ -0,0 +1,68 @@
+package grpcclient
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// subsetBuilder wraps a resolver so that only a subset of the resolved
+// addresses reaches the load balancer
+type subsetBuilder struct {
+	resolver.Builder
+	size int
+	key  string
+}
+
+func (b subsetBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	return b.Builder.Build(target, &subsetConn{ClientConn: cc, size: b.size, key: b.key}, opts)
+}
+
+type subsetConn struct {
+	resolver.ClientConn
+	size int
+	key  string
+}
+
+func (c *subsetConn) UpdateState(state resolver.State) error {
+	state.Addresses = subset(state.Addresses, c.size, c.key, func(a resolver.Address) string {
+		return a.Addr
+	})
+	state.Endpoints = subset(state.Endpoints, c.size, c.key, func(e resolver.Endpoint) string {
+		if len(e.Addresses) == 0 {
+			return ""
+		}
+		return e.Addresses[0].Addr
+	})
+	return c.ClientConn.UpdateState(state)
+}
+
+// subset picks size items by rendezvous hashing of key and each item's
+// address. A client keeps the same subset as long as its backends stay, and
+// losing one backend replaces only that backend.
+func subset[T any](items []T, size int, key string, addr func(T) string) []T {
+	if size <= 0 || len(items) <= size {
+		return items
+	}
+
+	type scored struct {
+		item  T
+		score uint64
+	}
+	all := make([]scored, len(items))
+	for i, item := range items {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(addr(item)))
+		all[i] = scored{item: item, score: h.Sum64()}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+
+	picked := make([]T, size)
+	for i := range picked {
+		picked[i] = all[i].item
+	}
+	return picked
+}
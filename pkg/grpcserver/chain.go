@@ -0,0 +1,67 @@
+package grpcserver
+
+import (
+	"log/slog"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"google.golang.org/grpc"
+)
+
+// Chain is the interceptor chain in front of a server's handlers, the gRPC
+// counterpart of the HTTP middleware stack. Each call, in order:
+//
+//   - gets its request ID and trace from the x-request-id and traceparent
+//     metadata, or new ones;
+//   - gets a request-scoped logger, and an access log line when it ends;
+//   - has panics recovered into Internal errors;
+//   - is counted and timed by method;
+//   - runs the extra Unary or Stream interceptors, such as authentication;
+//   - is bounded by the timeout of its method (unary calls only).
+//
+// A service builds it once and passes its options to grpc.NewServer:
+//
+//	chain := grpcserver.Chain{Logger: logger, Metrics: metrics, Endpoints: endpoints}
+//	server := grpc.NewServer(append(cfg.ServerOptions(maxBodyBytes), chain.ServerOptions()...)...)
+type Chain struct {
+	Logger    *slog.Logger
+	AccessLog logging.AccessLogOptions
+	// Metrics records calls by method; nil disables it
+	Metrics *middleware.Metrics
+	// Endpoints bounds each call by its method's timeout; nil leaves
+	// calls to their client deadlines
+	Endpoints *middleware.EndpointPolicies
+
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// ServerOptions returns the options installing the chain
+func (c Chain) ServerOptions() []grpc.ServerOption {
+	recovery := middleware.Recovery{Logger: c.Logger, Metrics: c.Metrics}
+	unary := []grpc.UnaryServerInterceptor{
+		middleware.RequestIDUnary,
+		logging.UnaryServerInterceptor(c.Logger),
+		logging.AccessLogUnary(c.Logger, c.AccessLog),
+		recovery.Unary,
+	}
+	streams := []grpc.StreamServerInterceptor{
+		middleware.RequestIDStream,
+		logging.StreamServerInterceptor(c.Logger),
+		logging.AccessLogStream(c.Logger, c.AccessLog),
+		recovery.Stream,
+	}
+	if c.Metrics != nil {
+		unary = append(unary, c.Metrics.UnaryServerInterceptor)
+		streams = append(streams, c.Metrics.StreamServerInterceptor)
+	}
+	unary = append(unary, c.Unary...)
+	streams = append(streams, c.Stream...)
+	if c.Endpoints != nil {
+		unary = append(unary, middleware.EndpointLimitsUnary(c.Endpoints))
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	}
+}
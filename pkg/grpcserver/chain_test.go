@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves the health service behind chain and returns a client for it
+func dial(t *testing.T, chain Chain) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(chain.ServerOptions()...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestChain(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logging.New(&logs, logging.Options{Service: "test"})
+	reg := prometheus.NewRegistry()
+	metrics := middleware.NewMetrics(reg, "test")
+
+	var requestID string
+	client := dial(t, Chain{
+		Logger:    logger,
+		AccessLog: logging.AccessLogOptions{SampleRate: 1},
+		Metrics:   metrics,
+		Endpoints: &middleware.EndpointPolicies{Default: middleware.EndpointPolicy{Timeout: middleware.Duration(time.Second), MaxBodyBytes: 16}},
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				requestID = logging.RequestID(ctx)
+				return handler(ctx, req)
+			},
+		},
+	})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), logging.RequestIDMetadataKey, "req-1")
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	assert.Equal(t, "req-1", requestID, "the request ID comes from the metadata")
+	assert.Contains(t, header.Get(logging.RequestIDMetadataKey), "req-1")
+	assert.Contains(t, logs.String(), `"request_id":"req-1"`, "the call is access logged")
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "test_grpc_server_handled_total"))
+
+	// Calls are held to the endpoint policy
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "a-service-name-over-the-limit"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestChainRecoversPanics(t *testing.T) {
+	client := dial(t, Chain{
+		Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				panic("boom")
+			},
+		},
+	})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
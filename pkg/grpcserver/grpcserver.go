@@ -0,0 +1,197 @@
+// Package grpcserver tunes gRPC servers the same way for every service:
+// message size limits, stream and connection limits, and keepalive, and
+// the interceptor chain in front of the handlers (see Chain).
+//
+// gRPC's defaults suit neither large bulk requests nor load balancers that
+// drop connections idle for a few minutes, so each setting can be changed
+// through a GRPC_* environment variable (see FromEnv). Zero keeps gRPC's
+// default.
+//
+//	cfg := grpcserver.FromEnv()
+//	...
+//	server := grpc.NewServer(append(cfg.ServerOptions(maxBodyBytes), creds.ServerOption(), ...)...)
+//	lis, err := net.Listen("tcp", addr)
+//	...
+//	server.Serve(cfg.Listener(lis))
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/netutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Config holds the gRPC server settings
+type Config struct {
+	// MaxRecvMsgBytes and MaxSendMsgBytes bound the size of a message;
+	// received messages are otherwise bounded by the service's body limit
+	MaxRecvMsgBytes int
+	MaxSendMsgBytes int
+
+	// MaxConcurrentStreams bounds the calls in flight on one connection
+	MaxConcurrentStreams uint32
+	// MaxConnections bounds the open connections on each listener; further
+	// clients wait until one closes
+	MaxConnections int
+
+	// The server pings a client after KeepaliveTime without activity and
+	// closes the connection when no answer comes within KeepaliveTimeout.
+	// A KeepaliveTime below the load balancer's idle timeout keeps
+	// connections through it open.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// Clients pinging more often than every KeepaliveMinTime, or while no
+	// call is in flight unless KeepalivePermitWithoutStream, are
+	// disconnected
+	KeepaliveMinTime             time.Duration
+	KeepalivePermitWithoutStream bool
+
+	// Connections are closed after MaxConnectionIdle without calls, and
+	// after MaxConnectionAge, giving calls in flight MaxConnectionAgeGrace
+	// to finish. Ageing connections out makes clients reconnect, and so
+	// spreads them over new instances.
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+
+	// problems lists the malformed environment values seen by FromEnv
+	problems []string
+}
+
+// FromEnv reads GRPC_MAX_RECV_MSG_BYTES, GRPC_MAX_SEND_MSG_BYTES,
+// GRPC_MAX_CONCURRENT_STREAMS, GRPC_MAX_CONNECTIONS, GRPC_KEEPALIVE_TIME,
+// GRPC_KEEPALIVE_TIMEOUT, GRPC_KEEPALIVE_MIN_TIME,
+// GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM, GRPC_MAX_CONNECTION_IDLE,
+// GRPC_MAX_CONNECTION_AGE and GRPC_MAX_CONNECTION_AGE_GRACE. Malformed
+// values are left at zero and reported by Validate.
+func FromEnv() Config {
+	var cfg Config
+	cfg.MaxRecvMsgBytes = cfg.envInt("GRPC_MAX_RECV_MSG_BYTES")
+	cfg.MaxSendMsgBytes = cfg.envInt("GRPC_MAX_SEND_MSG_BYTES")
+	if n := cfg.envInt("GRPC_MAX_CONCURRENT_STREAMS"); n > 0 && n <= math.MaxUint32 {
+		cfg.MaxConcurrentStreams = uint32(n)
+	} else if n != 0 {
+		cfg.problems = append(cfg.problems, fmt.Sprintf("GRPC_MAX_CONCURRENT_STREAMS=%d must be between 1 and %d", n, uint32(math.MaxUint32)))
+	}
+	cfg.MaxConnections = cfg.envInt("GRPC_MAX_CONNECTIONS")
+	cfg.KeepaliveTime = cfg.envDuration("GRPC_KEEPALIVE_TIME")
+	cfg.KeepaliveTimeout = cfg.envDuration("GRPC_KEEPALIVE_TIMEOUT")
+	cfg.KeepaliveMinTime = cfg.envDuration("GRPC_KEEPALIVE_MIN_TIME")
+	if value := os.Getenv("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM"); value != "" {
+		permit, err := strconv.ParseBool(value)
+		if err != nil {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM=%q must be a boolean (true or false)", value))
+		}
+		cfg.KeepalivePermitWithoutStream = permit
+	}
+	cfg.MaxConnectionIdle = cfg.envDuration("GRPC_MAX_CONNECTION_IDLE")
+	cfg.MaxConnectionAge = cfg.envDuration("GRPC_MAX_CONNECTION_AGE")
+	cfg.MaxConnectionAgeGrace = cfg.envDuration("GRPC_MAX_CONNECTION_AGE_GRACE")
+	return cfg
+}
+
+func (c *Config) envInt(key string) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		c.problems = append(c.problems, fmt.Sprintf("%s=%q must be an integer", key, value))
+	}
+	return n
+}
+
+func (c *Config) envDuration(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		c.problems = append(c.problems, fmt.Sprintf("%s=%q must be a duration with a unit, e.g. 30s or 5m", key, value))
+	}
+	return d
+}
+
+// Validate reports malformed, negative and inconsistent settings
+func (c Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	for _, setting := range []struct {
+		key      string
+		negative bool
+	}{
+		{"GRPC_MAX_RECV_MSG_BYTES", c.MaxRecvMsgBytes < 0},
+		{"GRPC_MAX_SEND_MSG_BYTES", c.MaxSendMsgBytes < 0},
+		{"GRPC_MAX_CONNECTIONS", c.MaxConnections < 0},
+		{"GRPC_KEEPALIVE_TIME", c.KeepaliveTime < 0},
+		{"GRPC_KEEPALIVE_TIMEOUT", c.KeepaliveTimeout < 0},
+		{"GRPC_KEEPALIVE_MIN_TIME", c.KeepaliveMinTime < 0},
+		{"GRPC_MAX_CONNECTION_IDLE", c.MaxConnectionIdle < 0},
+		{"GRPC_MAX_CONNECTION_AGE", c.MaxConnectionAge < 0},
+		{"GRPC_MAX_CONNECTION_AGE_GRACE", c.MaxConnectionAgeGrace < 0},
+	} {
+		if setting.negative {
+			problems = append(problems, setting.key+" must not be negative; use 0 for the default")
+		}
+	}
+	if c.KeepaliveTime > 0 && c.KeepaliveTime < time.Second {
+		problems = append(problems, "GRPC_KEEPALIVE_TIME must be at least 1s")
+	}
+	if c.MaxConnectionAgeGrace > 0 && c.MaxConnectionAge == 0 {
+		problems = append(problems, "GRPC_MAX_CONNECTION_AGE_GRACE requires GRPC_MAX_CONNECTION_AGE")
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ServerOptions returns the options applying the settings. Received
+// messages are bounded by defaultMaxRecvBytes, such as the service's body
+// limit, unless MaxRecvMsgBytes is set; neither applies when both are 0.
+func (c Config) ServerOptions(defaultMaxRecvBytes int64) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  c.KeepaliveTime,
+			Timeout:               c.KeepaliveTimeout,
+			MaxConnectionIdle:     c.MaxConnectionIdle,
+			MaxConnectionAge:      c.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.KeepaliveMinTime,
+			PermitWithoutStream: c.KeepalivePermitWithoutStream,
+		}),
+	}
+	if c.MaxRecvMsgBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(c.MaxRecvMsgBytes))
+	} else if defaultMaxRecvBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(defaultMaxRecvBytes)))
+	}
+	if c.MaxSendMsgBytes > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(c.MaxSendMsgBytes))
+	}
+	if c.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(c.MaxConcurrentStreams))
+	}
+	return opts
+}
+
+// Listener limits lis to MaxConnections open connections, when set
+func (c Config) Listener(lis net.Listener) net.Listener {
+	if c.MaxConnections > 0 {
+		return netutil.LimitListener(lis, c.MaxConnections)
+	}
+	return lis
+}
@@ -0,0 +1,90 @@
+package grpcserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("GRPC_MAX_RECV_MSG_BYTES", "16777216")
+	t.Setenv("GRPC_MAX_CONCURRENT_STREAMS", "500")
+	t.Setenv("GRPC_KEEPALIVE_TIME", "30s")
+	t.Setenv("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", "true")
+	t.Setenv("GRPC_MAX_CONNECTION_AGE", "30m")
+	t.Setenv("GRPC_MAX_CONNECTION_AGE_GRACE", "1m")
+
+	cfg := FromEnv()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, 16<<20, cfg.MaxRecvMsgBytes)
+	assert.Equal(t, uint32(500), cfg.MaxConcurrentStreams)
+	assert.Equal(t, 30*time.Second, cfg.KeepaliveTime)
+	assert.True(t, cfg.KeepalivePermitWithoutStream)
+	assert.Equal(t, 30*time.Minute, cfg.MaxConnectionAge)
+	assert.Zero(t, cfg.MaxSendMsgBytes, "unset keeps the default")
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("GRPC_KEEPALIVE_TIME", "30")
+	t.Setenv("GRPC_MAX_CONCURRENT_STREAMS", "-1")
+	t.Setenv("GRPC_MAX_CONNECTIONS", "many")
+	err := FromEnv().Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `GRPC_KEEPALIVE_TIME="30" must be a duration`)
+	assert.Contains(t, err.Error(), "GRPC_MAX_CONCURRENT_STREAMS=-1")
+	assert.Contains(t, err.Error(), `GRPC_MAX_CONNECTIONS="many"`)
+
+	for name, cfg := range map[string]Config{
+		"negative size":   {MaxRecvMsgBytes: -1},
+		"short keepalive": {KeepaliveTime: 100 * time.Millisecond},
+		"grace alone":     {MaxConnectionAgeGrace: time.Minute},
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	assert.Len(t, Config{}.ServerOptions(0), 2, "keepalive only")
+	assert.Len(t, Config{}.ServerOptions(1<<20), 3, "the default receive limit")
+	assert.Len(t, Config{MaxRecvMsgBytes: 1 << 24, MaxSendMsgBytes: 1 << 24, MaxConcurrentStreams: 100}.ServerOptions(1<<20), 5)
+}
+
+func TestListener(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	assert.Same(t, lis, Config{}.Listener(lis))
+
+	limited := Config{MaxConnections: 1}.Listener(lis)
+	first, err := net.Dial("tcp", lis.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	accepted, err := limited.Accept()
+	require.NoError(t, err)
+
+	second, err := net.Dial("tcp", lis.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	next := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := limited.Accept(); err == nil {
+			next <- conn
+		}
+	}()
+	select {
+	case <-next:
+		t.Fatal("accepted a connection beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	accepted.Close()
+	select {
+	case conn := <-next:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("did not accept once a connection closed")
+	}
+}
@@ -0,0 +1,271 @@
+// Package httptls serves the REST APIs over HTTPS, with HTTP/2, the same
+// way for every service. Each service reads the HTTP_TLS_* environment
+// variables (see FromEnv); without them it keeps serving plaintext.
+//
+//	tlsConfig, err := httptls.Load(ctx, cfg.HTTPTLS, logger)
+//	...
+//	server := &http.Server{Addr: addr, Handler: router, TLSConfig: tlsConfig}
+//	go httptls.ListenAndServe(server)
+//	if redirect := cfg.HTTPTLS.RedirectServer(addr); redirect != nil {
+//		go redirect.ListenAndServe()
+//	}
+//
+// Certificate files are reloaded when they change, as with pkg/mtls. For
+// development, a self-signed certificate can be generated at startup
+// instead.
+package httptls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/mtls"
+)
+
+// selfSignedValidity is how long generated certificates are valid
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// h2CipherSuites are the TLS 1.2 suites HTTP/2 requires one of
+var h2CipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+}
+
+// Config describes how a REST server is served over TLS
+type Config struct {
+	CertFile string // PEM certificate chain
+	KeyFile  string // PEM private key for CertFile
+
+	// SelfSigned generates a certificate for localhost at startup when no
+	// files are set. Browsers and clients do not trust it; it is meant for
+	// development.
+	SelfSigned bool
+
+	// MinVersion is the oldest TLS version accepted, tls.VersionTLS12 by
+	// default
+	MinVersion uint16
+	// CipherSuites restricts the TLS 1.2 cipher suites; Go's defaults when
+	// empty. TLS 1.3 suites are not configurable.
+	CipherSuites []uint16
+
+	// RedirectAddr, when set, is a plaintext listener that redirects every
+	// request to HTTPS
+	RedirectAddr string
+
+	// problems lists the malformed environment values seen by FromEnv
+	problems []string
+}
+
+// FromEnv reads HTTP_TLS_CERT_FILE, HTTP_TLS_KEY_FILE, HTTP_TLS_SELF_SIGNED,
+// HTTP_TLS_MIN_VERSION (1.2 or 1.3), HTTP_TLS_CIPHER_SUITES (comma-separated
+// Go names such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) and
+// HTTP_TLS_REDIRECT_ADDR. Malformed values are reported by Validate.
+func FromEnv() Config {
+	cfg := Config{
+		CertFile:     os.Getenv("HTTP_TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("HTTP_TLS_KEY_FILE"),
+		MinVersion:   tls.VersionTLS12,
+		RedirectAddr: os.Getenv("HTTP_TLS_REDIRECT_ADDR"),
+	}
+	if value := os.Getenv("HTTP_TLS_SELF_SIGNED"); value != "" {
+		selfSigned, err := strconv.ParseBool(value)
+		if err != nil {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("HTTP_TLS_SELF_SIGNED=%q must be a boolean (true or false)", value))
+		}
+		cfg.SelfSigned = selfSigned
+	}
+	switch value := os.Getenv("HTTP_TLS_MIN_VERSION"); value {
+	case "", "1.2":
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		cfg.problems = append(cfg.problems, fmt.Sprintf("HTTP_TLS_MIN_VERSION=%q must be 1.2 or 1.3", value))
+	}
+	for _, name := range strings.Split(os.Getenv("HTTP_TLS_CIPHER_SUITES"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		id, ok := cipherSuite(name)
+		if !ok {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("HTTP_TLS_CIPHER_SUITES has unknown or insecure suite %q", name))
+			continue
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+	return cfg
+}
+
+// cipherSuite looks up a secure TLS 1.2 cipher suite by name
+func cipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name && slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// Enabled reports whether the server is served over TLS
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.SelfSigned
+}
+
+// Validate reports malformed and inconsistent settings
+func (c Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		problems = append(problems, "HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together")
+	}
+	if c.CertFile != "" && c.SelfSigned {
+		problems = append(problems, "HTTP_TLS_SELF_SIGNED cannot be combined with HTTP_TLS_CERT_FILE")
+	}
+	if len(c.CipherSuites) > 0 && !slices.ContainsFunc(c.CipherSuites, func(id uint16) bool { return slices.Contains(h2CipherSuites, id) }) {
+		problems = append(problems, "HTTP_TLS_CIPHER_SUITES must include TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, which HTTP/2 requires")
+	}
+	if c.RedirectAddr != "" && !c.Enabled() {
+		problems = append(problems, "HTTP_TLS_REDIRECT_ADDR requires HTTP_TLS_CERT_FILE or HTTP_TLS_SELF_SIGNED")
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Load returns the TLS configuration for a REST server, or nil when TLS is
+// not enabled. Certificate files are checked for changes until ctx is done.
+func Load(ctx context.Context, cfg Config, logger *slog.Logger) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   cfg.MinVersion,
+		CipherSuites: cfg.CipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	if cfg.SelfSigned {
+		cert, err := selfSigned()
+		if err != nil {
+			return nil, err
+		}
+		logger.Warn("Serving HTTPS with a generated self-signed certificate; use it for development only")
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		return tlsConfig, nil
+	}
+
+	creds, err := mtls.Load(mtls.Config{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load HTTP TLS certificate: %w", err)
+	}
+	go creds.Watch(ctx)
+	tlsConfig.GetCertificate = creds.GetCertificate
+	return tlsConfig, nil
+}
+
+// selfSigned generates a certificate for localhost and the host name
+func selfSigned() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if host, err := os.Hostname(); err == nil && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create self-signed certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: template}, nil
+}
+
+// ListenAndServe serves srv over TLS when it has a TLS configuration, and
+// plaintext otherwise
+func ListenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// Serve serves srv on lis over TLS when it has a TLS configuration, and
+// plaintext otherwise
+func Serve(srv *http.Server, lis net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(lis, "", "")
+	}
+	return srv.Serve(lis)
+}
+
+// RedirectServer returns a server on RedirectAddr redirecting requests to
+// the same path over HTTPS on the port of httpsAddr, or nil without
+// RedirectAddr
+func (c Config) RedirectServer(httpsAddr string) *http.Server {
+	if c.RedirectAddr == "" {
+		return nil
+	}
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return &http.Server{
+		Addr:              c.RedirectAddr,
+		Handler:           Redirect(port),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// Redirect permanently redirects requests to HTTPS on port, keeping the
+// host, path and query. The port is left out when it is 443 or empty.
+func Redirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		// 308 keeps the method and body of API calls
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
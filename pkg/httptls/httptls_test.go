@@ -0,0 +1,102 @@
+package httptls
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("HTTP_TLS_SELF_SIGNED", "true")
+	t.Setenv("HTTP_TLS_MIN_VERSION", "1.3")
+	t.Setenv("HTTP_TLS_CIPHER_SUITES", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256")
+	t.Setenv("HTTP_TLS_REDIRECT_ADDR", ":8080")
+
+	cfg := FromEnv()
+	require.NoError(t, cfg.Validate())
+	assert.True(t, cfg.Enabled())
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}, cfg.CipherSuites)
+	assert.Equal(t, ":8080", cfg.RedirectAddr)
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("HTTP_TLS_MIN_VERSION", "1.0")
+	t.Setenv("HTTP_TLS_CIPHER_SUITES", "TLS_RSA_WITH_RC4_128_SHA")
+	err := FromEnv().Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP_TLS_MIN_VERSION")
+	assert.Contains(t, err.Error(), `insecure suite "TLS_RSA_WITH_RC4_128_SHA"`)
+
+	for name, cfg := range map[string]Config{
+		"cert without key":  {CertFile: "cert.pem"},
+		"cert and generate": {CertFile: "cert.pem", KeyFile: "key.pem", SelfSigned: true},
+		"no h2 suite":       {SelfSigned: true, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}},
+		"redirect alone":    {RedirectAddr: ":80"},
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+
+	tlsConfig, err := Load(context.Background(), Config{}, discard)
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig, "plaintext without TLS settings")
+}
+
+func TestSelfSignedServesHTTP2(t *testing.T) {
+	tlsConfig, err := Load(context.Background(), Config{SelfSigned: true}, discard)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Proto)
+		}),
+		TLSConfig: tlsConfig,
+	}
+	go srv.ServeTLS(lis, "", "")
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get("https://" + lis.Addr().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", string(body))
+	assert.Contains(t, resp.TLS.PeerCertificates[0].DNSNames, "localhost")
+}
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		port, host, want string
+	}{
+		{"443", "shop.example.com", "https://shop.example.com/v1/products?page=2"},
+		{"8443", "shop.example.com:8080", "https://shop.example.com:8443/v1/products?page=2"},
+		{"8443", "[::1]:8080", "https://[::1]:8443/v1/products?page=2"},
+		{"", "[::1]:8080", "https://[::1]/v1/products?page=2"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://"+tt.host+"/v1/products?page=2", nil)
+		rec := httptest.NewRecorder()
+		Redirect(tt.port).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, tt.want, rec.Header().Get("Location"), tt.host)
+	}
+
+	assert.Nil(t, Config{}.RedirectServer(":8443"))
+	assert.Equal(t, ":8080", Config{RedirectAddr: ":8080"}.RedirectServer(":8443").Addr)
+}
@@ -0,0 +1,186 @@
+// Package leader elects one replica of a service to run its heavier
+// workers, such as reindexing, while the others stand by and take over
+// when it goes away.
+//
+// Leadership is a lease from pkg/locks, held for as long as the leader
+// renews it. Workers consult IsLeader before each round, and use Term for
+// work that must stop as soon as leadership is lost:
+//
+//	elector := leader.New(locker, "search-reindex", leader.Options{Metrics: metrics}, logger)
+//	go elector.Run(ctx)
+//	...
+//	if elector.IsLeader() {
+//		reindexCtx, cancel := context.WithTimeout(elector.Term(), timeout)
+//		...
+//	}
+//
+// A leader that crashes stops renewing and is replaced once its lease
+// expires; one that shuts down resigns, and is replaced within
+// RetryInterval.
+package leader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options configures an Elector
+type Options struct {
+	// RetryInterval is how often a replica that is not the leader tries to
+	// become it (default 5s)
+	RetryInterval time.Duration
+	// Metrics records leadership; nil records nothing
+	Metrics *Metrics
+}
+
+// Elector campaigns for the leadership of one election
+type Elector struct {
+	locker *locks.Locker
+	name   string
+	opts   Options
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	term context.Context // nil while not the leader
+}
+
+// New creates an elector for the election name. Without a locker there is
+// no election and the replica leads as soon as Run starts, which suits a
+// single replica.
+func New(locker *locks.Locker, name string, opts Options, logger *slog.Logger) *Elector {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 5 * time.Second
+	}
+	return &Elector{locker: locker, name: name, opts: opts, logger: logger}
+}
+
+// IsLeader reports whether this replica currently leads
+func (e *Elector) IsLeader() bool {
+	return e.Term().Err() == nil
+}
+
+// Term returns a context that lasts as long as the current leadership, or
+// an already cancelled one while this replica is not the leader
+func (e *Elector) Term() context.Context {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term == nil {
+		return cancelled
+	}
+	return e.term
+}
+
+var cancelled = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// Run campaigns until ctx is done, leading whenever the lease is won, and
+// resigns on return
+func (e *Elector) Run(ctx context.Context) {
+	e.opts.Metrics.observe(e.name, false)
+	if e.locker == nil {
+		e.lead(ctx)
+		<-ctx.Done()
+		e.follow("resigned")
+		return
+	}
+
+	ticker := time.NewTicker(e.opts.RetryInterval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		lock, err := e.locker.TryLock(ctx, e.name)
+		switch {
+		case err == nil:
+			e.lead(lock.Context())
+			<-lock.Context().Done()
+			reason := "lost"
+			if ctx.Err() != nil {
+				reason = "resigned"
+			}
+			e.follow(reason)
+			if err := lock.Unlock(); err != nil {
+				e.logger.Warn("Failed to resign leadership", "election", e.name, "error", err)
+			}
+		case !errors.Is(err, locks.ErrLocked) && ctx.Err() == nil:
+			e.logger.Warn("Failed to campaign for leadership", "election", e.name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) lead(term context.Context) {
+	e.mu.Lock()
+	e.term = term
+	e.mu.Unlock()
+	e.logger.Info("Became the leader", "election", e.name)
+	e.opts.Metrics.observe(e.name, true)
+	e.opts.Metrics.changed(e.name, "elected")
+}
+
+func (e *Elector) follow(reason string) {
+	e.mu.Lock()
+	e.term = nil
+	e.mu.Unlock()
+	if reason == "lost" {
+		e.logger.Warn("Lost the leadership", "election", e.name)
+	} else {
+		e.logger.Info("Resigned the leadership", "election", e.name)
+	}
+	e.opts.Metrics.observe(e.name, false)
+	e.opts.Metrics.changed(e.name, reason)
+}
+
+// Metrics holds the Prometheus collectors for the elections of one process
+type Metrics struct {
+	isLeader *prometheus.GaugeVec
+	changes  *prometheus.CounterVec
+}
+
+// NewMetrics creates the leadership metrics under namespace and registers
+// them with reg
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		isLeader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "leader_is_leader",
+			Help:      "Whether this replica leads the election: 1 leader, 0 standing by.",
+		}, []string{"election"}),
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "leader_changes_total",
+			Help:      "Leadership changes of this replica by election and change: elected, lost or resigned.",
+		}, []string{"election", "change"}),
+	}
+	reg.MustRegister(m.isLeader, m.changes)
+	return m
+}
+
+func (m *Metrics) observe(election string, leader bool) {
+	if m == nil {
+		return
+	}
+	value := 0.0
+	if leader {
+		value = 1
+	}
+	m.isLeader.WithLabelValues(election).Set(value)
+}
+
+func (m *Metrics) changed(election, change string) {
+	if m == nil {
+		return
+	}
+	m.changes.WithLabelValues(election, change).Inc()
+}
@@ -0,0 +1,100 @@
+package leader
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestElector(store locks.Store, owner string, metrics *Metrics) *Elector {
+	locker := locks.New(store, locks.Options{TTL: 300 * time.Millisecond, Owner: owner}, discard)
+	return New(locker, "reindex", Options{RetryInterval: 10 * time.Millisecond, Metrics: metrics}, discard)
+}
+
+func TestFailover(t *testing.T) {
+	store := locks.NewMemoryStore()
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	a := newTestElector(store, "a", metrics)
+	b := newTestElector(store, "b", nil)
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA)
+		close(doneA)
+	}()
+	require.Eventually(t, a.IsLeader, time.Second, 5*time.Millisecond)
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go b.Run(ctxB)
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, b.IsLeader(), "one leader at a time")
+	assert.Error(t, b.Term().Err())
+	term := a.Term()
+	assert.NoError(t, term.Err())
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.isLeader.WithLabelValues("reindex")))
+
+	// a shuts down and resigns; b takes over without waiting for the TTL
+	stopA()
+	<-doneA
+	assert.Error(t, term.Err(), "the term ends with the leadership")
+	assert.False(t, a.IsLeader())
+	require.Eventually(t, b.IsLeader, 100*time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.isLeader.WithLabelValues("reindex")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.changes.WithLabelValues("reindex", "elected")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.changes.WithLabelValues("reindex", "resigned")))
+}
+
+func TestLostLeadership(t *testing.T) {
+	store := locks.NewMemoryStore()
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	a := newTestElector(store, "a", metrics)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go a.Run(ctx)
+	require.Eventually(t, a.IsLeader, time.Second, 5*time.Millisecond)
+	term := a.Term()
+
+	// Another replica holds the lease, as after a's lease expired while it
+	// was cut off from the store
+	_, err := store.Acquire(context.Background(), "reindex", "b", time.Now().Add(time.Hour), time.Hour)
+	require.NoError(t, err)
+
+	select {
+	case <-term.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the term did not end once the lease was lost")
+	}
+	assert.False(t, a.IsLeader())
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.changes.WithLabelValues("reindex", "lost")) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestWithoutElection(t *testing.T) {
+	e := New(nil, "reindex", Options{}, discard)
+	assert.False(t, e.IsLeader())
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+	require.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+	stop()
+	<-done
+	assert.False(t, e.IsLeader())
+}
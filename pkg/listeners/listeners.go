@@ -0,0 +1,246 @@
+// Package listeners binds the REST and gRPC servers to several addresses
+// at once, TCP ports and unix domain sockets alike, the same way for every
+// service. Sidecars and gateways on the same host can then reach a service
+// over a socket while everything else keeps using TCP.
+//
+// The addresses come from HTTP_LISTEN and GRPC_LISTEN (see FromEnv);
+// without them a service listens on its HTTP_PORT and GRPC_PORT as before.
+//
+//	lis, err := cfg.Listen.Listen(cfg.Listen.GRPCAddrs(cfg.GRPCPort))
+//	...
+//	listeners.Serve(logger, "gRPC", lis, server.Serve, func() { os.Exit(1) })
+//	...
+//	server.GracefulStop() // closes every listener and removes the sockets
+package listeners
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxSocketPath is the longest unix socket path Linux accepts
+const maxSocketPath = 107
+
+// Address is a network address to listen on
+type Address struct {
+	Network string // "tcp" or "unix"
+	Addr    string // host:port, or the socket path
+}
+
+// String formats the address the way ParseAddress reads it
+func (a Address) String() string {
+	return a.Network + "://" + a.Addr
+}
+
+// ParseAddress reads tcp://host:port, unix:///path/to.sock, or a bare
+// host:port, which is TCP
+func ParseAddress(value string) (Address, error) {
+	network, addr, found := strings.Cut(value, "://")
+	if !found {
+		network, addr = "tcp", value
+	}
+	switch network {
+	case "tcp":
+		if _, port, err := net.SplitHostPort(addr); err != nil || port == "" {
+			return Address{}, fmt.Errorf("%q must be host:port, e.g. :8080", value)
+		}
+	case "unix":
+		if addr == "" {
+			return Address{}, fmt.Errorf("%q has no socket path", value)
+		}
+		if len(addr) > maxSocketPath {
+			return Address{}, fmt.Errorf("socket path %q is longer than %d bytes", addr, maxSocketPath)
+		}
+	default:
+		return Address{}, fmt.Errorf("%q must start with tcp:// or unix://", value)
+	}
+	return Address{Network: network, Addr: addr}, nil
+}
+
+// Config lists the addresses of a service's servers
+type Config struct {
+	// HTTP and GRPC replace the HTTP_PORT and GRPC_PORT listeners when set
+	HTTP []Address
+	GRPC []Address
+
+	// SocketMode, when set, is applied to the unix sockets created, e.g.
+	// 0660 to let a sidecar in the same group connect
+	SocketMode fs.FileMode
+
+	// problems lists the malformed environment values seen by FromEnv
+	problems []string
+}
+
+// FromEnv reads HTTP_LISTEN and GRPC_LISTEN, comma-separated addresses
+// such as tcp://:8080,unix:///run/shop/http.sock, and LISTEN_SOCKET_MODE,
+// an octal permission such as 0660. Malformed values are reported by
+// Validate.
+func FromEnv() Config {
+	var cfg Config
+	cfg.HTTP = cfg.envAddresses("HTTP_LISTEN")
+	cfg.GRPC = cfg.envAddresses("GRPC_LISTEN")
+	if value := os.Getenv("LISTEN_SOCKET_MODE"); value != "" {
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || mode > 0o777 {
+			cfg.problems = append(cfg.problems, fmt.Sprintf("LISTEN_SOCKET_MODE=%q must be an octal permission such as 0660", value))
+		}
+		cfg.SocketMode = fs.FileMode(mode) & fs.ModePerm
+	}
+	return cfg
+}
+
+func (c *Config) envAddresses(key string) []Address {
+	var addrs []Address
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		addr, err := ParseAddress(value)
+		if err != nil {
+			c.problems = append(c.problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// HTTPAddrs returns the REST server's addresses, port on every interface
+// without HTTP
+func (c Config) HTTPAddrs(port int) []Address {
+	return addrsOrPort(c.HTTP, port)
+}
+
+// GRPCAddrs returns the gRPC server's addresses, port on every interface
+// without GRPC
+func (c Config) GRPCAddrs(port int) []Address {
+	return addrsOrPort(c.GRPC, port)
+}
+
+func addrsOrPort(addrs []Address, port int) []Address {
+	if len(addrs) > 0 {
+		return addrs
+	}
+	return []Address{{Network: "tcp", Addr: ":" + strconv.Itoa(port)}}
+}
+
+// TCPAddr returns the first TCP address among addrs, or "" when all are
+// unix sockets
+func TCPAddr(addrs []Address) string {
+	for _, addr := range addrs {
+		if addr.Network == "tcp" {
+			return addr.Addr
+		}
+	}
+	return ""
+}
+
+// Validate reports malformed settings and addresses used twice
+func (c Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	seen := make(map[Address]bool)
+	for _, addr := range append(append([]Address(nil), c.HTTP...), c.GRPC...) {
+		if seen[addr] {
+			problems = append(problems, fmt.Sprintf("%s is listed more than once in HTTP_LISTEN and GRPC_LISTEN", addr))
+		}
+		seen[addr] = true
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Listen binds every address, or none: when one fails, those already
+// bound are closed. A leftover socket file nothing listens on any more is
+// replaced; closing a unix listener removes its socket file.
+func (c Config) Listen(addrs []Address) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		lis, err := c.listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, lis)
+	}
+	return listeners, nil
+}
+
+func (c Config) listen(addr Address) (net.Listener, error) {
+	if addr.Network != "unix" {
+		return net.Listen(addr.Network, addr.Addr)
+	}
+	if err := removeStaleSocket(addr.Addr); err != nil {
+		return nil, err
+	}
+	lis, err := net.Listen("unix", addr.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if c.SocketMode != 0 {
+		if err := os.Chmod(addr.Addr, c.SocketMode); err != nil {
+			lis.Close()
+			return nil, err
+		}
+	}
+	return lis, nil
+}
+
+// removeStaleSocket removes the socket file at path when no server
+// answers on it, as after a crash. Other files are left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
+
+// Serve runs serve on each listener in its own goroutine. A listener that
+// fails stops alone and the others keep serving; failed is called once
+// every listener has failed. Stopping the server, which returns nil or
+// http.ErrServerClosed, is not a failure.
+func Serve(logger *slog.Logger, server string, listeners []net.Listener, serve func(net.Listener) error, failed func()) {
+	var mu sync.Mutex
+	remaining := len(listeners)
+	for _, lis := range listeners {
+		logger.Info(server+" server listening", "network", lis.Addr().Network(), "addr", lis.Addr().String())
+		go func() {
+			err := serve(lis)
+			if err == nil || errors.Is(err, http.ErrServerClosed) {
+				return
+			}
+			logger.Error(server+" listener failed", "network", lis.Addr().Network(), "addr", lis.Addr().String(), "error", err)
+			lis.Close()
+
+			mu.Lock()
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+			if last && failed != nil {
+				failed()
+			}
+		}()
+	}
+}
@@ -0,0 +1,141 @@
+package listeners
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("HTTP_LISTEN", "tcp://:8080, unix:///run/shop/http.sock")
+	t.Setenv("GRPC_LISTEN", "127.0.0.1:9090")
+	t.Setenv("LISTEN_SOCKET_MODE", "0660")
+
+	cfg := FromEnv()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, []Address{{"tcp", ":8080"}, {"unix", "/run/shop/http.sock"}}, cfg.HTTPAddrs(8081))
+	assert.Equal(t, []Address{{"tcp", "127.0.0.1:9090"}}, cfg.GRPCAddrs(9091))
+	assert.Equal(t, fs.FileMode(0o660), cfg.SocketMode)
+
+	assert.Equal(t, []Address{{"tcp", ":9091"}}, Config{}.GRPCAddrs(9091), "the port without GRPC_LISTEN")
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("HTTP_LISTEN", "udp://:53,unix://,tcp://:8080")
+	t.Setenv("GRPC_LISTEN", "tcp://:8080,localhost")
+	t.Setenv("LISTEN_SOCKET_MODE", "rw")
+	err := FromEnv().Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`HTTP_LISTEN: "udp://:53" must start with tcp:// or unix://`,
+		`HTTP_LISTEN: "unix://" has no socket path`,
+		`GRPC_LISTEN: "localhost" must be host:port`,
+		"tcp://:8080 is listed more than once",
+		`LISTEN_SOCKET_MODE="rw"`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grpc.sock")
+	cfg := Config{SocketMode: 0o600}
+
+	lis, err := cfg.Listen([]Address{{"tcp", "127.0.0.1:0"}, {"unix", path}})
+	require.NoError(t, err)
+	require.Len(t, lis, 2)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+
+	_, err = cfg.Listen([]Address{{"unix", path}})
+	assert.ErrorContains(t, err, "in use by another server")
+
+	for _, l := range lis {
+		l.Close()
+	}
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, fs.ErrNotExist, "closing removes the socket")
+
+	// A socket left behind by a crashed server is replaced
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	lis, err = cfg.Listen([]Address{{"unix", path}})
+	require.NoError(t, err)
+	lis[0].Close()
+
+	// Other files are not
+	other := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(other, nil, 0o600))
+	_, err = cfg.Listen([]Address{{"tcp", "127.0.0.1:0"}, {"unix", other}})
+	assert.ErrorContains(t, err, "is not a socket")
+}
+
+func TestServe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.sock")
+	lis, err := Config{}.Listen([]Address{{"tcp", "127.0.0.1:0"}, {"unix", path}})
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})}
+	failed := make(chan struct{})
+	Serve(discard, "HTTP", lis, srv.Serve, func() { close(failed) })
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://shop/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get("http://" + lis[0].Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, srv.Close())
+	select {
+	case <-failed:
+		t.Fatal("closing the server is not a failure")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestServeFailure(t *testing.T) {
+	lis, err := Config{}.Listen([]Address{{"tcp", "127.0.0.1:0"}, {"tcp", "127.0.0.1:0"}})
+	require.NoError(t, err)
+
+	failed := make(chan struct{})
+	Serve(discard, "gRPC", lis, func(net.Listener) error {
+		return errors.New("accept failed")
+	}, func() { close(failed) })
+
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("failed was not called once every listener failed")
+	}
+	_, err = net.Dial("tcp", lis[0].Addr().String())
+	assert.Error(t, err, "a failed listener is closed")
+}
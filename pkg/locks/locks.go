@@ -0,0 +1,221 @@
+// Package locks provides distributed locks, so background jobs that every
+// replica runs on a timer do their work on one replica at a time.
+//
+// A lock is a lease: it is held for a TTL and renewed while its holder
+// works. When the holder crashes or loses the store, renewal stops and the
+// lease expires, after which another replica takes the lock over. Work
+// done under a lock gets a context that is cancelled once the lock is
+// lost, so it stops before another replica starts the same work.
+//
+// Usage:
+//
+//	locker := locks.New(locks.NewMongoStore(db.Collection("locks")), locks.Options{}, logger)
+//	...
+//	ran, err := locker.Do(ctx, "inventory-snapshots", func(ctx context.Context) error {
+//		_, err := productService.CaptureInventorySnapshots(ctx)
+//		return err
+//	})
+//
+// Leases rely on the replicas' clocks roughly agreeing; keep the TTL well
+// above any clock skew.
+package locks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned by TryLock when another owner holds the lock
+var ErrLocked = errors.New("lock is held by another owner")
+
+// Store keeps the leases
+type Store interface {
+	// Acquire leases name to owner until now+ttl when it is free, expired
+	// or already owner's, and reports whether it did
+	Acquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error)
+	// Renew extends owner's lease to now+ttl, and reports false when
+	// owner no longer holds it
+	Renew(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error)
+	// Release ends owner's lease; it does nothing when owner does not
+	// hold it
+	Release(ctx context.Context, name, owner string) error
+}
+
+// Options configures a Locker
+type Options struct {
+	// TTL is how long a lease lasts without renewal, and so how long a
+	// crashed holder blocks the others (default 30s)
+	TTL time.Duration
+	// RenewInterval is how often held leases are renewed (default TTL/3)
+	RenewInterval time.Duration
+	// StoreTimeout bounds each store call (default 5s)
+	StoreTimeout time.Duration
+	// Owner identifies this process in the store (default host name and
+	// a random suffix)
+	Owner string
+}
+
+// Locker takes locks in a Store on behalf of one process
+type Locker struct {
+	store  Store
+	opts   Options
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// New creates a locker
+func New(store Store, opts Options, logger *slog.Logger) *Locker {
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RenewInterval <= 0 || opts.RenewInterval >= opts.TTL {
+		opts.RenewInterval = opts.TTL / 3
+	}
+	if opts.StoreTimeout <= 0 {
+		opts.StoreTimeout = 5 * time.Second
+	}
+	if opts.Owner == "" {
+		opts.Owner = defaultOwner()
+	}
+	return &Locker{store: store, opts: opts, logger: logger, now: time.Now}
+}
+
+// defaultOwner names the process by its host, which is the pod name on
+// Kubernetes, and a random suffix telling restarts apart
+func defaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// Owner returns the name this locker holds locks under
+func (l *Locker) Owner() string {
+	return l.opts.Owner
+}
+
+// Lock is a held lock. It is renewed in the background until Unlock, or
+// until renewal has failed for so long that the lease could expire before
+// the next attempt.
+type Lock struct {
+	name   string
+	locker *Locker
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// TryLock takes the lock name, or returns ErrLocked when another owner
+// holds it. The lock's context derives from ctx.
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	now := l.now()
+	storeCtx, cancel := context.WithTimeout(ctx, l.opts.StoreTimeout)
+	acquired, err := l.store.Acquire(storeCtx, name, l.opts.Owner, now, l.opts.TTL)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		return nil, ErrLocked
+	}
+
+	lockCtx, cancelLock := context.WithCancel(ctx)
+	lock := &Lock{name: name, locker: l, ctx: lockCtx, cancel: cancelLock, done: make(chan struct{})}
+	go lock.renew(now)
+	return lock, nil
+}
+
+// Context is cancelled once the lock is lost or unlocked. Work done under
+// the lock should use it.
+func (lk *Lock) Context() context.Context {
+	return lk.ctx
+}
+
+// renew extends the lease, taken at acquired, every RenewInterval. The
+// lock counts as lost when the store reports another owner, or when a
+// renewal fails and the lease has less than RenewInterval+StoreTimeout
+// left: the next attempt might only succeed after the lease expired and
+// another owner took over, so the work stops while the lease still holds.
+func (lk *Lock) renew(acquired time.Time) {
+	defer close(lk.done)
+	l := lk.locker
+	ticker := time.NewTicker(l.opts.RenewInterval)
+	defer ticker.Stop()
+
+	expires := acquired.Add(l.opts.TTL)
+	for {
+		select {
+		case <-lk.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := l.now()
+		ctx, cancel := context.WithTimeout(lk.ctx, l.opts.StoreTimeout)
+		held, err := l.store.Renew(ctx, lk.name, l.opts.Owner, now, l.opts.TTL)
+		cancel()
+		switch {
+		case lk.ctx.Err() != nil:
+			return
+		case err == nil && held:
+			expires = now.Add(l.opts.TTL)
+		case err == nil:
+			l.logger.Warn("Lost lock to another owner", "lock", lk.name)
+			lk.cancel()
+			return
+		case expires.Sub(l.now()) < l.opts.RenewInterval+l.opts.StoreTimeout:
+			l.logger.Warn("Gave up lock before its lease expires; renewal kept failing", "lock", lk.name, "error", err)
+			lk.cancel()
+			return
+		default:
+			l.logger.Warn("Failed to renew lock; retrying", "lock", lk.name, "error", err)
+		}
+	}
+}
+
+// Unlock stops renewal and releases the lock, so another owner can take it
+// right away rather than once the lease expires
+func (lk *Lock) Unlock() error {
+	var err error
+	lk.once.Do(func() {
+		lk.cancel()
+		<-lk.done
+		l := lk.locker
+		ctx, cancel := context.WithTimeout(context.Background(), l.opts.StoreTimeout)
+		defer cancel()
+		if err = l.store.Release(ctx, lk.name, l.opts.Owner); err != nil {
+			err = fmt.Errorf("failed to release lock %s: %w", lk.name, err)
+		}
+	})
+	return err
+}
+
+// Do runs fn while holding the lock name and reports whether it ran; it
+// does not run when another owner holds the lock. fn's context is
+// cancelled if the lock is lost midway.
+func (l *Locker) Do(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	lock, err := l.TryLock(ctx, name)
+	if errors.Is(err, ErrLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			l.logger.Warn("Failed to release lock", "lock", name, "error", err)
+		}
+	}()
+	return true, fn(lock.Context())
+}
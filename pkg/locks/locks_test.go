@@ -0,0 +1,152 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestLocker(store Store, owner string, ttl time.Duration) *Locker {
+	return New(store, Options{TTL: ttl, Owner: owner}, discard)
+}
+
+func TestDoRunsOnOneOwner(t *testing.T) {
+	store := NewMemoryStore()
+	a := newTestLocker(store, "a", time.Minute)
+	b := newTestLocker(store, "b", time.Minute)
+
+	ran, err := a.Do(context.Background(), "snapshots", func(ctx context.Context) error {
+		ran, err := b.Do(ctx, "snapshots", func(context.Context) error {
+			t.Fatal("ran while another owner held the lock")
+			return nil
+		})
+		assert.False(t, ran)
+		assert.NoError(t, err)
+
+		ran, err = b.Do(ctx, "expiry", func(context.Context) error { return nil })
+		assert.True(t, ran, "other locks are independent")
+		assert.NoError(t, err)
+		return nil
+	})
+	assert.True(t, ran)
+	assert.NoError(t, err)
+	assert.Empty(t, store.Holder("snapshots"), "released once done")
+
+	failure := errors.New("snapshot failed")
+	ran, err = b.Do(context.Background(), "snapshots", func(context.Context) error { return failure })
+	assert.True(t, ran)
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestExpiredLeaseIsTakenOver(t *testing.T) {
+	store := NewMemoryStore()
+	a := newTestLocker(store, "a", time.Minute)
+	b := newTestLocker(store, "b", time.Minute)
+
+	// a crashes while holding the lock: it is never renewed or released
+	_, err := store.Acquire(context.Background(), "snapshots", "a", time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = b.TryLock(context.Background(), "snapshots")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	b.now = func() time.Time { return time.Now().Add(time.Minute) }
+	lock, err := b.TryLock(context.Background(), "snapshots")
+	require.NoError(t, err)
+	assert.Equal(t, "b", store.Holder("snapshots"))
+
+	// a's late release does not free b's lock
+	require.NoError(t, store.Release(context.Background(), "snapshots", a.Owner()))
+	assert.Equal(t, "b", store.Holder("snapshots"))
+	require.NoError(t, lock.Unlock())
+}
+
+func TestRenewalKeepsTheLease(t *testing.T) {
+	store := NewMemoryStore()
+	a := newTestLocker(store, "a", 60*time.Millisecond)
+	b := newTestLocker(store, "b", 60*time.Millisecond)
+
+	lock, err := a.TryLock(context.Background(), "reindex")
+	require.NoError(t, err)
+	defer lock.Unlock()
+
+	time.Sleep(200 * time.Millisecond)
+	_, err = b.TryLock(context.Background(), "reindex")
+	assert.ErrorIs(t, err, ErrLocked, "renewed past the first TTL")
+	assert.NoError(t, lock.Context().Err())
+}
+
+func TestLostLockCancelsContext(t *testing.T) {
+	store := NewMemoryStore()
+	a := newTestLocker(store, "a", 60*time.Millisecond)
+
+	lock, err := a.TryLock(context.Background(), "reindex")
+	require.NoError(t, err)
+
+	// b takes the lock over, as it would after a's lease expired
+	_, err = store.Acquire(context.Background(), "reindex", "b", time.Now().Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+
+	select {
+	case <-lock.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the lock's context was not cancelled once it was lost")
+	}
+	require.NoError(t, lock.Unlock())
+	assert.Equal(t, "b", store.Holder("reindex"))
+}
+
+// failingStore fails renewals, as when the store is unreachable
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Renew(context.Context, string, string, time.Time, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestFailedRenewalGivesUpBeforeExpiry(t *testing.T) {
+	ttl := 300 * time.Millisecond
+	a := New(failingStore{NewMemoryStore()}, Options{
+		TTL:           ttl,
+		RenewInterval: 50 * time.Millisecond,
+		StoreTimeout:  50 * time.Millisecond,
+		Owner:         "a",
+	}, discard)
+
+	start := time.Now()
+	lock, err := a.TryLock(context.Background(), "reindex")
+	require.NoError(t, err)
+	select {
+	case <-lock.Context().Done():
+		held := time.Since(start)
+		assert.GreaterOrEqual(t, held, 150*time.Millisecond, "kept while a renewal could still succeed in time")
+		assert.Less(t, held, ttl, "given up before the lease expires")
+	case <-time.After(time.Second):
+		t.Fatal("the lock was kept although renewal kept failing")
+	}
+	require.NoError(t, lock.Unlock())
+}
+
+func TestSlowStoreGivesUpAtFirstFailure(t *testing.T) {
+	// With the default store timeout, a failed renewal of a short lease
+	// leaves no time for another attempt
+	a := newTestLocker(failingStore{NewMemoryStore()}, "a", 60*time.Millisecond)
+
+	start := time.Now()
+	lock, err := a.TryLock(context.Background(), "reindex")
+	require.NoError(t, err)
+	select {
+	case <-lock.Context().Done():
+		assert.Less(t, time.Since(start), 60*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("the lock was kept although renewal failed")
+	}
+	require.NoError(t, lock.Unlock())
+}
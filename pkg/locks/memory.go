@@ -0,0 +1,67 @@
+package locks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps leases in process. It is used for tests and for
+// running a single replica locally.
+type MemoryStore struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+type lease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{leases: make(map[string]lease)}
+}
+
+// Acquire implements Store
+func (m *MemoryStore) Acquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[name]; ok && current.owner != owner && current.expiresAt.After(now) {
+		return false, nil
+	}
+	m.leases[name] = lease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Renew implements Store
+func (m *MemoryStore) Renew(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[name]; !ok || current.owner != owner {
+		return false, nil
+	}
+	m.leases[name] = lease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Release implements Store
+func (m *MemoryStore) Release(ctx context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.leases[name]; ok && current.owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+// Holder returns the owner of name's lease, expired or not, for
+// inspection in tests
+func (m *MemoryStore) Holder(name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leases[name].owner
+}
@@ -0,0 +1,61 @@
+package locks
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps leases in a MongoDB collection, one document per lock
+// named by its _id
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store backed by the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// Acquire implements Store. The upsert only matches a lease that expired
+// or is already owner's; for a lease held by another owner it tries to
+// insert a second document with the same _id, which fails.
+func (s *MongoStore) Acquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"owner": owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl), "acquired_at": now}}
+	_, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Renew implements Store
+func (s *MongoStore) Renew(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": now.Add(ttl)}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// Release implements Store
+func (s *MongoStore) Release(ctx context.Context, name, owner string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
@@ -0,0 +1,69 @@
+package locks
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The scripts compare the owner and change the key in one step, so a
+// lease that expired and was taken over meanwhile is never touched
+var (
+	acquireScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisStore keeps leases as Redis keys that expire with the lease, so
+// expiry follows the Redis server's clock rather than the replicas'
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a store keeping lock name under prefix+name
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Acquire implements Store
+func (s *RedisStore) Acquire(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	return s.run(ctx, acquireScript, name, owner, ttl)
+}
+
+// Renew implements Store
+func (s *RedisStore) Renew(ctx context.Context, name, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	return s.run(ctx, renewScript, name, owner, ttl)
+}
+
+// Release implements Store
+func (s *RedisStore) Release(ctx context.Context, name, owner string) error {
+	_, err := releaseScript.Run(ctx, s.client, []string{s.prefix + name}, owner).Result()
+	return err
+}
+
+func (s *RedisStore) run(ctx context.Context, script *redis.Script, name, owner string, ttl time.Duration) (bool, error) {
+	n, err := script.Run(ctx, s.client, []string{s.prefix + name}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
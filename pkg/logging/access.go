@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AccessLogOptions configures access logs
+type AccessLogOptions struct {
+	// SampleRate is the fraction of successful requests (HTTP status below
+	// 400, gRPC OK) that are logged, from 0 to 1. Failed requests are
+	// always logged. Sampling follows the trace ID, so a sampled request is
+	// logged by every service it passes through.
+	SampleRate float64
+	// SlowThreshold makes requests taking at least this long always
+	// logged, flagged with slow=true. Zero disables the flag.
+	SlowThreshold time.Duration
+}
+
+// DefaultAccessLog logs every request and flags those slower than a second
+var DefaultAccessLog = AccessLogOptions{SampleRate: 1, SlowThreshold: time.Second}
+
+// AccessLog logs one record per HTTP request with its method, route
+// template, status, response size, duration and, once authenticated, the
+// caller. Install it after HTTPMiddleware so that records carry the
+// request's correlation IDs.
+func AccessLog(logger *slog.Logger, opts AccessLogOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx, annotations := withAccessAnnotations(r.Context())
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			level := slog.LevelInfo
+			switch {
+			case code >= 500:
+				level = slog.LevelError
+			case code >= 400:
+				level = slog.LevelWarn
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", httpRoute(r)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", code),
+				slog.Int("bytes", ww.BytesWritten()),
+			}
+			logAccess(ctx, FromContext(ctx, logger), opts, level, code < 400, time.Since(start), annotations, attrs)
+		})
+	}
+}
+
+// AccessLogUnary is the gRPC counterpart of AccessLog, logging the method,
+// status code, duration and caller of each call. Install it after
+// UnaryServerInterceptor.
+func AccessLogUnary(logger *slog.Logger, opts AccessLogOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, annotations := withAccessAnnotations(ctx)
+		resp, err := handler(ctx, req)
+		logGRPCAccess(ctx, logger, opts, info.FullMethod, start, err, annotations)
+		return resp, err
+	}
+}
+
+// AccessLogStream is the streaming counterpart of AccessLogUnary, logging
+// each stream when it ends
+func AccessLogStream(logger *slog.Logger, opts AccessLogOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, annotations := withAccessAnnotations(stream.Context())
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		logGRPCAccess(ctx, logger, opts, info.FullMethod, start, err, annotations)
+		return err
+	}
+}
+
+func logGRPCAccess(ctx context.Context, logger *slog.Logger, opts AccessLogOptions, method string, start time.Time, err error, annotations *accessAnnotations) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+	}
+	logAccess(ctx, FromContext(ctx, logger), opts, level, code == codes.OK, time.Since(start), annotations, attrs)
+}
+
+func logAccess(ctx context.Context, logger *slog.Logger, opts AccessLogOptions, level slog.Level, ok bool, elapsed time.Duration, annotations *accessAnnotations, attrs []slog.Attr) {
+	slow := opts.SlowThreshold > 0 && elapsed >= opts.SlowThreshold
+	if ok && !slow && !sampled(ctx, opts.SampleRate) {
+		return
+	}
+	if slow && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+
+	attrs = append(attrs, slog.Duration("duration", elapsed))
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	attrs = append(attrs, annotations.get()...)
+	logger.LogAttrs(ctx, level, "Request completed", attrs...)
+}
+
+// sampled decides whether a successful request is logged at rate. The
+// decision is derived from the trace ID when there is one so that every
+// service makes the same choice for a trace.
+func sampled(ctx context.Context, rate float64) bool {
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	if traceID, _ := Trace(ctx); len(traceID) >= 8 {
+		if n, err := strconv.ParseUint(traceID[:8], 16, 32); err == nil {
+			return float64(n) < rate*(1<<32)
+		}
+	}
+	return rand.Float64() < rate
+}
+
+// httpRoute returns the chi route template r matched, or "unmatched"
+func httpRoute(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// accessAnnotations collects attributes that handlers further down the
+// chain add to the request's access log record
+type accessAnnotations struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+type accessAnnotationsKey struct{}
+
+func withAccessAnnotations(ctx context.Context) (context.Context, *accessAnnotations) {
+	annotations := &accessAnnotations{}
+	return context.WithValue(ctx, accessAnnotationsKey{}, annotations), annotations
+}
+
+func (a *accessAnnotations) get() []slog.Attr {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.attrs
+}
+
+// AnnotateAccess adds attributes, such as the authenticated user, to the
+// access log record of the request ctx belongs to. It does nothing when
+// the request is not access logged.
+func AnnotateAccess(ctx context.Context, attrs ...slog.Attr) {
+	if a, ok := ctx.Value(accessAnnotationsKey{}).(*accessAnnotations); ok {
+		a.mu.Lock()
+		a.attrs = append(a.attrs, attrs...)
+		a.mu.Unlock()
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{})
+
+	router := chi.NewRouter()
+	router.Use(HTTPMiddleware(logger), AccessLog(logger, AccessLogOptions{SampleRate: 1}))
+	router.Get("/v1/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		AnnotateAccess(r.Context(), slog.String("user", "admin"))
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/products/42", nil))
+
+	records := decodeLines(t, &buf)
+	if assert.Len(t, records, 1) {
+		record := records[0]
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, "GET", record["method"])
+		assert.Equal(t, "/v1/products/{id}", record["route"])
+		assert.Equal(t, "/v1/products/42", record["path"])
+		assert.Equal(t, 404.0, record["status"])
+		assert.Equal(t, 7.0, record["bytes"])
+		assert.Equal(t, "admin", record["user"])
+		assert.Contains(t, record, KeyRequestID)
+		assert.Contains(t, record, "duration")
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{})
+	opts := AccessLogOptions{SampleRate: 0, SlowThreshold: 20 * time.Millisecond}
+
+	serve := func(status int, delay time.Duration) {
+		AccessLog(logger, opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	serve(http.StatusOK, 0)
+	serve(http.StatusInternalServerError, 0)
+	serve(http.StatusOK, 30*time.Millisecond)
+
+	records := decodeLines(t, &buf)
+	if assert.Len(t, records, 2, "successful fast requests are sampled out") {
+		assert.Equal(t, 500.0, records[0]["status"])
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, true, records[1]["slow"])
+		assert.Equal(t, "WARN", records[1]["level"])
+	}
+}
+
+func TestSampledFollowsTrace(t *testing.T) {
+	low := WithTrace(context.Background(), "10000000000000000000000000000000", "span")
+	high := WithTrace(context.Background(), "f0000000000000000000000000000000", "span")
+	assert.True(t, sampled(low, 0.5))
+	assert.False(t, sampled(high, 0.5))
+	assert.True(t, sampled(high, 1))
+	assert.False(t, sampled(low, 0))
+}
+
+func TestAccessLogUnary(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/product.v1.ProductService/GetProduct"}
+
+	interceptor := AccessLogUnary(logger, DefaultAccessLog)
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+
+	records := decodeLines(t, &buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, info.FullMethod, records[0]["method"])
+		assert.Equal(t, "Unavailable", records[0]["code"])
+	}
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC metadata keys carrying correlation IDs
+const (
+	RequestIDMetadataKey   = "x-request-id"
+	TraceparentMetadataKey = "traceparent"
+)
+
+// UnaryServerInterceptor stores the call's correlation IDs and a
+// request-scoped logger in its context. Errors carrying an ErrorInfo
+// detail, as built by pkg/apperrors, get the IDs added to its metadata.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = grpcContext(ctx, logger)
+		resp, err := handler(ctx, req)
+		return resp, annotateError(ctx, err)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor
+func StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := grpcContext(stream.Context(), logger)
+		return annotateError(ctx, handler(srv, &contextStream{ServerStream: stream, ctx: ctx}))
+	}
+}
+
+// UnaryClientInterceptor passes the correlation IDs of ctx on to the called
+// service in the x-request-id and traceparent metadata keys
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor is the streaming counterpart of
+// UnaryClientInterceptor
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+}
+
+// outgoingContext adds the correlation IDs of ctx to its outgoing metadata
+// unless the caller set them explicitly
+func outgoingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	var pairs []string
+	if id := RequestID(ctx); id != "" && len(md.Get(RequestIDMetadataKey)) == 0 {
+		pairs = append(pairs, RequestIDMetadataKey, id)
+	}
+	if traceparent := Traceparent(ctx); traceparent != "" && len(md.Get(TraceparentMetadataKey)) == 0 {
+		pairs = append(pairs, TraceparentMetadataKey, traceparent)
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// annotateError adds the request and trace IDs of ctx to the ErrorInfo
+// detail of err, so that clients can quote them when reporting a failure
+func annotateError(ctx context.Context, err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok {
+		return err
+	}
+	traceID, _ := Trace(ctx)
+
+	pb := st.Proto()
+	annotated := false
+	for i, detail := range pb.GetDetails() {
+		var info errdetails.ErrorInfo
+		if detail.UnmarshalTo(&info) != nil {
+			continue
+		}
+		info.Metadata = cloneMetadata(info.Metadata)
+		info.Metadata[KeyRequestID] = RequestID(ctx)
+		info.Metadata[KeyTraceID] = traceID
+		if packErr := pb.Details[i].MarshalFrom(&info); packErr != nil {
+			return err
+		}
+		annotated = true
+	}
+	if !annotated {
+		return err
+	}
+	return status.FromProto(pb).Err()
+}
+
+func cloneMetadata(md map[string]string) map[string]string {
+	clone := make(map[string]string, len(md)+2)
+	for k, v := range md {
+		clone[k] = v
+	}
+	return clone
+}
+
+func grpcContext(ctx context.Context, logger *slog.Logger) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		requestID = first(md, RequestIDMetadataKey)
+	}
+	ctx = requestContext(ctx, logger, requestID, first(md, TraceparentMetadataKey))
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, RequestID(ctx)))
+	return ctx
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
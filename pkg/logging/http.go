@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HTTP headers carrying correlation IDs
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceparentHeader = "traceparent"
+)
+
+// HTTPMiddleware stores the request's correlation IDs and a request-scoped
+// logger in its context. A request ID already in the context (set by
+// pkg/middleware or chi's RequestID middleware) is reused when present, then
+// the X-Request-ID header; otherwise a new one is generated. The ID is
+// echoed in the X-Request-ID response header.
+func HTTPMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := RequestID(r.Context())
+			if requestID == "" {
+				requestID = middleware.GetReqID(r.Context())
+			}
+			if requestID == "" {
+				requestID = r.Header.Get(RequestIDHeader)
+			}
+
+			ctx := requestContext(r.Context(), logger, requestID, r.Header.Get(TraceparentHeader))
+			w.Header().Set(RequestIDHeader, RequestID(ctx))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Transport wraps base, or http.DefaultTransport if nil, so that outgoing
+// requests carry the correlation IDs of their context in the X-Request-ID
+// and traceparent headers
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requestID := RequestID(r.Context())
+		traceparent := Traceparent(r.Context())
+		if requestID == "" && traceparent == "" {
+			return base.RoundTrip(r)
+		}
+
+		r = r.Clone(r.Context())
+		if requestID != "" && r.Header.Get(RequestIDHeader) == "" {
+			r.Header.Set(RequestIDHeader, requestID)
+		}
+		if traceparent != "" && r.Header.Get(TraceparentHeader) == "" {
+			r.Header.Set(TraceparentHeader, traceparent)
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,269 @@
+// Package logging builds the slog loggers used by all services.
+//
+// Every record carries the service name and version, and records logged
+// while handling a request also carry its request_id, trace_id and span_id.
+// The HTTP middleware and gRPC interceptors in this package put those IDs
+// and a request-scoped logger into the request context, and the client
+// interceptors and Transport pass them on to the services called while
+// handling it:
+//
+//	logger := logging.New(os.Stdout, logging.Options{Service: "product-service", Version: version})
+//	router.Use(logging.HTTPMiddleware(logger))
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor(logger)))
+//	grpc.NewClient(addr, grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor))
+//	httpClient := &http.Client{Transport: logging.Transport(nil)}
+//
+//	func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+//		logging.FromContext(r.Context(), h.logger).Info("getting thing")
+//	}
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys added to records
+const (
+	KeyService   = "service"
+	KeyVersion   = "version"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+)
+
+// Options configures a logger
+type Options struct {
+	Service string
+	Version string
+	Level   string // debug, info, warn or error; defaults to info
+	Text    bool   // Human-readable output instead of JSON, for local development
+}
+
+// New creates a logger writing to w
+func New(w io.Writer, opts Options) *slog.Logger {
+	return slog.New(NewHandler(w, opts))
+}
+
+// NewHandler creates a handler writing JSON records to w. The service and
+// version are added to every record, and request correlation IDs are
+// taken from the context passed to the *Context logging methods.
+func NewHandler(w io.Writer, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: ParseLevel(opts.Level)}
+
+	var inner slog.Handler
+	if opts.Text {
+		inner = slog.NewTextHandler(w, handlerOpts)
+	} else {
+		inner = slog.NewJSONHandler(w, handlerOpts)
+	}
+
+	var attrs []slog.Attr
+	if opts.Service != "" {
+		attrs = append(attrs, slog.String(KeyService, opts.Service))
+	}
+	if opts.Version != "" {
+		attrs = append(attrs, slog.String(KeyVersion, opts.Version))
+	}
+	return &Handler{inner: inner.WithAttrs(attrs)}
+}
+
+// ParseLevel converts a level name to a slog level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Handler adds request correlation IDs from the context to each record
+type Handler struct {
+	inner slog.Handler
+	bound bool // The IDs were already added with WithAttrs
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.bound {
+		record.AddAttrs(correlationAttrs(ctx)...)
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	bound := h.bound
+	for _, attr := range attrs {
+		if attr.Key == KeyRequestID {
+			bound = true
+		}
+	}
+	return &Handler{inner: h.inner.WithAttrs(attrs), bound: bound}
+}
+
+// WithGroup implements slog.Handler
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), bound: h.bound}
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceKey
+	loggerKey
+)
+
+type traceIDs struct {
+	traceID string
+	spanID  string
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTrace returns a context carrying the trace and span IDs
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceKey, traceIDs{traceID: traceID, spanID: spanID})
+}
+
+// Trace returns the trace and span IDs carried by ctx, if any. Those of
+// the current OpenTelemetry span come first, so that logs point to the
+// exported spans when tracing is enabled (see pkg/tracing).
+func Trace(ctx context.Context) (traceID, spanID string) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID().String(), sc.SpanID().String()
+	}
+	ids, _ := ctx.Value(traceKey).(traceIDs)
+	return ids.traceID, ids.spanID
+}
+
+// NewContext returns a context carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the request-scoped logger carried by ctx, or fallback
+// if there is none
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// scoped returns logger with the correlation IDs of ctx bound to it
+func scoped(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	attrs := correlationAttrs(ctx)
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	return logger.With(args...)
+}
+
+func correlationAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	var attrs []slog.Attr
+	if id := RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String(KeyRequestID, id))
+	}
+	if traceID, spanID := Trace(ctx); traceID != "" {
+		attrs = append(attrs, slog.String(KeyTraceID, traceID), slog.String(KeySpanID, spanID))
+	}
+	return attrs
+}
+
+// newID returns n random bytes as lowercase hex
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	return newID(16)
+}
+
+// ParseTraceparent extracts the trace and parent span IDs from a W3C
+// traceparent header value
+func ParseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if !isHex(parts[1]) || !isHex(parts[2]) || strings.Trim(parts[1], "0") == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
+
+// Traceparent returns the W3C traceparent value for calls made while
+// handling the request in ctx: the request's trace with its span as the
+// parent. It returns "" when ctx carries no trace. The sampled flag is
+// that of the current OpenTelemetry span, and set without one.
+func Traceparent(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+	}
+	traceID, spanID := Trace(ctx)
+	if traceID == "" || spanID == "" {
+		return ""
+	}
+	return "00-" + traceID + "-" + spanID + "-01"
+}
+
+// requestContext derives the correlation IDs of an incoming request from
+// its request ID and traceparent values and stores them, together with a
+// request-scoped logger, in ctx. Requests without a trace start a new one;
+// each request gets its own span ID. Requests already in an OpenTelemetry
+// span keep its IDs.
+func requestContext(ctx context.Context, logger *slog.Logger, requestID, traceparent string) context.Context {
+	if requestID == "" {
+		requestID = NewRequestID()
+	}
+	ctx = WithRequestID(ctx, requestID)
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		traceID, _, ok := ParseTraceparent(traceparent)
+		if !ok {
+			traceID = newID(16)
+		}
+		ctx = WithTrace(ctx, traceID, newID(8))
+	}
+
+	return NewContext(ctx, scoped(ctx, logger))
+}
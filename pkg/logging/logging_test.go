@@ -0,0 +1,265 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]interface{}
+		assert.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestHandlerAddsServiceAndContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{Service: "product-service", Version: "1.2.3"})
+
+	ctx := WithTrace(WithRequestID(context.Background(), "req-1"), "trace-1", "span-1")
+	logger.InfoContext(ctx, "with context")
+	logger.Info("without context")
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "product-service", records[0][KeyService])
+	assert.Equal(t, "1.2.3", records[0][KeyVersion])
+	assert.Equal(t, "req-1", records[0][KeyRequestID])
+	assert.Equal(t, "trace-1", records[0][KeyTraceID])
+	assert.Equal(t, "span-1", records[0][KeySpanID])
+	assert.NotContains(t, records[1], KeyRequestID)
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{Level: "warn"})
+
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	records := decodeLines(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "kept", records[0]["msg"])
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{Service: "test"})
+
+	var scoped *slog.Logger
+	var ctx context.Context
+	handler := HTTPMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		scoped = FromContext(r.Context(), nil)
+		// A scoped logger logging with the context must not repeat the IDs
+		scoped.InfoContext(r.Context(), "handled")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/products", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	req.Header.Set(TraceparentHeader, testTraceparent)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "abc", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc", RequestID(ctx))
+	traceID, spanID := Trace(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Len(t, spanID, 16)
+	assert.NotEqual(t, "00f067aa0ba902b7", spanID)
+
+	assert.NotNil(t, scoped)
+	out := buf.String()
+	assert.Equal(t, 1, bytes.Count([]byte(out), []byte(`"request_id"`)))
+	records := decodeLines(t, &buf)
+	assert.Equal(t, "abc", records[0][KeyRequestID])
+	assert.Equal(t, traceID, records[0][KeyTraceID])
+}
+
+func TestHTTPMiddlewareGeneratesIDs(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Options{})
+	var ctx context.Context
+	handler := HTTPMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceparentHeader, "garbage")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Len(t, RequestID(ctx), 32)
+	assert.Equal(t, RequestID(ctx), rec.Header().Get(RequestIDHeader))
+	traceID, _ := Trace(ctx)
+	assert.Len(t, traceID, 32)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Options{})
+	md := metadata.Pairs(RequestIDMetadataKey, "grpc-req", TraceparentMetadataKey, testTraceparent)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	var got context.Context
+	_, err := UnaryServerInterceptor(logger)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = ctx
+		return nil, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc-req", RequestID(got))
+	traceID, _ := Trace(got)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.NotEqual(t, logger, FromContext(got, logger))
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, ok := ParseTraceparent(testTraceparent)
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", spanID)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		_, _, ok := ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestHTTPMiddlewareReusesContextRequestID(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Options{})
+	var ctx context.Context
+	handler := HTTPMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "from-header")
+	req = req.WithContext(WithRequestID(req.Context(), "from-context"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "from-context", RequestID(ctx))
+}
+
+func TestUnaryServerInterceptorAnnotatesErrors(t *testing.T) {
+	logger := New(&bytes.Buffer{}, Options{})
+	md := metadata.Pairs(RequestIDMetadataKey, "grpc-req", TraceparentMetadataKey, testTraceparent)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	st, err := status.New(codes.NotFound, "product 42 not found").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "PRODUCT_NOT_FOUND",
+		Metadata: map[string]string{"kind": "not_found"},
+	})
+	assert.NoError(t, err)
+	_, err = UnaryServerInterceptor(logger)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, st.Err()
+	})
+
+	got := status.Convert(err)
+	assert.Equal(t, codes.NotFound, got.Code())
+	assert.Len(t, got.Details(), 1)
+	info := got.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, "PRODUCT_NOT_FOUND", info.Reason)
+	assert.Equal(t, "not_found", info.Metadata["kind"])
+	assert.Equal(t, "grpc-req", info.Metadata[KeyRequestID])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", info.Metadata[KeyTraceID])
+}
+
+func TestUnaryClientInterceptorPropagatesIDs(t *testing.T) {
+	ctx := WithTrace(WithRequestID(context.Background(), "req-1"), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+
+	var md metadata.MD
+	err := UnaryClientInterceptor(ctx, "/product.v1.ProductService/GetProduct", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"req-1"}, md.Get(RequestIDMetadataKey))
+	assert.Equal(t, []string{testTraceparent}, md.Get(TraceparentMetadataKey))
+}
+
+func TestUnaryClientInterceptorKeepsExplicitIDs(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, "explicit")
+
+	var md metadata.MD
+	_ = UnaryClientInterceptor(ctx, "/m", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+
+	assert.Equal(t, []string{"explicit"}, md.Get(RequestIDMetadataKey))
+	assert.Empty(t, md.Get(TraceparentMetadataKey))
+}
+
+func TestTransportPropagatesIDs(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer upstream.Close()
+
+	ctx := WithTrace(WithRequestID(context.Background(), "req-1"), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	assert.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-1", got.Get(RequestIDHeader))
+	assert.Equal(t, testTraceparent, got.Get(TraceparentHeader))
+	assert.Empty(t, req.Header.Get(RequestIDHeader), "the caller's request must not be modified")
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	assert.Empty(t, Traceparent(context.Background()))
+
+	ctx := WithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	traceID, spanID, ok := ParseTraceparent(Traceparent(ctx))
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", spanID)
+}
+
+func TestTraceFollowsOpenTelemetrySpan(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	ctx := trace.ContextWithSpanContext(WithTrace(context.Background(), "trace-1", "span-1"), sc)
+
+	gotTrace, gotSpan := Trace(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotTrace)
+	assert.Equal(t, "00f067aa0ba902b7", gotSpan)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", Traceparent(ctx), "the span was not sampled")
+
+	ctx = requestContext(ctx, slog.Default(), "req-1", "")
+	gotTrace, _ = Trace(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotTrace, "requests in a span keep its trace")
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxInflightAlerts bounds the reports a WebhookAlert sends at once;
+// reports beyond it are dropped so that a panic storm cannot pile up
+// goroutines
+const maxInflightAlerts = 8
+
+// WebhookAlert is an AlertHook that POSTs each report as JSON to a URL,
+// such as an incident tool's ingestion endpoint, in the background
+type WebhookAlert struct {
+	url      string
+	service  string
+	client   *http.Client
+	logger   *slog.Logger
+	inflight chan struct{}
+}
+
+var _ AlertHook = (*WebhookAlert)(nil)
+
+// NewWebhookAlert creates a hook posting reports of service's panics to url
+func NewWebhookAlert(url, service string, logger *slog.Logger) *WebhookAlert {
+	return &WebhookAlert{
+		url:      url,
+		service:  service,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		logger:   logger,
+		inflight: make(chan struct{}, maxInflightAlerts),
+	}
+}
+
+// ReportPanic implements AlertHook. It returns at once; the report is
+// sent in the background.
+func (a *WebhookAlert) ReportPanic(ctx context.Context, report PanicReport) {
+	select {
+	case a.inflight <- struct{}{}:
+	default:
+		a.logger.Warn("Dropped panic alert; too many in flight", "method", report.Method)
+		return
+	}
+	go func() {
+		defer func() { <-a.inflight }()
+		if err := a.send(report); err != nil {
+			a.logger.Error("Failed to send panic alert", "error", err)
+		}
+	}()
+}
+
+func (a *WebhookAlert) send(report PanicReport) error {
+	body, err := json.Marshal(struct {
+		Service string `json:"service"`
+		PanicReport
+	}{a.service, report})
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Authentication errors
+var (
+	// ErrUnauthenticated is returned when a request carries no valid credentials
+	ErrUnauthenticated = apperrors.New(apperrors.Unauthenticated, "missing or invalid credentials")
+	// ErrForbidden is returned when the caller lacks the role a request needs
+	ErrForbidden = apperrors.New(apperrors.Forbidden, "insufficient permissions")
+)
+
+// RoleAdmin is the role of callers who administer a service, such as
+// managing its promotions
+const RoleAdmin = "admin"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Roles   []string
+	// Group is the customer group of a signed-in user, such as wholesale,
+	// when the authenticator knows it
+	Group string
+}
+
+// HasRole reports whether the principal has the given role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator resolves a bearer token to a principal. It returns
+// ErrUnauthenticated, or another apperrors error, when the token is
+// rejected.
+type Authenticator func(ctx context.Context, token string) (*Principal, error)
+
+// StaticTokens authenticates against a fixed token-to-principal map, which
+// is enough for service-to-service and admin tokens loaded from config
+func StaticTokens(tokens map[string]*Principal) Authenticator {
+	return func(ctx context.Context, token string) (*Principal, error) {
+		for known, principal := range tokens {
+			if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+				return principal, nil
+			}
+		}
+		return nil, ErrUnauthenticated
+	}
+}
+
+// ParseTokens parses a comma-separated list of token=subject pairs, as read
+// from an environment variable. Entries without a subject use the token's
+// position, "token-1", "token-2", and so on. A subject may be followed by
+// its roles, as in "token=ops:admin|merchandiser".
+func ParseTokens(spec string) map[string]*Principal {
+	tokens := make(map[string]*Principal)
+	for i, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		token, subject, _ := strings.Cut(entry, "=")
+		subject, roles, _ := strings.Cut(subject, ":")
+		if subject = strings.TrimSpace(subject); subject == "" {
+			subject = "token-" + strconv.Itoa(i+1)
+		}
+		principal := &Principal{Subject: subject}
+		for _, role := range strings.Split(roles, "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+		tokens[strings.TrimSpace(token)] = principal
+	}
+	return tokens
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the principal. The principal's
+// subject is recorded as the user in the request's access log.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	logging.AnnotateAccess(ctx, slog.String("user", p.Subject))
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal authenticated for ctx, if any
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticated returns the principal that Auth stored for r, or else
+// authenticates its Authorization header. It is for requests that Auth
+// skips, such as reads, which show more to some callers; ok is false for
+// anonymous callers and rejected tokens.
+func Authenticated(r *http.Request, authn Authenticator) (principal *Principal, ok bool) {
+	if principal, ok := PrincipalFrom(r.Context()); ok {
+		return principal, true
+	}
+	if r.Header.Get("Authorization") == "" {
+		return nil, false
+	}
+	principal, err := authenticate(r.Context(), authn, r.Header.Get("Authorization"))
+	return principal, err == nil
+}
+
+// SkipSafeMethods is a skip function for Auth that lets GET, HEAD and
+// OPTIONS requests through unauthenticated
+func SkipSafeMethods(r *http.Request) bool {
+	return isSafeMethod(r.Method)
+}
+
+// SkipMethods returns a skip function for AuthUnary and AuthStream that
+// lets the named methods through unauthenticated. Names may be full
+// ("/product.v1.ProductService/GetProduct") or just the method ("GetProduct").
+func SkipMethods(names ...string) func(fullMethod string) bool {
+	skip := make(map[string]bool, len(names))
+	for _, name := range names {
+		skip[name] = true
+	}
+	return func(fullMethod string) bool {
+		return skip[fullMethod] || skip[fullMethod[strings.LastIndex(fullMethod, "/")+1:]]
+	}
+}
+
+// Auth authenticates the Authorization: Bearer token of each request and
+// stores the principal in its context. Requests for which skip returns true
+// pass through unauthenticated; skip may be nil.
+func Auth(authn Authenticator, skip func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip != nil && skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticate(r.Context(), authn, r.Header.Get("Authorization"))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				apperrors.WriteHTTP(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireRole lets through only requests whose caller has the given role.
+// Requests that Auth skipped, such as reads, are authenticated here.
+func RequireRole(authn Authenticator, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			principal, ok := PrincipalFrom(ctx)
+			if !ok {
+				var err error
+				if principal, err = authenticate(ctx, authn, r.Header.Get("Authorization")); err != nil {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					apperrors.WriteHTTP(w, r, err)
+					return
+				}
+				ctx = WithPrincipal(ctx, principal)
+			}
+			if !principal.HasRole(role) {
+				apperrors.WriteHTTP(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthUnary is the gRPC counterpart of Auth, reading the authorization
+// metadata key. Methods for which skip returns true pass through; skip may
+// be nil.
+func AuthUnary(authn Authenticator, skip func(fullMethod string) bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skip != nil && skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := grpcAuthenticate(ctx, authn)
+		if err != nil {
+			return nil, apperrors.ToGRPC(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStream is the streaming counterpart of AuthUnary
+func AuthStream(authn Authenticator, skip func(fullMethod string) bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skip != nil && skip(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		ctx, err := grpcAuthenticate(stream.Context(), authn)
+		if err != nil {
+			return apperrors.ToGRPC(err)
+		}
+		return handler(srv, withContext(stream, ctx))
+	}
+}
+
+func grpcAuthenticate(ctx context.Context, authn Authenticator) (context.Context, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	principal, err := authenticate(ctx, authn, header)
+	if err != nil {
+		return ctx, err
+	}
+	return WithPrincipal(ctx, principal), nil
+}
+
+func authenticate(ctx context.Context, authn Authenticator, header string) (*Principal, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, ErrUnauthenticated
+	}
+	return authn(ctx, strings.TrimSpace(token))
+}
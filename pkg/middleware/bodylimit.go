@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// ErrBodyTooLarge is returned when a request body exceeds the configured limit
+var ErrBodyTooLarge = apperrors.New(apperrors.Invalid, "request body too large").WithReason("BODY_TOO_LARGE")
+
+// BodyLimit caps request bodies at n bytes. Requests declaring a larger
+// Content-Length are rejected up front; others fail when the handler reads
+// past the limit. gRPC servers get the same protection from
+// grpc.MaxRecvMsgSize.
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				w.Header().Set("Connection", "close")
+				apperrors.WriteHTTP(w, r, ErrBodyTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Duration is a time.Duration read from JSON strings such as "200ms" or
+// "5m"
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"500ms\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// EndpointPolicy is the budget of a route or RPC. Zero fields fall back to
+// the default policy.
+type EndpointPolicy struct {
+	// Timeout bounds the request's context, including deadlines set by
+	// gRPC clients
+	Timeout Duration `json:"timeout,omitempty"`
+	// Retries is how many times callers may retry an idempotent call after
+	// a transient failure. Servers do not enforce it; SDK callers get it
+	// through ClientRetries.
+	Retries *int `json:"retries,omitempty"`
+	// MaxBodyBytes caps the HTTP request body or gRPC request message
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+}
+
+// EndpointPolicies selects the budget of each request. Routes are keyed
+// by "METHOD /chi/route/pattern" for HTTP and the full method name for
+// gRPC, as for RateLimitPolicies; other requests get Default.
+type EndpointPolicies struct {
+	Default EndpointPolicy            `json:"default"`
+	Routes  map[string]EndpointPolicy `json:"routes,omitempty"`
+}
+
+// LoadEndpointPolicies reads policies from a JSON file. Fields the file's
+// default policy leaves unset are taken from defaults.
+func LoadEndpointPolicies(path string, defaults EndpointPolicy) (*EndpointPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policies EndpointPolicies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("invalid endpoint policies in %s: %w", path, err)
+	}
+	if err := policies.Default.validate(); err != nil {
+		return nil, fmt.Errorf("invalid default endpoint policy in %s: %w", path, err)
+	}
+	for route, policy := range policies.Routes {
+		if err := policy.validate(); err != nil {
+			return nil, fmt.Errorf("invalid endpoint policy for %q in %s: %w", route, path, err)
+		}
+	}
+	policies.Default = merge(defaults, policies.Default)
+	return &policies, nil
+}
+
+// Resolve returns the policy of route, a key as in Routes
+func (p *EndpointPolicies) Resolve(route string) EndpointPolicy {
+	if policy, ok := p.Routes[route]; ok {
+		return merge(p.Default, policy)
+	}
+	return p.Default
+}
+
+// MaxBodyBytes returns the largest body any policy allows, for transport
+// limits such as grpc.MaxRecvMsgSize that apply before a route is known
+func (p *EndpointPolicies) MaxBodyBytes() int64 {
+	largest := p.Default.MaxBodyBytes
+	for _, policy := range p.Routes {
+		largest = max(largest, policy.MaxBodyBytes)
+	}
+	return largest
+}
+
+// ClientRetries returns the retries of each gRPC method that sets them,
+// keyed by method name ("CheckStock"), for clients.Options.MethodRetries
+func (p *EndpointPolicies) ClientRetries() map[string]int {
+	retries := make(map[string]int)
+	for route, policy := range p.Routes {
+		if policy.Retries != nil && strings.HasPrefix(route, "/") {
+			retries[route[strings.LastIndex(route, "/")+1:]] = *policy.Retries
+		}
+	}
+	return retries
+}
+
+func (p EndpointPolicy) validate() error {
+	if p.Timeout < 0 || p.MaxBodyBytes < 0 || (p.Retries != nil && *p.Retries < 0) {
+		return errors.New("values must not be negative")
+	}
+	return nil
+}
+
+// merge returns base with the fields set in override replaced
+func merge(base, override EndpointPolicy) EndpointPolicy {
+	if override.Timeout > 0 {
+		base.Timeout = override.Timeout
+	}
+	if override.Retries != nil {
+		base.Retries = override.Retries
+	}
+	if override.MaxBodyBytes > 0 {
+		base.MaxBodyBytes = override.MaxBodyBytes
+	}
+	return base
+}
+
+// EndpointLimits applies each request's policy: its context is bounded by
+// the timeout and its body capped like BodyLimit. It replaces Timeout and
+// BodyLimit where budgets differ per route.
+func EndpointLimits(policies *EndpointPolicies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy := policies.Resolve(httpRoute(r))
+			if n := policy.MaxBodyBytes; n > 0 {
+				if r.ContentLength > n {
+					w.Header().Set("Connection", "close")
+					apperrors.WriteHTTP(w, r, ErrBodyTooLarge)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			if d := time.Duration(policy.Timeout); d > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), d)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// EndpointLimitsUnary is the gRPC counterpart of EndpointLimits. Unlike
+// TimeoutUnary, the policy's timeout also shortens longer client
+// deadlines. Set grpc.MaxRecvMsgSize to policies.MaxBodyBytes() so that
+// the largest allowed message gets through to it.
+func EndpointLimitsUnary(policies *EndpointPolicies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		policy := policies.Resolve(info.FullMethod)
+		if n := policy.MaxBodyBytes; n > 0 {
+			if msg, ok := req.(proto.Message); ok && int64(proto.Size(msg)) > n {
+				return nil, apperrors.ToGRPC(ErrBodyTooLarge)
+			}
+		}
+		if d := time.Duration(policy.Timeout); d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
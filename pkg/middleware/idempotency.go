@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// IdempotencyKeyHeader is the request header carrying a client-chosen key
+// that makes retries of a POST safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from the store
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLen bounds client-supplied idempotency keys
+const maxIdempotencyKeyLen = 255
+
+// idempotencyLockTTL is how long a key stays claimed by a request that has
+// not finished, e.g. because its instance crashed, at least. A request with
+// a deadline claims its key until the deadline plus idempotencyLockSlack,
+// so that a retry of a slow request cannot run while it still does.
+const idempotencyLockTTL = time.Minute
+
+// idempotencyLockSlack is the time a handler past its deadline is given to
+// return and have its response stored
+const idempotencyLockSlack = 30 * time.Second
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a
+	// different method, path or body
+	ErrIdempotencyKeyReused = apperrors.New(apperrors.Conflict, "idempotency key was already used for a different request").WithReason("IDEMPOTENCY_KEY_REUSED")
+	// ErrIdempotencyInProgress is returned when a key is sent again while
+	// the first request is still running
+	ErrIdempotencyInProgress = apperrors.New(apperrors.Conflict, "a request with this idempotency key is still in progress").WithReason("IDEMPOTENCY_IN_PROGRESS")
+	// ErrInvalidIdempotencyKey is returned for empty, overlong or
+	// non-printable keys
+	ErrInvalidIdempotencyKey = apperrors.New(apperrors.Invalid, "Idempotency-Key must be 1 to 255 printable characters").WithReason("INVALID_IDEMPOTENCY_KEY")
+)
+
+// StoredResponse is a response kept for replay
+type StoredResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// IdempotencyStore keeps the responses of requests by idempotency key
+type IdempotencyStore interface {
+	// Begin claims key for a request with the given fingerprint for up to
+	// lockTTL. It returns the stored response when a request with the key
+	// already completed, ErrIdempotencyKeyReused when the fingerprints
+	// differ and ErrIdempotencyInProgress while the first request runs.
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*StoredResponse, error)
+	// Complete stores the response of the request holding key for ttl
+	Complete(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error
+	// Release drops the claim on key so that a retry runs the request again
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency makes POST and PATCH requests carrying an Idempotency-Key
+// header safe to retry. The first request with a key runs and its response
+// is kept for ttl; retries with the same key and payload get that response
+// replayed without running the handler again. Server errors are not kept,
+// so a retry after one runs the request again. Keys are scoped to the
+// authenticated principal, so run this after Auth. A key is claimed until
+// the request's deadline has passed, so run it after EndpointLimits or
+// Timeout too.
+//
+// Store errors are logged and the request is let through, so a store
+// outage does not take the API down with it.
+func Idempotency(store IdempotencyStore, ttl time.Duration, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientKey, ok := r.Header[http.CanonicalHeaderKey(IdempotencyKeyHeader)]
+			if !ok || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(clientKey[0]) {
+				apperrors.WriteHTTP(w, r, ErrInvalidIdempotencyKey)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					apperrors.WriteHTTP(w, r, ErrBodyTooLarge)
+					return
+				}
+				apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "failed to read request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			log := logging.FromContext(r.Context(), logger)
+			key := idempotencyScope(r, clientKey[0])
+			stored, err := store.Begin(r.Context(), key, hashOf(r.Method, r.URL.Path, string(body)), lockTTL(r.Context()))
+			switch {
+			case stored != nil:
+				replay(w, stored)
+				return
+			case errors.Is(err, ErrIdempotencyKeyReused), errors.Is(err, ErrIdempotencyInProgress):
+				apperrors.WriteHTTP(w, r, err)
+				return
+			case err != nil:
+				log.Warn("Idempotency store failed", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The outcome is recorded even if the client has gone away
+			ctx := context.WithoutCancel(r.Context())
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.Release(ctx, key); err != nil {
+						log.Warn("Failed to release idempotency key", "error", err)
+					}
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			header := w.Header().Clone()
+			header.Del(logging.RequestIDHeader)
+			if err := store.Complete(ctx, key, &StoredResponse{Status: status, Header: header, Body: buf.Bytes()}, ttl); err != nil {
+				log.Warn("Failed to store idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// lockTTL returns how long a request claims its idempotency key: past its
+// deadline, and never less than idempotencyLockTTL
+func lockTTL(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return idempotencyLockTTL
+	}
+	return max(time.Until(deadline)+idempotencyLockSlack, idempotencyLockTTL)
+}
+
+func replay(w http.ResponseWriter, stored *StoredResponse) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// idempotencyScope returns the store key for a client key: distinct
+// principals never share responses
+func idempotencyScope(r *http.Request, clientKey string) string {
+	subject := ""
+	if p, ok := PrincipalFrom(r.Context()); ok {
+		subject = p.Subject
+	}
+	return hashOf(subject, clientKey)
+}
+
+// hashOf returns the hex SHA-256 of the NUL-separated parts
+func hashOf(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for _, c := range key {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// MemoryIdempotencyStore keeps responses in memory. Each replica has its
+// own store, so use it for tests and single-instance deployments only.
+type MemoryIdempotencyStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	records map[string]*idempotencyRecord
+}
+
+type idempotencyRecord struct {
+	fingerprint string
+	response    *StoredResponse
+	expires     time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{now: time.Now, records: make(map[string]*idempotencyRecord)}
+}
+
+// Begin implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, rec := range s.records {
+		if !now.Before(rec.expires) {
+			delete(s.records, k)
+		}
+	}
+
+	if rec, ok := s.records[key]; ok {
+		return checkRecord(rec.fingerprint, rec.response, fingerprint)
+	}
+	s.records[key] = &idempotencyRecord{fingerprint: fingerprint, expires: now.Add(lockTTL)}
+	return nil, nil
+}
+
+// Complete implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok {
+		rec.response = resp
+		rec.expires = s.now().Add(ttl)
+	}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// checkRecord decides what a request with fingerprint gets when its key
+// is already taken by a request with stored fingerprint and response
+func checkRecord(storedFingerprint string, resp *StoredResponse, fingerprint string) (*StoredResponse, error) {
+	switch {
+	case storedFingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case resp == nil:
+		return nil, ErrIdempotencyInProgress
+	default:
+		return resp, nil
+	}
+}
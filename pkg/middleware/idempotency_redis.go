@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIdempotencyStore keeps responses in Redis, shared by all replicas
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisIdempotencyStore creates a store keeping records under
+// <prefix>:<key>
+func NewRedisIdempotencyStore(client *redis.Client, prefix string) *RedisIdempotencyStore {
+	if prefix == "" {
+		prefix = "idempotency"
+	}
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+type redisIdempotencyRecord struct {
+	Fingerprint string          `json:"fingerprint"`
+	Response    *StoredResponse `json:"response,omitempty"`
+}
+
+func (s *RedisIdempotencyStore) key(key string) string {
+	return s.prefix + ":" + key
+}
+
+// Begin implements IdempotencyStore
+func (s *RedisIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*StoredResponse, error) {
+	claim, err := json.Marshal(redisIdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.client.SetNX(ctx, s.key(key), claim, lockTTL).Result()
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	raw, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Released or expired in the meantime; the caller proceeds unclaimed
+		// rather than racing for the key again
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec redisIdempotencyRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, err
+	}
+	return checkRecord(rec.Fingerprint, rec.Response, fingerprint)
+}
+
+// Complete implements IdempotencyStore
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	raw, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if err != nil {
+		return err
+	}
+	var rec redisIdempotencyRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return err
+	}
+	rec.Response = resp
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.client.SetXX(ctx, s.key(key), value, ttl).Err()
+}
+
+// Release implements IdempotencyStore
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.key(key)).Err()
+}
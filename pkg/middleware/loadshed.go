@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// ErrOverloaded is returned when a request is shed because the server is
+// handling as many requests as it can
+var ErrOverloaded = apperrors.New(apperrors.Unavailable, "server is overloaded, retry later").WithReason("OVERLOADED")
+
+// LoadShedOptions configures a LoadShedder
+type LoadShedOptions struct {
+	// MaxConcurrency is the most requests handled at once. The limit
+	// starts there, shrinks while requests are slow and grows back while
+	// they are fast.
+	MaxConcurrency int
+	// MinConcurrency is the lowest the limit shrinks to; at least 1
+	MinConcurrency int
+	// LatencyTarget is the latency above which a completed request
+	// shrinks the limit
+	LatencyTarget time.Duration
+	// QueueSize requests may wait up to QueueTimeout for a slot when the
+	// limit is reached; requests beyond them are rejected at once
+	QueueSize    int
+	QueueTimeout time.Duration
+	// Exempt lists HTTP paths and gRPC full method names that are never
+	// shed or counted, such as health checks and metrics
+	Exempt []string
+}
+
+// Reasons a request is shed, as the reason label of the shed counter
+const (
+	shedQueueFull    = "queue_full"
+	shedQueueTimeout = "queue_timeout"
+	shedCanceled     = "canceled"
+)
+
+// LoadShedder bounds the requests handled at once so that a slow database
+// makes excess requests fail fast with 503 Service Unavailable, instead of
+// piling up goroutines until the process runs out of memory. The bound
+// adapts to latency: each request finishing slower than the latency target
+// cuts it by a tenth (at most once per target interval), and each fast one
+// raises it by 1/limit, so it climbs back by about one per limit's worth of
+// requests.
+type LoadShedder struct {
+	opts   LoadShedOptions
+	exempt map[string]bool
+	now    func() time.Time
+
+	mu           sync.Mutex
+	limit        float64
+	inflight     int
+	waiters      []chan struct{}
+	lastDecrease time.Time
+
+	shed *prometheus.CounterVec
+}
+
+// NewLoadShedder creates a load shedder and registers its metrics with reg:
+// <namespace>_load_shed_total, labeled by transport and reason, and the
+// <namespace>_concurrency_limit and <namespace>_inflight_requests gauges
+func NewLoadShedder(reg prometheus.Registerer, namespace string, opts LoadShedOptions) *LoadShedder {
+	if opts.MinConcurrency < 1 {
+		opts.MinConcurrency = 1
+	}
+	if opts.MaxConcurrency < opts.MinConcurrency {
+		opts.MaxConcurrency = opts.MinConcurrency
+	}
+	s := &LoadShedder{
+		opts:   opts,
+		exempt: make(map[string]bool, len(opts.Exempt)),
+		now:    time.Now,
+		limit:  float64(opts.MaxConcurrency),
+		shed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "load_shed_total",
+			Help:      "Requests rejected because the server was at its concurrency limit, by transport and reason.",
+		}, []string{"transport", "reason"}),
+	}
+	for _, name := range opts.Exempt {
+		s.exempt[name] = true
+	}
+	reg.MustRegister(
+		s.shed,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "concurrency_limit",
+			Help:      "Current adaptive limit on requests handled at once.",
+		}, func() float64 {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return math.Floor(s.limit)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "inflight_requests",
+			Help:      "Requests being handled, not counting those queued for a slot.",
+		}, func() float64 {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return float64(s.inflight)
+		}),
+	)
+	return s
+}
+
+// HTTP sheds requests over the limit with 503 Service Unavailable and
+// Retry-After. Install it early, right after recovery and metrics, so that
+// shed requests cost as little as possible.
+func (s *LoadShedder) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if reason, ok := s.acquire(r.Context()); !ok {
+			s.shed.WithLabelValues("http", reason).Inc()
+			w.Header().Set("Retry-After", "1")
+			apperrors.WriteHTTP(w, r, ErrOverloaded)
+			return
+		}
+		start := time.Now()
+		defer func() { s.release(time.Since(start)) }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor is the gRPC counterpart of HTTP, returning
+// Unavailable. Streams are not limited: they are long-lived, and their
+// latency says nothing about the server's health.
+func (s *LoadShedder) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.exempt[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	if reason, ok := s.acquire(ctx); !ok {
+		s.shed.WithLabelValues("grpc", reason).Inc()
+		return nil, apperrors.ToGRPC(ErrOverloaded)
+	}
+	start := time.Now()
+	defer func() { s.release(time.Since(start)) }()
+	return handler(ctx, req)
+}
+
+// acquire takes a slot, waiting in the queue if there is room in it. It
+// returns why the request was shed when it gets none.
+func (s *LoadShedder) acquire(ctx context.Context) (string, bool) {
+	s.mu.Lock()
+	if len(s.waiters) == 0 && s.inflight < int(s.limit) {
+		s.inflight++
+		s.mu.Unlock()
+		return "", true
+	}
+	if len(s.waiters) >= s.opts.QueueSize || s.opts.QueueTimeout <= 0 {
+		s.mu.Unlock()
+		return shedQueueFull, false
+	}
+	granted := make(chan struct{})
+	s.waiters = append(s.waiters, granted)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(s.opts.QueueTimeout)
+	defer timer.Stop()
+	reason := shedQueueTimeout
+	select {
+	case <-granted:
+		return "", true
+	case <-timer.C:
+	case <-ctx.Done():
+		reason = shedCanceled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, waiter := range s.waiters {
+		if waiter == granted {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return reason, false
+		}
+	}
+	// release handed over a slot while we gave up; keep it
+	return "", true
+}
+
+// release frees a slot, adjusts the limit to the request's latency and
+// hands free slots to queued requests
+func (s *LoadShedder) release(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+
+	if s.opts.LatencyTarget > 0 && elapsed > s.opts.LatencyTarget {
+		if now := s.now(); now.Sub(s.lastDecrease) >= s.opts.LatencyTarget {
+			s.lastDecrease = now
+			s.limit = math.Max(float64(s.opts.MinConcurrency), s.limit*0.9)
+		}
+	} else {
+		s.limit = math.Min(float64(s.opts.MaxConcurrency), s.limit+1/s.limit)
+	}
+
+	for len(s.waiters) > 0 && s.inflight < int(s.limit) {
+		s.inflight++
+		close(s.waiters[0])
+		s.waiters = s.waiters[1:]
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics holds the Prometheus collectors for HTTP requests and gRPC calls
+type Metrics struct {
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcCalls    *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	panics       *prometheus.CounterVec
+}
+
+// NewMetrics creates the request metrics under namespace and registers them
+// with reg
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		grpcCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handled_total",
+			Help:      "gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handling_seconds",
+			Help:      "gRPC call latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "panics_recovered_total",
+			Help:      "Panics recovered from handlers by transport and method.",
+		}, []string{"transport", "method"}),
+	}
+
+	reg.MustRegister(m.httpRequests, m.httpDuration, m.grpcCalls, m.grpcDuration, m.panics)
+	return m
+}
+
+// HTTP records request counts and latency. Requests are labelled with the
+// chi route pattern rather than the raw path to keep label cardinality
+// bounded; unmatched requests are labelled "unmatched".
+func (m *Metrics) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(code)).Inc()
+		observe(r.Context(), m.httpDuration.WithLabelValues(r.Method, route), time.Since(start))
+	})
+}
+
+// routePattern returns the chi route pattern r matched, or "unmatched"
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// UnaryServerInterceptor records gRPC call counts and latency
+func (m *Metrics) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observeGRPC(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor records gRPC stream counts and total stream
+// duration
+func (m *Metrics) StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	m.observeGRPC(stream.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func (m *Metrics) observeGRPC(ctx context.Context, method string, start time.Time, err error) {
+	m.grpcCalls.WithLabelValues(method, status.Code(err).String()).Inc()
+	observe(ctx, m.grpcDuration.WithLabelValues(method), time.Since(start))
+}
+
+// observe records elapsed in a latency histogram. Observations carry the
+// request's trace ID as an exemplar, linking latency outliers to their
+// traces; exemplars are exposed in the OpenMetrics format only.
+func observe(ctx context.Context, o prometheus.Observer, elapsed time.Duration) {
+	if traceID, _ := logging.Trace(ctx); traceID != "" {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(elapsed.Seconds(), prometheus.Labels{"trace_id": traceID})
+			return
+		}
+	}
+	o.Observe(elapsed.Seconds())
+}
+
+// countPanic counts a recovered panic; it is a no-op on a nil *Metrics
+func (m *Metrics) countPanic(transport, method string) {
+	if m == nil {
+		return
+	}
+	m.panics.WithLabelValues(transport, method).Inc()
+}
@@ -0,0 +1,60 @@
+// Package middleware is the shared HTTP and gRPC middleware stack.
+//
+// Every middleware comes as chi-compatible HTTP middleware and as gRPC
+// server interceptors, so the REST and gRPC sides of a service behave the
+// same. A typical service wires them as:
+//
+//	metrics := middleware.NewMetrics(registry, "product_service")
+//
+//	router.Use(middleware.RequestID)
+//	router.Use(logging.HTTPMiddleware(logger))
+//	router.Use(middleware.Recover(logger))
+//	router.Use(metrics.HTTP)
+//	router.Use(middleware.Timeout(30 * time.Second))
+//	router.Use(middleware.BodyLimit(1 << 20))
+//
+//	grpc.NewServer(
+//		grpc.MaxRecvMsgSize(1<<20),
+//		grpc.ChainUnaryInterceptor(
+//			middleware.RequestIDUnary,
+//			logging.UnaryServerInterceptor(logger),
+//			middleware.RecoverUnary(logger),
+//			metrics.UnaryServerInterceptor,
+//			middleware.TimeoutUnary(30*time.Second),
+//		),
+//	)
+//
+// Errors are written with pkg/apperrors, so clients get the same problem
+// bodies and status codes as from the handlers themselves.
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIP returns the host part of the request's remote address. Behind a
+// proxy, run chi's RealIP middleware first.
+func clientIP(r *http.Request) string {
+	return hostOf(r.RemoteAddr)
+}
+
+// hostOf strips the port from a host:port address
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// isSafeMethod reports whether the method does not modify resources
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,993 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func unaryInfo() *grpc.UnaryServerInfo {
+	return &grpc.UnaryServerInfo{FullMethod: "/product.v1.ProductService/GetProduct"}
+}
+
+func TestRequestIDKeepsValidHeaderAndReplacesInvalid(t *testing.T) {
+	var got string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(logging.RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "abc-123", got)
+	assert.Equal(t, "abc-123", rec.Header().Get(logging.RequestIDHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(logging.RequestIDHeader, "has spaces\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Len(t, got, 32)
+	assert.Equal(t, got, rec.Header().Get(logging.RequestIDHeader))
+}
+
+func TestRequestIDUnary(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(logging.RequestIDMetadataKey, "grpc-1"))
+	var got string
+	_, err := RequestIDUnary(ctx, nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = logging.RequestID(ctx)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc-1", got)
+}
+
+func TestAuth(t *testing.T) {
+	authn := StaticTokens(ParseTokens("secret=admin, other"))
+	var principal *Principal
+	handler := Auth(authn, SkipSafeMethods)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFrom(r.Context())
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		header  string
+		status  int
+		subject string
+	}{
+		{"valid token", http.MethodPost, "Bearer secret", http.StatusOK, "admin"},
+		{"token without subject", http.MethodPost, "bearer other", http.StatusOK, "token-2"},
+		{"unknown token", http.MethodPost, "Bearer nope", http.StatusUnauthorized, ""},
+		{"wrong scheme", http.MethodPost, "Basic c2VjcmV0", http.StatusUnauthorized, ""},
+		{"missing header", http.MethodDelete, "", http.StatusUnauthorized, ""},
+		{"safe method skipped", http.MethodGet, "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(tt.method, "/v1/products", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.subject != "" {
+				assert.Equal(t, tt.subject, principal.Subject)
+			}
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+				assert.Equal(t, apperrors.ProblemContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestParseTokensRoles(t *testing.T) {
+	tokens := ParseTokens("a=ops:admin|merchandiser, b=svc, c=:admin")
+
+	assert.Equal(t, &Principal{Subject: "ops", Roles: []string{"admin", "merchandiser"}}, tokens["a"])
+	assert.Equal(t, &Principal{Subject: "svc"}, tokens["b"])
+	assert.Equal(t, &Principal{Subject: "token-3", Roles: []string{"admin"}}, tokens["c"])
+}
+
+func TestAuthenticated(t *testing.T) {
+	authn := StaticTokens(ParseTokens("root=ops:admin"))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/products", nil)
+	_, ok := Authenticated(req, authn)
+	assert.False(t, ok, "anonymous")
+
+	req.Header.Set("Authorization", "Bearer nope")
+	_, ok = Authenticated(req, authn)
+	assert.False(t, ok, "unknown token")
+
+	req.Header.Set("Authorization", "Bearer root")
+	principal, ok := Authenticated(req, authn)
+	require.True(t, ok)
+	assert.True(t, principal.HasRole(RoleAdmin))
+
+	stored := &Principal{Subject: "from-auth"}
+	principal, ok = Authenticated(req.WithContext(WithPrincipal(req.Context(), stored)), authn)
+	require.True(t, ok)
+	assert.Same(t, stored, principal)
+}
+
+func TestRequireRole(t *testing.T) {
+	authn := StaticTokens(ParseTokens("root=ops:admin, user=ann"))
+	handler := RequireRole(authn, "admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFrom(r.Context())
+		io.WriteString(w, principal.Subject)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"admin", "Bearer root", http.StatusOK},
+		{"without the role", "Bearer user", http.StatusForbidden},
+		{"unknown token", "Bearer nope", http.StatusUnauthorized},
+		{"missing header", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/promotions", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "ops", rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("authenticated by Auth", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/promotions", nil)
+		req = req.WithContext(WithPrincipal(req.Context(), &Principal{Subject: "svc", Roles: []string{"admin"}}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "svc", rec.Body.String())
+	})
+}
+
+func TestAuthUnary(t *testing.T) {
+	interceptor := AuthUnary(StaticTokens(ParseTokens("secret=svc")), nil)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		p, _ := PrincipalFrom(ctx)
+		return p.Subject, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+	resp, err := interceptor(ctx, nil, unaryInfo(), handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "svc", resp)
+
+	_, err = interceptor(context.Background(), nil, unaryInfo(), handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSkipMethods(t *testing.T) {
+	skip := SkipMethods("GetProduct", "/product.v1.ProductService/ListProducts")
+	assert.True(t, skip("/product.v1.ProductService/GetProduct"))
+	assert.True(t, skip("/product.v1.ProductService/ListProducts"))
+	assert.False(t, skip("/product.v1.ProductService/DeleteProduct"))
+}
+
+func TestLocalLimiter(t *testing.T) {
+	limiter := NewLocalLimiter(1, 2)
+	now := time.Unix(0, 0)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	allow := func(key string) bool {
+		ok, err := limiter.Allow(ctx, key)
+		assert.NoError(t, err)
+		return ok
+	}
+
+	assert.True(t, allow("a"))
+	assert.True(t, allow("a"))
+	assert.False(t, allow("a"), "burst exhausted")
+	assert.True(t, allow("b"), "keys have separate buckets")
+
+	now = now.Add(time.Second)
+	assert.True(t, allow("a"), "refilled after one second")
+	assert.False(t, allow("a"))
+
+	now = now.Add(time.Hour)
+	limiter.Allow(ctx, "c")
+	assert.NotContains(t, limiter.buckets, "a", "idle buckets are swept")
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := RateLimit(NewLocalLimiter(0.001, 1), discardLogger())(okHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimitUnary(t *testing.T) {
+	interceptor := RateLimitUnary(NewLocalLimiter(0.001, 1), discardLogger())
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	_, err := interceptor(ctx, nil, unaryInfo(), handler)
+	assert.NoError(t, err)
+	_, err = interceptor(ctx, nil, unaryInfo(), handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "boom")
+	assert.Contains(t, buf.String(), `"panic":"boom"`)
+	assert.Contains(t, buf.String(), "stack")
+
+	assert.Panics(t, func() {
+		Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecoverUnary(t *testing.T) {
+	_, err := RecoverUnary(discardLogger())(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestRecoveryReportsPanics(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	var reports []PanicReport
+	rc := Recovery{Logger: discardLogger(), Metrics: metrics, Alert: AlertHookFunc(func(ctx context.Context, report PanicReport) {
+		reports = append(reports, report)
+	})}
+
+	router := chi.NewRouter()
+	router.Use(rc.HTTP)
+	router.Get("/v1/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map write in pricing")
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/products/42", nil)
+	router.ServeHTTP(rec, req.WithContext(logging.WithRequestID(req.Context(), "req-1")))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "pricing")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.panics.WithLabelValues("http", "GET /v1/products/{id}")))
+
+	_, err := rc.Unary(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("index out of range")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), "index out of range")
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.panics.WithLabelValues("grpc", unaryInfo().FullMethod)))
+
+	if assert.Len(t, reports, 2) {
+		assert.Equal(t, "nil map write in pricing", reports[0].Value)
+		assert.Equal(t, "req-1", reports[0].RequestID)
+		assert.Contains(t, reports[0].Stack, "TestRecoveryReportsPanics")
+		assert.Equal(t, "grpc", reports[1].Transport)
+	}
+}
+
+func TestWebhookAlert(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+	}))
+	defer server.Close()
+
+	NewWebhookAlert(server.URL, "product-service", discardLogger()).ReportPanic(context.Background(), PanicReport{Value: "boom", Method: "/svc/Method"})
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "product-service", body["service"])
+		assert.Equal(t, "boom", body["panic"])
+		assert.Equal(t, "/svc/Method", body["method"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert received")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestTimeoutUnaryKeepsClientDeadline(t *testing.T) {
+	interceptor := TimeoutUnary(time.Second)
+	deadlineOf := func(ctx context.Context) time.Time {
+		var got time.Time
+		interceptor(ctx, nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, _ = ctx.Deadline()
+			return nil, nil
+		})
+		return got
+	}
+
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadlineOf(context.Background()), 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	clientDeadline, _ := ctx.Deadline()
+	assert.Equal(t, clientDeadline, deadlineOf(ctx))
+}
+
+func TestBodyLimit(t *testing.T) {
+	var readErr error
+	handler := BodyLimit(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too long")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "BODY_TOO_LARGE")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too long"))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Error(t, readErr)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("ok")))
+	assert.NoError(t, readErr)
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	router := chi.NewRouter()
+	router.Use(metrics.HTTP)
+	router.Get("/v1/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/products/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/products/2", nil))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.httpRequests.WithLabelValues(http.MethodGet, "/v1/products/{id}", "404")))
+
+	metrics.UnaryServerInterceptor(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.grpcCalls.WithLabelValues(unaryInfo().FullMethod, "NotFound")))
+}
+
+func TestSLOTrackerCountsGoodAndBadRequests(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	slos := NewSLOTracker(reg, "test", Objective{
+		Name:    "get_product",
+		Routes:  []string{"GET /v1/products/{id}", unaryInfo().FullMethod},
+		Target:  0.99,
+		Latency: 50 * time.Millisecond,
+	})
+
+	router := chi.NewRouter()
+	router.Use(slos.HTTP)
+	router.Get("/v1/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch chi.URLParam(r, "id") {
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "slow":
+			time.Sleep(60 * time.Millisecond)
+		}
+	})
+	router.Get("/v1/products", okHandler)
+
+	for _, id := range []string{"1", "missing", "broken", "slow"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/products", nil))
+
+	slos.UnaryServerInterceptor(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad id")
+	})
+	slos.UnaryServerInterceptor(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+
+	// The 404 and InvalidArgument are the caller's fault; the 500, the slow
+	// request and Unavailable spend the error budget
+	assert.Equal(t, 3.0, testutil.ToFloat64(slos.requests.WithLabelValues("get_product", "good")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(slos.requests.WithLabelValues("get_product", "bad")))
+}
+
+func TestSLOTrackerSummarizesRollingWindows(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	slos := NewSLOTracker(prometheus.NewRegistry(), "test", Objective{
+		Name:   "get_product",
+		Routes: []string{unaryInfo().FullMethod},
+		Target: 0.9,
+	})
+	slos.now = func() time.Time { return now }
+	call := func(err error) {
+		slos.UnaryServerInterceptor(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+
+	// Two hours ago: all failing; ten minutes ago: one in four failing; now:
+	// all fine
+	now = now.Add(-2 * time.Hour)
+	call(status.Error(codes.Internal, "boom"))
+	now = now.Add(110 * time.Minute)
+	call(nil)
+	call(nil)
+	call(nil)
+	call(status.Error(codes.Internal, "boom"))
+	now = now.Add(10 * time.Minute)
+	call(nil)
+
+	summaries := slos.Summary()
+	if assert.Len(t, summaries, 1) {
+		windows := summaries[0].Windows
+		assert.Equal(t, SLOWindow{Requests: 1}, windows["5m"])
+		assert.Equal(t, int64(5), windows["30m"].Requests)
+		assert.Equal(t, int64(1), windows["30m"].Errors)
+		assert.InDelta(t, 0.2, windows["30m"].ErrorRate, 1e-9)
+		assert.InDelta(t, 2.0, windows["30m"].BurnRate, 1e-9)
+		assert.Equal(t, int64(5), windows["1h"].Requests)
+		assert.Equal(t, int64(6), windows["6h"].Requests)
+		assert.Equal(t, int64(2), windows["6h"].Errors)
+	}
+
+	// Requests drop out once they are older than the window
+	now = now.Add(6 * time.Hour)
+	summaries = slos.Summary()
+	assert.Equal(t, SLOWindow{}, summaries[0].Windows["6h"])
+
+	rec := httptest.NewRecorder()
+	slos.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slo", nil))
+	var body struct {
+		Objectives []SLOSummary `json:"objectives"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	if assert.Len(t, body.Objectives, 1) {
+		assert.Equal(t, "get_product", body.Objectives[0].Name)
+	}
+}
+
+func TestMetricsAttachTraceExemplars(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg, "test")
+
+	ctx := logging.WithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	metrics.UnaryServerInterceptor(ctx, nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	var exemplar string
+	for _, family := range families {
+		if family.GetName() != "test_grpc_server_handling_seconds" {
+			continue
+		}
+		for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+			if e := bucket.GetExemplar(); e != nil {
+				exemplar = e.GetLabel()[0].GetValue()
+			}
+		}
+	}
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exemplar)
+}
+
+func TestLoadShedderRejectsOverLimit(t *testing.T) {
+	shedder := NewLoadShedder(prometheus.NewRegistry(), "test", LoadShedOptions{
+		MaxConcurrency: 1,
+		LatencyTarget:  time.Second,
+		QueueSize:      1,
+		QueueTimeout:   time.Second,
+		Exempt:         []string{"/health"},
+	})
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	handler := shedder.HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			started <- struct{}{}
+			<-release
+		}
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	done := make(chan int, 2)
+	go func() { done <- serve("/v1/products/1").Code }()
+	<-started
+	// The second request queues for the only slot
+	go func() { done <- serve("/v1/products/2").Code }()
+	assert.Eventually(t, func() bool {
+		shedder.mu.Lock()
+		defer shedder.mu.Unlock()
+		return len(shedder.waiters) == 1
+	}, time.Second, time.Millisecond)
+
+	// The queue is full, so the third is shed at once
+	rec := serve("/v1/products/3")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "OVERLOADED")
+	assert.Equal(t, 1.0, testutil.ToFloat64(shedder.shed.WithLabelValues("http", shedQueueFull)))
+
+	// Exempt paths are not limited
+	assert.Equal(t, http.StatusOK, serve("/health").Code)
+
+	// Once the first request finishes, the queued one runs
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+func TestLoadShedderQueueTimeout(t *testing.T) {
+	shedder := NewLoadShedder(prometheus.NewRegistry(), "test", LoadShedOptions{
+		MaxConcurrency: 1,
+		QueueSize:      1,
+		QueueTimeout:   10 * time.Millisecond,
+	})
+	_, ok := shedder.acquire(context.Background())
+	require.True(t, ok)
+
+	_, err := shedder.UnaryServerInterceptor(context.Background(), nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1.0, testutil.ToFloat64(shedder.shed.WithLabelValues("grpc", shedQueueTimeout)))
+	assert.Empty(t, shedder.waiters)
+}
+
+func TestLoadShedderAdaptsLimitToLatency(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	shedder := NewLoadShedder(prometheus.NewRegistry(), "test", LoadShedOptions{
+		MaxConcurrency: 100,
+		MinConcurrency: 10,
+		LatencyTarget:  time.Second,
+	})
+	shedder.now = func() time.Time { return now }
+	slow := func() {
+		shedder.acquire(context.Background())
+		shedder.release(2 * time.Second)
+	}
+
+	// Slow requests cut the limit at most once per latency target
+	slow()
+	slow()
+	assert.InDelta(t, 90, shedder.limit, 1e-9)
+	for i := 0; i < 50; i++ {
+		now = now.Add(time.Second)
+		slow()
+	}
+	assert.Equal(t, 10.0, shedder.limit, "never below the minimum")
+
+	// Fast requests raise it back, up to the maximum
+	for i := 0; i < 10000; i++ {
+		shedder.acquire(context.Background())
+		shedder.release(time.Millisecond)
+	}
+	assert.Equal(t, 100.0, shedder.limit)
+	assert.Zero(t, shedder.inflight)
+}
+
+func TestIdempotencyReplaysRetries(t *testing.T) {
+	calls := 0
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Hour, discardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Location", "/v1/products/42")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post("key-1", `{"name":"Widget"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := post("key-1", `{"name":"Widget"}`)
+	assert.Equal(t, 1, calls, "the retry must not run the handler")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, `{"name":"Widget"}`, retry.Body.String())
+	assert.Equal(t, "/v1/products/42", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+
+	reused := post("key-1", `{"name":"Gadget"}`)
+	assert.Equal(t, http.StatusConflict, reused.Code)
+	assert.Contains(t, reused.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+	post("key-2", `{"name":"Widget"}`)
+	assert.Equal(t, 2, calls, "other keys run the handler")
+}
+
+func TestIdempotencyRunsAgainAfterServerErrors(t *testing.T) {
+	calls := 0
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Hour, discardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, want, rec.Code)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyRejectsConcurrentRetries(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+	fingerprint := hashOf(http.MethodPost, "/v1/users", "{}")
+	_, err := store.Begin(ctx, idempotencyScope(httptest.NewRequest(http.MethodPost, "/", nil), "key-1"), fingerprint, time.Minute)
+	assert.NoError(t, err)
+
+	handler := Idempotency(store, time.Hour, discardLogger())(okHandler)
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader("{}"))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_IN_PROGRESS")
+}
+
+// lockRecorder records the claim durations asked of the store
+type lockRecorder struct {
+	*MemoryIdempotencyStore
+	lockTTL time.Duration
+}
+
+func (s *lockRecorder) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*StoredResponse, error) {
+	s.lockTTL = lockTTL
+	return s.MemoryIdempotencyStore.Begin(ctx, key, fingerprint, lockTTL)
+}
+
+func TestIdempotencyClaimsKeysPastTheDeadline(t *testing.T) {
+	store := &lockRecorder{MemoryIdempotencyStore: NewMemoryIdempotencyStore()}
+	policies := &EndpointPolicies{Default: EndpointPolicy{Timeout: Duration(30 * time.Second)}, Routes: map[string]EndpointPolicy{
+		"POST /v1/reports": {Timeout: Duration(5 * time.Minute)},
+	}}
+	handler := EndpointLimits(policies)(Idempotency(store, time.Hour, discardLogger())(okHandler))
+
+	post := func(path string) time.Duration {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, path)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return store.lockTTL
+	}
+
+	assert.Equal(t, idempotencyLockTTL, post("/v1/products"), "never less than the minimum")
+	assert.Greater(t, post("/v1/reports"), 5*time.Minute, "beyond a longer route timeout")
+}
+
+func TestIdempotencyScopesKeysByPrincipal(t *testing.T) {
+	calls := 0
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Hour, discardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	for _, subject := range []string{"alice", "bob"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req = req.WithContext(WithPrincipal(req.Context(), &Principal{Subject: subject}))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyIgnoresOtherRequests(t *testing.T) {
+	calls := 0
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Hour, discardLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader("{}")))
+		req := httptest.NewRequest(http.MethodPut, "/v1/products/1", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 4, calls)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader("{}"))
+	req.Header.Set(IdempotencyKeyHeader, strings.Repeat("k", 256))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMemoryIdempotencyStoreExpiresRecords(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := store.Begin(ctx, "k", "f", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Complete(ctx, "k", &StoredResponse{Status: http.StatusCreated}, time.Hour))
+
+	stored, err := store.Begin(ctx, "k", "f", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, stored.Status)
+
+	now = now.Add(time.Hour)
+	stored, err = store.Begin(ctx, "k", "other", time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, stored, "expired keys can be reused")
+}
+
+// quotaRecorder is a QuotaLimiter that denies keys in deny and records
+// the buckets and policies it is asked about
+type quotaRecorder struct {
+	deny     map[string]bool
+	keys     []string
+	policies []Policy
+}
+
+func (q *quotaRecorder) AllowQuota(ctx context.Context, key string, policy Policy) (bool, time.Duration, error) {
+	q.keys = append(q.keys, key)
+	q.policies = append(q.policies, policy)
+	if q.deny[key] {
+		return false, 1500 * time.Millisecond, nil
+	}
+	return true, 0, nil
+}
+
+func TestRateLimitPoliciesResolve(t *testing.T) {
+	policies := &RateLimitPolicies{
+		Default: Policy{RPS: 10},
+		Routes:  map[string]Policy{"POST /v1/products/": {RPS: 1}},
+		Callers: map[string]Policy{"batch-importer": {RPS: 100}},
+	}
+
+	policy, key := policies.resolve("POST /v1/products/", "batch-importer", "10.0.0.1")
+	assert.Equal(t, Policy{RPS: 100}, policy, "caller policies win")
+	assert.Equal(t, "caller:batch-importer", key)
+
+	policy, key = policies.resolve("POST /v1/products/", "alice", "10.0.0.1")
+	assert.Equal(t, Policy{RPS: 1}, policy)
+	assert.Equal(t, "route:POST /v1/products/:sub:alice", key)
+
+	policy, key = policies.resolve("GET /v1/products/", "", "10.0.0.1")
+	assert.Equal(t, Policy{RPS: 10}, policy)
+	assert.Equal(t, "default:ip:10.0.0.1", key)
+}
+
+func TestPolicyRateLimit(t *testing.T) {
+	limiter := &quotaRecorder{deny: map[string]bool{"route:POST /v1/products/{id}/inventory:sub:alice": true}}
+	policies := NewPolicySet(RateLimitPolicies{
+		Default: Policy{RPS: 10},
+		Routes:  map[string]Policy{"POST /v1/products/{id}/inventory": {RPS: 1}},
+	})
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), &Principal{Subject: "alice"})))
+		})
+	})
+	router.Use(PolicyRateLimit(limiter, policies, discardLogger()))
+	router.Route("/v1/products", func(r chi.Router) {
+		r.Get("/", okHandler)
+		r.Post("/{id}/inventory", okHandler)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/products/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/products/42/inventory", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, []string{"default:sub:alice", "route:POST /v1/products/{id}/inventory:sub:alice"}, limiter.keys)
+
+	// Policies apply at runtime; zero RPS is unlimited
+	policies.Set(RateLimitPolicies{})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/products/42/inventory", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, limiter.keys, 2)
+}
+
+func TestPolicyRateLimitUnary(t *testing.T) {
+	limiter := &quotaRecorder{deny: map[string]bool{"default:ip:10.0.0.1": true}}
+	interceptor := PolicyRateLimitUnary(limiter, NewPolicySet(RateLimitPolicies{Default: Policy{RPS: 1}}), discardLogger())
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	_, err := interceptor(ctx, nil, unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestPolicySetReloadsFile(t *testing.T) {
+	path := t.TempDir() + "/policies.json"
+	assert.NoError(t, os.WriteFile(path, []byte(`{"default": {"rps": 5, "burst": 10}}`), 0o600))
+
+	set := NewPolicySet(RateLimitPolicies{})
+	assert.NoError(t, set.LoadFile(path))
+	assert.Equal(t, Policy{RPS: 5, Burst: 10}, set.Get().Default)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go set.Watch(ctx, path, 10*time.Millisecond, discardLogger())
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"default": {"rps": 5}, "callers": {"alice": {"rps": 50}}}`), 0o600))
+	assert.Eventually(t, func() bool {
+		return set.Get().Callers["alice"].RPS == 50
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Error(t, set.LoadFile(t.TempDir()+"/missing.json"))
+	assert.Equal(t, 50.0, set.Get().Callers["alice"].RPS, "failed loads keep the previous policies")
+}
+
+func TestEndpointPolicies(t *testing.T) {
+	path := t.TempDir() + "/endpoints.json"
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"default": {"retries": 2},
+		"routes": {
+			"POST /v1/products/import": {"timeout": "5m", "max_body_bytes": 10485760},
+			"/product.v1.ProductService/CheckStock": {"timeout": "200ms", "retries": 0}
+		}
+	}`), 0o600))
+
+	policies, err := LoadEndpointPolicies(path, EndpointPolicy{Timeout: Duration(30 * time.Second), MaxBodyBytes: 1 << 20})
+	require.NoError(t, err)
+
+	retries := 2
+	assert.Equal(t, EndpointPolicy{Timeout: Duration(30 * time.Second), Retries: &retries, MaxBodyBytes: 1 << 20}, policies.Resolve("GET /v1/products/{id}"))
+	imports := policies.Resolve("POST /v1/products/import")
+	assert.Equal(t, Duration(5*time.Minute), imports.Timeout)
+	assert.Equal(t, int64(10<<20), imports.MaxBodyBytes)
+	assert.Equal(t, int64(10<<20), policies.MaxBodyBytes())
+	assert.Equal(t, map[string]int{"CheckStock": 0}, policies.ClientRetries())
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"routes": {"GET /v1/products/": {"timeout": "fast"}}}`), 0o600))
+	_, err = LoadEndpointPolicies(path, EndpointPolicy{})
+	assert.Error(t, err)
+	assert.NoError(t, os.WriteFile(path, []byte(`{"routes": {"GET /v1/products/": {"max_body_bytes": -1}}}`), 0o600))
+	_, err = LoadEndpointPolicies(path, EndpointPolicy{})
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestEndpointLimits(t *testing.T) {
+	policies := &EndpointPolicies{
+		Default: EndpointPolicy{Timeout: Duration(30 * time.Second), MaxBodyBytes: 8},
+		Routes: map[string]EndpointPolicy{
+			"POST /v1/products/import": {Timeout: Duration(5 * time.Minute), MaxBodyBytes: 1024},
+		},
+	}
+	var budget time.Duration
+	router := chi.NewRouter()
+	router.Use(EndpointLimits(policies))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		budget = time.Until(deadline)
+		if _, err := io.ReadAll(r.Body); err != nil {
+			apperrors.WriteHTTP(w, r, ErrBodyTooLarge)
+		}
+	}
+	router.Post("/v1/products/import", handler)
+	router.Post("/v1/products/", handler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/products/import", strings.NewReader(`{"products": []}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Greater(t, budget, 4*time.Minute)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/products/", strings.NewReader(`{"products": []}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "BODY_TOO_LARGE")
+}
+
+func TestEndpointLimitsUnary(t *testing.T) {
+	policies := &EndpointPolicies{
+		Default: EndpointPolicy{Timeout: Duration(30 * time.Second)},
+		Routes: map[string]EndpointPolicy{
+			unaryInfo().FullMethod: {Timeout: Duration(200 * time.Millisecond), MaxBodyBytes: 16},
+		},
+	}
+	interceptor := EndpointLimitsUnary(policies)
+
+	// The endpoint's budget shortens a longer client deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var budget time.Duration
+	_, err := interceptor(ctx, wrapperspb.String("short"), unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		budget = time.Until(deadline)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, budget, 200*time.Millisecond)
+
+	_, err = interceptor(ctx, wrapperspb.String(strings.Repeat("x", 32)), unaryInfo(), func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("oversized request reached the handler")
+		return nil, nil
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrRateLimited is returned when a caller exceeds its request quota
+var ErrRateLimited = apperrors.New(apperrors.RateLimited, "rate limit exceeded")
+
+// Limiter decides whether a request identified by key may proceed
+type Limiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+// idleBucketTTL is how long an unused bucket is kept before being dropped
+const idleBucketTTL = 10 * time.Minute
+
+// LocalLimiter is an in-memory token bucket per key. Each replica enforces
+// its own quota, so the effective limit scales with the replica count.
+type LocalLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLocalLimiter creates a limiter allowing rps requests per second per
+// key with bursts of up to burst requests
+func NewLocalLimiter(rps float64, burst int) *LocalLimiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rps)))
+	}
+	return &LocalLimiter{
+		rate:    rps,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket, reporting false when it is empty
+func (l *LocalLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, nil
+	}
+	b.tokens--
+	return true, nil
+}
+
+// sweep drops idle buckets so the map does not grow with every client seen
+func (l *LocalLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests over the limiter's quota with 429 Too Many
+// Requests. Requests are keyed by client IP. Limiter errors are logged and
+// the request is let through, so a limiter outage does not take the API
+// down with it.
+func RateLimit(limiter Limiter, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, err := limiter.Allow(r.Context(), clientIP(r))
+			if err != nil {
+				logging.FromContext(r.Context(), logger).Warn("Rate limiter failed", "error", err)
+			} else if !allowed {
+				w.Header().Set("Retry-After", "1")
+				apperrors.WriteHTTP(w, r, ErrRateLimited)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitUnary is the gRPC counterpart of RateLimit, keyed by peer
+// address and returning ResourceExhausted
+func RateLimitUnary(limiter Limiter, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := grpcAllow(ctx, limiter, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStream is the streaming counterpart of RateLimitUnary. Only
+// opening a stream counts against the quota.
+func RateLimitStream(limiter Limiter, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := grpcAllow(stream.Context(), limiter, logger); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func grpcAllow(ctx context.Context, limiter Limiter, logger *slog.Logger) error {
+	allowed, err := limiter.Allow(ctx, peerKey(ctx))
+	if err != nil {
+		logging.FromContext(ctx, logger).Warn("Rate limiter failed", "error", err)
+		return nil
+	}
+	if !allowed {
+		return apperrors.ToGRPC(ErrRateLimited)
+	}
+	return nil
+}
+
+// peerKey returns the host of the calling peer
+func peerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	return hostOf(p.Addr.String())
+}
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
+)
+
+// Policy is a request quota of RPS requests per second with bursts of up
+// to Burst requests. A zero RPS means unlimited.
+type Policy struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+}
+
+// RateLimitPolicies selects the quota of each request:
+//
+//   - Callers, keyed by the subject of the authenticated principal (the
+//     owner of an API key, or a user), take precedence;
+//   - then Routes, keyed by "METHOD /chi/route/pattern" for HTTP and the
+//     full method name for gRPC, e.g. "POST /v1/products/" or
+//     "/product.v1.ProductService/CreateProduct";
+//   - other requests get Default.
+//
+// Route and default quotas apply per caller: per subject when
+// authenticated, per client IP otherwise.
+type RateLimitPolicies struct {
+	Default Policy            `json:"default"`
+	Routes  map[string]Policy `json:"routes,omitempty"`
+	Callers map[string]Policy `json:"callers,omitempty"`
+}
+
+// resolve returns the quota of a request to route by subject (empty when
+// unauthenticated) from ip, and the bucket it is counted in
+func (p *RateLimitPolicies) resolve(route, subject, ip string) (Policy, string) {
+	if subject != "" {
+		if policy, ok := p.Callers[subject]; ok {
+			return policy, "caller:" + subject
+		}
+	}
+
+	caller := "ip:" + ip
+	if subject != "" {
+		caller = "sub:" + subject
+	}
+	if policy, ok := p.Routes[route]; ok {
+		return policy, "route:" + route + ":" + caller
+	}
+	return p.Default, "default:" + caller
+}
+
+// PolicySet holds the current rate limit policies. It is safe for
+// concurrent use, and policies can be replaced while serving, either with
+// Set or by watching a JSON file.
+type PolicySet struct {
+	current atomic.Pointer[RateLimitPolicies]
+
+	mu    sync.Mutex
+	stamp fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewPolicySet creates a set holding policies
+func NewPolicySet(policies RateLimitPolicies) *PolicySet {
+	s := &PolicySet{}
+	s.Set(policies)
+	return s
+}
+
+// Set replaces the policies
+func (s *PolicySet) Set(policies RateLimitPolicies) {
+	s.current.Store(&policies)
+}
+
+// Get returns the current policies
+func (s *PolicySet) Get() *RateLimitPolicies {
+	return s.current.Load()
+}
+
+// LoadFile replaces the policies with those in a JSON file
+func (s *PolicySet) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var policies RateLimitPolicies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return fmt.Errorf("invalid rate limit policies in %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Set(policies)
+	s.stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	return nil
+}
+
+// Watch reloads the policies from path every interval while the file
+// changes, until ctx is done. Invalid files are logged and the previous
+// policies kept.
+func (s *PolicySet) Watch(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				logger.Error("Failed to stat rate limit policies", "path", path, "error", err)
+				continue
+			}
+			s.mu.Lock()
+			changed := s.stamp != fileStamp{modTime: info.ModTime(), size: info.Size()}
+			s.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if err := s.LoadFile(path); err != nil {
+				logger.Error("Failed to reload rate limit policies; keeping the previous ones", "error", err)
+				continue
+			}
+			logger.Info("Reloaded rate limit policies", "path", path)
+		}
+	}
+}
+
+// QuotaLimiter is a limiter whose quota is chosen per call
+type QuotaLimiter interface {
+	// AllowQuota counts a request against key's bucket under policy. When
+	// the request is denied, retryAfter tells when the next one will be
+	// allowed.
+	AllowQuota(ctx context.Context, key string, policy Policy) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// PolicyRateLimit rejects requests over the quota their policy gives them
+// with 429 Too Many Requests. Run it after Auth so that caller policies
+// apply. Like RateLimit, limiter errors are logged and the request is let
+// through.
+func PolicyRateLimit(limiter QuotaLimiter, policies *PolicySet, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy, key := policies.Get().resolve(httpRoute(r), subjectOf(r.Context()), clientIP(r))
+			allowed, retryAfter, err := allowQuota(r.Context(), limiter, key, policy)
+			if err != nil {
+				logging.FromContext(r.Context(), logger).Warn("Rate limiter failed", "error", err)
+			} else if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				apperrors.WriteHTTP(w, r, ErrRateLimited)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PolicyRateLimitUnary is the gRPC counterpart of PolicyRateLimit,
+// returning ResourceExhausted
+func PolicyRateLimitUnary(limiter QuotaLimiter, policies *PolicySet, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := grpcAllowQuota(ctx, info.FullMethod, limiter, policies, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// PolicyRateLimitStream is the streaming counterpart of
+// PolicyRateLimitUnary. Only opening a stream counts against the quota.
+func PolicyRateLimitStream(limiter QuotaLimiter, policies *PolicySet, logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := grpcAllowQuota(stream.Context(), info.FullMethod, limiter, policies, logger); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func grpcAllowQuota(ctx context.Context, method string, limiter QuotaLimiter, policies *PolicySet, logger *slog.Logger) error {
+	policy, key := policies.Get().resolve(method, subjectOf(ctx), peerKey(ctx))
+	allowed, _, err := allowQuota(ctx, limiter, key, policy)
+	if err != nil {
+		logging.FromContext(ctx, logger).Warn("Rate limiter failed", "error", err)
+		return nil
+	}
+	if !allowed {
+		return apperrors.ToGRPC(ErrRateLimited)
+	}
+	return nil
+}
+
+func allowQuota(ctx context.Context, limiter QuotaLimiter, key string, policy Policy) (bool, time.Duration, error) {
+	if policy.RPS <= 0 {
+		return true, 0, nil
+	}
+	return limiter.AllowQuota(ctx, key, policy)
+}
+
+// httpRoute returns "METHOD /route/pattern" for the chi route r matches,
+// or the raw path if there is none
+func httpRoute(r *http.Request) string {
+	path := r.URL.Path
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+			path = pattern
+		}
+	}
+	return r.Method + " " + path
+}
+
+func subjectOf(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
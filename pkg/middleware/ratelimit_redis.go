@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript implements the generic cell rate algorithm. A bucket stores
+// its theoretical arrival time (TAT) in microseconds; a request is allowed
+// when the TAT is at most the burst tolerance ahead of now, and each
+// allowed request moves it one emission interval further.
+//
+// KEYS[1] bucket, ARGV[1] emission interval, ARGV[2] burst tolerance (both
+// in microseconds). Returns {allowed, retry after in microseconds}.
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+if tat - now > tolerance then
+	return {0, tat - now - tolerance}
+end
+
+local new_tat = tat + interval
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000) + 1)
+return {1, 0}
+`)
+
+// RedisLimiter is a GCRA rate limiter keeping its buckets in Redis, so the
+// quota is shared by all replicas
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+	policy Policy
+}
+
+var (
+	_ Limiter      = (*RedisLimiter)(nil)
+	_ QuotaLimiter = (*RedisLimiter)(nil)
+)
+
+// NewRedisLimiter creates a limiter keeping buckets under <prefix>:<key>.
+// Allow applies the fallback policy; AllowQuota the policy given.
+func NewRedisLimiter(client *redis.Client, prefix string, fallback Policy) *RedisLimiter {
+	if prefix == "" {
+		prefix = "ratelimit"
+	}
+	return &RedisLimiter{client: client, prefix: prefix, policy: fallback}
+}
+
+// Allow implements Limiter with the limiter's fallback policy
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	allowed, _, err := l.AllowQuota(ctx, key, l.policy)
+	return allowed, err
+}
+
+// AllowQuota implements QuotaLimiter
+func (l *RedisLimiter) AllowQuota(ctx context.Context, key string, policy Policy) (bool, time.Duration, error) {
+	if policy.RPS <= 0 {
+		return true, 0, nil
+	}
+	burst := policy.Burst
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(policy.RPS)))
+	}
+	interval := int64(math.Max(1, float64(time.Second/time.Microsecond)/policy.RPS))
+	tolerance := interval * int64(burst-1)
+
+	res, err := gcraScript.Run(ctx, l.client, []string{l.prefix + ":" + key}, interval, tolerance).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Microsecond, nil
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"google.golang.org/grpc"
+)
+
+// PanicReport describes a recovered panic
+type PanicReport struct {
+	Value     string    `json:"panic"`
+	Stack     string    `json:"stack"`
+	Transport string    `json:"transport"` // http or grpc
+	Method    string    `json:"method"`    // "GET /v1/products/{id}" or the gRPC full method
+	RequestID string    `json:"request_id,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// AlertHook is notified of every recovered panic, e.g. to forward it to an
+// error tracker such as Sentry. ReportPanic runs on the request path, so
+// implementations should hand slow work off to a goroutine.
+type AlertHook interface {
+	ReportPanic(ctx context.Context, report PanicReport)
+}
+
+// AlertHookFunc adapts a function to AlertHook
+type AlertHookFunc func(ctx context.Context, report PanicReport)
+
+// ReportPanic implements AlertHook
+func (f AlertHookFunc) ReportPanic(ctx context.Context, report PanicReport) {
+	f(ctx, report)
+}
+
+// Recovery turns panics in handlers into internal errors. Every panic is
+// logged with its stack, counted in Metrics and reported to Alert when
+// those are set. Callers only ever see a generic internal error; the panic
+// value stays in the logs and the report.
+type Recovery struct {
+	Logger  *slog.Logger
+	Metrics *Metrics  // optional
+	Alert   AlertHook // optional
+}
+
+// Recover is Recovery.HTTP with logging only
+func Recover(logger *slog.Logger) func(http.Handler) http.Handler {
+	return Recovery{Logger: logger}.HTTP
+}
+
+// RecoverUnary is Recovery.Unary with logging only
+func RecoverUnary(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return Recovery{Logger: logger}.Unary
+}
+
+// RecoverStream is Recovery.Stream with logging only
+func RecoverStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return Recovery{Logger: logger}.Stream
+}
+
+// HTTP turns a panic in a handler into a 500 problem response.
+// http.ErrAbortHandler is re-panicked so net/http can abort the connection
+// as intended.
+func (rc Recovery) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+			err := rc.handle(r.Context(), rec, "http", r.Method+" "+routePattern(r))
+			apperrors.WriteHTTP(w, r, err)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unary is the gRPC counterpart of HTTP, returning codes.Internal
+func (rc Recovery) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = apperrors.ToGRPC(rc.handle(ctx, rec, "grpc", info.FullMethod))
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// Stream is the streaming counterpart of Unary
+func (rc Recovery) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = apperrors.ToGRPC(rc.handle(stream.Context(), rec, "grpc", info.FullMethod))
+		}
+	}()
+	return handler(srv, stream)
+}
+
+// handle logs, counts and reports a recovered panic and returns it as an
+// internal error, whose detail apperrors never shows to callers
+func (rc Recovery) handle(ctx context.Context, rec interface{}, transport, method string) error {
+	report := PanicReport{
+		Value:     fmt.Sprint(rec),
+		Stack:     string(debug.Stack()),
+		Transport: transport,
+		Method:    method,
+		RequestID: logging.RequestID(ctx),
+		Time:      time.Now(),
+	}
+	report.TraceID, _ = logging.Trace(ctx)
+
+	logger := rc.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logging.FromContext(ctx, logger).ErrorContext(ctx, "Recovered from panic",
+		"transport", transport, "method", method, "panic", report.Value, "stack", report.Stack)
+	rc.Metrics.countPanic(transport, method)
+	if rc.Alert != nil {
+		rc.Alert.ReportPanic(ctx, report)
+	}
+	return apperrors.Newf(apperrors.Internal, "panic: %v", rec)
+}
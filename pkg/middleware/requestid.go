@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// maxRequestIDLen bounds client-supplied request IDs so they cannot bloat logs
+const maxRequestIDLen = 128
+
+// RequestID assigns every request an ID, taken from the X-Request-ID header
+// or generated, stores it in the context and echoes it in the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := sanitizeRequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestIDUnary is the gRPC counterpart of RequestID, using the
+// x-request-id metadata key
+func RequestIDUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(grpcRequestID(ctx), req)
+}
+
+// RequestIDStream is the streaming counterpart of RequestIDUnary
+func RequestIDStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, withContext(stream, grpcRequestID(stream.Context())))
+}
+
+func grpcRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.RequestIDMetadataKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = sanitizeRequestID(id)
+	grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDMetadataKey, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+// sanitizeRequestID keeps printable client IDs of sane length and
+// generates a new ID otherwise
+func sanitizeRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLen {
+		return logging.NewRequestID()
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return logging.NewRequestID()
+		}
+	}
+	return id
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func withContext(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ServerStream: stream, ctx: ctx}
+}
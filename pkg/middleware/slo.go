@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Objective is a service level objective covering one or more routes and
+// RPCs. A request is good when it does not fail on the server side (5xx,
+// or a gRPC code such as Internal or Unavailable) and, with a Latency
+// threshold, completes within it.
+type Objective struct {
+	Name string
+	// Routes are "METHOD /chi/route/pattern" for HTTP and full method
+	// names for gRPC, e.g. "GET /v1/products/{id}" and
+	// "/product.v1.ProductService/GetProduct"
+	Routes []string
+	// Target is the fraction of requests that must be good, e.g. 0.999
+	Target  float64
+	Latency time.Duration
+}
+
+// sloWindows are the rolling windows reported by the /slo endpoint. Burn
+// rate alerts usually pair a long and a short window, e.g. 1h with 5m.
+var sloWindows = []struct {
+	name    string
+	minutes int
+}{{"5m", 5}, {"30m", 30}, {"1h", 60}, {"6h", 360}}
+
+// sloHistory is the number of minutes of history kept per objective
+const sloHistory = 360
+
+// SLOTracker counts good and bad requests for a set of objectives, both in
+// Prometheus, for alerting on error budget burn, and in memory, for a
+// quick summary of the rolling error rates served as JSON.
+type SLOTracker struct {
+	objectives []*objectiveState
+	byRoute    map[string]*objectiveState
+	requests   *prometheus.CounterVec
+	now        func() time.Time
+}
+
+type objectiveState struct {
+	Objective
+
+	mu      sync.Mutex
+	minutes [sloHistory]int64 // Unix minute each bucket counts
+	good    [sloHistory]int64
+	bad     [sloHistory]int64
+}
+
+// NewSLOTracker creates a tracker for objectives and registers its
+// <namespace>_slo_requests_total counter, labeled by objective and result
+// (good or bad), with reg
+func NewSLOTracker(reg prometheus.Registerer, namespace string, objectives ...Objective) *SLOTracker {
+	t := &SLOTracker{
+		byRoute: make(map[string]*objectiveState),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "slo_requests_total",
+			Help:      "Requests covered by service level objectives, by objective and result.",
+		}, []string{"slo", "result"}),
+		now: time.Now,
+	}
+	for _, objective := range objectives {
+		state := &objectiveState{Objective: objective}
+		t.objectives = append(t.objectives, state)
+		for _, route := range objective.Routes {
+			t.byRoute[route] = state
+		}
+	}
+	reg.MustRegister(t.requests)
+	return t
+}
+
+// HTTP tracks requests to routes covered by an objective
+func (t *SLOTracker) HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		t.record(r.Method+" "+routePattern(r), ww.Status() < 500, time.Since(start))
+	})
+}
+
+// UnaryServerInterceptor tracks calls to RPCs covered by an objective
+func (t *SLOTracker) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	t.record(info.FullMethod, !serverFault(status.Code(err)), time.Since(start))
+	return resp, err
+}
+
+// record counts a request to route, if an objective covers it
+func (t *SLOTracker) record(route string, ok bool, elapsed time.Duration) {
+	state, found := t.byRoute[route]
+	if !found {
+		return
+	}
+	good := ok && (state.Latency <= 0 || elapsed <= state.Latency)
+	result := "good"
+	if !good {
+		result = "bad"
+	}
+	t.requests.WithLabelValues(state.Name, result).Inc()
+
+	minute := t.now().Unix() / 60
+	i := minute % sloHistory
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.minutes[i] != minute {
+		state.minutes[i], state.good[i], state.bad[i] = minute, 0, 0
+	}
+	if good {
+		state.good[i]++
+	} else {
+		state.bad[i]++
+	}
+}
+
+// SLOWindow summarizes an objective over a rolling window. BurnRate is the
+// error rate relative to the error budget: at 1 the budget lasts exactly
+// the SLO period; at 14.4 a 30-day budget is gone in about two days.
+type SLOWindow struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	BurnRate  float64 `json:"burn_rate"`
+}
+
+// SLOSummary summarizes an objective over each rolling window
+type SLOSummary struct {
+	Name    string               `json:"name"`
+	Routes  []string             `json:"routes"`
+	Target  float64              `json:"target"`
+	Latency string               `json:"latency,omitempty"`
+	Windows map[string]SLOWindow `json:"windows"`
+}
+
+// Summary returns the rolling error rates of every objective
+func (t *SLOTracker) Summary() []SLOSummary {
+	current := t.now().Unix() / 60
+	summaries := make([]SLOSummary, 0, len(t.objectives))
+	for _, state := range t.objectives {
+		summary := SLOSummary{
+			Name:    state.Name,
+			Routes:  state.Routes,
+			Target:  state.Target,
+			Windows: make(map[string]SLOWindow, len(sloWindows)),
+		}
+		if state.Latency > 0 {
+			summary.Latency = state.Latency.String()
+		}
+		state.mu.Lock()
+		for _, w := range sloWindows {
+			var window SLOWindow
+			for minute := current - int64(w.minutes) + 1; minute <= current; minute++ {
+				if i := minute % sloHistory; state.minutes[i] == minute {
+					window.Requests += state.good[i] + state.bad[i]
+					window.Errors += state.bad[i]
+				}
+			}
+			if window.Requests > 0 {
+				window.ErrorRate = float64(window.Errors) / float64(window.Requests)
+				if budget := 1 - state.Target; budget > 0 {
+					window.BurnRate = window.ErrorRate / budget
+				}
+			}
+			summary.Windows[w.name] = window
+		}
+		state.mu.Unlock()
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// ServeHTTP serves the summary as JSON, for a /slo debug endpoint
+func (t *SLOTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"objectives": t.Summary()})
+}
+
+// serverFault reports whether a gRPC code means the server failed, as
+// opposed to the caller sending a bad request
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		return true
+	}
+	return false
+}
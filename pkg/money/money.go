@@ -0,0 +1,32 @@
+// Package money holds currency helpers shared by services that exchange
+// amounts in minor units (cents) over the wire.
+package money
+
+import "math"
+
+// minorUnits lists ISO 4217 currencies whose minor unit is not 2 digits
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns the number of decimal digits of a currency's minor unit
+func MinorUnits(currency string) int {
+	if digits, ok := minorUnits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// ToMinor converts a major-unit amount, such as a product price, to minor
+// units, rounding half away from zero
+func ToMinor(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(MinorUnits(currency))))
+}
+
+// FromMinor converts minor units to a major-unit amount
+func FromMinor(amount int64, currency string) float64 {
+	return float64(amount) / math.Pow10(MinorUnits(currency))
+}
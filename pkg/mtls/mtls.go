@@ -0,0 +1,371 @@
+// Package mtls provides TLS and mutual TLS for gRPC servers and clients,
+// with certificates that are reloaded from disk when they change.
+//
+// Every service is configured the same way through TLS_* environment
+// variables (see FromEnv). Certificates identify services by a URI SAN such
+// as spiffe://online-shop/product-service, a DNS SAN or the subject common
+// name; servers can restrict callers to a list of those identities.
+//
+//	creds, err := mtls.Load(cfg.TLS, logger)
+//	...
+//	go creds.Watch(ctx)
+//	server := grpc.NewServer(creds.ServerOption(), ...)
+//	conn, err := grpc.NewClient(addr, creds.DialOption())
+//
+// A nil *Credentials stands for plaintext, so services can pass the result
+// of Load around whether TLS is enabled or not.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for changes
+const DefaultReloadInterval = 30 * time.Second
+
+// Config describes where certificates live and how peers are verified
+type Config struct {
+	CertFile string // PEM certificate chain presented to peers
+	KeyFile  string // PEM private key for CertFile
+	CAFile   string // PEM CA bundle used to verify peers; system roots if empty
+
+	// ClientAuth makes servers require and verify client certificates
+	// (mutual TLS). Clients always present their certificate when they have one.
+	ClientAuth bool
+
+	// AllowedPeers lists the identities a peer must have; any identity is
+	// accepted when empty
+	AllowedPeers []string
+
+	// ServerName overrides the name clients expect in the server certificate
+	ServerName string
+
+	ReloadInterval time.Duration
+}
+
+// FromEnv reads the configuration from TLS_CERT_FILE, TLS_KEY_FILE,
+// TLS_CA_FILE, TLS_CLIENT_AUTH, TLS_ALLOWED_PEERS (comma-separated),
+// TLS_SERVER_NAME and TLS_RELOAD_INTERVAL
+func FromEnv() Config {
+	cfg := Config{
+		CertFile:       os.Getenv("TLS_CERT_FILE"),
+		KeyFile:        os.Getenv("TLS_KEY_FILE"),
+		CAFile:         os.Getenv("TLS_CA_FILE"),
+		ServerName:     os.Getenv("TLS_SERVER_NAME"),
+		ReloadInterval: DefaultReloadInterval,
+	}
+	cfg.ClientAuth, _ = strconv.ParseBool(os.Getenv("TLS_CLIENT_AUTH"))
+	for _, p := range strings.Split(os.Getenv("TLS_ALLOWED_PEERS"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cfg.AllowedPeers = append(cfg.AllowedPeers, p)
+		}
+	}
+	if d, err := time.ParseDuration(os.Getenv("TLS_RELOAD_INTERVAL")); err == nil && d > 0 {
+		cfg.ReloadInterval = d
+	}
+	return cfg
+}
+
+// Enabled reports whether TLS is configured at all
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+// Credentials holds the current certificate and CA pool and hands out TLS
+// configurations that always use the latest ones
+type Credentials struct {
+	cfg    Config
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	stamps map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Load reads the configured certificate, key and CA bundle. It returns nil
+// credentials, meaning plaintext, when TLS is not enabled.
+func Load(cfg Config, logger *slog.Logger) (*Credentials, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("TLS certificate and key must be set together")
+	}
+	if cfg.ClientAuth && cfg.CAFile == "" {
+		return nil, errors.New("TLS client auth requires a CA file")
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = DefaultReloadInterval
+	}
+
+	c := &Credentials{cfg: cfg, logger: logger}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the certificate files again. On error the previous
+// certificate and CA pool stay in use.
+func (c *Credentials) Reload() error {
+	stamps := c.stat()
+
+	var cert *tls.Certificate
+	if c.cfg.CertFile != "" {
+		loaded, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS key pair: %w", err)
+		}
+		cert = &loaded
+	}
+
+	var pool *x509.CertPool
+	if c.cfg.CAFile != "" {
+		pem, err := os.ReadFile(c.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in TLS CA file %s", c.cfg.CAFile)
+		}
+	}
+
+	c.mu.Lock()
+	c.cert, c.pool, c.stamps = cert, pool, stamps
+	c.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the certificates whenever one of the files changes, until
+// ctx is done. Files are polled rather than watched with inotify so that
+// Kubernetes secret updates, which swap symlinks, are picked up reliably.
+func (c *Credentials) Watch(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	ticker := time.NewTicker(c.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.reloadIfChanged()
+		}
+	}
+}
+
+// reloadIfChanged reloads the certificates when a file's modification time
+// or size differs from the last successful load
+func (c *Credentials) reloadIfChanged() bool {
+	stamps := c.stat()
+	c.mu.RLock()
+	changed := !mapsEqual(stamps, c.stamps)
+	c.mu.RUnlock()
+	if !changed {
+		return false
+	}
+
+	if err := c.Reload(); err != nil {
+		c.logger.Error("Failed to reload TLS certificates; keeping the previous ones", "error", err)
+		return false
+	}
+	c.logger.Info("Reloaded TLS certificates", "cert", c.cfg.CertFile, "ca", c.cfg.CAFile)
+	return true
+}
+
+func (c *Credentials) stat() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, name := range []string{c.cfg.CertFile, c.cfg.KeyFile, c.cfg.CAFile} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil {
+			stamps[name] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func mapsEqual(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !v.modTime.Equal(w.modTime) || v.size != w.size {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Credentials) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, c.pool
+}
+
+// GetCertificate returns the current certificate, for use as
+// tls.Config.GetCertificate by servers configured elsewhere
+func (c *Credentials) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, _ := c.current()
+	if cert == nil {
+		return nil, errors.New("no TLS server certificate configured")
+	}
+	return cert, nil
+}
+
+// ServerConfig returns a TLS configuration for servers. Each handshake uses
+// the certificate and CA pool current at that time.
+func (c *Credentials) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := c.current()
+			if cert == nil {
+				return nil, errors.New("no TLS server certificate configured")
+			}
+			cfg := &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				Certificates:     []tls.Certificate{*cert},
+				NextProtos:       []string{"h2"},
+				VerifyConnection: c.verifyPeer,
+			}
+			if c.cfg.ClientAuth {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = pool
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig returns a TLS configuration for clients. The server chain is
+// verified against the CA pool current at handshake time, and the client
+// certificate, if any, is presented for mutual TLS.
+func (c *Credentials) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.cfg.ServerName,
+		// Verification happens in VerifyConnection so that a reloaded CA
+		// pool applies to new connections; the standard check would pin
+		// the pool at creation.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			_, pool := c.current()
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				DNSName:       cs.ServerName,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+				return err
+			}
+			return c.verifyPeer(cs)
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+	}
+}
+
+// verifyPeer enforces AllowedPeers on the peer's leaf certificate
+func (c *Credentials) verifyPeer(cs tls.ConnectionState) error {
+	if len(c.cfg.AllowedPeers) == 0 || len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	for _, id := range Identities(cs.PeerCertificates[0]) {
+		if slices.Contains(c.cfg.AllowedPeers, id) {
+			return nil
+		}
+	}
+	return fmt.Errorf("peer %q is not an allowed identity", Identity(cs.PeerCertificates[0]))
+}
+
+// ServerOption returns the gRPC server option installing the credentials.
+// Nil credentials yield a no-op option, leaving the server plaintext.
+func (c *Credentials) ServerOption() grpc.ServerOption {
+	if c == nil {
+		return grpc.EmptyServerOption{}
+	}
+	return grpc.Creds(credentials.NewTLS(c.ServerConfig()))
+}
+
+// DialOption returns the gRPC dial option for clients. Nil credentials
+// yield plaintext transport credentials.
+func (c *Credentials) DialOption() grpc.DialOption {
+	if c == nil {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(c.ClientConfig()))
+}
+
+// Identities returns the names a certificate identifies its holder by: URI
+// SANs, then DNS SANs, then the subject common name
+func Identities(cert *x509.Certificate) []string {
+	var ids []string
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	ids = append(ids, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	return ids
+}
+
+// Identity returns the primary identity of a certificate
+func Identity(cert *x509.Certificate) string {
+	if ids := Identities(cert); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// PeerIdentity returns the verified certificate identity of the gRPC peer
+// calling with ctx, if it authenticated with a client certificate
+func PeerIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return "", false
+	}
+	return Identity(info.State.PeerCertificates[0]), true
+}
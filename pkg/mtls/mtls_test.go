@@ -0,0 +1,223 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "online-shop test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a leaf certificate for the service to dir and returns the
+// cert and key paths
+func (ca *testCA) issue(t *testing.T, dir, service string, serial int64) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: service},
+		DNSNames:     []string{"localhost"},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: "online-shop", Path: "/" + service}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, service+".crt")
+	keyFile := filepath.Join(dir, service+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// setup issues server and client certificates and loads credentials for both
+func setup(t *testing.T, allowed []string) (server, client *Credentials, ca *testCA, dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	ca = newCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	serverCert, serverKey := ca.issue(t, dir, "product-service", 10)
+	clientCert, clientKey := ca.issue(t, dir, "admin", 20)
+
+	server, err := Load(Config{CertFile: serverCert, KeyFile: serverKey, CAFile: caFile, ClientAuth: true, AllowedPeers: allowed}, discardLogger())
+	require.NoError(t, err)
+	client, err = Load(Config{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile, ServerName: "localhost"}, discardLogger())
+	require.NoError(t, err)
+	return server, client, ca, dir
+}
+
+// handshake runs a TLS handshake over loopback TCP and returns the
+// certificate the client saw and the errors of both sides
+func handshake(t *testing.T, server, client *Credentials) (*x509.Certificate, error, error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		raw, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer raw.Close()
+		conn := tls.Server(raw, server.ServerConfig())
+		err = conn.Handshake()
+		if err == nil {
+			// With TLS 1.3 the client certificate is checked after the
+			// client finishes; read once so failures reach the client
+			_, err = conn.Read(make([]byte, 1))
+		}
+		serverErr <- err
+	}()
+
+	raw, err := net.Dial("tcp", lis.Addr().String())
+	require.NoError(t, err)
+	defer raw.Close()
+	conn := tls.Client(raw, client.ClientConfig())
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	clientErr := conn.Handshake()
+	if clientErr == nil {
+		_, clientErr = conn.Write([]byte{1})
+	}
+	var leaf *x509.Certificate
+	if state := conn.ConnectionState(); len(state.PeerCertificates) > 0 {
+		leaf = state.PeerCertificates[0]
+	}
+	return leaf, <-serverErr, clientErr
+}
+
+func TestMutualTLSHandshake(t *testing.T) {
+	server, client, _, _ := setup(t, []string{"spiffe://online-shop/admin"})
+
+	leaf, serverErr, clientErr := handshake(t, server, client)
+	assert.NoError(t, serverErr)
+	assert.NoError(t, clientErr)
+	assert.Equal(t, "spiffe://online-shop/product-service", Identity(leaf))
+}
+
+func TestRejectsPeerNotAllowed(t *testing.T) {
+	server, client, _, _ := setup(t, []string{"spiffe://online-shop/checkout"})
+
+	_, serverErr, _ := handshake(t, server, client)
+	assert.ErrorContains(t, serverErr, "not an allowed identity")
+}
+
+func TestRejectsClientWithoutCertificate(t *testing.T) {
+	server, client, _, _ := setup(t, nil)
+	anonymous, err := Load(Config{CAFile: client.cfg.CAFile, ServerName: "localhost"}, discardLogger())
+	require.NoError(t, err)
+
+	_, serverErr, _ := handshake(t, server, anonymous)
+	assert.Error(t, serverErr)
+}
+
+func TestReloadPicksUpRotatedCertificate(t *testing.T) {
+	server, client, ca, dir := setup(t, nil)
+	assert.False(t, server.reloadIfChanged(), "nothing changed yet")
+
+	ca.issue(t, dir, "product-service", 11)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "product-service.crt"), later, later))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "product-service.key"), later, later))
+	assert.True(t, server.reloadIfChanged())
+
+	leaf, serverErr, clientErr := handshake(t, server, client)
+	assert.NoError(t, serverErr)
+	assert.NoError(t, clientErr)
+	assert.Equal(t, int64(11), leaf.SerialNumber.Int64())
+}
+
+func TestFailedReloadKeepsPreviousCertificate(t *testing.T) {
+	server, client, _, dir := setup(t, nil)
+
+	later := time.Now().Add(time.Minute)
+	keyFile := filepath.Join(dir, "product-service.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	assert.False(t, server.reloadIfChanged())
+
+	leaf, serverErr, clientErr := handshake(t, server, client)
+	assert.NoError(t, serverErr)
+	assert.NoError(t, clientErr)
+	assert.Equal(t, int64(10), leaf.SerialNumber.Int64())
+}
+
+func TestLoadDisabledAndInvalidConfig(t *testing.T) {
+	creds, err := Load(Config{}, discardLogger())
+	assert.NoError(t, err)
+	assert.Nil(t, creds)
+	assert.NotNil(t, creds.DialOption())
+	assert.NotNil(t, creds.ServerOption())
+
+	_, err = Load(Config{CertFile: "a.crt"}, discardLogger())
+	assert.Error(t, err)
+	_, err = Load(Config{CertFile: "a.crt", KeyFile: "a.key", ClientAuth: true}, discardLogger())
+	assert.Error(t, err)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "/certs/tls.crt")
+	t.Setenv("TLS_CLIENT_AUTH", "true")
+	t.Setenv("TLS_ALLOWED_PEERS", "spiffe://online-shop/admin, spiffe://online-shop/erp-sync")
+	t.Setenv("TLS_RELOAD_INTERVAL", "5s")
+
+	cfg := FromEnv()
+	assert.True(t, cfg.Enabled())
+	assert.True(t, cfg.ClientAuth)
+	assert.Equal(t, []string{"spiffe://online-shop/admin", "spiffe://online-shop/erp-sync"}, cfg.AllowedPeers)
+	assert.Equal(t, 5*time.Second, cfg.ReloadInterval)
+}
@@ -0,0 +1,179 @@
+// Package objectstore writes objects to S3-compatible object storage
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Store writes objects
+type Store interface {
+	// Put writes body to the object with the key, replacing it
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// S3 writes objects to a bucket with requests signed by AWS Signature
+// Version 4, as understood by S3, MinIO and other S3-compatible stores
+type S3 struct {
+	bucketURL       *url.URL
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+	now             func() time.Time
+}
+
+var _ Store = (*S3)(nil)
+
+// NewS3 creates a store for the bucket at bucketURL, either virtual-hosted
+// (https://bucket.s3.eu-west-1.amazonaws.com) or path-style
+// (http://minio:9000/bucket)
+func NewS3(bucketURL, region, accessKeyID, secretAccessKey string) (*S3, error) {
+	u, err := url.Parse(strings.TrimRight(bucketURL, "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid bucket URL %q", bucketURL)
+	}
+	if region == "" || accessKeyID == "" || secretAccessKey == "" {
+		return nil, errors.New("region and credentials are required")
+	}
+	return &S3{
+		bucketURL:       u,
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: time.Minute},
+		now:             time.Now,
+	}, nil
+}
+
+// Put implements Store
+func (s *S3) Put(ctx context.Context, key, contentType string, body []byte) error {
+	if key == "" {
+		return errors.New("object key is required")
+	}
+	path := s.bucketURL.EscapedPath() + "/" + uriEncode(key, false)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.bucketURL.Scheme+"://"+s.bucketURL.Host+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, path, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to put object %s: %s: %s", key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Delete removes the object with the key. Deleting an object that does
+// not exist succeeds.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return errors.New("object key is required")
+	}
+	path := s.bucketURL.EscapedPath() + "/" + uriEncode(key, false)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.bucketURL.Scheme+"://"+s.bucketURL.Host+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	s.sign(req, path, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to delete object %s: %s: %s", key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sign adds the SigV4 Authorization header to a request for path, signing
+// the host, content type if set, payload hash and date
+func (s *S3) sign(req *http.Request, path string, body []byte) {
+	now := s.now().UTC()
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", timestamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + s.bucketURL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + timestamp + "\n"
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		signedHeaders = "content-type;" + signedHeaders
+		canonicalHeaders = "content-type:" + contentType + "\n" + canonicalHeaders
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes everything but the unreserved characters of
+// RFC 3986, and slashes unless encodeSlash is set, as SigV4 requires
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"encoding/json"
+)
+
+// List is a page of items with its pagination metadata. It is encoded as
+//
+//	{"<key>": [...], "total": 42, "page": 1, "page_size": 20, "total_pages": 3, "next_cursor": "..."}
+//
+// where key names the items ("products", "users") so that list responses
+// of all services share one shape while keeping their resource names.
+type List[T any] struct {
+	Key        string
+	Items      []T
+	Total      int
+	Page       int
+	PageSize   int
+	TotalPages int
+	NextCursor string
+
+	index int
+}
+
+// NewList wraps one page of items. Nil item slices are encoded as [].
+func NewList[T any](key string, items []T, total int, req Request) *List[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &List[T]{
+		Key:        key,
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: TotalPages(total, req.PageSize),
+		index:      req.Index(),
+	}
+}
+
+// WithNextCursor sets the cursor for fetching the following page
+func (l *List[T]) WithNextCursor(cursor string) *List[T] {
+	l.NextCursor = cursor
+	return l
+}
+
+// HasMore reports whether pages follow this one
+func (l *List[T]) HasMore() bool {
+	return l.NextCursor != "" || l.index+1 < l.TotalPages
+}
+
+// MarshalJSON implements json.Marshaler
+func (l *List[T]) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{
+		l.Key:         l.Items,
+		"total":       l.Total,
+		"page":        l.Page,
+		"page_size":   l.PageSize,
+		"total_pages": l.TotalPages,
+	}
+	if l.NextCursor != "" {
+		body["next_cursor"] = l.NextCursor
+	}
+	return json.Marshal(body)
+}
@@ -0,0 +1,188 @@
+// Package pagination parses list requests and builds list responses so that
+// every service pages the same way.
+//
+// Handlers parse the query string, pass the request on to the service and
+// wrap the result:
+//
+//	page, err := pagination.Parse(r.URL.Query(), pagination.Options{})
+//	if err != nil {
+//		apperrors.WriteHTTP(w, r, err)
+//		return
+//	}
+//	users, total, err := svc.ListUsers(ctx, page.Offset(), page.PageSize)
+//	json.NewEncoder(w).Encode(pagination.NewList("users", users, total, page))
+//
+// Clients page either by number (page, page_size), by offset (offset,
+// limit) or with the opaque next_cursor returned by the previous page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Defaults used when Options leaves them unset
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Options configures request parsing
+type Options struct {
+	DefaultPageSize int // Page size when the client sends none
+	MaxPageSize     int // Larger page sizes are clamped to this
+
+	// ZeroBasedPages numbers the first page 0 instead of 1. New services
+	// should leave it unset; it keeps older APIs that count from 0 working.
+	ZeroBasedPages bool
+}
+
+func (o Options) defaultPageSize() int {
+	if o.DefaultPageSize > 0 {
+		return o.DefaultPageSize
+	}
+	return DefaultPageSize
+}
+
+func (o Options) maxPageSize() int {
+	if o.MaxPageSize > 0 {
+		return o.MaxPageSize
+	}
+	return MaxPageSize
+}
+
+func (o Options) firstPage() int {
+	if o.ZeroBasedPages {
+		return 0
+	}
+	return 1
+}
+
+// Request is a parsed and clamped page request
+type Request struct {
+	Page     int    // Page number in the API's numbering
+	PageSize int    // Items per page, between 1 and the maximum
+	Cursor   string // Opaque cursor from a previous page, if any
+
+	firstPage int
+	offset    int
+}
+
+// Offset returns the number of items before the requested page
+func (r Request) Offset() int {
+	return r.offset
+}
+
+// Index returns the zero-based page number
+func (r Request) Index() int {
+	return r.Page - r.firstPage
+}
+
+// New builds a request for the given page and page size, clamping both to
+// the allowed range. It suits gRPC handlers whose request messages carry
+// the numbers directly.
+func New(page, pageSize int, opts Options) Request {
+	if pageSize <= 0 {
+		pageSize = opts.defaultPageSize()
+	}
+	if pageSize > opts.maxPageSize() {
+		pageSize = opts.maxPageSize()
+	}
+	first := opts.firstPage()
+	if page < first {
+		page = first
+	}
+	return Request{
+		Page:      page,
+		PageSize:  pageSize,
+		firstPage: first,
+		offset:    (page - first) * pageSize,
+	}
+}
+
+// Parse reads page, page_size, offset, limit and cursor from a query
+// string. limit is an alias of page_size. An offset takes precedence over
+// page; the reported page is then the one containing the offset. Values
+// that are not numbers, negative offsets and an offset combined with a
+// cursor are rejected as invalid.
+func Parse(query url.Values, opts Options) (Request, error) {
+	pageSize, err := intParam(query, "page_size")
+	if err != nil {
+		return Request{}, err
+	}
+	if pageSize == 0 {
+		if pageSize, err = intParam(query, "limit"); err != nil {
+			return Request{}, err
+		}
+	}
+	page, err := intParam(query, "page")
+	if err != nil {
+		return Request{}, err
+	}
+	req := New(page, pageSize, opts)
+
+	req.Cursor = query.Get("cursor")
+	if query.Has("offset") {
+		if req.Cursor != "" {
+			return Request{}, apperrors.New(apperrors.Invalid, "offset and cursor cannot be combined")
+		}
+		offset, err := intParam(query, "offset")
+		if err != nil {
+			return Request{}, err
+		}
+		if offset < 0 {
+			return Request{}, apperrors.New(apperrors.Invalid, "offset must not be negative")
+		}
+		req.offset = offset
+		req.Page = req.firstPage + offset/req.PageSize
+	}
+	return req, nil
+}
+
+func intParam(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, apperrors.Newf(apperrors.Invalid, "%s must be an integer", name)
+	}
+	return n, nil
+}
+
+// TotalPages returns the number of pages needed for total items. An empty
+// result still has one page.
+func TotalPages(total, pageSize int) int {
+	if pageSize <= 0 || total <= 0 {
+		return 1
+	}
+	return (total + pageSize - 1) / pageSize
+}
+
+// EncodeCursor encodes a position, such as the sort key and ID of the last
+// item returned, as an opaque cursor string
+func EncodeCursor(position interface{}) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor into position.
+// Malformed cursors are reported as invalid.
+func DecodeCursor(cursor string, position interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, position)
+	}
+	if err != nil {
+		return apperrors.New(apperrors.Invalid, "invalid cursor")
+	}
+	return nil
+}
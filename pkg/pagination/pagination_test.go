@@ -0,0 +1,87 @@
+package pagination
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		opts     Options
+		page     int
+		pageSize int
+		offset   int
+	}{
+		{"defaults", "", Options{}, 1, DefaultPageSize, 0},
+		{"page and size", "page=3&page_size=10", Options{}, 3, 10, 20},
+		{"limit alias", "limit=5", Options{}, 1, 5, 0},
+		{"clamps size", "page_size=1000", Options{}, 1, MaxPageSize, 0},
+		{"custom limits", "page_size=1000", Options{DefaultPageSize: 10, MaxPageSize: 50}, 1, 50, 0},
+		{"clamps page", "page=-2&page_size=0", Options{}, 1, DefaultPageSize, 0},
+		{"zero based", "page=2&page_size=10", Options{ZeroBasedPages: true}, 2, 10, 20},
+		{"zero based default", "", Options{ZeroBasedPages: true}, 0, DefaultPageSize, 0},
+		{"offset wins", "page=9&offset=25&limit=10", Options{}, 3, 10, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			req, err := Parse(query, tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.page, req.Page)
+			assert.Equal(t, tt.pageSize, req.PageSize)
+			assert.Equal(t, tt.offset, req.Offset())
+		})
+	}
+}
+
+func TestParseRejectsInvalidValues(t *testing.T) {
+	for _, raw := range []string{"page=abc", "page_size=1.5", "offset=-1", "offset=10&cursor=abc"} {
+		query, _ := url.ParseQuery(raw)
+		_, err := Parse(query, Options{})
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), raw)
+	}
+}
+
+func TestNewList(t *testing.T) {
+	list := NewList[string]("products", nil, 45, New(2, 20, Options{}))
+	assert.Equal(t, 3, list.TotalPages)
+	assert.True(t, list.HasMore())
+
+	data, err := json.Marshal(list)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"products": [], "total": 45, "page": 2, "page_size": 20, "total_pages": 3}`, string(data))
+
+	last := NewList("users", []int{1}, 45, New(2, 20, Options{ZeroBasedPages: true}))
+	assert.False(t, last.HasMore())
+	data, _ = json.Marshal(last.WithNextCursor("abc"))
+	assert.Contains(t, string(data), `"next_cursor":"abc"`)
+}
+
+func TestTotalPages(t *testing.T) {
+	assert.Equal(t, 1, TotalPages(0, 20))
+	assert.Equal(t, 1, TotalPages(20, 20))
+	assert.Equal(t, 2, TotalPages(21, 20))
+	assert.Equal(t, 1, TotalPages(5, 0))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	type position struct {
+		Name string `json:"name"`
+		ID   string `json:"id"`
+	}
+	cursor, err := EncodeCursor(position{Name: "Widget", ID: "42"})
+	assert.NoError(t, err)
+
+	var got position
+	assert.NoError(t, DecodeCursor(cursor, &got))
+	assert.Equal(t, position{Name: "Widget", ID: "42"}, got)
+
+	assert.True(t, apperrors.Is(DecodeCursor("!!", &got), apperrors.Invalid))
+}
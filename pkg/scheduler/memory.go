@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore keeps jobs in process. It is used for tests and local
+// development; jobs do not survive a restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[primitive.ObjectID]*Job
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[primitive.ObjectID]*Job)}
+}
+
+// Insert implements Store
+func (m *MemoryStore) Insert(ctx context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job.Key != "" && m.pending(job.Type, job.Key) != nil {
+		return ErrJobExists
+	}
+	job.ID = primitive.NewObjectID()
+	stored := *job
+	m.jobs[job.ID] = &stored
+	return nil
+}
+
+// ClaimDue implements Store
+func (m *MemoryStore) ClaimDue(ctx context.Context, types []string, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	var due []*Job
+	for _, job := range m.jobs {
+		if job.Status == StatusPending && wanted[job.Type] && !job.RunAt.After(now) && !job.LeaseUntil.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*Job, 0, len(due))
+	for _, job := range due {
+		job.LeaseUntil = now.Add(lease)
+		job.Attempts++
+		copied := *job
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+// Complete implements Store
+func (m *MemoryStore) Complete(ctx context.Context, id primitive.ObjectID, now time.Time) error {
+	return m.update(id, func(job *Job) {
+		job.Status = StatusSucceeded
+		job.finish(now)
+	})
+}
+
+// Fail implements Store
+func (m *MemoryStore) Fail(ctx context.Context, id primitive.ObjectID, errMsg string, retryAt time.Time, dead bool, now time.Time) error {
+	return m.update(id, func(job *Job) {
+		job.LastError = errMsg
+		job.UpdatedAt = now
+		job.LeaseUntil = time.Time{}
+		if dead {
+			job.Status = StatusDead
+			job.finish(now)
+			return
+		}
+		job.RunAt = retryAt
+	})
+}
+
+// Cancel implements Store
+func (m *MemoryStore) Cancel(ctx context.Context, jobType, key string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.pending(jobType, key)
+	if job == nil {
+		return ErrJobNotFound
+	}
+	job.Status = StatusCancelled
+	job.finish(now)
+	return nil
+}
+
+// Jobs returns a copy of all jobs in insertion order, for inspection in tests
+func (m *MemoryStore) Jobs() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID.Hex() < jobs[j].ID.Hex() })
+	return jobs
+}
+
+func (m *MemoryStore) pending(jobType, key string) *Job {
+	for _, job := range m.jobs {
+		if job.Status == StatusPending && job.Type == jobType && job.Key == key {
+			return job
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) update(id primitive.ObjectID, fn func(job *Job)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	fn(job)
+	return nil
+}
+
+// finish marks the job as no longer runnable
+func (j *Job) finish(now time.Time) {
+	j.LeaseUntil = time.Time{}
+	j.UpdatedAt = now
+	j.FinishedAt = &now
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore persists jobs in a MongoDB collection
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store backed by the given collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes creates the indexes the store relies on. Finished jobs are
+// removed by MongoDB once retention has passed; zero keeps them forever.
+func (s *MongoStore) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "type", Value: 1}, {Key: "run_at", Value: 1}}},
+		{
+			// At most one pending job per type and key
+			Keys: bson.D{{Key: "type", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"status": StatusPending,
+				"key":    bson.M{"$exists": true},
+			}),
+		},
+	}
+	if retention > 0 {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys:    bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+		})
+	}
+
+	_, err := s.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Insert implements Store
+func (s *MongoStore) Insert(ctx context.Context, job *Job) error {
+	result, err := s.collection.InsertOne(ctx, job)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrJobExists
+	}
+	if err != nil {
+		return err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ClaimDue implements Store. Jobs are leased one at a time so concurrent
+// workers never claim the same job.
+func (s *MongoStore) ClaimDue(ctx context.Context, types []string, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	filter := bson.M{
+		"status": StatusPending,
+		"type":   bson.M{"$in": types},
+		"run_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"lease_until": bson.M{"$exists": false}},
+			bson.M{"lease_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"lease_until": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var claimed []*Job
+	for len(claimed) < limit {
+		var job Job
+		err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return claimed, err
+		}
+		claimed = append(claimed, &job)
+	}
+	return claimed, nil
+}
+
+// Complete implements Store
+func (s *MongoStore) Complete(ctx context.Context, id primitive.ObjectID, now time.Time) error {
+	return s.update(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": StatusSucceeded, "finished_at": now, "updated_at": now},
+		"$unset": bson.M{"lease_until": ""},
+	})
+}
+
+// Fail implements Store
+func (s *MongoStore) Fail(ctx context.Context, id primitive.ObjectID, errMsg string, retryAt time.Time, dead bool, now time.Time) error {
+	set := bson.M{"last_error": errMsg, "updated_at": now}
+	if dead {
+		set["status"] = StatusDead
+		set["finished_at"] = now
+	} else {
+		set["run_at"] = retryAt
+	}
+	return s.update(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_until": ""},
+	})
+}
+
+// Cancel implements Store
+func (s *MongoStore) Cancel(ctx context.Context, jobType, key string, now time.Time) error {
+	return s.update(ctx, bson.M{"type": jobType, "key": key, "status": StatusPending}, bson.M{
+		"$set":   bson.M{"status": StatusCancelled, "finished_at": now, "updated_at": now},
+		"$unset": bson.M{"lease_until": ""},
+	})
+}
+
+func (s *MongoStore) update(ctx context.Context, filter, update bson.M) error {
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
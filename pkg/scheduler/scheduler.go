@@ -0,0 +1,336 @@
+// Package scheduler runs delayed jobs that are persisted in a Store.
+//
+// A service registers a handler per job type and schedules jobs to run at a
+// point in time. Jobs are executed at least once: a job is leased while it
+// runs and is picked up again if the lease expires, so handlers must be
+// idempotent. Failed jobs are retried according to the job type's
+// RetryPolicy and dead-lettered once it is exhausted.
+//
+// Usage:
+//
+//	store := scheduler.NewMongoStore(db.Collection("jobs"))
+//	jobs := scheduler.New(store, scheduler.Options{}, logger)
+//	jobs.Register("coupon.expire", expireCoupon, scheduler.RetryPolicy{MaxAttempts: 10, ...})
+//	go jobs.Run(ctx)
+//
+//	jobs.Schedule(ctx, "coupon.expire", coupon.ExpiresAt, payload, coupon.ID)
+//
+// Only registered job types are run, so several services can share a
+// collection. Keys make scheduling idempotent and let jobs be cancelled.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+	StatusCancelled = "cancelled"
+)
+
+// Store errors
+var (
+	ErrJobExists   = errors.New("job already scheduled")
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is a unit of delayed work
+type Job struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type       string             `bson:"type" json:"type"`
+	Key        string             `bson:"key,omitempty" json:"key,omitempty"` // Optional; at most one pending job per type and key
+	Payload    []byte             `bson:"payload" json:"payload"`
+	Status     string             `bson:"status" json:"status"`
+	RunAt      time.Time          `bson:"run_at" json:"run_at"`
+	Attempts   int                `bson:"attempts" json:"attempts"`
+	LastError  string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LeaseUntil time.Time          `bson:"lease_until,omitempty" json:"-"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Decode unmarshals the job payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler runs a job. Returning an error schedules a retry unless the
+// error is wrapped with Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// RetryPolicy controls how a job type is retried
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts before the job is dead-lettered
+	Backoff     time.Duration // Delay after the first failure, doubled on each retry
+	MaxBackoff  time.Duration
+	Timeout     time.Duration // Deadline for a single run
+}
+
+// DefaultRetryPolicy is used for job types registered without a policy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     10 * time.Second,
+	MaxBackoff:  10 * time.Minute,
+	Timeout:     30 * time.Second,
+}
+
+// delay returns the backoff after the given number of failed attempts
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Store persists jobs
+type Store interface {
+	// Insert adds a pending job; ErrJobExists is returned if a pending job
+	// with the same type and key exists
+	Insert(ctx context.Context, job *Job) error
+	// ClaimDue leases up to limit due pending jobs of the given types and
+	// counts the attempt
+	ClaimDue(ctx context.Context, types []string, now time.Time, lease time.Duration, limit int) ([]*Job, error)
+	// Complete marks a claimed job as succeeded
+	Complete(ctx context.Context, id primitive.ObjectID, now time.Time) error
+	// Fail records an error and either reschedules the job at retryAt or,
+	// if dead is set, dead-letters it
+	Fail(ctx context.Context, id primitive.ObjectID, errMsg string, retryAt time.Time, dead bool, now time.Time) error
+	// Cancel cancels the pending job with the given type and key
+	Cancel(ctx context.Context, jobType, key string, now time.Time) error
+}
+
+// Options tunes the worker loop
+type Options struct {
+	PollInterval time.Duration
+	Lease        time.Duration // Must exceed the longest handler timeout
+	BatchSize    int
+	StoreTimeout time.Duration
+}
+
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Scheduler schedules jobs and runs the ones registered with it
+type Scheduler struct {
+	store    Store
+	opts     Options
+	logger   *slog.Logger
+	now      func() time.Time
+	mu       sync.RWMutex
+	handlers map[string]registration
+}
+
+// New creates a new Scheduler
+func New(store Store, opts Options, logger *slog.Logger) *Scheduler {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.StoreTimeout <= 0 {
+		opts.StoreTimeout = 10 * time.Second
+	}
+
+	return &Scheduler{
+		store:    store,
+		opts:     opts,
+		logger:   logger,
+		now:      time.Now,
+		handlers: make(map[string]registration),
+	}
+}
+
+// Register makes this scheduler run jobs of the given type. A zero policy
+// uses DefaultRetryPolicy.
+func (s *Scheduler) Register(jobType string, handler Handler, policy RetryPolicy) {
+	if policy == (RetryPolicy{}) {
+		policy = DefaultRetryPolicy
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = registration{handler: handler, policy: policy}
+}
+
+// Schedule persists a job to run at runAt. With a non-empty key, scheduling
+// is idempotent: ErrJobExists is returned while a job with the same type
+// and key is pending.
+func (s *Scheduler) Schedule(ctx context.Context, jobType string, runAt time.Time, payload interface{}, key string) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", jobType, err)
+	}
+
+	now := s.now().UTC()
+	job := &Job{
+		Type:      jobType,
+		Key:       key,
+		Payload:   data,
+		Status:    StatusPending,
+		RunAt:     runAt.UTC(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.StoreTimeout)
+	defer cancel()
+	if err := s.store.Insert(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Cancel cancels the pending job with the given type and key
+func (s *Scheduler) Cancel(ctx context.Context, jobType, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.StoreTimeout)
+	defer cancel()
+	return s.store.Cancel(ctx, jobType, key, s.now().UTC())
+}
+
+// Run executes due jobs until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			n, err := s.RunDue(ctx)
+			if err != nil {
+				s.logger.Error("Failed to run due jobs", "error", err)
+			}
+			if err != nil || n < s.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue claims one batch of due jobs of the registered types and runs them
+// concurrently. It returns the number of jobs run.
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	types := make([]string, 0, len(s.handlers))
+	for t := range s.handlers {
+		types = append(types, t)
+	}
+	s.mu.RUnlock()
+	if len(types) == 0 {
+		return 0, nil
+	}
+
+	claimCtx, cancel := context.WithTimeout(ctx, s.opts.StoreTimeout)
+	jobs, err := s.store.ClaimDue(claimCtx, types, s.now().UTC(), s.opts.Lease, s.opts.BatchSize)
+	cancel()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+			s.run(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return len(jobs), fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	return len(jobs), nil
+}
+
+// run executes a claimed job and records the outcome. If recording fails
+// the lease expires and the job runs again.
+func (s *Scheduler) run(ctx context.Context, job *Job) {
+	s.mu.RLock()
+	reg := s.handlers[job.Type]
+	s.mu.RUnlock()
+
+	err := s.invoke(ctx, reg, job)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; the lease expires and another worker runs the job
+		return
+	}
+
+	storeCtx, cancel := context.WithTimeout(context.Background(), s.opts.StoreTimeout)
+	defer cancel()
+	now := s.now().UTC()
+
+	if err == nil {
+		if err := s.store.Complete(storeCtx, job.ID, now); err != nil {
+			s.logger.Error("Failed to complete job", "type", job.Type, "id", job.ID.Hex(), "error", err)
+		}
+		return
+	}
+
+	var permanent permanentError
+	dead := errors.As(err, &permanent) || job.Attempts >= reg.policy.MaxAttempts
+	retryAt := now.Add(reg.policy.delay(job.Attempts))
+	if err := s.store.Fail(storeCtx, job.ID, err.Error(), retryAt, dead, now); err != nil {
+		s.logger.Error("Failed to record job failure", "type", job.Type, "id", job.ID.Hex(), "error", err)
+		return
+	}
+
+	if dead {
+		s.logger.Error("Job dead-lettered", "type", job.Type, "id", job.ID.Hex(), "attempts", job.Attempts, "error", err)
+	} else {
+		s.logger.Warn("Job failed, will retry", "type", job.Type, "id", job.ID.Hex(), "attempts", job.Attempts, "retryAt", retryAt, "error", err)
+	}
+}
+
+// invoke calls the handler with the policy's timeout, turning panics into errors
+func (s *Scheduler) invoke(ctx context.Context, reg registration, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	if reg.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, reg.policy.Timeout)
+		defer cancel()
+	}
+	return reg.handler(ctx, job)
+}
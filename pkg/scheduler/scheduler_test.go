@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestScheduler() (*Scheduler, *MemoryStore) {
+	store := NewMemoryStore()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	s := New(store, Options{Lease: time.Minute, BatchSize: 10}, logger)
+	s.now = func() time.Time { return testNow }
+	return s, store
+}
+
+func TestScheduleAndRun(t *testing.T) {
+	s, store := newTestScheduler()
+	var got []string
+	s.Register("greet", func(ctx context.Context, job *Job) error {
+		var payload struct{ Name string }
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		got = append(got, payload.Name)
+		return nil
+	}, RetryPolicy{})
+
+	_, err := s.Schedule(context.Background(), "greet", testNow.Add(-time.Second), struct{ Name string }{"due"}, "")
+	assert.NoError(t, err)
+	_, err = s.Schedule(context.Background(), "greet", testNow.Add(time.Hour), struct{ Name string }{"later"}, "")
+	assert.NoError(t, err)
+
+	n, err := s.RunDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"due"}, got)
+	jobs := store.Jobs()
+	assert.Equal(t, StatusSucceeded, jobs[0].Status)
+	assert.Equal(t, StatusPending, jobs[1].Status)
+}
+
+func TestScheduleWithKeyIsIdempotent(t *testing.T) {
+	s, _ := newTestScheduler()
+
+	_, err := s.Schedule(context.Background(), "expire", testNow, nil, "r1")
+	assert.NoError(t, err)
+	_, err = s.Schedule(context.Background(), "expire", testNow, nil, "r1")
+	assert.ErrorIs(t, err, ErrJobExists)
+
+	// Once cancelled, the key can be used again
+	assert.NoError(t, s.Cancel(context.Background(), "expire", "r1"))
+	assert.ErrorIs(t, s.Cancel(context.Background(), "expire", "r1"), ErrJobNotFound)
+	_, err = s.Schedule(context.Background(), "expire", testNow, nil, "r1")
+	assert.NoError(t, err)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("retried with backoff then dead-lettered", func(t *testing.T) {
+		s, store := newTestScheduler()
+		calls := 0
+		s.Register("flaky", func(ctx context.Context, job *Job) error {
+			calls++
+			return errors.New("downstream unavailable")
+		}, RetryPolicy{MaxAttempts: 2, Backoff: time.Minute, MaxBackoff: time.Hour})
+		_, err := s.Schedule(context.Background(), "flaky", testNow, nil, "")
+		assert.NoError(t, err)
+
+		s.RunDue(context.Background())
+		job := store.Jobs()[0]
+		assert.Equal(t, StatusPending, job.Status)
+		assert.Equal(t, testNow.Add(time.Minute), job.RunAt)
+		assert.Equal(t, "downstream unavailable", job.LastError)
+
+		// Not due yet
+		n, _ := s.RunDue(context.Background())
+		assert.Equal(t, 0, n)
+
+		s.now = func() time.Time { return testNow.Add(time.Minute) }
+		s.RunDue(context.Background())
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, StatusDead, store.Jobs()[0].Status)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		s, store := newTestScheduler()
+		s.Register("bad", func(ctx context.Context, job *Job) error {
+			return Permanent(errors.New("invalid payload"))
+		}, RetryPolicy{MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Hour})
+		_, err := s.Schedule(context.Background(), "bad", testNow, nil, "")
+		assert.NoError(t, err)
+
+		s.RunDue(context.Background())
+
+		assert.Equal(t, StatusDead, store.Jobs()[0].Status)
+	})
+
+	t.Run("panics are failures", func(t *testing.T) {
+		s, store := newTestScheduler()
+		s.Register("panics", func(ctx context.Context, job *Job) error {
+			panic("boom")
+		}, RetryPolicy{MaxAttempts: 1})
+		_, err := s.Schedule(context.Background(), "panics", testNow, nil, "")
+		assert.NoError(t, err)
+
+		s.RunDue(context.Background())
+
+		job := store.Jobs()[0]
+		assert.Equal(t, StatusDead, job.Status)
+		assert.Contains(t, job.LastError, "boom")
+	})
+}
+
+func TestUnregisteredTypesAreLeftAlone(t *testing.T) {
+	s, store := newTestScheduler()
+	s.Register("mine", func(ctx context.Context, job *Job) error { return nil }, RetryPolicy{})
+	_, err := s.Schedule(context.Background(), "theirs", testNow, nil, "")
+	assert.NoError(t, err)
+
+	n, err := s.RunDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, StatusPending, store.Jobs()[0].Status)
+}
+
+func TestExpiredLeaseIsReclaimed(t *testing.T) {
+	store := NewMemoryStore()
+	assert.NoError(t, store.Insert(context.Background(), &Job{Type: "t", Status: StatusPending, RunAt: testNow}))
+
+	claimed, _ := store.ClaimDue(context.Background(), []string{"t"}, testNow, time.Minute, 10)
+	assert.Len(t, claimed, 1)
+
+	// Still leased by the first worker
+	claimed, _ = store.ClaimDue(context.Background(), []string{"t"}, testNow.Add(30*time.Second), time.Minute, 10)
+	assert.Empty(t, claimed)
+
+	// The worker died; the job runs again
+	claimed, _ = store.ClaimDue(context.Background(), []string{"t"}, testNow.Add(2*time.Minute), time.Minute, 10)
+	if assert.Len(t, claimed, 1) {
+		assert.Equal(t, 2, claimed[0].Attempts)
+	}
+}
@@ -0,0 +1,180 @@
+// Package builders fills test data with plausible values. The values vary
+// from test to test but are the same on every run of a test, so failures
+// reproduce and tests do not come to depend on one hard-coded fixture.
+//
+// Each service has fluent builders for its own domain types in its
+// internal/testutil/builders package; they take their fill from here:
+//
+//	product := builders.NewProduct(t).WithPrice(9.99).WithStock(3).Build()
+package builders
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Epoch is the earliest time Fill returns. Times are fixed rather than
+// relative to now, so that they are reproducible.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Fill generates the values of one test. It is not safe for concurrent use.
+type Fill struct {
+	rng *rand.Rand
+	seq int
+}
+
+var (
+	fillsMu sync.Mutex
+	fills   = map[testing.TB]*Fill{}
+)
+
+// For returns the Fill of t, seeded from the test's name. Builders created
+// in the same test share it, so they get different values.
+func For(t testing.TB) *Fill {
+	fillsMu.Lock()
+	defer fillsMu.Unlock()
+	if fill, ok := fills[t]; ok {
+		return fill
+	}
+	fill := NewFill(seed(t.Name()))
+	fills[t] = fill
+	t.Cleanup(func() {
+		fillsMu.Lock()
+		defer fillsMu.Unlock()
+		delete(fills, t)
+	})
+	return fill
+}
+
+func seed(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
+
+// NewFill returns a Fill with the given seed, for use outside tests such as
+// in benchmarks' setup or seed data
+func NewFill(seed int64) *Fill {
+	return &Fill{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Seq returns 1, 2, 3... for values that must be unique within a test
+func (f *Fill) Seq() int {
+	f.seq++
+	return f.seq
+}
+
+// IntRange returns an int in [min, max]
+func (f *Fill) IntRange(min, max int) int {
+	return min + f.rng.Intn(max-min+1)
+}
+
+// Bool returns true or false
+func (f *Fill) Bool() bool {
+	return f.rng.Intn(2) == 1
+}
+
+// Pick returns one of options
+func (f *Fill) Pick(options ...string) string {
+	return options[f.rng.Intn(len(options))]
+}
+
+// Price returns an amount in [min, max] rounded to cents
+func (f *Fill) Price(min, max float64) float64 {
+	return math.Round((min+f.rng.Float64()*(max-min))*100) / 100
+}
+
+// Word returns a lowercase word
+func (f *Fill) Word() string {
+	return f.Pick(words...)
+}
+
+// Words returns n distinct words, or all of them if n is larger
+func (f *Fill) Words(n int) []string {
+	if n > len(words) {
+		n = len(words)
+	}
+	picked := make([]string, n)
+	for i, j := range f.rng.Perm(len(words))[:n] {
+		picked[i] = words[j]
+	}
+	return picked
+}
+
+// Sentence returns n words as a capitalized sentence
+func (f *Fill) Sentence(n int) string {
+	sentence := strings.Join(f.Words(n), " ")
+	return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+}
+
+// FirstName returns a given name
+func (f *Fill) FirstName() string {
+	return f.Pick(firstNames...)
+}
+
+// LastName returns a family name
+func (f *Fill) LastName() string {
+	return f.Pick(lastNames...)
+}
+
+// Email returns an address that is unique within the test, on a reserved
+// example domain
+func (f *Fill) Email(firstName, lastName string) string {
+	return fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(firstName), strings.ToLower(lastName), f.Seq())
+}
+
+// Phone returns a number in the 555-0100 to 555-0199 range reserved for
+// fiction
+func (f *Fill) Phone() string {
+	return fmt.Sprintf("+1202555%04d", 100+f.rng.Intn(100))
+}
+
+// SKU returns a stock keeping unit that is unique within the test
+func (f *Fill) SKU(prefix string) string {
+	return fmt.Sprintf("%s-%04d", strings.ToUpper(prefix), f.Seq())
+}
+
+// ObjectID returns a MongoDB ObjectID
+func (f *Fill) ObjectID() primitive.ObjectID {
+	var id primitive.ObjectID
+	f.rng.Read(id[:])
+	return id
+}
+
+// UUID returns a version 4 UUID
+func (f *Fill) UUID() string {
+	var b [16]byte
+	f.rng.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Time returns a time within a year after Epoch, to the second
+func (f *Fill) Time() time.Time {
+	return Epoch.Add(time.Duration(f.rng.Int63n(int64(365*24*time.Hour/time.Second))) * time.Second)
+}
+
+var (
+	words = []string{
+		"amber", "bamboo", "canvas", "cedar", "classic", "compact", "copper",
+		"cotton", "denim", "linen", "marble", "matte", "oak", "organic",
+		"pocket", "rustic", "slate", "steel", "travel", "walnut", "wool",
+	}
+	firstNames = []string{
+		"Ada", "Amir", "Bea", "Chen", "Dana", "Eli", "Fatima", "Grace", "Hiro",
+		"Ines", "Jonas", "Kemal", "Lena", "Mateo", "Nia", "Omar", "Priya",
+	}
+	lastNames = []string{
+		"Abbott", "Baker", "Costa", "Dubois", "Evans", "Fischer", "Garcia",
+		"Haddad", "Ito", "Jensen", "Kowalski", "Lopez", "Moreau", "Nakamura",
+	}
+)
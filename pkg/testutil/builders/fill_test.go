@@ -0,0 +1,39 @@
+package builders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForIsReproducible(t *testing.T) {
+	values := func(fill *Fill) []string {
+		return []string{fill.UUID(), fill.Email(fill.FirstName(), fill.LastName()), fill.Time().String()}
+	}
+	got := values(For(t))
+	assert.Equal(t, values(NewFill(seed("TestForIsReproducible"))), got, "the values depend only on the test name")
+
+	t.Run("other name", func(t *testing.T) {
+		assert.NotEqual(t, got, values(For(t)))
+	})
+}
+
+func TestForIsSharedWithinATest(t *testing.T) {
+	assert.Same(t, For(t), For(t))
+	assert.Equal(t, "MUG-0001", For(t).SKU("mug"))
+	assert.Equal(t, "MUG-0002", For(t).SKU("mug"))
+}
+
+func TestFillValues(t *testing.T) {
+	fill := NewFill(1)
+	for i := 0; i < 100; i++ {
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, fill.UUID())
+		assert.Regexp(t, `^\+12025550[01]\d\d$`, fill.Phone())
+
+		price := fill.Price(5, 10)
+		assert.True(t, price >= 5 && price <= 10, "price %v out of range", price)
+		assert.InDelta(t, price*100, float64(int(price*100+0.5)), 1e-6, "price %v is not in cents", price)
+
+		assert.False(t, fill.Time().Before(Epoch))
+	}
+}
@@ -0,0 +1,100 @@
+// Package golden compares API output with golden files kept under the test
+// package's testdata/golden directory, so that renamed fields and format
+// changes show up as test failures and as diffs in review.
+//
+// After an intended change, rewrite the files from the actual output and
+// review the diff. The -update flag only exists in packages with golden
+// tests, so name them rather than using ./...:
+//
+//	go test ./services/product-service/internal/api/rest -update
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the actual output")
+
+// Dir is where golden files are kept, relative to the test package
+const Dir = "testdata/golden"
+
+// Assert compares got with the golden file name, or rewrites the file when
+// the tests run with -update
+func Assert(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(Dir, name)
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s does not exist; run the test with -update to create it", path)
+	}
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "output differs from %s; run the test with -update if the change is intended", path)
+}
+
+// AssertJSON compares JSON with the golden file name.json, indented so
+// that the files diff well
+func AssertJSON(t testing.TB, name string, got []byte) {
+	t.Helper()
+	Assert(t, name+".json", indentJSON(t, got))
+}
+
+// AssertProto compares msg, marshalled to JSON with every field including
+// the unset ones, with the golden file name.json
+func AssertProto(t testing.TB, name string, msg proto.Message) {
+	t.Helper()
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true, UseProtoNames: true}.Marshal(msg)
+	require.NoError(t, err)
+	// protojson output is deliberately unstable in its whitespace
+	AssertJSON(t, name, data)
+}
+
+// AssertResponse compares the status, headers and body of a recorded
+// response with the golden file name.http. Only the given headers are
+// compared, since most vary between runs or Go versions.
+func AssertResponse(t testing.TB, name string, rec *httptest.ResponseRecorder, headers ...string) {
+	t.Helper()
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "HTTP %d\n", rec.Code)
+	sort.Strings(headers)
+	for _, header := range headers {
+		if value := rec.Header().Get(header); value != "" {
+			fmt.Fprintf(&out, "%s: %s\n", header, value)
+		}
+	}
+	out.WriteString("\n")
+
+	body := rec.Body.Bytes()
+	if json.Valid(body) && len(bytes.TrimSpace(body)) > 0 {
+		body = indentJSON(t, body)
+	}
+	out.Write(body)
+	Assert(t, name+".http", out.Bytes())
+}
+
+func indentJSON(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var compact, indented bytes.Buffer
+	require.NoError(t, json.Compact(&compact, data), "invalid JSON: %s", data)
+	require.NoError(t, json.Indent(&indented, compact.Bytes(), "", "  "))
+	return []byte(strings.TrimSpace(indented.String()) + "\n")
+}
@@ -0,0 +1,115 @@
+// Package tracing sets up OpenTelemetry tracing for the services.
+//
+// Setup installs a tracer provider exporting spans over OTLP/HTTP and the
+// W3C trace context propagator. The HTTP middleware and the gRPC server
+// option start a span per request from the incoming traceparent, and
+// services start child spans with the global tracer:
+//
+//	shutdown, err := tracing.Setup(ctx, tracing.Options{Service: "product-service", Endpoint: "http://jaeger:4318"})
+//	defer shutdown(context.Background())
+//	router.Use(tracing.HTTPMiddleware)
+//	grpc.NewServer(tracing.ServerOption())
+//
+//	ctx, span := tracer.Start(ctx, "ProductService.GetProduct")
+//	defer func() { tracing.End(span, err) }()
+//
+// Without Setup the global tracer provider is a no-op, so instrumented code
+// costs next to nothing when tracing is disabled. pkg/logging takes the
+// trace and span IDs of the current span, so logs, metric exemplars and
+// error responses point to the exported traces.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Options configures the tracer provider
+type Options struct {
+	Service string
+	Version string
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g.
+	// http://jaeger:4318; spans are sent to its /v1/traces path
+	Endpoint string
+	// SampleRatio is the fraction of new traces recorded. Requests that
+	// carry a trace follow the sampling decision of their caller.
+	SampleRatio float64
+}
+
+// Setup installs a tracer provider batching spans to the collector as the
+// global provider. The returned function flushes the spans still buffered
+// and stops the provider; it is called on shutdown.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.Service),
+		semconv.ServiceVersion(opts.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// End ends span, recording err on it when it is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// HTTPMiddleware starts a server span for each request, continuing the
+// trace of its traceparent header. Once the request is routed the span is
+// named after the method and chi route pattern, e.g. GET /products/{id}, so
+// that requests for different IDs group together. It goes before
+// logging.HTTPMiddleware so that the logs carry the span's IDs.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if pattern := chi.RouteContext(r.Context()).RoutePattern(); pattern != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + pattern)
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+	})
+	return otelhttp.NewHandler(named, "HTTP request",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+// ServerOption starts a server span for each gRPC call, continuing the trace
+// of its traceparent metadata. The span starts before the interceptors run,
+// so their logs carry its IDs.
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
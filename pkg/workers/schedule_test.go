@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2025, 6, 4, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 6, 4, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 6, 4, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2025, 6, 4, 10, 25, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 6, 5, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2025, 6, 4, 11, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2025, 6, 6, 0, 0, 0, 0, time.UTC)}, // the 13th or a Friday
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := Cron(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.spec)
+	}
+
+	never, err := Cron("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero())
+}
+
+func TestCronErrors(t *testing.T) {
+	for spec, want := range map[string]string{
+		"* * * *":     "must have 5 fields",
+		"60 * * * *":  "minute",
+		"* 5-1 * * *": "runs backwards",
+		"*/0 * * * *": "step",
+		"* * * 13 *":  "month",
+		"@yearly":     "must have 5 fields",
+	} {
+		_, err := Cron(spec)
+		assert.ErrorContains(t, err, want, spec)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Date(2025, 6, 4, 10, 7, 30, 0, time.UTC)
+	assert.Equal(t, from.Add(time.Minute), Every(time.Minute).Next(from))
+}
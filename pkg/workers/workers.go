@@ -0,0 +1,276 @@
+// Package workers runs a service's background work, such as expiring
+// reservations or capturing snapshots, on schedules.
+//
+// Each worker is a named function run on an interval or cron Schedule.
+// The Group runs every worker in its own goroutine with its own context,
+// so one worker that fails, panics or runs long does not hold up the
+// others, and stops them all on shutdown, waiting for runs in flight:
+//
+//	group := workers.NewGroup(workers.Options{Locker: locker, Metrics: metrics}, logger)
+//	group.Add(workers.Worker{
+//		Name:      "inventory-snapshots",
+//		Schedule:  workers.Every(time.Hour),
+//		Run:       captureSnapshots, // func(ctx context.Context) error
+//		Singleton: true,
+//	})
+//	group.Start(ctx)
+//	...
+//	group.Stop(shutdownCtx)
+//
+// Singleton workers run on one replica at a time, holding a lock named
+// after the worker (see pkg/locks).
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Run results, as recorded in metrics
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultPanic   = "panic"
+	ResultSkipped = "skipped" // a singleton whose lock another replica held
+)
+
+// Worker is a named unit of background work
+type Worker struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+
+	// RunOnStart runs the worker once when the group starts, before its
+	// schedule
+	RunOnStart bool
+	// Jitter delays each scheduled run by a random duration up to Jitter,
+	// so replicas started together do not all run at once
+	Jitter time.Duration
+	// Timeout bounds a single run; zero leaves it unbounded
+	Timeout time.Duration
+	// Singleton runs the worker on one replica at a time; replicas that
+	// find it running elsewhere skip the round
+	Singleton bool
+}
+
+// Options configures a Group
+type Options struct {
+	// Locker takes the locks of singleton workers
+	Locker *locks.Locker
+	// Metrics records runs; nil records nothing
+	Metrics *Metrics
+}
+
+// Group runs a set of workers
+type Group struct {
+	opts    Options
+	logger  *slog.Logger
+	workers []Worker
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+// NewGroup creates an empty group
+func NewGroup(opts Options, logger *slog.Logger) *Group {
+	return &Group{opts: opts, logger: logger, now: time.Now}
+}
+
+// Add registers a worker. Workers must be added before Start; a worker
+// without name, schedule or function, or a singleton without a Locker, is
+// a programming error and panics.
+func (g *Group) Add(w Worker) {
+	switch {
+	case w.Name == "" || w.Schedule == nil || w.Run == nil:
+		panic(fmt.Sprintf("workers: worker %q needs a name, a schedule and a function", w.Name))
+	case w.Singleton && g.opts.Locker == nil:
+		panic(fmt.Sprintf("workers: singleton worker %q needs Options.Locker", w.Name))
+	}
+	g.workers = append(g.workers, w)
+}
+
+// Start runs every worker until ctx is done or Stop is called
+func (g *Group) Start(ctx context.Context) {
+	ctx, g.cancel = context.WithCancel(ctx)
+	for _, w := range g.workers {
+		g.wg.Add(1)
+		go g.loop(ctx, w)
+	}
+}
+
+// Stop cancels the workers' contexts and waits for runs in flight to
+// return, or for ctx to be done
+func (g *Group) Stop(ctx context.Context) error {
+	if g.cancel != nil {
+		g.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers still running: %w", ctx.Err())
+	}
+}
+
+func (g *Group) loop(ctx context.Context, w Worker) {
+	defer g.wg.Done()
+
+	if w.RunOnStart {
+		g.runOnce(ctx, w)
+	}
+	for {
+		now := g.now()
+		next := w.Schedule.Next(now)
+		if next.IsZero() {
+			g.logger.Error("Worker schedule has no next run; stopping the worker", "worker", w.Name)
+			return
+		}
+		if w.Jitter > 0 {
+			next = next.Add(rand.N(w.Jitter))
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		g.runOnce(ctx, w)
+	}
+}
+
+// runOnce runs the worker, recording the result
+func (g *Group) runOnce(ctx context.Context, w Worker) {
+	if ctx.Err() != nil {
+		return
+	}
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	g.opts.Metrics.started(w.Name)
+	start := g.now()
+	result, err := g.call(ctx, w)
+	g.opts.Metrics.finished(w.Name, result, g.now().Sub(start))
+
+	var panicErr *panicError
+	switch {
+	case errors.As(err, &panicErr):
+		g.logger.Error("Worker panicked", "worker", w.Name, "panic", panicErr.value, "stack", panicErr.stack)
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		// Stopped mid-run by shutdown
+	case err != nil:
+		g.logger.Error("Worker run failed", "worker", w.Name, "error", err)
+	}
+}
+
+// call runs the worker and turns a panic into an error, so it only ends
+// the run
+func (g *Group) call(ctx context.Context, w Worker) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = ResultPanic, &panicError{value: r, stack: string(debug.Stack())}
+		}
+	}()
+
+	ran := true
+	if w.Singleton {
+		ran, err = g.opts.Locker.Do(ctx, w.Name, w.Run)
+	} else {
+		err = w.Run(ctx)
+	}
+	switch {
+	case err != nil:
+		return ResultError, err
+	case !ran:
+		return ResultSkipped, nil
+	}
+	return ResultSuccess, nil
+}
+
+type panicError struct {
+	value interface{}
+	stack string
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// Metrics holds the Prometheus collectors for the workers of one process
+type Metrics struct {
+	runs        *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	running     *prometheus.GaugeVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+// NewMetrics creates the worker metrics under namespace and registers them
+// with reg
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "worker_runs_total",
+			Help:      "Background worker runs by worker and result: success, error, panic or skipped.",
+		}, []string{"worker", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "worker_run_duration_seconds",
+			Help:      "Duration of background worker runs.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+		}, []string{"worker"}),
+		running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "worker_running",
+			Help:      "Whether a background worker is running: 1 running, 0 waiting.",
+		}, []string{"worker"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "worker_last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful run of a background worker.",
+		}, []string{"worker"}),
+	}
+	reg.MustRegister(m.runs, m.duration, m.running, m.lastSuccess)
+	return m
+}
+
+func (m *Metrics) started(worker string) {
+	if m == nil {
+		return
+	}
+	m.running.WithLabelValues(worker).Set(1)
+}
+
+func (m *Metrics) finished(worker, result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.running.WithLabelValues(worker).Set(0)
+	m.runs.WithLabelValues(worker, result).Inc()
+	if result == ResultSkipped {
+		return
+	}
+	m.duration.WithLabelValues(worker).Observe(duration.Seconds())
+	if result == ResultSuccess {
+		m.lastSuccess.WithLabelValues(worker).SetToCurrentTime()
+	}
+}
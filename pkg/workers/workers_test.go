@@ -0,0 +1,143 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestGroupRunsWorkers(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	group := NewGroup(Options{Metrics: metrics}, discard)
+
+	var ticks, panics, failures atomic.Int32
+	group.Add(Worker{Name: "tick", Schedule: Every(5 * time.Millisecond), RunOnStart: true, Run: func(context.Context) error {
+		ticks.Add(1)
+		return nil
+	}})
+	group.Add(Worker{Name: "panic", Schedule: Every(5 * time.Millisecond), Run: func(context.Context) error {
+		panics.Add(1)
+		panic("boom")
+	}})
+	group.Add(Worker{Name: "fail", Schedule: Every(5 * time.Millisecond), Run: func(context.Context) error {
+		failures.Add(1)
+		return errors.New("store unavailable")
+	}})
+
+	group.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return ticks.Load() >= 3 && panics.Load() >= 2 && failures.Load() >= 2
+	}, time.Second, 5*time.Millisecond, "a panicking worker keeps its schedule and the others run")
+	require.NoError(t, group.Stop(context.Background()))
+
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.runs.WithLabelValues("tick", ResultSuccess)), 3.0)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.runs.WithLabelValues("panic", ResultPanic)), 2.0)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.runs.WithLabelValues("fail", ResultError)), 2.0)
+	assert.Positive(t, testutil.ToFloat64(metrics.lastSuccess.WithLabelValues("tick")))
+	assert.Zero(t, testutil.ToFloat64(metrics.lastSuccess.WithLabelValues("fail")))
+
+	stopped := ticks.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, ticks.Load(), "no runs after Stop")
+}
+
+func TestStopWaitsForRuns(t *testing.T) {
+	group := NewGroup(Options{}, discard)
+	started := make(chan struct{})
+	var finished atomic.Bool
+	group.Add(Worker{Name: "slow", Schedule: Every(time.Hour), RunOnStart: true, Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond) // wrapping up
+		finished.Store(true)
+		return ctx.Err()
+	}})
+	group.Start(context.Background())
+	<-started
+
+	require.NoError(t, group.Stop(context.Background()))
+	assert.True(t, finished.Load())
+}
+
+func TestStopGivesUpAtDeadline(t *testing.T) {
+	group := NewGroup(Options{}, discard)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	group.Add(Worker{Name: "stuck", Schedule: Every(time.Hour), RunOnStart: true, Run: func(context.Context) error {
+		close(started)
+		<-release // ignores its context
+		return nil
+	}})
+	group.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, group.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestTimeout(t *testing.T) {
+	group := NewGroup(Options{}, discard)
+	errs := make(chan error, 1)
+	group.Add(Worker{Name: "slow", Schedule: Every(time.Hour), RunOnStart: true, Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		errs <- ctx.Err()
+		return ctx.Err()
+	}})
+	group.Start(context.Background())
+	defer group.Stop(context.Background())
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("the run was not cut off at its timeout")
+	}
+}
+
+func TestSingletonSkipsWhileLockedElsewhere(t *testing.T) {
+	store := locks.NewMemoryStore()
+	locker := locks.New(store, locks.Options{Owner: "a"}, discard)
+	metrics := NewMetrics(prometheus.NewRegistry(), "test")
+	group := NewGroup(Options{Locker: locker, Metrics: metrics}, discard)
+
+	var runs atomic.Int32
+	group.Add(Worker{Name: "snapshots", Schedule: Every(5 * time.Millisecond), Singleton: true, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	// Another replica holds the lock
+	_, err := store.Acquire(context.Background(), "snapshots", "b", time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	group.Start(context.Background())
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.runs.WithLabelValues("snapshots", ResultSkipped)) >= 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Zero(t, runs.Load())
+
+	require.NoError(t, store.Release(context.Background(), "snapshots", "b"))
+	require.Eventually(t, func() bool { return runs.Load() > 0 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, group.Stop(context.Background()))
+}
+
+func TestAddRejectsIncompleteWorkers(t *testing.T) {
+	group := NewGroup(Options{}, discard)
+	run := func(context.Context) error { return nil }
+	assert.Panics(t, func() { group.Add(Worker{Name: "x", Run: run}) })
+	assert.Panics(t, func() { group.Add(Worker{Name: "x", Schedule: Every(time.Second), Run: run, Singleton: true}) })
+}
@@ -0,0 +1,377 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: fx/v1/fx.proto
+
+package fxv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Request and Response messages
+type ConvertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        int64                  `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"` // Minor units of the source currency
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`      // ISO 4217
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`          // ISO 4217
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_fx_v1_fx_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fx_v1_fx_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_fx_v1_fx_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConvertRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ConvertRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type ConvertResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        int64                  `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"` // Minor units of the target currency
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Rate          string                 `protobuf:"bytes,4,opt,name=rate,proto3" json:"rate,omitempty"`              // Decimal rate applied, 1 from = rate to
+	AsOf          int64                  `protobuf:"varint,5,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"` // Unix time the rates were published
+	Provider      string                 `protobuf:"bytes,6,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertResponse) Reset() {
+	*x = ConvertResponse{}
+	mi := &file_fx_v1_fx_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertResponse) ProtoMessage() {}
+
+func (x *ConvertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fx_v1_fx_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertResponse.ProtoReflect.Descriptor instead.
+func (*ConvertResponse) Descriptor() ([]byte, []int) {
+	return file_fx_v1_fx_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ConvertResponse) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ConvertResponse) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+func (x *ConvertResponse) GetAsOf() int64 {
+	if x != nil {
+		return x.AsOf
+	}
+	return 0
+}
+
+func (x *ConvertResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type GetRatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Base          string                 `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"` // Empty means the provider's base currency
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRatesRequest) Reset() {
+	*x = GetRatesRequest{}
+	mi := &file_fx_v1_fx_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatesRequest) ProtoMessage() {}
+
+func (x *GetRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fx_v1_fx_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatesRequest.ProtoReflect.Descriptor instead.
+func (*GetRatesRequest) Descriptor() ([]byte, []int) {
+	return file_fx_v1_fx_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRatesRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+type GetRatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Base          string                 `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	Rates         map[string]string      `protobuf:"bytes,2,rep,name=rates,proto3" json:"rates,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Currency to decimal rate
+	AsOf          int64                  `protobuf:"varint,3,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	FetchedAt     int64                  `protobuf:"varint,4,opt,name=fetched_at,json=fetchedAt,proto3" json:"fetched_at,omitempty"`
+	Provider      string                 `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRatesResponse) Reset() {
+	*x = GetRatesResponse{}
+	mi := &file_fx_v1_fx_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatesResponse) ProtoMessage() {}
+
+func (x *GetRatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fx_v1_fx_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatesResponse.ProtoReflect.Descriptor instead.
+func (*GetRatesResponse) Descriptor() ([]byte, []int) {
+	return file_fx_v1_fx_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRatesResponse) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *GetRatesResponse) GetRates() map[string]string {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+func (x *GetRatesResponse) GetAsOf() int64 {
+	if x != nil {
+		return x.AsOf
+	}
+	return 0
+}
+
+func (x *GetRatesResponse) GetFetchedAt() int64 {
+	if x != nil {
+		return x.FetchedAt
+	}
+	return 0
+}
+
+func (x *GetRatesResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+var File_fx_v1_fx_proto protoreflect.FileDescriptor
+
+const file_fx_v1_fx_proto_rawDesc = "" +
+	"\n" +
+	"\x0efx/v1/fx.proto\x12\x05fx.v1\"L\n" +
+	"\x0eConvertRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x03R\x06amount\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\"\x92\x01\n" +
+	"\x0fConvertResponse\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x03R\x06amount\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x12\n" +
+	"\x04rate\x18\x04 \x01(\tR\x04rate\x12\x13\n" +
+	"\x05as_of\x18\x05 \x01(\x03R\x04asOf\x12\x1a\n" +
+	"\bprovider\x18\x06 \x01(\tR\bprovider\"%\n" +
+	"\x0fGetRatesRequest\x12\x12\n" +
+	"\x04base\x18\x01 \x01(\tR\x04base\"\xea\x01\n" +
+	"\x10GetRatesResponse\x12\x12\n" +
+	"\x04base\x18\x01 \x01(\tR\x04base\x128\n" +
+	"\x05rates\x18\x02 \x03(\v2\".fx.v1.GetRatesResponse.RatesEntryR\x05rates\x12\x13\n" +
+	"\x05as_of\x18\x03 \x01(\x03R\x04asOf\x12\x1d\n" +
+	"\n" +
+	"fetched_at\x18\x04 \x01(\x03R\tfetchedAt\x12\x1a\n" +
+	"\bprovider\x18\x05 \x01(\tR\bprovider\x1a8\n" +
+	"\n" +
+	"RatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\x86\x01\n" +
+	"\tFXService\x12:\n" +
+	"\aConvert\x12\x15.fx.v1.ConvertRequest\x1a\x16.fx.v1.ConvertResponse\"\x00\x12=\n" +
+	"\bGetRates\x12\x16.fx.v1.GetRatesRequest\x1a\x17.fx.v1.GetRatesResponse\"\x00B1Z/github.com/bekbull/online-shop/proto/fx/v1;fxv1b\x06proto3"
+
+var (
+	file_fx_v1_fx_proto_rawDescOnce sync.Once
+	file_fx_v1_fx_proto_rawDescData []byte
+)
+
+func file_fx_v1_fx_proto_rawDescGZIP() []byte {
+	file_fx_v1_fx_proto_rawDescOnce.Do(func() {
+		file_fx_v1_fx_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fx_v1_fx_proto_rawDesc), len(file_fx_v1_fx_proto_rawDesc)))
+	})
+	return file_fx_v1_fx_proto_rawDescData
+}
+
+var file_fx_v1_fx_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_fx_v1_fx_proto_goTypes = []any{
+	(*ConvertRequest)(nil),   // 0: fx.v1.ConvertRequest
+	(*ConvertResponse)(nil),  // 1: fx.v1.ConvertResponse
+	(*GetRatesRequest)(nil),  // 2: fx.v1.GetRatesRequest
+	(*GetRatesResponse)(nil), // 3: fx.v1.GetRatesResponse
+	nil,                      // 4: fx.v1.GetRatesResponse.RatesEntry
+}
+var file_fx_v1_fx_proto_depIdxs = []int32{
+	4, // 0: fx.v1.GetRatesResponse.rates:type_name -> fx.v1.GetRatesResponse.RatesEntry
+	0, // 1: fx.v1.FXService.Convert:input_type -> fx.v1.ConvertRequest
+	2, // 2: fx.v1.FXService.GetRates:input_type -> fx.v1.GetRatesRequest
+	1, // 3: fx.v1.FXService.Convert:output_type -> fx.v1.ConvertResponse
+	3, // 4: fx.v1.FXService.GetRates:output_type -> fx.v1.GetRatesResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_fx_v1_fx_proto_init() }
+func file_fx_v1_fx_proto_init() {
+	if File_fx_v1_fx_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fx_v1_fx_proto_rawDesc), len(file_fx_v1_fx_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fx_v1_fx_proto_goTypes,
+		DependencyIndexes: file_fx_v1_fx_proto_depIdxs,
+		MessageInfos:      file_fx_v1_fx_proto_msgTypes,
+	}.Build()
+	File_fx_v1_fx_proto = out.File
+	file_fx_v1_fx_proto_goTypes = nil
+	file_fx_v1_fx_proto_depIdxs = nil
+}
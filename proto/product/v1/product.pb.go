@@ -0,0 +1,3396 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: product/v1/product.proto
+
+package productv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Product data structures
+type Product struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description       string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price             float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	ImageUrls         []string               `protobuf:"bytes,5,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	Category          string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Inventory         *InventoryInfo         `protobuf:"bytes,7,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	Tags              []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Attributes        map[string]string      `protobuf:"bytes,9,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Active            bool                   `protobuf:"varint,10,opt,name=active,proto3" json:"active,omitempty"`
+	CreatedAt         int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt         int64                  `protobuf:"varint,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Suppliers         []*ProductSupplier     `protobuf:"bytes,13,rep,name=suppliers,proto3" json:"suppliers,omitempty"`
+	Featured          bool                   `protobuf:"varint,14,opt,name=featured,proto3" json:"featured,omitempty"`
+	FeaturedUntil     int64                  `protobuf:"varint,15,opt,name=featured_until,json=featuredUntil,proto3" json:"featured_until,omitempty"` // 0 when featured without an expiry
+	IsNew             bool                   `protobuf:"varint,16,opt,name=is_new,json=isNew,proto3" json:"is_new,omitempty"`                         // Created within the new arrival window
+	Type              string                 `protobuf:"bytes,17,opt,name=type,proto3" json:"type,omitempty"`                                         // "physical" or "digital"; empty is physical
+	Digital           *DigitalInfo           `protobuf:"bytes,18,opt,name=digital,proto3" json:"digital,omitempty"`                                   // Downloads of digital products
+	Weight            *Weight                `protobuf:"bytes,19,opt,name=weight,proto3" json:"weight,omitempty"`                                     // In kg
+	Dimensions        *Dimensions            `protobuf:"bytes,20,opt,name=dimensions,proto3" json:"dimensions,omitempty"`                             // In cm
+	ShippingClass     string                 `protobuf:"bytes,21,opt,name=shipping_class,json=shippingClass,proto3" json:"shipping_class,omitempty"`
+	Barcode           string                 `protobuf:"bytes,22,opt,name=barcode,proto3" json:"barcode,omitempty"`                                               // GTIN, UPC-A as EAN-13
+	MinOrderQuantity  int32                  `protobuf:"varint,23,opt,name=min_order_quantity,json=minOrderQuantity,proto3" json:"min_order_quantity,omitempty"`  // 0 is no minimum; CheckStock refuses less
+	MaxPerCustomer    int32                  `protobuf:"varint,24,opt,name=max_per_customer,json=maxPerCustomer,proto3" json:"max_per_customer,omitempty"`        // 0 is no cap; enforced by cart and checkout
+	ReleaseDate       int64                  `protobuf:"varint,25,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`                   // 0 when the product has no release date
+	Preorder          *PreorderInfo          `protobuf:"bytes,26,opt,name=preorder,proto3" json:"preorder,omitempty"`                                             // Set until the product is released
+	MinAge            int32                  `protobuf:"varint,27,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`                                  // 0 is no minimum
+	RestrictedRegions []string               `protobuf:"bytes,28,rep,name=restricted_regions,json=restrictedRegions,proto3" json:"restricted_regions,omitempty"`  // ISO 3166 codes; a country covers its subdivisions
+	CompareAtPrice    *float64               `protobuf:"fixed64,29,opt,name=compare_at_price,json=compareAtPrice,proto3,oneof" json:"compare_at_price,omitempty"` // The "was" price; the product is on sale below it
+	Badges            []string               `protobuf:"bytes,30,rep,name=badges,proto3" json:"badges,omitempty"`                                                 // Assigned badge keys
+	DisplayBadges     []*ProductBadge        `protobuf:"bytes,31,rep,name=display_badges,json=displayBadges,proto3" json:"display_badges,omitempty"`              // Assigned and automatic badges, highest priority first
+	Slug              string                 `protobuf:"bytes,32,opt,name=slug,proto3" json:"slug,omitempty"`                                                     // Identifies the product in storefront URLs
+	PreviousSlugs     []string               `protobuf:"bytes,33,rep,name=previous_slugs,json=previousSlugs,proto3" json:"previous_slugs,omitempty"`              // Earlier slugs, which still resolve to the product
+	MetaTitle         string                 `protobuf:"bytes,34,opt,name=meta_title,json=metaTitle,proto3" json:"meta_title,omitempty"`
+	MetaDescription   string                 `protobuf:"bytes,35,opt,name=meta_description,json=metaDescription,proto3" json:"meta_description,omitempty"`
+	GroupPrices       []*GroupPrice          `protobuf:"bytes,36,rep,name=group_prices,json=groupPrices,proto3" json:"group_prices,omitempty"`                     // Prices for customer groups other than retail
+	CustomerGroup     string                 `protobuf:"bytes,37,opt,name=customer_group,json=customerGroup,proto3" json:"customer_group,omitempty"`               // The caller's group, set by Get and List when known
+	EffectivePrice    float64                `protobuf:"fixed64,38,opt,name=effective_price,json=effectivePrice,proto3" json:"effective_price,omitempty"`          // The price customer_group pays
+	DiscountedPrice   *float64               `protobuf:"fixed64,39,opt,name=discounted_price,json=discountedPrice,proto3,oneof" json:"discounted_price,omitempty"` // Price less the running promotion that takes the most off
+	Promotion         *AppliedPromotion      `protobuf:"bytes,40,opt,name=promotion,proto3" json:"promotion,omitempty"`                                            // The promotion discounted_price comes from
+	Status            string                 `protobuf:"bytes,41,opt,name=status,proto3" json:"status,omitempty"`                                                  // "draft", "published" or "retired"; shoppers see published, active products
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_product_v1_product_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Product) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Product) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Product) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+func (x *Product) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Product) GetInventory() *InventoryInfo {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *Product) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Product) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Product) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *Product) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Product) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *Product) GetSuppliers() []*ProductSupplier {
+	if x != nil {
+		return x.Suppliers
+	}
+	return nil
+}
+
+func (x *Product) GetFeatured() bool {
+	if x != nil {
+		return x.Featured
+	}
+	return false
+}
+
+func (x *Product) GetFeaturedUntil() int64 {
+	if x != nil {
+		return x.FeaturedUntil
+	}
+	return 0
+}
+
+func (x *Product) GetIsNew() bool {
+	if x != nil {
+		return x.IsNew
+	}
+	return false
+}
+
+func (x *Product) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Product) GetDigital() *DigitalInfo {
+	if x != nil {
+		return x.Digital
+	}
+	return nil
+}
+
+func (x *Product) GetWeight() *Weight {
+	if x != nil {
+		return x.Weight
+	}
+	return nil
+}
+
+func (x *Product) GetDimensions() *Dimensions {
+	if x != nil {
+		return x.Dimensions
+	}
+	return nil
+}
+
+func (x *Product) GetShippingClass() string {
+	if x != nil {
+		return x.ShippingClass
+	}
+	return ""
+}
+
+func (x *Product) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *Product) GetMinOrderQuantity() int32 {
+	if x != nil {
+		return x.MinOrderQuantity
+	}
+	return 0
+}
+
+func (x *Product) GetMaxPerCustomer() int32 {
+	if x != nil {
+		return x.MaxPerCustomer
+	}
+	return 0
+}
+
+func (x *Product) GetReleaseDate() int64 {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return 0
+}
+
+func (x *Product) GetPreorder() *PreorderInfo {
+	if x != nil {
+		return x.Preorder
+	}
+	return nil
+}
+
+func (x *Product) GetMinAge() int32 {
+	if x != nil {
+		return x.MinAge
+	}
+	return 0
+}
+
+func (x *Product) GetRestrictedRegions() []string {
+	if x != nil {
+		return x.RestrictedRegions
+	}
+	return nil
+}
+
+func (x *Product) GetCompareAtPrice() float64 {
+	if x != nil && x.CompareAtPrice != nil {
+		return *x.CompareAtPrice
+	}
+	return 0
+}
+
+func (x *Product) GetBadges() []string {
+	if x != nil {
+		return x.Badges
+	}
+	return nil
+}
+
+func (x *Product) GetDisplayBadges() []*ProductBadge {
+	if x != nil {
+		return x.DisplayBadges
+	}
+	return nil
+}
+
+func (x *Product) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Product) GetPreviousSlugs() []string {
+	if x != nil {
+		return x.PreviousSlugs
+	}
+	return nil
+}
+
+func (x *Product) GetMetaTitle() string {
+	if x != nil {
+		return x.MetaTitle
+	}
+	return ""
+}
+
+func (x *Product) GetMetaDescription() string {
+	if x != nil {
+		return x.MetaDescription
+	}
+	return ""
+}
+
+func (x *Product) GetGroupPrices() []*GroupPrice {
+	if x != nil {
+		return x.GroupPrices
+	}
+	return nil
+}
+
+func (x *Product) GetCustomerGroup() string {
+	if x != nil {
+		return x.CustomerGroup
+	}
+	return ""
+}
+
+func (x *Product) GetEffectivePrice() float64 {
+	if x != nil {
+		return x.EffectivePrice
+	}
+	return 0
+}
+
+func (x *Product) GetDiscountedPrice() float64 {
+	if x != nil && x.DiscountedPrice != nil {
+		return *x.DiscountedPrice
+	}
+	return 0
+}
+
+func (x *Product) GetPromotion() *AppliedPromotion {
+	if x != nil {
+		return x.Promotion
+	}
+	return nil
+}
+
+func (x *Product) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type AppliedPromotion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`                    // "percentage" or "fixed"
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`                // Percent or amount off
+	EndsAt        int64                  `protobuf:"varint,5,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"` // 0 when the promotion has no end
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppliedPromotion) Reset() {
+	*x = AppliedPromotion{}
+	mi := &file_product_v1_product_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppliedPromotion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppliedPromotion) ProtoMessage() {}
+
+func (x *AppliedPromotion) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppliedPromotion.ProtoReflect.Descriptor instead.
+func (*AppliedPromotion) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AppliedPromotion) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AppliedPromotion) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AppliedPromotion) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AppliedPromotion) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AppliedPromotion) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+// The price of a product for a customer group such as wholesale or vip
+type GroupPrice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Price         float64                `protobuf:"fixed64,2,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupPrice) Reset() {
+	*x = GroupPrice{}
+	mi := &file_product_v1_product_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupPrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupPrice) ProtoMessage() {}
+
+func (x *GroupPrice) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupPrice.ProtoReflect.Descriptor instead.
+func (*GroupPrice) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GroupPrice) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *GroupPrice) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+// A badge as shown on the storefront
+type ProductBadge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Color         string                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductBadge) Reset() {
+	*x = ProductBadge{}
+	mi := &file_product_v1_product_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductBadge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductBadge) ProtoMessage() {}
+
+func (x *ProductBadge) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductBadge.ProtoReflect.Descriptor instead.
+func (*ProductBadge) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProductBadge) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ProductBadge) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *ProductBadge) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+// Preorders taken before the release date. They do not reduce stock and
+// become reservations on release.
+type PreorderInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Allocation    int32                  `protobuf:"varint,1,opt,name=allocation,proto3" json:"allocation,omitempty"`
+	Ordered       int32                  `protobuf:"varint,2,opt,name=ordered,proto3" json:"ordered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreorderInfo) Reset() {
+	*x = PreorderInfo{}
+	mi := &file_product_v1_product_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreorderInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreorderInfo) ProtoMessage() {}
+
+func (x *PreorderInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreorderInfo.ProtoReflect.Descriptor instead.
+func (*PreorderInfo) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PreorderInfo) GetAllocation() int32 {
+	if x != nil {
+		return x.Allocation
+	}
+	return 0
+}
+
+func (x *PreorderInfo) GetOrdered() int32 {
+	if x != nil {
+		return x.Ordered
+	}
+	return 0
+}
+
+// Shipping weight. Requests may use g, kg, oz or lb; products are
+// returned in kg.
+type Weight struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Unit          string                 `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Weight) Reset() {
+	*x = Weight{}
+	mi := &file_product_v1_product_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Weight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Weight) ProtoMessage() {}
+
+func (x *Weight) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Weight.ProtoReflect.Descriptor instead.
+func (*Weight) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Weight) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Weight) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+// Packaged size. Requests may use mm, cm, m or in; products are returned
+// in cm.
+type Dimensions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Length        float64                `protobuf:"fixed64,1,opt,name=length,proto3" json:"length,omitempty"`
+	Width         float64                `protobuf:"fixed64,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        float64                `protobuf:"fixed64,3,opt,name=height,proto3" json:"height,omitempty"`
+	Unit          string                 `protobuf:"bytes,4,opt,name=unit,proto3" json:"unit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Dimensions) Reset() {
+	*x = Dimensions{}
+	mi := &file_product_v1_product_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dimensions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dimensions) ProtoMessage() {}
+
+func (x *Dimensions) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dimensions.ProtoReflect.Descriptor instead.
+func (*Dimensions) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Dimensions) GetLength() float64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *Dimensions) GetWidth() float64 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Dimensions) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Dimensions) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+// What buyers of a digital product can download
+type DigitalInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Assets        []*DigitalAsset        `protobuf:"bytes,1,rep,name=assets,proto3" json:"assets,omitempty"`
+	MaxDownloads  int32                  `protobuf:"varint,2,opt,name=max_downloads,json=maxDownloads,proto3" json:"max_downloads,omitempty"` // Per order; 0 is unlimited
+	LicenseKeys   bool                   `protobuf:"varint,3,opt,name=license_keys,json=licenseKeys,proto3" json:"license_keys,omitempty"`    // Whether each order gets a license key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DigitalInfo) Reset() {
+	*x = DigitalInfo{}
+	mi := &file_product_v1_product_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DigitalInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigitalInfo) ProtoMessage() {}
+
+func (x *DigitalInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigitalInfo.ProtoReflect.Descriptor instead.
+func (*DigitalInfo) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DigitalInfo) GetAssets() []*DigitalAsset {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+func (x *DigitalInfo) GetMaxDownloads() int32 {
+	if x != nil {
+		return x.MaxDownloads
+	}
+	return 0
+}
+
+func (x *DigitalInfo) GetLicenseKeys() bool {
+	if x != nil {
+		return x.LicenseKeys
+	}
+	return false
+}
+
+// A downloadable file of a digital product
+type DigitalAsset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"` // Object key in the downloads bucket
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DigitalAsset) Reset() {
+	*x = DigitalAsset{}
+	mi := &file_product_v1_product_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DigitalAsset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigitalAsset) ProtoMessage() {}
+
+func (x *DigitalAsset) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigitalAsset.ProtoReflect.Descriptor instead.
+func (*DigitalAsset) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DigitalAsset) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DigitalAsset) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *DigitalAsset) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// A supplier the product can be reordered from
+type ProductSupplier struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SupplierId    string                 `protobuf:"bytes,1,opt,name=supplier_id,json=supplierId,proto3" json:"supplier_id,omitempty"`
+	SupplierSku   string                 `protobuf:"bytes,2,opt,name=supplier_sku,json=supplierSku,proto3" json:"supplier_sku,omitempty"`
+	LeadTimeDays  int32                  `protobuf:"varint,3,opt,name=lead_time_days,json=leadTimeDays,proto3" json:"lead_time_days,omitempty"` // 0 uses the supplier's default
+	Preferred     bool                   `protobuf:"varint,4,opt,name=preferred,proto3" json:"preferred,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductSupplier) Reset() {
+	*x = ProductSupplier{}
+	mi := &file_product_v1_product_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductSupplier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductSupplier) ProtoMessage() {}
+
+func (x *ProductSupplier) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductSupplier.ProtoReflect.Descriptor instead.
+func (*ProductSupplier) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ProductSupplier) GetSupplierId() string {
+	if x != nil {
+		return x.SupplierId
+	}
+	return ""
+}
+
+func (x *ProductSupplier) GetSupplierSku() string {
+	if x != nil {
+		return x.SupplierSku
+	}
+	return ""
+}
+
+func (x *ProductSupplier) GetLeadTimeDays() int32 {
+	if x != nil {
+		return x.LeadTimeDays
+	}
+	return 0
+}
+
+func (x *ProductSupplier) GetPreferred() bool {
+	if x != nil {
+		return x.Preferred
+	}
+	return false
+}
+
+type InventoryInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quantity      int32                  `protobuf:"varint,1,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Sku           string                 `protobuf:"bytes,2,opt,name=sku,proto3" json:"sku,omitempty"`
+	InStock       bool                   `protobuf:"varint,3,opt,name=in_stock,json=inStock,proto3" json:"in_stock,omitempty"`
+	Reserved      int32                  `protobuf:"varint,4,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryInfo) Reset() {
+	*x = InventoryInfo{}
+	mi := &file_product_v1_product_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryInfo) ProtoMessage() {}
+
+func (x *InventoryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryInfo.ProtoReflect.Descriptor instead.
+func (*InventoryInfo) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *InventoryInfo) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *InventoryInfo) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *InventoryInfo) GetInStock() bool {
+	if x != nil {
+		return x.InStock
+	}
+	return false
+}
+
+func (x *InventoryInfo) GetReserved() int32 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+// Request and Response messages
+type CreateProductRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Name               string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description        string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Price              float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	ImageUrls          []string               `protobuf:"bytes,4,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	Category           string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	Inventory          *InventoryInfo         `protobuf:"bytes,6,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	Tags               []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Attributes         map[string]string      `protobuf:"bytes,8,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Suppliers          []*ProductSupplier     `protobuf:"bytes,9,rep,name=suppliers,proto3" json:"suppliers,omitempty"`
+	Type               string                 `protobuf:"bytes,10,opt,name=type,proto3" json:"type,omitempty"`
+	Digital            *DigitalInfo           `protobuf:"bytes,11,opt,name=digital,proto3" json:"digital,omitempty"`
+	Weight             *Weight                `protobuf:"bytes,12,opt,name=weight,proto3" json:"weight,omitempty"`
+	Dimensions         *Dimensions            `protobuf:"bytes,13,opt,name=dimensions,proto3" json:"dimensions,omitempty"`
+	ShippingClass      string                 `protobuf:"bytes,14,opt,name=shipping_class,json=shippingClass,proto3" json:"shipping_class,omitempty"`
+	Barcode            string                 `protobuf:"bytes,15,opt,name=barcode,proto3" json:"barcode,omitempty"` // EAN-8, UPC-A, EAN-13 or GTIN-14
+	MinOrderQuantity   int32                  `protobuf:"varint,16,opt,name=min_order_quantity,json=minOrderQuantity,proto3" json:"min_order_quantity,omitempty"`
+	MaxPerCustomer     int32                  `protobuf:"varint,17,opt,name=max_per_customer,json=maxPerCustomer,proto3" json:"max_per_customer,omitempty"`
+	ReleaseDate        int64                  `protobuf:"varint,18,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`
+	PreorderAllocation int32                  `protobuf:"varint,19,opt,name=preorder_allocation,json=preorderAllocation,proto3" json:"preorder_allocation,omitempty"` // Takes preorders until release_date when set
+	MinAge             int32                  `protobuf:"varint,20,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	RestrictedRegions  []string               `protobuf:"bytes,21,rep,name=restricted_regions,json=restrictedRegions,proto3" json:"restricted_regions,omitempty"`
+	CompareAtPrice     *float64               `protobuf:"fixed64,22,opt,name=compare_at_price,json=compareAtPrice,proto3,oneof" json:"compare_at_price,omitempty"`
+	Badges             []string               `protobuf:"bytes,23,rep,name=badges,proto3" json:"badges,omitempty"` // Defined, non-automatic badge keys
+	Slug               string                 `protobuf:"bytes,24,opt,name=slug,proto3" json:"slug,omitempty"`     // Generated from the name when empty
+	MetaTitle          string                 `protobuf:"bytes,25,opt,name=meta_title,json=metaTitle,proto3" json:"meta_title,omitempty"`
+	MetaDescription    string                 `protobuf:"bytes,26,opt,name=meta_description,json=metaDescription,proto3" json:"meta_description,omitempty"`
+	GroupPrices        []*GroupPrice          `protobuf:"bytes,27,rep,name=group_prices,json=groupPrices,proto3" json:"group_prices,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *CreateProductRequest) Reset() {
+	*x = CreateProductRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateProductRequest) ProtoMessage() {}
+
+func (x *CreateProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateProductRequest.ProtoReflect.Descriptor instead.
+func (*CreateProductRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateProductRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetInventory() *InventoryInfo {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetSuppliers() []*ProductSupplier {
+	if x != nil {
+		return x.Suppliers
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetDigital() *DigitalInfo {
+	if x != nil {
+		return x.Digital
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetWeight() *Weight {
+	if x != nil {
+		return x.Weight
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetDimensions() *Dimensions {
+	if x != nil {
+		return x.Dimensions
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetShippingClass() string {
+	if x != nil {
+		return x.ShippingClass
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetMinOrderQuantity() int32 {
+	if x != nil {
+		return x.MinOrderQuantity
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetMaxPerCustomer() int32 {
+	if x != nil {
+		return x.MaxPerCustomer
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetReleaseDate() int64 {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetPreorderAllocation() int32 {
+	if x != nil {
+		return x.PreorderAllocation
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetMinAge() int32 {
+	if x != nil {
+		return x.MinAge
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetRestrictedRegions() []string {
+	if x != nil {
+		return x.RestrictedRegions
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetCompareAtPrice() float64 {
+	if x != nil && x.CompareAtPrice != nil {
+		return *x.CompareAtPrice
+	}
+	return 0
+}
+
+func (x *CreateProductRequest) GetBadges() []string {
+	if x != nil {
+		return x.Badges
+	}
+	return nil
+}
+
+func (x *CreateProductRequest) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetMetaTitle() string {
+	if x != nil {
+		return x.MetaTitle
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetMetaDescription() string {
+	if x != nil {
+		return x.MetaDescription
+	}
+	return ""
+}
+
+func (x *CreateProductRequest) GetGroupPrices() []*GroupPrice {
+	if x != nil {
+		return x.GroupPrices
+	}
+	return nil
+}
+
+type GetProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductRequest) Reset() {
+	*x = GetProductRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductRequest) ProtoMessage() {}
+
+func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
+func (*GetProductRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetProductsByIDsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductsByIDsRequest) Reset() {
+	*x = GetProductsByIDsRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductsByIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductsByIDsRequest) ProtoMessage() {}
+
+func (x *GetProductsByIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductsByIDsRequest.ProtoReflect.Descriptor instead.
+func (*GetProductsByIDsRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetProductsByIDsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type GetProductsByIDsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Products      []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	NotFoundIds   []string               `protobuf:"bytes,2,rep,name=not_found_ids,json=notFoundIds,proto3" json:"not_found_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductsByIDsResponse) Reset() {
+	*x = GetProductsByIDsResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductsByIDsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductsByIDsResponse) ProtoMessage() {}
+
+func (x *GetProductsByIDsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductsByIDsResponse.ProtoReflect.Descriptor instead.
+func (*GetProductsByIDsResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetProductsByIDsResponse) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *GetProductsByIDsResponse) GetNotFoundIds() []string {
+	if x != nil {
+		return x.NotFoundIds
+	}
+	return nil
+}
+
+type UpdateProductRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name               *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Description        *string                `protobuf:"bytes,3,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Price              *float64               `protobuf:"fixed64,4,opt,name=price,proto3,oneof" json:"price,omitempty"`
+	ImageUrls          []string               `protobuf:"bytes,5,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	Category           *string                `protobuf:"bytes,6,opt,name=category,proto3,oneof" json:"category,omitempty"`
+	Inventory          *InventoryInfo         `protobuf:"bytes,7,opt,name=inventory,proto3,oneof" json:"inventory,omitempty"`
+	Tags               []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Attributes         map[string]string      `protobuf:"bytes,9,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Active             *bool                  `protobuf:"varint,10,opt,name=active,proto3,oneof" json:"active,omitempty"`
+	Digital            *DigitalInfo           `protobuf:"bytes,11,opt,name=digital,proto3,oneof" json:"digital,omitempty"`
+	Weight             *Weight                `protobuf:"bytes,12,opt,name=weight,proto3,oneof" json:"weight,omitempty"`
+	Dimensions         *Dimensions            `protobuf:"bytes,13,opt,name=dimensions,proto3,oneof" json:"dimensions,omitempty"`
+	ShippingClass      *string                `protobuf:"bytes,14,opt,name=shipping_class,json=shippingClass,proto3,oneof" json:"shipping_class,omitempty"`
+	Barcode            *string                `protobuf:"bytes,15,opt,name=barcode,proto3,oneof" json:"barcode,omitempty"`
+	ReleaseDate        *int64                 `protobuf:"varint,16,opt,name=release_date,json=releaseDate,proto3,oneof" json:"release_date,omitempty"`
+	PreorderAllocation *int32                 `protobuf:"varint,17,opt,name=preorder_allocation,json=preorderAllocation,proto3,oneof" json:"preorder_allocation,omitempty"` // Keeps the preorders taken
+	CompareAtPrice     *float64               `protobuf:"fixed64,18,opt,name=compare_at_price,json=compareAtPrice,proto3,oneof" json:"compare_at_price,omitempty"`          // 0 clears it
+	Slug               *string                `protobuf:"bytes,19,opt,name=slug,proto3,oneof" json:"slug,omitempty"`                                                        // The current slug keeps resolving
+	MetaTitle          *string                `protobuf:"bytes,20,opt,name=meta_title,json=metaTitle,proto3,oneof" json:"meta_title,omitempty"`
+	MetaDescription    *string                `protobuf:"bytes,21,opt,name=meta_description,json=metaDescription,proto3,oneof" json:"meta_description,omitempty"`
+	GroupPrices        []*GroupPrice          `protobuf:"bytes,22,rep,name=group_prices,json=groupPrices,proto3" json:"group_prices,omitempty"` // Replaces the group prices when not empty
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UpdateProductRequest) Reset() {
+	*x = UpdateProductRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProductRequest) ProtoMessage() {}
+
+func (x *UpdateProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProductRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProductRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetPrice() float64 {
+	if x != nil && x.Price != nil {
+		return *x.Price
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetCategory() string {
+	if x != nil && x.Category != nil {
+		return *x.Category
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetInventory() *InventoryInfo {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetActive() bool {
+	if x != nil && x.Active != nil {
+		return *x.Active
+	}
+	return false
+}
+
+func (x *UpdateProductRequest) GetDigital() *DigitalInfo {
+	if x != nil {
+		return x.Digital
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetWeight() *Weight {
+	if x != nil {
+		return x.Weight
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetDimensions() *Dimensions {
+	if x != nil {
+		return x.Dimensions
+	}
+	return nil
+}
+
+func (x *UpdateProductRequest) GetShippingClass() string {
+	if x != nil && x.ShippingClass != nil {
+		return *x.ShippingClass
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetBarcode() string {
+	if x != nil && x.Barcode != nil {
+		return *x.Barcode
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetReleaseDate() int64 {
+	if x != nil && x.ReleaseDate != nil {
+		return *x.ReleaseDate
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetPreorderAllocation() int32 {
+	if x != nil && x.PreorderAllocation != nil {
+		return *x.PreorderAllocation
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetCompareAtPrice() float64 {
+	if x != nil && x.CompareAtPrice != nil {
+		return *x.CompareAtPrice
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetSlug() string {
+	if x != nil && x.Slug != nil {
+		return *x.Slug
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetMetaTitle() string {
+	if x != nil && x.MetaTitle != nil {
+		return *x.MetaTitle
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetMetaDescription() string {
+	if x != nil && x.MetaDescription != nil {
+		return *x.MetaDescription
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetGroupPrices() []*GroupPrice {
+	if x != nil {
+		return x.GroupPrices
+	}
+	return nil
+}
+
+type DeleteProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProductRequest) Reset() {
+	*x = DeleteProductRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProductRequest) ProtoMessage() {}
+
+func (x *DeleteProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProductRequest.ProtoReflect.Descriptor instead.
+func (*DeleteProductRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteProductResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProductResponse) Reset() {
+	*x = DeleteProductResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProductResponse) ProtoMessage() {}
+
+func (x *DeleteProductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProductResponse.ProtoReflect.Descriptor instead.
+func (*DeleteProductResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DeleteProductResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeleteProductResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListProductsRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Page                 int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize             int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Category             string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	Tags                 []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	MinPrice             float64                `protobuf:"fixed64,5,opt,name=min_price,json=minPrice,proto3" json:"min_price,omitempty"`
+	MaxPrice             float64                `protobuf:"fixed64,6,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
+	InStockOnly          bool                   `protobuf:"varint,7,opt,name=in_stock_only,json=inStockOnly,proto3" json:"in_stock_only,omitempty"`
+	SortBy               string                 `protobuf:"bytes,8,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	SortDesc             bool                   `protobuf:"varint,9,opt,name=sort_desc,json=sortDesc,proto3" json:"sort_desc,omitempty"`
+	SearchTerm           string                 `protobuf:"bytes,10,opt,name=search_term,json=searchTerm,proto3" json:"search_term,omitempty"`
+	SupplierId           string                 `protobuf:"bytes,11,opt,name=supplier_id,json=supplierId,proto3" json:"supplier_id,omitempty"` // Only products linked to this supplier
+	FeaturedOnly         bool                   `protobuf:"varint,12,opt,name=featured_only,json=featuredOnly,proto3" json:"featured_only,omitempty"`
+	NewOnly              bool                   `protobuf:"varint,13,opt,name=new_only,json=newOnly,proto3" json:"new_only,omitempty"`                                                                                                     // Only products created within the new arrival window
+	ShippingClass        string                 `protobuf:"bytes,14,opt,name=shipping_class,json=shippingClass,proto3" json:"shipping_class,omitempty"`                                                                                    // Only products of this shipping class
+	Badge                string                 `protobuf:"bytes,15,opt,name=badge,proto3" json:"badge,omitempty"`                                                                                                                         // Only products assigned this badge
+	IncludeSubcategories bool                   `protobuf:"varint,16,opt,name=include_subcategories,json=includeSubcategories,proto3" json:"include_subcategories,omitempty"`                                                              // Also products in the categories below category
+	AttributeFilters     map[string]string      `protobuf:"bytes,17,rep,name=attribute_filters,json=attributeFilters,proto3" json:"attribute_filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Only products with all of these attribute values, e.g. color: black
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ListProductsRequest) Reset() {
+	*x = ListProductsRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsRequest) ProtoMessage() {}
+
+func (x *ListProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsRequest.ProtoReflect.Descriptor instead.
+func (*ListProductsRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListProductsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListProductsRequest) GetMinPrice() float64 {
+	if x != nil {
+		return x.MinPrice
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetMaxPrice() float64 {
+	if x != nil {
+		return x.MaxPrice
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetInStockOnly() bool {
+	if x != nil {
+		return x.InStockOnly
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetSortDesc() bool {
+	if x != nil {
+		return x.SortDesc
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetSearchTerm() string {
+	if x != nil {
+		return x.SearchTerm
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetSupplierId() string {
+	if x != nil {
+		return x.SupplierId
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetFeaturedOnly() bool {
+	if x != nil {
+		return x.FeaturedOnly
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetNewOnly() bool {
+	if x != nil {
+		return x.NewOnly
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetShippingClass() string {
+	if x != nil {
+		return x.ShippingClass
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetBadge() string {
+	if x != nil {
+		return x.Badge
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetIncludeSubcategories() bool {
+	if x != nil {
+		return x.IncludeSubcategories
+	}
+	return false
+}
+
+func (x *ListProductsRequest) GetAttributeFilters() map[string]string {
+	if x != nil {
+		return x.AttributeFilters
+	}
+	return nil
+}
+
+type ListProductsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Products      []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,5,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProductsResponse) Reset() {
+	*x = ListProductsResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProductsResponse) ProtoMessage() {}
+
+func (x *ListProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProductsResponse.ProtoReflect.Descriptor instead.
+func (*ListProductsResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListProductsResponse) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *ListProductsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListProductsResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListProductsResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListProductsResponse) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+type ProductResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductResponse) Reset() {
+	*x = ProductResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductResponse) ProtoMessage() {}
+
+func (x *ProductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductResponse.ProtoReflect.Descriptor instead.
+func (*ProductResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ProductResponse) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+// Inventory specific messages
+type UpdateInventoryRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ProductId      string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	QuantityChange int32                  `protobuf:"varint,2,opt,name=quantity_change,json=quantityChange,proto3" json:"quantity_change,omitempty"` // Can be positive (add) or negative (remove)
+	OperationId    string                 `protobuf:"bytes,3,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`           // For idempotency
+	OperationType  string                 `protobuf:"bytes,4,opt,name=operation_type,json=operationType,proto3" json:"operation_type,omitempty"`     // e.g., "purchase", "restock", "reservation"
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateInventoryRequest) Reset() {
+	*x = UpdateInventoryRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInventoryRequest) ProtoMessage() {}
+
+func (x *UpdateInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInventoryRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateInventoryRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *UpdateInventoryRequest) GetQuantityChange() int32 {
+	if x != nil {
+		return x.QuantityChange
+	}
+	return 0
+}
+
+func (x *UpdateInventoryRequest) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *UpdateInventoryRequest) GetOperationType() string {
+	if x != nil {
+		return x.OperationType
+	}
+	return ""
+}
+
+type UpdateInventoryResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Success          bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	UpdatedInventory *InventoryInfo         `protobuf:"bytes,2,opt,name=updated_inventory,json=updatedInventory,proto3" json:"updated_inventory,omitempty"`
+	Message          string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateInventoryResponse) Reset() {
+	*x = UpdateInventoryResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInventoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInventoryResponse) ProtoMessage() {}
+
+func (x *UpdateInventoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInventoryResponse.ProtoReflect.Descriptor instead.
+func (*UpdateInventoryResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateInventoryResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateInventoryResponse) GetUpdatedInventory() *InventoryInfo {
+	if x != nil {
+		return x.UpdatedInventory
+	}
+	return nil
+}
+
+func (x *UpdateInventoryResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type CheckStockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckStockRequest) Reset() {
+	*x = CheckStockRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckStockRequest) ProtoMessage() {}
+
+func (x *CheckStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckStockRequest.ProtoReflect.Descriptor instead.
+func (*CheckStockRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CheckStockRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *CheckStockRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type CheckStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Available     bool                   `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
+	CurrentStock  int32                  `protobuf:"varint,2,opt,name=current_stock,json=currentStock,proto3" json:"current_stock,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckStockResponse) Reset() {
+	*x = CheckStockResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckStockResponse) ProtoMessage() {}
+
+func (x *CheckStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckStockResponse.ProtoReflect.Descriptor instead.
+func (*CheckStockResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CheckStockResponse) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *CheckStockResponse) GetCurrentStock() int32 {
+	if x != nil {
+		return x.CurrentStock
+	}
+	return 0
+}
+
+type ListInventoryOperationsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ProductId      string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Page           int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize       int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	OperationTypes []string               `protobuf:"bytes,4,rep,name=operation_types,json=operationTypes,proto3" json:"operation_types,omitempty"` // Empty means all types
+	From           int64                  `protobuf:"varint,5,opt,name=from,proto3" json:"from,omitempty"`                                          // Unix seconds, inclusive; 0 means no lower bound
+	To             int64                  `protobuf:"varint,6,opt,name=to,proto3" json:"to,omitempty"`                                              // Unix seconds, exclusive; 0 means no upper bound
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListInventoryOperationsRequest) Reset() {
+	*x = ListInventoryOperationsRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInventoryOperationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInventoryOperationsRequest) ProtoMessage() {}
+
+func (x *ListInventoryOperationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInventoryOperationsRequest.ProtoReflect.Descriptor instead.
+func (*ListInventoryOperationsRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListInventoryOperationsRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *ListInventoryOperationsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsRequest) GetOperationTypes() []string {
+	if x != nil {
+		return x.OperationTypes
+	}
+	return nil
+}
+
+func (x *ListInventoryOperationsRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+type InventoryOperation struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OperationId    string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	OperationType  string                 `protobuf:"bytes,2,opt,name=operation_type,json=operationType,proto3" json:"operation_type,omitempty"`
+	QuantityChange int32                  `protobuf:"varint,3,opt,name=quantity_change,json=quantityChange,proto3" json:"quantity_change,omitempty"`
+	Timestamp      int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InventoryOperation) Reset() {
+	*x = InventoryOperation{}
+	mi := &file_product_v1_product_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryOperation) ProtoMessage() {}
+
+func (x *InventoryOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryOperation.ProtoReflect.Descriptor instead.
+func (*InventoryOperation) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *InventoryOperation) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *InventoryOperation) GetOperationType() string {
+	if x != nil {
+		return x.OperationType
+	}
+	return ""
+}
+
+func (x *InventoryOperation) GetQuantityChange() int32 {
+	if x != nil {
+		return x.QuantityChange
+	}
+	return 0
+}
+
+func (x *InventoryOperation) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ListInventoryOperationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*InventoryOperation  `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,5,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInventoryOperationsResponse) Reset() {
+	*x = ListInventoryOperationsResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInventoryOperationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInventoryOperationsResponse) ProtoMessage() {}
+
+func (x *ListInventoryOperationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInventoryOperationsResponse.ProtoReflect.Descriptor instead.
+func (*ListInventoryOperationsResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListInventoryOperationsResponse) GetOperations() []*InventoryOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *ListInventoryOperationsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListInventoryOperationsResponse) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+type WatchInventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductIds    []string               `protobuf:"bytes,1,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"` // Empty means all products
+	Threshold     int32                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`                    // Only send updates when stock drops below this threshold
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchInventoryRequest) Reset() {
+	*x = WatchInventoryRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchInventoryRequest) ProtoMessage() {}
+
+func (x *WatchInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchInventoryRequest.ProtoReflect.Descriptor instead.
+func (*WatchInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *WatchInventoryRequest) GetProductIds() []string {
+	if x != nil {
+		return x.ProductIds
+	}
+	return nil
+}
+
+func (x *WatchInventoryRequest) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type InventoryUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	ProductName   string                 `protobuf:"bytes,2,opt,name=product_name,json=productName,proto3" json:"product_name,omitempty"`
+	Inventory     *InventoryInfo         `protobuf:"bytes,3,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryUpdate) Reset() {
+	*x = InventoryUpdate{}
+	mi := &file_product_v1_product_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryUpdate) ProtoMessage() {}
+
+func (x *InventoryUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryUpdate.ProtoReflect.Descriptor instead.
+func (*InventoryUpdate) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *InventoryUpdate) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *InventoryUpdate) GetProductName() string {
+	if x != nil {
+		return x.ProductName
+	}
+	return ""
+}
+
+func (x *InventoryUpdate) GetInventory() *InventoryInfo {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *InventoryUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type StreamProductsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IncludeInactive bool                   `protobuf:"varint,1,opt,name=include_inactive,json=includeInactive,proto3" json:"include_inactive,omitempty"` // Inactive and unpublished products are skipped unless set
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamProductsRequest) Reset() {
+	*x = StreamProductsRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamProductsRequest) ProtoMessage() {}
+
+func (x *StreamProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamProductsRequest.ProtoReflect.Descriptor instead.
+func (*StreamProductsRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *StreamProductsRequest) GetIncludeInactive() bool {
+	if x != nil {
+		return x.IncludeInactive
+	}
+	return false
+}
+
+type SetFeaturedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Featured      bool                   `protobuf:"varint,2,opt,name=featured,proto3" json:"featured,omitempty"`
+	FeaturedUntil int64                  `protobuf:"varint,3,opt,name=featured_until,json=featuredUntil,proto3" json:"featured_until,omitempty"` // Unfeatured at this time; 0 never expires
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFeaturedRequest) Reset() {
+	*x = SetFeaturedRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFeaturedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFeaturedRequest) ProtoMessage() {}
+
+func (x *SetFeaturedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFeaturedRequest.ProtoReflect.Descriptor instead.
+func (*SetFeaturedRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *SetFeaturedRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetFeaturedRequest) GetFeatured() bool {
+	if x != nil {
+		return x.Featured
+	}
+	return false
+}
+
+func (x *SetFeaturedRequest) GetFeaturedUntil() int64 {
+	if x != nil {
+		return x.FeaturedUntil
+	}
+	return 0
+}
+
+// What checkout knows about the buyer
+type CustomerProfile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BirthDate     string                 `protobuf:"bytes,1,opt,name=birth_date,json=birthDate,proto3" json:"birth_date,omitempty"` // YYYY-MM-DD; empty when unknown
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`                        // Delivery country or subdivision, such as DE or US-UT
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomerProfile) Reset() {
+	*x = CustomerProfile{}
+	mi := &file_product_v1_product_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomerProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomerProfile) ProtoMessage() {}
+
+func (x *CustomerProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomerProfile.ProtoReflect.Descriptor instead.
+func (*CustomerProfile) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *CustomerProfile) GetBirthDate() string {
+	if x != nil {
+		return x.BirthDate
+	}
+	return ""
+}
+
+func (x *CustomerProfile) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+type ValidatePurchaseEligibilityRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProductIds      []string               `protobuf:"bytes,1,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"` // At most 100
+	CustomerProfile *CustomerProfile       `protobuf:"bytes,2,opt,name=customer_profile,json=customerProfile,proto3" json:"customer_profile,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ValidatePurchaseEligibilityRequest) Reset() {
+	*x = ValidatePurchaseEligibilityRequest{}
+	mi := &file_product_v1_product_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatePurchaseEligibilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatePurchaseEligibilityRequest) ProtoMessage() {}
+
+func (x *ValidatePurchaseEligibilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatePurchaseEligibilityRequest.ProtoReflect.Descriptor instead.
+func (*ValidatePurchaseEligibilityRequest) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ValidatePurchaseEligibilityRequest) GetProductIds() []string {
+	if x != nil {
+		return x.ProductIds
+	}
+	return nil
+}
+
+func (x *ValidatePurchaseEligibilityRequest) GetCustomerProfile() *CustomerProfile {
+	if x != nil {
+		return x.CustomerProfile
+	}
+	return nil
+}
+
+// Why the customer may not buy a product
+type IneligibleProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // UNDERAGE, BIRTH_DATE_REQUIRED, REGION_RESTRICTED, REGION_REQUIRED or PRODUCT_UNAVAILABLE
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IneligibleProduct) Reset() {
+	*x = IneligibleProduct{}
+	mi := &file_product_v1_product_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IneligibleProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IneligibleProduct) ProtoMessage() {}
+
+func (x *IneligibleProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IneligibleProduct.ProtoReflect.Descriptor instead.
+func (*IneligibleProduct) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *IneligibleProduct) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *IneligibleProduct) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *IneligibleProduct) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ValidatePurchaseEligibilityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Eligible      bool                   `protobuf:"varint,1,opt,name=eligible,proto3" json:"eligible,omitempty"` // No product is ineligible
+	Ineligible    []*IneligibleProduct   `protobuf:"bytes,2,rep,name=ineligible,proto3" json:"ineligible,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidatePurchaseEligibilityResponse) Reset() {
+	*x = ValidatePurchaseEligibilityResponse{}
+	mi := &file_product_v1_product_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatePurchaseEligibilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatePurchaseEligibilityResponse) ProtoMessage() {}
+
+func (x *ValidatePurchaseEligibilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_v1_product_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatePurchaseEligibilityResponse.ProtoReflect.Descriptor instead.
+func (*ValidatePurchaseEligibilityResponse) Descriptor() ([]byte, []int) {
+	return file_product_v1_product_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ValidatePurchaseEligibilityResponse) GetEligible() bool {
+	if x != nil {
+		return x.Eligible
+	}
+	return false
+}
+
+func (x *ValidatePurchaseEligibilityResponse) GetIneligible() []*IneligibleProduct {
+	if x != nil {
+		return x.Ineligible
+	}
+	return nil
+}
+
+var File_product_v1_product_proto protoreflect.FileDescriptor
+
+const file_product_v1_product_proto_rawDesc = "" +
+	"\n" +
+	"\x18product/v1/product.proto\x12\n" +
+	"product.v1\"\x87\r\n" +
+	"\aProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\x05 \x03(\tR\timageUrls\x12\x1a\n" +
+	"\bcategory\x18\x06 \x01(\tR\bcategory\x127\n" +
+	"\tinventory\x18\a \x01(\v2\x19.product.v1.InventoryInfoR\tinventory\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12C\n" +
+	"\n" +
+	"attributes\x18\t \x03(\v2#.product.v1.Product.AttributesEntryR\n" +
+	"attributes\x12\x16\n" +
+	"\x06active\x18\n" +
+	" \x01(\bR\x06active\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\x03R\tupdatedAt\x129\n" +
+	"\tsuppliers\x18\r \x03(\v2\x1b.product.v1.ProductSupplierR\tsuppliers\x12\x1a\n" +
+	"\bfeatured\x18\x0e \x01(\bR\bfeatured\x12%\n" +
+	"\x0efeatured_until\x18\x0f \x01(\x03R\rfeaturedUntil\x12\x15\n" +
+	"\x06is_new\x18\x10 \x01(\bR\x05isNew\x12\x12\n" +
+	"\x04type\x18\x11 \x01(\tR\x04type\x121\n" +
+	"\adigital\x18\x12 \x01(\v2\x17.product.v1.DigitalInfoR\adigital\x12*\n" +
+	"\x06weight\x18\x13 \x01(\v2\x12.product.v1.WeightR\x06weight\x126\n" +
+	"\n" +
+	"dimensions\x18\x14 \x01(\v2\x16.product.v1.DimensionsR\n" +
+	"dimensions\x12%\n" +
+	"\x0eshipping_class\x18\x15 \x01(\tR\rshippingClass\x12\x18\n" +
+	"\abarcode\x18\x16 \x01(\tR\abarcode\x12,\n" +
+	"\x12min_order_quantity\x18\x17 \x01(\x05R\x10minOrderQuantity\x12(\n" +
+	"\x10max_per_customer\x18\x18 \x01(\x05R\x0emaxPerCustomer\x12!\n" +
+	"\frelease_date\x18\x19 \x01(\x03R\vreleaseDate\x124\n" +
+	"\bpreorder\x18\x1a \x01(\v2\x18.product.v1.PreorderInfoR\bpreorder\x12\x17\n" +
+	"\amin_age\x18\x1b \x01(\x05R\x06minAge\x12-\n" +
+	"\x12restricted_regions\x18\x1c \x03(\tR\x11restrictedRegions\x12-\n" +
+	"\x10compare_at_price\x18\x1d \x01(\x01H\x00R\x0ecompareAtPrice\x88\x01\x01\x12\x16\n" +
+	"\x06badges\x18\x1e \x03(\tR\x06badges\x12?\n" +
+	"\x0edisplay_badges\x18\x1f \x03(\v2\x18.product.v1.ProductBadgeR\rdisplayBadges\x12\x12\n" +
+	"\x04slug\x18  \x01(\tR\x04slug\x12%\n" +
+	"\x0eprevious_slugs\x18! \x03(\tR\rpreviousSlugs\x12\x1d\n" +
+	"\n" +
+	"meta_title\x18\" \x01(\tR\tmetaTitle\x12)\n" +
+	"\x10meta_description\x18# \x01(\tR\x0fmetaDescription\x129\n" +
+	"\fgroup_prices\x18$ \x03(\v2\x16.product.v1.GroupPriceR\vgroupPrices\x12%\n" +
+	"\x0ecustomer_group\x18% \x01(\tR\rcustomerGroup\x12'\n" +
+	"\x0feffective_price\x18& \x01(\x01R\x0eeffectivePrice\x12.\n" +
+	"\x10discounted_price\x18' \x01(\x01H\x01R\x0fdiscountedPrice\x88\x01\x01\x12:\n" +
+	"\tpromotion\x18( \x01(\v2\x1c.product.v1.AppliedPromotionR\tpromotion\x12\x16\n" +
+	"\x06status\x18) \x01(\tR\x06status\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x13\n" +
+	"\x11_compare_at_priceB\x13\n" +
+	"\x11_discounted_price\"y\n" +
+	"\x10AppliedPromotion\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x12\x17\n" +
+	"\aends_at\x18\x05 \x01(\x03R\x06endsAt\"8\n" +
+	"\n" +
+	"GroupPrice\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x01R\x05price\"L\n" +
+	"\fProductBadge\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x14\n" +
+	"\x05color\x18\x03 \x01(\tR\x05color\"H\n" +
+	"\fPreorderInfo\x12\x1e\n" +
+	"\n" +
+	"allocation\x18\x01 \x01(\x05R\n" +
+	"allocation\x12\x18\n" +
+	"\aordered\x18\x02 \x01(\x05R\aordered\"2\n" +
+	"\x06Weight\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x12\x12\n" +
+	"\x04unit\x18\x02 \x01(\tR\x04unit\"f\n" +
+	"\n" +
+	"Dimensions\x12\x16\n" +
+	"\x06length\x18\x01 \x01(\x01R\x06length\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x01R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x01R\x06height\x12\x12\n" +
+	"\x04unit\x18\x04 \x01(\tR\x04unit\"\x87\x01\n" +
+	"\vDigitalInfo\x120\n" +
+	"\x06assets\x18\x01 \x03(\v2\x18.product.v1.DigitalAssetR\x06assets\x12#\n" +
+	"\rmax_downloads\x18\x02 \x01(\x05R\fmaxDownloads\x12!\n" +
+	"\flicense_keys\x18\x03 \x01(\bR\vlicenseKeys\"H\n" +
+	"\fDigitalAsset\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\"\x99\x01\n" +
+	"\x0fProductSupplier\x12\x1f\n" +
+	"\vsupplier_id\x18\x01 \x01(\tR\n" +
+	"supplierId\x12!\n" +
+	"\fsupplier_sku\x18\x02 \x01(\tR\vsupplierSku\x12$\n" +
+	"\x0elead_time_days\x18\x03 \x01(\x05R\fleadTimeDays\x12\x1c\n" +
+	"\tpreferred\x18\x04 \x01(\bR\tpreferred\"t\n" +
+	"\rInventoryInfo\x12\x1a\n" +
+	"\bquantity\x18\x01 \x01(\x05R\bquantity\x12\x10\n" +
+	"\x03sku\x18\x02 \x01(\tR\x03sku\x12\x19\n" +
+	"\bin_stock\x18\x03 \x01(\bR\ainStock\x12\x1a\n" +
+	"\breserved\x18\x04 \x01(\x05R\breserved\"\x8b\t\n" +
+	"\x14CreateProductRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\x04 \x03(\tR\timageUrls\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x127\n" +
+	"\tinventory\x18\x06 \x01(\v2\x19.product.v1.InventoryInfoR\tinventory\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12P\n" +
+	"\n" +
+	"attributes\x18\b \x03(\v20.product.v1.CreateProductRequest.AttributesEntryR\n" +
+	"attributes\x129\n" +
+	"\tsuppliers\x18\t \x03(\v2\x1b.product.v1.ProductSupplierR\tsuppliers\x12\x12\n" +
+	"\x04type\x18\n" +
+	" \x01(\tR\x04type\x121\n" +
+	"\adigital\x18\v \x01(\v2\x17.product.v1.DigitalInfoR\adigital\x12*\n" +
+	"\x06weight\x18\f \x01(\v2\x12.product.v1.WeightR\x06weight\x126\n" +
+	"\n" +
+	"dimensions\x18\r \x01(\v2\x16.product.v1.DimensionsR\n" +
+	"dimensions\x12%\n" +
+	"\x0eshipping_class\x18\x0e \x01(\tR\rshippingClass\x12\x18\n" +
+	"\abarcode\x18\x0f \x01(\tR\abarcode\x12,\n" +
+	"\x12min_order_quantity\x18\x10 \x01(\x05R\x10minOrderQuantity\x12(\n" +
+	"\x10max_per_customer\x18\x11 \x01(\x05R\x0emaxPerCustomer\x12!\n" +
+	"\frelease_date\x18\x12 \x01(\x03R\vreleaseDate\x12/\n" +
+	"\x13preorder_allocation\x18\x13 \x01(\x05R\x12preorderAllocation\x12\x17\n" +
+	"\amin_age\x18\x14 \x01(\x05R\x06minAge\x12-\n" +
+	"\x12restricted_regions\x18\x15 \x03(\tR\x11restrictedRegions\x12-\n" +
+	"\x10compare_at_price\x18\x16 \x01(\x01H\x00R\x0ecompareAtPrice\x88\x01\x01\x12\x16\n" +
+	"\x06badges\x18\x17 \x03(\tR\x06badges\x12\x12\n" +
+	"\x04slug\x18\x18 \x01(\tR\x04slug\x12\x1d\n" +
+	"\n" +
+	"meta_title\x18\x19 \x01(\tR\tmetaTitle\x12)\n" +
+	"\x10meta_description\x18\x1a \x01(\tR\x0fmetaDescription\x129\n" +
+	"\fgroup_prices\x18\x1b \x03(\v2\x16.product.v1.GroupPriceR\vgroupPrices\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x13\n" +
+	"\x11_compare_at_price\"#\n" +
+	"\x11GetProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"+\n" +
+	"\x17GetProductsByIDsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"o\n" +
+	"\x18GetProductsByIDsResponse\x12/\n" +
+	"\bproducts\x18\x01 \x03(\v2\x13.product.v1.ProductR\bproducts\x12\"\n" +
+	"\rnot_found_ids\x18\x02 \x03(\tR\vnotFoundIds\"\xe0\t\n" +
+	"\x14UpdateProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x03 \x01(\tH\x01R\vdescription\x88\x01\x01\x12\x19\n" +
+	"\x05price\x18\x04 \x01(\x01H\x02R\x05price\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\x05 \x03(\tR\timageUrls\x12\x1f\n" +
+	"\bcategory\x18\x06 \x01(\tH\x03R\bcategory\x88\x01\x01\x12<\n" +
+	"\tinventory\x18\a \x01(\v2\x19.product.v1.InventoryInfoH\x04R\tinventory\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12P\n" +
+	"\n" +
+	"attributes\x18\t \x03(\v20.product.v1.UpdateProductRequest.AttributesEntryR\n" +
+	"attributes\x12\x1b\n" +
+	"\x06active\x18\n" +
+	" \x01(\bH\x05R\x06active\x88\x01\x01\x126\n" +
+	"\adigital\x18\v \x01(\v2\x17.product.v1.DigitalInfoH\x06R\adigital\x88\x01\x01\x12/\n" +
+	"\x06weight\x18\f \x01(\v2\x12.product.v1.WeightH\aR\x06weight\x88\x01\x01\x12;\n" +
+	"\n" +
+	"dimensions\x18\r \x01(\v2\x16.product.v1.DimensionsH\bR\n" +
+	"dimensions\x88\x01\x01\x12*\n" +
+	"\x0eshipping_class\x18\x0e \x01(\tH\tR\rshippingClass\x88\x01\x01\x12\x1d\n" +
+	"\abarcode\x18\x0f \x01(\tH\n" +
+	"R\abarcode\x88\x01\x01\x12&\n" +
+	"\frelease_date\x18\x10 \x01(\x03H\vR\vreleaseDate\x88\x01\x01\x124\n" +
+	"\x13preorder_allocation\x18\x11 \x01(\x05H\fR\x12preorderAllocation\x88\x01\x01\x12-\n" +
+	"\x10compare_at_price\x18\x12 \x01(\x01H\rR\x0ecompareAtPrice\x88\x01\x01\x12\x17\n" +
+	"\x04slug\x18\x13 \x01(\tH\x0eR\x04slug\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"meta_title\x18\x14 \x01(\tH\x0fR\tmetaTitle\x88\x01\x01\x12.\n" +
+	"\x10meta_description\x18\x15 \x01(\tH\x10R\x0fmetaDescription\x88\x01\x01\x129\n" +
+	"\fgroup_prices\x18\x16 \x03(\v2\x16.product.v1.GroupPriceR\vgroupPrices\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\a\n" +
+	"\x05_nameB\x0e\n" +
+	"\f_descriptionB\b\n" +
+	"\x06_priceB\v\n" +
+	"\t_categoryB\f\n" +
+	"\n" +
+	"_inventoryB\t\n" +
+	"\a_activeB\n" +
+	"\n" +
+	"\b_digitalB\t\n" +
+	"\a_weightB\r\n" +
+	"\v_dimensionsB\x11\n" +
+	"\x0f_shipping_classB\n" +
+	"\n" +
+	"\b_barcodeB\x0f\n" +
+	"\r_release_dateB\x16\n" +
+	"\x14_preorder_allocationB\x13\n" +
+	"\x11_compare_at_priceB\a\n" +
+	"\x05_slugB\r\n" +
+	"\v_meta_titleB\x13\n" +
+	"\x11_meta_description\"&\n" +
+	"\x14DeleteProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"K\n" +
+	"\x15DeleteProductResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xa7\x05\n" +
+	"\x13ListProductsRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x1b\n" +
+	"\tmin_price\x18\x05 \x01(\x01R\bminPrice\x12\x1b\n" +
+	"\tmax_price\x18\x06 \x01(\x01R\bmaxPrice\x12\"\n" +
+	"\rin_stock_only\x18\a \x01(\bR\vinStockOnly\x12\x17\n" +
+	"\asort_by\x18\b \x01(\tR\x06sortBy\x12\x1b\n" +
+	"\tsort_desc\x18\t \x01(\bR\bsortDesc\x12\x1f\n" +
+	"\vsearch_term\x18\n" +
+	" \x01(\tR\n" +
+	"searchTerm\x12\x1f\n" +
+	"\vsupplier_id\x18\v \x01(\tR\n" +
+	"supplierId\x12#\n" +
+	"\rfeatured_only\x18\f \x01(\bR\ffeaturedOnly\x12\x19\n" +
+	"\bnew_only\x18\r \x01(\bR\anewOnly\x12%\n" +
+	"\x0eshipping_class\x18\x0e \x01(\tR\rshippingClass\x12\x14\n" +
+	"\x05badge\x18\x0f \x01(\tR\x05badge\x123\n" +
+	"\x15include_subcategories\x18\x10 \x01(\bR\x14includeSubcategories\x12b\n" +
+	"\x11attribute_filters\x18\x11 \x03(\v25.product.v1.ListProductsRequest.AttributeFiltersEntryR\x10attributeFilters\x1aC\n" +
+	"\x15AttributeFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xaf\x01\n" +
+	"\x14ListProductsResponse\x12/\n" +
+	"\bproducts\x18\x01 \x03(\v2\x13.product.v1.ProductR\bproducts\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1f\n" +
+	"\vtotal_pages\x18\x05 \x01(\x05R\n" +
+	"totalPages\"@\n" +
+	"\x0fProductResponse\x12-\n" +
+	"\aproduct\x18\x01 \x01(\v2\x13.product.v1.ProductR\aproduct\"\xaa\x01\n" +
+	"\x16UpdateInventoryRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12'\n" +
+	"\x0fquantity_change\x18\x02 \x01(\x05R\x0equantityChange\x12!\n" +
+	"\foperation_id\x18\x03 \x01(\tR\voperationId\x12%\n" +
+	"\x0eoperation_type\x18\x04 \x01(\tR\roperationType\"\x95\x01\n" +
+	"\x17UpdateInventoryResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12F\n" +
+	"\x11updated_inventory\x18\x02 \x01(\v2\x19.product.v1.InventoryInfoR\x10updatedInventory\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"N\n" +
+	"\x11CheckStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\"W\n" +
+	"\x12CheckStockResponse\x12\x1c\n" +
+	"\tavailable\x18\x01 \x01(\bR\tavailable\x12#\n" +
+	"\rcurrent_stock\x18\x02 \x01(\x05R\fcurrentStock\"\xbd\x01\n" +
+	"\x1eListInventoryOperationsRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12'\n" +
+	"\x0foperation_types\x18\x04 \x03(\tR\x0eoperationTypes\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\x03R\x02to\"\xa5\x01\n" +
+	"\x12InventoryOperation\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\x12%\n" +
+	"\x0eoperation_type\x18\x02 \x01(\tR\roperationType\x12'\n" +
+	"\x0fquantity_change\x18\x03 \x01(\x05R\x0equantityChange\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"\xc9\x01\n" +
+	"\x1fListInventoryOperationsResponse\x12>\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x1e.product.v1.InventoryOperationR\n" +
+	"operations\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1f\n" +
+	"\vtotal_pages\x18\x05 \x01(\x05R\n" +
+	"totalPages\"V\n" +
+	"\x15WatchInventoryRequest\x12\x1f\n" +
+	"\vproduct_ids\x18\x01 \x03(\tR\n" +
+	"productIds\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x05R\tthreshold\"\xaa\x01\n" +
+	"\x0fInventoryUpdate\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12!\n" +
+	"\fproduct_name\x18\x02 \x01(\tR\vproductName\x127\n" +
+	"\tinventory\x18\x03 \x01(\v2\x19.product.v1.InventoryInfoR\tinventory\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"B\n" +
+	"\x15StreamProductsRequest\x12)\n" +
+	"\x10include_inactive\x18\x01 \x01(\bR\x0fincludeInactive\"g\n" +
+	"\x12SetFeaturedRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bfeatured\x18\x02 \x01(\bR\bfeatured\x12%\n" +
+	"\x0efeatured_until\x18\x03 \x01(\x03R\rfeaturedUntil\"H\n" +
+	"\x0fCustomerProfile\x12\x1d\n" +
+	"\n" +
+	"birth_date\x18\x01 \x01(\tR\tbirthDate\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\"\x8d\x01\n" +
+	"\"ValidatePurchaseEligibilityRequest\x12\x1f\n" +
+	"\vproduct_ids\x18\x01 \x03(\tR\n" +
+	"productIds\x12F\n" +
+	"\x10customer_profile\x18\x02 \x01(\v2\x1b.product.v1.CustomerProfileR\x0fcustomerProfile\"d\n" +
+	"\x11IneligibleProduct\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x80\x01\n" +
+	"#ValidatePurchaseEligibilityResponse\x12\x1a\n" +
+	"\beligible\x18\x01 \x01(\bR\beligible\x12=\n" +
+	"\n" +
+	"ineligible\x18\x02 \x03(\v2\x1d.product.v1.IneligibleProductR\n" +
+	"ineligible2\xa6\t\n" +
+	"\x0eProductService\x12P\n" +
+	"\rCreateProduct\x12 .product.v1.CreateProductRequest\x1a\x1b.product.v1.ProductResponse\"\x00\x12J\n" +
+	"\n" +
+	"GetProduct\x12\x1d.product.v1.GetProductRequest\x1a\x1b.product.v1.ProductResponse\"\x00\x12_\n" +
+	"\x10GetProductsByIDs\x12#.product.v1.GetProductsByIDsRequest\x1a$.product.v1.GetProductsByIDsResponse\"\x00\x12P\n" +
+	"\rUpdateProduct\x12 .product.v1.UpdateProductRequest\x1a\x1b.product.v1.ProductResponse\"\x00\x12V\n" +
+	"\rDeleteProduct\x12 .product.v1.DeleteProductRequest\x1a!.product.v1.DeleteProductResponse\"\x00\x12S\n" +
+	"\fListProducts\x12\x1f.product.v1.ListProductsRequest\x1a .product.v1.ListProductsResponse\"\x00\x12\\\n" +
+	"\x0fUpdateInventory\x12\".product.v1.UpdateInventoryRequest\x1a#.product.v1.UpdateInventoryResponse\"\x00\x12M\n" +
+	"\n" +
+	"CheckStock\x12\x1d.product.v1.CheckStockRequest\x1a\x1e.product.v1.CheckStockResponse\"\x00\x12t\n" +
+	"\x17ListInventoryOperations\x12*.product.v1.ListInventoryOperationsRequest\x1a+.product.v1.ListInventoryOperationsResponse\"\x00\x12T\n" +
+	"\x0eWatchInventory\x12!.product.v1.WatchInventoryRequest\x1a\x1b.product.v1.InventoryUpdate\"\x000\x01\x12L\n" +
+	"\x0eStreamProducts\x12!.product.v1.StreamProductsRequest\x1a\x13.product.v1.Product\"\x000\x01\x12L\n" +
+	"\vSetFeatured\x12\x1e.product.v1.SetFeaturedRequest\x1a\x1b.product.v1.ProductResponse\"\x00\x12\x80\x01\n" +
+	"\x1bValidatePurchaseEligibility\x12..product.v1.ValidatePurchaseEligibilityRequest\x1a/.product.v1.ValidatePurchaseEligibilityResponse\"\x00B;Z9github.com/bekbull/online-shop/proto/product/v1;productv1b\x06proto3"
+
+var (
+	file_product_v1_product_proto_rawDescOnce sync.Once
+	file_product_v1_product_proto_rawDescData []byte
+)
+
+func file_product_v1_product_proto_rawDescGZIP() []byte {
+	file_product_v1_product_proto_rawDescOnce.Do(func() {
+		file_product_v1_product_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_product_v1_product_proto_rawDesc), len(file_product_v1_product_proto_rawDesc)))
+	})
+	return file_product_v1_product_proto_rawDescData
+}
+
+var file_product_v1_product_proto_msgTypes = make([]protoimpl.MessageInfo, 40)
+var file_product_v1_product_proto_goTypes = []any{
+	(*Product)(nil),                             // 0: product.v1.Product
+	(*AppliedPromotion)(nil),                    // 1: product.v1.AppliedPromotion
+	(*GroupPrice)(nil),                          // 2: product.v1.GroupPrice
+	(*ProductBadge)(nil),                        // 3: product.v1.ProductBadge
+	(*PreorderInfo)(nil),                        // 4: product.v1.PreorderInfo
+	(*Weight)(nil),                              // 5: product.v1.Weight
+	(*Dimensions)(nil),                          // 6: product.v1.Dimensions
+	(*DigitalInfo)(nil),                         // 7: product.v1.DigitalInfo
+	(*DigitalAsset)(nil),                        // 8: product.v1.DigitalAsset
+	(*ProductSupplier)(nil),                     // 9: product.v1.ProductSupplier
+	(*InventoryInfo)(nil),                       // 10: product.v1.InventoryInfo
+	(*CreateProductRequest)(nil),                // 11: product.v1.CreateProductRequest
+	(*GetProductRequest)(nil),                   // 12: product.v1.GetProductRequest
+	(*GetProductsByIDsRequest)(nil),             // 13: product.v1.GetProductsByIDsRequest
+	(*GetProductsByIDsResponse)(nil),            // 14: product.v1.GetProductsByIDsResponse
+	(*UpdateProductRequest)(nil),                // 15: product.v1.UpdateProductRequest
+	(*DeleteProductRequest)(nil),                // 16: product.v1.DeleteProductRequest
+	(*DeleteProductResponse)(nil),               // 17: product.v1.DeleteProductResponse
+	(*ListProductsRequest)(nil),                 // 18: product.v1.ListProductsRequest
+	(*ListProductsResponse)(nil),                // 19: product.v1.ListProductsResponse
+	(*ProductResponse)(nil),                     // 20: product.v1.ProductResponse
+	(*UpdateInventoryRequest)(nil),              // 21: product.v1.UpdateInventoryRequest
+	(*UpdateInventoryResponse)(nil),             // 22: product.v1.UpdateInventoryResponse
+	(*CheckStockRequest)(nil),                   // 23: product.v1.CheckStockRequest
+	(*CheckStockResponse)(nil),                  // 24: product.v1.CheckStockResponse
+	(*ListInventoryOperationsRequest)(nil),      // 25: product.v1.ListInventoryOperationsRequest
+	(*InventoryOperation)(nil),                  // 26: product.v1.InventoryOperation
+	(*ListInventoryOperationsResponse)(nil),     // 27: product.v1.ListInventoryOperationsResponse
+	(*WatchInventoryRequest)(nil),               // 28: product.v1.WatchInventoryRequest
+	(*InventoryUpdate)(nil),                     // 29: product.v1.InventoryUpdate
+	(*StreamProductsRequest)(nil),               // 30: product.v1.StreamProductsRequest
+	(*SetFeaturedRequest)(nil),                  // 31: product.v1.SetFeaturedRequest
+	(*CustomerProfile)(nil),                     // 32: product.v1.CustomerProfile
+	(*ValidatePurchaseEligibilityRequest)(nil),  // 33: product.v1.ValidatePurchaseEligibilityRequest
+	(*IneligibleProduct)(nil),                   // 34: product.v1.IneligibleProduct
+	(*ValidatePurchaseEligibilityResponse)(nil), // 35: product.v1.ValidatePurchaseEligibilityResponse
+	nil, // 36: product.v1.Product.AttributesEntry
+	nil, // 37: product.v1.CreateProductRequest.AttributesEntry
+	nil, // 38: product.v1.UpdateProductRequest.AttributesEntry
+	nil, // 39: product.v1.ListProductsRequest.AttributeFiltersEntry
+}
+var file_product_v1_product_proto_depIdxs = []int32{
+	10, // 0: product.v1.Product.inventory:type_name -> product.v1.InventoryInfo
+	36, // 1: product.v1.Product.attributes:type_name -> product.v1.Product.AttributesEntry
+	9,  // 2: product.v1.Product.suppliers:type_name -> product.v1.ProductSupplier
+	7,  // 3: product.v1.Product.digital:type_name -> product.v1.DigitalInfo
+	5,  // 4: product.v1.Product.weight:type_name -> product.v1.Weight
+	6,  // 5: product.v1.Product.dimensions:type_name -> product.v1.Dimensions
+	4,  // 6: product.v1.Product.preorder:type_name -> product.v1.PreorderInfo
+	3,  // 7: product.v1.Product.display_badges:type_name -> product.v1.ProductBadge
+	2,  // 8: product.v1.Product.group_prices:type_name -> product.v1.GroupPrice
+	1,  // 9: product.v1.Product.promotion:type_name -> product.v1.AppliedPromotion
+	8,  // 10: product.v1.DigitalInfo.assets:type_name -> product.v1.DigitalAsset
+	10, // 11: product.v1.CreateProductRequest.inventory:type_name -> product.v1.InventoryInfo
+	37, // 12: product.v1.CreateProductRequest.attributes:type_name -> product.v1.CreateProductRequest.AttributesEntry
+	9,  // 13: product.v1.CreateProductRequest.suppliers:type_name -> product.v1.ProductSupplier
+	7,  // 14: product.v1.CreateProductRequest.digital:type_name -> product.v1.DigitalInfo
+	5,  // 15: product.v1.CreateProductRequest.weight:type_name -> product.v1.Weight
+	6,  // 16: product.v1.CreateProductRequest.dimensions:type_name -> product.v1.Dimensions
+	2,  // 17: product.v1.CreateProductRequest.group_prices:type_name -> product.v1.GroupPrice
+	0,  // 18: product.v1.GetProductsByIDsResponse.products:type_name -> product.v1.Product
+	10, // 19: product.v1.UpdateProductRequest.inventory:type_name -> product.v1.InventoryInfo
+	38, // 20: product.v1.UpdateProductRequest.attributes:type_name -> product.v1.UpdateProductRequest.AttributesEntry
+	7,  // 21: product.v1.UpdateProductRequest.digital:type_name -> product.v1.DigitalInfo
+	5,  // 22: product.v1.UpdateProductRequest.weight:type_name -> product.v1.Weight
+	6,  // 23: product.v1.UpdateProductRequest.dimensions:type_name -> product.v1.Dimensions
+	2,  // 24: product.v1.UpdateProductRequest.group_prices:type_name -> product.v1.GroupPrice
+	39, // 25: product.v1.ListProductsRequest.attribute_filters:type_name -> product.v1.ListProductsRequest.AttributeFiltersEntry
+	0,  // 26: product.v1.ListProductsResponse.products:type_name -> product.v1.Product
+	0,  // 27: product.v1.ProductResponse.product:type_name -> product.v1.Product
+	10, // 28: product.v1.UpdateInventoryResponse.updated_inventory:type_name -> product.v1.InventoryInfo
+	26, // 29: product.v1.ListInventoryOperationsResponse.operations:type_name -> product.v1.InventoryOperation
+	10, // 30: product.v1.InventoryUpdate.inventory:type_name -> product.v1.InventoryInfo
+	32, // 31: product.v1.ValidatePurchaseEligibilityRequest.customer_profile:type_name -> product.v1.CustomerProfile
+	34, // 32: product.v1.ValidatePurchaseEligibilityResponse.ineligible:type_name -> product.v1.IneligibleProduct
+	11, // 33: product.v1.ProductService.CreateProduct:input_type -> product.v1.CreateProductRequest
+	12, // 34: product.v1.ProductService.GetProduct:input_type -> product.v1.GetProductRequest
+	13, // 35: product.v1.ProductService.GetProductsByIDs:input_type -> product.v1.GetProductsByIDsRequest
+	15, // 36: product.v1.ProductService.UpdateProduct:input_type -> product.v1.UpdateProductRequest
+	16, // 37: product.v1.ProductService.DeleteProduct:input_type -> product.v1.DeleteProductRequest
+	18, // 38: product.v1.ProductService.ListProducts:input_type -> product.v1.ListProductsRequest
+	21, // 39: product.v1.ProductService.UpdateInventory:input_type -> product.v1.UpdateInventoryRequest
+	23, // 40: product.v1.ProductService.CheckStock:input_type -> product.v1.CheckStockRequest
+	25, // 41: product.v1.ProductService.ListInventoryOperations:input_type -> product.v1.ListInventoryOperationsRequest
+	28, // 42: product.v1.ProductService.WatchInventory:input_type -> product.v1.WatchInventoryRequest
+	30, // 43: product.v1.ProductService.StreamProducts:input_type -> product.v1.StreamProductsRequest
+	31, // 44: product.v1.ProductService.SetFeatured:input_type -> product.v1.SetFeaturedRequest
+	33, // 45: product.v1.ProductService.ValidatePurchaseEligibility:input_type -> product.v1.ValidatePurchaseEligibilityRequest
+	20, // 46: product.v1.ProductService.CreateProduct:output_type -> product.v1.ProductResponse
+	20, // 47: product.v1.ProductService.GetProduct:output_type -> product.v1.ProductResponse
+	14, // 48: product.v1.ProductService.GetProductsByIDs:output_type -> product.v1.GetProductsByIDsResponse
+	20, // 49: product.v1.ProductService.UpdateProduct:output_type -> product.v1.ProductResponse
+	17, // 50: product.v1.ProductService.DeleteProduct:output_type -> product.v1.DeleteProductResponse
+	19, // 51: product.v1.ProductService.ListProducts:output_type -> product.v1.ListProductsResponse
+	22, // 52: product.v1.ProductService.UpdateInventory:output_type -> product.v1.UpdateInventoryResponse
+	24, // 53: product.v1.ProductService.CheckStock:output_type -> product.v1.CheckStockResponse
+	27, // 54: product.v1.ProductService.ListInventoryOperations:output_type -> product.v1.ListInventoryOperationsResponse
+	29, // 55: product.v1.ProductService.WatchInventory:output_type -> product.v1.InventoryUpdate
+	0,  // 56: product.v1.ProductService.StreamProducts:output_type -> product.v1.Product
+	20, // 57: product.v1.ProductService.SetFeatured:output_type -> product.v1.ProductResponse
+	35, // 58: product.v1.ProductService.ValidatePurchaseEligibility:output_type -> product.v1.ValidatePurchaseEligibilityResponse
+	46, // [46:59] is the sub-list for method output_type
+	33, // [33:46] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
+}
+
+func init() { file_product_v1_product_proto_init() }
+func file_product_v1_product_proto_init() {
+	if File_product_v1_product_proto != nil {
+		return
+	}
+	file_product_v1_product_proto_msgTypes[0].OneofWrappers = []any{}
+	file_product_v1_product_proto_msgTypes[11].OneofWrappers = []any{}
+	file_product_v1_product_proto_msgTypes[15].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_product_v1_product_proto_rawDesc), len(file_product_v1_product_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   40,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_product_v1_product_proto_goTypes,
+		DependencyIndexes: file_product_v1_product_proto_depIdxs,
+		MessageInfos:      file_product_v1_product_proto_msgTypes,
+	}.Build()
+	File_product_v1_product_proto = out.File
+	file_product_v1_product_proto_goTypes = nil
+	file_product_v1_product_proto_depIdxs = nil
+}
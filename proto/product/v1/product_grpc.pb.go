@@ -1,10 +1,10 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.5.1
-// - protoc             v5.29.3
-// source: proto/product/product.proto
+// - protoc             (unknown)
+// source: product/v1/product.proto
 
-package product
+package productv1
 
 import (
 	context "context"
@@ -19,31 +19,51 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ProductService_CreateProduct_FullMethodName   = "/product.ProductService/CreateProduct"
-	ProductService_GetProduct_FullMethodName      = "/product.ProductService/GetProduct"
-	ProductService_UpdateProduct_FullMethodName   = "/product.ProductService/UpdateProduct"
-	ProductService_DeleteProduct_FullMethodName   = "/product.ProductService/DeleteProduct"
-	ProductService_ListProducts_FullMethodName    = "/product.ProductService/ListProducts"
-	ProductService_UpdateInventory_FullMethodName = "/product.ProductService/UpdateInventory"
-	ProductService_CheckStock_FullMethodName      = "/product.ProductService/CheckStock"
-	ProductService_WatchInventory_FullMethodName  = "/product.ProductService/WatchInventory"
+	ProductService_CreateProduct_FullMethodName               = "/product.v1.ProductService/CreateProduct"
+	ProductService_GetProduct_FullMethodName                  = "/product.v1.ProductService/GetProduct"
+	ProductService_GetProductsByIDs_FullMethodName            = "/product.v1.ProductService/GetProductsByIDs"
+	ProductService_UpdateProduct_FullMethodName               = "/product.v1.ProductService/UpdateProduct"
+	ProductService_DeleteProduct_FullMethodName               = "/product.v1.ProductService/DeleteProduct"
+	ProductService_ListProducts_FullMethodName                = "/product.v1.ProductService/ListProducts"
+	ProductService_UpdateInventory_FullMethodName             = "/product.v1.ProductService/UpdateInventory"
+	ProductService_CheckStock_FullMethodName                  = "/product.v1.ProductService/CheckStock"
+	ProductService_ListInventoryOperations_FullMethodName     = "/product.v1.ProductService/ListInventoryOperations"
+	ProductService_WatchInventory_FullMethodName              = "/product.v1.ProductService/WatchInventory"
+	ProductService_StreamProducts_FullMethodName              = "/product.v1.ProductService/StreamProducts"
+	ProductService_SetFeatured_FullMethodName                 = "/product.v1.ProductService/SetFeatured"
+	ProductService_ValidatePurchaseEligibility_FullMethodName = "/product.v1.ProductService/ValidatePurchaseEligibility"
 )
 
 // ProductServiceClient is the client API for ProductService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProductServiceClient interface {
-	// Product management
+	// Product management. GetProduct and ListProducts price products for
+	// the customer group in the x-customer-group metadata, set by the API
+	// gateway.
 	CreateProduct(ctx context.Context, in *CreateProductRequest, opts ...grpc.CallOption) (*ProductResponse, error)
 	GetProduct(ctx context.Context, in *GetProductRequest, opts ...grpc.CallOption) (*ProductResponse, error)
+	// The products with the IDs in their order, at most 100, and the IDs
+	// that were not found
+	GetProductsByIDs(ctx context.Context, in *GetProductsByIDsRequest, opts ...grpc.CallOption) (*GetProductsByIDsResponse, error)
 	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*ProductResponse, error)
 	DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductResponse, error)
 	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
 	// Inventory management
 	UpdateInventory(ctx context.Context, in *UpdateInventoryRequest, opts ...grpc.CallOption) (*UpdateInventoryResponse, error)
 	CheckStock(ctx context.Context, in *CheckStockRequest, opts ...grpc.CallOption) (*CheckStockResponse, error)
+	// The recorded inventory and preorder operations of a product, newest
+	// first, to audit how its stock reached its current level
+	ListInventoryOperations(ctx context.Context, in *ListInventoryOperationsRequest, opts ...grpc.CallOption) (*ListInventoryOperationsResponse, error)
 	// Streaming inventory updates (for real-time monitoring)
 	WatchInventory(ctx context.Context, in *WatchInventoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryUpdate], error)
+	// Streams the full catalog (used by consumers rebuilding from scratch)
+	StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error)
+	// Merchandising
+	SetFeatured(ctx context.Context, in *SetFeaturedRequest, opts ...grpc.CallOption) (*ProductResponse, error)
+	// Checkout: whether the customer may buy age-restricted and regulated
+	// products
+	ValidatePurchaseEligibility(ctx context.Context, in *ValidatePurchaseEligibilityRequest, opts ...grpc.CallOption) (*ValidatePurchaseEligibilityResponse, error)
 }
 
 type productServiceClient struct {
@@ -74,6 +94,16 @@ func (c *productServiceClient) GetProduct(ctx context.Context, in *GetProductReq
 	return out, nil
 }
 
+func (c *productServiceClient) GetProductsByIDs(ctx context.Context, in *GetProductsByIDsRequest, opts ...grpc.CallOption) (*GetProductsByIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProductsByIDsResponse)
+	err := c.cc.Invoke(ctx, ProductService_GetProductsByIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *productServiceClient) UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*ProductResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ProductResponse)
@@ -124,6 +154,16 @@ func (c *productServiceClient) CheckStock(ctx context.Context, in *CheckStockReq
 	return out, nil
 }
 
+func (c *productServiceClient) ListInventoryOperations(ctx context.Context, in *ListInventoryOperationsRequest, opts ...grpc.CallOption) (*ListInventoryOperationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInventoryOperationsResponse)
+	err := c.cc.Invoke(ctx, ProductService_ListInventoryOperations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *productServiceClient) WatchInventory(ctx context.Context, in *WatchInventoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InventoryUpdate], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &ProductService_ServiceDesc.Streams[0], ProductService_WatchInventory_FullMethodName, cOpts...)
@@ -143,21 +183,75 @@ func (c *productServiceClient) WatchInventory(ctx context.Context, in *WatchInve
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ProductService_WatchInventoryClient = grpc.ServerStreamingClient[InventoryUpdate]
 
+func (c *productServiceClient) StreamProducts(ctx context.Context, in *StreamProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductService_ServiceDesc.Streams[1], ProductService_StreamProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamProductsRequest, Product]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_StreamProductsClient = grpc.ServerStreamingClient[Product]
+
+func (c *productServiceClient) SetFeatured(ctx context.Context, in *SetFeaturedRequest, opts ...grpc.CallOption) (*ProductResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProductResponse)
+	err := c.cc.Invoke(ctx, ProductService_SetFeatured_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) ValidatePurchaseEligibility(ctx context.Context, in *ValidatePurchaseEligibilityRequest, opts ...grpc.CallOption) (*ValidatePurchaseEligibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidatePurchaseEligibilityResponse)
+	err := c.cc.Invoke(ctx, ProductService_ValidatePurchaseEligibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductServiceServer is the server API for ProductService service.
 // All implementations must embed UnimplementedProductServiceServer
 // for forward compatibility.
 type ProductServiceServer interface {
-	// Product management
+	// Product management. GetProduct and ListProducts price products for
+	// the customer group in the x-customer-group metadata, set by the API
+	// gateway.
 	CreateProduct(context.Context, *CreateProductRequest) (*ProductResponse, error)
 	GetProduct(context.Context, *GetProductRequest) (*ProductResponse, error)
+	// The products with the IDs in their order, at most 100, and the IDs
+	// that were not found
+	GetProductsByIDs(context.Context, *GetProductsByIDsRequest) (*GetProductsByIDsResponse, error)
 	UpdateProduct(context.Context, *UpdateProductRequest) (*ProductResponse, error)
 	DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductResponse, error)
 	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
 	// Inventory management
 	UpdateInventory(context.Context, *UpdateInventoryRequest) (*UpdateInventoryResponse, error)
 	CheckStock(context.Context, *CheckStockRequest) (*CheckStockResponse, error)
+	// The recorded inventory and preorder operations of a product, newest
+	// first, to audit how its stock reached its current level
+	ListInventoryOperations(context.Context, *ListInventoryOperationsRequest) (*ListInventoryOperationsResponse, error)
 	// Streaming inventory updates (for real-time monitoring)
 	WatchInventory(*WatchInventoryRequest, grpc.ServerStreamingServer[InventoryUpdate]) error
+	// Streams the full catalog (used by consumers rebuilding from scratch)
+	StreamProducts(*StreamProductsRequest, grpc.ServerStreamingServer[Product]) error
+	// Merchandising
+	SetFeatured(context.Context, *SetFeaturedRequest) (*ProductResponse, error)
+	// Checkout: whether the customer may buy age-restricted and regulated
+	// products
+	ValidatePurchaseEligibility(context.Context, *ValidatePurchaseEligibilityRequest) (*ValidatePurchaseEligibilityResponse, error)
 	mustEmbedUnimplementedProductServiceServer()
 }
 
@@ -174,6 +268,9 @@ func (UnimplementedProductServiceServer) CreateProduct(context.Context, *CreateP
 func (UnimplementedProductServiceServer) GetProduct(context.Context, *GetProductRequest) (*ProductResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
+func (UnimplementedProductServiceServer) GetProductsByIDs(context.Context, *GetProductsByIDsRequest) (*GetProductsByIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProductsByIDs not implemented")
+}
 func (UnimplementedProductServiceServer) UpdateProduct(context.Context, *UpdateProductRequest) (*ProductResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
 }
@@ -189,9 +286,21 @@ func (UnimplementedProductServiceServer) UpdateInventory(context.Context, *Updat
 func (UnimplementedProductServiceServer) CheckStock(context.Context, *CheckStockRequest) (*CheckStockResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckStock not implemented")
 }
+func (UnimplementedProductServiceServer) ListInventoryOperations(context.Context, *ListInventoryOperationsRequest) (*ListInventoryOperationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInventoryOperations not implemented")
+}
 func (UnimplementedProductServiceServer) WatchInventory(*WatchInventoryRequest, grpc.ServerStreamingServer[InventoryUpdate]) error {
 	return status.Errorf(codes.Unimplemented, "method WatchInventory not implemented")
 }
+func (UnimplementedProductServiceServer) StreamProducts(*StreamProductsRequest, grpc.ServerStreamingServer[Product]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamProducts not implemented")
+}
+func (UnimplementedProductServiceServer) SetFeatured(context.Context, *SetFeaturedRequest) (*ProductResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFeatured not implemented")
+}
+func (UnimplementedProductServiceServer) ValidatePurchaseEligibility(context.Context, *ValidatePurchaseEligibilityRequest) (*ValidatePurchaseEligibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidatePurchaseEligibility not implemented")
+}
 func (UnimplementedProductServiceServer) mustEmbedUnimplementedProductServiceServer() {}
 func (UnimplementedProductServiceServer) testEmbeddedByValue()                        {}
 
@@ -249,6 +358,24 @@ func _ProductService_GetProduct_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductService_GetProductsByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProductsByIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).GetProductsByIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_GetProductsByIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).GetProductsByIDs(ctx, req.(*GetProductsByIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ProductService_UpdateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateProductRequest)
 	if err := dec(in); err != nil {
@@ -339,6 +466,24 @@ func _ProductService_CheckStock_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductService_ListInventoryOperations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInventoryOperationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).ListInventoryOperations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_ListInventoryOperations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).ListInventoryOperations(ctx, req.(*ListInventoryOperationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ProductService_WatchInventory_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(WatchInventoryRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -350,11 +495,58 @@ func _ProductService_WatchInventory_Handler(srv interface{}, stream grpc.ServerS
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ProductService_WatchInventoryServer = grpc.ServerStreamingServer[InventoryUpdate]
 
+func _ProductService_StreamProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductServiceServer).StreamProducts(m, &grpc.GenericServerStream[StreamProductsRequest, Product]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductService_StreamProductsServer = grpc.ServerStreamingServer[Product]
+
+func _ProductService_SetFeatured_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFeaturedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).SetFeatured(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_SetFeatured_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).SetFeatured(ctx, req.(*SetFeaturedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_ValidatePurchaseEligibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidatePurchaseEligibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).ValidatePurchaseEligibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_ValidatePurchaseEligibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).ValidatePurchaseEligibility(ctx, req.(*ValidatePurchaseEligibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProductService_ServiceDesc is the grpc.ServiceDesc for ProductService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var ProductService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "product.ProductService",
+	ServiceName: "product.v1.ProductService",
 	HandlerType: (*ProductServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
@@ -365,6 +557,10 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProduct",
 			Handler:    _ProductService_GetProduct_Handler,
 		},
+		{
+			MethodName: "GetProductsByIDs",
+			Handler:    _ProductService_GetProductsByIDs_Handler,
+		},
 		{
 			MethodName: "UpdateProduct",
 			Handler:    _ProductService_UpdateProduct_Handler,
@@ -385,6 +581,18 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CheckStock",
 			Handler:    _ProductService_CheckStock_Handler,
 		},
+		{
+			MethodName: "ListInventoryOperations",
+			Handler:    _ProductService_ListInventoryOperations_Handler,
+		},
+		{
+			MethodName: "SetFeatured",
+			Handler:    _ProductService_SetFeatured_Handler,
+		},
+		{
+			MethodName: "ValidatePurchaseEligibility",
+			Handler:    _ProductService_ValidatePurchaseEligibility_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -392,6 +600,11 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ProductService_WatchInventory_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamProducts",
+			Handler:       _ProductService_StreamProducts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Metadata: "proto/product/product.proto",
+	Metadata: "product/v1/product.proto",
 }
@@ -0,0 +1,592 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: tax/v1/tax.proto
+
+package taxv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Tax data structures
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Country       string                 `protobuf:"bytes,1,opt,name=country,proto3" json:"country,omitempty"` // ISO 3166-1 alpha-2
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`   // ISO 3166-2 subdivision code without country prefix, e.g. "CA"
+	PostalCode    string                 `protobuf:"bytes,3,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_tax_v1_tax_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Address) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+type LineItem struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProductId      string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	TaxClass       string                 `protobuf:"bytes,3,opt,name=tax_class,json=taxClass,proto3" json:"tax_class,omitempty"` // Empty means the standard class
+	Quantity       int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	UnitAmount     int64                  `protobuf:"varint,5,opt,name=unit_amount,json=unitAmount,proto3" json:"unit_amount,omitempty"`             // Minor currency units
+	DiscountAmount int64                  `protobuf:"varint,6,opt,name=discount_amount,json=discountAmount,proto3" json:"discount_amount,omitempty"` // Minor currency units, for the whole line
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LineItem) Reset() {
+	*x = LineItem{}
+	mi := &file_tax_v1_tax_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LineItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LineItem) ProtoMessage() {}
+
+func (x *LineItem) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LineItem.ProtoReflect.Descriptor instead.
+func (*LineItem) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LineItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LineItem) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *LineItem) GetTaxClass() string {
+	if x != nil {
+		return x.TaxClass
+	}
+	return ""
+}
+
+func (x *LineItem) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *LineItem) GetUnitAmount() int64 {
+	if x != nil {
+		return x.UnitAmount
+	}
+	return 0
+}
+
+func (x *LineItem) GetDiscountAmount() int64 {
+	if x != nil {
+		return x.DiscountAmount
+	}
+	return 0
+}
+
+type TaxComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jurisdiction  string                 `protobuf:"bytes,1,opt,name=jurisdiction,proto3" json:"jurisdiction,omitempty"` // e.g. "US" or "US-CA"
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                 // e.g. "State sales tax"
+	Rate          float64                `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`               // 0.0725 for 7.25%
+	Amount        int64                  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`            // Minor currency units
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaxComponent) Reset() {
+	*x = TaxComponent{}
+	mi := &file_tax_v1_tax_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaxComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxComponent) ProtoMessage() {}
+
+func (x *TaxComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxComponent.ProtoReflect.Descriptor instead.
+func (*TaxComponent) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TaxComponent) GetJurisdiction() string {
+	if x != nil {
+		return x.Jurisdiction
+	}
+	return ""
+}
+
+func (x *TaxComponent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TaxComponent) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *TaxComponent) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type TaxLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TaxableAmount int64                  `protobuf:"varint,2,opt,name=taxable_amount,json=taxableAmount,proto3" json:"taxable_amount,omitempty"`
+	TaxAmount     int64                  `protobuf:"varint,3,opt,name=tax_amount,json=taxAmount,proto3" json:"tax_amount,omitempty"`
+	EffectiveRate float64                `protobuf:"fixed64,4,opt,name=effective_rate,json=effectiveRate,proto3" json:"effective_rate,omitempty"`
+	Components    []*TaxComponent        `protobuf:"bytes,5,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaxLine) Reset() {
+	*x = TaxLine{}
+	mi := &file_tax_v1_tax_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaxLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxLine) ProtoMessage() {}
+
+func (x *TaxLine) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxLine.ProtoReflect.Descriptor instead.
+func (*TaxLine) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TaxLine) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TaxLine) GetTaxableAmount() int64 {
+	if x != nil {
+		return x.TaxableAmount
+	}
+	return 0
+}
+
+func (x *TaxLine) GetTaxAmount() int64 {
+	if x != nil {
+		return x.TaxAmount
+	}
+	return 0
+}
+
+func (x *TaxLine) GetEffectiveRate() float64 {
+	if x != nil {
+		return x.EffectiveRate
+	}
+	return 0
+}
+
+func (x *TaxLine) GetComponents() []*TaxComponent {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+// Request and Response messages
+type CalculateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DocumentType  string                 `protobuf:"bytes,1,opt,name=document_type,json=documentType,proto3" json:"document_type,omitempty"` // "order" or "invoice"
+	DocumentId    string                 `protobuf:"bytes,2,opt,name=document_id,json=documentId,proto3" json:"document_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"` // ISO 4217
+	ShipTo        *Address               `protobuf:"bytes,4,opt,name=ship_to,json=shipTo,proto3" json:"ship_to,omitempty"`
+	Lines         []*LineItem            `protobuf:"bytes,5,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalculateRequest) Reset() {
+	*x = CalculateRequest{}
+	mi := &file_tax_v1_tax_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalculateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateRequest) ProtoMessage() {}
+
+func (x *CalculateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateRequest.ProtoReflect.Descriptor instead.
+func (*CalculateRequest) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CalculateRequest) GetDocumentType() string {
+	if x != nil {
+		return x.DocumentType
+	}
+	return ""
+}
+
+func (x *CalculateRequest) GetDocumentId() string {
+	if x != nil {
+		return x.DocumentId
+	}
+	return ""
+}
+
+func (x *CalculateRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CalculateRequest) GetShipTo() *Address {
+	if x != nil {
+		return x.ShipTo
+	}
+	return nil
+}
+
+func (x *CalculateRequest) GetLines() []*LineItem {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type CalculateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DocumentId    string                 `protobuf:"bytes,1,opt,name=document_id,json=documentId,proto3" json:"document_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Lines         []*TaxLine             `protobuf:"bytes,3,rep,name=lines,proto3" json:"lines,omitempty"`
+	TotalTaxable  int64                  `protobuf:"varint,4,opt,name=total_taxable,json=totalTaxable,proto3" json:"total_taxable,omitempty"`
+	TotalTax      int64                  `protobuf:"varint,5,opt,name=total_tax,json=totalTax,proto3" json:"total_tax,omitempty"`
+	Provider      string                 `protobuf:"bytes,6,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalculateResponse) Reset() {
+	*x = CalculateResponse{}
+	mi := &file_tax_v1_tax_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalculateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateResponse) ProtoMessage() {}
+
+func (x *CalculateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateResponse.ProtoReflect.Descriptor instead.
+func (*CalculateResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CalculateResponse) GetDocumentId() string {
+	if x != nil {
+		return x.DocumentId
+	}
+	return ""
+}
+
+func (x *CalculateResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CalculateResponse) GetLines() []*TaxLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *CalculateResponse) GetTotalTaxable() int64 {
+	if x != nil {
+		return x.TotalTaxable
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetTotalTax() int64 {
+	if x != nil {
+		return x.TotalTax
+	}
+	return 0
+}
+
+func (x *CalculateResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+var File_tax_v1_tax_proto protoreflect.FileDescriptor
+
+const file_tax_v1_tax_proto_rawDesc = "" +
+	"\n" +
+	"\x10tax/v1/tax.proto\x12\x06tax.v1\"p\n" +
+	"\aAddress\x12\x18\n" +
+	"\acountry\x18\x01 \x01(\tR\acountry\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\x12\x1f\n" +
+	"\vpostal_code\x18\x03 \x01(\tR\n" +
+	"postalCode\x12\x12\n" +
+	"\x04city\x18\x04 \x01(\tR\x04city\"\xbc\x01\n" +
+	"\bLineItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1b\n" +
+	"\ttax_class\x18\x03 \x01(\tR\btaxClass\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12\x1f\n" +
+	"\vunit_amount\x18\x05 \x01(\x03R\n" +
+	"unitAmount\x12'\n" +
+	"\x0fdiscount_amount\x18\x06 \x01(\x03R\x0ediscountAmount\"r\n" +
+	"\fTaxComponent\x12\"\n" +
+	"\fjurisdiction\x18\x01 \x01(\tR\fjurisdiction\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x01R\x04rate\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\"\xbc\x01\n" +
+	"\aTaxLine\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12%\n" +
+	"\x0etaxable_amount\x18\x02 \x01(\x03R\rtaxableAmount\x12\x1d\n" +
+	"\n" +
+	"tax_amount\x18\x03 \x01(\x03R\ttaxAmount\x12%\n" +
+	"\x0eeffective_rate\x18\x04 \x01(\x01R\reffectiveRate\x124\n" +
+	"\n" +
+	"components\x18\x05 \x03(\v2\x14.tax.v1.TaxComponentR\n" +
+	"components\"\xc6\x01\n" +
+	"\x10CalculateRequest\x12#\n" +
+	"\rdocument_type\x18\x01 \x01(\tR\fdocumentType\x12\x1f\n" +
+	"\vdocument_id\x18\x02 \x01(\tR\n" +
+	"documentId\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12(\n" +
+	"\aship_to\x18\x04 \x01(\v2\x0f.tax.v1.AddressR\x06shipTo\x12&\n" +
+	"\x05lines\x18\x05 \x03(\v2\x10.tax.v1.LineItemR\x05lines\"\xd5\x01\n" +
+	"\x11CalculateResponse\x12\x1f\n" +
+	"\vdocument_id\x18\x01 \x01(\tR\n" +
+	"documentId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12%\n" +
+	"\x05lines\x18\x03 \x03(\v2\x0f.tax.v1.TaxLineR\x05lines\x12#\n" +
+	"\rtotal_taxable\x18\x04 \x01(\x03R\ftotalTaxable\x12\x1b\n" +
+	"\ttotal_tax\x18\x05 \x01(\x03R\btotalTax\x12\x1a\n" +
+	"\bprovider\x18\x06 \x01(\tR\bprovider2P\n" +
+	"\n" +
+	"TaxService\x12B\n" +
+	"\tCalculate\x12\x18.tax.v1.CalculateRequest\x1a\x19.tax.v1.CalculateResponse\"\x00B3Z1github.com/bekbull/online-shop/proto/tax/v1;taxv1b\x06proto3"
+
+var (
+	file_tax_v1_tax_proto_rawDescOnce sync.Once
+	file_tax_v1_tax_proto_rawDescData []byte
+)
+
+func file_tax_v1_tax_proto_rawDescGZIP() []byte {
+	file_tax_v1_tax_proto_rawDescOnce.Do(func() {
+		file_tax_v1_tax_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tax_v1_tax_proto_rawDesc), len(file_tax_v1_tax_proto_rawDesc)))
+	})
+	return file_tax_v1_tax_proto_rawDescData
+}
+
+var file_tax_v1_tax_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_tax_v1_tax_proto_goTypes = []any{
+	(*Address)(nil),           // 0: tax.v1.Address
+	(*LineItem)(nil),          // 1: tax.v1.LineItem
+	(*TaxComponent)(nil),      // 2: tax.v1.TaxComponent
+	(*TaxLine)(nil),           // 3: tax.v1.TaxLine
+	(*CalculateRequest)(nil),  // 4: tax.v1.CalculateRequest
+	(*CalculateResponse)(nil), // 5: tax.v1.CalculateResponse
+}
+var file_tax_v1_tax_proto_depIdxs = []int32{
+	2, // 0: tax.v1.TaxLine.components:type_name -> tax.v1.TaxComponent
+	0, // 1: tax.v1.CalculateRequest.ship_to:type_name -> tax.v1.Address
+	1, // 2: tax.v1.CalculateRequest.lines:type_name -> tax.v1.LineItem
+	3, // 3: tax.v1.CalculateResponse.lines:type_name -> tax.v1.TaxLine
+	4, // 4: tax.v1.TaxService.Calculate:input_type -> tax.v1.CalculateRequest
+	5, // 5: tax.v1.TaxService.Calculate:output_type -> tax.v1.CalculateResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_tax_v1_tax_proto_init() }
+func file_tax_v1_tax_proto_init() {
+	if File_tax_v1_tax_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tax_v1_tax_proto_rawDesc), len(file_tax_v1_tax_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tax_v1_tax_proto_goTypes,
+		DependencyIndexes: file_tax_v1_tax_proto_depIdxs,
+		MessageInfos:      file_tax_v1_tax_proto_msgTypes,
+	}.Build()
+	File_tax_v1_tax_proto = out.File
+	file_tax_v1_tax_proto_goTypes = nil
+	file_tax_v1_tax_proto_depIdxs = nil
+}
@@ -0,0 +1,123 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: tax/v1/tax.proto
+
+package taxv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TaxService_Calculate_FullMethodName = "/tax.v1.TaxService/Calculate"
+)
+
+// TaxServiceClient is the client API for TaxService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TaxServiceClient interface {
+	// Calculates line-level taxes for an order quote or an invoice
+	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
+}
+
+type taxServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTaxServiceClient(cc grpc.ClientConnInterface) TaxServiceClient {
+	return &taxServiceClient{cc}
+}
+
+func (c *taxServiceClient) Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CalculateResponse)
+	err := c.cc.Invoke(ctx, TaxService_Calculate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TaxServiceServer is the server API for TaxService service.
+// All implementations must embed UnimplementedTaxServiceServer
+// for forward compatibility.
+type TaxServiceServer interface {
+	// Calculates line-level taxes for an order quote or an invoice
+	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
+	mustEmbedUnimplementedTaxServiceServer()
+}
+
+// UnimplementedTaxServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTaxServiceServer struct{}
+
+func (UnimplementedTaxServiceServer) Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Calculate not implemented")
+}
+func (UnimplementedTaxServiceServer) mustEmbedUnimplementedTaxServiceServer() {}
+func (UnimplementedTaxServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeTaxServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TaxServiceServer will
+// result in compilation errors.
+type UnsafeTaxServiceServer interface {
+	mustEmbedUnimplementedTaxServiceServer()
+}
+
+func RegisterTaxServiceServer(s grpc.ServiceRegistrar, srv TaxServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTaxServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TaxService_ServiceDesc, srv)
+}
+
+func _TaxService_Calculate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaxServiceServer).Calculate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TaxService_Calculate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaxServiceServer).Calculate(ctx, req.(*CalculateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TaxService_ServiceDesc is the grpc.ServiceDesc for TaxService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TaxService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tax.v1.TaxService",
+	HandlerType: (*TaxServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Calculate",
+			Handler:    _TaxService_Calculate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tax/v1/tax.proto",
+}
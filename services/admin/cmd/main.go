@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	sdk "github.com/bekbull/online-shop/pkg/clients"
+	"github.com/bekbull/online-shop/pkg/grpcclient"
+	"github.com/bekbull/online-shop/pkg/httptls"
+	"github.com/bekbull/online-shop/pkg/listeners"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"github.com/bekbull/online-shop/pkg/workers"
+	"github.com/bekbull/online-shop/services/admin/config"
+	restHandler "github.com/bekbull/online-shop/services/admin/internal/api/rest"
+	"github.com/bekbull/online-shop/services/admin/internal/clients"
+	"github.com/bekbull/online-shop/services/admin/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/admin/internal/service"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	// Initialize logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	logger.Info("Starting Admin Service")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	// Connect to MongoDB for the audit trail
+	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", err)
+		}
+	}()
+	logger.Info("Connected to MongoDB")
+
+	auditRepo := mongodb.New(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), cfg.MongoDB.WriteTimeout)
+	if err := auditRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+
+	// Load TLS credentials for the gRPC upstreams; certificates are
+	// reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go creds.Watch(watchCtx)
+
+	// Upstream connections resolve through DNS or Consul and balance
+	// round-robin over healthy instances
+	clientFactory := grpcclient.New(cfg.Discovery, creds, logger)
+
+	// SDK calls fail fast through per-method circuit breakers, whose
+	// states are exported on /metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	sdkOptions := sdk.DefaultOptions()
+	sdkOptions.Timeout = cfg.Upstreams.Timeout
+	sdkOptions.Metrics = sdk.NewMetrics(registry, "admin")
+	if path := cfg.Upstreams.ProductPoliciesFile; path != "" {
+		policies, err := middleware.LoadEndpointPolicies(path, middleware.EndpointPolicy{})
+		if err != nil {
+			logger.Error("Failed to load product-service endpoint policies", "error", err)
+			os.Exit(1)
+		}
+		sdkOptions.MethodRetries = policies.ClientRetries()
+	}
+
+	// Create upstream clients
+	productClient, err := clients.NewProductClient(cfg.Upstreams.ProductServiceAddr, sdkOptions, clientFactory.DialOptions("product")...)
+	if err != nil {
+		logger.Error("Failed to create product client", "error", err)
+		os.Exit(1)
+	}
+	defer productClient.Close()
+
+	inventoryClient, err := clients.NewInventoryClient(cfg.Upstreams.InventoryServiceAddr, cfg.Upstreams.Timeout, clientFactory.DialOptions("inventory")...)
+	if err != nil {
+		logger.Error("Failed to create inventory client", "error", err)
+		os.Exit(1)
+	}
+	defer inventoryClient.Close()
+
+	userClient := clients.NewUserClient(cfg.Upstreams.UserServiceURL, cfg.Upstreams.Timeout)
+
+	var orderClient service.OrderClient
+	if cfg.Upstreams.OrderServiceURL != "" {
+		orderClient = clients.NewOrderClient(cfg.Upstreams.OrderServiceURL, cfg.Upstreams.Timeout)
+	} else {
+		logger.Warn("ORDER_SERVICE_URL not set, order data will be unavailable")
+	}
+
+	// Create service
+	adminService := service.New(productClient, inventoryClient, userClient, orderClient, auditRepo, service.Options{
+		LowStockThreshold: cfg.Dashboard.LowStockThreshold,
+		LowStockLimit:     cfg.Dashboard.LowStockLimit,
+		NewUsersLimit:     cfg.Dashboard.NewUsersLimit,
+		SalesWindow:       cfg.Dashboard.SalesWindow,
+		SearchLimit:       cfg.Dashboard.SearchLimit,
+	}, logger)
+
+	// Audit entries older than AUDIT_RETENTION are archived to object
+	// storage, then deleted
+	if err := cfg.Audit.Validate(); err != nil {
+		logger.Error("Invalid audit configuration", "error", err)
+		os.Exit(1)
+	}
+	archive, err := cfg.Audit.Store()
+	if err != nil {
+		logger.Error("Failed to configure the audit archive", "error", err)
+		os.Exit(1)
+	}
+	background := workers.NewGroup(workers.Options{Metrics: workers.NewMetrics(registry, "admin")}, logger)
+	if archive != nil {
+		adminService.SetAuditRetention(cfg.Audit.Retention, archive, cfg.Audit.Prefix)
+		background.Add(workers.Worker{
+			Name:       "audit-retention",
+			Schedule:   workers.Every(cfg.Audit.Interval),
+			RunOnStart: true,
+			Run: func(ctx context.Context) error {
+				_, err := adminService.ApplyAuditRetention(ctx)
+				return err
+			},
+		})
+		logger.Info("Audit retention enabled", "retention", cfg.Audit.Retention, "bucket", cfg.Audit.BucketURL)
+	}
+	background.Start(context.Background())
+
+	// Setup HTTP server
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(chimiddleware.RealIP)
+	// Carries the request and trace IDs on to the upstream calls
+	router.Use(logging.HTTPMiddleware(logger))
+	router.Use(logging.AccessLog(logger, logging.DefaultAccessLog))
+	router.Use(middleware.Recover(logger))
+	router.Use(chimiddleware.Timeout(30 * time.Second))
+
+	restHandler.NewAdminHandler(adminService, logger).RegisterRoutes(router)
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	// The REST API is served over HTTPS when HTTP_TLS_* is set
+	httpTLS, err := httptls.Load(watchCtx, cfg.HTTPTLS, logger)
+	if err != nil {
+		logger.Error("Failed to load HTTP TLS configuration", "error", err)
+		os.Exit(1)
+	}
+	httpServer := &http.Server{
+		Handler:   router,
+		TLSConfig: httpTLS,
+	}
+
+	// Bind every address before serving; each listener is then served on
+	// its own, and the service exits once all of them have failed
+	if err := cfg.Listen.Validate(); err != nil {
+		logger.Error("Invalid listener configuration", "error", err)
+		os.Exit(1)
+	}
+	httpAddrs := cfg.Listen.HTTPAddrs(cfg.HTTPPort)
+	httpListeners, err := cfg.Listen.Listen(httpAddrs)
+	if err != nil {
+		logger.Error("Failed to listen for HTTP", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Starting HTTP server", "tls", httpTLS != nil)
+	listeners.Serve(logger, "HTTP", httpListeners, func(lis net.Listener) error {
+		return httptls.Serve(httpServer, lis)
+	}, func() { os.Exit(1) })
+	if redirectServer := cfg.HTTPTLS.RedirectServer(listeners.TCPAddr(httpAddrs)); redirectServer != nil {
+		httpServer.RegisterOnShutdown(func() { redirectServer.Close() })
+		go func() {
+			logger.Info("Redirecting HTTP to HTTPS", "addr", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTPS redirect server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Wait for interrupt signal
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+	logger.Info("Received shutdown signal", "signal", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger.Info("Shutting down HTTP server")
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Stopping background workers")
+	if err := background.Stop(ctx); err != nil {
+		logger.Error("Background workers did not stop in time", "error", err)
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping the database to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
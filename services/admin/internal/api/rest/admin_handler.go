@@ -0,0 +1,275 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// ActorHeader carries the authenticated user ID. It must be set by the API
+// gateway after authenticating the request and stripped from client input.
+const ActorHeader = "X-User-ID"
+
+// AdminService defines the interface for the admin service
+type AdminService interface {
+	Authenticate(ctx context.Context, userID string) (*domain.Actor, error)
+	Dashboard(ctx context.Context, actor *domain.Actor) (*domain.Dashboard, error)
+	Search(ctx context.Context, actor *domain.Actor, query string) (*domain.SearchResults, error)
+	SetProductActive(ctx context.Context, actor *domain.Actor, productID string, active bool) error
+	SetProductFeatured(ctx context.Context, actor *domain.Actor, productID string, featured bool, until *time.Time) error
+	AdjustStock(ctx context.Context, actor *domain.Actor, productID, warehouseID string, quantityChange int, reason string) error
+	SetUserRoles(ctx context.Context, actor *domain.Actor, userID string, roles []string) error
+	DeleteUser(ctx context.Context, actor *domain.Actor, userID string) error
+	ListAudit(ctx context.Context, actor *domain.Actor, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+	ExportAudit(ctx context.Context, actor *domain.Actor, from, to time.Time, fn func(*domain.AuditEntry) error) error
+}
+
+// AdminHandler handles HTTP requests for the back office
+type AdminHandler struct {
+	service AdminService
+	logger  *slog.Logger
+}
+
+type actorKey struct{}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(service AdminService, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the admin routes with the given router
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/admin", func(r chi.Router) {
+		r.Use(h.authenticate)
+
+		r.Get("/dashboard", h.Dashboard)
+		r.Get("/search", h.Search)
+		r.Get("/audit", h.ListAudit)
+		r.Get("/audit/export", h.ExportAudit)
+
+		r.Post("/products/{id}/activate", h.setProductActive(true))
+		r.Post("/products/{id}/deactivate", h.setProductActive(false))
+		r.Post("/products/{id}/feature", h.FeatureProduct)
+		r.Post("/products/{id}/unfeature", h.UnfeatureProduct)
+		r.Post("/inventory/adjust", h.AdjustStock)
+
+		r.Put("/users/{id}/roles", h.SetUserRoles)
+		r.Delete("/users/{id}", h.DeleteUser)
+	})
+}
+
+// authenticate resolves the actor for every admin request
+func (h *AdminHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, err := h.service.Authenticate(r.Context(), r.Header.Get(ActorHeader))
+		if err != nil {
+			h.logger.Warn("Failed to authenticate admin request", "error", err)
+			apperrors.WriteHTTP(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey{}, actor)))
+	})
+}
+
+// Dashboard handles GET /v1/admin/dashboard
+func (h *AdminHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := h.service.Dashboard(r.Context(), actorFrom(r))
+	if err != nil {
+		h.writeError(w, r, "Failed to build dashboard", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, dashboard)
+}
+
+// Search handles GET /v1/admin/search?q=
+func (h *AdminHandler) Search(w http.ResponseWriter, r *http.Request) {
+	results, err := h.service.Search(r.Context(), actorFrom(r), strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		h.writeError(w, r, "Failed to search", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, results)
+}
+
+// ListAudit handles GET /v1/admin/audit
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Limit:      parseInt(query.Get("limit"), 100),
+	}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid since, expected RFC 3339 timestamp"))
+			return
+		}
+		filter.Since = t
+	}
+
+	entries, err := h.service.ListAudit(r.Context(), actorFrom(r), filter)
+	if err != nil {
+		h.writeError(w, r, "Failed to list audit entries", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+}
+
+// ExportAudit handles GET /v1/admin/audit/export?from=&to=, streaming the
+// sealed entries as NDJSON
+func (h *AdminHandler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	from, to, err := audit.ParseRange(r.URL.Query())
+	if err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid range"))
+		return
+	}
+
+	stream := audit.NewStreamWriter(w, "admin-audit.ndjson")
+	err = h.service.ExportAudit(r.Context(), actorFrom(r), from, to, func(entry *domain.AuditEntry) error {
+		return stream.Write(entry)
+	})
+	if err != nil {
+		if !stream.Started() {
+			h.writeError(w, r, "Failed to export audit entries", err)
+			return
+		}
+		// The status is sent; the truncated stream fails verification
+		h.logger.Error("Audit export interrupted", "error", err)
+		return
+	}
+	stream.Finish()
+}
+
+// setProductActive handles POST /v1/admin/products/{id}/activate and /deactivate
+func (h *AdminHandler) setProductActive(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if err := h.service.SetProductActive(r.Context(), actorFrom(r), id, active); err != nil {
+			h.writeError(w, r, "Failed to update product", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// FeatureProduct handles POST /v1/admin/products/{id}/feature. The body is
+// optional; {"until": "<RFC 3339>"} makes the feature expire.
+func (h *AdminHandler) FeatureProduct(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Until *time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	if err := h.service.SetProductFeatured(r.Context(), actorFrom(r), chi.URLParam(r, "id"), true, req.Until); err != nil {
+		h.writeError(w, r, "Failed to feature product", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnfeatureProduct handles POST /v1/admin/products/{id}/unfeature
+func (h *AdminHandler) UnfeatureProduct(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.SetProductFeatured(r.Context(), actorFrom(r), chi.URLParam(r, "id"), false, nil); err != nil {
+		h.writeError(w, r, "Failed to unfeature product", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdjustStock handles POST /v1/admin/inventory/adjust
+func (h *AdminHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ProductID      string `json:"product_id"`
+		WarehouseID    string `json:"warehouse_id"`
+		QuantityChange int    `json:"quantity_change"`
+		Reason         string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	err := h.service.AdjustStock(r.Context(), actorFrom(r), req.ProductID, req.WarehouseID, req.QuantityChange, req.Reason)
+	if err != nil {
+		h.writeError(w, r, "Failed to adjust stock", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserRoles handles PUT /v1/admin/users/{id}/roles
+func (h *AdminHandler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Roles []string `json:"roles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	if err := h.service.SetUserRoles(r.Context(), actorFrom(r), chi.URLParam(r, "id"), req.Roles); err != nil {
+		h.writeError(w, r, "Failed to update roles", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUser handles DELETE /v1/admin/users/{id}
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteUser(r.Context(), actorFrom(r), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, r, "Failed to delete user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func actorFrom(r *http.Request) *domain.Actor {
+	return r.Context().Value(actorKey{}).(*domain.Actor)
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	h.logger.Error(msg, "error", err)
+	apperrors.WriteHTTP(w, r, err)
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// Helper function to parse int parameters with default value
+func parseInt(value string, defaultValue int) int {
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return intValue
+}
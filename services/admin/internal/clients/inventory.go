@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/inventory/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// InventoryClient talks to the inventory service over gRPC
+type InventoryClient struct {
+	conn    *grpc.ClientConn
+	client  pb.InventoryServiceClient
+	timeout time.Duration
+}
+
+// NewInventoryClient creates a client for the inventory service at addr. Connections are
+// plaintext unless opts carry transport credentials, e.g. from pkg/mtls.
+func NewInventoryClient(addr string, timeout time.Duration, opts ...grpc.DialOption) (*InventoryClient, error) {
+	conn, err := grpc.NewClient(addr, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory client: %w", err)
+	}
+
+	return &InventoryClient{
+		conn:    conn,
+		client:  pb.NewInventoryServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// Adjust changes on-hand stock of a product in a warehouse
+func (c *InventoryClient) Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.Adjust(ctx, &pb.AdjustRequest{
+		OperationId:    operationID,
+		ProductId:      productID,
+		WarehouseId:    warehouseID,
+		QuantityChange: int32(quantityChange),
+		Reason:         reason,
+	})
+	if err != nil {
+		return fmt.Errorf("inventory service error: %w", apperrors.FromGRPC(err))
+	}
+	return nil
+}
+
+// Close closes the underlying connection
+func (c *InventoryClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,60 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+)
+
+// OrderClient talks to the order service over its REST API. The endpoints
+// it expects are documented in the admin service README.
+type OrderClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOrderClient creates a client for the order service at baseURL
+func NewOrderClient(baseURL string, timeout time.Duration) *OrderClient {
+	return &OrderClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout, Transport: logging.Transport(nil)},
+	}
+}
+
+// Sales returns order totals for the given time window
+func (c *OrderClient) Sales(ctx context.Context, from, to time.Time) (*domain.SalesSummary, error) {
+	query := url.Values{
+		"from": {from.UTC().Format(time.RFC3339)},
+		"to":   {to.UTC().Format(time.RFC3339)},
+	}
+
+	var summary domain.SalesSummary
+	err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/v1/orders/summary?"+query.Encode(), nil, &summary, "order service")
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Search returns orders matching query by order ID or customer email
+func (c *OrderClient) Search(ctx context.Context, query string, limit int) ([]*domain.OrderSummary, error) {
+	params := url.Values{
+		"q":         {query},
+		"page_size": {strconv.Itoa(limit)},
+	}
+
+	var resp struct {
+		Orders []*domain.OrderSummary `json:"orders"`
+	}
+	err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/v1/orders?"+params.Encode(), nil, &resp, "order service")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Orders, nil
+}
@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	sdk "github.com/bekbull/online-shop/pkg/clients"
+	"github.com/bekbull/online-shop/pkg/clients/product"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProductClient talks to product-service over gRPC through its SDK
+type ProductClient struct {
+	conn   *grpc.ClientConn
+	client product.Client
+}
+
+// NewProductClient creates a client for product-service at addr that calls it
+// with options. Connections are plaintext unless opts carry transport
+// credentials, e.g. from pkg/mtls.
+func NewProductClient(addr string, options sdk.Options, opts ...grpc.DialOption) (*ProductClient, error) {
+	conn, err := grpc.NewClient(addr, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product client: %w", err)
+	}
+
+	return &ProductClient{
+		conn:   conn,
+		client: product.New(conn, options),
+	}, nil
+}
+
+// Search returns products matching the full-text query
+func (c *ProductClient) Search(ctx context.Context, query string, limit int) ([]*domain.ProductSummary, error) {
+	resp, err := c.client.ListProducts(ctx, &pb.ListProductsRequest{
+		Page:       1,
+		PageSize:   int32(limit),
+		SearchTerm: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("product service error: %w", err)
+	}
+
+	products := make([]*domain.ProductSummary, len(resp.Products))
+	for i, p := range resp.Products {
+		products[i] = toProductSummary(p)
+	}
+	return products, nil
+}
+
+// LowStock returns active products whose available stock is at or below
+// threshold, lowest first. It walks the whole catalog, which is acceptable
+// for back-office use but should move to a dedicated query if the catalog
+// grows large.
+func (c *ProductClient) LowStock(ctx context.Context, threshold, limit int) ([]*domain.ProductSummary, error) {
+	var low []*domain.ProductSummary
+	err := c.client.StreamProducts(ctx, false, func(p *pb.Product) error {
+		if summary := toProductSummary(p); summary.Available <= threshold {
+			low = append(low, summary)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("product service error: %w", err)
+	}
+
+	sort.SliceStable(low, func(i, j int) bool {
+		return low[i].Available < low[j].Available
+	})
+	if len(low) > limit {
+		low = low[:limit]
+	}
+	return low, nil
+}
+
+// SetActive activates or deactivates a product
+func (c *ProductClient) SetActive(ctx context.Context, productID string, active bool) error {
+	_, err := c.client.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Id:     productID,
+		Active: proto.Bool(active),
+	})
+	if err != nil {
+		return fmt.Errorf("product service error: %w", err)
+	}
+	return nil
+}
+
+// SetFeatured features a product until the given time, or without expiry
+// if until is nil, or unfeatures it
+func (c *ProductClient) SetFeatured(ctx context.Context, productID string, featured bool, until *time.Time) error {
+	req := &pb.SetFeaturedRequest{Id: productID, Featured: featured}
+	if until != nil {
+		req.FeaturedUntil = until.Unix()
+	}
+	if _, err := c.client.SetFeatured(ctx, req); err != nil {
+		return fmt.Errorf("product service error: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying connection
+func (c *ProductClient) Close() error {
+	return c.conn.Close()
+}
+
+func toProductSummary(p *pb.Product) *domain.ProductSummary {
+	summary := &domain.ProductSummary{
+		ID:       p.Id,
+		Name:     p.Name,
+		Category: p.Category,
+		Price:    p.Price,
+		Active:   p.Active,
+	}
+	if p.Inventory != nil {
+		summary.SKU = p.Inventory.Sku
+		summary.Quantity = int(p.Inventory.Quantity)
+		summary.Reserved = int(p.Inventory.Reserved)
+		summary.Available = summary.Quantity - summary.Reserved
+	}
+	return summary
+}
@@ -0,0 +1,136 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+)
+
+// UserClient talks to the user service over its REST API
+type UserClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewUserClient creates a client for the user service at baseURL
+func NewUserClient(baseURL string, timeout time.Duration) *UserClient {
+	return &UserClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout, Transport: logging.Transport(nil)},
+	}
+}
+
+// GetUser returns a single user
+func (c *UserClient) GetUser(ctx context.Context, id string) (*domain.UserSummary, error) {
+	var user domain.UserSummary
+	if err := c.do(ctx, http.MethodGet, "/v1/users/"+url.PathEscape(id), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RecentUsers returns the most recently registered users
+func (c *UserClient) RecentUsers(ctx context.Context, limit int) ([]*domain.UserSummary, error) {
+	return c.list(ctx, url.Values{"page_size": {strconv.Itoa(limit)}})
+}
+
+// SearchByEmail returns users whose email matches query
+func (c *UserClient) SearchByEmail(ctx context.Context, email string, limit int) ([]*domain.UserSummary, error) {
+	return c.list(ctx, url.Values{
+		"email":     {email},
+		"page_size": {strconv.Itoa(limit)},
+	})
+}
+
+// SetRoles replaces the roles of a user
+func (c *UserClient) SetRoles(ctx context.Context, id string, roles []string) error {
+	body := map[string]interface{}{"roles": roles}
+	return c.do(ctx, http.MethodPut, "/v1/users/"+url.PathEscape(id), body, nil)
+}
+
+// DeleteUser removes a user
+func (c *UserClient) DeleteUser(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/users/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *UserClient) list(ctx context.Context, query url.Values) ([]*domain.UserSummary, error) {
+	var resp struct {
+		Users []*domain.UserSummary `json:"users"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/users?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
+func (c *UserClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	return doJSON(ctx, c.httpClient, method, c.baseURL+path, body, out, "user service")
+}
+
+// doJSON sends a JSON request and decodes a JSON response into out
+func doJSON(ctx context.Context, client *http.Client, method, target string, body, out interface{}, upstream string) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return apperrors.Wrapf(err, apperrors.Unavailable, "%s error", upstream)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return apperrors.Newf(apperrors.NotFound, "%s: not found", upstream)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return apperrors.Newf(upstreamKind(resp.StatusCode), "%s returned %d: %s", upstream, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", upstream, err)
+	}
+	return nil
+}
+
+// upstreamKind is the kind of an error status returned by an upstream.
+// Rejected requests keep their meaning; upstream failures are Unavailable.
+func upstreamKind(status int) apperrors.Kind {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return apperrors.Invalid
+	case http.StatusForbidden:
+		return apperrors.Forbidden
+	case http.StatusConflict:
+		return apperrors.Conflict
+	default:
+		return apperrors.Unavailable
+	}
+}
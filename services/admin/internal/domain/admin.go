@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain errors returned by the service
+var (
+	ErrUnauthenticated = apperrors.New(apperrors.Unauthenticated, "unknown user")
+	ErrForbidden       = apperrors.New(apperrors.Forbidden, "permission denied")
+	ErrUnavailable     = apperrors.New(apperrors.Unavailable, "upstream service unavailable")
+)
+
+// Permission is a single capability checked before an admin action
+type Permission string
+
+// Permissions known to the admin API
+const (
+	PermDashboardRead  Permission = "dashboard:read"
+	PermProductsRead   Permission = "products:read"
+	PermProductsWrite  Permission = "products:write"
+	PermInventoryWrite Permission = "inventory:write"
+	PermUsersRead      Permission = "users:read"
+	PermUsersWrite     Permission = "users:write"
+	PermOrdersRead     Permission = "orders:read"
+	PermAuditRead      Permission = "audit:read"
+)
+
+// RolePermissions maps user roles to the permissions they grant.
+// Roles not listed here grant nothing.
+var RolePermissions = map[string][]Permission{
+	"admin": {
+		PermDashboardRead, PermProductsRead, PermProductsWrite, PermInventoryWrite,
+		PermUsersRead, PermUsersWrite, PermOrdersRead, PermAuditRead,
+	},
+	"merchandiser": {
+		PermDashboardRead, PermProductsRead, PermProductsWrite, PermInventoryWrite,
+	},
+	"support": {
+		PermDashboardRead, PermProductsRead, PermUsersRead, PermOrdersRead,
+	},
+	"auditor": {
+		PermDashboardRead, PermAuditRead,
+	},
+}
+
+// Actor is the back-office user performing a request
+type Actor struct {
+	UserID string
+	Email  string
+	Roles  []string
+}
+
+// Can reports whether any of the actor's roles grants the permission
+func (a *Actor) Can(permission Permission) bool {
+	for _, role := range a.Roles {
+		for _, p := range RolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Audit results
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+// AuditInProgress is the error of an action's entry while it runs. Such
+// entries are not chained yet; the outcome replaces them.
+const AuditInProgress = "in progress"
+
+// AuditEntry records a single admin action. Once its outcome is recorded
+// the entry is sealed: it is given the next Seq and hash-chained to the
+// entry before it (see pkg/audit).
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ActorID    string             `bson:"actor_id" json:"actor_id"`
+	ActorEmail string             `bson:"actor_email" json:"actor_email"`
+	Action     string             `bson:"action" json:"action"`
+	TargetType string             `bson:"target_type" json:"target_type"`
+	TargetID   string             `bson:"target_id" json:"target_id"`
+	Params     map[string]string  `bson:"params,omitempty" json:"params,omitempty"`
+	Result     string             `bson:"result" json:"result"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	RequestID  string             `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	Seq        int64              `bson:"seq,omitempty" json:"seq,omitempty"`
+	// RecordedAt is when the entry was sealed; it never decreases with Seq
+	RecordedAt *time.Time `bson:"recorded_at,omitempty" json:"recorded_at,omitempty"`
+	PrevHash   string     `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash       string     `bson:"hash,omitempty" json:"hash,omitempty"`
+}
+
+// Sealed reports whether the entry records an outcome, and so is chained
+func (e *AuditEntry) Sealed() bool {
+	return e.Error != AuditInProgress
+}
+
+// AuditFilter defines the parameters for listing audit entries
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Limit      int
+}
+
+// AuditRepository stores the audit trail. Record inserts the entry or
+// replaces an existing entry with the same ID, sealing it unless it is in
+// progress.
+type AuditRepository interface {
+	Record(ctx context.Context, entry *AuditEntry) error
+	List(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error)
+	// Export calls fn with the sealed entries recorded from from up to to,
+	// in Seq order
+	Export(ctx context.Context, from, to time.Time, fn func(*AuditEntry) error) error
+	// LastBefore returns the Seq of the last entry sealed before t, or 0
+	LastBefore(ctx context.Context, t time.Time) (int64, error)
+	// ExportThrough calls fn with the sealed entries up to and including
+	// Seq last, in Seq order
+	ExportThrough(ctx context.Context, last int64, fn func(*AuditEntry) error) error
+	// DeleteThrough deletes the sealed entries up to and including Seq last
+	DeleteThrough(ctx context.Context, last int64) (int64, error)
+}
+
+// ProductSummary is the subset of product data shown in back-office screens
+type ProductSummary struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku"`
+	Category  string  `json:"category"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Reserved  int     `json:"reserved"`
+	Available int     `json:"available"`
+	Active    bool    `json:"active"`
+}
+
+// UserSummary is the subset of user data shown in back-office screens
+type UserSummary struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Roles     []string  `json:"roles"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderSummary is the subset of order data shown in back-office screens
+type OrderSummary struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Status    string    `json:"status"`
+	Total     float64   `json:"total"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SalesSummary aggregates orders placed in a time window
+type SalesSummary struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	OrderCount int       `json:"order_count"`
+	Revenue    float64   `json:"revenue"`
+	Currency   string    `json:"currency"`
+}
+
+// Dashboard is the landing page of the back office. Sections whose
+// upstream service failed are nil and listed in Unavailable.
+type Dashboard struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Sales       *SalesSummary     `json:"sales"`
+	LowStock    []*ProductSummary `json:"low_stock"`
+	NewUsers    []*UserSummary    `json:"new_users"`
+	Unavailable []string          `json:"unavailable,omitempty"`
+}
+
+// SearchResults holds matches from every service the actor may read
+type SearchResults struct {
+	Query       string            `json:"query"`
+	Products    []*ProductSummary `json:"products,omitempty"`
+	Users       []*UserSummary    `json:"users,omitempty"`
+	Orders      []*OrderSummary   `json:"orders,omitempty"`
+	Unavailable []string          `json:"unavailable,omitempty"`
+}
@@ -0,0 +1,239 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/services/admin/config"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sealAttempts bounds the retries of sealing an entry when another
+// instance took the same Seq
+const sealAttempts = 5
+
+// AuditRepository implements domain.AuditRepository using MongoDB
+type AuditRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+	// sealing serializes seals within the instance; the unique seq index
+	// catches races between instances
+	sealing sync.Mutex
+}
+
+var _ domain.AuditRepository = (*AuditRepository)(nil)
+
+// New creates a new MongoDB audit repository
+func New(client *mongo.Client, cfg *config.MongoDBConfig) *AuditRepository {
+	return &AuditRepository{
+		collection: client.Database(cfg.Database).Collection(cfg.AuditCollection),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes used by audit queries
+func (r *AuditRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "recorded_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+	return nil
+}
+
+// Record inserts or replaces an audit entry. An entry with an outcome is
+// sealed: chained to the last sealed entry and given the next Seq.
+func (r *AuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	// Hashed as read back: BSON dates keep milliseconds
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Millisecond)
+	if !entry.Sealed() {
+		entry.Seq, entry.RecordedAt, entry.PrevHash, entry.Hash = 0, nil, "", ""
+		return r.replace(ctx, entry)
+	}
+
+	r.sealing.Lock()
+	defer r.sealing.Unlock()
+	for attempt := 1; ; attempt++ {
+		err := r.seal(ctx, entry)
+		if err == nil || !mongo.IsDuplicateKeyError(err) || attempt == sealAttempts {
+			return err
+		}
+	}
+}
+
+// seal chains entry to the last sealed entry and stores it
+func (r *AuditRepository) seal(ctx context.Context, entry *domain.AuditEntry) error {
+	var head domain.AuditEntry
+	err := r.collection.FindOne(ctx,
+		bson.M{"seq": bson.M{"$exists": true}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})).Decode(&head)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+
+	recordedAt := time.Now().UTC().Truncate(time.Millisecond)
+	if head.RecordedAt != nil && recordedAt.Before(*head.RecordedAt) {
+		recordedAt = head.RecordedAt.UTC()
+	}
+	entry.Seq, entry.RecordedAt, entry.PrevHash, entry.Hash = head.Seq+1, &recordedAt, head.Hash, ""
+	if entry.Hash, err = audit.Hash(entry); err != nil {
+		return err
+	}
+	return r.replace(ctx, entry)
+}
+
+// replace upserts an entry by ID
+func (r *AuditRepository) replace(ctx context.Context, entry *domain.AuditEntry) error {
+	_, err := r.collection.ReplaceOne(ctx,
+		bson.M{"_id": entry.ID},
+		entry,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns audit entries matching filter, newest first
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["target_type"] = filter.TargetType
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if !filter.Since.IsZero() {
+		query["created_at"] = bson.M{"$gte": filter.Since}
+	}
+
+	cursor, err := r.collection.Find(ctx, query,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetLimit(int64(filter.Limit)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*domain.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// Export calls fn with the sealed entries recorded from from up to to, in
+// Seq order
+func (r *AuditRepository) Export(ctx context.Context, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	return r.scan(ctx, bson.M{
+		"seq":         bson.M{"$exists": true},
+		"recorded_at": bson.M{"$gte": from, "$lt": to},
+	}, fn)
+}
+
+// LastBefore returns the Seq of the last entry sealed before t, or 0. The
+// newest entry is never returned: it stays as the head the chain continues
+// from.
+func (r *AuditRepository) LastBefore(ctx context.Context, t time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	latest := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+	var head domain.AuditEntry
+	err := r.collection.FindOne(ctx, bson.M{"seq": bson.M{"$exists": true}}, latest).Decode(&head)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+
+	var last domain.AuditEntry
+	err = r.collection.FindOne(ctx, bson.M{
+		"seq":         bson.M{"$lt": head.Seq},
+		"recorded_at": bson.M{"$lt": t},
+	}, latest).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired audit entries: %w", err)
+	}
+	return last.Seq, nil
+}
+
+// ExportThrough calls fn with the sealed entries up to and including Seq
+// last, in Seq order
+func (r *AuditRepository) ExportThrough(ctx context.Context, last int64, fn func(*domain.AuditEntry) error) error {
+	return r.scan(ctx, bson.M{"seq": bson.M{"$exists": true, "$lte": last}}, fn)
+}
+
+// DeleteThrough deletes the sealed entries up to and including Seq last
+func (r *AuditRepository) DeleteThrough(ctx context.Context, last int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"seq": bson.M{"$exists": true, "$lte": last}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit entries: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// scan calls fn with each entry matching query, in Seq order. Exports can
+// be large, so no timeout applies beyond ctx.
+func (r *AuditRepository) scan(ctx context.Context, query bson.M, fn func(*domain.AuditEntry) error) error {
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry domain.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		if entry.RecordedAt != nil {
+			recordedAt := entry.RecordedAt.UTC()
+			entry.RecordedAt = &recordedAt
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("error iterating audit entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/pkg/objectstore"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductClient reads and updates the product catalog
+type ProductClient interface {
+	Search(ctx context.Context, query string, limit int) ([]*domain.ProductSummary, error)
+	LowStock(ctx context.Context, threshold, limit int) ([]*domain.ProductSummary, error)
+	SetActive(ctx context.Context, productID string, active bool) error
+	// SetFeatured features a product until the given time, or without
+	// expiry if until is nil, or unfeatures it
+	SetFeatured(ctx context.Context, productID string, featured bool, until *time.Time) error
+}
+
+// InventoryClient changes stock levels in the inventory service
+type InventoryClient interface {
+	Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error
+}
+
+// UserClient reads and updates user accounts
+type UserClient interface {
+	GetUser(ctx context.Context, id string) (*domain.UserSummary, error)
+	RecentUsers(ctx context.Context, limit int) ([]*domain.UserSummary, error)
+	SearchByEmail(ctx context.Context, email string, limit int) ([]*domain.UserSummary, error)
+	SetRoles(ctx context.Context, id string, roles []string) error
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// OrderClient reads order data
+type OrderClient interface {
+	Sales(ctx context.Context, from, to time.Time) (*domain.SalesSummary, error)
+	Search(ctx context.Context, query string, limit int) ([]*domain.OrderSummary, error)
+}
+
+// Options tunes dashboard and search results
+type Options struct {
+	LowStockThreshold int
+	LowStockLimit     int
+	NewUsersLimit     int
+	SalesWindow       time.Duration
+	SearchLimit       int
+}
+
+// AdminService composes data from the other services for back-office screens
+type AdminService struct {
+	products  ProductClient
+	inventory InventoryClient
+	users     UserClient
+	orders    OrderClient
+	audit     domain.AuditRepository
+	opts      Options
+	logger    *slog.Logger
+
+	// Audit entries older than retention are archived to archive
+	retention     time.Duration
+	archive       objectstore.Store
+	archivePrefix string
+}
+
+// New creates a new AdminService. orders may be nil when no order service is
+// deployed; order data is then reported as unavailable.
+func New(products ProductClient, inventory InventoryClient, users UserClient, orders OrderClient, audit domain.AuditRepository, opts Options, logger *slog.Logger) *AdminService {
+	return &AdminService{
+		products:  products,
+		inventory: inventory,
+		users:     users,
+		orders:    orders,
+		audit:     audit,
+		opts:      opts,
+		logger:    logger,
+	}
+}
+
+// Authenticate resolves the actor behind a request from the user service
+func (s *AdminService) Authenticate(ctx context.Context, userID string) (*domain.Actor, error) {
+	if userID == "" {
+		return nil, domain.ErrUnauthenticated
+	}
+
+	user, err := s.users.GetUser(ctx, userID)
+	if apperrors.Is(err, apperrors.NotFound) {
+		return nil, domain.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Unavailable, "failed to resolve actor")
+	}
+
+	return &domain.Actor{UserID: user.ID, Email: user.Email, Roles: user.Roles}, nil
+}
+
+// Dashboard builds the back-office landing page. Upstream calls run
+// concurrently; a failing upstream blanks its section instead of failing
+// the whole dashboard.
+func (s *AdminService) Dashboard(ctx context.Context, actor *domain.Actor) (*domain.Dashboard, error) {
+	if !actor.Can(domain.PermDashboardRead) {
+		return nil, domain.ErrForbidden
+	}
+
+	dashboard := &domain.Dashboard{GeneratedAt: time.Now()}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	unavailable := func(section string, err error) {
+		s.logger.Warn("Dashboard section unavailable", "section", section, "error", err)
+		mu.Lock()
+		dashboard.Unavailable = append(dashboard.Unavailable, section)
+		mu.Unlock()
+	}
+
+	if actor.Can(domain.PermOrdersRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.orders == nil {
+				unavailable("sales", domain.ErrUnavailable)
+				return
+			}
+			to := time.Now()
+			sales, err := s.orders.Sales(ctx, to.Add(-s.opts.SalesWindow), to)
+			if err != nil {
+				unavailable("sales", err)
+				return
+			}
+			dashboard.Sales = sales
+		}()
+	}
+
+	if actor.Can(domain.PermProductsRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lowStock, err := s.products.LowStock(ctx, s.opts.LowStockThreshold, s.opts.LowStockLimit)
+			if err != nil {
+				unavailable("low_stock", err)
+				return
+			}
+			dashboard.LowStock = lowStock
+		}()
+	}
+
+	if actor.Can(domain.PermUsersRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users, err := s.users.RecentUsers(ctx, s.opts.NewUsersLimit)
+			if err != nil {
+				unavailable("new_users", err)
+				return
+			}
+			dashboard.NewUsers = users
+		}()
+	}
+
+	wg.Wait()
+	return dashboard, nil
+}
+
+// Search looks up products, users and orders matching query, limited to
+// what the actor is allowed to read
+func (s *AdminService) Search(ctx context.Context, actor *domain.Actor, query string) (*domain.SearchResults, error) {
+	if query == "" {
+		return nil, apperrors.New(apperrors.Invalid, "search query is required")
+	}
+
+	results := &domain.SearchResults{Query: query}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	unavailable := func(section string, err error) {
+		s.logger.Warn("Search section unavailable", "section", section, "error", err)
+		mu.Lock()
+		results.Unavailable = append(results.Unavailable, section)
+		mu.Unlock()
+	}
+
+	if actor.Can(domain.PermProductsRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			products, err := s.products.Search(ctx, query, s.opts.SearchLimit)
+			if err != nil {
+				unavailable("products", err)
+				return
+			}
+			results.Products = products
+		}()
+	}
+
+	if actor.Can(domain.PermUsersRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users, err := s.users.SearchByEmail(ctx, query, s.opts.SearchLimit)
+			if err != nil {
+				unavailable("users", err)
+				return
+			}
+			results.Users = users
+		}()
+	}
+
+	if actor.Can(domain.PermOrdersRead) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.orders == nil {
+				unavailable("orders", domain.ErrUnavailable)
+				return
+			}
+			orders, err := s.orders.Search(ctx, query, s.opts.SearchLimit)
+			if err != nil {
+				unavailable("orders", err)
+				return
+			}
+			results.Orders = orders
+		}()
+	}
+
+	wg.Wait()
+	return results, nil
+}
+
+// SetProductActive activates or deactivates a product
+func (s *AdminService) SetProductActive(ctx context.Context, actor *domain.Actor, productID string, active bool) error {
+	entry := &domain.AuditEntry{
+		Action:     "product.set_active",
+		TargetType: "product",
+		TargetID:   productID,
+		Params:     map[string]string{"active": strconv.FormatBool(active)},
+	}
+	return s.perform(ctx, actor, domain.PermProductsWrite, entry, func() error {
+		return s.products.SetActive(ctx, productID, active)
+	})
+}
+
+// SetProductFeatured features a product on the storefront until the given
+// time, or without expiry if until is nil, or unfeatures it
+func (s *AdminService) SetProductFeatured(ctx context.Context, actor *domain.Actor, productID string, featured bool, until *time.Time) error {
+	if !featured && until != nil {
+		return apperrors.New(apperrors.Invalid, "until is only allowed when featuring a product")
+	}
+
+	entry := &domain.AuditEntry{
+		Action:     "product.set_featured",
+		TargetType: "product",
+		TargetID:   productID,
+		Params:     map[string]string{"featured": strconv.FormatBool(featured)},
+	}
+	if until != nil {
+		entry.Params["until"] = until.UTC().Format(time.RFC3339)
+	}
+	return s.perform(ctx, actor, domain.PermProductsWrite, entry, func() error {
+		return s.products.SetFeatured(ctx, productID, featured, until)
+	})
+}
+
+// AdjustStock changes on-hand stock of a product in a warehouse. The audit
+// entry ID doubles as the inventory operation ID.
+func (s *AdminService) AdjustStock(ctx context.Context, actor *domain.Actor, productID, warehouseID string, quantityChange int, reason string) error {
+	if quantityChange == 0 {
+		return apperrors.New(apperrors.Invalid, "quantity change must not be zero")
+	}
+	if reason == "" {
+		return apperrors.New(apperrors.Invalid, "reason is required for stock adjustments")
+	}
+
+	entry := &domain.AuditEntry{
+		Action:     "inventory.adjust",
+		TargetType: "product",
+		TargetID:   productID,
+		Params: map[string]string{
+			"warehouse_id":    warehouseID,
+			"quantity_change": strconv.Itoa(quantityChange),
+			"reason":          reason,
+		},
+	}
+	return s.perform(ctx, actor, domain.PermInventoryWrite, entry, func() error {
+		return s.inventory.Adjust(ctx, "admin-"+entry.ID.Hex(), productID, warehouseID, quantityChange, reason)
+	})
+}
+
+// SetUserRoles replaces the roles of a user
+func (s *AdminService) SetUserRoles(ctx context.Context, actor *domain.Actor, userID string, roles []string) error {
+	for _, role := range roles {
+		if _, ok := domain.RolePermissions[role]; !ok && role != "user" {
+			return apperrors.Newf(apperrors.Invalid, "unknown role %q", role)
+		}
+	}
+
+	entry := &domain.AuditEntry{
+		Action:     "user.set_roles",
+		TargetType: "user",
+		TargetID:   userID,
+		Params:     map[string]string{"roles": fmt.Sprint(roles)},
+	}
+	return s.perform(ctx, actor, domain.PermUsersWrite, entry, func() error {
+		// Prevent admins from locking themselves out
+		if userID == actor.UserID {
+			return apperrors.New(apperrors.Invalid, "cannot change your own roles")
+		}
+		return s.users.SetRoles(ctx, userID, roles)
+	})
+}
+
+// DeleteUser removes a user account
+func (s *AdminService) DeleteUser(ctx context.Context, actor *domain.Actor, userID string) error {
+	entry := &domain.AuditEntry{
+		Action:     "user.delete",
+		TargetType: "user",
+		TargetID:   userID,
+	}
+	return s.perform(ctx, actor, domain.PermUsersWrite, entry, func() error {
+		if userID == actor.UserID {
+			return apperrors.New(apperrors.Invalid, "cannot delete your own account")
+		}
+		return s.users.DeleteUser(ctx, userID)
+	})
+}
+
+// ListAudit returns audit entries matching filter, newest first
+func (s *AdminService) ListAudit(ctx context.Context, actor *domain.Actor, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if !actor.Can(domain.PermAuditRead) {
+		return nil, domain.ErrForbidden
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	entries, err := s.audit.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return entries, nil
+}
+
+// ExportAudit calls fn with the sealed audit entries recorded from from up
+// to to, in chain order
+func (s *AdminService) ExportAudit(ctx context.Context, actor *domain.Actor, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	if !actor.Can(domain.PermAuditRead) {
+		return domain.ErrForbidden
+	}
+	if !from.Before(to) {
+		return apperrors.New(apperrors.Invalid, "from must be before to")
+	}
+	return s.audit.Export(ctx, from, to, fn)
+}
+
+// SetAuditRetention archives audit entries older than retention to store,
+// under prefix, and then deletes them
+func (s *AdminService) SetAuditRetention(retention time.Duration, store objectstore.Store, prefix string) {
+	s.retention, s.archive, s.archivePrefix = retention, store, prefix
+}
+
+// ApplyAuditRetention archives the sealed audit entries older than the
+// retention period to object storage, as one gzipped NDJSON object, and
+// deletes them. It returns how many were deleted; with no retention it
+// does nothing.
+func (s *AdminService) ApplyAuditRetention(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	last, err := s.audit.LastBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil || last == 0 {
+		return 0, err
+	}
+
+	archive := audit.NewArchive()
+	var first int64
+	err = s.audit.ExportThrough(ctx, last, func(entry *domain.AuditEntry) error {
+		if first == 0 {
+			first = entry.Seq
+		}
+		return archive.Write(entry)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read expired audit entries: %w", err)
+	}
+	if archive.Len() == 0 {
+		return 0, nil
+	}
+	key, err := archive.Upload(ctx, s.archive, s.archivePrefix, "admin", first, last)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.audit.DeleteThrough(ctx, last)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("Archived audit entries", "key", key, "entries", deleted)
+	return deleted, nil
+}
+
+// Helper functions
+
+// perform checks the permission, runs the action and records the outcome.
+// Denied attempts are audited too. If the audit record cannot be written
+// the action is not attempted, so no admin change goes unrecorded.
+func (s *AdminService) perform(ctx context.Context, actor *domain.Actor, permission domain.Permission, entry *domain.AuditEntry, action func() error) error {
+	entry.ID = primitive.NewObjectID()
+	entry.ActorID = actor.UserID
+	entry.ActorEmail = actor.Email
+	entry.RequestID = middleware.GetReqID(ctx)
+	entry.CreatedAt = time.Now()
+
+	if !actor.Can(permission) {
+		entry.Result = domain.AuditDenied
+		s.record(ctx, entry)
+		return domain.ErrForbidden
+	}
+
+	// Record the intent first; the outcome overwrites it below
+	entry.Result = domain.AuditFailure
+	entry.Error = domain.AuditInProgress
+	if err := s.audit.Record(ctx, entry); err != nil {
+		s.logger.Error("Failed to write audit entry", "action", entry.Action, "error", err)
+		return apperrors.Wrap(err, apperrors.Unavailable, "audit trail unavailable")
+	}
+
+	err := action()
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Result = domain.AuditSuccess
+		entry.Error = ""
+	}
+	s.record(ctx, entry)
+
+	s.logger.Info("Admin action",
+		"action", entry.Action,
+		"actor", actor.UserID,
+		"target", entry.TargetID,
+		"result", entry.Result)
+	return err
+}
+
+func (s *AdminService) record(ctx context.Context, entry *domain.AuditEntry) {
+	if err := s.audit.Record(ctx, entry); err != nil {
+		s.logger.Error("Failed to write audit entry", "action", entry.Action, "error", err)
+	}
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/services/admin/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mocks for the upstream clients and audit repository
+
+type MockProductClient struct {
+	mock.Mock
+}
+
+func (m *MockProductClient) Search(ctx context.Context, query string, limit int) ([]*domain.ProductSummary, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ProductSummary), args.Error(1)
+}
+
+func (m *MockProductClient) LowStock(ctx context.Context, threshold, limit int) ([]*domain.ProductSummary, error) {
+	args := m.Called(threshold, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ProductSummary), args.Error(1)
+}
+
+func (m *MockProductClient) SetActive(ctx context.Context, productID string, active bool) error {
+	return m.Called(productID, active).Error(0)
+}
+
+func (m *MockProductClient) SetFeatured(ctx context.Context, productID string, featured bool, until *time.Time) error {
+	return m.Called(productID, featured, until).Error(0)
+}
+
+type MockInventoryClient struct {
+	mock.Mock
+}
+
+func (m *MockInventoryClient) Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error {
+	return m.Called(operationID, productID, warehouseID, quantityChange, reason).Error(0)
+}
+
+type MockUserClient struct {
+	mock.Mock
+}
+
+func (m *MockUserClient) GetUser(ctx context.Context, id string) (*domain.UserSummary, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserSummary), args.Error(1)
+}
+
+func (m *MockUserClient) RecentUsers(ctx context.Context, limit int) ([]*domain.UserSummary, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserSummary), args.Error(1)
+}
+
+func (m *MockUserClient) SearchByEmail(ctx context.Context, email string, limit int) ([]*domain.UserSummary, error) {
+	args := m.Called(email, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserSummary), args.Error(1)
+}
+
+func (m *MockUserClient) SetRoles(ctx context.Context, id string, roles []string) error {
+	return m.Called(id, roles).Error(0)
+}
+
+func (m *MockUserClient) DeleteUser(ctx context.Context, id string) error {
+	return m.Called(id).Error(0)
+}
+
+// memoryAudit keeps the latest version of each audit entry, sealing them
+// as the MongoDB repository does
+type memoryAudit struct {
+	entries map[string]domain.AuditEntry
+	head    domain.AuditEntry
+	err     error
+}
+
+func (a *memoryAudit) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	if a.err != nil {
+		return a.err
+	}
+	if entry.Sealed() {
+		recordedAt := entry.CreatedAt
+		entry.Seq, entry.RecordedAt, entry.PrevHash, entry.Hash = a.head.Seq+1, &recordedAt, a.head.Hash, ""
+		hash, err := audit.Hash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		a.head = *entry
+	}
+	a.entries[entry.ID.Hex()] = *entry
+	return nil
+}
+
+// sealed returns the sealed entries matching keep, in Seq order
+func (a *memoryAudit) sealed(keep func(domain.AuditEntry) bool) []domain.AuditEntry {
+	var entries []domain.AuditEntry
+	for _, e := range a.entries {
+		if e.Seq > 0 && keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Seq < entries[j].Seq })
+	return entries
+}
+
+func (a *memoryAudit) Export(ctx context.Context, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	for _, e := range a.sealed(func(e domain.AuditEntry) bool {
+		return !e.RecordedAt.Before(from) && e.RecordedAt.Before(to)
+	}) {
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *memoryAudit) LastBefore(ctx context.Context, t time.Time) (int64, error) {
+	var last int64
+	for _, e := range a.sealed(func(e domain.AuditEntry) bool { return e.Seq < a.head.Seq && e.RecordedAt.Before(t) }) {
+		last = e.Seq
+	}
+	return last, nil
+}
+
+func (a *memoryAudit) ExportThrough(ctx context.Context, last int64, fn func(*domain.AuditEntry) error) error {
+	for _, e := range a.sealed(func(e domain.AuditEntry) bool { return e.Seq <= last }) {
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *memoryAudit) DeleteThrough(ctx context.Context, last int64) (int64, error) {
+	var deleted int64
+	for _, e := range a.sealed(func(e domain.AuditEntry) bool { return e.Seq <= last }) {
+		delete(a.entries, e.ID.Hex())
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (a *memoryAudit) List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var entries []*domain.AuditEntry
+	for _, e := range a.entries {
+		e := e
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+func (a *memoryAudit) only(t *testing.T) domain.AuditEntry {
+	t.Helper()
+	if !assert.Len(t, a.entries, 1) {
+		t.FailNow()
+	}
+	for _, e := range a.entries {
+		return e
+	}
+	return domain.AuditEntry{}
+}
+
+type testDeps struct {
+	products  *MockProductClient
+	inventory *MockInventoryClient
+	users     *MockUserClient
+	audit     *memoryAudit
+}
+
+func newTestService() (*AdminService, *testDeps) {
+	deps := &testDeps{
+		products:  new(MockProductClient),
+		inventory: new(MockInventoryClient),
+		users:     new(MockUserClient),
+		audit:     &memoryAudit{entries: map[string]domain.AuditEntry{}},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := New(deps.products, deps.inventory, deps.users, nil, deps.audit, Options{
+		LowStockThreshold: 5,
+		LowStockLimit:     10,
+		NewUsersLimit:     3,
+		SalesWindow:       24 * time.Hour,
+		SearchLimit:       5,
+	}, logger)
+	return svc, deps
+}
+
+var (
+	adminActor        = &domain.Actor{UserID: "u-admin", Email: "admin@example.com", Roles: []string{"admin"}}
+	merchandiserActor = &domain.Actor{UserID: "u-merch", Email: "merch@example.com", Roles: []string{"merchandiser"}}
+)
+
+func TestAuthenticate(t *testing.T) {
+	svc, deps := newTestService()
+	deps.users.On("GetUser", "u1").Return(&domain.UserSummary{ID: "u1", Roles: []string{"support"}}, nil)
+	deps.users.On("GetUser", "gone").Return(nil, apperrors.New(apperrors.NotFound, "user service: not found"))
+	deps.users.On("GetUser", "u2").Return(nil, apperrors.New(apperrors.Unavailable, "user service returned 503"))
+
+	actor, err := svc.Authenticate(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"support"}, actor.Roles)
+
+	_, err = svc.Authenticate(context.Background(), "")
+	assert.True(t, apperrors.Is(err, apperrors.Unauthenticated))
+	_, err = svc.Authenticate(context.Background(), "gone")
+	assert.True(t, apperrors.Is(err, apperrors.Unauthenticated), "unknown users are not authenticated")
+	_, err = svc.Authenticate(context.Background(), "u2")
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
+
+func TestDashboard(t *testing.T) {
+	t.Run("admin without order service", func(t *testing.T) {
+		svc, deps := newTestService()
+		deps.products.On("LowStock", 5, 10).Return([]*domain.ProductSummary{{ID: "p1", Available: 2}}, nil)
+		deps.users.On("RecentUsers", 3).Return([]*domain.UserSummary{{ID: "u1"}}, nil)
+
+		dashboard, err := svc.Dashboard(context.Background(), adminActor)
+
+		assert.NoError(t, err)
+		assert.Nil(t, dashboard.Sales)
+		assert.Len(t, dashboard.LowStock, 1)
+		assert.Len(t, dashboard.NewUsers, 1)
+		assert.Equal(t, []string{"sales"}, dashboard.Unavailable)
+	})
+
+	t.Run("sections are limited by permissions", func(t *testing.T) {
+		svc, deps := newTestService()
+		deps.products.On("LowStock", 5, 10).Return(nil, errors.New("product service error: unavailable"))
+
+		dashboard, err := svc.Dashboard(context.Background(), merchandiserActor)
+
+		assert.NoError(t, err)
+		assert.Nil(t, dashboard.NewUsers)
+		assert.Equal(t, []string{"low_stock"}, dashboard.Unavailable)
+		deps.users.AssertNotCalled(t, "RecentUsers", mock.Anything)
+	})
+
+	t.Run("forbidden without role", func(t *testing.T) {
+		svc, _ := newTestService()
+
+		_, err := svc.Dashboard(context.Background(), &domain.Actor{UserID: "u1", Roles: []string{"user"}})
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+}
+
+func TestAdjustStock(t *testing.T) {
+	t.Run("success is audited", func(t *testing.T) {
+		svc, deps := newTestService()
+		deps.inventory.On("Adjust", mock.AnythingOfType("string"), "p1", "w1", 10, "restock").Return(nil)
+
+		err := svc.AdjustStock(context.Background(), merchandiserActor, "p1", "w1", 10, "restock")
+
+		assert.NoError(t, err)
+		entry := deps.audit.only(t)
+		assert.Equal(t, "inventory.adjust", entry.Action)
+		assert.Equal(t, domain.AuditSuccess, entry.Result)
+		assert.Empty(t, entry.Error)
+		assert.Equal(t, "u-merch", entry.ActorID)
+		// The operation ID is derived from the audit entry so retries stay traceable
+		deps.inventory.AssertCalled(t, "Adjust", "admin-"+entry.ID.Hex(), "p1", "w1", 10, "restock")
+	})
+
+	t.Run("upstream failure is audited", func(t *testing.T) {
+		svc, deps := newTestService()
+		deps.inventory.On("Adjust", mock.Anything, "p1", "w1", -3, "damaged").Return(errors.New("inventory service error: insufficient stock"))
+
+		err := svc.AdjustStock(context.Background(), adminActor, "p1", "w1", -3, "damaged")
+
+		assert.Error(t, err)
+		entry := deps.audit.only(t)
+		assert.Equal(t, domain.AuditFailure, entry.Result)
+		assert.Contains(t, entry.Error, "insufficient stock")
+	})
+
+	t.Run("denied is audited", func(t *testing.T) {
+		svc, deps := newTestService()
+		support := &domain.Actor{UserID: "u-support", Roles: []string{"support"}}
+
+		err := svc.AdjustStock(context.Background(), support, "p1", "w1", 1, "restock")
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.Equal(t, domain.AuditDenied, deps.audit.only(t).Result)
+		deps.inventory.AssertNotCalled(t, "Adjust", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("not attempted when audit is down", func(t *testing.T) {
+		svc, deps := newTestService()
+		deps.audit.err = errors.New("connection refused")
+
+		err := svc.AdjustStock(context.Background(), adminActor, "p1", "w1", 1, "restock")
+
+		assert.Error(t, err)
+		deps.inventory.AssertNotCalled(t, "Adjust", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetProductFeatured(t *testing.T) {
+	t.Run("feature with expiry is audited", func(t *testing.T) {
+		svc, deps := newTestService()
+		until := time.Date(2030, 1, 2, 15, 0, 0, 0, time.UTC)
+		deps.products.On("SetFeatured", "p1", true, &until).Return(nil)
+
+		err := svc.SetProductFeatured(context.Background(), merchandiserActor, "p1", true, &until)
+
+		assert.NoError(t, err)
+		entry := deps.audit.only(t)
+		assert.Equal(t, "product.set_featured", entry.Action)
+		assert.Equal(t, map[string]string{"featured": "true", "until": "2030-01-02T15:00:00Z"}, entry.Params)
+		assert.Equal(t, domain.AuditSuccess, entry.Result)
+	})
+
+	t.Run("unfeature takes no expiry", func(t *testing.T) {
+		svc, deps := newTestService()
+		until := time.Now().Add(time.Hour)
+
+		err := svc.SetProductFeatured(context.Background(), adminActor, "p1", false, &until)
+
+		assert.Error(t, err)
+		deps.products.AssertNotCalled(t, "SetFeatured", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("denied without product write", func(t *testing.T) {
+		svc, deps := newTestService()
+		support := &domain.Actor{UserID: "u-support", Roles: []string{"support"}}
+
+		err := svc.SetProductFeatured(context.Background(), support, "p1", true, nil)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.Equal(t, domain.AuditDenied, deps.audit.only(t).Result)
+	})
+}
+
+func TestSetUserRoles(t *testing.T) {
+	svc, deps := newTestService()
+
+	err := svc.SetUserRoles(context.Background(), adminActor, "u2", []string{"superuser"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+
+	err = svc.SetUserRoles(context.Background(), adminActor, adminActor.UserID, []string{"support"})
+	assert.Error(t, err)
+	deps.users.AssertNotCalled(t, "SetRoles", mock.Anything, mock.Anything)
+
+	deps.users.On("SetRoles", "u2", []string{"support"}).Return(nil)
+	err = svc.SetUserRoles(context.Background(), adminActor, "u2", []string{"support"})
+	assert.NoError(t, err)
+	deps.users.AssertExpectations(t)
+}
+
+func TestExportAudit(t *testing.T) {
+	svc, deps := newTestService()
+	deps.products.On("SetActive", "p1", false).Return(nil)
+	deps.products.On("SetActive", "p2", false).Return(errors.New("product service error: unavailable"))
+	support := &domain.Actor{UserID: "u-support", Roles: []string{"support"}}
+
+	from := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.SetProductActive(context.Background(), merchandiserActor, "p1", false))
+	require.Error(t, svc.SetProductActive(context.Background(), merchandiserActor, "p2", false))
+	require.Error(t, svc.SetProductActive(context.Background(), support, "p3", false))
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	err := svc.ExportAudit(context.Background(), adminActor, from, time.Now().Add(time.Minute), func(entry *domain.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+	require.NoError(t, err)
+	result, err := audit.Verify(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Entries, "outcomes and denials are chained")
+	assert.Empty(t, result.Anchor)
+
+	err = svc.ExportAudit(context.Background(), support, from, time.Now(), nil)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+}
+
+// memoryStore is an objectstore.Store keeping the objects put
+type memoryStore map[string][]byte
+
+func (m memoryStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	m[key] = body
+	return nil
+}
+
+func TestApplyAuditRetention(t *testing.T) {
+	svc, deps := newTestService()
+	now := time.Now().UTC()
+	for _, age := range []time.Duration{72 * time.Hour, 50 * time.Hour, time.Hour} {
+		require.NoError(t, deps.audit.Record(context.Background(), &domain.AuditEntry{
+			ID:        primitive.NewObjectID(),
+			Action:    "user.delete",
+			Result:    domain.AuditSuccess,
+			CreatedAt: now.Add(-age),
+		}))
+	}
+	store := memoryStore{}
+	svc.SetAuditRetention(48*time.Hour, store, "audit")
+
+	deleted, err := svc.ApplyAuditRetention(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	require.Len(t, deps.audit.entries, 1)
+
+	body, ok := store["audit/admin/00000000000000000001_00000000000000000002.ndjson.gz"]
+	require.True(t, ok, "got %v", store)
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	result, err := audit.Verify(gz)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Entries)
+	assert.Equal(t, deps.audit.only(t).PrevHash, result.Head, "the kept entries continue the archived chain")
+}
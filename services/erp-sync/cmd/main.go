@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/grpcclient"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"github.com/bekbull/online-shop/services/erp-sync/config"
+	restHandler "github.com/bekbull/online-shop/services/erp-sync/internal/api/rest"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/clients"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/feed"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/service"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	// Initialize logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	logger.Info("Starting ERP Sync Connector")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded", "supplier", cfg.Feed.Supplier)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to MongoDB
+	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", err)
+		}
+	}()
+	logger.Info("Connected to MongoDB")
+
+	mappingRepo := mongodb.NewMappingRepository(mongoClient, &cfg.MongoDB)
+	runRepo := mongodb.NewRunRepository(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(ctx, cfg.MongoDB.WriteTimeout)
+	if err := mappingRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	if err := runRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+
+	// Load TLS credentials; certificates are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	go creds.Watch(ctx)
+
+	// Upstream connections resolve through DNS or Consul and balance
+	// round-robin over healthy instances
+	clientFactory := grpcclient.New(cfg.Discovery, creds, logger)
+
+	inventoryClient, err := clients.NewInventoryClient(cfg.Inventory.Addr, cfg.Inventory.Timeout, clientFactory.DialOptions("inventory")...)
+	if err != nil {
+		logger.Error("Failed to create inventory client", "error", err)
+		os.Exit(1)
+	}
+	defer inventoryClient.Close()
+
+	syncService := service.New(mappingRepo, runRepo, inventoryClient, service.Options{
+		DefaultWarehouse: cfg.Feed.DefaultWarehouse,
+		Warehouses:       cfg.Feed.Warehouses,
+		MaxAdjustment:    cfg.Feed.MaxAdjustment,
+	}, logger)
+
+	// Pick up CSV drops
+	if cfg.Feed.InboxDir != "" {
+		inbox := feed.NewInbox(cfg.Feed.InboxDir, cfg.Feed.Supplier, cfg.Feed.SettleTime, logger)
+		go inbox.Run(ctx, cfg.Feed.PollInterval, func(ctx context.Context, batch *domain.Batch) error {
+			_, err := syncService.Apply(ctx, batch)
+			return err
+		})
+		logger.Info("Watching inbox", "dir", cfg.Feed.InboxDir)
+	}
+	if cfg.Feed.WebhookSecret == "" {
+		logger.Warn("ERP_WEBHOOK_SECRET not set, webhook disabled")
+	}
+
+	// Setup HTTP server
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(chimiddleware.RealIP)
+	router.Use(logging.AccessLog(logger, logging.DefaultAccessLog))
+	router.Use(middleware.Recover(logger))
+	router.Use(chimiddleware.Timeout(5 * time.Minute))
+
+	restHandler.NewSyncHandler(syncService, cfg.Feed.Supplier, cfg.Feed.WebhookSecret, logger).RegisterRoutes(router)
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: router,
+	}
+
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down ERP sync connector")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping the database to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/feed"
+	"github.com/go-chi/chi/v5"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook body
+const SignatureHeader = "X-ERP-Signature"
+
+// maxFeedSize bounds webhook bodies
+const maxFeedSize = 10 << 20
+
+// SyncService defines the interface for the sync service
+type SyncService interface {
+	Apply(ctx context.Context, batch *domain.Batch) (*domain.Run, error)
+	GetRun(ctx context.Context, id string) (*domain.Run, error)
+	ListRuns(ctx context.Context, supplier string, limit int) ([]*domain.Run, error)
+	PutMappings(ctx context.Context, supplier string, mappings []*domain.Mapping) error
+	ListMappings(ctx context.Context, supplier string) ([]*domain.Mapping, error)
+	DeleteMapping(ctx context.Context, supplier, supplierSKU string) error
+}
+
+// SyncHandler handles HTTP requests for the ERP connector
+type SyncHandler struct {
+	service         SyncService
+	defaultSupplier string
+	webhookSecret   string
+	logger          *slog.Logger
+}
+
+// NewSyncHandler creates a new sync handler. The webhook is only served
+// when webhookSecret is set.
+func NewSyncHandler(service SyncService, defaultSupplier, webhookSecret string, logger *slog.Logger) *SyncHandler {
+	return &SyncHandler{
+		service:         service,
+		defaultSupplier: defaultSupplier,
+		webhookSecret:   webhookSecret,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes registers the sync routes with the given router
+func (h *SyncHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/erp-sync", func(r chi.Router) {
+		if h.webhookSecret != "" {
+			r.Post("/webhook", h.Webhook)
+		}
+
+		r.Get("/runs", h.ListRuns)
+		r.Get("/runs/{id}", h.GetRun)
+
+		r.Get("/mappings/{supplier}", h.ListMappings)
+		r.Put("/mappings/{supplier}", h.PutMappings)
+		r.Delete("/mappings/{supplier}/{sku}", h.DeleteMapping)
+	})
+}
+
+// Webhook handles POST /v1/erp-sync/webhook
+func (h *SyncHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFeedSize))
+	if err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+	if !h.validSignature(body, r.Header.Get(SignatureHeader)) {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Unauthenticated, "invalid signature"))
+		return
+	}
+
+	batch, err := feed.ParseJSON(body, h.defaultSupplier)
+	if err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid feed"))
+		return
+	}
+
+	run, err := h.service.Apply(r.Context(), batch)
+	if err != nil {
+		h.logger.Error("Failed to apply webhook feed", "batchID", batch.ID, "error", err)
+		if !apperrors.Is(err, apperrors.Invalid) {
+			// The ERP should retry the same batch later
+			err = apperrors.Wrap(err, apperrors.Unavailable, "failed to apply feed")
+		}
+		apperrors.WriteHTTP(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, run)
+}
+
+// ListRuns handles GET /v1/erp-sync/runs
+func (h *SyncHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	runs, err := h.service.ListRuns(r.Context(), query.Get("supplier"), parseInt(query.Get("limit"), 50))
+	if err != nil {
+		h.writeError(w, r, "Failed to list runs", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+// GetRun handles GET /v1/erp-sync/runs/{id}
+func (h *SyncHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	run, err := h.service.GetRun(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, "Failed to get run", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, run)
+}
+
+// ListMappings handles GET /v1/erp-sync/mappings/{supplier}
+func (h *SyncHandler) ListMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.service.ListMappings(r.Context(), chi.URLParam(r, "supplier"))
+	if err != nil {
+		h.writeError(w, r, "Failed to list mappings", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"mappings": mappings})
+}
+
+// PutMappings handles PUT /v1/erp-sync/mappings/{supplier}
+func (h *SyncHandler) PutMappings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mappings []*domain.Mapping `json:"mappings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	if err := h.service.PutMappings(r.Context(), chi.URLParam(r, "supplier"), req.Mappings); err != nil {
+		h.writeError(w, r, "Failed to update mappings", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteMapping handles DELETE /v1/erp-sync/mappings/{supplier}/{sku}
+func (h *SyncHandler) DeleteMapping(w http.ResponseWriter, r *http.Request) {
+	err := h.service.DeleteMapping(r.Context(), chi.URLParam(r, "supplier"), chi.URLParam(r, "sku"))
+	if err != nil {
+		h.writeError(w, r, "Failed to delete mapping", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *SyncHandler) validSignature(body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(h.webhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+func (h *SyncHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	h.logger.Error(msg, "error", err)
+	apperrors.WriteHTTP(w, r, err)
+}
+
+func (h *SyncHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// Helper function to parse int parameters with default value
+func parseInt(value string, defaultValue int) int {
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return intValue
+}
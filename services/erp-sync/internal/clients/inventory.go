@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/inventory/v1"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// InventoryClient talks to the inventory service over gRPC
+type InventoryClient struct {
+	conn    *grpc.ClientConn
+	client  pb.InventoryServiceClient
+	timeout time.Duration
+}
+
+// NewInventoryClient creates a client for the inventory service at addr. Connections are
+// plaintext unless opts carry transport credentials, e.g. from pkg/mtls.
+func NewInventoryClient(addr string, timeout time.Duration, opts ...grpc.DialOption) (*InventoryClient, error) {
+	conn, err := grpc.NewClient(addr, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory client: %w", err)
+	}
+
+	return &InventoryClient{
+		conn:    conn,
+		client:  pb.NewInventoryServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// OnHand returns the on-hand stock of a product in a warehouse, zero if
+// the product has never been stocked there
+func (c *InventoryClient) OnHand(ctx context.Context, productID, warehouseID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.CheckStock(ctx, &pb.CheckStockRequest{
+		ProductId:   productID,
+		WarehouseId: warehouseID,
+	})
+	if err != nil {
+		return 0, wrapError(err)
+	}
+
+	onHand := 0
+	for _, level := range resp.Warehouses {
+		if level.WarehouseId == warehouseID {
+			onHand += int(level.OnHand)
+		}
+	}
+	return onHand, nil
+}
+
+// Adjust changes on-hand stock of a product in a warehouse
+func (c *InventoryClient) Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.Adjust(ctx, &pb.AdjustRequest{
+		OperationId:    operationID,
+		ProductId:      productID,
+		WarehouseId:    warehouseID,
+		QuantityChange: int32(quantityChange),
+		Reason:         reason,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	return nil
+}
+
+// Close closes the underlying connection
+func (c *InventoryClient) Close() error {
+	return c.conn.Close()
+}
+
+// wrapError separates refusals, which will not succeed on retry, from
+// transport and server failures
+func wrapError(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", domain.ErrRejected, status.Convert(err).Message())
+	}
+	return apperrors.Wrap(err, apperrors.Unavailable, "inventory service error")
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain errors returned by the repositories and service
+var (
+	ErrNotFound = apperrors.New(apperrors.NotFound, "not found")
+	// ErrRejected is returned by the inventory client when the inventory
+	// service refuses an operation, as opposed to being unreachable
+	ErrRejected = apperrors.New(apperrors.Conflict, "rejected by inventory service")
+)
+
+// Run statuses
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+)
+
+// Discrepancy kinds
+const (
+	// DiscrepancyAdjusted means our on-hand stock differed from the ERP and
+	// was adjusted to match it
+	DiscrepancyAdjusted = "adjusted"
+	// DiscrepancyHeld means the difference exceeded the adjustment limit and
+	// was left for review
+	DiscrepancyHeld     = "held"
+	DiscrepancyUnmapped = "unmapped"
+	DiscrepancyInvalid  = "invalid"
+	DiscrepancyFailed   = "failed"
+)
+
+// Mapping links a supplier SKU to one of our products
+type Mapping struct {
+	Supplier    string    `bson:"supplier" json:"supplier"`
+	SupplierSKU string    `bson:"supplier_sku" json:"supplier_sku"`
+	ProductID   string    `bson:"product_id" json:"product_id"`
+	SKU         string    `bson:"sku,omitempty" json:"sku,omitempty"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// StockUpdate is the on-hand quantity the ERP reports for one supplier SKU
+type StockUpdate struct {
+	Line          int // Position in the feed, for reporting
+	SupplierSKU   string
+	WarehouseCode string // ERP warehouse code; empty for the default warehouse
+	Quantity      int
+}
+
+// Batch is one feed received from the ERP, either a CSV file or a webhook
+// call. Its ID is stable across redeliveries of the same feed.
+type Batch struct {
+	ID       string
+	Supplier string
+	Source   string
+	Updates  []StockUpdate
+	Invalid  []Discrepancy // Rows that could not be parsed
+}
+
+// Discrepancy reports a feed line that did not simply match our stock
+type Discrepancy struct {
+	Kind        string `bson:"kind" json:"kind"`
+	Line        int    `bson:"line,omitempty" json:"line,omitempty"`
+	SupplierSKU string `bson:"supplier_sku,omitempty" json:"supplier_sku,omitempty"`
+	ProductID   string `bson:"product_id,omitempty" json:"product_id,omitempty"`
+	WarehouseID string `bson:"warehouse_id,omitempty" json:"warehouse_id,omitempty"`
+	ERPQuantity int    `bson:"erp_quantity" json:"erp_quantity"`
+	OnHand      int    `bson:"on_hand" json:"on_hand"`
+	Delta       int    `bson:"delta" json:"delta"`
+	Error       string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// RunTotals counts feed lines by outcome
+type RunTotals struct {
+	Lines     int `bson:"lines" json:"lines"`
+	Unchanged int `bson:"unchanged" json:"unchanged"`
+	Adjusted  int `bson:"adjusted" json:"adjusted"`
+	Held      int `bson:"held" json:"held"`
+	Unmapped  int `bson:"unmapped" json:"unmapped"`
+	Invalid   int `bson:"invalid" json:"invalid"`
+	Failed    int `bson:"failed" json:"failed"`
+}
+
+// Run is the record and discrepancy report of applying one batch
+type Run struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BatchID       string             `bson:"batch_id" json:"batch_id"`
+	Supplier      string             `bson:"supplier" json:"supplier"`
+	Source        string             `bson:"source" json:"source"`
+	Status        string             `bson:"status" json:"status"`
+	Totals        RunTotals          `bson:"totals" json:"totals"`
+	Discrepancies []Discrepancy      `bson:"discrepancies" json:"discrepancies"`
+	Error         string             `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt     time.Time          `bson:"started_at" json:"started_at"`
+	CompletedAt   *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// Add records a discrepancy and counts it
+func (r *Run) Add(d Discrepancy) {
+	r.Discrepancies = append(r.Discrepancies, d)
+	switch d.Kind {
+	case DiscrepancyAdjusted:
+		r.Totals.Adjusted++
+	case DiscrepancyHeld:
+		r.Totals.Held++
+	case DiscrepancyUnmapped:
+		r.Totals.Unmapped++
+	case DiscrepancyInvalid:
+		r.Totals.Invalid++
+	case DiscrepancyFailed:
+		r.Totals.Failed++
+	}
+}
+
+// MappingRepository defines the interface for SKU mapping data operations
+type MappingRepository interface {
+	Upsert(ctx context.Context, mapping *Mapping) error
+	Get(ctx context.Context, supplier, supplierSKU string) (*Mapping, error)
+	List(ctx context.Context, supplier string) ([]*Mapping, error)
+	Delete(ctx context.Context, supplier, supplierSKU string) error
+}
+
+// RunRepository defines the interface for sync run data operations
+type RunRepository interface {
+	// Start records a new run for the batch, or returns the existing run
+	// if the batch was seen before
+	Start(ctx context.Context, run *Run) (*Run, bool, error)
+	Save(ctx context.Context, run *Run) error
+	GetByID(ctx context.Context, id string) (*Run, error)
+	List(ctx context.Context, supplier string, limit int) ([]*Run, error)
+}
@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+)
+
+// Column names accepted in the CSV header, case-insensitively
+var (
+	skuColumns       = []string{"sku", "supplier_sku", "item"}
+	quantityColumns  = []string{"quantity", "qty", "on_hand"}
+	warehouseColumns = []string{"warehouse", "warehouse_code", "location"}
+)
+
+// BatchID derives a batch ID from the feed contents so the same file
+// dropped twice is recognised as the same batch
+func BatchID(supplier string, content []byte) string {
+	sum := sha256.Sum256(append([]byte(supplier+"\n"), content...))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ParseCSV reads a stock feed with a header row. The sku and quantity
+// columns are required; the warehouse column is optional. Rows that cannot
+// be parsed are reported on the batch rather than failing the whole feed.
+func ParseCSV(content []byte, supplier, source string) (*domain.Batch, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("feed is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	skuCol := findColumn(header, skuColumns)
+	qtyCol := findColumn(header, quantityColumns)
+	whCol := findColumn(header, warehouseColumns)
+	if skuCol < 0 || qtyCol < 0 {
+		return nil, fmt.Errorf("header must contain a SKU column (%s) and a quantity column (%s)",
+			strings.Join(skuColumns, ", "), strings.Join(quantityColumns, ", "))
+	}
+
+	batch := &domain.Batch{
+		ID:       BatchID(supplier, content),
+		Supplier: supplier,
+		Source:   source,
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			batch.Invalid = append(batch.Invalid, domain.Discrepancy{Kind: domain.DiscrepancyInvalid, Line: parseErr.Line, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read feed: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		update, err := parseRecord(record, skuCol, qtyCol, whCol)
+		if err != nil {
+			batch.Invalid = append(batch.Invalid, domain.Discrepancy{
+				Kind:        domain.DiscrepancyInvalid,
+				Line:        line,
+				SupplierSKU: field(record, skuCol),
+				Error:       err.Error(),
+			})
+			continue
+		}
+		update.Line = line
+		batch.Updates = append(batch.Updates, update)
+	}
+
+	return batch, nil
+}
+
+func parseRecord(record []string, skuCol, qtyCol, whCol int) (domain.StockUpdate, error) {
+	sku := field(record, skuCol)
+	if sku == "" {
+		return domain.StockUpdate{}, errors.New("SKU is empty")
+	}
+
+	quantity, err := strconv.Atoi(field(record, qtyCol))
+	if err != nil {
+		return domain.StockUpdate{}, fmt.Errorf("invalid quantity %q", field(record, qtyCol))
+	}
+	if quantity < 0 {
+		return domain.StockUpdate{}, fmt.Errorf("negative quantity %d", quantity)
+	}
+
+	return domain.StockUpdate{
+		SupplierSKU:   sku,
+		WarehouseCode: field(record, whCol),
+		Quantity:      quantity,
+	}, nil
+}
+
+func findColumn(header []string, names []string) int {
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		for _, name := range names {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func field(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[col])
+}
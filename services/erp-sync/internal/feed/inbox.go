@@ -0,0 +1,141 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+)
+
+// Subdirectories of the inbox that processed files are moved to
+const (
+	ProcessedDir = "processed"
+	FailedDir    = "failed"
+)
+
+// BatchHandler applies a parsed batch
+type BatchHandler func(ctx context.Context, batch *domain.Batch) error
+
+// Inbox picks up CSV files that the ERP drops into a directory, typically
+// the upload directory of an SFTP server mounted into the container
+type Inbox struct {
+	dir      string
+	supplier string
+	settle   time.Duration
+	logger   *slog.Logger
+}
+
+// NewInbox creates an inbox for dir. Files are only read once they have
+// not been modified for settle, so partially uploaded files are skipped.
+func NewInbox(dir, supplier string, settle time.Duration, logger *slog.Logger) *Inbox {
+	return &Inbox{
+		dir:      dir,
+		supplier: supplier,
+		settle:   settle,
+		logger:   logger,
+	}
+}
+
+// Run polls the inbox every interval until ctx is done
+func (i *Inbox) Run(ctx context.Context, interval time.Duration, handle BatchHandler) {
+	for _, sub := range []string{ProcessedDir, FailedDir} {
+		if err := os.MkdirAll(filepath.Join(i.dir, sub), 0o755); err != nil {
+			i.logger.Error("Failed to create inbox directory", "dir", sub, "error", err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.Poll(ctx, handle); err != nil {
+			i.logger.Error("Failed to poll inbox", "dir", i.dir, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll processes the settled CSV files in the inbox, oldest first. Files
+// that cannot be parsed are moved to the failed directory; files whose
+// batch could not be applied are left in place and retried on the next poll.
+func (i *Inbox) Poll(ctx context.Context, handle BatchHandler) error {
+	entries, err := os.ReadDir(i.dir)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		name    string
+		modTime time.Time
+	}
+	var files []pending
+	cutoff := time.Now().Add(-i.settle)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		files = append(files, pending{name: entry.Name(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].modTime.Before(files[b].modTime) })
+
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		i.process(ctx, f.name, handle)
+	}
+	return nil
+}
+
+func (i *Inbox) process(ctx context.Context, name string, handle BatchHandler) {
+	path := filepath.Join(i.dir, name)
+	logger := i.logger.With("file", name)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("Failed to read feed file", "error", err)
+		return
+	}
+
+	batch, err := ParseCSV(content, i.supplier, "file:"+name)
+	if err != nil {
+		logger.Error("Rejecting malformed feed file", "error", err)
+		i.move(path, FailedDir, err)
+		return
+	}
+
+	if err := handle(ctx, batch); err != nil {
+		logger.Error("Failed to apply feed file, will retry", "batchID", batch.ID, "error", err)
+		return
+	}
+	i.move(path, ProcessedDir, nil)
+}
+
+// move files a processed feed away, prefixed with a timestamp so repeated
+// drops of the same file name do not collide. A failure reason is written
+// next to failed files.
+func (i *Inbox) move(path, sub string, reason error) {
+	target := filepath.Join(i.dir, sub, time.Now().UTC().Format("20060102T150405Z")+"-"+filepath.Base(path))
+	if err := os.Rename(path, target); err != nil {
+		i.logger.Error("Failed to move feed file", "file", path, "error", err)
+		return
+	}
+	if reason != nil {
+		os.WriteFile(target+".error", []byte(fmt.Sprintln(reason)), 0o644)
+	}
+}
@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+)
+
+// webhookFeed is the body the ERP posts to the webhook endpoint
+type webhookFeed struct {
+	BatchID  string `json:"batch_id"`
+	Supplier string `json:"supplier"`
+	Items    []struct {
+		SKU       string `json:"sku"`
+		Quantity  *int   `json:"quantity"`
+		Warehouse string `json:"warehouse"`
+	} `json:"items"`
+}
+
+// ParseJSON reads a stock feed posted to the webhook. Without a batch_id
+// the batch ID is derived from the body, so only byte-identical retries are
+// recognised as the same batch.
+func ParseJSON(content []byte, defaultSupplier string) (*domain.Batch, error) {
+	var body webhookFeed
+	if err := json.Unmarshal(content, &body); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	if len(body.Items) == 0 {
+		return nil, errors.New("feed has no items")
+	}
+
+	supplier := body.Supplier
+	if supplier == "" {
+		supplier = defaultSupplier
+	}
+	batch := &domain.Batch{
+		ID:       body.BatchID,
+		Supplier: supplier,
+		Source:   "webhook",
+	}
+	if batch.ID == "" {
+		batch.ID = BatchID(supplier, content)
+	}
+
+	for i, item := range body.Items {
+		line := i + 1
+		switch {
+		case item.SKU == "":
+			batch.Invalid = append(batch.Invalid, domain.Discrepancy{Kind: domain.DiscrepancyInvalid, Line: line, Error: "SKU is empty"})
+		case item.Quantity == nil:
+			batch.Invalid = append(batch.Invalid, domain.Discrepancy{Kind: domain.DiscrepancyInvalid, Line: line, SupplierSKU: item.SKU, Error: "quantity is missing"})
+		case *item.Quantity < 0:
+			batch.Invalid = append(batch.Invalid, domain.Discrepancy{Kind: domain.DiscrepancyInvalid, Line: line, SupplierSKU: item.SKU, Error: fmt.Sprintf("negative quantity %d", *item.Quantity)})
+		default:
+			batch.Updates = append(batch.Updates, domain.StockUpdate{
+				Line:          line,
+				SupplierSKU:   item.SKU,
+				WarehouseCode: item.Warehouse,
+				Quantity:      *item.Quantity,
+			})
+		}
+	}
+	return batch, nil
+}
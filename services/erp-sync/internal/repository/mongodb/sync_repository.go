@@ -0,0 +1,209 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/erp-sync/config"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MappingRepository implements domain.MappingRepository using MongoDB
+type MappingRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewMappingRepository creates a new MongoDB SKU mapping repository
+func NewMappingRepository(client *mongo.Client, cfg *config.MongoDBConfig) *MappingRepository {
+	return &MappingRepository{
+		collection: client.Database(cfg.Database).Collection("sku_mappings"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the unique index on supplier SKUs
+func (r *MappingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "supplier", Value: 1}, {Key: "supplier_sku", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create mapping indexes: %w", err)
+	}
+	return nil
+}
+
+// Upsert creates or replaces the mapping of a supplier SKU
+func (r *MappingRepository) Upsert(ctx context.Context, mapping *domain.Mapping) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.ReplaceOne(ctx,
+		bson.M{"supplier": mapping.Supplier, "supplier_sku": mapping.SupplierSKU},
+		mapping,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+// Get returns the mapping of a supplier SKU
+func (r *MappingRepository) Get(ctx context.Context, supplier, supplierSKU string) (*domain.Mapping, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var mapping domain.Mapping
+	err := r.collection.FindOne(ctx, bson.M{"supplier": supplier, "supplier_sku": supplierSKU}).Decode(&mapping)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// List returns the mappings of a supplier ordered by supplier SKU
+func (r *MappingRepository) List(ctx context.Context, supplier string) ([]*domain.Mapping, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"supplier": supplier},
+		options.Find().SetSort(bson.D{{Key: "supplier_sku", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mappings []*domain.Mapping
+	if err := cursor.All(ctx, &mappings); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// Delete removes the mapping of a supplier SKU
+func (r *MappingRepository) Delete(ctx context.Context, supplier, supplierSKU string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"supplier": supplier, "supplier_sku": supplierSKU})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RunRepository implements domain.RunRepository using MongoDB
+type RunRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewRunRepository creates a new MongoDB sync run repository
+func NewRunRepository(client *mongo.Client, cfg *config.MongoDBConfig) *RunRepository {
+	return &RunRepository{
+		collection: client.Database(cfg.Database).Collection("sync_runs"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes used to deduplicate batches and list runs
+func (r *RunRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "supplier", Value: 1}, {Key: "batch_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create run indexes: %w", err)
+	}
+	return nil
+}
+
+// Start inserts the run, or returns the existing run of the same batch
+func (r *RunRepository) Start(ctx context.Context, run *domain.Run) (*domain.Run, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, run)
+	if err == nil {
+		run.ID = result.InsertedID.(primitive.ObjectID)
+		return run, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	var existing domain.Run
+	err = r.collection.FindOne(ctx, bson.M{"supplier": run.Supplier, "batch_id": run.BatchID}).Decode(&existing)
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// Save replaces a run
+func (r *RunRepository) Save(ctx context.Context, run *domain.Run) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run)
+	return err
+}
+
+// GetByID returns a run by ID
+func (r *RunRepository) GetByID(ctx context.Context, id string) (*domain.Run, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var run domain.Run
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&run)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// List returns the latest runs, optionally for one supplier. Discrepancies
+// are left out; fetch a single run for its report.
+func (r *RunRepository) List(ctx context.Context, supplier string, limit int) ([]*domain.Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if supplier != "" {
+		filter["supplier"] = supplier
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"discrepancies": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*domain.Run
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+)
+
+// InventoryClient defines the inventory operations the connector needs
+type InventoryClient interface {
+	OnHand(ctx context.Context, productID, warehouseID string) (int, error)
+	Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error
+}
+
+// Options tunes how feeds are applied
+type Options struct {
+	DefaultWarehouse string            // Warehouse for lines without a warehouse code
+	Warehouses       map[string]string // ERP warehouse code to warehouse ID; empty uses codes as IDs
+	MaxAdjustment    int               // Larger differences are held for review; 0 disables the limit
+}
+
+// SyncService applies ERP stock feeds to the inventory service
+type SyncService struct {
+	mappings  domain.MappingRepository
+	runs      domain.RunRepository
+	inventory InventoryClient
+	opts      Options
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// New creates a new SyncService
+func New(mappings domain.MappingRepository, runs domain.RunRepository, inventory InventoryClient, opts Options, logger *slog.Logger) *SyncService {
+	return &SyncService{
+		mappings:  mappings,
+		runs:      runs,
+		inventory: inventory,
+		opts:      opts,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// target is the stock the ERP reports for one product in one warehouse,
+// summed over the supplier SKUs mapped to it
+type target struct {
+	productID    string
+	warehouseID  string
+	supplierSKUs []string
+	line         int
+	quantity     int
+}
+
+// Apply brings on-hand stock in line with a batch and records the run with
+// its discrepancy report. Each difference is applied as an adjustment whose
+// operation ID is derived from the batch, so applying the same batch again
+// never adjusts twice. A batch that was already completed is not reapplied.
+//
+// An error is returned only if the batch could not be applied at all, e.g.
+// because the inventory service is unreachable; the caller should retry.
+func (s *SyncService) Apply(ctx context.Context, batch *domain.Batch) (*domain.Run, error) {
+	logger := s.logger.With("batchID", batch.ID, "supplier", batch.Supplier, "source", batch.Source)
+	logger.Info("Applying stock feed", "lines", len(batch.Updates)+len(batch.Invalid))
+
+	if batch.ID == "" || batch.Supplier == "" {
+		return nil, apperrors.New(apperrors.Invalid, "batch ID and supplier are required")
+	}
+
+	run, created, err := s.runs.Start(ctx, &domain.Run{
+		BatchID:   batch.ID,
+		Supplier:  batch.Supplier,
+		Source:    batch.Source,
+		Status:    domain.RunRunning,
+		StartedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if !created && run.Status == domain.RunCompleted {
+		logger.Info("Stock feed already applied", "runID", run.ID.Hex())
+		return run, nil
+	}
+
+	// Start over on a rerun; adjustments that already went through are
+	// replayed by the inventory service without being applied again
+	run.Status = domain.RunRunning
+	run.Source = batch.Source
+	run.Totals = domain.RunTotals{Lines: len(batch.Updates) + len(batch.Invalid)}
+	run.Discrepancies = nil
+	run.Error = ""
+	for _, d := range batch.Invalid {
+		run.Add(d)
+	}
+
+	if err := s.apply(ctx, batch, run); err != nil {
+		run.Status = domain.RunFailed
+		run.Error = err.Error()
+		if saveErr := s.runs.Save(ctx, run); saveErr != nil {
+			logger.Error("Failed to save run", "error", saveErr)
+		}
+		return run, err
+	}
+
+	completed := s.now().UTC()
+	run.Status = domain.RunCompleted
+	run.CompletedAt = &completed
+	if err := s.runs.Save(ctx, run); err != nil {
+		return run, fmt.Errorf("repository error: %w", err)
+	}
+
+	logger.Info("Stock feed applied",
+		"runID", run.ID.Hex(),
+		"adjusted", run.Totals.Adjusted,
+		"unchanged", run.Totals.Unchanged,
+		"held", run.Totals.Held,
+		"unmapped", run.Totals.Unmapped,
+		"invalid", run.Totals.Invalid,
+		"failed", run.Totals.Failed)
+	return run, nil
+}
+
+func (s *SyncService) apply(ctx context.Context, batch *domain.Batch, run *domain.Run) error {
+	targets, err := s.resolve(ctx, batch, run)
+	if err != nil {
+		return err
+	}
+
+	reason := "erp-sync: " + batch.Supplier
+	for _, t := range targets {
+		onHand, err := s.inventory.OnHand(ctx, t.productID, t.warehouseID)
+		if err != nil {
+			return fmt.Errorf("failed to read stock of %s: %w", t.productID, err)
+		}
+
+		d := domain.Discrepancy{
+			Line:        t.line,
+			SupplierSKU: strings.Join(t.supplierSKUs, ","),
+			ProductID:   t.productID,
+			WarehouseID: t.warehouseID,
+			ERPQuantity: t.quantity,
+			OnHand:      onHand,
+			Delta:       t.quantity - onHand,
+		}
+		switch {
+		case d.Delta == 0:
+			run.Totals.Unchanged++
+			continue
+		case s.opts.MaxAdjustment > 0 && abs(d.Delta) > s.opts.MaxAdjustment:
+			d.Kind = domain.DiscrepancyHeld
+			d.Error = fmt.Sprintf("difference exceeds the limit of %d", s.opts.MaxAdjustment)
+			run.Add(d)
+			continue
+		}
+
+		operationID := fmt.Sprintf("erp-%s-%s-%s-%s", batch.Supplier, batch.ID, t.productID, t.warehouseID)
+		err = s.inventory.Adjust(ctx, operationID, t.productID, t.warehouseID, d.Delta, reason)
+		switch {
+		case errors.Is(err, domain.ErrRejected):
+			d.Kind = domain.DiscrepancyFailed
+			d.Error = err.Error()
+		case err != nil:
+			return fmt.Errorf("failed to adjust stock of %s: %w", t.productID, err)
+		default:
+			d.Kind = domain.DiscrepancyAdjusted
+		}
+		run.Add(d)
+	}
+	return nil
+}
+
+// resolve maps supplier SKUs and warehouse codes to our IDs and sums lines
+// that land on the same product and warehouse
+func (s *SyncService) resolve(ctx context.Context, batch *domain.Batch, run *domain.Run) ([]*target, error) {
+	byKey := make(map[string]*target)
+	var targets []*target
+
+	for _, update := range batch.Updates {
+		mapping, err := s.mappings.Get(ctx, batch.Supplier, update.SupplierSKU)
+		if errors.Is(err, domain.ErrNotFound) {
+			run.Add(domain.Discrepancy{
+				Kind:        domain.DiscrepancyUnmapped,
+				Line:        update.Line,
+				SupplierSKU: update.SupplierSKU,
+				ERPQuantity: update.Quantity,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+
+		warehouseID, err := s.warehouse(update.WarehouseCode)
+		if err != nil {
+			run.Add(domain.Discrepancy{
+				Kind:        domain.DiscrepancyInvalid,
+				Line:        update.Line,
+				SupplierSKU: update.SupplierSKU,
+				ProductID:   mapping.ProductID,
+				ERPQuantity: update.Quantity,
+				Error:       err.Error(),
+			})
+			continue
+		}
+
+		key := mapping.ProductID + "/" + warehouseID
+		t, ok := byKey[key]
+		if !ok {
+			t = &target{productID: mapping.ProductID, warehouseID: warehouseID, line: update.Line}
+			byKey[key] = t
+			targets = append(targets, t)
+		}
+		t.supplierSKUs = append(t.supplierSKUs, update.SupplierSKU)
+		t.quantity += update.Quantity
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].line < targets[j].line })
+	return targets, nil
+}
+
+func (s *SyncService) warehouse(code string) (string, error) {
+	if code == "" {
+		if s.opts.DefaultWarehouse == "" {
+			return "", errors.New("warehouse is required")
+		}
+		return s.opts.DefaultWarehouse, nil
+	}
+	if len(s.opts.Warehouses) == 0 {
+		return code, nil
+	}
+	if id, ok := s.opts.Warehouses[code]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("unknown warehouse code %q", code)
+}
+
+// GetRun returns a sync run by ID
+func (s *SyncService) GetRun(ctx context.Context, id string) (*domain.Run, error) {
+	run, err := s.runs.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns the latest sync runs, optionally for one supplier
+func (s *SyncService) ListRuns(ctx context.Context, supplier string, limit int) ([]*domain.Run, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	runs, err := s.runs.List(ctx, supplier, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return runs, nil
+}
+
+// PutMappings creates or replaces SKU mappings of a supplier
+func (s *SyncService) PutMappings(ctx context.Context, supplier string, mappings []*domain.Mapping) error {
+	s.logger.Info("Updating SKU mappings", "supplier", supplier, "count", len(mappings))
+
+	if supplier == "" {
+		return apperrors.New(apperrors.Invalid, "supplier is required")
+	}
+	for i, m := range mappings {
+		if m.SupplierSKU == "" || m.ProductID == "" {
+			return apperrors.Newf(apperrors.Invalid, "mapping %d: supplier_sku and product_id are required", i)
+		}
+	}
+
+	now := s.now().UTC()
+	for _, m := range mappings {
+		m.Supplier = supplier
+		m.UpdatedAt = now
+		if err := s.mappings.Upsert(ctx, m); err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListMappings returns the SKU mappings of a supplier
+func (s *SyncService) ListMappings(ctx context.Context, supplier string) ([]*domain.Mapping, error) {
+	mappings, err := s.mappings.List(ctx, supplier)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return mappings, nil
+}
+
+// DeleteMapping removes a SKU mapping
+func (s *SyncService) DeleteMapping(ctx context.Context, supplier, supplierSKU string) error {
+	s.logger.Info("Deleting SKU mapping", "supplier", supplier, "supplierSKU", supplierSKU)
+
+	if err := s.mappings.Delete(ctx, supplier, supplierSKU); err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
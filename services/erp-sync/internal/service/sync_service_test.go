@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/bekbull/online-shop/services/erp-sync/internal/domain"
+	"github.com/bekbull/online-shop/services/erp-sync/internal/feed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockInventoryClient is a mock implementation of the InventoryClient interface
+type MockInventoryClient struct {
+	mock.Mock
+}
+
+func (m *MockInventoryClient) OnHand(ctx context.Context, productID, warehouseID string) (int, error) {
+	args := m.Called(productID, warehouseID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockInventoryClient) Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) error {
+	return m.Called(operationID, productID, warehouseID, quantityChange, reason).Error(0)
+}
+
+// memoryMappings serves mappings from a map keyed by supplier SKU
+type memoryMappings struct {
+	domain.MappingRepository
+	bySKU map[string]string
+}
+
+func (m *memoryMappings) Get(ctx context.Context, supplier, supplierSKU string) (*domain.Mapping, error) {
+	productID, ok := m.bySKU[supplierSKU]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.Mapping{Supplier: supplier, SupplierSKU: supplierSKU, ProductID: productID}, nil
+}
+
+// memoryRuns keeps runs by batch ID
+type memoryRuns struct {
+	domain.RunRepository
+	runs map[string]*domain.Run
+}
+
+func (m *memoryRuns) Start(ctx context.Context, run *domain.Run) (*domain.Run, bool, error) {
+	if existing, ok := m.runs[run.BatchID]; ok {
+		copied := *existing
+		return &copied, false, nil
+	}
+	run.ID = primitive.NewObjectID()
+	m.runs[run.BatchID] = run
+	return run, true, nil
+}
+
+func (m *memoryRuns) Save(ctx context.Context, run *domain.Run) error {
+	copied := *run
+	m.runs[run.BatchID] = &copied
+	return nil
+}
+
+func newTestService(opts Options) (*SyncService, *MockInventoryClient, *memoryRuns) {
+	inventory := new(MockInventoryClient)
+	runs := &memoryRuns{runs: map[string]*domain.Run{}}
+	mappings := &memoryMappings{bySKU: map[string]string{
+		"ACME-1":   "p1",
+		"ACME-2":   "p2",
+		"ACME-2-B": "p2", // A second supplier SKU for the same product
+		"ACME-3":   "p3",
+	}}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return New(mappings, runs, inventory, opts, logger), inventory, runs
+}
+
+func parse(t *testing.T, content string) *domain.Batch {
+	t.Helper()
+	batch, err := feed.ParseCSV([]byte(content), "acme", "file:stock.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+const stockFeed = `SKU,Qty,Warehouse
+ACME-1,10,MAIN
+ACME-2,4,MAIN
+ACME-2-B,3,MAIN
+ACME-3,7,MAIN
+UNKNOWN,5,MAIN
+ACME-1,abc,MAIN
+`
+
+func TestApply(t *testing.T) {
+	svc, inventory, runs := newTestService(Options{Warehouses: map[string]string{"MAIN": "w1"}})
+	batch := parse(t, stockFeed)
+	inventory.On("OnHand", "p1", "w1").Return(8, nil)
+	inventory.On("OnHand", "p2", "w1").Return(7, nil)
+	inventory.On("OnHand", "p3", "w1").Return(9, nil)
+	inventory.On("Adjust", fmt.Sprintf("erp-acme-%s-p1-w1", batch.ID), "p1", "w1", 2, "erp-sync: acme").Return(nil)
+	inventory.On("Adjust", fmt.Sprintf("erp-acme-%s-p3-w1", batch.ID), "p3", "w1", -2, "erp-sync: acme").Return(nil)
+
+	run, err := svc.Apply(context.Background(), batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RunCompleted, run.Status)
+	assert.Equal(t, domain.RunTotals{Lines: 6, Unchanged: 1, Adjusted: 2, Unmapped: 1, Invalid: 1}, run.Totals)
+	inventory.AssertExpectations(t)
+
+	t.Run("same batch is not applied twice", func(t *testing.T) {
+		again, err := svc.Apply(context.Background(), parse(t, stockFeed))
+
+		assert.NoError(t, err)
+		assert.Equal(t, run.ID, again.ID)
+		inventory.AssertNumberOfCalls(t, "Adjust", 2)
+		assert.Len(t, runs.runs, 1)
+	})
+}
+
+func TestApplyHoldsLargeDifferences(t *testing.T) {
+	svc, inventory, _ := newTestService(Options{DefaultWarehouse: "w1", MaxAdjustment: 50})
+	inventory.On("OnHand", "p1", "w1").Return(500, nil)
+
+	run, err := svc.Apply(context.Background(), parse(t, "sku,quantity\nACME-1,0\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, run.Totals.Held)
+	assert.Equal(t, -500, run.Discrepancies[0].Delta)
+	inventory.AssertNotCalled(t, "Adjust", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApplyFailures(t *testing.T) {
+	t.Run("rejected adjustments are reported", func(t *testing.T) {
+		svc, inventory, _ := newTestService(Options{DefaultWarehouse: "w1"})
+		inventory.On("OnHand", "p1", "w1").Return(5, nil)
+		inventory.On("Adjust", mock.Anything, "p1", "w1", -5, mock.Anything).
+			Return(fmt.Errorf("%w: insufficient stock", domain.ErrRejected))
+
+		run, err := svc.Apply(context.Background(), parse(t, "sku,quantity\nACME-1,0\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, run.Totals.Failed)
+		assert.Contains(t, run.Discrepancies[0].Error, "insufficient stock")
+	})
+
+	t.Run("unreachable inventory fails the run for a retry", func(t *testing.T) {
+		svc, inventory, runs := newTestService(Options{DefaultWarehouse: "w1"})
+		batch := parse(t, "sku,quantity\nACME-1,3\n")
+		inventory.On("OnHand", "p1", "w1").Return(0, errors.New("inventory service error: unavailable")).Once()
+
+		_, err := svc.Apply(context.Background(), batch)
+
+		assert.Error(t, err)
+		assert.Equal(t, domain.RunFailed, runs.runs[batch.ID].Status)
+
+		// The retry reruns the failed batch
+		inventory.On("OnHand", "p1", "w1").Return(0, nil)
+		inventory.On("Adjust", mock.Anything, "p1", "w1", 3, mock.Anything).Return(nil)
+
+		run, err := svc.Apply(context.Background(), batch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.RunCompleted, run.Status)
+		assert.Empty(t, run.Error)
+	})
+
+	t.Run("lines without a warehouse need a default", func(t *testing.T) {
+		svc, _, _ := newTestService(Options{})
+
+		run, err := svc.Apply(context.Background(), parse(t, "sku,quantity\nACME-1,3\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, run.Totals.Invalid)
+	})
+}
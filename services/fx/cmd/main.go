@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/grpcserver"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	fxv1 "github.com/bekbull/online-shop/proto/fx/v1"
+	"github.com/bekbull/online-shop/services/fx/config"
+	grpcHandler "github.com/bekbull/online-shop/services/fx/internal/api/grpc"
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+	"github.com/bekbull/online-shop/services/fx/internal/provider"
+	"github.com/bekbull/online-shop/services/fx/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	// Initialize logger
+	logger := logging.New(os.Stdout, logging.Options{Service: "fx-service", Text: true})
+	logger.Info("Starting FX Service")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	var rateProvider domain.Provider
+	switch cfg.Provider.Name {
+	case "ecb":
+		rateProvider = provider.NewECBProvider(cfg.Provider.ECBURL, cfg.Provider.Timeout)
+	case "static":
+		static, err := provider.NewStaticProvider(cfg.Provider.StaticBase, cfg.Provider.StaticRates)
+		if err != nil {
+			logger.Error("Invalid static rates", "error", err)
+			os.Exit(1)
+		}
+		rateProvider = static
+	default:
+		logger.Error("Unknown FX provider", "provider", cfg.Provider.Name)
+		os.Exit(1)
+	}
+
+	// Create service and start refreshing rates
+	fxService := service.New(rateProvider, service.Options{
+		MaxCacheAge: cfg.Cache.MaxCacheAge,
+		MaxRateAge:  cfg.Cache.MaxRateAge,
+	}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fxService.Run(ctx, cfg.Cache.RefreshInterval, cfg.Cache.RetryInterval)
+
+	// Load TLS credentials; certificates are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	go creds.Watch(ctx)
+
+	// Setup gRPC server. Calls get request IDs, logs, metrics, panic
+	// recovery and the timeout of their method, as on the other services.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	endpoints, err := cfg.Server.EndpointPolicies()
+	if err != nil {
+		logger.Error("Failed to load endpoint policies", "error", err)
+		os.Exit(1)
+	}
+	chain := grpcserver.Chain{
+		Logger:    logger,
+		AccessLog: cfg.Server.AccessLog(),
+		Metrics:   middleware.NewMetrics(registry, "fx_service"),
+		Endpoints: endpoints,
+	}
+	grpcServer := grpc.NewServer(append(chain.ServerOptions(), creds.ServerOption())...)
+	fxv1.RegisterFXServiceServer(grpcServer, grpcHandler.New(fxService, logger))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if cfg.Env != "production" {
+		reflection.Register(grpcServer)
+	}
+
+	// Setup HTTP server for health checks. Readiness fails while rates are
+	// missing or stale so traffic is not routed to an instance that would
+	// refuse every conversion.
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if err := fxService.Ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: mux,
+	}
+
+	// Start servers in goroutines
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go func() {
+		logger.Info("Starting gRPC server", "port", cfg.GRPCPort)
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("Failed to listen for gRPC", "error", err)
+			os.Exit(1)
+		}
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("gRPC server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for interrupt signal
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+	logger.Info("Received shutdown signal", "signal", sig)
+
+	// Report NOT_SERVING first so health-checking clients move away
+	// before connections are drained
+	healthServer.Shutdown()
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelShutdown()
+
+	logger.Info("Shutting down HTTP server")
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutting down gRPC server")
+	grpcServer.GracefulStop()
+
+	logger.Info("Shutdown completed")
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+)
+
+// Config holds all configuration for the service
+type Config struct {
+	Provider ProviderConfig
+	Cache    CacheConfig
+	Server   ServerConfig
+	TLS      mtls.Config
+	GRPCPort int
+	HTTPPort int
+	Env      string
+}
+
+// ServerConfig holds the settings of the gRPC interceptor chain
+type ServerConfig struct {
+	// RequestTimeout bounds each call; EndpointPoliciesFile sets timeouts
+	// per RPC
+	RequestTimeout       time.Duration
+	EndpointPoliciesFile string
+
+	// Successful calls are access logged at AccessSampleRate; failed ones
+	// and those slower than AccessSlowThreshold always are
+	AccessSampleRate    float64
+	AccessSlowThreshold time.Duration
+}
+
+// ProviderConfig selects and configures the rate provider
+type ProviderConfig struct {
+	Name        string
+	ECBURL      string
+	StaticBase  string
+	StaticRates string
+	Timeout     time.Duration
+}
+
+// CacheConfig controls refresh scheduling and staleness bounds
+type CacheConfig struct {
+	RefreshInterval time.Duration
+	RetryInterval   time.Duration
+	MaxCacheAge     time.Duration
+	MaxRateAge      time.Duration
+}
+
+// Load loads configuration from environment variables
+func Load() *Config {
+	return &Config{
+		Provider: ProviderConfig{
+			Name:        getEnv("FX_PROVIDER", "ecb"),
+			ECBURL:      getEnv("FX_ECB_URL", ""),
+			StaticBase:  getEnv("FX_STATIC_BASE", "USD"),
+			StaticRates: getEnv("FX_STATIC_RATES", "EUR=0.92,GBP=0.79,KZT=480,JPY=150"),
+			Timeout:     getEnvDuration("FX_PROVIDER_TIMEOUT", 10*time.Second),
+		},
+		Cache: CacheConfig{
+			RefreshInterval: getEnvDuration("FX_REFRESH_INTERVAL", time.Hour),
+			RetryInterval:   getEnvDuration("FX_RETRY_INTERVAL", time.Minute),
+			MaxCacheAge:     getEnvDuration("FX_MAX_CACHE_AGE", 6*time.Hour),
+			MaxRateAge:      getEnvDuration("FX_MAX_RATE_AGE", 96*time.Hour),
+		},
+		Server: ServerConfig{
+			RequestTimeout:       getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+			EndpointPoliciesFile: getEnv("ENDPOINT_POLICIES_FILE", ""),
+			AccessSampleRate:     getEnvFloat("ACCESS_LOG_SAMPLE_RATE", 1),
+			AccessSlowThreshold:  getEnvDuration("ACCESS_LOG_SLOW_THRESHOLD", time.Second),
+		},
+		TLS:      mtls.FromEnv(),
+		GRPCPort: getEnvInt("GRPC_PORT", 50054),
+		HTTPPort: getEnvInt("HTTP_PORT", 8085),
+		Env:      getEnv("ENV", "development"),
+	}
+}
+
+// EndpointPolicies returns the timeouts per RPC from EndpointPoliciesFile,
+// with RequestTimeout as the default
+func (c *ServerConfig) EndpointPolicies() (*middleware.EndpointPolicies, error) {
+	defaults := middleware.EndpointPolicy{Timeout: middleware.Duration(c.RequestTimeout)}
+	if c.EndpointPoliciesFile == "" {
+		return &middleware.EndpointPolicies{Default: defaults}, nil
+	}
+	return middleware.LoadEndpointPolicies(c.EndpointPoliciesFile, defaults)
+}
+
+// AccessLog returns the access log sampling configuration
+func (c *ServerConfig) AccessLog() logging.AccessLogOptions {
+	return logging.AccessLogOptions{SampleRate: c.AccessSampleRate, SlowThreshold: c.AccessSlowThreshold}
+}
+
+// Helper functions
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
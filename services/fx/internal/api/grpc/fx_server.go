@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/fx/v1"
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+)
+
+// rateDigits is the number of decimal places rates are reported with
+const rateDigits = 10
+
+// FXServer implements the gRPC FXService
+type FXServer struct {
+	pb.UnimplementedFXServiceServer
+	fxService FXService
+	logger    *slog.Logger
+}
+
+// FXService represents the business logic interface for currency conversion
+type FXService interface {
+	Convert(amount int64, from, to string) (*domain.Conversion, error)
+	Rates(base string) (*domain.RateTable, error)
+}
+
+// New creates a new FXServer
+func New(service FXService, logger *slog.Logger) *FXServer {
+	return &FXServer{
+		fxService: service,
+		logger:    logger,
+	}
+}
+
+// Convert implements the Convert RPC method
+func (s *FXServer) Convert(ctx context.Context, req *pb.ConvertRequest) (*pb.ConvertResponse, error) {
+	conversion, err := s.fxService.Convert(req.Amount, req.From, req.To)
+	if err != nil {
+		return nil, toStatus("failed to convert", err)
+	}
+
+	return &pb.ConvertResponse{
+		Amount:   conversion.Amount,
+		From:     conversion.From,
+		To:       conversion.To,
+		Rate:     conversion.Rate.FloatString(rateDigits),
+		AsOf:     conversion.AsOf.Unix(),
+		Provider: conversion.Provider,
+	}, nil
+}
+
+// GetRates implements the GetRates RPC method
+func (s *FXServer) GetRates(ctx context.Context, req *pb.GetRatesRequest) (*pb.GetRatesResponse, error) {
+	table, err := s.fxService.Rates(req.Base)
+	if err != nil {
+		return nil, toStatus("failed to get rates", err)
+	}
+
+	rates := make(map[string]string, len(table.Rates))
+	for currency, rate := range table.Rates {
+		rates[currency] = rate.FloatString(rateDigits)
+	}
+
+	return &pb.GetRatesResponse{
+		Base:      table.Base,
+		Rates:     rates,
+		AsOf:      table.AsOf.Unix(),
+		FetchedAt: table.FetchedAt.Unix(),
+		Provider:  table.Provider,
+	}, nil
+}
+
+// toStatus converts err to a gRPC status, prefixing its message with msg
+func toStatus(msg string, err error) error {
+	return apperrors.ToGRPC(fmt.Errorf("%s: %w", msg, err))
+}
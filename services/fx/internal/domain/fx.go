@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/money"
+)
+
+// Domain errors returned by the service
+var (
+	ErrUnknownCurrency = apperrors.New(apperrors.Invalid, "unknown currency")
+	ErrNoRates         = apperrors.New(apperrors.Unavailable, "exchange rates not loaded")
+	ErrStaleRates      = apperrors.New(apperrors.Unavailable, "exchange rates are stale")
+	ErrOutOfRange      = apperrors.New(apperrors.Invalid, "converted amount out of range")
+)
+
+// RateTable holds exchange rates relative to a base currency:
+// 1 unit of Base buys Rates[c] units of c
+type RateTable struct {
+	Base      string
+	Rates     map[string]*big.Rat
+	AsOf      time.Time
+	FetchedAt time.Time
+	Provider  string
+}
+
+// Provider fetches the latest exchange rates
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (*RateTable, error)
+}
+
+// Rate returns how many units of to one unit of from buys
+func (t *RateTable) Rate(from, to string) (*big.Rat, error) {
+	fromRate, err := t.baseRate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := t.baseRate(to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert converts amount minor units of from into minor units of to.
+// The computation is exact; only the final result is rounded, half away
+// from zero, to the minor unit of the target currency. A result that does
+// not fit in an int64 is ErrOutOfRange.
+func (t *RateTable) Convert(amount int64, from, to string) (int64, *big.Rat, error) {
+	rate, err := t.Rate(from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// amount / 10^exp(from) * rate * 10^exp(to)
+	value := new(big.Rat).SetInt64(amount)
+	value.Mul(value, rate)
+	value.Mul(value, pow10(money.MinorUnits(to)))
+	value.Quo(value, pow10(money.MinorUnits(from)))
+
+	converted, ok := RoundHalfAwayFromZero(value)
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: %d %s in %s", ErrOutOfRange, amount, from, to)
+	}
+	return converted, rate, nil
+}
+
+// Rebase returns the table expressed relative to another base currency
+func (t *RateTable) Rebase(base string) (*RateTable, error) {
+	if base == "" || base == t.Base {
+		return t, nil
+	}
+	baseRate, err := t.baseRate(base)
+	if err != nil {
+		return nil, err
+	}
+
+	rebased := &RateTable{
+		Base:      base,
+		Rates:     make(map[string]*big.Rat, len(t.Rates)+1),
+		AsOf:      t.AsOf,
+		FetchedAt: t.FetchedAt,
+		Provider:  t.Provider,
+	}
+	rebased.Rates[t.Base] = new(big.Rat).Inv(baseRate)
+	for currency, rate := range t.Rates {
+		if currency == base {
+			continue
+		}
+		rebased.Rates[currency] = new(big.Rat).Quo(rate, baseRate)
+	}
+	return rebased, nil
+}
+
+func (t *RateTable) baseRate(currency string) (*big.Rat, error) {
+	if currency == t.Base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := t.Rates[currency]
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return rate, nil
+}
+
+// RoundHalfAwayFromZero rounds a rational to the nearest integer, with
+// halves rounded away from zero. ok is false if the result does not fit in
+// an int64.
+func RoundHalfAwayFromZero(r *big.Rat) (rounded int64, ok bool) {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	// floor((2*num + den) / (2*den))
+	num.Mul(num, big.NewInt(2))
+	num.Add(num, den)
+	result := num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+
+	if negative {
+		result.Neg(result)
+	}
+	if !result.IsInt64() {
+		return 0, false
+	}
+	return result.Int64(), true
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+// Conversion is the result of converting an amount between currencies
+type Conversion struct {
+	Amount   int64
+	From     string
+	To       string
+	Rate     *big.Rat
+	AsOf     time.Time
+	Provider string
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+)
+
+// DefaultECBURL is the European Central Bank daily reference rates feed
+const DefaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBProvider fetches the European Central Bank daily reference rates,
+// published against EUR on working days around 16:00 CET
+type ECBProvider struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewECBProvider creates a provider reading the ECB feed at url
+func NewECBProvider(url string, timeout time.Duration) *ECBProvider {
+	if url == "" {
+		url = DefaultECBURL
+	}
+	return &ECBProvider{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements domain.Provider
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Fetch implements domain.Provider
+func (p *ECBProvider) Fetch(ctx context.Context) (*domain.RateTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB returned status %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode ECB rates: %w", err)
+	}
+
+	day := envelope.Cube.Cube
+	asOf, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECB rate date %q: %w", day.Time, err)
+	}
+
+	table := &domain.RateTable{
+		Base:     "EUR",
+		Rates:    make(map[string]*big.Rat, len(day.Rates)),
+		AsOf:     asOf,
+		Provider: p.Name(),
+	}
+	for _, r := range day.Rates {
+		rate, ok := new(big.Rat).SetString(r.Rate)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid ECB rate %q for %s", r.Rate, r.Currency)
+		}
+		table.Rates[r.Currency] = rate
+	}
+	if len(table.Rates) == 0 {
+		return nil, fmt.Errorf("ECB feed contained no rates")
+	}
+
+	return table, nil
+}
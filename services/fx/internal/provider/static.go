@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+)
+
+// StaticProvider serves fixed rates. It is meant for development and tests.
+type StaticProvider struct {
+	table *domain.RateTable
+}
+
+// NewStaticProvider parses rates of the form "USD=1.08,GBP=0.85" relative to base
+func NewStaticProvider(base, rates string) (*StaticProvider, error) {
+	table := &domain.RateTable{
+		Base:     strings.ToUpper(base),
+		Rates:    make(map[string]*big.Rat),
+		AsOf:     time.Now(),
+		Provider: "static",
+	}
+
+	for _, pair := range strings.Split(rates, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q, expected CUR=rate", pair)
+		}
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s", value, currency)
+		}
+		table.Rates[strings.ToUpper(strings.TrimSpace(currency))] = rate
+	}
+
+	return &StaticProvider{table: table}, nil
+}
+
+// Name implements domain.Provider
+func (p *StaticProvider) Name() string {
+	return "static"
+}
+
+// Fetch implements domain.Provider
+func (p *StaticProvider) Fetch(ctx context.Context) (*domain.RateTable, error) {
+	table := *p.table
+	return &table, nil
+}
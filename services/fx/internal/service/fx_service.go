@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+)
+
+// Options bounds how old cached rates may be before conversions are refused
+type Options struct {
+	// MaxCacheAge is the longest time since the last successful fetch
+	MaxCacheAge time.Duration
+	// MaxRateAge is the longest time since the provider published the
+	// rates. It must allow for weekends and holidays when no rates are
+	// published.
+	MaxRateAge time.Duration
+}
+
+// FXService converts amounts between currencies using cached rates that are
+// refreshed from a provider on a schedule
+type FXService struct {
+	provider domain.Provider
+	opts     Options
+	logger   *slog.Logger
+
+	mu    sync.RWMutex
+	table *domain.RateTable
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// New creates a new FXService
+func New(provider domain.Provider, opts Options, logger *slog.Logger) *FXService {
+	return &FXService{
+		provider: provider,
+		opts:     opts,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Refresh fetches the latest rates. On failure the previous rates are kept.
+func (s *FXService) Refresh(ctx context.Context) error {
+	table, err := s.provider.Fetch(ctx)
+	if err != nil {
+		s.logger.Error("Failed to fetch exchange rates", "provider", s.provider.Name(), "error", err)
+		return fmt.Errorf("provider error: %w", err)
+	}
+	table.FetchedAt = s.now()
+
+	s.mu.Lock()
+	s.table = table
+	s.mu.Unlock()
+
+	s.logger.Info("Exchange rates refreshed",
+		"provider", table.Provider,
+		"base", table.Base,
+		"currencies", len(table.Rates),
+		"asOf", table.AsOf)
+	return nil
+}
+
+// Run refreshes rates every interval until ctx is done. After a failed
+// refresh it retries every retryInterval until a fetch succeeds.
+func (s *FXService) Run(ctx context.Context, interval, retryInterval time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := s.Refresh(ctx); err != nil {
+				timer.Reset(retryInterval)
+			} else {
+				timer.Reset(interval)
+			}
+		}
+	}
+}
+
+// Convert converts amount minor units of from into minor units of to
+func (s *FXService) Convert(amount int64, from, to string) (*domain.Conversion, error) {
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	table, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	converted, rate, err := table.Convert(amount, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Conversion{
+		Amount:   converted,
+		From:     from,
+		To:       to,
+		Rate:     rate,
+		AsOf:     table.AsOf,
+		Provider: table.Provider,
+	}, nil
+}
+
+// Rates returns the cached rates relative to base, or to the provider's
+// base currency when base is empty
+func (s *FXService) Rates(base string) (*domain.RateTable, error) {
+	table, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return table.Rebase(strings.ToUpper(base))
+}
+
+// Ready reports whether fresh rates are available
+func (s *FXService) Ready() error {
+	_, err := s.current()
+	return err
+}
+
+// current returns the cached table if it is within the staleness bounds
+func (s *FXService) current() (*domain.RateTable, error) {
+	s.mu.RLock()
+	table := s.table
+	s.mu.RUnlock()
+
+	if table == nil {
+		return nil, domain.ErrNoRates
+	}
+
+	now := s.now()
+	if s.opts.MaxCacheAge > 0 && now.Sub(table.FetchedAt) > s.opts.MaxCacheAge {
+		return nil, fmt.Errorf("%w: last fetched %s", domain.ErrStaleRates, table.FetchedAt.Format(time.RFC3339))
+	}
+	if s.opts.MaxRateAge > 0 && now.Sub(table.AsOf) > s.opts.MaxRateAge {
+		return nil, fmt.Errorf("%w: published %s", domain.ErrStaleRates, table.AsOf.Format(time.RFC3339))
+	}
+	return table, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/fx/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider returns a fixed table or error
+type stubProvider struct {
+	table *domain.RateTable
+	err   error
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) Fetch(ctx context.Context) (*domain.RateTable, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	table := *p.table
+	return &table, nil
+}
+
+func rat(s string) *big.Rat {
+	r, _ := new(big.Rat).SetString(s)
+	return r
+}
+
+func newTestService(t *testing.T, now time.Time) (*FXService, *stubProvider) {
+	t.Helper()
+	provider := &stubProvider{table: &domain.RateTable{
+		Base: "EUR",
+		Rates: map[string]*big.Rat{
+			"USD": rat("1.0823"),
+			"JPY": rat("162.35"),
+			"KWD": rat("0.33215"),
+			"GBP": rat("0.8571"),
+		},
+		AsOf:     now.Add(-time.Hour),
+		Provider: "stub",
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := New(provider, Options{MaxCacheAge: 6 * time.Hour, MaxRateAge: 96 * time.Hour}, logger)
+	svc.now = func() time.Time { return now }
+	require.NoError(t, svc.Refresh(context.Background()))
+	return svc, provider
+}
+
+func TestConvert(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	svc, _ := newTestService(t, now)
+
+	tests := []struct {
+		name   string
+		amount int64
+		from   string
+		to     string
+		want   int64
+	}{
+		{"from base", 10000, "EUR", "USD", 10823},
+		{"to base", 10823, "usd", "eur", 10000},
+		{"cross rate", 10000, "USD", "GBP", 7919},                // 100 * 0.8571 / 1.0823 = 79.1925
+		{"zero-decimal target", 1999, "EUR", "JPY", 3245},        // 19.99 * 162.35 = 3245.3765
+		{"three-decimal target", 1000, "EUR", "KWD", 3322},       // 10 * 0.33215 = 3.3215
+		{"half rounds away from zero", 1000, "EUR", "JPY", 1624}, // 10.00 * 162.35 = 1623.5
+		{"negative half rounds away from zero", -1000, "EUR", "JPY", -1624},
+		{"same currency", 1234, "GBP", "GBP", 1234},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conversion, err := svc.Convert(tc.amount, tc.from, tc.to)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, conversion.Amount)
+			assert.Equal(t, "stub", conversion.Provider)
+		})
+	}
+
+	_, err := svc.Convert(100, "EUR", "XYZ")
+	assert.ErrorIs(t, err, domain.ErrUnknownCurrency)
+
+	// 92 233 720 368 547 758.07 EUR is more yen than an int64 holds
+	_, err = svc.Convert(math.MaxInt64, "EUR", "JPY")
+	assert.ErrorIs(t, err, domain.ErrOutOfRange)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestRoundHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		in   *big.Rat
+		want int64
+		ok   bool
+	}{
+		{big.NewRat(5, 2), 3, true},
+		{big.NewRat(-5, 2), -3, true},
+		{big.NewRat(9, 4), 2, true},
+		{big.NewRat(-1, 3), 0, true},
+		{new(big.Rat).SetInt64(math.MaxInt64), math.MaxInt64, true},
+		{new(big.Rat).Add(new(big.Rat).SetInt64(math.MaxInt64), big.NewRat(1, 2)), 0, false},
+		{new(big.Rat).Sub(new(big.Rat).SetInt64(math.MinInt64), big.NewRat(1, 2)), 0, false},
+	}
+	for _, tc := range tests {
+		got, ok := domain.RoundHalfAwayFromZero(tc.in)
+		assert.Equal(t, tc.ok, ok, tc.in.String())
+		assert.Equal(t, tc.want, got, tc.in.String())
+	}
+}
+
+func TestRatesRebase(t *testing.T) {
+	svc, _ := newTestService(t, time.Now())
+
+	table, err := svc.Rates("usd")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", table.Base)
+	assert.Equal(t, "0.9239582371", table.Rates["EUR"].FloatString(10))
+	_, hasSelf := table.Rates["USD"]
+	assert.False(t, hasSelf)
+}
+
+func TestStaleness(t *testing.T) {
+	start := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	svc, provider := newTestService(t, start)
+
+	// A failed refresh keeps serving the previous rates while they are fresh
+	provider.err = errors.New("connection reset")
+	svc.now = func() time.Time { return start.Add(time.Hour) }
+	assert.Error(t, svc.Refresh(context.Background()))
+	_, err := svc.Convert(100, "EUR", "USD")
+	assert.NoError(t, err)
+
+	// Once the cache is too old, conversions are refused
+	svc.now = func() time.Time { return start.Add(7 * time.Hour) }
+	_, err = svc.Convert(100, "EUR", "USD")
+	assert.ErrorIs(t, err, domain.ErrStaleRates)
+
+	// A successful fetch of rates published too long ago is stale too
+	provider.err = nil
+	provider.table.AsOf = start.Add(-5 * 24 * time.Hour)
+	require.NoError(t, svc.Refresh(context.Background()))
+	_, err = svc.Convert(100, "EUR", "USD")
+	assert.ErrorIs(t, err, domain.ErrStaleRates)
+}
+
+func TestNoRatesLoaded(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := New(&stubProvider{err: errors.New("down")}, Options{}, logger)
+
+	_, err := svc.Convert(100, "EUR", "USD")
+	assert.ErrorIs(t, err, domain.ErrNoRates)
+	assert.ErrorIs(t, svc.Ready(), domain.ErrNoRates)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/grpcserver"
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"github.com/bekbull/online-shop/pkg/scheduler"
+	"github.com/bekbull/online-shop/pkg/workers"
+	inventoryv1 "github.com/bekbull/online-shop/proto/inventory/v1"
+	"github.com/bekbull/online-shop/services/inventory/config"
+	grpcHandler "github.com/bekbull/online-shop/services/inventory/internal/api/grpc"
+	"github.com/bekbull/online-shop/services/inventory/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/inventory/internal/service"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	// Initialize logger
+	logger := logging.New(os.Stdout, logging.Options{Service: "inventory-service", Text: true})
+	logger.Info("Starting Inventory Service")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	// Connect to MongoDB
+	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", err)
+		}
+	}()
+	logger.Info("Connected to MongoDB")
+
+	// Create repository
+	inventoryRepo := mongodb.New(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), cfg.MongoDB.WriteTimeout)
+	if err := inventoryRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+
+	// Create service
+	inventoryService := service.New(inventoryRepo, cfg.Reservation.DefaultTTL, logger)
+
+	// Publish stock movement events if enabled
+	if cfg.Events.Enabled {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Events.RedisAddr,
+			Password: cfg.Events.RedisPassword,
+			DB:       cfg.Events.RedisDB,
+		})
+		defer redisClient.Close()
+
+		inventoryService.SetPublisher(eventbus.NewRedisBus(redisClient, eventbus.RedisConfig{
+			StreamPrefix: cfg.Events.StreamPrefix,
+			MaxLen:       cfg.Events.MaxLen,
+		}, logger))
+		logger.Info("Event publishing enabled", "redis", cfg.Events.RedisAddr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Release each reservation when it lapses
+	if cfg.Scheduler.Enabled {
+		jobStore := scheduler.NewMongoStore(mongoClient.Database(cfg.MongoDB.Database).Collection(cfg.Scheduler.Collection))
+		indexCtx, cancelIndex := context.WithTimeout(ctx, cfg.MongoDB.WriteTimeout)
+		if err := jobStore.EnsureIndexes(indexCtx, cfg.Scheduler.Retention); err != nil {
+			cancelIndex()
+			logger.Error("Failed to create job indexes", "error", err)
+			os.Exit(1)
+		}
+		cancelIndex()
+
+		jobs := scheduler.New(jobStore, scheduler.Options{
+			PollInterval: cfg.Scheduler.PollInterval,
+			Lease:        cfg.Scheduler.Lease,
+			BatchSize:    cfg.Scheduler.BatchSize,
+			StoreTimeout: cfg.MongoDB.WriteTimeout,
+		}, logger)
+		jobs.Register(service.ExpireReservationJob, inventoryService.ExpireReservation, scheduler.RetryPolicy{
+			MaxAttempts: 10,
+			Backoff:     5 * time.Second,
+			MaxBackoff:  5 * time.Minute,
+			Timeout:     cfg.MongoDB.WriteTimeout,
+		})
+		inventoryService.SetScheduler(jobs)
+		go jobs.Run(ctx)
+		logger.Info("Job scheduler enabled", "collection", cfg.Scheduler.Collection)
+	}
+
+	// Sweep for lapsed reservations the scheduler missed, e.g. because
+	// scheduling the job failed; one instance at a time sweeps
+	locker := locks.New(locks.NewMongoStore(mongoClient.Database(cfg.MongoDB.Database).Collection(cfg.Locks.Collection)),
+		locks.Options{TTL: cfg.Locks.TTL, StoreTimeout: cfg.MongoDB.WriteTimeout}, logger)
+	background := workers.NewGroup(workers.Options{Locker: locker}, logger)
+	if cfg.Reservation.ExpiryEnabled {
+		background.Add(workers.Worker{
+			Name:      "reservation-expiry",
+			Schedule:  workers.Every(cfg.Reservation.ExpiryScan),
+			Singleton: true,
+			Run: func(ctx context.Context) error {
+				_, err := inventoryService.ReleaseExpired(ctx, cfg.Reservation.ExpiryBatch)
+				return err
+			},
+		})
+	}
+	background.Start(ctx)
+
+	// Load TLS credentials; certificates are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	go creds.Watch(ctx)
+
+	// Setup gRPC server. Calls get request IDs, logs, metrics, panic
+	// recovery and the timeout of their method, as on the other services.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	endpoints, err := cfg.Server.EndpointPolicies()
+	if err != nil {
+		logger.Error("Failed to load endpoint policies", "error", err)
+		os.Exit(1)
+	}
+	chain := grpcserver.Chain{
+		Logger:    logger,
+		AccessLog: cfg.Server.AccessLog(),
+		Metrics:   middleware.NewMetrics(registry, "inventory_service"),
+		Endpoints: endpoints,
+	}
+	grpcServer := grpc.NewServer(append(chain.ServerOptions(), creds.ServerOption())...)
+	inventoryv1.RegisterInventoryServiceServer(grpcServer, grpcHandler.New(inventoryService, logger))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if cfg.Env != "production" {
+		reflection.Register(grpcServer)
+	}
+
+	// Setup HTTP server for health checks
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(middleware.Recover(logger))
+	router.Method(http.MethodGet, "/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: router,
+	}
+
+	// Start servers in goroutines
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go func() {
+		logger.Info("Starting gRPC server", "port", cfg.GRPCPort)
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("Failed to listen for gRPC", "error", err)
+			os.Exit(1)
+		}
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("gRPC server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for interrupt signal
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+	logger.Info("Received shutdown signal", "signal", sig)
+
+	// Report NOT_SERVING first so health-checking clients move away
+	// before connections are drained
+	healthServer.Shutdown()
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelShutdown()
+
+	logger.Info("Shutting down HTTP server")
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutting down gRPC server")
+	grpcServer.GracefulStop()
+
+	logger.Info("Stopping background workers")
+	if err := background.Stop(shutdownCtx); err != nil {
+		logger.Error("Background workers did not stop in time", "error", err)
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping the database to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
@@ -0,0 +1,172 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/inventory/v1"
+	"github.com/bekbull/online-shop/services/inventory/internal/domain"
+)
+
+// InventoryServer implements the gRPC InventoryService
+type InventoryServer struct {
+	pb.UnimplementedInventoryServiceServer
+	inventoryService InventoryService
+	logger           *slog.Logger
+}
+
+// InventoryService represents the business logic interface for inventory operations
+type InventoryService interface {
+	Reserve(ctx context.Context, operationID, productID, warehouseID, correlationID string, quantity int, ttl time.Duration) (*domain.Reservation, *domain.StockItem, error)
+	Release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error)
+	Commit(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error)
+	CompensateByCorrelation(ctx context.Context, correlationID string) ([]*domain.Reservation, error)
+	Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) (*domain.StockItem, error)
+	CheckStock(ctx context.Context, productID string, quantity int, warehouseID string) (bool, int, []*domain.StockItem, error)
+}
+
+// New creates a new InventoryServer
+func New(service InventoryService, logger *slog.Logger) *InventoryServer {
+	return &InventoryServer{
+		inventoryService: service,
+		logger:           logger,
+	}
+}
+
+// Reserve implements the Reserve RPC method
+func (s *InventoryServer) Reserve(ctx context.Context, req *pb.ReserveRequest) (*pb.ReservationResponse, error) {
+	s.logger.Info("gRPC Reserve called", "productID", req.ProductId, "quantity", req.Quantity, "correlationID", req.CorrelationId)
+
+	reservation, stock, err := s.inventoryService.Reserve(
+		ctx,
+		req.OperationId,
+		req.ProductId,
+		req.WarehouseId,
+		req.CorrelationId,
+		int(req.Quantity),
+		time.Duration(req.TtlSeconds)*time.Second,
+	)
+	if err != nil {
+		return nil, toStatus("failed to reserve stock", err)
+	}
+
+	return &pb.ReservationResponse{
+		Reservation: domainToProtoReservation(reservation),
+		Stock:       domainToProtoStock(stock),
+	}, nil
+}
+
+// Release implements the Release RPC method
+func (s *InventoryServer) Release(ctx context.Context, req *pb.ReleaseRequest) (*pb.ReservationResponse, error) {
+	s.logger.Info("gRPC Release called", "reservationID", req.ReservationId)
+
+	reservation, stock, err := s.inventoryService.Release(ctx, req.OperationId, req.ReservationId)
+	if err != nil {
+		return nil, toStatus("failed to release reservation", err)
+	}
+
+	return &pb.ReservationResponse{
+		Reservation: domainToProtoReservation(reservation),
+		Stock:       domainToProtoStock(stock),
+	}, nil
+}
+
+// Commit implements the Commit RPC method
+func (s *InventoryServer) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.ReservationResponse, error) {
+	s.logger.Info("gRPC Commit called", "reservationID", req.ReservationId)
+
+	reservation, stock, err := s.inventoryService.Commit(ctx, req.OperationId, req.ReservationId)
+	if err != nil {
+		return nil, toStatus("failed to commit reservation", err)
+	}
+
+	return &pb.ReservationResponse{
+		Reservation: domainToProtoReservation(reservation),
+		Stock:       domainToProtoStock(stock),
+	}, nil
+}
+
+// CompensateByCorrelation implements the CompensateByCorrelation RPC method
+func (s *InventoryServer) CompensateByCorrelation(ctx context.Context, req *pb.CompensateByCorrelationRequest) (*pb.CompensateByCorrelationResponse, error) {
+	s.logger.Info("gRPC CompensateByCorrelation called", "correlationID", req.CorrelationId)
+
+	reservations, err := s.inventoryService.CompensateByCorrelation(ctx, req.CorrelationId)
+	if err != nil {
+		return nil, toStatus("failed to compensate reservations", err)
+	}
+
+	resp := &pb.CompensateByCorrelationResponse{Reservations: make([]*pb.Reservation, len(reservations))}
+	for i, reservation := range reservations {
+		resp.Reservations[i] = domainToProtoReservation(reservation)
+	}
+	return resp, nil
+}
+
+// Adjust implements the Adjust RPC method
+func (s *InventoryServer) Adjust(ctx context.Context, req *pb.AdjustRequest) (*pb.AdjustResponse, error) {
+	s.logger.Info("gRPC Adjust called",
+		"productID", req.ProductId,
+		"warehouseID", req.WarehouseId,
+		"quantityChange", req.QuantityChange)
+
+	stock, err := s.inventoryService.Adjust(ctx, req.OperationId, req.ProductId, req.WarehouseId, int(req.QuantityChange), req.Reason)
+	if err != nil {
+		return nil, toStatus("failed to adjust stock", err)
+	}
+
+	return &pb.AdjustResponse{Stock: domainToProtoStock(stock)}, nil
+}
+
+// CheckStock implements the CheckStock RPC method
+func (s *InventoryServer) CheckStock(ctx context.Context, req *pb.CheckStockRequest) (*pb.CheckStockResponse, error) {
+	s.logger.Info("gRPC CheckStock called", "productID", req.ProductId, "quantity", req.Quantity)
+
+	available, total, levels, err := s.inventoryService.CheckStock(ctx, req.ProductId, int(req.Quantity), req.WarehouseId)
+	if err != nil {
+		return nil, toStatus("failed to check stock", err)
+	}
+
+	warehouses := make([]*pb.StockLevel, len(levels))
+	for i, level := range levels {
+		warehouses[i] = domainToProtoStock(level)
+	}
+
+	return &pb.CheckStockResponse{
+		Available:      available,
+		TotalAvailable: int32(total),
+		Warehouses:     warehouses,
+	}, nil
+}
+
+// toStatus converts err to a gRPC status, prefixing its message with msg
+func toStatus(msg string, err error) error {
+	return apperrors.ToGRPC(fmt.Errorf("%s: %w", msg, err))
+}
+
+// Helper functions to convert domain types to proto messages
+func domainToProtoReservation(r *domain.Reservation) *pb.Reservation {
+	return &pb.Reservation{
+		Id:            r.ID.Hex(),
+		ProductId:     r.ProductID,
+		WarehouseId:   r.WarehouseID,
+		Quantity:      int32(r.Quantity),
+		Status:        r.Status,
+		ExpiresAt:     r.ExpiresAt.Unix(),
+		CreatedAt:     r.CreatedAt.Unix(),
+		UpdatedAt:     r.UpdatedAt.Unix(),
+		CorrelationId: r.CorrelationID,
+	}
+}
+
+func domainToProtoStock(s *domain.StockItem) *pb.StockLevel {
+	return &pb.StockLevel{
+		ProductId:   s.ProductID,
+		WarehouseId: s.WarehouseID,
+		OnHand:      int32(s.OnHand),
+		Reserved:    int32(s.Reserved),
+		Available:   int32(s.Available()),
+	}
+}
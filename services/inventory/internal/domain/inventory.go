@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain errors returned by the repository and service
+var (
+	ErrNotFound          = apperrors.New(apperrors.NotFound, "not found")
+	ErrInsufficientStock = apperrors.New(apperrors.Conflict, "insufficient stock").WithReason("INSUFFICIENT_STOCK")
+	ErrInvalidState      = apperrors.New(apperrors.Conflict, "invalid reservation state").WithReason("INVALID_RESERVATION_STATE")
+)
+
+// Reservation statuses
+const (
+	ReservationPending   = "pending"
+	ReservationReleased  = "released"
+	ReservationCommitted = "committed"
+)
+
+// Ledger entry types
+const (
+	OperationReserve = "reserve"
+	OperationRelease = "release"
+	OperationCommit  = "commit"
+	OperationAdjust  = "adjust"
+)
+
+// StockItem is the stock of one product in one warehouse
+type StockItem struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID   string             `bson:"product_id" json:"product_id"`
+	WarehouseID string             `bson:"warehouse_id" json:"warehouse_id"`
+	OnHand      int                `bson:"on_hand" json:"on_hand"`
+	Reserved    int                `bson:"reserved" json:"reserved"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Available returns the quantity that can still be reserved
+func (s *StockItem) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Reservation holds stock for a pending order until it is committed, released or expires
+type Reservation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID   string             `bson:"product_id" json:"product_id"`
+	WarehouseID string             `bson:"warehouse_id" json:"warehouse_id"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	Status      string             `bson:"status" json:"status"`
+	// CorrelationID groups the reservations of one checkout, e.g. by order
+	// ID, so that they can be compensated together
+	CorrelationID string    `bson:"correlation_id,omitempty" json:"correlation_id,omitempty"`
+	ExpiresAt     time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// LedgerEntry records a single stock movement. OperationID is unique and
+// makes every operation idempotent.
+type LedgerEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OperationID   string             `bson:"operation_id" json:"operation_id"`
+	Type          string             `bson:"type" json:"type"`
+	ProductID     string             `bson:"product_id" json:"product_id"`
+	WarehouseID   string             `bson:"warehouse_id" json:"warehouse_id"`
+	OnHandDelta   int                `bson:"on_hand_delta" json:"on_hand_delta"`
+	ReservedDelta int                `bson:"reserved_delta" json:"reserved_delta"`
+	ReservationID string             `bson:"reservation_id,omitempty" json:"reservation_id,omitempty"`
+	Reason        string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ReserveParams defines a request to hold stock
+type ReserveParams struct {
+	OperationID   string
+	ProductID     string
+	WarehouseID   string
+	CorrelationID string
+	Quantity      int
+	ExpiresAt     time.Time
+}
+
+// AdjustParams defines a request to change on-hand stock
+type AdjustParams struct {
+	OperationID    string
+	ProductID      string
+	WarehouseID    string
+	QuantityChange int
+	Reason         string
+}
+
+// InventoryRepository defines the interface for inventory data operations.
+// Every mutating method is idempotent on its operationID.
+type InventoryRepository interface {
+	Reserve(ctx context.Context, params ReserveParams) (*Reservation, *StockItem, error)
+	Release(ctx context.Context, operationID, reservationID string) (*Reservation, *StockItem, error)
+	Commit(ctx context.Context, operationID, reservationID string) (*Reservation, *StockItem, error)
+	Adjust(ctx context.Context, params AdjustParams) (*StockItem, error)
+	GetStock(ctx context.Context, productID string) ([]*StockItem, error)
+	ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*Reservation, error)
+	// ReservationsByCorrelation returns every reservation of a correlation,
+	// oldest first
+	ReservationsByCorrelation(ctx context.Context, correlationID string) ([]*Reservation, error)
+}
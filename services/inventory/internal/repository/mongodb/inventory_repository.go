@@ -0,0 +1,441 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bekbull/online-shop/services/inventory/config"
+	"github.com/bekbull/online-shop/services/inventory/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InventoryRepository implements the domain.InventoryRepository interface with MongoDB
+type InventoryRepository struct {
+	client       *mongo.Client
+	stock        *mongo.Collection
+	reservations *mongo.Collection
+	ledger       *mongo.Collection
+	config       *config.MongoDBConfig
+}
+
+// New creates a new InventoryRepository with MongoDB
+func New(client *mongo.Client, cfg *config.MongoDBConfig) *InventoryRepository {
+	db := client.Database(cfg.Database)
+	return &InventoryRepository{
+		client:       client,
+		stock:        db.Collection("stock"),
+		reservations: db.Collection("reservations"),
+		ledger:       db.Collection("ledger"),
+		config:       cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes the repository relies on for correctness
+func (r *InventoryRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.stock.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "warehouse_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	if _, err := r.ledger.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "operation_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.reservations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "correlation_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+// Reserve holds stock for the given product, choosing the warehouse with the
+// most available stock when none is specified
+func (r *InventoryRepository) Reserve(ctx context.Context, params domain.ReserveParams) (*domain.Reservation, *domain.StockItem, error) {
+	var reservation *domain.Reservation
+	var stock *domain.StockItem
+
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		// Replay of an operation that already succeeded
+		if entry, err := r.findLedgerEntry(sc, params.OperationID); err != nil {
+			return err
+		} else if entry != nil {
+			reservation, stock, err = r.loadReservation(sc, entry.ReservationID)
+			return err
+		}
+
+		candidates, err := r.candidateWarehouses(sc, params.ProductID, params.WarehouseID, params.Quantity)
+		if err != nil {
+			return err
+		}
+
+		for _, candidate := range candidates {
+			stock, err = r.incReserved(sc, candidate.ID, params.Quantity)
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, domain.ErrInsufficientStock) {
+				return err
+			}
+		}
+		if stock == nil {
+			return domain.ErrInsufficientStock
+		}
+
+		now := time.Now()
+		reservation = &domain.Reservation{
+			ID:            primitive.NewObjectID(),
+			ProductID:     params.ProductID,
+			WarehouseID:   stock.WarehouseID,
+			Quantity:      params.Quantity,
+			Status:        domain.ReservationPending,
+			CorrelationID: params.CorrelationID,
+			ExpiresAt:     params.ExpiresAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if _, err := r.reservations.InsertOne(sc, reservation); err != nil {
+			return err
+		}
+
+		return r.insertLedgerEntry(sc, &domain.LedgerEntry{
+			OperationID:   params.OperationID,
+			Type:          domain.OperationReserve,
+			ProductID:     params.ProductID,
+			WarehouseID:   stock.WarehouseID,
+			ReservedDelta: params.Quantity,
+			ReservationID: reservation.ID.Hex(),
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reservation, stock, nil
+}
+
+// Release returns a pending reservation's stock to the available pool
+func (r *InventoryRepository) Release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	return r.finishReservation(ctx, operationID, reservationID, domain.OperationRelease, domain.ReservationReleased)
+}
+
+// Commit converts a pending reservation into a permanent stock decrement
+func (r *InventoryRepository) Commit(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	return r.finishReservation(ctx, operationID, reservationID, domain.OperationCommit, domain.ReservationCommitted)
+}
+
+func (r *InventoryRepository) finishReservation(ctx context.Context, operationID, reservationID, operationType, status string) (*domain.Reservation, *domain.StockItem, error) {
+	var reservation *domain.Reservation
+	var stock *domain.StockItem
+
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if entry, err := r.findLedgerEntry(sc, operationID); err != nil {
+			return err
+		} else if entry != nil {
+			reservation, stock, err = r.loadReservation(sc, entry.ReservationID)
+			return err
+		}
+
+		objID, err := primitive.ObjectIDFromHex(reservationID)
+		if err != nil {
+			return domain.ErrNotFound
+		}
+
+		// Only pending reservations can transition
+		var updated domain.Reservation
+		err = r.reservations.FindOneAndUpdate(sc,
+			bson.M{"_id": objID, "status": domain.ReservationPending},
+			bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if count, _ := r.reservations.CountDocuments(sc, bson.M{"_id": objID}); count == 0 {
+				return domain.ErrNotFound
+			}
+			return domain.ErrInvalidState
+		}
+		if err != nil {
+			return err
+		}
+		reservation = &updated
+
+		inc := bson.M{"reserved": -updated.Quantity}
+		onHandDelta := 0
+		if operationType == domain.OperationCommit {
+			inc["on_hand"] = -updated.Quantity
+			onHandDelta = -updated.Quantity
+		}
+
+		var item domain.StockItem
+		err = r.stock.FindOneAndUpdate(sc,
+			bson.M{"product_id": updated.ProductID, "warehouse_id": updated.WarehouseID},
+			bson.M{"$inc": inc, "$set": bson.M{"updated_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&item)
+		if err != nil {
+			return err
+		}
+		stock = &item
+
+		return r.insertLedgerEntry(sc, &domain.LedgerEntry{
+			OperationID:   operationID,
+			Type:          operationType,
+			ProductID:     updated.ProductID,
+			WarehouseID:   updated.WarehouseID,
+			OnHandDelta:   onHandDelta,
+			ReservedDelta: -updated.Quantity,
+			ReservationID: reservationID,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reservation, stock, nil
+}
+
+// Adjust changes on-hand stock for a warehouse, creating the stock item on
+// first restock. On-hand stock can never drop below what is reserved.
+func (r *InventoryRepository) Adjust(ctx context.Context, params domain.AdjustParams) (*domain.StockItem, error) {
+	var stock *domain.StockItem
+
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if entry, err := r.findLedgerEntry(sc, params.OperationID); err != nil {
+			return err
+		} else if entry != nil {
+			stock, err = r.findStockItem(sc, entry.ProductID, entry.WarehouseID)
+			return err
+		}
+
+		filter := bson.M{"product_id": params.ProductID, "warehouse_id": params.WarehouseID}
+		if params.QuantityChange < 0 {
+			filter["$expr"] = bson.M{"$gte": bson.A{
+				bson.M{"$subtract": bson.A{bson.M{"$add": bson.A{"$on_hand", params.QuantityChange}}, "$reserved"}},
+				0,
+			}}
+		}
+
+		var item domain.StockItem
+		err := r.stock.FindOneAndUpdate(sc,
+			filter,
+			bson.M{
+				"$inc":         bson.M{"on_hand": params.QuantityChange},
+				"$set":         bson.M{"updated_at": time.Now()},
+				"$setOnInsert": bson.M{"reserved": 0},
+			},
+			options.FindOneAndUpdate().
+				SetReturnDocument(options.After).
+				SetUpsert(params.QuantityChange > 0),
+		).Decode(&item)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.ErrInsufficientStock
+		}
+		if err != nil {
+			return err
+		}
+		stock = &item
+
+		return r.insertLedgerEntry(sc, &domain.LedgerEntry{
+			OperationID: params.OperationID,
+			Type:        domain.OperationAdjust,
+			ProductID:   params.ProductID,
+			WarehouseID: params.WarehouseID,
+			OnHandDelta: params.QuantityChange,
+			Reason:      params.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// GetStock returns the stock of a product in every warehouse
+func (r *InventoryRepository) GetStock(ctx context.Context, productID string) ([]*domain.StockItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.stock.Find(ctx, bson.M{"product_id": productID},
+		options.Find().SetSort(bson.D{{Key: "warehouse_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []*domain.StockItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ExpiredReservations returns pending reservations whose hold has lapsed
+func (r *InventoryRepository) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*domain.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.reservations.Find(ctx,
+		bson.M{"status": domain.ReservationPending, "expires_at": bson.M{"$lte": before}},
+		options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*domain.Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// ReservationsByCorrelation returns every reservation of a correlation,
+// oldest first
+func (r *InventoryRepository) ReservationsByCorrelation(ctx context.Context, correlationID string) ([]*domain.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.reservations.Find(ctx,
+		bson.M{"correlation_id": correlationID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*domain.Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// Helper functions
+
+// withTransaction runs fn in a MongoDB transaction bounded by the write
+// timeout and by the caller's deadline, whichever is sooner
+func (r *InventoryRepository) withTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// candidateWarehouses returns stock items that could satisfy the request,
+// most available first
+func (r *InventoryRepository) candidateWarehouses(ctx context.Context, productID, warehouseID string, quantity int) ([]*domain.StockItem, error) {
+	filter := bson.M{"product_id": productID}
+	if warehouseID != "" {
+		filter["warehouse_id"] = warehouseID
+	}
+
+	cursor, err := r.stock.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var items []*domain.StockItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	candidates := items[:0]
+	for _, item := range items {
+		if item.Available() >= quantity {
+			candidates = append(candidates, item)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Available() > candidates[j].Available()
+	})
+	return candidates, nil
+}
+
+// incReserved atomically reserves quantity if enough stock is still available
+func (r *InventoryRepository) incReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*domain.StockItem, error) {
+	var item domain.StockItem
+	err := r.stock.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":   id,
+			"$expr": bson.M{"$gte": bson.A{bson.M{"$subtract": bson.A{"$on_hand", "$reserved"}}, quantity}},
+		},
+		bson.M{"$inc": bson.M{"reserved": quantity}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrInsufficientStock
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *InventoryRepository) findLedgerEntry(ctx context.Context, operationID string) (*domain.LedgerEntry, error) {
+	var entry domain.LedgerEntry
+	err := r.ledger.FindOne(ctx, bson.M{"operation_id": operationID}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *InventoryRepository) insertLedgerEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+	_, err := r.ledger.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *InventoryRepository) loadReservation(ctx context.Context, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	objID, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return nil, nil, domain.ErrNotFound
+	}
+
+	var reservation domain.Reservation
+	err = r.reservations.FindOne(ctx, bson.M{"_id": objID}).Decode(&reservation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stock, err := r.findStockItem(ctx, reservation.ProductID, reservation.WarehouseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &reservation, stock, nil
+}
+
+func (r *InventoryRepository) findStockItem(ctx context.Context, productID, warehouseID string) (*domain.StockItem, error) {
+	var item domain.StockItem
+	err := r.stock.FindOne(ctx, bson.M{"product_id": productID, "warehouse_id": warehouseID}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
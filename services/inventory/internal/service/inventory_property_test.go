@@ -0,0 +1,557 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/inventory/config"
+	"github.com/bekbull/online-shop/services/inventory/internal/domain"
+	"github.com/bekbull/online-shop/services/inventory/internal/repository/mongodb"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pgregory.net/rapid"
+)
+
+// The property tests run random sequences of Adjust, Reserve, Release and
+// Commit, with replays of earlier operations, against a model of the stock
+// and check after every step that:
+//   - on-hand stock is never negative and never below what is reserved
+//   - on-hand stock is the sum of adjustments minus commits, and reserved
+//     stock the sum of pending reservations
+//   - replaying an operation returns its result and changes nothing
+//   - a reservation is committed or released at most once
+//
+// They run against an in-memory repository, and against MongoDB when
+// INVENTORY_TEST_MONGODB_URI points at a replica set, e.g.
+//
+//	INVENTORY_TEST_MONGODB_URI='mongodb://localhost:27017/?replicaSet=rs0' go test -run TestInventoryProperties ./services/inventory/internal/service
+
+var propertyWarehouses = []string{"w1", "w2", "w3"}
+
+func TestInventoryProperties(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			runInventoryMachine(t, newMemoryRepository(), "product")
+		})
+	})
+
+	t.Run("mongodb", func(t *testing.T) {
+		uri := os.Getenv("INVENTORY_TEST_MONGODB_URI")
+		if uri == "" {
+			t.Skip("INVENTORY_TEST_MONGODB_URI is not set")
+		}
+		repo := newMongoRepository(t, uri)
+		checks := 0
+		rapid.Check(t, func(t *rapid.T) {
+			// Each check gets its own product, so that checks sharing the
+			// database do not see each other's stock
+			checks++
+			runInventoryMachine(t, repo, fmt.Sprintf("product-%d", checks))
+		})
+	})
+}
+
+// inventoryMachine is the model the service is checked against
+type inventoryMachine struct {
+	svc          *InventoryService
+	repo         domain.InventoryRepository
+	productID    string
+	operations   int
+	stock        map[string]*modelStock
+	reservations []*modelReservation
+	succeeded    []replayableOperation
+}
+
+// modelStock is the expected stock of a warehouse along with the movements
+// that produced it
+type modelStock struct {
+	onHand    int
+	reserved  int
+	adjusted  int
+	committed int
+}
+
+type modelReservation struct {
+	id          string
+	warehouseID string
+	quantity    int
+	status      string
+}
+
+// replayableOperation repeats a successful operation with its operation ID
+// and returns the reservation ID it reports
+type replayableOperation struct {
+	name          string
+	reservationID string
+	run           func() (string, error)
+}
+
+func runInventoryMachine(t *rapid.T, repo domain.InventoryRepository, productID string) {
+	m := &inventoryMachine{
+		svc:       New(repo, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		repo:      repo,
+		productID: productID,
+		stock:     make(map[string]*modelStock),
+	}
+	t.Repeat(map[string]func(*rapid.T){
+		"adjust":  m.adjust,
+		"reserve": m.reserve,
+		"release": func(t *rapid.T) { m.finish(t, domain.OperationRelease) },
+		"commit":  func(t *rapid.T) { m.finish(t, domain.OperationCommit) },
+		"unknown": m.finishUnknown,
+		"replay":  m.replay,
+		"":        m.check,
+	})
+}
+
+func (m *inventoryMachine) nextOperationID() string {
+	m.operations++
+	return fmt.Sprintf("%s-op-%d", m.productID, m.operations)
+}
+
+func (m *inventoryMachine) adjust(t *rapid.T) {
+	warehouseID := rapid.SampledFrom(propertyWarehouses).Draw(t, "warehouse")
+	change := rapid.IntRange(-10, 10).Filter(func(n int) bool { return n != 0 }).Draw(t, "change")
+	operationID := m.nextOperationID()
+
+	run := func() (string, error) {
+		_, err := m.svc.Adjust(context.Background(), operationID, m.productID, warehouseID, change, "property test")
+		return "", err
+	}
+	stock, err := m.svc.Adjust(context.Background(), operationID, m.productID, warehouseID, change, "property test")
+
+	expected, exists := m.stock[warehouseID]
+	if !exists && change < 0 || exists && expected.onHand+change < expected.reserved {
+		require.ErrorIs(t, err, domain.ErrInsufficientStock)
+		return
+	}
+	require.NoError(t, err)
+	if !exists {
+		expected = &modelStock{}
+		m.stock[warehouseID] = expected
+	}
+	expected.onHand += change
+	expected.adjusted += change
+	requireStock(t, expected, stock)
+	m.succeeded = append(m.succeeded, replayableOperation{name: "adjust " + operationID, run: run})
+}
+
+func (m *inventoryMachine) reserve(t *rapid.T) {
+	warehouseID := rapid.SampledFrom(append([]string{""}, propertyWarehouses...)).Draw(t, "warehouse")
+	quantity := rapid.IntRange(1, 10).Draw(t, "quantity")
+	operationID := m.nextOperationID()
+
+	// Without a warehouse the one with the most available stock is used
+	best := 0
+	for id, expected := range m.stock {
+		if (warehouseID == "" || warehouseID == id) && expected.onHand-expected.reserved > best {
+			best = expected.onHand - expected.reserved
+		}
+	}
+
+	reservation, stock, err := m.svc.Reserve(context.Background(), operationID, m.productID, warehouseID, "", quantity, time.Hour)
+	if best < quantity {
+		require.ErrorIs(t, err, domain.ErrInsufficientStock)
+		return
+	}
+	require.NoError(t, err)
+	if warehouseID != "" {
+		require.Equal(t, warehouseID, stock.WarehouseID)
+	}
+	expected := m.stock[stock.WarehouseID]
+	require.NotNil(t, expected, "reserved from unknown warehouse %q", stock.WarehouseID)
+	require.Equal(t, best, expected.onHand-expected.reserved, "reserved from %q, which does not have the most available", stock.WarehouseID)
+	require.Equal(t, domain.ReservationPending, reservation.Status)
+	require.Equal(t, quantity, reservation.Quantity)
+
+	expected.reserved += quantity
+	requireStock(t, expected, stock)
+
+	id := reservation.ID.Hex()
+	m.reservations = append(m.reservations, &modelReservation{
+		id:          id,
+		warehouseID: stock.WarehouseID,
+		quantity:    quantity,
+		status:      domain.ReservationPending,
+	})
+	m.succeeded = append(m.succeeded, replayableOperation{
+		name:          "reserve " + operationID,
+		reservationID: id,
+		run: func() (string, error) {
+			reservation, _, err := m.svc.Reserve(context.Background(), operationID, m.productID, warehouseID, "", quantity, time.Hour)
+			if err != nil {
+				return "", err
+			}
+			return reservation.ID.Hex(), nil
+		},
+	})
+}
+
+// finish releases or commits a reservation, which only succeeds once and
+// only while the reservation is pending
+func (m *inventoryMachine) finish(t *rapid.T, operation string) {
+	if len(m.reservations) == 0 {
+		t.Skip("no reservations")
+	}
+	reservation := m.reservations[rapid.IntRange(0, len(m.reservations)-1).Draw(t, "reservation")]
+	operationID := m.nextOperationID()
+
+	finish := m.svc.Release
+	status := domain.ReservationReleased
+	if operation == domain.OperationCommit {
+		finish = m.svc.Commit
+		status = domain.ReservationCommitted
+	}
+	run := func() (string, error) {
+		finished, _, err := finish(context.Background(), operationID, reservation.id)
+		if err != nil {
+			return "", err
+		}
+		return finished.ID.Hex(), nil
+	}
+
+	finished, stock, err := finish(context.Background(), operationID, reservation.id)
+	if reservation.status != domain.ReservationPending {
+		require.ErrorIs(t, err, domain.ErrInvalidState)
+		return
+	}
+	require.NoError(t, err)
+	require.Equal(t, status, finished.Status)
+
+	// Both give the reservation back; only a commit takes it off the shelf
+	expected := m.stock[reservation.warehouseID]
+	expected.reserved -= reservation.quantity
+	if operation == domain.OperationCommit {
+		expected.onHand -= reservation.quantity
+		expected.committed += reservation.quantity
+	}
+	requireStock(t, expected, stock)
+
+	reservation.status = status
+	m.succeeded = append(m.succeeded, replayableOperation{
+		name:          operation + " " + operationID,
+		reservationID: reservation.id,
+		run:           run,
+	})
+}
+
+func (m *inventoryMachine) finishUnknown(t *rapid.T) {
+	commit := rapid.Bool().Draw(t, "commit")
+	reservationID := rapid.SampledFrom([]string{primitive.NewObjectID().Hex(), "not-an-object-id"}).Draw(t, "reservationID")
+
+	finish := m.svc.Release
+	if commit {
+		finish = m.svc.Commit
+	}
+	_, _, err := finish(context.Background(), m.nextOperationID(), reservationID)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// replay repeats a successful operation, as a client would after losing
+// the response
+func (m *inventoryMachine) replay(t *rapid.T) {
+	if len(m.succeeded) == 0 {
+		t.Skip("no operations to replay")
+	}
+	operation := m.succeeded[rapid.IntRange(0, len(m.succeeded)-1).Draw(t, "operation")]
+
+	before := m.snapshot(t)
+	reservationID, err := operation.run()
+	require.NoError(t, err, operation.name)
+	require.Equal(t, operation.reservationID, reservationID, operation.name)
+	require.Equal(t, before, m.snapshot(t), "replaying %s changed the stock", operation.name)
+}
+
+// check compares the repository's stock with the model after every step
+func (m *inventoryMachine) check(t *rapid.T) {
+	reserved := make(map[string]int)
+	for _, reservation := range m.reservations {
+		if reservation.status == domain.ReservationPending {
+			reserved[reservation.warehouseID] += reservation.quantity
+		}
+	}
+
+	items, err := m.repo.GetStock(context.Background(), m.productID)
+	require.NoError(t, err)
+	require.Len(t, items, len(m.stock))
+	for _, item := range items {
+		require.GreaterOrEqual(t, item.OnHand, 0, "on hand in %s", item.WarehouseID)
+		require.GreaterOrEqual(t, item.Reserved, 0, "reserved in %s", item.WarehouseID)
+		require.LessOrEqual(t, item.Reserved, item.OnHand, "reserved above on hand in %s", item.WarehouseID)
+
+		expected := m.stock[item.WarehouseID]
+		require.NotNil(t, expected, "unexpected warehouse %q", item.WarehouseID)
+		requireStock(t, expected, item)
+		require.Equal(t, expected.adjusted-expected.committed, expected.onHand, "on hand in %s", item.WarehouseID)
+		require.Equal(t, reserved[item.WarehouseID], expected.reserved, "reserved in %s", item.WarehouseID)
+	}
+}
+
+func (m *inventoryMachine) snapshot(t *rapid.T) map[string][2]int {
+	items, err := m.repo.GetStock(context.Background(), m.productID)
+	require.NoError(t, err)
+	snapshot := make(map[string][2]int, len(items))
+	for _, item := range items {
+		snapshot[item.WarehouseID] = [2]int{item.OnHand, item.Reserved}
+	}
+	return snapshot
+}
+
+func requireStock(t *rapid.T, expected *modelStock, actual *domain.StockItem) {
+	t.Helper()
+	require.Equal(t, expected.onHand, actual.OnHand, "on hand in %s", actual.WarehouseID)
+	require.Equal(t, expected.reserved, actual.Reserved, "reserved in %s", actual.WarehouseID)
+}
+
+func newMongoRepository(t *testing.T, uri string) *mongodb.InventoryRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx, nil))
+
+	cfg := &config.MongoDBConfig{
+		Database:     fmt.Sprintf("inventory_property_test_%d", time.Now().UnixNano()),
+		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  5 * time.Second,
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		client.Database(cfg.Database).Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	repo := mongodb.New(client, cfg)
+	require.NoError(t, repo.EnsureIndexes(ctx))
+	return repo
+}
+
+// memoryRepository is an in-memory domain.InventoryRepository with the
+// same semantics as the MongoDB one
+type memoryRepository struct {
+	mu           sync.Mutex
+	stock        map[[2]string]*domain.StockItem
+	reservations map[string]*domain.Reservation
+	ledger       map[string]*domain.LedgerEntry
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		stock:        make(map[[2]string]*domain.StockItem),
+		reservations: make(map[string]*domain.Reservation),
+		ledger:       make(map[string]*domain.LedgerEntry),
+	}
+}
+
+func (r *memoryRepository) Reserve(ctx context.Context, params domain.ReserveParams) (*domain.Reservation, *domain.StockItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.ledger[params.OperationID]; ok {
+		return r.loadReservation(entry.ReservationID)
+	}
+
+	var candidates []*domain.StockItem
+	for _, item := range r.stock {
+		if item.ProductID == params.ProductID &&
+			(params.WarehouseID == "" || item.WarehouseID == params.WarehouseID) &&
+			item.Available() >= params.Quantity {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, domain.ErrInsufficientStock
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Available() != candidates[j].Available() {
+			return candidates[i].Available() > candidates[j].Available()
+		}
+		return candidates[i].WarehouseID < candidates[j].WarehouseID
+	})
+
+	now := time.Now()
+	stock := candidates[0]
+	stock.Reserved += params.Quantity
+	stock.UpdatedAt = now
+	reservation := &domain.Reservation{
+		ID:            primitive.NewObjectID(),
+		ProductID:     params.ProductID,
+		WarehouseID:   stock.WarehouseID,
+		Quantity:      params.Quantity,
+		Status:        domain.ReservationPending,
+		CorrelationID: params.CorrelationID,
+		ExpiresAt:     params.ExpiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	r.reservations[reservation.ID.Hex()] = reservation
+	r.ledger[params.OperationID] = &domain.LedgerEntry{
+		OperationID:   params.OperationID,
+		Type:          domain.OperationReserve,
+		ProductID:     params.ProductID,
+		WarehouseID:   stock.WarehouseID,
+		ReservedDelta: params.Quantity,
+		ReservationID: reservation.ID.Hex(),
+		CreatedAt:     now,
+	}
+	return r.loadReservation(reservation.ID.Hex())
+}
+
+func (r *memoryRepository) Release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	return r.finishReservation(operationID, reservationID, domain.OperationRelease, domain.ReservationReleased)
+}
+
+func (r *memoryRepository) Commit(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	return r.finishReservation(operationID, reservationID, domain.OperationCommit, domain.ReservationCommitted)
+}
+
+func (r *memoryRepository) finishReservation(operationID, reservationID, operationType, status string) (*domain.Reservation, *domain.StockItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.ledger[operationID]; ok {
+		return r.loadReservation(entry.ReservationID)
+	}
+
+	reservation, ok := r.reservations[reservationID]
+	if !ok {
+		return nil, nil, domain.ErrNotFound
+	}
+	if reservation.Status != domain.ReservationPending {
+		return nil, nil, domain.ErrInvalidState
+	}
+
+	now := time.Now()
+	reservation.Status = status
+	reservation.UpdatedAt = now
+	stock := r.stock[[2]string{reservation.ProductID, reservation.WarehouseID}]
+	stock.Reserved -= reservation.Quantity
+	onHandDelta := 0
+	if operationType == domain.OperationCommit {
+		stock.OnHand -= reservation.Quantity
+		onHandDelta = -reservation.Quantity
+	}
+	stock.UpdatedAt = now
+
+	r.ledger[operationID] = &domain.LedgerEntry{
+		OperationID:   operationID,
+		Type:          operationType,
+		ProductID:     reservation.ProductID,
+		WarehouseID:   reservation.WarehouseID,
+		OnHandDelta:   onHandDelta,
+		ReservedDelta: -reservation.Quantity,
+		ReservationID: reservationID,
+		CreatedAt:     now,
+	}
+	return r.loadReservation(reservationID)
+}
+
+func (r *memoryRepository) Adjust(ctx context.Context, params domain.AdjustParams) (*domain.StockItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.ledger[params.OperationID]; ok {
+		stock, ok := r.stock[[2]string{entry.ProductID, entry.WarehouseID}]
+		if !ok {
+			return nil, domain.ErrNotFound
+		}
+		copied := *stock
+		return &copied, nil
+	}
+
+	key := [2]string{params.ProductID, params.WarehouseID}
+	stock, ok := r.stock[key]
+	switch {
+	case !ok && params.QuantityChange < 0:
+		return nil, domain.ErrInsufficientStock
+	case !ok:
+		stock = &domain.StockItem{ID: primitive.NewObjectID(), ProductID: params.ProductID, WarehouseID: params.WarehouseID}
+		r.stock[key] = stock
+	case params.QuantityChange < 0 && stock.OnHand+params.QuantityChange-stock.Reserved < 0:
+		return nil, domain.ErrInsufficientStock
+	}
+
+	now := time.Now()
+	stock.OnHand += params.QuantityChange
+	stock.UpdatedAt = now
+	r.ledger[params.OperationID] = &domain.LedgerEntry{
+		OperationID: params.OperationID,
+		Type:        domain.OperationAdjust,
+		ProductID:   params.ProductID,
+		WarehouseID: params.WarehouseID,
+		OnHandDelta: params.QuantityChange,
+		Reason:      params.Reason,
+		CreatedAt:   now,
+	}
+	copied := *stock
+	return &copied, nil
+}
+
+func (r *memoryRepository) GetStock(ctx context.Context, productID string) ([]*domain.StockItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var items []*domain.StockItem
+	for _, item := range r.stock {
+		if item.ProductID == productID {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].WarehouseID < items[j].WarehouseID })
+	return items, nil
+}
+
+func (r *memoryRepository) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*domain.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reservations []*domain.Reservation
+	for _, reservation := range r.reservations {
+		if reservation.Status == domain.ReservationPending && !reservation.ExpiresAt.After(before) {
+			copied := *reservation
+			reservations = append(reservations, &copied)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ExpiresAt.Before(reservations[j].ExpiresAt) })
+	if len(reservations) > limit {
+		reservations = reservations[:limit]
+	}
+	return reservations, nil
+}
+
+func (r *memoryRepository) ReservationsByCorrelation(ctx context.Context, correlationID string) ([]*domain.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reservations []*domain.Reservation
+	for _, reservation := range r.reservations {
+		if reservation.CorrelationID == correlationID {
+			copied := *reservation
+			reservations = append(reservations, &copied)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].ID.Hex() < reservations[j].ID.Hex()
+	})
+	return reservations, nil
+}
+
+// loadReservation returns copies of a reservation and its stock item
+func (r *memoryRepository) loadReservation(reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	reservation, ok := r.reservations[reservationID]
+	if !ok {
+		return nil, nil, domain.ErrNotFound
+	}
+	stock, ok := r.stock[[2]string{reservation.ProductID, reservation.WarehouseID}]
+	if !ok {
+		return nil, nil, domain.ErrNotFound
+	}
+	copiedReservation, copiedStock := *reservation, *stock
+	return &copiedReservation, &copiedStock, nil
+}
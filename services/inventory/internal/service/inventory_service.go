@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/scheduler"
+	"github.com/bekbull/online-shop/services/inventory/internal/domain"
+)
+
+// ExpireReservationJob is the scheduler job type that releases a reservation
+// once its hold lapses
+const ExpireReservationJob = "inventory.reservation.expire"
+
+// JobScheduler schedules delayed jobs
+type JobScheduler interface {
+	Schedule(ctx context.Context, jobType string, runAt time.Time, payload interface{}, key string) (*scheduler.Job, error)
+	Cancel(ctx context.Context, jobType, key string) error
+}
+
+type expireReservationPayload struct {
+	ReservationID string `json:"reservation_id"`
+}
+
+// InventoryService provides business logic for stock reservations and adjustments
+type InventoryService struct {
+	repo           domain.InventoryRepository
+	publisher      eventbus.Publisher
+	scheduler      JobScheduler
+	reservationTTL time.Duration
+	logger         *slog.Logger
+}
+
+// New creates a new InventoryService
+func New(repo domain.InventoryRepository, reservationTTL time.Duration, logger *slog.Logger) *InventoryService {
+	return &InventoryService{
+		repo:           repo,
+		publisher:      eventbus.NoopPublisher{},
+		reservationTTL: reservationTTL,
+		logger:         logger,
+	}
+}
+
+// SetPublisher configures where inventory events are published
+func (s *InventoryService) SetPublisher(publisher eventbus.Publisher) {
+	s.publisher = publisher
+}
+
+// SetScheduler configures the scheduler used to release each reservation
+// when it expires. Without one, expiry relies on ReleaseExpired sweeps.
+func (s *InventoryService) SetScheduler(jobScheduler JobScheduler) {
+	s.scheduler = jobScheduler
+}
+
+// Reserve holds stock for a pending order. correlationID, which may be
+// empty, groups the reservations of one checkout for CompensateByCorrelation.
+func (s *InventoryService) Reserve(ctx context.Context, operationID, productID, warehouseID, correlationID string, quantity int, ttl time.Duration) (*domain.Reservation, *domain.StockItem, error) {
+	s.logger.Info("Reserving stock",
+		"operationID", operationID,
+		"productID", productID,
+		"warehouseID", warehouseID,
+		"correlationID", correlationID,
+		"quantity", quantity)
+
+	if operationID == "" {
+		return nil, nil, apperrors.New(apperrors.Invalid, "operation ID is required")
+	}
+	if productID == "" {
+		return nil, nil, apperrors.New(apperrors.Invalid, "product ID is required")
+	}
+	if quantity <= 0 {
+		return nil, nil, apperrors.New(apperrors.Invalid, "quantity must be greater than zero")
+	}
+	if ttl <= 0 {
+		ttl = s.reservationTTL
+	}
+
+	reservation, stock, err := s.repo.Reserve(ctx, domain.ReserveParams{
+		OperationID:   operationID,
+		ProductID:     productID,
+		WarehouseID:   warehouseID,
+		CorrelationID: correlationID,
+		Quantity:      quantity,
+		ExpiresAt:     time.Now().Add(ttl),
+	})
+	if err != nil {
+		s.logger.Error("Failed to reserve stock", "productID", productID, "error", err)
+		return nil, nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Stock reserved", "reservationID", reservation.ID.Hex(), "warehouseID", stock.WarehouseID)
+	s.publishMovement(ctx, events.InventoryReserved, operationID, reservation.ID.Hex(), -quantity, "", stock)
+	s.scheduleExpiry(ctx, reservation)
+	return reservation, stock, nil
+}
+
+// Release cancels a pending reservation
+func (s *InventoryService) Release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	reservation, stock, err := s.release(ctx, operationID, reservationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.cancelExpiry(ctx, reservationID)
+	return reservation, stock, nil
+}
+
+// release releases a reservation without touching its expiry job
+func (s *InventoryService) release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	s.logger.Info("Releasing reservation", "operationID", operationID, "reservationID", reservationID)
+
+	if operationID == "" || reservationID == "" {
+		return nil, nil, apperrors.New(apperrors.Invalid, "operation ID and reservation ID are required")
+	}
+
+	reservation, stock, err := s.repo.Release(ctx, operationID, reservationID)
+	if err != nil {
+		s.logger.Error("Failed to release reservation", "reservationID", reservationID, "error", err)
+		return nil, nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.publishMovement(ctx, events.InventoryReleased, operationID, reservationID, reservation.Quantity, "", stock)
+	return reservation, stock, nil
+}
+
+// Commit turns a pending reservation into a permanent stock decrement
+func (s *InventoryService) Commit(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	s.logger.Info("Committing reservation", "operationID", operationID, "reservationID", reservationID)
+
+	if operationID == "" || reservationID == "" {
+		return nil, nil, apperrors.New(apperrors.Invalid, "operation ID and reservation ID are required")
+	}
+
+	reservation, stock, err := s.repo.Commit(ctx, operationID, reservationID)
+	if err != nil {
+		s.logger.Error("Failed to commit reservation", "reservationID", reservationID, "error", err)
+		return nil, nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.publishMovement(ctx, events.InventoryCommitted, operationID, reservationID, -reservation.Quantity, "", stock)
+	s.cancelExpiry(ctx, reservationID)
+	return reservation, stock, nil
+}
+
+// CompensateByCorrelation releases every pending reservation of a
+// correlation, rolling back a failed checkout in one call. It returns all of
+// the correlation's reservations afterwards; committed ones are left as they
+// are. Each release has an operation ID derived from its reservation, so
+// retrying is safe.
+func (s *InventoryService) CompensateByCorrelation(ctx context.Context, correlationID string) ([]*domain.Reservation, error) {
+	s.logger.Info("Compensating reservations", "correlationID", correlationID)
+
+	if correlationID == "" {
+		return nil, apperrors.New(apperrors.Invalid, "correlation ID is required")
+	}
+
+	reservations, err := s.repo.ReservationsByCorrelation(ctx, correlationID)
+	if err != nil {
+		s.logger.Error("Failed to list reservations", "correlationID", correlationID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	released, raced := 0, false
+	for i, reservation := range reservations {
+		if reservation.Status != domain.ReservationPending {
+			continue
+		}
+		id := reservation.ID.Hex()
+		updated, _, err := s.Release(ctx, "compensate-"+id, id)
+		if errors.Is(err, domain.ErrInvalidState) {
+			// Committed or expired meanwhile
+			raced = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reservations[i] = updated
+		released++
+	}
+	if raced {
+		if reservations, err = s.repo.ReservationsByCorrelation(ctx, correlationID); err != nil {
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+	}
+
+	s.logger.Info("Reservations compensated", "correlationID", correlationID, "released", released, "reservations", len(reservations))
+	return reservations, nil
+}
+
+// Adjust changes on-hand stock, e.g. for restocks, returns or shrinkage
+func (s *InventoryService) Adjust(ctx context.Context, operationID, productID, warehouseID string, quantityChange int, reason string) (*domain.StockItem, error) {
+	s.logger.Info("Adjusting stock",
+		"operationID", operationID,
+		"productID", productID,
+		"warehouseID", warehouseID,
+		"quantityChange", quantityChange)
+
+	if operationID == "" {
+		return nil, apperrors.New(apperrors.Invalid, "operation ID is required")
+	}
+	if productID == "" || warehouseID == "" {
+		return nil, apperrors.New(apperrors.Invalid, "product ID and warehouse ID are required")
+	}
+	if quantityChange == 0 {
+		return nil, apperrors.New(apperrors.Invalid, "quantity change must not be zero")
+	}
+
+	stock, err := s.repo.Adjust(ctx, domain.AdjustParams{
+		OperationID:    operationID,
+		ProductID:      productID,
+		WarehouseID:    warehouseID,
+		QuantityChange: quantityChange,
+		Reason:         reason,
+	})
+	if err != nil {
+		s.logger.Error("Failed to adjust stock", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.publishMovement(ctx, events.InventoryAdjusted, operationID, "", quantityChange, reason, stock)
+	return stock, nil
+}
+
+// CheckStock reports whether quantity can be reserved, in a single warehouse
+// or across all warehouses, along with the per-warehouse breakdown
+func (s *InventoryService) CheckStock(ctx context.Context, productID string, quantity int, warehouseID string) (bool, int, []*domain.StockItem, error) {
+	s.logger.Info("Checking stock", "productID", productID, "quantity", quantity, "warehouseID", warehouseID)
+
+	items, err := s.repo.GetStock(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to get stock", "productID", productID, "error", err)
+		return false, 0, nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	total := 0
+	var levels []*domain.StockItem
+	for _, item := range items {
+		if warehouseID != "" && item.WarehouseID != warehouseID {
+			continue
+		}
+		total += item.Available()
+		levels = append(levels, item)
+	}
+
+	// A reservation is taken from a single warehouse, so availability means
+	// one warehouse can cover the full quantity
+	available := false
+	for _, item := range levels {
+		if item.Available() >= quantity {
+			available = true
+			break
+		}
+	}
+
+	return available, total, levels, nil
+}
+
+// ReleaseExpired releases pending reservations whose hold has lapsed and
+// returns how many were released
+func (s *InventoryService) ReleaseExpired(ctx context.Context, limit int) (int, error) {
+	expired, err := s.repo.ExpiredReservations(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("repository error: %w", err)
+	}
+
+	released := 0
+	for _, reservation := range expired {
+		id := reservation.ID.Hex()
+		_, _, err := s.Release(ctx, "expire-"+id, id)
+		if err != nil {
+			// Lost a race with a commit or explicit release
+			if errors.Is(err, domain.ErrInvalidState) {
+				continue
+			}
+			s.logger.Error("Failed to release expired reservation", "reservationID", id, "error", err)
+			continue
+		}
+		released++
+	}
+
+	if released > 0 {
+		s.logger.Info("Released expired reservations", "count", released)
+	}
+	return released, nil
+}
+
+// ExpireReservation is the handler for ExpireReservationJob
+func (s *InventoryService) ExpireReservation(ctx context.Context, job *scheduler.Job) error {
+	var payload expireReservationPayload
+	if err := job.Decode(&payload); err != nil || payload.ReservationID == "" {
+		return scheduler.Permanent(fmt.Errorf("invalid expiry job payload: %s", job.Payload))
+	}
+
+	id := payload.ReservationID
+	_, _, err := s.release(ctx, "expire-"+id, id)
+	switch {
+	case errors.Is(err, domain.ErrInvalidState):
+		// Already committed or released
+		return nil
+	case errors.Is(err, domain.ErrNotFound):
+		return scheduler.Permanent(err)
+	}
+	return err
+}
+
+// Helper functions
+
+// scheduleExpiry schedules the release of a reservation at its expiry. The
+// stock is held by then, so the job is scheduled even if the caller has
+// gone. A failure is logged and left to the ReleaseExpired sweep.
+func (s *InventoryService) scheduleExpiry(ctx context.Context, reservation *domain.Reservation) {
+	if s.scheduler == nil {
+		return
+	}
+
+	id := reservation.ID.Hex()
+	_, err := s.scheduler.Schedule(context.WithoutCancel(ctx), ExpireReservationJob, reservation.ExpiresAt,
+		expireReservationPayload{ReservationID: id}, id)
+	if err != nil && !errors.Is(err, scheduler.ErrJobExists) {
+		s.logger.Warn("Failed to schedule reservation expiry", "reservationID", id, "error", err)
+	}
+}
+
+// cancelExpiry cancels the expiry job of a reservation that was resolved
+// before it lapsed, even if the caller has gone
+func (s *InventoryService) cancelExpiry(ctx context.Context, reservationID string) {
+	if s.scheduler == nil {
+		return
+	}
+
+	err := s.scheduler.Cancel(context.WithoutCancel(ctx), ExpireReservationJob, reservationID)
+	if err != nil && !errors.Is(err, scheduler.ErrJobNotFound) {
+		s.logger.Warn("Failed to cancel reservation expiry", "reservationID", reservationID, "error", err)
+	}
+}
+
+// publishMovement emits the movement event and an inventory.changed event
+// with the product's totals across warehouses. Consumers should deduplicate
+// on operation_id since replays of idempotent operations publish again.
+// The stock has moved by then, so the events are published even if the
+// caller has gone.
+func (s *InventoryService) publishMovement(ctx context.Context, eventType, operationID, reservationID string, quantityChange int, reason string, stock *domain.StockItem) {
+	ctx = context.WithoutCancel(ctx)
+	s.publish(ctx, eventType, stock.ProductID, events.StockMovementPayload{
+		OperationID:    operationID,
+		ProductID:      stock.ProductID,
+		WarehouseID:    stock.WarehouseID,
+		ReservationID:  reservationID,
+		QuantityChange: quantityChange,
+		Reason:         reason,
+		OnHand:         stock.OnHand,
+		Reserved:       stock.Reserved,
+		Available:      stock.Available(),
+	})
+
+	items, err := s.repo.GetStock(ctx, stock.ProductID)
+	if err != nil {
+		s.logger.Error("Failed to load stock totals for event", "productID", stock.ProductID, "error", err)
+		return
+	}
+	totals := events.InventoryPayload{}
+	for _, item := range items {
+		totals.Quantity += item.OnHand
+		totals.Reserved += item.Reserved
+	}
+	totals.InStock = totals.Quantity-totals.Reserved > 0
+
+	s.publish(ctx, events.InventoryChanged, stock.ProductID, events.InventoryChangedPayload{
+		ProductID:      stock.ProductID,
+		QuantityChange: quantityChange,
+		OperationID:    operationID,
+		OperationType:  eventType,
+		Inventory:      totals,
+	})
+}
+
+func (s *InventoryService) publish(ctx context.Context, eventType, key string, payload interface{}) {
+	event, err := events.New(ctx, eventType, "inventory-service", key, payload)
+	if err != nil {
+		s.logger.Error("Failed to build event", "type", eventType, "error", err)
+		return
+	}
+
+	if err := s.publisher.Publish(context.Background(), event); err != nil {
+		s.logger.Error("Failed to publish event", "type", eventType, "key", key, "error", err)
+	}
+}
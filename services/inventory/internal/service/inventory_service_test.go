@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/scheduler"
+	"github.com/bekbull/online-shop/services/inventory/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockInventoryRepository is a mock implementation of the domain.InventoryRepository interface
+type MockInventoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepository) Reserve(ctx context.Context, params domain.ReserveParams) (*domain.Reservation, *domain.StockItem, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.Reservation), args.Get(1).(*domain.StockItem), args.Error(2)
+}
+
+func (m *MockInventoryRepository) Release(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	args := m.Called(operationID, reservationID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.Reservation), args.Get(1).(*domain.StockItem), args.Error(2)
+}
+
+func (m *MockInventoryRepository) Commit(ctx context.Context, operationID, reservationID string) (*domain.Reservation, *domain.StockItem, error) {
+	args := m.Called(operationID, reservationID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*domain.Reservation), args.Get(1).(*domain.StockItem), args.Error(2)
+}
+
+func (m *MockInventoryRepository) Adjust(ctx context.Context, params domain.AdjustParams) (*domain.StockItem, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StockItem), args.Error(1)
+}
+
+func (m *MockInventoryRepository) GetStock(ctx context.Context, productID string) ([]*domain.StockItem, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.StockItem), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*domain.Reservation, error) {
+	args := m.Called(before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Reservation), args.Error(1)
+}
+
+func (m *MockInventoryRepository) ReservationsByCorrelation(ctx context.Context, correlationID string) ([]*domain.Reservation, error) {
+	args := m.Called(correlationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Reservation), args.Error(1)
+}
+
+// recordingPublisher keeps published events in memory
+type recordingPublisher struct {
+	events []*eventbus.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *eventbus.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newTestService(repo *MockInventoryRepository) (*InventoryService, *recordingPublisher) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	svc := New(repo, 15*time.Minute, logger)
+	publisher := &recordingPublisher{}
+	svc.SetPublisher(publisher)
+	return svc, publisher
+}
+
+func TestReserve(t *testing.T) {
+	stock := &domain.StockItem{ProductID: "p1", WarehouseID: "w1", OnHand: 10, Reserved: 3}
+	reservation := &domain.Reservation{
+		ID:          primitive.NewObjectID(),
+		ProductID:   "p1",
+		WarehouseID: "w1",
+		Quantity:    3,
+		Status:      domain.ReservationPending,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(MockInventoryRepository)
+		svc, publisher := newTestService(repo)
+
+		repo.On("Reserve", mock.MatchedBy(func(p domain.ReserveParams) bool {
+			return p.OperationID == "op-1" && p.ProductID == "p1" && p.Quantity == 3 &&
+				time.Until(p.ExpiresAt) > 14*time.Minute
+		})).Return(reservation, stock, nil)
+		repo.On("GetStock", "p1").Return([]*domain.StockItem{stock}, nil)
+
+		result, level, err := svc.Reserve(context.Background(), "op-1", "p1", "", "", 3, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, reservation, result)
+		assert.Equal(t, 7, level.Available())
+		if assert.Len(t, publisher.events, 2) {
+			assert.Equal(t, events.InventoryReserved, publisher.events[0].Type)
+			assert.Equal(t, events.InventoryChanged, publisher.events[1].Type)
+
+			var payload events.StockMovementPayload
+			assert.NoError(t, publisher.events[0].Decode(&payload))
+			assert.Equal(t, -3, payload.QuantityChange)
+			assert.Equal(t, reservation.ID.Hex(), payload.ReservationID)
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("insufficient stock", func(t *testing.T) {
+		repo := new(MockInventoryRepository)
+		svc, publisher := newTestService(repo)
+
+		repo.On("Reserve", mock.Anything).Return(nil, nil, domain.ErrInsufficientStock)
+
+		_, _, err := svc.Reserve(context.Background(), "op-1", "p1", "", "", 30, 0)
+
+		assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		repo := new(MockInventoryRepository)
+		svc, _ := newTestService(repo)
+
+		_, _, err := svc.Reserve(context.Background(), "", "p1", "", "", 1, 0)
+		assert.Error(t, err)
+
+		_, _, err = svc.Reserve(context.Background(), "op-1", "p1", "", "", 0, 0)
+		assert.Error(t, err)
+
+		repo.AssertNotCalled(t, "Reserve", mock.Anything)
+	})
+}
+
+func TestCheckStock(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	svc, _ := newTestService(repo)
+
+	repo.On("GetStock", "p1").Return([]*domain.StockItem{
+		{ProductID: "p1", WarehouseID: "w1", OnHand: 5, Reserved: 1},
+		{ProductID: "p1", WarehouseID: "w2", OnHand: 8, Reserved: 0},
+	}, nil)
+
+	available, total, levels, err := svc.CheckStock(context.Background(), "p1", 6, "")
+	assert.NoError(t, err)
+	assert.True(t, available)
+	assert.Equal(t, 12, total)
+	assert.Len(t, levels, 2)
+
+	// No single warehouse can cover the quantity
+	available, _, _, err = svc.CheckStock(context.Background(), "p1", 10, "")
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	available, total, levels, err = svc.CheckStock(context.Background(), "p1", 6, "w1")
+	assert.NoError(t, err)
+	assert.False(t, available)
+	assert.Equal(t, 4, total)
+	assert.Len(t, levels, 1)
+}
+
+// expiredRepository fails reads as MongoDB does once the caller's deadline
+// has passed
+type expiredRepository struct {
+	MockInventoryRepository
+}
+
+func (r *expiredRepository) GetStock(ctx context.Context, productID string) ([]*domain.StockItem, error) {
+	return nil, ctx.Err()
+}
+
+func TestCheckStockHonorsCallerDeadline(t *testing.T) {
+	svc := New(&expiredRepository{}, 15*time.Minute, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, _, _, err := svc.CheckStock(ctx, "p1", 1, "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestReleaseExpired(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	svc, _ := newTestService(repo)
+
+	first := &domain.Reservation{ID: primitive.NewObjectID(), ProductID: "p1", Quantity: 2}
+	second := &domain.Reservation{ID: primitive.NewObjectID(), ProductID: "p1", Quantity: 1}
+	stock := &domain.StockItem{ProductID: "p1", WarehouseID: "w1", OnHand: 10}
+
+	repo.On("ExpiredReservations", mock.Anything, 50).Return([]*domain.Reservation{first, second}, nil)
+	repo.On("Release", "expire-"+first.ID.Hex(), first.ID.Hex()).Return(first, stock, nil)
+	// The second reservation was committed in the meantime
+	repo.On("Release", "expire-"+second.ID.Hex(), second.ID.Hex()).Return(nil, nil, domain.ErrInvalidState)
+	repo.On("GetStock", "p1").Return([]*domain.StockItem{stock}, nil)
+
+	released, err := svc.ReleaseExpired(context.Background(), 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, released)
+	repo.AssertExpectations(t)
+}
+
+func TestReservationExpiryJobs(t *testing.T) {
+	repo := new(MockInventoryRepository)
+	svc, _ := newTestService(repo)
+	jobs := scheduler.NewMemoryStore()
+	svc.SetScheduler(scheduler.New(jobs, scheduler.Options{}, svc.logger))
+
+	expiresAt := time.Now().Add(15 * time.Minute)
+	reservation := &domain.Reservation{ID: primitive.NewObjectID(), ProductID: "p1", Quantity: 2, ExpiresAt: expiresAt}
+	stock := &domain.StockItem{ProductID: "p1", WarehouseID: "w1", OnHand: 10, Reserved: 2}
+	repo.On("Reserve", mock.Anything).Return(reservation, stock, nil)
+	repo.On("GetStock", "p1").Return([]*domain.StockItem{stock}, nil)
+
+	_, _, err := svc.Reserve(context.Background(), "op-1", "p1", "", "", 2, 0)
+	assert.NoError(t, err)
+
+	scheduled := jobs.Jobs()
+	if assert.Len(t, scheduled, 1) {
+		assert.Equal(t, ExpireReservationJob, scheduled[0].Type)
+		assert.Equal(t, reservation.ID.Hex(), scheduled[0].Key)
+		assert.True(t, scheduled[0].RunAt.Equal(expiresAt))
+	}
+
+	t.Run("job releases a lapsed reservation", func(t *testing.T) {
+		id := reservation.ID.Hex()
+		repo.On("Release", "expire-"+id, id).Return(reservation, stock, nil).Once()
+
+		err := svc.ExpireReservation(context.Background(), &scheduled[0])
+
+		assert.NoError(t, err)
+		// The running job is not cancelled by its own release
+		assert.Equal(t, scheduler.StatusPending, jobs.Jobs()[0].Status)
+	})
+
+	t.Run("commit cancels the job", func(t *testing.T) {
+		repo.On("Commit", "op-2", reservation.ID.Hex()).Return(reservation, stock, nil)
+
+		_, _, err := svc.Commit(context.Background(), "op-2", reservation.ID.Hex())
+
+		assert.NoError(t, err)
+		assert.Equal(t, scheduler.StatusCancelled, jobs.Jobs()[0].Status)
+	})
+}
+
+func TestCompensateByCorrelation(t *testing.T) {
+	repo := newMemoryRepository()
+	svc := New(repo, 15*time.Minute, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	_, err := svc.Adjust(context.Background(), "restock", "p1", "w1", 10, "")
+	require.NoError(t, err)
+
+	paid, _, err := svc.Reserve(context.Background(), "op-1", "p1", "", "order-1", 2, 0)
+	require.NoError(t, err)
+	_, _, err = svc.Reserve(context.Background(), "op-2", "p1", "", "order-1", 3, 0)
+	require.NoError(t, err)
+	_, _, err = svc.Reserve(context.Background(), "op-3", "p1", "", "order-2", 1, 0)
+	require.NoError(t, err)
+	_, _, err = svc.Commit(context.Background(), "op-4", paid.ID.Hex())
+	require.NoError(t, err)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		reservations, err := svc.CompensateByCorrelation(context.Background(), "order-1")
+		require.NoError(t, err)
+		if assert.Len(t, reservations, 2) {
+			assert.Equal(t, domain.ReservationCommitted, reservations[0].Status, "committed reservations are left alone")
+			assert.Equal(t, domain.ReservationReleased, reservations[1].Status)
+		}
+
+		// Only order-2's hold remains, on the stock left after the commit
+		stock, err := repo.GetStock(context.Background(), "p1")
+		require.NoError(t, err)
+		assert.Equal(t, 8, stock[0].OnHand)
+		assert.Equal(t, 1, stock[0].Reserved)
+	}
+
+	reservations, err := svc.CompensateByCorrelation(context.Background(), "order-3")
+	assert.NoError(t, err)
+	assert.Empty(t, reservations)
+
+	_, err = svc.CompensateByCorrelation(context.Background(), "")
+	assert.Error(t, err)
+}
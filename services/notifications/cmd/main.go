@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/services/notifications/config"
+	restHandler "github.com/bekbull/online-shop/services/notifications/internal/api/rest"
+	"github.com/bekbull/online-shop/services/notifications/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/notifications/internal/service"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	// Initialize logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	logger.Info("Starting Notification Service")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded", "defaultLocale", cfg.Templates.DefaultLocale)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to MongoDB
+	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", err)
+		}
+	}()
+	logger.Info("Connected to MongoDB")
+
+	templateRepo := mongodb.NewTemplateRepository(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(ctx, cfg.MongoDB.WriteTimeout)
+	if err := templateRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+
+	templateService := service.New(templateRepo, service.Options{
+		DefaultLocale: cfg.Templates.DefaultLocale,
+	}, logger)
+
+	// Setup HTTP server
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(chimiddleware.RealIP)
+	router.Use(logging.AccessLog(logger, logging.DefaultAccessLog))
+	router.Use(middleware.Recover(logger))
+	router.Use(chimiddleware.Timeout(30 * time.Second))
+
+	restHandler.NewTemplateHandler(templateService, logger).RegisterRoutes(router)
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: router,
+	}
+
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down notification service")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping the database to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
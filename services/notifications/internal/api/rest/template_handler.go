@@ -0,0 +1,245 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/notifications/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// AuthorHeader identifies the user who creates a template version
+const AuthorHeader = "X-User-ID"
+
+// TemplateService defines the interface for the template service
+type TemplateService interface {
+	CreateVersion(ctx context.Context, key, locale string, v *domain.Version) (*domain.Version, error)
+	Publish(ctx context.Context, key, locale string, version int) (*domain.Template, error)
+	GetTemplate(ctx context.Context, key, locale string) (*domain.Template, error)
+	ListTemplates(ctx context.Context, key string) ([]*domain.Template, error)
+	ListVersions(ctx context.Context, key, locale string) ([]*domain.Version, error)
+	GetVersion(ctx context.Context, key, locale string, version int) (*domain.Version, error)
+	Render(ctx context.Context, key, locale string, data map[string]interface{}) (*domain.Rendered, error)
+	TestRender(ctx context.Context, key, locale string, version int, data map[string]interface{}) (*domain.Rendered, error)
+	Preview(ctx context.Context, v *domain.Version, data map[string]interface{}) (*domain.Rendered, error)
+}
+
+// TemplateHandler handles HTTP requests for email templates
+type TemplateHandler struct {
+	service TemplateService
+	logger  *slog.Logger
+}
+
+// NewTemplateHandler creates a new template handler
+func NewTemplateHandler(service TemplateService, logger *slog.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the template routes with the given router
+func (h *TemplateHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/templates", func(r chi.Router) {
+		r.Get("/", h.ListTemplates)
+		r.Post("/preview", h.Preview)
+
+		r.Route("/{key}/{locale}", func(r chi.Router) {
+			r.Get("/", h.GetTemplate)
+			r.Post("/render", h.Render)
+			r.Get("/versions", h.ListVersions)
+			r.Post("/versions", h.CreateVersion)
+			r.Get("/versions/{version}", h.GetVersion)
+			r.Post("/versions/{version}/publish", h.Publish)
+			r.Post("/versions/{version}/render", h.TestRender)
+		})
+	})
+}
+
+// versionRequest is the editable content of a version
+type versionRequest struct {
+	Subject   string            `json:"subject"`
+	HTML      string            `json:"html"`
+	Text      string            `json:"text"`
+	Variables []domain.Variable `json:"variables"`
+	Comment   string            `json:"comment"`
+}
+
+func (req *versionRequest) version() *domain.Version {
+	return &domain.Version{
+		Subject:   req.Subject,
+		HTML:      req.HTML,
+		Text:      req.Text,
+		Variables: req.Variables,
+		Comment:   req.Comment,
+	}
+}
+
+// ListTemplates handles GET /v1/templates
+func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.service.ListTemplates(r.Context(), r.URL.Query().Get("key"))
+	if err != nil {
+		h.writeError(w, r, "Failed to list templates", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"templates": templates})
+}
+
+// GetTemplate handles GET /v1/templates/{key}/{locale}
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := h.service.GetTemplate(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"))
+	if err != nil {
+		h.writeError(w, r, "Failed to get template", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, t)
+}
+
+// ListVersions handles GET /v1/templates/{key}/{locale}/versions
+func (h *TemplateHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.service.ListVersions(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"))
+	if err != nil {
+		h.writeError(w, r, "Failed to list versions", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"versions": versions})
+}
+
+// CreateVersion handles POST /v1/templates/{key}/{locale}/versions
+func (h *TemplateHandler) CreateVersion(w http.ResponseWriter, r *http.Request) {
+	var req versionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	v := req.version()
+	v.Author = r.Header.Get(AuthorHeader)
+	created, err := h.service.CreateVersion(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"), v)
+	if err != nil {
+		h.writeError(w, r, "Failed to create version", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, created)
+}
+
+// GetVersion handles GET /v1/templates/{key}/{locale}/versions/{version}
+func (h *TemplateHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	version, ok := h.versionParam(w, r)
+	if !ok {
+		return
+	}
+	v, err := h.service.GetVersion(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"), version)
+	if err != nil {
+		h.writeError(w, r, "Failed to get version", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, v)
+}
+
+// Publish handles POST /v1/templates/{key}/{locale}/versions/{version}/publish
+func (h *TemplateHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	version, ok := h.versionParam(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.service.Publish(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"), version)
+	if err != nil {
+		h.writeError(w, r, "Failed to publish version", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, t)
+}
+
+// TestRender handles POST /v1/templates/{key}/{locale}/versions/{version}/render
+func (h *TemplateHandler) TestRender(w http.ResponseWriter, r *http.Request) {
+	version, ok := h.versionParam(w, r)
+	if !ok {
+		return
+	}
+	data, ok := h.decodeData(w, r)
+	if !ok {
+		return
+	}
+	rendered, err := h.service.TestRender(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"), version, data)
+	if err != nil {
+		h.writeError(w, r, "Failed to render template", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rendered)
+}
+
+// Render handles POST /v1/templates/{key}/{locale}/render
+func (h *TemplateHandler) Render(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.decodeData(w, r)
+	if !ok {
+		return
+	}
+	rendered, err := h.service.Render(r.Context(), chi.URLParam(r, "key"), chi.URLParam(r, "locale"), data)
+	if err != nil {
+		h.writeError(w, r, "Failed to render template", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rendered)
+}
+
+// Preview handles POST /v1/templates/preview
+func (h *TemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Template versionRequest         `json:"template"`
+		Data     map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	rendered, err := h.service.Preview(r.Context(), req.Template.version(), req.Data)
+	if err != nil {
+		h.writeError(w, r, "Failed to render template", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rendered)
+}
+
+// Helper functions
+
+func (h *TemplateHandler) versionParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid version"))
+		return 0, false
+	}
+	return version, true
+}
+
+// decodeData reads an optional {"data": {...}} body
+func (h *TemplateHandler) decodeData(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	var req struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return nil, false
+	}
+	return req.Data, true
+}
+
+func (h *TemplateHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	h.logger.Error(msg, "error", err)
+	apperrors.WriteHTTP(w, r, err)
+}
+
+func (h *TemplateHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
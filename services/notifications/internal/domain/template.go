@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain errors returned by the repository and service
+var (
+	ErrNotFound        = apperrors.New(apperrors.NotFound, "not found")
+	ErrInvalidTemplate = apperrors.New(apperrors.Invalid, "invalid template")
+	ErrInvalidData     = apperrors.New(apperrors.Invalid, "invalid template data")
+)
+
+// Variable types a template schema can declare
+const (
+	VarString = "string"
+	VarNumber = "number"
+	VarBool   = "bool"
+	VarTime   = "time" // RFC 3339 string, rendered as time.Time
+	VarList   = "list"
+	VarObject = "object"
+)
+
+// Variable declares one value a template expects in its render data
+type Variable struct {
+	Name        string      `bson:"name" json:"name"`
+	Type        string      `bson:"type" json:"type"`
+	Required    bool        `bson:"required" json:"required"`
+	Description string      `bson:"description,omitempty" json:"description,omitempty"`
+	Example     interface{} `bson:"example,omitempty" json:"example,omitempty"`
+}
+
+// Template tracks the versions of one template key in one locale
+type Template struct {
+	Key              string    `bson:"key" json:"key"`
+	Locale           string    `bson:"locale" json:"locale"`
+	LatestVersion    int       `bson:"latest_version" json:"latest_version"`
+	PublishedVersion int       `bson:"published_version" json:"published_version"` // 0 until a version is published
+	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Version is an immutable revision of a template's content and schema
+type Version struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key       string             `bson:"key" json:"key"`
+	Locale    string             `bson:"locale" json:"locale"`
+	Version   int                `bson:"version" json:"version"`
+	Subject   string             `bson:"subject" json:"subject"`
+	HTML      string             `bson:"html" json:"html"`
+	Text      string             `bson:"text" json:"text"`
+	Variables []Variable         `bson:"variables" json:"variables"`
+	Author    string             `bson:"author,omitempty" json:"author,omitempty"`
+	Comment   string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Rendered is an email ready to be sent
+type Rendered struct {
+	Key     string `json:"key"`
+	Locale  string `json:"locale"`
+	Version int    `json:"version"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// TemplateRepository defines the interface for template storage
+type TemplateRepository interface {
+	// CreateVersion stores v under the next version number of its key and
+	// locale and sets v.Version
+	CreateVersion(ctx context.Context, v *Version) error
+	GetVersion(ctx context.Context, key, locale string, version int) (*Version, error)
+	ListVersions(ctx context.Context, key, locale string) ([]*Version, error)
+	Get(ctx context.Context, key, locale string) (*Template, error)
+	// List returns the templates of a key in all locales, or all templates
+	// when key is empty
+	List(ctx context.Context, key string) ([]*Template, error)
+	Publish(ctx context.Context, key, locale string, version int, at time.Time) error
+}
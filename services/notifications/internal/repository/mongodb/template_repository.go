@@ -0,0 +1,174 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/services/notifications/config"
+	"github.com/bekbull/online-shop/services/notifications/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TemplateRepository implements domain.TemplateRepository using MongoDB.
+// Each key and locale has a head document in "templates" and its immutable
+// revisions in "template_versions".
+type TemplateRepository struct {
+	templates *mongo.Collection
+	versions  *mongo.Collection
+	config    *config.MongoDBConfig
+}
+
+// NewTemplateRepository creates a new MongoDB template repository
+func NewTemplateRepository(client *mongo.Client, cfg *config.MongoDBConfig) *TemplateRepository {
+	db := client.Database(cfg.Database)
+	return &TemplateRepository{
+		templates: db.Collection("templates"),
+		versions:  db.Collection("template_versions"),
+		config:    cfg,
+	}
+}
+
+// EnsureIndexes creates the unique indexes on templates and versions
+func (r *TemplateRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.templates.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}, {Key: "locale", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create template indexes: %w", err)
+	}
+
+	_, err = r.versions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}, {Key: "locale", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create template version indexes: %w", err)
+	}
+	return nil
+}
+
+// CreateVersion reserves the next version number on the head document and
+// stores the version under it
+func (r *TemplateRepository) CreateVersion(ctx context.Context, v *domain.Version) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	var head domain.Template
+	err := r.templates.FindOneAndUpdate(ctx,
+		bson.M{"key": v.Key, "locale": v.Locale},
+		bson.M{
+			"$inc":         bson.M{"latest_version": 1},
+			"$set":         bson.M{"updated_at": v.CreatedAt},
+			"$setOnInsert": bson.M{"published_version": 0},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&head)
+	if err != nil {
+		return err
+	}
+
+	v.Version = head.LatestVersion
+	result, err := r.versions.InsertOne(ctx, v)
+	if err != nil {
+		return err
+	}
+	v.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetVersion returns one version of a template
+func (r *TemplateRepository) GetVersion(ctx context.Context, key, locale string, version int) (*domain.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var v domain.Version
+	err := r.versions.FindOne(ctx, bson.M{"key": key, "locale": locale, "version": version}).Decode(&v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVersions returns the versions of a template, newest first
+func (r *TemplateRepository) ListVersions(ctx context.Context, key, locale string) ([]*domain.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.versions.Find(ctx, bson.M{"key": key, "locale": locale},
+		options.Find().SetSort(bson.D{{Key: "version", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versions []*domain.Version
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Get returns the head document of a template
+func (r *TemplateRepository) Get(ctx context.Context, key, locale string) (*domain.Template, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var t domain.Template
+	err := r.templates.FindOne(ctx, bson.M{"key": key, "locale": locale}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns templates ordered by key and locale
+func (r *TemplateRepository) List(ctx context.Context, key string) ([]*domain.Template, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if key != "" {
+		filter["key"] = key
+	}
+	cursor, err := r.templates.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "key", Value: 1}, {Key: "locale", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*domain.Template
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Publish makes a version the one used for sending
+func (r *TemplateRepository) Publish(ctx context.Context, key, locale string, version int, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.templates.UpdateOne(ctx,
+		bson.M{"key": key, "locale": locale, "latest_version": bson.M{"$gte": version}},
+		bson.M{"$set": bson.M{"published_version": version, "updated_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/notifications/internal/domain"
+)
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// funcs are available to every template part
+var funcs = map[string]interface{}{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			return fallback
+		}
+		return value
+	},
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+	"join": func(sep string, items []interface{}) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// compiled holds the parsed parts of a version. A part that is empty in the
+// version is nil.
+type compiled struct {
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// compile parses the parts of a version and checks that they only refer to
+// declared variables
+func compile(v *domain.Version) (*compiled, error) {
+	if strings.TrimSpace(v.Subject) == "" {
+		return nil, fmt.Errorf("%w: subject is required", domain.ErrInvalidTemplate)
+	}
+	if strings.TrimSpace(v.HTML) == "" && strings.TrimSpace(v.Text) == "" {
+		return nil, fmt.Errorf("%w: html or text body is required", domain.ErrInvalidTemplate)
+	}
+
+	c := &compiled{}
+	var trees []*parse.Tree
+	var err error
+	if c.subject, err = texttemplate.New("subject").Funcs(funcs).Option("missingkey=error").Parse(v.Subject); err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", domain.ErrInvalidTemplate, err)
+	}
+	trees = append(trees, c.subject.Tree)
+	if strings.TrimSpace(v.HTML) != "" {
+		if c.html, err = htmltemplate.New("html").Funcs(funcs).Option("missingkey=error").Parse(v.HTML); err != nil {
+			return nil, fmt.Errorf("%w: html: %v", domain.ErrInvalidTemplate, err)
+		}
+		trees = append(trees, c.html.Tree)
+	}
+	if strings.TrimSpace(v.Text) != "" {
+		if c.text, err = texttemplate.New("text").Funcs(funcs).Option("missingkey=error").Parse(v.Text); err != nil {
+			return nil, fmt.Errorf("%w: text: %v", domain.ErrInvalidTemplate, err)
+		}
+		trees = append(trees, c.text.Tree)
+	}
+
+	declared := make(map[string]bool, len(v.Variables))
+	for _, variable := range v.Variables {
+		declared[variable.Name] = true
+	}
+	used := make(map[string]bool)
+	for _, tree := range trees {
+		if tree != nil {
+			collectFields(tree.Root, true, used)
+		}
+	}
+	var undeclared []string
+	for name := range used {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		return nil, fmt.Errorf("%w: undeclared variables: %s", domain.ErrInvalidTemplate, strings.Join(undeclared, ", "))
+	}
+	return c, nil
+}
+
+// collectFields records the top-level variables a template refers to. Fields
+// inside range and with blocks are relative to the new dot and are skipped;
+// $.Name always refers to the top level.
+func collectFields(node parse.Node, top bool, used map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(child, top, used)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, top, used)
+	case *parse.IfNode:
+		collectFields(n.Pipe, top, used)
+		collectFields(n.List, top, used)
+		collectFields(n.ElseList, top, used)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, top, used)
+		collectFields(n.List, false, used)
+		collectFields(n.ElseList, top, used)
+	case *parse.WithNode:
+		collectFields(n.Pipe, top, used)
+		collectFields(n.List, false, used)
+		collectFields(n.ElseList, top, used)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, top, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				collectFields(arg, top, used)
+			}
+		}
+	case *parse.ChainNode:
+		collectFields(n.Node, top, used)
+	case *parse.FieldNode:
+		if top {
+			used[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			used[n.Ident[1]] = true
+		}
+	}
+}
+
+// validateSchema checks variable declarations and their examples
+func validateSchema(variables []domain.Variable) error {
+	seen := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		if !variableNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("%w: invalid variable name %q", domain.ErrInvalidTemplate, v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("%w: variable %s is declared twice", domain.ErrInvalidTemplate, v.Name)
+		}
+		seen[v.Name] = true
+		if _, ok := zeroValues[v.Type]; !ok {
+			return fmt.Errorf("%w: variable %s has unknown type %q", domain.ErrInvalidTemplate, v.Name, v.Type)
+		}
+		if v.Example != nil {
+			if _, err := convert(v, v.Example); err != nil {
+				return fmt.Errorf("%w: example of %s: %v", domain.ErrInvalidTemplate, v.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// zeroValues are rendered for optional variables that were not supplied
+var zeroValues = map[string]interface{}{
+	domain.VarString: "",
+	domain.VarNumber: 0,
+	domain.VarBool:   false,
+	domain.VarTime:   time.Time{},
+	domain.VarList:   []interface{}{},
+	domain.VarObject: map[string]interface{}{},
+}
+
+// prepareData validates data against the schema and returns the values to
+// render with. Every problem is reported, not just the first.
+func prepareData(variables []domain.Variable, data map[string]interface{}) (map[string]interface{}, error) {
+	var problems []string
+	declared := make(map[string]bool, len(variables))
+	out := make(map[string]interface{}, len(variables))
+
+	for _, v := range variables {
+		declared[v.Name] = true
+		value, ok := data[v.Name]
+		if !ok || value == nil {
+			if v.Required {
+				problems = append(problems, v.Name+" is required")
+			}
+			out[v.Name] = zeroValues[v.Type]
+			continue
+		}
+		converted, err := convert(v, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", v.Name, err))
+			continue
+		}
+		out[v.Name] = converted
+	}
+	for name := range data {
+		if !declared[name] {
+			problems = append(problems, fmt.Sprintf("%s is not declared", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidData, strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+// exampleData builds render data from the schema examples, with a visible
+// placeholder for strings without one
+func exampleData(variables []domain.Variable) map[string]interface{} {
+	data := make(map[string]interface{}, len(variables))
+	for _, v := range variables {
+		switch {
+		case v.Example != nil:
+			data[v.Name] = v.Example
+		case v.Type == domain.VarString:
+			data[v.Name] = "[" + v.Name + "]"
+		case v.Type == domain.VarTime:
+			data[v.Name] = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(time.RFC3339)
+		default:
+			data[v.Name] = zeroValues[v.Type]
+		}
+	}
+	return data
+}
+
+// convert checks a value against the declared type, parsing times
+func convert(v domain.Variable, value interface{}) (interface{}, error) {
+	switch v.Type {
+	case domain.VarString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case domain.VarNumber:
+		switch n := value.(type) {
+		case float64, float32, int, int32, int64:
+			return n, nil
+		case json.Number:
+			return n.Float64()
+		}
+	case domain.VarBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case domain.VarTime:
+		switch t := value.(type) {
+		case time.Time:
+			return t, nil
+		case string:
+			parsed, err := time.Parse(time.RFC3339, t)
+			if err != nil {
+				return nil, fmt.Errorf("expected an RFC 3339 time")
+			}
+			return parsed, nil
+		}
+	case domain.VarList:
+		if items, ok := value.([]interface{}); ok {
+			return items, nil
+		}
+	case domain.VarObject:
+		if fields, ok := value.(map[string]interface{}); ok {
+			return fields, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", v.Type, value)
+}
+
+// execute renders all parts of a version
+func (c *compiled) execute(data map[string]interface{}) (subject, html, text string, err error) {
+	var buf bytes.Buffer
+	if err := c.subject.Execute(&buf, data); err != nil {
+		return "", "", "", apperrors.Wrap(err, apperrors.Invalid, "failed to render subject")
+	}
+	// A subject is a single header line
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	if c.html != nil {
+		buf.Reset()
+		if err := c.html.Execute(&buf, data); err != nil {
+			return "", "", "", apperrors.Wrap(err, apperrors.Invalid, "failed to render html")
+		}
+		html = buf.String()
+	}
+
+	if c.text != nil {
+		buf.Reset()
+		if err := c.text.Execute(&buf, data); err != nil {
+			return "", "", "", apperrors.Wrap(err, apperrors.Invalid, "failed to render text")
+		}
+		text = buf.String()
+	}
+	return subject, html, text, nil
+}
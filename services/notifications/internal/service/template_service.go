@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/services/notifications/internal/domain"
+)
+
+var (
+	keyPattern    = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+	localePattern = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_]([a-zA-Z0-9]{2,8}))?$`)
+)
+
+// Options configures the template service
+type Options struct {
+	DefaultLocale string // Last fallback when rendering a locale without a published template
+}
+
+// TemplateService manages email templates and renders them
+type TemplateService struct {
+	repo   domain.TemplateRepository
+	opts   Options
+	logger *slog.Logger
+	now    func() time.Time
+
+	// Versions are immutable, so their compiled form is cached by ID
+	compiledMu sync.RWMutex
+	compiled   map[string]*compiled
+}
+
+// New creates a new TemplateService
+func New(repo domain.TemplateRepository, opts Options, logger *slog.Logger) *TemplateService {
+	return &TemplateService{
+		repo:     repo,
+		opts:     opts,
+		logger:   logger,
+		now:      time.Now,
+		compiled: make(map[string]*compiled),
+	}
+}
+
+// CreateVersion validates and stores a new version of a template. The
+// version is not used for sending until it is published.
+func (s *TemplateService) CreateVersion(ctx context.Context, key, locale string, v *domain.Version) (*domain.Version, error) {
+	s.logger.Info("Creating template version", "key", key, "locale", locale)
+
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if !keyPattern.MatchString(key) || len(key) > 100 {
+		return nil, fmt.Errorf("%w: invalid key %q", domain.ErrInvalidTemplate, key)
+	}
+	if err := validateSchema(v.Variables); err != nil {
+		return nil, err
+	}
+	if _, err := compile(v); err != nil {
+		return nil, err
+	}
+
+	v.Key = key
+	v.Locale = locale
+	v.CreatedAt = s.now().UTC()
+	if v.Variables == nil {
+		v.Variables = []domain.Variable{}
+	}
+	if err := s.repo.CreateVersion(ctx, v); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Template version created", "key", key, "locale", locale, "version", v.Version)
+	return v, nil
+}
+
+// Publish makes a version the one used for sending. Publishing an older
+// version rolls the template back.
+func (s *TemplateService) Publish(ctx context.Context, key, locale string, version int) (*domain.Template, error) {
+	s.logger.Info("Publishing template version", "key", key, "locale", locale, "version", version)
+
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetVersion(ctx, key, locale, version); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if err := s.repo.Publish(ctx, key, locale, version, s.now().UTC()); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return s.GetTemplate(ctx, key, locale)
+}
+
+// GetTemplate returns the version summary of a template
+func (s *TemplateService) GetTemplate(ctx context.Context, key, locale string) (*domain.Template, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.repo.Get(ctx, key, locale)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return t, nil
+}
+
+// ListTemplates returns the templates of a key, or all templates
+func (s *TemplateService) ListTemplates(ctx context.Context, key string) ([]*domain.Template, error) {
+	templates, err := s.repo.List(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return templates, nil
+}
+
+// ListVersions returns the versions of a template, newest first
+func (s *TemplateService) ListVersions(ctx context.Context, key, locale string) ([]*domain.Version, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.ListVersions(ctx, key, locale)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return versions, nil
+}
+
+// GetVersion returns one version of a template
+func (s *TemplateService) GetVersion(ctx context.Context, key, locale string, version int) (*domain.Version, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.repo.GetVersion(ctx, key, locale, version)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return v, nil
+}
+
+// Render renders the published template for a locale. When the locale has
+// no published version, the base language and then the default locale are
+// tried, so "de-AT" falls back to "de" and then e.g. "en".
+func (s *TemplateService) Render(ctx context.Context, key, locale string, data map[string]interface{}) (*domain.Rendered, error) {
+	for _, candidate := range s.fallbacks(locale) {
+		t, err := s.repo.Get(ctx, key, candidate)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+		if t.PublishedVersion == 0 {
+			continue
+		}
+
+		v, err := s.repo.GetVersion(ctx, key, candidate, t.PublishedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+		return s.render(v, data)
+	}
+	return nil, fmt.Errorf("repository error: no published template %s for locale %q: %w", key, locale, domain.ErrNotFound)
+}
+
+// TestRender renders a stored version, published or not. Without data the
+// schema examples are used.
+func (s *TemplateService) TestRender(ctx context.Context, key, locale string, version int, data map[string]interface{}) (*domain.Rendered, error) {
+	v, err := s.GetVersion(ctx, key, locale, version)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = exampleData(v.Variables)
+	}
+	return s.render(v, data)
+}
+
+// Preview renders a version that has not been saved yet. Without data the
+// schema examples are used.
+func (s *TemplateService) Preview(ctx context.Context, v *domain.Version, data map[string]interface{}) (*domain.Rendered, error) {
+	if err := validateSchema(v.Variables); err != nil {
+		return nil, err
+	}
+	c, err := compile(v)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = exampleData(v.Variables)
+	}
+	return execute(c, v, data)
+}
+
+func (s *TemplateService) render(v *domain.Version, data map[string]interface{}) (*domain.Rendered, error) {
+	c, err := s.compile(v)
+	if err != nil {
+		return nil, err
+	}
+	return execute(c, v, data)
+}
+
+// compile returns the cached compiled form of a stored version
+func (s *TemplateService) compile(v *domain.Version) (*compiled, error) {
+	id := v.ID.Hex()
+	s.compiledMu.RLock()
+	c, ok := s.compiled[id]
+	s.compiledMu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	c, err := compile(v)
+	if err != nil {
+		return nil, err
+	}
+	s.compiledMu.Lock()
+	s.compiled[id] = c
+	s.compiledMu.Unlock()
+	return c, nil
+}
+
+func execute(c *compiled, v *domain.Version, data map[string]interface{}) (*domain.Rendered, error) {
+	values, err := prepareData(v.Variables, data)
+	if err != nil {
+		return nil, err
+	}
+	subject, html, text, err := c.execute(values)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Rendered{
+		Key:     v.Key,
+		Locale:  v.Locale,
+		Version: v.Version,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// fallbacks lists the locales tried when rendering, most specific first
+func (s *TemplateService) fallbacks(locale string) []string {
+	var candidates []string
+	add := func(l string) {
+		for _, c := range candidates {
+			if c == l {
+				return
+			}
+		}
+		candidates = append(candidates, l)
+	}
+
+	if normalized, err := normalizeLocale(locale); err == nil {
+		add(normalized)
+		if i := strings.IndexByte(normalized, '-'); i > 0 {
+			add(normalized[:i])
+		}
+	}
+	if s.opts.DefaultLocale != "" {
+		add(s.opts.DefaultLocale)
+	}
+	return candidates
+}
+
+// normalizeLocale turns "en_us" or "EN-us" into "en-US"
+func normalizeLocale(locale string) (string, error) {
+	m := localePattern.FindStringSubmatch(locale)
+	if m == nil {
+		return "", fmt.Errorf("%w: invalid locale %q", domain.ErrInvalidTemplate, locale)
+	}
+	if m[2] == "" {
+		return strings.ToLower(m[1]), nil
+	}
+	return strings.ToLower(m[1]) + "-" + strings.ToUpper(m[2]), nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/notifications/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryTemplates is an in-memory domain.TemplateRepository
+type memoryTemplates struct {
+	heads    map[string]*domain.Template
+	versions map[string][]*domain.Version
+}
+
+func newMemoryTemplates() *memoryTemplates {
+	return &memoryTemplates{heads: map[string]*domain.Template{}, versions: map[string][]*domain.Version{}}
+}
+
+func (m *memoryTemplates) CreateVersion(ctx context.Context, v *domain.Version) error {
+	id := v.Key + "/" + v.Locale
+	head, ok := m.heads[id]
+	if !ok {
+		head = &domain.Template{Key: v.Key, Locale: v.Locale}
+		m.heads[id] = head
+	}
+	head.LatestVersion++
+	v.ID = primitive.NewObjectID()
+	v.Version = head.LatestVersion
+	copied := *v
+	m.versions[id] = append(m.versions[id], &copied)
+	return nil
+}
+
+func (m *memoryTemplates) GetVersion(ctx context.Context, key, locale string, version int) (*domain.Version, error) {
+	for _, v := range m.versions[key+"/"+locale] {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryTemplates) ListVersions(ctx context.Context, key, locale string) ([]*domain.Version, error) {
+	return m.versions[key+"/"+locale], nil
+}
+
+func (m *memoryTemplates) Get(ctx context.Context, key, locale string) (*domain.Template, error) {
+	head, ok := m.heads[key+"/"+locale]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *head
+	return &copied, nil
+}
+
+func (m *memoryTemplates) List(ctx context.Context, key string) ([]*domain.Template, error) {
+	var templates []*domain.Template
+	for _, head := range m.heads {
+		if key == "" || head.Key == key {
+			templates = append(templates, head)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Locale < templates[j].Locale })
+	return templates, nil
+}
+
+func (m *memoryTemplates) Publish(ctx context.Context, key, locale string, version int, at time.Time) error {
+	head, ok := m.heads[key+"/"+locale]
+	if !ok || version > head.LatestVersion {
+		return domain.ErrNotFound
+	}
+	head.PublishedVersion = version
+	head.UpdatedAt = at
+	return nil
+}
+
+func newTestService() *TemplateService {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return New(newMemoryTemplates(), Options{DefaultLocale: "en"}, logger)
+}
+
+func orderShipped(subject string) *domain.Version {
+	return &domain.Version{
+		Subject: subject,
+		HTML:    `<p>Hi {{.Name}}, order {{.OrderID}} is on its way.</p>{{range .Items}}<li>{{.}}</li>{{end}}`,
+		Text:    `Hi {{.Name}}, order {{.OrderID}} shipped {{date "2006-01-02" .ShippedAt}}.`,
+		Variables: []domain.Variable{
+			{Name: "Name", Type: domain.VarString, Required: true, Example: "Ada"},
+			{Name: "OrderID", Type: domain.VarString, Required: true},
+			{Name: "Items", Type: domain.VarList},
+			{Name: "ShippedAt", Type: domain.VarTime},
+		},
+	}
+}
+
+func TestCreateVersion(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+
+	first, err := svc.CreateVersion(ctx, "order.shipped", "en_us", orderShipped("Order {{.OrderID}} shipped"))
+	require.NoError(t, err)
+	second, err := svc.CreateVersion(ctx, "order.shipped", "EN-US", orderShipped("Your order is on its way"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "en-US", first.Locale)
+	assert.Equal(t, 1, first.Version)
+	assert.Equal(t, 2, second.Version)
+
+	tests := []struct {
+		name    string
+		key     string
+		locale  string
+		version *domain.Version
+		wantErr string
+	}{
+		{"undeclared variable", "order.shipped", "en", &domain.Version{Subject: "Hi {{.Nmae}}", Text: "x"}, "undeclared variables: Nmae"},
+		{"undeclared top-level variable in range", "order.shipped", "en", &domain.Version{
+			Subject:   "Order",
+			Text:      "{{range .Items}}{{.Title}} {{$.Total}}{{end}}",
+			Variables: []domain.Variable{{Name: "Items", Type: domain.VarList}},
+		}, "undeclared variables: Total"},
+		{"syntax error", "order.shipped", "en", &domain.Version{Subject: "Hi {{.Name", Text: "x"}, "subject"},
+		{"missing body", "order.shipped", "en", &domain.Version{Subject: "Hi"}, "html or text body is required"},
+		{"unknown type", "order.shipped", "en", &domain.Version{
+			Subject: "Hi", Text: "x", Variables: []domain.Variable{{Name: "Name", Type: "text"}},
+		}, "unknown type"},
+		{"example of wrong type", "order.shipped", "en", &domain.Version{
+			Subject: "Hi", Text: "x", Variables: []domain.Variable{{Name: "Total", Type: domain.VarNumber, Example: "12"}},
+		}, "example of Total"},
+		{"invalid key", "Order Shipped", "en", orderShipped("Hi"), "invalid key"},
+		{"invalid locale", "order.shipped", "english", orderShipped("Hi"), "invalid locale"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateVersion(ctx, tt.key, tt.locale, tt.version)
+
+			assert.ErrorIs(t, err, domain.ErrInvalidTemplate)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	data := map[string]interface{}{
+		"Name":      "<b>Ada</b>",
+		"OrderID":   "A-1",
+		"Items":     []interface{}{"Mug", "Tee"},
+		"ShippedAt": "2025-06-01T10:00:00Z",
+	}
+
+	_, err := svc.Render(ctx, "order.shipped", "en", data)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "nothing is published yet")
+
+	_, err = svc.CreateVersion(ctx, "order.shipped", "en", orderShipped("Order {{.OrderID}} shipped"))
+	require.NoError(t, err)
+	_, err = svc.CreateVersion(ctx, "order.shipped", "en", orderShipped("Order {{.OrderID}}\n is on its way"))
+	require.NoError(t, err)
+	_, err = svc.CreateVersion(ctx, "order.shipped", "de", orderShipped("Bestellung {{.OrderID}} versandt"))
+	require.NoError(t, err)
+	_, err = svc.Publish(ctx, "order.shipped", "en", 1)
+	require.NoError(t, err)
+	_, err = svc.Publish(ctx, "order.shipped", "en", 3)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	rendered, err := svc.Render(ctx, "order.shipped", "en-GB", data)
+
+	require.NoError(t, err)
+	assert.Equal(t, "en", rendered.Locale)
+	assert.Equal(t, 1, rendered.Version)
+	assert.Equal(t, "Order A-1 shipped", rendered.Subject)
+	assert.Equal(t, "<p>Hi &lt;b&gt;Ada&lt;/b&gt;, order A-1 is on its way.</p><li>Mug</li><li>Tee</li>", rendered.HTML)
+	assert.Equal(t, "Hi <b>Ada</b>, order A-1 shipped 2025-06-01.", rendered.Text)
+
+	t.Run("publishing a new version switches the content", func(t *testing.T) {
+		_, err := svc.Publish(ctx, "order.shipped", "en", 2)
+		require.NoError(t, err)
+
+		rendered, err := svc.Render(ctx, "order.shipped", "en", data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Order A-1 is on its way", rendered.Subject)
+	})
+
+	t.Run("unpublished locales fall back to the default", func(t *testing.T) {
+		rendered, err := svc.Render(ctx, "order.shipped", "de-AT", data)
+
+		require.NoError(t, err)
+		assert.Equal(t, "en", rendered.Locale)
+	})
+
+	t.Run("data is validated against the schema", func(t *testing.T) {
+		_, err := svc.Render(ctx, "order.shipped", "en", map[string]interface{}{
+			"Name":      42,
+			"ShippedAt": "yesterday",
+			"Coupon":    "X",
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidData)
+		assert.EqualError(t, err, "invalid template data: "+
+			"Coupon is not declared; Name: expected string, got int; OrderID is required; ShippedAt: expected an RFC 3339 time")
+	})
+
+	t.Run("optional variables may be left out", func(t *testing.T) {
+		rendered, err := svc.Render(ctx, "order.shipped", "en", map[string]interface{}{"Name": "Ada", "OrderID": "A-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Hi Ada, order A-1 shipped .", rendered.Text)
+	})
+}
+
+func TestTestRender(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	_, err := svc.CreateVersion(ctx, "order.shipped", "en", orderShipped("Order {{.OrderID}} shipped"))
+	require.NoError(t, err)
+
+	rendered, err := svc.TestRender(ctx, "order.shipped", "en", 1, nil)
+
+	require.NoError(t, err, "draft versions render with the schema examples")
+	assert.Equal(t, "Order [OrderID] shipped", rendered.Subject)
+	assert.Equal(t, "Hi Ada, order [OrderID] shipped 2006-01-02.", rendered.Text)
+
+	preview, err := svc.Preview(ctx, &domain.Version{
+		Subject:   "Welcome {{.Name | upper}}",
+		Text:      "{{default \"friend\" .Nick}}",
+		Variables: []domain.Variable{{Name: "Name", Type: domain.VarString}, {Name: "Nick", Type: domain.VarString}},
+	}, map[string]interface{}{"Name": "ada"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome ADA", preview.Subject)
+	assert.Equal(t, "friend", preview.Text)
+}
@@ -0,0 +1,101 @@
+// Command backfill recomputes the derived fields stored on products, such
+// as inventory.in_stock, across the catalog. Run it after changing how a
+// derived field is computed. It reads the same MONGODB_* environment
+// variables as the service.
+//
+//	backfill -dry-run                       # count stale fields, write nothing
+//	backfill -rate 200                      # recompute every derived field
+//	backfill -fields inventory.in_stock     # recompute only some
+//	backfill -after 65f1c0d2e4b0a1b2c3d4e5f1  # resume after an interrupted run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/product-service/internal/service"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+func main() {
+	var paths []string
+	for _, field := range domain.DerivedFields {
+		paths = append(paths, field.Path)
+	}
+	fields := flag.String("fields", "", "comma-separated derived fields to recompute, of: "+strings.Join(paths, ", ")+" (default all)")
+	batchSize := flag.Int("batch-size", 500, "products read and written at once")
+	rate := flag.Float64("rate", 1000, "products processed per second at most; 0 is unlimited")
+	after := flag.String("after", "", "resume after the product with this ID")
+	dryRun := flag.Bool("dry-run", false, "count the stale fields without writing them")
+	flag.Parse()
+
+	cfg := config.Load()
+	logger := logging.New(os.Stderr, logging.Options{
+		Service: "backfill",
+		Version: version,
+		Level:   cfg.Logging.Level,
+		Text:    !cfg.Logging.JSON,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := connect(ctx, cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect(context.Background())
+
+	repo := mongodb.New(client, &cfg.MongoDB)
+	productService := service.New(repo, logger)
+	productService.SetBackfillRepository(repo)
+
+	opts := service.BackfillOptions{
+		BatchSize: *batchSize,
+		Rate:      *rate,
+		After:     *after,
+		DryRun:    *dryRun,
+	}
+	if *fields != "" {
+		opts.Fields = strings.Split(*fields, ",")
+	}
+	result, err := productService.BackfillDerivedFields(ctx, opts, func(p service.BackfillProgress) {
+		logger.Info("Backfill progress", "scanned", p.Scanned, "updated", p.Updated, "stale", p.Stale, "lastID", p.LastID)
+	})
+	if err != nil {
+		logger.Error("Backfill failed; rerun with -after to resume", "error", err, "lastID", result.LastID)
+		os.Exit(1)
+	}
+	fmt.Printf("scanned %d products, updated %d\n", result.Scanned, result.Updated)
+	for path, n := range result.Stale {
+		fmt.Printf("  %s: %d stale\n", path, n)
+	}
+}
+
+func connect(ctx context.Context, cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.ConnectionString()))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
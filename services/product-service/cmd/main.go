@@ -2,40 +2,105 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/bekbull/online-shop/proto/product"
+	"github.com/bekbull/online-shop/pkg/dualwrite"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/grpcclient"
+	"github.com/bekbull/online-shop/pkg/httptls"
+	"github.com/bekbull/online-shop/pkg/listeners"
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"github.com/bekbull/online-shop/pkg/objectstore"
+	"github.com/bekbull/online-shop/pkg/tracing"
+	"github.com/bekbull/online-shop/pkg/workers"
+	productv1 "github.com/bekbull/online-shop/proto/product/v1"
 	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/api/gateway"
 	grpcHandler "github.com/bekbull/online-shop/services/product-service/internal/api/grpc"
 	restHandler "github.com/bekbull/online-shop/services/product-service/internal/api/rest"
+	"github.com/bekbull/online-shop/services/product-service/internal/clients/fx"
+	"github.com/bekbull/online-shop/services/product-service/internal/clients/inventory"
+	"github.com/bekbull/online-shop/services/product-service/internal/clients/orders"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/feed"
+	"github.com/bekbull/online-shop/services/product-service/internal/invalidation"
+	"github.com/bekbull/online-shop/services/product-service/internal/popularity"
 	"github.com/bekbull/online-shop/services/product-service/internal/repository/mongodb"
 	"github.com/bekbull/online-shop/services/product-service/internal/service"
+	"github.com/bekbull/online-shop/services/product-service/internal/storage"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
-	// Initialize logger
-	logger := setupLogger()
-	logger.Info("Starting Product Service")
+	validateOnly := flag.Bool("validate-config", false, "validate the configuration and exit")
+	flag.Parse()
 
-	// Load configuration
+	// Load configuration, failing fast on invalid settings
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *validateOnly {
+		fmt.Println("Configuration is valid")
+		return
+	}
+
+	// Initialize logger
+	logger := setupLogger(cfg)
+	slog.SetDefault(logger)
+	logger.Info("Starting Product Service")
 	logger.Info("Configuration loaded")
 
+	// Export traces of requests, service calls and MongoDB commands
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+			Service:     cfg.Tracing.ServiceName,
+			Version:     version,
+			Endpoint:    cfg.Tracing.Endpoint,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Error("Failed to set up tracing", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error("Failed to flush traces", "error", err)
+			}
+		}()
+		logger.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint)
+	}
+
 	// Connect to MongoDB
-	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	mongoClient, err := connectToMongoDB(cfg.MongoDB, logger)
 	if err != nil {
 		logger.Error("Failed to connect to MongoDB", "error", err)
 		os.Exit(1)
@@ -53,64 +118,530 @@ func main() {
 	// Create service
 	productService := service.New(productRepo, logger)
 
+	// Index the catalog for lists, searches and lookups, failing to start
+	// if an index cannot be built, and store suppliers, purchase orders,
+	// the category tree, reviews, promotions and stock alerts next to it
+	supplierRepo := mongodb.NewSupplierRepository(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), cfg.MongoDB.WriteTimeout)
+	if err := productRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create product indexes", "error", err)
+		os.Exit(1)
+	}
+	if err := supplierRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create supplier indexes", "error", err)
+		os.Exit(1)
+	}
+	purchaseOrderRepo := mongodb.NewPurchaseOrderRepository(mongoClient, &cfg.MongoDB)
+	if err := purchaseOrderRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create purchase order indexes", "error", err)
+		os.Exit(1)
+	}
+	categoryRepo := mongodb.NewCategoryRepository(mongoClient, &cfg.MongoDB)
+	if err := categoryRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create category indexes", "error", err)
+		os.Exit(1)
+	}
+	reviewRepo := mongodb.NewReviewRepository(mongoClient, &cfg.MongoDB)
+	if err := reviewRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create review indexes", "error", err)
+		os.Exit(1)
+	}
+	promotionRepo := mongodb.NewPromotionRepository(mongoClient, &cfg.MongoDB)
+	if err := promotionRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create promotion indexes", "error", err)
+		os.Exit(1)
+	}
+	stockAlertRepo := mongodb.NewStockAlertRepository(mongoClient, &cfg.MongoDB)
+	if err := stockAlertRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create stock alert indexes", "error", err)
+		os.Exit(1)
+	}
+	auditRepo := mongodb.NewAuditRepository(mongoClient, &cfg.MongoDB)
+	if err := auditRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create audit indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+	productService.SetSupplierRepository(supplierRepo)
+	productService.SetPurchaseOrderRepository(purchaseOrderRepo)
+	productService.SetCategoryRepository(categoryRepo)
+	productService.SetReviewRepository(reviewRepo)
+	productService.SetStockAlertRepository(stockAlertRepo)
+	productService.SetAuditRepository(auditRepo)
+
+	// Count product views and purchases for trending products and the
+	// popularity sort
+	if cfg.Popularity.Enabled {
+		popularityRepo := mongodb.NewPopularityRepository(mongoClient, &cfg.MongoDB)
+		indexCtx, cancelIndex := context.WithTimeout(context.Background(), cfg.MongoDB.WriteTimeout)
+		err := popularityRepo.EnsureIndexes(indexCtx)
+		cancelIndex()
+		if err != nil {
+			logger.Error("Failed to create popularity indexes", "error", err)
+			os.Exit(1)
+		}
+
+		var counter service.PopularityCounter
+		if cfg.Popularity.RedisAddr != "" {
+			redisClient := redis.NewClient(&redis.Options{
+				Addr:     cfg.Popularity.RedisAddr,
+				Password: cfg.Popularity.RedisPassword,
+				DB:       cfg.Popularity.RedisDB,
+			})
+			defer redisClient.Close()
+			counter = popularity.NewRedisCounter(redisClient, "product-service:popularity")
+			logger.Info("Popularity counted in Redis", "redis", cfg.Popularity.RedisAddr)
+		} else {
+			counter = popularity.NewMemoryCounter()
+			logger.Info("Popularity counted in memory", "flushInterval", cfg.Popularity.FlushInterval)
+		}
+		productService.SetPopularity(counter, popularityRepo)
+	}
+
+	// Products count as new for the configured window; expired features
+	// are removed so that they no longer sort first
+	productService.SetNewArrivalWindow(cfg.Merchandising.NewArrivalWindow)
+
+	// Search box suggestions answer within their latency budget, with
+	// whatever was found by then
+	productService.SetSuggestOptions(domain.SuggestOptions{
+		DefaultLimit: cfg.Suggest.DefaultLimit,
+		MaxLimit:     cfg.Suggest.MaxLimit,
+		Budget:       cfg.Suggest.Budget,
+	})
+
+	// Tags in use are counted across the catalog at most once per TTL
+	productService.SetTagsCacheTTL(cfg.Tags.CacheTTL)
+
+	// Show products with the admin-defined badges; changes made through
+	// other instances show once the definitions are refreshed
+	productService.SetBadgeRepository(mongodb.NewBadgeRepository(mongoClient, &cfg.MongoDB))
+	badgeCtx, cancelBadges := context.WithTimeout(context.Background(), cfg.MongoDB.ReadTimeout)
+	if err := productService.RefreshBadges(badgeCtx); err != nil {
+		logger.Error("Failed to load badges", "error", err)
+	}
+	cancelBadges()
+
+	// Discount products by the live promotions, reloaded like badges
+	productService.SetPromotionRepository(promotionRepo)
+	promotionCtx, cancelPromotions := context.WithTimeout(context.Background(), cfg.MongoDB.ReadTimeout)
+	if err := productService.RefreshPromotions(promotionCtx); err != nil {
+		logger.Error("Failed to load promotions", "error", err)
+	}
+	cancelPromotions()
+
+	// Tell the other instances straight away when a write makes their tags,
+	// badges or promotions stale, rather than leaving them to expire or be
+	// refreshed
+	if cfg.Caches.InvalidationRedisAddr != "" {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Caches.InvalidationRedisAddr,
+			Password: cfg.Caches.InvalidationRedisPassword,
+			DB:       cfg.Caches.InvalidationRedisDB,
+		})
+		defer redisClient.Close()
+
+		broadcaster := invalidation.NewRedisBroadcaster(redisClient, cfg.Caches.InvalidationChannel, logger)
+		productService.SetCacheInvalidator(broadcaster)
+		listenCtx, stopListening := context.WithCancel(context.Background())
+		defer stopListening()
+		go broadcaster.Listen(listenCtx, productService.DropCache)
+	}
+
+	// Capture daily inventory snapshots for the stock level and
+	// sell-through reports; each capture replaces today's snapshots
+	if cfg.Reporting.SnapshotsEnabled {
+		snapshotRepo := mongodb.NewSnapshotRepository(mongoClient, &cfg.MongoDB)
+		indexCtx, cancelIndex := context.WithTimeout(context.Background(), cfg.MongoDB.WriteTimeout)
+		err := snapshotRepo.EnsureIndexes(indexCtx)
+		cancelIndex()
+		if err != nil {
+			logger.Error("Failed to create snapshot indexes", "error", err)
+			os.Exit(1)
+		}
+		productService.SetSnapshotRepository(snapshotRepo)
+	}
+
+	// Publish product and inventory events if enabled
+	if cfg.Events.Enabled {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Events.RedisAddr,
+			Password: cfg.Events.RedisPassword,
+			DB:       cfg.Events.RedisDB,
+		})
+		defer redisClient.Close()
+
+		bus := eventbus.NewRedisBus(redisClient, eventbus.RedisConfig{
+			StreamPrefix: cfg.Events.StreamPrefix,
+			MaxLen:       cfg.Events.MaxLen,
+		}, logger)
+		productService.SetPublisher(bus)
+		logger.Info("Event publishing enabled", "redis", cfg.Events.RedisAddr)
+
+		// Apply paid and cancelled orders to stock. Replicas in the same
+		// consumer group split the events between them.
+		if cfg.Events.ConsumeOrders {
+			consumeCtx, stopConsuming := context.WithCancel(context.Background())
+			defer stopConsuming()
+			go func() {
+				logger.Info("Consuming order events", "group", cfg.Events.ConsumerGroup, "types", service.OrderEventTypes)
+				if err := bus.Subscribe(consumeCtx, cfg.Events.ConsumerGroup, service.OrderEventTypes, productService.HandleOrderEvent); err != nil {
+					logger.Error("Order event consumer failed", "error", err)
+					os.Exit(1)
+				}
+			}()
+		}
+	}
+
+	// Load TLS credentials for the gRPC server and clients; certificates
+	// are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go creds.Watch(watchCtx)
+
+	// Upstream connections resolve through DNS or Consul and balance
+	// round-robin over healthy instances
+	clientFactory := grpcclient.New(cfg.Discovery, creds, logger)
+
+	// Show prices in other currencies via the FX service if configured
+	if cfg.FX.Addr != "" {
+		fxClient, err := fx.New(cfg.FX.Addr, cfg.FX.Timeout, clientFactory.DialOptions("fx")...)
+		if err != nil {
+			logger.Error("Failed to create FX client", "error", err)
+			os.Exit(1)
+		}
+		defer fxClient.Close()
+
+		productService.SetCurrencyConverter(fxClient, cfg.FX.PriceCurrency)
+		logger.Info("FX service client enabled", "addr", cfg.FX.Addr, "priceCurrency", cfg.FX.PriceCurrency)
+	}
+
+	// Serve downloads of digital products if a bucket is configured
+	if cfg.Downloads.BucketURL != "" {
+		presigner, err := storage.NewS3Presigner(cfg.Downloads.BucketURL, cfg.Downloads.Region, cfg.Downloads.AccessKeyID, cfg.Downloads.SecretAccessKey)
+		if err != nil {
+			logger.Error("Failed to create download signer", "error", err)
+			os.Exit(1)
+		}
+		productService.SetDownloads(
+			mongodb.NewDownloadRepository(mongoClient, &cfg.MongoDB),
+			orders.New(cfg.Downloads.OrderServiceURL, cfg.Downloads.OrderServiceTimeout),
+			presigner,
+			cfg.Downloads.URLTTL,
+		)
+		logger.Info("Digital product downloads enabled", "bucket", cfg.Downloads.BucketURL, "orderService", cfg.Downloads.OrderServiceURL)
+	}
+
+	if cfg.Images.BucketURL != "" {
+		objects, err := objectstore.NewS3(cfg.Images.BucketURL, cfg.Images.Region, cfg.Images.AccessKeyID, cfg.Images.SecretAccessKey)
+		if err != nil {
+			logger.Error("Failed to create image storage", "error", err)
+			os.Exit(1)
+		}
+		publicURL := cfg.Images.PublicURL
+		if publicURL == "" {
+			publicURL = cfg.Images.BucketURL
+		}
+		productService.SetImageStorage(storage.NewImageStore(objects, publicURL), cfg.Images.MaxBytes)
+		logger.Info("Product image uploads enabled", "bucket", cfg.Images.BucketURL, "publicURL", publicURL)
+	}
+	imagePolicy := domain.ImagePolicy{
+		AllowedHosts: cfg.Images.AllowedHosts,
+		CDNHost:      cfg.Images.CDNHost,
+		OriginHosts:  cfg.Images.OriginHosts,
+	}
+	// Uploaded images are always allowed
+	if len(imagePolicy.AllowedHosts) > 0 && cfg.Images.BucketURL != "" {
+		for _, base := range []string{cfg.Images.PublicURL, cfg.Images.BucketURL} {
+			if u, err := url.Parse(base); err == nil && u.Host != "" {
+				imagePolicy.AllowedHosts = append(imagePolicy.AllowedHosts, u.Hostname())
+			}
+		}
+	}
+	productService.SetImagePolicy(imagePolicy)
+
+	// Build the shared middleware stack
+	stack := newMiddlewareStack(cfg, logger)
+	defer stack.Close()
+
+	// Read availability from the inventory service if configured. Until
+	// MIGRATION_MODES says otherwise, reads go to the service and fall back
+	// to the product's own inventory.
+	if cfg.Inventory.Addr != "" {
+		inventoryClient, err := inventory.New(cfg.Inventory.Addr, cfg.Inventory.Timeout, clientFactory.DialOptions("inventory")...)
+		if err != nil {
+			logger.Error("Failed to create inventory client", "error", err)
+			os.Exit(1)
+		}
+		defer inventoryClient.Close()
+
+		warehouses := dualwrite.New(service.WarehouseMigration, cfg.Migrations.Flags(), dualwrite.Options{
+			Default:       dualwrite.ModeCutover,
+			ShadowTimeout: cfg.Migrations.ShadowTimeout,
+			Metrics:       dualwrite.NewMetrics(stack.registry, "product_service"),
+		}, logger)
+		productService.SetInventoryClient(inventoryClient, warehouses)
+		logger.Info("Inventory service client enabled", "addr", cfg.Inventory.Addr, "migrationMode", warehouses.Mode())
+	}
+
+	// Run the background workers. Feature expiry, preorder release and
+	// inventory snapshots change shared data, so they run on one instance
+	// at a time and the others skip a round while it is locked.
+	locker := locks.New(locks.NewMongoStore(mongoClient.Database(cfg.MongoDB.Database).Collection(cfg.Locks.Collection)),
+		locks.Options{TTL: cfg.Locks.TTL, StoreTimeout: cfg.MongoDB.WriteTimeout}, logger)
+	jobs := workers.NewGroup(workers.Options{Locker: locker, Metrics: workers.NewMetrics(stack.registry, "product_service")}, logger)
+	if cfg.Popularity.Enabled {
+		// Counted views and purchases are flushed by every instance
+		jobs.Add(workers.Worker{
+			Name:     "popularity-flush",
+			Schedule: workers.Every(cfg.Popularity.FlushInterval),
+			Run: func(ctx context.Context) error {
+				_, err := productService.FlushPopularity(ctx)
+				return err
+			},
+		})
+	}
+	jobs.Add(workers.Worker{
+		Name:      "feature-expiry",
+		Schedule:  workers.Every(cfg.Merchandising.FeatureExpiryInterval),
+		Singleton: true,
+		Run: func(ctx context.Context) error {
+			_, err := productService.UnfeatureExpired(ctx)
+			return err
+		},
+	})
+	jobs.Add(workers.Worker{
+		// Release products on preorder once their release date has passed
+		Name:      "preorder-release",
+		Schedule:  workers.Every(cfg.Merchandising.PreorderReleaseInterval),
+		Singleton: true,
+		Run: func(ctx context.Context) error {
+			_, err := productService.ReleasePreorders(ctx)
+			return err
+		},
+	})
+	jobs.Add(workers.Worker{
+		// Each instance keeps its own copy of the badge definitions
+		Name:     "badge-refresh",
+		Schedule: workers.Every(cfg.Merchandising.BadgeRefreshInterval),
+		Run:      productService.RefreshBadges,
+	})
+	jobs.Add(workers.Worker{
+		// And of the live promotions
+		Name:     "promotion-refresh",
+		Schedule: workers.Every(cfg.Merchandising.PromotionRefreshInterval),
+		Run:      productService.RefreshPromotions,
+	})
+	if cfg.Reporting.SnapshotsEnabled {
+		jobs.Add(workers.Worker{
+			Name:       "inventory-snapshots",
+			Schedule:   workers.Every(cfg.Reporting.SnapshotInterval),
+			RunOnStart: true,
+			Singleton:  true,
+			Run: func(ctx context.Context) error {
+				_, err := productService.CaptureInventorySnapshots(ctx)
+				return err
+			},
+		})
+	}
+	jobs.Start(context.Background())
+
 	// Setup HTTP server
-	router := setupHTTPServer(cfg, productService, logger)
+	router := setupHTTPServer(cfg, productService, stack, logger)
 
 	// Setup gRPC server
-	grpcServer := setupGRPCServer(cfg, productService, logger)
+	healthServer := health.NewServer()
+	grpcServer := setupGRPCServer(cfg, productService, stack, creds, healthServer, logger)
+
+	// The REST API is served over HTTPS when HTTP_TLS_* is set
+	httpTLS, err := httptls.Load(watchCtx, cfg.HTTPTLS, logger)
+	if err != nil {
+		logger.Error("Failed to load HTTP TLS configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Start HTTP server
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.HTTPPort),
 		Handler:      router,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
+		TLSConfig:    httpTLS,
 	}
 
-	// Start servers in goroutines
-	go func() {
-		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("HTTP server failed", "error", err)
-			os.Exit(1)
-		}
-	}()
+	// Bind every address before serving, so a taken port or socket stops
+	// the service at startup
+	httpAddrs := cfg.Listen.HTTPAddrs(cfg.HTTPPort)
+	httpListeners, err := cfg.Listen.Listen(httpAddrs)
+	if err != nil {
+		logger.Error("Failed to listen for HTTP", "error", err)
+		os.Exit(1)
+	}
+	grpcListeners, err := cfg.Listen.Listen(cfg.Listen.GRPCAddrs(cfg.GRPCPort))
+	if err != nil {
+		logger.Error("Failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	for i, lis := range grpcListeners {
+		grpcListeners[i] = cfg.GRPC.Listener(lis)
+	}
 
-	go func() {
-		logger.Info("Starting gRPC server", "port", cfg.GRPCPort)
-		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
-		if err != nil {
-			logger.Error("Failed to listen for gRPC", "error", err)
-			os.Exit(1)
-		}
-		if err := grpcServer.Serve(lis); err != nil {
-			logger.Error("gRPC server failed", "error", err)
-			os.Exit(1)
-		}
-	}()
+	// Start servers; each listener is served on its own, and the service
+	// exits once all of a server's listeners have failed
+	exit := func() { os.Exit(1) }
+	logger.Info("Starting HTTP server", "tls", httpTLS != nil)
+	listeners.Serve(logger, "HTTP", httpListeners, func(lis net.Listener) error {
+		return httptls.Serve(httpServer, lis)
+	}, exit)
+	if redirectServer := cfg.HTTPTLS.RedirectServer(listeners.TCPAddr(httpAddrs)); redirectServer != nil {
+		httpServer.RegisterOnShutdown(func() { redirectServer.Close() })
+		go func() {
+			logger.Info("Redirecting HTTP to HTTPS", "addr", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTPS redirect server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	logger.Info("Starting gRPC server")
+	listeners.Serve(logger, "gRPC", grpcListeners, grpcServer.Serve, exit)
 
 	// Handle graceful shutdown
-	handleGracefulShutdown(httpServer, grpcServer, logger)
+	handleGracefulShutdown(httpServer, grpcServer, healthServer, jobs, logger)
+}
+
+func setupLogger(cfg *config.Config) *slog.Logger {
+	return logging.New(os.Stdout, logging.Options{
+		Service: "product-service",
+		Version: version,
+		Level:   cfg.Logging.Level,
+		Text:    !cfg.Logging.JSON,
+	})
 }
 
-func setupLogger() *slog.Logger {
-	// Create a simple logger for now
-	// In a real application, we would configure this based on environment
-	// and use structured logging with proper levels and output formats
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+// middlewareStack holds the middleware shared by the HTTP and gRPC servers
+type middlewareStack struct {
+	registry    *prometheus.Registry
+	metrics     *middleware.Metrics
+	slos        *middleware.SLOTracker
+	shedder     *middleware.LoadShedder
+	recovery    middleware.Recovery
+	authn       middleware.Authenticator
+	limiter     middleware.Limiter
+	quotas      middleware.QuotaLimiter
+	policies    *middleware.PolicySet
+	idempotency middleware.IdempotencyStore
+	endpoints   *middleware.EndpointPolicies
+	closers     []func() error
 }
 
-func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+func newMiddlewareStack(cfg *config.Config, logger *slog.Logger) *middlewareStack {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	stack := &middlewareStack{
+		registry: registry,
+		metrics:  middleware.NewMetrics(registry, "product_service"),
+		slos: middleware.NewSLOTracker(registry, "product_service", middleware.Objective{
+			Name:    "GetProduct",
+			Routes:  []string{"GET /v1/products/{id}", productv1.ProductService_GetProduct_FullMethodName},
+			Target:  0.999,
+			Latency: 300 * time.Millisecond,
+		}),
+	}
+	stack.recovery = middleware.Recovery{Logger: logger, Metrics: stack.metrics}
+	if url := cfg.Server.PanicAlertURL; url != "" {
+		stack.recovery.Alert = middleware.NewWebhookAlert(url, "product-service", logger)
+	}
+	if limit := cfg.Server.LoadShedMaxConcurrency; limit > 0 {
+		stack.shedder = middleware.NewLoadShedder(registry, "product_service", middleware.LoadShedOptions{
+			MaxConcurrency: limit,
+			LatencyTarget:  cfg.Server.LoadShedLatencyTarget,
+			QueueSize:      cfg.Server.LoadShedQueueSize,
+			QueueTimeout:   cfg.Server.LoadShedQueueTimeout,
+			Exempt:         []string{"/health", "/slo", cfg.Metrics.Path, healthpb.Health_Check_FullMethodName},
+		})
+	}
+
+	endpoints, err := cfg.Server.EndpointPolicies()
+	if err != nil {
+		logger.Error("Failed to load endpoint policies", "error", err)
+		os.Exit(1)
+	}
+	stack.endpoints = endpoints
+
+	if tokens := middleware.ParseTokens(cfg.Server.AuthTokens); len(tokens) > 0 {
+		stack.authn = middleware.StaticTokens(tokens)
+		logger.Info("Authentication enabled for write operations", "tokens", len(tokens))
+	}
+	defaultPolicy := middleware.Policy{RPS: cfg.Server.RateLimitRPS, Burst: cfg.Server.RateLimitBurst}
+	switch {
+	case cfg.Server.RateLimitRedisAddr != "":
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Server.RateLimitRedisAddr,
+			Password: cfg.Server.RateLimitRedisPassword,
+		})
+		stack.closers = append(stack.closers, redisClient.Close)
+		stack.quotas = middleware.NewRedisLimiter(redisClient, "product-service:ratelimit", defaultPolicy)
+		stack.policies = middleware.NewPolicySet(middleware.RateLimitPolicies{Default: defaultPolicy})
+		if path := cfg.Server.RateLimitPoliciesFile; path != "" {
+			if err := stack.policies.LoadFile(path); err != nil {
+				logger.Error("Failed to load rate limit policies", "error", err)
+				os.Exit(1)
+			}
+			watchCtx, stopWatch := context.WithCancel(context.Background())
+			stack.closers = append(stack.closers, func() error { stopWatch(); return nil })
+			go stack.policies.Watch(watchCtx, path, 10*time.Second, logger)
+		}
+		logger.Info("Distributed rate limiting enabled", "redis", cfg.Server.RateLimitRedisAddr, "policies", cfg.Server.RateLimitPoliciesFile)
+	case cfg.Server.RateLimitRPS > 0:
+		stack.limiter = middleware.NewLocalLimiter(cfg.Server.RateLimitRPS, cfg.Server.RateLimitBurst)
+		logger.Info("Rate limiting enabled", "rps", cfg.Server.RateLimitRPS, "burst", cfg.Server.RateLimitBurst)
+	}
+	if cfg.Idempotency.RedisAddr != "" {
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     cfg.Idempotency.RedisAddr,
+			Password: cfg.Idempotency.RedisPassword,
+			DB:       cfg.Idempotency.RedisDB,
+		})
+		stack.closers = append(stack.closers, redisClient.Close)
+		stack.idempotency = middleware.NewRedisIdempotencyStore(redisClient, "product-service:idempotency")
+		logger.Info("Idempotency keys stored in Redis", "redis", cfg.Idempotency.RedisAddr)
+	} else {
+		stack.idempotency = middleware.NewMemoryIdempotencyStore()
+		logger.Warn("IDEMPOTENCY_REDIS_ADDR not set, idempotency keys are only honored per instance")
+	}
+	return stack
+}
+
+// Close releases the connections held by the stack
+func (s *middlewareStack) Close() {
+	for _, closer := range s.closers {
+		closer()
+	}
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig, logger *slog.Logger) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
 	defer cancel()
 
 	// Create MongoDB client
 	clientOptions := options.Client().
 		ApplyURI(cfg.ConnectionString()).
-		SetMaxPoolSize(cfg.MaxPoolSize)
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMonitor(mongodb.NewCommandMonitor(logger))
 
 	// Connect to MongoDB
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -126,49 +657,175 @@ func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
 	return client, nil
 }
 
-func setupHTTPServer(cfg *config.Config, productService *service.ProductService, logger *slog.Logger) *chi.Mux {
+// accessLogOptions returns the access log sampling configuration
+func accessLogOptions(cfg *config.Config) logging.AccessLogOptions {
+	return logging.AccessLogOptions{
+		SampleRate:    cfg.Logging.AccessSampleRate,
+		SlowThreshold: cfg.Logging.AccessSlowThreshold,
+	}
+}
+
+// feedOptions describes the store to the product feeds. The store URL is
+// the product URL's origin unless set.
+func feedOptions(cfg *config.Config) feed.Options {
+	storeURL := cfg.Feeds.StoreURL
+	if storeURL == "" {
+		if u, err := url.Parse(cfg.Feeds.ProductURL); err == nil {
+			storeURL = u.Scheme + "://" + u.Host + "/"
+		}
+	}
+	return feed.Options{
+		Title:      cfg.Feeds.Title,
+		StoreURL:   storeURL,
+		ProductURL: cfg.Feeds.ProductURL,
+		Currency:   cfg.FX.PriceCurrency,
+		Brand:      cfg.Feeds.Brand,
+	}
+}
+
+func setupHTTPServer(cfg *config.Config, productService *service.ProductService, stack *middlewareStack, logger *slog.Logger) *chi.Mux {
 	// Create router
 	router := chi.NewRouter()
 
 	// Add middleware
+	router.Use(tracing.HTTPMiddleware)
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(30 * time.Second))
+	router.Use(logging.HTTPMiddleware(logger))
+	router.Use(chimiddleware.RealIP)
+	router.Use(logging.AccessLog(logger, accessLogOptions(cfg)))
+	router.Use(stack.recovery.HTTP)
+	router.Use(stack.metrics.HTTP)
+	router.Use(stack.slos.HTTP)
+	if stack.shedder != nil {
+		router.Use(stack.shedder.HTTP)
+	}
+	if stack.limiter != nil {
+		router.Use(middleware.RateLimit(stack.limiter, logger))
+	}
+	if stack.authn != nil {
+		// Reads stay public; writes need a token
+		router.Use(middleware.Auth(stack.authn, middleware.SkipSafeMethods))
+	}
+	if stack.quotas != nil {
+		// After Auth, so that quotas can be chosen per caller
+		router.Use(middleware.PolicyRateLimit(stack.quotas, stack.policies, logger))
+	}
+	// Timeouts and body limits per route, SERVER_REQUEST_TIMEOUT and
+	// SERVER_MAX_BODY_BYTES by default
+	router.Use(middleware.EndpointLimits(stack.endpoints))
+	// Retries of POSTs carrying an Idempotency-Key replay the first response
+	router.Use(middleware.Idempotency(stack.idempotency, cfg.Idempotency.TTL, logger))
 
 	// Create REST handler
 	productHandler := restHandler.NewProductHandler(productService, logger)
+	productHandler.SetSunset(cfg.Server.V1Sunset)
+	if stack.authn != nil {
+		productHandler.SetAuthenticator(stack.authn)
+	}
 
 	// Register routes
 	productHandler.RegisterRoutes(router)
 
+	// The /v2 API is transcoded from the gRPC ProductService and shares its
+	// handler, so the two cannot drift apart
+	productGateway, err := gateway.New(grpcHandler.New(productService, logger))
+	if err != nil {
+		logger.Error("Failed to set up the REST gateway", "error", err)
+		os.Exit(1)
+	}
+	productGateway.RegisterRoutes(router)
+	restHandler.NewSupplierHandler(productService, logger).RegisterRoutes(router)
+	restHandler.NewBadgeHandler(productService, logger).RegisterRoutes(router)
+	restHandler.NewCategoryHandler(productService, logger).RegisterRoutes(router)
+	restHandler.NewReportHandler(productService, logger).RegisterRoutes(router)
+	restHandler.NewPurchaseOrderHandler(productService, logger).RegisterRoutes(router)
+	if cfg.Feeds.ProductURL != "" {
+		restHandler.NewFeedHandler(productService, feedOptions(cfg), logger).RegisterRoutes(router)
+	}
+	router.Group(func(r chi.Router) {
+		// Promotions and stock alerts are managed by admins, reads
+		// included, and only admins read the product audit trail; the
+		// tags in use are listed for the admin UI
+		if stack.authn != nil {
+			r.Use(middleware.RequireRole(stack.authn, middleware.RoleAdmin))
+		}
+		restHandler.NewPromotionHandler(productService, logger).RegisterRoutes(r)
+		restHandler.NewStockAlertHandler(productService, logger).RegisterRoutes(r)
+		restHandler.NewAuditHandler(productService, logger).RegisterRoutes(r)
+		restHandler.NewTagHandler(productService, logger).RegisterRoutes(r)
+	})
+
+	// The OpenAPI spec of the /v1/products API, browsable with Swagger UI
+	// at /docs outside production
+	restHandler.NewDocsHandler(cfg.Env != "production").RegisterRoutes(router)
+
 	// Add health check
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	// Add metrics endpoint (in a real application, we'd configure Prometheus here)
+	// Rolling error rates of the service level objectives
+	router.Get("/slo", stack.slos.ServeHTTP)
+
+	// Add metrics endpoint
 	if cfg.Metrics.Enabled {
-		router.Get(cfg.Metrics.Path, func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("Metrics would be here"))
-		})
+		router.Method(http.MethodGet, cfg.Metrics.Path, promhttp.HandlerFor(stack.registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 	}
 
 	return router
 }
 
-func setupGRPCServer(cfg *config.Config, productService *service.ProductService, logger *slog.Logger) *grpc.Server {
+func setupGRPCServer(cfg *config.Config, productService *service.ProductService, stack *middlewareStack, creds *mtls.Credentials, healthServer *health.Server, logger *slog.Logger) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{
+		middleware.RequestIDUnary,
+		logging.UnaryServerInterceptor(logger),
+		logging.AccessLogUnary(logger, accessLogOptions(cfg)),
+		stack.recovery.Unary,
+		stack.metrics.UnaryServerInterceptor,
+		stack.slos.UnaryServerInterceptor,
+	}
+	if stack.shedder != nil {
+		unary = append(unary, stack.shedder.UnaryServerInterceptor)
+	}
+	streams := []grpc.StreamServerInterceptor{
+		middleware.RequestIDStream,
+		logging.StreamServerInterceptor(logger),
+		logging.AccessLogStream(logger, accessLogOptions(cfg)),
+		stack.recovery.Stream,
+		stack.metrics.StreamServerInterceptor,
+	}
+	if stack.limiter != nil {
+		unary = append(unary, middleware.RateLimitUnary(stack.limiter, logger))
+		streams = append(streams, middleware.RateLimitStream(stack.limiter, logger))
+	}
+	if stack.authn != nil {
+		readOnly := middleware.SkipMethods("GetProduct", "ListProducts", "CheckStock", "WatchInventory", "StreamProducts",
+			healthpb.Health_Check_FullMethodName, healthpb.Health_Watch_FullMethodName)
+		unary = append(unary, middleware.AuthUnary(stack.authn, readOnly))
+		streams = append(streams, middleware.AuthStream(stack.authn, readOnly))
+	}
+	if stack.quotas != nil {
+		unary = append(unary, middleware.PolicyRateLimitUnary(stack.quotas, stack.policies, logger))
+		streams = append(streams, middleware.PolicyRateLimitStream(stack.quotas, stack.policies, logger))
+	}
+	unary = append(unary, middleware.EndpointLimitsUnary(stack.endpoints))
+
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	opts := append(cfg.GRPC.ServerOptions(stack.endpoints.MaxBodyBytes()),
+		creds.ServerOption(),
+		tracing.ServerOption(),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	)
+	grpcServer := grpc.NewServer(opts...)
 
 	// Create gRPC handler
 	productServer := grpcHandler.New(productService, logger)
 
 	// Register gRPC services
-	product.RegisterProductServiceServer(grpcServer, productServer)
+	productv1.RegisterProductServiceServer(grpcServer, productServer)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	// Enable reflection for development tools
 	if cfg.Env != "production" {
@@ -178,7 +835,7 @@ func setupGRPCServer(cfg *config.Config, productService *service.ProductService,
 	return grpcServer
 }
 
-func handleGracefulShutdown(httpServer *http.Server, grpcServer *grpc.Server, logger *slog.Logger) {
+func handleGracefulShutdown(httpServer *http.Server, grpcServer *grpc.Server, healthServer *health.Server, jobs *workers.Group, logger *slog.Logger) {
 	// Wait for interrupt signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -186,6 +843,10 @@ func handleGracefulShutdown(httpServer *http.Server, grpcServer *grpc.Server, lo
 
 	logger.Info("Received shutdown signal", "signal", sig)
 
+	// Report NOT_SERVING first so health-checking clients move away
+	// before connections are drained
+	healthServer.Shutdown()
+
 	// Create a deadline context for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -200,5 +861,11 @@ func handleGracefulShutdown(httpServer *http.Server, grpcServer *grpc.Server, lo
 	logger.Info("Shutting down gRPC server")
 	grpcServer.GracefulStop()
 
+	// Stop the background workers, letting runs in flight finish
+	logger.Info("Stopping background workers")
+	if err := jobs.Stop(ctx); err != nil {
+		logger.Error("Background workers did not stop in time", "error", err)
+	}
+
 	logger.Info("Shutdown completed")
 }
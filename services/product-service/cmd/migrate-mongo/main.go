@@ -0,0 +1,111 @@
+// Command migrate-mongo brings the product documents in MongoDB up to the
+// schema version the service writes. It reads the same MONGODB_*
+// environment variables as the service.
+//
+//	migrate-mongo -status       # show the progress of every migration
+//	migrate-mongo -dry-run      # log what would change, write nothing
+//	migrate-mongo               # apply every pending migration
+//	migrate-mongo -to 3         # apply the pending migrations up to version 3
+//
+// Interrupted runs resume where they stopped when run again.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/repository/mongodb/migrations"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+func main() {
+	status := flag.Bool("status", false, "print the progress of every migration and exit")
+	dryRun := flag.Bool("dry-run", false, "log the updates without writing them")
+	target := flag.Int("to", migrations.Latest(), "schema version to migrate to")
+	batchSize := flag.Int("batch-size", 500, "documents read and written at once")
+	samples := flag.Int("samples", 5, "updates logged per migration in a dry run")
+	flag.Parse()
+
+	cfg := config.Load()
+	logger := logging.New(os.Stderr, logging.Options{
+		Service: "migrate-mongo",
+		Version: version,
+		Level:   cfg.Logging.Level,
+		Text:    !cfg.Logging.JSON,
+	})
+
+	// Stop on interrupt; the next run resumes after the last batch written
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := connect(ctx, cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect(context.Background())
+
+	collection := client.Database(cfg.MongoDB.Database).Collection(cfg.MongoDB.Collection)
+	runner := migrations.NewRunner(collection, migrations.All, migrations.Options{
+		BatchSize: *batchSize,
+		DryRun:    *dryRun,
+		Samples:   *samples,
+	}, logger)
+
+	if *status {
+		if err := printStatus(ctx, runner); err != nil {
+			logger.Error("Failed to read migration status", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := runner.Up(ctx, *target); err != nil {
+		logger.Error("Migration failed", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Migrations complete", "collection", collection.Name(), "version", *target, "dryRun", *dryRun)
+}
+
+func connect(ctx context.Context, cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.ConnectionString()))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func printStatus(ctx context.Context, runner *migrations.Runner) error {
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tMIGRATED\tFINISHED\tDESCRIPTION")
+	for _, s := range statuses {
+		finished := "-"
+		if !s.FinishedAt.IsZero() {
+			finished = s.FinishedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", s.Version, s.State, s.Migrated, finished, s.Description)
+	}
+	return w.Flush()
+}
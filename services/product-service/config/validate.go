@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// loadProblems collects the malformed values the getEnv helpers see
+// during Load
+var loadProblems []string
+
+// malformed records that key holds value, which is not what
+func malformed(key, value, what string) {
+	loadProblems = append(loadProblems, fmt.Sprintf("%s=%q is not %s", key, value, what))
+}
+
+// ValidationError lists every invalid or missing setting
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration and returns a *ValidationError
+// listing all invalid or missing settings, so they can be fixed in one go
+func (c *Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(validPort(c.HTTPPort), "HTTP_PORT=%d must be a port between 1 and 65535", c.HTTPPort)
+	check(validPort(c.GRPCPort), "GRPC_PORT=%d must be a port between 1 and 65535", c.GRPCPort)
+	check(c.HTTPPort != c.GRPCPort, "HTTP_PORT and GRPC_PORT must differ, both are %d", c.HTTPPort)
+
+	check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT must be positive")
+	check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT must be positive")
+	check(c.Server.IdleTimeout > 0, "SERVER_IDLE_TIMEOUT must be positive")
+	check(c.Server.RequestTimeout > 0, "SERVER_REQUEST_TIMEOUT must be positive")
+	check(c.Server.MaxBodyBytes > 0, "SERVER_MAX_BODY_BYTES must be positive")
+	check(c.Server.RateLimitRPS >= 0, "RATE_LIMIT_RPS must not be negative; use 0 to disable rate limiting")
+	check(c.Server.RateLimitBurst >= 0, "RATE_LIMIT_BURST must not be negative")
+	if c.Server.RateLimitPoliciesFile != "" {
+		check(c.Server.RateLimitRedisAddr != "", "RATE_LIMIT_POLICIES_FILE requires RATE_LIMIT_REDIS_ADDR")
+		check(fileExists(c.Server.RateLimitPoliciesFile), "RATE_LIMIT_POLICIES_FILE=%q does not exist", c.Server.RateLimitPoliciesFile)
+	}
+	if c.Server.EndpointPoliciesFile != "" {
+		if _, err := c.Server.EndpointPolicies(); err != nil {
+			problems = append(problems, "ENDPOINT_POLICIES_FILE is invalid: "+err.Error())
+		}
+	}
+	if c.Server.PanicAlertURL != "" {
+		check(validURL(c.Server.PanicAlertURL), "PANIC_ALERT_WEBHOOK_URL=%q must be an http or https URL", c.Server.PanicAlertURL)
+	}
+	check(c.Server.LoadShedMaxConcurrency >= 0, "LOAD_SHED_MAX_CONCURRENCY must not be negative; use 0 to disable load shedding")
+	if c.Server.LoadShedMaxConcurrency > 0 {
+		check(c.Server.LoadShedLatencyTarget > 0, "LOAD_SHED_LATENCY_TARGET must be positive")
+		check(c.Server.LoadShedQueueSize >= 0, "LOAD_SHED_QUEUE_SIZE must not be negative")
+		check(c.Server.LoadShedQueueTimeout >= 0, "LOAD_SHED_QUEUE_TIMEOUT must not be negative")
+	}
+
+	check(strings.HasPrefix(c.MongoDB.URI, "mongodb://") || strings.HasPrefix(c.MongoDB.URI, "mongodb+srv://"),
+		"MONGODB_URI=%q must start with mongodb:// or mongodb+srv://", redactURI(c.MongoDB.URI))
+	check(c.MongoDB.Database != "", "MONGODB_DATABASE is required")
+	check(c.MongoDB.Collection != "", "MONGODB_COLLECTION is required")
+	check(c.MongoDB.MaxPoolSize > 0, "MONGODB_MAX_POOL_SIZE must be positive")
+	check(c.MongoDB.ConnTimeout > 0, "MONGODB_CONN_TIMEOUT must be positive")
+	check(c.MongoDB.WriteTimeout > 0, "MONGODB_WRITE_TIMEOUT must be positive")
+	check(c.MongoDB.ReadTimeout > 0, "MONGODB_READ_TIMEOUT must be positive")
+	check(validTextWeight(c.MongoDB.TextWeights.Name), "MONGODB_TEXT_WEIGHT_NAME must be between 1 and 99999")
+	check(validTextWeight(c.MongoDB.TextWeights.Tags), "MONGODB_TEXT_WEIGHT_TAGS must be between 1 and 99999")
+	check(validTextWeight(c.MongoDB.TextWeights.Description), "MONGODB_TEXT_WEIGHT_DESCRIPTION must be between 1 and 99999")
+	for _, key := range c.MongoDB.AttributeIndexes {
+		check(!strings.Contains(key, ".") && !strings.HasPrefix(key, "$"), "MONGODB_ATTRIBUTE_INDEXES has invalid attribute name %q", key)
+	}
+
+	check(validLogLevel(c.Logging.Level), "LOG_LEVEL=%q must be debug, info, warn or error", c.Logging.Level)
+	check(c.Logging.AccessSampleRate >= 0 && c.Logging.AccessSampleRate <= 1, "ACCESS_LOG_SAMPLE_RATE=%g must be between 0 and 1", c.Logging.AccessSampleRate)
+	check(c.Logging.AccessSlowThreshold >= 0, "ACCESS_LOG_SLOW_THRESHOLD must not be negative; use 0 to disable")
+	if c.Metrics.Enabled {
+		check(strings.HasPrefix(c.Metrics.Path, "/"), "METRICS_PATH=%q must start with /", c.Metrics.Path)
+	}
+	if c.Tracing.Enabled {
+		check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required when TRACING_ENABLED is true")
+		check(validURL(c.Tracing.Endpoint), "TRACING_ENDPOINT=%q must be an http or https URL", c.Tracing.Endpoint)
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO=%g must be between 0 and 1", c.Tracing.SampleRatio)
+	}
+	if c.Events.Enabled {
+		check(c.Events.RedisAddr != "", "EVENTS_REDIS_ADDR is required when EVENTS_ENABLED is true")
+		check(c.Events.MaxLen > 0, "EVENTS_STREAM_MAX_LEN must be positive")
+	}
+	if c.Events.ConsumeOrders {
+		check(c.Events.Enabled, "ORDER_EVENTS_ENABLED requires EVENTS_ENABLED")
+		check(c.Events.ConsumerGroup != "", "EVENTS_CONSUMER_GROUP is required when ORDER_EVENTS_ENABLED is true")
+	}
+	check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be positive")
+	if c.Inventory.Addr != "" {
+		check(c.Inventory.Timeout > 0, "INVENTORY_SERVICE_TIMEOUT must be positive")
+	}
+	if c.FX.Addr != "" {
+		check(c.FX.Timeout > 0, "FX_SERVICE_TIMEOUT must be positive")
+	}
+	if c.Popularity.Enabled {
+		check(c.Popularity.FlushInterval > 0, "POPULARITY_FLUSH_INTERVAL must be positive")
+	}
+	check(c.Merchandising.NewArrivalWindow > 0, "NEW_ARRIVAL_WINDOW must be positive")
+	check(c.Merchandising.FeatureExpiryInterval > 0, "FEATURE_EXPIRY_INTERVAL must be positive")
+	check(c.Merchandising.PreorderReleaseInterval > 0, "PREORDER_RELEASE_INTERVAL must be positive")
+	check(c.Merchandising.BadgeRefreshInterval > 0, "BADGE_REFRESH_INTERVAL must be positive")
+	check(c.Merchandising.PromotionRefreshInterval > 0, "PROMOTION_REFRESH_INTERVAL must be positive")
+	check(c.Suggest.MaxLimit > 0 && c.Suggest.MaxLimit <= 100, "SUGGEST_MAX_LIMIT must be between 1 and 100")
+	check(c.Suggest.DefaultLimit > 0 && c.Suggest.DefaultLimit <= c.Suggest.MaxLimit, "SUGGEST_DEFAULT_LIMIT must be between 1 and SUGGEST_MAX_LIMIT")
+	check(c.Suggest.Budget > 0, "SUGGEST_BUDGET must be positive")
+	check(c.Tags.CacheTTL > 0, "TAGS_CACHE_TTL must be positive")
+	if c.Caches.InvalidationRedisAddr != "" {
+		check(c.Caches.InvalidationChannel != "", "CACHE_INVALIDATION_CHANNEL must not be empty when CACHE_INVALIDATION_REDIS_ADDR is set")
+	}
+	if c.Reporting.SnapshotsEnabled {
+		check(c.Reporting.SnapshotInterval > 0 && c.Reporting.SnapshotInterval <= 24*time.Hour, "INVENTORY_SNAPSHOT_INTERVAL must be positive and at most 24h")
+	}
+	check(c.Locks.Collection != "", "LOCKS_COLLECTION must not be empty")
+	check(c.Locks.TTL >= time.Second, "LOCK_TTL must be at least 1s")
+	if c.Downloads.BucketURL != "" {
+		check(validURL(c.Downloads.BucketURL), "DOWNLOADS_BUCKET_URL=%q must be an http or https URL", c.Downloads.BucketURL)
+		check(c.Downloads.AccessKeyID != "" && c.Downloads.SecretAccessKey != "", "DOWNLOADS_ACCESS_KEY_ID and DOWNLOADS_SECRET_ACCESS_KEY are required with DOWNLOADS_BUCKET_URL")
+		check(c.Downloads.Region != "", "DOWNLOADS_REGION must not be empty")
+		check(c.Downloads.URLTTL >= time.Second && c.Downloads.URLTTL <= 7*24*time.Hour, "DOWNLOAD_URL_TTL must be between 1s and 168h")
+		check(validURL(c.Downloads.OrderServiceURL), "ORDER_SERVICE_URL=%q must be an http or https URL; it is required with DOWNLOADS_BUCKET_URL", c.Downloads.OrderServiceURL)
+		check(c.Downloads.OrderServiceTimeout > 0, "ORDER_SERVICE_TIMEOUT must be positive")
+	}
+	if c.Images.BucketURL != "" {
+		check(validURL(c.Images.BucketURL), "IMAGES_BUCKET_URL=%q must be an http or https URL", c.Images.BucketURL)
+		check(c.Images.AccessKeyID != "" && c.Images.SecretAccessKey != "", "IMAGES_ACCESS_KEY_ID and IMAGES_SECRET_ACCESS_KEY are required with IMAGES_BUCKET_URL")
+		check(c.Images.Region != "", "IMAGES_REGION must not be empty")
+		check(c.Images.PublicURL == "" || validURL(c.Images.PublicURL), "IMAGES_PUBLIC_URL=%q must be an http or https URL", c.Images.PublicURL)
+		check(c.Images.MaxBytes > 0, "IMAGES_MAX_BYTES must be positive")
+	}
+	check(c.Images.CDNHost == "" || validURL("https://"+c.Images.CDNHost) && !strings.ContainsAny(c.Images.CDNHost, "/?#@"), "IMAGES_CDN_HOST=%q must be a host name such as cdn.example.com", c.Images.CDNHost)
+	check(len(c.Images.OriginHosts) == 0 || c.Images.CDNHost != "", "IMAGES_ORIGIN_HOSTS requires IMAGES_CDN_HOST")
+	if c.Feeds.ProductURL != "" {
+		check(validURL(c.Feeds.ProductURL) && (strings.Contains(c.Feeds.ProductURL, "{slug}") || strings.Contains(c.Feeds.ProductURL, "{id}")), "FEEDS_PRODUCT_URL=%q must be an http or https URL containing {slug} or {id}", c.Feeds.ProductURL)
+		check(c.Feeds.StoreURL == "" || validURL(c.Feeds.StoreURL), "FEEDS_STORE_URL=%q must be an http or https URL", c.Feeds.StoreURL)
+		check(c.Feeds.Title != "", "FEEDS_TITLE must not be empty")
+	}
+	check(len(c.FX.PriceCurrency) == 3, "PRICE_CURRENCY=%q must be an ISO 4217 code such as USD", c.FX.PriceCurrency)
+
+	if err := c.GRPC.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := c.Migrations.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	if err := c.HTTPTLS.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := c.Listen.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, file := range []struct{ key, path string }{
+		{"TLS_CERT_FILE", c.TLS.CertFile},
+		{"TLS_KEY_FILE", c.TLS.KeyFile},
+		{"TLS_CA_FILE", c.TLS.CAFile},
+		{"HTTP_TLS_CERT_FILE", c.HTTPTLS.CertFile},
+		{"HTTP_TLS_KEY_FILE", c.HTTPTLS.KeyFile},
+	} {
+		if file.path != "" {
+			check(fileExists(file.path), "%s=%q does not exist", file.key, file.path)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port < 65536
+}
+
+func validTextWeight(weight int) bool {
+	return weight >= 1 && weight <= 99999
+}
+
+func validLogLevel(level string) bool {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "warning", "error":
+		return true
+	}
+	return false
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// redactURI hides the password of a connection URI
+func redactURI(uri string) string {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return uri
+	}
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		if user, _, hasPassword := strings.Cut(rest[:at], ":"); hasPassword {
+			return scheme + "://" + user + ":***" + rest[at:]
+		}
+	}
+	return uri
+}
@@ -0,0 +1,435 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	grpcapi "github.com/bekbull/online-shop/services/product-service/internal/api/grpc"
+	"github.com/bekbull/online-shop/services/product-service/internal/api/rest"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/service"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/status"
+)
+
+// The fuzz targets send hostile JSON to the REST handlers and check that
+// they never panic or fail with a server error, and that the gRPC API
+// accepts or rejects the same request the same way. Run one with e.g.
+//
+//	go test -run '^$' -fuzz FuzzCreateProduct ./services/product-service/internal/api
+
+// maxBodyBytes bounds request bodies like the default endpoint policy
+const maxBodyBytes = 64 << 10
+
+var seedID = primitive.NewObjectID()
+
+func FuzzCreateProduct(f *testing.F) {
+	f.Add([]byte(`{"name":"Mug","price":8.5,"category":"kitchen","inventory":{"quantity":3,"sku":"MUG-1"},"tags":["a"],"attributes":{"color":"red"}}`))
+	f.Add([]byte(`{"name":"Mug","price":8.5,"category":"kitchen"}`))
+	f.Add([]byte(`{"name":"Mug","price":-1,"category":"kitchen","inventory":{"quantity":3,"sku":"MUG-1"}}`))
+	f.Add([]byte(`{"name":"Mug","price":1e308,"category":"k","inventory":{"quantity":4294967296,"sku":"S"}}`))
+	f.Add([]byte(`{"name":"Mug","price":1,"category":"k","inventory":{"quantity":-1,"reserved":5,"sku":"S"}}`))
+	f.Add([]byte(`{"name":"\u0000","price":1,"category":"k","inventory":{"sku":"S"},"suppliers":[{"supplier_id":"x"}]}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`{"name":`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		status, _ := serveREST(t, http.MethodPost, "/v1/products", body)
+		if status >= 500 {
+			t.Fatalf("POST /v1/products answered %d for %q", status, body)
+		}
+
+		var req struct {
+			Name        string            `json:"name"`
+			Description string            `json:"description"`
+			Price       float64           `json:"price"`
+			ImageURLs   []string          `json:"image_urls"`
+			Category    string            `json:"category"`
+			Inventory   json.RawMessage   `json:"inventory"`
+			Tags        []string          `json:"tags"`
+			Attributes  map[string]string `json:"attributes"`
+			Suppliers   json.RawMessage   `json:"suppliers"`
+		}
+		var inventory domain.InventoryInfo
+		if json.Unmarshal(body, &req) != nil || req.Inventory != nil && json.Unmarshal(req.Inventory, &inventory) != nil {
+			return
+		}
+		if len(req.Suppliers) > 0 && string(req.Suppliers) != "null" {
+			// Supplier links need the supplier repository
+			return
+		}
+		if inventory.Quantity > math.MaxInt32 || inventory.Quantity < math.MinInt32 ||
+			inventory.Reserved > math.MaxInt32 || inventory.Reserved < math.MinInt32 {
+			// Not representable in the gRPC API; REST must reject it
+			if status != http.StatusBadRequest {
+				t.Fatalf("REST answered %d for out of range inventory %q", status, body)
+			}
+			return
+		}
+
+		_, err := newGRPC().CreateProduct(context.Background(), &pb.CreateProductRequest{
+			Name:        req.Name,
+			Description: req.Description,
+			Price:       req.Price,
+			ImageUrls:   req.ImageURLs,
+			Category:    req.Category,
+			Inventory: &pb.InventoryInfo{
+				Quantity: int32(inventory.Quantity),
+				Sku:      inventory.SKU,
+				Reserved: int32(inventory.Reserved),
+			},
+			Tags:       req.Tags,
+			Attributes: req.Attributes,
+		})
+		sameOutcome(t, body, status, http.StatusCreated, err)
+	})
+}
+
+func FuzzUpdateProduct(f *testing.F) {
+	f.Add([]byte(`{"name":"Cup","price":9,"active":false}`))
+	f.Add([]byte(`{"inventory":{"sku":"NEW","quantity":-5}}`))
+	f.Add([]byte(`{"price":-3,"tags":[],"attributes":null}`))
+	f.Add([]byte(`{"active":"yes"}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		status, resp := serveREST(t, http.MethodPut, "/v1/products/"+seedID.Hex(), body)
+		if status >= 500 {
+			t.Fatalf("PUT /v1/products/{id} answered %d for %q", status, body)
+		}
+		if status == http.StatusOK && !json.Valid(resp) {
+			t.Fatalf("PUT /v1/products/{id} answered invalid JSON %q", resp)
+		}
+	})
+}
+
+func FuzzUpdateInventory(f *testing.F) {
+	f.Add([]byte(`{"quantity_change":-2,"operation_id":"op-1","operation_type":"purchase"}`))
+	f.Add([]byte(`{"quantity_change":-20,"operation_type":"purchase"}`))
+	f.Add([]byte(`{"quantity_change":5,"operation_type":"restock"}`))
+	f.Add([]byte(`{"quantity_change":9223372036854775807,"operation_type":"restock"}`))
+	f.Add([]byte(`{"quantity_change":1.5,"operation_type":"adjustment"}`))
+	f.Add([]byte(`{"quantity_change":1,"operation_type":"steal"}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		status, _ := serveREST(t, http.MethodPost, "/v1/products/"+seedID.Hex()+"/inventory", body)
+		if status >= 500 {
+			t.Fatalf("POST /v1/products/{id}/inventory answered %d for %q", status, body)
+		}
+
+		var req struct {
+			QuantityChange json.Number `json:"quantity_change"`
+			OperationID    string      `json:"operation_id"`
+			OperationType  string      `json:"operation_type"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if decoder.Decode(&req) != nil {
+			return
+		}
+		change := int64(0)
+		if req.QuantityChange != "" {
+			var err error
+			if change, err = req.QuantityChange.Int64(); err != nil || change != int64(int32(change)) {
+				if status != http.StatusBadRequest {
+					t.Fatalf("REST answered %d for quantity change %s", status, req.QuantityChange)
+				}
+				return
+			}
+		}
+
+		_, err := newGRPC().UpdateInventory(context.Background(), &pb.UpdateInventoryRequest{
+			ProductId:      seedID.Hex(),
+			QuantityChange: int32(change),
+			OperationId:    req.OperationID,
+			OperationType:  req.OperationType,
+		})
+		sameOutcome(t, body, status, http.StatusOK, err)
+	})
+}
+
+// sameOutcome fails unless REST and gRPC both succeeded, or both failed
+// with the same kind of error
+func sameOutcome(t *testing.T, body []byte, restStatus, restOK int, grpcErr error) {
+	t.Helper()
+	if grpcErr == nil {
+		if restStatus != restOK {
+			t.Fatalf("gRPC accepted what REST answered %d to: %q", restStatus, body)
+		}
+		return
+	}
+	if _, ok := status.FromError(grpcErr); !ok {
+		t.Fatalf("gRPC returned a non-status error %v for %q", grpcErr, body)
+	}
+	if want := apperrors.HTTPStatus(apperrors.FromGRPC(grpcErr)); restStatus != want {
+		t.Fatalf("REST answered %d but gRPC %v (%d) for %q", restStatus, grpcErr, want, body)
+	}
+}
+
+// serveREST sends body to a product API backed by a fresh in-memory
+// catalog holding one product, and returns the status and response body
+func serveREST(t *testing.T, method, path string, body []byte) (int, []byte) {
+	t.Helper()
+	router := chi.NewRouter()
+	router.Use(middleware.BodyLimit(maxBodyBytes))
+	rest.NewProductHandler(newService(), discard).RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+func newGRPC() *grpcapi.ProductServer {
+	return grpcapi.New(newService(), discard)
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newService() *service.ProductService {
+	repo := &memoryRepo{products: map[string]*domain.Product{
+		seedID.Hex(): {
+			ID:        seedID,
+			Name:      "Seed",
+			Price:     10,
+			Category:  "seed",
+			Inventory: domain.InventoryInfo{Quantity: 10, SKU: "SEED-1", InStock: true},
+			Active:    true,
+		},
+	}}
+	return service.New(repo, discard)
+}
+
+// memoryRepo is a minimal in-memory domain.ProductRepository
+type memoryRepo struct {
+	mu       sync.Mutex
+	products map[string]*domain.Product
+}
+
+func (r *memoryRepo) Create(_ context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *product
+	r.products[product.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetByID(_ context.Context, id string) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[id]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *memoryRepo) GetByIDs(_ context.Context, ids []string) ([]*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := []*domain.Product{}
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			copied := *product
+			products = append(products, &copied)
+		}
+	}
+	return products, nil
+}
+
+func (r *memoryRepo) GetByBarcode(_ context.Context, barcode string) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, product := range r.products {
+		if product.Barcode == barcode {
+			copied := *product
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+func (r *memoryRepo) GetBySlug(_ context.Context, slug string) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, product := range r.products {
+		for _, s := range product.AllSlugs() {
+			if s == slug {
+				copied := *product
+				return &copied, nil
+			}
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+func (r *memoryRepo) Update(_ context.Context, product *domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[product.ID.Hex()]; !ok {
+		return domain.ErrProductNotFound
+	}
+	copied := *product
+	r.products[product.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[id]; !ok {
+		return domain.ErrProductNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *memoryRepo) List(_ context.Context, _ domain.ListProductsParams) ([]*domain.Product, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []*domain.Product
+	for _, product := range r.products {
+		copied := *product
+		products = append(products, &copied)
+	}
+	return products, len(products), nil
+}
+
+func (r *memoryRepo) UpdateInventory(_ context.Context, productID string, quantityChange int, _, _ string) (*domain.InventoryInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	product.Inventory.Quantity += quantityChange
+	product.Inventory.InStock = product.Inventory.Quantity > 0
+	inventory := product.Inventory
+	return &inventory, nil
+}
+
+func (r *memoryRepo) WatchInventory(context.Context, []string, func(*domain.InventoryChange) error) error {
+	return nil
+}
+
+func (r *memoryRepo) HasOperation(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryRepo) ImageInUse(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryRepo) ListOperations(context.Context, domain.InventoryOperationFilter, pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	return []*domain.InventoryOperation{}, 0, nil
+}
+
+func (r *memoryRepo) Suggest(_ context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suggestions := []domain.Suggestion{}
+	for _, product := range r.products {
+		if len(suggestions) < limit && product.Active && strings.HasPrefix(domain.NormalizeSearchName(product.Name), prefix) {
+			suggestions = append(suggestions, domain.Suggestion{Type: domain.SuggestionProduct, Text: product.Name, ProductID: product.ID.Hex()})
+		}
+	}
+	return suggestions, nil
+}
+
+func (r *memoryRepo) ListTags(context.Context) ([]domain.TagCount, error) {
+	return []domain.TagCount{}, nil
+}
+
+func (r *memoryRepo) RelatedProducts(context.Context, *domain.Product, int) ([]*domain.Product, error) {
+	return []*domain.Product{}, nil
+}
+
+func (r *memoryRepo) CheckStock(_ context.Context, productID string, quantity int) (bool, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return false, 0, domain.ErrProductNotFound
+	}
+	if err := product.CheckQuantity(quantity); err != nil {
+		return false, product.Inventory.Quantity, err
+	}
+	return product.Inventory.Quantity >= quantity, product.Inventory.Quantity, nil
+}
+
+func (r *memoryRepo) Stream(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error {
+	products, _, _ := r.List(ctx, domain.ListProductsParams{})
+	for _, product := range products {
+		if product.Active || includeInactive {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) Export(ctx context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error {
+	products, _, _ := r.List(ctx, params)
+	for _, product := range products {
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepo) SetFeatured(_ context.Context, id string, featured bool, until *time.Time) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[id]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	product.Featured, product.FeaturedUntil = featured, until
+	copied := *product
+	return &copied, nil
+}
+
+func (r *memoryRepo) UnfeatureExpired(_ context.Context, _ time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *memoryRepo) UpdatePreorders(_ context.Context, productID string, quantityChange int, _, _ string) (*domain.PreorderInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	if !product.OnPreorder() {
+		return nil, domain.ErrNotOnPreorder
+	}
+	if product.Preorder.Ordered-quantityChange > product.Preorder.Allocation {
+		return nil, domain.ErrPreorderSoldOut
+	}
+	product.Preorder.Ordered -= quantityChange
+	copied := *product.Preorder
+	return &copied, nil
+}
+
+func (r *memoryRepo) ReleaseDue(_ context.Context, _ time.Time) (*domain.PreorderRelease, error) {
+	return nil, nil
+}
@@ -0,0 +1,97 @@
+// Package gateway serves the gRPC ProductService as the /v2 REST API.
+//
+// The routes are generated by grpc-gateway from the HTTP bindings in
+// proto/product/v1/product_gateway.yaml, and each request calls the gRPC
+// handler in process. REST and gRPC therefore share one implementation and
+// one set of messages: a field added to the proto is served on both, and an
+// update only changes the fields present in its body, as over gRPC.
+//
+// The handler is mounted on the HTTP router, so requests go through the
+// HTTP middleware (authentication, rate limits, endpoint limits and
+// idempotency) rather than the gRPC interceptors. Errors are written as
+// problem JSON, like the /v1 handlers.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/go-chi/chi/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// forwardedHeaders are the HTTP headers passed to the gRPC handler as
+// metadata of the same name, besides those grpc-gateway forwards itself
+var forwardedHeaders = map[string]bool{
+	"x-customer-group": true,
+}
+
+// Handler serves the /v2 REST API
+type Handler struct {
+	mux *runtime.ServeMux
+}
+
+// New returns a handler calling server for each request
+func New(server pb.ProductServiceServer) (*Handler, error) {
+	mux := runtime.NewServeMux(
+		// Field names as in the proto, like the /v1 JSON; unset fields are
+		// written so that clients see every field of a message
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(matchHeader),
+		runtime.WithErrorHandler(writeError),
+		runtime.WithRoutingErrorHandler(writeRoutingError),
+	)
+	if err := pb.RegisterProductServiceHandlerServer(context.Background(), mux, server); err != nil {
+		return nil, err
+	}
+	return &Handler{mux: mux}, nil
+}
+
+// RegisterRoutes mounts the API under /v2
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Mount("/v2", h.mux)
+}
+
+// ServeHTTP serves a request of the API
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// matchHeader forwards the customer group set by the API gateway, and the
+// headers grpc-gateway forwards by default
+func matchHeader(key string) (string, bool) {
+	if name := strings.ToLower(key); forwardedHeaders[name] {
+		return name, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// writeError writes the gRPC status of err, or of a request the gateway
+// could not decode, as a problem JSON response
+func writeError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	err = apperrors.FromGRPC(err)
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		// The status message alone, not the status it came from
+		appErr.Err = nil
+	}
+	apperrors.WriteHTTP(w, r, err)
+}
+
+// writeRoutingError answers requests for no route as not found, and those
+// with an unbound method as chi does for the /v1 routes
+func writeRoutingError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	if httpStatus == http.StatusNotFound {
+		apperrors.WriteHTTP(w, r, apperrors.Newf(apperrors.NotFound, "no route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	w.WriteHeader(httpStatus)
+}
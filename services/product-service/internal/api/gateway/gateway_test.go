@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// recordingServer records the requests it is called with
+type recordingServer struct {
+	pb.UnimplementedProductServiceServer
+	update *pb.UpdateProductRequest
+	group  []string
+}
+
+func (s *recordingServer) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.ProductResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.group = md.Get("x-customer-group")
+	if req.Id == "missing" {
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+	return &pb.ProductResponse{Product: &pb.Product{Id: req.Id, Name: "Kettle"}}, nil
+}
+
+func (s *recordingServer) UpdateProduct(_ context.Context, req *pb.UpdateProductRequest) (*pb.ProductResponse, error) {
+	s.update = req
+	return &pb.ProductResponse{Product: &pb.Product{Id: req.Id}}, nil
+}
+
+func serve(t *testing.T, server pb.ProductServiceServer, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	handler, err := New(server)
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	handler.RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetProduct(t *testing.T) {
+	server := &recordingServer{}
+	req := httptest.NewRequest(http.MethodGet, "/v2/products/p1", nil)
+	req.Header.Set("X-Customer-Group", "wholesale")
+	rec := serve(t, server, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "p1", body["product"]["id"])
+	assert.Contains(t, body["product"], "image_urls", "fields have their proto names and are written when unset")
+	assert.Equal(t, []string{"wholesale"}, server.group, "the customer group is passed on as metadata")
+}
+
+func TestUpdateProductChangesOnlyTheFieldsSent(t *testing.T) {
+	server := &recordingServer{}
+	req := httptest.NewRequest(http.MethodPatch, "/v2/products/p1", strings.NewReader(`{"price": 0}`))
+	rec := serve(t, server, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, server.update)
+	assert.Equal(t, "p1", server.update.Id)
+	require.NotNil(t, server.update.Price, "a zero price that was sent is set")
+	assert.Zero(t, *server.update.Price)
+	assert.Nil(t, server.update.Name, "fields that were not sent are left unset")
+}
+
+func TestErrorsAreProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"handler error", http.MethodGet, "/v2/products/missing", http.StatusNotFound},
+		{"no route", http.MethodGet, "/v2/suppliers", http.StatusNotFound},
+		{"unimplemented", http.MethodDelete, "/v2/products/p1", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, &recordingServer{}, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		})
+	}
+
+	rec := serve(t, &recordingServer{}, httptest.NewRequest(http.MethodGet, "/v2/products/missing", nil))
+	var problem map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "product not found", problem["detail"])
+}
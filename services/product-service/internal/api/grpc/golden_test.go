@@ -0,0 +1,265 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/pkg/testutil/golden"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+	grpcapi "github.com/bekbull/online-shop/services/product-service/internal/api/grpc"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestGoldenMessages compares the proto messages converted from domain
+// products with their golden files in testdata/golden. Run with -update to
+// rewrite them.
+func TestGoldenMessages(t *testing.T) {
+	ctx := context.Background()
+	server := grpcapi.New(stubProducts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name string
+		call func() (proto.Message, error)
+	}{
+		{"get_product", func() (proto.Message, error) {
+			return server.GetProduct(ctx, &pb.GetProductRequest{Id: productID.Hex()})
+		}},
+		{"get_product_for_group", func() (proto.Message, error) {
+			ctx := metadata.NewIncomingContext(ctx, metadata.Pairs("x-customer-group", "Wholesale"))
+			return server.GetProduct(ctx, &pb.GetProductRequest{Id: productID.Hex()})
+		}},
+		{"get_products_by_ids", func() (proto.Message, error) {
+			return server.GetProductsByIDs(ctx, &pb.GetProductsByIDsRequest{Ids: []string{"65f1c0d2e4b0a1b2c3d4e5ff", productID.Hex()}})
+		}},
+		{"list_products", func() (proto.Message, error) {
+			return server.ListProducts(ctx, &pb.ListProductsRequest{Page: 1, PageSize: 2})
+		}},
+		{"update_inventory", func() (proto.Message, error) {
+			return server.UpdateInventory(ctx, &pb.UpdateInventoryRequest{ProductId: productID.Hex(), QuantityChange: -2, OperationId: "op-1", OperationType: "purchase"})
+		}},
+		{"list_inventory_operations", func() (proto.Message, error) {
+			return server.ListInventoryOperations(ctx, &pb.ListInventoryOperationsRequest{ProductId: productID.Hex(), Page: 0, PageSize: 2, OperationTypes: []string{"purchase", "restock"}, From: 1709251200})
+		}},
+		{"check_stock", func() (proto.Message, error) {
+			return server.CheckStock(ctx, &pb.CheckStockRequest{ProductId: productID.Hex(), Quantity: 2})
+		}},
+		{"set_featured", func() (proto.Message, error) {
+			return server.SetFeatured(ctx, &pb.SetFeaturedRequest{Id: productID.Hex(), Featured: true, FeaturedUntil: 1710849600})
+		}},
+		{"validate_purchase_eligibility", func() (proto.Message, error) {
+			return server.ValidatePurchaseEligibility(ctx, &pb.ValidatePurchaseEligibilityRequest{
+				ProductIds:      []string{productID.Hex()},
+				CustomerProfile: &pb.CustomerProfile{BirthDate: "2000-02-29", Region: "US-UT"},
+			})
+		}},
+		{"watch_inventory", func() (proto.Message, error) {
+			stream := &inventoryStream{ctx: ctx}
+			err := server.WatchInventory(&pb.WatchInventoryRequest{ProductIds: []string{productID.Hex()}, Threshold: 5}, stream)
+			if len(stream.sent) != 1 {
+				return nil, fmt.Errorf("sent %d updates, want 1", len(stream.sent))
+			}
+			return stream.sent[0], err
+		}},
+		{"delete_product", func() (proto.Message, error) {
+			return server.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: productID.Hex()})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := tt.call()
+			require.NoError(t, err)
+			golden.AssertProto(t, tt.name, msg)
+		})
+	}
+}
+
+// TestCreateProductDuplicateSKU checks that a taken SKU is reported as
+// AlreadyExists
+func TestCreateProductDuplicateSKU(t *testing.T) {
+	server := grpcapi.New(stubProducts{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	_, err := server.CreateProduct(context.Background(), &pb.CreateProductRequest{
+		Name: "Mug", Price: 8.5, Category: "kitchen", Inventory: &pb.InventoryInfo{Quantity: 3, Sku: takenSKU},
+	})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+	assert.Equal(t, "SKU_EXISTS", apperrors.ReasonOf(apperrors.FromGRPC(err)))
+}
+
+var (
+	productID  = mustObjectID("65f1c0d2e4b0a1b2c3d4e5f1")
+	supplierID = mustObjectID("65f1c0d2e4b0a1b2c3d4e5f2")
+)
+
+func mustObjectID(hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func fixedProduct() *domain.Product {
+	return &domain.Product{
+		ID:          productID,
+		Name:        "Mug",
+		Description: "Stoneware",
+		Price:       8.5,
+		GroupPrices: []domain.GroupPrice{{Group: domain.CustomerGroupWholesale, Price: 6.8}},
+		ImageURLs:   []string{"https://img.example.com/mug.png"},
+		Category:    "kitchen",
+		Inventory:   domain.InventoryInfo{Quantity: 10, SKU: "MUG-1", InStock: true, Reserved: 2},
+		Tags:        []string{"mug", "kitchen"},
+		Attributes:  map[string]string{"material": "stoneware", "color": "red"},
+		Suppliers:   []domain.ProductSupplier{{SupplierID: supplierID, SupplierSKU: "S-MUG", LeadTimeDays: 5, Preferred: true}},
+		Active:      true,
+		Status:      domain.StatusPublished,
+		CreatedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC),
+	}
+}
+
+// stubProducts serves a fixed catalog
+type stubProducts struct{}
+
+func (stubProducts) CreateProduct(_ context.Context, product *domain.Product) (*domain.Product, error) {
+	if product.Inventory.SKU == takenSKU {
+		return nil, fmt.Errorf("repository error: %w", domain.ErrDuplicateSKU)
+	}
+	return product, nil
+}
+
+// takenSKU is the SKU of another product
+const takenSKU = "MUG-TAKEN"
+
+func (stubProducts) GetProduct(ctx context.Context, _ string) (*domain.Product, error) {
+	product := fixedProduct()
+	if group := domain.CustomerGroupFrom(ctx); group != "" {
+		product.CustomerGroup, product.EffectivePrice = group, product.PriceFor(group)
+	}
+	return product, nil
+}
+
+func (p stubProducts) GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, []string, error) {
+	var products []*domain.Product
+	var missing []string
+	for _, id := range ids {
+		if id != productID.Hex() {
+			missing = append(missing, id)
+			continue
+		}
+		product, _ := p.GetProduct(ctx, id)
+		products = append(products, product)
+	}
+	return products, missing, nil
+}
+
+func (stubProducts) UpdateProduct(_ context.Context, product *domain.Product) (*domain.Product, error) {
+	return product, nil
+}
+
+func (stubProducts) DeleteProduct(_ context.Context, _ string) error {
+	return nil
+}
+
+func (stubProducts) ListProducts(_ context.Context, _ domain.ListProductsParams) ([]*domain.Product, int, error) {
+	second := fixedProduct()
+	second.ID = mustObjectID("65f1c0d2e4b0a1b2c3d4e5f4")
+	second.Name = "Plate"
+	second.Inventory = domain.InventoryInfo{SKU: "PLATE-1"}
+	second.Suppliers = nil
+	compareAt := 12.0
+	second.CompareAtPrice = &compareAt
+	discounted := 6.8
+	second.DiscountedPrice = &discounted
+	second.Promotion = &domain.AppliedPromotion{ID: "65f1c0d2e4b0a1b2c3d4e5f8", Name: "Spring Sale", Type: domain.PromotionPercentage, Value: 20}
+	second.Badges = []string{"eco"}
+	second.DisplayBadges = []domain.ProductBadge{
+		{Key: domain.BadgeSale, Label: "Sale", Color: "#d32f2f"},
+		{Key: "eco", Label: "Eco", Color: "#2e7d32"},
+	}
+	return []*domain.Product{fixedProduct(), second}, 5, nil
+}
+
+func (stubProducts) UpdateInventory(_ context.Context, _ string, quantityChange int, _, _ string) (*domain.InventoryInfo, error) {
+	inventory := fixedProduct().Inventory
+	inventory.Quantity += quantityChange
+	return &inventory, nil
+}
+
+func (stubProducts) CheckStock(_ context.Context, _ string, quantity int) (bool, int, error) {
+	return quantity <= 10, 10, nil
+}
+
+func (stubProducts) ListInventoryOperations(_ context.Context, _ domain.InventoryOperationFilter, _ pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	return fixedOperations(), 3, nil
+}
+
+// fixedOperations are the latest operations of the fixed product
+func fixedOperations() []*domain.InventoryOperation {
+	return []*domain.InventoryOperation{
+		{ProductID: productID.Hex(), QuantityChange: -2, OperationID: "order:checkout-1:" + productID.Hex() + ":commit", OperationType: "purchase", Timestamp: time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)},
+		{ProductID: productID.Hex(), QuantityChange: 12, OperationID: "po-1", OperationType: "restock", Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}
+}
+
+func (stubProducts) StreamProducts(_ context.Context, _ bool, fn func(*domain.Product) error) error {
+	return fn(fixedProduct())
+}
+
+// WatchInventory reports the fixed product's stock running low
+func (stubProducts) WatchInventory(_ context.Context, _ []string, _ int, fn func(*domain.InventoryChange) error) error {
+	product := fixedProduct()
+	return fn(&domain.InventoryChange{
+		ProductID:   product.ID.Hex(),
+		ProductName: product.Name,
+		Inventory:   domain.InventoryInfo{Quantity: 3, SKU: product.Inventory.SKU, InStock: true, Reserved: 2},
+		ChangedAt:   product.UpdatedAt,
+	})
+}
+
+// inventoryStream records the updates sent on a WatchInventory stream
+type inventoryStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*pb.InventoryUpdate
+}
+
+func (s *inventoryStream) Context() context.Context { return s.ctx }
+
+func (s *inventoryStream) Send(update *pb.InventoryUpdate) error {
+	s.sent = append(s.sent, update)
+	return nil
+}
+
+func (stubProducts) ValidatePurchaseEligibility(_ context.Context, productIDs []string, customer domain.CustomerProfile) ([]domain.Ineligibility, error) {
+	restrictions := domain.Restrictions{MinAge: 21, RestrictedRegions: []string{"US-UT"}}
+	var ineligible []domain.Ineligibility
+	for _, id := range productIDs {
+		if refusal := restrictions.Check(id, customer, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)); refusal != nil {
+			ineligible = append(ineligible, *refusal)
+		}
+	}
+	return ineligible, nil
+}
+
+func (stubProducts) SetFeatured(_ context.Context, _ string, featured bool, until *time.Time) (*domain.Product, error) {
+	product := fixedProduct()
+	product.Featured, product.FeaturedUntil, product.IsNew = featured, until, true
+	return product, nil
+}
@@ -2,16 +2,27 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
-	pb "github.com/bekbull/online-shop/proto/product"
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// customerGroupMetadata carries the customer group of the signed-in user.
+// It must be set by the API gateway, like the X-Customer-Group header.
+const customerGroupMetadata = "x-customer-group"
+
 // ProductServer implements the gRPC ProductService
 type ProductServer struct {
 	pb.UnimplementedProductServiceServer
@@ -21,13 +32,19 @@ type ProductServer struct {
 
 // ProductService represents the business logic interface for product operations
 type ProductService interface {
-	CreateProduct(product *domain.Product) (*domain.Product, error)
-	GetProduct(id string) (*domain.Product, error)
-	UpdateProduct(product *domain.Product) (*domain.Product, error)
-	DeleteProduct(id string) error
-	ListProducts(params domain.ListProductsParams) ([]*domain.Product, int, error)
-	UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error)
-	CheckStock(productID string, quantity int) (bool, int, error)
+	CreateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	GetProduct(ctx context.Context, id string) (*domain.Product, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, []string, error)
+	UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	ListProducts(ctx context.Context, params domain.ListProductsParams) ([]*domain.Product, int, error)
+	UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error)
+	CheckStock(ctx context.Context, productID string, quantity int) (bool, int, error)
+	ListInventoryOperations(ctx context.Context, filter domain.InventoryOperationFilter, page pagination.Request) ([]*domain.InventoryOperation, int, error)
+	StreamProducts(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error
+	WatchInventory(ctx context.Context, productIDs []string, threshold int, fn func(*domain.InventoryChange) error) error
+	SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*domain.Product, error)
+	ValidatePurchaseEligibility(ctx context.Context, productIDs []string, customer domain.CustomerProfile) ([]domain.Ineligibility, error)
 }
 
 // New creates a new ProductServer
@@ -40,30 +57,60 @@ func New(service ProductService, logger *slog.Logger) *ProductServer {
 
 // CreateProduct implements the CreateProduct RPC method
 func (s *ProductServer) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.ProductResponse, error) {
-	s.logger.Info("gRPC CreateProduct called", "name", req.Name)
+	s.log(ctx).Info("gRPC CreateProduct called", "name", req.Name)
+
+	suppliers, err := protoToDomainSuppliers(req.Suppliers)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid supplier: %v", err)
+	}
 
 	// Map protobuf request to domain model
 	product := &domain.Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		ImageURLs:   req.ImageUrls,
-		Category:    req.Category,
+		Name:            req.Name,
+		Description:     req.Description,
+		Slug:            req.Slug,
+		MetaTitle:       req.MetaTitle,
+		MetaDescription: req.MetaDescription,
+		Price:           req.Price,
+		CompareAtPrice:  req.CompareAtPrice,
+		GroupPrices:     protoToDomainGroupPrices(req.GroupPrices),
+		ImageURLs:       req.ImageUrls,
+		Category:        req.Category,
 		Inventory: domain.InventoryInfo{
-			Quantity: int(req.Inventory.Quantity),
-			SKU:      req.Inventory.Sku,
-			InStock:  req.Inventory.InStock,
-			Reserved: int(req.Inventory.Reserved),
+			Quantity: int(req.GetInventory().GetQuantity()),
+			SKU:      req.GetInventory().GetSku(),
+			InStock:  req.GetInventory().GetInStock(),
+			Reserved: int(req.GetInventory().GetReserved()),
+		},
+		Tags:          req.Tags,
+		Badges:        req.Badges,
+		Attributes:    req.Attributes,
+		Suppliers:     suppliers,
+		Type:          req.Type,
+		Digital:       protoToDomainDigital(req.Digital),
+		Weight:        protoToDomainWeight(req.Weight),
+		Dimensions:    protoToDomainDimensions(req.Dimensions),
+		ShippingClass: req.ShippingClass,
+		Barcode:       req.Barcode,
+		PurchaseLimits: domain.PurchaseLimits{
+			MinOrderQuantity: int(req.MinOrderQuantity),
+			MaxPerCustomer:   int(req.MaxPerCustomer),
 		},
-		Tags:       req.Tags,
-		Attributes: req.Attributes,
+		Restrictions: domain.Restrictions{
+			MinAge:            int(req.MinAge),
+			RestrictedRegions: req.RestrictedRegions,
+		},
+		ReleaseDate: unixTime(req.ReleaseDate),
+	}
+	if req.PreorderAllocation != 0 {
+		product.Preorder = &domain.PreorderInfo{Allocation: int(req.PreorderAllocation)}
 	}
 
 	// Call business logic
-	createdProduct, err := s.productService.CreateProduct(product)
+	createdProduct, err := s.productService.CreateProduct(ctx, product)
 	if err != nil {
-		s.logger.Error("Failed to create product", "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to create product: %v", err)
+		s.log(ctx).Error("Failed to create product", "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to create product: %w", err))
 	}
 
 	// Map domain model to protobuf response
@@ -74,13 +121,13 @@ func (s *ProductServer) CreateProduct(ctx context.Context, req *pb.CreateProduct
 
 // GetProduct implements the GetProduct RPC method
 func (s *ProductServer) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.ProductResponse, error) {
-	s.logger.Info("gRPC GetProduct called", "id", req.Id)
+	s.log(ctx).Info("gRPC GetProduct called", "id", req.Id)
 
 	// Call business logic
-	product, err := s.productService.GetProduct(req.Id)
+	product, err := s.productService.GetProduct(customerGroupContext(ctx), req.Id)
 	if err != nil {
-		s.logger.Error("Failed to get product", "id", req.Id, "error", err)
-		return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
+		s.log(ctx).Error("Failed to get product", "id", req.Id, "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get product: %w", err))
 	}
 
 	// Map domain model to protobuf response
@@ -89,14 +136,34 @@ func (s *ProductServer) GetProduct(ctx context.Context, req *pb.GetProductReques
 	}, nil
 }
 
+// GetProductsByIDs implements the GetProductsByIDs RPC method
+func (s *ProductServer) GetProductsByIDs(ctx context.Context, req *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error) {
+	s.log(ctx).Info("gRPC GetProductsByIDs called", "count", len(req.Ids))
+
+	products, missing, err := s.productService.GetProductsByIDs(customerGroupContext(ctx), req.Ids)
+	if err != nil {
+		s.log(ctx).Error("Failed to get products", "count", len(req.Ids), "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get products: %w", err))
+	}
+
+	protoProducts := make([]*pb.Product, len(products))
+	for i, product := range products {
+		protoProducts[i] = domainToProtoProduct(product)
+	}
+	return &pb.GetProductsByIDsResponse{
+		Products:    protoProducts,
+		NotFoundIds: missing,
+	}, nil
+}
+
 // UpdateProduct implements the UpdateProduct RPC method
 func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.ProductResponse, error) {
-	s.logger.Info("gRPC UpdateProduct called", "id", req.Id)
+	s.log(ctx).Info("gRPC UpdateProduct called", "id", req.Id)
 
 	// Convert ID to ObjectID
 	objectID, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
-		s.logger.Error("Invalid product ID format", "id", req.Id)
+		s.log(ctx).Error("Invalid product ID format", "id", req.Id)
 		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID: %v", err)
 	}
 
@@ -112,9 +179,22 @@ func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProduct
 	if req.Description != nil {
 		product.Description = *req.Description
 	}
+	if req.Slug != nil {
+		product.Slug = *req.Slug
+	}
+	if req.MetaTitle != nil {
+		product.MetaTitle = *req.MetaTitle
+	}
+	if req.MetaDescription != nil {
+		product.MetaDescription = *req.MetaDescription
+	}
 	if req.Price != nil {
 		product.Price = *req.Price
 	}
+	product.CompareAtPrice = req.CompareAtPrice
+	if len(req.GroupPrices) > 0 {
+		product.GroupPrices = protoToDomainGroupPrices(req.GroupPrices)
+	}
 	if len(req.ImageUrls) > 0 {
 		product.ImageURLs = req.ImageUrls
 	}
@@ -130,6 +210,23 @@ func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProduct
 	if req.Active != nil {
 		product.Active = *req.Active
 	}
+	if req.Digital != nil {
+		product.Digital = protoToDomainDigital(req.Digital)
+	}
+	product.Weight = protoToDomainWeight(req.Weight)
+	product.Dimensions = protoToDomainDimensions(req.Dimensions)
+	if req.ShippingClass != nil {
+		product.ShippingClass = *req.ShippingClass
+	}
+	if req.Barcode != nil {
+		product.Barcode = *req.Barcode
+	}
+	if req.ReleaseDate != nil {
+		product.ReleaseDate = unixTime(*req.ReleaseDate)
+	}
+	if req.PreorderAllocation != nil {
+		product.Preorder = &domain.PreorderInfo{Allocation: int(*req.PreorderAllocation)}
+	}
 
 	// Set inventory if provided
 	if req.Inventory != nil {
@@ -142,10 +239,10 @@ func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProduct
 	}
 
 	// Call business logic
-	updatedProduct, err := s.productService.UpdateProduct(product)
+	updatedProduct, err := s.productService.UpdateProduct(ctx, product)
 	if err != nil {
-		s.logger.Error("Failed to update product", "id", req.Id, "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to update product: %v", err)
+		s.log(ctx).Error("Failed to update product", "id", req.Id, "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to update product: %w", err))
 	}
 
 	// Map domain model to protobuf response
@@ -156,16 +253,16 @@ func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProduct
 
 // DeleteProduct implements the DeleteProduct RPC method
 func (s *ProductServer) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
-	s.logger.Info("gRPC DeleteProduct called", "id", req.Id)
+	s.log(ctx).Info("gRPC DeleteProduct called", "id", req.Id)
 
 	// Call business logic
-	err := s.productService.DeleteProduct(req.Id)
+	err := s.productService.DeleteProduct(ctx, req.Id)
 	if err != nil {
-		s.logger.Error("Failed to delete product", "id", req.Id, "error", err)
+		s.log(ctx).Error("Failed to delete product", "id", req.Id, "error", err)
 		return &pb.DeleteProductResponse{
 			Success: false,
 			Message: err.Error(),
-		}, status.Errorf(codes.Internal, "failed to delete product: %v", err)
+		}, apperrors.ToGRPC(fmt.Errorf("failed to delete product: %w", err))
 	}
 
 	return &pb.DeleteProductResponse{
@@ -176,15 +273,16 @@ func (s *ProductServer) DeleteProduct(ctx context.Context, req *pb.DeleteProduct
 
 // ListProducts implements the ListProducts RPC method
 func (s *ProductServer) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
-	s.logger.Info("gRPC ListProducts called",
+	s.log(ctx).Info("gRPC ListProducts called",
 		"page", req.Page,
 		"pageSize", req.PageSize,
 		"category", req.Category)
 
 	// Map protobuf request to domain params
+	page := pagination.New(int(req.Page), int(req.PageSize), domain.ProductPagination)
 	params := domain.ListProductsParams{
-		Page:        int(req.Page),
-		PageSize:    int(req.PageSize),
+		Page:        page.Page,
+		PageSize:    page.PageSize,
 		Category:    req.Category,
 		Tags:        req.Tags,
 		MinPrice:    req.MinPrice,
@@ -193,13 +291,22 @@ func (s *ProductServer) ListProducts(ctx context.Context, req *pb.ListProductsRe
 		SortBy:      req.SortBy,
 		SortDesc:    req.SortDesc,
 		SearchTerm:  req.SearchTerm,
+		SupplierID:  req.SupplierId,
+
+		FeaturedOnly:  req.FeaturedOnly,
+		NewOnly:       req.NewOnly,
+		ShippingClass: req.ShippingClass,
+		Badge:         req.Badge,
+
+		IncludeSubcategories: req.IncludeSubcategories,
+		AttributeFilters:     req.AttributeFilters,
 	}
 
 	// Call business logic
-	products, total, err := s.productService.ListProducts(params)
+	products, total, err := s.productService.ListProducts(customerGroupContext(ctx), params)
 	if err != nil {
-		s.logger.Error("Failed to list products", "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to list products: %v", err)
+		s.log(ctx).Error("Failed to list products", "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to list products: %w", err))
 	}
 
 	// Map domain models to protobuf response
@@ -211,32 +318,32 @@ func (s *ProductServer) ListProducts(ctx context.Context, req *pb.ListProductsRe
 	return &pb.ListProductsResponse{
 		Products:   protoProducts,
 		Total:      int32(total),
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: int32((total + int(req.PageSize) - 1) / int(req.PageSize)), // Calculate total pages
+		Page:       int32(page.Page),
+		PageSize:   int32(page.PageSize),
+		TotalPages: int32(pagination.TotalPages(total, page.PageSize)),
 	}, nil
 }
 
 // UpdateInventory implements the UpdateInventory RPC method
 func (s *ProductServer) UpdateInventory(ctx context.Context, req *pb.UpdateInventoryRequest) (*pb.UpdateInventoryResponse, error) {
-	s.logger.Info("gRPC UpdateInventory called",
+	s.log(ctx).Info("gRPC UpdateInventory called",
 		"productID", req.ProductId,
 		"quantityChange", req.QuantityChange,
 		"operationType", req.OperationType)
 
 	// Call business logic
-	updatedInventory, err := s.productService.UpdateInventory(
+	updatedInventory, err := s.productService.UpdateInventory(ctx,
 		req.ProductId,
 		int(req.QuantityChange),
 		req.OperationId,
 		req.OperationType,
 	)
 	if err != nil {
-		s.logger.Error("Failed to update inventory", "productID", req.ProductId, "error", err)
+		s.log(ctx).Error("Failed to update inventory", "productID", req.ProductId, "error", err)
 		return &pb.UpdateInventoryResponse{
 			Success: false,
 			Message: err.Error(),
-		}, status.Errorf(codes.Internal, "failed to update inventory: %v", err)
+		}, apperrors.ToGRPC(fmt.Errorf("failed to update inventory: %w", err))
 	}
 
 	// Map domain model to protobuf response
@@ -254,13 +361,13 @@ func (s *ProductServer) UpdateInventory(ctx context.Context, req *pb.UpdateInven
 
 // CheckStock implements the CheckStock RPC method
 func (s *ProductServer) CheckStock(ctx context.Context, req *pb.CheckStockRequest) (*pb.CheckStockResponse, error) {
-	s.logger.Info("gRPC CheckStock called", "productID", req.ProductId, "quantity", req.Quantity)
+	s.log(ctx).Info("gRPC CheckStock called", "productID", req.ProductId, "quantity", req.Quantity)
 
 	// Call business logic
-	available, currentStock, err := s.productService.CheckStock(req.ProductId, int(req.Quantity))
+	available, currentStock, err := s.productService.CheckStock(ctx, req.ProductId, int(req.Quantity))
 	if err != nil {
-		s.logger.Error("Failed to check stock", "productID", req.ProductId, "error", err)
-		return nil, status.Errorf(codes.Internal, "failed to check stock: %v", err)
+		s.log(ctx).Error("Failed to check stock", "productID", req.ProductId, "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to check stock: %w", err))
 	}
 
 	return &pb.CheckStockResponse{
@@ -269,55 +376,154 @@ func (s *ProductServer) CheckStock(ctx context.Context, req *pb.CheckStockReques
 	}, nil
 }
 
-// WatchInventory implements the WatchInventory RPC method
-func (s *ProductServer) WatchInventory(req *pb.WatchInventoryRequest, stream pb.ProductService_WatchInventoryServer) error {
-	s.logger.Info("gRPC WatchInventory called", "productIDs", req.ProductIds, "threshold", req.Threshold)
+// ListInventoryOperations implements the ListInventoryOperations RPC method
+func (s *ProductServer) ListInventoryOperations(ctx context.Context, req *pb.ListInventoryOperationsRequest) (*pb.ListInventoryOperationsResponse, error) {
+	s.log(ctx).Info("gRPC ListInventoryOperations called", "productID", req.ProductId, "operationTypes", req.OperationTypes)
+
+	page := pagination.New(int(req.Page), int(req.PageSize), domain.InventoryOperationPagination)
+	filter := domain.InventoryOperationFilter{
+		ProductID: req.ProductId,
+		Types:     req.OperationTypes,
+		From:      unixTime(req.From),
+		To:        unixTime(req.To),
+	}
+	operations, total, err := s.productService.ListInventoryOperations(ctx, filter, page)
+	if err != nil {
+		s.log(ctx).Error("Failed to list inventory operations", "productID", req.ProductId, "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to list inventory operations: %w", err))
+	}
+
+	protoOperations := make([]*pb.InventoryOperation, len(operations))
+	for i, operation := range operations {
+		protoOperations[i] = &pb.InventoryOperation{
+			OperationId:    operation.OperationID,
+			OperationType:  operation.OperationType,
+			QuantityChange: int32(operation.QuantityChange),
+			Timestamp:      operation.Timestamp.Unix(),
+		}
+	}
+
+	return &pb.ListInventoryOperationsResponse{
+		Operations: protoOperations,
+		Total:      int32(total),
+		Page:       int32(page.Page),
+		PageSize:   int32(page.PageSize),
+		TotalPages: int32(pagination.TotalPages(total, page.PageSize)),
+	}, nil
+}
 
-	// In a real implementation, we would:
-	// 1. Set up a database watch/change stream or subscribe to a message queue
-	// 2. Monitor inventory changes for the requested products
-	// 3. Send updates to the client when stock changes
+// WatchInventory implements the WatchInventory RPC method. Updates are
+// streamed as inventory changes until the client disconnects.
+func (s *ProductServer) WatchInventory(req *pb.WatchInventoryRequest, stream pb.ProductService_WatchInventoryServer) error {
+	s.log(stream.Context()).Info("gRPC WatchInventory called", "productIDs", req.ProductIds, "threshold", req.Threshold)
+
+	sent := 0
+	err := s.productService.WatchInventory(stream.Context(), req.ProductIds, int(req.Threshold), func(change *domain.InventoryChange) error {
+		err := stream.Send(&pb.InventoryUpdate{
+			ProductId:   change.ProductID,
+			ProductName: change.ProductName,
+			Inventory: &pb.InventoryInfo{
+				Quantity: int32(change.Inventory.Quantity),
+				Sku:      change.Inventory.SKU,
+				InStock:  change.Inventory.InStock,
+				Reserved: int32(change.Inventory.Reserved),
+			},
+			Timestamp: change.ChangedAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	if stream.Context().Err() != nil {
+		s.log(stream.Context()).Info("Client stopped watching inventory", "sent", sent)
+		return status.Errorf(codes.Canceled, "client cancelled request")
+	}
+	if err != nil {
+		s.log(stream.Context()).Error("Failed to watch inventory", "sent", sent, "error", err)
+		return apperrors.ToGRPC(fmt.Errorf("failed to watch inventory: %w", err))
+	}
+	return nil
+}
 
-	// For this implementation, we'll simulate periodic inventory updates
-	// This is just a placeholder for the real implementation
+// StreamProducts implements the StreamProducts RPC method
+func (s *ProductServer) StreamProducts(req *pb.StreamProductsRequest, stream pb.ProductService_StreamProductsServer) error {
+	s.log(stream.Context()).Info("gRPC StreamProducts called", "includeInactive", req.IncludeInactive)
 
-	// Continue sending updates until the client disconnects
-	for i := 0; i < 5; i++ {
-		// Check if client has cancelled the request
+	sent := 0
+	err := s.productService.StreamProducts(stream.Context(), req.IncludeInactive, func(product *domain.Product) error {
+		if err := stream.Send(domainToProtoProduct(product)); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	if err != nil {
 		if stream.Context().Err() != nil {
 			return status.Errorf(codes.Canceled, "client cancelled request")
 		}
+		s.log(stream.Context()).Error("Failed to stream products", "sent", sent, "error", err)
+		return apperrors.ToGRPC(fmt.Errorf("failed to stream products: %w", err))
+	}
 
-		// Simulate an inventory update
-		if len(req.ProductIds) > 0 {
-			update := &pb.InventoryUpdate{
-				ProductId:   req.ProductIds[0],
-				ProductName: "Sample Product",
-				Inventory: &pb.InventoryInfo{
-					Quantity: 100 - int32(i*10),
-					Sku:      "SKU123",
-					InStock:  true,
-					Reserved: 0,
-				},
-				Timestamp: time.Now().Unix(),
-			}
-
-			if err := stream.Send(update); err != nil {
-				s.logger.Error("Failed to send inventory update", "error", err)
-				return status.Errorf(codes.Internal, "failed to send update: %v", err)
-			}
+	s.log(stream.Context()).Info("Finished streaming products", "sent", sent)
+	return nil
+}
+
+// SetFeatured implements the SetFeatured RPC method
+func (s *ProductServer) SetFeatured(ctx context.Context, req *pb.SetFeaturedRequest) (*pb.ProductResponse, error) {
+	s.log(ctx).Info("gRPC SetFeatured called", "id", req.Id, "featured", req.Featured)
+
+	product, err := s.productService.SetFeatured(ctx, req.Id, req.Featured, unixTime(req.FeaturedUntil))
+	if err != nil {
+		s.log(ctx).Error("Failed to set product featured", "id", req.Id, "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to set product featured: %w", err))
+	}
+
+	return &pb.ProductResponse{
+		Product: domainToProtoProduct(product),
+	}, nil
+}
+
+// ValidatePurchaseEligibility implements the ValidatePurchaseEligibility RPC method
+func (s *ProductServer) ValidatePurchaseEligibility(ctx context.Context, req *pb.ValidatePurchaseEligibilityRequest) (*pb.ValidatePurchaseEligibilityResponse, error) {
+	s.log(ctx).Info("gRPC ValidatePurchaseEligibility called", "products", len(req.ProductIds))
+
+	customer := domain.CustomerProfile{Region: req.GetCustomerProfile().GetRegion()}
+	if birthDate := req.GetCustomerProfile().GetBirthDate(); birthDate != "" {
+		t, err := time.Parse(time.DateOnly, birthDate)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "birth_date must be a date such as 2006-01-02")
 		}
+		customer.BirthDate = &t
+	}
 
-		// Simulate delay between updates
-		time.Sleep(1 * time.Second)
+	ineligible, err := s.productService.ValidatePurchaseEligibility(ctx, req.ProductIds, customer)
+	if err != nil {
+		s.log(ctx).Error("Failed to validate purchase eligibility", "error", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to validate purchase eligibility: %w", err))
 	}
 
-	return nil
+	resp := &pb.ValidatePurchaseEligibilityResponse{Eligible: len(ineligible) == 0}
+	for _, i := range ineligible {
+		resp.Ineligible = append(resp.Ineligible, &pb.IneligibleProduct{
+			ProductId: i.ProductID,
+			Reason:    i.Reason,
+			Message:   i.Message,
+		})
+	}
+	return resp, nil
+}
+
+// log returns the request-scoped logger
+func (s *ProductServer) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
 }
 
 // Helper function to convert domain Product to proto Product
 func domainToProtoProduct(product *domain.Product) *pb.Product {
-	return &pb.Product{
+	p := &pb.Product{
 		Id:          product.ID.Hex(),
 		Name:        product.Name,
 		Description: product.Description,
@@ -330,10 +536,192 @@ func domainToProtoProduct(product *domain.Product) *pb.Product {
 			InStock:  product.Inventory.InStock,
 			Reserved: int32(product.Inventory.Reserved),
 		},
-		Tags:       product.Tags,
-		Attributes: product.Attributes,
-		Active:     product.Active,
-		CreatedAt:  product.CreatedAt.Unix(),
-		UpdatedAt:  product.UpdatedAt.Unix(),
+		Tags:              product.Tags,
+		Attributes:        product.Attributes,
+		Active:            product.Active,
+		Status:            product.Status,
+		CreatedAt:         product.CreatedAt.Unix(),
+		UpdatedAt:         product.UpdatedAt.Unix(),
+		Suppliers:         domainToProtoSuppliers(product.Suppliers),
+		Featured:          product.Featured,
+		IsNew:             product.IsNew,
+		Type:              product.Type,
+		Digital:           domainToProtoDigital(product.Digital),
+		ShippingClass:     product.ShippingClass,
+		Barcode:           product.Barcode,
+		MinOrderQuantity:  int32(product.MinOrderQuantity),
+		MaxPerCustomer:    int32(product.MaxPerCustomer),
+		MinAge:            int32(product.MinAge),
+		RestrictedRegions: product.RestrictedRegions,
+		CompareAtPrice:    product.CompareAtPrice,
+		Badges:            product.Badges,
+		Slug:              product.Slug,
+		PreviousSlugs:     product.PreviousSlugs,
+		MetaTitle:         product.MetaTitle,
+		MetaDescription:   product.MetaDescription,
+		CustomerGroup:     product.CustomerGroup,
+		EffectivePrice:    product.EffectivePrice,
+		DiscountedPrice:   product.DiscountedPrice,
+	}
+	for _, price := range product.GroupPrices {
+		p.GroupPrices = append(p.GroupPrices, &pb.GroupPrice{Group: price.Group, Price: price.Price})
+	}
+	for _, badge := range product.DisplayBadges {
+		p.DisplayBadges = append(p.DisplayBadges, &pb.ProductBadge{Key: badge.Key, Label: badge.Label, Color: badge.Color})
+	}
+	if product.Weight != nil {
+		p.Weight = &pb.Weight{Value: product.Weight.Value, Unit: product.Weight.Unit}
+	}
+	if product.Dimensions != nil {
+		p.Dimensions = &pb.Dimensions{
+			Length: product.Dimensions.Length,
+			Width:  product.Dimensions.Width,
+			Height: product.Dimensions.Height,
+			Unit:   product.Dimensions.Unit,
+		}
+	}
+	if product.FeaturedUntil != nil {
+		p.FeaturedUntil = product.FeaturedUntil.Unix()
+	}
+	if product.ReleaseDate != nil {
+		p.ReleaseDate = product.ReleaseDate.Unix()
+	}
+	if product.Preorder != nil {
+		p.Preorder = &pb.PreorderInfo{
+			Allocation: int32(product.Preorder.Allocation),
+			Ordered:    int32(product.Preorder.Ordered),
+		}
+	}
+	if promotion := product.Promotion; promotion != nil {
+		p.Promotion = &pb.AppliedPromotion{Id: promotion.ID, Name: promotion.Name, Type: promotion.Type, Value: promotion.Value}
+		if promotion.EndsAt != nil {
+			p.Promotion.EndsAt = promotion.EndsAt.Unix()
+		}
+	}
+	return p
+}
+
+// protoToDomainGroupPrices converts group prices from the API
+func protoToDomainGroupPrices(prices []*pb.GroupPrice) []domain.GroupPrice {
+	if len(prices) == 0 {
+		return nil
+	}
+	converted := make([]domain.GroupPrice, 0, len(prices))
+	for _, price := range prices {
+		converted = append(converted, domain.GroupPrice{Group: price.GetGroup(), Price: price.GetPrice()})
+	}
+	return converted
+}
+
+// customerGroupContext passes the customer group of the caller to the
+// service: the group of an authenticated principal, or else the one in
+// the x-customer-group metadata
+func customerGroupContext(ctx context.Context) context.Context {
+	var group string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(customerGroupMetadata); len(values) > 0 {
+			group = strings.ToLower(strings.TrimSpace(values[0]))
+		}
+	}
+	if principal, ok := middleware.PrincipalFrom(ctx); ok && principal.Group != "" {
+		group = principal.Group
+	}
+	if group == "" {
+		return ctx
+	}
+	return domain.WithCustomerGroup(ctx, group)
+}
+
+// unixTime converts Unix seconds to a time, with 0 as no time
+func unixTime(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
+func protoToDomainWeight(weight *pb.Weight) *domain.Weight {
+	if weight == nil {
+		return nil
+	}
+	return &domain.Weight{Value: weight.Value, Unit: weight.Unit}
+}
+
+func protoToDomainDimensions(dimensions *pb.Dimensions) *domain.Dimensions {
+	if dimensions == nil {
+		return nil
+	}
+	return &domain.Dimensions{
+		Length: dimensions.Length,
+		Width:  dimensions.Width,
+		Height: dimensions.Height,
+		Unit:   dimensions.Unit,
+	}
+}
+
+func domainToProtoDigital(digital *domain.DigitalInfo) *pb.DigitalInfo {
+	if digital == nil {
+		return nil
+	}
+	info := &pb.DigitalInfo{
+		Assets:       make([]*pb.DigitalAsset, len(digital.Assets)),
+		MaxDownloads: int32(digital.MaxDownloads),
+		LicenseKeys:  digital.LicenseKeys,
+	}
+	for i, asset := range digital.Assets {
+		info.Assets[i] = &pb.DigitalAsset{Name: asset.Name, Key: asset.Key, Size: asset.Size}
+	}
+	return info
+}
+
+func protoToDomainDigital(digital *pb.DigitalInfo) *domain.DigitalInfo {
+	if digital == nil {
+		return nil
+	}
+	info := &domain.DigitalInfo{
+		Assets:       make([]domain.DigitalAsset, len(digital.Assets)),
+		MaxDownloads: int(digital.MaxDownloads),
+		LicenseKeys:  digital.LicenseKeys,
+	}
+	for i, asset := range digital.Assets {
+		info.Assets[i] = domain.DigitalAsset{Name: asset.GetName(), Key: asset.GetKey(), Size: asset.GetSize()}
+	}
+	return info
+}
+
+func domainToProtoSuppliers(links []domain.ProductSupplier) []*pb.ProductSupplier {
+	if len(links) == 0 {
+		return nil
+	}
+	suppliers := make([]*pb.ProductSupplier, len(links))
+	for i, link := range links {
+		suppliers[i] = &pb.ProductSupplier{
+			SupplierId:   link.SupplierID.Hex(),
+			SupplierSku:  link.SupplierSKU,
+			LeadTimeDays: int32(link.LeadTimeDays),
+			Preferred:    link.Preferred,
+		}
+	}
+	return suppliers
+}
+
+func protoToDomainSuppliers(suppliers []*pb.ProductSupplier) ([]domain.ProductSupplier, error) {
+	if len(suppliers) == 0 {
+		return nil, nil
+	}
+	links := make([]domain.ProductSupplier, len(suppliers))
+	for i, supplier := range suppliers {
+		supplierID, err := primitive.ObjectIDFromHex(supplier.SupplierId)
+		if err != nil {
+			return nil, err
+		}
+		links[i] = domain.ProductSupplier{
+			SupplierID:   supplierID,
+			SupplierSKU:  supplier.SupplierSku,
+			LeadTimeDays: int(supplier.LeadTimeDays),
+			Preferred:    supplier.Preferred,
+		}
 	}
+	return links, nil
 }
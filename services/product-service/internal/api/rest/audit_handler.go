@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// AuditService defines the interface for reading the audit trail of
+// products
+type AuditService interface {
+	ProductAudit(ctx context.Context, productID string, page pagination.Request) ([]*domain.AuditEntry, int, error)
+}
+
+// AuditHandler handles HTTP requests for the audit trail of products. Its
+// routes are registered apart from the product routes, so that they can
+// be restricted to admins.
+type AuditHandler struct {
+	service AuditService
+	logger  *slog.Logger
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(service AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the audit routes with the given router
+func (h *AuditHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/v1/products/{id}/audit", h.ProductAudit)
+}
+
+// ProductAudit handles GET /v1/products/{id}/audit?page=0&page_size=20
+func (h *AuditHandler) ProductAudit(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP ProductAudit called", "id", id)
+
+	page, err := pagination.Parse(r.URL.Query(), domain.AuditPagination)
+	if err != nil {
+		writeError(h.logger, w, r, "Invalid pagination parameters", err)
+		return
+	}
+	entries, total, err := h.service.ProductAudit(r.Context(), id, page)
+	if err != nil {
+		writeError(h.logger, w, r, "Failed to get product audit", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pagination.NewList("entries", entries, total, page)); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// Helper functions
+
+func (h *AuditHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// BadgeService defines the interface for badge definition operations
+type BadgeService interface {
+	CreateBadge(ctx context.Context, badge *domain.Badge) (*domain.Badge, error)
+	ListBadges(ctx context.Context) ([]*domain.Badge, error)
+	UpdateBadge(ctx context.Context, badge *domain.Badge) (*domain.Badge, error)
+	DeleteBadge(ctx context.Context, key string) error
+}
+
+// BadgeHandler handles HTTP requests for badge definitions
+type BadgeHandler struct {
+	service BadgeService
+	logger  *slog.Logger
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(service BadgeService, logger *slog.Logger) *BadgeHandler {
+	return &BadgeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the badge routes with the given router. Badges
+// are assigned with PUT /v1/products/{id}/badges.
+func (h *BadgeHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/badges", func(r chi.Router) {
+		r.Post("/", h.CreateBadge)
+		r.Get("/", h.ListBadges)
+		r.Put("/{key}", h.UpdateBadge)
+		r.Delete("/{key}", h.DeleteBadge)
+	})
+}
+
+// badgeRequest is the editable part of a badge
+type badgeRequest struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Color    string `json:"color"`
+	Priority int    `json:"priority"`
+}
+
+func (req *badgeRequest) badge() *domain.Badge {
+	return &domain.Badge{
+		Key:      req.Key,
+		Label:    req.Label,
+		Color:    req.Color,
+		Priority: req.Priority,
+	}
+}
+
+// CreateBadge handles POST /v1/badges
+func (h *BadgeHandler) CreateBadge(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreateBadge called")
+
+	var request badgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	badge, err := h.service.CreateBadge(r.Context(), request.badge())
+	if err != nil {
+		h.writeError(w, r, "Failed to create badge", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, badge)
+}
+
+// ListBadges handles GET /v1/badges
+func (h *BadgeHandler) ListBadges(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListBadges called")
+
+	badges, err := h.service.ListBadges(r.Context())
+	if err != nil {
+		h.writeError(w, r, "Failed to list badges", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"badges": badges})
+}
+
+// UpdateBadge handles PUT /v1/badges/{key}
+func (h *BadgeHandler) UpdateBadge(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	h.log(r).Info("HTTP UpdateBadge called", "key", key)
+
+	var request badgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	badge := request.badge()
+	badge.Key = key
+	updated, err := h.service.UpdateBadge(r.Context(), badge)
+	if err != nil {
+		h.writeError(w, r, "Failed to update badge", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteBadge handles DELETE /v1/badges/{key}
+func (h *BadgeHandler) DeleteBadge(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	h.log(r).Info("HTTP DeleteBadge called", "key", key)
+
+	if err := h.service.DeleteBadge(r.Context(), key); err != nil {
+		h.writeError(w, r, "Failed to delete badge", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *BadgeHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *BadgeHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *BadgeHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// CategoryService defines the interface for category tree operations
+type CategoryService interface {
+	CreateCategory(ctx context.Context, category *domain.Category) (*domain.Category, error)
+	GetCategory(ctx context.Context, key string) (*domain.Category, error)
+	ListCategories(ctx context.Context) ([]*domain.Category, error)
+	UpdateCategory(ctx context.Context, category *domain.Category) (*domain.Category, error)
+	DeleteCategory(ctx context.Context, key string) error
+}
+
+// CategoryHandler handles HTTP requests for categories
+type CategoryHandler struct {
+	service CategoryService
+	logger  *slog.Logger
+}
+
+// NewCategoryHandler creates a new category handler
+func NewCategoryHandler(service CategoryService, logger *slog.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the category routes with the given router.
+// Products of a category and its subcategories are listed with
+// GET /v1/products?category={key}&include_subcategories=true.
+func (h *CategoryHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/categories", func(r chi.Router) {
+		r.Post("/", h.CreateCategory)
+		r.Get("/", h.ListCategories)
+		r.Get("/{key}", h.GetCategory)
+		r.Put("/{key}", h.UpdateCategory)
+		r.Delete("/{key}", h.DeleteCategory)
+	})
+}
+
+// categoryRequest is the editable part of a category
+type categoryRequest struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+}
+
+func (req *categoryRequest) category() *domain.Category {
+	return &domain.Category{
+		Key:    req.Key,
+		Name:   req.Name,
+		Parent: req.Parent,
+	}
+}
+
+// CreateCategory handles POST /v1/categories
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreateCategory called")
+
+	var request categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	category, err := h.service.CreateCategory(r.Context(), request.category())
+	if err != nil {
+		h.writeError(w, r, "Failed to create category", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, category)
+}
+
+// ListCategories handles GET /v1/categories
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListCategories called")
+
+	categories, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		h.writeError(w, r, "Failed to list categories", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"categories": categories})
+}
+
+// GetCategory handles GET /v1/categories/{key}
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	h.log(r).Info("HTTP GetCategory called", "key", key)
+
+	category, err := h.service.GetCategory(r.Context(), key)
+	if err != nil {
+		h.writeError(w, r, "Failed to get category", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, category)
+}
+
+// UpdateCategory handles PUT /v1/categories/{key}
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	h.log(r).Info("HTTP UpdateCategory called", "key", key)
+
+	var request categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	category := request.category()
+	category.Key = key
+	updated, err := h.service.UpdateCategory(r.Context(), category)
+	if err != nil {
+		h.writeError(w, r, "Failed to update category", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteCategory handles DELETE /v1/categories/{key}
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	h.log(r).Info("HTTP DeleteCategory called", "key", key)
+
+	if err := h.service.DeleteCategory(r.Context(), key); err != nil {
+		h.writeError(w, r, "Failed to delete category", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *CategoryHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *CategoryHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *CategoryHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
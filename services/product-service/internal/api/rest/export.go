@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// Export formats
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+// exportFlushInterval is how many products are written between flushes,
+// so that large exports reach the client as they are read
+const exportFlushInterval = 100
+
+// exportColumns are the columns of a CSV export. Lists are joined with |
+// and attributes written as name=value; NDJSON exports keep every field.
+var exportColumns = []string{
+	"id", "sku", "name", "slug", "category", "price", "compare_at_price", "quantity", "in_stock",
+	"active", "barcode", "tags", "attributes", "image_urls", "created_at", "updated_at",
+}
+
+// ExportProducts handles GET /v1/products/export?format=csv|ndjson. It
+// takes the filters of a product list and streams every matching product.
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportCSV
+	}
+	h.log(r).Info("HTTP ExportProducts called", "format", format)
+
+	if format != ExportCSV && format != ExportNDJSON {
+		h.writeError(w, r, "Invalid export format", apperrors.New(apperrors.Invalid, "format must be csv or ndjson"))
+		return
+	}
+	var params domain.ListProductsParams
+	h.listFilters(r, &params)
+
+	// Exports outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	exporter := newProductExporter(w, format)
+	err := h.service.ExportProducts(r.Context(), params, exporter.Write)
+	if err == nil {
+		err = exporter.Finish()
+	}
+	if err != nil {
+		if !exporter.Started() {
+			h.writeError(w, r, "Failed to export products", err)
+			return
+		}
+		// The status is sent; abort the response so that the client sees
+		// a truncated export rather than a complete one
+		h.log(r).Error("Product export interrupted", "exported", exporter.count, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// productExporter writes products to an export response. The status and
+// headers are sent with the first product, or by Finish, so errors before
+// then can still be reported.
+type productExporter struct {
+	w       http.ResponseWriter
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	flusher http.Flusher
+	started bool
+	count   int
+}
+
+func newProductExporter(w http.ResponseWriter, format string) *productExporter {
+	flusher, _ := w.(http.Flusher)
+	return &productExporter{w: w, format: format, flusher: flusher}
+}
+
+// Started reports whether the response has been sent
+func (e *productExporter) Started() bool {
+	return e.started
+}
+
+// start sends the status and headers, and the header row of CSV exports
+func (e *productExporter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	contentType := "text/csv; charset=utf-8"
+	if e.format == ExportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", `attachment; filename="products.`+e.format+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == ExportNDJSON {
+		e.json = json.NewEncoder(e.w)
+		return nil
+	}
+	e.csv = csv.NewWriter(e.w)
+	return e.csv.Write(exportColumns)
+}
+
+// Write writes a product
+func (e *productExporter) Write(product *domain.Product) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	var err error
+	if e.format == ExportNDJSON {
+		err = e.json.Encode(product)
+	} else {
+		err = e.csv.Write(exportRecord(product))
+	}
+	if err != nil {
+		return err
+	}
+	e.count++
+	if e.count%exportFlushInterval == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// Finish writes what is buffered, and sends the response of an empty
+// export
+func (e *productExporter) Finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+func (e *productExporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return nil
+}
+
+// exportRecord returns the CSV row of a product, in exportColumns order
+func exportRecord(product *domain.Product) []string {
+	compareAt := ""
+	if product.CompareAtPrice != nil {
+		compareAt = formatPrice(*product.CompareAtPrice)
+	}
+	attributes := make([]string, 0, len(product.Attributes))
+	for _, name := range slices.Sorted(maps.Keys(product.Attributes)) {
+		attributes = append(attributes, name+"="+product.Attributes[name])
+	}
+	return []string{
+		product.ID.Hex(),
+		product.Inventory.SKU,
+		product.Name,
+		product.Slug,
+		product.Category,
+		formatPrice(product.Price),
+		compareAt,
+		strconv.Itoa(product.Inventory.Quantity),
+		strconv.FormatBool(product.Inventory.InStock),
+		strconv.FormatBool(product.Active),
+		product.Barcode,
+		strings.Join(product.Tags, "|"),
+		strings.Join(attributes, "|"),
+		strings.Join(product.ImageURLs, "|"),
+		product.CreatedAt.UTC().Format(time.RFC3339),
+		product.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/feed"
+	"github.com/go-chi/chi/v5"
+)
+
+// FeedService defines the interface the product feeds are read through
+type FeedService interface {
+	StreamProducts(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error
+}
+
+// FeedHandler serves the active catalog as product feeds for shopping ads
+type FeedHandler struct {
+	service FeedService
+	options feed.Options
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+// NewFeedHandler creates a new feed handler for the store described by
+// options
+func NewFeedHandler(service FeedService, options feed.Options, logger *slog.Logger) *FeedHandler {
+	return &FeedHandler{
+		service: service,
+		options: options,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// RegisterRoutes registers the feed routes with the given router
+func (h *FeedHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/v1/feeds/google-shopping", h.GoogleShopping)
+	r.Get("/v1/feeds/facebook", h.Facebook)
+}
+
+// feedWriter writes a feed one product at a time
+type feedWriter interface {
+	Write(product *domain.Product) error
+	Close() error
+}
+
+// GoogleShopping handles GET /v1/feeds/google-shopping
+func (h *FeedHandler) GoogleShopping(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP GoogleShopping feed called")
+	h.serveFeed(w, r, "application/xml; charset=utf-8", feed.NewGoogleWriter(w, h.options, h.now()))
+}
+
+// Facebook handles GET /v1/feeds/facebook
+func (h *FeedHandler) Facebook(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP Facebook feed called")
+	h.serveFeed(w, r, "text/csv; charset=utf-8", feed.NewFacebookWriter(w, h.options, h.now()))
+}
+
+// serveFeed streams the active products through writer. The writer sends
+// nothing before the first product, so errors until then are reported as
+// usual; later ones abort the response, so that the platform fetching the
+// feed does not take a truncated feed for the catalog.
+func (h *FeedHandler) serveFeed(w http.ResponseWriter, r *http.Request, contentType string, writer feedWriter) {
+	// Feeds of large catalogs outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", contentType)
+	started, count := false, 0
+	err := h.service.StreamProducts(r.Context(), false, func(product *domain.Product) error {
+		started = true
+		count++
+		return writer.Write(product)
+	})
+	if err == nil {
+		started = true
+		err = writer.Close()
+	}
+	if err != nil {
+		if !started {
+			writeError(h.logger, w, r, "Failed to render feed", err)
+			return
+		}
+		h.log(r).Error("Feed interrupted", "products", count, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (h *FeedHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
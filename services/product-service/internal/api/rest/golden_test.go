@@ -0,0 +1,1240 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/pkg/testutil/golden"
+	"github.com/bekbull/online-shop/services/product-service/internal/api/rest"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/feed"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestGoldenResponses compares every REST endpoint's response with its
+// golden file in testdata/golden. Run with -update to rewrite them.
+func TestGoldenResponses(t *testing.T) {
+	productID, supplierID, orderID := fixedID("65f1c0d2e4b0a1b2c3d4e5f1"), fixedID("65f1c0d2e4b0a1b2c3d4e5f2"), fixedID("65f1c0d2e4b0a1b2c3d4e5f3")
+	promotionID, stockAlertID := stubPromotionID.Hex(), stubStockAlertID.Hex()
+	missing := "65f1c0d2e4b0a1b2c3d4e5ff"
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"create_product", http.MethodPost, "/v1/products", `{"name":"Mug","description":"Stoneware","price":8.5,"image_urls":["https://img.example.com/mug.png"],"category":"kitchen","inventory":{"quantity":3,"sku":"MUG-1"},"tags":["mug"],"attributes":{"color":"red"},"suppliers":[{"supplier_id":"` + supplierID.Hex() + `","supplier_sku":"S-MUG","lead_time_days":5,"preferred":true}]}`},
+		{"create_digital_product", http.MethodPost, "/v1/products", `{"name":"Go Book","price":20,"category":"books","inventory":{"sku":"BOOK-1"},"type":"digital","digital":{"assets":[{"name":"book.pdf","key":"books/go.pdf","size":1048576}],"max_downloads":3,"license_keys":true}}`},
+		{"create_shipped_product", http.MethodPost, "/v1/products", `{"name":"Kettle","price":35,"category":"kitchen","inventory":{"quantity":5,"sku":"KETTLE-1"},"weight":{"value":1.2,"unit":"kg"},"dimensions":{"length":25,"width":18,"height":22,"unit":"cm"},"shipping_class":"bulky"}`},
+		{"create_preorder_product", http.MethodPost, "/v1/products", `{"name":"Console","price":499,"category":"games","inventory":{"sku":"CONSOLE-2"},"release_date":"2030-11-15T00:00:00Z","preorder":{"allocation":500}}`},
+		{"create_discounted_product", http.MethodPost, "/v1/products", `{"name":"Teapot","price":24,"compare_at_price":30,"category":"kitchen","inventory":{"quantity":2,"sku":"TEAPOT-1"},"badges":["eco"]}`},
+		{"create_product_with_seo", http.MethodPost, "/v1/products", `{"name":"Stoneware Mug","price":12,"category":"kitchen","inventory":{"quantity":4,"sku":"MUG-2"},"slug":"stoneware-mug","meta_title":"Stoneware Mug | Online Shop","meta_description":"A 350 ml stoneware mug, dishwasher safe."}`},
+		{"create_product_with_group_prices", http.MethodPost, "/v1/products", `{"name":"Crate of Mugs","price":60,"category":"kitchen","inventory":{"quantity":8,"sku":"MUG-12"},"group_prices":[{"group":"wholesale","price":45},{"group":"vip","price":54}]}`},
+		{"create_product_invalid_group_price", http.MethodPost, "/v1/products", `{"name":"Crate of Mugs","price":60,"category":"kitchen","inventory":{"sku":"MUG-12"},"group_prices":[{"group":"retail","price":55}]}`},
+		{"create_product_duplicate_sku", http.MethodPost, "/v1/products", `{"name":"Mug","price":8.5,"category":"kitchen","inventory":{"quantity":3,"sku":"` + stubTakenSKU + `"}}`},
+		{"create_product_draft", http.MethodPost, "/v1/products", `{"name":"Mug","price":8.5,"category":"kitchen","inventory":{"sku":"MUG-3"},"status":"draft"}`},
+		{"create_product_invalid_body", http.MethodPost, "/v1/products", `{"name":`},
+		{"get_product", http.MethodGet, "/v1/products/" + productID.Hex(), ""},
+		{"get_product_not_found", http.MethodGet, "/v1/products/" + missing, ""},
+		{"get_product_by_slug", http.MethodGet, "/v1/products/slug/stoneware-mug", ""},
+		{"get_product_by_slug_redirect", http.MethodGet, "/v1/products/slug/mug", ""},
+		{"get_product_by_slug_not_found", http.MethodGet, "/v1/products/slug/teapot", ""},
+		{"get_product_by_barcode", http.MethodGet, "/v1/products/by-barcode/4006381333931", ""},
+		{"get_product_by_barcode_invalid", http.MethodGet, "/v1/products/by-barcode/4006381333932", ""},
+		{"update_product", http.MethodPut, "/v1/products/" + productID.Hex(), `{"name":"Cup","price":9,"active":false,"inventory":{"quantity":4,"sku":"CUP-1"}}`},
+		{"update_product_invalid_id", http.MethodPut, "/v1/products/not-an-id", `{}`},
+		{"patch_product", http.MethodPatch, "/v1/products/" + productID.Hex(), `{"name":"Cup","description":"","compare_at_price":null}`},
+		{"patch_product_read_only_field", http.MethodPatch, "/v1/products/" + productID.Hex(), `{"inventory":{"quantity":100}}`},
+		{"patch_product_not_object", http.MethodPatch, "/v1/products/" + productID.Hex(), `[{"op":"replace","path":"/name","value":"Cup"}]`},
+		{"clone_product", http.MethodPost, "/v1/products/" + productID.Hex() + "/clone", `{"sku":"MUG-2","name":"Blue Mug"}`},
+		{"clone_product_missing_sku", http.MethodPost, "/v1/products/" + productID.Hex() + "/clone", `{"name":"Blue Mug"}`},
+		{"clone_product_duplicate_sku", http.MethodPost, "/v1/products/" + productID.Hex() + "/clone", `{"sku":"` + stubTakenSKU + `"}`},
+		{"clone_product_not_found", http.MethodPost, "/v1/products/65f1c0d2e4b0a1b2c3d4e5f9/clone", `{"sku":"MUG-2"}`},
+		{"publish_product", http.MethodPost, "/v1/products/" + productID.Hex() + "/publish", ""},
+		{"publish_product_incomplete", http.MethodPost, "/v1/products/" + stubDraftID + "/publish", ""},
+		{"retire_product", http.MethodPost, "/v1/products/" + productID.Hex() + "/retire", ""},
+		{"retire_product_draft", http.MethodPost, "/v1/products/" + stubDraftID + "/retire", ""},
+		{"delete_product", http.MethodDelete, "/v1/products/" + productID.Hex(), ""},
+		{"list_products_by_ids", http.MethodGet, "/v1/products?ids=" + missing + "," + productID.Hex() + "&category=garden", ""},
+		{"list_products", http.MethodGet, "/v1/products?page=1&page_size=2&category=kitchen", ""},
+		{"trending_products", http.MethodGet, "/v1/products/trending?window=7d&limit=2", ""},
+		{"trending_products_invalid_window", http.MethodGet, "/v1/products/trending?window=week", ""},
+		{"related_products", http.MethodGet, "/v1/products/" + productID.Hex() + "/related?limit=4", ""},
+		{"related_products_not_found", http.MethodGet, "/v1/products/" + missing + "/related", ""},
+		{"suggest", http.MethodGet, "/v1/products/suggest?q=mu&limit=5", ""},
+		{"suggest_invalid_limit", http.MethodGet, "/v1/products/suggest?q=mu&limit=five", ""},
+		{"update_inventory", http.MethodPost, "/v1/products/" + productID.Hex() + "/inventory", `{"quantity_change":-2,"operation_id":"op-1","operation_type":"purchase"}`},
+		{"update_inventory_insufficient_stock", http.MethodPost, "/v1/products/" + productID.Hex() + "/inventory", `{"quantity_change":-20,"operation_id":"op-2","operation_type":"purchase"}`},
+		{"list_inventory_operations", http.MethodGet, "/v1/products/" + productID.Hex() + "/inventory/operations?operation_type=purchase,adjustment&from=2024-03-01T00:00:00Z&page=0&page_size=10", ""},
+		{"list_inventory_operations_invalid_time", http.MethodGet, "/v1/products/" + productID.Hex() + "/inventory/operations?to=2024-03-31", ""},
+		{"list_inventory_operations_not_found", http.MethodGet, "/v1/products/" + missing + "/inventory/operations", ""},
+		{"check_stock", http.MethodGet, "/v1/products/" + productID.Hex() + "/stock?quantity=2", ""},
+		{"get_availability", http.MethodGet, "/v1/products/" + productID.Hex() + "/availability", ""},
+		{"get_price", http.MethodGet, "/v1/products/" + productID.Hex() + "/price?currency=EUR", ""},
+		{"set_product_suppliers", http.MethodPut, "/v1/products/" + productID.Hex() + "/suppliers", `{"suppliers":[{"supplier_id":"` + supplierID.Hex() + `","supplier_sku":"S-MUG","lead_time_days":3,"preferred":true}]}`},
+		{"set_purchase_limits", http.MethodPut, "/v1/products/" + productID.Hex() + "/purchase-limits", `{"min_order_quantity":6,"max_per_customer":24}`},
+		{"set_purchase_limits_invalid", http.MethodPut, "/v1/products/" + productID.Hex() + "/purchase-limits", `{"min_order_quantity":6,"max_per_customer":4}`},
+		{"set_restrictions", http.MethodPut, "/v1/products/" + productID.Hex() + "/restrictions", `{"min_age":18,"restricted_regions":["us-ut","DE","de"]}`},
+		{"purchase_eligibility", http.MethodPost, "/v1/products/purchase-eligibility", `{"product_ids":["` + productID.Hex() + `","` + missing + `"],"customer":{"birth_date":"2010-05-04","region":"DE"}}`},
+		{"purchase_eligibility_invalid_birth_date", http.MethodPost, "/v1/products/purchase-eligibility", `{"product_ids":["` + productID.Hex() + `"],"customer":{"birth_date":"04/05/2010"}}`},
+		{"set_product_badges", http.MethodPut, "/v1/products/" + productID.Hex() + "/badges", `{"badges":["eco","bestseller"]}`},
+		{"set_product_badges_automatic", http.MethodPut, "/v1/products/" + productID.Hex() + "/badges", `{"badges":["sale"]}`},
+		{"reorder_product_images", http.MethodPut, "/v1/products/" + productID.Hex() + "/images", `{"image_urls":["https://img.example.com/mug-side.png","https://img.example.com/mug.png"]}`},
+		{"reorder_product_images_mismatch", http.MethodPut, "/v1/products/" + productID.Hex() + "/images", `{"image_urls":["https://img.example.com/cup.png"]}`},
+		{"delete_product_image", http.MethodDelete, "/v1/products/" + productID.Hex() + "/images/1", ""},
+		{"delete_product_image_not_found", http.MethodDelete, "/v1/products/" + productID.Hex() + "/images/5", ""},
+		{"delete_product_image_invalid_position", http.MethodDelete, "/v1/products/" + productID.Hex() + "/images/first", ""},
+		{"feature_product", http.MethodPut, "/v1/products/" + productID.Hex() + "/featured", `{"until":"2024-03-19T12:00:00Z"}`},
+		{"unfeature_product", http.MethodDelete, "/v1/products/" + productID.Hex() + "/featured", ""},
+		{"create_download", http.MethodPost, "/v1/products/" + productID.Hex() + "/downloads", `{"order_id":"order-1","asset":"book.pdf"}`},
+		{"create_download_not_bought", http.MethodPost, "/v1/products/" + productID.Hex() + "/downloads", `{"order_id":"order-2","asset":"book.pdf"}`},
+		{"create_review", http.MethodPost, "/v1/products/" + productID.Hex() + "/reviews", `{"rating":4,"title":" Solid mug ","body":"Keeps coffee warm."}`},
+		{"create_review_invalid_rating", http.MethodPost, "/v1/products/" + productID.Hex() + "/reviews", `{"rating":6}`},
+		{"list_reviews", http.MethodGet, "/v1/products/" + productID.Hex() + "/reviews?page=0&page_size=2", ""},
+		{"create_supplier", http.MethodPost, "/v1/suppliers", `{"code":"ACME","name":"Acme","contact_name":"Ann","email":"ann@acme.example","phone":"+15551234567","lead_time_days":5}`},
+		{"list_suppliers", http.MethodGet, "/v1/suppliers?active=true", ""},
+		{"get_supplier", http.MethodGet, "/v1/suppliers/" + supplierID.Hex(), ""},
+		{"update_supplier", http.MethodPut, "/v1/suppliers/" + supplierID.Hex(), `{"code":"ACME","name":"Acme Ltd","active":false}`},
+		{"delete_supplier", http.MethodDelete, "/v1/suppliers/" + supplierID.Hex(), ""},
+		{"create_badge", http.MethodPost, "/v1/badges", `{"key":"eco","label":"Eco","color":"#2E7D32","priority":40}`},
+		{"create_badge_invalid_color", http.MethodPost, "/v1/badges", `{"key":"eco","label":"Eco","color":"green","priority":40}`},
+		{"list_badges", http.MethodGet, "/v1/badges", ""},
+		{"update_badge", http.MethodPut, "/v1/badges/eco", `{"label":"Eco-friendly","color":"#2e7d32","priority":45}`},
+		{"delete_badge_in_use", http.MethodDelete, "/v1/badges/eco", ""},
+		{"create_category", http.MethodPost, "/v1/categories", `{"key":"phones","name":"Phones","parent":"electronics"}`},
+		{"create_category_unknown_parent", http.MethodPost, "/v1/categories", `{"key":"phones","name":"Phones","parent":"gadgets"}`},
+		{"list_categories", http.MethodGet, "/v1/categories", ""},
+		{"get_category", http.MethodGet, "/v1/categories/phones", ""},
+		{"update_category", http.MethodPut, "/v1/categories/phones", `{"name":"Mobile Phones","parent":"electronics"}`},
+		{"delete_category_with_children", http.MethodDelete, "/v1/categories/electronics", ""},
+		{"list_products_with_subcategories", http.MethodGet, "/v1/products?category=electronics&include_subcategories=true", ""},
+		{"list_products_by_attributes", http.MethodGet, "/v1/products?attr.color=red&attr.material=stoneware", ""},
+		{"list_products_by_invalid_attribute", http.MethodGet, "/v1/products?attr.=red", ""},
+		{"list_draft_products", http.MethodGet, "/v1/products?status=draft", ""},
+		{"list_products_invalid_status", http.MethodGet, "/v1/products?status=hidden", ""},
+		{"create_promotion", http.MethodPost, "/v1/promotions", `{"name":" Spring Sale ","type":"percentage","value":20,"scope":{"categories":["kitchen"],"tags":["mug"]},"starts_at":"2024-03-01T00:00:00Z","ends_at":"2024-03-31T00:00:00Z"}`},
+		{"create_promotion_invalid_type", http.MethodPost, "/v1/promotions", `{"name":"Spring Sale","type":"bogo","value":1,"scope":{"tags":["mug"]}}`},
+		{"list_promotions", http.MethodGet, "/v1/promotions?page=1&page_size=10", ""},
+		{"get_promotion", http.MethodGet, "/v1/promotions/" + promotionID, ""},
+		{"get_promotion_not_found", http.MethodGet, "/v1/promotions/" + missing, ""},
+		{"update_promotion", http.MethodPut, "/v1/promotions/" + promotionID, `{"name":"Spring Sale","type":"fixed","value":2,"scope":{"product_ids":["` + productID.Hex() + `"]},"active":false}`},
+		{"delete_promotion", http.MethodDelete, "/v1/promotions/" + promotionID, ""},
+		{"create_stock_alert", http.MethodPost, "/v1/stock-alerts", `{"product_id":"` + productID.Hex() + `","threshold":5}`},
+		{"create_stock_alert_product_and_category", http.MethodPost, "/v1/stock-alerts", `{"product_id":"` + productID.Hex() + `","category":"kitchen","threshold":5}`},
+		{"list_stock_alerts", http.MethodGet, "/v1/stock-alerts?page=1&page_size=10", ""},
+		{"product_audit", http.MethodGet, "/v1/products/" + productID.Hex() + "/audit", ""},
+		{"product_audit_not_found", http.MethodGet, "/v1/products/65f1c0d2e4b0a1b2c3d4e5f9/audit", ""},
+		{"get_stock_alert", http.MethodGet, "/v1/stock-alerts/" + stockAlertID, ""},
+		{"get_stock_alert_not_found", http.MethodGet, "/v1/stock-alerts/" + missing, ""},
+		{"update_stock_alert", http.MethodPut, "/v1/stock-alerts/" + stockAlertID, `{"category":"kitchen","threshold":20}`},
+		{"delete_stock_alert", http.MethodDelete, "/v1/stock-alerts/" + stockAlertID, ""},
+		{"create_purchase_order", http.MethodPost, "/v1/purchase-orders", `{"supplier_id":"` + supplierID.Hex() + `","lines":[{"product_id":"` + productID.Hex() + `","supplier_sku":"S-MUG","quantity":10}],"notes":"Spring restock","expected_at":"2024-03-08T09:00:00Z"}`},
+		{"list_purchase_orders", http.MethodGet, "/v1/purchase-orders?status=open", ""},
+		{"get_purchase_order", http.MethodGet, "/v1/purchase-orders/" + orderID.Hex(), ""},
+		{"receive_purchase_order", http.MethodPost, "/v1/purchase-orders/" + orderID.Hex() + "/receipts", `{"reference":"DN-1","lines":[{"product_id":"` + productID.Hex() + `","quantity":4,"note":"1 damaged"}],"received_at":"2024-03-07T15:30:00Z"}`},
+		{"cancel_purchase_order", http.MethodPost, "/v1/purchase-orders/" + orderID.Hex() + "/cancel", `{"reason":"Supplier out of stock"}`},
+		{"stock_levels", http.MethodGet, "/v1/reports/inventory/levels?from=2024-03-01&to=2024-03-02&category=kitchen", ""},
+		{"stock_levels_invalid_day", http.MethodGet, "/v1/reports/inventory/levels?from=01/03/2024", ""},
+		{"list_tags", http.MethodGet, "/v1/tags?prefix=Kit", ""},
+		{"sell_through", http.MethodGet, "/v1/reports/inventory/sell-through?from=2024-03-01&to=2024-03-31", ""},
+	}
+
+	catalog := &stubCatalog{productID: productID, supplierID: supplierID, orderID: orderID}
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	products := rest.NewProductHandler(catalog, discard)
+	products.SetAuthenticator(stubAuthn)
+	products.RegisterRoutes(router)
+	rest.NewSupplierHandler(catalog, discard).RegisterRoutes(router)
+	rest.NewBadgeHandler(catalog, discard).RegisterRoutes(router)
+	rest.NewCategoryHandler(catalog, discard).RegisterRoutes(router)
+	rest.NewPurchaseOrderHandler(catalog, discard).RegisterRoutes(router)
+	rest.NewReportHandler(catalog, discard).RegisterRoutes(router)
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.RequireRole(stubAuthn, middleware.RoleAdmin))
+		rest.NewPromotionHandler(catalog, discard).RegisterRoutes(r)
+		rest.NewStockAlertHandler(catalog, discard).RegisterRoutes(r)
+		rest.NewAuditHandler(catalog, discard).RegisterRoutes(r)
+		rest.NewTagHandler(catalog, discard).RegisterRoutes(r)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(logging.RequestIDHeader, "golden-request")
+			req.Header.Set(rest.UserHeader, "user-1")
+			req.Header.Set("Authorization", "Bearer admin-token")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			golden.AssertResponse(t, tt.name, rec, "Content-Type", "Location")
+		})
+	}
+}
+
+// TestGoldenPromotionsForbidden compares the response to a caller without
+// the admin role managing promotions with its golden file
+func TestGoldenPromotionsForbidden(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.RequireRole(stubAuthn, middleware.RoleAdmin))
+		rest.NewPromotionHandler(&stubCatalog{}, discard).RegisterRoutes(r)
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/promotions/"+stubPromotionID.Hex(), nil)
+	req.Header.Set(logging.RequestIDHeader, "golden-request")
+	req.Header.Set("Authorization", "Bearer user-token")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	golden.AssertResponse(t, "delete_promotion_forbidden", rec, "Content-Type")
+}
+
+// TestDraftsListedForAdminsOnly checks that callers other than admins asking
+// for drafts get published products
+func TestDraftsListedForAdminsOnly(t *testing.T) {
+	handler := rest.NewProductHandler(&stubCatalog{productID: fixedID("65f1c0d2e4b0a1b2c3d4e5f1")}, discard)
+	handler.SetAuthenticator(stubAuthn)
+	router := chi.NewRouter()
+	handler.RegisterRoutes(router)
+
+	for _, tt := range []struct {
+		name, authorization string
+		draft               bool
+	}{
+		{"anonymous", "", false},
+		{"customer", "Bearer user-token", false},
+		{"invalid token", "Bearer nope", false},
+		{"admin", "Bearer admin-token", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/v1/products?status=draft", "/v1/products/export?format=ndjson&status=draft"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.authorization != "" {
+					req.Header.Set("Authorization", tt.authorization)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code, path)
+				assert.Equal(t, tt.draft, strings.Contains(rec.Body.String(), stubDraftID), path)
+			}
+		})
+	}
+}
+
+// TestGoldenCustomerGroup compares a product read for the customer group
+// passed by the API gateway with its golden file
+func TestGoldenCustomerGroup(t *testing.T) {
+	productID := fixedID("65f1c0d2e4b0a1b2c3d4e5f1")
+	router := chi.NewRouter()
+	rest.NewProductHandler(&stubCatalog{productID: productID}, discard).RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/products/"+productID.Hex(), nil)
+	req.Header.Set(rest.CustomerGroupHeader, "Wholesale")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	golden.AssertResponse(t, "get_product_for_group", rec, "Content-Type")
+}
+
+// TestGoldenConditionalGet compares a product read and its revalidation
+// with If-None-Match with their golden files
+func TestGoldenConditionalGet(t *testing.T) {
+	productID := fixedID("65f1c0d2e4b0a1b2c3d4e5f1")
+	router := chi.NewRouter()
+	rest.NewProductHandler(&stubCatalog{productID: productID}, discard).RegisterRoutes(router)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/products/"+productID.Hex(), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	golden.AssertResponse(t, "get_product_etag", first, "Cache-Control", "Content-Type", "ETag")
+
+	golden.AssertResponse(t, "get_product_not_modified", get(`"stale", W/`+etag), "Cache-Control", "Content-Type", "ETag")
+	assert.Equal(t, http.StatusOK, get(`"stale"`).Code)
+}
+
+// TestGoldenDeprecated compares the responses of a /v1 route replaced by
+// /v2 and of one that is not with their golden files
+func TestGoldenDeprecated(t *testing.T) {
+	productID := fixedID("65f1c0d2e4b0a1b2c3d4e5f1")
+	handler := rest.NewProductHandler(&stubCatalog{productID: productID}, discard)
+	handler.SetSunset(time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC))
+	router := chi.NewRouter()
+	handler.RegisterRoutes(router)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	golden.AssertResponse(t, "check_stock_deprecated", get("/v1/products/"+productID.Hex()+"/stock?quantity=2"), "Content-Type", "Deprecation", "Sunset", "Link")
+	related := get("/v1/products/" + productID.Hex() + "/related")
+	assert.Empty(t, related.Header().Get("Deprecation"))
+	assert.Empty(t, related.Header().Get("Sunset"))
+}
+
+// TestGoldenExport compares catalog exports with their golden files
+func TestGoldenExport(t *testing.T) {
+	router := chi.NewRouter()
+	rest.NewProductHandler(&stubCatalog{productID: fixedID("65f1c0d2e4b0a1b2c3d4e5f1")}, discard).RegisterRoutes(router)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"export_products_csv", "?category=kitchen"},
+		{"export_products_ndjson", "?format=ndjson"},
+		{"export_products_empty", "?format=csv&category=garden"},
+		{"export_products_invalid_format", "?format=xlsx"},
+		{"export_products_invalid_filter", "?shipping_class=Huge!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/products/export"+tt.query, nil))
+			golden.AssertResponse(t, tt.name, rec, "Content-Disposition", "Content-Type")
+		})
+	}
+}
+
+// TestGoldenFeeds compares the product feeds with their golden files
+func TestGoldenFeeds(t *testing.T) {
+	router := chi.NewRouter()
+	stub := &stubCatalog{productID: fixedID("65f1c0d2e4b0a1b2c3d4e5f1"), supplierID: fixedID("65f1c0d2e4b0a1b2c3d4e5f2")}
+	rest.NewFeedHandler(stub, feed.Options{
+		Title:      "Online Shop",
+		StoreURL:   "https://shop.example.com/",
+		ProductURL: "https://shop.example.com/products/{slug}",
+		Currency:   "USD",
+		Brand:      "Online Shop",
+	}, discard).RegisterRoutes(router)
+
+	for name, path := range map[string]string{
+		"feed_google_shopping": "/v1/feeds/google-shopping",
+		"feed_facebook":        "/v1/feeds/facebook",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			golden.AssertResponse(t, name, rec, "Content-Type")
+		})
+	}
+}
+
+// TestGoldenImageUpload compares multipart image uploads with their golden
+// files
+func TestGoldenImageUpload(t *testing.T) {
+	productID := fixedID("65f1c0d2e4b0a1b2c3d4e5f1")
+	router := chi.NewRouter()
+	rest.NewProductHandler(&stubCatalog{productID: productID}, discard).RegisterRoutes(router)
+	upload := func(field string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile(field, "mug.png")
+		require.NoError(t, err)
+		_, err = part.Write([]byte("\x89PNG\r\n\x1a\n"))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/products/"+productID.Hex()+"/images", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	golden.AssertResponse(t, "add_product_image", upload("image"), "Content-Type")
+	golden.AssertResponse(t, "add_product_image_missing", upload("photo"), "Content-Type")
+}
+
+var (
+	discard     = slog.New(slog.NewTextHandler(io.Discard, nil))
+	fixedTime   = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fixedUpdate = time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)
+)
+
+// stubAuthn knows an admin's token and a customer's
+var stubAuthn = middleware.StaticTokens(middleware.ParseTokens("admin-token=ops:admin, user-token=user-1"))
+
+// stubBadges are the badge definitions products are shown with
+var stubBadges = map[string]domain.Badge{
+	domain.BadgeSale: domain.DefaultBadges[0],
+	"eco":            {Key: "eco", Label: "Eco", Color: "#2e7d32", Priority: 40},
+}
+
+func fixedID(hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// stubCatalog serves fixed products, suppliers and purchase orders, and
+// echoes what the handlers decoded from the request so that the golden
+// files also cover request field names
+type stubCatalog struct {
+	productID  primitive.ObjectID
+	supplierID primitive.ObjectID
+	orderID    primitive.ObjectID
+}
+
+func (s *stubCatalog) product() *domain.Product {
+	return &domain.Product{
+		ID:          s.productID,
+		Name:        "Mug",
+		Description: "Stoneware",
+		Price:       8.5,
+		GroupPrices: []domain.GroupPrice{{Group: domain.CustomerGroupWholesale, Price: 6.8}},
+		ImageURLs:   []string{"https://img.example.com/mug.png"},
+		Category:    "kitchen",
+		Inventory:   domain.InventoryInfo{Quantity: 10, SKU: "MUG-1", InStock: true, Reserved: 2},
+		Tags:        []string{"mug", "kitchen"},
+		Attributes:  map[string]string{"color": "red", "material": "stoneware"},
+		Suppliers:   []domain.ProductSupplier{{SupplierID: s.supplierID, SupplierSKU: "S-MUG", LeadTimeDays: 5, Preferred: true}},
+		Active:      true,
+		Status:      domain.StatusPublished,
+		CreatedAt:   fixedTime,
+		UpdatedAt:   fixedUpdate,
+	}
+}
+
+func (s *stubCatalog) findProduct(id string) (*domain.Product, error) {
+	if id != s.productID.Hex() {
+		return nil, domain.ErrProductNotFound
+	}
+	return s.product(), nil
+}
+
+// stubTakenSKU is the SKU of another product
+const stubTakenSKU = "MUG-TAKEN"
+
+func (s *stubCatalog) CreateProduct(_ context.Context, product *domain.Product) (*domain.Product, error) {
+	if product.Inventory.SKU == stubTakenSKU {
+		return nil, fmt.Errorf("repository error: %w", domain.ErrDuplicateSKU)
+	}
+	product.ID = s.productID
+	product.Inventory.InStock = product.Inventory.Quantity > 0
+	product.Active = true
+	if product.Status == "" {
+		product.Status = domain.StatusPublished
+	}
+	product.CreatedAt, product.UpdatedAt = fixedTime, fixedTime
+	product.DisplayBadges = domain.ResolveBadges(product, stubBadges)
+	var err error
+	if product.GroupPrices, err = domain.NormalizeGroupPrices(product.GroupPrices); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	if product.Slug == "" {
+		product.Slug = domain.Slugify(product.Name)
+	}
+	return product, nil
+}
+
+func (s *stubCatalog) GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, []string, error) {
+	products, missing := []*domain.Product{}, []string{}
+	for _, id := range ids {
+		product, err := s.GetProduct(ctx, id)
+		if err != nil {
+			missing = append(missing, id)
+			continue
+		}
+		products = append(products, product)
+	}
+	return products, missing, nil
+}
+
+func (s *stubCatalog) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := s.findProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	if group := domain.CustomerGroupFrom(ctx); group != "" {
+		product.CustomerGroup, product.EffectivePrice = group, product.PriceFor(group)
+	}
+	return product, nil
+}
+
+func (s *stubCatalog) GetProductByBarcode(_ context.Context, barcode string) (*domain.Product, error) {
+	normalized, err := domain.NormalizeBarcode(barcode)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	if normalized != "4006381333931" {
+		return nil, domain.ErrProductNotFound
+	}
+	product := s.product()
+	product.Barcode = normalized
+	return product, nil
+}
+
+func (s *stubCatalog) GetProductBySlug(_ context.Context, slug string) (*domain.Product, error) {
+	normalized, err := domain.NormalizeSlug(slug)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	product := s.product()
+	product.Slug, product.PreviousSlugs = "stoneware-mug", []string{"mug"}
+	product.MetaTitle = "Stoneware Mug | Online Shop"
+	product.MetaDescription = "A 350 ml stoneware mug, dishwasher safe."
+	for _, known := range product.AllSlugs() {
+		if normalized == known {
+			return product, nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+func (s *stubCatalog) UpdateProduct(_ context.Context, product *domain.Product) (*domain.Product, error) {
+	if _, err := s.findProduct(product.ID.Hex()); err != nil {
+		return nil, err
+	}
+	product.CreatedAt, product.UpdatedAt = fixedTime, fixedUpdate
+	return product, nil
+}
+
+func (s *stubCatalog) PatchProduct(_ context.Context, id string, patch *domain.ProductPatch) (*domain.Product, error) {
+	product, err := s.findProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	patch.Name.Apply(&product.Name)
+	patch.Description.Apply(&product.Description)
+	if patch.CompareAtPrice.Null {
+		product.CompareAtPrice = nil
+	}
+	product.UpdatedAt = fixedUpdate
+	return product, nil
+}
+
+func (s *stubCatalog) DeleteProduct(_ context.Context, id string) error {
+	_, err := s.findProduct(id)
+	return err
+}
+
+func (s *stubCatalog) ListProducts(_ context.Context, params domain.ListProductsParams) ([]*domain.Product, int, error) {
+	if params.IncludeSubcategories {
+		phone := s.product()
+		phone.Name, phone.Category = "Phone", "phones"
+		return []*domain.Product{phone}, 1, nil
+	}
+	if err := domain.ValidateAttributeFilters(params.AttributeFilters); err != nil {
+		return nil, 0, apperrors.New(apperrors.Invalid, err.Error())
+	}
+	if len(params.AttributeFilters) > 0 {
+		return []*domain.Product{s.product()}, 1, nil
+	}
+	if params.Status != "" && !domain.ValidStatus(params.Status) {
+		return nil, 0, apperrors.New(apperrors.Invalid, "invalid status")
+	}
+	if params.Status == domain.StatusDraft {
+		draft := s.product()
+		draft.ID, draft.Status = fixedID(stubDraftID), domain.StatusDraft
+		draft.Description, draft.ImageURLs = "", []string{}
+		return []*domain.Product{draft}, 1, nil
+	}
+	second := s.product()
+	second.ID = fixedID("65f1c0d2e4b0a1b2c3d4e5f4")
+	second.Name = "Plate"
+	second.Inventory = domain.InventoryInfo{SKU: "PLATE-1"}
+	second.Suppliers = nil
+	second.Rating = &domain.Rating{Average: 4.33, Count: 3}
+	discounted := 6.8
+	second.DiscountedPrice = &discounted
+	second.Promotion = &domain.AppliedPromotion{ID: stubPromotionID.Hex(), Name: "Spring Sale", Type: domain.PromotionPercentage, Value: 20, EndsAt: &stubPromotionEnd}
+	return []*domain.Product{s.product(), second}, 5, nil
+}
+
+func (s *stubCatalog) TrendingProducts(_ context.Context, _ time.Duration, _ string, _ int) ([]domain.TrendingCategory, error) {
+	product := s.product()
+	product.Popularity = &domain.Popularity{Views: 120, Purchases: 9, Score: 210}
+	return []domain.TrendingCategory{{
+		Category: "kitchen",
+		Products: []domain.TrendingProduct{{Product: product, Views: 40, Purchases: 3, Score: 70}},
+	}}, nil
+}
+
+func (s *stubCatalog) ListTags(_ context.Context, prefix string) ([]domain.TagCount, error) {
+	tags := []domain.TagCount{}
+	for _, tag := range []domain.TagCount{{Tag: "gift", Products: 4}, {Tag: "kitchen", Products: 12}, {Tag: "Kitchen", Products: 1}} {
+		if strings.HasPrefix(strings.ToLower(tag.Tag), strings.ToLower(prefix)) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *stubCatalog) RelatedProducts(_ context.Context, id string, _ int) ([]*domain.Product, error) {
+	if _, err := s.findProduct(id); err != nil {
+		return nil, err
+	}
+	related := s.product()
+	related.ID = fixedID("65f1c0d2e4b0a1b2c3d4e5f4")
+	related.Name = "Saucer"
+	related.Tags = []string{"kitchen"}
+	return []*domain.Product{related}, nil
+}
+
+func (s *stubCatalog) AddProductImage(_ context.Context, productID string, image []byte) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.ImageURLs = append(product.ImageURLs, fmt.Sprintf("https://img.example.com/products/%s/%d-bytes.png", productID, len(image)))
+	return product, nil
+}
+
+func (s *stubCatalog) DeleteProductImage(_ context.Context, productID string, position int) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.ImageURLs = append(product.ImageURLs, "https://img.example.com/mug-side.png")
+	if position < 0 || position >= len(product.ImageURLs) {
+		return nil, apperrors.New(apperrors.NotFound, "image not found")
+	}
+	product.ImageURLs = slices.Delete(product.ImageURLs, position, position+1)
+	return product, nil
+}
+
+func (s *stubCatalog) ReorderProductImages(_ context.Context, productID string, urls []string) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.ImageURLs = append(product.ImageURLs, "https://img.example.com/mug-side.png")
+	if !slices.Equal(slices.Sorted(slices.Values(product.ImageURLs)), slices.Sorted(slices.Values(urls))) {
+		return nil, apperrors.New(apperrors.Invalid, "image_urls must list each of the product's images once")
+	}
+	product.ImageURLs = urls
+	return product, nil
+}
+
+func (s *stubCatalog) ExportProducts(_ context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error {
+	if params.ShippingClass != "" && !domain.ValidShippingClass(params.ShippingClass) {
+		return apperrors.New(apperrors.Invalid, "invalid shipping class")
+	}
+	product := s.product()
+	product.Slug, product.Barcode = "mug", "4006381333931"
+	if params.Status == domain.StatusDraft {
+		product.ID, product.Status = fixedID(stubDraftID), domain.StatusDraft
+	}
+	if params.Category != "" && params.Category != product.Category {
+		return nil
+	}
+	return fn(product)
+}
+
+func (s *stubCatalog) StreamProducts(_ context.Context, _ bool, fn func(*domain.Product) error) error {
+	product := s.product()
+	compareAt := 10.0
+	product.Slug, product.Barcode, product.CompareAtPrice = "mug", "4006381333931", &compareAt
+	product.Attributes["brand"] = "Stoneware & Co"
+	if err := fn(product); err != nil {
+		return err
+	}
+	soldOut := s.product()
+	soldOut.ID, soldOut.Name, soldOut.Description = s.supplierID, "Teapot", ""
+	soldOut.Inventory = domain.InventoryInfo{SKU: "POT-1"}
+	soldOut.ImageURLs = append(soldOut.ImageURLs, "https://img.example.com/teapot-side.png")
+	return fn(soldOut)
+}
+
+func (s *stubCatalog) CloneProduct(_ context.Context, productID string, opts domain.CloneOptions) (*domain.Product, error) {
+	if opts.SKU == "" {
+		return nil, apperrors.New(apperrors.Invalid, "validation error: the clone's SKU is required")
+	}
+	if opts.SKU == stubTakenSKU {
+		return nil, fmt.Errorf("repository error: %w", domain.ErrDuplicateSKU)
+	}
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	clone := product.Clone(opts)
+	clone.ID, clone.Status = s.orderID, domain.StatusPublished
+	clone.Slug = domain.Slugify(clone.Name)
+	clone.CreatedAt, clone.UpdatedAt = fixedUpdate, fixedUpdate
+	return clone, nil
+}
+
+// stubDraftID is the ID of a draft product without a description or
+// images
+const stubDraftID = "65f1c0d2e4b0a1b2c3d4e5f6"
+
+func (s *stubCatalog) PublishProduct(_ context.Context, productID string) (*domain.Product, error) {
+	if productID == stubDraftID {
+		return nil, apperrors.New(apperrors.Invalid, "product is missing description, image_urls").WithReason("INCOMPLETE_PRODUCT")
+	}
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.Status = domain.StatusPublished
+	return product, nil
+}
+
+func (s *stubCatalog) RetireProduct(_ context.Context, productID string) (*domain.Product, error) {
+	if productID == stubDraftID {
+		return nil, domain.ErrStatusTransition
+	}
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.Status = domain.StatusRetired
+	return product, nil
+}
+
+func (s *stubCatalog) ProductAudit(_ context.Context, productID string, _ pagination.Request) ([]*domain.AuditEntry, int, error) {
+	if productID != s.productID.Hex() {
+		return nil, 0, domain.ErrProductNotFound
+	}
+	return []*domain.AuditEntry{
+		{
+			ID:        fixedID("65f1c0d2e4b0a1b2c3d4e5a2"),
+			ProductID: productID,
+			Action:    domain.AuditUpdate,
+			Actor:     "ops",
+			Changes:   []domain.FieldChange{{Field: "price", From: 19.99, To: 24.99}},
+			At:        fixedUpdate,
+		},
+		{
+			ID:        fixedID("65f1c0d2e4b0a1b2c3d4e5a1"),
+			ProductID: productID,
+			Action:    domain.AuditCreate,
+			Actor:     "ops",
+			Changes: []domain.FieldChange{
+				{Field: "inventory.sku", To: "MUG-001"},
+				{Field: "name", To: "Ceramic Mug"},
+				{Field: "price", To: 19.99},
+			},
+			At: fixedTime,
+		},
+	}, 2, nil
+}
+
+func (s *stubCatalog) Suggest(_ context.Context, _ string, _ int) ([]domain.Suggestion, error) {
+	product := s.product()
+	return []domain.Suggestion{
+		{Type: domain.SuggestionCategory, Text: "Mugs", Category: "mugs"},
+		{Type: domain.SuggestionProduct, Text: product.Name, ProductID: product.ID.Hex(), Category: product.Category},
+	}, nil
+}
+
+func (s *stubCatalog) UpdateInventory(_ context.Context, productID string, quantityChange int, _, _ string) (*domain.InventoryInfo, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Inventory.Quantity+quantityChange < 0 {
+		return nil, domain.ErrInsufficientStock
+	}
+	product.Inventory.Quantity += quantityChange
+	return &product.Inventory, nil
+}
+
+func (s *stubCatalog) CheckStock(_ context.Context, productID string, quantity int) (bool, int, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return false, 0, err
+	}
+	return product.Inventory.Quantity >= quantity, product.Inventory.Quantity, nil
+}
+
+func (s *stubCatalog) ListInventoryOperations(_ context.Context, filter domain.InventoryOperationFilter, _ pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	if _, err := s.findProduct(filter.ProductID); err != nil {
+		return nil, 0, err
+	}
+	operations := []*domain.InventoryOperation{}
+	for _, operation := range []*domain.InventoryOperation{
+		{ProductID: filter.ProductID, QuantityChange: -2, OperationID: "op-1", OperationType: "purchase", Timestamp: fixedUpdate},
+		{ProductID: filter.ProductID, QuantityChange: 1, OperationID: "count-3", OperationType: "adjustment", Timestamp: fixedTime},
+		{ProductID: filter.ProductID, QuantityChange: 12, OperationID: "po-1", OperationType: "restock", Timestamp: fixedTime.Add(-time.Hour)},
+	} {
+		if len(filter.Types) == 0 || slices.Contains(filter.Types, operation.OperationType) {
+			operations = append(operations, operation)
+		}
+	}
+	return operations, len(operations), nil
+}
+
+func (s *stubCatalog) GetAvailability(_ context.Context, productID string) (*domain.Availability, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	return &domain.Availability{
+		ProductID:  productID,
+		Available:  8,
+		InStock:    true,
+		Warehouses: []domain.WarehouseStock{{WarehouseID: "w1", Available: 5}, {WarehouseID: "w2", Available: 3}},
+	}, nil
+}
+
+func (s *stubCatalog) GetPrice(_ context.Context, productID, currency string) (*domain.Price, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	asOf := fixedTime
+	return &domain.Price{
+		ProductID:    productID,
+		Amount:       7.82,
+		Currency:     currency,
+		BaseAmount:   8.5,
+		BaseCurrency: "USD",
+		ExchangeRate: "0.92",
+		RatesAsOf:    &asOf,
+	}, nil
+}
+
+func (s *stubCatalog) SetProductSuppliers(_ context.Context, productID string, links []domain.ProductSupplier) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	product.Suppliers = links
+	return product, nil
+}
+
+func (s *stubCatalog) SetPurchaseLimits(_ context.Context, productID string, limits domain.PurchaseLimits) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	product.PurchaseLimits = limits
+	return product, nil
+}
+
+func (s *stubCatalog) SetRestrictions(_ context.Context, productID string, restrictions domain.Restrictions) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Restrictions, err = restrictions.Normalize(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	return product, nil
+}
+
+func (s *stubCatalog) ValidatePurchaseEligibility(_ context.Context, productIDs []string, customer domain.CustomerProfile) ([]domain.Ineligibility, error) {
+	ineligible := []domain.Ineligibility{}
+	for _, id := range productIDs {
+		product, err := s.findProduct(id)
+		if err != nil {
+			ineligible = append(ineligible, domain.Ineligibility{ProductID: id, Reason: domain.ReasonProductUnavailable, Message: "the product is not available"})
+			continue
+		}
+		product.Restrictions = domain.Restrictions{MinAge: 18}
+		if refusal := product.Restrictions.Check(id, customer, fixedTime); refusal != nil {
+			ineligible = append(ineligible, *refusal)
+		}
+	}
+	return ineligible, nil
+}
+
+func (s *stubCatalog) SetProductBadges(_ context.Context, productID string, keys []string) (*domain.Product, error) {
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if domain.IsAutomaticBadge(key) {
+			return nil, apperrors.Newf(apperrors.Invalid, "badge %q is given automatically and cannot be assigned", key)
+		}
+	}
+	product.Badges = keys
+	product.DisplayBadges = []domain.ProductBadge{
+		{Key: "bestseller", Label: "Bestseller", Color: "#f9a825"},
+		{Key: "eco", Label: "Eco", Color: "#2e7d32"},
+	}
+	return product, nil
+}
+
+func (s *stubCatalog) SetFeatured(_ context.Context, id string, featured bool, until *time.Time) (*domain.Product, error) {
+	product, err := s.findProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	product.Featured, product.FeaturedUntil = featured, until
+	return product, nil
+}
+
+func (s *stubCatalog) GetDownloadURL(_ context.Context, productID, orderID, userID, asset string) (*domain.Download, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	if userID != "user-1" || orderID != "order-1" {
+		return nil, apperrors.New(apperrors.Forbidden, "product was not bought in this order")
+	}
+	left := 2
+	return &domain.Download{
+		ProductID:     productID,
+		OrderID:       orderID,
+		Asset:         asset,
+		URL:           "https://downloads.example.com/books/" + asset + "?X-Amz-Signature=abc",
+		ExpiresAt:     fixedUpdate,
+		DownloadsLeft: &left,
+		LicenseKey:    "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2",
+	}, nil
+}
+
+func (s *stubCatalog) supplier() *domain.Supplier {
+	return &domain.Supplier{
+		ID:           s.supplierID,
+		Code:         "ACME",
+		Name:         "Acme",
+		ContactName:  "Ann",
+		Email:        "ann@acme.example",
+		Phone:        "+15551234567",
+		LeadTimeDays: 5,
+		Active:       true,
+		CreatedAt:    fixedTime,
+		UpdatedAt:    fixedUpdate,
+	}
+}
+
+func (s *stubCatalog) CreateSupplier(_ context.Context, supplier *domain.Supplier) (*domain.Supplier, error) {
+	supplier.ID = s.supplierID
+	supplier.CreatedAt, supplier.UpdatedAt = fixedTime, fixedTime
+	return supplier, nil
+}
+
+func (s *stubCatalog) GetSupplier(_ context.Context, id string) (*domain.Supplier, error) {
+	if id != s.supplierID.Hex() {
+		return nil, domain.ErrSupplierNotFound
+	}
+	return s.supplier(), nil
+}
+
+func (s *stubCatalog) UpdateSupplier(_ context.Context, supplier *domain.Supplier) (*domain.Supplier, error) {
+	supplier.CreatedAt, supplier.UpdatedAt = fixedTime, fixedUpdate
+	return supplier, nil
+}
+
+func (s *stubCatalog) DeleteSupplier(_ context.Context, id string) error {
+	_, err := s.GetSupplier(context.Background(), id)
+	return err
+}
+
+func (s *stubCatalog) ListSuppliers(_ context.Context, _ bool) ([]*domain.Supplier, error) {
+	return []*domain.Supplier{s.supplier()}, nil
+}
+
+func (s *stubCatalog) CreateBadge(_ context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	if err := badge.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	badge.CreatedAt, badge.UpdatedAt = fixedTime, fixedTime
+	return badge, nil
+}
+
+func (s *stubCatalog) ListBadges(_ context.Context) ([]*domain.Badge, error) {
+	var badges []*domain.Badge
+	for _, badge := range domain.DefaultBadges {
+		badge := badge
+		badges = append(badges, &badge)
+	}
+	return append(badges, &domain.Badge{Key: "eco", Label: "Eco", Color: "#2e7d32", Priority: 40, CreatedAt: fixedTime, UpdatedAt: fixedUpdate}), nil
+}
+
+func (s *stubCatalog) UpdateBadge(_ context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	if err := badge.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	badge.CreatedAt, badge.UpdatedAt = fixedTime, fixedUpdate
+	return badge, nil
+}
+
+func (s *stubCatalog) DeleteBadge(_ context.Context, key string) error {
+	return fmt.Errorf("%w: assigned to 1 products", domain.ErrBadgeInUse)
+}
+
+func (s *stubCatalog) CreateReview(_ context.Context, review *domain.Review) (*domain.Review, error) {
+	if _, err := s.findProduct(review.ProductID.Hex()); err != nil {
+		return nil, err
+	}
+	if err := review.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	review.ID = fixedID("65f1c0d2e4b0a1b2c3d4e5f6")
+	review.CreatedAt = fixedUpdate
+	return review, nil
+}
+
+func (s *stubCatalog) ListReviews(_ context.Context, productID string, page pagination.Request) ([]*domain.Review, int, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, 0, err
+	}
+	return []*domain.Review{
+		{ID: fixedID("65f1c0d2e4b0a1b2c3d4e5f6"), ProductID: s.productID, UserID: "user-1", Rating: 4, Title: "Solid mug", CreatedAt: fixedUpdate},
+		{ID: fixedID("65f1c0d2e4b0a1b2c3d4e5f7"), ProductID: s.productID, UserID: "user-2", Rating: 2, Body: "Chipped on arrival.", CreatedAt: fixedTime},
+	}, 3, nil
+}
+
+var (
+	stubPromotionID  = fixedID("65f1c0d2e4b0a1b2c3d4e5f8")
+	stubPromotionEnd = time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func (s *stubCatalog) promotion() *domain.Promotion {
+	return &domain.Promotion{
+		ID:        stubPromotionID,
+		Name:      "Spring Sale",
+		Type:      domain.PromotionPercentage,
+		Value:     20,
+		Scope:     domain.PromotionScope{Categories: []string{"kitchen"}, Tags: []string{"mug"}},
+		StartsAt:  fixedTime,
+		EndsAt:    &stubPromotionEnd,
+		Active:    true,
+		CreatedAt: fixedTime,
+		UpdatedAt: fixedTime,
+	}
+}
+
+func (s *stubCatalog) CreatePromotion(_ context.Context, promotion *domain.Promotion) (*domain.Promotion, error) {
+	if err := promotion.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	promotion.ID = stubPromotionID
+	promotion.CreatedAt, promotion.UpdatedAt = fixedTime, fixedTime
+	return promotion, nil
+}
+
+func (s *stubCatalog) GetPromotion(_ context.Context, id string) (*domain.Promotion, error) {
+	if id != stubPromotionID.Hex() {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return s.promotion(), nil
+}
+
+func (s *stubCatalog) ListPromotions(_ context.Context, _ pagination.Request) ([]*domain.Promotion, int, error) {
+	return []*domain.Promotion{s.promotion()}, 1, nil
+}
+
+func (s *stubCatalog) UpdatePromotion(ctx context.Context, id string, promotion *domain.Promotion) (*domain.Promotion, error) {
+	existing, err := s.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if promotion.StartsAt.IsZero() {
+		promotion.StartsAt = existing.StartsAt
+	}
+	if err := promotion.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	promotion.ID = existing.ID
+	promotion.CreatedAt, promotion.UpdatedAt = existing.CreatedAt, fixedUpdate
+	return promotion, nil
+}
+
+func (s *stubCatalog) DeletePromotion(ctx context.Context, id string) error {
+	_, err := s.GetPromotion(ctx, id)
+	return err
+}
+
+var stubStockAlertID = fixedID("65f1c0d2e4b0a1b2c3d4e5f9")
+
+func (s *stubCatalog) stockAlert() *domain.StockAlert {
+	return &domain.StockAlert{
+		ID:        stubStockAlertID,
+		Category:  "kitchen",
+		Threshold: 10,
+		Alerted:   []string{s.productID.Hex()},
+		CreatedAt: fixedTime,
+		UpdatedAt: fixedTime,
+	}
+}
+
+func (s *stubCatalog) CreateStockAlert(_ context.Context, alert *domain.StockAlert) (*domain.StockAlert, error) {
+	if err := alert.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	alert.ID = stubStockAlertID
+	alert.Alerted = []string{}
+	alert.CreatedAt, alert.UpdatedAt = fixedTime, fixedTime
+	return alert, nil
+}
+
+func (s *stubCatalog) GetStockAlert(_ context.Context, id string) (*domain.StockAlert, error) {
+	if id != stubStockAlertID.Hex() {
+		return nil, domain.ErrStockAlertNotFound
+	}
+	return s.stockAlert(), nil
+}
+
+func (s *stubCatalog) ListStockAlerts(_ context.Context, _ pagination.Request) ([]*domain.StockAlert, int, error) {
+	return []*domain.StockAlert{s.stockAlert()}, 1, nil
+}
+
+func (s *stubCatalog) UpdateStockAlert(ctx context.Context, id string, alert *domain.StockAlert) (*domain.StockAlert, error) {
+	existing, err := s.GetStockAlert(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := alert.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	alert.ID = existing.ID
+	alert.Alerted = []string{}
+	alert.CreatedAt, alert.UpdatedAt = existing.CreatedAt, fixedUpdate
+	return alert, nil
+}
+
+func (s *stubCatalog) DeleteStockAlert(ctx context.Context, id string) error {
+	_, err := s.GetStockAlert(ctx, id)
+	return err
+}
+
+// stubCategories are Electronics and Phones below it
+var stubCategories = map[string]*domain.Category{
+	"electronics": {Key: "electronics", Name: "Electronics", Path: []string{}, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+	"phones":      {Key: "phones", Name: "Phones", Parent: "electronics", Path: []string{"electronics"}, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+}
+
+func (s *stubCatalog) CreateCategory(_ context.Context, category *domain.Category) (*domain.Category, error) {
+	if err := category.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	parent, ok := stubCategories[category.Parent]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.Invalid, "parent category %q does not exist", category.Parent)
+	}
+	category.SetParent(parent)
+	category.CreatedAt, category.UpdatedAt = fixedTime, fixedTime
+	return category, nil
+}
+
+func (s *stubCatalog) GetCategory(_ context.Context, key string) (*domain.Category, error) {
+	category, ok := stubCategories[key]
+	if !ok {
+		return nil, domain.ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+func (s *stubCatalog) ListCategories(_ context.Context) ([]*domain.Category, error) {
+	return []*domain.Category{stubCategories["electronics"], stubCategories["phones"]}, nil
+}
+
+func (s *stubCatalog) UpdateCategory(_ context.Context, category *domain.Category) (*domain.Category, error) {
+	if err := category.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "validation error")
+	}
+	category.SetParent(stubCategories[category.Parent])
+	category.CreatedAt, category.UpdatedAt = fixedTime, fixedUpdate
+	return category, nil
+}
+
+func (s *stubCatalog) DeleteCategory(_ context.Context, key string) error {
+	return fmt.Errorf("%w: 1 below it", domain.ErrCategoryHasChildren)
+}
+
+func (s *stubCatalog) purchaseOrder() *domain.PurchaseOrder {
+	expected := time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC)
+	return &domain.PurchaseOrder{
+		ID:         s.orderID,
+		SupplierID: s.supplierID,
+		Status:     domain.POStatusOpen,
+		Lines:      []domain.POLine{{ProductID: s.productID, SupplierSKU: "S-MUG", Expected: 10}},
+		Receipts:   []domain.POReceipt{},
+		Notes:      "Spring restock",
+		ExpectedAt: &expected,
+		Version:    1,
+		CreatedAt:  fixedTime,
+		UpdatedAt:  fixedTime,
+	}
+}
+
+func (s *stubCatalog) CreatePurchaseOrder(_ context.Context, po *domain.PurchaseOrder) (*domain.PurchaseOrder, error) {
+	po.ID = s.orderID
+	po.Status = domain.POStatusOpen
+	po.Receipts = []domain.POReceipt{}
+	po.Version = 1
+	po.CreatedAt, po.UpdatedAt = fixedTime, fixedTime
+	return po, nil
+}
+
+func (s *stubCatalog) GetPurchaseOrder(_ context.Context, id string) (*domain.PurchaseOrder, error) {
+	if id != s.orderID.Hex() {
+		return nil, domain.ErrPurchaseOrderNotFound
+	}
+	return s.purchaseOrder(), nil
+}
+
+func (s *stubCatalog) ListPurchaseOrders(_ context.Context, _ domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, error) {
+	return []*domain.PurchaseOrder{s.purchaseOrder()}, nil
+}
+
+func (s *stubCatalog) ReceivePurchaseOrder(_ context.Context, id string, receipt domain.POReceipt) (*domain.PurchaseOrder, error) {
+	po, err := s.GetPurchaseOrder(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	po.Status = domain.POStatusPartiallyReceived
+	po.Lines[0].Received = receipt.Lines[0].Quantity
+	po.Receipts = append(po.Receipts, receipt)
+	po.Version, po.UpdatedAt = 2, fixedUpdate
+	return po, nil
+}
+
+func (s *stubCatalog) CancelPurchaseOrder(_ context.Context, id, reason string) (*domain.PurchaseOrder, error) {
+	po, err := s.GetPurchaseOrder(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	po.Status = domain.POStatusCancelled
+	po.Notes = reason
+	po.Version, po.UpdatedAt = 2, fixedUpdate
+	return po, nil
+}
+
+func (s *stubCatalog) StockLevels(_ context.Context, params domain.StockLevelParams) ([]domain.StockLevel, error) {
+	var levels []domain.StockLevel
+	for day, quantity := params.From, 40; !day.After(params.To); day, quantity = day.AddDate(0, 0, 1), quantity-6 {
+		levels = append(levels, domain.StockLevel{Day: day, Products: 3, Quantity: quantity, Reserved: 2, Value: float64(quantity) * 8.5})
+	}
+	return levels, nil
+}
+
+func (s *stubCatalog) SellThrough(_ context.Context, from, to time.Time) (*domain.SellThroughReport, error) {
+	opening := from.AddDate(0, 0, -1)
+	return &domain.SellThroughReport{
+		From:       from,
+		To:         to,
+		OpeningDay: &opening,
+		Categories: []domain.CategorySellThrough{
+			{Category: "books", Opening: 40},
+			{Category: "kitchen", Opening: 30, Received: 20, Sold: 25, SellThrough: domain.SellThroughRate(30, 20, 25)},
+		},
+	}, nil
+}
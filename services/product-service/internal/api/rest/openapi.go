@@ -0,0 +1,67 @@
+package rest
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OpenAPISpec is the OpenAPI 3 document of the /v1/products API. The test
+// of this package fails when a route is not documented in it.
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
+
+// swaggerUIVersion is the swagger-ui-dist release the docs page loads
+const swaggerUIVersion = "5.17.14"
+
+// swaggerUIPage renders the spec with Swagger UI loaded from a CDN, so that
+// the service ships no UI assets
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Product Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/v1/openapi.yaml", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// DocsHandler serves the API documentation
+type DocsHandler struct {
+	swaggerUI bool
+}
+
+// NewDocsHandler creates a docs handler. The spec is always served; the
+// Swagger UI page only with swaggerUI, which is left off in production.
+func NewDocsHandler(swaggerUI bool) *DocsHandler {
+	return &DocsHandler{swaggerUI: swaggerUI}
+}
+
+// RegisterRoutes registers the docs routes with the given router
+func (h *DocsHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/v1/openapi.yaml", h.Spec)
+	if h.swaggerUI {
+		r.Get("/docs", h.SwaggerUI)
+	}
+}
+
+// Spec handles GET /v1/openapi.yaml
+func (h *DocsHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(OpenAPISpec)
+}
+
+// SwaggerUI handles GET /docs
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,90 @@
+package rest_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/api/rest"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIDocument is the part of the spec the tests check
+type openAPIDocument struct {
+	OpenAPI    string                          `yaml:"openapi"`
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components map[string]map[string]yaml.Node `yaml:"components"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	require.NoError(t, yaml.Unmarshal(rest.OpenAPISpec, &doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."), "an OpenAPI 3 document")
+	return doc
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	router := chi.NewRouter()
+	rest.NewProductHandler(nil, slog.Default()).RegisterRoutes(router)
+	rest.NewAuditHandler(nil, slog.Default()).RegisterRoutes(router)
+
+	routes := 0
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := strings.TrimSuffix(route, "/")
+		operations, ok := doc.Paths[path]
+		if assert.True(t, ok, "%s is not documented", path) {
+			assert.Contains(t, operations, strings.ToLower(method), "%s %s is not documented", method, path)
+		}
+		routes++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Greater(t, routes, 30)
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	refs := regexp.MustCompile(`\$ref: "#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(rest.OpenAPISpec), -1)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		assert.Contains(t, doc.Components[ref[1]], ref[2], "%s/%s is not defined", ref[1], ref[2])
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		swaggerUI bool
+		path      string
+		status    int
+		mediaType string
+	}{
+		{"spec", false, "/v1/openapi.yaml", http.StatusOK, "application/yaml"},
+		{"swagger UI", true, "/docs", http.StatusOK, "text/html; charset=utf-8"},
+		{"no swagger UI in production", false, "/docs", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			rest.NewDocsHandler(tt.swaggerUI).RegisterRoutes(router)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.mediaType != "" {
+				assert.Equal(t, tt.mediaType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
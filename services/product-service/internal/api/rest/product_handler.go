@@ -1,32 +1,86 @@
 package rest
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// UserHeader carries the ID of the signed-in user. It must be set by the
+// API gateway after authenticating the request and stripped from client
+// input.
+const UserHeader = "X-User-ID"
+
+// CustomerGroupHeader carries the customer group of the signed-in user,
+// such as wholesale, which products are priced for. Like UserHeader it
+// must be set by the API gateway; the group of an authenticated principal
+// takes precedence.
+const CustomerGroupHeader = "X-Customer-Group"
+
 // ProductService defines the interface for the product service
 type ProductService interface {
-	CreateProduct(product *domain.Product) (*domain.Product, error)
-	GetProduct(id string) (*domain.Product, error)
-	UpdateProduct(product *domain.Product) (*domain.Product, error)
-	DeleteProduct(id string) error
-	ListProducts(params domain.ListProductsParams) ([]*domain.Product, int, error)
-	UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error)
-	CheckStock(productID string, quantity int) (bool, int, error)
+	CreateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	CloneProduct(ctx context.Context, productID string, opts domain.CloneOptions) (*domain.Product, error)
+	PublishProduct(ctx context.Context, productID string) (*domain.Product, error)
+	RetireProduct(ctx context.Context, productID string) (*domain.Product, error)
+	GetProduct(ctx context.Context, id string) (*domain.Product, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*domain.Product, []string, error)
+	RelatedProducts(ctx context.Context, id string, limit int) ([]*domain.Product, error)
+	GetProductByBarcode(ctx context.Context, barcode string) (*domain.Product, error)
+	GetProductBySlug(ctx context.Context, slug string) (*domain.Product, error)
+	UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
+	PatchProduct(ctx context.Context, id string, patch *domain.ProductPatch) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	ListProducts(ctx context.Context, params domain.ListProductsParams) ([]*domain.Product, int, error)
+	ExportProducts(ctx context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error
+	UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error)
+	CheckStock(ctx context.Context, productID string, quantity int) (bool, int, error)
+	ListInventoryOperations(ctx context.Context, filter domain.InventoryOperationFilter, page pagination.Request) ([]*domain.InventoryOperation, int, error)
+	GetAvailability(ctx context.Context, productID string) (*domain.Availability, error)
+	GetPrice(ctx context.Context, productID, currency string) (*domain.Price, error)
+	SetProductSuppliers(ctx context.Context, productID string, links []domain.ProductSupplier) (*domain.Product, error)
+	SetPurchaseLimits(ctx context.Context, productID string, limits domain.PurchaseLimits) (*domain.Product, error)
+	SetRestrictions(ctx context.Context, productID string, restrictions domain.Restrictions) (*domain.Product, error)
+	SetProductBadges(ctx context.Context, productID string, keys []string) (*domain.Product, error)
+	AddProductImage(ctx context.Context, productID string, image []byte) (*domain.Product, error)
+	DeleteProductImage(ctx context.Context, productID string, position int) (*domain.Product, error)
+	ReorderProductImages(ctx context.Context, productID string, urls []string) (*domain.Product, error)
+	ValidatePurchaseEligibility(ctx context.Context, productIDs []string, customer domain.CustomerProfile) ([]domain.Ineligibility, error)
+	TrendingProducts(ctx context.Context, window time.Duration, category string, limit int) ([]domain.TrendingCategory, error)
+	Suggest(ctx context.Context, query string, limit int) ([]domain.Suggestion, error)
+	SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*domain.Product, error)
+	GetDownloadURL(ctx context.Context, productID, orderID, userID, asset string) (*domain.Download, error)
+	CreateReview(ctx context.Context, review *domain.Review) (*domain.Review, error)
+	ListReviews(ctx context.Context, productID string, page pagination.Request) ([]*domain.Review, int, error)
 }
 
+// v1DeprecatedAt is when the /v2 API replaced the /v1 product routes it
+// binds, announced in their Deprecation header
+var v1DeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
 // ProductHandler handles HTTP requests for products
 type ProductHandler struct {
 	service ProductService
 	logger  *slog.Logger
+	sunset  time.Time
+	authn   middleware.Authenticator
 }
 
 // NewProductHandler creates a new product handler
@@ -37,60 +91,160 @@ func NewProductHandler(service ProductService, logger *slog.Logger) *ProductHand
 	}
 }
 
-// RegisterRoutes registers the product routes with the given router
+// SetAuthenticator sets how the callers of reads are authenticated. Lists
+// only include draft and retired products for admins; without an
+// authenticator the service is open and they are listed for everyone.
+func (h *ProductHandler) SetAuthenticator(authn middleware.Authenticator) {
+	h.authn = authn
+}
+
+// SetSunset sets the date announced in the Sunset header of the deprecated
+// /v1 routes, after which they are removed. Without it only their
+// deprecation is announced.
+func (h *ProductHandler) SetSunset(sunset time.Time) {
+	h.sunset = sunset
+}
+
+// RegisterRoutes registers the product routes with the given router. The
+// routes that /v2 serves are marked deprecated; the others stay until they
+// are bound in the gateway.
 func (h *ProductHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/v1/products", func(r chi.Router) {
-		r.Post("/", h.CreateProduct)
-		r.Get("/", h.ListProducts)
-		r.Get("/{id}", h.GetProduct)
-		r.Put("/{id}", h.UpdateProduct)
-		r.Delete("/{id}", h.DeleteProduct)
+		r.Use(customerGroupContext)
+		deprecated := r.With(h.deprecated)
+		deprecated.Post("/", h.CreateProduct)
+		deprecated.Get("/", h.ListProducts)
+		r.Get("/trending", h.TrendingProducts)
+		r.Get("/export", h.ExportProducts)
+		r.Get("/suggest", h.Suggest)
+		deprecated.Post("/purchase-eligibility", h.ValidatePurchaseEligibility)
+		r.Get("/by-barcode/{code}", h.GetProductByBarcode)
+		r.Get("/slug/{slug}", h.GetProductBySlug)
+		deprecated.Get("/{id}", h.GetProduct)
+		r.Get("/{id}/related", h.RelatedProducts)
+		deprecated.Put("/{id}", h.UpdateProduct)
+		deprecated.Patch("/{id}", h.PatchProduct)
+		deprecated.Delete("/{id}", h.DeleteProduct)
+		r.Post("/{id}/clone", h.CloneProduct)
+		r.Post("/{id}/publish", h.PublishProduct)
+		r.Post("/{id}/retire", h.RetireProduct)
 
 		// Inventory management endpoints
-		r.Post("/{id}/inventory", h.UpdateInventory)
-		r.Get("/{id}/stock", h.CheckStock)
+		deprecated.Post("/{id}/inventory", h.UpdateInventory)
+		deprecated.Get("/{id}/inventory/operations", h.ListInventoryOperations)
+		deprecated.Get("/{id}/stock", h.CheckStock)
+		r.Get("/{id}/availability", h.GetAvailability)
+		r.Get("/{id}/price", h.GetPrice)
+		r.Put("/{id}/suppliers", h.SetProductSuppliers)
+		r.Put("/{id}/purchase-limits", h.SetPurchaseLimits)
+		r.Put("/{id}/restrictions", h.SetRestrictions)
+		r.Put("/{id}/badges", h.SetProductBadges)
+
+		// Image endpoints
+		r.Post("/{id}/images", h.AddProductImage)
+		r.Put("/{id}/images", h.ReorderProductImages)
+		r.Delete("/{id}/images/{position}", h.DeleteProductImage)
+
+		// Merchandising endpoints
+		deprecated.Put("/{id}/featured", h.FeatureProduct)
+		deprecated.Delete("/{id}/featured", h.UnfeatureProduct)
+
+		// Digital product endpoints
+		r.Post("/{id}/downloads", h.CreateDownload)
+
+		// Review endpoints
+		r.Post("/{id}/reviews", h.CreateReview)
+		r.Get("/{id}/reviews", h.ListReviews)
+	})
+}
+
+// deprecated announces that the route is replaced by the /v2 route of the
+// same path (RFC 9745 and RFC 8594)
+func (h *ProductHandler) deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(v1DeprecatedAt.Unix(), 10))
+		if !h.sunset.IsZero() {
+			w.Header().Set("Sunset", h.sunset.UTC().Format(http.TimeFormat))
+		}
+		successor := "/v2" + strings.TrimPrefix(r.URL.Path, "/v1")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
 	})
 }
 
 // CreateProduct handles POST /v1/products
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP CreateProduct called")
+	h.log(r).Info("HTTP CreateProduct called")
 
 	// Decode request body
 	var productRequest struct {
-		Name        string               `json:"name"`
-		Description string               `json:"description"`
-		Price       float64              `json:"price"`
-		ImageURLs   []string             `json:"image_urls"`
-		Category    string               `json:"category"`
-		Inventory   domain.InventoryInfo `json:"inventory"`
-		Tags        []string             `json:"tags"`
-		Attributes  map[string]string    `json:"attributes"`
+		Name          string                   `json:"name"`
+		Description   string                   `json:"description"`
+		Slug          string                   `json:"slug"`
+		MetaTitle     string                   `json:"meta_title"`
+		MetaDesc      string                   `json:"meta_description"`
+		Price         float64                  `json:"price"`
+		CompareAt     *float64                 `json:"compare_at_price"`
+		GroupPrices   []domain.GroupPrice      `json:"group_prices"`
+		ImageURLs     []string                 `json:"image_urls"`
+		Category      string                   `json:"category"`
+		Inventory     domain.InventoryInfo     `json:"inventory"`
+		Barcode       string                   `json:"barcode"`
+		Tags          []string                 `json:"tags"`
+		Badges        []string                 `json:"badges"`
+		Attributes    map[string]string        `json:"attributes"`
+		Suppliers     []domain.ProductSupplier `json:"suppliers"`
+		Type          string                   `json:"type"`
+		Digital       *domain.DigitalInfo      `json:"digital"`
+		Weight        *domain.Weight           `json:"weight"`
+		Dimensions    *domain.Dimensions       `json:"dimensions"`
+		ShippingClass string                   `json:"shipping_class"`
+		ReleaseDate   *time.Time               `json:"release_date"`
+		Preorder      *domain.PreorderInfo     `json:"preorder"`
+		Status        string                   `json:"status"`
+		domain.PurchaseLimits
+		domain.Restrictions
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&productRequest); err != nil {
-		h.logger.Error("Failed to decode request body", "error", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
 		return
 	}
 
 	// Create domain product
 	product := &domain.Product{
-		Name:        productRequest.Name,
-		Description: productRequest.Description,
-		Price:       productRequest.Price,
-		ImageURLs:   productRequest.ImageURLs,
-		Category:    productRequest.Category,
-		Inventory:   productRequest.Inventory,
-		Tags:        productRequest.Tags,
-		Attributes:  productRequest.Attributes,
+		Name:            productRequest.Name,
+		Description:     productRequest.Description,
+		Slug:            productRequest.Slug,
+		MetaTitle:       productRequest.MetaTitle,
+		MetaDescription: productRequest.MetaDesc,
+		Price:           productRequest.Price,
+		CompareAtPrice:  productRequest.CompareAt,
+		GroupPrices:     productRequest.GroupPrices,
+		ImageURLs:       productRequest.ImageURLs,
+		Category:        productRequest.Category,
+		Inventory:       productRequest.Inventory,
+		Barcode:         productRequest.Barcode,
+		Tags:            productRequest.Tags,
+		Badges:          productRequest.Badges,
+		Attributes:      productRequest.Attributes,
+		Suppliers:       productRequest.Suppliers,
+		PurchaseLimits:  productRequest.PurchaseLimits,
+		Restrictions:    productRequest.Restrictions,
+		Type:            productRequest.Type,
+		Digital:         productRequest.Digital,
+		Weight:          productRequest.Weight,
+		Dimensions:      productRequest.Dimensions,
+		ShippingClass:   productRequest.ShippingClass,
+		ReleaseDate:     productRequest.ReleaseDate,
+		Preorder:        productRequest.Preorder,
+		Status:          productRequest.Status,
 	}
 
 	// Call service
-	createdProduct, err := h.service.CreateProduct(product)
+	createdProduct, err := h.service.CreateProduct(r.Context(), product)
 	if err != nil {
-		h.logger.Error("Failed to create product", "error", err)
-		http.Error(w, "Failed to create product: "+err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, "Failed to create product", err)
 		return
 	}
 
@@ -98,76 +252,163 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(createdProduct); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
 	}
 }
 
 // GetProduct handles GET /v1/products/{id}
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	h.logger.Info("HTTP GetProduct called", "id", id)
+	h.log(r).Info("HTTP GetProduct called", "id", id)
 
 	// Call service
-	product, err := h.service.GetProduct(id)
+	product, err := h.service.GetProduct(r.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get product", "id", id, "error", err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to get product: "+err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, "Failed to get product", err)
+		return
+	}
+
+	// Return response, or 304 Not Modified when the client has it cached
+	if err := writeConditionalJSON(w, r, product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// RelatedProducts handles GET /v1/products/{id}/related
+func (h *ProductHandler) RelatedProducts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP RelatedProducts called", "id", id)
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			h.writeError(w, r, "Invalid limit", apperrors.New(apperrors.Invalid, "limit must be an integer"))
+			return
 		}
+	}
+
+	products, err := h.service.RelatedProducts(r.Context(), id, limit)
+	if err != nil {
+		h.writeError(w, r, "Failed to get related products", err)
+		return
+	}
+
+	response := struct {
+		Products []*domain.Product `json:"products"`
+	}{
+		Products: products,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// GetProductByBarcode handles GET /v1/products/by-barcode/{code}
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	h.log(r).Info("HTTP GetProductByBarcode called", "barcode", code)
+
+	product, err := h.service.GetProductByBarcode(r.Context(), code)
+	if err != nil {
+		h.writeError(w, r, "Failed to get product by barcode", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// GetProductBySlug handles GET /v1/products/slug/{slug}. Previous slugs,
+// and slugs in another case, are redirected to the current one.
+func (h *ProductHandler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	h.log(r).Info("HTTP GetProductBySlug called", "slug", slug)
+
+	product, err := h.service.GetProductBySlug(r.Context(), slug)
+	if err != nil {
+		h.writeError(w, r, "Failed to get product by slug", err)
+		return
+	}
+	if product.Slug != slug {
+		http.Redirect(w, r, "/v1/products/slug/"+product.Slug, http.StatusMovedPermanently)
 		return
 	}
 
-	// Return response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(product); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
 	}
 }
 
 // UpdateProduct handles PUT /v1/products/{id}
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	h.logger.Info("HTTP UpdateProduct called", "id", id)
+	h.log(r).Info("HTTP UpdateProduct called", "id", id)
 
 	// Parse ID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		h.logger.Error("Invalid product ID format", "id", id)
-		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		h.writeError(w, r, "Invalid product ID format", apperrors.New(apperrors.Invalid, "invalid product ID"))
 		return
 	}
 
 	// Decode request body
 	var productRequest struct {
-		Name        string                `json:"name"`
-		Description string                `json:"description"`
-		Price       float64               `json:"price"`
-		ImageURLs   []string              `json:"image_urls"`
-		Category    string                `json:"category"`
-		Inventory   *domain.InventoryInfo `json:"inventory"`
-		Tags        []string              `json:"tags"`
-		Attributes  map[string]string     `json:"attributes"`
-		Active      *bool                 `json:"active"`
+		Name          string                `json:"name"`
+		Description   string                `json:"description"`
+		Slug          string                `json:"slug"`
+		MetaTitle     string                `json:"meta_title"`
+		MetaDesc      string                `json:"meta_description"`
+		Price         float64               `json:"price"`
+		CompareAt     *float64              `json:"compare_at_price"`
+		GroupPrices   []domain.GroupPrice   `json:"group_prices"`
+		ImageURLs     []string              `json:"image_urls"`
+		Category      string                `json:"category"`
+		Inventory     *domain.InventoryInfo `json:"inventory"`
+		Barcode       string                `json:"barcode"`
+		Tags          []string              `json:"tags"`
+		Attributes    map[string]string     `json:"attributes"`
+		Active        *bool                 `json:"active"`
+		Digital       *domain.DigitalInfo   `json:"digital"`
+		Weight        *domain.Weight        `json:"weight"`
+		Dimensions    *domain.Dimensions    `json:"dimensions"`
+		ShippingClass string                `json:"shipping_class"`
+		ReleaseDate   *time.Time            `json:"release_date"`
+		Preorder      *domain.PreorderInfo  `json:"preorder"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&productRequest); err != nil {
-		h.logger.Error("Failed to decode request body", "error", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
 		return
 	}
 
 	// Create domain product
 	product := &domain.Product{
-		ID:          objectID,
-		Name:        productRequest.Name,
-		Description: productRequest.Description,
-		Price:       productRequest.Price,
-		ImageURLs:   productRequest.ImageURLs,
-		Category:    productRequest.Category,
-		Tags:        productRequest.Tags,
-		Attributes:  productRequest.Attributes,
+		ID:              objectID,
+		Name:            productRequest.Name,
+		Description:     productRequest.Description,
+		Slug:            productRequest.Slug,
+		MetaTitle:       productRequest.MetaTitle,
+		MetaDescription: productRequest.MetaDesc,
+		Price:           productRequest.Price,
+		CompareAtPrice:  productRequest.CompareAt,
+		GroupPrices:     productRequest.GroupPrices,
+		ImageURLs:       productRequest.ImageURLs,
+		Category:        productRequest.Category,
+		Barcode:         productRequest.Barcode,
+		Tags:            productRequest.Tags,
+		Attributes:      productRequest.Attributes,
+		Digital:         productRequest.Digital,
+		Weight:          productRequest.Weight,
+		Dimensions:      productRequest.Dimensions,
+		ShippingClass:   productRequest.ShippingClass,
+		ReleaseDate:     productRequest.ReleaseDate,
+		Preorder:        productRequest.Preorder,
 	}
 
 	// Set active status if provided
@@ -181,38 +422,72 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call service
-	updatedProduct, err := h.service.UpdateProduct(product)
+	updatedProduct, err := h.service.UpdateProduct(r.Context(), product)
 	if err != nil {
-		h.logger.Error("Failed to update product", "id", id, "error", err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to update product: "+err.Error(), http.StatusInternalServerError)
-		}
+		h.writeError(w, r, "Failed to update product", err)
 		return
 	}
 
 	// Return response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(updatedProduct); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// MergePatchContentType is the media type of JSON Merge Patch (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// PatchProduct handles PATCH /v1/products/{id}. The body is a JSON Merge
+// Patch: fields left out are kept and fields set to null are cleared.
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP PatchProduct called", "id", id)
+
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		h.writeError(w, r, "Invalid product ID format", apperrors.New(apperrors.Invalid, "invalid product ID"))
+		return
+	}
+	mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	switch strings.TrimSpace(mediaType) {
+	case MergePatchContentType, "application/json":
+	default:
+		h.writeError(w, r, "Unsupported patch format", apperrors.Newf(apperrors.Invalid, "Content-Type must be %s", MergePatchContentType))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.writeError(w, r, "Failed to read request body", invalidBody(err))
+		return
+	}
+	patch, err := domain.ParseProductPatch(body)
+	if err != nil {
+		h.writeError(w, r, "Failed to decode merge patch", err)
+		return
+	}
+
+	product, err := h.service.PatchProduct(r.Context(), id, patch)
+	if err != nil {
+		h.writeError(w, r, "Failed to patch product", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
 	}
 }
 
 // DeleteProduct handles DELETE /v1/products/{id}
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	h.logger.Info("HTTP DeleteProduct called", "id", id)
+	h.log(r).Info("HTTP DeleteProduct called", "id", id)
 
 	// Call service
-	err := h.service.DeleteProduct(id)
+	err := h.service.DeleteProduct(r.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to delete product", "id", id, "error", err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to delete product: "+err.Error(), http.StatusInternalServerError)
-		}
+		h.writeError(w, r, "Failed to delete product", err)
 		return
 	}
 
@@ -220,118 +495,316 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// ListProducts handles GET /v1/products
+// CloneProduct handles POST /v1/products/{id}/clone
+func (h *ProductHandler) CloneProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP CloneProduct called", "id", id)
+
+	var opts domain.CloneOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	clone, err := h.service.CloneProduct(r.Context(), id, opts)
+	if err != nil {
+		h.writeError(w, r, "Failed to clone product", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(clone); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// PublishProduct handles POST /v1/products/{id}/publish
+func (h *ProductHandler) PublishProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP PublishProduct called", "id", id)
+
+	product, err := h.service.PublishProduct(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to publish product", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// RetireProduct handles POST /v1/products/{id}/retire
+func (h *ProductHandler) RetireProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP RetireProduct called", "id", id)
+
+	product, err := h.service.RetireProduct(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to retire product", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// getProductsByIDs handles GET /v1/products?ids=a,b,c, which returns the
+// products in the order of ids, ignoring the other list parameters, and
+// the IDs that were not found
+func (h *ProductHandler) getProductsByIDs(w http.ResponseWriter, r *http.Request, ids []string) {
+	h.log(r).Info("HTTP GetProductsByIDs called", "count", len(ids))
+
+	products, missing, err := h.service.GetProductsByIDs(r.Context(), ids)
+	if err != nil {
+		h.writeError(w, r, "Failed to get products", err)
+		return
+	}
+
+	response := struct {
+		Products []*domain.Product `json:"products"`
+		NotFound []string          `json:"not_found"`
+	}{
+		Products: products,
+		NotFound: missing,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// ListProducts handles GET /v1/products, or GET /v1/products?ids=a,b,c
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP ListProducts called")
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		h.getProductsByIDs(w, r, strings.Split(ids, ","))
+		return
+	}
+	h.log(r).Info("HTTP ListProducts called")
 
 	// Parse query parameters
+	page, err := pagination.Parse(r.URL.Query(), domain.ProductPagination)
+	if err != nil {
+		h.writeError(w, r, "Invalid pagination parameters", err)
+		return
+	}
 	params := domain.ListProductsParams{
-		Page:     parseInt(r.URL.Query().Get("page"), 0),
-		PageSize: parseInt(r.URL.Query().Get("page_size"), 20),
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Offset:   page.Offset(),
+	}
+
+	h.listFilters(r, &params)
+
+	// Call service
+	products, total, err := h.service.ListProducts(r.Context(), params)
+	if err != nil {
+		h.writeError(w, r, "Failed to list products", err)
+		return
+	}
+
+	// Prepare response
+	response := pagination.NewList("products", products, total, page)
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// listFilters parses the filters of a product list from the request's
+// query. Other callers than admins only see published products, whatever
+// status they ask for.
+func (h *ProductHandler) listFilters(r *http.Request, params *domain.ListProductsParams) {
+	listFilters(r.URL.Query(), params)
+	if params.Status != "" && params.Status != domain.StatusPublished && !h.isAdmin(r) {
+		params.Status = domain.StatusPublished
+	}
+}
+
+// isAdmin reports whether the caller of r is an admin, which every caller
+// is when the service runs without authentication
+func (h *ProductHandler) isAdmin(r *http.Request) bool {
+	if h.authn == nil {
+		return true
 	}
+	principal, ok := middleware.Authenticated(r, h.authn)
+	return ok && principal.HasRole(middleware.RoleAdmin)
+}
 
-	// Parse optional filters
-	if category := r.URL.Query().Get("category"); category != "" {
+// listFilters parses the filters of a product list from its query
+func listFilters(query url.Values, params *domain.ListProductsParams) {
+	if category := query.Get("category"); category != "" {
 		params.Category = category
 	}
 
-	if tags := r.URL.Query().Get("tags"); tags != "" {
+	if subcategories := query.Get("include_subcategories"); subcategories == "true" {
+		params.IncludeSubcategories = true
+	}
+
+	if tags := query.Get("tags"); tags != "" {
 		params.Tags = strings.Split(tags, ",")
 	}
 
-	if minPrice := r.URL.Query().Get("min_price"); minPrice != "" {
+	if minPrice := query.Get("min_price"); minPrice != "" {
 		if p, err := strconv.ParseFloat(minPrice, 64); err == nil {
 			params.MinPrice = p
 		}
 	}
 
-	if maxPrice := r.URL.Query().Get("max_price"); maxPrice != "" {
+	if maxPrice := query.Get("max_price"); maxPrice != "" {
 		if p, err := strconv.ParseFloat(maxPrice, 64); err == nil {
 			params.MaxPrice = p
 		}
 	}
 
-	if inStock := r.URL.Query().Get("in_stock"); inStock == "true" {
+	if inStock := query.Get("in_stock"); inStock == "true" {
 		params.InStockOnly = true
 	}
 
-	if sortBy := r.URL.Query().Get("sort_by"); sortBy != "" {
+	if sortBy := query.Get("sort_by"); sortBy != "" {
 		params.SortBy = sortBy
 	}
 
-	if sortDesc := r.URL.Query().Get("sort_desc"); sortDesc == "true" {
+	if sortDesc := query.Get("sort_desc"); sortDesc == "true" {
 		params.SortDesc = true
 	}
 
-	if search := r.URL.Query().Get("search"); search != "" {
+	if search := query.Get("search"); search != "" {
 		params.SearchTerm = search
 	}
 
-	// Call service
-	products, total, err := h.service.ListProducts(params)
+	if supplierID := query.Get("supplier_id"); supplierID != "" {
+		params.SupplierID = supplierID
+	}
+
+	if featured := query.Get("featured"); featured == "true" {
+		params.FeaturedOnly = true
+	}
+
+	if isNew := query.Get("new"); isNew == "true" {
+		params.NewOnly = true
+	}
+
+	if shippingClass := query.Get("shipping_class"); shippingClass != "" {
+		params.ShippingClass = shippingClass
+	}
+
+	if badge := query.Get("badge"); badge != "" {
+		params.Badge = badge
+	}
+
+	if status := query.Get("status"); status != "" {
+		params.Status = status
+	}
+
+	// Attribute filters are given as attr.<name>=<value>
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "attr."); ok {
+			if params.AttributeFilters == nil {
+				params.AttributeFilters = make(map[string]string)
+			}
+			params.AttributeFilters[name] = values[0]
+		}
+	}
+}
+
+// TrendingProducts handles GET /v1/products/trending?window=7d
+func (h *ProductHandler) TrendingProducts(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP TrendingProducts called")
+
+	query := r.URL.Query()
+	window, err := parseWindow(query.Get("window"))
 	if err != nil {
-		h.logger.Error("Failed to list products", "error", err)
-		http.Error(w, "Failed to list products: "+err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, "Invalid window", err)
 		return
 	}
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			h.writeError(w, r, "Invalid limit", apperrors.New(apperrors.Invalid, "limit must be an integer"))
+			return
+		}
+	}
 
-	// Calculate pagination metadata
-	totalPages := (total + params.PageSize - 1) / params.PageSize
-	if totalPages == 0 {
-		totalPages = 1
+	trending, err := h.service.TrendingProducts(r.Context(), window, query.Get("category"), limit)
+	if err != nil {
+		h.writeError(w, r, "Failed to get trending products", err)
+		return
 	}
 
-	// Prepare response
 	response := struct {
-		Products   []*domain.Product `json:"products"`
-		Total      int               `json:"total"`
-		Page       int               `json:"page"`
-		PageSize   int               `json:"page_size"`
-		TotalPages int               `json:"total_pages"`
+		Categories []domain.TrendingCategory `json:"categories"`
 	}{
-		Products:   products,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
+		Categories: trending,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// Suggest handles GET /v1/products/suggest
+func (h *ProductHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	h.log(r).Info("HTTP Suggest called")
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			h.writeError(w, r, "Invalid limit", apperrors.New(apperrors.Invalid, "limit must be an integer"))
+			return
+		}
+	}
+
+	suggestions, err := h.service.Suggest(r.Context(), query.Get("q"), limit)
+	if err != nil {
+		h.writeError(w, r, "Failed to get suggestions", err)
+		return
+	}
+
+	response := struct {
+		Suggestions []domain.Suggestion `json:"suggestions"`
+	}{
+		Suggestions: suggestions,
 	}
 
-	// Return response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
 	}
 }
 
 // UpdateInventory handles POST /v1/products/{id}/inventory
 func (h *ProductHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	h.logger.Info("HTTP UpdateInventory called", "id", id)
+	h.log(r).Info("HTTP UpdateInventory called", "id", id)
 
 	// Decode request body
+	// int32 like the gRPC API, so that both reject the same out of range
+	// changes
 	var request struct {
-		QuantityChange int    `json:"quantity_change"`
+		QuantityChange int32  `json:"quantity_change"`
 		OperationID    string `json:"operation_id"`
 		OperationType  string `json:"operation_type"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		h.logger.Error("Failed to decode request body", "error", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
 		return
 	}
 
 	// Call service
-	updatedInventory, err := h.service.UpdateInventory(id, request.QuantityChange, request.OperationID, request.OperationType)
-	if err != nil {
-		h.logger.Error("Failed to update inventory", "id", id, "error", err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else if strings.Contains(err.Error(), "insufficient") {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, "Failed to update inventory: "+err.Error(), http.StatusInternalServerError)
-		}
+	updatedInventory, err := h.service.UpdateInventory(r.Context(), id, int(request.QuantityChange), request.OperationID, request.OperationType)
+	if err != nil {
+		h.writeError(w, r, "Failed to update inventory", err)
 		return
 	}
 
@@ -348,27 +821,71 @@ func (h *ProductHandler) UpdateInventory(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// ListInventoryOperations handles GET /v1/products/{id}/inventory/operations,
+// filtered by operation_type (comma-separated) and by from and to (RFC 3339)
+func (h *ProductHandler) ListInventoryOperations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP ListInventoryOperations called", "id", id)
+
+	query := r.URL.Query()
+	page, err := pagination.Parse(query, domain.InventoryOperationPagination)
+	if err != nil {
+		h.writeError(w, r, "Invalid pagination parameters", err)
+		return
+	}
+	filter := domain.InventoryOperationFilter{ProductID: id}
+	if types := query.Get("operation_type"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+	if filter.From, err = parseTimeParam(query.Get("from"), "from"); err != nil {
+		h.writeError(w, r, "Invalid time range", err)
+		return
+	}
+	if filter.To, err = parseTimeParam(query.Get("to"), "to"); err != nil {
+		h.writeError(w, r, "Invalid time range", err)
+		return
+	}
+
+	operations, total, err := h.service.ListInventoryOperations(r.Context(), filter, page)
+	if err != nil {
+		h.writeError(w, r, "Failed to list inventory operations", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pagination.NewList("operations", operations, total, page)); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
 	}
 }
 
+// parseTimeParam parses an RFC 3339 query parameter, with empty as no time
+func parseTimeParam(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, apperrors.Newf(apperrors.Invalid, "%s must be a time such as 2024-03-01T00:00:00Z", name)
+	}
+	return &t, nil
+}
+
 // CheckStock handles GET /v1/products/{id}/stock
 func (h *ProductHandler) CheckStock(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	h.logger.Info("HTTP CheckStock called", "id", id)
+	h.log(r).Info("HTTP CheckStock called", "id", id)
 
 	// Parse quantity parameter
 	quantity := parseInt(r.URL.Query().Get("quantity"), 1)
 
 	// Call service
-	available, currentStock, err := h.service.CheckStock(id, quantity)
+	available, currentStock, err := h.service.CheckStock(r.Context(), id, quantity)
 	if err != nil {
-		h.logger.Error("Failed to check stock", "id", id, "error", err)
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "Product not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to check stock: "+err.Error(), http.StatusInternalServerError)
-		}
+		h.writeError(w, r, "Failed to check stock", err)
 		return
 	}
 
@@ -385,8 +902,456 @@ func (h *ProductHandler) CheckStock(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode response", "error", err)
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// GetAvailability handles GET /v1/products/{id}/availability
+func (h *ProductHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP GetAvailability called", "id", id)
+
+	availability, err := h.service.GetAvailability(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to get availability", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(availability); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// GetPrice handles GET /v1/products/{id}/price?currency=EUR
+func (h *ProductHandler) GetPrice(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	currency := r.URL.Query().Get("currency")
+	h.log(r).Info("HTTP GetPrice called", "id", id, "currency", currency)
+
+	price, err := h.service.GetPrice(r.Context(), id, currency)
+	if err != nil {
+		h.writeError(w, r, "Failed to get price", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(price); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// SetProductSuppliers handles PUT /v1/products/{id}/suppliers
+func (h *ProductHandler) SetProductSuppliers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP SetProductSuppliers called", "id", id)
+
+	var request struct {
+		Suppliers []domain.ProductSupplier `json:"suppliers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.SetProductSuppliers(r.Context(), id, request.Suppliers)
+	if err != nil {
+		h.writeError(w, r, "Failed to set product suppliers", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// SetPurchaseLimits handles PUT /v1/products/{id}/purchase-limits
+func (h *ProductHandler) SetPurchaseLimits(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP SetPurchaseLimits called", "id", id)
+
+	var limits domain.PurchaseLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.SetPurchaseLimits(r.Context(), id, limits)
+	if err != nil {
+		h.writeError(w, r, "Failed to set purchase limits", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// SetRestrictions handles PUT /v1/products/{id}/restrictions
+func (h *ProductHandler) SetRestrictions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP SetRestrictions called", "id", id)
+
+	var restrictions domain.Restrictions
+	if err := json.NewDecoder(r.Body).Decode(&restrictions); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.SetRestrictions(r.Context(), id, restrictions)
+	if err != nil {
+		h.writeError(w, r, "Failed to set restrictions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// SetProductBadges handles PUT /v1/products/{id}/badges
+func (h *ProductHandler) SetProductBadges(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP SetProductBadges called", "id", id)
+
+	var request struct {
+		Badges []string `json:"badges"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.SetProductBadges(r.Context(), id, request.Badges)
+	if err != nil {
+		h.writeError(w, r, "Failed to set product badges", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// AddProductImage handles POST /v1/products/{id}/images. The image is the
+// "image" field of a multipart form.
+func (h *ProductHandler) AddProductImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP AddProductImage called", "id", id)
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			err = middleware.ErrBodyTooLarge
+		case errors.Is(err, http.ErrMissingFile):
+			err = apperrors.New(apperrors.Invalid, `multipart field "image" is required`)
+		default:
+			err = invalidBody(err)
+		}
+		h.writeError(w, r, "Failed to read image upload", err)
+		return
+	}
+	defer file.Close()
+	image, err := io.ReadAll(file)
+	if err != nil {
+		h.writeError(w, r, "Failed to read image upload", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.AddProductImage(r.Context(), id, image)
+	if err != nil {
+		h.writeError(w, r, "Failed to add product image", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// ReorderProductImages handles PUT /v1/products/{id}/images
+func (h *ProductHandler) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP ReorderProductImages called", "id", id)
+
+	var request struct {
+		ImageURLs []string `json:"image_urls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	product, err := h.service.ReorderProductImages(r.Context(), id, request.ImageURLs)
+	if err != nil {
+		h.writeError(w, r, "Failed to reorder product images", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// DeleteProductImage handles DELETE /v1/products/{id}/images/{position},
+// where position counts the product's images from zero
+func (h *ProductHandler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP DeleteProductImage called", "id", id, "position", chi.URLParam(r, "position"))
+
+	position, err := strconv.Atoi(chi.URLParam(r, "position"))
+	if err != nil {
+		h.writeError(w, r, "Invalid image position", apperrors.New(apperrors.Invalid, "image position must be a number"))
+		return
+	}
+
+	product, err := h.service.DeleteProductImage(r.Context(), id, position)
+	if err != nil {
+		h.writeError(w, r, "Failed to delete product image", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// ValidatePurchaseEligibility handles POST /v1/products/purchase-eligibility
+func (h *ProductHandler) ValidatePurchaseEligibility(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ProductIDs []string `json:"product_ids"`
+		Customer   struct {
+			// BirthDate is a date such as 2006-01-02
+			BirthDate string `json:"birth_date"`
+			Region    string `json:"region"`
+		} `json:"customer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+	h.log(r).Info("HTTP ValidatePurchaseEligibility called", "products", len(request.ProductIDs))
+
+	customer := domain.CustomerProfile{Region: request.Customer.Region}
+	if request.Customer.BirthDate != "" {
+		birthDate, err := time.Parse(time.DateOnly, request.Customer.BirthDate)
+		if err != nil {
+			h.writeError(w, r, "Invalid birth date", apperrors.New(apperrors.Invalid, "birth_date must be a date such as 2006-01-02"))
+			return
+		}
+		customer.BirthDate = &birthDate
+	}
+
+	ineligible, err := h.service.ValidatePurchaseEligibility(r.Context(), request.ProductIDs, customer)
+	if err != nil {
+		h.writeError(w, r, "Failed to validate purchase eligibility", err)
+		return
+	}
+
+	response := struct {
+		Eligible   bool                   `json:"eligible"`
+		Ineligible []domain.Ineligibility `json:"ineligible"`
+	}{
+		Eligible:   len(ineligible) == 0,
+		Ineligible: ineligible,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// FeatureProduct handles PUT /v1/products/{id}/featured
+func (h *ProductHandler) FeatureProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP FeatureProduct called", "id", id)
+
+	// An empty body features the product without expiry
+	var request struct {
+		Until *time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	h.setFeatured(w, r, id, true, request.Until)
+}
+
+// UnfeatureProduct handles DELETE /v1/products/{id}/featured
+func (h *ProductHandler) UnfeatureProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP UnfeatureProduct called", "id", id)
+
+	h.setFeatured(w, r, id, false, nil)
+}
+
+func (h *ProductHandler) setFeatured(w http.ResponseWriter, r *http.Request, id string, featured bool, until *time.Time) {
+	product, err := h.service.SetFeatured(r.Context(), id, featured, until)
+	if err != nil {
+		h.writeError(w, r, "Failed to set product featured", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// CreateDownload handles POST /v1/products/{id}/downloads, which issues a
+// signed link to an asset of a digital product bought by the signed-in
+// user
+func (h *ProductHandler) CreateDownload(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP CreateDownload called", "id", id)
+
+	var request struct {
+		OrderID string `json:"order_id"`
+		Asset   string `json:"asset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	download, err := h.service.GetDownloadURL(r.Context(), id, request.OrderID, r.Header.Get(UserHeader), request.Asset)
+	if err != nil {
+		h.writeError(w, r, "Failed to create download", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(download); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// CreateReview handles POST /v1/products/{id}/reviews. The reviewer is
+// the signed-in user passed by the API gateway.
+func (h *ProductHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP CreateReview called", "id", id)
+
+	productID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		h.writeError(w, r, "Invalid product ID format", apperrors.New(apperrors.Invalid, "invalid product ID"))
+		return
+	}
+	var request struct {
+		Rating int    `json:"rating"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	review, err := h.service.CreateReview(r.Context(), &domain.Review{
+		ProductID: productID,
+		UserID:    r.Header.Get(UserHeader),
+		Rating:    request.Rating,
+		Title:     request.Title,
+		Body:      request.Body,
+	})
+	if err != nil {
+		h.writeError(w, r, "Failed to create review", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// ListReviews handles GET /v1/products/{id}/reviews
+func (h *ProductHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP ListReviews called", "id", id)
+
+	page, err := pagination.Parse(r.URL.Query(), domain.ReviewPagination)
+	if err != nil {
+		h.writeError(w, r, "Invalid pagination parameters", err)
+		return
+	}
+	reviews, total, err := h.service.ListReviews(r.Context(), id, page)
+	if err != nil {
+		h.writeError(w, r, "Failed to list reviews", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pagination.NewList("reviews", reviews, total, page)); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// log returns the request-scoped logger
+func (h *ProductHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+// writeError logs err and writes it as a problem response whose status
+// follows from the error's kind
+func (h *ProductHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func writeError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logging.FromContext(r.Context(), logger).Error(msg, "path", r.URL.Path, "error", err)
+	apperrors.WriteHTTP(w, r, err)
+}
+
+// customerGroupContext passes the customer group of the caller to the
+// service in the request context
+func customerGroupContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group := strings.ToLower(strings.TrimSpace(r.Header.Get(CustomerGroupHeader)))
+		if principal, ok := middleware.PrincipalFrom(r.Context()); ok && principal.Group != "" {
+			group = principal.Group
+		}
+		if group != "" {
+			r = r.WithContext(domain.WithCustomerGroup(r.Context(), group))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// invalidBody marks a request body decoding failure as a client error
+func invalidBody(err error) error {
+	return apperrors.Wrap(err, apperrors.Invalid, "invalid request body")
+}
+
+// parseWindow parses a number of days such as 7d, or a Go duration. An
+// empty window is zero, for the service's default.
+func parseWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
 	}
+	return 0, apperrors.New(apperrors.Invalid, "window must be a number of days such as 7d, or a duration such as 12h")
 }
 
 // Helper function to parse int parameters with default value
@@ -400,3 +1365,48 @@ func parseInt(value string, defaultValue int) int {
 	}
 	return intValue
 }
+
+// writeConditionalJSON writes v with a strong ETag, or an empty 304 Not
+// Modified when If-None-Match already names it. The ETag is a hash of the
+// encoded body rather than of updated_at, because reads also carry
+// promotions, badges and group prices that change without the product
+// being updated.
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// Match json.Encoder, which the other handlers write with
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	// Caches may keep the body but must revalidate it, per group and user
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", CustomerGroupHeader)
+	header.Add("Vary", "Authorization")
+	if etagMatches(r.Header.Values("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	header.Set("Content-Type", "application/json")
+	_, err = w.Write(body)
+	return err
+}
+
+// etagMatches reports whether any If-None-Match value names etag. As RFC
+// 9110 requires for If-None-Match the comparison is weak, so W/"x"
+// matches "x".
+func etagMatches(ifNoneMatch []string, etag string) bool {
+	for _, value := range ifNoneMatch {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// PromotionService defines the interface for promotion operations
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, promotion *domain.Promotion) (*domain.Promotion, error)
+	GetPromotion(ctx context.Context, id string) (*domain.Promotion, error)
+	ListPromotions(ctx context.Context, page pagination.Request) ([]*domain.Promotion, int, error)
+	UpdatePromotion(ctx context.Context, id string, promotion *domain.Promotion) (*domain.Promotion, error)
+	DeletePromotion(ctx context.Context, id string) error
+}
+
+// PromotionHandler handles HTTP requests for promotions
+type PromotionHandler struct {
+	service PromotionService
+	logger  *slog.Logger
+}
+
+// NewPromotionHandler creates a new promotion handler
+func NewPromotionHandler(service PromotionService, logger *slog.Logger) *PromotionHandler {
+	return &PromotionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the promotion routes with the given router.
+// They are for admins, so callers register them behind
+// middleware.RequireRole.
+func (h *PromotionHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/promotions", func(r chi.Router) {
+		r.Post("/", h.CreatePromotion)
+		r.Get("/", h.ListPromotions)
+		r.Get("/{id}", h.GetPromotion)
+		r.Put("/{id}", h.UpdatePromotion)
+		r.Delete("/{id}", h.DeletePromotion)
+	})
+}
+
+// promotionRequest is the editable part of a promotion. Promotions are
+// active unless active is false.
+type promotionRequest struct {
+	Name     string                `json:"name"`
+	Type     string                `json:"type"`
+	Value    float64               `json:"value"`
+	Scope    domain.PromotionScope `json:"scope"`
+	StartsAt *time.Time            `json:"starts_at"`
+	EndsAt   *time.Time            `json:"ends_at"`
+	Active   *bool                 `json:"active"`
+}
+
+func (req *promotionRequest) promotion() *domain.Promotion {
+	promotion := &domain.Promotion{
+		Name:   req.Name,
+		Type:   req.Type,
+		Value:  req.Value,
+		Scope:  req.Scope,
+		EndsAt: req.EndsAt,
+		Active: req.Active == nil || *req.Active,
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = *req.StartsAt
+	}
+	return promotion
+}
+
+// CreatePromotion handles POST /v1/promotions
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreatePromotion called")
+
+	var request promotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(r.Context(), request.promotion())
+	if err != nil {
+		h.writeError(w, r, "Failed to create promotion", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, promotion)
+}
+
+// GetPromotion handles GET /v1/promotions/{id}
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP GetPromotion called", "id", id)
+
+	promotion, err := h.service.GetPromotion(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to get promotion", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, promotion)
+}
+
+// ListPromotions handles GET /v1/promotions
+func (h *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListPromotions called")
+
+	page, err := pagination.Parse(r.URL.Query(), domain.PromotionPagination)
+	if err != nil {
+		h.writeError(w, r, "Invalid pagination parameters", err)
+		return
+	}
+	promotions, total, err := h.service.ListPromotions(r.Context(), page)
+	if err != nil {
+		h.writeError(w, r, "Failed to list promotions", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, pagination.NewList("promotions", promotions, total, page))
+}
+
+// UpdatePromotion handles PUT /v1/promotions/{id}
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP UpdatePromotion called", "id", id)
+
+	var request promotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	updated, err := h.service.UpdatePromotion(r.Context(), id, request.promotion())
+	if err != nil {
+		h.writeError(w, r, "Failed to update promotion", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeletePromotion handles DELETE /v1/promotions/{id}
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP DeletePromotion called", "id", id)
+
+	if err := h.service.DeletePromotion(r.Context(), id); err != nil {
+		h.writeError(w, r, "Failed to delete promotion", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *PromotionHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *PromotionHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *PromotionHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurchaseOrderService defines the interface for purchase order operations
+type PurchaseOrderService interface {
+	CreatePurchaseOrder(ctx context.Context, po *domain.PurchaseOrder) (*domain.PurchaseOrder, error)
+	GetPurchaseOrder(ctx context.Context, id string) (*domain.PurchaseOrder, error)
+	ListPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, id string, receipt domain.POReceipt) (*domain.PurchaseOrder, error)
+	CancelPurchaseOrder(ctx context.Context, id, reason string) (*domain.PurchaseOrder, error)
+}
+
+// PurchaseOrderHandler handles HTTP requests for purchase orders
+type PurchaseOrderHandler struct {
+	service PurchaseOrderService
+	logger  *slog.Logger
+}
+
+// NewPurchaseOrderHandler creates a new purchase order handler
+func NewPurchaseOrderHandler(service PurchaseOrderService, logger *slog.Logger) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the purchase order routes with the given router
+func (h *PurchaseOrderHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/purchase-orders", func(r chi.Router) {
+		r.Post("/", h.CreatePurchaseOrder)
+		r.Get("/", h.ListPurchaseOrders)
+		r.Get("/{id}", h.GetPurchaseOrder)
+		r.Post("/{id}/receipts", h.ReceivePurchaseOrder)
+		r.Post("/{id}/cancel", h.CancelPurchaseOrder)
+	})
+}
+
+// CreatePurchaseOrder handles POST /v1/purchase-orders
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreatePurchaseOrder called")
+
+	var request struct {
+		SupplierID primitive.ObjectID `json:"supplier_id"`
+		Lines      []struct {
+			ProductID   primitive.ObjectID `json:"product_id"`
+			SupplierSKU string             `json:"supplier_sku"`
+			Quantity    int                `json:"quantity"`
+		} `json:"lines"`
+		Notes      string     `json:"notes"`
+		ExpectedAt *time.Time `json:"expected_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	po := &domain.PurchaseOrder{
+		SupplierID: request.SupplierID,
+		Notes:      request.Notes,
+		ExpectedAt: request.ExpectedAt,
+	}
+	for _, line := range request.Lines {
+		po.Lines = append(po.Lines, domain.POLine{
+			ProductID:   line.ProductID,
+			SupplierSKU: line.SupplierSKU,
+			Expected:    line.Quantity,
+		})
+	}
+
+	created, err := h.service.CreatePurchaseOrder(r.Context(), po)
+	if err != nil {
+		h.writeError(w, r, "Failed to create purchase order", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, created)
+}
+
+// ListPurchaseOrders handles GET /v1/purchase-orders
+func (h *PurchaseOrderHandler) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListPurchaseOrders called")
+
+	query := r.URL.Query()
+	orders, err := h.service.ListPurchaseOrders(r.Context(), domain.PurchaseOrderFilter{
+		SupplierID: query.Get("supplier_id"),
+		Status:     query.Get("status"),
+		Limit:      parseInt(query.Get("limit"), 50),
+	})
+	if err != nil {
+		h.writeError(w, r, "Failed to list purchase orders", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"purchase_orders": orders})
+}
+
+// GetPurchaseOrder handles GET /v1/purchase-orders/{id}
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP GetPurchaseOrder called", "id", id)
+
+	po, err := h.service.GetPurchaseOrder(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to get purchase order", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, po)
+}
+
+// ReceivePurchaseOrder handles POST /v1/purchase-orders/{id}/receipts
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP ReceivePurchaseOrder called", "id", id)
+
+	var receipt domain.POReceipt
+	if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	po, err := h.service.ReceivePurchaseOrder(r.Context(), id, receipt)
+	if err != nil {
+		h.writeError(w, r, "Failed to receive purchase order", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, po)
+}
+
+// CancelPurchaseOrder handles POST /v1/purchase-orders/{id}/cancel
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP CancelPurchaseOrder called", "id", id)
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	po, err := h.service.CancelPurchaseOrder(r.Context(), id, request.Reason)
+	if err != nil {
+		h.writeError(w, r, "Failed to cancel purchase order", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, po)
+}
+
+// Helper functions
+
+func (h *PurchaseOrderHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *PurchaseOrderHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *PurchaseOrderHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
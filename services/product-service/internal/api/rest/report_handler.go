@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// reportDate is the layout of the days reports are asked for
+const reportDate = "2006-01-02"
+
+// ReportService defines the interface for inventory report operations
+type ReportService interface {
+	StockLevels(ctx context.Context, params domain.StockLevelParams) ([]domain.StockLevel, error)
+	SellThrough(ctx context.Context, from, to time.Time) (*domain.SellThroughReport, error)
+}
+
+// ReportHandler handles HTTP requests for inventory reports
+type ReportHandler struct {
+	service ReportService
+	logger  *slog.Logger
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(service ReportService, logger *slog.Logger) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the report routes with the given router
+func (h *ReportHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/reports/inventory", func(r chi.Router) {
+		r.Get("/levels", h.StockLevels)
+		r.Get("/sell-through", h.SellThrough)
+	})
+}
+
+// StockLevels handles GET /v1/reports/inventory/levels?from=2024-03-01&to=2024-03-31
+func (h *ReportHandler) StockLevels(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP StockLevels called")
+
+	query := r.URL.Query()
+	from, to, err := parseReportRange(query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.writeError(w, r, "Invalid report range", err)
+		return
+	}
+
+	levels, err := h.service.StockLevels(r.Context(), domain.StockLevelParams{
+		From:      from,
+		To:        to,
+		Category:  query.Get("category"),
+		ProductID: query.Get("product_id"),
+	})
+	if err != nil {
+		h.writeError(w, r, "Failed to get stock levels", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"levels": levels})
+}
+
+// SellThrough handles GET /v1/reports/inventory/sell-through?from=2024-03-01&to=2024-03-31
+func (h *ReportHandler) SellThrough(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP SellThrough called")
+
+	query := r.URL.Query()
+	from, to, err := parseReportRange(query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.writeError(w, r, "Invalid report range", err)
+		return
+	}
+
+	report, err := h.service.SellThrough(r.Context(), from, to)
+	if err != nil {
+		h.writeError(w, r, "Failed to get sell-through", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, report)
+}
+
+// parseReportRange parses the first and last day of a report. Days left
+// empty are zero, for the service's default range.
+func parseReportRange(from, to string) (time.Time, time.Time, error) {
+	var days [2]time.Time
+	for i, value := range []string{from, to} {
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse(reportDate, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperrors.New(apperrors.Invalid, "from and to must be days such as 2024-03-31")
+		}
+		days[i] = day
+	}
+	return days[0], days[1], nil
+}
+
+// Helper functions
+
+func (h *ReportHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *ReportHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *ReportHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// StockAlertService defines the interface for stock alert operations
+type StockAlertService interface {
+	CreateStockAlert(ctx context.Context, alert *domain.StockAlert) (*domain.StockAlert, error)
+	GetStockAlert(ctx context.Context, id string) (*domain.StockAlert, error)
+	ListStockAlerts(ctx context.Context, page pagination.Request) ([]*domain.StockAlert, int, error)
+	UpdateStockAlert(ctx context.Context, id string, alert *domain.StockAlert) (*domain.StockAlert, error)
+	DeleteStockAlert(ctx context.Context, id string) error
+}
+
+// StockAlertHandler handles HTTP requests for low-stock alerts
+type StockAlertHandler struct {
+	service StockAlertService
+	logger  *slog.Logger
+}
+
+// NewStockAlertHandler creates a new stock alert handler
+func NewStockAlertHandler(service StockAlertService, logger *slog.Logger) *StockAlertHandler {
+	return &StockAlertHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the stock alert routes with the given router
+func (h *StockAlertHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/stock-alerts", func(r chi.Router) {
+		r.Post("/", h.CreateStockAlert)
+		r.Get("/", h.ListStockAlerts)
+		r.Get("/{id}", h.GetStockAlert)
+		r.Put("/{id}", h.UpdateStockAlert)
+		r.Delete("/{id}", h.DeleteStockAlert)
+	})
+}
+
+// stockAlertRequest is the editable part of a stock alert
+type stockAlertRequest struct {
+	ProductID string `json:"product_id"`
+	Category  string `json:"category"`
+	Threshold int    `json:"threshold"`
+}
+
+func (req *stockAlertRequest) alert() *domain.StockAlert {
+	return &domain.StockAlert{
+		ProductID: req.ProductID,
+		Category:  req.Category,
+		Threshold: req.Threshold,
+	}
+}
+
+// CreateStockAlert handles POST /v1/stock-alerts
+func (h *StockAlertHandler) CreateStockAlert(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreateStockAlert called")
+
+	var request stockAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	alert, err := h.service.CreateStockAlert(r.Context(), request.alert())
+	if err != nil {
+		h.writeError(w, r, "Failed to create stock alert", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, alert)
+}
+
+// GetStockAlert handles GET /v1/stock-alerts/{id}
+func (h *StockAlertHandler) GetStockAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP GetStockAlert called", "id", id)
+
+	alert, err := h.service.GetStockAlert(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to get stock alert", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, alert)
+}
+
+// ListStockAlerts handles GET /v1/stock-alerts
+func (h *StockAlertHandler) ListStockAlerts(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListStockAlerts called")
+
+	page, err := pagination.Parse(r.URL.Query(), domain.StockAlertPagination)
+	if err != nil {
+		h.writeError(w, r, "Invalid pagination parameters", err)
+		return
+	}
+	alerts, total, err := h.service.ListStockAlerts(r.Context(), page)
+	if err != nil {
+		h.writeError(w, r, "Failed to list stock alerts", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, pagination.NewList("stock_alerts", alerts, total, page))
+}
+
+// UpdateStockAlert handles PUT /v1/stock-alerts/{id}
+func (h *StockAlertHandler) UpdateStockAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP UpdateStockAlert called", "id", id)
+
+	var request stockAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	updated, err := h.service.UpdateStockAlert(r.Context(), id, request.alert())
+	if err != nil {
+		h.writeError(w, r, "Failed to update stock alert", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteStockAlert handles DELETE /v1/stock-alerts/{id}
+func (h *StockAlertHandler) DeleteStockAlert(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP DeleteStockAlert called", "id", id)
+
+	if err := h.service.DeleteStockAlert(r.Context(), id); err != nil {
+		h.writeError(w, r, "Failed to delete stock alert", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *StockAlertHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *StockAlertHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *StockAlertHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SupplierService defines the interface for supplier operations
+type SupplierService interface {
+	CreateSupplier(ctx context.Context, supplier *domain.Supplier) (*domain.Supplier, error)
+	GetSupplier(ctx context.Context, id string) (*domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, supplier *domain.Supplier) (*domain.Supplier, error)
+	DeleteSupplier(ctx context.Context, id string) error
+	ListSuppliers(ctx context.Context, activeOnly bool) ([]*domain.Supplier, error)
+}
+
+// SupplierHandler handles HTTP requests for suppliers
+type SupplierHandler struct {
+	service SupplierService
+	logger  *slog.Logger
+}
+
+// NewSupplierHandler creates a new supplier handler
+func NewSupplierHandler(service SupplierService, logger *slog.Logger) *SupplierHandler {
+	return &SupplierHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the supplier routes with the given router.
+// Products of a supplier are listed with GET /v1/products?supplier_id=.
+func (h *SupplierHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/suppliers", func(r chi.Router) {
+		r.Post("/", h.CreateSupplier)
+		r.Get("/", h.ListSuppliers)
+		r.Get("/{id}", h.GetSupplier)
+		r.Put("/{id}", h.UpdateSupplier)
+		r.Delete("/{id}", h.DeleteSupplier)
+	})
+}
+
+// supplierRequest is the editable part of a supplier
+type supplierRequest struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	ContactName  string `json:"contact_name"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone"`
+	LeadTimeDays int    `json:"lead_time_days"`
+	Active       *bool  `json:"active"`
+}
+
+func (req *supplierRequest) supplier() *domain.Supplier {
+	supplier := &domain.Supplier{
+		Code:         req.Code,
+		Name:         req.Name,
+		ContactName:  req.ContactName,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		LeadTimeDays: req.LeadTimeDays,
+		Active:       true,
+	}
+	if req.Active != nil {
+		supplier.Active = *req.Active
+	}
+	return supplier
+}
+
+// CreateSupplier handles POST /v1/suppliers
+func (h *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP CreateSupplier called")
+
+	var request supplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	supplier, err := h.service.CreateSupplier(r.Context(), request.supplier())
+	if err != nil {
+		h.writeError(w, r, "Failed to create supplier", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, supplier)
+}
+
+// ListSuppliers handles GET /v1/suppliers
+func (h *SupplierHandler) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListSuppliers called")
+
+	suppliers, err := h.service.ListSuppliers(r.Context(), r.URL.Query().Get("active") == "true")
+	if err != nil {
+		h.writeError(w, r, "Failed to list suppliers", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"suppliers": suppliers})
+}
+
+// GetSupplier handles GET /v1/suppliers/{id}
+func (h *SupplierHandler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP GetSupplier called", "id", id)
+
+	supplier, err := h.service.GetSupplier(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, "Failed to get supplier", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, supplier)
+}
+
+// UpdateSupplier handles PUT /v1/suppliers/{id}
+func (h *SupplierHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP UpdateSupplier called", "id", id)
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		h.writeError(w, r, "Invalid supplier ID format", domain.ErrSupplierNotFound)
+		return
+	}
+
+	var request supplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.writeError(w, r, "Failed to decode request body", invalidBody(err))
+		return
+	}
+
+	supplier := request.supplier()
+	supplier.ID = objectID
+	updated, err := h.service.UpdateSupplier(r.Context(), supplier)
+	if err != nil {
+		h.writeError(w, r, "Failed to update supplier", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteSupplier handles DELETE /v1/suppliers/{id}
+func (h *SupplierHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	h.log(r).Info("HTTP DeleteSupplier called", "id", id)
+
+	if err := h.service.DeleteSupplier(r.Context(), id); err != nil {
+		h.writeError(w, r, "Failed to delete supplier", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper functions
+
+func (h *SupplierHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
+
+func (h *SupplierHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	writeError(h.logger, w, r, msg, err)
+}
+
+func (h *SupplierHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
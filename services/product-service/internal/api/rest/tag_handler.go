@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// TagService defines the interface for tag operations
+type TagService interface {
+	ListTags(ctx context.Context, prefix string) ([]domain.TagCount, error)
+}
+
+// TagHandler handles HTTP requests for the tags used on products
+type TagHandler struct {
+	service TagService
+	logger  *slog.Logger
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(service TagService, logger *slog.Logger) *TagHandler {
+	return &TagHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the tag routes with the given router
+func (h *TagHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/v1/tags", h.ListTags)
+}
+
+// ListTags handles GET /v1/tags?prefix=gre
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("HTTP ListTags called")
+
+	tags, err := h.service.ListTags(r.Context(), r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(h.logger, w, r, "Failed to list tags", err)
+		return
+	}
+
+	response := struct {
+		Tags []domain.TagCount `json:"tags"`
+	}{
+		Tags: tags,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log(r).Error("Failed to encode response", "error", err)
+	}
+}
+
+// Helper functions
+
+func (h *TagHandler) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), h.logger)
+}
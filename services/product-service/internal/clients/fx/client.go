@@ -0,0 +1,61 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/fx/v1"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is a client for the FX service
+type Client struct {
+	conn    *grpc.ClientConn
+	client  pb.FXServiceClient
+	timeout time.Duration
+}
+
+// New creates a client for the FX service at addr. Connections are
+// plaintext unless opts carry transport credentials, e.g. from pkg/mtls.
+func New(addr string, timeout time.Duration, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(addr, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fx client: %w", err)
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  pb.NewFXServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// Convert converts amount minor units of from into minor units of to
+func (c *Client) Convert(ctx context.Context, amount int64, from, to string) (*domain.ConvertedAmount, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Convert(ctx, &pb.ConvertRequest{
+		Amount: amount,
+		From:   from,
+		To:     to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fx service error: %w", apperrors.FromGRPC(err))
+	}
+
+	return &domain.ConvertedAmount{
+		Amount: resp.Amount,
+		Rate:   resp.Rate,
+		AsOf:   time.Unix(resp.AsOf, 0).UTC(),
+	}, nil
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,69 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/inventory/v1"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is a read-only client for the inventory service
+type Client struct {
+	conn    *grpc.ClientConn
+	client  pb.InventoryServiceClient
+	timeout time.Duration
+}
+
+// New creates a client for the inventory service at addr. Connections are
+// plaintext unless opts carry transport credentials, e.g. from pkg/mtls.
+func New(addr string, timeout time.Duration, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(addr, append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory client: %w", err)
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  pb.NewInventoryServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+// GetAvailability returns the reservable stock of a product across warehouses
+func (c *Client) GetAvailability(ctx context.Context, productID string) (*domain.Availability, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.CheckStock(ctx, &pb.CheckStockRequest{
+		ProductId: productID,
+		Quantity:  1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("inventory service error: %w", apperrors.FromGRPC(err))
+	}
+
+	availability := &domain.Availability{
+		ProductID:  productID,
+		Available:  int(resp.TotalAvailable),
+		InStock:    resp.Available,
+		Warehouses: make([]domain.WarehouseStock, len(resp.Warehouses)),
+	}
+	for i, level := range resp.Warehouses {
+		availability.Warehouses[i] = domain.WarehouseStock{
+			WarehouseID: level.WarehouseId,
+			Available:   int(level.Available),
+		}
+	}
+
+	return availability, nil
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
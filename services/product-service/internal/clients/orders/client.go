@@ -0,0 +1,87 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+)
+
+// Order statuses whose products may no longer be downloaded
+var revokedStatuses = map[string]bool{
+	"cancelled": true,
+	"refunded":  true,
+}
+
+// Client reads orders from the order service over its REST API, which is
+// expected to serve GET /v1/orders/{id} as
+// {"id", "user_id", "status", "items": [{"product_id", "quantity"}]}
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the order service at baseURL
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout, Transport: logging.Transport(nil)},
+	}
+}
+
+type order struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+	Items  []struct {
+		ProductID string `json:"product_id"`
+	} `json:"items"`
+}
+
+// VerifyPurchase checks that the order belongs to userID, contains
+// productID and has not been cancelled or refunded. Orders of other users
+// are reported as not found, so that order IDs cannot be probed.
+func (c *Client) VerifyPurchase(ctx context.Context, orderID, userID, productID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/orders/"+url.PathEscape(orderID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.Unavailable, "order service error")
+	}
+	defer resp.Body.Close()
+
+	notFound := apperrors.New(apperrors.NotFound, "order not found")
+	if resp.StatusCode == http.StatusNotFound {
+		return notFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return apperrors.Newf(apperrors.Unavailable, "order service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var o order
+	if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
+		return apperrors.Wrap(err, apperrors.Unavailable, "failed to decode order service response")
+	}
+	if o.UserID != userID {
+		return notFound
+	}
+	if revokedStatuses[o.Status] {
+		return apperrors.New(apperrors.Forbidden, "order was "+o.Status)
+	}
+	for _, item := range o.Items {
+		if item.ProductID == productID {
+			return nil
+		}
+	}
+	return apperrors.New(apperrors.Forbidden, "product was not bought in this order")
+}
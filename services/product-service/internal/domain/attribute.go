@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxAttributeFilters is the most attributes a product list can filter on
+const MaxAttributeFilters = 10
+
+// ValidateAttributeFilters checks the attribute filters of a product list:
+// keys become part of a document path, so they cannot hold dots or start
+// with a dollar sign, and every key needs a value
+func ValidateAttributeFilters(filters map[string]string) error {
+	if len(filters) > MaxAttributeFilters {
+		return fmt.Errorf("at most %d attribute filters are allowed", MaxAttributeFilters)
+	}
+	for key, value := range filters {
+		if key == "" || strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+			return fmt.Errorf("invalid attribute name %q", key)
+		}
+		if value == "" {
+			return errors.New("attribute filter " + key + " needs a value")
+		}
+	}
+	return nil
+}
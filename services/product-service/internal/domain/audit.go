@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited actions on products
+const (
+	AuditCreate    = "create"
+	AuditUpdate    = "update"
+	AuditDelete    = "delete"
+	AuditInventory = "inventory"
+)
+
+// AuditPagination configures paging of a product's audit trail, which is
+// paged like product lists
+var AuditPagination = ProductPagination
+
+// FieldChange is the change of one product field, named by its JSON path
+// such as "price" or "inventory.quantity". From is nil for a field the
+// product did not have, and To for one it no longer has.
+type FieldChange struct {
+	Field string      `bson:"field" json:"field"`
+	From  interface{} `bson:"from" json:"from"`
+	To    interface{} `bson:"to" json:"to"`
+}
+
+// AuditEntry records a change to a product: who made it, when, and the
+// fields it changed
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID string             `bson:"product_id" json:"product_id"`
+	Action    string             `bson:"action" json:"action"`
+	// Actor is the authenticated subject that made the change; it is
+	// empty when authentication is off
+	Actor string `bson:"actor,omitempty" json:"actor,omitempty"`
+	// OperationID and OperationType identify the inventory operation of
+	// an inventory change. An operation is recorded once however often
+	// it is retried.
+	OperationID   string        `bson:"operation_id,omitempty" json:"operation_id,omitempty"`
+	OperationType string        `bson:"operation_type,omitempty" json:"operation_type,omitempty"`
+	Changes       []FieldChange `bson:"changes" json:"changes"`
+	At            time.Time     `bson:"at" json:"at"`
+}
+
+// AuditRepository stores the audit trail of products
+type AuditRepository interface {
+	// Record adds an entry to a product's trail. An inventory operation
+	// already recorded is not recorded again.
+	Record(ctx context.Context, entry *AuditEntry) error
+	// List returns a page of a product's trail, newest first, and how
+	// many entries it has
+	List(ctx context.Context, productID string, page pagination.Request) ([]*AuditEntry, int, error)
+}
+
+// unauditedFields are the JSON fields of a product that are not audited:
+// its ID, the timestamps every change moves, and the fields computed when
+// it is read rather than stored
+var unauditedFields = func() map[string]bool {
+	fields := map[string]bool{"id": true, "created_at": true, "updated_at": true}
+	t := reflect.TypeOf(Product{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("bson") == "-" {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// AuditFields returns the audited fields of a product by their JSON paths,
+// for Diff. Nested objects are flattened, so that a change to the stock
+// is a change to "inventory.quantity"; lists are compared whole.
+func AuditFields(p *Product) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if p == nil {
+		return fields
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fields
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fields
+	}
+	for name, value := range doc {
+		if !unauditedFields[name] {
+			flatten(fields, name, value)
+		}
+	}
+	return fields
+}
+
+// flatten adds value to fields under path, and the fields of an object
+// under their paths below it
+func flatten(fields map[string]interface{}, path string, value interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		fields[path] = value
+		return
+	}
+	for name, value := range object {
+		flatten(fields, path+"."+name, value)
+	}
+}
+
+// Diff returns the changes from one set of audited fields to another, in
+// field order
+func Diff(before, after map[string]interface{}) []FieldChange {
+	var changes []FieldChange
+	for field, from := range before {
+		if to := after[field]; !reflect.DeepEqual(from, to) {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	for field, to := range after {
+		if _, ok := before[field]; !ok && to != nil {
+			changes = append(changes, FieldChange{Field: field, To: to})
+		}
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int { return strings.Compare(a.Field, b.Field) })
+	return changes
+}
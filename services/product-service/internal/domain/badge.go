@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Badge errors
+var (
+	ErrBadgeNotFound = apperrors.New(apperrors.NotFound, "badge not found")
+	ErrBadgeExists   = apperrors.New(apperrors.Conflict, "badge already exists").WithReason("BADGE_EXISTS")
+	ErrBadgeInUse    = apperrors.New(apperrors.Conflict, "badge is in use").WithReason("BADGE_IN_USE")
+)
+
+// Keys of the automatic badges, which products get from BadgeRules
+// rather than by assignment
+const (
+	BadgeSale     = "sale"
+	BadgeNew      = "new"
+	BadgePreorder = "preorder"
+)
+
+var (
+	badgeKeyPattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+	badgeColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+)
+
+// Badge defines how a badge such as "sale", "bestseller" or "eco" is shown
+// on the storefront. Badges with a higher priority are shown first. The
+// defaults of automatic badges have no timestamps.
+type Badge struct {
+	Key       string    `bson:"_id" json:"key"`
+	Label     string    `bson:"label" json:"label"`
+	Color     string    `bson:"color" json:"color"`
+	Priority  int       `bson:"priority" json:"priority"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at,omitzero"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at,omitzero"`
+}
+
+// DefaultBadges define the automatic badges until admins define them
+var DefaultBadges = []Badge{
+	{Key: BadgeSale, Label: "Sale", Color: "#d32f2f", Priority: 100},
+	{Key: BadgePreorder, Label: "Pre-order", Color: "#7b1fa2", Priority: 80},
+	{Key: BadgeNew, Label: "New", Color: "#1976d2", Priority: 60},
+}
+
+// Validate checks the badge and lower-cases its color
+func (b *Badge) Validate() error {
+	if !badgeKeyPattern.MatchString(b.Key) {
+		return errors.New("badge key must be lowercase letters, digits and dashes, at most 32 long")
+	}
+	b.Label = strings.TrimSpace(b.Label)
+	if b.Label == "" || utf8.RuneCountInString(b.Label) > 40 {
+		return errors.New("badge label must be between 1 and 40 characters")
+	}
+	b.Color = strings.ToLower(b.Color)
+	if !badgeColorPattern.MatchString(b.Color) {
+		return errors.New("badge color must be a hex color such as #1a2b3c")
+	}
+	if b.Priority < 0 || b.Priority > 1000 {
+		return errors.New("badge priority must be between 0 and 1000")
+	}
+	return nil
+}
+
+// IsAutomaticBadge reports whether products get the badge from a rule
+func IsAutomaticBadge(key string) bool {
+	for _, rule := range BadgeRules {
+		if rule.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// BadgeRule gives products a badge automatically
+type BadgeRule struct {
+	Key     string
+	Applies func(*Product) bool
+}
+
+// BadgeRules list the automatic badges. The new badge follows IsNew, so
+// it applies once the service has set it.
+var BadgeRules = []BadgeRule{
+	{Key: BadgeSale, Applies: (*Product).Discounted},
+	{Key: BadgeNew, Applies: func(p *Product) bool { return p.IsNew }},
+	{Key: BadgePreorder, Applies: (*Product).OnPreorder},
+}
+
+// Discounted reports whether the product sells below its compare-at price
+// or a promotion discounts it
+func (p *Product) Discounted() bool {
+	return (p.CompareAtPrice != nil && *p.CompareAtPrice > p.Price) || p.DiscountedPrice != nil
+}
+
+// ProductBadge is a badge as shown on a product
+type ProductBadge struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// ResolveBadges returns the assigned and automatic badges of a product,
+// highest priority first. Badges without a definition are left out.
+func ResolveBadges(p *Product, definitions map[string]Badge) []ProductBadge {
+	keys := append([]string(nil), p.Badges...)
+	for _, rule := range BadgeRules {
+		if rule.Applies(p) {
+			keys = append(keys, rule.Key)
+		}
+	}
+
+	seen := make(map[string]bool, len(keys))
+	var badges []Badge
+	for _, key := range keys {
+		badge, ok := definitions[key]
+		if ok && !seen[key] {
+			seen[key] = true
+			badges = append(badges, badge)
+		}
+	}
+	sort.Slice(badges, func(i, j int) bool {
+		if badges[i].Priority != badges[j].Priority {
+			return badges[i].Priority > badges[j].Priority
+		}
+		return badges[i].Key < badges[j].Key
+	})
+
+	resolved := make([]ProductBadge, len(badges))
+	for i, badge := range badges {
+		resolved[i] = ProductBadge{Key: badge.Key, Label: badge.Label, Color: badge.Color}
+	}
+	return resolved
+}
+
+// BadgeRepository defines the interface for badge definition storage
+type BadgeRepository interface {
+	Create(ctx context.Context, badge *Badge) error
+	Get(ctx context.Context, key string) (*Badge, error)
+	Update(ctx context.Context, badge *Badge) error
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context) ([]*Badge, error)
+}
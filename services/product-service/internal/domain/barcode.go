@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// ErrBarcodeExists is returned when another product already has the barcode
+var ErrBarcodeExists = apperrors.New(apperrors.Conflict, "barcode already exists").WithReason("BARCODE_EXISTS")
+
+// NormalizeBarcode validates a GTIN barcode (EAN-8, UPC-A, EAN-13 or
+// GTIN-14) and returns it in the form products store and are looked up
+// by. Spaces and dashes are dropped. UPC-A codes are stored as the EAN-13
+// with a leading zero, and GTIN-14 codes with a leading zero as the
+// EAN-13 they contain, so that a product scanned in either form is found.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return "", errors.New("barcode must contain only digits")
+		}
+	}
+
+	switch len(code) {
+	case 8, 13, 14:
+	case 12:
+		code = "0" + code
+	default:
+		return "", errors.New("barcode must have 8, 12, 13 or 14 digits")
+	}
+	if !validCheckDigit(code) {
+		return "", errors.New("barcode check digit is wrong")
+	}
+	if len(code) == 14 && code[0] == '0' {
+		code = code[1:]
+	}
+	return code, nil
+}
+
+// validCheckDigit checks the GS1 check digit: the digits before it are
+// weighted 3 and 1 alternately from the right
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
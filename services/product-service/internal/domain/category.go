@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Category errors
+var (
+	ErrCategoryNotFound    = apperrors.New(apperrors.NotFound, "category not found")
+	ErrCategoryExists      = apperrors.New(apperrors.Conflict, "category already exists").WithReason("CATEGORY_EXISTS")
+	ErrCategoryHasChildren = apperrors.New(apperrors.Conflict, "category has subcategories").WithReason("CATEGORY_HAS_CHILDREN")
+	ErrCategoryInUse       = apperrors.New(apperrors.Conflict, "category is in use").WithReason("CATEGORY_IN_USE")
+)
+
+// MaxCategoryDepth is the most levels a category tree has, counting the
+// top level
+const MaxCategoryDepth = 6
+
+var categoryKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Category is a node of the category tree, such as "Phones" under
+// "Electronics". Products refer to their category by its key. Path holds
+// the keys of the category's ancestors, top level first, so that the
+// descendants of a category are the categories whose path contains it.
+type Category struct {
+	Key       string    `bson:"_id" json:"key"`
+	Name      string    `bson:"name" json:"name"`
+	Parent    string    `bson:"parent,omitempty" json:"parent,omitempty"`
+	Path      []string  `bson:"path" json:"path"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Validate checks the category's key and name, trimming the name
+func (c *Category) Validate() error {
+	if !categoryKeyPattern.MatchString(c.Key) {
+		return errors.New("category key must be lowercase letters, digits and dashes, at most 64 long")
+	}
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || utf8.RuneCountInString(c.Name) > 80 {
+		return errors.New("category name must be between 1 and 80 characters")
+	}
+	if c.Parent == c.Key {
+		return errors.New("a category cannot be its own parent")
+	}
+	return nil
+}
+
+// SetParent places the category under parent, or at the top level when
+// parent is nil
+func (c *Category) SetParent(parent *Category) {
+	c.Parent, c.Path = "", []string{}
+	if parent != nil {
+		c.Parent = parent.Key
+		c.Path = append(append([]string{}, parent.Path...), parent.Key)
+	}
+}
+
+// Depth is the level of the category, 1 at the top level
+func (c *Category) Depth() int {
+	return len(c.Path) + 1
+}
+
+// HasAncestor reports whether key is the category's parent or one of its
+// ancestors
+func (c *Category) HasAncestor(key string) bool {
+	for _, ancestor := range c.Path {
+		if ancestor == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryRepository defines the interface for category storage
+type CategoryRepository interface {
+	Create(ctx context.Context, category *Category) error
+	Get(ctx context.Context, key string) (*Category, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, key string) error
+	// List returns all categories, by key
+	List(ctx context.Context) ([]*Category, error)
+	// Descendants returns the categories below key, at any depth
+	Descendants(ctx context.Context, key string) ([]*Category, error)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+)
+
+// CloneOptions are what a clone of a product does not take from it
+type CloneOptions struct {
+	// SKU is the clone's SKU, which must be new
+	SKU string `json:"sku"`
+	// Name is the clone's name; the product's name if empty
+	Name string `json:"name,omitempty"`
+	// Active lists the clone at once. Clones are inactive by default, so
+	// that they can be edited before shoppers see them.
+	Active bool `json:"active"`
+}
+
+// Clone returns a new product with the product's listing: its
+// description, prices, images, category, tags, attributes, suppliers,
+// limits and shipping details. Everything identifying the product or
+// earned by it is left out: the clone has no ID, slug or barcode, no
+// stock or preorders, and no reviews, popularity or featured status.
+func (p *Product) Clone(opts CloneOptions) *Product {
+	clone := &Product{
+		Name:            p.Name,
+		Description:     p.Description,
+		MetaTitle:       p.MetaTitle,
+		MetaDescription: p.MetaDescription,
+		Price:           p.Price,
+		GroupPrices:     slices.Clone(p.GroupPrices),
+		ImageURLs:       slices.Clone(p.ImageURLs),
+		Category:        p.Category,
+		Inventory:       InventoryInfo{SKU: opts.SKU},
+		Tags:            slices.Clone(p.Tags),
+		Badges:          slices.Clone(p.Badges),
+		Attributes:      maps.Clone(p.Attributes),
+		Suppliers:       slices.Clone(p.Suppliers),
+		PurchaseLimits:  p.PurchaseLimits,
+		Restrictions: Restrictions{
+			MinAge:            p.MinAge,
+			RestrictedRegions: slices.Clone(p.RestrictedRegions),
+		},
+		Active:        opts.Active,
+		Type:          p.Type,
+		ShippingClass: p.ShippingClass,
+	}
+	if opts.Name != "" {
+		clone.Name = opts.Name
+	}
+	if p.CompareAtPrice != nil {
+		compareAt := *p.CompareAtPrice
+		clone.CompareAtPrice = &compareAt
+	}
+	if p.Digital != nil {
+		digital := *p.Digital
+		digital.Assets = slices.Clone(p.Digital.Assets)
+		clone.Digital = &digital
+	}
+	if p.Weight != nil {
+		weight := *p.Weight
+		clone.Weight = &weight
+	}
+	if p.Dimensions != nil {
+		dimensions := *p.Dimensions
+		clone.Dimensions = &dimensions
+	}
+	return clone
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Customer groups, which the user service assigns to users. Retail
+// customers pay a product's price; the other groups pay its group price
+// when it has one.
+const (
+	CustomerGroupRetail    = "retail"
+	CustomerGroupWholesale = "wholesale"
+	CustomerGroupVIP       = "vip"
+)
+
+// IsCustomerGroup reports whether group is a known customer group
+func IsCustomerGroup(group string) bool {
+	switch group {
+	case CustomerGroupRetail, CustomerGroupWholesale, CustomerGroupVIP:
+		return true
+	}
+	return false
+}
+
+// GroupPrice overrides the price of a product for a customer group
+type GroupPrice struct {
+	Group string  `bson:"group" json:"group"`
+	Price float64 `bson:"price" json:"price"`
+}
+
+// NormalizeGroupPrices checks the group prices of a product and sorts them
+// by group. Retail customers pay the product's price, so it has no group
+// price.
+func NormalizeGroupPrices(prices []GroupPrice) ([]GroupPrice, error) {
+	if len(prices) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(prices))
+	for _, price := range prices {
+		switch {
+		case price.Group == CustomerGroupRetail:
+			return nil, errors.New("retail customers pay the product price, which has no group price")
+		case !IsCustomerGroup(price.Group):
+			return nil, fmt.Errorf("unknown customer group %q", price.Group)
+		case seen[price.Group]:
+			return nil, fmt.Errorf("customer group %q is priced twice", price.Group)
+		case !(price.Price > 0) || math.IsInf(price.Price, 1):
+			return nil, fmt.Errorf("price for customer group %q must be greater than zero", price.Group)
+		}
+		seen[price.Group] = true
+	}
+	sorted := append([]GroupPrice(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Group < sorted[j].Group })
+	return sorted, nil
+}
+
+// PriceFor returns the price a customer group pays for the product: its
+// group price if it has one, otherwise its price
+func (p *Product) PriceFor(group string) float64 {
+	for _, price := range p.GroupPrices {
+		if price.Group == group {
+			return price.Price
+		}
+	}
+	return p.Price
+}
+
+type customerGroupKey struct{}
+
+// WithCustomerGroup returns a context carrying the customer group of the
+// caller, which Get and List price products for
+func WithCustomerGroup(ctx context.Context, group string) context.Context {
+	return context.WithValue(ctx, customerGroupKey{}, group)
+}
+
+// CustomerGroupFrom returns the customer group of the caller, or "" when
+// it is not known
+func CustomerGroupFrom(ctx context.Context) string {
+	group, _ := ctx.Value(customerGroupKey{}).(string)
+	return group
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DerivedField is a field stored on products that is computed from their
+// other fields, so that it can be filtered and sorted on. When the
+// computation changes, the stored values are recomputed with the backfill
+// command.
+type DerivedField struct {
+	// Path is the field's path in the stored document
+	Path string
+	// Derive returns the value the field should have
+	Derive func(*Product) interface{}
+	// Stored returns the value the field has
+	Stored func(*Product) interface{}
+}
+
+// DerivedFields lists every derived product field
+var DerivedFields = []DerivedField{
+	{
+		Path:   "inventory.in_stock",
+		Derive: func(p *Product) interface{} { return p.HasStock() },
+		Stored: func(p *Product) interface{} { return p.Inventory.InStock },
+	},
+	{
+		Path:   "search_name",
+		Derive: func(p *Product) interface{} { return NormalizeSearchName(p.Name) },
+		Stored: func(p *Product) interface{} { return p.SearchName },
+	},
+}
+
+// LookupDerivedField returns the derived field with the path
+func LookupDerivedField(path string) (DerivedField, bool) {
+	for _, field := range DerivedFields {
+		if field.Path == path {
+			return field, true
+		}
+	}
+	return DerivedField{}, false
+}
+
+// StaleFields returns the fields whose stored value differs from the
+// derived one, keyed by path, with their derived values
+func StaleFields(product *Product, fields []DerivedField) map[string]interface{} {
+	stale := map[string]interface{}{}
+	for _, field := range fields {
+		if value := field.Derive(product); !reflect.DeepEqual(value, field.Stored(product)) {
+			stale[field.Path] = value
+		}
+	}
+	return stale
+}
+
+// FieldUpdate sets some fields of one product, by path, leaving the others
+type FieldUpdate struct {
+	ProductID primitive.ObjectID
+	Fields    map[string]interface{}
+}
+
+// ProductBackfillRepository reads the whole catalog in pages and writes
+// individual fields, for maintenance jobs
+type ProductBackfillRepository interface {
+	// ListAfter returns up to limit products, active or not, with IDs
+	// after the given one in ID order
+	ListAfter(ctx context.Context, after primitive.ObjectID, limit int) ([]*Product, error)
+	// SetFields applies the updates and returns how many products changed
+	SetFields(ctx context.Context, updates []FieldUpdate) (int, error)
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Product types. Products without a type are physical.
+const (
+	ProductTypePhysical = "physical"
+	ProductTypeDigital  = "digital"
+)
+
+// ErrDownloadLimitReached is returned when an order has used up the
+// downloads of a digital product
+var ErrDownloadLimitReached = apperrors.New(apperrors.Conflict, "download limit reached").WithReason("DOWNLOAD_LIMIT_REACHED")
+
+// DigitalInfo describes what buyers of a digital product can download
+type DigitalInfo struct {
+	Assets []DigitalAsset `bson:"assets" json:"assets"`
+	// MaxDownloads is how often an order may download the product's
+	// assets; 0 is unlimited
+	MaxDownloads int `bson:"max_downloads" json:"max_downloads"`
+	// LicenseKeys is whether each order gets a license key with its
+	// first download
+	LicenseKeys bool `bson:"license_keys" json:"license_keys"`
+}
+
+// DigitalAsset is a downloadable file of a digital product
+type DigitalAsset struct {
+	Name string `bson:"name" json:"name"`
+	// Key is the object's key in the downloads bucket
+	Key  string `bson:"key" json:"key"`
+	Size int64  `bson:"size,omitempty" json:"size,omitempty"`
+}
+
+// IsDigital reports whether the product is a digital product, which has
+// no stock to check
+func (p *Product) IsDigital() bool {
+	return p.Type == ProductTypeDigital
+}
+
+// HasStock reports whether the product can be bought: digital products
+// always can, physical ones while they have a quantity
+func (p *Product) HasStock() bool {
+	return p.IsDigital() || p.Inventory.Quantity > 0
+}
+
+// Asset returns the digital asset with the given name, or the only one if
+// name is empty
+func (p *Product) Asset(name string) (*DigitalAsset, bool) {
+	if p.Digital == nil {
+		return nil, false
+	}
+	if name == "" && len(p.Digital.Assets) == 1 {
+		return &p.Digital.Assets[0], true
+	}
+	for i := range p.Digital.Assets {
+		if p.Digital.Assets[i].Name == name {
+			return &p.Digital.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// DownloadGrant counts the downloads of a digital product bought in an
+// order
+type DownloadGrant struct {
+	OrderID    string    `bson:"order_id" json:"order_id"`
+	ProductID  string    `bson:"product_id" json:"product_id"`
+	UserID     string    `bson:"user_id" json:"user_id"`
+	Downloads  int       `bson:"downloads" json:"downloads"`
+	LicenseKey string    `bson:"license_key,omitempty" json:"license_key,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Download is a signed, time-limited link to a digital asset
+type Download struct {
+	ProductID string    `json:"product_id"`
+	OrderID   string    `json:"order_id"`
+	Asset     string    `json:"asset"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// DownloadsLeft is the number of downloads left to the order, or nil
+	// if they are unlimited
+	DownloadsLeft *int   `json:"downloads_left,omitempty"`
+	LicenseKey    string `json:"license_key,omitempty"`
+}
+
+// DownloadRepository counts downloads of digital products per order
+type DownloadRepository interface {
+	// Record counts a download of productID by orderID, unless max
+	// downloads (if not 0) have already been counted, in which case it
+	// returns ErrDownloadLimitReached
+	Record(ctx context.Context, orderID, productID, userID string, max int) (*DownloadGrant, error)
+	// Revert takes back a download counted by Record whose link could not
+	// be issued
+	Revert(ctx context.Context, orderID, productID string) error
+	// SetLicenseKey stores the license key of an order's product unless it
+	// already has one, and returns the stored key
+	SetLicenseKey(ctx context.Context, orderID, productID, key string) (string, error)
+}
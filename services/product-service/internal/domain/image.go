@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// MaxProductImages is the most images a product may have
+const MaxProductImages = 20
+
+// DefaultMaxImageBytes is the largest image that may be uploaded by default
+const DefaultMaxImageBytes = 5 << 20
+
+// ImageExtensions are the file extensions of the image types that may be
+// uploaded, by content type
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// MaxImageURLLength is the longest image URL, in bytes, a product may have
+const MaxImageURLLength = 2048
+
+// ImagePolicy decides which image URLs products may have and which are
+// rewritten to be served from a CDN
+type ImagePolicy struct {
+	// AllowedHosts are the hosts images may be served from; a leading dot
+	// allows the subdomains of a domain. Any public host is allowed when
+	// empty.
+	AllowedHosts []string
+	// CDNHost, if set, replaces the host of image URLs served from one of
+	// OriginHosts, which are allowed too
+	CDNHost     string
+	OriginHosts []string
+}
+
+// Apply checks a product's image URLs and returns them with the origins
+// rewritten to the CDN. URLs must be absolute http or https URLs of a
+// public host allowed by the policy.
+func (p ImagePolicy) Apply(urls []string) ([]string, error) {
+	if len(urls) > MaxProductImages {
+		return nil, fmt.Errorf("a product may have at most %d images", MaxProductImages)
+	}
+	if len(urls) == 0 {
+		return urls, nil
+	}
+	applied := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		if len(raw) > MaxImageURLLength {
+			return nil, fmt.Errorf("image URL must be at most %d bytes", MaxImageURLLength)
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return nil, fmt.Errorf("image URL %q must be an absolute http or https URL", raw)
+		}
+		host := strings.ToLower(u.Hostname())
+		if internalHost(host) {
+			return nil, fmt.Errorf("image URL %q must be on a public host", raw)
+		}
+		origin := p.CDNHost != "" && matchHost(p.OriginHosts, host)
+		if len(p.AllowedHosts) > 0 && !origin && !matchHost(p.AllowedHosts, host) && !strings.EqualFold(host, p.CDNHost) {
+			return nil, fmt.Errorf("image host %q is not allowed", host)
+		}
+		if origin {
+			u.Scheme, u.Host = "https", p.CDNHost
+		}
+		applied = append(applied, u.String())
+	}
+	return applied, nil
+}
+
+// matchHost reports whether host is one of hosts, or a subdomain of one
+// listed with a leading dot
+func matchHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if domain, ok := strings.CutPrefix(h, "."); ok {
+			if strings.HasSuffix(host, "."+strings.ToLower(domain)) {
+				return true
+			}
+		} else if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// internalHost reports whether host can only be reached from inside a
+// network: single-label and .local or .internal names, and loopback,
+// private and link-local addresses
+func internalHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsUnspecified()
+	}
+	return !strings.Contains(host, ".") || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") ||
+		strings.HasSuffix(host, ".localhost")
+}
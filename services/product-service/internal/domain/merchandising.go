@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Orderings of product lists besides sorting by a field. They ignore
+// SortDesc.
+const (
+	// SortByNewest lists the most recently created products first
+	SortByNewest = "newest"
+	// SortByFeatured lists featured products first, then the others, each
+	// newest first
+	SortByFeatured = "featured"
+)
+
+// DefaultNewArrivalWindow is how long products count as new by default
+const DefaultNewArrivalWindow = 30 * 24 * time.Hour
+
+// FeaturedAt reports whether the product is featured at now: it was
+// featured and the feature has not expired
+func (p *Product) FeaturedAt(now time.Time) bool {
+	return p.Featured && (p.FeaturedUntil == nil || p.FeaturedUntil.After(now))
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Optional is a field of a patch. Set is whether the patch has the field
+// at all and Null whether it is null, which clears it; Value holds the
+// value otherwise.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Some returns a field set to value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Set: true, Value: value}
+}
+
+// Null returns a field set to null
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// Apply sets *dst to the field's value, or to its zero value when the
+// field is null, if the field is set
+func (o Optional[T]) Apply(dst *T) {
+	if !o.Set {
+		return
+	}
+	var zero T
+	*dst = zero
+	if !o.Null {
+		*dst = o.Value
+	}
+}
+
+// UnmarshalJSON records that the field is present, and whether it is
+// null. Unknown fields of objects are errors, as in ParseProductPatch.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(&o.Value)
+}
+
+// ProductPatch is a partial update of a product as a JSON Merge Patch
+// (RFC 7396): fields left out keep their value, fields set to null are
+// cleared, and objects are merged field by field. Arrays replace the
+// stored ones. Attributes set to null are removed and the others set.
+type ProductPatch struct {
+	Name            Optional[string]             `json:"name"`
+	Description     Optional[string]             `json:"description"`
+	Slug            Optional[string]             `json:"slug"`
+	MetaTitle       Optional[string]             `json:"meta_title"`
+	MetaDescription Optional[string]             `json:"meta_description"`
+	Price           Optional[float64]            `json:"price"`
+	CompareAtPrice  Optional[float64]            `json:"compare_at_price"`
+	GroupPrices     Optional[[]GroupPrice]       `json:"group_prices"`
+	ImageURLs       Optional[[]string]           `json:"image_urls"`
+	Category        Optional[string]             `json:"category"`
+	Barcode         Optional[string]             `json:"barcode"`
+	Tags            Optional[[]string]           `json:"tags"`
+	Attributes      Optional[map[string]*string] `json:"attributes"`
+	Active          Optional[bool]               `json:"active"`
+	Inventory       Optional[InventoryPatch]     `json:"inventory"`
+	ReleaseDate     Optional[time.Time]          `json:"release_date"`
+	Preorder        Optional[PreorderPatch]      `json:"preorder"`
+	Digital         Optional[DigitalPatch]       `json:"digital"`
+	Weight          Optional[WeightPatch]        `json:"weight"`
+	Dimensions      Optional[DimensionsPatch]    `json:"dimensions"`
+	ShippingClass   Optional[string]             `json:"shipping_class"`
+}
+
+// InventoryPatch changes the SKU of a product; stock changes through the
+// inventory endpoints
+type InventoryPatch struct {
+	SKU Optional[string] `json:"sku"`
+}
+
+// PreorderPatch changes the preorder allocation of a product; the
+// preorders taken are kept
+type PreorderPatch struct {
+	Allocation Optional[int] `json:"allocation"`
+}
+
+// DigitalPatch changes the downloads of a digital product
+type DigitalPatch struct {
+	Assets       Optional[[]DigitalAsset] `json:"assets"`
+	MaxDownloads Optional[int]            `json:"max_downloads"`
+	LicenseKeys  Optional[bool]           `json:"license_keys"`
+}
+
+// WeightPatch changes the weight of a product
+type WeightPatch struct {
+	Value Optional[float64] `json:"value"`
+	Unit  Optional[string]  `json:"unit"`
+}
+
+// DimensionsPatch changes the dimensions of a product
+type DimensionsPatch struct {
+	Length Optional[float64] `json:"length"`
+	Width  Optional[float64] `json:"width"`
+	Height Optional[float64] `json:"height"`
+	Unit   Optional[string]  `json:"unit"`
+}
+
+// ParseProductPatch decodes a merge patch. Fields that cannot be patched,
+// such as the ID or the stock, are rejected rather than ignored.
+func ParseProductPatch(data []byte) (*ProductPatch, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, apperrors.New(apperrors.Invalid, "a merge patch must be a JSON object")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var patch ProductPatch
+	if err := decoder.Decode(&patch); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Invalid, "invalid merge patch")
+	}
+	if decoder.More() {
+		return nil, apperrors.New(apperrors.Invalid, "a merge patch must be a single JSON object")
+	}
+	return &patch, nil
+}
+
+// Apply applies the patch to a weight, which is nil when there is none
+func (p WeightPatch) Apply(weight *Weight) *Weight {
+	var w Weight
+	if weight != nil {
+		w = *weight
+	}
+	p.Value.Apply(&w.Value)
+	p.Unit.Apply(&w.Unit)
+	return &w
+}
+
+// Apply applies the patch to dimensions, which are nil when there are none
+func (p DimensionsPatch) Apply(dimensions *Dimensions) *Dimensions {
+	var d Dimensions
+	if dimensions != nil {
+		d = *dimensions
+	}
+	p.Length.Apply(&d.Length)
+	p.Width.Apply(&d.Width)
+	p.Height.Apply(&d.Height)
+	p.Unit.Apply(&d.Unit)
+	return &d
+}
+
+// Apply applies the patch to downloads, which are nil when there are none
+func (p DigitalPatch) Apply(digital *DigitalInfo) *DigitalInfo {
+	var d DigitalInfo
+	if digital != nil {
+		d = *digital
+	}
+	p.Assets.Apply(&d.Assets)
+	p.MaxDownloads.Apply(&d.MaxDownloads)
+	p.LicenseKeys.Apply(&d.LicenseKeys)
+	return &d
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SortByPopularity sorts product lists by Popularity.Score
+const SortByPopularity = "popularity"
+
+// PurchaseWeight is how many views a purchased unit counts for in
+// popularity scores
+const PurchaseWeight = 10
+
+// MaxTrendingWindow is the longest window trending products are ranked
+// over; daily counts older than that are dropped
+const MaxTrendingWindow = 90 * 24 * time.Hour
+
+// Popularity is how often a product has been viewed and bought
+type Popularity struct {
+	Views     int64   `bson:"views" json:"views"`
+	Purchases int64   `bson:"purchases" json:"purchases"`
+	Score     float64 `bson:"score" json:"score"`
+}
+
+// PopularityScore ranks products by their views and purchased units
+func PopularityScore(views, purchases int64) float64 {
+	return float64(views + PurchaseWeight*purchases)
+}
+
+// PopularityCount is the number of views and purchased units of a product
+// counted on one day
+type PopularityCount struct {
+	ProductID primitive.ObjectID
+	// Day is midnight UTC of the day counted
+	Day       time.Time
+	Views     int64
+	Purchases int64
+}
+
+// TrendingProduct is a product ranked by its popularity within a window
+type TrendingProduct struct {
+	Product   *Product `json:"product"`
+	Views     int64    `json:"views"`
+	Purchases int64    `json:"purchases"`
+	Score     float64  `json:"score"`
+}
+
+// TrendingCategory holds the top trending products of a category, most
+// popular first
+type TrendingCategory struct {
+	Category string            `json:"category"`
+	Products []TrendingProduct `json:"products"`
+}
+
+// TrendingParams selects trending products
+type TrendingParams struct {
+	// Since is the first day counted
+	Since time.Time
+	// Category restricts the result to one category if set
+	Category string
+	// Limit is the number of products per category
+	Limit int
+}
+
+// PopularityRepository stores the counted views and purchases of products
+type PopularityRepository interface {
+	// AddCounts adds daily counts to the stored ones and to the totals of
+	// the products
+	AddCounts(ctx context.Context, counts []PopularityCount) error
+	// Trending returns the active products with the highest scores in
+	// the window, per category in category order
+	Trending(ctx context.Context, params TrendingParams) ([]TrendingCategory, error)
+}
+
+// Day returns midnight UTC of t's day
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Preorder errors
+var (
+	ErrPreorderSoldOut = apperrors.New(apperrors.Conflict, "preorder allocation exhausted").WithReason("PREORDER_SOLD_OUT")
+	// ErrNotOnPreorder is returned by preorder writes to products that are
+	// not, or no longer, taking preorders
+	ErrNotOnPreorder = apperrors.New(apperrors.Conflict, "product is not on preorder").WithReason("NOT_ON_PREORDER")
+)
+
+// PreorderInfo is the preorder state of a product before its release date.
+// Preorders are counted against the allocation without touching on-hand
+// stock, and become reservations when the product is released.
+type PreorderInfo struct {
+	Allocation int `bson:"allocation" json:"allocation"`
+	Ordered    int `bson:"ordered" json:"ordered"`
+}
+
+// Left returns the number of units that can still be preordered
+func (p PreorderInfo) Left() int {
+	if p.Ordered >= p.Allocation {
+		return 0
+	}
+	return p.Allocation - p.Ordered
+}
+
+// OnPreorder reports whether the product takes preorders
+func (p *Product) OnPreorder() bool {
+	return p.Preorder != nil
+}
+
+// PreorderRelease is a product released by the release job, with the
+// preorders that became reservations
+type PreorderRelease struct {
+	Product   *Product
+	Converted int
+}
@@ -1,27 +1,110 @@
 package domain
 
 import (
+	"context"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Product errors
+var (
+	ErrProductNotFound   = apperrors.New(apperrors.NotFound, "product not found")
+	ErrInsufficientStock = apperrors.New(apperrors.Conflict, "insufficient stock").WithReason("INSUFFICIENT_STOCK")
+	ErrDuplicateSKU      = apperrors.New(apperrors.AlreadyExists, "SKU already exists").WithReason("SKU_EXISTS")
+)
+
 // Product represents a product in the catalog
 type Product struct {
 	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
 	Name        string                 `bson:"name" json:"name"`
+	// SearchName is the name in the form NormalizeSearchName returns,
+	// which search suggestions match prefixes of
+	SearchName  string                 `bson:"search_name,omitempty" json:"-"`
 	Description string                 `bson:"description" json:"description"`
+	// Slug identifies the product in storefront URLs. PreviousSlugs keep
+	// resolving to the product after the slug changes.
+	Slug        string                 `bson:"slug,omitempty" json:"slug,omitempty"`
+	PreviousSlugs []string             `bson:"previous_slugs,omitempty" json:"previous_slugs,omitempty"`
+	MetaTitle   string                 `bson:"meta_title,omitempty" json:"meta_title,omitempty"`
+	MetaDescription string             `bson:"meta_description,omitempty" json:"meta_description,omitempty"`
 	Price       float64                `bson:"price" json:"price"`
+	// CompareAtPrice is the regular price of a discounted product; the
+	// product is on sale while it is above Price
+	CompareAtPrice *float64            `bson:"compare_at_price,omitempty" json:"compare_at_price,omitempty"`
+	// GroupPrices override Price for customer groups other than retail
+	GroupPrices []GroupPrice           `bson:"group_prices,omitempty" json:"group_prices,omitempty"`
 	ImageURLs   []string               `bson:"image_urls" json:"image_urls"`
 	Category    string                 `bson:"category" json:"category"`
 	Inventory   InventoryInfo          `bson:"inventory" json:"inventory"`
+	// Barcode is a GTIN in the form NormalizeBarcode returns
+	Barcode     string                 `bson:"barcode,omitempty" json:"barcode,omitempty"`
 	Tags        []string               `bson:"tags" json:"tags"`
+	// Badges are the keys of the badges assigned to the product;
+	// automatic badges are not stored
+	Badges      []string               `bson:"badges,omitempty" json:"badges,omitempty"`
 	Attributes  map[string]string      `bson:"attributes" json:"attributes"`
+	Suppliers   []ProductSupplier      `bson:"suppliers,omitempty" json:"suppliers,omitempty"`
+	// PurchaseLimits adds min_order_quantity and max_per_customer
+	PurchaseLimits `bson:",inline"`
+	// Restrictions adds min_age and restricted_regions
+	Restrictions `bson:",inline"`
+	// ReleaseDate is when a product on preorder is released. It is kept
+	// after the release.
+	ReleaseDate *time.Time             `bson:"release_date,omitempty" json:"release_date,omitempty"`
+	Preorder    *PreorderInfo          `bson:"preorder,omitempty" json:"preorder,omitempty"`
 	Active      bool                   `bson:"active" json:"active"`
+	// Status is StatusDraft, StatusPublished or StatusRetired
+	Status      string                 `bson:"status" json:"status"`
+	// Type is ProductTypePhysical or ProductTypeDigital; empty is physical
+	Type        string                 `bson:"type,omitempty" json:"type,omitempty"`
+	Digital     *DigitalInfo           `bson:"digital,omitempty" json:"digital,omitempty"`
+	// Weight and Dimensions are stored in WeightUnit and DimensionUnit
+	Weight      *Weight                `bson:"weight,omitempty" json:"weight,omitempty"`
+	Dimensions  *Dimensions            `bson:"dimensions,omitempty" json:"dimensions,omitempty"`
+	ShippingClass string                 `bson:"shipping_class,omitempty" json:"shipping_class,omitempty"`
+	Featured    bool                   `bson:"featured" json:"featured"`
+	FeaturedUntil *time.Time           `bson:"featured_until,omitempty" json:"featured_until,omitempty"`
+	Popularity  *Popularity            `bson:"popularity,omitempty" json:"popularity,omitempty"`
+	// Rating summarizes the product's reviews; products without reviews
+	// have none
+	Rating      *Rating                `bson:"rating,omitempty" json:"rating,omitempty"`
 	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time              `bson:"updated_at" json:"updated_at"`
+
+	// IsNew is whether the product was created within the new arrival
+	// window. It is set by the service and not stored.
+	IsNew bool `bson:"-" json:"is_new"`
+	// DisplayBadges are the assigned and automatic badges as shown on the
+	// storefront. They are set by the service and not stored.
+	DisplayBadges []ProductBadge `bson:"-" json:"display_badges,omitempty"`
+	// CustomerGroup is the customer group the product was read for and
+	// EffectivePrice the price it pays. They are set by the service when
+	// the caller's group is known and not stored.
+	CustomerGroup  string  `bson:"-" json:"customer_group,omitempty"`
+	EffectivePrice float64 `bson:"-" json:"effective_price,omitempty"`
+	// DiscountedPrice is Price less the promotion that takes the most off
+	// it, which Promotion describes. They are set by the service while a
+	// promotion runs and not stored.
+	DiscountedPrice *float64          `bson:"-" json:"discounted_price,omitempty"`
+	Promotion       *AppliedPromotion `bson:"-" json:"promotion,omitempty"`
+
+	// Slugs are the current and previous slugs, which are unique across
+	// products. They are set by the repository and not returned.
+	Slugs []string `bson:"slugs,omitempty" json:"-"`
+
+	// SchemaVersion is the shape the document was stored in, set by the
+	// repository. Older documents are brought up to date by the migrations
+	// in repository/mongodb/migrations.
+	SchemaVersion int `bson:"schema_version" json:"-"`
 }
 
+// ProductSchemaVersion is the version of the product documents this code
+// writes: the version of the last migration
+const ProductSchemaVersion = 2
+
 // InventoryInfo contains product inventory details
 type InventoryInfo struct {
 	Quantity int    `bson:"quantity" json:"quantity"`
@@ -30,21 +113,110 @@ type InventoryInfo struct {
 	Reserved int    `bson:"reserved" json:"reserved"`
 }
 
-// ProductRepository defines the interface for product data operations
+// Availability is the stock of a product as shown to shoppers
+type Availability struct {
+	ProductID  string           `json:"product_id"`
+	Available  int              `json:"available"`
+	InStock    bool             `json:"in_stock"`
+	Warehouses []WarehouseStock `json:"warehouses,omitempty"`
+}
+
+// WarehouseStock is the reservable stock of a product in one warehouse
+type WarehouseStock struct {
+	WarehouseID string `json:"warehouse_id"`
+	Available   int    `json:"available"`
+}
+
+// InventoryChange is the inventory of a product after it changed
+type InventoryChange struct {
+	ProductID   string
+	ProductName string
+	Inventory   InventoryInfo
+	ChangedAt   time.Time
+}
+
+// ConvertedAmount is an amount converted by the FX service, in minor units
+type ConvertedAmount struct {
+	Amount int64
+	Rate   string
+	AsOf   time.Time
+}
+
+// Price is a product price in a requested currency
+type Price struct {
+	ProductID    string     `json:"product_id"`
+	Amount       float64    `json:"amount"`
+	Currency     string     `json:"currency"`
+	BaseAmount   float64    `json:"base_amount"`
+	BaseCurrency string     `json:"base_currency"`
+	ExchangeRate string     `json:"exchange_rate,omitempty"`
+	RatesAsOf    *time.Time `json:"rates_as_of,omitempty"`
+}
+
+// ProductRepository defines the interface for product data operations.
+// Operations honor ctx's deadline and cancellation.
 type ProductRepository interface {
-	Create(product *Product) error
-	GetByID(id string) (*Product, error)
-	Update(product *Product) error
-	Delete(id string) error
-	List(params ListProductsParams) ([]*Product, int, error)
-	UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*InventoryInfo, error)
-	CheckStock(productID string, quantity int) (bool, int, error)
+	Create(ctx context.Context, product *Product) error
+	GetByID(ctx context.Context, id string) (*Product, error)
+	// GetByIDs retrieves the products with the IDs that exist, in no
+	// particular order
+	GetByIDs(ctx context.Context, ids []string) ([]*Product, error)
+	GetByBarcode(ctx context.Context, barcode string) (*Product, error)
+	// GetBySlug retrieves the product with the slug, current or previous
+	GetBySlug(ctx context.Context, slug string) (*Product, error)
+	Update(ctx context.Context, product *Product) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, params ListProductsParams) ([]*Product, int, error)
+	UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*InventoryInfo, error)
+	CheckStock(ctx context.Context, productID string, quantity int) (bool, int, error)
+	// HasOperation reports whether the inventory or preorder operation
+	// with operationID was applied
+	HasOperation(ctx context.Context, operationID string) (bool, error)
+	// ImageInUse reports whether a product has the image URL
+	ImageInUse(ctx context.Context, url string) (bool, error)
+	Stream(ctx context.Context, includeInactive bool, fn func(*Product) error) error
+	// Export calls fn with the products matching the filters of a product
+	// list, in ID order, ignoring pagination and sorting
+	Export(ctx context.Context, params ListProductsParams, fn func(*Product) error) error
+	// WatchInventory calls fn with each change to the inventory of the
+	// products, or of all products if productIDs is empty, until ctx is
+	// done or fn fails
+	WatchInventory(ctx context.Context, productIDs []string, fn func(*InventoryChange) error) error
+	SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*Product, error)
+	UnfeatureExpired(ctx context.Context, now time.Time) (int, error)
+	// UpdatePreorders takes (negative quantityChange) or cancels (positive)
+	// preorders of a product on preorder, once per operationID
+	UpdatePreorders(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*PreorderInfo, error)
+	// ReleaseDue releases the next product on preorder whose release date
+	// is at or before now, or returns nil if there is none
+	ReleaseDue(ctx context.Context, now time.Time) (*PreorderRelease, error)
+	// ListOperations returns a page of the recorded inventory and
+	// preorder operations the filter selects, newest first, and how many
+	// it selects
+	ListOperations(ctx context.Context, filter InventoryOperationFilter, page pagination.Request) ([]*InventoryOperation, int, error)
+	// Suggest returns up to limit active products whose search name starts
+	// with prefix, which is normalized, in name order
+	Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error)
+	// ListTags returns every tag on products, active or not, with the
+	// number of products carrying it, in tag order
+	ListTags(ctx context.Context) ([]TagCount, error)
+	// RelatedProducts returns up to limit other active products sharing
+	// the product's category or tags, most similar first
+	RelatedProducts(ctx context.Context, product *Product, limit int) ([]*Product, error)
 }
 
+// MaxProductBatch is the most products that can be retrieved by ID at once
+const MaxProductBatch = 100
+
+// ProductPagination configures paging of product lists. Product pages are
+// numbered from 0, as they were before pkg/pagination existed.
+var ProductPagination = pagination.Options{ZeroBasedPages: true}
+
 // ListProductsParams defines the parameters for listing products
 type ListProductsParams struct {
 	Page        int
 	PageSize    int
+	Offset      int
 	Category    string
 	Tags        []string
 	MinPrice    float64
@@ -53,6 +225,31 @@ type ListProductsParams struct {
 	SortBy      string
 	SortDesc    bool
 	SearchTerm  string
+	SupplierID  string
+	// FeaturedOnly selects products featured now
+	FeaturedOnly bool
+	// NewOnly selects products created within the new arrival window, by
+	// setting CreatedSince
+	NewOnly bool
+	// CreatedSince selects products created at or after it, if set
+	CreatedSince time.Time
+	// ShippingClass selects products of a shipping class, if set
+	ShippingClass string
+	// Badge selects products the badge is assigned to, if set
+	Badge string
+	// IncludeSubcategories selects the products of the categories below
+	// Category too. The service sets Categories to the category and its
+	// descendants.
+	IncludeSubcategories bool
+	// Categories selects products in any of these categories, if set; it
+	// takes precedence over Category
+	Categories []string
+	// AttributeFilters selects products with all of these attribute
+	// values, such as color=black, if set
+	AttributeFilters map[string]string
+	// Status selects products with the status; published products if
+	// empty
+	Status string
 }
 
 // InventoryOperation represents a change to inventory
@@ -64,11 +261,26 @@ type InventoryOperation struct {
 	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
 }
 
+// InventoryOperationPagination configures paging of inventory operation
+// lists, which are paged like product lists
+var InventoryOperationPagination = ProductPagination
+
+// InventoryOperationFilter selects the recorded operations of a product
+type InventoryOperationFilter struct {
+	ProductID string
+	// Types selects operations of any of these types, if set
+	Types []string
+	// From and To select operations at or after From and before To, if set
+	From *time.Time
+	To   *time.Time
+}
+
 // NewProduct creates a new product with default values
 func NewProduct() *Product {
 	return &Product{
 		ID:         primitive.NewObjectID(),
 		Active:     true,
+		Status:     StatusPublished,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 		Attributes: make(map[string]string),
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrPromotionNotFound is returned when a promotion does not exist
+var ErrPromotionNotFound = apperrors.New(apperrors.NotFound, "promotion not found")
+
+// Promotion types
+const (
+	// PromotionPercentage takes Value percent off the price
+	PromotionPercentage = "percentage"
+	// PromotionFixed takes Value off the price, down to zero
+	PromotionFixed = "fixed"
+)
+
+// PromotionPagination configures paging of promotion lists, which are
+// paged like product lists
+var PromotionPagination = ProductPagination
+
+// Promotion is a discount on the products in its scope while it runs,
+// from StartsAt until EndsAt, or without end when EndsAt is nil. Inactive
+// promotions do not apply at all.
+type Promotion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Type      string             `bson:"type" json:"type"`
+	Value     float64            `bson:"value" json:"value"`
+	Scope     PromotionScope     `bson:"scope" json:"scope"`
+	StartsAt  time.Time          `bson:"starts_at" json:"starts_at"`
+	EndsAt    *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Active    bool               `bson:"active" json:"active"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PromotionScope selects the products a promotion applies to: those in
+// one of its categories, or below them, those with one of its tags, and
+// those listed by ID
+type PromotionScope struct {
+	Categories []string `bson:"categories,omitempty" json:"categories,omitempty"`
+	Tags       []string `bson:"tags,omitempty" json:"tags,omitempty"`
+	ProductIDs []string `bson:"product_ids,omitempty" json:"product_ids,omitempty"`
+}
+
+// Validate checks the promotion and trims its name
+func (p *Promotion) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > 80 {
+		return errors.New("promotion name must be between 1 and 80 characters")
+	}
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("percentage discount must be above 0 and at most 100")
+		}
+	case PromotionFixed:
+		if p.Value <= 0 {
+			return errors.New("fixed discount must be positive")
+		}
+	default:
+		return errors.New("promotion type must be percentage or fixed")
+	}
+	if len(p.Scope.Categories)+len(p.Scope.Tags)+len(p.Scope.ProductIDs) == 0 {
+		return errors.New("promotion scope must name categories, tags or products")
+	}
+	for _, id := range p.Scope.ProductIDs {
+		if !primitive.IsValidObjectID(id) {
+			return errors.New("promotion product IDs must be product IDs")
+		}
+	}
+	if p.StartsAt.IsZero() {
+		return errors.New("promotion start is required")
+	}
+	if p.EndsAt != nil && !p.EndsAt.After(p.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+	return nil
+}
+
+// RunningAt reports whether the promotion is active and within its
+// validity window at t
+func (p *Promotion) RunningAt(t time.Time) bool {
+	return p.Active && !t.Before(p.StartsAt) && (p.EndsAt == nil || t.Before(*p.EndsAt))
+}
+
+// Covers reports whether the product is in the promotion's scope
+func (p *Promotion) Covers(product *Product) bool {
+	return contains(p.Scope.Categories, product.Category) ||
+		contains(p.Scope.ProductIDs, product.ID.Hex()) ||
+		containsAny(p.Scope.Tags, product.Tags)
+}
+
+// Discount returns the price less the promotion's discount, rounded to
+// 0.01
+func (p *Promotion) Discount(price float64) float64 {
+	discounted := price - p.Value
+	if p.Type == PromotionPercentage {
+		discounted = price * (1 - p.Value/100)
+	}
+	return math.Max(math.Round(discounted*100)/100, 0)
+}
+
+// AppliedPromotion is a promotion as shown on a discounted product
+type AppliedPromotion struct {
+	ID     string     `json:"id"`
+	Name   string     `json:"name"`
+	Type   string     `json:"type"`
+	Value  float64    `json:"value"`
+	EndsAt *time.Time `json:"ends_at,omitempty"`
+}
+
+// ApplyPromotions discounts the product by the promotion running at t that
+// takes the most off its price. Promotions do not stack.
+func (p *Product) ApplyPromotions(promotions []*Promotion, t time.Time) {
+	p.DiscountedPrice, p.Promotion = nil, nil
+	for _, promotion := range promotions {
+		if !promotion.RunningAt(t) || !promotion.Covers(p) {
+			continue
+		}
+		price := promotion.Discount(p.Price)
+		if price >= p.Price || (p.DiscountedPrice != nil && price >= *p.DiscountedPrice) {
+			continue
+		}
+		p.DiscountedPrice = &price
+		p.Promotion = &AppliedPromotion{
+			ID:     promotion.ID.Hex(),
+			Name:   promotion.Name,
+			Type:   promotion.Type,
+			Value:  promotion.Value,
+			EndsAt: promotion.EndsAt,
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// PromotionRepository defines the interface for promotion storage
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *Promotion) error
+	Get(ctx context.Context, id string) (*Promotion, error)
+	Update(ctx context.Context, promotion *Promotion) error
+	Delete(ctx context.Context, id string) error
+	// List returns a page of all promotions, latest start first, and how
+	// many there are
+	List(ctx context.Context, page pagination.Request) ([]*Promotion, int, error)
+	// Live returns the active promotions that have not ended by t,
+	// including those yet to start
+	Live(ctx context.Context, t time.Time) ([]*Promotion, error)
+}
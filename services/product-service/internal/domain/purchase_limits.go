@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// ReasonBelowMinOrderQuantity is the reason of errors for quantities below
+// a product's minimum order quantity
+const ReasonBelowMinOrderQuantity = "BELOW_MIN_ORDER_QUANTITY"
+
+// PurchaseLimits bound the quantity of a product a customer may buy. Zero
+// means no limit. The product service enforces the minimum; the cap per
+// customer is left to the cart and checkout, which know the customer's
+// earlier orders.
+type PurchaseLimits struct {
+	MinOrderQuantity int `bson:"min_order_quantity,omitempty" json:"min_order_quantity,omitempty"`
+	MaxPerCustomer   int `bson:"max_per_customer,omitempty" json:"max_per_customer,omitempty"`
+}
+
+// Validate checks that the limits are not negative and that the minimum
+// does not exceed the cap
+func (l PurchaseLimits) Validate() error {
+	if l.MinOrderQuantity < 0 || l.MaxPerCustomer < 0 {
+		return errors.New("purchase limits must not be negative")
+	}
+	if l.MaxPerCustomer > 0 && l.MinOrderQuantity > l.MaxPerCustomer {
+		return errors.New("minimum order quantity must not exceed the maximum per customer")
+	}
+	return nil
+}
+
+// CheckQuantity returns an error if quantity is below the minimum order
+// quantity
+func (l PurchaseLimits) CheckQuantity(quantity int) error {
+	if quantity < l.MinOrderQuantity {
+		return apperrors.Newf(apperrors.Invalid, "quantity must be at least %d", l.MinOrderQuantity).
+			WithReason(ReasonBelowMinOrderQuantity)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Purchase order errors
+var (
+	ErrPurchaseOrderNotFound = apperrors.New(apperrors.NotFound, "purchase order not found")
+	ErrPurchaseOrderConflict = apperrors.New(apperrors.Conflict, "purchase order was modified concurrently")
+)
+
+// Purchase order statuses
+const (
+	POStatusOpen              = "open"
+	POStatusPartiallyReceived = "partially_received"
+	POStatusReceived          = "received"
+	POStatusClosed            = "closed"    // Closed short after partial receipts
+	POStatusCancelled         = "cancelled" // Cancelled before anything was received
+)
+
+// PurchaseOrder is an order placed with a supplier to restock products
+type PurchaseOrder struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SupplierID primitive.ObjectID `bson:"supplier_id" json:"supplier_id"`
+	Status     string             `bson:"status" json:"status"`
+	Lines      []POLine           `bson:"lines" json:"lines"`
+	Receipts   []POReceipt        `bson:"receipts" json:"receipts"`
+	Notes      string             `bson:"notes" json:"notes"`
+	ExpectedAt *time.Time         `bson:"expected_at,omitempty" json:"expected_at,omitempty"`
+	Version    int                `bson:"version" json:"version"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// POLine is the quantity of one product ordered on a purchase order
+type POLine struct {
+	ProductID   primitive.ObjectID `bson:"product_id" json:"product_id"`
+	SupplierSKU string             `bson:"supplier_sku" json:"supplier_sku"`
+	Expected    int                `bson:"expected" json:"expected"`
+	Received    int                `bson:"received" json:"received"`
+}
+
+// Outstanding returns the quantity still to be delivered
+func (l POLine) Outstanding() int {
+	if l.Received >= l.Expected {
+		return 0
+	}
+	return l.Expected - l.Received
+}
+
+// POReceipt records one delivery against a purchase order
+type POReceipt struct {
+	Reference  string          `bson:"reference" json:"reference"` // e.g. the delivery note number
+	Lines      []POReceiptLine `bson:"lines" json:"lines"`
+	Note       string          `bson:"note,omitempty" json:"note,omitempty"`
+	ReceivedAt time.Time       `bson:"received_at" json:"received_at"`
+}
+
+// POReceiptLine is the quantity of one product accepted into stock. The
+// note explains discrepancies such as short, damaged or extra items.
+type POReceiptLine struct {
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	// Barcode is the barcode scanned for the product, which names it in
+	// place of ProductID. It is kept in the form NormalizeBarcode returns.
+	Barcode  string `bson:"barcode,omitempty" json:"barcode,omitempty"`
+	Quantity int    `bson:"quantity" json:"quantity"`
+	Note     string `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// PurchaseOrderFilter defines the parameters for listing purchase orders
+type PurchaseOrderFilter struct {
+	SupplierID string
+	Status     string
+	Limit      int
+}
+
+// PurchaseOrderRepository defines the interface for purchase order data operations
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, po *PurchaseOrder) error
+	GetByID(ctx context.Context, id string) (*PurchaseOrder, error)
+	// Update replaces a purchase order if its version is unchanged since it
+	// was read, and increments the version
+	Update(ctx context.Context, po *PurchaseOrder) error
+	List(ctx context.Context, filter PurchaseOrderFilter) ([]*PurchaseOrder, error)
+}
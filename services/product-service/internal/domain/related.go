@@ -0,0 +1,11 @@
+package domain
+
+// Related product limits
+const (
+	DefaultRelatedLimit = 8
+	MaxRelatedLimit     = 24
+)
+
+// RelatedCategoryWeight is what sharing a product's category adds to the
+// similarity of another product; each shared tag adds one
+const RelatedCategoryWeight = 2
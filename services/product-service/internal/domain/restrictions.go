@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxMinAge is the highest minimum age a product may require
+const MaxMinAge = 99
+
+// Reasons a customer may not buy a product
+const (
+	ReasonProductUnavailable = "PRODUCT_UNAVAILABLE"
+	ReasonUnderage           = "UNDERAGE"
+	ReasonBirthDateRequired  = "BIRTH_DATE_REQUIRED"
+	ReasonRegionRestricted   = "REGION_RESTRICTED"
+	ReasonRegionRequired     = "REGION_REQUIRED"
+)
+
+// regionPattern is an ISO 3166-1 alpha-2 country code, optionally followed
+// by an ISO 3166-2 subdivision, such as DE or US-UT
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// Restrictions limit who may buy an age-restricted or regulated product.
+// A restricted country covers all its subdivisions.
+type Restrictions struct {
+	MinAge            int      `bson:"min_age,omitempty" json:"min_age,omitempty"`
+	RestrictedRegions []string `bson:"restricted_regions,omitempty" json:"restricted_regions,omitempty"`
+}
+
+// Normalize returns the restrictions with the regions upper-cased, sorted
+// and without duplicates
+func (r Restrictions) Normalize() (Restrictions, error) {
+	if r.MinAge < 0 || r.MinAge > MaxMinAge {
+		return Restrictions{}, fmt.Errorf("minimum age must be between 0 and %d", MaxMinAge)
+	}
+	seen := make(map[string]bool, len(r.RestrictedRegions))
+	var regions []string
+	for _, region := range r.RestrictedRegions {
+		region, err := NormalizeRegion(region)
+		if err != nil {
+			return Restrictions{}, err
+		}
+		if !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return Restrictions{MinAge: r.MinAge, RestrictedRegions: regions}, nil
+}
+
+// NormalizeRegion upper-cases an ISO 3166 region code and checks its form
+func NormalizeRegion(region string) (string, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if !regionPattern.MatchString(region) {
+		return "", fmt.Errorf("region %q must be an ISO 3166 country or subdivision code", region)
+	}
+	return region, nil
+}
+
+// CustomerProfile is what checkout knows about the buyer when it checks
+// purchase eligibility
+type CustomerProfile struct {
+	// BirthDate is the verified date of birth; nil when unknown
+	BirthDate *time.Time
+	// Region is where the order is delivered, in the form NormalizeRegion
+	// returns. Subdivisions matter for products restricted in one.
+	Region string
+}
+
+// Validate checks that the birth date is not in the future and normalizes
+// the region
+func (c *CustomerProfile) Validate(now time.Time) error {
+	if c.BirthDate != nil && c.BirthDate.After(now) {
+		return errors.New("birth date must not be in the future")
+	}
+	if c.Region == "" {
+		return nil
+	}
+	region, err := NormalizeRegion(c.Region)
+	if err != nil {
+		return err
+	}
+	c.Region = region
+	return nil
+}
+
+// Ineligibility is why a customer may not buy a product
+type Ineligibility struct {
+	ProductID string `json:"product_id"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+}
+
+// Check returns why the customer may not buy a product with these
+// restrictions, or nil if they may. Unknown birth dates and regions are
+// refused when they are needed to decide.
+func (r Restrictions) Check(productID string, customer CustomerProfile, now time.Time) *Ineligibility {
+	refuse := func(reason, format string, args ...any) *Ineligibility {
+		return &Ineligibility{ProductID: productID, Reason: reason, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if r.MinAge > 0 {
+		if customer.BirthDate == nil {
+			return refuse(ReasonBirthDateRequired, "buyers must be at least %d; a birth date is required", r.MinAge)
+		}
+		if AgeOn(*customer.BirthDate, now) < r.MinAge {
+			return refuse(ReasonUnderage, "buyers must be at least %d", r.MinAge)
+		}
+	}
+
+	if len(r.RestrictedRegions) > 0 && customer.Region == "" {
+		return refuse(ReasonRegionRequired, "the product cannot be sold everywhere; a region is required")
+	}
+	country, _, hasSubdivision := strings.Cut(customer.Region, "-")
+	for _, restricted := range r.RestrictedRegions {
+		switch {
+		case restricted == customer.Region || restricted == country:
+			return refuse(ReasonRegionRestricted, "the product cannot be sold in %s", restricted)
+		case !hasSubdivision && strings.HasPrefix(restricted, country+"-"):
+			return refuse(ReasonRegionRequired, "the product cannot be sold in %s; the subdivision is required", restricted)
+		}
+	}
+	return nil
+}
+
+// AgeOn returns the age in whole years on the given day of someone born on
+// birthDate
+func AgeOn(birthDate, day time.Time) int {
+	by, bm, bd := birthDate.Date()
+	y, m, d := day.Date()
+	age := y - by
+	if m < bm || (m == bm && d < bd) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrReviewExists is returned when a user reviews a product a second time
+var ErrReviewExists = apperrors.New(apperrors.Conflict, "product already reviewed").WithReason("REVIEW_EXISTS")
+
+// SortByRating sorts product lists by Rating.Average, then Rating.Count
+const SortByRating = "rating"
+
+// Ratings are whole stars from MinRating to MaxRating
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// ReviewPagination configures paging of review lists, which are paged
+// like product lists
+var ReviewPagination = ProductPagination
+
+// Review is a customer's rating of a product, with an optional text. Each
+// user reviews a product at most once.
+type Review struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Rating    int                `bson:"rating" json:"rating"`
+	Title     string             `bson:"title,omitempty" json:"title,omitempty"`
+	Body      string             `bson:"body,omitempty" json:"body,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Validate checks the review and trims its title and body
+func (r *Review) Validate() error {
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		return errors.New("rating must be between 1 and 5")
+	}
+	r.Title = strings.TrimSpace(r.Title)
+	if utf8.RuneCountInString(r.Title) > 120 {
+		return errors.New("review title must be at most 120 characters")
+	}
+	r.Body = strings.TrimSpace(r.Body)
+	if utf8.RuneCountInString(r.Body) > 5000 {
+		return errors.New("review body must be at most 5000 characters")
+	}
+	return nil
+}
+
+// Rating summarizes the reviews of a product. It is kept on the product
+// so that product lists can show and sort by it.
+type Rating struct {
+	// Average is the mean rating, rounded to 0.01
+	Average float64 `bson:"average" json:"average"`
+	Count   int     `bson:"count" json:"count"`
+}
+
+// RoundRating rounds an average rating to 0.01
+func RoundRating(average float64) float64 {
+	return math.Round(average*100) / 100
+}
+
+// ReviewRepository defines the interface for review storage
+type ReviewRepository interface {
+	// Create stores a review and updates the rating of its product, which
+	// it returns
+	Create(ctx context.Context, review *Review) (*Rating, error)
+	// List returns a page of the reviews of a product, newest first, and
+	// how many it has
+	List(ctx context.Context, productID string, page pagination.Request) ([]*Review, int, error)
+}
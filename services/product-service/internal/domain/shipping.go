@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// Units weights and dimensions are stored in. Other units are converted
+// when products are written.
+const (
+	WeightUnit    = "kg"
+	DimensionUnit = "cm"
+)
+
+// weightUnits and dimensionUnits convert the accepted units to the stored
+// ones
+var (
+	weightUnits = map[string]float64{
+		"g":  0.001,
+		"kg": 1,
+		"oz": 0.028349523125,
+		"lb": 0.45359237,
+	}
+	dimensionUnits = map[string]float64{
+		"mm": 0.1,
+		"cm": 1,
+		"m":  100,
+		"in": 2.54,
+	}
+)
+
+// shippingClassPattern is the form of shipping classes such as standard,
+// bulky or hazmat
+var shippingClassPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Weight is the shipping weight of a product
+type Weight struct {
+	Value float64 `bson:"value" json:"value"`
+	Unit  string  `bson:"unit" json:"unit"`
+}
+
+// Dimensions are the packaged length, width and height of a product
+type Dimensions struct {
+	Length float64 `bson:"length" json:"length"`
+	Width  float64 `bson:"width" json:"width"`
+	Height float64 `bson:"height" json:"height"`
+	Unit   string  `bson:"unit" json:"unit"`
+}
+
+// Normalize returns the weight in WeightUnit, rounded to the gram. An
+// empty unit is WeightUnit.
+func (w Weight) Normalize() (Weight, error) {
+	factor, err := unitFactor(weightUnits, w.Unit, WeightUnit, "weight")
+	if err != nil {
+		return Weight{}, err
+	}
+	if !validMeasure(w.Value) {
+		return Weight{}, errors.New("weight must be a non-negative number")
+	}
+	return Weight{Value: round(w.Value*factor, 1000), Unit: WeightUnit}, nil
+}
+
+// Normalize returns the dimensions in DimensionUnit, rounded to the tenth
+// of a millimeter. An empty unit is DimensionUnit.
+func (d Dimensions) Normalize() (Dimensions, error) {
+	factor, err := unitFactor(dimensionUnits, d.Unit, DimensionUnit, "dimension")
+	if err != nil {
+		return Dimensions{}, err
+	}
+	if !validMeasure(d.Length) || !validMeasure(d.Width) || !validMeasure(d.Height) {
+		return Dimensions{}, errors.New("dimensions must be non-negative numbers")
+	}
+	return Dimensions{
+		Length: round(d.Length*factor, 100),
+		Width:  round(d.Width*factor, 100),
+		Height: round(d.Height*factor, 100),
+		Unit:   DimensionUnit,
+	}, nil
+}
+
+// ValidShippingClass reports whether class is a valid shipping class:
+// lowercase letters, digits, dashes and underscores, at most 32 long
+func ValidShippingClass(class string) bool {
+	return shippingClassPattern.MatchString(class)
+}
+
+func unitFactor(units map[string]float64, unit, fallback, what string) (float64, error) {
+	if unit == "" {
+		unit = fallback
+	}
+	factor, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown %s unit %q", what, unit)
+	}
+	return factor, nil
+}
+
+func validMeasure(v float64) bool {
+	return v >= 0 && !math.IsInf(v, 1)
+}
+
+func round(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
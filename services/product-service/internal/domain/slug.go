@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrSlugExists is returned when another product has, or had, the slug
+var ErrSlugExists = apperrors.New(apperrors.Conflict, "slug already exists").WithReason("SLUG_EXISTS")
+
+// Limits of the SEO fields of a product. The meta limits are about what
+// search engines show in results.
+const (
+	MaxSlugLength            = 80
+	MaxMetaTitleLength       = 70
+	MaxMetaDescriptionLength = 160
+	// MaxPreviousSlugs is how many earlier slugs of a product keep
+	// redirecting; the oldest are dropped first
+	MaxPreviousSlugs = 20
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Slugify turns a product name into a slug: lowercase ASCII letters and
+// digits separated by single dashes, with accents removed, such as
+// "creme-brulee-set" for "Crème Brûlée Set". It returns "product" for
+// names without any letter or digit it can keep.
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents left over from the decomposition
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+		if b.Len() >= MaxSlugLength {
+			break
+		}
+	}
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	if slug == "" {
+		return "product"
+	}
+	return slug
+}
+
+// NormalizeSlug lower-cases a slug given by an admin and checks its form
+func NormalizeSlug(slug string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if len(slug) > MaxSlugLength || !slugPattern.MatchString(slug) {
+		return "", fmt.Errorf("slug must be lowercase letters and digits separated by dashes, at most %d long", MaxSlugLength)
+	}
+	return slug, nil
+}
+
+// SlugCandidate returns a slug to try for a product whose name slugifies
+// to base: base itself without a suffix, otherwise base and the suffix,
+// such as mug-2
+func SlugCandidate(base, suffix string) string {
+	if suffix == "" {
+		return base
+	}
+	if len(base)+len(suffix)+1 > MaxSlugLength {
+		base = strings.TrimRight(base[:MaxSlugLength-len(suffix)-1], "-")
+	}
+	return base + "-" + suffix
+}
+
+// GeneratedFrom reports whether the slug could have been generated from
+// the name, with or without a suffix of digits and letters a to f, so
+// that it follows the product when it is renamed
+func GeneratedFrom(slug, name string) bool {
+	base := Slugify(name)
+	if slug == base {
+		return true
+	}
+	rest, ok := strings.CutPrefix(slug, base+"-")
+	if !ok || rest == "" {
+		return false
+	}
+	for _, r := range rest {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateMeta checks the lengths of the meta title and description
+func ValidateMeta(title, description string) error {
+	if utf8.RuneCountInString(title) > MaxMetaTitleLength {
+		return fmt.Errorf("meta title must be at most %d characters", MaxMetaTitleLength)
+	}
+	if utf8.RuneCountInString(description) > MaxMetaDescriptionLength {
+		return fmt.Errorf("meta description must be at most %d characters", MaxMetaDescriptionLength)
+	}
+	return nil
+}
+
+// ChangeSlug gives the product a new slug and keeps the current one among
+// the previous slugs, so that links to it still resolve. A previous slug
+// that becomes current again is removed from them.
+func (p *Product) ChangeSlug(slug string) {
+	if slug == p.Slug {
+		return
+	}
+	previous := make([]string, 0, len(p.PreviousSlugs)+1)
+	for _, old := range p.PreviousSlugs {
+		if old != slug {
+			previous = append(previous, old)
+		}
+	}
+	if p.Slug != "" {
+		previous = append(previous, p.Slug)
+	}
+	if len(previous) > MaxPreviousSlugs {
+		previous = previous[len(previous)-MaxPreviousSlugs:]
+	}
+	if len(previous) == 0 {
+		previous = nil
+	}
+	p.Slug, p.PreviousSlugs = slug, previous
+}
+
+// AllSlugs returns the current and previous slugs of the product
+func (p *Product) AllSlugs() []string {
+	if p.Slug == "" {
+		return p.PreviousSlugs
+	}
+	return append([]string{p.Slug}, p.PreviousSlugs...)
+}
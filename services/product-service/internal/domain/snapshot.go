@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxReportRange is the longest range of days inventory reports cover
+const MaxReportRange = 366 * 24 * time.Hour
+
+// SnapshotRetention is how long daily inventory snapshots are kept, long
+// enough to compare a report with the same range a year earlier
+const SnapshotRetention = 2 * MaxReportRange
+
+// InventorySnapshot is the stock of a physical product as captured on one
+// day. A product is captured once a day; capturing it again the same day
+// replaces the snapshot.
+type InventorySnapshot struct {
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	// Day is midnight UTC of the day captured
+	Day      time.Time `bson:"day" json:"day"`
+	Category string    `bson:"category" json:"category"`
+	SKU      string    `bson:"sku" json:"sku"`
+	Quantity int       `bson:"quantity" json:"quantity"`
+	Reserved int       `bson:"reserved" json:"reserved"`
+	// UnitPrice is the product's price when captured and Value the
+	// quantity at that price
+	UnitPrice  float64   `bson:"unit_price" json:"unit_price"`
+	Value      float64   `bson:"value" json:"value"`
+	CapturedAt time.Time `bson:"captured_at" json:"captured_at"`
+}
+
+// StockLevel is the stock captured on one day, summed over the products
+// selected
+type StockLevel struct {
+	Day      time.Time `bson:"_id" json:"day"`
+	Products int       `bson:"products" json:"products"`
+	Quantity int       `bson:"quantity" json:"quantity"`
+	Reserved int       `bson:"reserved" json:"reserved"`
+	Value    float64   `bson:"value" json:"value"`
+}
+
+// StockLevelParams selects the snapshots of stock level reports
+type StockLevelParams struct {
+	// From and To are the first and last day reported
+	From time.Time
+	To   time.Time
+	// Category and ProductID restrict the report, if set
+	Category  string
+	ProductID string
+}
+
+// CategoryStock is the stock of a category on one day
+type CategoryStock struct {
+	Category string `bson:"_id"`
+	Quantity int    `bson:"quantity"`
+}
+
+// CategoryMovement is how many units of a category were sold and received
+// within a range
+type CategoryMovement struct {
+	Category string `bson:"_id"`
+	Sold     int    `bson:"sold"`
+	Received int    `bson:"received"`
+}
+
+// CategorySellThrough is the share of the units available in a category
+// within a range that were sold: the opening stock and the units received
+// make up what was available
+type CategorySellThrough struct {
+	Category    string  `json:"category"`
+	Opening     int     `json:"opening"`
+	Received    int     `json:"received"`
+	Sold        int     `json:"sold"`
+	SellThrough float64 `json:"sell_through"`
+}
+
+// SellThroughReport is the sell-through of each category within a range
+type SellThroughReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// OpeningDay is the day the opening stock was captured: the day
+	// before From, or the first day captured after it. It is nil when no
+	// stock was captured, and the opening stock zero.
+	OpeningDay *time.Time            `json:"opening_day,omitempty"`
+	Categories []CategorySellThrough `json:"categories"`
+}
+
+// SellThroughRate returns sold / (opening + received), or 0 when nothing
+// was available
+func SellThroughRate(opening, received, sold int) float64 {
+	available := opening + received
+	if available <= 0 {
+		return 0
+	}
+	return float64(sold) / float64(available)
+}
+
+// SnapshotRepository stores daily inventory snapshots and reports on them
+type SnapshotRepository interface {
+	// Capture snapshots the stock of every physical product for the day
+	// and returns the number of products captured
+	Capture(ctx context.Context, day, at time.Time) (int, error)
+	// StockLevels returns the stock captured on each day of the range, in
+	// day order; days without snapshots are left out
+	StockLevels(ctx context.Context, params StockLevelParams) ([]StockLevel, error)
+	// OpeningStock returns the stock per category on the first day
+	// captured from the day before from up to to, and that day; the day
+	// is zero without snapshots in the range
+	OpeningStock(ctx context.Context, from, to time.Time) ([]CategoryStock, time.Time, error)
+	// Movements returns the units sold and received per category from
+	// from until to, excluding to
+	Movements(ctx context.Context, from, to time.Time) ([]CategoryMovement, error)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Product statuses. A product is authored as a draft, shown to shoppers
+// once published, and retired when it is no longer sold. The status is
+// independent of Active, which hides a published product for a while;
+// shoppers see products that are both published and active.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusRetired   = "retired"
+)
+
+// Status errors
+var (
+	// ErrStatusTransition is returned when a product cannot move from its
+	// status to the one asked for
+	ErrStatusTransition = apperrors.New(apperrors.Conflict, "invalid product status transition").WithReason("INVALID_STATUS_TRANSITION")
+)
+
+// ValidStatus reports whether status is a product status
+func ValidStatus(status string) bool {
+	return status == StatusDraft || status == StatusPublished || status == StatusRetired
+}
+
+// Visible reports whether shoppers see the product: whether it is both
+// published and active
+func (p *Product) Visible() bool {
+	return p.Status == StatusPublished && p.Active
+}
+
+// MissingForPublish returns the fields, by their JSON names, that the
+// product needs before it can be published and lacks
+func (p *Product) MissingForPublish() []string {
+	var missing []string
+	for _, field := range []struct {
+		name    string
+		present bool
+	}{
+		{"name", strings.TrimSpace(p.Name) != ""},
+		{"description", strings.TrimSpace(p.Description) != ""},
+		{"price", p.Price > 0},
+		{"category", p.Category != ""},
+		{"image_urls", len(p.ImageURLs) > 0},
+		{"inventory.sku", p.Inventory.SKU != ""},
+		{"slug", p.Slug != ""},
+	} {
+		if !field.present {
+			missing = append(missing, field.name)
+		}
+	}
+	return missing
+}
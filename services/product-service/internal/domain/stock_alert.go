@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrStockAlertNotFound is returned when a stock alert does not exist
+var ErrStockAlertNotFound = apperrors.New(apperrors.NotFound, "stock alert not found")
+
+// MaxStockAlertThreshold is the highest threshold a stock alert may have
+const MaxStockAlertThreshold = 1000000
+
+// StockAlertPagination configures paging of stock alert lists, which are
+// paged like product lists
+var StockAlertPagination = ProductPagination
+
+// StockAlert asks to be told when the stock of a product, or of any
+// product in a category or below it, falls below Threshold. Alerted lists
+// the products that are below it and have been alerted for; they are not
+// alerted for again until their stock is back at the threshold.
+type StockAlert struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID string             `bson:"product_id,omitempty" json:"product_id,omitempty"`
+	Category  string             `bson:"category,omitempty" json:"category,omitempty"`
+	Threshold int                `bson:"threshold" json:"threshold"`
+	Alerted   []string           `bson:"alerted" json:"alerted"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Validate checks that the alert watches either a product or a category,
+// and its threshold
+func (a *StockAlert) Validate() error {
+	if (a.ProductID == "") == (a.Category == "") {
+		return errors.New("stock alert must watch either a product or a category")
+	}
+	if a.ProductID != "" && !primitive.IsValidObjectID(a.ProductID) {
+		return errors.New("stock alert product ID must be a product ID")
+	}
+	if a.Threshold < 1 || a.Threshold > MaxStockAlertThreshold {
+		return errors.New("stock alert threshold must be between 1 and 1000000")
+	}
+	return nil
+}
+
+// Below reports whether a stock quantity is below the alert's threshold
+func (a *StockAlert) Below(quantity int) bool {
+	return quantity < a.Threshold
+}
+
+// HasAlerted reports whether the alert has fired for the product since its
+// stock last was at the threshold
+func (a *StockAlert) HasAlerted(productID string) bool {
+	return contains(a.Alerted, productID)
+}
+
+// StockAlertRepository defines the interface for stock alert storage
+type StockAlertRepository interface {
+	Create(ctx context.Context, alert *StockAlert) error
+	Get(ctx context.Context, id string) (*StockAlert, error)
+	Update(ctx context.Context, alert *StockAlert) error
+	Delete(ctx context.Context, id string) error
+	// List returns a page of all stock alerts, newest first, and how many
+	// there are
+	List(ctx context.Context, page pagination.Request) ([]*StockAlert, int, error)
+	// Matching returns the alerts watching the product or one of the
+	// categories
+	Matching(ctx context.Context, productID string, categories []string) ([]*StockAlert, error)
+	// MarkAlerted adds the product to the alert's Alerted, reporting
+	// false when it already was there, so that of concurrent updates only
+	// one alerts
+	MarkAlerted(ctx context.Context, id primitive.ObjectID, productID string) (bool, error)
+	// ClearAlerted removes the product from the alert's Alerted
+	ClearAlerted(ctx context.Context, id primitive.ObjectID, productID string) error
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Suggestion types
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+)
+
+// Suggestion is a completion offered for what a shopper has typed into
+// the search box: the name of a product or of a category
+type Suggestion struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	// ProductID is set on product suggestions
+	ProductID string `json:"product_id,omitempty"`
+	// Category is the product's category, or the category's key
+	Category string `json:"category,omitempty"`
+}
+
+// SuggestOptions configures search suggestions
+type SuggestOptions struct {
+	// DefaultLimit is the number of suggestions returned when the caller
+	// asks for none in particular, and MaxLimit the most it may ask for
+	DefaultLimit int
+	MaxLimit     int
+	// Budget is how long finding suggestions may take; past it the
+	// suggestions found so far are returned
+	Budget time.Duration
+}
+
+// DefaultSuggestOptions are the suggestion options used unless configured
+var DefaultSuggestOptions = SuggestOptions{DefaultLimit: 8, MaxLimit: 20, Budget: 150 * time.Millisecond}
+
+// MaxSuggestPrefix is the longest text, in bytes, suggestions are found for
+const MaxSuggestPrefix = 100
+
+// NormalizeSearchName returns the form of a name that search prefixes are
+// matched against: lowercase with single spaces between words
+func NormalizeSearchName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Supplier errors
+var (
+	ErrSupplierNotFound   = apperrors.New(apperrors.NotFound, "supplier not found")
+	ErrSupplierCodeExists = apperrors.New(apperrors.Conflict, "supplier code already exists").WithReason("SUPPLIER_CODE_EXISTS")
+	ErrSupplierInUse      = apperrors.New(apperrors.Conflict, "supplier is in use").WithReason("SUPPLIER_IN_USE")
+)
+
+// Supplier is a vendor products are purchased from
+type Supplier struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code         string             `bson:"code" json:"code"`
+	Name         string             `bson:"name" json:"name"`
+	ContactName  string             `bson:"contact_name" json:"contact_name"`
+	Email        string             `bson:"email" json:"email"`
+	Phone        string             `bson:"phone" json:"phone"`
+	LeadTimeDays int                `bson:"lead_time_days" json:"lead_time_days"` // Default for products without their own
+	Active       bool               `bson:"active" json:"active"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ProductSupplier links a product to a supplier it can be reordered from
+type ProductSupplier struct {
+	SupplierID   primitive.ObjectID `bson:"supplier_id" json:"supplier_id"`
+	SupplierSKU  string             `bson:"supplier_sku" json:"supplier_sku"`
+	LeadTimeDays int                `bson:"lead_time_days" json:"lead_time_days"` // 0 uses the supplier's default
+	Preferred    bool               `bson:"preferred" json:"preferred"`
+}
+
+// SupplierRepository defines the interface for supplier data operations
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *Supplier) error
+	GetByID(ctx context.Context, id string) (*Supplier, error)
+	Update(ctx context.Context, supplier *Supplier) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, activeOnly bool) ([]*Supplier, error)
+}
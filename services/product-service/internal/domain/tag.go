@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// TagCount is a tag used on products and how many products carry it
+type TagCount struct {
+	Tag      string `bson:"_id" json:"tag"`
+	Products int    `bson:"products" json:"products"`
+}
+
+// DefaultTagsCacheTTL is how long the tags in use are cached by default
+const DefaultTagsCacheTTL = time.Minute
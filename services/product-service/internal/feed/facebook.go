@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// facebookColumns are the columns of a Facebook catalog CSV
+var facebookColumns = []string{
+	"id", "title", "description", "availability", "condition", "price", "sale_price", "link",
+	"image_link", "additional_image_link", "brand", "gtin", "product_type",
+}
+
+// facebookAvailability names the availabilities as Facebook catalogs do
+var facebookAvailability = map[string]string{
+	InStock:    "in stock",
+	OutOfStock: "out of stock",
+	Preorder:   "preorder",
+}
+
+// FacebookWriter writes a Facebook catalog CSV one product at a time
+type FacebookWriter struct {
+	csv     *csv.Writer
+	opts    Options
+	now     time.Time
+	started bool
+}
+
+// NewFacebookWriter creates a writer of a Facebook catalog CSV to w
+func NewFacebookWriter(w io.Writer, opts Options, now time.Time) *FacebookWriter {
+	return &FacebookWriter{csv: csv.NewWriter(w), opts: opts, now: now}
+}
+
+func (f *FacebookWriter) start() error {
+	if f.started {
+		return nil
+	}
+	f.started = true
+	return f.csv.Write(facebookColumns)
+}
+
+// Write writes a product's row
+func (f *FacebookWriter) Write(product *domain.Product) error {
+	if err := f.start(); err != nil {
+		return err
+	}
+	item := NewItem(product, f.opts, f.now)
+	return f.csv.Write([]string{
+		item.ID,
+		item.Title,
+		item.Description,
+		facebookAvailability[item.Availability],
+		item.Condition,
+		item.Price,
+		item.SalePrice,
+		item.Link,
+		item.ImageLink,
+		strings.Join(item.AdditionalImages, ","),
+		item.Brand,
+		item.GTIN,
+		item.ProductType,
+	})
+}
+
+// Close writes what is buffered
+func (f *FacebookWriter) Close() error {
+	if err := f.start(); err != nil {
+		return err
+	}
+	f.csv.Flush()
+	return f.csv.Error()
+}
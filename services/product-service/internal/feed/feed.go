@@ -0,0 +1,130 @@
+// Package feed renders the catalog as the product feeds shopping ads are
+// built from: Google Merchant Center XML and Facebook catalog CSV
+package feed
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// MaxAdditionalImages is the most images listed besides the main one, as
+// Google Merchant Center accepts
+const MaxAdditionalImages = 10
+
+// Options describe the store the feed is for
+type Options struct {
+	// Title names the feed
+	Title string
+	// StoreURL is the storefront's home page
+	StoreURL string
+	// ProductURL is the storefront page of a product, with {slug} or {id}
+	// standing for the product's slug or ID
+	ProductURL string
+	// Currency is the currency catalog prices are in
+	Currency string
+	// Brand is the brand of products without a brand attribute
+	Brand string
+}
+
+// Availability of an item
+const (
+	InStock    = "in_stock"
+	OutOfStock = "out_of_stock"
+	Preorder   = "preorder"
+)
+
+// Item is a product as described to a shopping ads platform
+type Item struct {
+	ID               string
+	Title            string
+	Description      string
+	Link             string
+	ImageLink        string
+	AdditionalImages []string
+	Availability     string
+	// AvailabilityDate is when a product on preorder is released
+	AvailabilityDate *time.Time
+	// Price is the regular price and SalePrice, if any, the price the
+	// product sells at now, both with their currency
+	Price       string
+	SalePrice   string
+	Brand       string
+	GTIN        string
+	Condition   string
+	ProductType string
+	// ShippingWeight is the weight with its unit, if known
+	ShippingWeight string
+}
+
+// NewItem describes a product. The product's promotions are expected to
+// be applied.
+func NewItem(product *domain.Product, opts Options, now time.Time) Item {
+	item := Item{
+		ID:          product.ID.Hex(),
+		Title:       product.Name,
+		Description: product.Description,
+		Link:        productURL(product, opts.ProductURL),
+		Brand:       product.Attributes["brand"],
+		GTIN:        product.Barcode,
+		Condition:   "new",
+		ProductType: product.Category,
+	}
+	if item.Description == "" {
+		item.Description = product.Name
+	}
+	if item.Brand == "" {
+		item.Brand = opts.Brand
+	}
+	if len(product.ImageURLs) > 0 {
+		item.ImageLink = product.ImageURLs[0]
+		item.AdditionalImages = product.ImageURLs[1:min(len(product.ImageURLs), MaxAdditionalImages+1)]
+	}
+
+	switch {
+	case product.OnPreorder() && product.ReleaseDate != nil && product.ReleaseDate.After(now):
+		item.Availability = Preorder
+		item.AvailabilityDate = product.ReleaseDate
+	case product.HasStock():
+		item.Availability = InStock
+	default:
+		item.Availability = OutOfStock
+	}
+
+	// Discounted products are listed at their regular price with the price
+	// they sell at as the sale price
+	regular := product.Price
+	if product.CompareAtPrice != nil && *product.CompareAtPrice > product.Price {
+		regular = *product.CompareAtPrice
+	}
+	item.Price = formatPrice(regular, opts.Currency)
+	selling := product.Price
+	if product.DiscountedPrice != nil {
+		selling = *product.DiscountedPrice
+	}
+	if selling < regular {
+		item.SalePrice = formatPrice(selling, opts.Currency)
+	}
+
+	if product.Weight != nil && product.Weight.Value > 0 {
+		item.ShippingWeight = strconv.FormatFloat(product.Weight.Value, 'f', -1, 64) + " " + product.Weight.Unit
+	}
+	return item
+}
+
+// productURL fills the product's slug and ID into the product URL
+// template. Products without a slug are linked by ID.
+func productURL(product *domain.Product, template string) string {
+	slug := product.Slug
+	if slug == "" {
+		slug = product.ID.Hex()
+	}
+	return strings.NewReplacer("{slug}", url.PathEscape(slug), "{id}", product.ID.Hex()).Replace(template)
+}
+
+func formatPrice(price float64, currency string) string {
+	return strconv.FormatFloat(price, 'f', 2, 64) + " " + currency
+}
@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	now  = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts = Options{ProductURL: "https://shop.example.com/p/{slug}?id={id}", Currency: "EUR", Brand: "House"}
+)
+
+func TestNewItem(t *testing.T) {
+	id := primitive.NewObjectID()
+	discounted := 7.0
+	product := &domain.Product{
+		ID:              id,
+		Name:            "Mug",
+		Slug:            "red mug",
+		Price:           9,
+		DiscountedPrice: &discounted,
+		Barcode:         "4006381333931",
+		Category:        "kitchen",
+		Attributes:      map[string]string{"brand": "Acme"},
+		Inventory:       domain.InventoryInfo{Quantity: 3, InStock: true},
+		Weight:          &domain.Weight{Value: 0.35, Unit: "kg"},
+	}
+
+	item := NewItem(product, opts, now)
+	assert.Equal(t, "https://shop.example.com/p/red%20mug?id="+id.Hex(), item.Link)
+	assert.Equal(t, InStock, item.Availability)
+	assert.Equal(t, "9.00 EUR", item.Price)
+	assert.Equal(t, "7.00 EUR", item.SalePrice)
+	assert.Equal(t, "Acme", item.Brand)
+	assert.Equal(t, "4006381333931", item.GTIN)
+	assert.Equal(t, "Mug", item.Description)
+	assert.Equal(t, "0.35 kg", item.ShippingWeight)
+}
+
+func TestNewItemCompareAtPrice(t *testing.T) {
+	compareAt := 12.0
+	item := NewItem(&domain.Product{Name: "Mug", Price: 9, CompareAtPrice: &compareAt}, opts, now)
+	assert.Equal(t, "12.00 EUR", item.Price)
+	assert.Equal(t, "9.00 EUR", item.SalePrice)
+	assert.Equal(t, "House", item.Brand)
+	assert.Equal(t, OutOfStock, item.Availability)
+}
+
+func TestNewItemPreorder(t *testing.T) {
+	release := now.Add(14 * 24 * time.Hour)
+	product := &domain.Product{Name: "Kettle", Price: 30, ReleaseDate: &release, Preorder: &domain.PreorderInfo{Allocation: 50}}
+
+	item := NewItem(product, opts, now)
+	assert.Equal(t, Preorder, item.Availability)
+	assert.Equal(t, &release, item.AvailabilityDate)
+
+	// Once released, the product is listed by its stock
+	item = NewItem(product, opts, release.Add(time.Hour))
+	assert.Equal(t, OutOfStock, item.Availability)
+	assert.Nil(t, item.AvailabilityDate)
+}
+
+func TestNewItemImages(t *testing.T) {
+	urls := make([]string, 15)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://img.example.com/%d.png", i)
+	}
+
+	item := NewItem(&domain.Product{Name: "Mug", ImageURLs: urls}, opts, now)
+	assert.Equal(t, urls[0], item.ImageLink)
+	assert.Equal(t, urls[1:MaxAdditionalImages+1], item.AdditionalImages)
+}
@@ -0,0 +1,111 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// GoogleNamespace is the namespace of Google Merchant Center attributes
+const GoogleNamespace = "http://base.google.com/ns/1.0"
+
+// googleItem is an item of an RSS 2.0 Google Merchant Center feed
+type googleItem struct {
+	XMLName          xml.Name `xml:"item"`
+	ID               string   `xml:"g:id"`
+	Title            string   `xml:"title"`
+	Description      string   `xml:"description"`
+	Link             string   `xml:"link"`
+	ImageLink        string   `xml:"g:image_link,omitempty"`
+	AdditionalImages []string `xml:"g:additional_image_link,omitempty"`
+	Availability     string   `xml:"g:availability"`
+	AvailabilityDate string   `xml:"g:availability_date,omitempty"`
+	Price            string   `xml:"g:price"`
+	SalePrice        string   `xml:"g:sale_price,omitempty"`
+	Brand            string   `xml:"g:brand,omitempty"`
+	GTIN             string   `xml:"g:gtin,omitempty"`
+	IdentifierExists string   `xml:"g:identifier_exists,omitempty"`
+	Condition        string   `xml:"g:condition"`
+	ProductType      string   `xml:"g:product_type,omitempty"`
+	ShippingWeight   string   `xml:"g:shipping_weight,omitempty"`
+}
+
+// GoogleWriter writes a Google Merchant Center feed, an RSS 2.0 channel of
+// items, one product at a time
+type GoogleWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	opts    Options
+	now     time.Time
+	started bool
+}
+
+// NewGoogleWriter creates a writer of a Google Merchant Center feed to w
+func NewGoogleWriter(w io.Writer, opts Options, now time.Time) *GoogleWriter {
+	return &GoogleWriter{w: w, encoder: xml.NewEncoder(w), opts: opts, now: now}
+}
+
+// start writes the channel up to its first item
+func (g *GoogleWriter) start() error {
+	if g.started {
+		return nil
+	}
+	g.started = true
+	if _, err := io.WriteString(g.w, xml.Header+`<rss version="2.0" xmlns:g="`+GoogleNamespace+`"><channel>`); err != nil {
+		return err
+	}
+	channel := []struct {
+		name, value string
+	}{{"title", g.opts.Title}, {"link", g.opts.StoreURL}, {"description", g.opts.Title}}
+	for _, element := range channel {
+		if err := g.encoder.EncodeElement(element.value, xml.StartElement{Name: xml.Name{Local: element.name}}); err != nil {
+			return err
+		}
+	}
+	return g.encoder.Flush()
+}
+
+// Write writes a product's item
+func (g *GoogleWriter) Write(product *domain.Product) error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	item := NewItem(product, g.opts, g.now)
+	entry := googleItem{
+		ID:               item.ID,
+		Title:            item.Title,
+		Description:      item.Description,
+		Link:             item.Link,
+		ImageLink:        item.ImageLink,
+		AdditionalImages: item.AdditionalImages,
+		Availability:     item.Availability,
+		Price:            item.Price,
+		SalePrice:        item.SalePrice,
+		Brand:            item.Brand,
+		GTIN:             item.GTIN,
+		Condition:        item.Condition,
+		ProductType:      item.ProductType,
+		ShippingWeight:   item.ShippingWeight,
+	}
+	if item.AvailabilityDate != nil {
+		entry.AvailabilityDate = item.AvailabilityDate.UTC().Format(time.RFC3339)
+	}
+	if item.GTIN == "" {
+		entry.IdentifierExists = "no"
+	}
+	return g.encoder.Encode(entry)
+}
+
+// Close ends the channel
+func (g *GoogleWriter) Close() error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	if err := g.encoder.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(g.w, "</channel></rss>\n")
+	return err
+}
@@ -0,0 +1,131 @@
+// Package invalidation tells every instance of the service that a cache
+// it keeps in memory is stale, when another instance has changed what the
+// cache holds, over Redis pub/sub
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// retryDelay is how long Listen waits before subscribing or receiving
+// again after Redis failed
+const retryDelay = time.Second
+
+// message is an invalidation as published on the channel
+type message struct {
+	// Origin is the instance that published it, which has dropped its
+	// own cache already
+	Origin string `json:"origin"`
+	Cache  string `json:"cache"`
+}
+
+// RedisBroadcaster publishes invalidations to, and receives them from, a
+// Redis pub/sub channel shared by the instances. Pub/sub does not keep
+// messages: an instance that is disconnected misses them, and its caches
+// stay stale until they expire or are refreshed.
+type RedisBroadcaster struct {
+	client  *redis.Client
+	channel string
+	origin  string
+	logger  *slog.Logger
+	retry   time.Duration
+}
+
+// NewRedisBroadcaster creates a broadcaster on the given channel
+func NewRedisBroadcaster(client *redis.Client, channel string, logger *slog.Logger) *RedisBroadcaster {
+	hostname, _ := os.Hostname()
+	return &RedisBroadcaster{
+		client:  client,
+		channel: channel,
+		origin:  fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		logger:  logger,
+		retry:   retryDelay,
+	}
+}
+
+// Invalidate tells the other instances that the named cache is stale
+func (b *RedisBroadcaster) Invalidate(ctx context.Context, cache string) error {
+	payload, err := json.Marshal(message{Origin: b.origin, Cache: cache})
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation: %w", err)
+	}
+	if err := b.client.Publish(ctx, b.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish invalidation of %s: %w", cache, err)
+	}
+	return nil
+}
+
+// Listen calls drop with each cache other instances invalidate. It returns
+// when ctx is cancelled; Redis failures, including at startup, are logged
+// and retried.
+func (b *RedisBroadcaster) Listen(ctx context.Context, drop func(ctx context.Context, cache string)) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// Wait for the subscription, so that an instance started while Redis
+	// is unreachable subscribes once it is back. The client subscribes
+	// again on each receive.
+	for {
+		_, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			break
+		}
+		b.logger.Warn("Failed to subscribe to cache invalidations", "channel", b.channel, "error", err)
+		if !sleep(ctx, b.retry) {
+			return
+		}
+	}
+	b.logger.Info("Listening for cache invalidations", "channel", b.channel)
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// The client resubscribes on the next receive
+			b.logger.Warn("Failed to receive cache invalidation", "error", err)
+			if !sleep(ctx, b.retry) {
+				return
+			}
+			continue
+		}
+		b.handle(ctx, msg.Payload, drop)
+	}
+}
+
+// handle calls drop with the cache invalidated by payload, unless this
+// instance published it or it is malformed
+func (b *RedisBroadcaster) handle(ctx context.Context, payload string, drop func(ctx context.Context, cache string)) {
+	var invalidation message
+	if err := json.Unmarshal([]byte(payload), &invalidation); err != nil || invalidation.Cache == "" {
+		b.logger.Warn("Ignoring malformed cache invalidation", "payload", payload, "error", err)
+		return
+	}
+	if invalidation.Origin == b.origin {
+		return
+	}
+	drop(ctx, invalidation.Cache)
+}
+
+// sleep waits for d, or reports false if ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
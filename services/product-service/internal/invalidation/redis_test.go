@@ -0,0 +1,98 @@
+package invalidation
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// drops records the caches dropped by Listen
+type drops struct {
+	mu     sync.Mutex
+	caches []string
+}
+
+func (d *drops) drop(_ context.Context, cache string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.caches = append(d.caches, cache)
+}
+
+func (d *drops) get() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.caches...)
+}
+
+func TestHandle(t *testing.T) {
+	b := &RedisBroadcaster{origin: "host-1", logger: discard}
+	var dropped drops
+
+	for _, payload := range []string{
+		`{"origin":"host-2","cache":"tags"}`,
+		`{"origin":"host-1","cache":"badges"}`, // published by this instance
+		`{"origin":"host-2"}`,                  // no cache
+		`not json`,
+		`{"origin":"host-3","cache":"promotions"}`,
+	} {
+		b.handle(context.Background(), payload, dropped.drop)
+	}
+
+	assert.Equal(t, []string{"tags", "promotions"}, dropped.get())
+}
+
+func TestListenRetriesUnreachableRedis(t *testing.T) {
+	// Nothing listens on port 1
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	var logs strings.Builder
+	b := NewRedisBroadcaster(client, "invalidations", slog.New(slog.NewTextHandler(&logs, nil)))
+	b.retry = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	b.Listen(ctx, func(context.Context, string) {})
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "Listen keeps retrying until ctx is cancelled")
+	assert.Greater(t, strings.Count(logs.String(), "Failed to subscribe to cache invalidations"), 1)
+}
+
+// TestInvalidateRoundTrip runs against Redis when PRODUCT_TEST_REDIS_ADDR
+// is set
+func TestInvalidateRoundTrip(t *testing.T) {
+	addr := os.Getenv("PRODUCT_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("PRODUCT_TEST_REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	channel := "invalidations-test-" + time.Now().Format("150405.000000000")
+	listener := NewRedisBroadcaster(client, channel, discard)
+	publisher := NewRedisBroadcaster(client, channel, discard)
+	publisher.origin = "other-instance"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var dropped drops
+	go listener.Listen(ctx, dropped.drop)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, listener.Invalidate(ctx, "badges"))
+		require.NoError(t, publisher.Invalidate(ctx, "tags"))
+		return len(dropped.get()) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.NotContains(t, dropped.get(), "badges", "own invalidations are ignored")
+	assert.Contains(t, dropped.get(), "tags")
+}
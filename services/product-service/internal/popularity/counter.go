@@ -0,0 +1,71 @@
+// Package popularity counts product views and purchases between flushes to
+// the popularity repository
+package popularity
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// key identifies the counts of a product on one day
+type key struct {
+	productID primitive.ObjectID
+	day       int64
+}
+
+// MemoryCounter keeps counts in memory, so each instance flushes its own
+type MemoryCounter struct {
+	mu     sync.Mutex
+	counts map[key]*domain.PopularityCount
+}
+
+// NewMemoryCounter creates an empty MemoryCounter
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{counts: make(map[key]*domain.PopularityCount)}
+}
+
+// Add adds count to the counts of its product and day
+func (c *MemoryCounter) Add(_ context.Context, count domain.PopularityCount) error {
+	count.Day = domain.Day(count.Day)
+	k := key{count.ProductID, count.Day.Unix()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.counts[k]; ok {
+		existing.Views += count.Views
+		existing.Purchases += count.Purchases
+		return nil
+	}
+	c.counts[k] = &count
+	return nil
+}
+
+// Drain returns and resets the counts added since the last drain
+func (c *MemoryCounter) Drain(context.Context) ([]domain.PopularityCount, error) {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = make(map[key]*domain.PopularityCount)
+	c.mu.Unlock()
+
+	return sorted(counts), nil
+}
+
+// sorted returns the counts by day and product, so that flushes write in
+// a stable order
+func sorted(counts map[key]*domain.PopularityCount) []domain.PopularityCount {
+	list := make([]domain.PopularityCount, 0, len(counts))
+	for _, count := range counts {
+		list = append(list, *count)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Day.Equal(list[j].Day) {
+			return list[i].Day.Before(list[j].Day)
+		}
+		return list[i].ProductID.Hex() < list[j].ProductID.Hex()
+	})
+	return list
+}
@@ -0,0 +1,43 @@
+package popularity
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryCounter(t *testing.T) {
+	counter := NewMemoryCounter()
+	mug, plate := primitive.NewObjectID(), primitive.NewObjectID()
+	today := time.Date(2024, 3, 2, 15, 4, 5, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, counter.Add(context.Background(), domain.PopularityCount{ProductID: mug, Day: today, Views: 1}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, counter.Add(context.Background(), domain.PopularityCount{ProductID: mug, Day: today.Add(time.Hour), Purchases: 2}))
+	require.NoError(t, counter.Add(context.Background(), domain.PopularityCount{ProductID: plate, Day: yesterday, Views: 3}))
+
+	counts, err := counter.Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PopularityCount{
+		{ProductID: plate, Day: domain.Day(yesterday), Views: 3},
+		{ProductID: mug, Day: domain.Day(today), Views: 50, Purchases: 2},
+	}, counts, "counts are merged per product and day, oldest first")
+
+	counts, err = counter.Drain(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
@@ -0,0 +1,101 @@
+package popularity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// drainScript returns and deletes the pending counts in one step, so that
+// counts added meanwhile are left for the next drain and no two instances
+// drain the same counts
+var drainScript = redis.NewScript(`
+local counts = redis.call('HGETALL', KEYS[1])
+redis.call('DEL', KEYS[1])
+return counts
+`)
+
+// dayLayout formats days in hash fields
+const dayLayout = "20060102"
+
+// RedisCounter keeps counts in a Redis hash shared by all instances. Its
+// fields are <product ID>:<day>:<v or p>.
+type RedisCounter struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisCounter creates a counter keeping counts under <prefix>:pending
+func NewRedisCounter(client *redis.Client, prefix string) *RedisCounter {
+	if prefix == "" {
+		prefix = "popularity"
+	}
+	return &RedisCounter{client: client, key: prefix + ":pending"}
+}
+
+// Add adds count to the counts of its product and day
+func (c *RedisCounter) Add(ctx context.Context, count domain.PopularityCount) error {
+	field := count.ProductID.Hex() + ":" + domain.Day(count.Day).Format(dayLayout) + ":"
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if count.Views != 0 {
+			pipe.HIncrBy(ctx, c.key, field+"v", count.Views)
+		}
+		if count.Purchases != 0 {
+			pipe.HIncrBy(ctx, c.key, field+"p", count.Purchases)
+		}
+		return nil
+	})
+	return err
+}
+
+// Drain returns and resets the counts added by all instances since the
+// last drain
+func (c *RedisCounter) Drain(ctx context.Context) ([]domain.PopularityCount, error) {
+	values, err := drainScript.Run(ctx, c.client, []string{c.key}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[key]*domain.PopularityCount)
+	for i := 0; i+1 < len(values); i += 2 {
+		field, value := values[i], values[i+1]
+		parts := strings.Split(field, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed popularity field %q", field)
+		}
+		productID, err := primitive.ObjectIDFromHex(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("malformed popularity field %q: %w", field, err)
+		}
+		day, err := time.Parse(dayLayout, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed popularity field %q: %w", field, err)
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed popularity count %q: %w", value, err)
+		}
+
+		k := key{productID, day.Unix()}
+		count, ok := counts[k]
+		if !ok {
+			count = &domain.PopularityCount{ProductID: productID, Day: day}
+			counts[k] = count
+		}
+		switch parts[2] {
+		case "v":
+			count.Views += n
+		case "p":
+			count.Purchases += n
+		default:
+			return nil, fmt.Errorf("malformed popularity field %q", field)
+		}
+	}
+	return sorted(counts), nil
+}
@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository implements the domain.AuditRepository interface with
+// MongoDB
+type AuditRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewAuditRepository creates a new AuditRepository. The values of field
+// changes are read back as maps rather than ordered documents, so that
+// they are written out as JSON objects.
+func NewAuditRepository(client *mongo.Client, cfg *config.MongoDBConfig) *AuditRepository {
+	opts := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &AuditRepository{
+		collection: client.Database(cfg.Database).Collection("product_audit", opts),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes a product's trail is listed with, and
+// the one recording each inventory operation once
+func (r *AuditRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "at", Value: -1}, {Key: "_id", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "operation_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"operation_id": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+	return nil
+}
+
+// Record inserts an audit entry. An inventory operation already recorded
+// for the product is skipped.
+func (r *AuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) && entry.OperationID != "" {
+		return nil
+	}
+	return err
+}
+
+// List returns a page of a product's audit trail, newest first
+func (r *AuditRepository) List(ctx context.Context, productID string, page pagination.Request) ([]*domain.AuditEntry, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{"product_id": productID}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.PageSize))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*domain.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, int(total), nil
+}
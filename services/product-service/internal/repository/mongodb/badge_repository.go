@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BadgeRepository implements the domain.BadgeRepository interface with
+// MongoDB. Badges are keyed by their key, so no index is needed.
+type BadgeRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewBadgeRepository creates a new BadgeRepository
+func NewBadgeRepository(client *mongo.Client, cfg *config.MongoDBConfig) *BadgeRepository {
+	return &BadgeRepository{
+		collection: client.Database(cfg.Database).Collection("badges"),
+		config:     cfg,
+	}
+}
+
+// Create inserts a new badge
+func (r *BadgeRepository) Create(ctx context.Context, badge *domain.Badge) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, badge)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrBadgeExists
+	}
+	return err
+}
+
+// Get retrieves a badge by its key
+func (r *BadgeRepository) Get(ctx context.Context, key string) (*domain.Badge, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var badge domain.Badge
+	err := r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&badge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrBadgeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &badge, nil
+}
+
+// Update replaces an existing badge
+func (r *BadgeRepository) Update(ctx context.Context, badge *domain.Badge) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": badge.Key}, badge)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrBadgeNotFound
+	}
+	return nil
+}
+
+// Delete removes a badge by its key
+func (r *BadgeRepository) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrBadgeNotFound
+	}
+	return nil
+}
+
+// List returns all badges, highest priority first
+func (r *BadgeRepository) List(ctx context.Context) ([]*domain.Badge, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var badges []*domain.Badge
+	if err := cursor.All(ctx, &badges); err != nil {
+		return nil, err
+	}
+	return badges, nil
+}
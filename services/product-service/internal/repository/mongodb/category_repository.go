@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CategoryRepository implements the domain.CategoryRepository interface
+// with MongoDB. Categories are keyed by their key.
+type CategoryRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewCategoryRepository creates a new CategoryRepository
+func NewCategoryRepository(client *mongo.Client, cfg *config.MongoDBConfig) *CategoryRepository {
+	return &CategoryRepository{
+		collection: client.Database(cfg.Database).Collection("categories"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the index on ancestor paths that descendant
+// lookups use
+func (r *CategoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "path", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create category indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new category
+func (r *CategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrCategoryExists
+	}
+	return err
+}
+
+// Get retrieves a category by its key
+func (r *CategoryRepository) Get(ctx context.Context, key string) (*domain.Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var category domain.Category
+	err := r.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&category)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Update replaces an existing category
+func (r *CategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.Key}, category)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrCategoryNotFound
+	}
+	return nil
+}
+
+// Delete removes a category by its key
+func (r *CategoryRepository) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrCategoryNotFound
+	}
+	return nil
+}
+
+// List returns all categories, by key
+func (r *CategoryRepository) List(ctx context.Context) ([]*domain.Category, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// Descendants returns the categories whose path contains key
+func (r *CategoryRepository) Descendants(ctx context.Context, key string) ([]*domain.Category, error) {
+	return r.find(ctx, bson.M{"path": key})
+}
+
+func (r *CategoryRepository) find(ctx context.Context, filter bson.M) ([]*domain.Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*domain.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
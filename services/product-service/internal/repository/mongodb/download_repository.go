@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DownloadRepository implements the domain.DownloadRepository interface
+// with MongoDB. product_downloads holds a document per order and digital
+// product.
+type DownloadRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewDownloadRepository creates a new DownloadRepository
+func NewDownloadRepository(client *mongo.Client, cfg *config.MongoDBConfig) *DownloadRepository {
+	return &DownloadRepository{
+		collection: client.Database(cfg.Database).Collection("product_downloads"),
+		config:     cfg,
+	}
+}
+
+func downloadID(orderID, productID string) bson.D {
+	return bson.D{{Key: "order_id", Value: orderID}, {Key: "product_id", Value: productID}}
+}
+
+// Record counts a download in a single upsert. Once the limit is reached
+// the filter no longer matches and the upsert collides with the existing
+// document, which reports the limit.
+func (r *DownloadRepository) Record(ctx context.Context, orderID, productID, userID string, max int) (*domain.DownloadGrant, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	filter := bson.M{"_id": downloadID(orderID, productID)}
+	if max > 0 {
+		filter["downloads"] = bson.M{"$lt": max}
+	}
+	now := time.Now()
+	update := bson.M{
+		"$inc": bson.M{"downloads": 1},
+		"$set": bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{
+			"order_id":   orderID,
+			"product_id": productID,
+			"user_id":    userID,
+			"created_at": now,
+		},
+	}
+
+	var grant domain.DownloadGrant
+	err := r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&grant)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, domain.ErrDownloadLimitReached
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Revert decrements the count, never below zero
+func (r *DownloadRepository) Revert(ctx context.Context, orderID, productID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": downloadID(orderID, productID), "downloads": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"downloads": -1}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
+
+// SetLicenseKey keeps the first key stored for an order's product
+func (r *DownloadRepository) SetLicenseKey(ctx context.Context, orderID, productID, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"license_key": bson.M{"$ifNull": bson.A{"$license_key", key}},
+	}}}}
+	var grant domain.DownloadGrant
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": downloadID(orderID, productID)}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&grant)
+	if err != nil {
+		return "", err
+	}
+	return grant.LicenseKey, nil
+}
@@ -0,0 +1,115 @@
+// Package migrations transforms product documents from one schema version
+// to the next. Every product records the version of its shape in
+// schema_version; a migration rewrites the documents below its version,
+// in batches, and records its progress so that an interrupted run resumes
+// where it stopped.
+//
+// To change the shape of products, add a migration to All with the next
+// version, bump domain.ProductSchemaVersion, and run cmd/migrate-mongo
+// before deploying code that relies on the new shape.
+package migrations
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Migration rewrites product documents to its Version from the one before
+type Migration struct {
+	Version     int
+	Description string
+
+	// Up returns the update for one document, in update operator form
+	// ($set, $unset...), or nil if only its schema_version changes. It must
+	// accept documents it has already rewritten.
+	Up func(doc bson.M) (bson.M, error)
+}
+
+// All lists the migrations in version order. Versions start at 1 and have
+// no gaps; documents without a schema_version are at version 0.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "fill in fields that older documents lack or store as null",
+		Up:          normalizeProduct,
+	},
+	{
+		Version:     2,
+		Description: "publish products written before the draft and publish workflow",
+		Up:          publishProduct,
+	},
+}
+
+// Latest returns the version of the last migration
+func Latest() int {
+	if len(All) == 0 {
+		return 0
+	}
+	return All[len(All)-1].Version
+}
+
+// normalizeProduct gives documents written before lists, attributes,
+// reservations and soft deletion existed the values the service writes today
+func normalizeProduct(doc bson.M) (bson.M, error) {
+	set := bson.M{}
+	for _, field := range []string{"image_urls", "tags"} {
+		if doc[field] == nil {
+			set[field] = bson.A{}
+		}
+	}
+	if doc["attributes"] == nil {
+		set["attributes"] = bson.M{}
+	}
+	if _, ok := doc["active"]; !ok {
+		set["active"] = true
+	}
+
+	inventory := document(doc["inventory"])
+	if _, ok := inventory["reserved"]; !ok {
+		set["inventory.reserved"] = 0
+	}
+	if inStock, ok := inventory["in_stock"].(bool); !ok || inStock != (number(inventory["quantity"]) > 0) {
+		set["inventory.in_stock"] = number(inventory["quantity"]) > 0
+	}
+
+	if len(set) == 0 {
+		return nil, nil
+	}
+	return bson.M{"$set": set}, nil
+}
+
+// publishProduct gives documents without a status the published status,
+// so that products listed before statuses existed stay listed
+func publishProduct(doc bson.M) (bson.M, error) {
+	if status, ok := doc["status"].(string); ok && status != "" {
+		return nil, nil
+	}
+	return bson.M{"$set": bson.M{"status": "published"}}, nil
+}
+
+// document returns an embedded document as a map, or nil
+func document(v interface{}) bson.M {
+	switch v := v.(type) {
+	case bson.M:
+		return v
+	case bson.D:
+		m := make(bson.M, len(v))
+		for _, e := range v {
+			m[e.Key] = e.Value
+		}
+		return m
+	}
+	return nil
+}
+
+// number returns a BSON number as a float64, or 0
+func number(v interface{}) float64 {
+	switch v := v.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
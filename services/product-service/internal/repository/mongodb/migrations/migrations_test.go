@@ -0,0 +1,156 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range All {
+		assert.Equal(t, i+1, m.Version, "versions start at 1 and have no gaps")
+		assert.NotEmpty(t, m.Description)
+		assert.NotNil(t, m.Up)
+	}
+	assert.Equal(t, domain.ProductSchemaVersion, Latest(), "domain.ProductSchemaVersion is the last migration's version")
+}
+
+func TestNormalizeProduct(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  bson.M
+		want bson.M
+	}{
+		{
+			name: "legacy document",
+			doc:  bson.M{"name": "Mug", "tags": nil, "inventory": bson.M{"quantity": int32(3)}},
+			want: bson.M{"$set": bson.M{
+				"image_urls":         bson.A{},
+				"tags":               bson.A{},
+				"attributes":         bson.M{},
+				"active":             true,
+				"inventory.reserved": 0,
+				"inventory.in_stock": true,
+			}},
+		},
+		{
+			name: "stale in_stock",
+			doc: bson.M{"image_urls": bson.A{}, "tags": bson.A{}, "attributes": bson.M{}, "active": false,
+				"inventory": bson.D{{Key: "quantity", Value: int64(0)}, {Key: "reserved", Value: int32(0)}, {Key: "in_stock", Value: true}}},
+			want: bson.M{"$set": bson.M{"inventory.in_stock": false}},
+		},
+		{
+			name: "current document",
+			doc: bson.M{"image_urls": bson.A{}, "tags": bson.A{"a"}, "attributes": bson.M{}, "active": true,
+				"inventory": bson.M{"quantity": 2.0, "reserved": int32(1), "in_stock": true}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeProduct(tt.doc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPublishProduct(t *testing.T) {
+	update, err := publishProduct(bson.M{"name": "Mug"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$set": bson.M{"status": "published"}}, update)
+
+	update, err = publishProduct(bson.M{"name": "Mug", "status": "draft"})
+	require.NoError(t, err)
+	assert.Nil(t, update, "documents with a status keep it")
+}
+
+func TestWithVersion(t *testing.T) {
+	assert.Equal(t, bson.M{"$set": bson.M{"schema_version": 2}}, withVersion(nil, 2))
+	assert.Equal(t,
+		bson.M{"$set": bson.M{"a": 1, "schema_version": 2}, "$unset": bson.M{"b": ""}},
+		withVersion(bson.M{"$set": bson.M{"a": 1}, "$unset": bson.M{"b": ""}}, 2))
+}
+
+// TestRunner runs against MongoDB when PRODUCT_TEST_MONGODB_URI is set
+func TestRunner(t *testing.T) {
+	uri := os.Getenv("PRODUCT_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("PRODUCT_TEST_MONGODB_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+	db := client.Database(fmt.Sprintf("product_migrations_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() { db.Drop(ctx) })
+	products := db.Collection("products")
+
+	var docs []interface{}
+	for i := 0; i < 25; i++ {
+		docs = append(docs, bson.M{"_id": primitive.NewObjectID(), "name": fmt.Sprint("legacy ", i), "inventory": bson.M{"quantity": i % 3}})
+	}
+	docs = append(docs, bson.M{"_id": primitive.NewObjectID(), "name": "current", "schema_version": Latest()})
+	_, err = products.InsertMany(ctx, docs)
+	require.NoError(t, err)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	pending := func() int64 {
+		n, err := products.CountDocuments(ctx, bson.M{"schema_version": bson.M{"$exists": false}})
+		require.NoError(t, err)
+		return n
+	}
+
+	dryRun := NewRunner(products, All, Options{BatchSize: 10, DryRun: true}, logger)
+	require.NoError(t, dryRun.Up(ctx, Latest()))
+	assert.EqualValues(t, 25, pending(), "a dry run writes nothing")
+	statuses, err := dryRun.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, statuses[0].State)
+
+	// Interrupt the first run after two batches
+	failing := append([]Migration(nil), All...)
+	calls := 0
+	up := failing[0].Up
+	failing[0].Up = func(doc bson.M) (bson.M, error) {
+		if calls++; calls > 20 {
+			return nil, fmt.Errorf("interrupted")
+		}
+		return up(doc)
+	}
+	require.Error(t, NewRunner(products, failing, Options{BatchSize: 10}, logger).Up(ctx, Latest()))
+	assert.EqualValues(t, 5, pending())
+
+	runner := NewRunner(products, All, Options{BatchSize: 10}, logger)
+	statuses, err = runner.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, statuses[0].State)
+	assert.EqualValues(t, 20, statuses[0].Migrated)
+
+	require.NoError(t, runner.Up(ctx, Latest()))
+	assert.EqualValues(t, 0, pending())
+	statuses, err = runner.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StateDone, statuses[0].State)
+	assert.EqualValues(t, 25, statuses[0].Migrated)
+
+	var product domain.Product
+	require.NoError(t, products.FindOne(ctx, bson.M{"name": "legacy 1"}).Decode(&product))
+	assert.Equal(t, domain.ProductSchemaVersion, product.SchemaVersion)
+	assert.True(t, product.Active)
+	assert.Equal(t, domain.StatusPublished, product.Status)
+	assert.True(t, product.Inventory.InStock)
+	assert.NotNil(t, product.Tags)
+}
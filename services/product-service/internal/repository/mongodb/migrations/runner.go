@@ -0,0 +1,228 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StateCollection records the progress of every migration
+const StateCollection = "schema_migrations"
+
+// Migration states
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDone    = "done"
+)
+
+// Status is the progress of one migration on one collection
+type Status struct {
+	Version     int         `bson:"version"`
+	Description string      `bson:"description"`
+	State       string      `bson:"state"`
+	LastID      interface{} `bson:"last_id,omitempty"`
+	Migrated    int64       `bson:"migrated"`
+	StartedAt   time.Time   `bson:"started_at,omitempty"`
+	FinishedAt  time.Time   `bson:"finished_at,omitempty"`
+}
+
+// Options configures a Runner
+type Options struct {
+	// BatchSize is the number of documents read and written at once
+	BatchSize int
+
+	// DryRun computes and logs the updates without writing them or any
+	// progress
+	DryRun bool
+
+	// Samples is the number of updates a dry run logs per migration
+	Samples int
+}
+
+// Runner applies migrations to one collection of product documents
+type Runner struct {
+	collection *mongo.Collection
+	state      *mongo.Collection
+	migrations []Migration
+	opts       Options
+	logger     *slog.Logger
+}
+
+// NewRunner returns a Runner for the collection, keeping its progress in
+// StateCollection of the same database
+func NewRunner(collection *mongo.Collection, migrations []Migration, opts Options, logger *slog.Logger) *Runner {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	return &Runner{
+		collection: collection,
+		state:      collection.Database().Collection(StateCollection),
+		migrations: migrations,
+		opts:       opts,
+		logger:     logger,
+	}
+}
+
+// Status returns the progress of every migration, pending ones included
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status, err := r.status(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies the migrations up to and including version target in order,
+// skipping those already done and resuming one that was interrupted
+func (r *Runner) Up(ctx context.Context, target int) error {
+	for _, m := range r.migrations {
+		if m.Version > target {
+			break
+		}
+		status, err := r.status(ctx, m)
+		if err != nil {
+			return err
+		}
+		if status.State == StateDone {
+			continue
+		}
+		if err := r.run(ctx, m, status); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, m Migration, status Status) error {
+	// Documents leave the filter as they are migrated, so a resumed run
+	// only has the rest to do; last_id saves rescanning documents that
+	// needed no change
+	pending := bson.M{"schema_version": bson.M{"$not": bson.M{"$gte": m.Version}}}
+	total, err := r.collection.CountDocuments(ctx, pending)
+	if err != nil {
+		return fmt.Errorf("failed to count documents: %w", err)
+	}
+	r.logger.Info("Running migration", "version", m.Version, "description", m.Description,
+		"documents", total, "resumeAfter", status.LastID, "dryRun", r.opts.DryRun)
+
+	if !r.opts.DryRun {
+		_, err := r.state.UpdateOne(ctx, bson.M{"_id": r.stateID(m)}, bson.M{
+			"$set":         bson.M{"version": m.Version, "description": m.Description, "state": StateRunning},
+			"$setOnInsert": bson.M{"started_at": time.Now().UTC(), "migrated": int64(0)},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("failed to record progress: %w", err)
+		}
+	}
+
+	lastID, processed, sampled := status.LastID, int64(0), 0
+	for {
+		filter := pending
+		if lastID != nil {
+			filter = bson.M{"$and": bson.A{pending, bson.M{"_id": bson.M{"$gt": lastID}}}}
+		}
+		cursor, err := r.collection.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(int64(r.opts.BatchSize)))
+		if err != nil {
+			return fmt.Errorf("failed to read documents: %w", err)
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return fmt.Errorf("failed to read documents: %w", err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		models := make([]mongo.WriteModel, 0, len(docs))
+		for _, doc := range docs {
+			update, err := m.Up(doc)
+			if err != nil {
+				return fmt.Errorf("document %v: %w", doc["_id"], err)
+			}
+			update = withVersion(update, m.Version)
+			if r.opts.DryRun && sampled < r.opts.Samples {
+				r.logger.Info("Would update document", "version", m.Version, "id", doc["_id"], "update", update)
+				sampled++
+			}
+			// Skip documents the service rewrote since they were read
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"$and": bson.A{bson.M{"_id": doc["_id"]}, pending}}).
+				SetUpdate(update))
+		}
+		lastID = docs[len(docs)-1]["_id"]
+		processed += int64(len(docs))
+
+		if !r.opts.DryRun {
+			result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+			if err != nil {
+				return fmt.Errorf("failed to write batch: %w", err)
+			}
+			_, err = r.state.UpdateOne(ctx, bson.M{"_id": r.stateID(m)}, bson.M{
+				"$set": bson.M{"last_id": lastID},
+				"$inc": bson.M{"migrated": result.ModifiedCount},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record progress: %w", err)
+			}
+		}
+		r.logger.Info("Migration progress", "version", m.Version, "processed", processed, "documents", total)
+	}
+
+	if r.opts.DryRun {
+		r.logger.Info("Dry run of migration finished", "version", m.Version, "wouldUpdate", processed)
+		return nil
+	}
+	_, err = r.state.UpdateOne(ctx, bson.M{"_id": r.stateID(m)}, bson.M{
+		"$set": bson.M{"state": StateDone, "finished_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record progress: %w", err)
+	}
+	r.logger.Info("Migration finished", "version", m.Version, "processed", processed)
+	return nil
+}
+
+func (r *Runner) status(ctx context.Context, m Migration) (Status, error) {
+	var status Status
+	err := r.state.FindOne(ctx, bson.M{"_id": r.stateID(m)}).Decode(&status)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Status{Version: m.Version, Description: m.Description, State: StatePending}, nil
+	}
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read progress of migration %d: %w", m.Version, err)
+	}
+	return status, nil
+}
+
+// stateID keys progress by collection, so that each collection named by
+// MONGODB_COLLECTION is migrated on its own
+func (r *Runner) stateID(m Migration) string {
+	return fmt.Sprintf("%s:%d", r.collection.Name(), m.Version)
+}
+
+// withVersion adds setting schema_version to version to update
+func withVersion(update bson.M, version int) bson.M {
+	if update == nil {
+		update = bson.M{}
+	}
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+	}
+	set["schema_version"] = version
+	update["$set"] = set
+	return update
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/bekbull/online-shop/pkg/tracing"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/bekbull/online-shop/services/product-service/internal/repository/mongodb")
+
+// commandKey identifies a command in flight; request IDs are only unique
+// per connection
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+// NewCommandMonitor returns a monitor that traces each MongoDB command as a
+// client span, a child of the span in the operation context, and logs
+// failed commands. The logger takes the request and trace IDs from the
+// operation context, so failures can be matched to the HTTP or gRPC request
+// that caused them.
+func NewCommandMonitor(logger *slog.Logger) *event.CommandMonitor {
+	var mu sync.Mutex
+	spans := make(map[commandKey]trace.Span)
+	finish := func(key commandKey, err error) {
+		mu.Lock()
+		span, ok := spans[key]
+		delete(spans, key)
+		mu.Unlock()
+		if ok {
+			tracing.End(span, err)
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemMongoDB,
+				semconv.DBNamespace(e.DatabaseName),
+				semconv.DBOperationName(e.CommandName),
+			}
+			name := e.CommandName
+			if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+				attrs = append(attrs, semconv.DBCollectionName(collection))
+				name = e.CommandName + " " + collection
+			}
+			_, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+			mu.Lock()
+			spans[commandKey{e.ConnectionID, e.RequestID}] = span
+			mu.Unlock()
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			finish(commandKey{e.ConnectionID, e.RequestID}, nil)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			logger.WarnContext(ctx, "MongoDB command failed",
+				"command", e.CommandName,
+				"database", e.DatabaseName,
+				"duration", e.Duration,
+				"error", e.Failure,
+			)
+			finish(commandKey{e.ConnectionID, e.RequestID}, errors.New(e.Failure))
+		},
+	}
+}
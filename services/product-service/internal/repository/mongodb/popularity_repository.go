@@ -0,0 +1,157 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PopularityRepository implements the domain.PopularityRepository
+// interface with MongoDB. Daily counts are kept in product_popularity, one
+// document per product and day; the totals in the products' popularity
+// field.
+type PopularityRepository struct {
+	collection *mongo.Collection
+	products   *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewPopularityRepository creates a new PopularityRepository
+func NewPopularityRepository(client *mongo.Client, cfg *config.MongoDBConfig) *PopularityRepository {
+	db := client.Database(cfg.Database)
+	return &PopularityRepository{
+		collection: db.Collection("product_popularity"),
+		products:   db.Collection(cfg.Collection),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the index used to select a window, which also
+// drops daily counts older than the longest window
+func (r *PopularityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "day", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32((domain.MaxTrendingWindow + 24*time.Hour).Seconds())),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create popularity indexes: %w", err)
+	}
+	return nil
+}
+
+// AddCounts adds daily counts to the stored ones and to the totals of the
+// products. Products that have been deleted are skipped.
+func (r *PopularityRepository) AddCounts(ctx context.Context, counts []domain.PopularityCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	daily := make([]mongo.WriteModel, 0, len(counts))
+	totals := make([]mongo.WriteModel, 0, len(counts))
+	for _, count := range counts {
+		day := domain.Day(count.Day)
+		daily = append(daily, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": bson.D{{Key: "product_id", Value: count.ProductID}, {Key: "day", Value: day}}}).
+			SetUpdate(bson.M{
+				"$set": bson.M{"product_id": count.ProductID, "day": day},
+				"$inc": bson.M{"views": count.Views, "purchases": count.Purchases},
+			}).
+			SetUpsert(true))
+		totals = append(totals, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": count.ProductID}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"popularity.views":     count.Views,
+				"popularity.purchases": count.Purchases,
+				"popularity.score":     domain.PopularityScore(count.Views, count.Purchases),
+			}}))
+	}
+
+	if _, err := r.collection.BulkWrite(ctx, daily, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+	_, err := r.products.BulkWrite(ctx, totals, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Trending returns the active products with the highest scores in the
+// window, per category in category order
+func (r *PopularityRepository) Trending(ctx context.Context, params domain.TrendingParams) ([]domain.TrendingCategory, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	productFilter := bson.M{"product.active": true}
+	if params.Category != "" {
+		productFilter["product.category"] = params.Category
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": bson.M{"$gte": domain.Day(params.Since)}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$product_id",
+			"views":     bson.M{"$sum": "$views"},
+			"purchases": bson.M{"$sum": "$purchases"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"score": bson.M{"$add": bson.A{"$views", bson.M{"$multiply": bson.A{"$purchases", domain.PurchaseWeight}}}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.products.Name(),
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$match", Value: productFilter}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$product.category",
+			"products": bson.M{"$push": bson.M{
+				"product":   "$product",
+				"views":     "$views",
+				"purchases": "$purchases",
+				"score":     "$score",
+			}},
+		}}},
+		{{Key: "$project", Value: bson.M{"products": bson.M{"$slice": bson.A{"$products", params.Limit}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var results []struct {
+		Category string `bson:"_id"`
+		Products []struct {
+			Product   *domain.Product `bson:"product"`
+			Views     int64           `bson:"views"`
+			Purchases int64           `bson:"purchases"`
+			Score     float64         `bson:"score"`
+		} `bson:"products"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	trending := make([]domain.TrendingCategory, 0, len(results))
+	for _, result := range results {
+		category := domain.TrendingCategory{Category: result.Category, Products: make([]domain.TrendingProduct, 0, len(result.Products))}
+		for _, p := range result.Products {
+			category.Products = append(category.Products, domain.TrendingProduct{
+				Product:   p.Product,
+				Views:     p.Views,
+				Purchases: p.Purchases,
+				Score:     p.Score,
+			})
+		}
+		trending = append(trending, category)
+	}
+	return trending, nil
+}
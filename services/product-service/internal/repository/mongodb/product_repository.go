@@ -3,8 +3,14 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/product-service/config"
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson"
@@ -30,9 +36,135 @@ func New(client *mongo.Client, cfg *config.MongoDBConfig) *ProductRepository {
 	}
 }
 
+// Error codes of an index clashing with an existing one of the same name
+// or keys
+const (
+	indexOptionsConflict  = 85
+	indexKeySpecsConflict = 86
+)
+
+// EnsureIndexes creates the indexes lists filter and sort on: the text
+// index searches match, weighted as configured, and the indexes on
+// category, price and creation date. It also creates the unique indexes on product barcodes and on
+// current and previous slugs, sparse as older products have neither, the
+// unique index on SKUs, which leaves out the empty SKUs of older products,
+// the index on assigned badges, the index search suggestions match the
+// names of active products with, the indexes on the configured attributes
+// and the index the inventory operations of a product are listed with.
+// Existing indexes are left as they are, so it is safe on every start,
+// except a text index other than the configured one, which is replaced;
+// an index that cannot be built, or one of the same name with other keys
+// or options, fails it.
+func (r *ProductRepository) EnsureIndexes(ctx context.Context) error {
+	if err := r.ensureTextIndex(ctx); err != nil {
+		return err
+	}
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "price", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "barcode", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "slugs", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "inventory.sku", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"inventory.sku": bson.M{"$gt": ""}}),
+		},
+		{Keys: bson.D{{Key: "badges", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "search_name", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"active": true}),
+		},
+	}
+	// Attribute indexes are sparse, as most products have only some of
+	// the attributes
+	for _, key := range r.config.AttributeIndexes {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys:    bson.D{{Key: "attributes." + key, Value: 1}},
+			Options: options.Index().SetSparse(true),
+		})
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to create product indexes, products share a barcode, slug or SKU: %w", err)
+	}
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(indexOptionsConflict) || serverErr.HasErrorCode(indexKeySpecsConflict)) {
+		return fmt.Errorf("failed to create product indexes, an existing index has the name or keys of one with other options: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create product indexes: %w", err)
+	}
+	_, err = r.operations().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "timestamp", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create inventory operation indexes: %w", err)
+	}
+	return nil
+}
+
+// textIndexName names the text index searches match
+const textIndexName = "product_search"
+
+// ensureTextIndex creates the text index with the configured weights. A
+// collection has at most one text index, so one with other fields or
+// weights, left by an earlier configuration or created by hand, is
+// dropped first; searches fail until the new index is built.
+func (r *ProductRepository) ensureTextIndex(ctx context.Context) error {
+	weights := map[string]float64{
+		"name":        float64(r.config.TextWeights.Name),
+		"tags":        float64(r.config.TextWeights.Tags),
+		"description": float64(r.config.TextWeights.Description),
+	}
+
+	cursor, err := r.collection.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list product indexes: %w", err)
+	}
+	var existing []struct {
+		Name    string             `bson:"name"`
+		Key     bson.M             `bson:"key"`
+		Weights map[string]float64 `bson:"weights"`
+	}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return fmt.Errorf("failed to list product indexes: %w", err)
+	}
+	for _, index := range existing {
+		if index.Key["_fts"] != "text" {
+			continue
+		}
+		if index.Name == textIndexName && maps.Equal(index.Weights, weights) {
+			return nil
+		}
+		if _, err := r.collection.Indexes().DropOne(ctx, index.Name); err != nil {
+			return fmt.Errorf("failed to drop text index %s: %w", index.Name, err)
+		}
+	}
+
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "name", Value: "text"},
+			{Key: "tags", Value: "text"},
+			{Key: "description", Value: "text"},
+		},
+		Options: options.Index().SetName(textIndexName).SetWeights(weights),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create text index: %w", err)
+	}
+	return nil
+}
+
 // Create inserts a new product into the database
-func (r *ProductRepository) Create(product *domain.Product) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.WriteTimeout)
+func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
 	defer cancel()
 
 	// Ensure ID and timestamps are set
@@ -45,27 +177,30 @@ func (r *ProductRepository) Create(product *domain.Product) error {
 	product.UpdatedAt = time.Now()
 
 	// Ensure inventory.InStock is set correctly
-	product.Inventory.InStock = product.Inventory.Quantity > 0
+	product.Inventory.InStock = product.HasStock()
+	product.SearchName = domain.NormalizeSearchName(product.Name)
+	product.Slugs = product.AllSlugs()
+	product.SchemaVersion = domain.ProductSchemaVersion
 
 	_, err := r.collection.InsertOne(ctx, product)
-	return err
+	return duplicateKeyError(err)
 }
 
 // GetByID retrieves a product by its ID
-func (r *ProductRepository) GetByID(id string) (*domain.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.ReadTimeout)
+func (r *ProductRepository) GetByID(ctx context.Context, id string) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, domain.ErrProductNotFound
 	}
 
 	var product domain.Product
 	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("product not found")
+			return nil, domain.ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -73,29 +208,108 @@ func (r *ProductRepository) GetByID(id string) (*domain.Product, error) {
 	return &product, nil
 }
 
+// GetByIDs retrieves the products with the IDs that exist in a single
+// query. IDs that are not product IDs match nothing.
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	objIDs := make(bson.A, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	products := []*domain.Product{}
+	if len(objIDs) == 0 {
+		return products, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetByBarcode retrieves a product by its normalized barcode
+func (r *ProductRepository) GetByBarcode(ctx context.Context, barcode string) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var product domain.Product
+	err := r.collection.FindOne(ctx, bson.M{"barcode": barcode}).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetBySlug retrieves the product with the slug, current or previous
+func (r *ProductRepository) GetBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var product domain.Product
+	err := r.collection.FindOne(ctx, bson.M{"slugs": slug}).Decode(&product)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 // Update updates an existing product
-func (r *ProductRepository) Update(product *domain.Product) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.WriteTimeout)
+func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
 	defer cancel()
 
 	// Update timestamps
 	product.UpdatedAt = time.Now()
 
 	// Ensure inventory.InStock is set correctly
-	product.Inventory.InStock = product.Inventory.Quantity > 0
+	product.Inventory.InStock = product.HasStock()
+	product.SearchName = domain.NormalizeSearchName(product.Name)
+	product.Slugs = product.AllSlugs()
+	product.SchemaVersion = domain.ProductSchemaVersion
 
 	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": product.ID}, product)
-	return err
+	return duplicateKeyError(err)
+}
+
+// duplicateKeyError returns the domain error for a write that broke the
+// unique index on barcodes, on slugs or on SKUs
+func duplicateKeyError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	if strings.Contains(err.Error(), "slugs_1") {
+		return domain.ErrSlugExists
+	}
+	if strings.Contains(err.Error(), "inventory.sku_1") {
+		return domain.ErrDuplicateSKU
+	}
+	return domain.ErrBarcodeExists
 }
 
 // Delete removes a product by its ID
-func (r *ProductRepository) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.WriteTimeout)
+func (r *ProductRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return domain.ErrProductNotFound
 	}
 
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
@@ -104,50 +318,20 @@ func (r *ProductRepository) Delete(id string) error {
 	}
 
 	if result.DeletedCount == 0 {
-		return errors.New("product not found")
+		return domain.ErrProductNotFound
 	}
 
 	return nil
 }
 
 // List retrieves products based on filter parameters
-func (r *ProductRepository) List(params domain.ListProductsParams) ([]*domain.Product, int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.ReadTimeout)
+func (r *ProductRepository) List(ctx context.Context, params domain.ListProductsParams) ([]*domain.Product, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
 	defer cancel()
 
-	// Build filter
-	filter := bson.M{}
-
-	// Add category filter if provided
-	if params.Category != "" {
-		filter["category"] = params.Category
-	}
-
-	// Add tags filter if provided
-	if len(params.Tags) > 0 {
-		filter["tags"] = bson.M{"$all": params.Tags}
-	}
-
-	// Add price range filter if provided
-	if params.MinPrice > 0 || params.MaxPrice > 0 {
-		priceFilter := bson.M{}
-		if params.MinPrice > 0 {
-			priceFilter["$gte"] = params.MinPrice
-		}
-		if params.MaxPrice > 0 {
-			priceFilter["$lte"] = params.MaxPrice
-		}
-		filter["price"] = priceFilter
-	}
-
-	// Add in-stock filter if requested
-	if params.InStockOnly {
-		filter["inventory.in_stock"] = true
-	}
-
-	// Add text search if provided
-	if params.SearchTerm != "" {
-		filter["$text"] = bson.M{"$search": params.SearchTerm}
+	filter, err := listFilter(params)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Count total matching documents
@@ -160,7 +344,7 @@ func (r *ProductRepository) List(params domain.ListProductsParams) ([]*domain.Pr
 	findOptions := options.Find()
 	if params.PageSize > 0 {
 		findOptions.SetLimit(int64(params.PageSize))
-		findOptions.SetSkip(int64(params.Page * params.PageSize))
+		findOptions.SetSkip(int64(params.Offset))
 	}
 
 	// Set up sorting
@@ -169,7 +353,22 @@ func (r *ProductRepository) List(params domain.ListProductsParams) ([]*domain.Pr
 		if params.SortDesc {
 			sortDirection = -1
 		}
-		findOptions.SetSort(bson.D{{Key: params.SortBy, Value: sortDirection}})
+		switch params.SortBy {
+		case domain.SortByPopularity:
+			findOptions.SetSort(bson.D{{Key: "popularity.score", Value: sortDirection}})
+		case domain.SortByRating:
+			findOptions.SetSort(bson.D{{Key: "rating.average", Value: sortDirection}, {Key: "rating.count", Value: sortDirection}})
+		case domain.SortByNewest:
+			findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+		case domain.SortByFeatured:
+			findOptions.SetSort(bson.D{{Key: "featured", Value: -1}, {Key: "created_at", Value: -1}})
+		default:
+			findOptions.SetSort(bson.D{{Key: params.SortBy, Value: sortDirection}})
+		}
+	} else if params.SearchTerm != "" {
+		// Searches list the best matches first, by the weights of the
+		// fields the terms are found in
+		findOptions.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "created_at", Value: -1}})
 	} else {
 		// Default sort by creation date descending
 		findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
@@ -191,14 +390,92 @@ func (r *ProductRepository) List(params domain.ListProductsParams) ([]*domain.Pr
 	return products, int(total), nil
 }
 
+// listFilter builds the query selecting the products matching the filters
+// of a product list
+func listFilter(params domain.ListProductsParams) (bson.M, error) {
+	filter := bson.M{"status": domain.StatusPublished}
+	if params.Status != "" {
+		filter["status"] = params.Status
+	}
+
+	// Add category filter if provided
+	if len(params.Categories) > 0 {
+		filter["category"] = bson.M{"$in": params.Categories}
+	} else if params.Category != "" {
+		filter["category"] = params.Category
+	}
+
+	// Add tags filter if provided
+	if len(params.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": params.Tags}
+	}
+
+	// Add price range filter if provided
+	if params.MinPrice > 0 || params.MaxPrice > 0 {
+		priceFilter := bson.M{}
+		if params.MinPrice > 0 {
+			priceFilter["$gte"] = params.MinPrice
+		}
+		if params.MaxPrice > 0 {
+			priceFilter["$lte"] = params.MaxPrice
+		}
+		filter["price"] = priceFilter
+	}
+
+	// Add in-stock filter if requested
+	if params.InStockOnly {
+		filter["inventory.in_stock"] = true
+	}
+
+	// Add supplier filter if provided
+	if params.SupplierID != "" {
+		supplierID, err := primitive.ObjectIDFromHex(params.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+		filter["suppliers.supplier_id"] = supplierID
+	}
+
+	// Add merchandising filters if requested
+	if params.FeaturedOnly {
+		filter["featured"] = true
+		filter["$or"] = bson.A{
+			bson.M{"featured_until": nil},
+			bson.M{"featured_until": bson.M{"$gt": time.Now()}},
+		}
+	}
+	if !params.CreatedSince.IsZero() {
+		filter["created_at"] = bson.M{"$gte": params.CreatedSince}
+	}
+
+	// Add shipping class filter if provided
+	if params.ShippingClass != "" {
+		filter["shipping_class"] = params.ShippingClass
+	}
+	if params.Badge != "" {
+		filter["badges"] = params.Badge
+	}
+
+	// Add attribute filters if provided
+	for key, value := range params.AttributeFilters {
+		filter["attributes."+key] = value
+	}
+
+	// Add text search if provided
+	if params.SearchTerm != "" {
+		filter["$text"] = bson.M{"$search": params.SearchTerm}
+	}
+	return filter, nil
+}
+
 // UpdateInventory updates a product's inventory
-func (r *ProductRepository) UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.WriteTimeout)
+func (r *ProductRepository) UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
-		return nil, err
+		return nil, domain.ErrProductNotFound
 	}
 
 	// Use a session with transaction to ensure atomicity
@@ -216,39 +493,26 @@ func (r *ProductRepository) UpdateInventory(productID string, quantityChange int
 		}
 
 		// Check for duplicate operation if operationID is provided (idempotency)
-		if operationID != "" {
-			// Create a collection for inventory operations if we need to track them
-			opCollection := r.client.Database(r.config.Database).Collection("inventory_operations")
-			
-			// Check if this operation already exists
-			var existingOp domain.InventoryOperation
-			err := opCollection.FindOne(sc, bson.M{"operation_id": operationID}).Decode(&existingOp)
-			if err == nil {
-				// Operation already processed
-				// Fetch current inventory and return
-				var product domain.Product
-				err = r.collection.FindOne(sc, bson.M{"_id": objID}).Decode(&product)
-				if err != nil {
-					return err
-				}
-				updatedInventory = &product.Inventory
-				return nil
-			} else if err != mongo.ErrNoDocuments {
-				// Unexpected error
-				return err
-			}
-			
-			// Record the operation
-			_, err = opCollection.InsertOne(sc, domain.InventoryOperation{
-				ProductID:      productID,
-				QuantityChange: quantityChange,
-				OperationID:    operationID,
-				OperationType:  operationType,
-				Timestamp:      time.Now(),
-			})
+		duplicate, err := r.recordOperation(sc, domain.InventoryOperation{
+			ProductID:      productID,
+			QuantityChange: quantityChange,
+			OperationID:    operationID,
+			OperationType:  operationType,
+			Timestamp:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			// Operation already processed
+			// Fetch current inventory and return
+			var product domain.Product
+			err = r.collection.FindOne(sc, bson.M{"_id": objID}).Decode(&product)
 			if err != nil {
 				return err
 			}
+			updatedInventory = &product.Inventory
+			return nil
 		}
 
 		// Update the product's inventory
@@ -268,12 +532,15 @@ func (r *ProductRepository) UpdateInventory(productID string, quantityChange int
 			options.FindOneAndUpdate().SetReturnDocument(options.After),
 		).Decode(&product)
 		
+		if err == mongo.ErrNoDocuments {
+			return domain.ErrProductNotFound
+		}
 		if err != nil {
 			return err
 		}
 
 		// Update the in_stock status based on the new quantity
-		inStock := product.Inventory.Quantity > 0
+		inStock := product.HasStock()
 		if inStock != product.Inventory.InStock {
 			_, err = r.collection.UpdateOne(
 				sc,
@@ -300,25 +567,574 @@ func (r *ProductRepository) UpdateInventory(productID string, quantityChange int
 }
 
 // CheckStock checks if a product has sufficient stock
-func (r *ProductRepository) CheckStock(productID string, quantity int) (bool, int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.ReadTimeout)
+func (r *ProductRepository) CheckStock(ctx context.Context, productID string, quantity int) (bool, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(productID)
 	if err != nil {
-		return false, 0, err
+		return false, 0, domain.ErrProductNotFound
 	}
 
 	var product domain.Product
 	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return false, 0, errors.New("product not found")
+			return false, 0, domain.ErrProductNotFound
 		}
 		return false, 0, err
 	}
 
-	// Available quantity is (total - reserved)
+	// Available quantity is (total - reserved). Digital products are
+	// always available, whatever their quantity.
 	availableQuantity := product.Inventory.Quantity - product.Inventory.Reserved
-	return availableQuantity >= quantity, availableQuantity, nil
-} 
\ No newline at end of file
+	if err := product.CheckQuantity(quantity); err != nil {
+		return false, availableQuantity, err
+	}
+	// Products on preorder are available up to their preorder allocation
+	if product.OnPreorder() {
+		left := product.Preorder.Left()
+		return left >= quantity, left, nil
+	}
+	return product.IsDigital() || availableQuantity >= quantity, availableQuantity, nil
+} 
+// HasOperation reports whether the inventory or preorder operation with
+// operationID was recorded
+func (r *ProductRepository) HasOperation(ctx context.Context, operationID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	count, err := r.operations().CountDocuments(ctx, bson.M{"operation_id": operationID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ImageInUse reports whether a product has the image URL. Image URLs are
+// not indexed, so this scans the products; it runs only when an image is
+// deleted.
+func (r *ProductRepository) ImageInUse(ctx context.Context, url string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"image_urls": url}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListOperations returns a page of the recorded inventory and preorder
+// operations the filter selects, newest first
+func (r *ProductRepository) ListOperations(ctx context.Context, filter domain.InventoryOperationFilter, page pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	query := bson.M{"product_id": filter.ProductID}
+	if len(filter.Types) > 0 {
+		query["operation_type"] = bson.M{"$in": filter.Types}
+	}
+	timestamp := bson.M{}
+	if filter.From != nil {
+		timestamp["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		timestamp["$lt"] = *filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	total, err := r.operations().CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.PageSize))
+	cursor, err := r.operations().Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	operations := []*domain.InventoryOperation{}
+	if err := cursor.All(ctx, &operations); err != nil {
+		return nil, 0, err
+	}
+	return operations, int(total), nil
+}
+
+// Suggest returns up to limit active products whose search name starts
+// with prefix, in name order. The prefix is anchored and case-sensitive
+// against the normalized name, so the query is a range scan of the
+// search_name index.
+func (r *ProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"active":      true,
+		"status":      domain.StatusPublished,
+		"search_name": bson.M{"$regex": "^" + regexp.QuoteMeta(domain.NormalizeSearchName(prefix))},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "search_name", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"name": 1, "category": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*domain.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	suggestions := make([]domain.Suggestion, 0, len(products))
+	for _, product := range products {
+		suggestions = append(suggestions, domain.Suggestion{
+			Type:      domain.SuggestionProduct,
+			Text:      product.Name,
+			ProductID: product.ID.Hex(),
+			Category:  product.Category,
+		})
+	}
+	return suggestions, nil
+}
+
+// ListTags returns every tag on products, active or not, with the number
+// of products carrying it, in tag order. A tag repeated on a product counts
+// once.
+func (r *ProductRepository) ListTags(ctx context.Context) ([]domain.TagCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$project", Value: bson.M{"tags": bson.M{"$setUnion": bson.A{"$tags", bson.A{}}}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "products": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []domain.TagCount{}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// RelatedProducts returns up to limit other active products sharing the
+// product's category or tags, most similar first. Sharing the category
+// scores domain.RelatedCategoryWeight and each shared tag one; ties go to
+// the more popular product.
+func (r *ProductRepository) RelatedProducts(ctx context.Context, product *domain.Product, limit int) ([]*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	tags := product.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	shared := bson.A{bson.M{"tags": bson.M{"$in": tags}}}
+	var categoryScore interface{} = 0
+	if product.Category != "" {
+		shared = append(shared, bson.M{"category": product.Category})
+		categoryScore = bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$category", product.Category}}, domain.RelatedCategoryWeight, 0}}
+	}
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$ne": product.ID}, "active": true, "status": domain.StatusPublished, "$or": shared}}},
+		{{Key: "$addFields", Value: bson.M{"related_score": bson.M{"$add": bson.A{
+			categoryScore,
+			bson.M{"$size": bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}, tags}}},
+		}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "related_score", Value: -1}, {Key: "popularity.score", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"related_score": 0}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []*domain.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// operations returns the collection inventory and preorder operations are
+// recorded in
+func (r *ProductRepository) operations() *mongo.Collection {
+	return r.client.Database(r.config.Database).Collection("inventory_operations")
+}
+
+// Stream iterates over all products in ID order using a cursor, so memory
+// stays flat regardless of catalog size. Unless includeInactive, only the
+// products shoppers see, active and published, are passed to fn.
+func (r *ProductRepository) Stream(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error {
+	filter := bson.M{}
+	if !includeInactive {
+		filter["active"] = true
+		filter["status"] = domain.StatusPublished
+	}
+	return r.each(ctx, filter, fn)
+}
+
+// Export iterates over the products matching the filters of a product list
+// in ID order using a cursor, like Stream. Pagination and sorting are
+// ignored. Exports are not bounded by the read timeout, only by ctx.
+func (r *ProductRepository) Export(ctx context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error {
+	filter, err := listFilter(params)
+	if err != nil {
+		return err
+	}
+	return r.each(ctx, filter, fn)
+}
+
+// each passes the products matching filter to fn in ID order
+func (r *ProductRepository) each(ctx context.Context, filter bson.M, fn func(*domain.Product) error) error {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(500)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var product domain.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// WatchInventory follows the collection's change stream, which needs
+// MongoDB to run as a replica set, and calls fn for the products whose
+// inventory changed. The stream is closed when ctx is done.
+func (r *ProductRepository) WatchInventory(ctx context.Context, productIDs []string, fn func(*domain.InventoryChange) error) error {
+	match := bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace"}}}
+	if len(productIDs) > 0 {
+		ids := make(bson.A, 0, len(productIDs))
+		for _, id := range productIDs {
+			objID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return apperrors.Newf(apperrors.Invalid, "invalid product ID %q", id)
+			}
+			ids = append(ids, objID)
+		}
+		match["documentKey._id"] = bson.M{"$in": ids}
+	}
+
+	stream, err := r.collection.Watch(ctx, mongo.Pipeline{{{Key: "$match", Value: match}}},
+		options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType     string              `bson:"operationType"`
+			ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+			FullDocument      *domain.Product     `bson:"fullDocument"`
+			UpdateDescription struct {
+				UpdatedFields bson.Raw `bson:"updatedFields"`
+			} `bson:"updateDescription"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+		// Updates that leave the inventory alone, and products deleted
+		// before the lookup, are skipped
+		if event.OperationType == "update" && !inventoryUpdated(event.UpdateDescription.UpdatedFields) {
+			continue
+		}
+		if event.FullDocument == nil {
+			continue
+		}
+		err := fn(&domain.InventoryChange{
+			ProductID:   event.FullDocument.ID.Hex(),
+			ProductName: event.FullDocument.Name,
+			Inventory:   event.FullDocument.Inventory,
+			ChangedAt:   time.Unix(int64(event.ClusterTime.T), 0).UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
+
+// inventoryUpdated reports whether the fields of an update include the
+// inventory or one of its fields
+func inventoryUpdated(fields bson.Raw) bool {
+	elements, err := fields.Elements()
+	if err != nil {
+		return true
+	}
+	for _, element := range elements {
+		if key := element.Key(); key == "inventory" || strings.HasPrefix(key, "inventory.") {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAfter returns up to limit products, active or not, with IDs after
+// the given one in ID order
+func (r *ProductRepository) ListAfter(ctx context.Context, after primitive.ObjectID, limit int) ([]*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	var products []*domain.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// SetFields sets individual fields of products in one bulk write, leaving
+// their other fields and updated_at as they are
+func (r *ProductRepository) SetFields(ctx context.Context, updates []domain.FieldUpdate) (int, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, 0, len(updates))
+	for _, update := range updates {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": update.ProductID}).
+			SetUpdate(bson.M{"$set": update.Fields}))
+	}
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// SetFeatured features a product until the given time, or without expiry
+// if until is nil, or unfeatures it
+func (r *ProductRepository) SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*domain.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrProductNotFound
+	}
+
+	update := bson.M{"$set": bson.M{"featured": featured, "updated_at": time.Now()}}
+	if featured && until != nil {
+		update["$set"].(bson.M)["featured_until"] = *until
+	} else {
+		update["$unset"] = bson.M{"featured_until": ""}
+	}
+
+	var product domain.Product
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// UnfeatureExpired unfeatures the products whose feature expired by now
+func (r *ProductRepository) UnfeatureExpired(ctx context.Context, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"featured": true, "featured_until": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"featured": false, "updated_at": now}, "$unset": bson.M{"featured_until": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// recordOperation records an inventory operation in the session's
+// transaction, and reports whether one with its ID was recorded before.
+// Operations without an ID are not recorded.
+func (r *ProductRepository) recordOperation(sc mongo.SessionContext, op domain.InventoryOperation) (bool, error) {
+	if op.OperationID == "" {
+		return false, nil
+	}
+	opCollection := r.operations()
+
+	var existingOp domain.InventoryOperation
+	err := opCollection.FindOne(sc, bson.M{"operation_id": op.OperationID}).Decode(&existingOp)
+	if err == nil {
+		return true, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	_, err = opCollection.InsertOne(sc, op)
+	return false, err
+}
+
+// UpdatePreorders takes (negative quantityChange) or cancels (positive)
+// preorders of a product on preorder, once per operationID
+func (r *ProductRepository) UpdatePreorders(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.PreorderInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, domain.ErrProductNotFound
+	}
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var preorder *domain.PreorderInfo
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
+
+		duplicate, err := r.recordOperation(sc, domain.InventoryOperation{
+			ProductID:      productID,
+			QuantityChange: quantityChange,
+			OperationID:    operationID,
+			OperationType:  operationType,
+			Timestamp:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		var product domain.Product
+		if duplicate {
+			if err := r.collection.FindOne(sc, bson.M{"_id": objID}).Decode(&product); err != nil {
+				return err
+			}
+			preorder = product.Preorder
+			return session.CommitTransaction(sc)
+		}
+
+		// Preorders taken may not exceed the allocation, and cancellations
+		// may not exceed the preorders taken
+		filter := bson.M{"_id": objID, "preorder": bson.M{"$type": "object"}}
+		if quantityChange < 0 {
+			filter["$expr"] = bson.M{"$lte": bson.A{
+				bson.M{"$add": bson.A{"$preorder.ordered", -quantityChange}}, "$preorder.allocation",
+			}}
+		} else {
+			filter["preorder.ordered"] = bson.M{"$gte": quantityChange}
+		}
+		update := bson.M{
+			"$inc": bson.M{"preorder.ordered": -quantityChange},
+			"$set": bson.M{"updated_at": time.Now()},
+		}
+		err = r.collection.FindOneAndUpdate(sc, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&product)
+		if err == mongo.ErrNoDocuments {
+			return r.preorderRefusal(sc, objID, quantityChange)
+		}
+		if err != nil {
+			return err
+		}
+		preorder = product.Preorder
+		return session.CommitTransaction(sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preorder, nil
+}
+
+// preorderRefusal explains why a preorder update matched no product
+func (r *ProductRepository) preorderRefusal(sc mongo.SessionContext, objID primitive.ObjectID, quantityChange int) error {
+	var product domain.Product
+	err := r.collection.FindOne(sc, bson.M{"_id": objID}).Decode(&product)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return domain.ErrProductNotFound
+	case err != nil:
+		return err
+	case !product.OnPreorder():
+		return domain.ErrNotOnPreorder
+	case quantityChange < 0:
+		return domain.ErrPreorderSoldOut
+	default:
+		return apperrors.New(apperrors.Invalid, "cannot cancel more preorders than were taken")
+	}
+}
+
+// ReleaseDue releases the next product on preorder whose release date is at
+// or before now: its preorders become reservations and it stops taking
+// preorders. Each product is released by a single call, even with several
+// instances running the release job.
+func (r *ProductRepository) ReleaseDue(ctx context.Context, now time.Time) (*domain.PreorderRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	filter := bson.M{"preorder": bson.M{"$type": "object"}, "release_date": bson.M{"$lte": now}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"inventory.reserved": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$inventory.reserved", 0}}, "$preorder.ordered"}},
+			"updated_at":         now,
+		}}},
+		{{Key: "$unset", Value: "preorder"}},
+	}
+
+	var product domain.Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "release_date", Value: 1}})).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The document is the one before the update
+	converted := product.Preorder.Ordered
+	product.Inventory.Reserved += converted
+	product.Preorder = nil
+	product.UpdatedAt = now
+	return &domain.PreorderRelease{Product: &product, Converted: converted}, nil
+}
@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PromotionRepository implements the domain.PromotionRepository interface
+// with MongoDB
+type PromotionRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewPromotionRepository creates a new PromotionRepository
+func NewPromotionRepository(client *mongo.Client, cfg *config.MongoDBConfig) *PromotionRepository {
+	return &PromotionRepository{
+		collection: client.Database(cfg.Database).Collection("promotions"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the index live promotions are read with
+func (r *PromotionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "active", Value: 1}, {Key: "ends_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create promotion indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new promotion
+func (r *PromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if promotion.ID.IsZero() {
+		promotion.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, promotion)
+	return err
+}
+
+// Get retrieves a promotion by its ID
+func (r *PromotionRepository) Get(ctx context.Context, id string) (*domain.Promotion, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrPromotionNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var promotion domain.Promotion
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&promotion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrPromotionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// Update replaces an existing promotion
+func (r *PromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": promotion.ID}, promotion)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrPromotionNotFound
+	}
+	return nil
+}
+
+// Delete removes a promotion by its ID
+func (r *PromotionRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrPromotionNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrPromotionNotFound
+	}
+	return nil
+}
+
+// List returns a page of all promotions, latest start first
+func (r *PromotionRepository) List(ctx context.Context, page pagination.Request) ([]*domain.Promotion, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "starts_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.PageSize))
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	promotions := []*domain.Promotion{}
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, 0, err
+	}
+	return promotions, int(total), nil
+}
+
+// Live returns the active promotions that have not ended by t
+func (r *PromotionRepository) Live(ctx context.Context, t time.Time) ([]*domain.Promotion, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"active": true,
+		"$or": bson.A{
+			bson.M{"ends_at": bson.M{"$exists": false}},
+			bson.M{"ends_at": bson.M{"$gt": t}},
+		},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []*domain.Promotion
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
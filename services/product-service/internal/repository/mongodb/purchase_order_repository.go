@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PurchaseOrderRepository implements the domain.PurchaseOrderRepository interface with MongoDB
+type PurchaseOrderRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewPurchaseOrderRepository creates a new PurchaseOrderRepository
+func NewPurchaseOrderRepository(client *mongo.Client, cfg *config.MongoDBConfig) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{
+		collection: client.Database(cfg.Database).Collection("purchase_orders"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes used to list purchase orders
+func (r *PurchaseOrderRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "supplier_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create purchase order indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new purchase order
+func (r *PurchaseOrderRepository) Create(ctx context.Context, po *domain.PurchaseOrder) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if po.ID.IsZero() {
+		po.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, po)
+	return err
+}
+
+// GetByID retrieves a purchase order by its ID
+func (r *PurchaseOrderRepository) GetByID(ctx context.Context, id string) (*domain.PurchaseOrder, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrPurchaseOrderNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var po domain.PurchaseOrder
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&po)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// Update replaces a purchase order guarded by its version
+func (r *PurchaseOrderRepository) Update(ctx context.Context, po *domain.PurchaseOrder) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	expected := po.Version
+	po.Version++
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": po.ID, "version": expected}, po)
+	if err != nil {
+		po.Version = expected
+		return err
+	}
+	if result.MatchedCount == 0 {
+		po.Version = expected
+		return domain.ErrPurchaseOrderConflict
+	}
+	return nil
+}
+
+// List returns purchase orders, newest first
+func (r *PurchaseOrderRepository) List(ctx context.Context, filter domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.SupplierID != "" {
+		supplierID, err := primitive.ObjectIDFromHex(filter.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+		query["supplier_id"] = supplierID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(filter.Limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*domain.PurchaseOrder
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReviewRepository implements the domain.ReviewRepository interface with
+// MongoDB. Reviews are kept in product_reviews; the rating they add up to
+// in the products' rating field.
+type ReviewRepository struct {
+	collection *mongo.Collection
+	products   *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewReviewRepository creates a new ReviewRepository
+func NewReviewRepository(client *mongo.Client, cfg *config.MongoDBConfig) *ReviewRepository {
+	db := client.Database(cfg.Database)
+	return &ReviewRepository{
+		collection: db.Collection("product_reviews"),
+		products:   db.Collection(cfg.Collection),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the unique index that allows one review per user
+// and product, and the index review lists are read with
+func (r *ReviewRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create review indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a review and sets the rating of its product from all of
+// its reviews. Reviews are never removed, so of two ratings computed by
+// concurrent writes the one counting more reviews is the later: the rating
+// is only replaced by one with a higher count, and a write that lost the
+// race returns the stored rating.
+func (r *ReviewRepository) Create(ctx context.Context, review *domain.Review) (*domain.Rating, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if review.ID.IsZero() {
+		review.ID = primitive.NewObjectID()
+	}
+	if _, err := r.collection.InsertOne(ctx, review); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrReviewExists
+		}
+		return nil, err
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": review.ProductID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rating domain.Rating
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&rating); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	rating.Average = domain.RoundRating(rating.Average)
+
+	result, err := r.products.UpdateOne(ctx, newerRatingFilter(review.ProductID, rating.Count), bson.M{"$set": bson.M{"rating": rating}})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount > 0 {
+		return &rating, nil
+	}
+
+	var stored struct {
+		Rating *domain.Rating `bson:"rating"`
+	}
+	err = r.products.FindOne(ctx, bson.M{"_id": review.ProductID}, options.FindOne().SetProjection(bson.M{"rating": 1})).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && stored.Rating == nil) {
+		return &rating, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored.Rating, nil
+}
+
+// newerRatingFilter selects the product unless its stored rating already
+// counts at least count reviews
+func newerRatingFilter(productID primitive.ObjectID, count int) bson.M {
+	return bson.M{
+		"_id": productID,
+		"$or": bson.A{
+			bson.M{"rating.count": bson.M{"$exists": false}},
+			bson.M{"rating.count": bson.M{"$lt": count}},
+		},
+	}
+}
+
+// List returns a page of the reviews of a product, newest first
+func (r *ReviewRepository) List(ctx context.Context, productID string, page pagination.Request) ([]*domain.Review, int, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, 0, domain.ErrProductNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{"product_id": objID}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.PageSize))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []*domain.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, int(total), nil
+}
@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNewerRatingFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	assert.Equal(t, bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"rating.count": bson.M{"$exists": false}},
+			bson.M{"rating.count": bson.M{"$lt": 3}},
+		},
+	}, newerRatingFilter(id, 3))
+}
+
+// TestConcurrentReviews runs against MongoDB when PRODUCT_TEST_MONGODB_URI
+// is set
+func TestConcurrentReviews(t *testing.T) {
+	uri := os.Getenv("PRODUCT_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("PRODUCT_TEST_MONGODB_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+	cfg := &config.MongoDBConfig{
+		Database:     fmt.Sprintf("product_reviews_test_%d", time.Now().UnixNano()),
+		Collection:   "products",
+		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  10 * time.Second,
+	}
+	t.Cleanup(func() { client.Database(cfg.Database).Drop(ctx) })
+	repo := NewReviewRepository(client, cfg)
+	require.NoError(t, repo.EnsureIndexes(ctx))
+
+	productID := primitive.NewObjectID()
+	_, err = client.Database(cfg.Database).Collection(cfg.Collection).InsertOne(ctx, bson.M{"_id": productID, "name": "Mug"})
+	require.NoError(t, err)
+
+	const reviews = 20
+	var wg sync.WaitGroup
+	for i := 0; i < reviews; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.Create(ctx, &domain.Review{ProductID: productID, UserID: fmt.Sprintf("user-%d", i), Rating: i%5 + 1, CreatedAt: time.Now()})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	var product struct {
+		Rating domain.Rating `bson:"rating"`
+	}
+	require.NoError(t, client.Database(cfg.Database).Collection(cfg.Collection).FindOne(ctx, bson.M{"_id": productID}).Decode(&product))
+	assert.Equal(t, domain.Rating{Average: 3, Count: reviews}, product.Rating, "the last write counts every review")
+}
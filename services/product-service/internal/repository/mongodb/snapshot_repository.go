@@ -0,0 +1,225 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SnapshotRepository implements the domain.SnapshotRepository interface
+// with MongoDB. Snapshots are kept in inventory_snapshots, one document
+// per product and day; units sold and received are read from the
+// inventory operations recorded with the products.
+type SnapshotRepository struct {
+	collection *mongo.Collection
+	products   *mongo.Collection
+	operations *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewSnapshotRepository creates a new SnapshotRepository
+func NewSnapshotRepository(client *mongo.Client, cfg *config.MongoDBConfig) *SnapshotRepository {
+	db := client.Database(cfg.Database)
+	return &SnapshotRepository{
+		collection: db.Collection("inventory_snapshots"),
+		products:   db.Collection(cfg.Collection),
+		operations: db.Collection("inventory_operations"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes used to select days, which also drop
+// snapshots past their retention, and the index used to select inventory
+// operations by time
+func (r *SnapshotRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "day", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(domain.SnapshotRetention.Seconds())),
+		},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "day", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot indexes: %w", err)
+	}
+	_, err = r.operations.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "timestamp", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create inventory operation indexes: %w", err)
+	}
+	return nil
+}
+
+// Capture snapshots the stock of every physical product for the day. The
+// products are copied by the server, so the catalog is not loaded into
+// the service whatever its size.
+func (r *SnapshotRepository) Capture(ctx context.Context, day, at time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	day = domain.Day(day)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"type": bson.M{"$ne": domain.ProductTypeDigital}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         bson.D{{Key: "product_id", Value: "$_id"}, {Key: "day", Value: day}},
+			"product_id":  "$_id",
+			"day":         day,
+			"category":    "$category",
+			"sku":         "$inventory.sku",
+			"quantity":    "$inventory.quantity",
+			"reserved":    bson.M{"$ifNull": bson.A{"$inventory.reserved", 0}},
+			"unit_price":  "$price",
+			"value":       bson.M{"$multiply": bson.A{"$inventory.quantity", "$price"}},
+			"captured_at": at,
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           r.collection.Name(),
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	}
+	cursor, err := r.products.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return 0, err
+	}
+
+	captured, err := r.collection.CountDocuments(ctx, bson.M{"day": day, "captured_at": at})
+	return int(captured), err
+}
+
+// StockLevels returns the stock captured on each day of the range, in day
+// order
+func (r *SnapshotRepository) StockLevels(ctx context.Context, params domain.StockLevelParams) ([]domain.StockLevel, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{"day": bson.M{"$gte": domain.Day(params.From), "$lte": domain.Day(params.To)}}
+	if params.Category != "" {
+		filter["category"] = params.Category
+	}
+	if params.ProductID != "" {
+		objID, err := primitive.ObjectIDFromHex(params.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		filter["product_id"] = objID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$day",
+			"products": bson.M{"$sum": 1},
+			"quantity": bson.M{"$sum": "$quantity"},
+			"reserved": bson.M{"$sum": "$reserved"},
+			"value":    bson.M{"$sum": "$value"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	levels := []domain.StockLevel{}
+	if err := cursor.All(ctx, &levels); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// OpeningStock returns the stock per category on the first day captured
+// from the day before from up to to, in category order
+func (r *SnapshotRepository) OpeningStock(ctx context.Context, from, to time.Time) ([]domain.CategoryStock, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var first struct {
+		Day time.Time `bson:"day"`
+	}
+	err := r.collection.FindOne(ctx,
+		bson.M{"day": bson.M{"$gte": domain.Day(from).Add(-24 * time.Hour), "$lte": domain.Day(to)}},
+		options.FindOne().SetSort(bson.D{{Key: "day", Value: 1}}).SetProjection(bson.M{"day": 1}),
+	).Decode(&first)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": first.Day}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category", "quantity": bson.M{"$sum": "$quantity"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var stock []domain.CategoryStock
+	if err := cursor.All(ctx, &stock); err != nil {
+		return nil, time.Time{}, err
+	}
+	return stock, first.Day.UTC(), nil
+}
+
+// Movements returns the units sold and received per category from from
+// until to, in category order. Purchases count as sold and restocks as
+// received; operations of deleted products count under no category.
+func (r *SnapshotRepository) Movements(ctx context.Context, from, to time.Time) ([]domain.CategoryMovement, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	isType := func(operationType string) bson.M {
+		return bson.M{"$eq": bson.A{"$operation_type", operationType}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"timestamp":      bson.M{"$gte": from, "$lt": to},
+			"operation_type": bson.M{"$in": bson.A{"purchase", "restock"}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$product_id",
+			"sold":     bson.M{"$sum": bson.M{"$cond": bson.A{isType("purchase"), bson.M{"$multiply": bson.A{"$quantity_change", -1}}, 0}}},
+			"received": bson.M{"$sum": bson.M{"$cond": bson.A{isType("restock"), "$quantity_change", 0}}},
+		}}},
+		// Operations refer to products by their hex ID
+		{{Key: "$addFields", Value: bson.M{
+			"product_oid": bson.M{"$convert": bson.M{"input": "$_id", "to": "objectId", "onError": nil, "onNull": nil}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.products.Name(),
+			"localField":   "product_oid",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$product.category", 0}}, ""}},
+			"sold":     bson.M{"$sum": "$sold"},
+			"received": bson.M{"$sum": "$received"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.operations.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var movements []domain.CategoryMovement
+	if err := cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
@@ -0,0 +1,184 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StockAlertRepository implements the domain.StockAlertRepository
+// interface with MongoDB
+type StockAlertRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewStockAlertRepository creates a new StockAlertRepository
+func NewStockAlertRepository(client *mongo.Client, cfg *config.MongoDBConfig) *StockAlertRepository {
+	return &StockAlertRepository{
+		collection: client.Database(cfg.Database).Collection("stock_alerts"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes the alerts of a product are found
+// with on each inventory update
+func (r *StockAlertRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "category", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stock alert indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new stock alert
+func (r *StockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if alert.ID.IsZero() {
+		alert.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, alert)
+	return err
+}
+
+// Get retrieves a stock alert by its ID
+func (r *StockAlertRepository) Get(ctx context.Context, id string) (*domain.StockAlert, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrStockAlertNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var alert domain.StockAlert
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&alert)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrStockAlertNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Update replaces an existing stock alert
+func (r *StockAlertRepository) Update(ctx context.Context, alert *domain.StockAlert) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": alert.ID}, alert)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrStockAlertNotFound
+	}
+	return nil
+}
+
+// Delete removes a stock alert by its ID
+func (r *StockAlertRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrStockAlertNotFound
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrStockAlertNotFound
+	}
+	return nil
+}
+
+// List returns a page of all stock alerts, newest first
+func (r *StockAlertRepository) List(ctx context.Context, page pagination.Request) ([]*domain.StockAlert, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.PageSize))
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []*domain.StockAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, 0, err
+	}
+	return alerts, int(total), nil
+}
+
+// Matching returns the alerts watching the product or one of the
+// categories
+func (r *StockAlertRepository) Matching(ctx context.Context, productID string, categories []string) ([]*domain.StockAlert, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"product_id": productID},
+		bson.M{"category": bson.M{"$in": categories}},
+	}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []*domain.StockAlert
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// MarkAlerted adds the product to the alert's alerted list unless it is
+// there already, in a single update so that one of concurrent callers wins
+func (r *StockAlertRepository) MarkAlerted(ctx context.Context, id primitive.ObjectID, productID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "alerted": bson.M{"$ne": productID}},
+		bson.M{"$addToSet": bson.M{"alerted": productID}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ClearAlerted removes the product from the alert's alerted list
+func (r *StockAlertRepository) ClearAlerted(ctx context.Context, id primitive.ObjectID, productID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"alerted": productID}})
+	return err
+}
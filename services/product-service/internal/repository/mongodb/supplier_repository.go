@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/product-service/config"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SupplierRepository implements the domain.SupplierRepository interface with MongoDB
+type SupplierRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewSupplierRepository creates a new SupplierRepository
+func NewSupplierRepository(client *mongo.Client, cfg *config.MongoDBConfig) *SupplierRepository {
+	return &SupplierRepository{
+		collection: client.Database(cfg.Database).Collection("suppliers"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the unique index on supplier codes
+func (r *SupplierRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create supplier indexes: %w", err)
+	}
+	return nil
+}
+
+// Create inserts a new supplier
+func (r *SupplierRepository) Create(ctx context.Context, supplier *domain.Supplier) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	if supplier.ID.IsZero() {
+		supplier.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, supplier)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrSupplierCodeExists
+	}
+	return err
+}
+
+// GetByID retrieves a supplier by its ID
+func (r *SupplierRepository) GetByID(ctx context.Context, id string) (*domain.Supplier, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrSupplierNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var supplier domain.Supplier
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&supplier)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrSupplierNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// Update replaces an existing supplier
+func (r *SupplierRepository) Update(ctx context.Context, supplier *domain.Supplier) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": supplier.ID}, supplier)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrSupplierCodeExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrSupplierNotFound
+	}
+	return nil
+}
+
+// Delete removes a supplier by its ID
+func (r *SupplierRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrSupplierNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrSupplierNotFound
+	}
+	return nil
+}
+
+// List returns suppliers ordered by name
+func (r *SupplierRepository) List(ctx context.Context, activeOnly bool) ([]*domain.Supplier, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var suppliers []*domain.Supplier
+	if err := cursor.All(ctx, &suppliers); err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetAuditRepository configures where the audit trail of products is
+// stored. Until it is set, changes are not audited.
+func (s *ProductService) SetAuditRepository(audits domain.AuditRepository) {
+	s.audits = audits
+}
+
+// ProductAudit returns a page of the changes made to a product, newest
+// first, and how many there are. The trail of a deleted product is kept.
+func (s *ProductService) ProductAudit(ctx context.Context, productID string, page pagination.Request) ([]*domain.AuditEntry, int, error) {
+	if s.audits == nil {
+		return nil, 0, apperrors.New(apperrors.Unavailable, "product audit not available")
+	}
+	if !primitive.IsValidObjectID(productID) {
+		return nil, 0, domain.ErrProductNotFound
+	}
+	page = pagination.New(page.Page, page.PageSize, domain.AuditPagination)
+	entries, total, err := s.audits.List(ctx, productID, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+	return entries, total, nil
+}
+
+// auditFields returns the audited fields of a product before a change, or
+// nil when changes are not audited
+func (s *ProductService) auditFields(product *domain.Product) map[string]interface{} {
+	if s.audits == nil {
+		return nil
+	}
+	return domain.AuditFields(product)
+}
+
+// auditStored returns the audited fields of a stored product before a
+// change that does not read it, or nil when changes are not audited or
+// the product cannot be read
+func (s *ProductService) auditStored(ctx context.Context, productID string) map[string]interface{} {
+	if s.audits == nil {
+		return nil
+	}
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil
+	}
+	return domain.AuditFields(product)
+}
+
+// audit records the change of a product from the fields before to after,
+// made by the authenticated caller. The change is made by then, so a
+// failure to record it is logged rather than returned.
+func (s *ProductService) audit(ctx context.Context, action, productID string, before map[string]interface{}, after *domain.Product) {
+	if s.audits == nil {
+		return
+	}
+	changes := domain.Diff(before, domain.AuditFields(after))
+	if len(changes) == 0 && action == domain.AuditUpdate {
+		return
+	}
+	s.recordAudit(ctx, &domain.AuditEntry{ProductID: productID, Action: action, Changes: changes})
+}
+
+// auditInventory records an inventory operation that moved field, such as
+// "inventory.quantity", by change to the value to
+func (s *ProductService) auditInventory(ctx context.Context, productID, operationID, operationType, field string, change, to int) {
+	if s.audits == nil || change == 0 {
+		return
+	}
+	s.recordAudit(ctx, &domain.AuditEntry{
+		ProductID:     productID,
+		Action:        domain.AuditInventory,
+		OperationID:   operationID,
+		OperationType: operationType,
+		Changes:       []domain.FieldChange{{Field: field, From: to - change, To: to}},
+	})
+}
+
+// recordAudit records an entry for the authenticated caller
+func (s *ProductService) recordAudit(ctx context.Context, entry *domain.AuditEntry) {
+	if principal, ok := middleware.PrincipalFrom(ctx); ok {
+		entry.Actor = principal.Subject
+	}
+	if entry.Changes == nil {
+		entry.Changes = []domain.FieldChange{}
+	}
+	entry.At = time.Now().UTC()
+	if err := s.audits.Record(ctx, entry); err != nil {
+		s.logger.Error("Failed to record product audit", "productID", entry.ProductID, "action", entry.Action, "error", err)
+	}
+}
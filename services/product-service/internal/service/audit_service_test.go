@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAuditRepository keeps audit entries in memory, oldest first
+type memoryAuditRepository struct {
+	entries []*domain.AuditEntry
+}
+
+func (r *memoryAuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	for _, recorded := range r.entries {
+		if entry.OperationID != "" && recorded.ProductID == entry.ProductID && recorded.OperationID == entry.OperationID {
+			return nil
+		}
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, productID string, page pagination.Request) ([]*domain.AuditEntry, int, error) {
+	entries := []*domain.AuditEntry{}
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].ProductID == productID {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, len(entries), nil
+}
+
+func newAuditedService() (*ProductService, *MockProductRepository, *memoryAuditRepository) {
+	service, products := newMerchandisingService()
+	audits := &memoryAuditRepository{}
+	service.SetAuditRepository(audits)
+	return service, products, audits
+}
+
+func TestAuditUpdate(t *testing.T) {
+	service, products, audits := newAuditedService()
+	product := builders.NewProduct(t).WithPrice(19.99).WithAttribute("color", "blue").Build()
+	product.Slug = domain.Slugify(product.Name)
+	material := product.Attributes["material"]
+	productID := product.ID.Hex()
+	products.On("GetByID", productID).Return(product, nil)
+	products.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Subject: "ops"})
+	_, err := service.UpdateProduct(ctx, &domain.Product{
+		ID:         product.ID,
+		Price:      24.99,
+		Attributes: map[string]string{"color": "red"},
+		Active:     true,
+	})
+	require.NoError(t, err)
+
+	entries, total, err := service.ProductAudit(context.Background(), productID, pagination.Request{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	entry := entries[0]
+	assert.Equal(t, domain.AuditUpdate, entry.Action)
+	assert.Equal(t, "ops", entry.Actor)
+	assert.False(t, entry.At.IsZero())
+	// Only the changed fields are recorded, nested ones by their path
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "attributes.color", From: "blue", To: "red"},
+		{Field: "attributes.material", From: material},
+		{Field: "price", From: 19.99, To: 24.99},
+	}, entry.Changes)
+
+	// An update changing nothing is not recorded
+	_, err = service.SetProductBadges(ctx, productID, nil)
+	require.NoError(t, err)
+	assert.Len(t, audits.entries, 1)
+}
+
+func TestAuditCreateAndDelete(t *testing.T) {
+	service, products, audits := newAuditedService()
+	product := builders.NewProduct(t).WithoutID().WithSKU("MUG-001").Build()
+	products.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	created, err := service.CreateProduct(context.Background(), product)
+	require.NoError(t, err)
+	productID := created.ID.Hex()
+	products.On("GetByID", productID).Return(created, nil)
+	products.On("Delete", productID).Return(nil)
+	require.NoError(t, service.DeleteProduct(context.Background(), productID))
+
+	require.Len(t, audits.entries, 2)
+	create, remove := audits.entries[0], audits.entries[1]
+	assert.Equal(t, domain.AuditCreate, create.Action)
+	assert.Empty(t, create.Actor, "without authentication")
+	assert.Contains(t, create.Changes, domain.FieldChange{Field: "inventory.sku", To: "MUG-001"})
+	assert.Equal(t, domain.AuditDelete, remove.Action)
+	assert.Contains(t, remove.Changes, domain.FieldChange{Field: "inventory.sku", From: "MUG-001"})
+	for _, change := range append(create.Changes, remove.Changes...) {
+		assert.NotContains(t, []string{"id", "updated_at", "is_new"}, change.Field)
+	}
+}
+
+func TestAuditInventory(t *testing.T) {
+	service, products, audits := newAuditedService()
+	product := builders.NewProduct(t).WithStock(10).Build()
+	productID := product.ID.Hex()
+	products.On("UpdateInventory", productID, 5, "op-1", "restock").
+		Return(&domain.InventoryInfo{Quantity: 15, SKU: product.Inventory.SKU, InStock: true}, nil)
+
+	for range 2 {
+		_, err := service.UpdateInventory(context.Background(), productID, 5, "op-1", "restock")
+		require.NoError(t, err)
+	}
+
+	// The retried operation is recorded once
+	require.Len(t, audits.entries, 1)
+	entry := audits.entries[0]
+	assert.Equal(t, domain.AuditInventory, entry.Action)
+	assert.Equal(t, "op-1", entry.OperationID)
+	assert.Equal(t, "restock", entry.OperationType)
+	assert.Equal(t, []domain.FieldChange{{Field: "inventory.quantity", From: 10, To: 15}}, entry.Changes)
+}
+
+func TestProductAuditErrors(t *testing.T) {
+	service, _ := newMerchandisingService()
+	_, _, err := service.ProductAudit(context.Background(), "65f1c0d2e4b0a1b2c3d4e5f1", pagination.Request{})
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable), "without a repository: %v", err)
+
+	service, _, _ = newAuditedService()
+	_, _, err = service.ProductAudit(context.Background(), "not-an-id", pagination.Request{})
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetBackfillRepository configures how the catalog is read and written by
+// BackfillDerivedFields
+func (s *ProductService) SetBackfillRepository(backfill domain.ProductBackfillRepository) {
+	s.backfill = backfill
+}
+
+// BackfillOptions configures a backfill of derived fields
+type BackfillOptions struct {
+	// Fields are the paths of the derived fields to recompute, all of
+	// domain.DerivedFields if empty
+	Fields []string
+	// BatchSize is the number of products read and written at once
+	BatchSize int
+	// Rate limits the products processed per second; zero is unlimited
+	Rate float64
+	// After resumes a backfill after the product with this ID
+	After string
+	// DryRun counts the stale fields without writing them
+	DryRun bool
+}
+
+// BackfillProgress is how far a backfill has got
+type BackfillProgress struct {
+	Scanned int
+	Updated int
+	// Stale counts the products whose field differed, by path
+	Stale map[string]int
+	// LastID is the last product processed, to resume from
+	LastID string
+}
+
+// BackfillDerivedFields recomputes derived fields across the catalog in
+// batches, writing only the fields that changed. progress, if not nil, is
+// called after every batch.
+func (s *ProductService) BackfillDerivedFields(ctx context.Context, opts BackfillOptions, progress func(BackfillProgress)) (BackfillProgress, error) {
+	result := BackfillProgress{Stale: map[string]int{}, LastID: opts.After}
+	if s.backfill == nil {
+		return result, apperrors.New(apperrors.Unavailable, "backfill not available")
+	}
+
+	fields := domain.DerivedFields
+	if len(opts.Fields) > 0 {
+		fields = nil
+		for _, path := range opts.Fields {
+			field, ok := domain.LookupDerivedField(path)
+			if !ok {
+				return result, apperrors.Newf(apperrors.Invalid, "unknown derived field %q", path)
+			}
+			fields = append(fields, field)
+		}
+	}
+	var after primitive.ObjectID
+	if opts.After != "" {
+		id, err := primitive.ObjectIDFromHex(opts.After)
+		if err != nil {
+			return result, apperrors.Newf(apperrors.Invalid, "invalid product ID %q to resume after", opts.After)
+		}
+		after = id
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	// Batches are spaced so that the catalog is read at no more than Rate
+	// products per second on average
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(opts.BatchSize) / opts.Rate * float64(time.Second))
+	}
+
+	s.logger.Info("Backfilling derived fields", "fields", len(fields), "after", opts.After, "dryRun", opts.DryRun)
+	for {
+		started := time.Now()
+		products, err := s.backfill.ListAfter(ctx, after, opts.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("repository error: %w", err)
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		var updates []domain.FieldUpdate
+		for _, product := range products {
+			stale := domain.StaleFields(product, fields)
+			for path := range stale {
+				result.Stale[path]++
+			}
+			if len(stale) > 0 {
+				updates = append(updates, domain.FieldUpdate{ProductID: product.ID, Fields: stale})
+			}
+		}
+		if !opts.DryRun {
+			updated, err := s.backfill.SetFields(ctx, updates)
+			if err != nil {
+				return result, fmt.Errorf("repository error: %w", err)
+			}
+			result.Updated += updated
+		}
+		after = products[len(products)-1].ID
+		result.Scanned += len(products)
+		result.LastID = after.Hex()
+		if progress != nil {
+			progress(result)
+		}
+
+		if wait := interval - time.Since(started); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	s.logger.Info("Backfill finished", "scanned", result.Scanned, "updated", result.Updated, "dryRun", opts.DryRun)
+	return result, nil
+}
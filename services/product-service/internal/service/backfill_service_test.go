@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryBackfill keeps products in ID order and applies field updates by path
+type memoryBackfill struct {
+	products []*domain.Product
+}
+
+func (m *memoryBackfill) ListAfter(ctx context.Context, after primitive.ObjectID, limit int) ([]*domain.Product, error) {
+	var page []*domain.Product
+	for _, product := range m.products {
+		if product.ID.Hex() > after.Hex() && len(page) < limit {
+			copied := *product
+			page = append(page, &copied)
+		}
+	}
+	return page, nil
+}
+
+func (m *memoryBackfill) SetFields(ctx context.Context, updates []domain.FieldUpdate) (int, error) {
+	for _, update := range updates {
+		for _, product := range m.products {
+			if product.ID != update.ProductID {
+				continue
+			}
+			for path, value := range update.Fields {
+				switch path {
+				case "inventory.in_stock":
+					product.Inventory.InStock = value.(bool)
+				case "search_name":
+					product.SearchName = value.(string)
+				}
+			}
+		}
+	}
+	return len(updates), nil
+}
+
+func TestBackfillDerivedFields(t *testing.T) {
+	newService := func() (*ProductService, *memoryBackfill) {
+		repo := &memoryBackfill{}
+		for i := 0; i < 7; i++ {
+			product := builders.NewProduct(t).WithStock(i % 2).Build()
+			// Every third product has a stale in_stock
+			product.Inventory.InStock = product.Inventory.Quantity > 0 != (i%3 == 0)
+			product.SearchName = domain.NormalizeSearchName(product.Name)
+			repo.products = append(repo.products, product)
+		}
+		sort.Slice(repo.products, func(i, j int) bool { return repo.products[i].ID.Hex() < repo.products[j].ID.Hex() })
+		service := New(new(MockProductRepository), slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+		service.SetBackfillRepository(repo)
+		return service, repo
+	}
+	stale := func(repo *memoryBackfill) int {
+		n := 0
+		for _, product := range repo.products {
+			n += len(domain.StaleFields(product, domain.DerivedFields))
+		}
+		return n
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		service, repo := newService()
+		result, err := service.BackfillDerivedFields(context.Background(), BackfillOptions{DryRun: true}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 7, result.Scanned)
+		assert.Equal(t, 0, result.Updated)
+		assert.Equal(t, map[string]int{"inventory.in_stock": 3}, result.Stale)
+		assert.Equal(t, 3, stale(repo))
+	})
+
+	t.Run("in batches", func(t *testing.T) {
+		service, repo := newService()
+		var batches []int
+		result, err := service.BackfillDerivedFields(context.Background(), BackfillOptions{BatchSize: 3, Rate: 1000}, func(p BackfillProgress) {
+			batches = append(batches, p.Scanned)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{3, 6, 7}, batches)
+		assert.Equal(t, 3, result.Updated)
+		assert.Equal(t, repo.products[6].ID.Hex(), result.LastID)
+		assert.Zero(t, stale(repo))
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		service, repo := newService()
+		result, err := service.BackfillDerivedFields(context.Background(), BackfillOptions{After: repo.products[3].ID.Hex(), DryRun: true}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Scanned)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		service, _ := newService()
+		_, err := service.BackfillDerivedFields(context.Background(), BackfillOptions{Fields: []string{"average_rating"}}, nil)
+		assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+		_, err = service.BackfillDerivedFields(context.Background(), BackfillOptions{After: "nope"}, nil)
+		assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+
+		_, err = New(new(MockProductRepository), service.logger).BackfillDerivedFields(context.Background(), BackfillOptions{}, nil)
+		assert.Equal(t, apperrors.Unavailable, apperrors.KindOf(err))
+	})
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// maxProductBadges is the most badges that can be assigned to a product
+const maxProductBadges = 10
+
+// SetBadgeRepository configures where badge definitions are stored. Until
+// it is set, or the first RefreshBadges, products are shown with the
+// default automatic badges only.
+func (s *ProductService) SetBadgeRepository(badges domain.BadgeRepository) {
+	s.badgeRepo = badges
+}
+
+// RefreshBadges reloads the badge definitions products are shown with.
+// Each instance keeps its own copy, so changes made through another
+// instance show once it refreshes, or straight away with a cache
+// invalidator.
+func (s *ProductService) RefreshBadges(ctx context.Context) error {
+	if err := s.requireBadges(); err != nil {
+		return err
+	}
+	badges, err := s.badgeRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	definitions := defaultBadgeDefinitions()
+	for _, badge := range badges {
+		definitions[badge.Key] = *badge
+	}
+	s.badgesMu.Lock()
+	s.badges = definitions
+	s.badgesMu.Unlock()
+	return nil
+}
+
+// CreateBadge defines a new badge. Defining an automatic badge replaces
+// its default.
+func (s *ProductService) CreateBadge(ctx context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	s.logger.Info("Creating badge", "key", badge.Key)
+
+	if err := s.requireBadges(); err != nil {
+		return nil, err
+	}
+	if err := badge.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	badge.CreatedAt = time.Now()
+	badge.UpdatedAt = badge.CreatedAt
+	if err := s.badgeRepo.Create(ctx, badge); err != nil {
+		s.logger.Error("Failed to create badge", "key", badge.Key, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshBadgesAfterWrite(ctx)
+	return badge, nil
+}
+
+// ListBadges returns the badge definitions, including the defaults of
+// automatic badges that were not defined, highest priority first
+func (s *ProductService) ListBadges(ctx context.Context) ([]*domain.Badge, error) {
+	if err := s.requireBadges(); err != nil {
+		return nil, err
+	}
+	badges, err := s.badgeRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	defined := make(map[string]bool, len(badges))
+	for _, badge := range badges {
+		defined[badge.Key] = true
+	}
+	for _, badge := range domain.DefaultBadges {
+		if !defined[badge.Key] {
+			badge := badge
+			badges = append(badges, &badge)
+		}
+	}
+	sort.SliceStable(badges, func(i, j int) bool {
+		if badges[i].Priority != badges[j].Priority {
+			return badges[i].Priority > badges[j].Priority
+		}
+		return badges[i].Key < badges[j].Key
+	})
+	return badges, nil
+}
+
+// UpdateBadge replaces the label, color and priority of a badge
+func (s *ProductService) UpdateBadge(ctx context.Context, badge *domain.Badge) (*domain.Badge, error) {
+	s.logger.Info("Updating badge", "key", badge.Key)
+
+	if err := s.requireBadges(); err != nil {
+		return nil, err
+	}
+	if err := badge.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	existing, err := s.badgeRepo.Get(ctx, badge.Key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	badge.CreatedAt = existing.CreatedAt
+	badge.UpdatedAt = time.Now()
+	if err := s.badgeRepo.Update(ctx, badge); err != nil {
+		s.logger.Error("Failed to update badge", "key", badge.Key, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshBadgesAfterWrite(ctx)
+	return badge, nil
+}
+
+// DeleteBadge removes a badge definition. Badges assigned to products
+// cannot be deleted; automatic badges fall back to their defaults.
+func (s *ProductService) DeleteBadge(ctx context.Context, key string) error {
+	s.logger.Info("Deleting badge", "key", key)
+
+	if err := s.requireBadges(); err != nil {
+		return err
+	}
+	_, assigned, err := s.repo.List(ctx, domain.ListProductsParams{Badge: key, PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if assigned > 0 {
+		return fmt.Errorf("%w: assigned to %d products", domain.ErrBadgeInUse, assigned)
+	}
+	if err := s.badgeRepo.Delete(ctx, key); err != nil {
+		s.logger.Error("Failed to delete badge", "key", key, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshBadgesAfterWrite(ctx)
+	return nil
+}
+
+// SetProductBadges replaces the badges assigned to a product
+func (s *ProductService) SetProductBadges(ctx context.Context, productID string, keys []string) (*domain.Product, error) {
+	s.logger.Info("Setting product badges", "productID", productID, "badges", keys)
+
+	keys, err := s.validateProductBadges(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	product.Badges = keys
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to update product badges", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// validateProductBadges checks that the badges to assign are defined and
+// not automatic, and returns them without duplicates
+func (s *ProductService) validateProductBadges(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if err := s.requireBadges(); err != nil {
+		return nil, err
+	}
+
+	var unique []string
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if domain.IsAutomaticBadge(key) {
+			return nil, apperrors.Newf(apperrors.Invalid, "badge %q is given automatically and cannot be assigned", key)
+		}
+		if _, err := s.badgeRepo.Get(ctx, key); err != nil {
+			if apperrors.Is(err, apperrors.NotFound) {
+				return nil, apperrors.Newf(apperrors.Invalid, "badge %q is not defined", key)
+			}
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+		unique = append(unique, key)
+	}
+	if len(unique) > maxProductBadges {
+		return nil, apperrors.Newf(apperrors.Invalid, "at most %d badges can be assigned to a product", maxProductBadges)
+	}
+	return unique, nil
+}
+
+// defaultBadgeDefinitions returns the defaults of the automatic badges
+// by key
+func defaultBadgeDefinitions() map[string]domain.Badge {
+	definitions := make(map[string]domain.Badge, len(domain.DefaultBadges))
+	for _, badge := range domain.DefaultBadges {
+		definitions[badge.Key] = badge
+	}
+	return definitions
+}
+
+// badgeDefinitions returns the badge definitions products are shown with
+func (s *ProductService) badgeDefinitions() map[string]domain.Badge {
+	s.badgesMu.RLock()
+	defer s.badgesMu.RUnlock()
+	return s.badges
+}
+
+// refreshBadgesAfterWrite shows a badge change on this instance straight
+// away, and tells the other instances to reload theirs. Failures are
+// logged; the periodic refresh catches up.
+func (s *ProductService) refreshBadgesAfterWrite(ctx context.Context) {
+	if err := s.RefreshBadges(ctx); err != nil {
+		s.logger.Error("Failed to refresh badges", "error", err)
+	}
+	s.invalidate(ctx, CacheBadges)
+}
+
+func (s *ProductService) requireBadges() error {
+	if s.badgeRepo == nil {
+		return apperrors.New(apperrors.Unavailable, "badges not available")
+	}
+	return nil
+}
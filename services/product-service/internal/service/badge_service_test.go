@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockBadgeRepository is a mock implementation of the domain.BadgeRepository interface
+type MockBadgeRepository struct {
+	mock.Mock
+}
+
+func (m *MockBadgeRepository) Create(ctx context.Context, badge *domain.Badge) error {
+	return m.Called(badge).Error(0)
+}
+
+func (m *MockBadgeRepository) Get(ctx context.Context, key string) (*domain.Badge, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Badge), args.Error(1)
+}
+
+func (m *MockBadgeRepository) Update(ctx context.Context, badge *domain.Badge) error {
+	return m.Called(badge).Error(0)
+}
+
+func (m *MockBadgeRepository) Delete(ctx context.Context, key string) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *MockBadgeRepository) List(ctx context.Context) ([]*domain.Badge, error) {
+	args := m.Called()
+	return args.Get(0).([]*domain.Badge), args.Error(1)
+}
+
+func newBadgeTestService() (*ProductService, *MockProductRepository, *MockBadgeRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	products := new(MockProductRepository)
+	badges := new(MockBadgeRepository)
+	service := New(products, logger)
+	service.SetBadgeRepository(badges)
+	return service, products, badges
+}
+
+func TestResolveBadges(t *testing.T) {
+	definitions := map[string]domain.Badge{
+		domain.BadgeSale:     {Key: domain.BadgeSale, Label: "Sale", Priority: 100},
+		domain.BadgePreorder: {Key: domain.BadgePreorder, Label: "Pre-order", Priority: 80},
+		"eco":                {Key: "eco", Label: "Eco", Priority: 40},
+		"bestseller":         {Key: "bestseller", Label: "Bestseller", Priority: 40},
+	}
+	compareAt := 30.0
+	product := builders.NewProduct(t).WithPrice(24).Build()
+	product.CompareAtPrice = &compareAt
+	product.Badges = []string{"eco", "retired", "bestseller"}
+
+	keys := func(badges []domain.ProductBadge) []string {
+		var keys []string
+		for _, badge := range badges {
+			keys = append(keys, badge.Key)
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"sale", "bestseller", "eco"}, keys(domain.ResolveBadges(product, definitions)))
+
+	compareAt = 24
+	assert.Equal(t, []string{"bestseller", "eco"}, keys(domain.ResolveBadges(product, definitions)),
+		"the sale badge needs a compare-at price above the price")
+
+	preorder := builders.NewProduct(t).WithPreorder(time.Now().Add(24*time.Hour), 10).Build()
+	assert.Equal(t, []string{"preorder"}, keys(domain.ResolveBadges(preorder, definitions)))
+}
+
+func TestRefreshBadgesOverridesDefaults(t *testing.T) {
+	service, _, badges := newBadgeTestService()
+	badges.On("List").Return([]*domain.Badge{
+		{Key: domain.BadgeSale, Label: "Deal", Color: "#000000", Priority: 10},
+		{Key: "eco", Label: "Eco", Color: "#2e7d32", Priority: 40},
+	}, nil)
+	require.NoError(t, service.RefreshBadges(context.Background()))
+
+	compareAt := 20.0
+	product := builders.NewProduct(t).WithPrice(10).Build()
+	product.CompareAtPrice = &compareAt
+	product.Badges = []string{"eco"}
+	service.merchandise(product)
+
+	assert.Equal(t, []domain.ProductBadge{
+		{Key: "eco", Label: "Eco", Color: "#2e7d32"},
+		{Key: domain.BadgeSale, Label: "Deal", Color: "#000000"},
+	}, product.DisplayBadges)
+}
+
+func TestSetProductBadges(t *testing.T) {
+	service, products, badges := newBadgeTestService()
+	product := builders.NewProduct(t).Build()
+	badges.On("Get", "eco").Return(&domain.Badge{Key: "eco", Label: "Eco"}, nil)
+	badges.On("Get", "retired").Return(nil, domain.ErrBadgeNotFound)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	_, err := service.SetProductBadges(context.Background(), product.ID.Hex(), []string{domain.BadgeSale})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	assert.ErrorContains(t, err, "given automatically")
+
+	_, err = service.SetProductBadges(context.Background(), product.ID.Hex(), []string{"eco", "retired"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	assert.ErrorContains(t, err, "not defined")
+	products.AssertNotCalled(t, "Update", mock.Anything)
+
+	updated, err := service.SetProductBadges(context.Background(), product.ID.Hex(), []string{"eco", "eco"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eco"}, updated.Badges)
+}
+
+func TestDeleteBadge(t *testing.T) {
+	service, products, badges := newBadgeTestService()
+	products.On("List", domain.ListProductsParams{Badge: "eco", PageSize: 1}).Return([]*domain.Product{builders.NewProduct(t).Build()}, 2, nil)
+	products.On("List", domain.ListProductsParams{Badge: "retired", PageSize: 1}).Return([]*domain.Product{}, 0, nil)
+	badges.On("Delete", "retired").Return(nil)
+	badges.On("List").Return([]*domain.Badge{}, nil)
+
+	err := service.DeleteBadge(context.Background(), "eco")
+	assert.ErrorIs(t, err, domain.ErrBadgeInUse)
+	badges.AssertNotCalled(t, "Delete", "eco")
+
+	assert.NoError(t, service.DeleteBadge(context.Background(), "retired"))
+}
+
+func TestBadgesUnavailableWithoutRepository(t *testing.T) {
+	service, _ := newMerchandisingService()
+
+	_, err := service.ListBadges(context.Background())
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+
+	// Products are still shown with the default automatic badges
+	compareAt := 20.0
+	product := builders.NewProduct(t).WithPrice(10).Build()
+	product.CompareAtPrice = &compareAt
+	service.merchandise(product)
+	require.Len(t, product.DisplayBadges, 1)
+	assert.Equal(t, domain.BadgeSale, product.DisplayBadges[0].Key)
+}
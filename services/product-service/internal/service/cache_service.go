@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// The in-memory caches each instance keeps, by the names they are
+// invalidated with
+const (
+	// CacheTags is the tags in use, cached by ListTags
+	CacheTags = "tags"
+	// CacheBadges is the badge definitions, reloaded by RefreshBadges
+	CacheBadges = "badges"
+	// CachePromotions is the live promotions, reloaded by RefreshPromotions
+	CachePromotions = "promotions"
+)
+
+// CacheInvalidator tells the other instances of the service that one of
+// their caches is stale
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context, cache string) error
+}
+
+// SetCacheInvalidator configures how the other instances are told about
+// changes to what their caches hold. Until it is set, they see a change
+// once their cache expires or is refreshed.
+func (s *ProductService) SetCacheInvalidator(invalidator CacheInvalidator) {
+	s.invalidator = invalidator
+}
+
+// DropCache drops the named cache on this instance, when another instance
+// has changed what it holds. The tags are counted again when next listed;
+// badges and promotions are reloaded straight away.
+func (s *ProductService) DropCache(ctx context.Context, cache string) {
+	s.logger.Info("Dropping cache", "cache", cache)
+
+	switch cache {
+	case CacheTags:
+		s.dropTags()
+	case CacheBadges:
+		if s.badgeRepo == nil {
+			return
+		}
+		if err := s.RefreshBadges(ctx); err != nil {
+			s.logger.Error("Failed to refresh badges", "error", err)
+		}
+	case CachePromotions:
+		if s.promotionRepo == nil {
+			return
+		}
+		if err := s.RefreshPromotions(ctx); err != nil {
+			s.logger.Error("Failed to refresh promotions", "error", err)
+		}
+	default:
+		s.logger.Warn("Ignoring invalidation of unknown cache", "cache", cache)
+	}
+}
+
+// invalidate tells the other instances that the named cache is stale,
+// after a write this instance has already applied to its own. The write
+// is made by then, so a failure is logged; the other instances catch up
+// once their cache expires or is refreshed.
+func (s *ProductService) invalidate(ctx context.Context, cache string) {
+	if s.invalidator == nil {
+		return
+	}
+	if err := s.invalidator.Invalidate(ctx, cache); err != nil {
+		s.logger.Error("Failed to invalidate cache", "cache", cache, "error", err)
+	}
+}
+
+// invalidateTags drops the tags in use on this instance and every other,
+// after a write that may have changed them
+func (s *ProductService) invalidateTags(ctx context.Context) {
+	s.dropTags()
+	s.invalidate(ctx, CacheTags)
+}
+
+// dropTags makes ListTags count the tags again
+func (s *ProductService) dropTags() {
+	s.tagsMu.Lock()
+	defer s.tagsMu.Unlock()
+	s.tagsLoaded = time.Time{}
+}
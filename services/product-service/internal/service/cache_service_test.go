@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingInvalidator records the caches invalidated, failing with err
+type recordingInvalidator struct {
+	caches []string
+	err    error
+}
+
+func (i *recordingInvalidator) Invalidate(ctx context.Context, cache string) error {
+	i.caches = append(i.caches, cache)
+	return i.err
+}
+
+func TestProductWriteInvalidatesTags(t *testing.T) {
+	service, products := newMerchandisingService()
+	invalidator := &recordingInvalidator{err: errors.New("connection refused")}
+	service.SetCacheInvalidator(invalidator)
+	product := builders.NewProduct(t).Build()
+	product.Slug = domain.Slugify(product.Name)
+	products.On("ListTags").Return([]domain.TagCount{{Tag: "kitchen", Products: 1}}, nil)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	_, err := service.ListTags(context.Background(), "")
+	require.NoError(t, err)
+
+	// The write is made even though the other instances cannot be told
+	_, err = service.UpdateProduct(context.Background(), &domain.Product{ID: product.ID, Tags: []string{"gift"}, Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{CacheTags}, invalidator.caches)
+
+	// This instance counts again straight away
+	_, err = service.ListTags(context.Background(), "")
+	require.NoError(t, err)
+	products.AssertNumberOfCalls(t, "ListTags", 2)
+}
+
+func TestDropCache(t *testing.T) {
+	service, products, badges := newBadgeTestService()
+	invalidator := &recordingInvalidator{}
+	service.SetCacheInvalidator(invalidator)
+	products.On("ListTags").Return([]domain.TagCount{}, nil)
+	badges.On("List").Return([]*domain.Badge{{Key: "eco", Label: "Eco"}}, nil)
+
+	_, err := service.ListTags(context.Background(), "")
+	require.NoError(t, err)
+	service.DropCache(context.Background(), CacheTags)
+	_, err = service.ListTags(context.Background(), "")
+	require.NoError(t, err)
+	products.AssertNumberOfCalls(t, "ListTags", 2)
+
+	service.DropCache(context.Background(), CacheBadges)
+	assert.Contains(t, service.badgeDefinitions(), "eco")
+
+	// Dropped caches are not invalidated again, and unknown ones are
+	// ignored
+	service.DropCache(context.Background(), CachePromotions)
+	service.DropCache(context.Background(), "reviews")
+	assert.Empty(t, invalidator.caches)
+}
+
+func TestBadgeWriteInvalidatesBadges(t *testing.T) {
+	service, _, badges := newBadgeTestService()
+	invalidator := &recordingInvalidator{}
+	service.SetCacheInvalidator(invalidator)
+	badges.On("Create", mock.AnythingOfType("*domain.Badge")).Return(nil)
+	badges.On("List").Return([]*domain.Badge{}, nil)
+
+	_, err := service.CreateBadge(context.Background(), &domain.Badge{Key: "eco", Label: "Eco", Color: "#2e7d32"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{CacheBadges}, invalidator.caches)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetCategoryRepository configures where the category tree is stored
+func (s *ProductService) SetCategoryRepository(categories domain.CategoryRepository) {
+	s.categories = categories
+}
+
+// CreateCategory adds a category to the tree, under its parent if it has
+// one
+func (s *ProductService) CreateCategory(ctx context.Context, category *domain.Category) (*domain.Category, error) {
+	s.logger.Info("Creating category", "key", category.Key, "parent", category.Parent)
+
+	if err := s.requireCategories(); err != nil {
+		return nil, err
+	}
+	if err := category.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	parent, err := s.parentCategory(ctx, category.Parent)
+	if err != nil {
+		return nil, err
+	}
+	category.SetParent(parent)
+	if category.Depth() > domain.MaxCategoryDepth {
+		return nil, apperrors.Newf(apperrors.Invalid, "categories can be at most %d levels deep", domain.MaxCategoryDepth)
+	}
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+	if err := s.categories.Create(ctx, category); err != nil {
+		s.logger.Error("Failed to create category", "key", category.Key, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return category, nil
+}
+
+// GetCategory retrieves a category by its key
+func (s *ProductService) GetCategory(ctx context.Context, key string) (*domain.Category, error) {
+	if err := s.requireCategories(); err != nil {
+		return nil, err
+	}
+	category, err := s.categories.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return category, nil
+}
+
+// ListCategories returns all categories, by key
+func (s *ProductService) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	if err := s.requireCategories(); err != nil {
+		return nil, err
+	}
+	categories, err := s.categories.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory renames a category or moves it under another parent. A
+// category moves with its subcategories, so it cannot move below itself.
+func (s *ProductService) UpdateCategory(ctx context.Context, category *domain.Category) (*domain.Category, error) {
+	s.logger.Info("Updating category", "key", category.Key, "parent", category.Parent)
+
+	if err := s.requireCategories(); err != nil {
+		return nil, err
+	}
+	if err := category.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	existing, err := s.categories.Get(ctx, category.Key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	parent, err := s.parentCategory(ctx, category.Parent)
+	if err != nil {
+		return nil, err
+	}
+	if parent != nil && parent.HasAncestor(category.Key) {
+		return nil, apperrors.New(apperrors.Invalid, "a category cannot move below one of its subcategories")
+	}
+	category.SetParent(parent)
+
+	descendants, err := s.categories.Descendants(ctx, category.Key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	// The subcategories move along, keeping the levels below the category
+	levels := 0
+	for _, descendant := range descendants {
+		levels = max(levels, len(descendant.Path)-len(existing.Path))
+	}
+	if category.Depth()+levels > domain.MaxCategoryDepth {
+		return nil, apperrors.Newf(apperrors.Invalid, "categories can be at most %d levels deep", domain.MaxCategoryDepth)
+	}
+
+	category.CreatedAt = existing.CreatedAt
+	category.UpdatedAt = time.Now()
+	if err := s.categories.Update(ctx, category); err != nil {
+		s.logger.Error("Failed to update category", "key", category.Key, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if category.Parent != existing.Parent {
+		s.moveDescendants(ctx, category, existing, descendants)
+	}
+	return category, nil
+}
+
+// moveDescendants rewrites the paths of the subcategories of a category
+// that moved from previous
+func (s *ProductService) moveDescendants(ctx context.Context, category, previous *domain.Category, descendants []*domain.Category) {
+	for _, descendant := range descendants {
+		below := descendant.Path[len(previous.Path)+1:]
+		descendant.Path = append(append(append([]string{}, category.Path...), category.Key), below...)
+		descendant.UpdatedAt = category.UpdatedAt
+		if err := s.categories.Update(ctx, descendant); err != nil {
+			// Left with its old path, the subcategory is found under the
+			// old ancestors until the category is saved again
+			s.logger.Error("Failed to move subcategory", "key", descendant.Key, "category", category.Key, "error", err)
+		}
+	}
+}
+
+// DeleteCategory removes a category. Categories with subcategories or
+// products cannot be deleted.
+func (s *ProductService) DeleteCategory(ctx context.Context, key string) error {
+	s.logger.Info("Deleting category", "key", key)
+
+	if err := s.requireCategories(); err != nil {
+		return err
+	}
+	descendants, err := s.categories.Descendants(ctx, key)
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if len(descendants) > 0 {
+		return fmt.Errorf("%w: %d below it", domain.ErrCategoryHasChildren, len(descendants))
+	}
+	_, products, err := s.repo.List(ctx, domain.ListProductsParams{Category: key, PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if products > 0 {
+		return fmt.Errorf("%w: %d products in it", domain.ErrCategoryInUse, products)
+	}
+	if err := s.categories.Delete(ctx, key); err != nil {
+		s.logger.Error("Failed to delete category", "key", key, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+// categoryAndDescendants returns the key of a category and of all
+// categories below it. A category that is not in the tree has only itself.
+func (s *ProductService) categoryAndDescendants(ctx context.Context, key string) ([]string, error) {
+	if err := s.requireCategories(); err != nil {
+		return nil, err
+	}
+	descendants, err := s.categories.Descendants(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	keys := []string{key}
+	for _, descendant := range descendants {
+		keys = append(keys, descendant.Key)
+	}
+	return keys, nil
+}
+
+// parentCategory returns the parent a category is to be placed under, or
+// nil for the top level
+func (s *ProductService) parentCategory(ctx context.Context, key string) (*domain.Category, error) {
+	if key == "" {
+		return nil, nil
+	}
+	parent, err := s.categories.Get(ctx, key)
+	if apperrors.Is(err, apperrors.NotFound) {
+		return nil, apperrors.Newf(apperrors.Invalid, "parent category %q does not exist", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return parent, nil
+}
+
+func (s *ProductService) requireCategories() error {
+	if s.categories == nil {
+		return apperrors.New(apperrors.Unavailable, "categories not available")
+	}
+	return nil
+}
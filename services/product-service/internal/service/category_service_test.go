@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCategoryRepository keeps the category tree in memory
+type memoryCategoryRepository struct {
+	categories map[string]domain.Category
+}
+
+func (r *memoryCategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	if _, ok := r.categories[category.Key]; ok {
+		return domain.ErrCategoryExists
+	}
+	r.categories[category.Key] = *category
+	return nil
+}
+
+func (r *memoryCategoryRepository) Get(ctx context.Context, key string) (*domain.Category, error) {
+	category, ok := r.categories[key]
+	if !ok {
+		return nil, domain.ErrCategoryNotFound
+	}
+	return &category, nil
+}
+
+func (r *memoryCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	if _, ok := r.categories[category.Key]; !ok {
+		return domain.ErrCategoryNotFound
+	}
+	r.categories[category.Key] = *category
+	return nil
+}
+
+func (r *memoryCategoryRepository) Delete(ctx context.Context, key string) error {
+	if _, ok := r.categories[key]; !ok {
+		return domain.ErrCategoryNotFound
+	}
+	delete(r.categories, key)
+	return nil
+}
+
+func (r *memoryCategoryRepository) List(ctx context.Context) ([]*domain.Category, error) {
+	return r.find(func(*domain.Category) bool { return true }), nil
+}
+
+func (r *memoryCategoryRepository) Descendants(ctx context.Context, key string) ([]*domain.Category, error) {
+	return r.find(func(c *domain.Category) bool { return c.HasAncestor(key) }), nil
+}
+
+func (r *memoryCategoryRepository) find(match func(*domain.Category) bool) []*domain.Category {
+	var categories []*domain.Category
+	for _, category := range r.categories {
+		category := category
+		if match(&category) {
+			categories = append(categories, &category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Key < categories[j].Key })
+	return categories
+}
+
+func newCategoryTestService(t *testing.T) (*ProductService, *MockProductRepository, *memoryCategoryRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	products := new(MockProductRepository)
+	categories := &memoryCategoryRepository{categories: make(map[string]domain.Category)}
+	service := New(products, logger)
+	service.SetCategoryRepository(categories)
+
+	// electronics > phones > smartphones, and audio at the top level
+	for _, category := range []domain.Category{
+		{Key: "electronics", Name: "Electronics"},
+		{Key: "phones", Name: "Phones", Parent: "electronics"},
+		{Key: "smartphones", Name: "Smartphones", Parent: "phones"},
+		{Key: "audio", Name: "Audio"},
+	} {
+		category := category
+		_, err := service.CreateCategory(context.Background(), &category)
+		require.NoError(t, err)
+	}
+	return service, products, categories
+}
+
+func TestCreateCategory(t *testing.T) {
+	service, _, categories := newCategoryTestService(t)
+
+	assert.Equal(t, []string{"electronics", "phones"}, categories.categories["smartphones"].Path)
+	assert.Empty(t, categories.categories["audio"].Path)
+
+	_, err := service.CreateCategory(context.Background(), &domain.Category{Key: "cases", Name: "Cases", Parent: "accessories"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "unknown parent: %v", err)
+
+	_, err = service.CreateCategory(context.Background(), &domain.Category{Key: "Phones 2", Name: "Phones"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "invalid key: %v", err)
+
+	_, err = service.CreateCategory(context.Background(), &domain.Category{Key: "phones", Name: "Phones"})
+	assert.ErrorIs(t, err, domain.ErrCategoryExists)
+
+	parent := "smartphones"
+	for i, key := range []string{"level-4", "level-5", "level-6", "level-7"} {
+		_, err = service.CreateCategory(context.Background(), &domain.Category{Key: key, Name: key, Parent: parent})
+		if i < 3 {
+			require.NoError(t, err)
+		}
+		parent = key
+	}
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "too deep: %v", err)
+}
+
+func TestUpdateCategoryMovesSubcategories(t *testing.T) {
+	service, _, categories := newCategoryTestService(t)
+
+	moved, err := service.UpdateCategory(context.Background(), &domain.Category{Key: "phones", Name: "Phones", Parent: "audio"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"audio"}, moved.Path)
+	assert.Equal(t, []string{"audio", "phones"}, categories.categories["smartphones"].Path)
+
+	// A category cannot move below itself
+	_, err = service.UpdateCategory(context.Background(), &domain.Category{Key: "phones", Name: "Phones", Parent: "smartphones"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "got %v", err)
+
+	moved, err = service.UpdateCategory(context.Background(), &domain.Category{Key: "phones", Name: "Phones"})
+	require.NoError(t, err)
+	assert.Empty(t, moved.Path)
+	assert.Equal(t, []string{"phones"}, categories.categories["smartphones"].Path)
+}
+
+func TestDeleteCategory(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+
+	assert.ErrorIs(t, service.DeleteCategory(context.Background(), "phones"), domain.ErrCategoryHasChildren)
+
+	products.On("List", domain.ListProductsParams{Category: "smartphones", PageSize: 1}).Return([]*domain.Product{}, 3, nil).Once()
+	assert.ErrorIs(t, service.DeleteCategory(context.Background(), "smartphones"), domain.ErrCategoryInUse)
+
+	products.On("List", domain.ListProductsParams{Category: "audio", PageSize: 1}).Return([]*domain.Product{}, 0, nil).Once()
+	require.NoError(t, service.DeleteCategory(context.Background(), "audio"))
+	_, err := service.GetCategory(context.Background(), "audio")
+	assert.ErrorIs(t, err, domain.ErrCategoryNotFound)
+}
+
+func TestListProductsIncludesSubcategories(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+
+	products.On("List", mock.MatchedBy(func(params domain.ListProductsParams) bool {
+		return slices.Equal(params.Categories, []string{"electronics", "phones", "smartphones"})
+	})).Return([]*domain.Product{}, 0, nil).Once()
+	_, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{Category: "electronics", IncludeSubcategories: true})
+	require.NoError(t, err)
+
+	products.On("List", mock.MatchedBy(func(params domain.ListProductsParams) bool {
+		return params.Category == "electronics" && params.Categories == nil
+	})).Return([]*domain.Product{}, 0, nil).Once()
+	_, _, err = service.ListProducts(context.Background(), domain.ListProductsParams{Category: "electronics"})
+	require.NoError(t, err)
+	products.AssertExpectations(t)
+}
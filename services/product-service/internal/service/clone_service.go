@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// CloneProduct creates a product from another one's listing under a new
+// ID and the SKU in opts, with no stock. The clone is validated and saved
+// like a new product, so its slug is generated from its name, and it
+// shares the product's images.
+func (s *ProductService) CloneProduct(ctx context.Context, productID string, opts domain.CloneOptions) (*domain.Product, error) {
+	s.logger.Info("Cloning product", "productID", productID, "sku", opts.SKU)
+
+	if opts.SKU == "" {
+		return nil, invalid(errors.New("the clone's SKU is required"))
+	}
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	clone, err := s.create(ctx, product.Clone(opts))
+	if err != nil {
+		s.logger.Error("Failed to clone product", "productID", productID, "error", err)
+		return nil, err
+	}
+	s.logger.Info("Product cloned", "productID", productID, "cloneID", clone.ID.Hex())
+	return clone, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneProduct(t *testing.T) {
+	service, products := newMerchandisingService()
+	until := time.Now().Add(time.Hour)
+	product := builders.NewProduct(t).WithStock(40).WithReserved(3).Build()
+	product.Slug, product.Barcode = "stoneware-mug", "4006381333931"
+	product.Featured, product.FeaturedUntil = true, &until
+	product.Rating = &domain.Rating{Count: 4, Average: 4.5}
+	productID := product.ID.Hex()
+	products.On("GetByID", productID).Return(product, nil)
+	products.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	clone, err := service.CloneProduct(context.Background(), productID, domain.CloneOptions{SKU: "MUG-2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, product.ID, clone.ID)
+	assert.False(t, clone.ID.IsZero())
+	assert.Equal(t, domain.InventoryInfo{SKU: "MUG-2"}, clone.Inventory)
+	assert.False(t, clone.Active, "clones are inactive by default")
+	assert.Equal(t, product.Name, clone.Name)
+	assert.Equal(t, product.Price, clone.Price)
+	assert.Equal(t, product.ImageURLs, clone.ImageURLs)
+	assert.Equal(t, product.Attributes, clone.Attributes)
+	assert.Equal(t, domain.Slugify(product.Name), clone.Slug)
+	assert.Empty(t, clone.Barcode)
+	assert.False(t, clone.Featured)
+	assert.Nil(t, clone.Rating)
+
+	// The clone's lists are its own
+	clone.Tags[0] = "changed"
+	clone.Attributes["material"] = "changed"
+	assert.NotEqual(t, "changed", product.Tags[0])
+	assert.NotEqual(t, "changed", product.Attributes["material"])
+}
+
+func TestCloneProductOptions(t *testing.T) {
+	service, products := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	clone, err := service.CloneProduct(context.Background(), product.ID.Hex(), domain.CloneOptions{SKU: "MUG-2", Name: "Blue mug", Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Blue mug", clone.Name)
+	assert.Equal(t, "blue-mug", clone.Slug)
+	assert.True(t, clone.Active)
+}
+
+func TestCloneProductErrors(t *testing.T) {
+	service, products := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("GetByID", mock.Anything).Return(nil, domain.ErrProductNotFound)
+	products.On("Create", mock.AnythingOfType("*domain.Product")).Return(domain.ErrDuplicateSKU)
+
+	_, err := service.CloneProduct(context.Background(), product.ID.Hex(), domain.CloneOptions{})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "without a SKU: %v", err)
+
+	_, err = service.CloneProduct(context.Background(), "65f1c0d2e4b0a1b2c3d4e5f9", domain.CloneOptions{SKU: "MUG-2"})
+	assert.True(t, apperrors.Is(err, apperrors.NotFound), "missing product: %v", err)
+
+	_, err = service.CloneProduct(context.Background(), product.ID.Hex(), domain.CloneOptions{SKU: product.Inventory.SKU})
+	assert.ErrorIs(t, err, domain.ErrDuplicateSKU)
+}
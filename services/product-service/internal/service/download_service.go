@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// defaultDownloadTTL is how long download links are valid when no TTL is
+// configured
+const defaultDownloadTTL = 15 * time.Minute
+
+// OrderVerifier checks that a user bought a product
+type OrderVerifier interface {
+	// VerifyPurchase returns nil if the order belongs to userID and
+	// contains productID, and a Forbidden or NotFound error otherwise
+	VerifyPurchase(ctx context.Context, orderID, userID, productID string) error
+}
+
+// URLSigner signs time-limited links to objects in the downloads bucket
+type URLSigner interface {
+	SignGetURL(key string, ttl time.Duration) (string, error)
+}
+
+// LicenseKeyGenerator issues license keys for digital products bought in
+// an order. It is called at most once per order and product.
+type LicenseKeyGenerator interface {
+	GenerateLicenseKey(ctx context.Context, product *domain.Product, orderID, userID string) (string, error)
+}
+
+// SetDownloads configures where downloads of digital products are counted,
+// how orders are verified and how links are signed. Links are valid for
+// ttl.
+func (s *ProductService) SetDownloads(repo domain.DownloadRepository, orders OrderVerifier, signer URLSigner, ttl time.Duration) {
+	s.downloads = repo
+	s.orderVerifier = orders
+	s.signer = signer
+	s.downloadTTL = ttl
+}
+
+// SetLicenseKeyGenerator replaces the random license keys given to
+// digital products that have them
+func (s *ProductService) SetLicenseKeyGenerator(generator LicenseKeyGenerator) {
+	s.licenses = generator
+}
+
+// GetDownloadURL returns a signed link to an asset of a digital product
+// bought by userID in orderID, and counts the download. asset may be empty
+// if the product has a single asset.
+func (s *ProductService) GetDownloadURL(ctx context.Context, productID, orderID, userID, assetName string) (*domain.Download, error) {
+	s.logger.Info("Getting download URL", "productID", productID, "orderID", orderID, "asset", assetName)
+
+	if userID == "" {
+		return nil, apperrors.New(apperrors.Unauthenticated, "downloads require a signed-in user")
+	}
+	if orderID == "" {
+		return nil, apperrors.New(apperrors.Invalid, "order ID is required")
+	}
+	if s.downloads == nil || s.orderVerifier == nil || s.signer == nil {
+		return nil, apperrors.New(apperrors.Unavailable, "downloads not available")
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to get product", "id", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if !product.IsDigital() {
+		return nil, apperrors.New(apperrors.Invalid, "product is not digital")
+	}
+	asset, ok := product.Asset(assetName)
+	if !ok {
+		return nil, apperrors.New(apperrors.NotFound, "digital asset not found")
+	}
+
+	if err := s.orderVerifier.VerifyPurchase(ctx, orderID, userID, productID); err != nil {
+		s.logger.Warn("Download refused", "productID", productID, "orderID", orderID, "error", err)
+		return nil, err
+	}
+
+	// Signed before the download is counted, so that a failure does not
+	// use up one of the order's downloads
+	ttl := s.downloadTTL
+	if ttl <= 0 {
+		ttl = defaultDownloadTTL
+	}
+	url, err := s.signer.SignGetURL(asset.Key, ttl)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.Internal, "failed to sign download URL")
+	}
+
+	grant, err := s.downloads.Record(ctx, orderID, productID, userID, product.Digital.MaxDownloads)
+	if err != nil {
+		s.logger.Error("Failed to record download", "productID", productID, "orderID", orderID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	download := &domain.Download{
+		ProductID:  productID,
+		OrderID:    orderID,
+		Asset:      asset.Name,
+		URL:        url,
+		ExpiresAt:  time.Now().Add(ttl).UTC(),
+		LicenseKey: grant.LicenseKey,
+	}
+	if max := product.Digital.MaxDownloads; max > 0 {
+		left := max - grant.Downloads
+		download.DownloadsLeft = &left
+	}
+	// The key is only generated once the grant shows the order has none,
+	// so a failure gives back the download counted above
+	if product.Digital.LicenseKeys && download.LicenseKey == "" {
+		key, err := s.licenses.GenerateLicenseKey(ctx, product, orderID, userID)
+		if err != nil {
+			s.logger.Error("Failed to generate license key", "productID", productID, "orderID", orderID, "error", err)
+			s.revertDownload(ctx, orderID, productID)
+			return nil, apperrors.Wrap(err, apperrors.Unavailable, "failed to generate license key")
+		}
+		// A concurrent first download may have stored its key first
+		if download.LicenseKey, err = s.downloads.SetLicenseKey(ctx, orderID, productID, key); err != nil {
+			s.revertDownload(ctx, orderID, productID)
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+	}
+
+	s.logger.Info("Download URL issued", "productID", productID, "orderID", orderID, "downloads", grant.Downloads)
+	return download, nil
+}
+
+// revertDownload takes back a counted download whose link is not returned.
+// It runs even if the request was cancelled; a failure is only logged, and
+// costs the order that download.
+func (s *ProductService) revertDownload(ctx context.Context, orderID, productID string) {
+	if err := s.downloads.Revert(context.WithoutCancel(ctx), orderID, productID); err != nil {
+		s.logger.Error("Failed to revert download", "productID", productID, "orderID", orderID, "error", err)
+	}
+}
+
+// randomLicenseKeys issues random keys of five groups of five characters
+type randomLicenseKeys struct{}
+
+// licenseKeyAlphabet leaves out characters that are easily mistaken for
+// one another
+const licenseKeyAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func (randomLicenseKeys) GenerateLicenseKey(ctx context.Context, product *domain.Product, orderID, userID string) (string, error) {
+	b := make([]byte, 25)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	var key strings.Builder
+	for i, c := range b {
+		if i > 0 && i%5 == 0 {
+			key.WriteByte('-')
+		}
+		key.WriteByte(licenseKeyAlphabet[int(c)%len(licenseKeyAlphabet)])
+	}
+	return key.String(), nil
+}
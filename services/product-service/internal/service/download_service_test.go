@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDownloads counts downloads per order and product
+type memoryDownloads struct {
+	grants map[string]*domain.DownloadGrant
+}
+
+func (m *memoryDownloads) Record(ctx context.Context, orderID, productID, userID string, max int) (*domain.DownloadGrant, error) {
+	grant, ok := m.grants[orderID+"/"+productID]
+	if !ok {
+		grant = &domain.DownloadGrant{OrderID: orderID, ProductID: productID, UserID: userID}
+		m.grants[orderID+"/"+productID] = grant
+	}
+	if max > 0 && grant.Downloads >= max {
+		return nil, domain.ErrDownloadLimitReached
+	}
+	grant.Downloads++
+	copied := *grant
+	return &copied, nil
+}
+
+func (m *memoryDownloads) Revert(ctx context.Context, orderID, productID string) error {
+	if grant := m.grants[orderID+"/"+productID]; grant != nil && grant.Downloads > 0 {
+		grant.Downloads--
+	}
+	return nil
+}
+
+func (m *memoryDownloads) SetLicenseKey(ctx context.Context, orderID, productID, key string) (string, error) {
+	grant := m.grants[orderID+"/"+productID]
+	if grant.LicenseKey == "" {
+		grant.LicenseKey = key
+	}
+	return grant.LicenseKey, nil
+}
+
+// ownedOrders lets userID download from orderID only
+type ownedOrders struct {
+	orderID, userID string
+}
+
+func (o ownedOrders) VerifyPurchase(ctx context.Context, orderID, userID, productID string) error {
+	if orderID != o.orderID || userID != o.userID {
+		return apperrors.New(apperrors.NotFound, "order not found")
+	}
+	return nil
+}
+
+type fakeSigner struct{}
+
+// failingSigner cannot sign links
+type failingSigner struct{}
+
+func (failingSigner) SignGetURL(key string, ttl time.Duration) (string, error) {
+	return "", errors.New("signing key unavailable")
+}
+
+// failingLicenses cannot issue license keys
+type failingLicenses struct{}
+
+func (failingLicenses) GenerateLicenseKey(ctx context.Context, product *domain.Product, orderID, userID string) (string, error) {
+	return "", errors.New("license server unavailable")
+}
+
+func (fakeSigner) SignGetURL(key string, ttl time.Duration) (string, error) {
+	return "https://downloads.example.com/" + key + "?ttl=" + ttl.String(), nil
+}
+
+func newDownloadService(t *testing.T, digital *domain.DigitalInfo) (*ProductService, *domain.Product) {
+	mockRepo := new(MockProductRepository)
+	service := New(mockRepo, slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	service.SetDownloads(&memoryDownloads{grants: map[string]*domain.DownloadGrant{}}, ownedOrders{orderID: "order-1", userID: "user-1"}, fakeSigner{}, 5*time.Minute)
+
+	product := builders.NewProduct(t).Build()
+	product.Type, product.Digital = domain.ProductTypeDigital, digital
+	mockRepo.On("GetByID", product.ID.Hex()).Return(product, nil)
+	return service, product
+}
+
+func TestGetDownloadURL(t *testing.T) {
+	service, product := newDownloadService(t, &domain.DigitalInfo{
+		Assets:       []domain.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}},
+		MaxDownloads: 2,
+		LicenseKeys:  true,
+	})
+	productID := product.ID.Hex()
+
+	first, err := service.GetDownloadURL(context.Background(), productID, "order-1", "user-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://downloads.example.com/books/go.pdf?ttl=5m0s", first.URL)
+	assert.Equal(t, "book.pdf", first.Asset)
+	assert.Equal(t, 1, *first.DownloadsLeft)
+	assert.Regexp(t, regexp.MustCompile(`^([A-Z2-9]{5}-){4}[A-Z2-9]{5}$`), first.LicenseKey)
+
+	second, err := service.GetDownloadURL(context.Background(), productID, "order-1", "user-1", "book.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, 0, *second.DownloadsLeft)
+	assert.Equal(t, first.LicenseKey, second.LicenseKey, "the license key is issued once per order")
+
+	_, err = service.GetDownloadURL(context.Background(), productID, "order-1", "user-1", "book.pdf")
+	assert.Equal(t, "DOWNLOAD_LIMIT_REACHED", apperrors.ReasonOf(err))
+}
+
+func TestGetDownloadURL_FailureKeepsDownload(t *testing.T) {
+	digital := &domain.DigitalInfo{
+		Assets:       []domain.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}},
+		MaxDownloads: 1,
+		LicenseKeys:  true,
+	}
+
+	t.Run("signing fails", func(t *testing.T) {
+		service, product := newDownloadService(t, digital)
+		downloads := service.downloads
+		service.SetDownloads(downloads, ownedOrders{orderID: "order-1", userID: "user-1"}, failingSigner{}, time.Minute)
+
+		_, err := service.GetDownloadURL(context.Background(), product.ID.Hex(), "order-1", "user-1", "")
+		assert.True(t, apperrors.Is(err, apperrors.Internal), "got %v", err)
+		assert.Empty(t, downloads.(*memoryDownloads).grants, "nothing is counted")
+	})
+
+	t.Run("license key fails", func(t *testing.T) {
+		service, product := newDownloadService(t, digital)
+		service.SetLicenseKeyGenerator(failingLicenses{})
+
+		_, err := service.GetDownloadURL(context.Background(), product.ID.Hex(), "order-1", "user-1", "")
+		assert.True(t, apperrors.Is(err, apperrors.Unavailable), "got %v", err)
+
+		service.SetLicenseKeyGenerator(randomLicenseKeys{})
+		download, err := service.GetDownloadURL(context.Background(), product.ID.Hex(), "order-1", "user-1", "")
+		require.NoError(t, err, "the failed attempt was not counted")
+		assert.Equal(t, 0, *download.DownloadsLeft)
+		assert.NotEmpty(t, download.LicenseKey)
+	})
+}
+
+func TestGetDownloadURL_Refused(t *testing.T) {
+	service, product := newDownloadService(t, &domain.DigitalInfo{
+		Assets: []domain.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}, {Name: "book.epub", Key: "books/go.epub"}},
+	})
+	productID := product.ID.Hex()
+
+	tests := []struct {
+		name            string
+		orderID, userID string
+		asset           string
+		kind            apperrors.Kind
+	}{
+		{"anonymous", "order-1", "", "book.pdf", apperrors.Unauthenticated},
+		{"no order", "", "user-1", "book.pdf", apperrors.Invalid},
+		{"order of another user", "order-1", "user-2", "book.pdf", apperrors.NotFound},
+		{"unknown asset", "order-1", "user-1", "book.mobi", apperrors.NotFound},
+		{"ambiguous asset", "order-1", "user-1", "", apperrors.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetDownloadURL(context.Background(), productID, tt.orderID, tt.userID, tt.asset)
+			assert.True(t, apperrors.Is(err, tt.kind), "got %v", err)
+		})
+	}
+}
+
+func TestGetDownloadURL_Unlimited(t *testing.T) {
+	service, product := newDownloadService(t, &domain.DigitalInfo{
+		Assets: []domain.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}},
+	})
+
+	download, err := service.GetDownloadURL(context.Background(), product.ID.Hex(), "order-1", "user-1", "")
+	require.NoError(t, err)
+	assert.Nil(t, download.DownloadsLeft)
+	assert.Empty(t, download.LicenseKey)
+}
+
+func TestGetDownloadURL_PhysicalProduct(t *testing.T) {
+	service, product := newDownloadService(t, nil)
+	product.Type = domain.ProductTypePhysical
+
+	_, err := service.GetDownloadURL(context.Background(), product.ID.Hex(), "order-1", "user-1", "")
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestValidateProductType(t *testing.T) {
+	asset := domain.DigitalAsset{Name: "book.pdf", Key: "books/go.pdf"}
+	tests := []struct {
+		name    string
+		typ     string
+		digital *domain.DigitalInfo
+		valid   bool
+	}{
+		{"physical", "", nil, true},
+		{"digital", domain.ProductTypeDigital, &domain.DigitalInfo{Assets: []domain.DigitalAsset{asset}}, true},
+		{"unknown type", "service", nil, false},
+		{"physical with downloads", domain.ProductTypePhysical, &domain.DigitalInfo{Assets: []domain.DigitalAsset{asset}}, false},
+		{"digital without assets", domain.ProductTypeDigital, &domain.DigitalInfo{}, false},
+		{"asset without key", domain.ProductTypeDigital, &domain.DigitalInfo{Assets: []domain.DigitalAsset{{Name: "book.pdf"}}}, false},
+		{"duplicate assets", domain.ProductTypeDigital, &domain.DigitalInfo{Assets: []domain.DigitalAsset{asset, asset}}, false},
+		{"negative limit", domain.ProductTypeDigital, &domain.DigitalInfo{Assets: []domain.DigitalAsset{asset}, MaxDownloads: -1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProductType(&domain.Product{Type: tt.typ, Digital: tt.digital})
+			assert.Equal(t, tt.valid, err == nil, "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// ExportProducts passes every product matching the filters of a product
+// list to fn, in ID order, priced and merchandised like a list. Products
+// are read with a cursor, so the whole catalog can be exported without
+// holding it in memory. Pagination and sorting are ignored.
+func (s *ProductService) ExportProducts(ctx context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error {
+	s.logger.Info("Exporting products", "category", params.Category, "inStockOnly", params.InStockOnly)
+
+	if err := s.prepareListFilters(ctx, &params); err != nil {
+		return err
+	}
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	var fnErr error
+	err = s.repo.Export(ctx, params, func(product *domain.Product) error {
+		s.merchandise(product)
+		priceForGroup(group, product)
+		if fnErr = fn(product); fnErr != nil {
+			return fnErr
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		if fnErr != nil {
+			return fnErr
+		}
+		s.logger.Error("Failed to export products", "exported", exported, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Products exported", "count", exported)
+	return nil
+}
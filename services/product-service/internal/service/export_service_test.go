@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportProducts(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+	phone := builders.NewProduct(t).WithCategory("smartphones").Build()
+	phoneCase := builders.NewProduct(t).WithCategory("phones").Build()
+	products.On("Export", mock.MatchedBy(func(params domain.ListProductsParams) bool {
+		return params.Category == "phones"
+	})).Return([]*domain.Product{phoneCase, phone}, nil)
+
+	var exported []string
+	err := service.ExportProducts(context.Background(), domain.ListProductsParams{Category: "phones", IncludeSubcategories: true},
+		func(product *domain.Product) error {
+			exported = append(exported, product.ID.Hex())
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{phoneCase.ID.Hex(), phone.ID.Hex()}, exported)
+	params := products.Calls[0].Arguments.Get(0).(domain.ListProductsParams)
+	assert.ElementsMatch(t, []string{"phones", "smartphones"}, params.Categories, "subcategories are resolved")
+}
+
+func TestExportProductsErrors(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+	product := builders.NewProduct(t).WithCategory("audio").Build()
+	products.On("Export", domain.ListProductsParams{Category: "audio"}).Return([]*domain.Product{product}, nil)
+	products.On("Export", domain.ListProductsParams{Category: "phones"}).Return(nil, errors.New("cursor killed"))
+	ignore := func(*domain.Product) error { return nil }
+
+	err := service.ExportProducts(context.Background(), domain.ListProductsParams{AttributeFilters: map[string]string{"$where": "1"}}, ignore)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "%v", err)
+
+	broken := errors.New("connection reset")
+	err = service.ExportProducts(context.Background(), domain.ListProductsParams{Category: "audio"}, func(*domain.Product) error { return broken })
+	assert.Equal(t, broken, err, "errors writing the export are returned as they are")
+
+	err = service.ExportProducts(context.Background(), domain.ListProductsParams{Category: "phones"}, ignore)
+	assert.ErrorContains(t, err, "repository error")
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// customerGroup returns the customer group products are read for, taken
+// from ctx; it is "" when the caller's group is not known
+func customerGroup(ctx context.Context) (string, error) {
+	group := domain.CustomerGroupFrom(ctx)
+	if group != "" && !domain.IsCustomerGroup(group) {
+		return "", apperrors.Newf(apperrors.Invalid, "unknown customer group %q", group)
+	}
+	return group, nil
+}
+
+// priceForGroup sets the price the customer group pays on products read
+// for it: its group price, or the promotional price when that is lower.
+// Nothing is set when the group is not known.
+func priceForGroup(group string, products ...*domain.Product) {
+	if group == "" {
+		return
+	}
+	for _, product := range products {
+		product.CustomerGroup = group
+		product.EffectivePrice = product.PriceFor(group)
+		if product.DiscountedPrice != nil && *product.DiscountedPrice < product.EffectivePrice {
+			product.EffectivePrice = *product.DiscountedPrice
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeGroupPrices(t *testing.T) {
+	prices, err := domain.NormalizeGroupPrices([]domain.GroupPrice{{Group: "wholesale", Price: 45}, {Group: "vip", Price: 54}})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.GroupPrice{{Group: "vip", Price: 54}, {Group: "wholesale", Price: 45}}, prices)
+
+	for _, invalid := range [][]domain.GroupPrice{
+		{{Group: "retail", Price: 55}},
+		{{Group: "staff", Price: 30}},
+		{{Group: "vip", Price: 54}, {Group: "vip", Price: 50}},
+		{{Group: "vip", Price: 0}},
+	} {
+		_, err := domain.NormalizeGroupPrices(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetProductForCustomerGroup(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).WithPrice(60).Build()
+	product.GroupPrices = []domain.GroupPrice{{Group: domain.CustomerGroupWholesale, Price: 45}}
+	mockRepo.On("GetByID", product.ID.Hex()).Return(product, nil)
+
+	found, err := service.GetProduct(context.Background(), product.ID.Hex())
+	require.NoError(t, err)
+	assert.Empty(t, found.CustomerGroup)
+	assert.Zero(t, found.EffectivePrice)
+
+	ctx := domain.WithCustomerGroup(context.Background(), domain.CustomerGroupWholesale)
+	found, err = service.GetProduct(ctx, product.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 45.0, found.EffectivePrice)
+
+	ctx = domain.WithCustomerGroup(context.Background(), domain.CustomerGroupVIP)
+	found, err = service.GetProduct(ctx, product.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 60.0, found.EffectivePrice, "groups without a group price pay the price")
+
+	ctx = domain.WithCustomerGroup(context.Background(), "staff")
+	_, err = service.GetProduct(ctx, product.ID.Hex())
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestUpdateProductGroupPrices(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	product.GroupPrices = []domain.GroupPrice{{Group: domain.CustomerGroupVIP, Price: 9}}
+	mockRepo.On("GetByID", product.ID.Hex()).Return(product, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	updated, err := service.UpdateProduct(context.Background(), &domain.Product{ID: product.ID, Name: "Cup", Active: true})
+	require.NoError(t, err)
+	assert.Len(t, updated.GroupPrices, 1, "kept when not given")
+
+	updated, err = service.UpdateProduct(context.Background(), &domain.Product{ID: product.ID, GroupPrices: []domain.GroupPrice{}, Active: true})
+	require.NoError(t, err)
+	assert.Empty(t, updated.GroupPrices)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// ImageStorage stores uploaded product images
+type ImageStorage interface {
+	// Put stores an image under key and returns the URL it is served from
+	Put(ctx context.Context, key, contentType string, body []byte) (string, error)
+	// Delete removes the image stored under key
+	Delete(ctx context.Context, key string) error
+	// Key returns the key of the image at url, or false if the image is
+	// hosted elsewhere
+	Key(url string) (string, bool)
+}
+
+// SetImageStorage configures where uploaded product images are stored and
+// the largest image that may be uploaded
+func (s *ProductService) SetImageStorage(storage ImageStorage, maxBytes int64) {
+	s.images = storage
+	s.maxImageBytes = maxBytes
+}
+
+// SetImagePolicy configures which image URLs products may be created and
+// updated with, and which are rewritten to be served from a CDN
+func (s *ProductService) SetImagePolicy(policy domain.ImagePolicy) {
+	s.imagePolicy = policy
+}
+
+// AddProductImage stores an uploaded image and appends its URL to the
+// product's images. JPEG, PNG, GIF and WebP images are accepted.
+func (s *ProductService) AddProductImage(ctx context.Context, productID string, image []byte) (*domain.Product, error) {
+	s.logger.Info("Adding product image", "productID", productID, "bytes", len(image))
+
+	if s.images == nil {
+		return nil, apperrors.New(apperrors.Unavailable, "image uploads not available")
+	}
+	if len(image) == 0 {
+		return nil, apperrors.New(apperrors.Invalid, "image is empty")
+	}
+	if int64(len(image)) > s.maxImageBytes {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("image must be at most %d bytes", s.maxImageBytes))
+	}
+	contentType := http.DetectContentType(image)
+	extension, ok := domain.ImageExtensions[contentType]
+	if !ok {
+		return nil, apperrors.New(apperrors.Invalid, "image must be a JPEG, PNG, GIF or WebP file")
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	if len(product.ImageURLs) >= domain.MaxProductImages {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("a product may have at most %d images", domain.MaxProductImages))
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, fmt.Errorf("failed to name image: %w", err)
+	}
+	key := "products/" + product.ID.Hex() + "/" + hex.EncodeToString(name) + extension
+	url, err := s.images.Put(ctx, key, contentType, image)
+	if err != nil {
+		s.logger.Error("Failed to store image", "productID", productID, "key", key, "error", err)
+		return nil, apperrors.Wrap(err, apperrors.Unavailable, "image storage error")
+	}
+
+	product.ImageURLs = append(product.ImageURLs, url)
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to add image", "productID", productID, "error", err)
+		s.deleteImage(ctx, url)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// DeleteProductImage removes the image at position, counted from zero,
+// from the product's images, and deletes it from storage if it was
+// uploaded there
+func (s *ProductService) DeleteProductImage(ctx context.Context, productID string, position int) (*domain.Product, error) {
+	s.logger.Info("Deleting product image", "productID", productID, "position", position)
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	if position < 0 || position >= len(product.ImageURLs) {
+		return nil, apperrors.New(apperrors.NotFound, "image not found")
+	}
+	url := product.ImageURLs[position]
+	product.ImageURLs = slices.Delete(product.ImageURLs, position, position+1)
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to delete image", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.deleteImage(ctx, url)
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// ReorderProductImages puts the product's images in the order of urls,
+// which must list each of them once
+func (s *ProductService) ReorderProductImages(ctx context.Context, productID string, urls []string) (*domain.Product, error) {
+	s.logger.Info("Reordering product images", "productID", productID, "images", len(urls))
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	current := slices.Sorted(slices.Values(product.ImageURLs))
+	reordered := slices.Sorted(slices.Values(urls))
+	if !slices.Equal(current, reordered) {
+		return nil, apperrors.New(apperrors.Invalid, "image_urls must list each of the product's images once")
+	}
+	product.ImageURLs = urls
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to reorder images", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// deleteImage deletes an image from storage if it was uploaded there and
+// no product refers to it any more; clones share their product's images.
+// Failures only leave an orphaned object behind, so they are logged.
+func (s *ProductService) deleteImage(ctx context.Context, url string) {
+	if s.images == nil {
+		return
+	}
+	key, ok := s.images.Key(url)
+	if !ok {
+		return
+	}
+	inUse, err := s.repo.ImageInUse(ctx, url)
+	if err != nil {
+		s.logger.Warn("Failed to check whether the stored image is in use", "key", key, "error", err)
+		return
+	}
+	if inUse {
+		return
+	}
+	if err := s.images.Delete(ctx, key); err != nil {
+		s.logger.Warn("Failed to delete stored image", "key", key, "error", err)
+	}
+}
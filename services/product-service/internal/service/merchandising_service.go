@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetNewArrivalWindow configures how long after their creation products
+// count as new
+func (s *ProductService) SetNewArrivalWindow(window time.Duration) {
+	s.newArrivals = window
+}
+
+// SetFeatured features a product on the storefront until the given time,
+// or without expiry if until is nil, or unfeatures it
+func (s *ProductService) SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*domain.Product, error) {
+	s.logger.Info("Setting product featured", "id", id, "featured", featured, "until", until)
+
+	if featured && until != nil && !until.After(time.Now()) {
+		return nil, apperrors.New(apperrors.Invalid, "featured until must be in the future")
+	}
+	if !featured {
+		until = nil
+	}
+
+	before := s.auditStored(ctx, id)
+	product, err := s.repo.SetFeatured(ctx, id, featured, until)
+	if err != nil {
+		s.logger.Error("Failed to set product featured", "id", id, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, id, before, product)
+	s.merchandise(product)
+	s.logger.Info("Product featured set successfully", "id", id, "featured", featured)
+	s.publish(ctx, events.ProductUpdated, id, product)
+	return product, nil
+}
+
+// UnfeatureExpired unfeatures the products whose feature has expired, so
+// that they no longer sort with the featured ones. Until then they are
+// already shown as not featured.
+func (s *ProductService) UnfeatureExpired(ctx context.Context) (int, error) {
+	n, err := s.repo.UnfeatureExpired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("repository error: %w", err)
+	}
+	if n > 0 {
+		s.logger.Info("Expired features removed", "count", n)
+	}
+	return n, nil
+}
+
+// merchandise sets the merchandising flags of products as of now: whether
+// they are new, whether their feature has expired, the promotion they are
+// discounted by and the badges they are shown with
+func (s *ProductService) merchandise(products ...*domain.Product) {
+	now := time.Now()
+	badges := s.badgeDefinitions()
+	promotions := s.livePromotions()
+	for _, product := range products {
+		if product == nil {
+			continue
+		}
+		product.IsNew = now.Sub(product.CreatedAt) < s.newArrivals
+		if !product.FeaturedAt(now) {
+			product.Featured, product.FeaturedUntil = false, nil
+		}
+		product.ApplyPromotions(promotions, now)
+		product.DisplayBadges = domain.ResolveBadges(product, badges)
+	}
+}
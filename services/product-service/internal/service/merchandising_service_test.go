@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMerchandisingService() (*ProductService, *MockProductRepository) {
+	mockRepo := new(MockProductRepository)
+	return New(mockRepo, slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))), mockRepo
+}
+
+func TestSetFeatured(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+
+	until := time.Now().Add(24 * time.Hour)
+	featured := *product
+	featured.Featured, featured.FeaturedUntil = true, &until
+	mockRepo.On("SetFeatured", productID, true, &until).Return(&featured, nil)
+
+	result, err := service.SetFeatured(context.Background(), productID, true, &until)
+	require.NoError(t, err)
+	assert.True(t, result.Featured)
+	assert.Equal(t, &until, result.FeaturedUntil)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetFeatured_ExpiryInThePast(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+
+	until := time.Now().Add(-time.Hour)
+	_, err := service.SetFeatured(context.Background(), "65f1c0d2e4b0a1b2c3d4e5f1", true, &until)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	mockRepo.AssertNotCalled(t, "SetFeatured", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetFeatured_UnfeatureDropsExpiry(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("SetFeatured", productID, false, (*time.Time)(nil)).Return(product, nil)
+
+	until := time.Now().Add(time.Hour)
+	_, err := service.SetFeatured(context.Background(), productID, false, &until)
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMerchandiseFlags(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	service.SetNewArrivalWindow(7 * 24 * time.Hour)
+
+	fresh := builders.NewProduct(t).WithName("Fresh").Build()
+	fresh.CreatedAt = time.Now().Add(-24 * time.Hour)
+	expired := time.Now().Add(-time.Minute)
+	old := builders.NewProduct(t).WithName("Old").Build()
+	old.CreatedAt = time.Now().Add(-30 * 24 * time.Hour)
+	old.Featured, old.FeaturedUntil = true, &expired
+	mockRepo.On("List", mock.Anything).Return([]*domain.Product{fresh, old}, 2, nil)
+
+	products, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{})
+	require.NoError(t, err)
+	assert.True(t, products[0].IsNew)
+	assert.False(t, products[1].IsNew)
+	assert.False(t, products[1].Featured, "expired feature is not shown")
+	assert.Nil(t, products[1].FeaturedUntil)
+}
+
+func TestListProducts_NewOnly(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	service.SetNewArrivalWindow(48 * time.Hour)
+
+	var params domain.ListProductsParams
+	mockRepo.On("List", mock.Anything).Run(func(args mock.Arguments) {
+		params = args.Get(0).(domain.ListProductsParams)
+	}).Return([]*domain.Product{}, 0, nil)
+
+	_, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{NewOnly: true})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), params.CreatedSince, time.Minute)
+}
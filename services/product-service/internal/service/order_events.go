@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+)
+
+// OrderEventTypes are the order events HandleOrderEvent applies to stock
+var OrderEventTypes = []string{
+	events.OrderPaid,
+	events.OrderCancelled,
+}
+
+// HandleOrderEvent applies order events to stock: a paid order commits its
+// items as purchases, and a cancelled order releases the stock its payment
+// committed. Operation IDs are derived from the order's idempotency key, so
+// redelivered events are applied once, and a cancellation consumed before
+// the payment keeps the payment from taking the stock.
+//
+// Failures that retrying cannot fix, such as an unknown product or a paid
+// order the stock no longer covers, are logged and the item skipped, so
+// they do not hold up the stream; others are returned for redelivery.
+func (s *ProductService) HandleOrderEvent(ctx context.Context, event *eventbus.Event) error {
+	var apply func(ctx context.Context, key string, item events.OrderItemPayload) error
+	switch event.Type {
+	case events.OrderPaid:
+		apply = s.commitOrderItem
+	case events.OrderCancelled:
+		apply = s.releaseOrderItem
+	default:
+		return nil
+	}
+
+	var order events.OrderPayload
+	if err := events.Decode(event, &order); err != nil {
+		s.logger.Error("Dropping invalid order event", "type", event.Type, "id", event.ID, "error", err)
+		return nil
+	}
+	key := order.IdempotencyKey
+	if key == "" {
+		key = order.OrderID
+	}
+
+	for _, item := range orderQuantities(order.Items) {
+		err := apply(ctx, key, item)
+		if err == nil {
+			continue
+		}
+		switch apperrors.KindOf(err) {
+		case apperrors.NotFound, apperrors.Conflict, apperrors.Invalid, apperrors.Forbidden:
+			s.logger.Error("Failed to apply order to stock; skipping the item",
+				"type", event.Type, "orderID", order.OrderID, "productID", item.ProductID, "quantity", item.Quantity, "error", err)
+		default:
+			return fmt.Errorf("order %s, product %s: %w", order.OrderID, item.ProductID, err)
+		}
+	}
+	return nil
+}
+
+// commitOrderItem takes the stock of a paid order item, unless it was
+// taken already or the order's cancellation was applied first
+func (s *ProductService) commitOrderItem(ctx context.Context, key string, item events.OrderItemPayload) error {
+	commitID := orderOperationID(key, item.ProductID, "commit")
+	for _, id := range []string{commitID, orderOperationID(key, item.ProductID, "release")} {
+		applied, err := s.repo.HasOperation(ctx, id)
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		if applied {
+			// A redelivered payment, or an order cancelled first
+			return nil
+		}
+	}
+	_, err := s.UpdateInventory(ctx, item.ProductID, -item.Quantity, commitID, "purchase")
+	return err
+}
+
+// releaseOrderItem returns the stock committed for an order item. When
+// nothing was committed, a release of no units is recorded instead, so a
+// payment consumed later is not applied.
+func (s *ProductService) releaseOrderItem(ctx context.Context, key string, item events.OrderItemPayload) error {
+	releaseID := orderOperationID(key, item.ProductID, "release")
+	committed, err := s.repo.HasOperation(ctx, orderOperationID(key, item.ProductID, "commit"))
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if !committed {
+		if _, err := s.repo.UpdateInventory(ctx, item.ProductID, 0, releaseID, "release"); err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		return nil
+	}
+	_, err = s.UpdateInventory(ctx, item.ProductID, item.Quantity, releaseID, "release")
+	return err
+}
+
+// orderOperationID is the ID of the inventory operation applying an order
+// event to one of its products
+func orderOperationID(key, productID, action string) string {
+	return "order:" + key + ":" + productID + ":" + action
+}
+
+// orderQuantities merges the lines of an order by product, in the order
+// products first appear
+func orderQuantities(items []events.OrderItemPayload) []events.OrderItemPayload {
+	merged := make([]events.OrderItemPayload, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func orderEvent(t *testing.T, eventType string, items ...events.OrderItemPayload) *eventbus.Event {
+	event, err := eventbus.NewEvent(eventType, "order-service", "order-1", events.OrderPayload{
+		OrderID:        "order-1",
+		IdempotencyKey: "checkout-1",
+		Items:          items,
+	})
+	require.NoError(t, err)
+	return event
+}
+
+func TestHandleOrderEvent_PaidCommitsStock(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).WithStock(10).Build()
+	productID := product.ID.Hex()
+	commitID := "order:checkout-1:" + productID + ":commit"
+	mockRepo.On("HasOperation", commitID).Return(false, nil)
+	mockRepo.On("HasOperation", "order:checkout-1:"+productID+":release").Return(false, nil)
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("CheckStock", productID, 3).Return(true, 10, nil)
+	mockRepo.On("UpdateInventory", productID, -3, commitID, "purchase").Return(&domain.InventoryInfo{Quantity: 7}, nil)
+
+	// Lines of the same product are applied as one
+	event := orderEvent(t, events.OrderPaid,
+		events.OrderItemPayload{ProductID: productID, Quantity: 1},
+		events.OrderItemPayload{ProductID: productID, Quantity: 2})
+	require.NoError(t, service.HandleOrderEvent(context.Background(), event))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandleOrderEvent_RedeliveredPaymentIsSkipped(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	mockRepo.On("HasOperation", "order:checkout-1:p1:commit").Return(true, nil)
+
+	event := orderEvent(t, events.OrderPaid, events.OrderItemPayload{ProductID: "p1", Quantity: 3})
+	require.NoError(t, service.HandleOrderEvent(context.Background(), event))
+	mockRepo.AssertNotCalled(t, "UpdateInventory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleOrderEvent_CancelledReleasesCommittedStock(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	mockRepo.On("HasOperation", "order:checkout-1:p1:commit").Return(true, nil)
+	mockRepo.On("GetByID", "p1").Return(builders.NewProduct(t).Build(), nil)
+	mockRepo.On("UpdateInventory", "p1", 3, "order:checkout-1:p1:release", "release").Return(&domain.InventoryInfo{Quantity: 10}, nil)
+	// Not paid yet: nothing to return, but a later payment must not apply
+	mockRepo.On("HasOperation", "order:checkout-1:p2:commit").Return(false, nil)
+	mockRepo.On("UpdateInventory", "p2", 0, "order:checkout-1:p2:release", "release").Return(&domain.InventoryInfo{Quantity: 5}, nil)
+
+	event := orderEvent(t, events.OrderCancelled,
+		events.OrderItemPayload{ProductID: "p1", Quantity: 3},
+		events.OrderItemPayload{ProductID: "p2", Quantity: 1})
+	require.NoError(t, service.HandleOrderEvent(context.Background(), event))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetByID", "p2")
+}
+
+func TestHandleOrderEvent_PaymentAfterCancellationIsSkipped(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	mockRepo.On("HasOperation", "order:checkout-1:p1:commit").Return(false, nil)
+	mockRepo.On("HasOperation", "order:checkout-1:p1:release").Return(true, nil)
+
+	event := orderEvent(t, events.OrderPaid, events.OrderItemPayload{ProductID: "p1", Quantity: 3})
+	require.NoError(t, service.HandleOrderEvent(context.Background(), event))
+	mockRepo.AssertNotCalled(t, "UpdateInventory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleOrderEvent_Failures(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	mockRepo.On("HasOperation", mock.Anything).Return(false, nil)
+	// Oversold and deleted products are skipped rather than redelivered
+	sold := builders.NewProduct(t).WithStock(1).Build()
+	mockRepo.On("GetByID", sold.ID.Hex()).Return(sold, nil)
+	mockRepo.On("CheckStock", sold.ID.Hex(), 2).Return(false, 1, nil)
+	mockRepo.On("GetByID", "gone").Return(nil, domain.ErrProductNotFound)
+
+	event := orderEvent(t, events.OrderPaid,
+		events.OrderItemPayload{ProductID: sold.ID.Hex(), Quantity: 2},
+		events.OrderItemPayload{ProductID: "gone", Quantity: 1})
+	assert.NoError(t, service.HandleOrderEvent(context.Background(), event))
+
+	// Invalid events are dropped
+	event = orderEvent(t, events.OrderPaid, events.OrderItemPayload{ProductID: "p1", Quantity: 0})
+	assert.NoError(t, service.HandleOrderEvent(context.Background(), event))
+	mockRepo.AssertNotCalled(t, "HasOperation", "order:checkout-1:p1:commit")
+
+	// Store failures are redelivered
+	service, mockRepo = newMerchandisingService()
+	mockRepo.On("HasOperation", mock.Anything).Return(false, errors.New("connection reset"))
+	event = orderEvent(t, events.OrderPaid, events.OrderItemPayload{ProductID: "p1", Quantity: 1})
+	assert.Error(t, service.HandleOrderEvent(context.Background(), event))
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Trending limits
+const (
+	DefaultTrendingWindow = 7 * 24 * time.Hour
+	DefaultTrendingLimit  = 10
+	MaxTrendingLimit      = 50
+)
+
+// PopularityCounter counts views and purchases until they are drained into
+// the popularity repository
+type PopularityCounter interface {
+	Add(ctx context.Context, count domain.PopularityCount) error
+	Drain(ctx context.Context) ([]domain.PopularityCount, error)
+}
+
+// SetPopularity configures where views and purchases are counted, and
+// where the counts are flushed to by FlushPopularity
+func (s *ProductService) SetPopularity(counter PopularityCounter, repo domain.PopularityRepository) {
+	s.counter = counter
+	s.popularity = repo
+}
+
+// countPopularity counts a product's views and purchased units. Failures
+// are logged but do not fail the operation counted.
+func (s *ProductService) countPopularity(ctx context.Context, productID string, views, purchases int64) {
+	if s.counter == nil {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return
+	}
+	count := domain.PopularityCount{ProductID: id, Day: time.Now(), Views: views, Purchases: purchases}
+	if err := s.counter.Add(ctx, count); err != nil {
+		s.logger.Warn("Failed to count product popularity", "productID", productID, "error", err)
+	}
+}
+
+// FlushPopularity moves the counted views and purchases to the popularity
+// repository. Counts that cannot be stored are counted again, to be
+// retried by the next flush.
+func (s *ProductService) FlushPopularity(ctx context.Context) (int, error) {
+	if s.counter == nil || s.popularity == nil {
+		return 0, apperrors.New(apperrors.Unavailable, "popularity tracking not available")
+	}
+
+	counts, err := s.counter.Drain(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to drain popularity counts: %w", err)
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	if err := s.popularity.AddCounts(ctx, counts); err != nil {
+		s.logger.Error("Failed to store popularity counts", "counts", len(counts), "error", err)
+		for _, count := range counts {
+			if err := s.counter.Add(context.Background(), count); err != nil {
+				s.logger.Error("Failed to restore popularity count", "productID", count.ProductID.Hex(), "error", err)
+			}
+		}
+		return 0, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Popularity counts flushed", "counts", len(counts))
+	return len(counts), nil
+}
+
+// TrendingProducts returns the active products viewed and bought most
+// within the window up to now, top limit per category
+func (s *ProductService) TrendingProducts(ctx context.Context, window time.Duration, category string, limit int) ([]domain.TrendingCategory, error) {
+	s.logger.Info("Getting trending products", "window", window, "category", category, "limit", limit)
+
+	if s.popularity == nil {
+		return nil, apperrors.New(apperrors.Unavailable, "popularity tracking not available")
+	}
+	if window == 0 {
+		window = DefaultTrendingWindow
+	}
+	if window < 24*time.Hour || window > domain.MaxTrendingWindow {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("window must be between 1d and %dd", domain.MaxTrendingWindow/(24*time.Hour)))
+	}
+	if limit == 0 {
+		limit = DefaultTrendingLimit
+	}
+	if limit < 1 || limit > MaxTrendingLimit {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("limit must be between 1 and %d", MaxTrendingLimit))
+	}
+
+	// Counts are daily, so windows cover whole days including today
+	days := int((window + 24*time.Hour - 1) / (24 * time.Hour))
+	since := domain.Day(time.Now()).AddDate(0, 0, 1-days)
+
+	trending, err := s.popularity.Trending(ctx, domain.TrendingParams{Since: since, Category: category, Limit: limit})
+	if err != nil {
+		s.logger.Error("Failed to get trending products", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	for _, category := range trending {
+		for _, product := range category.Products {
+			s.merchandise(product.Product)
+		}
+	}
+	return trending, nil
+}
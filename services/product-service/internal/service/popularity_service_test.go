@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/popularity"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPopularity records the counts added and the trending queries made
+type memoryPopularity struct {
+	counts []domain.PopularityCount
+	params domain.TrendingParams
+	err    error
+}
+
+func (m *memoryPopularity) AddCounts(ctx context.Context, counts []domain.PopularityCount) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.counts = append(m.counts, counts...)
+	return nil
+}
+
+func (m *memoryPopularity) Trending(ctx context.Context, params domain.TrendingParams) ([]domain.TrendingCategory, error) {
+	m.params = params
+	return []domain.TrendingCategory{}, nil
+}
+
+func TestPopularityIsCountedAndFlushed(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := New(mockRepo, slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	repo := &memoryPopularity{}
+	service.SetPopularity(popularity.NewMemoryCounter(), repo)
+
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("CheckStock", productID, 3).Return(true, 10, nil)
+	mockRepo.On("UpdateInventory", productID, -3, "op-1", "purchase").Return(&domain.InventoryInfo{Quantity: 7}, nil)
+	mockRepo.On("UpdateInventory", productID, 5, "op-2", "restock").Return(&domain.InventoryInfo{Quantity: 12}, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := service.GetProduct(context.Background(), productID)
+		require.NoError(t, err)
+	}
+	_, err := service.UpdateInventory(context.Background(), productID, -3, "op-1", "purchase")
+	require.NoError(t, err)
+	_, err = service.UpdateInventory(context.Background(), productID, 5, "op-2", "restock")
+	require.NoError(t, err)
+
+	// A failed flush keeps the counts for the next one
+	repo.err = errors.New("mongo down")
+	_, err = service.FlushPopularity(context.Background())
+	require.Error(t, err)
+	repo.err = nil
+
+	n, err := service.FlushPopularity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, repo.counts, 1)
+	assert.Equal(t, product.ID, repo.counts[0].ProductID)
+	assert.Equal(t, domain.Day(time.Now()), repo.counts[0].Day)
+	assert.Equal(t, int64(2), repo.counts[0].Views)
+	assert.Equal(t, int64(3), repo.counts[0].Purchases, "restocks are not purchases")
+
+	n, err = service.FlushPopularity(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n, "flushed counts are not flushed again")
+}
+
+func TestTrendingProducts(t *testing.T) {
+	service := New(new(MockProductRepository), slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	_, err := service.TrendingProducts(context.Background(), 0, "", 0)
+	assert.Equal(t, apperrors.Unavailable, apperrors.KindOf(err))
+
+	repo := &memoryPopularity{}
+	service.SetPopularity(popularity.NewMemoryCounter(), repo)
+	today := domain.Day(time.Now())
+
+	_, err = service.TrendingProducts(context.Background(), 0, "kitchen", 0)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TrendingParams{Since: today.AddDate(0, 0, -6), Category: "kitchen", Limit: DefaultTrendingLimit}, repo.params)
+
+	_, err = service.TrendingProducts(context.Background(), 36*time.Hour, "", 3)
+	require.NoError(t, err)
+	assert.Equal(t, today.AddDate(0, 0, -1), repo.params.Since, "windows cover whole days")
+	assert.Equal(t, 3, repo.params.Limit)
+
+	for _, tt := range []struct {
+		window time.Duration
+		limit  int
+	}{
+		{time.Hour, 0},
+		{domain.MaxTrendingWindow + 24*time.Hour, 0},
+		{0, -1},
+		{0, MaxTrendingLimit + 1},
+	} {
+		_, err := service.TrendingProducts(context.Background(), tt.window, "", tt.limit)
+		assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err), "window %s, limit %d", tt.window, tt.limit)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// ReleasePreorders releases the products on preorder whose release date
+// has passed. Their preorders become reservations of on-hand stock, and a
+// product.released event is published for each so that buyers can be
+// notified. It returns the number of products released.
+func (s *ProductService) ReleasePreorders(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		release, err := s.repo.ReleaseDue(ctx, time.Now())
+		if err != nil {
+			return released, fmt.Errorf("repository error: %w", err)
+		}
+		if release == nil {
+			return released, nil
+		}
+		released++
+
+		product := release.Product
+		id := product.ID.Hex()
+		s.logger.Info("Product released", "id", id, "preorders", release.Converted)
+		s.merchandise(product)
+		s.publish(ctx, events.ProductUpdated, id, product)
+		s.publish(ctx, events.ProductReleased, id, events.ProductReleasedPayload{
+			ProductID:   id,
+			Name:        product.Name,
+			ReleaseDate: *product.ReleaseDate,
+			Preorders:   release.Converted,
+		})
+	}
+}
+
+// updatePreorders counts a purchase or reservation (negative quantityChange)
+// against the preorder allocation of a product, or cancels preorders with a
+// release (positive quantityChange). On-hand stock is not touched.
+func (s *ProductService) updatePreorders(ctx context.Context, product *domain.Product, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
+	productID := product.ID.Hex()
+	if quantityChange < 0 {
+		if err := product.CheckQuantity(-quantityChange); err != nil {
+			return nil, err
+		}
+	}
+
+	preorder, err := s.repo.UpdatePreorders(ctx, productID, quantityChange, operationID, operationType)
+	if err != nil {
+		s.logger.Error("Failed to update preorders", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Preorders updated successfully", "productID", productID, "ordered", preorder.Ordered)
+	// Purchases and reservations take preorders, releases cancel them
+	s.auditInventory(ctx, productID, operationID, operationType, "preorder.ordered", -quantityChange, preorder.Ordered)
+	if operationType == "purchase" && quantityChange < 0 {
+		s.countPopularity(ctx, productID, 0, int64(-quantityChange))
+	}
+	return &product.Inventory, nil
+}
+
+// preorderOperation reports whether an inventory operation on a product on
+// preorder goes to its preorders
+func preorderOperation(operationType string, quantityChange int) bool {
+	switch operationType {
+	case "purchase", "reservation":
+		return quantityChange < 0
+	case "release":
+		return quantityChange > 0
+	}
+	return false
+}
+
+// validatePreorder checks that a product on preorder has a release date in
+// the future and an allocation covering the preorders taken
+func validatePreorder(product *domain.Product, now time.Time) error {
+	if !product.OnPreorder() {
+		return nil
+	}
+	if product.ReleaseDate == nil || !product.ReleaseDate.After(now) {
+		return errors.New("products on preorder need a release date in the future")
+	}
+	allocation := product.Preorder.Allocation
+	if allocation <= 0 || allocation > math.MaxInt32 {
+		return errors.New("preorder allocation must be between 1 and 2147483647")
+	}
+	if allocation < product.Preorder.Ordered {
+		return errors.New("preorder allocation must cover the preorders taken")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateInventory_PreorderLeavesStockAlone(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).WithStock(0).WithPreorder(time.Now().Add(24*time.Hour), 50).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("UpdatePreorders", productID, -3, "op-1", "purchase").
+		Return(&domain.PreorderInfo{Allocation: 50, Ordered: 3}, nil)
+
+	_, err := service.UpdateInventory(context.Background(), productID, -3, "op-1", "purchase")
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CheckStock", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateInventory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateInventory_PreorderSoldOut(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).WithPreorder(time.Now().Add(24*time.Hour), 2).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("UpdatePreorders", productID, -3, "op-1", "purchase").Return(nil, domain.ErrPreorderSoldOut)
+
+	_, err := service.UpdateInventory(context.Background(), productID, -3, "op-1", "purchase")
+	assert.ErrorIs(t, err, domain.ErrPreorderSoldOut)
+}
+
+func TestReleasePreorders(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	publisher := &recordingPublisher{}
+	service.SetPublisher(publisher)
+
+	released := builders.NewProduct(t).WithReserved(4).Build()
+	releaseDate := time.Now().Add(-time.Minute).UTC()
+	released.ReleaseDate = &releaseDate
+	mockRepo.On("ReleaseDue", mock.AnythingOfType("time.Time")).
+		Return(&domain.PreorderRelease{Product: released, Converted: 4}, nil).Once()
+	mockRepo.On("ReleaseDue", mock.AnythingOfType("time.Time")).Return(nil, nil).Once()
+
+	count, err := service.ReleasePreorders(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, events.ProductUpdated, publisher.events[0].Type)
+	var payload events.ProductReleasedPayload
+	require.NoError(t, publisher.events[1].Decode(&payload))
+	assert.Equal(t, events.ProductReleased, publisher.events[1].Type)
+	assert.Equal(t, released.ID.Hex(), payload.ProductID)
+	assert.Equal(t, 4, payload.Preorders)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateProduct_PreorderValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		releaseDate time.Time
+		allocation  int
+	}{
+		{"release date in the past", time.Now().Add(-time.Hour), 10},
+		{"no allocation", time.Now().Add(time.Hour), 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service, mockRepo := newMerchandisingService()
+			product := builders.NewProduct(t).WithoutID().WithPreorder(tc.releaseDate, tc.allocation).Build()
+
+			_, err := service.CreateProduct(context.Background(), product)
+			assert.True(t, apperrors.Is(err, apperrors.Invalid))
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
@@ -1,37 +1,194 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/dualwrite"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/money"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/pkg/tracing"
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/bekbull/online-shop/services/product-service/internal/service")
+
+// defaultCurrency is the catalog currency when none is configured
+const defaultCurrency = "USD"
+
 // ProductService provides business logic for product operations
 type ProductService struct {
-	repo   domain.ProductRepository
-	logger *slog.Logger
+	repo       domain.ProductRepository
+	suppliers  domain.SupplierRepository
+	orders     domain.PurchaseOrderRepository
+	backfill   domain.ProductBackfillRepository
+	popularity domain.PopularityRepository
+	counter    PopularityCounter
+	publisher  eventbus.Publisher
+	inventory  InventoryClient
+	// warehouses decides whether availability is read from the product's
+	// own inventory or from the inventory service
+	warehouses *dualwrite.Migration
+	fx         CurrencyConverter
+	currency   string
+	// newArrivals is how long after their creation products count as new
+	newArrivals time.Duration
+	// downloads, orderVerifier and signer serve digital products
+	downloads     domain.DownloadRepository
+	orderVerifier OrderVerifier
+	signer        URLSigner
+	downloadTTL   time.Duration
+	licenses      LicenseKeyGenerator
+	// badgeRepo stores badge definitions; badges is the snapshot products
+	// are shown with, refreshed by RefreshBadges
+	badgeRepo domain.BadgeRepository
+	badgesMu  sync.RWMutex
+	badges    map[string]domain.Badge
+	// categories stores the category tree
+	categories domain.CategoryRepository
+	// reviews stores product reviews and keeps product ratings
+	reviews domain.ReviewRepository
+	// promotionRepo stores promotions; promotions is the snapshot of live
+	// ones products are discounted by, refreshed by RefreshPromotions
+	promotionRepo domain.PromotionRepository
+	promotionsMu  sync.RWMutex
+	promotions    []*domain.Promotion
+	// stockAlerts stores the low-stock alerts inventory updates check
+	stockAlerts domain.StockAlertRepository
+	// snapshots stores daily inventory snapshots for the reports
+	snapshots domain.SnapshotRepository
+	// audits stores the audit trail of product changes
+	audits domain.AuditRepository
+	// images stores uploaded product images of up to maxImageBytes;
+	// imagePolicy checks and rewrites the image URLs products are saved with
+	images        ImageStorage
+	maxImageBytes int64
+	imagePolicy   domain.ImagePolicy
+	// related finds related products, the repository unless replaced
+	related RelatedProductsStrategy
+	// suggest limits search suggestions
+	suggest domain.SuggestOptions
+	// invalidator tells the other instances their caches are stale
+	invalidator CacheInvalidator
+	// tags caches the tags in use for tagsTTL after tagsLoaded
+	tagsMu     sync.Mutex
+	tags       []domain.TagCount
+	tagsLoaded time.Time
+	tagsTTL    time.Duration
+	logger     *slog.Logger
+}
+
+// CurrencyConverter converts amounts between currencies
+type CurrencyConverter interface {
+	Convert(ctx context.Context, amount int64, from, to string) (*domain.ConvertedAmount, error)
+}
+
+// InventoryClient reads stock levels from the inventory service
+type InventoryClient interface {
+	GetAvailability(ctx context.Context, productID string) (*domain.Availability, error)
 }
 
 // New creates a new ProductService
 func New(repo domain.ProductRepository, logger *slog.Logger) *ProductService {
 	return &ProductService{
-		repo:   repo,
-		logger: logger,
+		repo:          repo,
+		related:       repo,
+		publisher:     eventbus.NoopPublisher{},
+		newArrivals:   domain.DefaultNewArrivalWindow,
+		licenses:      randomLicenseKeys{},
+		badges:        defaultBadgeDefinitions(),
+		suggest:       domain.DefaultSuggestOptions,
+		tagsTTL:       domain.DefaultTagsCacheTTL,
+		maxImageBytes: domain.DefaultMaxImageBytes,
+		logger:        logger,
 	}
 }
 
-// CreateProduct creates a new product
-func (s *ProductService) CreateProduct(product *domain.Product) (*domain.Product, error) {
+// SetPublisher configures where product and inventory events are published
+func (s *ProductService) SetPublisher(publisher eventbus.Publisher) {
+	s.publisher = publisher
+}
+
+// CreateProduct creates a new product, published unless its status is
+// draft
+func (s *ProductService) CreateProduct(ctx context.Context, product *domain.Product) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CreateProduct")
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Creating new product", "name", product.Name)
 
+	product.Active = true
+	return s.create(ctx, product)
+}
+
+// create validates and saves a new product, shown to shoppers if it is
+// published and active
+func (s *ProductService) create(ctx context.Context, product *domain.Product) (*domain.Product, error) {
 	// Validate product data
 	if err := validateProduct(product); err != nil {
 		s.logger.Error("Product validation failed", "error", err)
-		return nil, fmt.Errorf("validation error: %w", err)
+		return nil, invalid(err)
+	}
+	switch product.Status {
+	case "":
+		product.Status = domain.StatusPublished
+	case domain.StatusDraft, domain.StatusPublished:
+	default:
+		return nil, invalid(fmt.Errorf("new products must be %s or %s", domain.StatusDraft, domain.StatusPublished))
+	}
+	if err := normalizeShipping(product); err != nil {
+		s.logger.Error("Product validation failed", "error", err)
+		return nil, invalid(err)
+	}
+	if err := normalizeBarcode(product); err != nil {
+		s.logger.Error("Product validation failed", "error", err)
+		return nil, invalid(err)
+	}
+	generateSlug, err := normalizeSEO(product)
+	if err != nil {
+		s.logger.Error("Product validation failed", "error", err)
+		return nil, invalid(err)
+	}
+	restrictions, err := product.Restrictions.Normalize()
+	if err != nil {
+		s.logger.Error("Product validation failed", "error", err)
+		return nil, invalid(err)
+	}
+	product.Restrictions = restrictions
+	if product.Preorder != nil {
+		product.Preorder.Ordered = 0
+	}
+	if err := validatePreorder(product, time.Now()); err != nil {
+		s.logger.Error("Product validation failed", "error", err)
+		return nil, invalid(err)
+	}
+
+	if err := s.validateProductSuppliers(ctx, product.Suppliers); err != nil {
+		s.logger.Error("Product supplier validation failed", "error", err)
+		return nil, err
+	}
+	if product.Badges, err = s.validateProductBadges(ctx, product.Badges); err != nil {
+		s.logger.Error("Product badge validation failed", "error", err)
+		return nil, err
+	}
+	if product.GroupPrices, err = domain.NormalizeGroupPrices(product.GroupPrices); err != nil {
+		return nil, invalid(err)
+	}
+	if product.ImageURLs, err = s.imagePolicy.Apply(product.ImageURLs); err != nil {
+		return nil, invalid(err)
 	}
 
 	// Set default values
@@ -40,44 +197,142 @@ func (s *ProductService) CreateProduct(product *domain.Product) (*domain.Product
 	}
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
-	product.Active = true
-	product.Inventory.InStock = product.Inventory.Quantity > 0
+	if product.Type == "" {
+		product.Type = domain.ProductTypePhysical
+	}
+	product.Inventory.InStock = product.HasStock()
 
 	// Persist product
-	if err := s.repo.Create(product); err != nil {
+	if err := s.saveWithSlug(product, generateSlug, func() error { return s.repo.Create(ctx, product) }); err != nil {
 		s.logger.Error("Failed to create product", "error", err)
 		return nil, fmt.Errorf("repository error: %w", err)
 	}
 
+	s.audit(ctx, domain.AuditCreate, product.ID.Hex(), nil, product)
+	s.invalidateTags(ctx)
+	s.merchandise(product)
 	s.logger.Info("Product created successfully", "id", product.ID.Hex())
+	s.publish(ctx, events.ProductCreated, product.ID.Hex(), product)
 	return product, nil
 }
 
 // GetProduct retrieves a product by ID
-func (s *ProductService) GetProduct(id string) (*domain.Product, error) {
+func (s *ProductService) GetProduct(ctx context.Context, id string) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProduct", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Getting product", "id", id)
 
-	product, err := s.repo.GetByID(id)
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Failed to get product", "id", id, "error", err)
 		return nil, fmt.Errorf("repository error: %w", err)
 	}
 
+	s.merchandise(product)
+	priceForGroup(group, product)
+	s.countPopularity(ctx, id, 1, 0)
+	return product, nil
+}
+
+// GetProductsByIDs retrieves the products with the IDs, in the order they
+// are asked for and once each, and the IDs of those that do not exist.
+// Unlike GetProduct it does not count the products as viewed.
+func (s *ProductService) GetProductsByIDs(ctx context.Context, ids []string) (_ []*domain.Product, _ []string, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductsByIDs")
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Info("Getting products by IDs", "count", len(ids))
+
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > domain.MaxProductBatch {
+		return nil, nil, apperrors.Newf(apperrors.Invalid, "at most %d product IDs can be retrieved at once", domain.MaxProductBatch)
+	}
+
+	found, err := s.repo.GetByIDs(ctx, unique)
+	if err != nil {
+		s.logger.Error("Failed to get products", "count", len(unique), "error", err)
+		return nil, nil, fmt.Errorf("repository error: %w", err)
+	}
+	byID := make(map[string]*domain.Product, len(found))
+	for _, product := range found {
+		byID[product.ID.Hex()] = product
+	}
+
+	products := make([]*domain.Product, 0, len(unique))
+	missing := []string{}
+	for _, id := range unique {
+		// Hex IDs match whatever their case
+		if product, ok := byID[strings.ToLower(id)]; ok {
+			products = append(products, product)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	s.merchandise(products...)
+	priceForGroup(group, products...)
+	return products, missing, nil
+}
+
+// GetProductByBarcode retrieves the product with a barcode, given in any
+// form NormalizeBarcode accepts
+func (s *ProductService) GetProductByBarcode(ctx context.Context, barcode string) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductByBarcode", trace.WithAttributes(attribute.String("product.barcode", barcode)))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Info("Getting product by barcode", "barcode", barcode)
+
+	normalized, err := domain.NormalizeBarcode(barcode)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetByBarcode(ctx, normalized)
+	if err != nil {
+		s.logger.Error("Failed to get product by barcode", "barcode", normalized, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.merchandise(product)
+	priceForGroup(group, product)
 	return product, nil
 }
 
 // UpdateProduct updates an existing product
-func (s *ProductService) UpdateProduct(product *domain.Product) (*domain.Product, error) {
+func (s *ProductService) UpdateProduct(ctx context.Context, product *domain.Product) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProduct", trace.WithAttributes(attribute.String("product.id", product.ID.Hex())))
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Updating product", "id", product.ID.Hex())
 
 	// Check if product exists
-	existingProduct, err := s.repo.GetByID(product.ID.Hex())
+	existingProduct, err := s.repo.GetByID(ctx, product.ID.Hex())
 	if err != nil {
 		s.logger.Error("Failed to find product for update", "id", product.ID.Hex(), "error", err)
 		return nil, fmt.Errorf("product not found: %w", err)
 	}
 
 	// Update fields that can be changed
+	before := s.auditFields(existingProduct)
+	oldName := existingProduct.Name
 	if product.Name != "" {
 		existingProduct.Name = product.Name
 	}
@@ -87,8 +342,20 @@ func (s *ProductService) UpdateProduct(product *domain.Product) (*domain.Product
 	if product.Price > 0 {
 		existingProduct.Price = product.Price
 	}
+	// A compare-at price of zero ends the sale
+	if product.CompareAtPrice != nil {
+		existingProduct.CompareAtPrice = product.CompareAtPrice
+		if *product.CompareAtPrice == 0 {
+			existingProduct.CompareAtPrice = nil
+		}
+		if err := validateCompareAtPrice(existingProduct.CompareAtPrice); err != nil {
+			return nil, invalid(err)
+		}
+	}
 	if len(product.ImageURLs) > 0 {
-		existingProduct.ImageURLs = product.ImageURLs
+		if existingProduct.ImageURLs, err = s.imagePolicy.Apply(product.ImageURLs); err != nil {
+			return nil, invalid(err)
+		}
 	}
 	if product.Category != "" {
 		existingProduct.Category = product.Category
@@ -99,12 +366,73 @@ func (s *ProductService) UpdateProduct(product *domain.Product) (*domain.Product
 	if len(product.Attributes) > 0 {
 		existingProduct.Attributes = product.Attributes
 	}
+	// Group prices replace the stored ones when given; an empty list
+	// removes them
+	if product.GroupPrices != nil {
+		if existingProduct.GroupPrices, err = domain.NormalizeGroupPrices(product.GroupPrices); err != nil {
+			return nil, invalid(err)
+		}
+	}
 
 	// Handle inventory update if provided
 	if product.Inventory.SKU != "" {
 		existingProduct.Inventory.SKU = product.Inventory.SKU
 	}
 
+	// Shipping details replace the stored ones when given
+	if product.Weight != nil {
+		existingProduct.Weight = product.Weight
+	}
+	if product.Dimensions != nil {
+		existingProduct.Dimensions = product.Dimensions
+	}
+	if product.ShippingClass != "" {
+		existingProduct.ShippingClass = product.ShippingClass
+	}
+	if err := normalizeShipping(existingProduct); err != nil {
+		return nil, invalid(err)
+	}
+	if product.Barcode != "" {
+		existingProduct.Barcode = product.Barcode
+		if err := normalizeBarcode(existingProduct); err != nil {
+			return nil, invalid(err)
+		}
+	}
+
+	// Renaming a product moves a slug generated from its name; the old
+	// slug keeps resolving
+	generateSlug, err := updateSEO(existingProduct, product, oldName)
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	// A new allocation keeps the preorders taken; products not on
+	// preorder start taking preorders
+	if product.ReleaseDate != nil {
+		existingProduct.ReleaseDate = product.ReleaseDate
+	}
+	if product.Preorder != nil {
+		ordered := 0
+		if existingProduct.Preorder != nil {
+			ordered = existingProduct.Preorder.Ordered
+		}
+		existingProduct.Preorder = &domain.PreorderInfo{Allocation: product.Preorder.Allocation, Ordered: ordered}
+	}
+	if product.ReleaseDate != nil || product.Preorder != nil {
+		if err := validatePreorder(existingProduct, time.Now()); err != nil {
+			return nil, invalid(err)
+		}
+	}
+
+	// The type is fixed at creation; only the downloads of digital
+	// products can change
+	if product.Digital != nil {
+		existingProduct.Digital = product.Digital
+		if err := validateProductType(existingProduct); err != nil {
+			return nil, invalid(err)
+		}
+	}
+
 	// Only update quantity through dedicated inventory update methods
 	// This prevents accidental inventory changes
 
@@ -118,88 +446,351 @@ func (s *ProductService) UpdateProduct(product *domain.Product) (*domain.Product
 	}
 
 	// Persist changes
-	if err := s.repo.Update(existingProduct); err != nil {
+	if err := s.saveWithSlug(existingProduct, generateSlug, func() error { return s.repo.Update(ctx, existingProduct) }); err != nil {
 		s.logger.Error("Failed to update product", "id", existingProduct.ID.Hex(), "error", err)
 		return nil, fmt.Errorf("repository error: %w", err)
 	}
 
+	s.audit(ctx, domain.AuditUpdate, existingProduct.ID.Hex(), before, existingProduct)
+	s.invalidateTags(ctx)
+	s.merchandise(existingProduct)
 	s.logger.Info("Product updated successfully", "id", existingProduct.ID.Hex())
+	s.publish(ctx, events.ProductUpdated, existingProduct.ID.Hex(), existingProduct)
 	return existingProduct, nil
 }
 
+// PatchProduct applies a merge patch to a product. Unlike UpdateProduct,
+// zero values are values: a patch can clear the description or the
+// compare-at price. Required fields cannot be cleared, and the patched
+// product is validated as a whole.
+func (s *ProductService) PatchProduct(ctx context.Context, id string, patch *domain.ProductPatch) (_ *domain.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.PatchProduct", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Info("Patching product", "id", id)
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to find product for patch", "id", id, "error", err)
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	before := s.auditFields(product)
+	generateSlug, err := applyPatch(product, patch, time.Now())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if patch.ImageURLs.Set {
+		if product.ImageURLs, err = s.imagePolicy.Apply(product.ImageURLs); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if err := validateProduct(product); err != nil {
+		return nil, invalid(err)
+	}
+
+	product.UpdatedAt = time.Now()
+	if err := s.saveWithSlug(product, generateSlug, func() error { return s.repo.Update(ctx, product) }); err != nil {
+		s.logger.Error("Failed to patch product", "id", id, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, id, before, product)
+	s.invalidateTags(ctx)
+	s.merchandise(product)
+	s.logger.Info("Product patched successfully", "id", id)
+	s.publish(ctx, events.ProductUpdated, id, product)
+	return product, nil
+}
+
+// applyPatch applies the fields a patch sets to the product and reports
+// whether its slug is to be generated from the name. A null slug asks for
+// that; otherwise slugs are generated as in updateSEO.
+func applyPatch(product *domain.Product, patch *domain.ProductPatch, now time.Time) (generateSlug bool, err error) {
+	if patch.Name.Set && (patch.Name.Null || patch.Name.Value == "") {
+		return false, errors.New("product name is required")
+	}
+	if patch.Price.Null {
+		return false, errors.New("product price must be greater than zero")
+	}
+	if patch.Category.Set && (patch.Category.Null || patch.Category.Value == "") {
+		return false, errors.New("product category is required")
+	}
+	if patch.Active.Null {
+		return false, errors.New("active cannot be null")
+	}
+	if patch.Inventory.Null || patch.Inventory.Value.SKU.Null {
+		return false, errors.New("product SKU is required")
+	}
+
+	oldName := product.Name
+	patch.Name.Apply(&product.Name)
+	patch.Description.Apply(&product.Description)
+	patch.Price.Apply(&product.Price)
+	patch.ImageURLs.Apply(&product.ImageURLs)
+	patch.Category.Apply(&product.Category)
+	patch.Tags.Apply(&product.Tags)
+	patch.Active.Apply(&product.Active)
+	patch.Inventory.Value.SKU.Apply(&product.Inventory.SKU)
+
+	if patch.CompareAtPrice.Set {
+		product.CompareAtPrice = nil
+		if !patch.CompareAtPrice.Null {
+			price := patch.CompareAtPrice.Value
+			product.CompareAtPrice = &price
+		}
+	}
+	if patch.GroupPrices.Set {
+		if product.GroupPrices, err = domain.NormalizeGroupPrices(patch.GroupPrices.Value); err != nil {
+			return false, err
+		}
+	}
+	if patch.Attributes.Set {
+		if patch.Attributes.Null {
+			product.Attributes = nil
+		}
+		for key, value := range patch.Attributes.Value {
+			if value == nil {
+				delete(product.Attributes, key)
+				continue
+			}
+			if product.Attributes == nil {
+				product.Attributes = make(map[string]string)
+			}
+			product.Attributes[key] = *value
+		}
+	}
+	if patch.Barcode.Set {
+		patch.Barcode.Apply(&product.Barcode)
+		if err := normalizeBarcode(product); err != nil {
+			return false, err
+		}
+	}
+
+	if patch.MetaTitle.Set || patch.MetaDescription.Set {
+		patch.MetaTitle.Apply(&product.MetaTitle)
+		patch.MetaDescription.Apply(&product.MetaDescription)
+		if err := domain.ValidateMeta(product.MetaTitle, product.MetaDescription); err != nil {
+			return false, err
+		}
+	}
+	switch {
+	case patch.Slug.Set && patch.Slug.Value != "":
+		slug, err := domain.NormalizeSlug(patch.Slug.Value)
+		if err != nil {
+			return false, err
+		}
+		product.ChangeSlug(slug)
+	case patch.Slug.Set:
+		generateSlug = true
+	default:
+		renamed := product.Name != oldName && domain.GeneratedFrom(product.Slug, oldName)
+		generateSlug = product.Slug == "" || renamed
+	}
+
+	if patch.Weight.Set || patch.Dimensions.Set || patch.ShippingClass.Set {
+		// Objects are merged into the stored ones, as JSON Merge Patch does
+		if patch.Weight.Set {
+			if patch.Weight.Null {
+				product.Weight = nil
+			} else {
+				product.Weight = patch.Weight.Value.Apply(product.Weight)
+			}
+		}
+		if patch.Dimensions.Set {
+			if patch.Dimensions.Null {
+				product.Dimensions = nil
+			} else {
+				product.Dimensions = patch.Dimensions.Value.Apply(product.Dimensions)
+			}
+		}
+		patch.ShippingClass.Apply(&product.ShippingClass)
+		if err := normalizeShipping(product); err != nil {
+			return false, err
+		}
+	}
+
+	// Clearing the preorder of a product with preorders taken would lose
+	// them; a new allocation keeps them
+	if patch.ReleaseDate.Set || patch.Preorder.Set {
+		if patch.ReleaseDate.Set {
+			product.ReleaseDate = nil
+			if !patch.ReleaseDate.Null {
+				date := patch.ReleaseDate.Value
+				product.ReleaseDate = &date
+			}
+		}
+		if patch.Preorder.Set {
+			switch {
+			case patch.Preorder.Null:
+				if product.Preorder != nil && product.Preorder.Ordered > 0 {
+					return false, errors.New("preorders have been taken for the product")
+				}
+				product.Preorder = nil
+			default:
+				preorder := domain.PreorderInfo{}
+				if product.Preorder != nil {
+					preorder = *product.Preorder
+				}
+				patch.Preorder.Value.Allocation.Apply(&preorder.Allocation)
+				product.Preorder = &preorder
+			}
+		}
+		if err := validatePreorder(product, now); err != nil {
+			return false, err
+		}
+	}
+
+	// The type is fixed at creation; only the downloads of digital
+	// products can change
+	if patch.Digital.Set {
+		if patch.Digital.Null {
+			product.Digital = nil
+		} else {
+			product.Digital = patch.Digital.Value.Apply(product.Digital)
+		}
+	}
+	return generateSlug, nil
+}
+
 // DeleteProduct removes a product
-func (s *ProductService) DeleteProduct(id string) error {
+func (s *ProductService) DeleteProduct(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.DeleteProduct", trace.WithAttributes(attribute.String("product.id", id)))
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Deleting product", "id", id)
 
-	if err := s.repo.Delete(id); err != nil {
+	before := s.auditStored(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
 		s.logger.Error("Failed to delete product", "id", id, "error", err)
 		return fmt.Errorf("repository error: %w", err)
 	}
+	s.audit(ctx, domain.AuditDelete, id, before, nil)
+	s.invalidateTags(ctx)
 
 	s.logger.Info("Product deleted successfully", "id", id)
+	s.publish(ctx, events.ProductDeleted, id, events.ProductDeletedPayload{ID: id})
 	return nil
 }
 
 // ListProducts retrieves a list of products based on filters
-func (s *ProductService) ListProducts(params domain.ListProductsParams) ([]*domain.Product, int, error) {
+func (s *ProductService) ListProducts(ctx context.Context, params domain.ListProductsParams) (_ []*domain.Product, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.ListProducts")
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Listing products",
 		"page", params.Page,
 		"pageSize", params.PageSize,
 		"category", params.Category,
 		"inStockOnly", params.InStockOnly)
 
-	// Apply default values if not provided
-	if params.PageSize <= 0 {
-		params.PageSize = 20 // Default page size
+	// Clamp the page to the allowed range
+	page := pagination.New(params.Page, params.PageSize, domain.ProductPagination)
+	params.Page, params.PageSize = page.Page, page.PageSize
+	if params.Offset <= 0 {
+		params.Offset = page.Offset()
 	}
-	if params.Page < 0 {
-		params.Page = 0 // First page
+	if err := s.prepareListFilters(ctx, &params); err != nil {
+		return nil, 0, err
+	}
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	products, total, err := s.repo.List(params)
+	products, total, err := s.repo.List(ctx, params)
 	if err != nil {
 		s.logger.Error("Failed to list products", "error", err)
 		return nil, 0, fmt.Errorf("repository error: %w", err)
 	}
 
+	s.merchandise(products...)
+	priceForGroup(group, products...)
 	s.logger.Info("Products listed successfully", "count", len(products), "total", total)
 	return products, total, nil
 }
 
+// prepareListFilters validates the filters of a product list and resolves
+// those the repository cannot: new arrivals and subcategories
+func (s *ProductService) prepareListFilters(ctx context.Context, params *domain.ListProductsParams) error {
+	if params.SupplierID != "" {
+		if _, err := primitive.ObjectIDFromHex(params.SupplierID); err != nil {
+			return apperrors.New(apperrors.Invalid, "invalid supplier ID")
+		}
+	}
+	if params.NewOnly {
+		params.CreatedSince = time.Now().Add(-s.newArrivals)
+	}
+	if params.ShippingClass != "" && !domain.ValidShippingClass(params.ShippingClass) {
+		return apperrors.New(apperrors.Invalid, "invalid shipping class")
+	}
+	if err := domain.ValidateAttributeFilters(params.AttributeFilters); err != nil {
+		return invalid(err)
+	}
+	if params.Status != "" && !domain.ValidStatus(params.Status) {
+		return apperrors.New(apperrors.Invalid, "invalid status")
+	}
+	if params.Category != "" && params.IncludeSubcategories {
+		var err error
+		if params.Categories, err = s.categoryAndDescendants(ctx, params.Category); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inventoryOperationTypes are the types of inventory operations
+var inventoryOperationTypes = map[string]bool{
+	"purchase":    true,
+	"restock":     true,
+	"reservation": true,
+	"release":     true,
+	"adjustment":  true,
+}
+
 // UpdateInventory updates a product's inventory
-func (s *ProductService) UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
+func (s *ProductService) UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (_ *domain.InventoryInfo, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateInventory", trace.WithAttributes(attribute.String("product.id", productID)))
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Updating inventory",
 		"productID", productID,
 		"quantityChange", quantityChange,
 		"operationType", operationType)
 
 	// Validate operation type
-	validOperationTypes := map[string]bool{
-		"purchase":    true,
-		"restock":     true,
-		"reservation": true,
-		"release":     true,
-		"adjustment":  true,
+	if !inventoryOperationTypes[operationType] {
+		return nil, apperrors.New(apperrors.Invalid, "invalid operation type")
 	}
-	if !validOperationTypes[operationType] {
-		return nil, errors.New("invalid operation type")
+
+	// Products on preorder count purchases and reservations against their
+	// preorder allocation instead of stock, until they are released
+	if preorderOperation(operationType, quantityChange) {
+		product, err := s.repo.GetByID(ctx, productID)
+		if err != nil {
+			s.logger.Error("Failed to get product", "productID", productID, "error", err)
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+		if product.OnPreorder() {
+			return s.updatePreorders(ctx, product, quantityChange, operationID, operationType)
+		}
 	}
 
 	// For purchase and reservation operations, check if there's enough stock
 	if (operationType == "purchase" || operationType == "reservation") && quantityChange < 0 {
-		available, current, err := s.repo.CheckStock(productID, -quantityChange)
+		available, current, err := s.repo.CheckStock(ctx, productID, -quantityChange)
 		if err != nil {
 			s.logger.Error("Failed to check stock", "productID", productID, "error", err)
 			return nil, fmt.Errorf("stock check error: %w", err)
 		}
 		if !available {
 			s.logger.Error("Insufficient stock", "productID", productID, "required", -quantityChange, "available", current)
-			return nil, errors.New("insufficient stock")
+			return nil, domain.ErrInsufficientStock
 		}
 	}
 
 	// Update inventory
-	updatedInventory, err := s.repo.UpdateInventory(productID, quantityChange, operationID, operationType)
+	updatedInventory, err := s.repo.UpdateInventory(ctx, productID, quantityChange, operationID, operationType)
 	if err != nil {
 		s.logger.Error("Failed to update inventory", "productID", productID, "error", err)
 		return nil, fmt.Errorf("repository error: %w", err)
@@ -208,14 +799,161 @@ func (s *ProductService) UpdateInventory(productID string, quantityChange int, o
 	s.logger.Info("Inventory updated successfully",
 		"productID", productID,
 		"newQuantity", updatedInventory.Quantity)
+	s.auditInventory(ctx, productID, operationID, operationType, "inventory.quantity", quantityChange, updatedInventory.Quantity)
+	if operationType == "purchase" && quantityChange < 0 {
+		s.countPopularity(ctx, productID, 0, int64(-quantityChange))
+	}
+	s.publish(ctx, events.InventoryChanged, productID, events.InventoryChangedPayload{
+		ProductID:      productID,
+		QuantityChange: quantityChange,
+		OperationID:    operationID,
+		OperationType:  operationType,
+		Inventory: events.InventoryPayload{
+			Quantity: updatedInventory.Quantity,
+			SKU:      updatedInventory.SKU,
+			InStock:  updatedInventory.InStock,
+			Reserved: updatedInventory.Reserved,
+		},
+	})
+	s.checkStockAlerts(ctx, productID, updatedInventory)
 	return updatedInventory, nil
 }
 
+// WarehouseMigration is the migration of availability from the product's
+// own inventory to the inventory service's warehouses
+const WarehouseMigration = "warehouse-inventory"
+
+// SetInventoryClient configures the inventory service used for
+// availability. The migration decides whether availability is read from
+// the service or the product's own inventory; nil reads the service and
+// falls back to the product's inventory when it fails, as in cutover.
+func (s *ProductService) SetInventoryClient(client InventoryClient, migration *dualwrite.Migration) {
+	if migration == nil {
+		migration = dualwrite.New(WarehouseMigration, nil, dualwrite.Options{Default: dualwrite.ModeCutover}, s.logger)
+	}
+	s.inventory = client
+	s.warehouses = migration
+}
+
+// SetCurrencyConverter configures the FX service used to show prices in
+// other currencies. Catalog prices are stored in baseCurrency.
+func (s *ProductService) SetCurrencyConverter(converter CurrencyConverter, baseCurrency string) {
+	s.fx = converter
+	s.currency = strings.ToUpper(baseCurrency)
+}
+
+// GetPrice returns a product's price in the requested currency, for the
+// customer group of the caller if known
+func (s *ProductService) GetPrice(ctx context.Context, productID, currency string) (_ *domain.Price, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetPrice", trace.WithAttributes(attribute.String("product.id", productID)))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Info("Getting price", "productID", productID, "currency", currency)
+
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to get product", "id", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	amount := product.PriceFor(group)
+
+	base := s.currency
+	if base == "" {
+		base = defaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+	price := &domain.Price{
+		ProductID:    productID,
+		Amount:       amount,
+		Currency:     base,
+		BaseAmount:   amount,
+		BaseCurrency: base,
+	}
+	if currency == "" || currency == base {
+		return price, nil
+	}
+
+	if s.fx == nil {
+		return nil, apperrors.New(apperrors.Unavailable, "currency conversion not available")
+	}
+	converted, err := s.fx.Convert(ctx, money.ToMinor(amount, base), base, currency)
+	if err != nil {
+		s.logger.Error("Failed to convert price", "productID", productID, "currency", currency, "error", err)
+		// Unsupported currencies are the caller's fault; anything else means
+		// the FX service could not help right now
+		kind := apperrors.KindOf(err)
+		if kind != apperrors.Invalid {
+			kind = apperrors.Unavailable
+		}
+		return nil, apperrors.Wrap(err, kind, "currency conversion failed")
+	}
+
+	price.Amount = money.FromMinor(converted.Amount, currency)
+	price.Currency = currency
+	price.ExchangeRate = converted.Rate
+	price.RatesAsOf = &converted.AsOf
+	return price, nil
+}
+
+// GetAvailability returns the stock a shopper can order. It reads from the
+// product's own inventory block, or from the inventory service when one is
+// configured and the warehouse migration has moved reads to it.
+func (s *ProductService) GetAvailability(ctx context.Context, productID string) (_ *domain.Availability, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAvailability", trace.WithAttributes(attribute.String("product.id", productID)))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Info("Getting availability", "productID", productID)
+
+	if s.inventory == nil {
+		return s.productAvailability(ctx, productID)
+	}
+	return dualwrite.Read(ctx, s.warehouses, productID,
+		func(ctx context.Context) (*domain.Availability, error) {
+			return s.productAvailability(ctx, productID)
+		},
+		func(ctx context.Context) (*domain.Availability, error) {
+			return s.inventory.GetAvailability(ctx, productID)
+		},
+		sameAvailability)
+}
+
+// productAvailability returns the stock in a product's own inventory block
+func (s *ProductService) productAvailability(ctx context.Context, productID string) (*domain.Availability, error) {
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to get product", "id", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	available := product.Inventory.Quantity - product.Inventory.Reserved
+	if available < 0 {
+		available = 0
+	}
+	return &domain.Availability{
+		ProductID: productID,
+		Available: available,
+		InStock:   available > 0,
+	}, nil
+}
+
+// sameAvailability compares availability across the migration's stores.
+// The product's own inventory has no warehouses, so only totals count.
+func sameAvailability(a, b *domain.Availability) bool {
+	return a.Available == b.Available && a.InStock == b.InStock
+}
+
 // CheckStock checks if a product has sufficient stock
-func (s *ProductService) CheckStock(productID string, quantity int) (bool, int, error) {
+func (s *ProductService) CheckStock(ctx context.Context, productID string, quantity int) (_ bool, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CheckStock", trace.WithAttributes(attribute.String("product.id", productID)))
+	defer func() { tracing.End(span, err) }()
+
 	s.logger.Info("Checking stock", "productID", productID, "quantity", quantity)
 
-	available, current, err := s.repo.CheckStock(productID, quantity)
+	available, current, err := s.repo.CheckStock(ctx, productID, quantity)
 	if err != nil {
 		s.logger.Error("Failed to check stock", "productID", productID, "error", err)
 		return false, 0, fmt.Errorf("repository error: %w", err)
@@ -228,21 +966,241 @@ func (s *ProductService) CheckStock(productID string, quantity int) (bool, int,
 	return available, current, nil
 }
 
+// ListInventoryOperations returns a page of the recorded inventory and
+// preorder operations of a product, newest first, and how many the
+// filter selects
+func (s *ProductService) ListInventoryOperations(ctx context.Context, filter domain.InventoryOperationFilter, page pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	s.logger.Info("Listing inventory operations", "productID", filter.ProductID, "types", filter.Types)
+
+	for _, operationType := range filter.Types {
+		if !inventoryOperationTypes[operationType] {
+			return nil, 0, apperrors.Newf(apperrors.Invalid, "invalid operation type %q", operationType)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, apperrors.New(apperrors.Invalid, "from must be before to")
+	}
+	// Operations outlive deleted products, but are only listed for
+	// products that exist
+	if _, err := s.repo.GetByID(ctx, filter.ProductID); err != nil {
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+
+	page = pagination.New(page.Page, page.PageSize, domain.InventoryOperationPagination)
+	operations, total, err := s.repo.ListOperations(ctx, filter, page)
+	if err != nil {
+		s.logger.Error("Failed to list inventory operations", "productID", filter.ProductID, "error", err)
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+	return operations, total, nil
+}
+
+// SetPurchaseLimits replaces the purchase limits of a product
+func (s *ProductService) SetPurchaseLimits(ctx context.Context, productID string, limits domain.PurchaseLimits) (*domain.Product, error) {
+	s.logger.Info("Setting purchase limits", "productID", productID,
+		"minOrderQuantity", limits.MinOrderQuantity, "maxPerCustomer", limits.MaxPerCustomer)
+
+	if err := validatePurchaseLimits(limits); err != nil {
+		return nil, invalid(err)
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	product.PurchaseLimits = limits
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to update purchase limits", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// StreamProducts passes every product in the catalog to fn, in ID order.
+// It is used by downstream consumers to rebuild their state from scratch.
+func (s *ProductService) StreamProducts(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error {
+	s.logger.Info("Streaming products", "includeInactive", includeInactive)
+
+	err := s.repo.Stream(ctx, includeInactive, func(product *domain.Product) error {
+		s.merchandise(product)
+		return fn(product)
+	})
+	if err != nil {
+		s.logger.Error("Failed to stream products", "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	return nil
+}
+
+// WatchInventory calls fn with each change to the inventory of the
+// products, or of all products if productIDs is empty, until ctx is done
+// or fn fails. With a positive threshold, only changes that leave less
+// than threshold units in stock are passed on.
+func (s *ProductService) WatchInventory(ctx context.Context, productIDs []string, threshold int, fn func(*domain.InventoryChange) error) error {
+	s.logger.Info("Watching inventory", "productIDs", productIDs, "threshold", threshold)
+
+	err := s.repo.WatchInventory(ctx, productIDs, func(change *domain.InventoryChange) error {
+		if threshold > 0 && change.Inventory.Quantity >= threshold {
+			return nil
+		}
+		return fn(change)
+	})
+	if err != nil {
+		s.logger.Error("Failed to watch inventory", "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	return nil
+}
+
 // Helper functions
 
+// publish emits an event for a completed change. Failures are logged but do
+// not fail the operation, since the write has already been committed.
+func (s *ProductService) publish(ctx context.Context, eventType, key string, payload interface{}) {
+	event, err := events.New(ctx, eventType, "product-service", key, payload)
+	if err != nil {
+		s.logger.Error("Failed to build event", "type", eventType, "error", err)
+		return
+	}
+
+	if err := s.publisher.Publish(context.Background(), event); err != nil {
+		s.logger.Error("Failed to publish event", "type", eventType, "key", key, "error", err)
+	}
+}
+
+// invalid marks err as a validation failure
+func invalid(err error) error {
+	return apperrors.Wrap(err, apperrors.Invalid, "validation error")
+}
+
 // validateProduct performs basic validation on product data
 func validateProduct(product *domain.Product) error {
 	if product.Name == "" {
 		return errors.New("product name is required")
 	}
-	if product.Price <= 0 {
+	if !(product.Price > 0) || math.IsInf(product.Price, 1) {
 		return errors.New("product price must be greater than zero")
 	}
+	if err := validateCompareAtPrice(product.CompareAtPrice); err != nil {
+		return err
+	}
 	if product.Category == "" {
 		return errors.New("product category is required")
 	}
 	if product.Inventory.SKU == "" {
 		return errors.New("product SKU is required")
 	}
+	// Quantities are int32 in the gRPC API
+	if product.Inventory.Quantity < 0 || product.Inventory.Quantity > math.MaxInt32 {
+		return errors.New("inventory quantity must be between 0 and 2147483647")
+	}
+	if product.Inventory.Reserved < 0 || product.Inventory.Reserved > product.Inventory.Quantity {
+		return errors.New("reserved inventory must be between 0 and the quantity")
+	}
+	if err := validatePurchaseLimits(product.PurchaseLimits); err != nil {
+		return err
+	}
+	return validateProductType(product)
+}
+
+// validateCompareAtPrice checks the compare-at price of a product, if any
+func validateCompareAtPrice(price *float64) error {
+	if price != nil && (!(*price > 0) || math.IsInf(*price, 1)) {
+		return errors.New("compare-at price must be greater than zero")
+	}
+	return nil
+}
+
+// validatePurchaseLimits checks purchase limits, which are int32 in the
+// gRPC API like quantities
+func validatePurchaseLimits(limits domain.PurchaseLimits) error {
+	if limits.MinOrderQuantity > math.MaxInt32 || limits.MaxPerCustomer > math.MaxInt32 {
+		return errors.New("purchase limits must be at most 2147483647")
+	}
+	return limits.Validate()
+}
+
+// normalizeShipping converts the weight and dimensions of a product to the
+// stored units and checks its shipping class. Digital products are not
+// shipped and have none of them.
+func normalizeShipping(product *domain.Product) error {
+	if product.IsDigital() {
+		if product.Weight != nil || product.Dimensions != nil || product.ShippingClass != "" {
+			return errors.New("digital products have no weight, dimensions or shipping class")
+		}
+		return nil
+	}
+	if product.Weight != nil {
+		weight, err := product.Weight.Normalize()
+		if err != nil {
+			return err
+		}
+		product.Weight = &weight
+	}
+	if product.Dimensions != nil {
+		dimensions, err := product.Dimensions.Normalize()
+		if err != nil {
+			return err
+		}
+		product.Dimensions = &dimensions
+	}
+	if product.ShippingClass != "" && !domain.ValidShippingClass(product.ShippingClass) {
+		return errors.New("shipping class must be lowercase letters, digits, dashes or underscores, at most 32 long")
+	}
+	return nil
+}
+
+// normalizeBarcode validates the barcode of a product, if any, and stores
+// it in normalized form
+func normalizeBarcode(product *domain.Product) error {
+	if product.Barcode == "" {
+		return nil
+	}
+	barcode, err := domain.NormalizeBarcode(product.Barcode)
+	if err != nil {
+		return err
+	}
+	product.Barcode = barcode
+	return nil
+}
+
+// validateProductType checks that digital products, and only they, have
+// downloadable assets
+func validateProductType(product *domain.Product) error {
+	switch product.Type {
+	case "", domain.ProductTypePhysical:
+		if product.Digital != nil {
+			return errors.New("only digital products have downloads")
+		}
+		return nil
+	case domain.ProductTypeDigital:
+	default:
+		return fmt.Errorf("product type must be %q or %q", domain.ProductTypePhysical, domain.ProductTypeDigital)
+	}
+
+	if product.Digital == nil || len(product.Digital.Assets) == 0 {
+		return errors.New("digital products need at least one asset")
+	}
+	if product.Digital.MaxDownloads < 0 {
+		return errors.New("max downloads must not be negative")
+	}
+	names := make(map[string]bool, len(product.Digital.Assets))
+	for _, asset := range product.Digital.Assets {
+		if asset.Name == "" || asset.Key == "" {
+			return errors.New("digital assets need a name and a key")
+		}
+		if names[asset.Name] {
+			return fmt.Errorf("duplicate digital asset %q", asset.Name)
+		}
+		names[asset.Name] = true
+	}
 	return nil
 }
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -23,22 +25,14 @@ func BenchmarkCreateProduct(b *testing.B) {
 	// Setup expectations for all iterations
 	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
 
+	productBuilder := builders.NewProduct(b).WithoutID()
+
 	// Run the benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		product := &domain.Product{
-			Name:        "Benchmark Product",
-			Description: "This is a product for benchmarking",
-			Price:       99.99,
-			Category:    "Benchmark",
-			Inventory: domain.InventoryInfo{
-				Quantity: 100,
-				SKU:      "BENCH-123",
-				InStock:  true,
-			},
-		}
-
-		_, _ = service.CreateProduct(product)
+		product := productBuilder.Build()
+
+		_, _ = service.CreateProduct(context.Background(), product)
 	}
 }
 
@@ -55,13 +49,7 @@ func BenchmarkListProducts(b *testing.B) {
 	// Create test products
 	products := make([]*domain.Product, 50)
 	for i := 0; i < 50; i++ {
-		products[i] = &domain.Product{
-			ID:          primitive.NewObjectID(),
-			Name:        "Product" + string(rune(i)),
-			Description: "Description",
-			Price:       float64(i * 10),
-			Category:    "Category",
-		}
+		products[i] = builders.NewProduct(b).WithCategory("Category").Build()
 	}
 
 	// Define list parameters
@@ -77,7 +65,7 @@ func BenchmarkListProducts(b *testing.B) {
 	// Run the benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _, _ = service.ListProducts(params)
+		_, _, _ = service.ListProducts(context.Background(), params)
 	}
 }
 
@@ -110,7 +98,7 @@ func BenchmarkUpdateInventory(b *testing.B) {
 	// Run the benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.UpdateInventory(productID, 10, operationID, operationType)
+		_, _ = service.UpdateInventory(context.Background(), productID, 10, operationID, operationType)
 	}
 }
 
@@ -133,6 +121,6 @@ func BenchmarkCheckStock(b *testing.B) {
 	// Run the benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _, _ = service.CheckStock(productID, 5)
+		_, _, _ = service.CheckStock(context.Background(), productID, 5)
 	}
 }
@@ -1,16 +1,29 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
 	"log/slog"
 	"os"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/dualwrite"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,12 +34,12 @@ type MockProductRepository struct {
 
 // Methods implementing the domain.ProductRepository interface
 
-func (m *MockProductRepository) Create(product *domain.Product) error {
+func (m *MockProductRepository) Create(ctx context.Context, product *domain.Product) error {
 	args := m.Called(product)
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) GetByID(id string) (*domain.Product, error) {
+func (m *MockProductRepository) GetByID(ctx context.Context, id string) (*domain.Product, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -34,17 +47,41 @@ func (m *MockProductRepository) GetByID(id string) (*domain.Product, error) {
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) Update(product *domain.Product) error {
+func (m *MockProductRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Product, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetByBarcode(ctx context.Context, barcode string) (*domain.Product, error) {
+	args := m.Called(barcode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Update(ctx context.Context, product *domain.Product) error {
 	args := m.Called(product)
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) Delete(id string) error {
+func (m *MockProductRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) List(params domain.ListProductsParams) ([]*domain.Product, int, error) {
+func (m *MockProductRepository) List(ctx context.Context, params domain.ListProductsParams) ([]*domain.Product, int, error) {
 	args := m.Called(params)
 	if args.Get(0) == nil {
 		return nil, args.Int(1), args.Error(2)
@@ -52,7 +89,7 @@ func (m *MockProductRepository) List(params domain.ListProductsParams) ([]*domai
 	return args.Get(0).([]*domain.Product), args.Int(1), args.Error(2)
 }
 
-func (m *MockProductRepository) UpdateInventory(productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
+func (m *MockProductRepository) UpdateInventory(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.InventoryInfo, error) {
 	args := m.Called(productID, quantityChange, operationID, operationType)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -60,32 +97,124 @@ func (m *MockProductRepository) UpdateInventory(productID string, quantityChange
 	return args.Get(0).(*domain.InventoryInfo), args.Error(1)
 }
 
-func (m *MockProductRepository) CheckStock(productID string, quantity int) (bool, int, error) {
+func (m *MockProductRepository) CheckStock(ctx context.Context, productID string, quantity int) (bool, int, error) {
 	args := m.Called(productID, quantity)
 	return args.Bool(0), args.Int(1), args.Error(2)
 }
 
-// Helper function to create a test product
-func createTestProduct() *domain.Product {
-	return &domain.Product{
-		ID:          primitive.NewObjectID(),
-		Name:        "Test Product",
-		Description: "This is a test product",
-		Price:       99.99,
-		ImageURLs:   []string{"http://example.com/image.jpg"},
-		Category:    "Electronics",
-		Inventory: domain.InventoryInfo{
-			Quantity: 100,
-			SKU:      "TEST-SKU-123",
-			InStock:  true,
-			Reserved: 0,
-		},
-		Tags:       []string{"test", "electronics"},
-		Attributes: map[string]string{"color": "black", "size": "medium"},
-		Active:     true,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+func (m *MockProductRepository) WatchInventory(ctx context.Context, productIDs []string, fn func(*domain.InventoryChange) error) error {
+	args := m.Called(productIDs)
+	for _, change := range args.Get(0).([]*domain.InventoryChange) {
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockProductRepository) HasOperation(ctx context.Context, operationID string) (bool, error) {
+	args := m.Called(operationID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductRepository) ImageInUse(ctx context.Context, url string) (bool, error) {
+	args := m.Called(url)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductRepository) ListOperations(ctx context.Context, filter domain.InventoryOperationFilter, page pagination.Request) ([]*domain.InventoryOperation, int, error) {
+	args := m.Called(filter, page)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.InventoryOperation), args.Int(1), args.Error(2)
+}
+
+func (m *MockProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	args := m.Called(prefix, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Suggestion), args.Error(1)
+}
+
+func (m *MockProductRepository) ListTags(ctx context.Context) ([]domain.TagCount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).([]domain.TagCount), args.Error(1)
+}
+
+func (m *MockProductRepository) RelatedProducts(ctx context.Context, product *domain.Product, limit int) ([]*domain.Product, error) {
+	args := m.Called(product.ID.Hex(), limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Stream(ctx context.Context, includeInactive bool, fn func(*domain.Product) error) error {
+	args := m.Called(ctx, includeInactive)
+	if products, ok := args.Get(0).([]*domain.Product); ok {
+		for _, p := range products {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockProductRepository) Export(ctx context.Context, params domain.ListProductsParams, fn func(*domain.Product) error) error {
+	args := m.Called(params)
+	if products, ok := args.Get(0).([]*domain.Product); ok {
+		for _, p := range products {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockProductRepository) SetFeatured(ctx context.Context, id string, featured bool, until *time.Time) (*domain.Product, error) {
+	args := m.Called(id, featured, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) UnfeatureExpired(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdatePreorders(ctx context.Context, productID string, quantityChange int, operationID, operationType string) (*domain.PreorderInfo, error) {
+	args := m.Called(productID, quantityChange, operationID, operationType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PreorderInfo), args.Error(1)
+}
+
+func (m *MockProductRepository) ReleaseDue(ctx context.Context, now time.Time) (*domain.PreorderRelease, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PreorderRelease), args.Error(1)
+}
+
+// recordingPublisher captures published events for assertions
+type recordingPublisher struct {
+	events []*eventbus.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *eventbus.Event) error {
+	p.events = append(p.events, event)
+	return nil
 }
 
 func TestCreateProduct(t *testing.T) {
@@ -99,13 +228,13 @@ func TestCreateProduct(t *testing.T) {
 	service := New(mockRepo, logger)
 
 	// Create test product
-	product := createTestProduct()
+	product := builders.NewProduct(t).Build()
 
 	// Setup expectations
 	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
 
 	// Call the service method
-	createdProduct, err := service.CreateProduct(product)
+	createdProduct, err := service.CreateProduct(context.Background(), product)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -137,58 +266,51 @@ func TestCreateProduct_ValidationError(t *testing.T) {
 		expectedErr string
 	}{
 		{
-			name: "Empty name",
-			product: &domain.Product{
-				Price:    99.99,
-				Category: "Electronics",
-				Inventory: domain.InventoryInfo{
-					SKU: "TEST-SKU-123",
-				},
-			},
+			name:        "Empty name",
+			product:     builders.NewProduct(t).WithName("").Build(),
 			expectedErr: "product name is required",
 		},
 		{
-			name: "Zero price",
-			product: &domain.Product{
-				Name:     "Test Product",
-				Price:    0,
-				Category: "Electronics",
-				Inventory: domain.InventoryInfo{
-					SKU: "TEST-SKU-123",
-				},
-			},
+			name:        "Zero price",
+			product:     builders.NewProduct(t).WithPrice(0).Build(),
 			expectedErr: "product price must be greater than zero",
 		},
 		{
-			name: "Empty category",
-			product: &domain.Product{
-				Name:  "Test Product",
-				Price: 99.99,
-				Inventory: domain.InventoryInfo{
-					SKU: "TEST-SKU-123",
-				},
-			},
+			name:        "Empty category",
+			product:     builders.NewProduct(t).WithCategory("").Build(),
 			expectedErr: "product category is required",
 		},
 		{
-			name: "Empty SKU",
-			product: &domain.Product{
-				Name:     "Test Product",
-				Price:    99.99,
-				Category: "Electronics",
-			},
+			name:        "Empty SKU",
+			product:     builders.NewProduct(t).WithSKU("").Build(),
 			expectedErr: "product SKU is required",
 		},
+		{
+			name:        "NaN price",
+			product:     builders.NewProduct(t).WithPrice(math.NaN()).Build(),
+			expectedErr: "product price must be greater than zero",
+		},
+		{
+			name:        "Quantity beyond int32",
+			product:     builders.NewProduct(t).WithStock(math.MaxInt32 + 1).Build(),
+			expectedErr: "inventory quantity must be between 0 and 2147483647",
+		},
+		{
+			name:        "Reserved beyond quantity",
+			product:     builders.NewProduct(t).WithStock(1).WithReserved(2).Build(),
+			expectedErr: "reserved inventory must be between 0 and the quantity",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the service method
-			_, err := service.CreateProduct(tc.product)
+			_, err := service.CreateProduct(context.Background(), tc.product)
 
 			// Assert error
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tc.expectedErr)
+			assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
 		})
 	}
 }
@@ -204,14 +326,14 @@ func TestGetProduct(t *testing.T) {
 	service := New(mockRepo, logger)
 
 	// Create test product
-	product := createTestProduct()
+	product := builders.NewProduct(t).Build()
 	productID := product.ID.Hex()
 
 	// Setup expectations
 	mockRepo.On("GetByID", productID).Return(product, nil)
 
 	// Call the service method
-	fetchedProduct, err := service.GetProduct(productID)
+	fetchedProduct, err := service.GetProduct(context.Background(), productID)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -237,7 +359,7 @@ func TestGetProduct_NotFound(t *testing.T) {
 	mockRepo.On("GetByID", "non-existent-id").Return(nil, errors.New("product not found"))
 
 	// Call the service method
-	fetchedProduct, err := service.GetProduct("non-existent-id")
+	fetchedProduct, err := service.GetProduct(context.Background(), "non-existent-id")
 
 	// Assert expectations
 	assert.Error(t, err)
@@ -248,6 +370,36 @@ func TestGetProduct_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetProductsByIDs(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	mug := builders.NewProduct(t).WithName("Mug").Build()
+	plate := builders.NewProduct(t).WithName("Plate").Build()
+	missing := primitive.NewObjectID().Hex()
+	upper := strings.ToUpper(mug.ID.Hex())
+	ids := []string{plate.ID.Hex(), missing, upper, plate.ID.Hex(), "not-an-id"}
+	// Duplicates are asked for once; the repository returns in its own order
+	mockRepo.On("GetByIDs", []string{plate.ID.Hex(), missing, upper, "not-an-id"}).Return([]*domain.Product{mug, plate}, nil)
+
+	products, notFound, err := service.GetProductsByIDs(context.Background(), ids)
+	require.NoError(t, err)
+	if assert.Len(t, products, 2) {
+		assert.Equal(t, "Plate", products[0].Name)
+		assert.Equal(t, "Mug", products[1].Name)
+	}
+	assert.Equal(t, []string{missing, "not-an-id"}, notFound)
+
+	tooMany := make([]string, domain.MaxProductBatch+1)
+	for i := range tooMany {
+		tooMany[i] = primitive.NewObjectID().Hex()
+	}
+	_, _, err = service.GetProductsByIDs(context.Background(), tooMany)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "too many IDs: %v", err)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUpdateProduct(t *testing.T) {
 	// Create mock repository
 	mockRepo := new(MockProductRepository)
@@ -259,7 +411,8 @@ func TestUpdateProduct(t *testing.T) {
 	service := New(mockRepo, logger)
 
 	// Create test product
-	existingProduct := createTestProduct()
+	productBuilder := builders.NewProduct(t)
+	existingProduct := productBuilder.Build()
 	productID := existingProduct.ID.Hex()
 
 	// Create update product
@@ -271,7 +424,7 @@ func TestUpdateProduct(t *testing.T) {
 	}
 
 	// Expected updated product
-	expectedProduct := createTestProduct()
+	expectedProduct := productBuilder.Build()
 	expectedProduct.Name = updateProduct.Name
 	expectedProduct.Description = updateProduct.Description
 	expectedProduct.Price = updateProduct.Price
@@ -282,7 +435,7 @@ func TestUpdateProduct(t *testing.T) {
 	mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
 
 	// Call the service method
-	updatedProduct, err := service.UpdateProduct(updateProduct)
+	updatedProduct, err := service.UpdateProduct(context.Background(), updateProduct)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -300,6 +453,106 @@ func TestUpdateProduct(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestPatchProduct(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	price := 30.0
+
+	t.Run("clears and sets fields", func(t *testing.T) {
+		mockRepo := new(MockProductRepository)
+		service := New(mockRepo, logger)
+		existing := builders.NewProduct(t).WithPrice(25).Build()
+		existing.CompareAtPrice = &price
+		existing.Attributes = map[string]string{"material": "stoneware", "color": "red"}
+		mockRepo.On("GetByID", existing.ID.Hex()).Return(existing, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+		patch, err := domain.ParseProductPatch([]byte(`{"description":"","compare_at_price":null,"active":false,"attributes":{"color":null,"size":"L"},"weight":{"value":250,"unit":"g"}}`))
+		require.NoError(t, err)
+		product, err := service.PatchProduct(context.Background(), existing.ID.Hex(), patch)
+		require.NoError(t, err)
+
+		assert.Empty(t, product.Description)
+		assert.Nil(t, product.CompareAtPrice)
+		assert.False(t, product.Active)
+		assert.Equal(t, map[string]string{"material": "stoneware", "size": "L"}, product.Attributes)
+		assert.Equal(t, &domain.Weight{Value: 0.25, Unit: domain.WeightUnit}, product.Weight)
+		assert.Equal(t, 25.0, product.Price, "fields left out are kept")
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name       string
+		patch      string
+		weight     *domain.Weight
+		dimensions *domain.Dimensions
+	}{
+		{"weight value only", `{"weight":{"value":0.7}}`,
+			&domain.Weight{Value: 0.7, Unit: "kg"}, &domain.Dimensions{Length: 25, Width: 18, Height: 22, Unit: "cm"}},
+		{"dimension height only", `{"dimensions":{"height":50}}`,
+			&domain.Weight{Value: 1.2, Unit: "kg"}, &domain.Dimensions{Length: 25, Width: 18, Height: 50, Unit: "cm"}},
+		{"both merged", `{"dimensions":{"height":50},"weight":{"value":700,"unit":"g"}}`,
+			&domain.Weight{Value: 0.7, Unit: "kg"}, &domain.Dimensions{Length: 25, Width: 18, Height: 50, Unit: "cm"}},
+		{"weight cleared", `{"weight":null}`,
+			nil, &domain.Dimensions{Length: 25, Width: 18, Height: 22, Unit: "cm"}},
+	} {
+		t.Run("merges "+tt.name, func(t *testing.T) {
+			mockRepo := new(MockProductRepository)
+			service := New(mockRepo, logger)
+			existing := builders.NewProduct(t).Build()
+			existing.Weight = &domain.Weight{Value: 1.2, Unit: "kg"}
+			existing.Dimensions = &domain.Dimensions{Length: 25, Width: 18, Height: 22, Unit: "cm"}
+			mockRepo.On("GetByID", existing.ID.Hex()).Return(existing, nil)
+			mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+			patch, err := domain.ParseProductPatch([]byte(tt.patch))
+			require.NoError(t, err)
+			product, err := service.PatchProduct(context.Background(), existing.ID.Hex(), patch)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.weight, product.Weight)
+			assert.Equal(t, tt.dimensions, product.Dimensions)
+		})
+	}
+
+	for _, tt := range []struct {
+		name  string
+		patch string
+	}{
+		{"null name", `{"name":null}`},
+		{"null price", `{"price":null}`},
+		{"zero price", `{"price":0}`},
+		{"empty category", `{"category":""}`},
+		{"null SKU", `{"inventory":{"sku":null}}`},
+		{"downloads on a physical product", `{"digital":{"max_downloads":3}}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockProductRepository)
+			service := New(mockRepo, logger)
+			existing := builders.NewProduct(t).Build()
+			mockRepo.On("GetByID", existing.ID.Hex()).Return(existing, nil)
+
+			patch, err := domain.ParseProductPatch([]byte(tt.patch))
+			require.NoError(t, err)
+			_, err = service.PatchProduct(context.Background(), existing.ID.Hex(), patch)
+			assert.True(t, apperrors.Is(err, apperrors.Invalid), "got %v", err)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		})
+	}
+}
+
+func TestParseProductPatch(t *testing.T) {
+	patch, err := domain.ParseProductPatch([]byte(`{"description":null,"name":"Cup"}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.Null[string](), patch.Description)
+	assert.Equal(t, domain.Some("Cup"), patch.Name)
+	assert.False(t, patch.Price.Set)
+
+	for _, body := range []string{`[]`, `"Cup"`, `{"id":"65f1c0d2e4b0a1b2c3d4e5f1"}`, `{"inventory":{"quantity":5}}`, `{"name":"Cup"} {}`} {
+		_, err := domain.ParseProductPatch([]byte(body))
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), body)
+	}
+}
+
 func TestDeleteProduct(t *testing.T) {
 	// Create mock repository
 	mockRepo := new(MockProductRepository)
@@ -315,7 +568,7 @@ func TestDeleteProduct(t *testing.T) {
 	mockRepo.On("Delete", productID).Return(nil)
 
 	// Call the service method
-	err := service.DeleteProduct(productID)
+	err := service.DeleteProduct(context.Background(), productID)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -335,9 +588,8 @@ func TestListProducts(t *testing.T) {
 	service := New(mockRepo, logger)
 
 	// Create test products
-	product1 := createTestProduct()
-	product2 := createTestProduct()
-	product2.Name = "Another Product"
+	product1 := builders.NewProduct(t).Build()
+	product2 := builders.NewProduct(t).WithName("Another Product").Build()
 
 	products := []*domain.Product{product1, product2}
 	totalCount := 2
@@ -353,7 +605,7 @@ func TestListProducts(t *testing.T) {
 	mockRepo.On("List", params).Return(products, totalCount, nil)
 
 	// Call the service method
-	listedProducts, total, err := service.ListProducts(params)
+	listedProducts, total, err := service.ListProducts(context.Background(), params)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -395,7 +647,7 @@ func TestUpdateInventory(t *testing.T) {
 		Return(updatedInventory, nil)
 
 	// Call the service method
-	inventory, err := service.UpdateInventory(productID, quantityChange, operationID, operationType)
+	inventory, err := service.UpdateInventory(context.Background(), productID, quantityChange, operationID, operationType)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -412,6 +664,7 @@ func TestUpdateInventory(t *testing.T) {
 	quantityChange = -5
 
 	// First check stock
+	mockRepo.On("GetByID", productID).Return(builders.NewProduct(t).Build(), nil)
 	mockRepo.On("CheckStock", productID, 5).Return(true, 110, nil)
 
 	// Then update inventory
@@ -419,7 +672,7 @@ func TestUpdateInventory(t *testing.T) {
 		Return(updatedInventory, nil)
 
 	// Call the service method
-	inventory, err = service.UpdateInventory(productID, quantityChange, operationID, operationType)
+	inventory, err = service.UpdateInventory(context.Background(), productID, quantityChange, operationID, operationType)
 
 	// Assert expectations
 	assert.NoError(t, err)
@@ -446,15 +699,17 @@ func TestUpdateInventory_InsufficientStock(t *testing.T) {
 	operationType := "purchase"
 
 	// Setup expectations - not enough stock
+	mockRepo.On("GetByID", productID).Return(builders.NewProduct(t).Build(), nil)
 	mockRepo.On("CheckStock", productID, 20).Return(false, 10, nil)
 
 	// Call the service method
-	inventory, err := service.UpdateInventory(productID, quantityChange, operationID, operationType)
+	inventory, err := service.UpdateInventory(context.Background(), productID, quantityChange, operationID, operationType)
 
 	// Assert expectations
 	assert.Error(t, err)
 	assert.Nil(t, inventory)
 	assert.Contains(t, err.Error(), "insufficient stock")
+	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
 
 	// Verify that mock expectations were met
 	mockRepo.AssertExpectations(t)
@@ -523,7 +778,7 @@ func TestCheckStock(t *testing.T) {
 				Return(tc.mockAvailable, tc.mockStock, tc.mockErr).Once()
 
 			// Call the service method
-			available, stock, err := service.CheckStock(productID, tc.quantity)
+			available, stock, err := service.CheckStock(context.Background(), productID, tc.quantity)
 
 			// Assert expectations
 			if tc.expectErr {
@@ -539,3 +794,445 @@ func TestCheckStock(t *testing.T) {
 	// Verify that all mock expectations were met
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListInventoryOperations(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	missing := primitive.NewObjectID().Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("GetByID", missing).Return(nil, domain.ErrProductNotFound)
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	filter := domain.InventoryOperationFilter{ProductID: productID, Types: []string{"purchase", "restock"}, From: &from, To: &to}
+	operations := []*domain.InventoryOperation{
+		{ProductID: productID, QuantityChange: -2, OperationID: "op-2", OperationType: "purchase", Timestamp: from.Add(time.Hour)},
+		{ProductID: productID, QuantityChange: 10, OperationID: "op-1", OperationType: "restock", Timestamp: from},
+	}
+	// Oversized pages are clamped before they reach the repository
+	mockRepo.On("ListOperations", filter, pagination.New(0, 100, domain.InventoryOperationPagination)).Return(operations, 2, nil)
+
+	got, total, err := service.ListInventoryOperations(context.Background(), filter, pagination.Request{Page: 0, PageSize: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, operations, got)
+	assert.Equal(t, 2, total)
+
+	_, _, err = service.ListInventoryOperations(context.Background(), domain.InventoryOperationFilter{ProductID: missing}, pagination.Request{})
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+
+	invalid := map[string]domain.InventoryOperationFilter{
+		"unknown type":       {ProductID: productID, Types: []string{"theft"}},
+		"from not before to": {ProductID: productID, From: &to, To: &from},
+	}
+	for name, filter := range invalid {
+		_, _, err := service.ListInventoryOperations(context.Background(), filter, pagination.Request{})
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "%s: %v", name, err)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductEventsArePublished(t *testing.T) {
+	// Create mock repository
+	mockRepo := new(MockProductRepository)
+
+	// Create logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Create service with a publisher that records events
+	service := New(mockRepo, logger)
+	publisher := &recordingPublisher{}
+	service.SetPublisher(publisher)
+
+	// Setup expectations
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("CheckStock", productID, 5).Return(true, 100, nil)
+	mockRepo.On("UpdateInventory", productID, -5, "op-1", "purchase").
+		Return(&domain.InventoryInfo{Quantity: 95, SKU: "TEST-SKU-123", InStock: true}, nil)
+	mockRepo.On("Delete", productID).Return(nil)
+
+	// Call the service methods
+	_, err := service.CreateProduct(context.Background(), product)
+	assert.NoError(t, err)
+	_, err = service.UpdateInventory(context.Background(), productID, -5, "op-1", "purchase")
+	assert.NoError(t, err)
+	assert.NoError(t, service.DeleteProduct(context.Background(), productID))
+
+	// Assert events were published in order with their payloads
+	received := publisher.events
+	if assert.Len(t, received, 3) {
+		assert.Equal(t, events.ProductCreated, received[0].Type)
+		assert.Equal(t, productID, received[0].Key)
+
+		var inventory events.InventoryChangedPayload
+		assert.NoError(t, received[1].Decode(&inventory))
+		assert.Equal(t, events.InventoryChanged, received[1].Type)
+		assert.Equal(t, 95, inventory.Inventory.Quantity)
+		assert.Equal(t, "purchase", inventory.OperationType)
+
+		assert.Equal(t, events.ProductDeleted, received[2].Type)
+	}
+
+	// Verify that mock expectations were met
+	mockRepo.AssertExpectations(t)
+}
+
+// stubInventoryClient returns a fixed availability or error
+type stubInventoryClient struct {
+	availability *domain.Availability
+	err          error
+}
+
+func (c *stubInventoryClient) GetAvailability(ctx context.Context, productID string) (*domain.Availability, error) {
+	return c.availability, c.err
+}
+
+func TestGetAvailability(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+
+	t.Run("from inventory service", func(t *testing.T) {
+		mockRepo := new(MockProductRepository)
+		service := New(mockRepo, logger)
+		service.SetInventoryClient(&stubInventoryClient{availability: &domain.Availability{
+			ProductID: productID,
+			Available: 42,
+			InStock:   true,
+		}}, nil)
+
+		availability, err := service.GetAvailability(context.Background(), productID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, availability.Available)
+		mockRepo.AssertNotCalled(t, "GetByID", productID)
+	})
+
+	t.Run("falls back to product inventory", func(t *testing.T) {
+		mockRepo := new(MockProductRepository)
+		service := New(mockRepo, logger)
+		service.SetInventoryClient(&stubInventoryClient{err: errors.New("connection refused")}, nil)
+
+		mockRepo.On("GetByID", productID).Return(product, nil)
+
+		availability, err := service.GetAvailability(context.Background(), productID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, product.Inventory.Quantity-product.Inventory.Reserved, availability.Available)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("shadow reads compare the inventory service", func(t *testing.T) {
+		mockRepo := new(MockProductRepository)
+		service := New(mockRepo, logger)
+		flags := dualwrite.NewFlags(map[string]dualwrite.Mode{WarehouseMigration: dualwrite.ModeShadow})
+		registry := prometheus.NewRegistry()
+		metrics := dualwrite.NewMetrics(registry, "test")
+		service.SetInventoryClient(&stubInventoryClient{availability: &domain.Availability{
+			ProductID: productID,
+			Available: 42,
+			InStock:   true,
+		}}, dualwrite.New(WarehouseMigration, flags, dualwrite.Options{Metrics: metrics}, logger))
+
+		mockRepo.On("GetByID", productID).Return(product, nil)
+
+		availability, err := service.GetAvailability(context.Background(), productID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, product.Inventory.Quantity-product.Inventory.Reserved, availability.Available)
+		// The stores disagree until stock is moved to the warehouses
+		assert.Eventually(t, func() bool {
+			return testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_dualwrite_comparisons_total Shadow reads compared by migrations by result: match, mismatch, error or skipped.
+# TYPE test_dualwrite_comparisons_total counter
+test_dualwrite_comparisons_total{migration="warehouse-inventory",result="mismatch"} 1
+`), "test_dualwrite_comparisons_total") == nil
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestWatchInventory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockRepo := new(MockProductRepository)
+	service := New(mockRepo, logger)
+	mockRepo.On("WatchInventory", []string{"p1", "p2"}).Return([]*domain.InventoryChange{
+		{ProductID: "p1", Inventory: domain.InventoryInfo{Quantity: 12}},
+		{ProductID: "p2", Inventory: domain.InventoryInfo{Quantity: 4}},
+		{ProductID: "p1", Inventory: domain.InventoryInfo{Quantity: 9}},
+	}, nil)
+
+	// Only changes leaving less than the threshold are passed on
+	var low []string
+	err := service.WatchInventory(context.Background(), []string{"p1", "p2"}, 10, func(change *domain.InventoryChange) error {
+		low = append(low, change.ProductID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p2", "p1"}, low)
+
+	// A failing receiver ends the watch
+	err = service.WatchInventory(context.Background(), []string{"p1", "p2"}, 0, func(*domain.InventoryChange) error {
+		return errors.New("stream closed")
+	})
+	assert.ErrorContains(t, err, "stream closed")
+}
+
+// stubConverter converts at a fixed rate of 0.5
+type stubConverter struct {
+	from, to string
+}
+
+func (c *stubConverter) Convert(ctx context.Context, amount int64, from, to string) (*domain.ConvertedAmount, error) {
+	c.from, c.to = from, to
+	return &domain.ConvertedAmount{Amount: amount / 2, Rate: "0.5", AsOf: time.Now()}, nil
+}
+
+func TestGetPrice(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	product := builders.NewProduct(t).Build()
+	product.Price = 19.99
+	productID := product.ID.Hex()
+
+	mockRepo := new(MockProductRepository)
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	service := New(mockRepo, logger)
+
+	// Without a converter only the catalog currency is available
+	price, err := service.GetPrice(context.Background(), productID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", price.Currency)
+	assert.Equal(t, 19.99, price.Amount)
+
+	_, err = service.GetPrice(context.Background(), productID, "EUR")
+	assert.Error(t, err)
+
+	converter := &stubConverter{}
+	service.SetCurrencyConverter(converter, "usd")
+
+	price, err = service.GetPrice(context.Background(), productID, "eur")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", converter.from)
+	assert.Equal(t, "EUR", converter.to)
+	assert.Equal(t, "EUR", price.Currency)
+	assert.Equal(t, 9.99, price.Amount) // 1999 / 2 = 999 cents
+	assert.Equal(t, 19.99, price.BaseAmount)
+	assert.Equal(t, "0.5", price.ExchangeRate)
+}
+
+func TestListProductsClampsPage(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	// Oversized pages are clamped and the offset derived from the page
+	expected := domain.ListProductsParams{Page: 2, PageSize: 100, Offset: 200}
+	mockRepo.On("List", expected).Return([]*domain.Product{}, 0, nil)
+
+	_, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{Page: 2, PageSize: 1000})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListProductsByAttributes(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	filters := map[string]string{"color": "black", "size": "M"}
+	expected := domain.ListProductsParams{PageSize: 20, AttributeFilters: filters}
+	mockRepo.On("List", expected).Return([]*domain.Product{}, 0, nil)
+
+	_, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{AttributeFilters: filters})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	tooMany := map[string]string{}
+	for i := 0; i <= domain.MaxAttributeFilters; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	for name, filters := range map[string]map[string]string{
+		"dotted name":   {"size.eu": "40"},
+		"operator name": {"$where": "true"},
+		"empty value":   {"color": ""},
+		"too many":      tooMany,
+	} {
+		_, _, err := service.ListProducts(context.Background(), domain.ListProductsParams{AttributeFilters: filters})
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "%s: %v", name, err)
+	}
+}
+
+func TestCreateProductNormalizesShipping(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	product := builders.NewProduct(t).Build()
+	product.Weight = &domain.Weight{Value: 12, Unit: "oz"}
+	product.Dimensions = &domain.Dimensions{Length: 10, Width: 4, Height: 0.5, Unit: "in"}
+	product.ShippingClass = "standard"
+	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	created, err := service.CreateProduct(context.Background(), product)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Weight{Value: 0.34, Unit: "kg"}, created.Weight)
+	assert.Equal(t, &domain.Dimensions{Length: 25.4, Width: 10.16, Height: 1.27, Unit: "cm"}, created.Dimensions)
+}
+
+func TestCreateProduct_ShippingValidationError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	testCases := []struct {
+		name        string
+		modify      func(*domain.Product)
+		expectedErr string
+	}{
+		{
+			name:        "Negative weight",
+			modify:      func(p *domain.Product) { p.Weight = &domain.Weight{Value: -1} },
+			expectedErr: "weight must be a non-negative number",
+		},
+		{
+			name:        "Unknown weight unit",
+			modify:      func(p *domain.Product) { p.Weight = &domain.Weight{Value: 1, Unit: "stone"} },
+			expectedErr: `unknown weight unit "stone"`,
+		},
+		{
+			name:        "NaN dimension",
+			modify:      func(p *domain.Product) { p.Dimensions = &domain.Dimensions{Length: math.NaN()} },
+			expectedErr: "dimensions must be non-negative numbers",
+		},
+		{
+			name:        "Invalid shipping class",
+			modify:      func(p *domain.Product) { p.ShippingClass = "Over Sized" },
+			expectedErr: "shipping class must be lowercase",
+		},
+		{
+			name: "Digital product with weight",
+			modify: func(p *domain.Product) {
+				p.Type = domain.ProductTypeDigital
+				p.Digital = &domain.DigitalInfo{Assets: []domain.DigitalAsset{{Name: "book.pdf", Key: "books/go.pdf"}}}
+				p.Weight = &domain.Weight{Value: 1}
+			},
+			expectedErr: "digital products have no weight",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			product := builders.NewProduct(t).Build()
+			tc.modify(product)
+
+			_, err := service.CreateProduct(context.Background(), product)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedErr)
+			assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+		})
+	}
+}
+
+func TestNormalizeBarcode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		barcode  string
+		expected string
+		valid    bool
+	}{
+		{"EAN-13", "4006381333931", "4006381333931", true},
+		{"EAN-13 with spaces", "4 006381 333931", "4006381333931", true},
+		{"UPC-A as EAN-13", "036000291452", "0036000291452", true},
+		{"GTIN-14 of an EAN-13", "00036000291452", "0036000291452", true},
+		{"GTIN-14 of a case", "10036000291459", "10036000291459", true},
+		{"EAN-8", "96385074", "96385074", true},
+		{"Wrong check digit", "4006381333932", "", false},
+		{"Letters", "40063813339A1", "", false},
+		{"Wrong length", "123456789", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			barcode, err := domain.NormalizeBarcode(tc.barcode)
+
+			assert.Equal(t, tc.valid, err == nil, "got %v", err)
+			assert.Equal(t, tc.expected, barcode)
+		})
+	}
+}
+
+func TestGetProductByBarcode(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	product := builders.NewProduct(t).Build()
+	product.Barcode = "0036000291452"
+	mockRepo.On("GetByBarcode", "0036000291452").Return(product, nil)
+
+	// Scanners may read the UPC-A form of the stored EAN-13
+	found, err := service.GetProductByBarcode(context.Background(), "036000291452")
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, found.ID)
+
+	_, err = service.GetProductByBarcode(context.Background(), "036000291453")
+	assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurchaseLimits(t *testing.T) {
+	testCases := []struct {
+		name     string
+		limits   domain.PurchaseLimits
+		quantity int
+		valid    bool
+		allowed  bool
+	}{
+		{"No limits", domain.PurchaseLimits{}, 1, true, true},
+		{"At the minimum", domain.PurchaseLimits{MinOrderQuantity: 6}, 6, true, true},
+		{"Below the minimum", domain.PurchaseLimits{MinOrderQuantity: 6}, 5, true, false},
+		{"Cap is not enforced here", domain.PurchaseLimits{MaxPerCustomer: 2}, 3, true, true},
+		{"Negative minimum", domain.PurchaseLimits{MinOrderQuantity: -1}, 1, false, true},
+		{"Minimum above cap", domain.PurchaseLimits{MinOrderQuantity: 6, MaxPerCustomer: 4}, 6, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.valid, tc.limits.Validate() == nil)
+
+			err := tc.limits.CheckQuantity(tc.quantity)
+			assert.Equal(t, tc.allowed, err == nil)
+			if err != nil {
+				assert.Equal(t, domain.ReasonBelowMinOrderQuantity, apperrors.ReasonOf(err))
+			}
+		})
+	}
+}
+
+func TestSetPurchaseLimits(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := New(mockRepo, logger)
+
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	updated, err := service.SetPurchaseLimits(context.Background(), productID, domain.PurchaseLimits{MinOrderQuantity: 6, MaxPerCustomer: 24})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, updated.MinOrderQuantity)
+	assert.Equal(t, 24, updated.MaxPerCustomer)
+
+	_, err = service.SetPurchaseLimits(context.Background(), productID, domain.PurchaseLimits{MinOrderQuantity: 6, MaxPerCustomer: 4})
+	assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+	mockRepo.AssertExpectations(t)
+}
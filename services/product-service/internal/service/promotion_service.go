@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetPromotionRepository configures where promotions are stored. Until it
+// is set, or the first RefreshPromotions, products are not discounted.
+func (s *ProductService) SetPromotionRepository(promotions domain.PromotionRepository) {
+	s.promotionRepo = promotions
+}
+
+// RefreshPromotions reloads the live promotions products are discounted
+// by. Category scopes are widened to the categories below them as the
+// tree stands now. Each instance keeps its own copy, so changes made
+// through another instance show once it refreshes, or straight away with
+// a cache invalidator.
+func (s *ProductService) RefreshPromotions(ctx context.Context) error {
+	if err := s.requirePromotions(); err != nil {
+		return err
+	}
+	promotions, err := s.promotionRepo.Live(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	if s.categories != nil {
+		expanded := make(map[string][]string)
+		for _, promotion := range promotions {
+			var categories []string
+			for _, key := range promotion.Scope.Categories {
+				if _, ok := expanded[key]; !ok {
+					if expanded[key], err = s.categoryAndDescendants(ctx, key); err != nil {
+						return err
+					}
+				}
+				categories = append(categories, expanded[key]...)
+			}
+			promotion.Scope.Categories = categories
+		}
+	}
+
+	s.promotionsMu.Lock()
+	s.promotions = promotions
+	s.promotionsMu.Unlock()
+	return nil
+}
+
+// CreatePromotion creates a promotion. Promotions without a start run
+// from their creation.
+func (s *ProductService) CreatePromotion(ctx context.Context, promotion *domain.Promotion) (*domain.Promotion, error) {
+	s.logger.Info("Creating promotion", "name", promotion.Name)
+
+	if err := s.requirePromotions(); err != nil {
+		return nil, err
+	}
+	promotion.CreatedAt = time.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+	if promotion.StartsAt.IsZero() {
+		promotion.StartsAt = promotion.CreatedAt
+	}
+	if err := promotion.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	if err := s.promotionRepo.Create(ctx, promotion); err != nil {
+		s.logger.Error("Failed to create promotion", "name", promotion.Name, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshPromotionsAfterWrite(ctx)
+	return promotion, nil
+}
+
+// GetPromotion retrieves a promotion by its ID
+func (s *ProductService) GetPromotion(ctx context.Context, id string) (*domain.Promotion, error) {
+	if err := s.requirePromotions(); err != nil {
+		return nil, err
+	}
+	promotion, err := s.promotionRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return promotion, nil
+}
+
+// ListPromotions returns a page of all promotions, running or not, latest
+// start first, and how many there are
+func (s *ProductService) ListPromotions(ctx context.Context, page pagination.Request) ([]*domain.Promotion, int, error) {
+	if err := s.requirePromotions(); err != nil {
+		return nil, 0, err
+	}
+	page = pagination.New(page.Page, page.PageSize, domain.PromotionPagination)
+	promotions, total, err := s.promotionRepo.List(ctx, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+	return promotions, total, nil
+}
+
+// UpdatePromotion replaces a promotion. Promotions without a start keep
+// the one they had.
+func (s *ProductService) UpdatePromotion(ctx context.Context, id string, promotion *domain.Promotion) (*domain.Promotion, error) {
+	s.logger.Info("Updating promotion", "id", id)
+
+	if err := s.requirePromotions(); err != nil {
+		return nil, err
+	}
+	existing, err := s.promotionRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	promotion.ID = existing.ID
+	if promotion.StartsAt.IsZero() {
+		promotion.StartsAt = existing.StartsAt
+	}
+	if err := promotion.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	promotion.CreatedAt = existing.CreatedAt
+	promotion.UpdatedAt = time.Now()
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		s.logger.Error("Failed to update promotion", "id", id, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshPromotionsAfterWrite(ctx)
+	return promotion, nil
+}
+
+// DeletePromotion removes a promotion, ending its discount
+func (s *ProductService) DeletePromotion(ctx context.Context, id string) error {
+	s.logger.Info("Deleting promotion", "id", id)
+
+	if err := s.requirePromotions(); err != nil {
+		return err
+	}
+	if err := s.promotionRepo.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete promotion", "id", id, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+	s.refreshPromotionsAfterWrite(ctx)
+	return nil
+}
+
+// livePromotions returns the promotions products are discounted by
+func (s *ProductService) livePromotions() []*domain.Promotion {
+	s.promotionsMu.RLock()
+	defer s.promotionsMu.RUnlock()
+	return s.promotions
+}
+
+// refreshPromotionsAfterWrite shows a promotion change on this instance
+// straight away, and tells the other instances to reload theirs. Failures
+// are logged; the periodic refresh catches up.
+func (s *ProductService) refreshPromotionsAfterWrite(ctx context.Context) {
+	if err := s.RefreshPromotions(ctx); err != nil {
+		s.logger.Error("Failed to refresh promotions", "error", err)
+	}
+	s.invalidate(ctx, CachePromotions)
+}
+
+func (s *ProductService) requirePromotions() error {
+	if s.promotionRepo == nil {
+		return apperrors.New(apperrors.Unavailable, "promotions not available")
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryPromotionRepository keeps promotions in memory
+type memoryPromotionRepository struct {
+	promotions map[primitive.ObjectID]domain.Promotion
+}
+
+func (r *memoryPromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	promotion.ID = primitive.NewObjectID()
+	r.promotions[promotion.ID] = *promotion
+	return nil
+}
+
+func (r *memoryPromotionRepository) Get(ctx context.Context, id string) (*domain.Promotion, error) {
+	objID, _ := primitive.ObjectIDFromHex(id)
+	promotion, ok := r.promotions[objID]
+	if !ok {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return &promotion, nil
+}
+
+func (r *memoryPromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	if _, ok := r.promotions[promotion.ID]; !ok {
+		return domain.ErrPromotionNotFound
+	}
+	r.promotions[promotion.ID] = *promotion
+	return nil
+}
+
+func (r *memoryPromotionRepository) Delete(ctx context.Context, id string) error {
+	objID, _ := primitive.ObjectIDFromHex(id)
+	if _, ok := r.promotions[objID]; !ok {
+		return domain.ErrPromotionNotFound
+	}
+	delete(r.promotions, objID)
+	return nil
+}
+
+func (r *memoryPromotionRepository) List(ctx context.Context, page pagination.Request) ([]*domain.Promotion, int, error) {
+	promotions, _ := r.Live(ctx, time.Time{})
+	return promotions, len(promotions), nil
+}
+
+func (r *memoryPromotionRepository) Live(ctx context.Context, t time.Time) ([]*domain.Promotion, error) {
+	var live []*domain.Promotion
+	for _, promotion := range r.promotions {
+		promotion := promotion
+		if t.IsZero() || (promotion.Active && (promotion.EndsAt == nil || promotion.EndsAt.After(t))) {
+			live = append(live, &promotion)
+		}
+	}
+	return live, nil
+}
+
+func newPromotionTestService() (*ProductService, *MockProductRepository, *memoryPromotionRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	products := new(MockProductRepository)
+	promotions := &memoryPromotionRepository{promotions: make(map[primitive.ObjectID]domain.Promotion)}
+	service := New(products, logger)
+	service.SetPromotionRepository(promotions)
+	return service, products, promotions
+}
+
+func TestCreatePromotionDiscountsProducts(t *testing.T) {
+	service, products, _ := newPromotionTestService()
+	product := builders.NewProduct(t).WithPrice(40).WithCategory("kitchen").Build()
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+
+	promotion, err := service.CreatePromotion(context.Background(), &domain.Promotion{
+		Name:   " Spring Sale ",
+		Type:   domain.PromotionPercentage,
+		Value:  15,
+		Scope:  domain.PromotionScope{Categories: []string{"kitchen"}},
+		Active: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Spring Sale", promotion.Name)
+	assert.False(t, promotion.StartsAt.IsZero(), "starts on creation")
+
+	got, err := service.GetProduct(context.Background(), product.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, got.Price)
+	require.NotNil(t, got.DiscountedPrice)
+	assert.Equal(t, 34.0, *got.DiscountedPrice)
+	assert.Equal(t, promotion.ID.Hex(), got.Promotion.ID)
+	assert.True(t, got.Discounted(), "shown on sale")
+
+	require.NoError(t, service.DeletePromotion(context.Background(), promotion.ID.Hex()))
+	got, err = service.GetProduct(context.Background(), product.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, got.DiscountedPrice)
+	assert.Nil(t, got.Promotion)
+}
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Now()
+	ended, later := now.Add(-time.Hour), now.Add(time.Hour)
+	product := builders.NewProduct(t).WithPrice(20).WithCategory("kitchen").WithTags("mug").Build()
+	running := func(name, kind string, value float64, scope domain.PromotionScope) *domain.Promotion {
+		return &domain.Promotion{Name: name, Type: kind, Value: value, Scope: scope, StartsAt: now.Add(-2 * time.Hour), Active: true}
+	}
+	byTag := domain.PromotionScope{Tags: []string{"mug"}}
+
+	tests := []struct {
+		name       string
+		promotions []*domain.Promotion
+		price      float64
+		promotion  string
+	}{
+		{"best of several", []*domain.Promotion{
+			running("ten", domain.PromotionPercentage, 10, byTag),
+			running("five off", domain.PromotionFixed, 5, domain.PromotionScope{ProductIDs: []string{product.ID.Hex()}}),
+			running("other", domain.PromotionPercentage, 50, domain.PromotionScope{Categories: []string{"garden"}}),
+		}, 15, "five off"},
+		{"fixed down to zero", []*domain.Promotion{running("free", domain.PromotionFixed, 25, byTag)}, 0, "free"},
+		{"rounded", []*domain.Promotion{running("third", domain.PromotionPercentage, 33.333, byTag)}, 13.33, "third"},
+		{"ended", []*domain.Promotion{func() *domain.Promotion {
+			p := running("ended", domain.PromotionPercentage, 10, byTag)
+			p.EndsAt = &ended
+			return p
+		}()}, 0, ""},
+		{"not started", []*domain.Promotion{func() *domain.Promotion {
+			p := running("later", domain.PromotionPercentage, 10, byTag)
+			p.StartsAt = later
+			return p
+		}()}, 0, ""},
+		{"inactive", []*domain.Promotion{func() *domain.Promotion {
+			p := running("off", domain.PromotionPercentage, 10, byTag)
+			p.Active = false
+			return p
+		}()}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product.ApplyPromotions(tt.promotions, now)
+			if tt.promotion == "" {
+				assert.Nil(t, product.DiscountedPrice)
+				assert.Nil(t, product.Promotion)
+				return
+			}
+			require.NotNil(t, product.DiscountedPrice)
+			assert.Equal(t, tt.price, *product.DiscountedPrice)
+			assert.Equal(t, tt.promotion, product.Promotion.Name)
+		})
+	}
+}
+
+func TestPromotionCoversSubcategories(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+	service.SetPromotionRepository(&memoryPromotionRepository{promotions: make(map[primitive.ObjectID]domain.Promotion)})
+	phone := builders.NewProduct(t).WithPrice(100).WithCategory("smartphones").Build()
+	headphones := builders.NewProduct(t).WithPrice(100).WithCategory("audio").Build()
+	products.On("GetByID", phone.ID.Hex()).Return(phone, nil)
+	products.On("GetByID", headphones.ID.Hex()).Return(headphones, nil)
+
+	_, err := service.CreatePromotion(context.Background(), &domain.Promotion{
+		Name:   "Phone week",
+		Type:   domain.PromotionFixed,
+		Value:  10,
+		Scope:  domain.PromotionScope{Categories: []string{"phones"}},
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	got, err := service.GetProduct(context.Background(), phone.ID.Hex())
+	require.NoError(t, err)
+	require.NotNil(t, got.DiscountedPrice)
+	assert.Equal(t, 90.0, *got.DiscountedPrice)
+
+	got, err = service.GetProduct(context.Background(), headphones.ID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, got.DiscountedPrice)
+}
+
+func TestPromotionWithGroupPrice(t *testing.T) {
+	service, products, _ := newPromotionTestService()
+	product := builders.NewProduct(t).WithPrice(50).WithTags("mug").Build()
+	product.GroupPrices = []domain.GroupPrice{{Group: domain.CustomerGroupWholesale, Price: 35}, {Group: domain.CustomerGroupVIP, Price: 48}}
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	_, err := service.CreatePromotion(context.Background(), &domain.Promotion{
+		Name:   "Mugs",
+		Type:   domain.PromotionPercentage,
+		Value:  20,
+		Scope:  domain.PromotionScope{Tags: []string{"mug"}},
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	// Groups pay the lower of their price and the promotional one
+	for group, want := range map[string]float64{domain.CustomerGroupWholesale: 35, domain.CustomerGroupVIP: 40} {
+		ctx := domain.WithCustomerGroup(context.Background(), group)
+		got, err := service.GetProduct(ctx, product.ID.Hex())
+		require.NoError(t, err)
+		assert.Equal(t, want, got.EffectivePrice, group)
+		assert.Equal(t, 40.0, *got.DiscountedPrice, group)
+	}
+}
+
+func TestPromotionErrors(t *testing.T) {
+	service, _, _ := newPromotionTestService()
+	valid := func() *domain.Promotion {
+		return &domain.Promotion{Name: "Sale", Type: domain.PromotionPercentage, Value: 10, Scope: domain.PromotionScope{Tags: []string{"mug"}}, Active: true}
+	}
+
+	invalid := map[string]func(p *domain.Promotion){
+		"no name":          func(p *domain.Promotion) { p.Name = " " },
+		"unknown type":     func(p *domain.Promotion) { p.Type = "bogo" },
+		"over 100 percent": func(p *domain.Promotion) { p.Value = 120 },
+		"no discount":      func(p *domain.Promotion) { p.Type, p.Value = domain.PromotionFixed, 0 },
+		"no scope":         func(p *domain.Promotion) { p.Scope = domain.PromotionScope{} },
+		"bad product ID":   func(p *domain.Promotion) { p.Scope.ProductIDs = []string{"mug"} },
+		"ends before start": func(p *domain.Promotion) {
+			p.StartsAt = time.Now()
+			ends := p.StartsAt.Add(-time.Minute)
+			p.EndsAt = &ends
+		},
+	}
+	for name, change := range invalid {
+		promotion := valid()
+		change(promotion)
+		_, err := service.CreatePromotion(context.Background(), promotion)
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "%s: %v", name, err)
+	}
+
+	_, err := service.UpdatePromotion(context.Background(), primitive.NewObjectID().Hex(), valid())
+	assert.ErrorIs(t, err, domain.ErrPromotionNotFound)
+
+	_, err = New(new(MockProductRepository), service.logger).CreatePromotion(context.Background(), valid())
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable), "without a repository: %v", err)
+}
+
+func TestUpdatePromotionKeepsStart(t *testing.T) {
+	service, _, promotions := newPromotionTestService()
+	starts := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	created, err := service.CreatePromotion(context.Background(), &domain.Promotion{
+		Name: "Sale", Type: domain.PromotionPercentage, Value: 10,
+		Scope: domain.PromotionScope{Tags: []string{"mug"}}, StartsAt: starts, Active: true,
+	})
+	require.NoError(t, err)
+
+	updated, err := service.UpdatePromotion(context.Background(), created.ID.Hex(), &domain.Promotion{
+		Name: "Bigger sale", Type: domain.PromotionPercentage, Value: 25, Scope: domain.PromotionScope{Tags: []string{"mug"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, starts, updated.StartsAt)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+	assert.Equal(t, "Bigger sale", promotions.promotions[created.ID].Name)
+	assert.Empty(t, service.livePromotions(), "inactive promotions are not live")
+}
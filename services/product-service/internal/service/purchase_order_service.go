@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetPurchaseOrderRepository configures where purchase orders are stored
+func (s *ProductService) SetPurchaseOrderRepository(purchaseOrders domain.PurchaseOrderRepository) {
+	s.orders = purchaseOrders
+}
+
+// CreatePurchaseOrder places a purchase order with an active supplier.
+// Supplier SKUs are filled in from the product's supplier links when not
+// given.
+func (s *ProductService) CreatePurchaseOrder(ctx context.Context, po *domain.PurchaseOrder) (*domain.PurchaseOrder, error) {
+	s.logger.Info("Creating purchase order", "supplierID", po.SupplierID.Hex(), "lines", len(po.Lines))
+
+	if err := s.requirePurchaseOrders(); err != nil {
+		return nil, err
+	}
+
+	supplier, err := s.suppliers.GetByID(ctx, po.SupplierID.Hex())
+	if errors.Is(err, domain.ErrSupplierNotFound) {
+		return nil, invalid(fmt.Errorf("unknown supplier %s", po.SupplierID.Hex()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if !supplier.Active {
+		return nil, invalid(fmt.Errorf("supplier %s is inactive", supplier.Code))
+	}
+
+	if len(po.Lines) == 0 {
+		return nil, invalid(errors.New("at least one line is required"))
+	}
+	seen := make(map[primitive.ObjectID]bool, len(po.Lines))
+	for i := range po.Lines {
+		line := &po.Lines[i]
+		if line.Expected <= 0 {
+			return nil, invalid(fmt.Errorf("line %d: quantity must be greater than zero", i+1))
+		}
+		if seen[line.ProductID] {
+			return nil, invalid(fmt.Errorf("line %d: product %s is ordered twice", i+1, line.ProductID.Hex()))
+		}
+		seen[line.ProductID] = true
+
+		product, err := s.repo.GetByID(ctx, line.ProductID.Hex())
+		if err != nil {
+			return nil, invalid(fmt.Errorf("line %d: %w", i+1, err))
+		}
+		if line.SupplierSKU == "" {
+			for _, link := range product.Suppliers {
+				if link.SupplierID == po.SupplierID {
+					line.SupplierSKU = link.SupplierSKU
+				}
+			}
+		}
+		line.Received = 0
+	}
+
+	now := time.Now()
+	po.ID = primitive.NewObjectID()
+	po.Status = domain.POStatusOpen
+	po.Receipts = []domain.POReceipt{}
+	po.Version = 0
+	po.CreatedAt = now
+	po.UpdatedAt = now
+	if err := s.orders.Create(ctx, po); err != nil {
+		s.logger.Error("Failed to create purchase order", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Purchase order created successfully", "id", po.ID.Hex())
+	return po, nil
+}
+
+// GetPurchaseOrder retrieves a purchase order by ID
+func (s *ProductService) GetPurchaseOrder(ctx context.Context, id string) (*domain.PurchaseOrder, error) {
+	if err := s.requirePurchaseOrders(); err != nil {
+		return nil, err
+	}
+	po, err := s.orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return po, nil
+}
+
+// ListPurchaseOrders returns purchase orders, newest first
+func (s *ProductService) ListPurchaseOrders(ctx context.Context, filter domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, error) {
+	if err := s.requirePurchaseOrders(); err != nil {
+		return nil, err
+	}
+	if filter.SupplierID != "" {
+		if _, err := primitive.ObjectIDFromHex(filter.SupplierID); err != nil {
+			return nil, invalid(errors.New("invalid supplier ID"))
+		}
+	}
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	orders, err := s.orders.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return orders, nil
+}
+
+// ReceivePurchaseOrder books a delivery against a purchase order. Every
+// accepted quantity is restocked with an operation ID derived from the
+// purchase order and the receipt reference, so a receipt that is retried
+// with the same reference never restocks twice. Quantities beyond what was
+// ordered need a note explaining the discrepancy.
+func (s *ProductService) ReceivePurchaseOrder(ctx context.Context, id string, receipt domain.POReceipt) (*domain.PurchaseOrder, error) {
+	s.logger.Info("Receiving purchase order", "id", id, "reference", receipt.Reference)
+
+	if err := s.requirePurchaseOrders(); err != nil {
+		return nil, err
+	}
+	po, err := s.orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	receipt.Reference = strings.TrimSpace(receipt.Reference)
+	if receipt.Reference == "" {
+		receipt.Reference = primitive.NewObjectID().Hex()
+	}
+	for _, existing := range po.Receipts {
+		if existing.Reference == receipt.Reference {
+			s.logger.Info("Receipt already booked", "id", id, "reference", receipt.Reference)
+			return po, nil
+		}
+	}
+	if po.Status != domain.POStatusOpen && po.Status != domain.POStatusPartiallyReceived {
+		return nil, invalid(fmt.Errorf("purchase order is %s", po.Status))
+	}
+	if err := s.resolveReceiptBarcodes(ctx, receipt.Lines); err != nil {
+		return nil, err
+	}
+	if err := validateReceipt(po, receipt); err != nil {
+		return nil, err
+	}
+
+	// Restock first: the operations are idempotent, so a failure below is
+	// repaired by retrying the receipt with the same reference
+	for _, line := range receipt.Lines {
+		if line.Quantity == 0 {
+			continue
+		}
+		operationID := fmt.Sprintf("po-%s-%s-%s", po.ID.Hex(), receipt.Reference, line.ProductID.Hex())
+		if _, err := s.UpdateInventory(ctx, line.ProductID.Hex(), line.Quantity, operationID, "restock"); err != nil {
+			return nil, fmt.Errorf("failed to restock %s: %w", line.ProductID.Hex(), err)
+		}
+	}
+
+	for _, received := range receipt.Lines {
+		for i := range po.Lines {
+			if po.Lines[i].ProductID == received.ProductID {
+				po.Lines[i].Received += received.Quantity
+			}
+		}
+	}
+	receipt.ReceivedAt = time.Now()
+	po.Receipts = append(po.Receipts, receipt)
+	po.Status = domain.POStatusReceived
+	for _, line := range po.Lines {
+		if line.Outstanding() > 0 {
+			po.Status = domain.POStatusPartiallyReceived
+			break
+		}
+	}
+	po.UpdatedAt = receipt.ReceivedAt
+
+	if err := s.orders.Update(ctx, po); err != nil {
+		s.logger.Error("Failed to record receipt", "id", id, "reference", receipt.Reference, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Purchase order received", "id", id, "reference", receipt.Reference, "status", po.Status)
+	return po, nil
+}
+
+// CancelPurchaseOrder stops a purchase order from being received. An order
+// with receipts is closed short instead of cancelled.
+func (s *ProductService) CancelPurchaseOrder(ctx context.Context, id, reason string) (*domain.PurchaseOrder, error) {
+	s.logger.Info("Cancelling purchase order", "id", id)
+
+	if err := s.requirePurchaseOrders(); err != nil {
+		return nil, err
+	}
+	po, err := s.orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	switch po.Status {
+	case domain.POStatusOpen:
+		po.Status = domain.POStatusCancelled
+	case domain.POStatusPartiallyReceived:
+		po.Status = domain.POStatusClosed
+	default:
+		return nil, invalid(fmt.Errorf("purchase order is %s", po.Status))
+	}
+	if reason != "" {
+		po.Notes = strings.TrimSpace(po.Notes + "\n" + reason)
+	}
+	po.UpdatedAt = time.Now()
+
+	if err := s.orders.Update(ctx, po); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return po, nil
+}
+
+// Helper functions
+
+func (s *ProductService) requirePurchaseOrders() error {
+	if s.orders == nil || s.suppliers == nil {
+		return apperrors.New(apperrors.Unavailable, "purchase orders not available")
+	}
+	return nil
+}
+
+// resolveReceiptBarcodes sets the product of the receipt lines that name
+// it by the barcode scanned rather than by ID
+func (s *ProductService) resolveReceiptBarcodes(ctx context.Context, lines []domain.POReceiptLine) error {
+	for i := range lines {
+		line := &lines[i]
+		if line.Barcode == "" {
+			continue
+		}
+		barcode, err := domain.NormalizeBarcode(line.Barcode)
+		if err != nil {
+			return invalid(fmt.Errorf("line %d: %w", i+1, err))
+		}
+		product, err := s.repo.GetByBarcode(ctx, barcode)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return invalid(fmt.Errorf("line %d: no product has barcode %s", i+1, barcode))
+		}
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		if !line.ProductID.IsZero() && line.ProductID != product.ID {
+			return invalid(fmt.Errorf("line %d: barcode %s is not product %s's", i+1, barcode, line.ProductID.Hex()))
+		}
+		line.ProductID, line.Barcode = product.ID, barcode
+	}
+	return nil
+}
+
+// validateReceipt checks that a receipt only books products on the order,
+// each once, and that over-deliveries are explained
+func validateReceipt(po *domain.PurchaseOrder, receipt domain.POReceipt) error {
+	if len(receipt.Lines) == 0 {
+		return invalid(errors.New("at least one line is required"))
+	}
+
+	lines := make(map[primitive.ObjectID]domain.POLine, len(po.Lines))
+	for _, line := range po.Lines {
+		lines[line.ProductID] = line
+	}
+	seen := make(map[primitive.ObjectID]bool, len(receipt.Lines))
+	for i, received := range receipt.Lines {
+		line, ok := lines[received.ProductID]
+		if !ok {
+			return invalid(fmt.Errorf("line %d: product %s is not on the purchase order", i+1, received.ProductID.Hex()))
+		}
+		if seen[received.ProductID] {
+			return invalid(fmt.Errorf("line %d: product %s is received twice", i+1, received.ProductID.Hex()))
+		}
+		seen[received.ProductID] = true
+		if received.Quantity < 0 {
+			return invalid(fmt.Errorf("line %d: quantity cannot be negative", i+1))
+		}
+		if received.Quantity > line.Outstanding() && strings.TrimSpace(received.Note) == "" {
+			return invalid(fmt.Errorf("line %d: %d received but %d outstanding, a note is required",
+				i+1, received.Quantity, line.Outstanding()))
+		}
+	}
+	return nil
+}
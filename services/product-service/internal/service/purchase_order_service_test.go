@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryPurchaseOrders keeps purchase orders in a map, honouring versions
+type memoryPurchaseOrders struct {
+	orders map[string]domain.PurchaseOrder
+}
+
+func (m *memoryPurchaseOrders) Create(ctx context.Context, po *domain.PurchaseOrder) error {
+	m.orders[po.ID.Hex()] = *po
+	return nil
+}
+
+func (m *memoryPurchaseOrders) GetByID(ctx context.Context, id string) (*domain.PurchaseOrder, error) {
+	po, ok := m.orders[id]
+	if !ok {
+		return nil, domain.ErrPurchaseOrderNotFound
+	}
+	po.Lines = append([]domain.POLine(nil), po.Lines...)
+	return &po, nil
+}
+
+func (m *memoryPurchaseOrders) Update(ctx context.Context, po *domain.PurchaseOrder) error {
+	if m.orders[po.ID.Hex()].Version != po.Version {
+		return domain.ErrPurchaseOrderConflict
+	}
+	po.Version++
+	m.orders[po.ID.Hex()] = *po
+	return nil
+}
+
+func (m *memoryPurchaseOrders) List(ctx context.Context, filter domain.PurchaseOrderFilter) ([]*domain.PurchaseOrder, error) {
+	var orders []*domain.PurchaseOrder
+	for _, po := range m.orders {
+		if filter.SupplierID == "" || po.SupplierID.Hex() == filter.SupplierID {
+			po := po
+			orders = append(orders, &po)
+		}
+	}
+	return orders, nil
+}
+
+func TestPurchaseOrderLifecycle(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	service.SetPurchaseOrderRepository(&memoryPurchaseOrders{orders: map[string]domain.PurchaseOrder{}})
+	ctx := context.Background()
+
+	supplier := builders.NewSupplier(t).Build()
+	mug := builders.NewProduct(t).WithSupplier(supplier.ID, "AC-MUG").Build()
+	tee := builders.NewProduct(t).Build()
+	suppliers.On("GetByID", supplier.ID.Hex()).Return(supplier, nil)
+	products.On("GetByID", mug.ID.Hex()).Return(mug, nil)
+	products.On("GetByID", tee.ID.Hex()).Return(tee, nil)
+	products.On("UpdateInventory", mock.Anything, mock.Anything, mock.Anything, "restock").
+		Return(&domain.InventoryInfo{Quantity: 1}, nil)
+
+	po, err := service.CreatePurchaseOrder(ctx, &domain.PurchaseOrder{
+		SupplierID: supplier.ID,
+		Lines: []domain.POLine{
+			{ProductID: mug.ID, Expected: 10},
+			{ProductID: tee.ID, Expected: 5, SupplierSKU: "AC-TEE"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusOpen, po.Status)
+	assert.Equal(t, "AC-MUG", po.Lines[0].SupplierSKU, "filled in from the product link")
+
+	// First delivery is short on mugs and has no tees
+	receipt := domain.POReceipt{Reference: "DN-1", Lines: []domain.POReceiptLine{
+		{ProductID: mug.ID, Quantity: 6, Note: "4 damaged, returned"},
+	}}
+	po, err = service.ReceivePurchaseOrder(ctx, po.ID.Hex(), receipt)
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusPartiallyReceived, po.Status)
+	assert.Equal(t, 4, po.Lines[0].Outstanding())
+	products.AssertCalled(t, "UpdateInventory", mug.ID.Hex(), 6, fmt.Sprintf("po-%s-DN-1-%s", po.ID.Hex(), mug.ID.Hex()), "restock")
+
+	t.Run("retried receipts are booked once", func(t *testing.T) {
+		again, err := service.ReceivePurchaseOrder(ctx, po.ID.Hex(), receipt)
+
+		require.NoError(t, err)
+		assert.Len(t, again.Receipts, 1)
+		assert.Equal(t, 6, again.Lines[0].Received)
+		products.AssertNumberOfCalls(t, "UpdateInventory", 1)
+	})
+
+	t.Run("over-deliveries need a note", func(t *testing.T) {
+		_, err := service.ReceivePurchaseOrder(ctx, po.ID.Hex(), domain.POReceipt{Reference: "DN-2", Lines: []domain.POReceiptLine{
+			{ProductID: tee.ID, Quantity: 7},
+		}})
+		assert.ErrorContains(t, err, "a note is required")
+
+		_, err = service.ReceivePurchaseOrder(ctx, po.ID.Hex(), domain.POReceipt{Reference: "DN-2", Lines: []domain.POReceiptLine{
+			{ProductID: primitive.NewObjectID(), Quantity: 1},
+		}})
+		assert.ErrorContains(t, err, "not on the purchase order")
+	})
+
+	po, err = service.ReceivePurchaseOrder(ctx, po.ID.Hex(), domain.POReceipt{Reference: "DN-3", Lines: []domain.POReceiptLine{
+		{ProductID: mug.ID, Quantity: 4},
+		{ProductID: tee.ID, Quantity: 6, Note: "1 extra sent free of charge"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusReceived, po.Status)
+	assert.Equal(t, 6, po.Lines[1].Received)
+
+	_, err = service.CancelPurchaseOrder(ctx, po.ID.Hex(), "")
+	assert.ErrorContains(t, err, "purchase order is received")
+}
+
+func TestReceivePurchaseOrderByBarcode(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	service.SetPurchaseOrderRepository(&memoryPurchaseOrders{orders: map[string]domain.PurchaseOrder{}})
+	ctx := context.Background()
+
+	supplier := builders.NewSupplier(t).Build()
+	mug := builders.NewProduct(t).Build()
+	mug.Barcode = "0036000291452"
+	tee := builders.NewProduct(t).Build()
+	suppliers.On("GetByID", supplier.ID.Hex()).Return(supplier, nil)
+	products.On("GetByID", mug.ID.Hex()).Return(mug, nil)
+	products.On("GetByBarcode", "0036000291452").Return(mug, nil)
+	products.On("GetByBarcode", mock.Anything).Return(nil, domain.ErrProductNotFound)
+	products.On("UpdateInventory", mug.ID.Hex(), 4, mock.Anything, "restock").
+		Return(&domain.InventoryInfo{Quantity: 4}, nil)
+
+	po, err := service.CreatePurchaseOrder(ctx, &domain.PurchaseOrder{
+		SupplierID: supplier.ID,
+		Lines:      []domain.POLine{{ProductID: mug.ID, Expected: 4}},
+	})
+	require.NoError(t, err)
+
+	// Barcodes that are malformed, unknown or another product's are refused
+	for _, line := range []domain.POReceiptLine{
+		{Barcode: "036000291453", Quantity: 4},
+		{Barcode: "4006381333931", Quantity: 4},
+		{ProductID: tee.ID, Barcode: "036000291452", Quantity: 4},
+	} {
+		_, err := service.ReceivePurchaseOrder(ctx, po.ID.Hex(), domain.POReceipt{Reference: "DN-1", Lines: []domain.POReceiptLine{line}})
+		assert.ErrorContains(t, err, "validation error: line 1:")
+	}
+
+	// The UPC-A a scanner reads names the product
+	po, err = service.ReceivePurchaseOrder(ctx, po.ID.Hex(), domain.POReceipt{
+		Reference: "DN-1",
+		Lines:     []domain.POReceiptLine{{Barcode: "036000291452", Quantity: 4}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusReceived, po.Status)
+	assert.Equal(t, domain.POReceiptLine{ProductID: mug.ID, Barcode: "0036000291452", Quantity: 4}, po.Receipts[0].Lines[0])
+	products.AssertCalled(t, "UpdateInventory", mug.ID.Hex(), 4, mock.Anything, "restock")
+}
+
+func TestCancelPurchaseOrder(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	service.SetPurchaseOrderRepository(&memoryPurchaseOrders{orders: map[string]domain.PurchaseOrder{}})
+	ctx := context.Background()
+
+	supplier := builders.NewSupplier(t).Build()
+	product := builders.NewProduct(t).Build()
+	suppliers.On("GetByID", supplier.ID.Hex()).Return(supplier, nil)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("UpdateInventory", mock.Anything, mock.Anything, mock.Anything, "restock").Return(&domain.InventoryInfo{}, nil)
+
+	open, err := service.CreatePurchaseOrder(ctx, &domain.PurchaseOrder{SupplierID: supplier.ID, Lines: []domain.POLine{{ProductID: product.ID, Expected: 3}}})
+	require.NoError(t, err)
+	partial, err := service.CreatePurchaseOrder(ctx, &domain.PurchaseOrder{SupplierID: supplier.ID, Lines: []domain.POLine{{ProductID: product.ID, Expected: 3}}})
+	require.NoError(t, err)
+	_, err = service.ReceivePurchaseOrder(ctx, partial.ID.Hex(), domain.POReceipt{Lines: []domain.POReceiptLine{{ProductID: product.ID, Quantity: 1}}})
+	require.NoError(t, err)
+
+	cancelled, err := service.CancelPurchaseOrder(ctx, open.ID.Hex(), "ordered by mistake")
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusCancelled, cancelled.Status)
+
+	closed, err := service.CancelPurchaseOrder(ctx, partial.ID.Hex(), "rest is discontinued")
+	require.NoError(t, err)
+	assert.Equal(t, domain.POStatusClosed, closed.Status)
+
+	_, err = service.ReceivePurchaseOrder(ctx, closed.ID.Hex(), domain.POReceipt{Lines: []domain.POReceiptLine{{ProductID: product.ID, Quantity: 2}}})
+	assert.ErrorContains(t, err, "purchase order is closed")
+
+	products.On("List", domain.ListProductsParams{SupplierID: supplier.ID.Hex(), PageSize: 1}).Return([]*domain.Product{}, 0, nil)
+	err = service.DeleteSupplier(ctx, supplier.ID.Hex())
+	assert.ErrorIs(t, err, domain.ErrSupplierInUse)
+}
+
+func TestCreatePurchaseOrderValidation(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	service.SetPurchaseOrderRepository(&memoryPurchaseOrders{orders: map[string]domain.PurchaseOrder{}})
+
+	inactive := builders.NewSupplier(t).Inactive().Build()
+	active := builders.NewSupplier(t).Build()
+	product := builders.NewProduct(t).Build()
+	suppliers.On("GetByID", inactive.ID.Hex()).Return(inactive, nil)
+	suppliers.On("GetByID", active.ID.Hex()).Return(active, nil)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+
+	tests := []struct {
+		name    string
+		po      *domain.PurchaseOrder
+		wantErr string
+	}{
+		{"inactive supplier", &domain.PurchaseOrder{SupplierID: inactive.ID, Lines: []domain.POLine{{ProductID: product.ID, Expected: 1}}}, "inactive"},
+		{"no lines", &domain.PurchaseOrder{SupplierID: active.ID}, "at least one line"},
+		{"zero quantity", &domain.PurchaseOrder{SupplierID: active.ID, Lines: []domain.POLine{{ProductID: product.ID}}}, "greater than zero"},
+		{"duplicate product", &domain.PurchaseOrder{SupplierID: active.ID, Lines: []domain.POLine{
+			{ProductID: product.ID, Expected: 1},
+			{ProductID: product.ID, Expected: 2},
+		}}, "ordered twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreatePurchaseOrder(context.Background(), tt.po)
+
+			assert.ErrorContains(t, err, "validation error")
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
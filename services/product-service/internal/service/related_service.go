@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// RelatedProductsStrategy finds the products related to a product, most
+// related first. By default the product repository scores products by
+// their shared category and tags; a recommendation backend can take its
+// place.
+type RelatedProductsStrategy interface {
+	RelatedProducts(ctx context.Context, product *domain.Product, limit int) ([]*domain.Product, error)
+}
+
+// SetRelatedProductsStrategy configures how related products are found
+func (s *ProductService) SetRelatedProductsStrategy(strategy RelatedProductsStrategy) {
+	s.related = strategy
+}
+
+// RelatedProducts returns up to limit active products related to the
+// product, most related first. Like GetProductsByIDs it does not count the
+// products as viewed.
+func (s *ProductService) RelatedProducts(ctx context.Context, id string, limit int) ([]*domain.Product, error) {
+	s.logger.Info("Getting related products", "id", id, "limit", limit)
+
+	if limit == 0 {
+		limit = domain.DefaultRelatedLimit
+	}
+	if limit < 1 || limit > domain.MaxRelatedLimit {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("limit must be between 1 and %d", domain.MaxRelatedLimit))
+	}
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get product", "id", id, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	related, err := s.related.RelatedProducts(ctx, product, limit)
+	if err != nil {
+		s.logger.Error("Failed to find related products", "id", id, "error", err)
+		return nil, fmt.Errorf("related products: %w", err)
+	}
+	// A recommendation backend may not know that a product was since
+	// deactivated or retired, or may recommend the product itself
+	products := make([]*domain.Product, 0, len(related))
+	for _, candidate := range related {
+		if candidate.Visible() && candidate.ID != product.ID && len(products) < limit {
+			products = append(products, candidate)
+		}
+	}
+
+	s.merchandise(products...)
+	priceForGroup(group, products...)
+	return products, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recommender is a related products strategy returning fixed products
+type recommender struct {
+	products []*domain.Product
+	limit    int
+}
+
+func (r *recommender) RelatedProducts(ctx context.Context, product *domain.Product, limit int) ([]*domain.Product, error) {
+	r.limit = limit
+	return r.products, nil
+}
+
+func TestRelatedProducts(t *testing.T) {
+	products := new(MockProductRepository)
+	service := New(products, slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	mug := builders.NewProduct(t).WithName("Mug").WithCategory("kitchen").Build()
+	saucer := builders.NewProduct(t).WithName("Saucer").WithCategory("kitchen").Build()
+	products.On("GetByID", mug.ID.Hex()).Return(mug, nil)
+
+	// The repository is the default strategy
+	products.On("RelatedProducts", mug.ID.Hex(), domain.DefaultRelatedLimit).Return([]*domain.Product{saucer}, nil)
+	related, err := service.RelatedProducts(context.Background(), mug.ID.Hex(), 0)
+	require.NoError(t, err)
+	require.Len(t, related, 1)
+	assert.Equal(t, "Saucer", related[0].Name)
+
+	// A replacement strategy is trusted for the order, not for the product
+	// itself or inactive products
+	retired := builders.NewProduct(t).WithName("Retired").Build()
+	retired.Active = false
+	backend := &recommender{products: []*domain.Product{mug, retired, saucer}}
+	service.SetRelatedProductsStrategy(backend)
+	related, err = service.RelatedProducts(context.Background(), mug.ID.Hex(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, backend.limit)
+	require.Len(t, related, 1)
+	assert.Equal(t, saucer.ID, related[0].ID)
+
+	missing := primitive.NewObjectID().Hex()
+	products.On("GetByID", missing).Return(nil, domain.ErrProductNotFound)
+	_, err = service.RelatedProducts(context.Background(), missing, 0)
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+
+	for _, limit := range []int{-1, domain.MaxRelatedLimit + 1} {
+		_, err := service.RelatedProducts(context.Background(), mug.ID.Hex(), limit)
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "limit %d: %v", limit, err)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// maxEligibilityProducts is the most products checked by one eligibility
+// request, enough for any cart
+const maxEligibilityProducts = 100
+
+// SetRestrictions replaces the age and region restrictions of a product
+func (s *ProductService) SetRestrictions(ctx context.Context, productID string, restrictions domain.Restrictions) (*domain.Product, error) {
+	s.logger.Info("Setting restrictions", "productID", productID,
+		"minAge", restrictions.MinAge, "restrictedRegions", restrictions.RestrictedRegions)
+
+	restrictions, err := restrictions.Normalize()
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	product.Restrictions = restrictions
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to update restrictions", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// ValidatePurchaseEligibility returns why the customer may not buy some of
+// the products, for checkout to refuse the order. The customer may buy all
+// of them when none is returned. Missing products and products shoppers
+// do not see, inactive or unpublished, are reported as unavailable.
+func (s *ProductService) ValidatePurchaseEligibility(ctx context.Context, productIDs []string, customer domain.CustomerProfile) ([]domain.Ineligibility, error) {
+	s.logger.Info("Validating purchase eligibility", "products", len(productIDs), "region", customer.Region)
+
+	if len(productIDs) == 0 || len(productIDs) > maxEligibilityProducts {
+		return nil, invalid(fmt.Errorf("between 1 and %d product IDs are required", maxEligibilityProducts))
+	}
+	now := time.Now().UTC()
+	if err := customer.Validate(now); err != nil {
+		return nil, invalid(err)
+	}
+
+	ineligible := []domain.Ineligibility{}
+	checked := make(map[string]bool, len(productIDs))
+	for _, id := range productIDs {
+		if checked[id] {
+			continue
+		}
+		checked[id] = true
+
+		product, err := s.repo.GetByID(ctx, id)
+		if errors.Is(err, domain.ErrProductNotFound) || (err == nil && !product.Visible()) {
+			ineligible = append(ineligible, domain.Ineligibility{
+				ProductID: id,
+				Reason:    domain.ReasonProductUnavailable,
+				Message:   "the product is not available",
+			})
+			continue
+		}
+		if err != nil {
+			s.logger.Error("Failed to get product", "productID", id, "error", err)
+			return nil, fmt.Errorf("repository error: %w", err)
+		}
+		if refusal := product.Restrictions.Check(id, customer, now); refusal != nil {
+			ineligible = append(ineligible, *refusal)
+		}
+	}
+	return ineligible, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestrictionsCheck(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	date := func(s string) *time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return &d
+	}
+	restrictions := domain.Restrictions{MinAge: 18, RestrictedRegions: []string{"DE", "US-UT"}}
+
+	tests := []struct {
+		name     string
+		customer domain.CustomerProfile
+		reason   string
+	}{
+		{"adult elsewhere", domain.CustomerProfile{BirthDate: date("2006-03-01"), Region: "US-CA"}, ""},
+		{"a day short of 18", domain.CustomerProfile{BirthDate: date("2006-03-02"), Region: "US-CA"}, domain.ReasonUnderage},
+		{"no birth date", domain.CustomerProfile{Region: "US-CA"}, domain.ReasonBirthDateRequired},
+		{"restricted subdivision", domain.CustomerProfile{BirthDate: date("1990-01-01"), Region: "US-UT"}, domain.ReasonRegionRestricted},
+		{"subdivision of a restricted country", domain.CustomerProfile{BirthDate: date("1990-01-01"), Region: "DE-BY"}, domain.ReasonRegionRestricted},
+		{"country without subdivision", domain.CustomerProfile{BirthDate: date("1990-01-01"), Region: "US"}, domain.ReasonRegionRequired},
+		{"no region", domain.CustomerProfile{BirthDate: date("1990-01-01")}, domain.ReasonRegionRequired},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			refusal := restrictions.Check("p1", tc.customer, now)
+			if tc.reason == "" {
+				assert.Nil(t, refusal)
+				return
+			}
+			require.NotNil(t, refusal)
+			assert.Equal(t, tc.reason, refusal.Reason)
+		})
+	}
+}
+
+func TestSetRestrictionsNormalizesRegions(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	mockRepo.On("GetByID", productID).Return(product, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	result, err := service.SetRestrictions(context.Background(), productID,
+		domain.Restrictions{MinAge: 21, RestrictedRegions: []string{"us-ut", " DE", "US-UT"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DE", "US-UT"}, result.RestrictedRegions)
+
+	_, err = service.SetRestrictions(context.Background(), productID, domain.Restrictions{RestrictedRegions: []string{"Germany"}})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestValidatePurchaseEligibility(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	wine := builders.NewProduct(t).WithName("Wine").Build()
+	wine.Restrictions = domain.Restrictions{MinAge: 18}
+	mug := builders.NewProduct(t).WithName("Mug").Build()
+	inactive := builders.NewProduct(t).Inactive().Build()
+	missing := "65f1c0d2e4b0a1b2c3d4e5ff"
+	mockRepo.On("GetByID", wine.ID.Hex()).Return(wine, nil).Once()
+	mockRepo.On("GetByID", mug.ID.Hex()).Return(mug, nil).Once()
+	mockRepo.On("GetByID", inactive.ID.Hex()).Return(inactive, nil).Once()
+	mockRepo.On("GetByID", missing).Return(nil, domain.ErrProductNotFound).Once()
+
+	birthDate := time.Now().AddDate(-17, 0, 0)
+	ineligible, err := service.ValidatePurchaseEligibility(context.Background(),
+		[]string{wine.ID.Hex(), mug.ID.Hex(), wine.ID.Hex(), inactive.ID.Hex(), missing},
+		domain.CustomerProfile{BirthDate: &birthDate, Region: "de"})
+	require.NoError(t, err)
+	require.Len(t, ineligible, 3)
+	assert.Equal(t, domain.ReasonUnderage, ineligible[0].Reason)
+	assert.Equal(t, domain.ReasonProductUnavailable, ineligible[1].Reason)
+	assert.Equal(t, domain.ReasonProductUnavailable, ineligible[2].Reason)
+	mockRepo.AssertExpectations(t)
+
+	_, err = service.ValidatePurchaseEligibility(context.Background(), nil, domain.CustomerProfile{})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetReviewRepository configures where product reviews are stored
+func (s *ProductService) SetReviewRepository(reviews domain.ReviewRepository) {
+	s.reviews = reviews
+}
+
+// CreateReview adds a user's review of an active product and updates the
+// product's rating
+func (s *ProductService) CreateReview(ctx context.Context, review *domain.Review) (*domain.Review, error) {
+	s.logger.Info("Creating review", "productID", review.ProductID.Hex(), "rating", review.Rating)
+
+	if err := s.requireReviews(); err != nil {
+		return nil, err
+	}
+	if review.UserID == "" {
+		return nil, apperrors.New(apperrors.Unauthenticated, "sign in to review products")
+	}
+	if err := review.Validate(); err != nil {
+		return nil, invalid(err)
+	}
+	product, err := s.repo.GetByID(ctx, review.ProductID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if !product.Visible() {
+		return nil, domain.ErrProductNotFound
+	}
+
+	review.CreatedAt = time.Now()
+	rating, err := s.reviews.Create(ctx, review)
+	if err != nil {
+		s.logger.Error("Failed to create review", "productID", review.ProductID.Hex(), "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	s.logger.Info("Review created", "productID", review.ProductID.Hex(), "average", rating.Average, "count", rating.Count)
+	return review, nil
+}
+
+// ListReviews returns a page of the reviews of a product, newest first,
+// and how many it has
+func (s *ProductService) ListReviews(ctx context.Context, productID string, page pagination.Request) ([]*domain.Review, int, error) {
+	if err := s.requireReviews(); err != nil {
+		return nil, 0, err
+	}
+	page = pagination.New(page.Page, page.PageSize, domain.ReviewPagination)
+	reviews, total, err := s.reviews.List(ctx, productID, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+	return reviews, total, nil
+}
+
+func (s *ProductService) requireReviews() error {
+	if s.reviews == nil {
+		return apperrors.New(apperrors.Unavailable, "reviews not available")
+	}
+	return nil
+}
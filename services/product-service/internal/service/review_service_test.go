@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockReviewRepository is a mock implementation of the domain.ReviewRepository interface
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) Create(ctx context.Context, review *domain.Review) (*domain.Rating, error) {
+	args := m.Called(review)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Rating), args.Error(1)
+}
+
+func (m *MockReviewRepository) List(ctx context.Context, productID string, page pagination.Request) ([]*domain.Review, int, error) {
+	args := m.Called(productID, page)
+	return args.Get(0).([]*domain.Review), args.Int(1), args.Error(2)
+}
+
+func newReviewTestService() (*ProductService, *MockProductRepository, *MockReviewRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	products := new(MockProductRepository)
+	reviews := new(MockReviewRepository)
+	service := New(products, logger)
+	service.SetReviewRepository(reviews)
+	return service, products, reviews
+}
+
+func TestCreateReview(t *testing.T) {
+	service, products, reviews := newReviewTestService()
+	product := builders.NewProduct(t).Build()
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	reviews.On("Create", mock.MatchedBy(func(review *domain.Review) bool {
+		return review.Title == "Great" && !review.CreatedAt.IsZero()
+	})).Return(&domain.Rating{Average: 4.5, Count: 2}, nil)
+
+	review, err := service.CreateReview(context.Background(), &domain.Review{ProductID: product.ID, UserID: "user-1", Rating: 5, Title: "  Great "})
+	require.NoError(t, err)
+	assert.Equal(t, "Great", review.Title)
+	reviews.AssertExpectations(t)
+}
+
+func TestCreateReviewErrors(t *testing.T) {
+	service, products, reviews := newReviewTestService()
+	active := builders.NewProduct(t).Build()
+	inactive := builders.NewProduct(t).Build()
+	inactive.Active = false
+	products.On("GetByID", active.ID.Hex()).Return(active, nil)
+	products.On("GetByID", inactive.ID.Hex()).Return(inactive, nil)
+	reviews.On("Create", mock.Anything).Return(nil, domain.ErrReviewExists)
+
+	_, err := service.CreateReview(context.Background(), &domain.Review{ProductID: active.ID, Rating: 5})
+	assert.True(t, apperrors.Is(err, apperrors.Unauthenticated), "no user: %v", err)
+
+	for _, rating := range []int{0, 6} {
+		_, err = service.CreateReview(context.Background(), &domain.Review{ProductID: active.ID, UserID: "user-1", Rating: rating})
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "rating %d: %v", rating, err)
+	}
+
+	_, err = service.CreateReview(context.Background(), &domain.Review{ProductID: inactive.ID, UserID: "user-1", Rating: 3})
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+
+	_, err = service.CreateReview(context.Background(), &domain.Review{ProductID: active.ID, UserID: "user-1", Rating: 3})
+	assert.ErrorIs(t, err, domain.ErrReviewExists)
+
+	_, _, err = New(products, service.logger).ListReviews(context.Background(), active.ID.Hex(), pagination.Request{})
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable), "no repository: %v", err)
+}
+
+func TestListReviewsClampsPage(t *testing.T) {
+	service, _, reviews := newReviewTestService()
+	reviews.On("List", "p1", mock.MatchedBy(func(page pagination.Request) bool {
+		return page.PageSize == 100
+	})).Return([]*domain.Review{}, 0, nil)
+
+	_, _, err := service.ListReviews(context.Background(), "p1", pagination.Request{PageSize: 1000})
+	require.NoError(t, err)
+	reviews.AssertExpectations(t)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// maxSlugCandidates is how many numbered slugs are tried for a product
+// before its ID is used as the suffix
+const maxSlugCandidates = 10
+
+// GetProductBySlug retrieves the product with a slug, current or previous.
+// Callers compare the slug of the product returned with the one asked for
+// to redirect from previous slugs; only lookups by the current slug count
+// as views.
+func (s *ProductService) GetProductBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	s.logger.Info("Getting product by slug", "slug", slug)
+
+	normalized, err := domain.NormalizeSlug(slug)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	group, err := customerGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.repo.GetBySlug(ctx, normalized)
+	if err != nil {
+		s.logger.Error("Failed to get product by slug", "slug", normalized, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.merchandise(product)
+	priceForGroup(group, product)
+	if product.Slug == normalized {
+		s.countPopularity(ctx, product.ID.Hex(), 1, 0)
+	}
+	return product, nil
+}
+
+// normalizeSEO validates the slug and meta fields of a new product and
+// reports whether its slug is to be generated from the name
+func normalizeSEO(product *domain.Product) (generate bool, err error) {
+	if err := domain.ValidateMeta(product.MetaTitle, product.MetaDescription); err != nil {
+		return false, err
+	}
+	product.PreviousSlugs = nil
+	if product.Slug == "" {
+		return true, nil
+	}
+	product.Slug, err = domain.NormalizeSlug(product.Slug)
+	return false, err
+}
+
+// updateSEO applies the slug and meta fields of an update to the stored
+// product and reports whether its slug is to be generated from the name.
+// It is when the product has no slug yet, or when it is renamed and its
+// slug was generated from the old name; a slug set by hand is kept.
+func updateSEO(existing, update *domain.Product, oldName string) (generate bool, err error) {
+	if update.MetaTitle != "" {
+		existing.MetaTitle = update.MetaTitle
+	}
+	if update.MetaDescription != "" {
+		existing.MetaDescription = update.MetaDescription
+	}
+	if err := domain.ValidateMeta(existing.MetaTitle, existing.MetaDescription); err != nil {
+		return false, err
+	}
+
+	if update.Slug != "" {
+		slug, err := domain.NormalizeSlug(update.Slug)
+		if err != nil {
+			return false, err
+		}
+		existing.ChangeSlug(slug)
+		return false, nil
+	}
+	renamed := existing.Name != oldName && domain.GeneratedFrom(existing.Slug, oldName)
+	return existing.Slug == "" || renamed, nil
+}
+
+// saveWithSlug saves the product with save. With generate, the product is
+// first given a slug generated from its name, trying base, base-2 up to
+// base-10 and then base-<product ID> while another product has the slug.
+func (s *ProductService) saveWithSlug(product *domain.Product, generate bool, save func() error) error {
+	if !generate {
+		return save()
+	}
+
+	base := domain.Slugify(product.Name)
+	slug, previous := product.Slug, product.PreviousSlugs
+	for n := 1; ; n++ {
+		suffix := ""
+		switch {
+		case n > maxSlugCandidates:
+			suffix = product.ID.Hex()
+		case n > 1:
+			suffix = strconv.Itoa(n)
+		}
+		product.Slug, product.PreviousSlugs = slug, previous
+		product.ChangeSlug(domain.SlugCandidate(base, suffix))
+
+		err := save()
+		if !errors.Is(err, domain.ErrSlugExists) || n > maxSlugCandidates {
+			return err
+		}
+		s.logger.Info("Slug taken, trying the next", "slug", product.Slug)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Stoneware Mug":            "stoneware-mug",
+		"  Crème Brûlée Set (4) ":  "creme-brulee-set-4",
+		"USB-C -- Cable, 2m":       "usb-c-cable-2m",
+		"日本茶":                      "product",
+		strings.Repeat("tea ", 40): strings.TrimSuffix(strings.Repeat("tea-", 20), "-"),
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, domain.Slugify(name), name)
+	}
+	assert.Equal(t, 80, len(domain.SlugCandidate(strings.Repeat("a", 80), "12")))
+	assert.True(t, domain.GeneratedFrom("stoneware-mug-3", "Stoneware Mug"))
+	assert.False(t, domain.GeneratedFrom("best-mug", "Stoneware Mug"))
+}
+
+func TestChangeSlugKeepsHistory(t *testing.T) {
+	product := &domain.Product{}
+	product.ChangeSlug("mug")
+	product.ChangeSlug("cup")
+	product.ChangeSlug("beaker")
+	assert.Equal(t, []string{"mug", "cup"}, product.PreviousSlugs)
+
+	product.ChangeSlug("mug")
+	assert.Equal(t, "mug", product.Slug)
+	assert.Equal(t, []string{"cup", "beaker"}, product.PreviousSlugs)
+	assert.Equal(t, []string{"mug", "cup", "beaker"}, product.AllSlugs())
+}
+
+func TestCreateProductGeneratesFreeSlug(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	var tried []string
+	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Run(func(args mock.Arguments) {
+		tried = append(tried, args.Get(0).(*domain.Product).Slug)
+	}).Return(domain.ErrSlugExists).Twice()
+	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil).Once()
+
+	product := builders.NewProduct(t).WithName("Stoneware Mug").Build()
+	created, err := service.CreateProduct(context.Background(), product)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stoneware-mug", "stoneware-mug-2"}, tried)
+	assert.Equal(t, "stoneware-mug-3", created.Slug)
+	assert.Empty(t, created.PreviousSlugs)
+
+	// A slug given by hand is not replaced when taken
+	mockRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(domain.ErrSlugExists).Once()
+	product = builders.NewProduct(t).Build()
+	product.Slug = "Best-Mug"
+	_, err = service.CreateProduct(context.Background(), product)
+	assert.ErrorIs(t, err, domain.ErrSlugExists)
+	assert.Equal(t, "best-mug", product.Slug)
+}
+
+func TestUpdateProductMovesGeneratedSlug(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	generated := builders.NewProduct(t).WithName("Stoneware Mug").Build()
+	generated.Slug = "stoneware-mug-2"
+	custom := builders.NewProduct(t).WithName("Stoneware Mug").Build()
+	custom.Slug = "best-mug"
+	mockRepo.On("GetByID", generated.ID.Hex()).Return(generated, nil)
+	mockRepo.On("GetByID", custom.ID.Hex()).Return(custom, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	updated, err := service.UpdateProduct(context.Background(), &domain.Product{ID: generated.ID, Name: "Porcelain Mug", Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, "porcelain-mug", updated.Slug)
+	assert.Equal(t, []string{"stoneware-mug-2"}, updated.PreviousSlugs)
+
+	updated, err = service.UpdateProduct(context.Background(), &domain.Product{ID: custom.ID, Name: "Porcelain Mug", Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, "best-mug", updated.Slug)
+
+	_, err = service.UpdateProduct(context.Background(), &domain.Product{ID: custom.ID, Slug: "not a slug", Active: true})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestGetProductBySlug(t *testing.T) {
+	service, mockRepo := newMerchandisingService()
+	product := builders.NewProduct(t).Build()
+	product.Slug, product.PreviousSlugs = "porcelain-mug", []string{"stoneware-mug"}
+	mockRepo.On("GetBySlug", "stoneware-mug").Return(product, nil)
+
+	found, err := service.GetProductBySlug(context.Background(), "Stoneware-Mug")
+	require.NoError(t, err)
+	assert.Equal(t, "porcelain-mug", found.Slug, "callers redirect to the current slug")
+
+	_, err = service.GetProductBySlug(context.Background(), "no/slug")
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultReportRange is the range inventory reports cover when no start
+// day is given, ending with the last day given or today
+const DefaultReportRange = 30 * 24 * time.Hour
+
+// SetSnapshotRepository configures where daily inventory snapshots are
+// stored. Until it is set, snapshots are not captured and the inventory
+// reports are unavailable.
+func (s *ProductService) SetSnapshotRepository(snapshots domain.SnapshotRepository) {
+	s.snapshots = snapshots
+}
+
+// CaptureInventorySnapshots snapshots the stock of every physical product
+// for today and returns the number of products captured. Capturing again
+// the same day replaces the day's snapshots.
+func (s *ProductService) CaptureInventorySnapshots(ctx context.Context) (int, error) {
+	if err := s.requireSnapshots(); err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	captured, err := s.snapshots.Capture(ctx, domain.Day(now), now)
+	if err != nil {
+		s.logger.Error("Failed to capture inventory snapshots", "error", err)
+		return 0, fmt.Errorf("repository error: %w", err)
+	}
+	s.logger.Info("Captured inventory snapshots", "day", domain.Day(now), "products", captured)
+	return captured, nil
+}
+
+// StockLevels returns the stock captured on each day of a range, summed
+// over all products or those of a category or a single product
+func (s *ProductService) StockLevels(ctx context.Context, params domain.StockLevelParams) ([]domain.StockLevel, error) {
+	s.logger.Info("Getting stock levels", "from", params.From, "to", params.To, "category", params.Category, "productID", params.ProductID)
+
+	if err := s.requireSnapshots(); err != nil {
+		return nil, err
+	}
+	from, to, err := reportRange(params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	if params.ProductID != "" {
+		if _, err := primitive.ObjectIDFromHex(params.ProductID); err != nil {
+			return nil, apperrors.New(apperrors.Invalid, "product_id must be a product ID")
+		}
+	}
+	params.From, params.To = from, to
+
+	levels, err := s.snapshots.StockLevels(ctx, params)
+	if err != nil {
+		s.logger.Error("Failed to get stock levels", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return levels, nil
+}
+
+// SellThrough reports the share of the units available in each category
+// within a range that were sold. The opening stock is the one captured
+// the day before the range, or on its first day captured; the units sold
+// and received are those of the purchases and restocks recorded with an
+// operation ID.
+func (s *ProductService) SellThrough(ctx context.Context, from, to time.Time) (*domain.SellThroughReport, error) {
+	s.logger.Info("Getting sell-through", "from", from, "to", to)
+
+	if err := s.requireSnapshots(); err != nil {
+		return nil, err
+	}
+	from, to, err := reportRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, openingDay, err := s.snapshots.OpeningStock(ctx, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get opening stock", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	movements, err := s.snapshots.Movements(ctx, from, to.Add(24*time.Hour))
+	if err != nil {
+		s.logger.Error("Failed to get inventory movements", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	categories := make(map[string]*domain.CategorySellThrough)
+	category := func(name string) *domain.CategorySellThrough {
+		if categories[name] == nil {
+			categories[name] = &domain.CategorySellThrough{Category: name}
+		}
+		return categories[name]
+	}
+	for _, stock := range opening {
+		category(stock.Category).Opening = stock.Quantity
+	}
+	for _, movement := range movements {
+		c := category(movement.Category)
+		c.Sold, c.Received = movement.Sold, movement.Received
+	}
+
+	report := &domain.SellThroughReport{From: from, To: to, Categories: make([]domain.CategorySellThrough, 0, len(categories))}
+	if !openingDay.IsZero() {
+		report.OpeningDay = &openingDay
+	}
+	for _, c := range categories {
+		c.SellThrough = domain.SellThroughRate(c.Opening, c.Received, c.Sold)
+		report.Categories = append(report.Categories, *c)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Category < report.Categories[j].Category
+	})
+	return report, nil
+}
+
+// reportRange returns the first and last day of a report, defaulting to
+// the DefaultReportRange up to today
+func reportRange(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	to = domain.Day(to)
+	if from.IsZero() {
+		from = to.Add(-DefaultReportRange + 24*time.Hour)
+	}
+	from = domain.Day(from)
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, apperrors.New(apperrors.Invalid, "from must not be after to")
+	}
+	if to.Sub(from) >= domain.MaxReportRange {
+		return time.Time{}, time.Time{}, apperrors.New(apperrors.Invalid, fmt.Sprintf("reports cover at most %d days", domain.MaxReportRange/(24*time.Hour)))
+	}
+	return from, to, nil
+}
+
+func (s *ProductService) requireSnapshots() error {
+	if s.snapshots == nil {
+		return apperrors.New(apperrors.Unavailable, "inventory reports not available")
+	}
+	return nil
+}
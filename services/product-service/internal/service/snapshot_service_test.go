@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSnapshotRepository is a mock implementation of the domain.SnapshotRepository interface
+type MockSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotRepository) Capture(ctx context.Context, day, at time.Time) (int, error) {
+	args := m.Called(day, at)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSnapshotRepository) StockLevels(ctx context.Context, params domain.StockLevelParams) ([]domain.StockLevel, error) {
+	args := m.Called(params)
+	return args.Get(0).([]domain.StockLevel), args.Error(1)
+}
+
+func (m *MockSnapshotRepository) OpeningStock(ctx context.Context, from, to time.Time) ([]domain.CategoryStock, time.Time, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]domain.CategoryStock), args.Get(1).(time.Time), args.Error(2)
+}
+
+func (m *MockSnapshotRepository) Movements(ctx context.Context, from, to time.Time) ([]domain.CategoryMovement, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]domain.CategoryMovement), args.Error(1)
+}
+
+func newSnapshotTestService() (*ProductService, *MockSnapshotRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	snapshots := new(MockSnapshotRepository)
+	service := New(new(MockProductRepository), logger)
+	service.SetSnapshotRepository(snapshots)
+	return service, snapshots
+}
+
+func TestCaptureInventorySnapshots(t *testing.T) {
+	service, snapshots := newSnapshotTestService()
+	snapshots.On("Capture", domain.Day(time.Now()), mock.AnythingOfType("time.Time")).Return(12, nil)
+
+	captured, err := service.CaptureInventorySnapshots(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 12, captured)
+}
+
+func TestSellThrough(t *testing.T) {
+	service, snapshots := newSnapshotTestService()
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	openingDay := from.AddDate(0, 0, -1)
+	snapshots.On("OpeningStock", from, to).Return([]domain.CategoryStock{
+		{Category: "books", Quantity: 40},
+		{Category: "kitchen", Quantity: 30},
+	}, openingDay, nil)
+	snapshots.On("Movements", from, to.AddDate(0, 0, 1)).Return([]domain.CategoryMovement{
+		{Category: "garden", Sold: 0, Received: 0},
+		{Category: "kitchen", Sold: 25, Received: 20},
+	}, nil)
+
+	report, err := service.SellThrough(context.Background(), from.Add(15*time.Hour), to)
+	require.NoError(t, err)
+	assert.Equal(t, from, report.From, "ranges cover whole days")
+	assert.Equal(t, openingDay, *report.OpeningDay)
+	assert.Equal(t, []domain.CategorySellThrough{
+		{Category: "books", Opening: 40},
+		{Category: "garden"},
+		{Category: "kitchen", Opening: 30, Received: 20, Sold: 25, SellThrough: 0.5},
+	}, report.Categories)
+}
+
+func TestReportRangeValidation(t *testing.T) {
+	service, snapshots := newSnapshotTestService()
+	today := domain.Day(time.Now())
+	snapshots.On("StockLevels", domain.StockLevelParams{From: today.AddDate(0, 0, -29), To: today}).Return([]domain.StockLevel{}, nil)
+
+	_, err := service.StockLevels(context.Background(), domain.StockLevelParams{})
+	require.NoError(t, err, "the last 30 days by default")
+
+	_, err = service.StockLevels(context.Background(), domain.StockLevelParams{From: today, To: today.AddDate(0, 0, -1)})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	_, err = service.StockLevels(context.Background(), domain.StockLevelParams{From: today.AddDate(-2, 0, 0), To: today})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	_, err = service.StockLevels(context.Background(), domain.StockLevelParams{ProductID: "mug"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+
+	_, err = New(new(MockProductRepository), service.logger).SellThrough(context.Background(), time.Time{}, time.Time{})
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// PublishProduct publishes a draft or retired product, so that shoppers
+// see it while it is active. The product must have every field
+// merchandising needs; the error names those it lacks.
+func (s *ProductService) PublishProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	s.logger.Info("Publishing product", "productID", productID)
+
+	return s.setStatus(ctx, productID, domain.StatusPublished, func(product *domain.Product) error {
+		if missing := product.MissingForPublish(); len(missing) > 0 {
+			return apperrors.New(apperrors.Invalid, "product is missing "+strings.Join(missing, ", ")).WithReason("INCOMPLETE_PRODUCT")
+		}
+		return nil
+	})
+}
+
+// RetireProduct retires a published product that is no longer sold. It
+// is kept, and can be published again.
+func (s *ProductService) RetireProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	s.logger.Info("Retiring product", "productID", productID)
+
+	return s.setStatus(ctx, productID, domain.StatusRetired, func(product *domain.Product) error {
+		if product.Status != domain.StatusPublished {
+			return domain.ErrStatusTransition
+		}
+		return nil
+	})
+}
+
+// setStatus moves a product to status if check allows it. A product
+// already in status is returned as it is.
+func (s *ProductService) setStatus(ctx context.Context, productID, status string, check func(*domain.Product) error) (*domain.Product, error) {
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if product.Status == status {
+		s.merchandise(product)
+		return product, nil
+	}
+	if err := check(product); err != nil {
+		return nil, err
+	}
+	before := s.auditFields(product)
+	product.Status = status
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to set product status", "productID", productID, "status", status, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishProduct(t *testing.T) {
+	service, products := newMerchandisingService()
+	product := builders.NewProduct(t).WithStatus(domain.StatusDraft).Build()
+	product.Slug = domain.Slugify(product.Name)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("Update", mock.MatchedBy(func(p *domain.Product) bool {
+		return p.Status == domain.StatusPublished
+	})).Return(nil).Once()
+
+	published, err := service.PublishProduct(context.Background(), product.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPublished, published.Status)
+	assert.True(t, published.Visible())
+
+	// Publishing a published product changes nothing
+	_, err = service.PublishProduct(context.Background(), product.ID.Hex())
+	require.NoError(t, err)
+	products.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestPublishIncompleteProduct(t *testing.T) {
+	service, products := newMerchandisingService()
+	product := builders.NewProduct(t).WithStatus(domain.StatusDraft).Build()
+	product.Description, product.ImageURLs = "", nil
+	product.Slug = domain.Slugify(product.Name)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+
+	_, err := service.PublishProduct(context.Background(), product.ID.Hex())
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "incomplete product: %v", err)
+	assert.Equal(t, "INCOMPLETE_PRODUCT", apperrors.ReasonOf(err))
+	assert.ErrorContains(t, err, "description, image_urls")
+	assert.Equal(t, domain.StatusDraft, product.Status)
+	products.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestRetireProduct(t *testing.T) {
+	service, products := newMerchandisingService()
+	published := builders.NewProduct(t).Build()
+	draft := builders.NewProduct(t).WithStatus(domain.StatusDraft).Build()
+	products.On("GetByID", published.ID.Hex()).Return(published, nil)
+	products.On("GetByID", draft.ID.Hex()).Return(draft, nil)
+	products.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	retired, err := service.RetireProduct(context.Background(), published.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusRetired, retired.Status)
+	assert.False(t, retired.Visible())
+
+	// Only published products are retired
+	_, err = service.RetireProduct(context.Background(), draft.ID.Hex())
+	assert.ErrorIs(t, err, domain.ErrStatusTransition)
+	assert.True(t, apperrors.Is(err, apperrors.Conflict))
+}
+
+func TestCreateProductStatus(t *testing.T) {
+	service, products := newMerchandisingService()
+	products.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	draft := builders.NewProduct(t).WithoutID().WithStatus(domain.StatusDraft).Build()
+	created, err := service.CreateProduct(context.Background(), draft)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusDraft, created.Status)
+
+	retired := builders.NewProduct(t).WithoutID().WithStatus(domain.StatusRetired).Build()
+	_, err = service.CreateProduct(context.Background(), retired)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "retired product: %v", err)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetStockAlertRepository configures where stock alerts are stored. Until
+// it is set, inventory updates do not alert.
+func (s *ProductService) SetStockAlertRepository(alerts domain.StockAlertRepository) {
+	s.stockAlerts = alerts
+}
+
+// CreateStockAlert registers a stock alert. Products it watches that are
+// already below the threshold alert on their next inventory update.
+func (s *ProductService) CreateStockAlert(ctx context.Context, alert *domain.StockAlert) (*domain.StockAlert, error) {
+	s.logger.Info("Creating stock alert", "productID", alert.ProductID, "category", alert.Category, "threshold", alert.Threshold)
+
+	if err := s.requireStockAlerts(); err != nil {
+		return nil, err
+	}
+	if err := s.validateStockAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	alert.Alerted = []string{}
+	alert.CreatedAt = time.Now()
+	alert.UpdatedAt = alert.CreatedAt
+	if err := s.stockAlerts.Create(ctx, alert); err != nil {
+		s.logger.Error("Failed to create stock alert", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return alert, nil
+}
+
+// GetStockAlert retrieves a stock alert by its ID
+func (s *ProductService) GetStockAlert(ctx context.Context, id string) (*domain.StockAlert, error) {
+	if err := s.requireStockAlerts(); err != nil {
+		return nil, err
+	}
+	alert, err := s.stockAlerts.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return alert, nil
+}
+
+// ListStockAlerts returns a page of all stock alerts, newest first, and
+// how many there are
+func (s *ProductService) ListStockAlerts(ctx context.Context, page pagination.Request) ([]*domain.StockAlert, int, error) {
+	if err := s.requireStockAlerts(); err != nil {
+		return nil, 0, err
+	}
+	page = pagination.New(page.Page, page.PageSize, domain.StockAlertPagination)
+	alerts, total, err := s.stockAlerts.List(ctx, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("repository error: %w", err)
+	}
+	return alerts, total, nil
+}
+
+// UpdateStockAlert replaces what a stock alert watches and its threshold.
+// The products alerted for are forgotten, so those below the new
+// threshold alert again on their next inventory update.
+func (s *ProductService) UpdateStockAlert(ctx context.Context, id string, alert *domain.StockAlert) (*domain.StockAlert, error) {
+	s.logger.Info("Updating stock alert", "id", id)
+
+	if err := s.requireStockAlerts(); err != nil {
+		return nil, err
+	}
+	existing, err := s.stockAlerts.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if err := s.validateStockAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	alert.ID = existing.ID
+	alert.Alerted = []string{}
+	alert.CreatedAt = existing.CreatedAt
+	alert.UpdatedAt = time.Now()
+	if err := s.stockAlerts.Update(ctx, alert); err != nil {
+		s.logger.Error("Failed to update stock alert", "id", id, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return alert, nil
+}
+
+// DeleteStockAlert removes a stock alert
+func (s *ProductService) DeleteStockAlert(ctx context.Context, id string) error {
+	s.logger.Info("Deleting stock alert", "id", id)
+
+	if err := s.requireStockAlerts(); err != nil {
+		return err
+	}
+	if err := s.stockAlerts.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete stock alert", "id", id, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+// validateStockAlert checks the alert and that the product it watches
+// exists
+func (s *ProductService) validateStockAlert(ctx context.Context, alert *domain.StockAlert) error {
+	if err := alert.Validate(); err != nil {
+		return invalid(err)
+	}
+	if alert.ProductID == "" {
+		return nil
+	}
+	if _, err := s.repo.GetByID(ctx, alert.ProductID); err != nil {
+		if apperrors.Is(err, apperrors.NotFound) {
+			return apperrors.Newf(apperrors.Invalid, "product %q does not exist", alert.ProductID)
+		}
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+// checkStockAlerts publishes inventory.low_stock for each alert watching
+// the product whose threshold its stock is now below, unless the alert
+// has already fired for it, and re-arms the alerts whose threshold it is
+// back at. Failures are logged; the inventory update stands.
+func (s *ProductService) checkStockAlerts(ctx context.Context, productID string, inventory *domain.InventoryInfo) {
+	if s.stockAlerts == nil {
+		return
+	}
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to get product for stock alerts", "productID", productID, "error", err)
+		return
+	}
+	alerts, err := s.stockAlerts.Matching(ctx, productID, s.categoryAndAncestors(ctx, product.Category))
+	if err != nil {
+		s.logger.Error("Failed to find stock alerts", "productID", productID, "error", err)
+		return
+	}
+
+	for _, alert := range alerts {
+		below, alerted := alert.Below(inventory.Quantity), alert.HasAlerted(productID)
+		switch {
+		case below && !alerted:
+			first, err := s.stockAlerts.MarkAlerted(ctx, alert.ID, productID)
+			if err != nil {
+				s.logger.Error("Failed to record stock alert", "alertID", alert.ID.Hex(), "productID", productID, "error", err)
+				continue
+			}
+			if !first {
+				continue
+			}
+			s.logger.Info("Stock below alert threshold", "alertID", alert.ID.Hex(), "productID", productID, "quantity", inventory.Quantity, "threshold", alert.Threshold)
+			s.publish(ctx, events.InventoryLowStock, productID, events.LowStockPayload{
+				AlertID:   alert.ID.Hex(),
+				ProductID: productID,
+				Name:      product.Name,
+				SKU:       inventory.SKU,
+				Category:  product.Category,
+				Quantity:  inventory.Quantity,
+				Threshold: alert.Threshold,
+			})
+		case !below && alerted:
+			if err := s.stockAlerts.ClearAlerted(ctx, alert.ID, productID); err != nil {
+				s.logger.Error("Failed to re-arm stock alert", "alertID", alert.ID.Hex(), "productID", productID, "error", err)
+			}
+		}
+	}
+}
+
+// categoryAndAncestors returns the category and, when the category tree
+// knows it, the categories above it
+func (s *ProductService) categoryAndAncestors(ctx context.Context, key string) []string {
+	keys := []string{key}
+	if s.categories == nil {
+		return keys
+	}
+	category, err := s.categories.Get(ctx, key)
+	if err != nil {
+		if !apperrors.Is(err, apperrors.NotFound) {
+			s.logger.Error("Failed to get category", "category", key, "error", err)
+		}
+		return keys
+	}
+	return append(keys, category.Path...)
+}
+
+func (s *ProductService) requireStockAlerts() error {
+	if s.stockAlerts == nil {
+		return apperrors.New(apperrors.Unavailable, "stock alerts not available")
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStockAlertRepository keeps stock alerts in memory
+type memoryStockAlertRepository struct {
+	alerts map[primitive.ObjectID]*domain.StockAlert
+}
+
+func (r *memoryStockAlertRepository) Create(ctx context.Context, alert *domain.StockAlert) error {
+	alert.ID = primitive.NewObjectID()
+	stored := *alert
+	r.alerts[alert.ID] = &stored
+	return nil
+}
+
+func (r *memoryStockAlertRepository) Get(ctx context.Context, id string) (*domain.StockAlert, error) {
+	objID, _ := primitive.ObjectIDFromHex(id)
+	alert, ok := r.alerts[objID]
+	if !ok {
+		return nil, domain.ErrStockAlertNotFound
+	}
+	found := *alert
+	return &found, nil
+}
+
+func (r *memoryStockAlertRepository) Update(ctx context.Context, alert *domain.StockAlert) error {
+	if _, ok := r.alerts[alert.ID]; !ok {
+		return domain.ErrStockAlertNotFound
+	}
+	stored := *alert
+	r.alerts[alert.ID] = &stored
+	return nil
+}
+
+func (r *memoryStockAlertRepository) Delete(ctx context.Context, id string) error {
+	objID, _ := primitive.ObjectIDFromHex(id)
+	if _, ok := r.alerts[objID]; !ok {
+		return domain.ErrStockAlertNotFound
+	}
+	delete(r.alerts, objID)
+	return nil
+}
+
+func (r *memoryStockAlertRepository) List(ctx context.Context, page pagination.Request) ([]*domain.StockAlert, int, error) {
+	var alerts []*domain.StockAlert
+	for _, alert := range r.alerts {
+		found := *alert
+		alerts = append(alerts, &found)
+	}
+	return alerts, len(alerts), nil
+}
+
+func (r *memoryStockAlertRepository) Matching(ctx context.Context, productID string, categories []string) ([]*domain.StockAlert, error) {
+	var alerts []*domain.StockAlert
+	for _, alert := range r.alerts {
+		if alert.ProductID == productID || (alert.Category != "" && slices.Contains(categories, alert.Category)) {
+			found := *alert
+			alerts = append(alerts, &found)
+		}
+	}
+	return alerts, nil
+}
+
+func (r *memoryStockAlertRepository) MarkAlerted(ctx context.Context, id primitive.ObjectID, productID string) (bool, error) {
+	alert := r.alerts[id]
+	if alert.HasAlerted(productID) {
+		return false, nil
+	}
+	alert.Alerted = append(alert.Alerted, productID)
+	return true, nil
+}
+
+func (r *memoryStockAlertRepository) ClearAlerted(ctx context.Context, id primitive.ObjectID, productID string) error {
+	alert := r.alerts[id]
+	var kept []string
+	for _, alerted := range alert.Alerted {
+		if alerted != productID {
+			kept = append(kept, alerted)
+		}
+	}
+	alert.Alerted = kept
+	return nil
+}
+
+func newStockAlertTestService(t *testing.T) (*ProductService, *MockProductRepository, *recordingPublisher) {
+	service, products, _ := newCategoryTestService(t)
+	service.SetStockAlertRepository(&memoryStockAlertRepository{alerts: make(map[primitive.ObjectID]*domain.StockAlert)})
+	publisher := &recordingPublisher{}
+	service.SetPublisher(publisher)
+	return service, products, publisher
+}
+
+// setStock makes the product's stock change to quantity on the next
+// inventory adjustment
+func setStock(products *MockProductRepository, productID string, quantity int) {
+	products.On("UpdateInventory", productID, 0, "op", "adjustment").
+		Return(&domain.InventoryInfo{Quantity: quantity, SKU: "MUG-1", InStock: quantity > 0}, nil).Once()
+}
+
+// lowStockEvents returns the payloads of the inventory.low_stock events
+// published
+func lowStockEvents(t *testing.T, publisher *recordingPublisher) []events.LowStockPayload {
+	var payloads []events.LowStockPayload
+	for _, event := range publisher.events {
+		if event.Type != events.InventoryLowStock {
+			continue
+		}
+		var payload events.LowStockPayload
+		require.NoError(t, event.Decode(&payload))
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestStockAlertFiresOncePerDrop(t *testing.T) {
+	service, products, publisher := newStockAlertTestService(t)
+	product := builders.NewProduct(t).WithName("Mug").WithCategory("audio").Build()
+	productID := product.ID.Hex()
+	products.On("GetByID", productID).Return(product, nil)
+
+	alert, err := service.CreateStockAlert(context.Background(), &domain.StockAlert{ProductID: productID, Threshold: 10})
+	require.NoError(t, err)
+
+	// Above, below, further below, restocked, below again
+	for _, quantity := range []int{12, 9, 3, 10, 4} {
+		setStock(products, productID, quantity)
+		_, err := service.UpdateInventory(context.Background(), productID, 0, "op", "adjustment")
+		require.NoError(t, err)
+	}
+
+	alerts := lowStockEvents(t, publisher)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, events.LowStockPayload{
+		AlertID:   alert.ID.Hex(),
+		ProductID: productID,
+		Name:      "Mug",
+		SKU:       "MUG-1",
+		Category:  "audio",
+		Quantity:  9,
+		Threshold: 10,
+	}, alerts[0])
+	assert.Equal(t, 4, alerts[1].Quantity, "re-armed by the restock")
+
+	stored, err := service.GetStockAlert(context.Background(), alert.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{productID}, stored.Alerted)
+}
+
+func TestStockAlertForCategoryCoversSubcategories(t *testing.T) {
+	service, products, publisher := newStockAlertTestService(t)
+	phone := builders.NewProduct(t).WithCategory("smartphones").Build()
+	headphones := builders.NewProduct(t).WithCategory("audio").Build()
+	for _, product := range []*domain.Product{phone, headphones} {
+		products.On("GetByID", product.ID.Hex()).Return(product, nil)
+		setStock(products, product.ID.Hex(), 2)
+	}
+
+	_, err := service.CreateStockAlert(context.Background(), &domain.StockAlert{Category: "electronics", Threshold: 5})
+	require.NoError(t, err)
+	for _, product := range []*domain.Product{phone, headphones} {
+		_, err := service.UpdateInventory(context.Background(), product.ID.Hex(), 0, "op", "adjustment")
+		require.NoError(t, err)
+	}
+
+	alerts := lowStockEvents(t, publisher)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, phone.ID.Hex(), alerts[0].ProductID)
+}
+
+func TestUpdateStockAlertRearms(t *testing.T) {
+	service, products, publisher := newStockAlertTestService(t)
+	product := builders.NewProduct(t).Build()
+	productID := product.ID.Hex()
+	products.On("GetByID", productID).Return(product, nil)
+
+	alert, err := service.CreateStockAlert(context.Background(), &domain.StockAlert{ProductID: productID, Threshold: 10})
+	require.NoError(t, err)
+	setStock(products, productID, 5)
+	_, err = service.UpdateInventory(context.Background(), productID, 0, "op", "adjustment")
+	require.NoError(t, err)
+
+	updated, err := service.UpdateStockAlert(context.Background(), alert.ID.Hex(), &domain.StockAlert{ProductID: productID, Threshold: 8})
+	require.NoError(t, err)
+	assert.Equal(t, alert.CreatedAt, updated.CreatedAt)
+	assert.Empty(t, updated.Alerted)
+
+	setStock(products, productID, 5)
+	_, err = service.UpdateInventory(context.Background(), productID, 0, "op", "adjustment")
+	require.NoError(t, err)
+	assert.Len(t, lowStockEvents(t, publisher), 2)
+}
+
+func TestStockAlertErrors(t *testing.T) {
+	service, products, _ := newStockAlertTestService(t)
+	missing := primitive.NewObjectID().Hex()
+	products.On("GetByID", missing).Return(nil, domain.ErrProductNotFound)
+
+	invalid := map[string]*domain.StockAlert{
+		"nothing watched":      {Threshold: 5},
+		"product and category": {ProductID: missing, Category: "audio", Threshold: 5},
+		"bad product ID":       {ProductID: "mug", Threshold: 5},
+		"zero threshold":       {Category: "audio"},
+		"threshold too high":   {Category: "audio", Threshold: domain.MaxStockAlertThreshold + 1},
+		"unknown product":      {ProductID: missing, Threshold: 5},
+	}
+	for name, alert := range invalid {
+		_, err := service.CreateStockAlert(context.Background(), alert)
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "%s: %v", name, err)
+	}
+
+	_, err := service.UpdateStockAlert(context.Background(), missing, &domain.StockAlert{Category: "audio", Threshold: 5})
+	assert.ErrorIs(t, err, domain.ErrStockAlertNotFound)
+	assert.ErrorIs(t, service.DeleteStockAlert(context.Background(), missing), domain.ErrStockAlertNotFound)
+
+	_, err = New(new(MockProductRepository), service.logger).CreateStockAlert(context.Background(), &domain.StockAlert{Category: "audio", Threshold: 5})
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable), "without a repository: %v", err)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetSuggestOptions configures the limits and latency budget of search
+// suggestions
+func (s *ProductService) SetSuggestOptions(opts domain.SuggestOptions) {
+	s.suggest = opts
+}
+
+// Suggest returns up to limit completions of what a shopper has typed:
+// the categories whose name starts with it, at most a third of the
+// suggestions, then the active products whose name does. Suggestions found
+// within the latency budget are returned even if the rest are not.
+func (s *ProductService) Suggest(ctx context.Context, query string, limit int) ([]domain.Suggestion, error) {
+	prefix := domain.NormalizeSearchName(query)
+	if prefix == "" {
+		return nil, apperrors.New(apperrors.Invalid, "query is required")
+	}
+	if len(prefix) > domain.MaxSuggestPrefix {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("query must be at most %d bytes", domain.MaxSuggestPrefix))
+	}
+	if limit == 0 {
+		limit = s.suggest.DefaultLimit
+	}
+	if limit < 1 || limit > s.suggest.MaxLimit {
+		return nil, apperrors.New(apperrors.Invalid, fmt.Sprintf("limit must be between 1 and %d", s.suggest.MaxLimit))
+	}
+
+	budgetCtx, cancel := context.WithTimeout(ctx, s.suggest.Budget)
+	defer cancel()
+
+	suggestions := []domain.Suggestion{}
+	if s.categories != nil {
+		categories, err := s.categories.List(budgetCtx)
+		if err != nil {
+			return s.partialSuggestions(ctx, budgetCtx, suggestions, err)
+		}
+		for _, category := range categories {
+			if len(suggestions) == (limit+2)/3 {
+				break
+			}
+			if strings.HasPrefix(domain.NormalizeSearchName(category.Name), prefix) {
+				suggestions = append(suggestions, domain.Suggestion{
+					Type:     domain.SuggestionCategory,
+					Text:     category.Name,
+					Category: category.Key,
+				})
+			}
+		}
+	}
+
+	products, err := s.repo.Suggest(budgetCtx, prefix, limit-len(suggestions))
+	if err != nil {
+		return s.partialSuggestions(ctx, budgetCtx, suggestions, err)
+	}
+	return append(suggestions, products...), nil
+}
+
+// partialSuggestions returns the suggestions found before the latency
+// budget ran out, or the error if something else went wrong
+func (s *ProductService) partialSuggestions(ctx, budgetCtx context.Context, suggestions []domain.Suggestion, err error) ([]domain.Suggestion, error) {
+	if budgetCtx.Err() != nil && ctx.Err() == nil {
+		s.logger.Warn("Suggestions exceeded their latency budget", "budget", s.suggest.Budget, "found", len(suggestions))
+		return suggestions, nil
+	}
+	s.logger.Error("Failed to find suggestions", "error", err)
+	return nil, fmt.Errorf("repository error: %w", err)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+	phone := domain.Suggestion{Type: domain.SuggestionProduct, Text: "Phone Case", ProductID: "65f1c0d2e4b0a1b2c3d4e5f1", Category: "phones"}
+	products.On("Suggest", "ph", 2).Return([]domain.Suggestion{phone}, nil)
+
+	suggestions, err := service.Suggest(context.Background(), "  PH ", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Suggestion{
+		{Type: domain.SuggestionCategory, Text: "Phones", Category: "phones"},
+		phone,
+	}, suggestions)
+
+	products.On("Suggest", "zebra", domain.DefaultSuggestOptions.DefaultLimit).Return([]domain.Suggestion{}, nil)
+	suggestions, err = service.Suggest(context.Background(), "Zebra", 0)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+
+	invalid := map[string]struct {
+		query string
+		limit int
+	}{
+		"blank query":     {"   ", 5},
+		"long query":      {strings.Repeat("a", domain.MaxSuggestPrefix+1), 5},
+		"negative limit":  {"ph", -1},
+		"limit too large": {"ph", domain.DefaultSuggestOptions.MaxLimit + 1},
+	}
+	for name, tt := range invalid {
+		_, err := service.Suggest(context.Background(), tt.query, tt.limit)
+		assert.True(t, apperrors.Is(err, apperrors.Invalid), "%s: %v", name, err)
+	}
+}
+
+func TestSuggestLatencyBudget(t *testing.T) {
+	service, products, _ := newCategoryTestService(t)
+	service.SetSuggestOptions(domain.SuggestOptions{DefaultLimit: 6, MaxLimit: 10, Budget: time.Millisecond})
+	products.On("Suggest", "audio", 5).WaitUntil(time.After(20*time.Millisecond)).Return(nil, context.DeadlineExceeded)
+	products.On("Suggest", "smart", 5).Return(nil, errors.New("connection refused"))
+
+	suggestions, err := service.Suggest(context.Background(), "audio", 0)
+	require.NoError(t, err, "past the budget the suggestions found so far are returned")
+	assert.Equal(t, []domain.Suggestion{{Type: domain.SuggestionCategory, Text: "Audio", Category: "audio"}}, suggestions)
+
+	// Within the budget, failures are errors
+	service.SetSuggestOptions(domain.SuggestOptions{DefaultLimit: 6, MaxLimit: 10, Budget: time.Minute})
+	_, err = service.Suggest(context.Background(), "smart", 0)
+	assert.Error(t, err)
+}
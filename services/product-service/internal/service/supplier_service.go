@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetSupplierRepository configures where suppliers are stored
+func (s *ProductService) SetSupplierRepository(suppliers domain.SupplierRepository) {
+	s.suppliers = suppliers
+}
+
+// CreateSupplier creates a new supplier
+func (s *ProductService) CreateSupplier(ctx context.Context, supplier *domain.Supplier) (*domain.Supplier, error) {
+	s.logger.Info("Creating supplier", "code", supplier.Code)
+
+	if err := s.requireSuppliers(); err != nil {
+		return nil, err
+	}
+	if err := validateSupplier(supplier); err != nil {
+		return nil, invalid(err)
+	}
+
+	supplier.ID = primitive.NewObjectID()
+	supplier.Active = true
+	supplier.CreatedAt = time.Now()
+	supplier.UpdatedAt = supplier.CreatedAt
+	if err := s.suppliers.Create(ctx, supplier); err != nil {
+		s.logger.Error("Failed to create supplier", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Supplier created successfully", "id", supplier.ID.Hex())
+	return supplier, nil
+}
+
+// GetSupplier retrieves a supplier by ID
+func (s *ProductService) GetSupplier(ctx context.Context, id string) (*domain.Supplier, error) {
+	if err := s.requireSuppliers(); err != nil {
+		return nil, err
+	}
+	supplier, err := s.suppliers.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return supplier, nil
+}
+
+// UpdateSupplier replaces the details of an existing supplier
+func (s *ProductService) UpdateSupplier(ctx context.Context, supplier *domain.Supplier) (*domain.Supplier, error) {
+	s.logger.Info("Updating supplier", "id", supplier.ID.Hex())
+
+	if err := s.requireSuppliers(); err != nil {
+		return nil, err
+	}
+	if err := validateSupplier(supplier); err != nil {
+		return nil, invalid(err)
+	}
+
+	existing, err := s.suppliers.GetByID(ctx, supplier.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	supplier.CreatedAt = existing.CreatedAt
+	supplier.UpdatedAt = time.Now()
+	if err := s.suppliers.Update(ctx, supplier); err != nil {
+		s.logger.Error("Failed to update supplier", "id", supplier.ID.Hex(), "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Supplier updated successfully", "id", supplier.ID.Hex())
+	return supplier, nil
+}
+
+// DeleteSupplier removes a supplier that no product or purchase order
+// refers to
+func (s *ProductService) DeleteSupplier(ctx context.Context, id string) error {
+	s.logger.Info("Deleting supplier", "id", id)
+
+	if err := s.requireSuppliers(); err != nil {
+		return err
+	}
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return fmt.Errorf("repository error: %w", domain.ErrSupplierNotFound)
+	}
+
+	_, linked, err := s.repo.List(ctx, domain.ListProductsParams{SupplierID: id, PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if linked > 0 {
+		return fmt.Errorf("%w: linked to %d products", domain.ErrSupplierInUse, linked)
+	}
+	if s.orders != nil {
+		orders, err := s.orders.List(ctx, domain.PurchaseOrderFilter{SupplierID: id, Limit: 1})
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		if len(orders) > 0 {
+			return fmt.Errorf("%w: has purchase orders", domain.ErrSupplierInUse)
+		}
+	}
+
+	if err := s.suppliers.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete supplier", "id", id, "error", err)
+		return fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Supplier deleted successfully", "id", id)
+	return nil
+}
+
+// ListSuppliers returns all suppliers, or only active ones
+func (s *ProductService) ListSuppliers(ctx context.Context, activeOnly bool) ([]*domain.Supplier, error) {
+	if err := s.requireSuppliers(); err != nil {
+		return nil, err
+	}
+	suppliers, err := s.suppliers.List(ctx, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return suppliers, nil
+}
+
+// SetProductSuppliers replaces the suppliers a product can be reordered
+// from. An empty list unlinks all suppliers.
+func (s *ProductService) SetProductSuppliers(ctx context.Context, productID string, links []domain.ProductSupplier) (*domain.Product, error) {
+	s.logger.Info("Setting product suppliers", "productID", productID, "count", len(links))
+
+	if err := s.validateProductSuppliers(ctx, links); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	before := s.auditFields(product)
+	product.Suppliers = links
+	product.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.logger.Error("Failed to update product suppliers", "productID", productID, "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.audit(ctx, domain.AuditUpdate, productID, before, product)
+	s.merchandise(product)
+	s.publish(ctx, events.ProductUpdated, productID, product)
+	return product, nil
+}
+
+// Helper functions
+
+func (s *ProductService) requireSuppliers() error {
+	if s.suppliers == nil {
+		return apperrors.New(apperrors.Unavailable, "suppliers not available")
+	}
+	return nil
+}
+
+// validateProductSuppliers checks that every linked supplier exists and is
+// linked once, with at most one preferred supplier
+func (s *ProductService) validateProductSuppliers(ctx context.Context, links []domain.ProductSupplier) error {
+	if len(links) == 0 {
+		return nil
+	}
+	if err := s.requireSuppliers(); err != nil {
+		return err
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(links))
+	preferred := 0
+	for _, link := range links {
+		if link.SupplierID.IsZero() {
+			return invalid(errors.New("supplier ID is required"))
+		}
+		if seen[link.SupplierID] {
+			return invalid(fmt.Errorf("supplier %s is linked twice", link.SupplierID.Hex()))
+		}
+		seen[link.SupplierID] = true
+		if link.LeadTimeDays < 0 {
+			return invalid(errors.New("lead time cannot be negative"))
+		}
+		if link.Preferred {
+			preferred++
+		}
+
+		_, err := s.suppliers.GetByID(ctx, link.SupplierID.Hex())
+		if errors.Is(err, domain.ErrSupplierNotFound) {
+			return invalid(fmt.Errorf("unknown supplier %s", link.SupplierID.Hex()))
+		}
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+	}
+	if preferred > 1 {
+		return invalid(errors.New("only one supplier can be preferred"))
+	}
+	return nil
+}
+
+// validateSupplier performs basic validation on supplier data
+func validateSupplier(supplier *domain.Supplier) error {
+	supplier.Code = strings.ToUpper(strings.TrimSpace(supplier.Code))
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	if supplier.Code == "" {
+		return errors.New("supplier code is required")
+	}
+	if supplier.Name == "" {
+		return errors.New("supplier name is required")
+	}
+	if supplier.LeadTimeDays < 0 {
+		return errors.New("lead time cannot be negative")
+	}
+	return nil
+}
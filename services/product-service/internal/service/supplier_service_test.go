@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/bekbull/online-shop/services/product-service/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockSupplierRepository is a mock implementation of the domain.SupplierRepository interface
+type MockSupplierRepository struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepository) Create(ctx context.Context, supplier *domain.Supplier) error {
+	return m.Called(supplier).Error(0)
+}
+
+func (m *MockSupplierRepository) GetByID(ctx context.Context, id string) (*domain.Supplier, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) Update(ctx context.Context, supplier *domain.Supplier) error {
+	return m.Called(supplier).Error(0)
+}
+
+func (m *MockSupplierRepository) Delete(ctx context.Context, id string) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockSupplierRepository) List(ctx context.Context, activeOnly bool) ([]*domain.Supplier, error) {
+	args := m.Called(activeOnly)
+	return args.Get(0).([]*domain.Supplier), args.Error(1)
+}
+
+func newSupplierTestService() (*ProductService, *MockProductRepository, *MockSupplierRepository) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	products := new(MockProductRepository)
+	suppliers := new(MockSupplierRepository)
+	service := New(products, logger)
+	service.SetSupplierRepository(suppliers)
+	return service, products, suppliers
+}
+
+func TestCreateSupplier(t *testing.T) {
+	service, _, suppliers := newSupplierTestService()
+	suppliers.On("Create", mock.AnythingOfType("*domain.Supplier")).Return(nil)
+
+	supplier, err := service.CreateSupplier(context.Background(), &domain.Supplier{Code: " acme ", Name: "Acme Corp", LeadTimeDays: 7})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ACME", supplier.Code)
+	assert.True(t, supplier.Active)
+	assert.False(t, supplier.ID.IsZero())
+
+	_, err = service.CreateSupplier(context.Background(), &domain.Supplier{Code: "X", Name: "X", LeadTimeDays: -1})
+	assert.ErrorContains(t, err, "validation error")
+}
+
+func TestDeleteSupplier(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	inUse := primitive.NewObjectID().Hex()
+	unused := primitive.NewObjectID().Hex()
+	products.On("List", domain.ListProductsParams{SupplierID: inUse, PageSize: 1}).Return([]*domain.Product{builders.NewProduct(t).Build()}, 3, nil)
+	products.On("List", domain.ListProductsParams{SupplierID: unused, PageSize: 1}).Return([]*domain.Product{}, 0, nil)
+	suppliers.On("Delete", unused).Return(nil)
+
+	err := service.DeleteSupplier(context.Background(), inUse)
+	assert.ErrorIs(t, err, domain.ErrSupplierInUse)
+	suppliers.AssertNotCalled(t, "Delete", inUse)
+
+	assert.NoError(t, service.DeleteSupplier(context.Background(), unused))
+}
+
+func TestSetProductSuppliers(t *testing.T) {
+	service, products, suppliers := newSupplierTestService()
+	publisher := &recordingPublisher{}
+	service.SetPublisher(publisher)
+
+	product := builders.NewProduct(t).Build()
+	acme := builders.NewSupplier(t).WithCode("ACME").Build()
+	globex := builders.NewSupplier(t).WithCode("GLOBEX").Build()
+	unknown := primitive.NewObjectID()
+	suppliers.On("GetByID", acme.ID.Hex()).Return(acme, nil)
+	suppliers.On("GetByID", globex.ID.Hex()).Return(globex, nil)
+	suppliers.On("GetByID", unknown.Hex()).Return(nil, domain.ErrSupplierNotFound)
+	products.On("GetByID", product.ID.Hex()).Return(product, nil)
+	products.On("Update", mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	tests := []struct {
+		name    string
+		links   []domain.ProductSupplier
+		wantErr string
+	}{
+		{"unknown supplier", []domain.ProductSupplier{{SupplierID: unknown}}, "unknown supplier"},
+		{"duplicate supplier", []domain.ProductSupplier{{SupplierID: acme.ID}, {SupplierID: acme.ID}}, "linked twice"},
+		{"two preferred suppliers", []domain.ProductSupplier{
+			{SupplierID: acme.ID, Preferred: true},
+			{SupplierID: globex.ID, Preferred: true},
+		}, "only one supplier can be preferred"},
+		{"negative lead time", []domain.ProductSupplier{{SupplierID: acme.ID, LeadTimeDays: -2}}, "lead time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetProductSuppliers(context.Background(), product.ID.Hex(), tt.links)
+
+			assert.ErrorContains(t, err, "validation error")
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+	products.AssertNotCalled(t, "Update", mock.Anything)
+
+	links := []domain.ProductSupplier{
+		{SupplierID: acme.ID, SupplierSKU: "AC-100", LeadTimeDays: 5, Preferred: true},
+		{SupplierID: globex.ID, SupplierSKU: "GX-9"},
+	}
+	updated, err := service.SetProductSuppliers(context.Background(), product.ID.Hex(), links)
+
+	assert.NoError(t, err)
+	assert.Equal(t, links, updated.Suppliers)
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, events.ProductUpdated, publisher.events[0].Type)
+}
+
+func TestListProductsBySupplier(t *testing.T) {
+	service, products, _ := newSupplierTestService()
+	supplierID := primitive.NewObjectID().Hex()
+	products.On("List", domain.ListProductsParams{PageSize: 20, SupplierID: supplierID}).Return([]*domain.Product{builders.NewProduct(t).Build()}, 1, nil)
+
+	list, total, err := service.ListProducts(context.Background(), domain.ListProductsParams{SupplierID: supplierID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, list, 1)
+
+	_, _, err = service.ListProducts(context.Background(), domain.ListProductsParams{SupplierID: "acme"})
+	assert.EqualError(t, err, "invalid supplier ID")
+}
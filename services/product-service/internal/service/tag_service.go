@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+)
+
+// SetTagsCacheTTL configures how long the tags in use are cached by
+// ListTags
+func (s *ProductService) SetTagsCacheTTL(ttl time.Duration) {
+	s.tagsMu.Lock()
+	defer s.tagsMu.Unlock()
+	s.tagsTTL = ttl
+	s.tagsLoaded = time.Time{}
+}
+
+// ListTags returns the tags used on products, with the number of products
+// carrying each, in alphabetical order ignoring case, so that tags which
+// differ only in case sit together. Only tags starting with prefix are
+// returned, ignoring case, if it is set. Counting needs the whole catalog,
+// so the counts are cached until a product is written, through this
+// instance or, with a cache invalidator, another; changes made otherwise
+// show once the cache TTL has passed.
+func (s *ProductService) ListTags(ctx context.Context, prefix string) ([]domain.TagCount, error) {
+	s.logger.Info("Listing tags", "prefix", prefix)
+
+	tags, err := s.cachedTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	matching := []domain.TagCount{}
+	for _, tag := range tags {
+		if strings.HasPrefix(strings.ToLower(tag.Tag), prefix) {
+			matching = append(matching, tag)
+		}
+	}
+	return matching, nil
+}
+
+// cachedTags returns the cached tags, counting them again once the cache
+// has expired. The lock is held while counting, so that concurrent
+// requests count once.
+func (s *ProductService) cachedTags(ctx context.Context) ([]domain.TagCount, error) {
+	s.tagsMu.Lock()
+	defer s.tagsMu.Unlock()
+	if !s.tagsLoaded.IsZero() && time.Since(s.tagsLoaded) < s.tagsTTL {
+		return s.tags, nil
+	}
+
+	tags, err := s.repo.ListTags(ctx)
+	if err != nil {
+		s.logger.Error("Failed to count tags", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	slices.SortStableFunc(tags, func(a, b domain.TagCount) int {
+		return strings.Compare(strings.ToLower(a.Tag), strings.ToLower(b.Tag))
+	})
+	s.tags, s.tagsLoaded = tags, time.Now()
+	return tags, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTags(t *testing.T) {
+	products := new(MockProductRepository)
+	service := New(products, slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	products.On("ListTags").Return([]domain.TagCount{
+		{Tag: "Kitchen", Products: 1},
+		{Tag: "gift", Products: 4},
+		{Tag: "kitchen", Products: 12},
+	}, nil).Once()
+
+	tags, err := service.ListTags(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TagCount{
+		{Tag: "gift", Products: 4},
+		{Tag: "Kitchen", Products: 1},
+		{Tag: "kitchen", Products: 12},
+	}, tags, "tags differing only in case sit together")
+
+	// Served from the cache
+	tags, err = service.ListTags(context.Background(), " KIT")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.TagCount{{Tag: "Kitchen", Products: 1}, {Tag: "kitchen", Products: 12}}, tags)
+	products.AssertNumberOfCalls(t, "ListTags", 1)
+
+	// Counted again once the cache expires
+	service.SetTagsCacheTTL(time.Nanosecond)
+	products.On("ListTags").Return(nil, errors.New("connection refused")).Once()
+	_, err = service.ListTags(context.Background(), "")
+	assert.Error(t, err)
+	products.AssertNumberOfCalls(t, "ListTags", 2)
+}
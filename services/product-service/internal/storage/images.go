@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/objectstore"
+)
+
+// ImageStore keeps product images in an S3-compatible bucket and links to
+// them under a public base URL, the bucket itself or a CDN in front of it
+type ImageStore struct {
+	objects   *objectstore.S3
+	publicURL string
+}
+
+// NewImageStore creates an image store writing to objects and linking to
+// images under publicURL
+func NewImageStore(objects *objectstore.S3, publicURL string) *ImageStore {
+	return &ImageStore{objects: objects, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// Put stores an image under key and returns its public URL
+func (s *ImageStore) Put(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	if err := s.objects.Put(ctx, key, contentType, body); err != nil {
+		return "", err
+	}
+	return s.publicURL + "/" + key, nil
+}
+
+// Delete removes the image stored under key
+func (s *ImageStore) Delete(ctx context.Context, key string) error {
+	return s.objects.Delete(ctx, key)
+}
+
+// Key returns the key of the image at url, or false if the image is not
+// kept in the store
+func (s *ImageStore) Key(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.publicURL+"/")
+	return key, ok && key != ""
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/objectstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageStore(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	objects, err := objectstore.NewS3(server.URL+"/images", "us-east-1", "key", "secret")
+	require.NoError(t, err)
+	store := NewImageStore(objects, "https://cdn.example.com/")
+
+	url, err := store.Put(context.Background(), "products/1/a.png", "image/png", []byte("png"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/products/1/a.png", url)
+
+	key, ok := store.Key(url)
+	assert.True(t, ok)
+	assert.Equal(t, "products/1/a.png", key)
+	_, ok = store.Key("https://img.example.com/products/1/a.png")
+	assert.False(t, ok, "images hosted elsewhere have no key")
+
+	require.NoError(t, store.Delete(context.Background(), key))
+	assert.Equal(t, []string{"PUT /images/products/1/a.png", "DELETE /images/products/1/a.png"}, requests)
+}
@@ -0,0 +1,209 @@
+// Package builders builds products and suppliers for tests, filled with
+// values that are reproducible per test. Set only what the test is about:
+//
+//	product := builders.NewProduct(t).WithPrice(9.99).WithStock(3).Build()
+package builders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/testutil/builders"
+	"github.com/bekbull/online-shop/services/product-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductBuilder builds a domain.Product
+type ProductBuilder struct {
+	product domain.Product
+}
+
+// NewProduct returns a builder for a published, active, in-stock product
+func NewProduct(t testing.TB) *ProductBuilder {
+	fill := builders.For(t)
+	material, item := fill.Word(), fill.Pick("mug", "lamp", "bag", "scarf", "bottle", "notebook")
+	created := fill.Time()
+	quantity := fill.IntRange(10, 100)
+	return &ProductBuilder{product: domain.Product{
+		ID:          fill.ObjectID(),
+		Name:        strings.ToUpper(material[:1]) + material[1:] + " " + item,
+		Description: fill.Sentence(6),
+		Price:       fill.Price(5, 200),
+		ImageURLs:   []string{"https://img.example.com/" + material + "-" + item + ".jpg"},
+		Category:    fill.Pick("home", "kitchen", "outdoor", "office", "apparel"),
+		Inventory: domain.InventoryInfo{
+			Quantity: quantity,
+			SKU:      fill.SKU(item[:3]),
+			InStock:  true,
+		},
+		Tags:       []string{material, item},
+		Attributes: map[string]string{"material": material},
+		Active:     true,
+		Status:     domain.StatusPublished,
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	}}
+}
+
+// WithID sets the product ID
+func (b *ProductBuilder) WithID(id primitive.ObjectID) *ProductBuilder {
+	b.product.ID = id
+	return b
+}
+
+// WithoutID clears the ID, as for a product about to be created
+func (b *ProductBuilder) WithoutID() *ProductBuilder {
+	b.product.ID = primitive.NilObjectID
+	return b
+}
+
+// WithName sets the name
+func (b *ProductBuilder) WithName(name string) *ProductBuilder {
+	b.product.Name = name
+	return b
+}
+
+// WithPrice sets the price
+func (b *ProductBuilder) WithPrice(price float64) *ProductBuilder {
+	b.product.Price = price
+	return b
+}
+
+// WithCategory sets the category
+func (b *ProductBuilder) WithCategory(category string) *ProductBuilder {
+	b.product.Category = category
+	return b
+}
+
+// WithSKU sets the SKU
+func (b *ProductBuilder) WithSKU(sku string) *ProductBuilder {
+	b.product.Inventory.SKU = sku
+	return b
+}
+
+// WithStock sets the quantity on hand, and whether the product is in stock
+func (b *ProductBuilder) WithStock(quantity int) *ProductBuilder {
+	b.product.Inventory.Quantity = quantity
+	b.product.Inventory.InStock = quantity > 0
+	return b
+}
+
+// WithReserved sets the quantity reserved for pending orders
+func (b *ProductBuilder) WithReserved(reserved int) *ProductBuilder {
+	b.product.Inventory.Reserved = reserved
+	return b
+}
+
+// WithTags sets the tags
+func (b *ProductBuilder) WithTags(tags ...string) *ProductBuilder {
+	b.product.Tags = tags
+	return b
+}
+
+// WithAttribute sets one attribute
+func (b *ProductBuilder) WithAttribute(key, value string) *ProductBuilder {
+	if b.product.Attributes == nil {
+		b.product.Attributes = make(map[string]string)
+	}
+	b.product.Attributes[key] = value
+	return b
+}
+
+// WithSupplier links the product to a supplier
+func (b *ProductBuilder) WithSupplier(supplierID primitive.ObjectID, supplierSKU string) *ProductBuilder {
+	b.product.Suppliers = append(b.product.Suppliers, domain.ProductSupplier{SupplierID: supplierID, SupplierSKU: supplierSKU})
+	return b
+}
+
+// WithPreorder puts the product on preorder until releaseDate
+func (b *ProductBuilder) WithPreorder(releaseDate time.Time, allocation int) *ProductBuilder {
+	b.product.ReleaseDate = &releaseDate
+	b.product.Preorder = &domain.PreorderInfo{Allocation: allocation}
+	return b
+}
+
+// Inactive marks the product inactive
+func (b *ProductBuilder) Inactive() *ProductBuilder {
+	b.product.Active = false
+	return b
+}
+
+// WithStatus sets the status
+func (b *ProductBuilder) WithStatus(status string) *ProductBuilder {
+	b.product.Status = status
+	return b
+}
+
+// Build returns the product. Each call returns a new copy.
+func (b *ProductBuilder) Build() *domain.Product {
+	product := b.product
+	product.ImageURLs = append([]string(nil), b.product.ImageURLs...)
+	product.Tags = append([]string(nil), b.product.Tags...)
+	product.Suppliers = append([]domain.ProductSupplier(nil), b.product.Suppliers...)
+	if b.product.Preorder != nil {
+		preorder := *b.product.Preorder
+		product.Preorder = &preorder
+	}
+	if b.product.Attributes != nil {
+		product.Attributes = make(map[string]string, len(b.product.Attributes))
+		for key, value := range b.product.Attributes {
+			product.Attributes[key] = value
+		}
+	}
+	return &product
+}
+
+// SupplierBuilder builds a domain.Supplier
+type SupplierBuilder struct {
+	supplier domain.Supplier
+}
+
+// NewSupplier returns a builder for an active supplier
+func NewSupplier(t testing.TB) *SupplierBuilder {
+	fill := builders.For(t)
+	name := fill.LastName()
+	created := fill.Time()
+	return &SupplierBuilder{supplier: domain.Supplier{
+		ID:           fill.ObjectID(),
+		Code:         strings.ToUpper(name),
+		Name:         name + " " + fill.Pick("Trading", "Supply", "Wholesale", "Goods"),
+		ContactName:  fill.FirstName() + " " + name,
+		Email:        fill.Email("orders", name),
+		Phone:        fill.Phone(),
+		LeadTimeDays: fill.IntRange(2, 21),
+		Active:       true,
+		CreatedAt:    created,
+		UpdatedAt:    created,
+	}}
+}
+
+// WithID sets the supplier ID
+func (b *SupplierBuilder) WithID(id primitive.ObjectID) *SupplierBuilder {
+	b.supplier.ID = id
+	return b
+}
+
+// WithCode sets the supplier code
+func (b *SupplierBuilder) WithCode(code string) *SupplierBuilder {
+	b.supplier.Code = code
+	return b
+}
+
+// WithLeadTime sets the default lead time in days
+func (b *SupplierBuilder) WithLeadTime(days int) *SupplierBuilder {
+	b.supplier.LeadTimeDays = days
+	return b
+}
+
+// Inactive marks the supplier inactive
+func (b *SupplierBuilder) Inactive() *SupplierBuilder {
+	b.supplier.Active = false
+	return b
+}
+
+// Build returns the supplier. Each call returns a new copy.
+func (b *SupplierBuilder) Build() *domain.Supplier {
+	supplier := b.supplier
+	return &supplier
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/grpcclient"
+	"github.com/bekbull/online-shop/pkg/leader"
+	"github.com/bekbull/online-shop/pkg/locks"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	productv1 "github.com/bekbull/online-shop/proto/product/v1"
+	"github.com/bekbull/online-shop/services/search-indexer/config"
+	"github.com/bekbull/online-shop/services/search-indexer/internal/elasticsearch"
+	"github.com/bekbull/online-shop/services/search-indexer/internal/indexer"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	reindexOnly := flag.Bool("reindex", false, "rebuild the index from product-service and exit")
+	flag.Parse()
+
+	// Initialize logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	logger.Info("Starting Search Indexer")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Load TLS credentials; certificates are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	go creds.Watch(ctx)
+
+	// Connect to product-service for full reindexing
+	conn, err := grpcclient.New(cfg.Discovery, creds, logger).Dial("product", cfg.ProductService.Addr)
+	if err != nil {
+		logger.Error("Failed to create product-service client", "error", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	// Create indexer
+	esClient := elasticsearch.New(cfg.Elasticsearch.URL, cfg.Elasticsearch.Username, cfg.Elasticsearch.Password, cfg.Elasticsearch.HTTPTimeout)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	idx := indexer.New(
+		esClient,
+		indexer.NewGRPCProductSource(productv1.NewProductServiceClient(conn)),
+		cfg.Elasticsearch.IndexAlias,
+		elasticsearch.IndexSettings{
+			Shards:   cfg.Elasticsearch.Shards,
+			Replicas: cfg.Elasticsearch.Replicas,
+			Synonyms: cfg.Elasticsearch.Synonyms,
+		},
+		cfg.Elasticsearch.BulkSize,
+		indexer.NewMetrics(registry),
+		logger,
+	)
+
+	if *reindexOnly {
+		reindexCtx, cancel := context.WithTimeout(ctx, cfg.ProductService.StreamTimeout)
+		defer cancel()
+		count, err := idx.Reindex(reindexCtx)
+		if err != nil {
+			logger.Error("Reindex failed", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Reindex finished", "documents", count)
+		return
+	}
+
+	if err := idx.EnsureIndex(ctx); err != nil {
+		logger.Error("Failed to ensure search index", "error", err)
+		os.Exit(1)
+	}
+
+	// Consume product and inventory events
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Events.RedisAddr,
+		Password: cfg.Events.RedisPassword,
+		DB:       cfg.Events.RedisDB,
+	})
+	defer redisClient.Close()
+
+	bus := eventbus.NewRedisBus(redisClient, eventbus.RedisConfig{StreamPrefix: cfg.Events.StreamPrefix}, logger)
+	go func() {
+		logger.Info("Consuming events", "group", cfg.Events.ConsumerGroup, "types", indexer.EventTypes)
+		if err := bus.Subscribe(ctx, cfg.Events.ConsumerGroup, indexer.EventTypes, idx.HandleEvent); err != nil {
+			logger.Error("Event consumer failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go idx.MonitorHealth(ctx, cfg.HealthInterval)
+
+	// Elect the replica that runs full reindexes, so replicas never race
+	// to swap the alias
+	var locker *locks.Locker
+	if cfg.Leader.Enabled {
+		locker = locks.New(locks.NewRedisStore(redisClient, "locks:"), locks.Options{TTL: cfg.Leader.LeaseTTL}, logger)
+	}
+	elector := leader.New(locker, "search-reindex", leader.Options{
+		RetryInterval: cfg.Leader.RetryInterval,
+		Metrics:       leader.NewMetrics(registry, "search_indexer"),
+	}, logger)
+	go elector.Run(ctx)
+	if cfg.ReindexInterval > 0 {
+		go runScheduledReindex(ctx, idx, elector, cfg, logger)
+	}
+
+	// Start HTTP server for health, metrics and admin operations
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: setupRouter(cfg, idx, elector, registry, logger),
+	}
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down search indexer")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+// runScheduledReindex rebuilds the index every ReindexInterval while this
+// replica leads, until ctx is done
+func runScheduledReindex(ctx context.Context, idx *indexer.Indexer, elector *leader.Elector, cfg *config.Config, logger *slog.Logger) {
+	ticker := time.NewTicker(cfg.ReindexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !elector.IsLeader() {
+			continue
+		}
+		reindexCtx, cancel := context.WithTimeout(elector.Term(), cfg.ProductService.StreamTimeout)
+		count, err := idx.Reindex(reindexCtx)
+		cancel()
+		switch {
+		case errors.Is(err, indexer.ErrReindexInProgress):
+		case err != nil:
+			logger.Error("Scheduled reindex failed", "error", err)
+		default:
+			logger.Info("Scheduled reindex finished", "documents", count)
+		}
+	}
+}
+
+func setupRouter(cfg *config.Config, idx *indexer.Indexer, elector *leader.Elector, registry *prometheus.Registry, logger *slog.Logger) *chi.Mux {
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(middleware.Recover(logger))
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	// Trigger a full rebuild in the background; progress is visible in
+	// metrics. Only the leader reindexes, and stops if it loses leadership.
+	router.Post("/v1/reindex", func(w http.ResponseWriter, r *http.Request) {
+		if !elector.IsLeader() {
+			http.Error(w, "this replica is not the leader; reindexes run on the replica reporting search_indexer_leader_is_leader 1", http.StatusConflict)
+			return
+		}
+		started := make(chan error, 1)
+		go func() {
+			reindexCtx, cancel := context.WithTimeout(elector.Term(), cfg.ProductService.StreamTimeout)
+			defer cancel()
+			_, err := idx.Reindex(reindexCtx)
+			if errors.Is(err, indexer.ErrReindexInProgress) {
+				started <- err
+				return
+			}
+			started <- nil
+			if err != nil {
+				logger.Error("Reindex failed", "error", err)
+			}
+		}()
+
+		select {
+		case err := <-started:
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return router
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/grpcclient"
+	"github.com/bekbull/online-shop/pkg/mtls"
+)
+
+// Config holds all configuration for the search indexer
+type Config struct {
+	Elasticsearch  ElasticsearchConfig
+	Events         EventsConfig
+	ProductService ProductServiceConfig
+	Leader         LeaderConfig
+	TLS            mtls.Config
+	Discovery      grpcclient.Options
+	HTTPPort       int
+	HealthInterval time.Duration
+	// ReindexInterval is how often the leader rebuilds the index; zero
+	// leaves reindexing to POST /v1/reindex
+	ReindexInterval time.Duration
+	Env             string
+}
+
+// ElasticsearchConfig holds Elasticsearch connection and index configuration
+type ElasticsearchConfig struct {
+	URL         string
+	Username    string
+	Password    string
+	IndexAlias  string
+	Shards      int
+	Replicas    int
+	Synonyms    []string
+	BulkSize    int
+	HTTPTimeout time.Duration
+}
+
+// EventsConfig holds configuration for consuming domain events
+type EventsConfig struct {
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	StreamPrefix  string
+	ConsumerGroup string
+}
+
+// ProductServiceConfig holds configuration for the product-service gRPC client
+type ProductServiceConfig struct {
+	Addr          string
+	StreamTimeout time.Duration
+}
+
+// LeaderConfig holds configuration for electing the replica that runs full
+// reindexes. Without election every replica counts as the leader, which
+// suits a single replica.
+type LeaderConfig struct {
+	Enabled       bool
+	LeaseTTL      time.Duration
+	RetryInterval time.Duration
+}
+
+// Load loads configuration from environment variables
+func Load() *Config {
+	return &Config{
+		Elasticsearch: ElasticsearchConfig{
+			URL:         getEnv("ELASTICSEARCH_URL", "http://elasticsearch:9200"),
+			Username:    getEnv("ELASTICSEARCH_USERNAME", ""),
+			Password:    getEnv("ELASTICSEARCH_PASSWORD", ""),
+			IndexAlias:  getEnv("ELASTICSEARCH_INDEX_ALIAS", "products"),
+			Shards:      getEnvInt("ELASTICSEARCH_SHARDS", 1),
+			Replicas:    getEnvInt("ELASTICSEARCH_REPLICAS", 0),
+			Synonyms:    getEnvList("SEARCH_SYNONYMS", ";", defaultSynonyms),
+			BulkSize:    getEnvInt("ELASTICSEARCH_BULK_SIZE", 500),
+			HTTPTimeout: getEnvDuration("ELASTICSEARCH_TIMEOUT", 30*time.Second),
+		},
+		Events: EventsConfig{
+			RedisAddr:     getEnv("EVENTS_REDIS_ADDR", "redis:6379"),
+			RedisPassword: getEnv("EVENTS_REDIS_PASSWORD", ""),
+			RedisDB:       getEnvInt("EVENTS_REDIS_DB", 0),
+			StreamPrefix:  getEnv("EVENTS_STREAM_PREFIX", "events"),
+			ConsumerGroup: getEnv("EVENTS_CONSUMER_GROUP", "search-indexer"),
+		},
+		ProductService: ProductServiceConfig{
+			Addr:          getEnv("PRODUCT_SERVICE_ADDR", "product-service:50051"),
+			StreamTimeout: getEnvDuration("PRODUCT_SERVICE_STREAM_TIMEOUT", 30*time.Minute),
+		},
+		Leader: LeaderConfig{
+			Enabled:       getEnvBool("LEADER_ELECTION_ENABLED", true),
+			LeaseTTL:      getEnvDuration("LEADER_LEASE_TTL", 15*time.Second),
+			RetryInterval: getEnvDuration("LEADER_RETRY_INTERVAL", 5*time.Second),
+		},
+		TLS:             mtls.FromEnv(),
+		Discovery:       grpcclient.FromEnv(),
+		HTTPPort:        getEnvInt("HTTP_PORT", 8090),
+		HealthInterval:  getEnvDuration("INDEX_HEALTH_INTERVAL", 30*time.Second),
+		ReindexInterval: getEnvDuration("REINDEX_INTERVAL", 0),
+		Env:             getEnv("ENV", "development"),
+	}
+}
+
+// defaultSynonyms are Solr-format synonym rules applied when SEARCH_SYNONYMS is unset
+var defaultSynonyms = []string{
+	"tv, television",
+	"phone, mobile, cellphone, smartphone",
+	"laptop, notebook",
+	"tee, t-shirt, tshirt",
+	"sneakers, trainers",
+}
+
+// Helper functions
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key, sep string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
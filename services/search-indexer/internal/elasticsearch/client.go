@@ -0,0 +1,249 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a document or index does not exist
+var ErrNotFound = errors.New("not found")
+
+// Client is a minimal Elasticsearch REST client covering what the indexer needs
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// BulkItem is a single document in a bulk index request
+type BulkItem struct {
+	ID       string
+	Document interface{}
+}
+
+// Health summarizes the state of an index
+type Health struct {
+	Status           string `json:"status"`
+	ActiveShards     int    `json:"active_shards"`
+	UnassignedShards int    `json:"unassigned_shards"`
+	DocCount         int64  `json:"-"`
+}
+
+// New creates a new Elasticsearch client
+func New(baseURL, username, password string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// CreateIndex creates a physical index with the product settings and mappings
+func (c *Client) CreateIndex(ctx context.Context, name string, settings IndexSettings) error {
+	return c.do(ctx, http.MethodPut, "/"+url.PathEscape(name), indexBody(settings), nil)
+}
+
+// DeleteIndex removes a physical index
+func (c *Client) DeleteIndex(ctx context.Context, name string) error {
+	err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(name), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// AliasIndices returns the physical indices the alias currently points to
+func (c *Client) AliasIndices(ctx context.Context, alias string) ([]string, error) {
+	var result map[string]json.RawMessage
+	err := c.do(ctx, http.MethodGet, "/_alias/"+url.PathEscape(alias), nil, &result)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(result))
+	for name := range result {
+		indices = append(indices, name)
+	}
+	return indices, nil
+}
+
+// SwapAlias atomically points the alias at newIndex, detaching it from any
+// indices it previously referenced. It returns the detached indices.
+func (c *Client) SwapAlias(ctx context.Context, alias, newIndex string) ([]string, error) {
+	old, err := c.AliasIndices(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []map[string]interface{}{
+		{"add": map[string]string{"index": newIndex, "alias": alias}},
+	}
+	var detached []string
+	for _, name := range old {
+		if name == newIndex {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]string{"index": name, "alias": alias},
+		})
+		detached = append(detached, name)
+	}
+
+	body := map[string]interface{}{"actions": actions}
+	if err := c.do(ctx, http.MethodPost, "/_aliases", body, nil); err != nil {
+		return nil, err
+	}
+	return detached, nil
+}
+
+// IndexDocument creates or replaces a document
+func (c *Client) IndexDocument(ctx context.Context, index, id string, doc interface{}) error {
+	return c.do(ctx, http.MethodPut, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), doc, nil)
+}
+
+// UpdateDocument merges fields into an existing document
+func (c *Client) UpdateDocument(ctx context.Context, index, id string, fields interface{}) error {
+	body := map[string]interface{}{"doc": fields}
+	return c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_update/"+url.PathEscape(id), body, nil)
+}
+
+// DeleteDocument removes a document. Missing documents are not an error.
+func (c *Client) DeleteDocument(ctx context.Context, index, id string) error {
+	err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Bulk indexes a batch of documents in a single request
+func (c *Client) Bulk(ctx context.Context, index string, items []BulkItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		action := map[string]interface{}{
+			"index": map[string]string{"_index": index, "_id": item.ID},
+		}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(item.Document); err != nil {
+			return err
+		}
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", &buf, &result); err != nil {
+		return err
+	}
+
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, r := range item {
+				if r.Error != nil {
+					return fmt.Errorf("bulk index failed for %s: %s", r.ID, r.Error.Reason)
+				}
+			}
+		}
+		return errors.New("bulk index reported errors")
+	}
+	return nil
+}
+
+// Refresh makes recent writes to the index visible to search
+func (c *Client) Refresh(ctx context.Context, index string) error {
+	return c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_refresh", nil, nil)
+}
+
+// IndexHealth returns cluster health scoped to the index plus its document count
+func (c *Client) IndexHealth(ctx context.Context, index string) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/_cluster/health/"+url.PathEscape(index), nil, &health); err != nil {
+		return nil, err
+	}
+
+	var count struct {
+		Count int64 `json:"count"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(index)+"/_count", nil, &count); err != nil {
+		return nil, err
+	}
+	health.DocCount = count.Count
+
+	return &health, nil
+}
+
+// do sends a JSON request and decodes the JSON response into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	return c.send(ctx, method, path, "application/json", reader, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("elasticsearch %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
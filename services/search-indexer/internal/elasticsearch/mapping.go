@@ -0,0 +1,84 @@
+package elasticsearch
+
+// IndexSettings describes how a new physical products index is created
+type IndexSettings struct {
+	Shards   int
+	Replicas int
+	Synonyms []string
+}
+
+// indexBody builds the settings and mappings for a products index. Text
+// fields are analyzed with a synonym-aware analyzer at search time only, so
+// synonym changes take effect on the next reindex without touching stored tokens.
+func indexBody(settings IndexSettings) map[string]interface{} {
+	synonyms := settings.Synonyms
+	if synonyms == nil {
+		synonyms = []string{}
+	}
+
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   settings.Shards,
+			"number_of_replicas": settings.Replicas,
+			"analysis": map[string]interface{}{
+				"filter": map[string]interface{}{
+					"product_synonyms": map[string]interface{}{
+						"type":     "synonym_graph",
+						"synonyms": synonyms,
+					},
+				},
+				"analyzer": map[string]interface{}{
+					"product_text": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding"},
+					},
+					"product_search": map[string]interface{}{
+						"type":      "custom",
+						"tokenizer": "standard",
+						"filter":    []string{"lowercase", "asciifolding", "product_synonyms"},
+					},
+				},
+				"normalizer": map[string]interface{}{
+					"lowercase": map[string]interface{}{
+						"type":   "custom",
+						"filter": []string{"lowercase"},
+					},
+				},
+			},
+		},
+		"mappings": map[string]interface{}{
+			"dynamic": "strict",
+			"properties": map[string]interface{}{
+				"id": map[string]interface{}{"type": "keyword"},
+				"name": map[string]interface{}{
+					"type":            "text",
+					"analyzer":        "product_text",
+					"search_analyzer": "product_search",
+					"fields": map[string]interface{}{
+						"keyword": map[string]interface{}{"type": "keyword", "normalizer": "lowercase"},
+						"suggest": map[string]interface{}{"type": "search_as_you_type"},
+					},
+				},
+				"description": map[string]interface{}{
+					"type":            "text",
+					"analyzer":        "product_text",
+					"search_analyzer": "product_search",
+				},
+				"category":   map[string]interface{}{"type": "keyword", "normalizer": "lowercase"},
+				"tags":       map[string]interface{}{"type": "keyword", "normalizer": "lowercase"},
+				"attributes": map[string]interface{}{"type": "flattened"},
+				"price":      map[string]interface{}{"type": "scaled_float", "scaling_factor": 100},
+				"image_url":  map[string]interface{}{"type": "keyword", "index": false},
+				"sku":        map[string]interface{}{"type": "keyword"},
+				"barcode":    map[string]interface{}{"type": "keyword"},
+				"quantity":   map[string]interface{}{"type": "integer"},
+				"reserved":   map[string]interface{}{"type": "integer"},
+				"in_stock":   map[string]interface{}{"type": "boolean"},
+				"created_at": map[string]interface{}{"type": "date"},
+				"updated_at": map[string]interface{}{"type": "date"},
+				"indexed_at": map[string]interface{}{"type": "date"},
+			},
+		},
+	}
+}
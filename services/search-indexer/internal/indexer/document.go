@@ -0,0 +1,88 @@
+package indexer
+
+import (
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/events"
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+)
+
+// Document is the search representation of a product
+type Document struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Category    string            `json:"category"`
+	Tags        []string          `json:"tags"`
+	Attributes  map[string]string `json:"attributes"`
+	Price       float64           `json:"price"`
+	ImageURL    string            `json:"image_url,omitempty"`
+	SKU         string            `json:"sku"`
+	Barcode     string            `json:"barcode,omitempty"`
+	Quantity    int               `json:"quantity"`
+	Reserved    int               `json:"reserved"`
+	InStock     bool              `json:"in_stock"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	IndexedAt   time.Time         `json:"indexed_at"`
+}
+
+// inventoryFields is the partial document applied on inventory.changed
+type inventoryFields struct {
+	Quantity  int       `json:"quantity"`
+	Reserved  int       `json:"reserved"`
+	InStock   bool      `json:"in_stock"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// documentFromEvent converts a product event payload into a search document
+func documentFromEvent(p *events.ProductPayload) *Document {
+	doc := &Document{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category,
+		Tags:        p.Tags,
+		Attributes:  p.Attributes,
+		Price:       p.Price,
+		SKU:         p.Inventory.SKU,
+		Barcode:     p.Barcode,
+		Quantity:    p.Inventory.Quantity,
+		Reserved:    p.Inventory.Reserved,
+		InStock:     p.Inventory.InStock,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		IndexedAt:   time.Now().UTC(),
+	}
+	if len(p.ImageURLs) > 0 {
+		doc.ImageURL = p.ImageURLs[0]
+	}
+	return doc
+}
+
+// documentFromProto converts a product received from StreamProducts
+func documentFromProto(p *pb.Product) *Document {
+	doc := &Document{
+		ID:          p.Id,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category,
+		Tags:        p.Tags,
+		Attributes:  p.Attributes,
+		Price:       p.Price,
+		Barcode:     p.Barcode,
+		CreatedAt:   time.Unix(p.CreatedAt, 0).UTC(),
+		UpdatedAt:   time.Unix(p.UpdatedAt, 0).UTC(),
+		IndexedAt:   time.Now().UTC(),
+	}
+	if p.Inventory != nil {
+		doc.SKU = p.Inventory.Sku
+		doc.Quantity = int(p.Inventory.Quantity)
+		doc.Reserved = int(p.Inventory.Reserved)
+		doc.InStock = p.Inventory.InStock
+	}
+	if len(p.ImageUrls) > 0 {
+		doc.ImageURL = p.ImageUrls[0]
+	}
+	return doc
+}
@@ -0,0 +1,313 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/search-indexer/internal/elasticsearch"
+)
+
+// ErrReindexInProgress is returned when a reindex is requested while one is running
+var ErrReindexInProgress = errors.New("reindex already in progress")
+
+// EventTypes are the events the indexer subscribes to
+var EventTypes = []string{
+	events.ProductCreated,
+	events.ProductUpdated,
+	events.ProductDeleted,
+	events.InventoryChanged,
+}
+
+// Store is the subset of the Elasticsearch client used by the indexer
+type Store interface {
+	CreateIndex(ctx context.Context, name string, settings elasticsearch.IndexSettings) error
+	DeleteIndex(ctx context.Context, name string) error
+	AliasIndices(ctx context.Context, alias string) ([]string, error)
+	SwapAlias(ctx context.Context, alias, newIndex string) ([]string, error)
+	IndexDocument(ctx context.Context, index, id string, doc interface{}) error
+	UpdateDocument(ctx context.Context, index, id string, fields interface{}) error
+	DeleteDocument(ctx context.Context, index, id string) error
+	Bulk(ctx context.Context, index string, items []elasticsearch.BulkItem) error
+	Refresh(ctx context.Context, index string) error
+	IndexHealth(ctx context.Context, index string) (*elasticsearch.Health, error)
+}
+
+// ProductSource provides the full catalog for rebuilding the index
+type ProductSource interface {
+	Stream(ctx context.Context, fn func(*Document) error) error
+}
+
+// Indexer keeps the products search index in sync with product events
+type Indexer struct {
+	store    Store
+	source   ProductSource
+	alias    string
+	settings elasticsearch.IndexSettings
+	bulkSize int
+	metrics  *Metrics
+	logger   *slog.Logger
+
+	mu            sync.RWMutex
+	reindexTarget string // Physical index being built by a running reindex
+	reindexing    bool
+}
+
+// New creates a new Indexer writing through the given alias
+func New(store Store, source ProductSource, alias string, settings elasticsearch.IndexSettings, bulkSize int, metrics *Metrics, logger *slog.Logger) *Indexer {
+	if bulkSize <= 0 {
+		bulkSize = 500
+	}
+	return &Indexer{
+		store:    store,
+		source:   source,
+		alias:    alias,
+		settings: settings,
+		bulkSize: bulkSize,
+		metrics:  metrics,
+		logger:   logger,
+	}
+}
+
+// EnsureIndex creates the first physical index and alias if none exist yet
+func (i *Indexer) EnsureIndex(ctx context.Context) error {
+	indices, err := i.store.AliasIndices(ctx, i.alias)
+	if err != nil {
+		return fmt.Errorf("failed to resolve alias %s: %w", i.alias, err)
+	}
+	if len(indices) > 0 {
+		i.logger.Info("Search index alias exists", "alias", i.alias, "indices", indices)
+		return nil
+	}
+
+	name := i.newIndexName()
+	if err := i.store.CreateIndex(ctx, name, i.settings); err != nil {
+		return fmt.Errorf("failed to create index %s: %w", name, err)
+	}
+	if _, err := i.store.SwapAlias(ctx, i.alias, name); err != nil {
+		return fmt.Errorf("failed to point alias %s at %s: %w", i.alias, name, err)
+	}
+
+	i.logger.Info("Created search index", "alias", i.alias, "index", name)
+	return nil
+}
+
+// HandleEvent applies a single product or inventory event to the index
+func (i *Indexer) HandleEvent(ctx context.Context, event *eventbus.Event) error {
+	err := i.apply(ctx, event)
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	i.metrics.eventsProcessed.WithLabelValues(event.Type, result).Inc()
+	if err == nil && !event.OccurredAt.IsZero() {
+		i.metrics.eventLag.Observe(time.Since(event.OccurredAt).Seconds())
+	}
+	return err
+}
+
+func (i *Indexer) apply(ctx context.Context, event *eventbus.Event) error {
+	switch event.Type {
+	case events.ProductCreated, events.ProductUpdated:
+		var payload events.ProductPayload
+		if err := events.Decode(event, &payload); err != nil {
+			return err
+		}
+		// Products shoppers do not see, inactive or unpublished, are not
+		// searchable
+		if !payload.Listed() {
+			return i.forEachIndex(func(index string) error {
+				return i.store.DeleteDocument(ctx, index, payload.ID)
+			})
+		}
+		doc := documentFromEvent(&payload)
+		return i.forEachIndex(func(index string) error {
+			return i.store.IndexDocument(ctx, index, doc.ID, doc)
+		})
+
+	case events.ProductDeleted:
+		var payload events.ProductDeletedPayload
+		if err := events.Decode(event, &payload); err != nil {
+			return err
+		}
+		return i.forEachIndex(func(index string) error {
+			return i.store.DeleteDocument(ctx, index, payload.ID)
+		})
+
+	case events.InventoryChanged:
+		var payload events.InventoryChangedPayload
+		if err := events.Decode(event, &payload); err != nil {
+			return err
+		}
+		fields := inventoryFields{
+			Quantity:  payload.Inventory.Quantity,
+			Reserved:  payload.Inventory.Reserved,
+			InStock:   payload.Inventory.InStock,
+			IndexedAt: time.Now().UTC(),
+		}
+		return i.forEachIndex(func(index string) error {
+			err := i.store.UpdateDocument(ctx, index, payload.ProductID, fields)
+			// The product may be unlisted and therefore not indexed
+			if errors.Is(err, elasticsearch.ErrNotFound) {
+				return nil
+			}
+			return err
+		})
+
+	default:
+		i.logger.Warn("Ignoring unknown event type", "type", event.Type)
+		return nil
+	}
+}
+
+// forEachIndex runs fn against the live alias and, while a reindex is in
+// progress, against the index being built so no changes are lost at cutover
+func (i *Indexer) forEachIndex(fn func(index string) error) error {
+	i.mu.RLock()
+	target := i.reindexTarget
+	i.mu.RUnlock()
+
+	if err := fn(i.alias); err != nil {
+		return err
+	}
+	if target != "" {
+		return fn(target)
+	}
+	return nil
+}
+
+// Reindex rebuilds the index from scratch using the product-service stream,
+// then atomically swaps the alias and drops the previous index
+func (i *Indexer) Reindex(ctx context.Context) (int, error) {
+	i.mu.Lock()
+	if i.reindexing {
+		i.mu.Unlock()
+		return 0, ErrReindexInProgress
+	}
+	i.reindexing = true
+	i.mu.Unlock()
+
+	i.metrics.reindexRunning.Set(1)
+	defer func() {
+		i.mu.Lock()
+		i.reindexing = false
+		i.reindexTarget = ""
+		i.mu.Unlock()
+		i.metrics.reindexRunning.Set(0)
+	}()
+
+	start := time.Now()
+	name := i.newIndexName()
+	i.logger.Info("Starting full reindex", "index", name)
+
+	if err := i.store.CreateIndex(ctx, name, i.settings); err != nil {
+		return 0, fmt.Errorf("failed to create index %s: %w", name, err)
+	}
+
+	i.mu.Lock()
+	i.reindexTarget = name
+	i.mu.Unlock()
+
+	count, err := i.load(ctx, name)
+	if err != nil {
+		if delErr := i.store.DeleteIndex(context.Background(), name); delErr != nil {
+			i.logger.Error("Failed to clean up partial index", "index", name, "error", delErr)
+		}
+		return count, fmt.Errorf("reindex failed after %d documents: %w", count, err)
+	}
+
+	if err := i.store.Refresh(ctx, name); err != nil {
+		return count, fmt.Errorf("failed to refresh index %s: %w", name, err)
+	}
+
+	detached, err := i.store.SwapAlias(ctx, i.alias, name)
+	if err != nil {
+		return count, fmt.Errorf("failed to swap alias to %s: %w", name, err)
+	}
+	for _, old := range detached {
+		if err := i.store.DeleteIndex(ctx, old); err != nil {
+			i.logger.Error("Failed to delete previous index", "index", old, "error", err)
+		}
+	}
+
+	duration := time.Since(start)
+	i.metrics.reindexDocs.Set(float64(count))
+	i.metrics.reindexDuration.Set(duration.Seconds())
+	i.metrics.reindexLastOK.SetToCurrentTime()
+	i.logger.Info("Reindex completed", "index", name, "documents", count, "duration", duration)
+	return count, nil
+}
+
+// load streams the catalog into the given index in bulk batches
+func (i *Indexer) load(ctx context.Context, index string) (int, error) {
+	count := 0
+	batch := make([]elasticsearch.BulkItem, 0, i.bulkSize)
+
+	flush := func() error {
+		if err := i.store.Bulk(ctx, index, batch); err != nil {
+			return err
+		}
+		count += len(batch)
+		i.metrics.reindexDocs.Set(float64(count))
+		batch = batch[:0]
+		return nil
+	}
+
+	err := i.source.Stream(ctx, func(doc *Document) error {
+		batch = append(batch, elasticsearch.BulkItem{ID: doc.ID, Document: doc})
+		if len(batch) >= i.bulkSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// MonitorHealth periodically records index health metrics until ctx is done
+func (i *Indexer) MonitorHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		i.recordHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *Indexer) recordHealth(ctx context.Context) {
+	health, err := i.store.IndexHealth(ctx, i.alias)
+	if err != nil {
+		if ctx.Err() == nil {
+			i.metrics.healthCheckFails.Inc()
+			i.metrics.indexStatus.Set(healthStatusValue("red"))
+			i.logger.Error("Failed to read index health", "alias", i.alias, "error", err)
+		}
+		return
+	}
+
+	i.metrics.indexStatus.Set(healthStatusValue(health.Status))
+	i.metrics.indexDocs.Set(float64(health.DocCount))
+	i.metrics.unassignedShards.Set(float64(health.UnassignedShards))
+}
+
+func (i *Indexer) newIndexName() string {
+	return fmt.Sprintf("%s-%d", i.alias, time.Now().UnixNano())
+}
@@ -0,0 +1,243 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/services/search-indexer/internal/elasticsearch"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore is an in-memory implementation of Store
+type fakeStore struct {
+	mu      sync.Mutex
+	indices map[string]map[string]interface{}
+	aliases map[string]string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		indices: make(map[string]map[string]interface{}),
+		aliases: make(map[string]string),
+	}
+}
+
+func (f *fakeStore) resolve(name string) string {
+	if target, ok := f.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+func (f *fakeStore) CreateIndex(ctx context.Context, name string, settings elasticsearch.IndexSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.indices[name] = make(map[string]interface{})
+	return nil
+}
+
+func (f *fakeStore) DeleteIndex(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.indices, name)
+	return nil
+}
+
+func (f *fakeStore) AliasIndices(ctx context.Context, alias string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if target, ok := f.aliases[alias]; ok {
+		return []string{target}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) SwapAlias(ctx context.Context, alias, newIndex string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var detached []string
+	if old, ok := f.aliases[alias]; ok && old != newIndex {
+		detached = append(detached, old)
+	}
+	f.aliases[alias] = newIndex
+	return detached, nil
+}
+
+func (f *fakeStore) IndexDocument(ctx context.Context, index, id string, doc interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.indices[f.resolve(index)][id] = doc
+	return nil
+}
+
+func (f *fakeStore) UpdateDocument(ctx context.Context, index, id string, fields interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	docs := f.indices[f.resolve(index)]
+	existing, ok := docs[id].(*Document)
+	if !ok {
+		return elasticsearch.ErrNotFound
+	}
+	inv := fields.(inventoryFields)
+	updated := *existing
+	updated.Quantity = inv.Quantity
+	updated.Reserved = inv.Reserved
+	updated.InStock = inv.InStock
+	docs[id] = &updated
+	return nil
+}
+
+func (f *fakeStore) DeleteDocument(ctx context.Context, index, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.indices[f.resolve(index)], id)
+	return nil
+}
+
+func (f *fakeStore) Bulk(ctx context.Context, index string, items []elasticsearch.BulkItem) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, item := range items {
+		f.indices[f.resolve(index)][item.ID] = item.Document
+	}
+	return nil
+}
+
+func (f *fakeStore) Refresh(ctx context.Context, index string) error {
+	return nil
+}
+
+func (f *fakeStore) IndexHealth(ctx context.Context, index string) (*elasticsearch.Health, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &elasticsearch.Health{Status: "green", DocCount: int64(len(f.indices[f.resolve(index)]))}, nil
+}
+
+func (f *fakeStore) live(alias string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.indices[f.resolve(alias)]
+}
+
+// fakeSource streams a fixed list of documents
+type fakeSource struct {
+	docs []*Document
+	err  error
+}
+
+func (s *fakeSource) Stream(ctx context.Context, fn func(*Document) error) error {
+	for _, d := range s.docs {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func newTestIndexer(store Store, source ProductSource) *Indexer {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return New(store, source, "products", elasticsearch.IndexSettings{}, 2, NewMetrics(prometheus.NewRegistry()), logger)
+}
+
+func mustEvent(t *testing.T, eventType, key string, payload interface{}) *eventbus.Event {
+	event, err := eventbus.NewEvent(eventType, "test", key, payload)
+	require.NoError(t, err)
+	return event
+}
+
+func TestHandleEvent_ProductLifecycle(t *testing.T) {
+	store := newFakeStore()
+	idx := newTestIndexer(store, &fakeSource{})
+	ctx := context.Background()
+	require.NoError(t, idx.EnsureIndex(ctx))
+
+	product := events.ProductPayload{
+		ID:        "p1",
+		Name:      "Television",
+		Category:  "Electronics",
+		Active:    true,
+		ImageURLs: []string{"http://img/1.jpg"},
+		Inventory: events.InventoryPayload{Quantity: 5, SKU: "TV-1", InStock: true},
+	}
+
+	// Created products are indexed
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductCreated, "p1", product)))
+	doc := store.live("products")["p1"].(*Document)
+	assert.Equal(t, "Television", doc.Name)
+	assert.Equal(t, "http://img/1.jpg", doc.ImageURL)
+
+	// Inventory changes are applied as partial updates
+	inventory := events.InventoryChangedPayload{
+		ProductID: "p1",
+		Inventory: events.InventoryPayload{Quantity: 0, SKU: "TV-1", InStock: false},
+	}
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.InventoryChanged, "p1", inventory)))
+	doc = store.live("products")["p1"].(*Document)
+	assert.Equal(t, 0, doc.Quantity)
+	assert.False(t, doc.InStock)
+
+	// Retired products are removed from the index
+	product.Status = "retired"
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductUpdated, "p1", product)))
+	assert.NotContains(t, store.live("products"), "p1")
+
+	// Republished products are indexed again
+	product.Status = "published"
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductUpdated, "p1", product)))
+	assert.Contains(t, store.live("products"), "p1")
+
+	// Deactivated products are removed from the index
+	product.Active = false
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductUpdated, "p1", product)))
+	assert.NotContains(t, store.live("products"), "p1")
+
+	// Inventory changes for products that are not indexed are ignored
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.InventoryChanged, "p1", inventory)))
+
+	// Deletes of missing documents are not errors
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductDeleted, "p1", events.ProductDeletedPayload{ID: "p1"})))
+}
+
+func TestReindex_SwapsAliasAndDropsOldIndex(t *testing.T) {
+	store := newFakeStore()
+	source := &fakeSource{docs: []*Document{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	idx := newTestIndexer(store, source)
+	ctx := context.Background()
+
+	require.NoError(t, idx.EnsureIndex(ctx))
+	oldIndex := store.aliases["products"]
+	require.NoError(t, idx.HandleEvent(ctx, mustEvent(t, events.ProductCreated, "stale", events.ProductPayload{ID: "stale", Name: "Stale", Active: true})))
+
+	count, err := idx.Reindex(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NotEqual(t, oldIndex, store.aliases["products"])
+	assert.NotContains(t, store.indices, oldIndex)
+	assert.Len(t, store.live("products"), 3)
+	assert.NotContains(t, store.live("products"), "stale")
+}
+
+func TestReindex_FailureKeepsLiveIndex(t *testing.T) {
+	store := newFakeStore()
+	source := &fakeSource{docs: []*Document{{ID: "a"}}, err: errors.New("stream broken")}
+	idx := newTestIndexer(store, source)
+	ctx := context.Background()
+
+	require.NoError(t, idx.EnsureIndex(ctx))
+	liveIndex := store.aliases["products"]
+
+	_, err := idx.Reindex(ctx)
+
+	assert.Error(t, err)
+	assert.Equal(t, liveIndex, store.aliases["products"])
+	assert.Len(t, store.indices, 1)
+}
@@ -0,0 +1,92 @@
+package indexer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors describing indexer and index health
+type Metrics struct {
+	eventsProcessed  *prometheus.CounterVec
+	eventLag         prometheus.Histogram
+	indexDocs        prometheus.Gauge
+	indexStatus      prometheus.Gauge
+	unassignedShards prometheus.Gauge
+	healthCheckFails prometheus.Counter
+	reindexRunning   prometheus.Gauge
+	reindexDocs      prometheus.Gauge
+	reindexDuration  prometheus.Gauge
+	reindexLastOK    prometheus.Gauge
+}
+
+// NewMetrics creates the indexer metrics and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		eventsProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "search_indexer_events_processed_total",
+			Help: "Events processed by type and result.",
+		}, []string{"type", "result"}),
+		eventLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "search_indexer_event_lag_seconds",
+			Help:    "Delay between an event occurring and it being applied to the index.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		indexDocs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_index_documents",
+			Help: "Number of documents in the live products index.",
+		}),
+		indexStatus: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_index_health_status",
+			Help: "Index health: 0 = green, 1 = yellow, 2 = red.",
+		}),
+		unassignedShards: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_index_unassigned_shards",
+			Help: "Unassigned shards for the live products index.",
+		}),
+		healthCheckFails: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "search_index_health_check_failures_total",
+			Help: "Failed attempts to read index health.",
+		}),
+		reindexRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_indexer_reindex_running",
+			Help: "1 while a full reindex is in progress.",
+		}),
+		reindexDocs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_indexer_reindex_documents",
+			Help: "Documents written by the most recent reindex.",
+		}),
+		reindexDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_indexer_reindex_duration_seconds",
+			Help: "Duration of the most recent successful reindex.",
+		}),
+		reindexLastOK: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "search_indexer_reindex_last_success_timestamp_seconds",
+			Help: "Unix time of the most recent successful reindex.",
+		}),
+	}
+
+	reg.MustRegister(
+		m.eventsProcessed,
+		m.eventLag,
+		m.indexDocs,
+		m.indexStatus,
+		m.unassignedShards,
+		m.healthCheckFails,
+		m.reindexRunning,
+		m.reindexDocs,
+		m.reindexDuration,
+		m.reindexLastOK,
+	)
+	return m
+}
+
+// healthStatusValue maps Elasticsearch health colours onto a gauge value
+func healthStatusValue(status string) float64 {
+	switch status {
+	case "green":
+		return 0
+	case "yellow":
+		return 1
+	default:
+		return 2
+	}
+}
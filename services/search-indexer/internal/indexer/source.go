@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "github.com/bekbull/online-shop/proto/product/v1"
+)
+
+// GRPCProductSource reads the full catalog from product-service's StreamProducts RPC
+type GRPCProductSource struct {
+	client pb.ProductServiceClient
+}
+
+// NewGRPCProductSource creates a product source backed by the product-service client
+func NewGRPCProductSource(client pb.ProductServiceClient) *GRPCProductSource {
+	return &GRPCProductSource{client: client}
+}
+
+// Stream calls fn for every active product
+func (s *GRPCProductSource) Stream(ctx context.Context, fn func(*Document) error) error {
+	stream, err := s.client.StreamProducts(ctx, &pb.StreamProductsRequest{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		product, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(documentFromProto(product)); err != nil {
+			return err
+		}
+	}
+}
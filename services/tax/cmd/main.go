@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/grpcserver"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	taxv1 "github.com/bekbull/online-shop/proto/tax/v1"
+	"github.com/bekbull/online-shop/services/tax/config"
+	grpcHandler "github.com/bekbull/online-shop/services/tax/internal/api/grpc"
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+	"github.com/bekbull/online-shop/services/tax/internal/provider"
+	"github.com/bekbull/online-shop/services/tax/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	// Initialize logger
+	logger := logging.New(os.Stdout, logging.Options{Service: "tax-service", Text: true})
+	logger.Info("Starting Tax Service")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	// The rate table is always loaded: it is either the provider or the
+	// fallback for an external one
+	table, err := provider.LoadTableProvider(cfg.RatesFile)
+	if err != nil {
+		logger.Error("Failed to load tax rates", "error", err)
+		os.Exit(1)
+	}
+
+	var taxProvider domain.Provider
+	switch cfg.Provider {
+	case "table":
+		taxProvider = table
+	default:
+		// External adapters (TaxJar, Avalara) are wired here, wrapped in
+		// provider.NewFallbackProvider(adapter, table, logger)
+		logger.Error("Unknown tax provider", "provider", cfg.Provider)
+		os.Exit(1)
+	}
+	logger.Info("Tax provider configured", "provider", taxProvider.Name())
+
+	// Create service
+	taxService := service.New(taxProvider, logger)
+
+	// Load TLS credentials; certificates are reloaded when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go creds.Watch(watchCtx)
+
+	// Setup gRPC server. Calls get request IDs, logs, metrics, panic
+	// recovery and the timeout of their method, as on the other services.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	endpoints, err := cfg.Server.EndpointPolicies()
+	if err != nil {
+		logger.Error("Failed to load endpoint policies", "error", err)
+		os.Exit(1)
+	}
+	chain := grpcserver.Chain{
+		Logger:    logger,
+		AccessLog: cfg.Server.AccessLog(),
+		Metrics:   middleware.NewMetrics(registry, "tax_service"),
+		Endpoints: endpoints,
+	}
+	grpcServer := grpc.NewServer(append(chain.ServerOptions(), creds.ServerOption())...)
+	taxv1.RegisterTaxServiceServer(grpcServer, grpcHandler.New(taxService, logger))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if cfg.Env != "production" {
+		reflection.Register(grpcServer)
+	}
+
+	// Setup HTTP server for health checks
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: mux,
+	}
+
+	// Start servers in goroutines
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go func() {
+		logger.Info("Starting gRPC server", "port", cfg.GRPCPort)
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("Failed to listen for gRPC", "error", err)
+			os.Exit(1)
+		}
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("gRPC server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Wait for interrupt signal
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+	logger.Info("Received shutdown signal", "signal", sig)
+
+	// Report NOT_SERVING first so health-checking clients move away
+	// before connections are drained
+	healthServer.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger.Info("Shutting down HTTP server")
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	logger.Info("Shutting down gRPC server")
+	grpcServer.GracefulStop()
+
+	logger.Info("Shutdown completed")
+}
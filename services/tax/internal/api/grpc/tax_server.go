@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	pb "github.com/bekbull/online-shop/proto/tax/v1"
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+)
+
+// TaxServer implements the gRPC TaxService
+type TaxServer struct {
+	pb.UnimplementedTaxServiceServer
+	taxService TaxService
+	logger     *slog.Logger
+}
+
+// TaxService represents the business logic interface for tax calculation
+type TaxService interface {
+	Calculate(ctx context.Context, params domain.CalculateParams) (*domain.Calculation, error)
+}
+
+// New creates a new TaxServer
+func New(service TaxService, logger *slog.Logger) *TaxServer {
+	return &TaxServer{
+		taxService: service,
+		logger:     logger,
+	}
+}
+
+// Calculate implements the Calculate RPC method
+func (s *TaxServer) Calculate(ctx context.Context, req *pb.CalculateRequest) (*pb.CalculateResponse, error) {
+	s.logger.Info("gRPC Calculate called", "documentID", req.DocumentId, "lines", len(req.Lines))
+
+	params := domain.CalculateParams{
+		DocumentType: req.DocumentType,
+		DocumentID:   req.DocumentId,
+		Currency:     strings.ToUpper(req.Currency),
+		Lines:        make([]domain.LineItem, len(req.Lines)),
+	}
+	if req.ShipTo != nil {
+		params.ShipTo = domain.Address{
+			Country:    req.ShipTo.Country,
+			Region:     req.ShipTo.Region,
+			PostalCode: req.ShipTo.PostalCode,
+			City:       req.ShipTo.City,
+		}
+	}
+	for i, line := range req.Lines {
+		params.Lines[i] = domain.LineItem{
+			ID:             line.Id,
+			ProductID:      line.ProductId,
+			TaxClass:       line.TaxClass,
+			Quantity:       int(line.Quantity),
+			UnitAmount:     line.UnitAmount,
+			DiscountAmount: line.DiscountAmount,
+		}
+	}
+
+	calc, err := s.taxService.Calculate(ctx, params)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to calculate tax: %w", err))
+	}
+
+	return domainToProtoCalculation(calc), nil
+}
+
+// Helper functions to convert domain types to proto messages
+func domainToProtoCalculation(calc *domain.Calculation) *pb.CalculateResponse {
+	lines := make([]*pb.TaxLine, len(calc.Lines))
+	for i, line := range calc.Lines {
+		components := make([]*pb.TaxComponent, len(line.Components))
+		for j, c := range line.Components {
+			components[j] = &pb.TaxComponent{
+				Jurisdiction: c.Jurisdiction,
+				Name:         c.Name,
+				Rate:         c.Rate,
+				Amount:       c.Amount,
+			}
+		}
+		lines[i] = &pb.TaxLine{
+			Id:            line.ID,
+			TaxableAmount: line.TaxableAmount,
+			TaxAmount:     line.TaxAmount,
+			EffectiveRate: line.EffectiveRate(),
+			Components:    components,
+		}
+	}
+
+	return &pb.CalculateResponse{
+		DocumentId:   calc.DocumentID,
+		Currency:     calc.Currency,
+		Lines:        lines,
+		TotalTaxable: calc.TotalTaxable,
+		TotalTax:     calc.TotalTax,
+		Provider:     calc.Provider,
+	}
+}
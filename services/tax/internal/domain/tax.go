@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+)
+
+// Domain errors returned by providers and the service
+var (
+	ErrUnsupportedJurisdiction = apperrors.New(apperrors.Conflict, "unsupported jurisdiction")
+	ErrProviderUnavailable     = apperrors.New(apperrors.Unavailable, "tax provider unavailable")
+)
+
+// Document types a calculation can be made for
+const (
+	DocumentOrder   = "order"
+	DocumentInvoice = "invoice"
+)
+
+// StandardClass is the tax class used when a line does not specify one
+const StandardClass = "standard"
+
+// Address is the destination used to determine tax jurisdictions
+type Address struct {
+	Country    string
+	Region     string
+	PostalCode string
+	City       string
+}
+
+// LineItem is a taxable line of an order or invoice. Amounts are in minor
+// currency units.
+type LineItem struct {
+	ID             string
+	ProductID      string
+	TaxClass       string
+	Quantity       int
+	UnitAmount     int64
+	DiscountAmount int64
+}
+
+// TaxableAmount returns the line amount after discount, never below zero
+func (l LineItem) TaxableAmount() int64 {
+	amount := int64(l.Quantity)*l.UnitAmount - l.DiscountAmount
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+// CalculateParams describes a document to calculate tax for
+type CalculateParams struct {
+	DocumentType string
+	DocumentID   string
+	Currency     string
+	ShipTo       Address
+	Lines        []LineItem
+}
+
+// TaxComponent is the tax levied by a single jurisdiction on a line
+type TaxComponent struct {
+	Jurisdiction string
+	Name         string
+	Rate         float64
+	Amount       int64
+}
+
+// TaxLine is the tax breakdown of a single line
+type TaxLine struct {
+	ID            string
+	TaxableAmount int64
+	TaxAmount     int64
+	Components    []TaxComponent
+}
+
+// EffectiveRate returns the combined rate actually charged on the line
+func (l TaxLine) EffectiveRate() float64 {
+	if l.TaxableAmount == 0 {
+		return 0
+	}
+	return float64(l.TaxAmount) / float64(l.TaxableAmount)
+}
+
+// Calculation is the result of a tax calculation
+type Calculation struct {
+	DocumentID   string
+	Currency     string
+	Lines        []TaxLine
+	TotalTaxable int64
+	TotalTax     int64
+	Provider     string
+}
+
+// Provider calculates taxes. The built-in table provider and adapters for
+// external services such as TaxJar or Avalara implement this interface.
+// Implementations must return one TaxLine per input line, in order.
+type Provider interface {
+	Name() string
+	Calculate(ctx context.Context, params CalculateParams) (*Calculation, error)
+}
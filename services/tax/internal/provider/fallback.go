@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+)
+
+// FallbackProvider uses a primary provider, typically an external service,
+// and falls back to a secondary one when the primary is unavailable.
+// Adapters signal unavailability by wrapping domain.ErrProviderUnavailable;
+// other errors, such as rejected addresses, are returned as is.
+type FallbackProvider struct {
+	primary  domain.Provider
+	fallback domain.Provider
+	logger   *slog.Logger
+}
+
+// NewFallbackProvider creates a provider that prefers primary
+func NewFallbackProvider(primary, fallback domain.Provider, logger *slog.Logger) *FallbackProvider {
+	return &FallbackProvider{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+// Name implements domain.Provider
+func (p *FallbackProvider) Name() string {
+	return p.primary.Name()
+}
+
+// Calculate implements domain.Provider
+func (p *FallbackProvider) Calculate(ctx context.Context, params domain.CalculateParams) (*domain.Calculation, error) {
+	calc, err := p.primary.Calculate(ctx, params)
+	if err == nil || !errors.Is(err, domain.ErrProviderUnavailable) {
+		return calc, err
+	}
+
+	p.logger.Warn("Tax provider unavailable, using fallback",
+		"provider", p.primary.Name(),
+		"fallback", p.fallback.Name(),
+		"documentID", params.DocumentID,
+		"error", err)
+	return p.fallback.Calculate(ctx, params)
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+)
+
+//go:embed default_rates.json
+var defaultRates []byte
+
+// Rule is a single rate in the tax table. An empty Region applies to the
+// whole country; regional rules are levied in addition to country rules.
+type Rule struct {
+	Country  string  `json:"country"`
+	Region   string  `json:"region"`
+	TaxClass string  `json:"tax_class"`
+	Name     string  `json:"name"`
+	Rate     float64 `json:"rate"`
+}
+
+// TableProvider calculates taxes from a static table of rates keyed by
+// country, region and tax class
+type TableProvider struct {
+	// rules indexed by jurisdiction ("US" or "US-CA") and then tax class
+	rules map[string]map[string][]Rule
+}
+
+// NewTableProvider creates a provider from rules
+func NewTableProvider(rules []Rule) (*TableProvider, error) {
+	p := &TableProvider{rules: make(map[string]map[string][]Rule)}
+	for i, rule := range rules {
+		if rule.Country == "" {
+			return nil, fmt.Errorf("rule %d: country is required", i)
+		}
+		if rule.Rate < 0 || rule.Rate >= 1 {
+			return nil, fmt.Errorf("rule %d: rate %v out of range [0, 1)", i, rule.Rate)
+		}
+		rule.Country = strings.ToUpper(rule.Country)
+		rule.Region = strings.ToUpper(rule.Region)
+		if rule.TaxClass == "" {
+			rule.TaxClass = domain.StandardClass
+		}
+
+		key := jurisdiction(rule.Country, rule.Region)
+		if p.rules[key] == nil {
+			p.rules[key] = make(map[string][]Rule)
+		}
+		p.rules[key][rule.TaxClass] = append(p.rules[key][rule.TaxClass], rule)
+	}
+	return p, nil
+}
+
+// LoadTableProvider reads rules from a JSON file, or uses the built-in
+// table when path is empty
+func LoadTableProvider(path string) (*TableProvider, error) {
+	data := defaultRates
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tax rates: %w", err)
+		}
+	}
+
+	var table struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse tax rates: %w", err)
+	}
+	return NewTableProvider(table.Rules)
+}
+
+// Name implements domain.Provider
+func (p *TableProvider) Name() string {
+	return "table"
+}
+
+// Calculate implements domain.Provider. Each component is rounded half away
+// from zero to the minor unit, so line totals are the sum of rounded
+// components and the document total is the sum of line totals.
+func (p *TableProvider) Calculate(ctx context.Context, params domain.CalculateParams) (*domain.Calculation, error) {
+	country := strings.ToUpper(params.ShipTo.Country)
+	region := strings.ToUpper(params.ShipTo.Region)
+
+	// A jurisdiction is supported when the table has country-wide rules or
+	// rules for the region. Countries taxed only regionally (like the US)
+	// therefore need an entry for every region, even a zero rate, so a
+	// missing region is an error rather than silently untaxed.
+	countryRules := p.rules[jurisdiction(country, "")]
+	var regionRules map[string][]Rule
+	if region != "" {
+		regionRules = p.rules[jurisdiction(country, region)]
+	}
+	if countryRules == nil && regionRules == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedJurisdiction, jurisdiction(country, region))
+	}
+
+	calc := &domain.Calculation{
+		DocumentID: params.DocumentID,
+		Currency:   params.Currency,
+		Lines:      make([]domain.TaxLine, len(params.Lines)),
+		Provider:   p.Name(),
+	}
+
+	for i, item := range params.Lines {
+		line := domain.TaxLine{
+			ID:            item.ID,
+			TaxableAmount: item.TaxableAmount(),
+		}
+
+		var rules []Rule
+		rules = append(rules, rulesFor(countryRules, item.TaxClass)...)
+		rules = append(rules, rulesFor(regionRules, item.TaxClass)...)
+		for _, rule := range rules {
+			amount := applyRate(line.TaxableAmount, rule.Rate)
+			line.Components = append(line.Components, domain.TaxComponent{
+				Jurisdiction: jurisdiction(rule.Country, rule.Region),
+				Name:         rule.Name,
+				Rate:         rule.Rate,
+				Amount:       amount,
+			})
+			line.TaxAmount += amount
+		}
+
+		calc.Lines[i] = line
+		calc.TotalTaxable += line.TaxableAmount
+		calc.TotalTax += line.TaxAmount
+	}
+
+	return calc, nil
+}
+
+// applyRate returns amount * rate rounded half away from zero. The rate is
+// first fixed to millionths so results do not depend on how the float
+// product happens to round (e.g. 10000 * 0.09975).
+func applyRate(amount int64, rate float64) int64 {
+	const scale = 1_000_000
+	micros := int64(math.Round(rate * scale))
+	product := amount * micros
+	if product < 0 {
+		return -((-product + scale/2) / scale)
+	}
+	return (product + scale/2) / scale
+}
+
+// rulesFor returns the rules for a tax class, falling back to the standard
+// class when the jurisdiction has no specific rate for it
+func rulesFor(rules map[string][]Rule, taxClass string) []Rule {
+	if taxClass == "" {
+		taxClass = domain.StandardClass
+	}
+	if r, ok := rules[taxClass]; ok {
+		return r
+	}
+	return rules[domain.StandardClass]
+}
+
+func jurisdiction(country, region string) string {
+	if region == "" {
+		return country
+	}
+	return country + "-" + region
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTable(t *testing.T) *TableProvider {
+	t.Helper()
+	p, err := NewTableProvider([]Rule{
+		{Country: "US", Region: "CA", Name: "California sales tax", Rate: 0.0725},
+		{Country: "US", Region: "NY", Name: "New York sales tax", Rate: 0.04},
+		{Country: "US", Region: "NY", TaxClass: "clothing", Name: "New York sales tax", Rate: 0},
+		{Country: "DE", Name: "Umsatzsteuer", Rate: 0.19},
+		{Country: "DE", TaxClass: "books", Name: "Umsatzsteuer (ermäßigt)", Rate: 0.07},
+		{Country: "CA", Name: "GST", Rate: 0.05},
+		{Country: "CA", Region: "QC", Name: "QST", Rate: 0.09975},
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestTableProviderCalculate(t *testing.T) {
+	p := testTable(t)
+
+	t.Run("tax class and rounding", func(t *testing.T) {
+		calc, err := p.Calculate(context.Background(), domain.CalculateParams{
+			DocumentID: "o-1",
+			Currency:   "EUR",
+			ShipTo:     domain.Address{Country: "de"},
+			Lines: []domain.LineItem{
+				{ID: "1", Quantity: 3, UnitAmount: 333},                     // 999 * 0.19 = 189.81
+				{ID: "2", TaxClass: "books", Quantity: 1, UnitAmount: 1250}, // 1250 * 0.07 = 87.5
+				{ID: "3", TaxClass: "food", Quantity: 1, UnitAmount: 1000, DiscountAmount: 200},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(190), calc.Lines[0].TaxAmount)
+		assert.Equal(t, int64(88), calc.Lines[1].TaxAmount)
+		assert.InDelta(t, 0.07, calc.Lines[1].Components[0].Rate, 1e-9)
+		// No rate for food, so the standard rate applies to the discounted amount
+		assert.Equal(t, int64(800), calc.Lines[2].TaxableAmount)
+		assert.Equal(t, int64(152), calc.Lines[2].TaxAmount)
+		assert.Equal(t, int64(999+1250+800), calc.TotalTaxable)
+		assert.Equal(t, int64(190+88+152), calc.TotalTax)
+		assert.Equal(t, "table", calc.Provider)
+	})
+
+	t.Run("country and region components", func(t *testing.T) {
+		calc, err := p.Calculate(context.Background(), domain.CalculateParams{
+			ShipTo: domain.Address{Country: "CA", Region: "qc"},
+			Lines:  []domain.LineItem{{ID: "1", Quantity: 1, UnitAmount: 10000}},
+		})
+
+		require.NoError(t, err)
+		line := calc.Lines[0]
+		require.Len(t, line.Components, 2)
+		assert.Equal(t, "CA", line.Components[0].Jurisdiction)
+		assert.Equal(t, int64(500), line.Components[0].Amount)
+		assert.Equal(t, "CA-QC", line.Components[1].Jurisdiction)
+		assert.Equal(t, int64(998), line.Components[1].Amount)
+		assert.Equal(t, int64(1498), line.TaxAmount)
+		assert.InDelta(t, 0.1498, line.EffectiveRate(), 1e-9)
+	})
+
+	t.Run("regional exemption", func(t *testing.T) {
+		calc, err := p.Calculate(context.Background(), domain.CalculateParams{
+			ShipTo: domain.Address{Country: "US", Region: "NY"},
+			Lines:  []domain.LineItem{{ID: "1", TaxClass: "clothing", Quantity: 2, UnitAmount: 2500}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), calc.Lines[0].TaxAmount)
+		assert.Len(t, calc.Lines[0].Components, 1)
+	})
+
+	t.Run("unsupported jurisdictions", func(t *testing.T) {
+		params := domain.CalculateParams{Lines: []domain.LineItem{{ID: "1", Quantity: 1, UnitAmount: 100}}}
+
+		params.ShipTo = domain.Address{Country: "JP"}
+		_, err := p.Calculate(context.Background(), params)
+		assert.ErrorIs(t, err, domain.ErrUnsupportedJurisdiction)
+
+		// US is only taxed regionally, so an unknown state must not come back untaxed
+		params.ShipTo = domain.Address{Country: "US", Region: "NV"}
+		_, err = p.Calculate(context.Background(), params)
+		assert.ErrorIs(t, err, domain.ErrUnsupportedJurisdiction)
+	})
+}
+
+func TestNewTableProviderValidation(t *testing.T) {
+	_, err := NewTableProvider([]Rule{{Region: "CA", Rate: 0.05}})
+	assert.Error(t, err)
+
+	_, err = NewTableProvider([]Rule{{Country: "DE", Rate: 19}})
+	assert.Error(t, err)
+}
+
+func TestLoadDefaultRates(t *testing.T) {
+	p, err := LoadTableProvider("")
+	require.NoError(t, err)
+
+	calc, err := p.Calculate(context.Background(), domain.CalculateParams{
+		ShipTo: domain.Address{Country: "GB"},
+		Lines:  []domain.LineItem{{ID: "1", Quantity: 1, UnitAmount: 1000}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), calc.TotalTax)
+}
+
+// unavailableProvider always fails as if the external service were down
+type unavailableProvider struct{ err error }
+
+func (p unavailableProvider) Name() string { return "external" }
+
+func (p unavailableProvider) Calculate(ctx context.Context, params domain.CalculateParams) (*domain.Calculation, error) {
+	return nil, p.err
+}
+
+func TestFallbackProvider(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	params := domain.CalculateParams{
+		ShipTo: domain.Address{Country: "DE"},
+		Lines:  []domain.LineItem{{ID: "1", Quantity: 1, UnitAmount: 100}},
+	}
+
+	p := NewFallbackProvider(unavailableProvider{err: domain.ErrProviderUnavailable}, testTable(t), logger)
+	calc, err := p.Calculate(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, "table", calc.Provider)
+
+	rejected := errors.New("address rejected")
+	p = NewFallbackProvider(unavailableProvider{err: rejected}, testTable(t), logger)
+	_, err = p.Calculate(context.Background(), params)
+	assert.ErrorIs(t, err, rejected)
+}
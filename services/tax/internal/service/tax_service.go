@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/tax/internal/domain"
+)
+
+// TaxService validates tax requests and delegates them to a provider
+type TaxService struct {
+	provider domain.Provider
+	logger   *slog.Logger
+}
+
+// New creates a new TaxService
+func New(provider domain.Provider, logger *slog.Logger) *TaxService {
+	return &TaxService{
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+// Calculate returns the line-level tax breakdown for an order or invoice
+func (s *TaxService) Calculate(ctx context.Context, params domain.CalculateParams) (*domain.Calculation, error) {
+	s.logger.Info("Calculating tax",
+		"documentType", params.DocumentType,
+		"documentID", params.DocumentID,
+		"country", params.ShipTo.Country,
+		"region", params.ShipTo.Region,
+		"lines", len(params.Lines))
+
+	if err := validateParams(params); err != nil {
+		return nil, err
+	}
+
+	calc, err := s.provider.Calculate(ctx, params)
+	if err != nil {
+		s.logger.Error("Tax calculation failed", "documentID", params.DocumentID, "error", err)
+		return nil, fmt.Errorf("provider error: %w", err)
+	}
+
+	// Guard against adapters that drop or reorder lines
+	if len(calc.Lines) != len(params.Lines) {
+		return nil, fmt.Errorf("provider %s returned %d lines for %d inputs", calc.Provider, len(calc.Lines), len(params.Lines))
+	}
+	for i, line := range calc.Lines {
+		if line.ID != params.Lines[i].ID {
+			return nil, fmt.Errorf("provider %s returned line %q at position %d, expected %q", calc.Provider, line.ID, i, params.Lines[i].ID)
+		}
+	}
+
+	return calc, nil
+}
+
+// validateParams performs basic validation on a tax request
+func validateParams(params domain.CalculateParams) error {
+	switch params.DocumentType {
+	case domain.DocumentOrder, domain.DocumentInvoice:
+	default:
+		return apperrors.Newf(apperrors.Invalid, "document type must be %q or %q", domain.DocumentOrder, domain.DocumentInvoice)
+	}
+	if params.DocumentType == domain.DocumentInvoice && params.DocumentID == "" {
+		return apperrors.New(apperrors.Invalid, "document ID is required for invoices")
+	}
+	if len(params.Currency) != 3 {
+		return apperrors.New(apperrors.Invalid, "currency must be an ISO 4217 code")
+	}
+	if len(params.ShipTo.Country) != 2 {
+		return apperrors.New(apperrors.Invalid, "ship-to country must be an ISO 3166-1 alpha-2 code")
+	}
+	if len(params.Lines) == 0 {
+		return apperrors.New(apperrors.Invalid, "at least one line is required")
+	}
+
+	seen := make(map[string]bool, len(params.Lines))
+	for _, line := range params.Lines {
+		if strings.TrimSpace(line.ID) == "" {
+			return apperrors.New(apperrors.Invalid, "line ID is required")
+		}
+		if seen[line.ID] {
+			return apperrors.Newf(apperrors.Invalid, "duplicate line ID %q", line.ID)
+		}
+		seen[line.ID] = true
+		if line.Quantity <= 0 {
+			return apperrors.Newf(apperrors.Invalid, "line %q: quantity must be greater than zero", line.ID)
+		}
+		if line.UnitAmount < 0 || line.DiscountAmount < 0 {
+			return apperrors.Newf(apperrors.Invalid, "line %q: amounts must not be negative", line.ID)
+		}
+	}
+	return nil
+}
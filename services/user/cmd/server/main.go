@@ -2,8 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -11,37 +12,64 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/bekbull/online-shop/services/user/api/proto"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+	"github.com/bekbull/online-shop/pkg/httptls"
+	"github.com/bekbull/online-shop/pkg/listeners"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+	"github.com/bekbull/online-shop/pkg/workers"
+	userv1 "github.com/bekbull/online-shop/proto/user/v1"
+	"github.com/bekbull/online-shop/services/user/config"
 	"github.com/bekbull/online-shop/services/user/internal/handler"
 	"github.com/bekbull/online-shop/services/user/internal/repository"
 	"github.com/bekbull/online-shop/services/user/internal/service"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	validateOnly := flag.Bool("validate-config", false, "validate the configuration and exit")
+	flag.Parse()
+
+	// Load configuration from environment variables, failing fast on
+	// invalid settings
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *validateOnly {
+		fmt.Println("Configuration is valid")
+		return
+	}
+
 	// Initialize logger
-	logger := log.New(os.Stdout, "[USER-SERVICE] ", log.LstdFlags)
-	logger.Println("Starting user service...")
-
-	// Load configuration from environment variables
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "users")
-	httpPort := getEnv("HTTP_PORT", "8081")
-	grpcPort := getEnv("GRPC_PORT", "9091")
+	logger := logging.New(os.Stdout, logging.Options{
+		Service: "user-service",
+		Version: version,
+		Level:   cfg.Logging.Level,
+		Text:    !cfg.Logging.JSON,
+	})
+	slog.SetDefault(logger)
+	logger.Info("Starting user service")
 
 	// Database connection
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-
-	db, err := sqlx.Connect("postgres", dsn)
+	db, err := sqlx.Connect("postgres", cfg.DB.DSN())
 	if err != nil {
-		logger.Fatalf("Failed to connect to database: %v", err)
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
@@ -50,74 +78,321 @@ func main() {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
+	// Personal data is encrypted with data keys wrapped by these master keys
+	keyring, err := fieldcrypt.ParseKeyring(cfg.PIIMasterKeys)
+	if err != nil {
+		logger.Error("Invalid PII_MASTER_KEYS", "error", err)
+		os.Exit(1)
+	}
+
 	// Create repository
-	repo := repository.NewPostgresRepository(db)
+	crypt := fieldcrypt.New(keyring)
+	repo := repository.NewPostgresRepository(db, crypt)
 
 	// Initialize database schema
 	if err := repo.InitDB(); err != nil {
-		logger.Fatalf("Failed to initialize database schema: %v", err)
+		logger.Error("Failed to initialize database schema", "error", err)
+		os.Exit(1)
+	}
+
+	// Notification preferences reference users, so their schema comes after
+	prefRepo := repository.NewPreferenceRepository(db)
+	if err := prefRepo.InitDB(); err != nil {
+		logger.Error("Failed to initialize notification preference schema", "error", err)
+		os.Exit(1)
 	}
 
 	// Create service
 	userService := service.NewUserService(repo)
+	userService.SetRewrapper(crypt)
+	userService.SetPreferenceRepository(prefRepo)
+
+	loginRepo := repository.NewLoginRepository(db)
+	if err := loginRepo.InitDB(); err != nil {
+		logger.Error("Failed to initialize login schema", "error", err)
+		os.Exit(1)
+	}
+	userService.SetLoginRepository(loginRepo, cfg.Login.StepUpRequired)
+
+	// Changes to users are audited in a hash-chained trail, archived to
+	// object storage once older than AUDIT_RETENTION
+	auditRepo := repository.NewAuditRepository(db)
+	if err := auditRepo.InitDB(); err != nil {
+		logger.Error("Failed to initialize audit schema", "error", err)
+		os.Exit(1)
+	}
+	userService.SetAuditRepository(auditRepo)
+	archive, err := cfg.Audit.Store()
+	if err != nil {
+		logger.Error("Failed to configure the audit archive", "error", err)
+		os.Exit(1)
+	}
+	if archive != nil {
+		userService.SetAuditRetention(cfg.Audit.Retention, archive, cfg.Audit.Prefix)
+		logger.Info("Audit retention enabled", "retention", cfg.Audit.Retention, "bucket", cfg.Audit.BucketURL)
+	}
+
+	bulkRepo := repository.NewBulkJobRepository(db)
+	if err := bulkRepo.InitDB(); err != nil {
+		logger.Error("Failed to initialize bulk job schema", "error", err)
+		os.Exit(1)
+	}
+	userService.SetBulkJobRepository(bulkRepo)
+
+	// Publish user events, such as suspicious logins, if enabled
+	if cfg.Events.Enabled {
+		eventsRedis := redis.NewClient(&redis.Options{
+			Addr:     cfg.Events.RedisAddr,
+			Password: cfg.Events.RedisPassword,
+			DB:       cfg.Events.RedisDB,
+		})
+		defer eventsRedis.Close()
+
+		userService.SetPublisher(eventbus.NewRedisBus(eventsRedis, eventbus.RedisConfig{
+			StreamPrefix: cfg.Events.StreamPrefix,
+			MaxLen:       cfg.Events.MaxLen,
+		}, logger))
+		logger.Info("Event publishing enabled", "redis", cfg.Events.RedisAddr)
+	}
+
+	// Build the shared middleware stack
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	metrics := middleware.NewMetrics(registry, "user_service")
+	// There is no login endpoint yet; add a Login objective with it
+	slos := middleware.NewSLOTracker(registry, "user_service", middleware.Objective{
+		Name:    "GetUser",
+		Routes:  []string{"GET /v1/users/{id}", userv1.UserService_GetUser_FullMethodName},
+		Target:  0.999,
+		Latency: 300 * time.Millisecond,
+	})
+
+	// Background workers
+	background := workers.NewGroup(workers.Options{Metrics: workers.NewMetrics(registry, "user_service")}, logger)
+	if archive != nil {
+		background.Add(workers.Worker{
+			Name:       "audit-retention",
+			Schedule:   workers.Every(cfg.Audit.Interval),
+			RunOnStart: true,
+			Run: func(ctx context.Context) error {
+				_, err := userService.ApplyAuditRetention(ctx)
+				return err
+			},
+		})
+	}
+	// Values still wrapped by a rotated-out master key are re-wrapped with
+	// the current one
+	background.Add(workers.Worker{
+		Name:       "pii-rewrap",
+		Schedule:   workers.Every(cfg.PIIRewrapInterval),
+		RunOnStart: true,
+		Run: func(ctx context.Context) error {
+			_, err := userService.RewrapPhones(ctx)
+			return err
+		},
+	})
+	background.Start(context.Background())
+
+	recovery := middleware.Recovery{Logger: logger, Metrics: metrics}
+	if url := cfg.Server.PanicAlertURL; url != "" {
+		recovery.Alert = middleware.NewWebhookAlert(url, "user-service", logger)
+	}
+
+	accessLog := logging.AccessLogOptions{SampleRate: cfg.Logging.AccessSampleRate, SlowThreshold: cfg.Logging.AccessSlowThreshold}
+
+	apiMiddleware := []func(http.Handler) http.Handler{logging.AccessLog(logger, accessLog), recovery.HTTP, metrics.HTTP, slos.HTTP}
+	unary := []grpc.UnaryServerInterceptor{
+		middleware.RequestIDUnary,
+		logging.UnaryServerInterceptor(logger),
+		logging.AccessLogUnary(logger, accessLog),
+		recovery.Unary,
+		metrics.UnaryServerInterceptor,
+		slos.UnaryServerInterceptor,
+	}
+	streams := []grpc.StreamServerInterceptor{
+		middleware.RequestIDStream,
+		logging.StreamServerInterceptor(logger),
+		logging.AccessLogStream(logger, accessLog),
+		recovery.Stream,
+		metrics.StreamServerInterceptor,
+	}
+	if limit := cfg.Server.LoadShedMaxConcurrency; limit > 0 {
+		shedder := middleware.NewLoadShedder(registry, "user_service", middleware.LoadShedOptions{
+			MaxConcurrency: limit,
+			LatencyTarget:  cfg.Server.LoadShedLatencyTarget,
+			QueueSize:      cfg.Server.LoadShedQueueSize,
+			QueueTimeout:   cfg.Server.LoadShedQueueTimeout,
+			Exempt:         []string{"/health", healthpb.Health_Check_FullMethodName},
+		})
+		apiMiddleware = append(apiMiddleware, shedder.HTTP)
+		unary = append(unary, shedder.UnaryServerInterceptor)
+	}
+	defaultPolicy := middleware.Policy{RPS: cfg.Server.RateLimitRPS, Burst: cfg.Server.RateLimitBurst}
+	redisAddr := cfg.Server.RateLimitRedisAddr
+	if rps := defaultPolicy.RPS; rps > 0 && redisAddr == "" {
+		limiter := middleware.NewLocalLimiter(rps, defaultPolicy.Burst)
+		apiMiddleware = append(apiMiddleware, middleware.RateLimit(limiter, logger))
+		unary = append(unary, middleware.RateLimitUnary(limiter, logger))
+		streams = append(streams, middleware.RateLimitStream(limiter, logger))
+		logger.Info("Rate limiting enabled", "rps", rps)
+	}
+	if tokens := middleware.ParseTokens(cfg.Server.AuthTokens); len(tokens) > 0 {
+		// User data is personal, so every API call needs a token
+		authn := middleware.StaticTokens(tokens)
+		apiMiddleware = append(apiMiddleware, middleware.Auth(authn, nil))
+		// Health checks from load-balancing clients carry no token
+		healthChecks := middleware.SkipMethods(healthpb.Health_Check_FullMethodName, healthpb.Health_Watch_FullMethodName)
+		unary = append(unary, middleware.AuthUnary(authn, healthChecks))
+		streams = append(streams, middleware.AuthStream(authn, healthChecks))
+		logger.Info("Authentication enabled", "tokens", len(tokens))
+	}
+	if redisAddr != "" {
+		// Quotas shared by all instances and chosen per route and caller;
+		// applied after authentication so caller policies can match
+		redisClient := redis.NewClient(&redis.Options{Addr: redisAddr, Password: cfg.Server.RateLimitRedisPassword})
+		defer redisClient.Close()
+		limiter := middleware.NewRedisLimiter(redisClient, "user-service:ratelimit", defaultPolicy)
+		policies := middleware.NewPolicySet(middleware.RateLimitPolicies{Default: defaultPolicy})
+		if path := cfg.Server.RateLimitPoliciesFile; path != "" {
+			if err := policies.LoadFile(path); err != nil {
+				logger.Error("Failed to load rate limit policies", "error", err)
+				os.Exit(1)
+			}
+			go policies.Watch(context.Background(), path, 10*time.Second, logger)
+		}
+		apiMiddleware = append(apiMiddleware, middleware.PolicyRateLimit(limiter, policies, logger))
+		unary = append(unary, middleware.PolicyRateLimitUnary(limiter, policies, logger))
+		streams = append(streams, middleware.PolicyRateLimitStream(limiter, policies, logger))
+		logger.Info("Distributed rate limiting enabled", "redis", redisAddr)
+	}
+	// Timeouts and body limits per route, REQUEST_TIMEOUT and
+	// MAX_BODY_BYTES by default
+	endpoints, err := cfg.Server.EndpointPolicies()
+	if err != nil {
+		logger.Error("Failed to load endpoint policies", "error", err)
+		os.Exit(1)
+	}
+	apiMiddleware = append(apiMiddleware, middleware.EndpointLimits(endpoints))
+
+	// Retries of POSTs carrying an Idempotency-Key replay the first response
+	idempotencyStore := repository.NewIdempotencyStore(db)
+	if err := idempotencyStore.InitDB(); err != nil {
+		logger.Error("Failed to initialize idempotency schema", "error", err)
+		os.Exit(1)
+	}
+	apiMiddleware = append(apiMiddleware, middleware.Idempotency(idempotencyStore, cfg.Idempotency.TTL, logger))
+	unary = append(unary, middleware.EndpointLimitsUnary(endpoints))
 
 	// Create HTTP server
-	httpServer := handler.NewHTTPServer(userService)
+	httpServer := handler.NewHTTPServer(userService, logger, apiMiddleware...)
+	if tokens := middleware.ParseTokens(cfg.Server.SCIMTokens); len(tokens) > 0 {
+		// Identity providers provision staff accounts with their own
+		// tokens, which the /v1 API does not accept
+		httpServer.EnableSCIM(middleware.Auth(middleware.StaticTokens(tokens), nil), middleware.EndpointLimits(endpoints))
+		logger.Info("SCIM provisioning enabled", "tokens", len(tokens))
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	mux.Handle("/slo", slos)
+	mux.Handle("/", httpServer.Router())
+
+	// Load TLS credentials for the gRPC server; certificates are reloaded
+	// when rotated on disk
+	creds, err := mtls.Load(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load TLS credentials", "error", err)
+		os.Exit(1)
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go creds.Watch(watchCtx)
+
+	// The REST API is served over HTTPS when HTTP_TLS_* is set
+	httpTLS, err := httptls.Load(watchCtx, cfg.HTTPTLS, logger)
+	if err != nil {
+		logger.Error("Failed to load HTTP TLS configuration", "error", err)
+		os.Exit(1)
+	}
 	httpSrv := &http.Server{
-		Addr:    ":" + httpPort,
-		Handler: httpServer.Router(),
+		Handler:   mux,
+		TLSConfig: httpTLS,
 	}
+	httpAddrs := cfg.Listen.HTTPAddrs(cfg.HTTPPort)
+	redirectSrv := cfg.HTTPTLS.RedirectServer(listeners.TCPAddr(httpAddrs))
 
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(append(cfg.GRPC.ServerOptions(endpoints.MaxBodyBytes()),
+		creds.ServerOption(),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	)...)
 	userGrpcServer := handler.NewGRPCServer(userService)
-	proto.RegisterUserServiceServer(grpcServer, userGrpcServer)
+	userv1.RegisterUserServiceServer(grpcServer, userGrpcServer)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 	reflection.Register(grpcServer) // Enable reflection for debugging
 
-	// Start HTTP server in a goroutine
-	go func() {
-		logger.Printf("HTTP server listening on port %s", httpPort)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("HTTP server failed: %v", err)
-		}
-	}()
+	// Bind every address before serving, so a taken port or socket stops
+	// the service at startup
+	httpListeners, err := cfg.Listen.Listen(httpAddrs)
+	if err != nil {
+		logger.Error("Failed to listen for HTTP", "error", err)
+		os.Exit(1)
+	}
+	grpcListeners, err := cfg.Listen.Listen(cfg.Listen.GRPCAddrs(cfg.GRPCPort))
+	if err != nil {
+		logger.Error("Failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	for i, lis := range grpcListeners {
+		grpcListeners[i] = cfg.GRPC.Listener(lis)
+	}
 
-	// Start gRPC server in a goroutine
-	go func() {
-		listener, err := net.Listen("tcp", ":"+grpcPort)
-		if err != nil {
-			logger.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
-		}
-		logger.Printf("gRPC server listening on port %s", grpcPort)
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Fatalf("gRPC server failed: %v", err)
-		}
-	}()
+	// Each listener is served on its own; the service exits once all of a
+	// server's listeners have failed
+	exit := func() { os.Exit(1) }
+	logger.Info("Serving HTTP", "tls", httpTLS != nil)
+	listeners.Serve(logger, "HTTP", httpListeners, func(lis net.Listener) error {
+		return httptls.Serve(httpSrv, lis)
+	}, exit)
+	if redirectSrv != nil {
+		httpSrv.RegisterOnShutdown(func() { redirectSrv.Close() })
+		go func() {
+			logger.Info("Redirecting HTTP to HTTPS", "addr", redirectSrv.Addr)
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("HTTPS redirect server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	listeners.Serve(logger, "gRPC", grpcListeners, grpcServer.Serve, exit)
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	logger.Println("Shutting down servers...")
+	logger.Info("Shutting down servers")
+
+	// Report NOT_SERVING first so health-checking clients move away
+	// before connections are drained
+	healthServer.Shutdown()
 
 	// Shutdown HTTP server
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := httpSrv.Shutdown(ctx); err != nil {
-		logger.Fatalf("HTTP server forced to shutdown: %v", err)
+		logger.Error("HTTP server forced to shutdown", "error", err)
 	}
 
 	// Shutdown gRPC server
 	grpcServer.GracefulStop()
 
-	logger.Println("Servers stopped")
-}
-
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+	if err := background.Stop(ctx); err != nil {
+		logger.Error("Background workers did not stop in time", "error", err)
 	}
-	return value
+
+	// Let bulk jobs started before the shutdown finish
+	userService.WaitBulkJobs()
+
+	logger.Info("Servers stopped")
 }
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/pkg/grpcserver"
+	"github.com/bekbull/online-shop/pkg/httptls"
+	"github.com/bekbull/online-shop/pkg/listeners"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/mtls"
+)
+
+// Config holds all configuration for the service
+type Config struct {
+	DB          DBConfig
+	Server      ServerConfig
+	Logging     LoggingConfig
+	Idempotency IdempotencyConfig
+	Events      EventsConfig
+	Login       LoginConfig
+	Audit       audit.Config
+	TLS         mtls.Config
+	GRPC        grpcserver.Config
+	HTTPTLS     httptls.Config
+	Listen      listeners.Config
+	HTTPPort    int
+	GRPCPort    int
+
+	// PIIMasterKeys are the master keys encrypting personal data, as
+	// comma-separated <id>:<base64 key> entries; the first one is current
+	PIIMasterKeys string
+	// PIIRewrapInterval is how often stored personal data is re-wrapped
+	// with the current master key, besides at startup
+	PIIRewrapInterval time.Duration
+
+	// problems lists the malformed environment values seen by Load
+	problems []string
+}
+
+// DBConfig holds PostgreSQL configuration
+type DBConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+}
+
+// DSN returns the PostgreSQL connection string
+func (c *DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		c.Host, c.Port, c.User, c.Password, c.Name)
+}
+
+// ServerConfig holds HTTP and gRPC API configuration
+type ServerConfig struct {
+	RequestTimeout time.Duration
+	MaxBodyBytes   int64
+	AuthTokens     string
+	// SCIMTokens are the bearer tokens of identity providers, the only
+	// callers of the SCIM endpoints, which are off without them
+	SCIMTokens     string
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// With a Redis address, quotas are shared by all instances and chosen
+	// per route and caller from the policies file, which is reloaded when
+	// it changes. RateLimitRPS and RateLimitBurst are the default policy.
+	RateLimitRedisAddr     string
+	RateLimitRedisPassword string
+	RateLimitPoliciesFile  string
+
+	// EndpointPoliciesFile sets timeouts, retries and body limits per
+	// route and RPC; RequestTimeout and MaxBodyBytes are the defaults
+	EndpointPoliciesFile string
+
+	// PanicAlertURL receives a JSON report of every recovered panic
+	PanicAlertURL string
+
+	// At most LoadShedMaxConcurrency requests are handled at once, fewer
+	// while they take longer than LoadShedLatencyTarget. Up to
+	// LoadShedQueueSize more wait LoadShedQueueTimeout for a slot; the rest
+	// get 503. Zero disables load shedding.
+	LoadShedMaxConcurrency int
+	LoadShedLatencyTarget  time.Duration
+	LoadShedQueueSize      int
+	LoadShedQueueTimeout   time.Duration
+}
+
+// LoggingConfig holds configuration for logging
+type LoggingConfig struct {
+	Level string
+	JSON  bool
+
+	// Successful requests are access logged at AccessSampleRate; failed
+	// ones and those slower than AccessSlowThreshold always are
+	AccessSampleRate    float64
+	AccessSlowThreshold time.Duration
+}
+
+// IdempotencyConfig holds configuration for Idempotency-Key handling on
+// POST endpoints
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// EventsConfig holds configuration for publishing user events
+type EventsConfig struct {
+	Enabled       bool
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	StreamPrefix  string
+	MaxLen        int64
+}
+
+// LoginConfig holds configuration for recorded logins
+type LoginConfig struct {
+	// StepUpRequired makes suspicious logins wait for verification before
+	// their device and location are trusted
+	StepUpRequired bool
+}
+
+// Load loads configuration from environment variables. Malformed values
+// fall back to their defaults; Validate reports them. Load is not safe for
+// concurrent use.
+func Load() *Config {
+	loadProblems = nil
+	cfg := &Config{
+		DB: DBConfig{
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     getEnvInt("DB_PORT", 5432),
+			User:     getEnv("DB_USER", "postgres"),
+			Password: getEnv("DB_PASSWORD", "postgres"),
+			Name:     getEnv("DB_NAME", "users"),
+		},
+		Server: ServerConfig{
+			RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+			MaxBodyBytes:   int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+			AuthTokens:     getEnv("AUTH_TOKENS", ""),
+			SCIMTokens:     getEnv("SCIM_TOKENS", ""),
+			RateLimitRPS:   getEnvFloat("RATE_LIMIT_RPS", 0),
+			RateLimitBurst: getEnvInt("RATE_LIMIT_BURST", 0),
+
+			RateLimitRedisAddr:     getEnv("RATE_LIMIT_REDIS_ADDR", ""),
+			RateLimitRedisPassword: getEnv("RATE_LIMIT_REDIS_PASSWORD", ""),
+			RateLimitPoliciesFile:  getEnv("RATE_LIMIT_POLICIES_FILE", ""),
+
+			EndpointPoliciesFile: getEnv("ENDPOINT_POLICIES_FILE", ""),
+
+			PanicAlertURL: getEnv("PANIC_ALERT_WEBHOOK_URL", ""),
+
+			LoadShedMaxConcurrency: getEnvInt("LOAD_SHED_MAX_CONCURRENCY", 256),
+			LoadShedLatencyTarget:  getEnvDuration("LOAD_SHED_LATENCY_TARGET", time.Second),
+			LoadShedQueueSize:      getEnvInt("LOAD_SHED_QUEUE_SIZE", 64),
+			LoadShedQueueTimeout:   getEnvDuration("LOAD_SHED_QUEUE_TIMEOUT", 100*time.Millisecond),
+		},
+		Logging: LoggingConfig{
+			Level: getEnv("LOG_LEVEL", "info"),
+			JSON:  getEnvBool("LOG_JSON", true),
+
+			AccessSampleRate:    getEnvFloat("ACCESS_LOG_SAMPLE_RATE", 1),
+			AccessSlowThreshold: getEnvDuration("ACCESS_LOG_SLOW_THRESHOLD", time.Second),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		Events: EventsConfig{
+			Enabled:       getEnvBool("EVENTS_ENABLED", false),
+			RedisAddr:     getEnv("EVENTS_REDIS_ADDR", "redis:6379"),
+			RedisPassword: getEnv("EVENTS_REDIS_PASSWORD", ""),
+			RedisDB:       getEnvInt("EVENTS_REDIS_DB", 0),
+			StreamPrefix:  getEnv("EVENTS_STREAM_PREFIX", "events"),
+			MaxLen:        int64(getEnvInt("EVENTS_STREAM_MAX_LEN", 100000)),
+		},
+		Login: LoginConfig{
+			StepUpRequired: getEnvBool("LOGIN_STEP_UP_REQUIRED", false),
+		},
+		Audit:             audit.FromEnv(),
+		TLS:               mtls.FromEnv(),
+		GRPC:              grpcserver.FromEnv(),
+		HTTPTLS:           httptls.FromEnv(),
+		Listen:            listeners.FromEnv(),
+		HTTPPort:          getEnvInt("HTTP_PORT", 8081),
+		GRPCPort:          getEnvInt("GRPC_PORT", 9091),
+		PIIMasterKeys:     getEnv("PII_MASTER_KEYS", ""),
+		PIIRewrapInterval: getEnvDuration("PII_REWRAP_INTERVAL", 24*time.Hour),
+	}
+	cfg.problems = loadProblems
+	return cfg
+}
+
+// Helper functions
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+		malformed(key, value, "an integer")
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		malformed(key, value, "a number")
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		malformed(key, value, "a boolean (true or false)")
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+		malformed(key, value, "a duration with a unit, e.g. 500ms, 30s or 5m")
+	}
+	return defaultValue
+}
+
+// EndpointPolicies returns the timeouts, retries and body limits per route
+// and RPC from EndpointPoliciesFile. RequestTimeout and MaxBodyBytes apply
+// where it sets none, and to everything without a file.
+func (c *ServerConfig) EndpointPolicies() (*middleware.EndpointPolicies, error) {
+	defaults := middleware.EndpointPolicy{Timeout: middleware.Duration(c.RequestTimeout), MaxBodyBytes: c.MaxBodyBytes}
+	if c.EndpointPoliciesFile == "" {
+		return &middleware.EndpointPolicies{Default: defaults}, nil
+	}
+	return middleware.LoadEndpointPolicies(c.EndpointPoliciesFile, defaults)
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMasterKeys = "dev-1:1HVNU+xzdbIenQHrJ871CYj7kyST3gDRuLlKiFMP1+k="
+
+func TestValidate(t *testing.T) {
+	t.Setenv("PII_MASTER_KEYS", testMasterKeys)
+	assert.NoError(t, Load().Validate())
+
+	t.Setenv("PII_MASTER_KEYS", "dev-1:short")
+	t.Setenv("DB_PORT", "postgres")
+	t.Setenv("REQUEST_TIMEOUT", "-1s")
+
+	var verr *ValidationError
+	require.ErrorAs(t, Load().Validate(), &verr)
+	require.Len(t, verr.Problems, 3)
+	assert.Equal(t, `DB_PORT="postgres" is not an integer`, verr.Problems[0])
+	assert.Equal(t, "REQUEST_TIMEOUT must be positive", verr.Problems[1])
+	assert.Contains(t, verr.Problems[2], "PII_MASTER_KEYS is invalid")
+}
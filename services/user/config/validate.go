@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+)
+
+// loadProblems collects the malformed values the getEnv helpers see
+// during Load
+var loadProblems []string
+
+// malformed records that key holds value, which is not what
+func malformed(key, value, what string) {
+	loadProblems = append(loadProblems, fmt.Sprintf("%s=%q is not %s", key, value, what))
+}
+
+// ValidationError lists every invalid or missing setting
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration and returns a *ValidationError
+// listing all invalid or missing settings, so they can be fixed in one go
+func (c *Config) Validate() error {
+	problems := append([]string(nil), c.problems...)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(validPort(c.HTTPPort), "HTTP_PORT=%d must be a port between 1 and 65535", c.HTTPPort)
+	check(validPort(c.GRPCPort), "GRPC_PORT=%d must be a port between 1 and 65535", c.GRPCPort)
+	check(c.HTTPPort != c.GRPCPort, "HTTP_PORT and GRPC_PORT must differ, both are %d", c.HTTPPort)
+	check(validPort(c.DB.Port), "DB_PORT=%d must be a port between 1 and 65535", c.DB.Port)
+
+	check(c.Server.RequestTimeout > 0, "REQUEST_TIMEOUT must be positive")
+	check(c.Server.MaxBodyBytes > 0, "MAX_BODY_BYTES must be positive")
+	check(c.Server.RateLimitRPS >= 0, "RATE_LIMIT_RPS must not be negative; use 0 to disable rate limiting")
+	check(c.Server.RateLimitBurst >= 0, "RATE_LIMIT_BURST must not be negative")
+	if c.Server.RateLimitPoliciesFile != "" {
+		check(c.Server.RateLimitRedisAddr != "", "RATE_LIMIT_POLICIES_FILE requires RATE_LIMIT_REDIS_ADDR")
+		check(fileExists(c.Server.RateLimitPoliciesFile), "RATE_LIMIT_POLICIES_FILE=%q does not exist", c.Server.RateLimitPoliciesFile)
+	}
+	if c.Server.EndpointPoliciesFile != "" {
+		if _, err := c.Server.EndpointPolicies(); err != nil {
+			problems = append(problems, "ENDPOINT_POLICIES_FILE is invalid: "+err.Error())
+		}
+	}
+	if c.Server.PanicAlertURL != "" {
+		check(validURL(c.Server.PanicAlertURL), "PANIC_ALERT_WEBHOOK_URL=%q must be an http or https URL", c.Server.PanicAlertURL)
+	}
+	check(c.Server.LoadShedMaxConcurrency >= 0, "LOAD_SHED_MAX_CONCURRENCY must not be negative; use 0 to disable load shedding")
+	if c.Server.LoadShedMaxConcurrency > 0 {
+		check(c.Server.LoadShedLatencyTarget > 0, "LOAD_SHED_LATENCY_TARGET must be positive")
+		check(c.Server.LoadShedQueueSize >= 0, "LOAD_SHED_QUEUE_SIZE must not be negative")
+		check(c.Server.LoadShedQueueTimeout >= 0, "LOAD_SHED_QUEUE_TIMEOUT must not be negative")
+	}
+	check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be positive")
+	if c.Events.Enabled {
+		check(c.Events.RedisAddr != "", "EVENTS_REDIS_ADDR is required with EVENTS_ENABLED")
+		check(c.Events.MaxLen > 0, "EVENTS_STREAM_MAX_LEN must be positive")
+	}
+	if err := c.Audit.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	} else if _, err := c.Audit.Store(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := c.GRPC.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	check(validLogLevel(c.Logging.Level), "LOG_LEVEL=%q must be debug, info, warn or error", c.Logging.Level)
+	check(c.Logging.AccessSampleRate >= 0 && c.Logging.AccessSampleRate <= 1, "ACCESS_LOG_SAMPLE_RATE=%g must be between 0 and 1", c.Logging.AccessSampleRate)
+	check(c.Logging.AccessSlowThreshold >= 0, "ACCESS_LOG_SLOW_THRESHOLD must not be negative; use 0 to disable")
+
+	if c.PIIMasterKeys == "" {
+		problems = append(problems, "PII_MASTER_KEYS is required: comma-separated <id>:<base64 32-byte key> entries, e.g. generated with `openssl rand -base64 32`")
+	} else if _, err := fieldcrypt.ParseKeyring(c.PIIMasterKeys); err != nil {
+		problems = append(problems, "PII_MASTER_KEYS is invalid: "+err.Error())
+	}
+	check(c.PIIRewrapInterval > 0, "PII_REWRAP_INTERVAL must be positive")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	if err := c.HTTPTLS.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := c.Listen.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, file := range []struct{ key, path string }{
+		{"TLS_CERT_FILE", c.TLS.CertFile},
+		{"TLS_KEY_FILE", c.TLS.KeyFile},
+		{"TLS_CA_FILE", c.TLS.CAFile},
+		{"HTTP_TLS_CERT_FILE", c.HTTPTLS.CertFile},
+		{"HTTP_TLS_KEY_FILE", c.HTTPTLS.KeyFile},
+	} {
+		if file.path != "" {
+			check(fileExists(file.path), "%s=%q does not exist", file.key, file.path)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port < 65536
+}
+
+func validLogLevel(level string) bool {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "warning", "error":
+		return true
+	}
+	return false
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Results of an action on a user, as audited
+const (
+	AuditResultApplied   = "applied"
+	AuditResultUnchanged = "unchanged"
+	AuditResultFailed    = "failed"
+)
+
+// AuditEntry records an administrative change to a user. Entries are
+// hash-chained in ID order: Hash covers the entry and PrevHash, the hash
+// of the entry before it (see pkg/audit).
+type AuditEntry struct {
+	ID     int64  `json:"id"`
+	UserID string `json:"user_id"`
+	Action string `json:"action"`
+	// Detail is the parameter of the action, such as the role assigned
+	Detail   string    `json:"detail,omitempty"`
+	Actor    string    `json:"actor"`
+	JobID    string    `json:"job_id,omitempty"`
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+}
+
+// AuditRepository stores the audit trail of changes to users
+type AuditRepository interface {
+	// Record appends an entry to the chain, setting its ID and hashes
+	Record(ctx context.Context, entry *AuditEntry) error
+	// Export calls fn with the entries from from up to to, in ID order
+	Export(ctx context.Context, from, to time.Time, fn func(*AuditEntry) error) error
+	// LastBefore returns the ID of the last entry recorded before t, or 0
+	LastBefore(ctx context.Context, t time.Time) (int64, error)
+	// ExportThrough calls fn with the entries up to and including ID last,
+	// in ID order
+	ExportThrough(ctx context.Context, last int64, fn func(*AuditEntry) error) error
+	// DeleteThrough deletes the entries up to and including ID last
+	DeleteThrough(ctx context.Context, last int64) (int64, error)
+}
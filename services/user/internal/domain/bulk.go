@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Bulk actions administrators apply to many users at once
+const (
+	BulkAssignRole         = "assign_role"
+	BulkSuspend            = "suspend"
+	BulkTag                = "tag"
+	BulkForcePasswordReset = "force_password_reset"
+)
+
+// Statuses of a bulk job
+const (
+	BulkStatusQueued    = "queued"
+	BulkStatusRunning   = "running"
+	BulkStatusCompleted = "completed"
+	BulkStatusFailed    = "failed"
+)
+
+const (
+	// MaxBulkUsers is the most users one bulk job applies to
+	MaxBulkUsers = 10000
+	// MaxBulkErrors is the most failures a bulk job keeps
+	MaxBulkErrors = 100
+)
+
+// IsBulkAction reports whether action is a known bulk action
+func IsBulkAction(action string) bool {
+	switch action {
+	case BulkAssignRole, BulkSuspend, BulkTag, BulkForcePasswordReset:
+		return true
+	}
+	return false
+}
+
+// UserFilter selects users by their attributes. Email matches part of the
+// address; the other fields match exactly.
+type UserFilter struct {
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role,omitempty"`
+	Group string `json:"customer_group,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// IsZero reports whether the filter selects every user
+func (f UserFilter) IsZero() bool {
+	return f == UserFilter{}
+}
+
+// BulkError is the failure of a bulk action on one user
+type BulkError struct {
+	UserID string `json:"user_id"`
+	Error  string `json:"error"`
+}
+
+// BulkJob applies an action to a set of users in the background. The users
+// are the explicit UserIDs, or those matching Filter when the job was
+// started. Role and Tag are the parameters of the assign_role and tag
+// actions.
+type BulkJob struct {
+	ID        string      `json:"id"`
+	Action    string      `json:"action"`
+	Role      string      `json:"role,omitempty"`
+	Tag       string      `json:"tag,omitempty"`
+	Filter    *UserFilter `json:"filter,omitempty"`
+	UserIDs   []string    `json:"-"`
+	Status    string      `json:"status"`
+	Total     int         `json:"total"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	// Errors holds the first MaxBulkErrors failures
+	Errors     []BulkError `json:"errors"`
+	CreatedBy  string      `json:"created_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// Processed returns how many users the job has handled
+func (j *BulkJob) Processed() int {
+	return j.Succeeded + j.Failed
+}
+
+// BulkJobRepository stores bulk jobs
+type BulkJobRepository interface {
+	Create(ctx context.Context, job *BulkJob) error
+	Get(ctx context.Context, id string) (*BulkJob, error)
+	// Update stores the status and progress of a job
+	Update(ctx context.Context, job *BulkJob) error
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+)
+
+// LoginPagination configures paging of login histories
+var LoginPagination = pagination.Options{DefaultPageSize: 20}
+
+// Reasons a login is suspicious
+const (
+	LoginReasonNewDevice   = "new_device"
+	LoginReasonNewLocation = "new_location"
+)
+
+// LoginAttempt is a successful sign-in as reported by the component that
+// checked the credentials. Country and Region are its coarse location,
+// such as "DE" and "BE", when the reporter knows it.
+type LoginAttempt struct {
+	UserAgent string
+	IP        string
+	Country   string
+	Region    string
+}
+
+// Login is a recorded sign-in of a user
+type Login struct {
+	ID     int64  `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"user_id"`
+	// DeviceID fingerprints the device by its user agent
+	DeviceID  string `json:"device_id" db:"device_id"`
+	UserAgent string `json:"user_agent" db:"user_agent"`
+	IP        string `json:"ip,omitempty" db:"ip"`
+	Country   string `json:"country,omitempty" db:"country"`
+	Region    string `json:"region,omitempty" db:"region"`
+	// Location is the coarse location logins are compared by: the country
+	// and region, or the IP's network without them
+	Location string `json:"location" db:"location"`
+	// Reasons lists why the login is suspicious, empty when it is not
+	Reasons []string `json:"reasons" db:"-"`
+	// StepUpRequired is set when the login must be verified before the
+	// device and location are trusted; VerifiedAt is when it was
+	StepUpRequired bool       `json:"step_up_required" db:"step_up_required"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Suspicious reports whether the login came from a new device or location
+func (l *Login) Suspicious() bool {
+	return len(l.Reasons) > 0
+}
+
+// DeviceFingerprint returns the device ID of a user agent
+func DeviceFingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(userAgent))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoginLocation returns the coarse location of a login: the upper-cased
+// country and region when the country is known, otherwise the /24 network
+// of an IPv4 address or the /48 of an IPv6 one. It is empty when neither is
+// known.
+func LoginLocation(country, region, ip string) string {
+	if country = strings.ToUpper(strings.TrimSpace(country)); country != "" {
+		if region = strings.ToUpper(strings.TrimSpace(region)); region != "" {
+			return country + "/" + region
+		}
+		return country
+	}
+	addr := net.ParseIP(strings.TrimSpace(ip))
+	switch {
+	case addr == nil:
+		return ""
+	case addr.To4() != nil:
+		return addr.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	default:
+		return addr.Mask(net.CIDRMask(48, 128)).String() + "/48"
+	}
+}
+
+// LoginHistory is what a user's trusted logins have in common with a new
+// one. Logins are trusted unless they await step-up verification.
+type LoginHistory struct {
+	Logins        int
+	KnownDevice   bool
+	KnownLocation bool
+}
+
+// LoginRepository stores the logins of users
+type LoginRepository interface {
+	// History compares the user's trusted logins with a device and location
+	History(ctx context.Context, userID, deviceID, location string) (LoginHistory, error)
+	// Create stores a login and sets its ID
+	Create(ctx context.Context, login *Login) error
+	Get(ctx context.Context, userID string, id int64) (*Login, error)
+	// Verify sets the verification time of a login awaiting step-up
+	Verify(ctx context.Context, userID string, id int64, at time.Time) error
+	// List returns the logins of a user, newest first
+	List(ctx context.Context, userID string, page pagination.Request) ([]Login, int, error)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+)
+
+// ConsentPagination configures paging of consent histories
+var ConsentPagination = pagination.Options{DefaultPageSize: 20}
+
+// Channels notifications are sent through
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+// Categories of notifications
+const (
+	CategoryOrderUpdates = "order_updates"
+	CategoryMarketing    = "marketing"
+	CategoryPriceAlerts  = "price_alerts"
+)
+
+// Channels and Categories list the channels and categories in the order
+// preferences are returned
+var (
+	Channels   = []string{ChannelEmail, ChannelSMS, ChannelPush}
+	Categories = []string{CategoryOrderUpdates, CategoryMarketing, CategoryPriceAlerts}
+)
+
+// IsChannel reports whether channel is a known notification channel
+func IsChannel(channel string) bool {
+	return channel == ChannelEmail || channel == ChannelSMS || channel == ChannelPush
+}
+
+// IsCategory reports whether category is a known notification category
+func IsCategory(category string) bool {
+	return category == CategoryOrderUpdates || category == CategoryMarketing || category == CategoryPriceAlerts
+}
+
+// DefaultConsent reports whether a user who has not set a preference gets
+// notifications of a category on a channel. Only order updates by email
+// and push are sent by default; SMS and marketing need the user's consent.
+func DefaultConsent(channel, category string) bool {
+	return category == CategoryOrderUpdates && channel != ChannelSMS
+}
+
+// Preference is whether a user gets notifications of a category on a
+// channel
+type Preference struct {
+	Channel  string `json:"channel" db:"channel"`
+	Category string `json:"category" db:"category"`
+	Enabled  bool   `json:"enabled" db:"enabled"`
+	// Default is set when the user has not chosen, and Enabled is the
+	// default
+	Default   bool       `json:"default" db:"-"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// ConsentChange is an audit entry for one preference a user or admin
+// changed
+type ConsentChange struct {
+	ID       int64  `json:"id" db:"id"`
+	UserID   string `json:"user_id" db:"user_id"`
+	Channel  string `json:"channel" db:"channel"`
+	Category string `json:"category" db:"category"`
+	Enabled  bool   `json:"enabled" db:"enabled"`
+	// ChangedBy is the authenticated subject that made the change, empty
+	// without authentication
+	ChangedBy string    `json:"changed_by,omitempty" db:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// PreferenceRepository stores notification preferences and the audit
+// entries of their changes
+type PreferenceRepository interface {
+	// Preferences returns the preferences the user has set
+	Preferences(ctx context.Context, userID string) ([]Preference, error)
+	// SetPreferences stores the changes and their audit entries together
+	SetPreferences(ctx context.Context, changes []ConsentChange) error
+	// ConsentHistory returns the audit entries of a user, newest first
+	ConsentHistory(ctx context.Context, userID string, page pagination.Request) ([]ConsentChange, int, error)
+}
@@ -1,21 +1,71 @@
 package domain
 
 import (
+	"context"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/google/uuid"
 )
 
-// User represents a user in the system
+// UserPagination configures paging of user lists
+var UserPagination = pagination.Options{DefaultPageSize: 10}
+
+// Customer groups of users. The product service prices products for the
+// group of the caller; retail customers pay the list price.
+const (
+	CustomerGroupRetail    = "retail"
+	CustomerGroupWholesale = "wholesale"
+	CustomerGroupVIP       = "vip"
+)
+
+// IsCustomerGroup reports whether group is a known customer group
+func IsCustomerGroup(group string) bool {
+	switch group {
+	case CustomerGroupRetail, CustomerGroupWholesale, CustomerGroupVIP:
+		return true
+	}
+	return false
+}
+
+// Handles are public names shown instead of the email, e.g. on reviews:
+// lowercase letters, digits and underscores, starting with a letter
+const (
+	MinHandleLength = 3
+	MaxHandleLength = 30
+)
+
+// reservedHandles could pass for the shop or its staff
+var reservedHandles = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "help": true, "moderator": true,
+	"null": true, "official": true, "onlineshop": true, "online_shop": true, "root": true,
+	"security": true, "shop": true, "staff": true, "support": true, "system": true,
+}
+
+// IsReservedHandle reports whether handle may not be taken by users
+func IsReservedHandle(handle string) bool {
+	return reservedHandles[handle]
+}
+
+// User represents a user in the system. Suspended is set on users an
+// administrator has locked out; PasswordResetRequired asks the user to
+// choose a new password, and is cleared when they do. Handle is empty
+// until the user picks one.
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	FirstName    string    `json:"first_name" db:"first_name"`
-	LastName     string    `json:"last_name" db:"last_name"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Roles        []string  `json:"roles" db:"roles"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                    string    `json:"id" db:"id"`
+	Email                 string    `json:"email" db:"email"`
+	Handle                string    `json:"handle,omitempty" db:"handle"`
+	FirstName             string    `json:"first_name" db:"first_name"`
+	LastName              string    `json:"last_name" db:"last_name"`
+	PasswordHash          string    `json:"-" db:"password_hash"`
+	Roles                 []string  `json:"roles" db:"roles"`
+	Group                 string    `json:"customer_group" db:"customer_group"`
+	Tags                  []string  `json:"tags" db:"tags"`
+	Suspended             bool      `json:"suspended" db:"suspended"`
+	PasswordResetRequired bool      `json:"password_reset_required" db:"password_reset_required"`
+	Phone                 string    `json:"phone,omitempty" db:"-"` // stored encrypted
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // NewUser creates a new user with default values
@@ -28,6 +78,8 @@ func NewUser(email, firstName, lastName, passwordHash string, roles []string) *U
 		LastName:     lastName,
 		PasswordHash: passwordHash,
 		Roles:        roles,
+		Group:        CustomerGroupRetail,
+		Tags:         []string{},
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -35,20 +87,56 @@ func NewUser(email, firstName, lastName, passwordHash string, roles []string) *U
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
-	Create(user *User) error
-	GetByID(id string) (*User, error)
-	GetByEmail(email string) (*User, error)
-	Update(user *User) error
-	Delete(id string) error
-	List(page, pageSize int, emailFilter string) ([]*User, int, error)
+	Create(ctx context.Context, user *User) error
+	GetByID(ctx context.Context, id string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByHandle(ctx context.Context, handle string) (*User, error)
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, page pagination.Request, emailFilter string) ([]*User, int, error)
+	// ListIDs returns the IDs of up to limit users matching the filter, in
+	// creation order
+	ListIDs(ctx context.Context, filter UserFilter, limit int) ([]string, error)
+	// ListRoles returns the roles held by at least one user, sorted
+	ListRoles(ctx context.Context) ([]string, error)
+	// HandlesTaken returns those of handles that users have
+	HandlesTaken(ctx context.Context, handles []string) ([]string, error)
+	// ListEncryptedPhones returns up to limit stored phone ciphertexts of
+	// users whose ID sorts after after, in ID order
+	ListEncryptedPhones(ctx context.Context, after string, limit int) ([]EncryptedValue, error)
+	// ReplaceEncryptedPhone stores ciphertext as a user's phone if the
+	// stored one is still old, and reports whether it did
+	ReplaceEncryptedPhone(ctx context.Context, userID, old, ciphertext string) (bool, error)
+}
+
+// EncryptedValue is a field of a user as stored, still encrypted
+type EncryptedValue struct {
+	UserID     string `db:"id"`
+	Ciphertext string `db:"ciphertext"`
 }
 
 // UserService defines the interface for user business logic
 type UserService interface {
-	CreateUser(email, firstName, lastName, password string, roles []string) (*User, error)
-	GetUser(id string) (*User, error)
-	GetUserByEmail(email string) (*User, error)
-	UpdateUser(id string, updates map[string]interface{}) (*User, error)
-	DeleteUser(id string) error
-	ListUsers(page, pageSize int, emailFilter string) ([]*User, int, error)
+	CreateUser(ctx context.Context, email, firstName, lastName, password string, roles []string) (*User, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUserByHandle(ctx context.Context, handle string) (*User, error)
+	SuggestHandles(ctx context.Context, userID, base string) ([]string, error)
+	UpdateUser(ctx context.Context, id string, updates map[string]interface{}) (*User, error)
+	DeleteUser(ctx context.Context, id string) error
+	ListUsers(ctx context.Context, page pagination.Request, emailFilter string) ([]*User, int, error)
+	NotificationPreferences(ctx context.Context, userID string) ([]Preference, error)
+	UpdateNotificationPreferences(ctx context.Context, userID string, updates []Preference) ([]Preference, error)
+	CheckConsent(ctx context.Context, userID, channel, category string) (bool, error)
+	ConsentHistory(ctx context.Context, userID string, page pagination.Request) ([]ConsentChange, int, error)
+	RecordLogin(ctx context.Context, userID string, attempt LoginAttempt) (*Login, error)
+	VerifyLogin(ctx context.Context, userID string, loginID int64) (*Login, error)
+	ListLogins(ctx context.Context, userID string, page pagination.Request) ([]Login, int, error)
+	StartBulkJob(ctx context.Context, job *BulkJob) (*BulkJob, error)
+	GetBulkJob(ctx context.Context, id string) (*BulkJob, error)
+	ExportAudit(ctx context.Context, from, to time.Time, fn func(*AuditEntry) error) error
+	ProvisionUser(ctx context.Context, user *User, password string) (*User, error)
+	ListRoles(ctx context.Context) ([]string, error)
+	RoleMembers(ctx context.Context, role string) ([]string, error)
+	SetUserRole(ctx context.Context, userID, role string, member bool) error
 }
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/service"
+	"google.golang.org/grpc/status"
+)
+
+// The fuzz targets send hostile JSON to the REST handlers and check that
+// they never panic or fail with a server error, and that the gRPC API
+// accepts or rejects the same request the same way. Run one with e.g.
+//
+//	go test -run '^$' -fuzz FuzzCreateUser ./internal/handler
+
+// maxBodyBytes bounds request bodies like the default endpoint policy
+const maxBodyBytes = 64 << 10
+
+const (
+	seedUserID    = "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1"
+	seedUserEmail = "taken@example.com"
+)
+
+func FuzzCreateUser(f *testing.F) {
+	f.Add([]byte(`{"email":"ann@example.com","first_name":"Ann","last_name":"Lee","password":"correct-horse","roles":["customer"]}`))
+	f.Add([]byte(`{"email":"taken@example.com","first_name":"Ann","last_name":"Lee","password":"correct-horse"}`))
+	f.Add([]byte(`{"email":"ann@example.com","first_name":"Ann","last_name":"Lee","password":"short"}`))
+	f.Add([]byte(`{"email":"ann@example.com","first_name":"Ann","last_name":"Lee","password":"` + strings.Repeat("p", 100) + `"}`))
+	f.Add([]byte(`{"email":"\ud800","first_name":"\u0000","last_name":"x","password":"12345678","roles":null}`))
+	f.Add([]byte(`{"email":1}`))
+	f.Add([]byte(`{}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		status, _ := serveREST(t, http.MethodPost, "/v1/users", body)
+		if status >= 500 {
+			t.Fatalf("POST /v1/users answered %d for %q", status, body)
+		}
+
+		var req pb.CreateUserRequest
+		if json.Unmarshal(body, &struct {
+			Email     *string   `json:"email"`
+			FirstName *string   `json:"first_name"`
+			LastName  *string   `json:"last_name"`
+			Password  *string   `json:"password"`
+			Roles     *[]string `json:"roles"`
+		}{&req.Email, &req.FirstName, &req.LastName, &req.Password, &req.Roles}) != nil {
+			return
+		}
+		_, err := newGRPC().CreateUser(context.Background(), &req)
+		sameOutcome(t, body, status, http.StatusCreated, err)
+	})
+}
+
+func FuzzUpdateUser(f *testing.F) {
+	f.Add([]byte(`{"first_name":"Ann","roles":["admin"]}`))
+	f.Add([]byte(`{"email":"taken2@example.com","password":"new-password"}`))
+	f.Add([]byte(`{"password":"short"}`))
+	f.Add([]byte(`{"email":"","last_name":null}`))
+	f.Add([]byte(`{"phone":"+15551234567"}`))
+	f.Add([]byte(`{"roles":"admin"}`))
+	f.Add([]byte(`{"handle":"@Ann_Lee"}`))
+	f.Add([]byte(`{"handle":"taken"}`))
+	f.Add([]byte(`{"handle":"admin"}`))
+	f.Add([]byte(`{"handle":""}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		status, resp := serveREST(t, http.MethodPut, "/v1/users/"+seedUserID, body)
+		if status >= 500 {
+			t.Fatalf("PUT /v1/users/{id} answered %d for %q", status, body)
+		}
+		if status == http.StatusOK && !json.Valid(resp) {
+			t.Fatalf("PUT /v1/users/{id} answered invalid JSON %q", resp)
+		}
+
+		var fields struct {
+			Email     *string  `json:"email"`
+			FirstName *string  `json:"first_name"`
+			LastName  *string  `json:"last_name"`
+			Password  *string  `json:"password"`
+			Roles     []string `json:"roles"`
+			Phone     *string  `json:"phone"`
+			Handle    *string  `json:"handle"`
+		}
+		if json.Unmarshal(body, &fields) != nil || fields.Phone != nil {
+			// The gRPC API has no phone number
+			return
+		}
+		_, err := newGRPC().UpdateUser(context.Background(), &pb.UpdateUserRequest{
+			Id:        seedUserID,
+			Email:     fields.Email,
+			FirstName: fields.FirstName,
+			LastName:  fields.LastName,
+			Password:  fields.Password,
+			Roles:     fields.Roles,
+			Handle:    fields.Handle,
+		})
+		sameOutcome(t, body, status, http.StatusOK, err)
+	})
+}
+
+// sameOutcome fails unless REST and gRPC both succeeded, or both failed
+// with the same kind of error
+func sameOutcome(t *testing.T, body []byte, restStatus, restOK int, grpcErr error) {
+	t.Helper()
+	if grpcErr == nil {
+		if restStatus != restOK {
+			t.Fatalf("gRPC accepted what REST answered %d to: %q", restStatus, body)
+		}
+		return
+	}
+	if _, ok := status.FromError(grpcErr); !ok {
+		t.Fatalf("gRPC returned a non-status error %v for %q", grpcErr, body)
+	}
+	if want := apperrors.HTTPStatus(apperrors.FromGRPC(grpcErr)); restStatus != want {
+		t.Fatalf("REST answered %d but gRPC %v (%d) for %q", restStatus, grpcErr, want, body)
+	}
+}
+
+// serveREST sends body to a user API backed by a fresh in-memory store
+// holding one user, and returns the status and response body
+func serveREST(t *testing.T, method, path string, body []byte) (int, []byte) {
+	t.Helper()
+	server := NewHTTPServer(newService(), discard, middleware.BodyLimit(maxBodyBytes))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	server.Router().ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+func newGRPC() *GRPCServer {
+	return NewGRPCServer(newService())
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newService() *service.UserService {
+	now := time.Now()
+	repo := &memoryRepo{users: map[string]*domain.User{
+		seedUserID: {ID: seedUserID, Email: seedUserEmail, FirstName: "Seed", LastName: "User", Roles: []string{"customer"}, CreatedAt: now, UpdatedAt: now},
+		"other":    {ID: "other", Email: "taken2@example.com", Handle: "taken", FirstName: "Other", LastName: "User", CreatedAt: now, UpdatedAt: now},
+	}}
+	return service.NewUserService(repo)
+}
+
+// memoryRepo is a minimal in-memory domain.UserRepository
+type memoryRepo struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+}
+
+func (r *memoryRepo) Create(_ context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetByID(_ context.Context, id string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.NotFound, "user with ID %s not found", id)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryRepo) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.Newf(apperrors.NotFound, "user with email %s not found", email)
+}
+
+func (r *memoryRepo) GetByHandle(_ context.Context, handle string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Handle != "" && user.Handle == handle {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.Newf(apperrors.NotFound, "user with handle %s not found", handle)
+}
+
+func (r *memoryRepo) HandlesTaken(_ context.Context, handles []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	taken := []string{}
+	for _, user := range r.users {
+		if user.Handle != "" && slices.Contains(handles, user.Handle) {
+			taken = append(taken, user.Handle)
+		}
+	}
+	return taken, nil
+}
+
+func (r *memoryRepo) ListEncryptedPhones(context.Context, string, int) ([]domain.EncryptedValue, error) {
+	return nil, nil
+}
+
+func (r *memoryRepo) ReplaceEncryptedPhone(context.Context, string, string, string) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryRepo) Update(_ context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+func (r *memoryRepo) List(_ context.Context, _ pagination.Request, _ string) ([]*domain.User, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*domain.User
+	for _, user := range r.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	return users, len(users), nil
+}
+
+func (r *memoryRepo) ListRoles(_ context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[string]bool{}
+	var roles []string
+	for _, user := range r.users {
+		for _, role := range user.Roles {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func (r *memoryRepo) ListIDs(_ context.Context, _ domain.UserFilter, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id := range r.users {
+		if len(ids) < limit {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
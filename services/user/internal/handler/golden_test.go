@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/pkg/testutil/golden"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestGoldenResponses compares every REST endpoint's response, and the
+// proto messages the gRPC API converts users to, with their golden files
+// in testdata/golden. Run with -update to rewrite them.
+func TestGoldenResponses(t *testing.T) {
+	const missing = "00000000-0000-0000-0000-000000000000"
+
+	t.Run("rest", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			body   string
+		}{
+			{"create_user", http.MethodPost, "/v1/users", `{"email":"ann@example.com","first_name":"Ann","last_name":"Lee","password":"correct-horse","roles":["customer"]}`},
+			{"create_user_invalid_body", http.MethodPost, "/v1/users", `{"email":`},
+			{"get_user", http.MethodGet, "/v1/users/" + goldenUserID, ""},
+			{"get_user_not_found", http.MethodGet, "/v1/users/" + missing, ""},
+			{"update_user", http.MethodPut, "/v1/users/" + goldenUserID, `{"first_name":"Annie","roles":["customer","admin"],"phone":"+15551234567","customer_group":"vip"}`},
+			{"update_user_handle", http.MethodPut, "/v1/users/" + goldenUserID, `{"handle":"annie"}`},
+			{"delete_user", http.MethodDelete, "/v1/users/" + goldenUserID, ""},
+			{"get_user_by_handle", http.MethodGet, "/v1/users/by-handle/ann_lee", ""},
+			{"get_user_by_handle_not_found", http.MethodGet, "/v1/users/by-handle/bo", ""},
+			{"suggest_handles", http.MethodGet, "/v1/users/" + goldenUserID + "/handle-suggestions", ""},
+			{"suggest_handles_base", http.MethodGet, "/v1/users/" + goldenUserID + "/handle-suggestions?base=annie", ""},
+			{"list_users", http.MethodGet, "/v1/users?page=1&page_size=2", ""},
+			{"get_notification_preferences", http.MethodGet, "/v1/users/" + goldenUserID + "/notification-preferences", ""},
+			{"update_notification_preferences", http.MethodPut, "/v1/users/" + goldenUserID + "/notification-preferences", `{"preferences":[{"channel":"sms","category":"marketing","enabled":true}]}`},
+			{"update_notification_preferences_missing_enabled", http.MethodPut, "/v1/users/" + goldenUserID + "/notification-preferences", `{"preferences":[{"channel":"sms","category":"marketing"}]}`},
+			{"consent_history", http.MethodGet, "/v1/users/" + goldenUserID + "/consent-history", ""},
+			{"record_login", http.MethodPost, "/v1/users/" + goldenUserID + "/logins", `{"user_agent":"Mozilla/5.0 (X11; Linux x86_64) Firefox/125.0","ip":"203.0.113.7","country":"de","region":"be"}`},
+			{"verify_login", http.MethodPost, "/v1/users/" + goldenUserID + "/logins/7/verify", ""},
+			{"verify_login_invalid_id", http.MethodPost, "/v1/users/" + goldenUserID + "/logins/latest/verify", ""},
+			{"list_logins", http.MethodGet, "/v1/users/" + goldenUserID + "/logins", ""},
+			{"start_bulk_job", http.MethodPost, "/v1/users/bulk", `{"action":"assign_role","role":"support","user_ids":["` + goldenUserID + `","` + missing + `"]}`},
+			{"start_bulk_job_filter", http.MethodPost, "/v1/users/bulk", `{"action":"tag","tag":"beta","filter":{"customer_group":"vip","role":"customer"}}`},
+			{"get_bulk_job", http.MethodGet, "/v1/users/bulk/" + goldenJobID, ""},
+			{"get_bulk_job_not_found", http.MethodGet, "/v1/users/bulk/" + missing, ""},
+			{"audit_export", http.MethodGet, "/v1/audit/export?from=2024-03-02T00:00:00Z&to=2024-03-03T00:00:00Z", ""},
+			{"scim_create_user", http.MethodPost, "/scim/v2/Users", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"ann@example.com","name":{"givenName":"Ann","familyName":"Lee"},"emails":[{"value":"ann@example.com","primary":true}],"active":true}`},
+			{"scim_list_users", http.MethodGet, "/scim/v2/Users?startIndex=1&count=2", ""},
+			{"scim_list_users_filter", http.MethodGet, "/scim/v2/Users?filter=" + url.QueryEscape(`userName eq "ann@example.com"`), ""},
+			{"scim_list_users_invalid_filter", http.MethodGet, "/scim/v2/Users?filter=" + url.QueryEscape(`title pr`), ""},
+			{"scim_get_user_not_found", http.MethodGet, "/scim/v2/Users/" + missing, ""},
+			{"scim_patch_user", http.MethodPatch, "/scim/v2/Users/" + goldenUserID, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"Replace","path":"active","value":"False"},{"op":"replace","value":{"name.givenName":"Annie","externalId":"00u1"}}]}`},
+			{"scim_patch_user_remove_name", http.MethodPatch, "/scim/v2/Users/" + goldenUserID, `{"Operations":[{"op":"remove","path":"name.familyName"}]}`},
+			{"scim_list_groups", http.MethodGet, "/scim/v2/Groups", ""},
+			{"scim_list_groups_filter", http.MethodGet, "/scim/v2/Groups?excludedAttributes=members&filter=" + url.QueryEscape(`displayName eq "support"`), ""},
+			{"scim_create_group_exists", http.MethodPost, "/scim/v2/Groups", `{"displayName":"customer","members":[{"value":"` + goldenUserID + `"}]}`},
+			{"scim_get_group", http.MethodGet, "/scim/v2/Groups/customer", ""},
+			{"scim_get_group_not_found", http.MethodGet, "/scim/v2/Groups/auditor", ""},
+			{"scim_patch_group", http.MethodPatch, "/scim/v2/Groups/support", `{"Operations":[{"op":"add","path":"members","value":[{"value":"` + goldenUserID + `"}]},{"op":"remove","path":"members[value eq \"` + goldenUserID + `\"]"}]}`},
+			{"scim_patch_group_rename", http.MethodPatch, "/scim/v2/Groups/support", `{"Operations":[{"op":"replace","value":{"displayName":"helpdesk"}}]}`},
+			{"audit_export_invalid_range", http.MethodGet, "/v1/audit/export?from=2024-03-03T00:00:00Z&to=2024-03-02T00:00:00Z", ""},
+		}
+
+		server := NewHTTPServer(stubUsers{}, discard)
+		server.EnableSCIM()
+		router := server.Router()
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(logging.RequestIDHeader, "golden-request")
+				req.Header.Set(logging.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				golden.AssertResponse(t, "rest/"+tt.name, rec, "Content-Type")
+			})
+		}
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		ctx := context.Background()
+		server := NewGRPCServer(stubUsers{})
+		tests := []struct {
+			name string
+			call func() (proto.Message, error)
+		}{
+			{"get_user", func() (proto.Message, error) {
+				return server.GetUser(ctx, &pb.GetUserRequest{Id: goldenUserID})
+			}},
+			{"get_user_by_email", func() (proto.Message, error) {
+				return server.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: "ann@example.com"})
+			}},
+			{"get_user_by_handle", func() (proto.Message, error) {
+				return server.GetUserByHandle(ctx, &pb.GetUserByHandleRequest{Handle: "ann_lee"})
+			}},
+			{"list_users", func() (proto.Message, error) {
+				return server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, PageSize: 2})
+			}},
+			{"get_notification_preferences", func() (proto.Message, error) {
+				return server.GetNotificationPreferences(ctx, &pb.GetNotificationPreferencesRequest{UserId: goldenUserID})
+			}},
+			{"check_consent", func() (proto.Message, error) {
+				return server.CheckConsent(ctx, &pb.CheckConsentRequest{UserId: goldenUserID, Channel: "email", Category: "order_updates"})
+			}},
+			{"record_login", func() (proto.Message, error) {
+				return server.RecordLogin(ctx, &pb.RecordLoginRequest{UserId: goldenUserID, UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/125.0", Ip: "203.0.113.7"})
+			}},
+			{"delete_user", func() (proto.Message, error) {
+				return server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: goldenUserID})
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				msg, err := tt.call()
+				require.NoError(t, err)
+				golden.AssertProto(t, "grpc/"+tt.name, msg)
+			})
+		}
+	})
+}
+
+const goldenUserID = "4b0a6c38-9a55-4c84-9d4b-5a3f3bb8f5d1"
+
+func goldenUser() *domain.User {
+	return &domain.User{
+		ID:           goldenUserID,
+		Email:        "ann@example.com",
+		Handle:       "ann_lee",
+		FirstName:    "Ann",
+		LastName:     "Lee",
+		PasswordHash: "$2a$10$not-a-real-hash",
+		Roles:        []string{"customer"},
+		Group:        domain.CustomerGroupRetail,
+		Tags:         []string{"beta"},
+		CreatedAt:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:    time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC),
+	}
+}
+
+// stubUsers serves one fixed user and echoes what the handlers decoded
+// from the request, so that the golden files also cover request field
+// names
+type stubUsers struct{}
+
+func (stubUsers) CreateUser(_ context.Context, email, firstName, lastName, _ string, roles []string) (*domain.User, error) {
+	user := goldenUser()
+	user.Email, user.FirstName, user.LastName, user.Roles = email, firstName, lastName, roles
+	user.UpdatedAt = user.CreatedAt
+	return user, nil
+}
+
+func (s stubUsers) GetUser(_ context.Context, id string) (*domain.User, error) {
+	if id != goldenUserID {
+		return nil, apperrors.Newf(apperrors.NotFound, "user with ID %s not found", id)
+	}
+	return goldenUser(), nil
+}
+
+func (stubUsers) GetUserByEmail(_ context.Context, _ string) (*domain.User, error) {
+	return goldenUser(), nil
+}
+
+func (stubUsers) GetUserByHandle(_ context.Context, handle string) (*domain.User, error) {
+	if handle != goldenUser().Handle {
+		return nil, apperrors.Newf(apperrors.NotFound, "user with handle %s not found", handle)
+	}
+	return goldenUser(), nil
+}
+
+// SuggestHandles echoes base, or suggests from the golden user's name
+func (s stubUsers) SuggestHandles(ctx context.Context, userID, base string) ([]string, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if base != "" {
+		return []string{base, base + "1"}, nil
+	}
+	return []string{"ann_lee1", "annlee", "alee"}, nil
+}
+
+func (s stubUsers) UpdateUser(ctx context.Context, id string, updates map[string]interface{}) (*domain.User, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if firstName, ok := updates["first_name"].(string); ok {
+		user.FirstName = firstName
+	}
+	if roles, ok := updates["roles"].([]string); ok {
+		user.Roles = roles
+	}
+	if phone, ok := updates["phone"].(string); ok {
+		user.Phone = phone
+	}
+	if group, ok := updates["customer_group"].(string); ok {
+		user.Group = group
+	}
+	if suspended, ok := updates["suspended"].(bool); ok {
+		user.Suspended = suspended
+	}
+	if handle, ok := updates["handle"].(string); ok {
+		user.Handle = handle
+	}
+	return user, nil
+}
+
+func (s stubUsers) DeleteUser(ctx context.Context, id string) error {
+	_, err := s.GetUser(ctx, id)
+	return err
+}
+
+func (stubUsers) ListUsers(_ context.Context, _ pagination.Request, _ string) ([]*domain.User, int, error) {
+	second := goldenUser()
+	second.ID = "9c1d3f1e-2b7a-4d0e-8f55-0d6b2e7c4a10"
+	second.Email = "bo@example.com"
+	second.FirstName = "Bo"
+	second.Roles = nil
+	second.Group = domain.CustomerGroupWholesale
+	return []*domain.User{goldenUser(), second}, 3, nil
+}
+
+// goldenChangedAt is when the golden user last changed a preference
+var goldenChangedAt = time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)
+
+// NotificationPreferences returns the defaults but for SMS marketing,
+// which the golden user agreed to
+func (s stubUsers) NotificationPreferences(ctx context.Context, userID string) ([]domain.Preference, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	var prefs []domain.Preference
+	for _, channel := range domain.Channels {
+		for _, category := range domain.Categories {
+			pref := domain.Preference{Channel: channel, Category: category, Enabled: domain.DefaultConsent(channel, category), Default: true}
+			if channel == domain.ChannelSMS && category == domain.CategoryMarketing {
+				pref.Enabled, pref.Default, pref.UpdatedAt = true, false, &goldenChangedAt
+			}
+			prefs = append(prefs, pref)
+		}
+	}
+	return prefs, nil
+}
+
+func (s stubUsers) UpdateNotificationPreferences(ctx context.Context, userID string, _ []domain.Preference) ([]domain.Preference, error) {
+	return s.NotificationPreferences(ctx, userID)
+}
+
+func (s stubUsers) CheckConsent(ctx context.Context, userID, channel, category string) (bool, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return false, err
+	}
+	return domain.DefaultConsent(channel, category), nil
+}
+
+func (s stubUsers) ConsentHistory(_ context.Context, userID string, _ pagination.Request) ([]domain.ConsentChange, int, error) {
+	return []domain.ConsentChange{{
+		ID:        1,
+		UserID:    userID,
+		Channel:   domain.ChannelSMS,
+		Category:  domain.CategoryMarketing,
+		Enabled:   true,
+		ChangedBy: "storefront",
+		ChangedAt: goldenChangedAt,
+	}}, 1, nil
+}
+
+// goldenLogin is a suspicious login of the golden user from the attempt,
+// which awaits step-up verification
+func goldenLogin(attempt domain.LoginAttempt) *domain.Login {
+	return &domain.Login{
+		ID:             7,
+		UserID:         goldenUserID,
+		DeviceID:       domain.DeviceFingerprint(attempt.UserAgent),
+		UserAgent:      attempt.UserAgent,
+		IP:             attempt.IP,
+		Country:        attempt.Country,
+		Region:         attempt.Region,
+		Location:       domain.LoginLocation(attempt.Country, attempt.Region, attempt.IP),
+		Reasons:        []string{domain.LoginReasonNewDevice, domain.LoginReasonNewLocation},
+		StepUpRequired: true,
+		CreatedAt:      goldenChangedAt,
+	}
+}
+
+func (s stubUsers) RecordLogin(ctx context.Context, userID string, attempt domain.LoginAttempt) (*domain.Login, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return goldenLogin(attempt), nil
+}
+
+func (s stubUsers) VerifyLogin(_ context.Context, userID string, loginID int64) (*domain.Login, error) {
+	login := goldenLogin(domain.LoginAttempt{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/125.0", IP: "203.0.113.7"})
+	login.ID = loginID
+	verified := goldenChangedAt.Add(time.Minute)
+	login.VerifiedAt = &verified
+	return login, nil
+}
+
+func (s stubUsers) ListLogins(_ context.Context, _ string, _ pagination.Request) ([]domain.Login, int, error) {
+	login := goldenLogin(domain.LoginAttempt{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/125.0", IP: "203.0.113.7"})
+	return []domain.Login{*login}, 1, nil
+}
+
+const goldenJobID = "9d3e2b1c-5f4a-4e8b-a6c7-0d1e2f3a4b5c"
+
+func (s stubUsers) StartBulkJob(_ context.Context, job *domain.BulkJob) (*domain.BulkJob, error) {
+	job.ID = goldenJobID
+	job.Status = domain.BulkStatusQueued
+	job.Total = len(job.UserIDs)
+	if job.Filter != nil {
+		job.Total = 3
+	}
+	job.Errors = []domain.BulkError{}
+	job.CreatedBy = "admin-console"
+	job.CreatedAt = goldenChangedAt
+	return job, nil
+}
+
+func (s stubUsers) GetBulkJob(_ context.Context, id string) (*domain.BulkJob, error) {
+	if id != goldenJobID {
+		return nil, apperrors.Newf(apperrors.NotFound, "bulk job %s not found", id)
+	}
+	started, finished := goldenChangedAt.Add(time.Second), goldenChangedAt.Add(time.Minute)
+	return &domain.BulkJob{
+		ID:         goldenJobID,
+		Action:     domain.BulkSuspend,
+		Filter:     &domain.UserFilter{Tag: "fraud-review"},
+		Status:     domain.BulkStatusCompleted,
+		Total:      2,
+		Succeeded:  1,
+		Failed:     1,
+		Errors:     []domain.BulkError{{UserID: "00000000-0000-0000-0000-000000000000", Error: "user not found"}},
+		CreatedBy:  "admin-console",
+		CreatedAt:  goldenChangedAt,
+		StartedAt:  &started,
+		FinishedAt: &finished,
+	}, nil
+}
+
+// ExportAudit exports the entries of the golden bulk job, chained to an
+// earlier entry
+func (s stubUsers) ExportAudit(_ context.Context, _, _ time.Time, fn func(*domain.AuditEntry) error) error {
+	prev := "5e0c5d2b6f1d7a3c9b8e4f2a1d0c6b5a4e3f2d1c0b9a8f7e6d5c4b3a2f1e0d9c"
+	for i, result := range []string{domain.AuditResultApplied, domain.AuditResultFailed} {
+		entry := &domain.AuditEntry{
+			ID:       int64(41 + i),
+			UserID:   goldenUserID,
+			Action:   domain.BulkSuspend,
+			Actor:    "admin-console",
+			JobID:    goldenJobID,
+			Result:   result,
+			At:       goldenChangedAt.Add(time.Duration(i) * time.Second),
+			PrevHash: prev,
+		}
+		if result == domain.AuditResultFailed {
+			entry.UserID, entry.Error = "00000000-0000-0000-0000-000000000000", "user not found"
+		}
+		hash, err := audit.Hash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash, prev = hash, hash
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s stubUsers) ProvisionUser(ctx context.Context, user *domain.User, _ string) (*domain.User, error) {
+	created, err := s.CreateUser(ctx, user.Email, user.FirstName, user.LastName, "", user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	created.Suspended, created.PasswordResetRequired = user.Suspended, true
+	return created, nil
+}
+
+// goldenRoles are the roles of the golden users, with their members
+var goldenRoles = map[string][]string{
+	"customer": {goldenUserID},
+	"support":  {"9c1d3f1e-2b7a-4d0e-8f55-0d6b2e7c4a10"},
+}
+
+func (stubUsers) ListRoles(_ context.Context) ([]string, error) {
+	return []string{"customer", "support"}, nil
+}
+
+func (stubUsers) RoleMembers(_ context.Context, role string) ([]string, error) {
+	return goldenRoles[role], nil
+}
+
+func (s stubUsers) SetUserRole(ctx context.Context, userID, _ string, _ bool) error {
+	_, err := s.GetUser(ctx, userID)
+	return err
+}
@@ -2,12 +2,13 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"time"
 
-	pb "github.com/bekbull/online-shop/services/user/api/proto"
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	pb "github.com/bekbull/online-shop/proto/user/v1"
 	"github.com/bekbull/online-shop/services/user/internal/domain"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // GRPCServer is the gRPC server for the User service
@@ -25,7 +26,7 @@ func NewGRPCServer(userService domain.UserService) *GRPCServer {
 
 // CreateUser creates a new user
 func (s *GRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
-	user, err := s.userService.CreateUser(
+	user, err := s.userService.CreateUser(ctx,
 		req.Email,
 		req.FirstName,
 		req.LastName,
@@ -33,7 +34,7 @@ func (s *GRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		req.Roles,
 	)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "failed to create user: %v", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to create user: %w", err))
 	}
 
 	return convertDomainUserToProto(user), nil
@@ -41,9 +42,9 @@ func (s *GRPCServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 
 // GetUser retrieves a user by ID
 func (s *GRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserResponse, error) {
-	user, err := s.userService.GetUser(req.Id)
+	user, err := s.userService.GetUser(ctx, req.Id)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "failed to get user: %v", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get user: %w", err))
 	}
 
 	return convertDomainUserToProto(user), nil
@@ -68,10 +69,16 @@ func (s *GRPCServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 	if len(req.Roles) > 0 {
 		updates["roles"] = req.Roles
 	}
+	if req.CustomerGroup != nil {
+		updates["customer_group"] = *req.CustomerGroup
+	}
+	if req.Handle != nil {
+		updates["handle"] = *req.Handle
+	}
 
-	user, err := s.userService.UpdateUser(req.Id, updates)
+	user, err := s.userService.UpdateUser(ctx, req.Id, updates)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to update user: %w", err))
 	}
 
 	return convertDomainUserToProto(user), nil
@@ -79,9 +86,9 @@ func (s *GRPCServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 
 // DeleteUser deletes a user by ID
 func (s *GRPCServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
-	err := s.userService.DeleteUser(req.Id)
+	err := s.userService.DeleteUser(ctx, req.Id)
 	if err != nil {
-		return &pb.DeleteUserResponse{Success: false}, status.Errorf(codes.NotFound, "failed to delete user: %v", err)
+		return &pb.DeleteUserResponse{Success: false}, apperrors.ToGRPC(fmt.Errorf("failed to delete user: %w", err))
 	}
 
 	return &pb.DeleteUserResponse{Success: true}, nil
@@ -89,9 +96,10 @@ func (s *GRPCServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest)
 
 // ListUsers retrieves a list of users with pagination and optional filtering
 func (s *GRPCServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	users, total, err := s.userService.ListUsers(int(req.Page), int(req.PageSize), req.EmailFilter)
+	page := pagination.New(int(req.Page), int(req.PageSize), domain.UserPagination)
+	users, total, err := s.userService.ListUsers(ctx, page, req.EmailFilter)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list users: %v", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to list users: %w", err))
 	}
 
 	var protoUsers []*pb.UserResponse
@@ -102,30 +110,141 @@ func (s *GRPCServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*
 	return &pb.ListUsersResponse{
 		Users:      protoUsers,
 		TotalCount: int32(total),
-		Page:       req.Page,
-		PageSize:   req.PageSize,
+		Page:       int32(page.Page),
+		PageSize:   int32(page.PageSize),
 	}, nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (s *GRPCServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.UserResponse, error) {
-	user, err := s.userService.GetUserByEmail(req.Email)
+	user, err := s.userService.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "failed to get user by email: %v", err)
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get user by email: %w", err))
 	}
 
 	return convertDomainUserToProto(user), nil
 }
 
+// GetUserByHandle retrieves a user by handle
+func (s *GRPCServer) GetUserByHandle(ctx context.Context, req *pb.GetUserByHandleRequest) (*pb.UserResponse, error) {
+	user, err := s.userService.GetUserByHandle(ctx, req.Handle)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get user by handle: %w", err))
+	}
+
+	return convertDomainUserToProto(user), nil
+}
+
+// GetNotificationPreferences returns a user's notification preferences
+func (s *GRPCServer) GetNotificationPreferences(ctx context.Context, req *pb.GetNotificationPreferencesRequest) (*pb.NotificationPreferencesResponse, error) {
+	prefs, err := s.userService.NotificationPreferences(ctx, req.UserId)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to get notification preferences: %w", err))
+	}
+
+	return convertPreferencesToProto(prefs), nil
+}
+
+// UpdateNotificationPreferences sets some of a user's notification preferences
+func (s *GRPCServer) UpdateNotificationPreferences(ctx context.Context, req *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferencesResponse, error) {
+	updates := make([]domain.Preference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		updates = append(updates, domain.Preference{Channel: pref.Channel, Category: pref.Category, Enabled: pref.Enabled})
+	}
+
+	prefs, err := s.userService.UpdateNotificationPreferences(ctx, req.UserId, updates)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to update notification preferences: %w", err))
+	}
+
+	return convertPreferencesToProto(prefs), nil
+}
+
+// CheckConsent reports whether a user gets notifications of a category on a channel
+func (s *GRPCServer) CheckConsent(ctx context.Context, req *pb.CheckConsentRequest) (*pb.CheckConsentResponse, error) {
+	allowed, err := s.userService.CheckConsent(ctx, req.UserId, req.Channel, req.Category)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to check consent: %w", err))
+	}
+
+	return &pb.CheckConsentResponse{Allowed: allowed}, nil
+}
+
+// RecordLogin records a successful sign-in of a user
+func (s *GRPCServer) RecordLogin(ctx context.Context, req *pb.RecordLoginRequest) (*pb.LoginResponse, error) {
+	login, err := s.userService.RecordLogin(ctx, req.UserId, domain.LoginAttempt{
+		UserAgent: req.UserAgent,
+		IP:        req.Ip,
+		Country:   req.Country,
+		Region:    req.Region,
+	})
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to record login: %w", err))
+	}
+
+	return convertLoginToProto(login), nil
+}
+
+// VerifyLogin marks a login awaiting step-up verification as verified
+func (s *GRPCServer) VerifyLogin(ctx context.Context, req *pb.VerifyLoginRequest) (*pb.LoginResponse, error) {
+	login, err := s.userService.VerifyLogin(ctx, req.UserId, req.LoginId)
+	if err != nil {
+		return nil, apperrors.ToGRPC(fmt.Errorf("failed to verify login: %w", err))
+	}
+
+	return convertLoginToProto(login), nil
+}
+
+// convertLoginToProto converts a domain Login to a proto LoginResponse
+func convertLoginToProto(login *domain.Login) *pb.LoginResponse {
+	resp := &pb.LoginResponse{
+		Id:             login.ID,
+		UserId:         login.UserID,
+		DeviceId:       login.DeviceID,
+		Location:       login.Location,
+		Suspicious:     login.Suspicious(),
+		Reasons:        login.Reasons,
+		StepUpRequired: login.StepUpRequired,
+		CreatedAt:      login.CreatedAt.Format(time.RFC3339),
+	}
+	if login.VerifiedAt != nil {
+		resp.VerifiedAt = login.VerifiedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// convertPreferencesToProto converts notification preferences to a proto response
+func convertPreferencesToProto(prefs []domain.Preference) *pb.NotificationPreferencesResponse {
+	resp := &pb.NotificationPreferencesResponse{}
+	for _, pref := range prefs {
+		protoPref := &pb.NotificationPreference{
+			Channel:  pref.Channel,
+			Category: pref.Category,
+			Enabled:  pref.Enabled,
+			Default:  pref.Default,
+		}
+		if pref.UpdatedAt != nil {
+			protoPref.UpdatedAt = pref.UpdatedAt.Format(time.RFC3339)
+		}
+		resp.Preferences = append(resp.Preferences, protoPref)
+	}
+	return resp
+}
+
 // convertDomainUserToProto converts a domain User to a proto UserResponse
 func convertDomainUserToProto(user *domain.User) *pb.UserResponse {
 	return &pb.UserResponse{
-		Id:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Roles:     user.Roles,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+		Id:                    user.ID,
+		Email:                 user.Email,
+		Handle:                user.Handle,
+		FirstName:             user.FirstName,
+		LastName:              user.LastName,
+		Roles:                 user.Roles,
+		CustomerGroup:         user.Group,
+		Tags:                  user.Tags,
+		Suspended:             user.Suspended,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:             user.UpdatedAt.Format(time.RFC3339),
 	}
 }
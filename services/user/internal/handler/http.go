@@ -2,48 +2,68 @@ package handler
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
-	"strings"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/user/internal/domain"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 // HTTPServer handles HTTP requests for the User service
 type HTTPServer struct {
 	router      *chi.Mux
 	userService domain.UserService
+	logger      *slog.Logger
 }
 
-// NewHTTPServer creates a new HTTP server for the User service
-func NewHTTPServer(userService domain.UserService) *HTTPServer {
+// NewHTTPServer creates a new HTTP server for the User service. The
+// apiMiddleware, such as auth and rate limiting, wraps the /v1 routes only,
+// so health checks stay reachable.
+func NewHTTPServer(userService domain.UserService, logger *slog.Logger, apiMiddleware ...func(http.Handler) http.Handler) *HTTPServer {
 	server := &HTTPServer{
 		router:      chi.NewRouter(),
 		userService: userService,
+		logger:      logger,
 	}
 
-	server.setupRoutes()
+	server.setupRoutes(apiMiddleware)
 	return server
 }
 
 // setupRoutes configures the HTTP routes
-func (s *HTTPServer) setupRoutes() {
+func (s *HTTPServer) setupRoutes(apiMiddleware []func(http.Handler) http.Handler) {
 	// Middleware
-	s.router.Use(middleware.Logger)
-	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.RequestID)
+	s.router.Use(logging.HTTPMiddleware(s.logger))
+	s.router.Use(middleware.Recover(s.logger))
 
 	// API Routes with versioning
 	s.router.Route("/v1", func(r chi.Router) {
+		r.Use(apiMiddleware...)
 		r.Route("/users", func(r chi.Router) {
 			r.Get("/", s.ListUsers)
 			r.Post("/", s.CreateUser)
+			r.Post("/bulk", s.StartBulkJob)
+			r.Get("/bulk/{jobID}", s.GetBulkJob)
+			r.Get("/by-handle/{handle}", s.GetUserByHandle)
 			r.Get("/{id}", s.GetUser)
 			r.Put("/{id}", s.UpdateUser)
 			r.Delete("/{id}", s.DeleteUser)
+			r.Get("/{id}/handle-suggestions", s.SuggestHandles)
+			r.Get("/{id}/notification-preferences", s.GetNotificationPreferences)
+			r.Put("/{id}/notification-preferences", s.UpdateNotificationPreferences)
+			r.Get("/{id}/consent-history", s.ConsentHistory)
+			r.Get("/{id}/logins", s.ListLogins)
+			r.Post("/{id}/logins", s.RecordLogin)
+			r.Post("/{id}/logins/{loginID}/verify", s.VerifyLogin)
 		})
+		r.Get("/audit/export", s.ExportAudit)
 	})
 
 	// Health check endpoint
@@ -69,17 +89,13 @@ func (s *HTTPServer) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		s.writeError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
 		return
 	}
 
-	user, err := s.userService.CreateUser(req.Email, req.FirstName, req.LastName, req.Password, req.Roles)
+	user, err := s.userService.CreateUser(r.Context(), req.Email, req.FirstName, req.LastName, req.Password, req.Roles)
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -89,15 +105,38 @@ func (s *HTTPServer) CreateUser(w http.ResponseWriter, r *http.Request) {
 // GetUser handles user retrieval requests
 func (s *HTTPServer) GetUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	user, err := s.userService.GetUser(id)
+	user, err := s.userService.GetUser(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapUserToResponse(user))
+}
+
+// GetUserByHandle handles requests for the user with a handle
+func (s *HTTPServer) GetUserByHandle(w http.ResponseWriter, r *http.Request) {
+	user, err := s.userService.GetUserByHandle(r.Context(), chi.URLParam(r, "handle"))
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		s.writeError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, mapUserToResponse(user))
 }
 
+// SuggestHandles handles requests for free handles a user could choose,
+// derived from the base query parameter or the user's name and email
+func (s *HTTPServer) SuggestHandles(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := s.userService.SuggestHandles(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("base"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"suggestions": suggestions})
+}
+
 // UpdateUser handles user update requests
 func (s *HTTPServer) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -108,10 +147,13 @@ func (s *HTTPServer) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		LastName  *string  `json:"last_name,omitempty"`
 		Password  *string  `json:"password,omitempty"`
 		Roles     []string `json:"roles,omitempty"`
+		Phone     *string  `json:"phone,omitempty"`
+		Group     *string  `json:"customer_group,omitempty"`
+		Handle    *string  `json:"handle,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		s.writeError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
 		return
 	}
 
@@ -131,14 +173,19 @@ func (s *HTTPServer) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if req.Roles != nil {
 		updates["roles"] = req.Roles
 	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+	if req.Group != nil {
+		updates["customer_group"] = *req.Group
+	}
+	if req.Handle != nil {
+		updates["handle"] = *req.Handle
+	}
 
-	user, err := s.userService.UpdateUser(id, updates)
+	user, err := s.userService.UpdateUser(r.Context(), id, updates)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -148,12 +195,8 @@ func (s *HTTPServer) UpdateUser(w http.ResponseWriter, r *http.Request) {
 // DeleteUser handles user deletion requests
 func (s *HTTPServer) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if err := s.userService.DeleteUser(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.userService.DeleteUser(r.Context(), id); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -163,42 +206,232 @@ func (s *HTTPServer) DeleteUser(w http.ResponseWriter, r *http.Request) {
 // ListUsers handles requests to list users
 func (s *HTTPServer) ListUsers(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination parameters
-	page := 1
-	pageSize := 10
+	page, err := pagination.Parse(r.URL.Query(), domain.UserPagination)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 	emailFilter := r.URL.Query().Get("email")
 
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	users, total, err := s.userService.ListUsers(r.Context(), page, emailFilter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	responseUsers := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		responseUsers = append(responseUsers, mapUserToResponse(user))
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewList("users", responseUsers, total, page))
+}
+
+// GetNotificationPreferences handles requests for a user's notification
+// preferences
+func (s *HTTPServer) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := s.userService.NotificationPreferences(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
 	}
 
-	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"preferences": prefs})
+}
+
+// UpdateNotificationPreferences handles requests setting some of a user's
+// notification preferences
+func (s *HTTPServer) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Preferences []struct {
+			Channel  string `json:"channel"`
+			Category string `json:"category"`
+			Enabled  *bool  `json:"enabled"`
+		} `json:"preferences"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	updates := make([]domain.Preference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		if pref.Enabled == nil {
+			s.writeError(w, r, apperrors.Newf(apperrors.Invalid, "enabled is required for %s %s", pref.Channel, pref.Category))
+			return
 		}
+		updates = append(updates, domain.Preference{Channel: pref.Channel, Category: pref.Category, Enabled: *pref.Enabled})
 	}
 
-	users, total, err := s.userService.ListUsers(page, pageSize, emailFilter)
+	prefs, err := s.userService.UpdateNotificationPreferences(r.Context(), chi.URLParam(r, "id"), updates)
 	if err != nil {
-		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
-	responseUsers := make([]map[string]interface{}, 0, len(users))
-	for _, user := range users {
-		responseUsers = append(responseUsers, mapUserToResponse(user))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"preferences": prefs})
+}
+
+// ConsentHistory handles requests for the changes to a user's notification
+// preferences
+func (s *HTTPServer) ConsentHistory(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), domain.ConsentPagination)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	changes, total, err := s.userService.ConsentHistory(r.Context(), chi.URLParam(r, "id"), page)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewList("changes", changes, total, page))
+}
+
+// RecordLogin handles reports of successful sign-ins
+func (s *HTTPServer) RecordLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserAgent string `json:"user_agent"`
+		IP        string `json:"ip"`
+		Country   string `json:"country"`
+		Region    string `json:"region"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	login, err := s.userService.RecordLogin(r.Context(), chi.URLParam(r, "id"), domain.LoginAttempt{
+		UserAgent: req.UserAgent,
+		IP:        req.IP,
+		Country:   req.Country,
+		Region:    req.Region,
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mapLoginToResponse(login))
+}
+
+// VerifyLogin handles requests marking a login awaiting step-up
+// verification as verified
+func (s *HTTPServer) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	loginID, err := strconv.ParseInt(chi.URLParam(r, "loginID"), 10, 64)
+	if err != nil {
+		s.writeError(w, r, apperrors.New(apperrors.Invalid, "login ID must be a number"))
+		return
+	}
+
+	login, err := s.userService.VerifyLogin(r.Context(), chi.URLParam(r, "id"), loginID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapLoginToResponse(login))
+}
+
+// ListLogins handles requests for a user's logins
+func (s *HTTPServer) ListLogins(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), domain.LoginPagination)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	logins, total, err := s.userService.ListLogins(r.Context(), chi.URLParam(r, "id"), page)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	responseLogins := make([]map[string]interface{}, 0, len(logins))
+	for i := range logins {
+		responseLogins = append(responseLogins, mapLoginToResponse(&logins[i]))
+	}
+
+	respondWithJSON(w, http.StatusOK, pagination.NewList("logins", responseLogins, total, page))
+}
+
+// StartBulkJob handles requests applying an admin action to many users.
+// The job runs in the background; the response links to its status.
+func (s *HTTPServer) StartBulkJob(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Action  string             `json:"action"`
+		Role    string             `json:"role"`
+		Tag     string             `json:"tag"`
+		UserIDs []string           `json:"user_ids"`
+		Filter  *domain.UserFilter `json:"filter"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	job, err := s.userService.StartBulkJob(r.Context(), &domain.BulkJob{
+		Action:  req.Action,
+		Role:    req.Role,
+		Tag:     req.Tag,
+		UserIDs: req.UserIDs,
+		Filter:  req.Filter,
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/v1/users/bulk/"+job.ID)
+	respondWithJSON(w, http.StatusAccepted, mapBulkJobToResponse(job))
+}
+
+// GetBulkJob handles requests for the status of a bulk job
+func (s *HTTPServer) GetBulkJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.userService.GetBulkJob(r.Context(), chi.URLParam(r, "jobID"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapBulkJobToResponse(job))
+}
+
+// ExportAudit streams the audit entries recorded from the from query
+// parameter up to to as NDJSON
+func (s *HTTPServer) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	from, to, err := audit.ParseRange(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, apperrors.New(apperrors.Invalid, err.Error()))
+		return
 	}
 
-	response := map[string]interface{}{
-		"users":       responseUsers,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + pageSize - 1) / pageSize,
+	stream := audit.NewStreamWriter(w, "user-audit.ndjson")
+	err = s.userService.ExportAudit(r.Context(), from, to, func(entry *domain.AuditEntry) error {
+		return stream.Write(entry)
+	})
+	if err != nil {
+		if !stream.Started() {
+			s.writeError(w, r, err)
+			return
+		}
+		// The status is sent; the client sees a truncated export
+		logging.FromContext(r.Context(), s.logger).Error("Audit export failed", "error", err)
+		return
 	}
+	stream.Finish()
+}
 
-	respondWithJSON(w, http.StatusOK, response)
+// writeError logs err with the request-scoped logger and writes it as a
+// problem response
+func (s *HTTPServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context(), s.logger).Error("Request failed", "path", r.URL.Path, "error", err)
+	apperrors.WriteHTTP(w, r, err)
 }
 
 // respondWithJSON writes a JSON response
@@ -211,12 +444,58 @@ func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
 // mapUserToResponse maps a domain User to a response object
 func mapUserToResponse(user *domain.User) map[string]interface{} {
 	return map[string]interface{}{
-		"id":         user.ID,
-		"email":      user.Email,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-		"roles":      user.Roles,
-		"created_at": user.CreatedAt,
-		"updated_at": user.UpdatedAt,
+		"id":                      user.ID,
+		"email":                   user.Email,
+		"handle":                  user.Handle,
+		"first_name":              user.FirstName,
+		"last_name":               user.LastName,
+		"roles":                   user.Roles,
+		"customer_group":          user.Group,
+		"tags":                    user.Tags,
+		"suspended":               user.Suspended,
+		"password_reset_required": user.PasswordResetRequired,
+		"phone":                   user.Phone,
+		"created_at":              user.CreatedAt,
+		"updated_at":              user.UpdatedAt,
+	}
+}
+
+// mapLoginToResponse maps a domain Login to a response object
+func mapLoginToResponse(login *domain.Login) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               login.ID,
+		"user_id":          login.UserID,
+		"device_id":        login.DeviceID,
+		"user_agent":       login.UserAgent,
+		"ip":               login.IP,
+		"country":          login.Country,
+		"region":           login.Region,
+		"location":         login.Location,
+		"suspicious":       login.Suspicious(),
+		"reasons":          login.Reasons,
+		"step_up_required": login.StepUpRequired,
+		"verified_at":      login.VerifiedAt,
+		"created_at":       login.CreatedAt,
+	}
+}
+
+// mapBulkJobToResponse maps a domain BulkJob to a response object
+func mapBulkJobToResponse(job *domain.BulkJob) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          job.ID,
+		"action":      job.Action,
+		"role":        job.Role,
+		"tag":         job.Tag,
+		"filter":      job.Filter,
+		"status":      job.Status,
+		"total":       job.Total,
+		"processed":   job.Processed(),
+		"succeeded":   job.Succeeded,
+		"failed":      job.Failed,
+		"errors":      job.Errors,
+		"created_by":  job.CreatedBy,
+		"created_at":  job.CreatedAt,
+		"started_at":  job.StartedAt,
+		"finished_at": job.FinishedAt,
 	}
 }
@@ -0,0 +1,696 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// SCIM 2.0 (RFC 7643 and 7644) provisioning, for identity providers that
+// manage staff accounts. SCIM users are users: userName is the email and
+// active is the opposite of suspended. SCIM groups are roles, identified by
+// their name; a group exists while a user holds the role.
+
+// SCIMContentType is the media type of SCIM responses
+const SCIMContentType = "application/scim+json"
+
+// SCIM schema URNs
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIM error types, set as the reason of apperrors errors
+const (
+	scimInvalidFilter = "invalidFilter"
+	scimInvalidPath   = "invalidPath"
+	scimInvalidValue  = "invalidValue"
+	scimMutability    = "mutability"
+	scimUniqueness    = "uniqueness"
+)
+
+// EnableSCIM serves SCIM provisioning under /scim/v2, behind
+// scimMiddleware, which must authenticate the identity provider
+func (s *HTTPServer) EnableSCIM(scimMiddleware ...func(http.Handler) http.Handler) {
+	s.router.Route("/scim/v2", func(r chi.Router) {
+		r.Use(scimMiddleware...)
+		r.Get("/Users", s.SCIMListUsers)
+		r.Post("/Users", s.SCIMCreateUser)
+		r.Get("/Users/{id}", s.SCIMGetUser)
+		r.Patch("/Users/{id}", s.SCIMPatchUser)
+		r.Get("/Groups", s.SCIMListGroups)
+		r.Post("/Groups", s.SCIMCreateGroup)
+		r.Get("/Groups/{id}", s.SCIMGetGroup)
+		r.Patch("/Groups/{id}", s.SCIMPatchGroup)
+		r.Delete("/Groups/{id}", s.SCIMDeleteGroup)
+	})
+}
+
+// scimName is the name attribute of a SCIM user
+type scimName struct {
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+}
+
+// scimEmail is an entry of the emails attribute of a SCIM user
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// scimMember is a member of a SCIM group, or a group of a SCIM user
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// scimPatchRequest is the body of a PATCH request
+type scimPatchRequest struct {
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// SCIMListUsers handles GET /scim/v2/Users. The filter may match userName,
+// emails or id exactly (eq), or userName by substring (co).
+func (s *HTTPServer) SCIMListUsers(w http.ResponseWriter, r *http.Request) {
+	startIndex, count, err := scimRange(r.URL.Query())
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+
+	var users []*domain.User
+	total := 0
+	attr, op, value, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	switch {
+	case err != nil:
+		s.writeSCIMError(w, r, err)
+		return
+	case attr == "":
+		users, total, err = s.userService.ListUsers(r.Context(), scimPage(startIndex, count), "")
+	case op == "co" && attr == "username":
+		users, total, err = s.userService.ListUsers(r.Context(), scimPage(startIndex, count), value)
+	case op == "eq" && (attr == "username" || attr == "emails" || attr == "emails.value"):
+		users, total, err = s.scimLookup(s.userService.GetUserByEmail(r.Context(), value))
+	case op == "eq" && attr == "id":
+		users, total, err = s.scimLookup(s.userService.GetUser(r.Context(), value))
+	default:
+		err = apperrors.Newf(apperrors.Invalid, "unsupported filter on %s with %s", attr, op).WithReason(scimInvalidFilter)
+	}
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	if startIndex > 1 && op == "eq" {
+		users = nil
+	}
+	users = users[:min(count, len(users))]
+
+	resources := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, mapUserToSCIM(user))
+	}
+	respondWithSCIM(w, http.StatusOK, scimList(resources, total, startIndex))
+}
+
+// scimLookup turns the result of looking up one user into a list, empty
+// when the user does not exist
+func (s *HTTPServer) scimLookup(user *domain.User, err error) ([]*domain.User, int, error) {
+	if apperrors.Is(err, apperrors.NotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return []*domain.User{user}, 1, nil
+}
+
+// SCIMCreateUser handles POST /scim/v2/Users. Users are created active and
+// without roles unless the request says otherwise; roles are granted
+// through groups.
+func (s *HTTPServer) SCIMCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserName string      `json:"userName"`
+		Name     scimName    `json:"name"`
+		Emails   []scimEmail `json:"emails"`
+		Active   *bool       `json:"active"`
+		Password string      `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeSCIMError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	user := &domain.User{
+		Email:     req.UserName,
+		FirstName: req.Name.GivenName,
+		LastName:  req.Name.FamilyName,
+		Suspended: req.Active != nil && !*req.Active,
+	}
+	if user.Email == "" {
+		user.Email = primaryEmail(req.Emails)
+	}
+	created, err := s.userService.ProvisionUser(r.Context(), user, req.Password)
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", scimUserLocation(created.ID))
+	respondWithSCIM(w, http.StatusCreated, mapUserToSCIM(created))
+}
+
+// SCIMGetUser handles GET /scim/v2/Users/{id}
+func (s *HTTPServer) SCIMGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.userService.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	respondWithSCIM(w, http.StatusOK, mapUserToSCIM(user))
+}
+
+// SCIMPatchUser handles PATCH /scim/v2/Users/{id}. It sets userName,
+// emails, name, password and active; setting active to false deactivates
+// the user. Attributes the service does not keep are ignored.
+func (s *HTTPServer) SCIMPatchUser(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeSCIMError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	updates := make(map[string]interface{})
+	for _, op := range req.Operations {
+		var err error
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			err = patchUserAttribute(updates, op.Path, op.Value)
+		case "remove":
+			if scimUserAttributes[scimAttribute(op.Path)] {
+				err = apperrors.Newf(apperrors.Invalid, "%s cannot be removed", op.Path).WithReason(scimMutability)
+			}
+		default:
+			err = apperrors.Newf(apperrors.Invalid, "unknown operation %q", op.Op)
+		}
+		if err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+	}
+
+	user, err := s.userService.UpdateUser(r.Context(), chi.URLParam(r, "id"), updates)
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	respondWithSCIM(w, http.StatusOK, mapUserToSCIM(user))
+}
+
+// scimUserAttributes are the user attributes a patch can set
+var scimUserAttributes = map[string]bool{
+	"username":        true,
+	"emails":          true,
+	"emails.value":    true,
+	"name":            true,
+	"name.givenname":  true,
+	"name.familyname": true,
+	"password":        true,
+	"active":          true,
+}
+
+// patchUserAttribute adds the update setting the attribute at path to the
+// JSON value. Without a path, value is an object of attributes.
+func patchUserAttribute(updates map[string]interface{}, path string, value json.RawMessage) error {
+	invalid := func() error {
+		return apperrors.Newf(apperrors.Invalid, "invalid value for %s", path).WithReason(scimInvalidValue)
+	}
+
+	attr := scimAttribute(path)
+	if strings.HasPrefix(attr, "emails[") && strings.HasSuffix(attr, "].value") {
+		attr = "emails.value"
+	}
+	switch attr {
+	case "":
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(value, &attrs); err != nil {
+			return invalid()
+		}
+		for name, v := range attrs {
+			if err := patchUserAttribute(updates, name, v); err != nil {
+				return err
+			}
+		}
+	case "username", "emails.value", "password", "name.givenname", "name.familyname":
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return invalid()
+		}
+		key := map[string]string{
+			"username":        "email",
+			"emails.value":    "email",
+			"password":        "password",
+			"name.givenname":  "first_name",
+			"name.familyname": "last_name",
+		}[attr]
+		// userName wins over emails when a patch sets both
+		if _, set := updates[key]; !set || attr == "username" {
+			updates[key] = s
+		}
+	case "emails":
+		var emails []scimEmail
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return invalid()
+		}
+		if email := primaryEmail(emails); email != "" {
+			raw, _ := json.Marshal(email)
+			return patchUserAttribute(updates, "emails.value", raw)
+		}
+	case "name":
+		var name scimName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalid()
+		}
+		updates["first_name"], updates["last_name"] = name.GivenName, name.FamilyName
+	case "active":
+		// Some identity providers send booleans as strings
+		var active interface{}
+		if err := json.Unmarshal(value, &active); err != nil {
+			return invalid()
+		}
+		switch v := active.(type) {
+		case bool:
+			updates["suspended"] = !v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return invalid()
+			}
+			updates["suspended"] = !b
+		default:
+			return invalid()
+		}
+	}
+	return nil
+}
+
+// SCIMListGroups handles GET /scim/v2/Groups. The filter may match
+// displayName or id exactly. With excludedAttributes=members, members are
+// left out.
+func (s *HTTPServer) SCIMListGroups(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startIndex, count, err := scimRange(query)
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	attr, op, value, err := parseSCIMFilter(query.Get("filter"))
+	if err == nil && attr != "" && (op != "eq" || (attr != "displayname" && attr != "id")) {
+		err = apperrors.Newf(apperrors.Invalid, "unsupported filter on %s with %s", attr, op).WithReason(scimInvalidFilter)
+	}
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+
+	roles, err := s.userService.ListRoles(r.Context())
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	if attr != "" {
+		roles = slices.DeleteFunc(roles, func(role string) bool { return role != value })
+	}
+	total := len(roles)
+	roles = roles[min(startIndex-1, len(roles)):]
+	roles = roles[:min(count, len(roles))]
+
+	withMembers := !strings.Contains(strings.ToLower(query.Get("excludedAttributes")), "members")
+	resources := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		var members []string
+		if withMembers {
+			if members, err = s.userService.RoleMembers(r.Context(), role); err != nil {
+				s.writeSCIMError(w, r, err)
+				return
+			}
+		}
+		resources = append(resources, mapRoleToSCIM(role, members, withMembers))
+	}
+	respondWithSCIM(w, http.StatusOK, scimList(resources, total, startIndex))
+}
+
+// SCIMCreateGroup handles POST /scim/v2/Groups, granting the role named by
+// displayName to the members. A group without members is not kept.
+func (s *HTTPServer) SCIMCreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName string       `json:"displayName"`
+		Members     []scimMember `json:"members"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeSCIMError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	role := req.DisplayName
+	existing, err := s.userService.RoleMembers(r.Context(), role)
+	if err == nil && len(existing) > 0 {
+		err = apperrors.Newf(apperrors.Conflict, "group %s already exists", role)
+	}
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	members := make([]string, 0, len(req.Members))
+	for _, member := range req.Members {
+		if err := s.userService.SetUserRole(r.Context(), member.Value, role, true); err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+		members = append(members, member.Value)
+	}
+
+	w.Header().Set("Location", scimGroupLocation(role))
+	respondWithSCIM(w, http.StatusCreated, mapRoleToSCIM(role, members, true))
+}
+
+// SCIMGetGroup handles GET /scim/v2/Groups/{id}
+func (s *HTTPServer) SCIMGetGroup(w http.ResponseWriter, r *http.Request) {
+	role := chi.URLParam(r, "id")
+	members, err := s.groupMembers(r, role)
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	respondWithSCIM(w, http.StatusOK, mapRoleToSCIM(role, members, true))
+}
+
+// SCIMPatchGroup handles PATCH /scim/v2/Groups/{id}, which adds, removes
+// or replaces members. Groups cannot be renamed.
+func (s *HTTPServer) SCIMPatchGroup(w http.ResponseWriter, r *http.Request) {
+	role := chi.URLParam(r, "id")
+	var req scimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeSCIMError(w, r, apperrors.Wrap(err, apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	for _, op := range req.Operations {
+		if err := s.patchGroup(r, role, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+			s.writeSCIMError(w, r, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// memberFilter matches the path of a single member, members[value eq "id"]
+var memberFilter = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// patchGroup applies one operation of a group patch
+func (s *HTTPServer) patchGroup(r *http.Request, role, op, path string, value json.RawMessage) error {
+	ctx := r.Context()
+	if m := memberFilter.FindStringSubmatch(path); m != nil {
+		if op != "remove" {
+			return apperrors.Newf(apperrors.Invalid, "%s only supports remove", path).WithReason(scimInvalidPath)
+		}
+		return s.userService.SetUserRole(ctx, m[1], role, false)
+	}
+
+	switch scimAttribute(path) {
+	case "":
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(value, &attrs); err != nil {
+			return apperrors.New(apperrors.Invalid, "invalid patch value").WithReason(scimInvalidValue)
+		}
+		for name, v := range attrs {
+			if err := s.patchGroup(r, role, op, name, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "displayname":
+		var name string
+		if json.Unmarshal(value, &name) == nil && name == role {
+			return nil
+		}
+		return apperrors.New(apperrors.Invalid, "groups are roles and cannot be renamed").WithReason(scimMutability)
+	case "members":
+	default:
+		return apperrors.Newf(apperrors.Invalid, "unsupported path %q", path).WithReason(scimInvalidPath)
+	}
+
+	var members []scimMember
+	if len(value) > 0 && string(value) != "null" {
+		if err := json.Unmarshal(value, &members); err != nil {
+			return apperrors.New(apperrors.Invalid, "members must be a list").WithReason(scimInvalidValue)
+		}
+	}
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Value)
+	}
+
+	switch op {
+	case "add":
+		return s.setRoleMembers(r, role, ids, true)
+	case "remove":
+		if len(ids) == 0 {
+			// Removing the members attribute removes every member
+			current, err := s.userService.RoleMembers(ctx, role)
+			if err != nil {
+				return err
+			}
+			ids = current
+		}
+		return s.setRoleMembers(r, role, ids, false)
+	case "replace":
+		current, err := s.userService.RoleMembers(ctx, role)
+		if err != nil {
+			return err
+		}
+		removed := slices.DeleteFunc(current, func(id string) bool { return slices.Contains(ids, id) })
+		if err := s.setRoleMembers(r, role, removed, false); err != nil {
+			return err
+		}
+		return s.setRoleMembers(r, role, ids, true)
+	default:
+		return apperrors.Newf(apperrors.Invalid, "unknown operation %q", op)
+	}
+}
+
+// setRoleMembers grants role to the users, or revokes it
+func (s *HTTPServer) setRoleMembers(r *http.Request, role string, ids []string, member bool) error {
+	for _, id := range ids {
+		if err := s.userService.SetUserRole(r.Context(), id, role, member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SCIMDeleteGroup handles DELETE /scim/v2/Groups/{id}, revoking the role
+// from every member
+func (s *HTTPServer) SCIMDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	role := chi.URLParam(r, "id")
+	members, err := s.groupMembers(r, role)
+	if err == nil {
+		err = s.setRoleMembers(r, role, members, false)
+	}
+	if err != nil {
+		s.writeSCIMError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// groupMembers returns the members of the group of a role, which does not
+// exist without them
+func (s *HTTPServer) groupMembers(r *http.Request, role string) ([]string, error) {
+	members, err := s.userService.RoleMembers(r.Context(), role)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, apperrors.Newf(apperrors.NotFound, "group %s not found", role)
+	}
+	return members, nil
+}
+
+// scimFilter matches the filters the endpoints support: one attribute
+// compared with a string
+var scimFilter = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+([A-Za-z]{2})\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// parseSCIMFilter parses a filter of the form attr op "value". The
+// attribute and operator are returned lowercased; an empty filter returns
+// an empty attribute.
+func parseSCIMFilter(filter string) (attr, op, value string, err error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", "", nil
+	}
+	m := scimFilter.FindStringSubmatch(filter)
+	if m == nil || json.Unmarshal([]byte(m[3]), &value) != nil {
+		return "", "", "", apperrors.Newf(apperrors.Invalid, "unsupported filter %q", filter).WithReason(scimInvalidFilter)
+	}
+	return scimAttribute(m[1]), strings.ToLower(m[2]), value, nil
+}
+
+// scimAttribute lowercases an attribute path and strips the user schema
+// URN it may be qualified with
+func scimAttribute(path string) string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), scimUserSchema+":")
+	return strings.ToLower(path)
+}
+
+// scimRange reads the 1-based startIndex and the count of a list request
+func scimRange(query url.Values) (startIndex, count int, err error) {
+	startIndex, count = 1, domain.UserPagination.DefaultPageSize
+	if v := query.Get("startIndex"); v != "" {
+		if startIndex, err = strconv.Atoi(v); err != nil {
+			return 0, 0, apperrors.New(apperrors.Invalid, "startIndex must be an integer")
+		}
+		startIndex = max(startIndex, 1)
+	}
+	if v := query.Get("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil {
+			return 0, 0, apperrors.New(apperrors.Invalid, "count must be an integer")
+		}
+		count = min(max(count, 0), pagination.MaxPageSize)
+	}
+	return startIndex, count, nil
+}
+
+// scimPage converts a SCIM range to a page request
+func scimPage(startIndex, count int) pagination.Request {
+	page, _ := pagination.Parse(url.Values{
+		"offset": {strconv.Itoa(startIndex - 1)},
+		"limit":  {strconv.Itoa(max(count, 1))},
+	}, domain.UserPagination)
+	return page
+}
+
+// primaryEmail returns the primary email of a list, or the first one
+func primaryEmail(emails []scimEmail) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+func scimUserLocation(id string) string {
+	return "/scim/v2/Users/" + id
+}
+
+func scimGroupLocation(role string) string {
+	return "/scim/v2/Groups/" + url.PathEscape(role)
+}
+
+// mapUserToSCIM maps a domain User to a SCIM user resource
+func mapUserToSCIM(user *domain.User) map[string]interface{} {
+	groups := make([]scimMember, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		groups = append(groups, scimMember{Value: role, Display: role, Ref: scimGroupLocation(role)})
+	}
+	return map[string]interface{}{
+		"schemas":  []string{scimUserSchema},
+		"id":       user.ID,
+		"userName": user.Email,
+		"name": map[string]string{
+			"givenName":  user.FirstName,
+			"familyName": user.LastName,
+			"formatted":  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		},
+		"displayName": strings.TrimSpace(user.FirstName + " " + user.LastName),
+		"emails":      []scimEmail{{Value: user.Email, Type: "work", Primary: true}},
+		"active":      !user.Suspended,
+		"groups":      groups,
+		"meta": map[string]interface{}{
+			"resourceType": "User",
+			"created":      user.CreatedAt,
+			"lastModified": user.UpdatedAt,
+			"location":     scimUserLocation(user.ID),
+		},
+	}
+}
+
+// mapRoleToSCIM maps a role and the IDs of its members to a SCIM group
+// resource
+func mapRoleToSCIM(role string, members []string, withMembers bool) map[string]interface{} {
+	group := map[string]interface{}{
+		"schemas":     []string{scimGroupSchema},
+		"id":          role,
+		"displayName": role,
+		"meta": map[string]interface{}{
+			"resourceType": "Group",
+			"location":     scimGroupLocation(role),
+		},
+	}
+	if withMembers {
+		refs := make([]scimMember, 0, len(members))
+		for _, id := range members {
+			refs = append(refs, scimMember{Value: id, Ref: scimUserLocation(id)})
+		}
+		group["members"] = refs
+	}
+	return group
+}
+
+// scimList builds a list response
+func scimList(resources []interface{}, total, startIndex int) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}
+}
+
+// writeSCIMError logs err with the request-scoped logger and writes it as
+// a SCIM error response
+func (s *HTTPServer) writeSCIMError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context(), s.logger).Error("SCIM request failed", "path", r.URL.Path, "error", err)
+	problem := apperrors.ToProblem(r, err)
+	scimType := problem.Reason
+	if scimType == "" && apperrors.Is(err, apperrors.Conflict) {
+		scimType = scimUniqueness
+	}
+	body := map[string]interface{}{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(problem.Status),
+		"detail":  problem.Detail,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	respondWithSCIM(w, problem.Status, body)
+}
+
+// respondWithSCIM writes a SCIM response
+func respondWithSCIM(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", SCIMContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
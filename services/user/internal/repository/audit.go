@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// auditChainLock is the advisory lock key that serializes appends to the
+// audit chain across instances
+const auditChainLock = 0x75736572617564 // "useraud"
+
+// AuditRepository implements domain.AuditRepository with PostgreSQL.
+// Entries are kept after their user is deleted, until retention archives
+// them.
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+var _ domain.AuditRepository = (*AuditRepository)(nil)
+
+// NewAuditRepository creates a PostgreSQL audit repository
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// auditColumns are the columns scanned by scanAuditEntry
+const auditColumns = `id, user_id, action, detail, actor, COALESCE(job_id, ''), result, error, created_at, prev_hash, hash`
+
+// Record appends an entry to the chain. Appends take an advisory lock, so
+// that IDs, times and hashes follow one order.
+func (r *AuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLock); err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+	var prev string
+	err = tx.GetContext(ctx, &prev, `SELECT hash FROM user_audit_log ORDER BY id DESC LIMIT 1`)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	var id int64
+	if err := tx.GetContext(ctx, &id, `SELECT nextval(pg_get_serial_sequence('user_audit_log', 'id'))`); err != nil {
+		return fmt.Errorf("failed to allocate audit entry ID: %w", err)
+	}
+
+	// Hashed as read back: TIMESTAMPTZ keeps microseconds
+	entry.ID, entry.At, entry.PrevHash, entry.Hash = id, time.Now().UTC().Truncate(time.Microsecond), prev, ""
+	if entry.Hash, err = audit.Hash(entry); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_audit_log (id, user_id, action, detail, actor, job_id, result, error, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11)
+	`, entry.ID, entry.UserID, entry.Action, entry.Detail, entry.Actor, entry.JobID, entry.Result, entry.Error,
+		entry.At, entry.PrevHash, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit entry: %w", err)
+	}
+	return nil
+}
+
+// Export calls fn with the entries from from up to to, in ID order
+func (r *AuditRepository) Export(ctx context.Context, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	return r.scan(ctx, fn, `
+		SELECT `+auditColumns+`
+		FROM user_audit_log
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY id
+	`, from, to)
+}
+
+// LastBefore returns the ID of the last entry recorded before t, or 0.
+// The newest entry is never returned: it stays as the head the chain
+// continues from.
+func (r *AuditRepository) LastBefore(ctx context.Context, t time.Time) (int64, error) {
+	var id int64
+	err := r.db.GetContext(ctx, &id, `
+		SELECT COALESCE(MAX(id), 0)
+		FROM user_audit_log
+		WHERE created_at < $1 AND id < (SELECT MAX(id) FROM user_audit_log)
+	`, t)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired audit entries: %w", err)
+	}
+	return id, nil
+}
+
+// ExportThrough calls fn with the entries up to and including ID last, in
+// ID order
+func (r *AuditRepository) ExportThrough(ctx context.Context, last int64, fn func(*domain.AuditEntry) error) error {
+	return r.scan(ctx, fn, `SELECT `+auditColumns+` FROM user_audit_log WHERE id <= $1 ORDER BY id`, last)
+}
+
+// DeleteThrough deletes the entries up to and including ID last
+func (r *AuditRepository) DeleteThrough(ctx context.Context, last int64) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_audit_log WHERE id <= $1`, last)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit entries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scan calls fn with each entry a query returns
+func (r *AuditRepository) scan(ctx context.Context, fn func(*domain.AuditEntry) error, query string, args ...interface{}) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry domain.AuditEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Action,
+			&entry.Detail,
+			&entry.Actor,
+			&entry.JobID,
+			&entry.Result,
+			&entry.Error,
+			&entry.At,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.At = entry.At.UTC()
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating audit rows: %w", err)
+	}
+	return nil
+}
+
+// InitDB creates the user_audit_log table
+func (r *AuditRepository) InitDB() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS user_audit_log (
+		id BIGSERIAL PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL,
+		action VARCHAR(32) NOT NULL,
+		detail VARCHAR(255) NOT NULL DEFAULT '',
+		actor VARCHAR(255) NOT NULL DEFAULT '',
+		job_id VARCHAR(36),
+		result VARCHAR(16) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+		hash VARCHAR(64) NOT NULL DEFAULT ''
+	);
+
+	ALTER TABLE user_audit_log ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64) NOT NULL DEFAULT '';
+	ALTER TABLE user_audit_log ADD COLUMN IF NOT EXISTS hash VARCHAR(64) NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_user_audit_log_user_id ON user_audit_log(user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_user_audit_log_job_id ON user_audit_log(job_id);
+	CREATE INDEX IF NOT EXISTS idx_user_audit_log_created_at ON user_audit_log(created_at);
+	`
+
+	if _, err := r.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to initialize audit schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// BulkJobRepository implements domain.BulkJobRepository with PostgreSQL
+type BulkJobRepository struct {
+	db *sqlx.DB
+}
+
+var _ domain.BulkJobRepository = (*BulkJobRepository)(nil)
+
+// NewBulkJobRepository creates a PostgreSQL bulk job repository
+func NewBulkJobRepository(db *sqlx.DB) *BulkJobRepository {
+	return &BulkJobRepository{db: db}
+}
+
+// Create stores a new job with its users
+func (r *BulkJobRepository) Create(ctx context.Context, job *domain.BulkJob) error {
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to encode bulk job filter: %w", err)
+	}
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode bulk job errors: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO bulk_jobs (id, action, role, tag, filter, user_ids, status, total, succeeded, failed, errors, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, job.ID, job.Action, job.Role, job.Tag, filter, pq.Array(job.UserIDs), job.Status,
+		job.Total, job.Succeeded, job.Failed, errs, job.CreatedBy, job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk job: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a job with its users
+func (r *BulkJobRepository) Get(ctx context.Context, id string) (*domain.BulkJob, error) {
+	var job domain.BulkJob
+	var filter, errs []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, action, role, tag, filter, user_ids, status, total, succeeded, failed, errors,
+			created_by, created_at, started_at, finished_at
+		FROM bulk_jobs
+		WHERE id = $1
+	`, id).Scan(
+		&job.ID,
+		&job.Action,
+		&job.Role,
+		&job.Tag,
+		&filter,
+		(*pq.StringArray)(&job.UserIDs),
+		&job.Status,
+		&job.Total,
+		&job.Succeeded,
+		&job.Failed,
+		&errs,
+		&job.CreatedBy,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.Newf(apperrors.NotFound, "bulk job %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk job: %w", err)
+	}
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk job filter: %w", err)
+	}
+	if err := json.Unmarshal(errs, &job.Errors); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk job errors: %w", err)
+	}
+	return &job, nil
+}
+
+// Update stores the status and progress of a job
+func (r *BulkJobRepository) Update(ctx context.Context, job *domain.BulkJob) error {
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode bulk job errors: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE bulk_jobs
+		SET status = $2, succeeded = $3, failed = $4, errors = $5, started_at = $6, finished_at = $7
+		WHERE id = $1
+	`, job.ID, job.Status, job.Succeeded, job.Failed, errs, job.StartedAt, job.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update bulk job: %w", err)
+	}
+	return nil
+}
+
+// InitDB creates the bulk_jobs table
+func (r *BulkJobRepository) InitDB() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS bulk_jobs (
+		id VARCHAR(36) PRIMARY KEY,
+		action VARCHAR(32) NOT NULL,
+		role VARCHAR(64) NOT NULL DEFAULT '',
+		tag VARCHAR(64) NOT NULL DEFAULT '',
+		filter JSONB,
+		user_ids TEXT[] NOT NULL DEFAULT '{}',
+		status VARCHAR(16) NOT NULL,
+		total INTEGER NOT NULL DEFAULT 0,
+		succeeded INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		errors JSONB NOT NULL DEFAULT '[]',
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ
+	);
+	`
+
+	if _, err := r.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to initialize bulk job schema: %w", err)
+	}
+	return nil
+}
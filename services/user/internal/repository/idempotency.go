@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyStore keeps the responses of requests sent with an
+// Idempotency-Key in PostgreSQL, shared by all instances of the service
+type IdempotencyStore struct {
+	db *sqlx.DB
+}
+
+var _ middleware.IdempotencyStore = (*IdempotencyStore)(nil)
+
+// NewIdempotencyStore creates a PostgreSQL idempotency store
+func NewIdempotencyStore(db *sqlx.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// Begin implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*middleware.StoredResponse, error) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`); err != nil {
+		return nil, fmt.Errorf("failed to expire idempotency keys: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, fingerprint, time.Now().Add(lockTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 1 {
+		return nil, nil
+	}
+
+	var stored struct {
+		Fingerprint string        `db:"fingerprint"`
+		Status      sql.NullInt32 `db:"status"`
+		Header      []byte        `db:"header"`
+		Body        []byte        `db:"body"`
+	}
+	err = s.db.GetContext(ctx, &stored, `SELECT fingerprint, status, header, body FROM idempotency_keys WHERE key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released in the meantime; proceed unclaimed
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return nil, middleware.ErrIdempotencyKeyReused
+	case !stored.Status.Valid:
+		return nil, middleware.ErrIdempotencyInProgress
+	}
+	resp := &middleware.StoredResponse{Status: int(stored.Status.Int32), Body: stored.Body}
+	if err := json.Unmarshal(stored.Header, &resp.Header); err != nil {
+		resp.Header = http.Header{}
+	}
+	return resp, nil
+}
+
+// Complete implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, resp *middleware.StoredResponse, ttl time.Duration) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = $2, header = $3, body = $4, expires_at = $5
+		WHERE key = $1
+	`, key, resp.Status, header, resp.Body, time.Now().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// InitDB creates the idempotency_keys table
+func (s *IdempotencyStore) InitDB() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key VARCHAR(64) PRIMARY KEY,
+		fingerprint VARCHAR(64) NOT NULL,
+		status INTEGER,
+		header JSONB,
+		body BYTEA,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+	`
+
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to initialize idempotency schema: %w", err)
+	}
+	return nil
+}
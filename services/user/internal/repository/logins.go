@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// LoginRepository implements domain.LoginRepository with PostgreSQL.
+// Logins are deleted with their user.
+type LoginRepository struct {
+	db *sqlx.DB
+}
+
+var _ domain.LoginRepository = (*LoginRepository)(nil)
+
+// NewLoginRepository creates a PostgreSQL login repository
+func NewLoginRepository(db *sqlx.DB) *LoginRepository {
+	return &LoginRepository{db: db}
+}
+
+// trusted selects the logins not awaiting step-up verification
+const trusted = `(NOT step_up_required OR verified_at IS NOT NULL)`
+
+// loginColumns are the columns scanned by scanLogin
+const loginColumns = `id, user_id, device_id, user_agent, ip, country, region, location, reasons, step_up_required, verified_at, created_at`
+
+// History compares the user's trusted logins with a device and location
+func (r *LoginRepository) History(ctx context.Context, userID, deviceID, location string) (domain.LoginHistory, error) {
+	var history domain.LoginHistory
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			COALESCE(BOOL_OR(device_id = $2), FALSE),
+			COALESCE(BOOL_OR(location = $3), FALSE)
+		FROM logins
+		WHERE user_id = $1 AND `+trusted, userID, deviceID, location,
+	).Scan(&history.Logins, &history.KnownDevice, &history.KnownLocation)
+	if err != nil {
+		return history, fmt.Errorf("failed to get login history: %w", err)
+	}
+	return history, nil
+}
+
+// Create stores a login and sets its ID
+func (r *LoginRepository) Create(ctx context.Context, login *domain.Login) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO logins (user_id, device_id, user_agent, ip, country, region, location, reasons, step_up_required, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, login.UserID, login.DeviceID, login.UserAgent, login.IP, login.Country, login.Region, login.Location,
+		pq.Array(login.Reasons), login.StepUpRequired, login.CreatedAt,
+	).Scan(&login.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a login of a user
+func (r *LoginRepository) Get(ctx context.Context, userID string, id int64) (*domain.Login, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+loginColumns+` FROM logins WHERE user_id = $1 AND id = $2`, userID, id)
+	login, err := scanLogin(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.Newf(apperrors.NotFound, "login %d of user %s not found", id, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login: %w", err)
+	}
+	return login, nil
+}
+
+// Verify sets the verification time of a login awaiting step-up
+func (r *LoginRepository) Verify(ctx context.Context, userID string, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE logins SET verified_at = $3
+		WHERE user_id = $1 AND id = $2 AND step_up_required AND verified_at IS NULL
+	`, userID, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to verify login: %w", err)
+	}
+	return nil
+}
+
+// List returns the logins of a user, newest first
+func (r *LoginRepository) List(ctx context.Context, userID string, page pagination.Request) ([]domain.Login, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM logins WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count logins: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+loginColumns+`
+		FROM logins
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, page.PageSize, page.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list logins: %w", err)
+	}
+	defer rows.Close()
+
+	logins := []domain.Login{}
+	for rows.Next() {
+		login, err := scanLogin(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan login: %w", err)
+		}
+		logins = append(logins, *login)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating login rows: %w", err)
+	}
+	return logins, total, nil
+}
+
+// scanLogin scans the loginColumns of a row
+func scanLogin(row interface{ Scan(...interface{}) error }) (*domain.Login, error) {
+	var login domain.Login
+	var ip sql.NullString
+	var reasons pq.StringArray
+	err := row.Scan(
+		&login.ID,
+		&login.UserID,
+		&login.DeviceID,
+		&login.UserAgent,
+		&ip,
+		&login.Country,
+		&login.Region,
+		&login.Location,
+		&reasons,
+		&login.StepUpRequired,
+		&login.VerifiedAt,
+		&login.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	login.IP = ip.String
+	login.Reasons = []string(reasons)
+	return &login, nil
+}
+
+// InitDB creates the logins table. It runs after the users table is
+// created.
+func (r *LoginRepository) InitDB() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS logins (
+		id BIGSERIAL PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		device_id VARCHAR(32) NOT NULL,
+		user_agent TEXT NOT NULL DEFAULT '',
+		ip VARCHAR(45),
+		country VARCHAR(8) NOT NULL DEFAULT '',
+		region VARCHAR(16) NOT NULL DEFAULT '',
+		location VARCHAR(64) NOT NULL DEFAULT '',
+		reasons TEXT[] NOT NULL DEFAULT '{}',
+		step_up_required BOOLEAN NOT NULL DEFAULT FALSE,
+		verified_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_logins_user_id ON logins(user_id, created_at);
+	`
+
+	if _, err := r.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to initialize login schema: %w", err)
+	}
+	return nil
+}
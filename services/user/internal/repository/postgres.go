@@ -1,37 +1,76 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/user/internal/domain"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
-// PostgresRepository implements the UserRepository interface using PostgreSQL
+// PostgresRepository implements the UserRepository interface using PostgreSQL.
+// Personal data other than the email (the phone number) is stored
+// encrypted with crypt and decrypted on read, so callers only see
+// plaintext.
 type PostgresRepository struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	crypt *fieldcrypt.Encryptor
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository
-func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+func NewPostgresRepository(db *sqlx.DB, crypt *fieldcrypt.Encryptor) *PostgresRepository {
 	return &PostgresRepository{
-		db: db,
+		db:    db,
+		crypt: crypt,
 	}
 }
 
+// phoneAAD binds an encrypted phone number to its user
+func phoneAAD(userID string) string {
+	return "users.phone:" + userID
+}
+
+// encryptPhone returns the phone column value of user
+func (r *PostgresRepository) encryptPhone(ctx context.Context, user *domain.User) (sql.NullString, error) {
+	sealed, err := r.crypt.Encrypt(ctx, user.Phone, phoneAAD(user.ID))
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encrypt phone: %w", err)
+	}
+	return sql.NullString{String: sealed, Valid: sealed != ""}, nil
+}
+
+// decryptPhone sets the phone number of user from its column value
+func (r *PostgresRepository) decryptPhone(ctx context.Context, user *domain.User, sealed sql.NullString) error {
+	phone, err := r.crypt.Decrypt(ctx, sealed.String, phoneAAD(user.ID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt phone: %w", err)
+	}
+	user.Phone = phone
+	return nil
+}
+
 // Create inserts a new user into the database
-func (r *PostgresRepository) Create(user *domain.User) error {
+func (r *PostgresRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (id, email, first_name, last_name, password_hash, roles, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (id, email, first_name, last_name, password_hash, roles, customer_group, tags, suspended, password_reset_required, phone_encrypted, created_at, updated_at, handle)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, '{}'::TEXT[]), $9, $10, $11, $12, $13, NULLIF($14, ''))
 	`
 
-	_, err := r.db.Exec(
+	phone, err := r.encryptPhone(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
 		query,
 		user.ID,
 		user.Email,
@@ -39,14 +78,22 @@ func (r *PostgresRepository) Create(user *domain.User) error {
 		user.LastName,
 		user.PasswordHash,
 		pq.Array(user.Roles),
+		user.Group,
+		pq.Array(user.Tags),
+		user.Suspended,
+		user.PasswordResetRequired,
+		phone,
 		user.CreatedAt,
 		user.UpdatedAt,
+		user.Handle,
 	)
 
 	if err != nil {
-		// Check for duplicate email
-		if strings.Contains(err.Error(), "duplicate key") && strings.Contains(err.Error(), "email") {
-			return fmt.Errorf("user with email %s already exists", user.Email)
+		if isDuplicateHandle(err) {
+			return apperrors.Newf(apperrors.Conflict, "handle %s is taken", user.Handle)
+		}
+		if isDuplicateEmail(err) {
+			return apperrors.Newf(apperrors.Conflict, "user with email %s already exists", user.Email)
 		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -55,30 +102,37 @@ func (r *PostgresRepository) Create(user *domain.User) error {
 }
 
 // GetByID retrieves a user by ID
-func (r *PostgresRepository) GetByID(id string) (*domain.User, error) {
+func (r *PostgresRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	query := `
-		SELECT id, email, first_name, last_name, password_hash, roles, created_at, updated_at
+		SELECT id, email, COALESCE(handle, ''), first_name, last_name, password_hash, roles, customer_group, tags, suspended, password_reset_required, phone_encrypted, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
 	var user domain.User
 	var roles []byte // Store the roles as a byte array initially
+	var phone sql.NullString
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
+		&user.Handle,
 		&user.FirstName,
 		&user.LastName,
 		&user.PasswordHash,
 		&roles, // Roles will be parsed separately
+		&user.Group,
+		(*pq.StringArray)(&user.Tags),
+		&user.Suspended,
+		&user.PasswordResetRequired,
+		&phone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user with ID %s not found", id)
+			return nil, apperrors.Newf(apperrors.NotFound, "user with ID %s not found", id)
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -93,34 +147,45 @@ func (r *PostgresRepository) GetByID(id string) (*domain.User, error) {
 	// Convert to string slice
 	user.Roles = []string(roleArray)
 
+	if err := r.decryptPhone(ctx, &user, phone); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by email
-func (r *PostgresRepository) GetByEmail(email string) (*domain.User, error) {
+// GetByEmail retrieves a user by email, ignoring case
+func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, email, first_name, last_name, password_hash, roles, created_at, updated_at
+		SELECT id, email, COALESCE(handle, ''), first_name, last_name, password_hash, roles, customer_group, tags, suspended, password_reset_required, phone_encrypted, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 
 	var user domain.User
 	var roles []byte // Store the roles as a byte array initially
+	var phone sql.NullString
 
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
+		&user.Handle,
 		&user.FirstName,
 		&user.LastName,
 		&user.PasswordHash,
 		&roles, // Roles will be parsed separately
+		&user.Group,
+		(*pq.StringArray)(&user.Tags),
+		&user.Suspended,
+		&user.PasswordResetRequired,
+		&phone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, apperrors.Newf(apperrors.NotFound, "user with email %s not found", email)
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -135,20 +200,100 @@ func (r *PostgresRepository) GetByEmail(email string) (*domain.User, error) {
 	// Convert to string slice
 	user.Roles = []string(roleArray)
 
+	if err := r.decryptPhone(ctx, &user, phone); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
+// GetByHandle retrieves a user by handle
+func (r *PostgresRepository) GetByHandle(ctx context.Context, handle string) (*domain.User, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, `SELECT id FROM users WHERE handle = $1`, handle)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Newf(apperrors.NotFound, "user with handle %s not found", handle)
+		}
+		return nil, fmt.Errorf("failed to get user by handle: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// HandlesTaken returns those of handles that users have
+func (r *PostgresRepository) HandlesTaken(ctx context.Context, handles []string) ([]string, error) {
+	taken := []string{}
+	if err := r.db.SelectContext(ctx, &taken, `SELECT handle FROM users WHERE handle = ANY($1)`, pq.Array(handles)); err != nil {
+		return nil, fmt.Errorf("failed to look up handles: %w", err)
+	}
+	return taken, nil
+}
+
+// ListEncryptedPhones returns up to limit stored phone ciphertexts of
+// users whose ID sorts after after, in ID order
+func (r *PostgresRepository) ListEncryptedPhones(ctx context.Context, after string, limit int) ([]domain.EncryptedValue, error) {
+	values := []domain.EncryptedValue{}
+	err := r.db.SelectContext(ctx, &values, `
+		SELECT id, phone_encrypted AS ciphertext
+		FROM users
+		WHERE id > $1 AND phone_encrypted <> ''
+		ORDER BY id
+		LIMIT $2
+	`, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list encrypted phones: %w", err)
+	}
+	return values, nil
+}
+
+// ReplaceEncryptedPhone stores ciphertext as a user's phone if the stored
+// one is still old, so that a concurrent update is not overwritten. The
+// phone number itself is unchanged, so updated_at is left alone.
+func (r *PostgresRepository) ReplaceEncryptedPhone(ctx context.Context, userID, old, ciphertext string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET phone_encrypted = $3 WHERE id = $1 AND phone_encrypted = $2`,
+		userID, old, ciphertext)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace encrypted phone: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to replace encrypted phone: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// isDuplicateEmail reports whether err is a violation of the unique
+// constraint on emails, which Postgres names after the column
+func isDuplicateEmail(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_key"
+}
+
+// isDuplicateHandle reports whether err is a violation of the unique index
+// on handles
+func isDuplicateHandle(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_users_handle"
+}
+
 // Update updates a user in the database
-func (r *PostgresRepository) Update(user *domain.User) error {
+func (r *PostgresRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET email = $2, first_name = $3, last_name = $4, password_hash = $5, roles = $6, updated_at = $7
+		SET email = $2, first_name = $3, last_name = $4, password_hash = $5, roles = $6, customer_group = $7, tags = COALESCE($8, '{}'::TEXT[]), suspended = $9, password_reset_required = $10, phone_encrypted = $11, updated_at = $12, handle = NULLIF($13, '')
 		WHERE id = $1
 	`
 
 	user.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(
+	phone, err := r.encryptPhone(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
 		query,
 		user.ID,
 		user.Email,
@@ -156,13 +301,21 @@ func (r *PostgresRepository) Update(user *domain.User) error {
 		user.LastName,
 		user.PasswordHash,
 		pq.Array(user.Roles),
+		user.Group,
+		pq.Array(user.Tags),
+		user.Suspended,
+		user.PasswordResetRequired,
+		phone,
 		user.UpdatedAt,
+		user.Handle,
 	)
 
 	if err != nil {
-		// Check for duplicate email
-		if strings.Contains(err.Error(), "duplicate key") && strings.Contains(err.Error(), "email") {
-			return fmt.Errorf("user with email %s already exists", user.Email)
+		if isDuplicateHandle(err) {
+			return apperrors.Newf(apperrors.Conflict, "handle %s is taken", user.Handle)
+		}
+		if isDuplicateEmail(err) {
+			return apperrors.Newf(apperrors.Conflict, "user with email %s already exists", user.Email)
 		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -171,10 +324,10 @@ func (r *PostgresRepository) Update(user *domain.User) error {
 }
 
 // Delete removes a user from the database
-func (r *PostgresRepository) Delete(id string) error {
+func (r *PostgresRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -185,27 +338,18 @@ func (r *PostgresRepository) Delete(id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %s not found", id)
+		return apperrors.Newf(apperrors.NotFound, "user with ID %s not found", id)
 	}
 
 	return nil
 }
 
 // List retrieves a list of users with pagination and optional filtering
-func (r *PostgresRepository) List(page, pageSize int, emailFilter string) ([]*domain.User, int, error) {
-	// Ensure valid pagination parameters
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 {
-		pageSize = 10
-	}
-
-	offset := (page - 1) * pageSize
+func (r *PostgresRepository) List(ctx context.Context, page pagination.Request, emailFilter string) ([]*domain.User, int, error) {
 
 	// Base query
 	query := `
-		SELECT id, email, first_name, last_name, password_hash, roles, created_at, updated_at
+		SELECT id, email, COALESCE(handle, ''), first_name, last_name, password_hash, roles, customer_group, tags, suspended, password_reset_required, phone_encrypted, created_at, updated_at
 		FROM users
 	`
 	countQuery := `SELECT COUNT(*) FROM users`
@@ -220,17 +364,17 @@ func (r *PostgresRepository) List(page, pageSize int, emailFilter string) ([]*do
 
 	// Add pagination
 	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
-	args = append(args, pageSize, offset)
+	args = append(args, page.PageSize, page.Offset())
 
 	// Get total count
 	var totalCount int
-	err := r.db.Get(&totalCount, countQuery, args[:max(0, len(args)-2)]...)
+	err := r.db.GetContext(ctx, &totalCount, countQuery, args[:max(0, len(args)-2)]...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	// Execute query
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -241,14 +385,21 @@ func (r *PostgresRepository) List(page, pageSize int, emailFilter string) ([]*do
 	for rows.Next() {
 		var user domain.User
 		var roles []byte // Store the roles as a byte array initially
+		var phone sql.NullString
 
 		err := rows.Scan(
 			&user.ID,
 			&user.Email,
+			&user.Handle,
 			&user.FirstName,
 			&user.LastName,
 			&user.PasswordHash,
 			&roles, // Roles will be parsed separately
+			&user.Group,
+			(*pq.StringArray)(&user.Tags),
+			&user.Suspended,
+			&user.PasswordResetRequired,
+			&phone,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -266,6 +417,10 @@ func (r *PostgresRepository) List(page, pageSize int, emailFilter string) ([]*do
 		// Convert to string slice
 		user.Roles = []string(roleArray)
 
+		if err := r.decryptPhone(ctx, &user, phone); err != nil {
+			return nil, 0, err
+		}
+
 		users = append(users, &user)
 	}
 
@@ -276,6 +431,52 @@ func (r *PostgresRepository) List(page, pageSize int, emailFilter string) ([]*do
 	return users, totalCount, nil
 }
 
+// ListIDs returns the IDs of up to limit users matching the filter, in
+// creation order
+func (r *PostgresRepository) ListIDs(ctx context.Context, filter domain.UserFilter, limit int) ([]string, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Email != "" {
+		add("email ILIKE $%d", "%"+filter.Email+"%")
+	}
+	if filter.Role != "" {
+		add("$%d = ANY(roles)", filter.Role)
+	}
+	if filter.Group != "" {
+		add("customer_group = $%d", filter.Group)
+	}
+	if filter.Tag != "" {
+		add("$%d = ANY(tags)", filter.Tag)
+	}
+
+	query := `SELECT id FROM users`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY created_at, id LIMIT $%d`, len(args))
+
+	var ids []string
+	if err := r.db.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list user IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// ListRoles returns the roles held by at least one user, sorted
+func (r *PostgresRepository) ListRoles(ctx context.Context) ([]string, error) {
+	var roles []string
+	err := r.db.SelectContext(ctx, &roles, `SELECT DISTINCT unnest(roles) AS role FROM users ORDER BY role`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
 // max returns the maximum of two integers
 func max(a, b int) int {
 	if a > b {
@@ -287,18 +488,34 @@ func max(a, b int) int {
 // InitDB initializes the database schema
 func (r *PostgresRepository) InitDB() error {
 	schema := `
+	CREATE EXTENSION IF NOT EXISTS citext;
+
 	CREATE TABLE IF NOT EXISTS users (
 		id VARCHAR(36) PRIMARY KEY,
-		email VARCHAR(255) UNIQUE NOT NULL,
+		email CITEXT UNIQUE NOT NULL,
 		first_name VARCHAR(100) NOT NULL,
 		last_name VARCHAR(100) NOT NULL,
 		password_hash VARCHAR(255) NOT NULL,
 		roles TEXT[] NOT NULL DEFAULT '{}',
+		customer_group VARCHAR(32) NOT NULL DEFAULT 'retail',
+		tags TEXT[] NOT NULL DEFAULT '{}',
+		suspended BOOLEAN NOT NULL DEFAULT FALSE,
+		password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
+		phone_encrypted TEXT,
+		handle VARCHAR(30),
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL
 	);
 	
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_encrypted TEXT;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS customer_group VARCHAR(32) NOT NULL DEFAULT 'retail';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(30);
+
 	CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle);
 	`
 
 	_, err := r.db.Exec(schema)
@@ -306,5 +523,65 @@ func (r *PostgresRepository) InitDB() error {
 		return fmt.Errorf("failed to initialize database schema: %w", err)
 	}
 
+	return r.migrateEmailCase()
+}
+
+// emailCaseDuplicatesReported caps the duplicates listed by migrateEmailCase
+const emailCaseDuplicatesReported = 20
+
+// migrateEmailCase makes the email column of a table created before emails
+// were case-insensitive a CITEXT, so that User@X.com and user@x.com can no
+// longer both be stored. Emails that already differ only in case must be
+// merged or renamed by hand: they are reported in the returned error and
+// the column is left as it is.
+func (r *PostgresRepository) migrateEmailCase() error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var dataType string
+	err = tx.Get(&dataType, `
+		SELECT udt_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'email'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to get email column type: %w", err)
+	}
+	if dataType == "citext" {
+		return nil
+	}
+
+	// Keeps users from being written until the column is converted
+	if _, err := tx.Exec(`LOCK TABLE users IN EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	var duplicates []struct {
+		Email   string         `db:"email"`
+		UserIDs pq.StringArray `db:"user_ids"`
+	}
+	err = tx.Select(&duplicates, `
+		SELECT lower(email) AS email, array_agg(id ORDER BY created_at) AS user_ids
+		FROM users GROUP BY lower(email) HAVING count(*) > 1 ORDER BY lower(email)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to find emails differing only in case: %w", err)
+	}
+	if len(duplicates) > 0 {
+		reported := make([]string, 0, emailCaseDuplicatesReported)
+		for _, duplicate := range duplicates[:min(len(duplicates), emailCaseDuplicatesReported)] {
+			reported = append(reported, fmt.Sprintf("%s (users %s)", duplicate.Email, strings.Join(duplicate.UserIDs, ", ")))
+		}
+		return fmt.Errorf("%d emails are used by several users differing only in case, merge or rename them to make emails case-insensitive: %s",
+			len(duplicates), strings.Join(reported, "; "))
+	}
+
+	if _, err := tx.Exec(`ALTER TABLE users ALTER COLUMN email TYPE CITEXT`); err != nil {
+		return fmt.Errorf("failed to make emails case-insensitive: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email migration: %w", err)
+	}
 	return nil
 }
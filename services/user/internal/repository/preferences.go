@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// PreferenceRepository implements domain.PreferenceRepository with
+// PostgreSQL. Preferences are deleted with their user; the consent audit
+// entries are kept as the record of what the user agreed to.
+type PreferenceRepository struct {
+	db *sqlx.DB
+}
+
+var _ domain.PreferenceRepository = (*PreferenceRepository)(nil)
+
+// NewPreferenceRepository creates a PostgreSQL preference repository
+func NewPreferenceRepository(db *sqlx.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: db}
+}
+
+// Preferences returns the preferences the user has set
+func (r *PreferenceRepository) Preferences(ctx context.Context, userID string) ([]domain.Preference, error) {
+	var prefs []domain.Preference
+	err := r.db.SelectContext(ctx, &prefs, `
+		SELECT channel, category, enabled, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SetPreferences stores the changes and their audit entries in one
+// transaction
+func (r *PreferenceRepository) SetPreferences(ctx context.Context, changes []domain.ConsentChange) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, channel, category, enabled, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, channel, category) DO UPDATE SET enabled = $4, updated_at = $5
+		`, change.UserID, change.Channel, change.Category, change.Enabled, change.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("failed to set notification preference: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO consent_changes (user_id, channel, category, enabled, changed_by, changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, change.UserID, change.Channel, change.Category, change.Enabled, change.ChangedBy, change.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record consent change: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit notification preferences: %w", err)
+	}
+	return nil
+}
+
+// ConsentHistory returns the audit entries of a user, newest first
+func (r *PreferenceRepository) ConsentHistory(ctx context.Context, userID string, page pagination.Request) ([]domain.ConsentChange, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM consent_changes WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count consent changes: %w", err)
+	}
+
+	changes := []domain.ConsentChange{}
+	err := r.db.SelectContext(ctx, &changes, `
+		SELECT id, user_id, channel, category, enabled, changed_by, changed_at
+		FROM consent_changes
+		WHERE user_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, page.PageSize, page.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list consent changes: %w", err)
+	}
+	return changes, total, nil
+}
+
+// InitDB creates the notification_preferences and consent_changes tables.
+// It runs after the users table is created.
+func (r *PreferenceRepository) InitDB() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		channel VARCHAR(16) NOT NULL,
+		category VARCHAR(32) NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (user_id, channel, category)
+	);
+
+	CREATE TABLE IF NOT EXISTS consent_changes (
+		id BIGSERIAL PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL,
+		channel VARCHAR(16) NOT NULL,
+		category VARCHAR(32) NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		changed_by VARCHAR(255) NOT NULL DEFAULT '',
+		changed_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_consent_changes_user_id ON consent_changes(user_id, changed_at);
+	`
+
+	if _, err := r.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to initialize notification preference schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/pkg/objectstore"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// SetAuditRepository enables the audit trail, stored in trail
+func (s *UserService) SetAuditRepository(trail domain.AuditRepository) {
+	s.audit = trail
+}
+
+// SetAuditRetention archives audit entries older than retention to store,
+// under prefix, and then deletes them
+func (s *UserService) SetAuditRetention(retention time.Duration, store objectstore.Store, prefix string) {
+	s.retention, s.archive, s.archivePrefix = retention, store, prefix
+}
+
+// requireAudit fails when the audit trail is not enabled
+func (s *UserService) requireAudit() error {
+	if s.audit == nil {
+		return apperrors.New(apperrors.Unavailable, "the audit trail is not enabled")
+	}
+	return nil
+}
+
+// ExportAudit calls fn with the audit entries recorded from from up to to,
+// in chain order
+func (s *UserService) ExportAudit(ctx context.Context, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	if err := s.requireAudit(); err != nil {
+		return err
+	}
+	if !from.Before(to) {
+		return apperrors.New(apperrors.Invalid, "from must be before to")
+	}
+	return s.audit.Export(ctx, from, to, fn)
+}
+
+// ApplyAuditRetention archives the audit entries older than the retention
+// period to object storage, as one gzipped NDJSON object, and deletes them.
+// It returns how many were deleted; with no retention it does nothing.
+func (s *UserService) ApplyAuditRetention(ctx context.Context) (int64, error) {
+	if s.audit == nil || s.retention <= 0 {
+		return 0, nil
+	}
+	last, err := s.audit.LastBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil || last == 0 {
+		return 0, err
+	}
+
+	archive := audit.NewArchive()
+	var first int64
+	err = s.audit.ExportThrough(ctx, last, func(entry *domain.AuditEntry) error {
+		if first == 0 {
+			first = entry.ID
+		}
+		return archive.Write(entry)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read expired audit entries: %w", err)
+	}
+	if archive.Len() == 0 {
+		return 0, nil
+	}
+	key, err := archive.Upload(ctx, s.archive, s.archivePrefix, "user-service", first, last)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.audit.DeleteThrough(ctx, last)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("Archived audit entries", "key", key, "entries", deleted)
+	return deleted, nil
+}
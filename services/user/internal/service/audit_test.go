@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/audit"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAudit is a domain.AuditRepository chaining entries in memory, as
+// the PostgreSQL repository does
+type memoryAudit struct {
+	mu      sync.Mutex
+	entries []domain.AuditEntry
+	nextID  int64
+}
+
+func newMemoryAudit() *memoryAudit {
+	return &memoryAudit{nextID: 1}
+}
+
+func (m *memoryAudit) Record(_ context.Context, entry *domain.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.ID, entry.PrevHash, entry.Hash = m.nextID, "", ""
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+	if len(m.entries) > 0 {
+		entry.PrevHash = m.entries[len(m.entries)-1].Hash
+	}
+	hash, err := audit.Hash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	m.nextID++
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *memoryAudit) Export(_ context.Context, from, to time.Time, fn func(*domain.AuditEntry) error) error {
+	return m.each(func(entry domain.AuditEntry) bool {
+		return !entry.At.Before(from) && entry.At.Before(to)
+	}, fn)
+}
+
+func (m *memoryAudit) LastBefore(_ context.Context, t time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last int64
+	for i, entry := range m.entries {
+		if i < len(m.entries)-1 && entry.At.Before(t) {
+			last = entry.ID
+		}
+	}
+	return last, nil
+}
+
+func (m *memoryAudit) ExportThrough(_ context.Context, last int64, fn func(*domain.AuditEntry) error) error {
+	return m.each(func(entry domain.AuditEntry) bool { return entry.ID <= last }, fn)
+}
+
+func (m *memoryAudit) DeleteThrough(_ context.Context, last int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []domain.AuditEntry
+	for _, entry := range m.entries {
+		if entry.ID > last {
+			kept = append(kept, entry)
+		}
+	}
+	deleted := int64(len(m.entries) - len(kept))
+	m.entries = kept
+	return deleted, nil
+}
+
+func (m *memoryAudit) each(match func(domain.AuditEntry) bool, fn func(*domain.AuditEntry) error) error {
+	m.mu.Lock()
+	entries := append([]domain.AuditEntry(nil), m.entries...)
+	m.mu.Unlock()
+	for _, entry := range entries {
+		if !match(entry) {
+			continue
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryStore is an objectstore.Store keeping the objects put
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (m *memoryStore) Put(_ context.Context, key, _ string, body []byte) error {
+	m.objects[key] = body
+	return nil
+}
+
+// recordAt records an entry at a fixed time
+func recordAt(t *testing.T, trail *memoryAudit, userID string, at time.Time) {
+	t.Helper()
+	require.NoError(t, trail.Record(context.Background(), &domain.AuditEntry{
+		UserID: userID,
+		Action: domain.BulkSuspend,
+		Actor:  "admin-console",
+		Result: domain.AuditResultApplied,
+		At:     at,
+	}))
+}
+
+func TestExportAudit(t *testing.T) {
+	trail := newMemoryAudit()
+	now := time.Now().UTC()
+	recordAt(t, trail, "u1", now.Add(-2*time.Hour))
+	recordAt(t, trail, "u2", now.Add(-time.Hour))
+	recordAt(t, trail, "u3", now)
+
+	userService := NewUserService(new(MockUserRepository))
+	userService.SetAuditRepository(trail)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	err := userService.ExportAudit(context.Background(), now.Add(-90*time.Minute), now, func(entry *domain.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+	require.NoError(t, err)
+
+	result, err := audit.Verify(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Entries)
+	assert.Equal(t, trail.entries[0].Hash, result.Anchor, "the export chains to the entry before it")
+
+	err = userService.ExportAudit(context.Background(), now, now, func(*domain.AuditEntry) error { return nil })
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	err = NewUserService(new(MockUserRepository)).ExportAudit(context.Background(), now.Add(-time.Hour), now, nil)
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
+
+func TestApplyAuditRetention(t *testing.T) {
+	trail := newMemoryAudit()
+	now := time.Now().UTC()
+	recordAt(t, trail, "u1", now.Add(-72*time.Hour))
+	recordAt(t, trail, "u2", now.Add(-50*time.Hour))
+	recordAt(t, trail, "u3", now.Add(-time.Hour))
+	store := &memoryStore{objects: map[string][]byte{}}
+
+	userService := NewUserService(new(MockUserRepository))
+	userService.SetAuditRepository(trail)
+	userService.SetAuditRetention(48*time.Hour, store, "audit")
+
+	deleted, err := userService.ApplyAuditRetention(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	require.Len(t, trail.entries, 1)
+	head := trail.entries[0]
+
+	body, ok := store.objects["audit/user-service/00000000000000000001_00000000000000000002.ndjson.gz"]
+	require.True(t, ok, "got %v", store.objects)
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	result, err := audit.Verify(gz)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Entries)
+	assert.Empty(t, result.Anchor, "the first archive starts the chain")
+	assert.Equal(t, head.PrevHash, result.Head, "the kept entries continue the archived chain")
+
+	deleted, err = userService.ApplyAuditRetention(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "the chain head is kept")
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/google/uuid"
+)
+
+// bulkProgressEvery is how many users a bulk job handles between progress
+// updates
+const bulkProgressEvery = 100
+
+// SetBulkJobRepository enables bulk jobs, stored in jobs. They also need
+// the audit trail.
+func (s *UserService) SetBulkJobRepository(jobs domain.BulkJobRepository) {
+	s.jobs = jobs
+}
+
+// requireBulkJobs fails when bulk jobs are not enabled
+func (s *UserService) requireBulkJobs() error {
+	if s.jobs == nil || s.audit == nil {
+		return apperrors.New(apperrors.Unavailable, "bulk jobs are not enabled")
+	}
+	return nil
+}
+
+// StartBulkJob validates a bulk job and runs it in the background. The
+// users are resolved before it returns: the explicit IDs, or those
+// matching the filter. The job is returned queued; GetBulkJob reports its
+// progress.
+func (s *UserService) StartBulkJob(ctx context.Context, job *domain.BulkJob) (*domain.BulkJob, error) {
+	if err := s.requireBulkJobs(); err != nil {
+		return nil, err
+	}
+	if err := validateBulkJob(job); err != nil {
+		return nil, err
+	}
+
+	ids := job.UserIDs
+	if job.Filter != nil {
+		var err error
+		ids, err = s.repo.ListIDs(ctx, *job.Filter, domain.MaxBulkUsers+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select users: %w", err)
+		}
+	}
+	ids = dedupe(ids)
+	if job.Filter == nil && len(ids) == 0 {
+		return nil, apperrors.New(apperrors.Invalid, "either user IDs or a filter is required")
+	}
+	if len(ids) > domain.MaxBulkUsers {
+		return nil, apperrors.Newf(apperrors.Invalid, "a bulk job applies to at most %d users", domain.MaxBulkUsers)
+	}
+
+	job.ID = uuid.New().String()
+	job.UserIDs = ids
+	job.Status = domain.BulkStatusQueued
+	job.Total, job.Succeeded, job.Failed = len(ids), 0, 0
+	job.Errors = []domain.BulkError{}
+	job.CreatedAt = time.Now().UTC()
+	job.StartedAt, job.FinishedAt = nil, nil
+	if principal, ok := middleware.PrincipalFrom(ctx); ok {
+		job.CreatedBy = principal.Subject
+	}
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	// The job outlives the request, so it runs on its own copy
+	run := *job
+	run.UserIDs = slices.Clone(ids)
+	run.Errors = []domain.BulkError{}
+	s.bulk.Add(1)
+	go func() {
+		defer s.bulk.Done()
+		s.runBulkJob(context.Background(), &run)
+	}()
+	return job, nil
+}
+
+// GetBulkJob returns a bulk job and its progress
+func (s *UserService) GetBulkJob(ctx context.Context, id string) (*domain.BulkJob, error) {
+	if err := s.requireBulkJobs(); err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, apperrors.New(apperrors.Invalid, "bulk job ID is required")
+	}
+	job, err := s.jobs.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk job: %w", err)
+	}
+	return job, nil
+}
+
+// WaitBulkJobs blocks until the running bulk jobs finish. It is called on
+// shutdown; jobs interrupted anyway stay running in the repository.
+func (s *UserService) WaitBulkJobs() {
+	s.bulk.Wait()
+}
+
+// runBulkJob applies the action of a job to each of its users, writing an
+// audit entry per user and storing the progress as it goes. The job fails
+// when no user could be updated.
+func (s *UserService) runBulkJob(ctx context.Context, job *domain.BulkJob) {
+	started := time.Now().UTC()
+	job.Status, job.StartedAt = domain.BulkStatusRunning, &started
+	s.saveBulkJob(ctx, job)
+
+	for _, id := range job.UserIDs {
+		result, err := s.applyBulkAction(ctx, job, id)
+		entry := domain.AuditEntry{
+			UserID: id,
+			Action: job.Action,
+			Detail: job.Role + job.Tag,
+			Actor:  job.CreatedBy,
+			JobID:  job.ID,
+			Result: result,
+		}
+		if err != nil {
+			job.Failed++
+			if len(job.Errors) < domain.MaxBulkErrors {
+				job.Errors = append(job.Errors, domain.BulkError{UserID: id, Error: err.Error()})
+			}
+			entry.Error = err.Error()
+		} else {
+			job.Succeeded++
+		}
+		if err := s.audit.Record(ctx, &entry); err != nil {
+			s.logger.Error("Failed to audit bulk action", "job", job.ID, "user", id, "error", err)
+		}
+		if job.Processed()%bulkProgressEvery == 0 {
+			s.saveBulkJob(ctx, job)
+		}
+	}
+
+	finished := time.Now().UTC()
+	job.Status, job.FinishedAt = domain.BulkStatusCompleted, &finished
+	if job.Failed > 0 && job.Succeeded == 0 {
+		job.Status = domain.BulkStatusFailed
+	}
+	s.saveBulkJob(ctx, job)
+	s.logger.Info("Bulk job finished", "job", job.ID, "action", job.Action,
+		"status", job.Status, "succeeded", job.Succeeded, "failed", job.Failed)
+}
+
+// applyBulkAction applies the action of a job to a user and returns the
+// audit result
+func (s *UserService) applyBulkAction(ctx context.Context, job *domain.BulkJob, id string) (string, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return domain.AuditResultFailed, err
+	}
+
+	changed := false
+	switch job.Action {
+	case domain.BulkAssignRole:
+		if !slices.Contains(user.Roles, job.Role) {
+			user.Roles, changed = append(user.Roles, job.Role), true
+		}
+	case domain.BulkTag:
+		if !slices.Contains(user.Tags, job.Tag) {
+			user.Tags, changed = append(user.Tags, job.Tag), true
+		}
+	case domain.BulkSuspend:
+		changed = !user.Suspended
+		user.Suspended = true
+	case domain.BulkForcePasswordReset:
+		changed = !user.PasswordResetRequired
+		user.PasswordResetRequired = true
+	}
+	if !changed {
+		return domain.AuditResultUnchanged, nil
+	}
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return domain.AuditResultFailed, err
+	}
+	return domain.AuditResultApplied, nil
+}
+
+// saveBulkJob stores the progress of a job, logging failures: the job
+// carries on without it
+func (s *UserService) saveBulkJob(ctx context.Context, job *domain.BulkJob) {
+	if err := s.jobs.Update(ctx, job); err != nil {
+		s.logger.Error("Failed to update bulk job", "job", job.ID, "error", err)
+	}
+}
+
+// validateBulkJob checks the action, its parameter and the users of a job
+func validateBulkJob(job *domain.BulkJob) error {
+	if !domain.IsBulkAction(job.Action) {
+		return apperrors.Newf(apperrors.Invalid, "unknown bulk action %q", job.Action)
+	}
+	job.Role, job.Tag = strings.TrimSpace(job.Role), strings.TrimSpace(job.Tag)
+	if job.Action == domain.BulkAssignRole && job.Role == "" {
+		return apperrors.New(apperrors.Invalid, "role is required")
+	}
+	if job.Action == domain.BulkTag && job.Tag == "" {
+		return apperrors.New(apperrors.Invalid, "tag is required")
+	}
+	if job.Action != domain.BulkAssignRole {
+		job.Role = ""
+	}
+	if job.Action != domain.BulkTag {
+		job.Tag = ""
+	}
+
+	switch {
+	case job.Filter != nil && len(job.UserIDs) > 0:
+		return apperrors.New(apperrors.Invalid, "either user IDs or a filter is required, not both")
+	case job.Filter != nil:
+		if job.Filter.IsZero() {
+			return apperrors.New(apperrors.Invalid, "the filter must select users by at least one field")
+		}
+		if group := job.Filter.Group; group != "" && !domain.IsCustomerGroup(group) {
+			return apperrors.Newf(apperrors.Invalid, "unknown customer group %q", group)
+		}
+	case len(job.UserIDs) == 0:
+		return apperrors.New(apperrors.Invalid, "either user IDs or a filter is required")
+	}
+	return nil
+}
+
+// dedupe returns ids without empty and repeated IDs, in order
+func dedupe(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
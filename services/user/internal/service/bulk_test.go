@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingJobs is a domain.BulkJobRepository keeping the last stored
+// state of each job
+type recordingJobs struct {
+	mu   sync.Mutex
+	jobs map[string]domain.BulkJob
+}
+
+func newRecordingJobs() *recordingJobs {
+	return &recordingJobs{jobs: map[string]domain.BulkJob{}}
+}
+
+func (r *recordingJobs) Create(_ context.Context, job *domain.BulkJob) error {
+	return r.Update(context.Background(), job)
+}
+
+func (r *recordingJobs) Get(_ context.Context, id string) (*domain.BulkJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.NotFound, "bulk job %s not found", id)
+	}
+	return &job, nil
+}
+
+func (r *recordingJobs) Update(_ context.Context, job *domain.BulkJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *job
+	stored.Errors = append([]domain.BulkError(nil), job.Errors...)
+	r.jobs[job.ID] = stored
+	return nil
+}
+
+func TestStartBulkJobValidation(t *testing.T) {
+	userService := NewUserService(new(MockUserRepository))
+	userService.SetBulkJobRepository(newRecordingJobs())
+	userService.SetAuditRepository(newMemoryAudit())
+
+	tests := []struct {
+		name string
+		job  domain.BulkJob
+	}{
+		{"unknown action", domain.BulkJob{Action: "delete", UserIDs: []string{"u1"}}},
+		{"role missing", domain.BulkJob{Action: domain.BulkAssignRole, UserIDs: []string{"u1"}}},
+		{"tag missing", domain.BulkJob{Action: domain.BulkTag, Tag: " ", UserIDs: []string{"u1"}}},
+		{"no users", domain.BulkJob{Action: domain.BulkSuspend}},
+		{"empty IDs", domain.BulkJob{Action: domain.BulkSuspend, UserIDs: []string{""}}},
+		{"IDs and filter", domain.BulkJob{Action: domain.BulkSuspend, UserIDs: []string{"u1"}, Filter: &domain.UserFilter{Tag: "x"}}},
+		{"empty filter", domain.BulkJob{Action: domain.BulkSuspend, Filter: &domain.UserFilter{}}},
+		{"unknown group", domain.BulkJob{Action: domain.BulkSuspend, Filter: &domain.UserFilter{Group: "gold"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := userService.StartBulkJob(context.Background(), &tt.job)
+			assert.True(t, apperrors.Is(err, apperrors.Invalid), "got %v", err)
+		})
+	}
+
+	_, err := NewUserService(new(MockUserRepository)).GetBulkJob(context.Background(), "job")
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
+
+func TestBulkJobAppliesAndAudits(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("ListIDs", domain.UserFilter{Group: domain.CustomerGroupVIP}, domain.MaxBulkUsers+1).
+		Return([]string{"support", "customer", "gone", "customer"}, nil)
+	mockRepo.On("GetByID", "support").Return(builders.NewUser(t).WithID("support").WithRoles("support").Build(), nil)
+	mockRepo.On("GetByID", "customer").Return(builders.NewUser(t).WithID("customer").WithRoles("customer").Build(), nil)
+	mockRepo.On("GetByID", "gone").Return(nil, apperrors.New(apperrors.NotFound, "user with ID gone not found"))
+	var updated []*domain.User
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		updated = append(updated, args.Get(0).(*domain.User))
+	}).Return(nil)
+	auditLog := newMemoryAudit()
+
+	userService := NewUserService(mockRepo)
+	userService.SetBulkJobRepository(newRecordingJobs())
+	userService.SetAuditRepository(auditLog)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Subject: "admin-console"})
+	job, err := userService.StartBulkJob(ctx, &domain.BulkJob{
+		Action: domain.BulkAssignRole,
+		Role:   "support",
+		Filter: &domain.UserFilter{Group: domain.CustomerGroupVIP},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BulkStatusQueued, job.Status)
+	assert.Equal(t, 3, job.Total, "repeated users are applied once")
+	userService.WaitBulkJobs()
+
+	done, err := userService.GetBulkJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.BulkStatusCompleted, done.Status)
+	assert.Equal(t, 2, done.Succeeded)
+	assert.Equal(t, 1, done.Failed)
+	assert.Equal(t, []domain.BulkError{{UserID: "gone", Error: "user with ID gone not found"}}, done.Errors)
+	assert.NotNil(t, done.FinishedAt)
+
+	if assert.Len(t, updated, 1, "users with the role already are left alone") {
+		assert.Equal(t, []string{"customer", "support"}, updated[0].Roles)
+	}
+	require.Len(t, auditLog.entries, 3)
+	results := map[string]string{}
+	for _, entry := range auditLog.entries {
+		assert.Equal(t, "admin-console", entry.Actor)
+		assert.Equal(t, job.ID, entry.JobID)
+		assert.Equal(t, "support", entry.Detail)
+		results[entry.UserID] = entry.Result
+	}
+	assert.Equal(t, map[string]string{
+		"support":  domain.AuditResultUnchanged,
+		"customer": domain.AuditResultApplied,
+		"gone":     domain.AuditResultFailed,
+	}, results)
+}
+
+func TestBulkJobFailsWhenNoUserIsUpdated(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", "gone").Return(nil, apperrors.New(apperrors.NotFound, "user with ID gone not found"))
+	userService := NewUserService(mockRepo)
+	userService.SetBulkJobRepository(newRecordingJobs())
+	userService.SetAuditRepository(newMemoryAudit())
+	job, err := userService.StartBulkJob(context.Background(), &domain.BulkJob{Action: domain.BulkSuspend, UserIDs: []string{"gone"}})
+	require.NoError(t, err)
+	userService.WaitBulkJobs()
+
+	done, err := userService.GetBulkJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.BulkStatusFailed, done.Status)
+}
+
+func TestUpdatePasswordClearsResetRequired(t *testing.T) {
+	user := builders.NewUser(t).WithID("user-id-123").Build()
+	user.PasswordResetRequired = true
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", "user-id-123").Return(user, nil)
+	mockRepo.On("Update", user).Return(nil)
+
+	updated, err := NewUserService(mockRepo).UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"password": "a-new-password"})
+	require.NoError(t, err)
+	assert.False(t, updated.PasswordResetRequired)
+}
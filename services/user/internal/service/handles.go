@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// maxHandleSuggestions caps the handles SuggestHandles returns
+const maxHandleSuggestions = 5
+
+// handleSuffixes is how many numbered variants of the first stem
+// SuggestHandles considers, e.g. ann_lee1 to ann_lee20
+const handleSuffixes = 20
+
+// GetUserByHandle retrieves a user by handle, ignoring case and a leading @
+func (s *UserService) GetUserByHandle(ctx context.Context, handle string) (*domain.User, error) {
+	handle = normalizeHandle(handle)
+	if handle == "" {
+		return nil, apperrors.New(apperrors.Invalid, "handle is required")
+	}
+
+	user, err := s.repo.GetByHandle(ctx, handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by handle: %w", err)
+	}
+	return user, nil
+}
+
+// SuggestHandles returns up to five free handles for a user, derived from
+// base when it is set and from the user's name and email otherwise
+func (s *UserService) SuggestHandles(ctx context.Context, userID, base string) ([]string, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stems []string
+	if base != "" {
+		stems = []string{handleStem(base)}
+	} else {
+		local, _, _ := strings.Cut(user.Email, "@")
+		first, last := handleWord(user.FirstName), handleWord(user.LastName)
+		stems = []string{
+			handleStem(user.FirstName + " " + user.LastName),
+			truncate(first+last, domain.MaxHandleLength),
+			truncate(first[:min(1, len(first))]+last, domain.MaxHandleLength),
+			handleStem(local),
+		}
+	}
+	candidates := handleCandidates(stems)
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	taken, err := s.repo.HandlesTaken(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest handles: %w", err)
+	}
+	suggestions := make([]string, 0, maxHandleSuggestions)
+	for _, candidate := range candidates {
+		if user.Handle != candidate && !slices.Contains(taken, candidate) {
+			suggestions = append(suggestions, candidate)
+		}
+		if len(suggestions) == maxHandleSuggestions {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// handleCandidates lists the valid handles made of stems, then of the
+// first stem with a number appended, without duplicates
+func handleCandidates(stems []string) []string {
+	var candidates []string
+	add := func(handle string) {
+		if validateHandle(handle) == nil && !slices.Contains(candidates, handle) {
+			candidates = append(candidates, handle)
+		}
+	}
+	for _, stem := range stems {
+		add(stem)
+	}
+	if len(stems) == 0 || stems[0] == "" {
+		return candidates
+	}
+	for i := 1; i <= handleSuffixes; i++ {
+		suffix := strconv.Itoa(i)
+		add(truncate(stems[0], domain.MaxHandleLength-len(suffix)) + suffix)
+	}
+	return candidates
+}
+
+// handleStem turns a name into the start of a handle: lowercase ASCII
+// letters and digits, other runs of characters becoming one underscore,
+// starting with a letter
+func handleStem(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9' && b.Len() > 0:
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+			underscore = false
+		default:
+			underscore = true
+		}
+	}
+	return truncate(b.String(), domain.MaxHandleLength)
+}
+
+// handleWord is handleStem without underscores, e.g. annmarie for
+// Ann-Marie
+func handleWord(name string) string {
+	return strings.ReplaceAll(handleStem(name), "_", "")
+}
+
+// truncate cuts an ASCII string to at most n bytes, without a trailing
+// underscore
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.TrimRight(s, "_")
+}
+
+// normalizeHandle lowercases a handle and drops spaces and a leading @
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// validateHandle checks a normalized handle's length, characters and that
+// it is not reserved
+func validateHandle(handle string) error {
+	if len(handle) < domain.MinHandleLength || len(handle) > domain.MaxHandleLength {
+		return apperrors.Newf(apperrors.Invalid, "handle must be %d to %d characters long", domain.MinHandleLength, domain.MaxHandleLength)
+	}
+	for i, c := range handle {
+		letter := c >= 'a' && c <= 'z'
+		if i == 0 && !letter {
+			return apperrors.New(apperrors.Invalid, "handle must start with a letter")
+		}
+		if !letter && !(c >= '0' && c <= '9') && c != '_' {
+			return apperrors.New(apperrors.Invalid, "handle may only contain letters, digits and underscores")
+		}
+	}
+	if domain.IsReservedHandle(handle) {
+		return apperrors.Newf(apperrors.Invalid, "handle %q is reserved", handle)
+	}
+	return nil
+}
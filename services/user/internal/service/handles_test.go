@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserHandle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").Build(), nil)
+	mockRepo.On("GetByHandle", "ann_lee").Return(nil, apperrors.New(apperrors.NotFound, "user not found"))
+	mockRepo.On("GetByHandle", "bo").Return(builders.NewUser(t).WithID("user-id-456").Build(), nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	user, err := userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"handle": " @Ann_Lee"})
+	require.NoError(t, err)
+	assert.Equal(t, "ann_lee", user.Handle)
+
+	user, err = userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"handle": ""})
+	require.NoError(t, err)
+	assert.Empty(t, user.Handle, "an empty handle clears it")
+
+	_, err = userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"handle": "bo"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid), "too short: %v", err)
+
+	mockRepo.On("GetByHandle", "bo_lee").Return(builders.NewUser(t).WithID("user-id-456").Build(), nil)
+	_, err = userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"handle": "bo_lee"})
+	assert.True(t, apperrors.Is(err, apperrors.Conflict), "taken: %v", err)
+}
+
+func TestValidateHandle(t *testing.T) {
+	for _, handle := range []string{"ann", "ann_lee", "a1_", strings.Repeat("a", 30)} {
+		assert.NoError(t, validateHandle(handle), handle)
+	}
+	for _, handle := range []string{"", "an", strings.Repeat("a", 31), "1ann", "_ann", "ann-lee", "ann.lee", "änn", "admin", "support"} {
+		assert.True(t, apperrors.Is(validateHandle(handle), apperrors.Invalid), handle)
+	}
+}
+
+func TestSuggestHandles(t *testing.T) {
+	t.Run("from the user's name and email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").
+			WithName("Ann-Marie", "O'Lee").WithEmail("ann.lee@example.com").Build(), nil)
+		mockRepo.On("HandlesTaken", mock.Anything).Return([]string{"ann_marie_o_lee", "annmarieolee"}, nil)
+
+		suggestions, err := NewUserService(mockRepo).SuggestHandles(context.Background(), "user-id-123", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aolee", "ann_lee", "ann_marie_o_lee1", "ann_marie_o_lee2", "ann_marie_o_lee3"}, suggestions)
+	})
+
+	t.Run("from a base", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").Build(), nil)
+		mockRepo.On("HandlesTaken", mock.Anything).Return([]string{}, nil)
+
+		suggestions, err := NewUserService(mockRepo).SuggestHandles(context.Background(), "user-id-123", "Admin")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin1", "admin2", "admin3", "admin4", "admin5"}, suggestions, "reserved handles are not suggested")
+
+		suggestions, err = NewUserService(mockRepo).SuggestHandles(context.Background(), "user-id-123", strings.Repeat("x", 40))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("x", 30), suggestions[0])
+		assert.Equal(t, strings.Repeat("x", 29)+"1", suggestions[1])
+	})
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// SetLoginRepository enables login recording, stored in logins. With
+// stepUp, suspicious logins must be verified before their device and
+// location are trusted.
+func (s *UserService) SetLoginRepository(logins domain.LoginRepository, stepUp bool) {
+	s.logins, s.stepUp = logins, stepUp
+}
+
+// requireLogins fails when login recording is not enabled
+func (s *UserService) requireLogins() error {
+	if s.logins == nil {
+		return apperrors.New(apperrors.Unavailable, "login recording is not enabled")
+	}
+	return nil
+}
+
+// RecordLogin records a successful sign-in of a user. A login from a
+// device or location none of the user's trusted logins came from is
+// suspicious, unless it is the user's first: it is flagged with the
+// reasons and a user.suspicious_login event is published for the
+// notification service.
+func (s *UserService) RecordLogin(ctx context.Context, userID string, attempt domain.LoginAttempt) (*domain.Login, error) {
+	if err := s.requireLogins(); err != nil {
+		return nil, err
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	attempt.Country = strings.ToUpper(strings.TrimSpace(attempt.Country))
+	attempt.Region = strings.ToUpper(strings.TrimSpace(attempt.Region))
+	location := domain.LoginLocation(attempt.Country, attempt.Region, attempt.IP)
+	if attempt.UserAgent == "" && location == "" {
+		return nil, apperrors.New(apperrors.Invalid, "a user agent, IP address or country is required")
+	}
+
+	login := &domain.Login{
+		UserID:    userID,
+		DeviceID:  domain.DeviceFingerprint(attempt.UserAgent),
+		UserAgent: attempt.UserAgent,
+		IP:        attempt.IP,
+		Country:   attempt.Country,
+		Region:    attempt.Region,
+		Location:  location,
+		Reasons:   []string{},
+		CreatedAt: time.Now().UTC(),
+	}
+	history, err := s.logins.History(ctx, userID, login.DeviceID, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+	if history.Logins > 0 {
+		if !history.KnownDevice {
+			login.Reasons = append(login.Reasons, domain.LoginReasonNewDevice)
+		}
+		if !history.KnownLocation && location != "" {
+			login.Reasons = append(login.Reasons, domain.LoginReasonNewLocation)
+		}
+	}
+	login.StepUpRequired = s.stepUp && login.Suspicious()
+
+	if err := s.logins.Create(ctx, login); err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+	if login.Suspicious() {
+		s.publish(ctx, events.UserSuspiciousLogin, userID, events.SuspiciousLoginPayload{
+			UserID:         userID,
+			Email:          user.Email,
+			LoginID:        login.ID,
+			DeviceID:       login.DeviceID,
+			UserAgent:      login.UserAgent,
+			Location:       login.Location,
+			Reasons:        login.Reasons,
+			StepUpRequired: login.StepUpRequired,
+			LoggedInAt:     login.CreatedAt,
+		})
+	}
+	return login, nil
+}
+
+// VerifyLogin marks a login awaiting step-up as verified, once the user
+// passed the extra check, which trusts its device and location
+func (s *UserService) VerifyLogin(ctx context.Context, userID string, loginID int64) (*domain.Login, error) {
+	if err := s.requireLogins(); err != nil {
+		return nil, err
+	}
+	login, err := s.logins.Get(ctx, userID, loginID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login: %w", err)
+	}
+	if !login.StepUpRequired {
+		return nil, apperrors.Newf(apperrors.Conflict, "login %d does not require verification", loginID)
+	}
+	if login.VerifiedAt != nil {
+		return login, nil
+	}
+	now := time.Now().UTC()
+	if err := s.logins.Verify(ctx, userID, loginID, now); err != nil {
+		return nil, fmt.Errorf("failed to verify login: %w", err)
+	}
+	login.VerifiedAt = &now
+	return login, nil
+}
+
+// ListLogins returns the logins of a user, newest first
+func (s *UserService) ListLogins(ctx context.Context, userID string, page pagination.Request) ([]domain.Login, int, error) {
+	if err := s.requireLogins(); err != nil {
+		return nil, 0, err
+	}
+	if userID == "" {
+		return nil, 0, apperrors.New(apperrors.Invalid, "user ID is required")
+	}
+	logins, total, err := s.logins.List(ctx, userID, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list logins: %w", err)
+	}
+	return logins, total, nil
+}
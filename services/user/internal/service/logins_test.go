@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockLoginRepository is a mock implementation of domain.LoginRepository
+type MockLoginRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginRepository) History(ctx context.Context, userID, deviceID, location string) (domain.LoginHistory, error) {
+	args := m.Called(userID, deviceID, location)
+	return args.Get(0).(domain.LoginHistory), args.Error(1)
+}
+
+func (m *MockLoginRepository) Create(ctx context.Context, login *domain.Login) error {
+	args := m.Called(login)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepository) Get(ctx context.Context, userID string, id int64) (*domain.Login, error) {
+	args := m.Called(userID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Login), args.Error(1)
+}
+
+func (m *MockLoginRepository) Verify(ctx context.Context, userID string, id int64, at time.Time) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepository) List(ctx context.Context, userID string, page pagination.Request) ([]domain.Login, int, error) {
+	args := m.Called(userID, page)
+	return args.Get(0).([]domain.Login), args.Int(1), args.Error(2)
+}
+
+func newLoginService(t *testing.T, stepUp bool) (*UserService, *MockLoginRepository, *[]*eventbus.Event) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").WithEmail("ann@example.com").Build(), nil)
+	loginRepo := new(MockLoginRepository)
+	loginRepo.On("Create", mock.AnythingOfType("*domain.Login")).Return(nil)
+
+	var published []*eventbus.Event
+
+	userService := NewUserService(mockRepo)
+	userService.SetLoginRepository(loginRepo, stepUp)
+	userService.SetPublisher(recordingPublisher{events: &published})
+	return userService, loginRepo, &published
+}
+
+// recordingPublisher keeps the events published
+type recordingPublisher struct {
+	events *[]*eventbus.Event
+}
+
+func (p recordingPublisher) Publish(_ context.Context, event *eventbus.Event) error {
+	*p.events = append(*p.events, event)
+	return nil
+}
+
+func TestLoginLocation(t *testing.T) {
+	assert.Equal(t, "DE/BE", domain.LoginLocation(" de", "be ", "203.0.113.7"))
+	assert.Equal(t, "DE", domain.LoginLocation("DE", "", ""))
+	assert.Equal(t, "203.0.113.0/24", domain.LoginLocation("", "", "203.0.113.7"))
+	assert.Equal(t, "2001:db8:85a3::/48", domain.LoginLocation("", "", "2001:db8:85a3::8a2e:370:7334"))
+	assert.Empty(t, domain.LoginLocation("", "", "not an IP"))
+	assert.Equal(t, domain.DeviceFingerprint("Firefox/125.0"), domain.DeviceFingerprint(" firefox/125.0"))
+}
+
+func TestRecordLogin(t *testing.T) {
+	attempt := domain.LoginAttempt{UserAgent: "Firefox/125.0", IP: "203.0.113.7", Country: "de"}
+	device := domain.DeviceFingerprint(attempt.UserAgent)
+
+	t.Run("first login", func(t *testing.T) {
+		userService, loginRepo, published := newLoginService(t, true)
+		loginRepo.On("History", "user-id-123", device, "DE").Return(domain.LoginHistory{}, nil)
+
+		login, err := userService.RecordLogin(context.Background(), "user-id-123", attempt)
+		require.NoError(t, err)
+		assert.False(t, login.Suspicious(), "there is nothing to compare the first login with")
+		assert.False(t, login.StepUpRequired)
+		assert.Empty(t, *published)
+	})
+
+	t.Run("new device", func(t *testing.T) {
+		userService, loginRepo, published := newLoginService(t, false)
+		loginRepo.On("History", "user-id-123", device, "DE").Return(domain.LoginHistory{Logins: 3, KnownLocation: true}, nil)
+
+		login, err := userService.RecordLogin(context.Background(), "user-id-123", attempt)
+		require.NoError(t, err)
+		assert.Equal(t, []string{domain.LoginReasonNewDevice}, login.Reasons)
+		assert.False(t, login.StepUpRequired, "step-up is off")
+		require.Len(t, *published, 1)
+		var payload events.SuspiciousLoginPayload
+		require.NoError(t, (*published)[0].Decode(&payload))
+		assert.Equal(t, "ann@example.com", payload.Email)
+		assert.Equal(t, "user-id-123", (*published)[0].Key)
+	})
+
+	t.Run("new device and location with step-up", func(t *testing.T) {
+		userService, loginRepo, _ := newLoginService(t, true)
+		loginRepo.On("History", "user-id-123", device, "DE").Return(domain.LoginHistory{Logins: 1}, nil)
+
+		login, err := userService.RecordLogin(context.Background(), "user-id-123", attempt)
+		require.NoError(t, err)
+		assert.Equal(t, []string{domain.LoginReasonNewDevice, domain.LoginReasonNewLocation}, login.Reasons)
+		assert.True(t, login.StepUpRequired)
+		assert.Equal(t, "DE", login.Country)
+	})
+
+	t.Run("nothing to fingerprint", func(t *testing.T) {
+		userService, _, _ := newLoginService(t, true)
+		_, err := userService.RecordLogin(context.Background(), "user-id-123", domain.LoginAttempt{})
+		assert.True(t, apperrors.Is(err, apperrors.Invalid))
+	})
+}
+
+func TestVerifyLogin(t *testing.T) {
+	userService, loginRepo, _ := newLoginService(t, true)
+	loginRepo.On("Get", "user-id-123", int64(7)).Return(&domain.Login{ID: 7, StepUpRequired: true}, nil)
+	loginRepo.On("Get", "user-id-123", int64(8)).Return(&domain.Login{ID: 8}, nil)
+	loginRepo.On("Verify", "user-id-123", int64(7)).Return(nil)
+
+	login, err := userService.VerifyLogin(context.Background(), "user-id-123", 7)
+	require.NoError(t, err)
+	assert.NotNil(t, login.VerifiedAt)
+
+	_, err = userService.VerifyLogin(context.Background(), "user-id-123", 8)
+	assert.True(t, apperrors.Is(err, apperrors.Conflict))
+}
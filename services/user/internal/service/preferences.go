@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// SetPreferenceRepository enables notification preferences, stored in prefs
+func (s *UserService) SetPreferenceRepository(prefs domain.PreferenceRepository) {
+	s.prefs = prefs
+}
+
+// requirePreferences fails when notification preferences are not enabled
+func (s *UserService) requirePreferences() error {
+	if s.prefs == nil {
+		return apperrors.New(apperrors.Unavailable, "notification preferences are not enabled")
+	}
+	return nil
+}
+
+// NotificationPreferences returns every channel and category of a user's
+// notification preferences, with the defaults where the user has not
+// chosen
+func (s *UserService) NotificationPreferences(ctx context.Context, userID string) ([]domain.Preference, error) {
+	if err := s.requirePreferences(); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	stored, err := s.prefs.Preferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return withDefaults(stored), nil
+}
+
+// UpdateNotificationPreferences sets the given preferences of a user and
+// returns all of them. Each preference that changes is recorded in the
+// user's consent history, with the authenticated subject making the
+// change.
+func (s *UserService) UpdateNotificationPreferences(ctx context.Context, userID string, updates []domain.Preference) ([]domain.Preference, error) {
+	if err := s.requirePreferences(); err != nil {
+		return nil, err
+	}
+	for _, update := range updates {
+		if err := validateConsentKey(update.Channel, update.Category); err != nil {
+			return nil, err
+		}
+	}
+	current, err := s.NotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changedBy string
+	if principal, ok := middleware.PrincipalFrom(ctx); ok {
+		changedBy = principal.Subject
+	}
+	now := time.Now().UTC()
+	var changes []domain.ConsentChange
+	for _, update := range updates {
+		pref := find(current, update.Channel, update.Category)
+		// Choosing the default is recorded too: it is consent given
+		if pref.Enabled == update.Enabled && !pref.Default {
+			continue
+		}
+		pref.Enabled, pref.Default, pref.UpdatedAt = update.Enabled, false, &now
+		changes = append(changes, domain.ConsentChange{
+			UserID:    userID,
+			Channel:   update.Channel,
+			Category:  update.Category,
+			Enabled:   update.Enabled,
+			ChangedBy: changedBy,
+			ChangedAt: now,
+		})
+	}
+	if len(changes) == 0 {
+		return current, nil
+	}
+	if err := s.prefs.SetPreferences(ctx, changes); err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+	return current, nil
+}
+
+// CheckConsent reports whether a user gets notifications of a category on
+// a channel. Senders call it before each notification.
+func (s *UserService) CheckConsent(ctx context.Context, userID, channel, category string) (bool, error) {
+	if err := validateConsentKey(channel, category); err != nil {
+		return false, err
+	}
+	prefs, err := s.NotificationPreferences(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return find(prefs, channel, category).Enabled, nil
+}
+
+// ConsentHistory returns the changes to a user's notification preferences,
+// newest first
+func (s *UserService) ConsentHistory(ctx context.Context, userID string, page pagination.Request) ([]domain.ConsentChange, int, error) {
+	if err := s.requirePreferences(); err != nil {
+		return nil, 0, err
+	}
+	if userID == "" {
+		return nil, 0, apperrors.New(apperrors.Invalid, "user ID is required")
+	}
+	changes, total, err := s.prefs.ConsentHistory(ctx, userID, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get consent history: %w", err)
+	}
+	return changes, total, nil
+}
+
+// validateConsentKey checks a channel and category
+func validateConsentKey(channel, category string) error {
+	if !domain.IsChannel(channel) {
+		return apperrors.Newf(apperrors.Invalid, "unknown notification channel %q", channel)
+	}
+	if !domain.IsCategory(category) {
+		return apperrors.Newf(apperrors.Invalid, "unknown notification category %q", category)
+	}
+	return nil
+}
+
+// withDefaults returns a preference for every channel and category: the
+// stored one, or the default
+func withDefaults(stored []domain.Preference) []domain.Preference {
+	prefs := make([]domain.Preference, 0, len(domain.Channels)*len(domain.Categories))
+	for _, channel := range domain.Channels {
+		for _, category := range domain.Categories {
+			pref := domain.Preference{
+				Channel:  channel,
+				Category: category,
+				Enabled:  domain.DefaultConsent(channel, category),
+				Default:  true,
+			}
+			for _, s := range stored {
+				if s.Channel == channel && s.Category == category {
+					pref = s
+				}
+			}
+			prefs = append(prefs, pref)
+		}
+	}
+	return prefs
+}
+
+// find returns the preference for a channel and category of prefs, which
+// has every one
+func find(prefs []domain.Preference, channel, category string) *domain.Preference {
+	for i := range prefs {
+		if prefs[i].Channel == channel && prefs[i].Category == category {
+			return &prefs[i]
+		}
+	}
+	panic("no preference for " + channel + "/" + category)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/pkg/pagination"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPreferenceRepository is a mock implementation of domain.PreferenceRepository
+type MockPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockPreferenceRepository) Preferences(ctx context.Context, userID string) ([]domain.Preference, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.Preference), args.Error(1)
+}
+
+func (m *MockPreferenceRepository) SetPreferences(ctx context.Context, changes []domain.ConsentChange) error {
+	args := m.Called(changes)
+	return args.Error(0)
+}
+
+func (m *MockPreferenceRepository) ConsentHistory(ctx context.Context, userID string, page pagination.Request) ([]domain.ConsentChange, int, error) {
+	args := m.Called(userID, page)
+	return args.Get(0).([]domain.ConsentChange), args.Int(1), args.Error(2)
+}
+
+func newPreferenceService(t *testing.T, stored ...domain.Preference) (*UserService, *MockPreferenceRepository) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").Build(), nil)
+	prefRepo := new(MockPreferenceRepository)
+	prefRepo.On("Preferences", "user-id-123").Return(stored, nil)
+
+	userService := NewUserService(mockRepo)
+	userService.SetPreferenceRepository(prefRepo)
+	return userService, prefRepo
+}
+
+func TestNotificationPreferencesDefaults(t *testing.T) {
+	updated := time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)
+	userService, _ := newPreferenceService(t,
+		domain.Preference{Channel: domain.ChannelEmail, Category: domain.CategoryOrderUpdates, Enabled: false, UpdatedAt: &updated},
+	)
+
+	prefs, err := userService.NotificationPreferences(context.Background(), "user-id-123")
+	assert.NoError(t, err)
+	assert.Len(t, prefs, 9)
+	assert.Equal(t, domain.Preference{Channel: domain.ChannelEmail, Category: domain.CategoryOrderUpdates, UpdatedAt: &updated}, prefs[0])
+	assert.Equal(t, domain.Preference{Channel: domain.ChannelEmail, Category: domain.CategoryMarketing, Default: true}, prefs[1])
+
+	allowed, err := userService.CheckConsent(context.Background(), "user-id-123", domain.ChannelPush, domain.CategoryOrderUpdates)
+	assert.NoError(t, err)
+	assert.True(t, allowed, "order updates are pushed by default")
+	allowed, err = userService.CheckConsent(context.Background(), "user-id-123", domain.ChannelEmail, domain.CategoryOrderUpdates)
+	assert.NoError(t, err)
+	assert.False(t, allowed, "the user opted out")
+
+	_, err = userService.CheckConsent(context.Background(), "user-id-123", "fax", domain.CategoryMarketing)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestUpdateNotificationPreferencesRecordsChanges(t *testing.T) {
+	userService, prefRepo := newPreferenceService(t)
+	var recorded []domain.ConsentChange
+	prefRepo.On("SetPreferences", mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).([]domain.ConsentChange)
+	}).Return(nil)
+
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Subject: "storefront"})
+	prefs, err := userService.UpdateNotificationPreferences(ctx, "user-id-123", []domain.Preference{
+		{Channel: domain.ChannelSMS, Category: domain.CategoryMarketing, Enabled: true},
+		{Channel: domain.ChannelEmail, Category: domain.CategoryOrderUpdates, Enabled: true},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, recorded, 2, "choosing the default is consent given too") {
+		assert.Equal(t, domain.ChannelSMS, recorded[0].Channel)
+		assert.True(t, recorded[0].Enabled)
+		assert.Equal(t, "storefront", recorded[0].ChangedBy)
+	}
+	for _, pref := range prefs {
+		if pref.Channel == domain.ChannelSMS && pref.Category == domain.CategoryMarketing {
+			assert.True(t, pref.Enabled)
+			assert.False(t, pref.Default)
+		}
+	}
+
+	_, err = userService.UpdateNotificationPreferences(ctx, "user-id-123", []domain.Preference{{Channel: domain.ChannelPush, Category: "news"}})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
+func TestNotificationPreferencesUnavailable(t *testing.T) {
+	userService := NewUserService(new(MockUserRepository))
+
+	_, err := userService.CheckConsent(context.Background(), "user-id-123", domain.ChannelEmail, domain.CategoryMarketing)
+	assert.True(t, apperrors.Is(err, apperrors.Unavailable))
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// ProvisionUser creates a user on behalf of an identity provider, with the
+// name, email, roles and suspension of user. Users provisioned without a
+// password get a random one and must choose their own before signing in
+// with a password.
+func (s *UserService) ProvisionUser(ctx context.Context, user *domain.User, password string) (*domain.User, error) {
+	resetRequired := password == ""
+	if resetRequired {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate password: %w", err)
+		}
+		password = hex.EncodeToString(secret)
+	}
+
+	created, err := s.CreateUser(ctx, user.Email, user.FirstName, user.LastName, password, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if !resetRequired && !user.Suspended {
+		return created, nil
+	}
+
+	created.Suspended, created.PasswordResetRequired = user.Suspended, resetRequired
+	if err := s.repo.Update(ctx, created); err != nil {
+		return nil, fmt.Errorf("failed to update provisioned user: %w", err)
+	}
+	return created, nil
+}
+
+// ListRoles returns the roles held by at least one user, sorted
+func (s *UserService) ListRoles(ctx context.Context) ([]string, error) {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// RoleMembers returns the IDs of the users holding role, up to
+// domain.MaxBulkUsers, in creation order
+func (s *UserService) RoleMembers(ctx context.Context, role string) ([]string, error) {
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+	ids, err := s.repo.ListIDs(ctx, domain.UserFilter{Role: role}, domain.MaxBulkUsers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role members: %w", err)
+	}
+	return ids, nil
+}
+
+// SetUserRole grants role to a user, or revokes it when member is false.
+// Granting a role the user holds, or revoking one they do not, changes
+// nothing.
+func (s *UserService) SetUserRole(ctx context.Context, userID, role string, member bool) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	held := slices.Contains(user.Roles, role)
+	switch {
+	case member && !held:
+		user.Roles = append(user.Roles, role)
+	case !member && held:
+		user.Roles = slices.DeleteFunc(slices.Clone(user.Roles), func(r string) bool { return r == role })
+	default:
+		return nil
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user roles: %w", err)
+	}
+	return nil
+}
+
+// validateRole checks that a role name is not blank and has no spaces
+func validateRole(role string) error {
+	if strings.TrimSpace(role) == "" {
+		return apperrors.New(apperrors.Invalid, "role is required")
+	}
+	if strings.ContainsAny(role, " \t\r\n") {
+		return apperrors.Newf(apperrors.Invalid, "role %q must not contain spaces", role)
+	}
+	return nil
+}
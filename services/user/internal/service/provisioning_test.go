@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestProvisionUser(t *testing.T) {
+	t.Run("without a password", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByEmail", "ann@example.com").Return(nil, errors.New("not found"))
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := NewUserService(mockRepo).ProvisionUser(context.Background(), &domain.User{
+			Email:     "ann@example.com",
+			FirstName: "Ann",
+			LastName:  "Lee",
+			Suspended: true,
+		}, "")
+		require.NoError(t, err)
+		assert.True(t, user.PasswordResetRequired, "a random password must be replaced")
+		assert.True(t, user.Suspended)
+		assert.NotEmpty(t, user.PasswordHash)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("with a password", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByEmail", "ann@example.com").Return(nil, errors.New("not found"))
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := NewUserService(mockRepo).ProvisionUser(context.Background(), &domain.User{
+			Email:     "ann@example.com",
+			FirstName: "Ann",
+			LastName:  "Lee",
+		}, "correct-horse")
+		require.NoError(t, err)
+		assert.False(t, user.PasswordResetRequired)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("correct-horse")))
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestSetUserRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").WithRoles("customer").Build(), nil)
+	var updated [][]string
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		updated = append(updated, slices.Clone(args.Get(0).(*domain.User).Roles))
+	}).Return(nil)
+	userService := NewUserService(mockRepo)
+
+	require.NoError(t, userService.SetUserRole(context.Background(), "user-id-123", "support", true))
+	require.NoError(t, userService.SetUserRole(context.Background(), "user-id-123", "customer", true))
+	require.NoError(t, userService.SetUserRole(context.Background(), "user-id-123", "customer", false))
+	require.NoError(t, userService.SetUserRole(context.Background(), "user-id-123", "admin", false))
+	assert.Equal(t, [][]string{{"customer", "support"}, {"support"}}, updated, "unchanged roles are not written")
+
+	err := userService.SetUserRole(context.Background(), "user-id-123", "help desk", true)
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// rewrapBatchSize is how many users RewrapPhones reads at a time
+const rewrapBatchSize = 500
+
+// Rewrapper re-wraps the data keys of encrypted fields with the current
+// master key; fieldcrypt.Encryptor implements it
+type Rewrapper interface {
+	NeedsRewrap(ciphertext string) bool
+	Rewrap(ctx context.Context, ciphertext string) (string, error)
+}
+
+// SetRewrapper enables RewrapPhones with the encryptor of the repository
+func (s *UserService) SetRewrapper(crypt Rewrapper) {
+	s.crypt = crypt
+}
+
+// RewrapPhones re-wraps the stored phone numbers whose data key is wrapped
+// by an older master key, so that the key can be retired after a rotation.
+// It pages through the users in ID order and returns how many it rewrote.
+// A phone updated while the pass runs is skipped, as the update already
+// encrypted it with the current key.
+func (s *UserService) RewrapPhones(ctx context.Context) (int, error) {
+	if s.crypt == nil {
+		return 0, nil
+	}
+
+	rewrapped := 0
+	after := ""
+	for {
+		values, err := s.repo.ListEncryptedPhones(ctx, after, rewrapBatchSize)
+		if err != nil {
+			return rewrapped, err
+		}
+		for _, value := range values {
+			after = value.UserID
+			if !s.crypt.NeedsRewrap(value.Ciphertext) {
+				continue
+			}
+			ciphertext, err := s.crypt.Rewrap(ctx, value.Ciphertext)
+			if err != nil {
+				return rewrapped, fmt.Errorf("failed to rewrap phone of user %s: %w", value.UserID, err)
+			}
+			replaced, err := s.repo.ReplaceEncryptedPhone(ctx, value.UserID, value.Ciphertext, ciphertext)
+			if err != nil {
+				return rewrapped, err
+			}
+			if replaced {
+				rewrapped++
+			}
+		}
+		if len(values) < rewrapBatchSize {
+			break
+		}
+	}
+
+	if rewrapped > 0 {
+		s.logger.Info("Re-wrapped phone numbers", "users", rewrapped)
+	}
+	return rewrapped, nil
+}
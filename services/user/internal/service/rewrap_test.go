@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/fieldcrypt"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewrapPhones(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	before, err := fieldcrypt.NewLocalKeyring("k1", map[string][]byte{"k1": oldKey})
+	require.NoError(t, err)
+	rotated, err := fieldcrypt.NewLocalKeyring("k2", map[string][]byte{"k1": oldKey, "k2": newKey})
+	require.NoError(t, err)
+	old, current := fieldcrypt.New(before), fieldcrypt.New(rotated)
+
+	seal := func(crypt *fieldcrypt.Encryptor, userID string) string {
+		ciphertext, err := crypt.Encrypt(ctx, "+15550100", userID)
+		require.NoError(t, err)
+		return ciphertext
+	}
+	u1, u2, u3 := seal(old, "u1"), seal(current, "u2"), seal(old, "u3")
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("ListEncryptedPhones", "", rewrapBatchSize).Return([]domain.EncryptedValue{
+		{UserID: "u1", Ciphertext: u1},
+		{UserID: "u2", Ciphertext: u2},
+		{UserID: "u3", Ciphertext: u3},
+	}, nil)
+	var rewrapped string
+	mockRepo.On("ReplaceEncryptedPhone", "u1", u1, mock.Anything).Run(func(args mock.Arguments) {
+		rewrapped = args.String(2)
+	}).Return(true, nil)
+	// u3 changed its phone while the pass ran
+	mockRepo.On("ReplaceEncryptedPhone", "u3", u3, mock.Anything).Return(false, nil)
+
+	userService := NewUserService(mockRepo)
+	userService.SetRewrapper(current)
+	count, err := userService.RewrapPhones(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockRepo.AssertNotCalled(t, "ReplaceEncryptedPhone", "u2", mock.Anything, mock.Anything)
+	keyID, err := fieldcrypt.KeyID(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keyID)
+
+	// The old key can be retired
+	retired, err := fieldcrypt.NewLocalKeyring("k2", map[string][]byte{"k2": newKey})
+	require.NoError(t, err)
+	phone, err := fieldcrypt.New(retired).Decrypt(ctx, rewrapped, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, "+15550100", phone)
+}
+
+func TestRewrapPhonesPages(t *testing.T) {
+	page := make([]domain.EncryptedValue, rewrapBatchSize)
+	for i := range page {
+		page[i] = domain.EncryptedValue{UserID: fmt.Sprintf("u%03d", i+1), Ciphertext: "enc:v1:k2:a:b"}
+	}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("ListEncryptedPhones", "", rewrapBatchSize).Return(page, nil)
+	mockRepo.On("ListEncryptedPhones", "u500", rewrapBatchSize).Return([]domain.EncryptedValue{}, nil)
+	keyring, err := fieldcrypt.NewLocalKeyring("k2", map[string][]byte{"k2": bytes.Repeat([]byte{2}, 32)})
+	require.NoError(t, err)
+
+	userService := NewUserService(mockRepo)
+	userService.SetRewrapper(fieldcrypt.New(keyring))
+	count, err := userService.RewrapPhones(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	mockRepo.AssertExpectations(t)
+}
@@ -1,49 +1,77 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/events"
+	"github.com/bekbull/online-shop/pkg/objectstore"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/user/internal/domain"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService implements the UserService interface
 type UserService struct {
-	repo domain.UserRepository
+	repo      domain.UserRepository
+	prefs     domain.PreferenceRepository
+	logins    domain.LoginRepository
+	stepUp    bool
+	jobs      domain.BulkJobRepository
+	bulk      sync.WaitGroup
+	audit     domain.AuditRepository
+	retention time.Duration
+	archive   objectstore.Store
+	// archivePrefix is prepended to the keys of audit archives
+	archivePrefix string
+	publisher     eventbus.Publisher
+	crypt         Rewrapper
+	logger        *slog.Logger
 }
 
 // NewUserService creates a new user service
 func NewUserService(repo domain.UserRepository) *UserService {
 	return &UserService{
-		repo: repo,
+		repo:      repo,
+		publisher: eventbus.NoopPublisher{},
+		logger:    slog.Default(),
 	}
 }
 
+// SetPublisher configures where user events are published
+func (s *UserService) SetPublisher(publisher eventbus.Publisher) {
+	s.publisher = publisher
+}
+
 // CreateUser creates a new user
-func (s *UserService) CreateUser(email, firstName, lastName, password string, roles []string) (*domain.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, email, firstName, lastName, password string, roles []string) (*domain.User, error) {
 	// Validate input
 	if email == "" {
-		return nil, errors.New("email is required")
+		return nil, apperrors.New(apperrors.Invalid, "email is required")
 	}
 	if firstName == "" {
-		return nil, errors.New("first name is required")
+		return nil, apperrors.New(apperrors.Invalid, "first name is required")
 	}
 	if lastName == "" {
-		return nil, errors.New("last name is required")
+		return nil, apperrors.New(apperrors.Invalid, "last name is required")
 	}
 	if password == "" {
-		return nil, errors.New("password is required")
+		return nil, apperrors.New(apperrors.Invalid, "password is required")
 	}
-	if len(password) < 8 {
-		return nil, errors.New("password must be at least 8 characters")
+	if err := validatePassword(password); err != nil {
+		return nil, err
 	}
 
 	// Check if user already exists
-	existingUser, err := s.repo.GetByEmail(email)
+	existingUser, err := s.repo.GetByEmail(ctx, email)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", email)
+		return nil, apperrors.Newf(apperrors.Conflict, "user with email %s already exists", email)
 	}
 
 	// Hash password
@@ -56,7 +84,7 @@ func (s *UserService) CreateUser(email, firstName, lastName, password string, ro
 	user := domain.NewUser(email, firstName, lastName, string(hashedPassword), roles)
 
 	// Save to repository
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -64,12 +92,12 @@ func (s *UserService) CreateUser(email, firstName, lastName, password string, ro
 }
 
 // GetUser retrieves a user by ID
-func (s *UserService) GetUser(id string) (*domain.User, error) {
+func (s *UserService) GetUser(ctx context.Context, id string) (*domain.User, error) {
 	if id == "" {
-		return nil, errors.New("user ID is required")
+		return nil, apperrors.New(apperrors.Invalid, "user ID is required")
 	}
 
-	user, err := s.repo.GetByID(id)
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -78,12 +106,12 @@ func (s *UserService) GetUser(id string) (*domain.User, error) {
 }
 
 // GetUserByEmail retrieves a user by email
-func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	if email == "" {
-		return nil, errors.New("email is required")
+		return nil, apperrors.New(apperrors.Invalid, "email is required")
 	}
 
-	user, err := s.repo.GetByEmail(email)
+	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -92,13 +120,13 @@ func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
 }
 
 // UpdateUser updates a user's details
-func (s *UserService) UpdateUser(id string, updates map[string]interface{}) (*domain.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, id string, updates map[string]interface{}) (*domain.User, error) {
 	if id == "" {
-		return nil, errors.New("user ID is required")
+		return nil, apperrors.New(apperrors.Invalid, "user ID is required")
 	}
 
 	// Get existing user
-	user, err := s.repo.GetByID(id)
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user for update: %w", err)
 	}
@@ -109,12 +137,27 @@ func (s *UserService) UpdateUser(id string, updates map[string]interface{}) (*do
 		case "email":
 			if email, ok := value.(string); ok && email != "" {
 				// Check if email is already taken by another user
-				existingUser, err := s.repo.GetByEmail(email)
+				existingUser, err := s.repo.GetByEmail(ctx, email)
 				if err == nil && existingUser != nil && existingUser.ID != id {
-					return nil, fmt.Errorf("email %s is already taken", email)
+					return nil, apperrors.Newf(apperrors.Conflict, "email %s is already taken", email)
 				}
 				user.Email = email
 			}
+		case "handle":
+			// An empty handle clears it
+			if handle, ok := value.(string); ok {
+				handle = normalizeHandle(handle)
+				if handle != "" {
+					if err := validateHandle(handle); err != nil {
+						return nil, err
+					}
+					existingUser, err := s.repo.GetByHandle(ctx, handle)
+					if err == nil && existingUser != nil && existingUser.ID != id {
+						return nil, apperrors.Newf(apperrors.Conflict, "handle %s is taken", handle)
+					}
+				}
+				user.Handle = handle
+			}
 		case "first_name":
 			if firstName, ok := value.(string); ok && firstName != "" {
 				user.FirstName = firstName
@@ -125,19 +168,37 @@ func (s *UserService) UpdateUser(id string, updates map[string]interface{}) (*do
 			}
 		case "password":
 			if password, ok := value.(string); ok && password != "" {
-				if len(password) < 8 {
-					return nil, errors.New("password must be at least 8 characters")
+				if err := validatePassword(password); err != nil {
+					return nil, err
 				}
 				hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 				if err != nil {
 					return nil, fmt.Errorf("failed to hash password: %w", err)
 				}
 				user.PasswordHash = string(hashedPassword)
+				user.PasswordResetRequired = false
 			}
 		case "roles":
 			if roles, ok := value.([]string); ok {
 				user.Roles = roles
 			}
+		case "customer_group":
+			if group, ok := value.(string); ok && group != "" {
+				group = strings.ToLower(group)
+				if !domain.IsCustomerGroup(group) {
+					return nil, apperrors.Newf(apperrors.Invalid, "unknown customer group %q", group)
+				}
+				user.Group = group
+			}
+		case "phone":
+			// An empty phone number clears it
+			if phone, ok := value.(string); ok {
+				user.Phone = phone
+			}
+		case "suspended":
+			if suspended, ok := value.(bool); ok {
+				user.Suspended = suspended
+			}
 		}
 	}
 
@@ -145,7 +206,7 @@ func (s *UserService) UpdateUser(id string, updates map[string]interface{}) (*do
 	user.UpdatedAt = time.Now()
 
 	// Save to repository
-	if err := s.repo.Update(user); err != nil {
+	if err := s.repo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
@@ -153,12 +214,12 @@ func (s *UserService) UpdateUser(id string, updates map[string]interface{}) (*do
 }
 
 // DeleteUser deletes a user by ID
-func (s *UserService) DeleteUser(id string) error {
+func (s *UserService) DeleteUser(ctx context.Context, id string) error {
 	if id == "" {
-		return errors.New("user ID is required")
+		return apperrors.New(apperrors.Invalid, "user ID is required")
 	}
 
-	if err := s.repo.Delete(id); err != nil {
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
@@ -166,8 +227,8 @@ func (s *UserService) DeleteUser(id string) error {
 }
 
 // ListUsers retrieves a list of users with pagination and optional filtering
-func (s *UserService) ListUsers(page, pageSize int, emailFilter string) ([]*domain.User, int, error) {
-	users, total, err := s.repo.List(page, pageSize, emailFilter)
+func (s *UserService) ListUsers(ctx context.Context, page pagination.Request, emailFilter string) ([]*domain.User, int, error) {
+	users, total, err := s.repo.List(ctx, page, emailFilter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -175,8 +236,32 @@ func (s *UserService) ListUsers(page, pageSize int, emailFilter string) ([]*doma
 	return users, total, nil
 }
 
+// validatePassword checks the length of a new password. bcrypt only
+// hashes the first 72 bytes and refuses longer passwords.
+func validatePassword(password string) error {
+	if len(password) < 8 {
+		return apperrors.New(apperrors.Invalid, "password must be at least 8 characters")
+	}
+	if len(password) > 72 {
+		return apperrors.New(apperrors.Invalid, "password must be at most 72 bytes")
+	}
+	return nil
+}
+
 // VerifyPassword checks if the provided password matches the stored hash
 func (s *UserService) VerifyPassword(user *domain.User, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	return err == nil
 }
+
+func (s *UserService) publish(ctx context.Context, eventType, key string, payload interface{}) {
+	event, err := events.New(ctx, eventType, "user-service", key, payload)
+	if err != nil {
+		s.logger.Error("Failed to build event", "type", eventType, "error", err)
+		return
+	}
+
+	if err := s.publisher.Publish(context.Background(), event); err != nil {
+		s.logger.Error("Failed to publish event", "type", eventType, "key", key, "error", err)
+	}
+}
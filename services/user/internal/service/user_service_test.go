@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
-	"time"
 
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/pagination"
 	"github.com/bekbull/online-shop/services/user/internal/domain"
+	"github.com/bekbull/online-shop/services/user/internal/testutil/builders"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -16,12 +20,12 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *domain.User) error {
+func (m *MockUserRepository) Create(_ context.Context, user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByID(id string) (*domain.User, error) {
+func (m *MockUserRepository) GetByID(_ context.Context, id string) (*domain.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -29,7 +33,7 @@ func (m *MockUserRepository) GetByID(id string) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (m *MockUserRepository) GetByEmail(_ context.Context, email string) (*domain.User, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -37,21 +41,54 @@ func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *domain.User) error {
+func (m *MockUserRepository) GetByHandle(_ context.Context, handle string) (*domain.User, error) {
+	args := m.Called(handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) HandlesTaken(_ context.Context, handles []string) ([]string, error) {
+	args := m.Called(handles)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) ListEncryptedPhones(_ context.Context, after string, limit int) ([]domain.EncryptedValue, error) {
+	args := m.Called(after, limit)
+	return args.Get(0).([]domain.EncryptedValue), args.Error(1)
+}
+
+func (m *MockUserRepository) ReplaceEncryptedPhone(_ context.Context, userID, old, ciphertext string) (bool, error) {
+	args := m.Called(userID, old, ciphertext)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(_ context.Context, user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id string) error {
+func (m *MockUserRepository) Delete(_ context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) List(page, pageSize int, emailFilter string) ([]*domain.User, int, error) {
-	args := m.Called(page, pageSize, emailFilter)
+func (m *MockUserRepository) List(_ context.Context, page pagination.Request, emailFilter string) ([]*domain.User, int, error) {
+	args := m.Called(page, emailFilter)
 	return args.Get(0).([]*domain.User), args.Int(1), args.Error(2)
 }
 
+func (m *MockUserRepository) ListIDs(_ context.Context, filter domain.UserFilter, limit int) ([]string, error) {
+	args := m.Called(filter, limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) ListRoles(_ context.Context) ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func TestCreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	userService := NewUserService(mockRepo)
@@ -61,7 +98,7 @@ func TestCreateUser(t *testing.T) {
 		mockRepo.On("GetByEmail", "test@example.com").Return(nil, errors.New("not found"))
 		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
 
-		user, err := userService.CreateUser("test@example.com", "Test", "User", "password123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "Test", "User", "password123", []string{"user"})
 
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
@@ -75,17 +112,10 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: User with email already exists
 	t.Run("Email already exists", func(t *testing.T) {
-		existingUser := &domain.User{
-			ID:        "1",
-			Email:     "existing@example.com",
-			FirstName: "Existing",
-			LastName:  "User",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+		existingUser := builders.NewUser(t).WithEmail("existing@example.com").Build()
 		mockRepo.On("GetByEmail", "existing@example.com").Return(existingUser, nil)
 
-		user, err := userService.CreateUser("existing@example.com", "Test", "User", "password123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "existing@example.com", "Test", "User", "password123", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -95,7 +125,7 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: Invalid input - empty email
 	t.Run("Empty email", func(t *testing.T) {
-		user, err := userService.CreateUser("", "Test", "User", "password123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "", "Test", "User", "password123", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -104,7 +134,7 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: Invalid input - empty first name
 	t.Run("Empty first name", func(t *testing.T) {
-		user, err := userService.CreateUser("test@example.com", "", "User", "password123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "", "User", "password123", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -113,7 +143,7 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: Invalid input - empty last name
 	t.Run("Empty last name", func(t *testing.T) {
-		user, err := userService.CreateUser("test@example.com", "Test", "", "password123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "Test", "", "password123", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -122,7 +152,7 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: Invalid input - empty password
 	t.Run("Empty password", func(t *testing.T) {
-		user, err := userService.CreateUser("test@example.com", "Test", "User", "", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "Test", "User", "", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -131,12 +161,21 @@ func TestCreateUser(t *testing.T) {
 
 	// Test case: Invalid input - password too short
 	t.Run("Password too short", func(t *testing.T) {
-		user, err := userService.CreateUser("test@example.com", "Test", "User", "123", []string{"user"})
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "Test", "User", "123", []string{"user"})
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
 		assert.Contains(t, err.Error(), "password must be at least 8 characters")
 	})
+
+	// Test case: Invalid input - password too long for bcrypt
+	t.Run("Password too long", func(t *testing.T) {
+		user, err := userService.CreateUser(context.Background(), "test@example.com", "Test", "User", strings.Repeat("p", 73), []string{"user"})
+
+		assert.Error(t, err)
+		assert.Nil(t, user)
+		assert.Equal(t, apperrors.Invalid, apperrors.KindOf(err))
+	})
 }
 
 func TestGetUser(t *testing.T) {
@@ -145,17 +184,10 @@ func TestGetUser(t *testing.T) {
 
 	// Test case: Successful user retrieval
 	t.Run("Successful retrieval", func(t *testing.T) {
-		mockUser := &domain.User{
-			ID:        "user-id-123",
-			Email:     "test@example.com",
-			FirstName: "Test",
-			LastName:  "User",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+		mockUser := builders.NewUser(t).WithID("user-id-123").WithEmail("test@example.com").Build()
 		mockRepo.On("GetByID", "user-id-123").Return(mockUser, nil)
 
-		user, err := userService.GetUser("user-id-123")
+		user, err := userService.GetUser(context.Background(), "user-id-123")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
@@ -168,7 +200,7 @@ func TestGetUser(t *testing.T) {
 	t.Run("User not found", func(t *testing.T) {
 		mockRepo.On("GetByID", "non-existent").Return(nil, errors.New("user not found"))
 
-		user, err := userService.GetUser("non-existent")
+		user, err := userService.GetUser(context.Background(), "non-existent")
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -178,7 +210,7 @@ func TestGetUser(t *testing.T) {
 
 	// Test case: Empty ID
 	t.Run("Empty ID", func(t *testing.T) {
-		user, err := userService.GetUser("")
+		user, err := userService.GetUser(context.Background(), "")
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -186,15 +218,43 @@ func TestGetUser(t *testing.T) {
 	})
 }
 
+func TestUpdateUserPhone(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").WithPhone("+1 555 0100").Build(), nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	user, err := userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"phone": "+1 555 0199"})
+	assert.NoError(t, err)
+	assert.Equal(t, "+1 555 0199", user.Phone)
+
+	user, err = userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"phone": ""})
+	assert.NoError(t, err)
+	assert.Empty(t, user.Phone, "an empty phone number clears it")
+}
+
+func TestUpdateUserCustomerGroup(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+
+	mockRepo.On("GetByID", "user-id-123").Return(builders.NewUser(t).WithID("user-id-123").Build(), nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	user, err := userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"customer_group": "Wholesale"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CustomerGroupWholesale, user.Group)
+
+	_, err = userService.UpdateUser(context.Background(), "user-id-123", map[string]interface{}{"customer_group": "gold"})
+	assert.True(t, apperrors.Is(err, apperrors.Invalid))
+}
+
 func TestVerifyPassword(t *testing.T) {
 	userService := NewUserService(nil) // Repository not needed for this test
 
 	// Create a user with a known password hash
 	passwordHash, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
-	user := &domain.User{
-		ID:           "user-id",
-		PasswordHash: string(passwordHash),
-	}
+	user := builders.NewUser(t).WithPasswordHash(string(passwordHash)).Build()
 
 	// Test case: Correct password
 	t.Run("Correct password", func(t *testing.T) {
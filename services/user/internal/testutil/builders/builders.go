@@ -0,0 +1,85 @@
+// Package builders builds users for tests, filled with values that are
+// reproducible per test. Set only what the test is about:
+//
+//	admin := builders.NewUser(t).WithRoles("admin").Build()
+package builders
+
+import (
+	"testing"
+
+	"github.com/bekbull/online-shop/pkg/testutil/builders"
+	"github.com/bekbull/online-shop/services/user/internal/domain"
+)
+
+// UserBuilder builds a domain.User
+type UserBuilder struct {
+	user domain.User
+}
+
+// NewUser returns a builder for a customer
+func NewUser(t testing.TB) *UserBuilder {
+	fill := builders.For(t)
+	firstName, lastName := fill.FirstName(), fill.LastName()
+	created := fill.Time()
+	return &UserBuilder{user: domain.User{
+		ID:           fill.UUID(),
+		Email:        fill.Email(firstName, lastName),
+		FirstName:    firstName,
+		LastName:     lastName,
+		PasswordHash: "$2a$10$not-a-real-hash",
+		Roles:        []string{"customer"},
+		Group:        domain.CustomerGroupRetail,
+		Phone:        fill.Phone(),
+		CreatedAt:    created,
+		UpdatedAt:    created,
+	}}
+}
+
+// WithID sets the user ID
+func (b *UserBuilder) WithID(id string) *UserBuilder {
+	b.user.ID = id
+	return b
+}
+
+// WithEmail sets the email address
+func (b *UserBuilder) WithEmail(email string) *UserBuilder {
+	b.user.Email = email
+	return b
+}
+
+// WithName sets the first and last name
+func (b *UserBuilder) WithName(firstName, lastName string) *UserBuilder {
+	b.user.FirstName, b.user.LastName = firstName, lastName
+	return b
+}
+
+// WithPasswordHash sets the password hash
+func (b *UserBuilder) WithPasswordHash(hash string) *UserBuilder {
+	b.user.PasswordHash = hash
+	return b
+}
+
+// WithRoles sets the roles
+func (b *UserBuilder) WithRoles(roles ...string) *UserBuilder {
+	b.user.Roles = roles
+	return b
+}
+
+// WithGroup sets the customer group
+func (b *UserBuilder) WithGroup(group string) *UserBuilder {
+	b.user.Group = group
+	return b
+}
+
+// WithPhone sets the phone number
+func (b *UserBuilder) WithPhone(phone string) *UserBuilder {
+	b.user.Phone = phone
+	return b
+}
+
+// Build returns the user. Each call returns a new copy.
+func (b *UserBuilder) Build() *domain.User {
+	user := b.user
+	user.Roles = append([]string(nil), b.user.Roles...)
+	return &user
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/pkg/logging"
+	"github.com/bekbull/online-shop/pkg/middleware"
+	"github.com/bekbull/online-shop/services/webhooks/config"
+	restHandler "github.com/bekbull/online-shop/services/webhooks/internal/api/rest"
+	"github.com/bekbull/online-shop/services/webhooks/internal/dispatcher"
+	"github.com/bekbull/online-shop/services/webhooks/internal/repository/mongodb"
+	"github.com/bekbull/online-shop/services/webhooks/internal/service"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	// Initialize logger
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	logger.Info("Starting Webhook Dispatcher")
+
+	// Load configuration
+	cfg := config.Load()
+	logger.Info("Configuration loaded")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Connect to MongoDB
+	mongoClient, err := connectToMongoDB(cfg.MongoDB)
+	if err != nil {
+		logger.Error("Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error("Failed to disconnect from MongoDB", "error", err)
+		}
+	}()
+	logger.Info("Connected to MongoDB")
+
+	subscriptionRepo := mongodb.NewSubscriptionRepository(mongoClient, &cfg.MongoDB)
+	deliveryRepo := mongodb.NewDeliveryRepository(mongoClient, &cfg.MongoDB)
+	indexCtx, cancelIndex := context.WithTimeout(ctx, cfg.MongoDB.WriteTimeout)
+	if err := deliveryRepo.EnsureIndexes(indexCtx); err != nil {
+		cancelIndex()
+		logger.Error("Failed to create indexes", "error", err)
+		os.Exit(1)
+	}
+	cancelIndex()
+
+	webhookService := service.New(subscriptionRepo, deliveryRepo, cfg.Events.EventTypes, logger)
+	if cfg.Dispatcher.AllowInsecureTargets {
+		logger.Warn("Webhooks may be sent over http and to private addresses, do not use outside development")
+		webhookService.SetAllowInsecureTargets(true)
+	}
+
+	// Turn events into deliveries
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Events.RedisAddr,
+		Password: cfg.Events.RedisPassword,
+		DB:       cfg.Events.RedisDB,
+	})
+	defer redisClient.Close()
+
+	bus := eventbus.NewRedisBus(redisClient, eventbus.RedisConfig{StreamPrefix: cfg.Events.StreamPrefix}, logger)
+	go func() {
+		logger.Info("Consuming events", "group", cfg.Events.ConsumerGroup, "types", cfg.Events.EventTypes)
+		if err := bus.Subscribe(ctx, cfg.Events.ConsumerGroup, cfg.Events.EventTypes, webhookService.HandleEvent); err != nil {
+			logger.Error("Event consumer failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Send deliveries
+	d := dispatcher.New(subscriptionRepo, deliveryRepo, dispatcher.Options{
+		PollInterval:        cfg.Dispatcher.PollInterval,
+		BatchSize:           cfg.Dispatcher.BatchSize,
+		Lease:               cfg.Dispatcher.Lease,
+		Timeout:             cfg.Dispatcher.Timeout,
+		MaxAttempts:         cfg.Dispatcher.MaxAttempts,
+		BaseBackoff:         cfg.Dispatcher.BaseBackoff,
+		MaxBackoff:          cfg.Dispatcher.MaxBackoff,
+		AllowPrivateTargets: cfg.Dispatcher.AllowInsecureTargets,
+	}, logger)
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		d.Run(ctx)
+	}()
+
+	// Setup HTTP server
+	router := chi.NewRouter()
+	router.Use(chimiddleware.RequestID)
+	router.Use(chimiddleware.RealIP)
+	router.Use(logging.AccessLog(logger, logging.DefaultAccessLog))
+	router.Use(middleware.Recover(logger))
+	router.Use(chimiddleware.Timeout(30 * time.Second))
+
+	restHandler.NewWebhookHandler(webhookService, logger).RegisterRoutes(router)
+
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
+		Handler: router,
+	}
+
+	go func() {
+		logger.Info("Starting HTTP server", "port", cfg.HTTPPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down webhook dispatcher")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown failed", "error", err)
+	}
+
+	// In-flight attempts are cancelled with ctx; their leases expire and
+	// they are retried by the next instance
+	select {
+	case <-dispatched:
+	case <-shutdownCtx.Done():
+	}
+
+	logger.Info("Shutdown completed")
+}
+
+func connectToMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnTimeout)
+	defer cancel()
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize)
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ping the database to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
@@ -0,0 +1,204 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/services/webhooks/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// WebhookService defines the interface for the webhook service
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, owner, endpoint string, eventTypes []string) (*domain.Subscription, error)
+	GetSubscription(ctx context.Context, id string) (*domain.Subscription, error)
+	ListSubscriptions(ctx context.Context, owner string) ([]*domain.Subscription, error)
+	SetSubscriptionActive(ctx context.Context, id string, active bool) (*domain.Subscription, error)
+	RotateSecret(ctx context.Context, id string) (*domain.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]*domain.Delivery, error)
+	GetDelivery(ctx context.Context, id string) (*domain.Delivery, error)
+	Redeliver(ctx context.Context, id string) (*domain.Delivery, error)
+}
+
+// WebhookHandler handles HTTP requests for subscriptions and deliveries
+type WebhookHandler struct {
+	service WebhookService
+	logger  *slog.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the webhook routes with the given router
+func (h *WebhookHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/v1/webhooks", func(r chi.Router) {
+		r.Post("/subscriptions", h.CreateSubscription)
+		r.Get("/subscriptions", h.ListSubscriptions)
+		r.Get("/subscriptions/{id}", h.GetSubscription)
+		r.Post("/subscriptions/{id}/activate", h.setSubscriptionActive(true))
+		r.Post("/subscriptions/{id}/deactivate", h.setSubscriptionActive(false))
+		r.Post("/subscriptions/{id}/rotate-secret", h.RotateSecret)
+		r.Delete("/subscriptions/{id}", h.DeleteSubscription)
+
+		r.Get("/deliveries", h.ListDeliveries)
+		r.Get("/deliveries/{id}", h.GetDelivery)
+		r.Post("/deliveries/{id}/redeliver", h.Redeliver)
+	})
+}
+
+// subscriptionWithSecret exposes the signing secret, which is otherwise
+// never serialised
+type subscriptionWithSecret struct {
+	*domain.Subscription
+	Secret string `json:"secret"`
+}
+
+// deliveryWithPayload includes the payload as sent, for inspection
+type deliveryWithPayload struct {
+	*domain.Delivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+// CreateSubscription handles POST /v1/webhooks/subscriptions
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Owner      string   `json:"owner"`
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperrors.WriteHTTP(w, r, apperrors.New(apperrors.Invalid, "invalid request body"))
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), req.Owner, req.URL, req.EventTypes)
+	if err != nil {
+		h.writeError(w, r, "Failed to create subscription", err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, subscriptionWithSecret{Subscription: sub, Secret: sub.Secret})
+}
+
+// ListSubscriptions handles GET /v1/webhooks/subscriptions?owner=
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context(), r.URL.Query().Get("owner"))
+	if err != nil {
+		h.writeError(w, r, "Failed to list subscriptions", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"subscriptions": subs})
+}
+
+// GetSubscription handles GET /v1/webhooks/subscriptions/{id}
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.service.GetSubscription(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, "Failed to get subscription", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, sub)
+}
+
+// setSubscriptionActive handles POST /v1/webhooks/subscriptions/{id}/activate and /deactivate
+func (h *WebhookHandler) setSubscriptionActive(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sub, err := h.service.SetSubscriptionActive(r.Context(), chi.URLParam(r, "id"), active)
+		if err != nil {
+			h.writeError(w, r, "Failed to update subscription", err)
+			return
+		}
+		h.writeJSON(w, http.StatusOK, sub)
+	}
+}
+
+// RotateSecret handles POST /v1/webhooks/subscriptions/{id}/rotate-secret
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.service.RotateSecret(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, "Failed to rotate secret", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, subscriptionWithSecret{Subscription: sub, Secret: sub.Secret})
+}
+
+// DeleteSubscription handles DELETE /v1/webhooks/subscriptions/{id}
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteSubscription(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, r, "Failed to delete subscription", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /v1/webhooks/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	deliveries, err := h.service.ListDeliveries(r.Context(), domain.DeliveryFilter{
+		SubscriptionID: query.Get("subscription_id"),
+		EventID:        query.Get("event_id"),
+		Status:         query.Get("status"),
+		Limit:          parseInt(query.Get("limit"), 100),
+	})
+	if err != nil {
+		h.writeError(w, r, "Failed to list deliveries", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// GetDelivery handles GET /v1/webhooks/deliveries/{id}
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.GetDelivery(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, "Failed to get delivery", err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, deliveryWithPayload{Delivery: delivery, Payload: delivery.Payload})
+}
+
+// Redeliver handles POST /v1/webhooks/deliveries/{id}/redeliver
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.Redeliver(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, "Failed to redeliver", err)
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, delivery)
+}
+
+// Helper functions
+
+func (h *WebhookHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	h.logger.Error(msg, "error", err)
+	apperrors.WriteHTTP(w, r, err)
+}
+
+func (h *WebhookHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// Helper function to parse int parameters with default value
+func parseInt(value string, defaultValue int) int {
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return intValue
+}
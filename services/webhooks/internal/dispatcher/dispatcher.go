@@ -0,0 +1,296 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bekbull/online-shop/services/webhooks/internal/domain"
+)
+
+// Headers set on every webhook request
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Options tunes delivery attempts and retries
+type Options struct {
+	PollInterval time.Duration // How often due deliveries are claimed
+	BatchSize    int           // Deliveries claimed per poll
+	Lease        time.Duration // How long a claimed delivery is hidden from other workers
+	Timeout      time.Duration // HTTP timeout of a single attempt
+	MaxAttempts  int           // Attempts before a delivery is dead-lettered
+	BaseBackoff  time.Duration // Delay after the first failure, doubled on each retry
+	MaxBackoff   time.Duration
+	// AllowPrivateTargets lets deliveries reach loopback, private and
+	// link-local addresses. Only meant for local development.
+	AllowPrivateTargets bool
+}
+
+// ErrPrivateTarget is returned when an endpoint resolves to an address
+// that webhooks may not be sent to
+var ErrPrivateTarget = errors.New("destination address is not public")
+
+// Dispatcher sends queued deliveries to subscriber endpoints
+type Dispatcher struct {
+	subscriptions domain.SubscriptionRepository
+	deliveries    domain.DeliveryRepository
+	client        *http.Client
+	opts          Options
+	logger        *slog.Logger
+	now           func() time.Time
+	jitter        func(time.Duration) time.Duration
+}
+
+// New creates a new Dispatcher
+func New(subscriptions domain.SubscriptionRepository, deliveries domain.DeliveryRepository, opts Options, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: newTransport(opts.AllowPrivateTargets),
+			// Redirects are not followed so a signed payload is only ever
+			// sent to the registered URL
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		opts:   opts,
+		logger: logger,
+		now:    time.Now,
+		jitter: func(d time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(d)/2 + 1))
+		},
+	}
+}
+
+// newTransport returns the transport deliveries are sent with. Unless
+// allowPrivate is set, the address is checked when the connection is made,
+// after DNS resolution, so a hostname cannot be pointed at an internal
+// service after the subscription was registered.
+func newTransport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return transport
+	}
+	// A proxy would hide the endpoint's address from the check
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   refusePrivate,
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// refusePrivate is a net.Dialer Control func that rejects connections to
+// addresses that are not public
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, address)
+	}
+	if !domain.IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, addrPort.Addr())
+	}
+	return nil
+}
+
+// Run dispatches due deliveries until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			n, err := d.DispatchDue(ctx)
+			if err != nil {
+				d.logger.Error("Failed to dispatch deliveries", "error", err)
+			}
+			if err != nil || n < d.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue claims one batch of due deliveries and attempts them
+// concurrently, so a batch finishes within one timeout and well inside the
+// lease. It returns the number of deliveries attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	claimed, err := d.deliveries.ClaimDue(ctx, d.now().UTC(), d.opts.Lease, d.opts.BatchSize)
+
+	var wg sync.WaitGroup
+	for _, delivery := range claimed {
+		wg.Add(1)
+		go func(delivery *domain.Delivery) {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return len(claimed), fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	return len(claimed), nil
+}
+
+// attempt sends a delivery once and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery *domain.Delivery) {
+	started := d.now().UTC()
+	attempt := domain.Attempt{At: started}
+	status := domain.DeliveryPending
+	next := started
+
+	sub, err := d.subscriptions.GetByID(ctx, delivery.SubscriptionID.Hex())
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		attempt.Error = "subscription deleted"
+		status = domain.DeliveryDead
+	case err != nil:
+		// Storage trouble is not the endpoint's fault; the lease expires and
+		// the delivery is retried without spending an attempt
+		d.logger.Error("Failed to load subscription", "subscriptionID", delivery.SubscriptionID.Hex(), "error", err)
+		return
+	case !sub.Active:
+		attempt.Error = "subscription inactive"
+		status = domain.DeliveryDead
+	default:
+		attempt.StatusCode, err = d.send(ctx, sub, delivery, started)
+		attempt.Duration = d.now().Sub(started)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		switch {
+		case err == nil:
+			status = domain.DeliverySucceeded
+		case delivery.AttemptCount+1 >= d.opts.MaxAttempts:
+			status = domain.DeliveryDead
+		default:
+			next = started.Add(d.backoff(delivery.AttemptCount + 1))
+		}
+	}
+
+	if err := d.deliveries.RecordAttempt(ctx, delivery.ID, attempt, status, next); err != nil {
+		// The lease expires and the delivery is retried, so the endpoint may
+		// see it twice; receivers deduplicate on the webhook ID
+		d.logger.Error("Failed to record delivery attempt", "deliveryID", delivery.ID.Hex(), "error", err)
+		return
+	}
+
+	switch status {
+	case domain.DeliverySucceeded:
+		d.logger.Info("Webhook delivered", "deliveryID", delivery.ID.Hex(), "status", attempt.StatusCode)
+	case domain.DeliveryDead:
+		d.logger.Warn("Webhook dead-lettered", "deliveryID", delivery.ID.Hex(), "attempts", delivery.AttemptCount+1, "error", attempt.Error)
+	default:
+		d.logger.Info("Webhook attempt failed", "deliveryID", delivery.ID.Hex(), "nextAttemptAt", next, "error", attempt.Error)
+	}
+}
+
+// send posts the payload and returns the response status. Any non-2xx
+// response is an error.
+func (d *Dispatcher) send(ctx context.Context, sub *domain.Subscription, delivery *domain.Delivery, at time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "online-shop-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.ID.Hex())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, at, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the given retry, doubling from
+// BaseBackoff up to MaxBackoff and spreading retries with jitter
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxBackoff {
+		delay = d.opts.MaxBackoff
+	}
+	return delay + d.jitter(delay)
+}
+
+// Sign returns the signature header value for a payload sent at the given
+// time: "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<payload>">"
+func Sign(secret string, at time.Time, payload []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, payload)
+}
+
+// Verify checks a signature header against the payload and rejects
+// signatures older than tolerance. It is what receivers are expected to do.
+func Verify(secret, header string, payload []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature header")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, payload))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func signature(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
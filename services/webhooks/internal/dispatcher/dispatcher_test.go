@@ -0,0 +1,223 @@
+package dispatcher
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bekbull/online-shop/services/webhooks/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memorySubscriptions serves subscriptions from a map
+type memorySubscriptions struct {
+	domain.SubscriptionRepository
+	subs map[string]*domain.Subscription
+}
+
+func (m *memorySubscriptions) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return sub, nil
+}
+
+type recordedAttempt struct {
+	attempt domain.Attempt
+	status  string
+	next    time.Time
+}
+
+// memoryDeliveries hands out queued deliveries and records attempts
+type memoryDeliveries struct {
+	domain.DeliveryRepository
+	queue    []*domain.Delivery
+	attempts []recordedAttempt
+}
+
+func (m *memoryDeliveries) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.Delivery, error) {
+	claimed := m.queue
+	m.queue = nil
+	return claimed, nil
+}
+
+func (m *memoryDeliveries) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt domain.Attempt, status string, next time.Time) error {
+	m.attempts = append(m.attempts, recordedAttempt{attempt: attempt, status: status, next: next})
+	return nil
+}
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestDispatcher(sub *domain.Subscription, delivery *domain.Delivery) (*Dispatcher, *memoryDeliveries) {
+	subs := &memorySubscriptions{subs: map[string]*domain.Subscription{}}
+	if sub != nil {
+		subs.subs[sub.ID.Hex()] = sub
+	}
+	deliveries := &memoryDeliveries{queue: []*domain.Delivery{delivery}}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	d := New(subs, deliveries, Options{
+		BatchSize:   10,
+		Lease:       time.Minute,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		BaseBackoff: 10 * time.Second,
+		MaxBackoff:  time.Minute,
+		// httptest servers listen on loopback
+		AllowPrivateTargets: true,
+	}, logger)
+	d.now = func() time.Time { return testNow }
+	d.jitter = func(time.Duration) time.Duration { return 0 }
+	return d, deliveries
+}
+
+func newTestDelivery(sub *domain.Subscription, attempts int) *domain.Delivery {
+	return &domain.Delivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: sub.ID,
+		EventID:        "evt-1",
+		EventType:      "product.updated",
+		Payload:        []byte(`{"id":"evt-1","type":"product.updated"}`),
+		Status:         domain.DeliveryPending,
+		AttemptCount:   attempts,
+	}
+}
+
+func TestDispatchSignsAndDelivers(t *testing.T) {
+	var received http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sub := &domain.Subscription{ID: primitive.NewObjectID(), URL: server.URL, Secret: "whsec_test", Active: true}
+	delivery := newTestDelivery(sub, 0)
+	d, deliveries := newTestDispatcher(sub, delivery)
+
+	n, err := d.DispatchDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, delivery.Payload, body)
+	assert.Equal(t, delivery.ID.Hex(), received.Get(HeaderID))
+	assert.Equal(t, "product.updated", received.Get(HeaderEvent))
+	assert.NoError(t, Verify("whsec_test", received.Get(HeaderSignature), body, testNow, 5*time.Minute))
+	assert.Error(t, Verify("other", received.Get(HeaderSignature), body, testNow, 5*time.Minute))
+
+	if assert.Len(t, deliveries.attempts, 1) {
+		assert.Equal(t, domain.DeliverySucceeded, deliveries.attempts[0].status)
+		assert.Equal(t, http.StatusNoContent, deliveries.attempts[0].attempt.StatusCode)
+	}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sub := &domain.Subscription{ID: primitive.NewObjectID(), URL: server.URL, Secret: "s", Active: true}
+
+	t.Run("scheduled for retry", func(t *testing.T) {
+		d, deliveries := newTestDispatcher(sub, newTestDelivery(sub, 1))
+
+		_, err := d.DispatchDue(context.Background())
+
+		assert.NoError(t, err)
+		got := deliveries.attempts[0]
+		assert.Equal(t, domain.DeliveryPending, got.status)
+		assert.Equal(t, http.StatusServiceUnavailable, got.attempt.StatusCode)
+		// Second attempt failed: base backoff doubled once
+		assert.Equal(t, testNow.Add(20*time.Second), got.next)
+	})
+
+	t.Run("dead-lettered after max attempts", func(t *testing.T) {
+		d, deliveries := newTestDispatcher(sub, newTestDelivery(sub, 2))
+
+		_, err := d.DispatchDue(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryDead, deliveries.attempts[0].status)
+	})
+}
+
+func TestDispatchRefusesPrivateTargets(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sub := &domain.Subscription{ID: primitive.NewObjectID(), URL: server.URL, Secret: "s", Active: true}
+	d, deliveries := newTestDispatcher(sub, newTestDelivery(sub, 0))
+	d.client.Transport = newTransport(false)
+
+	_, err := d.DispatchDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, called)
+	if assert.Len(t, deliveries.attempts, 1) {
+		assert.Equal(t, domain.DeliveryPending, deliveries.attempts[0].status)
+		assert.Contains(t, deliveries.attempts[0].attempt.Error, ErrPrivateTarget.Error())
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:4700::1111":      true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+	} {
+		assert.Equal(t, want, domain.IsPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestDispatchWithoutSubscription(t *testing.T) {
+	sub := &domain.Subscription{ID: primitive.NewObjectID()}
+	d, deliveries := newTestDispatcher(nil, newTestDelivery(sub, 0))
+
+	_, err := d.DispatchDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DeliveryDead, deliveries.attempts[0].status)
+	assert.Equal(t, "subscription deleted", deliveries.attempts[0].attempt.Error)
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d, _ := newTestDispatcher(nil, nil)
+
+	assert.Equal(t, 10*time.Second, d.backoff(1))
+	assert.Equal(t, 40*time.Second, d.backoff(3))
+	assert.Equal(t, time.Minute, d.backoff(10))
+}
+
+func TestVerifyRejectsStaleSignature(t *testing.T) {
+	payload := []byte(`{}`)
+	header := Sign("s", testNow.Add(-10*time.Minute), payload)
+
+	assert.Error(t, Verify("s", header, payload, testNow, 5*time.Minute))
+	assert.Error(t, Verify("s", "garbage", payload, testNow, 5*time.Minute))
+}
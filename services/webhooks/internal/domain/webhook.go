@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain errors returned by the repository and service
+var (
+	ErrNotFound      = apperrors.New(apperrors.NotFound, "not found")
+	ErrAlreadyExists = apperrors.New(apperrors.AlreadyExists, "already exists")
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// Subscription registers a partner endpoint for a set of event types
+type Subscription struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Owner      string             `bson:"owner" json:"owner"`
+	URL        string             `bson:"url" json:"url"`
+	EventTypes []string           `bson:"event_types" json:"event_types"`
+	Secret     string             `bson:"secret" json:"-"`
+	Active     bool               `bson:"active" json:"active"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Matches reports whether the subscription wants events of the given type.
+// Patterns are exact types, a prefix wildcard such as "product.*", or "*".
+func (s *Subscription) Matches(eventType string) bool {
+	for _, pattern := range s.EventTypes {
+		switch {
+		case pattern == "*", pattern == eventType:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// nonPublicPrefixes are ranges that are not caught by the netip predicates
+// but are not reachable on the public internet either
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+}
+
+// IsPublicAddr reports whether webhooks may be sent to addr. Loopback,
+// private, link-local and other non-routable addresses are refused so a
+// subscription cannot be used to reach internal services.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Attempt records a single delivery attempt
+type Attempt struct {
+	At         time.Time     `bson:"at" json:"at"`
+	StatusCode int           `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string        `bson:"error,omitempty" json:"error,omitempty"`
+	Duration   time.Duration `bson:"duration" json:"duration"`
+}
+
+// Delivery is one event to be delivered to one subscription
+type Delivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	EventID        string             `bson:"event_id" json:"event_id"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	Payload        []byte             `bson:"payload" json:"-"`
+	Status         string             `bson:"status" json:"status"`
+	AttemptCount   int                `bson:"attempt_count" json:"attempt_count"`
+	Attempts       []Attempt          `bson:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LeaseUntil     time.Time          `bson:"lease_until,omitempty" json:"-"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DeliveryFilter defines the parameters for listing deliveries
+type DeliveryFilter struct {
+	SubscriptionID string
+	EventID        string
+	Status         string
+	Limit          int
+}
+
+// SubscriptionRepository defines the interface for subscription data operations
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub *Subscription) error
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+	List(ctx context.Context, owner string) ([]*Subscription, error)
+	ListActive(ctx context.Context) ([]*Subscription, error)
+	Update(ctx context.Context, sub *Subscription) error
+	Delete(ctx context.Context, id string) error
+}
+
+// DeliveryRepository defines the interface for delivery data operations
+type DeliveryRepository interface {
+	// Enqueue inserts a delivery; ErrAlreadyExists is returned if the
+	// event was already enqueued for the subscription
+	Enqueue(ctx context.Context, delivery *Delivery) error
+	// ClaimDue leases up to limit pending deliveries that are due
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error)
+	// RecordAttempt stores an attempt and the delivery's new status and
+	// schedule, releasing the lease
+	RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt Attempt, status string, nextAttemptAt time.Time) error
+	GetByID(ctx context.Context, id string) (*Delivery, error)
+	List(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error)
+	// Requeue makes a delivery pending again and due immediately
+	Requeue(ctx context.Context, id string, now time.Time) (*Delivery, error)
+}
@@ -0,0 +1,311 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbull/online-shop/services/webhooks/config"
+	"github.com/bekbull/online-shop/services/webhooks/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxAttemptLog is the number of attempts kept on each delivery
+const maxAttemptLog = 20
+
+// SubscriptionRepository implements domain.SubscriptionRepository with MongoDB
+type SubscriptionRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewSubscriptionRepository creates a new SubscriptionRepository
+func NewSubscriptionRepository(client *mongo.Client, cfg *config.MongoDBConfig) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		collection: client.Database(cfg.Database).Collection("subscriptions"),
+		config:     cfg,
+	}
+}
+
+// Create inserts a new subscription
+func (r *SubscriptionRepository) Create(ctx context.Context, sub *domain.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, sub)
+	if err != nil {
+		return err
+	}
+	sub.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID returns a subscription by ID
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var sub domain.Subscription
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// List returns the subscriptions of an owner, or all when owner is empty
+func (r *SubscriptionRepository) List(ctx context.Context, owner string) ([]*domain.Subscription, error) {
+	filter := bson.M{}
+	if owner != "" {
+		filter["owner"] = owner
+	}
+	return r.find(ctx, filter)
+}
+
+// ListActive returns all active subscriptions
+func (r *SubscriptionRepository) ListActive(ctx context.Context) ([]*domain.Subscription, error) {
+	return r.find(ctx, bson.M{"active": true})
+}
+
+// Update replaces a subscription
+func (r *SubscriptionRepository) Update(ctx context.Context, sub *domain.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": sub.ID}, sub)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a subscription
+func (r *SubscriptionRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) find(ctx context.Context, filter bson.M) ([]*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subs []*domain.Subscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// DeliveryRepository implements domain.DeliveryRepository with MongoDB
+type DeliveryRepository struct {
+	collection *mongo.Collection
+	config     *config.MongoDBConfig
+}
+
+// NewDeliveryRepository creates a new DeliveryRepository
+func NewDeliveryRepository(client *mongo.Client, cfg *config.MongoDBConfig) *DeliveryRepository {
+	return &DeliveryRepository{
+		collection: client.Database(cfg.Database).Collection("deliveries"),
+		config:     cfg,
+	}
+}
+
+// EnsureIndexes creates the indexes the repository relies on. The unique
+// index makes enqueueing idempotent when events are redelivered by the bus.
+func (r *DeliveryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// Enqueue inserts a new delivery
+func (r *DeliveryRepository) Enqueue(ctx context.Context, delivery *domain.Delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, delivery)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	delivery.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ClaimDue leases due pending deliveries one at a time so that concurrent
+// dispatchers never claim the same delivery
+func (r *DeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.Delivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          domain.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"lease_until": bson.M{"$exists": false}},
+			bson.M{"lease_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"lease_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var claimed []*domain.Delivery
+	for len(claimed) < limit {
+		var delivery domain.Delivery
+		err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return claimed, err
+		}
+		claimed = append(claimed, &delivery)
+	}
+	return claimed, nil
+}
+
+// RecordAttempt stores the outcome of an attempt and releases the lease
+func (r *DeliveryRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt domain.Attempt, status string, nextAttemptAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{
+		"$push": bson.M{"attempts": bson.M{
+			"$each":  bson.A{attempt},
+			"$slice": -maxAttemptLog,
+		}},
+		"$inc":   bson.M{"attempt_count": 1},
+		"$set":   bson.M{"status": status, "next_attempt_at": nextAttemptAt, "updated_at": attempt.At},
+		"$unset": bson.M{"lease_until": ""},
+	})
+	return err
+}
+
+// GetByID returns a delivery by ID
+func (r *DeliveryRepository) GetByID(ctx context.Context, id string) (*domain.Delivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	var delivery domain.Delivery
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// List returns deliveries matching filter, newest first
+func (r *DeliveryRepository) List(ctx context.Context, filter domain.DeliveryFilter) ([]*domain.Delivery, error) {
+	query := bson.M{}
+	if filter.SubscriptionID != "" {
+		objID, err := primitive.ObjectIDFromHex(filter.SubscriptionID)
+		if err != nil {
+			return nil, nil
+		}
+		query["subscription_id"] = objID
+	}
+	if filter.EventID != "" {
+		query["event_id"] = filter.EventID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.ReadTimeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(filter.Limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*domain.Delivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Requeue makes a delivery pending and due now with a fresh retry budget.
+// The attempt log is kept.
+func (r *DeliveryRepository) Requeue(ctx context.Context, id string, now time.Time) (*domain.Delivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.WriteTimeout)
+	defer cancel()
+
+	var delivery domain.Delivery
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID},
+		bson.M{
+			"$set":   bson.M{"status": domain.DeliveryPending, "attempt_count": 0, "next_attempt_at": now, "updated_at": now},
+			"$unset": bson.M{"lease_until": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bekbull/online-shop/pkg/apperrors"
+	"github.com/bekbull/online-shop/pkg/eventbus"
+	"github.com/bekbull/online-shop/services/webhooks/internal/domain"
+)
+
+// secretPrefix marks signing secrets so they are recognisable in partner config
+const secretPrefix = "whsec_"
+
+// WebhookService manages subscriptions and turns events into deliveries
+type WebhookService struct {
+	subscriptions domain.SubscriptionRepository
+	deliveries    domain.DeliveryRepository
+	eventTypes    []string
+	allowInsecure bool
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+// New creates a new WebhookService. eventTypes are the event types that
+// are consumed from the bus; subscriptions may only ask for those.
+func New(subscriptions domain.SubscriptionRepository, deliveries domain.DeliveryRepository, eventTypes []string, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		eventTypes:    eventTypes,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// SetAllowInsecureTargets lets subscriptions use plain http URLs and
+// loopback or private hosts. Only meant for local development.
+func (s *WebhookService) SetAllowInsecureTargets(allow bool) {
+	s.allowInsecure = allow
+}
+
+// CreateSubscription registers an endpoint and generates its signing secret.
+// The secret is only returned here and when it is rotated.
+func (s *WebhookService) CreateSubscription(ctx context.Context, owner, endpoint string, eventTypes []string) (*domain.Subscription, error) {
+	s.logger.Info("Creating subscription", "owner", owner, "url", endpoint, "eventTypes", eventTypes)
+
+	if owner == "" {
+		return nil, apperrors.New(apperrors.Invalid, "owner is required")
+	}
+	if err := s.validateURL(endpoint); err != nil {
+		return nil, err
+	}
+	if err := s.validateEventTypes(eventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	sub := &domain.Subscription{
+		Owner:      owner,
+		URL:        endpoint,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.subscriptions.Create(ctx, sub); err != nil {
+		s.logger.Error("Failed to create subscription", "error", err)
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	s.logger.Info("Subscription created", "id", sub.ID.Hex())
+	return sub, nil
+}
+
+// GetSubscription returns a subscription by ID
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	sub, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptions returns the subscriptions of an owner, or all of them
+func (s *WebhookService) ListSubscriptions(ctx context.Context, owner string) ([]*domain.Subscription, error) {
+	subs, err := s.subscriptions.List(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return subs, nil
+}
+
+// SetSubscriptionActive pauses or resumes a subscription. Deliveries for a
+// paused subscription are not created; queued ones are dead-lettered.
+func (s *WebhookService) SetSubscriptionActive(ctx context.Context, id string, active bool) (*domain.Subscription, error) {
+	s.logger.Info("Updating subscription", "id", id, "active", active)
+
+	sub, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	sub.Active = active
+	sub.UpdatedAt = s.now().UTC()
+	if err := s.subscriptions.Update(ctx, sub); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return sub, nil
+}
+
+// RotateSecret replaces the signing secret of a subscription
+func (s *WebhookService) RotateSecret(ctx context.Context, id string) (*domain.Subscription, error) {
+	s.logger.Info("Rotating subscription secret", "id", id)
+
+	sub, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if sub.Secret, err = newSecret(); err != nil {
+		return nil, err
+	}
+	sub.UpdatedAt = s.now().UTC()
+	if err := s.subscriptions.Update(ctx, sub); err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes a subscription
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	s.logger.Info("Deleting subscription", "id", id)
+
+	if err := s.subscriptions.Delete(ctx, id); err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+// HandleEvent enqueues a delivery of the event for every matching active
+// subscription. It is safe to call again for the same event: deliveries
+// that already exist are left alone.
+func (s *WebhookService) HandleEvent(ctx context.Context, event *eventbus.Event) error {
+	subs, err := s.subscriptions.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	var payload []byte
+	for _, sub := range subs {
+		if !sub.Matches(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to encode event: %w", err)
+			}
+		}
+
+		now := s.now().UTC()
+		delivery := &domain.Delivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		err := s.deliveries.Enqueue(ctx, delivery)
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to enqueue delivery for subscription %s: %w", sub.ID.Hex(), err)
+		}
+		s.logger.Debug("Delivery enqueued", "deliveryID", delivery.ID.Hex(), "subscriptionID", sub.ID.Hex(), "eventID", event.ID)
+	}
+	return nil
+}
+
+// ListDeliveries returns deliveries matching filter
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]*domain.Delivery, error) {
+	if filter.Status != "" && filter.Status != domain.DeliveryPending &&
+		filter.Status != domain.DeliverySucceeded && filter.Status != domain.DeliveryDead {
+		return nil, apperrors.Newf(apperrors.Invalid, "invalid status %q", filter.Status)
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	deliveries, err := s.deliveries.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery returns a delivery by ID
+func (s *WebhookService) GetDelivery(ctx context.Context, id string) (*domain.Delivery, error) {
+	delivery, err := s.deliveries.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return delivery, nil
+}
+
+// Redeliver queues a delivery for another round of attempts, typically
+// after a dead-lettered endpoint has been fixed
+func (s *WebhookService) Redeliver(ctx context.Context, id string) (*domain.Delivery, error) {
+	s.logger.Info("Requeueing delivery", "id", id)
+
+	delivery, err := s.deliveries.Requeue(ctx, id, s.now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	return delivery, nil
+}
+
+func (s *WebhookService) validateEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return apperrors.New(apperrors.Invalid, "at least one event type is required")
+	}
+	for _, pattern := range eventTypes {
+		probe := &domain.Subscription{EventTypes: []string{pattern}}
+		matched := false
+		for _, eventType := range s.eventTypes {
+			if probe.Matches(eventType) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return apperrors.Newf(apperrors.Invalid, "unsupported event type %q", pattern)
+		}
+	}
+	return nil
+}
+
+// validateURL checks that endpoint is an absolute https URL without
+// credentials. Hosts that are obviously internal are refused here; the
+// dispatcher checks the resolved address again on every delivery.
+func (s *WebhookService) validateURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return apperrors.Newf(apperrors.Invalid, "invalid URL %q, expected an absolute https URL", endpoint)
+	}
+	if u.User != nil {
+		return apperrors.New(apperrors.Invalid, "URL must not contain credentials")
+	}
+	if s.allowInsecure {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return apperrors.Newf(apperrors.Invalid, "invalid URL %q, webhooks are only sent over https", endpoint)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return apperrors.Newf(apperrors.Invalid, "invalid URL %q, host is not public", endpoint)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !domain.IsPublicAddr(addr) {
+		return apperrors.Newf(apperrors.Invalid, "invalid URL %q, host is not public", endpoint)
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}